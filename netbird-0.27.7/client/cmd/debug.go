@@ -3,13 +3,28 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 
+	"github.com/netbirdio/netbird/client/anonymize"
 	"github.com/netbirdio/netbird/client/proto"
+	"github.com/netbirdio/netbird/iface"
+	"github.com/netbirdio/netbird/iface/wgcrypto"
+)
+
+var (
+	cryptoBenchDuration   time.Duration
+	cryptoBenchPacketSize int
+	pcapDuration          time.Duration
+	pcapSnapLen           uint32
+	dropsLimit            uint32
+	debugBundleUpload     bool
 )
 
 var debugCmd = &cobra.Command{
@@ -20,10 +35,43 @@ var debugCmd = &cobra.Command{
 
 var debugBundleCmd = &cobra.Command{
 	Use:     "bundle",
-	Example: "  netbird debug bundle",
+	Example: "  netbird debug bundle\n  netbird debug bundle --anonymize --upload",
 	Short:   "Create a debug bundle",
-	Long:    "Generates a compressed archive of the daemon's logs and status for debugging purposes.",
-	RunE:    debugBundle,
+	Long: "Generates a compressed archive of the daemon's logs, status, WireGuard interface, routes, firewall rules and recent management connection errors for debugging purposes. " +
+		"It holds no private keys. With --upload the archive is also sent to the management server for support.",
+	RunE: debugBundle,
+}
+
+var debugRoutesCmd = &cobra.Command{
+	Use:     "routes",
+	Example: "  netbird debug routes",
+	Short:   "Show the routing state",
+	Long:    "Shows the routing table, the resolved next hops, the routes added through the physical interface and the routes skipped or conflicting with local routes.",
+	RunE:    debugRoutes,
+}
+
+var debugCryptoBenchCmd = &cobra.Command{
+	Use:     "crypto-bench",
+	Example: "  netbird debug crypto-bench --duration 5s",
+	Short:   "Benchmark the userspace Wireguard encryption",
+	Long:    "Shows the CPU crypto and vector extensions and measures the ChaCha20-Poly1305 throughput of the userspace Wireguard data path on this device. It runs locally and doesn't need the daemon.",
+	RunE:    debugCryptoBench,
+}
+
+var debugPcapCmd = &cobra.Command{
+	Use:     "pcap",
+	Example: "  netbird debug pcap --duration 30s",
+	Short:   "Capture the tunneled packets",
+	Long:    "Captures the packets of the Wireguard interface to a pcapng file for the given duration. Outbound packets are captured before the encryption and inbound packets after the decryption, the packets dropped by the packet filter are marked. Requires the userspace Wireguard implementation.",
+	RunE:    debugPcap,
+}
+
+var debugDropsCmd = &cobra.Command{
+	Use:     "drops",
+	Example: "  netbird debug drops\n  netbird debug drops --limit 20",
+	Short:   "Show the dropped connections",
+	Long:    "Shows the latest inbound connections on the Netbird interface dropped by the firewall with the policies of the drop rule they matched.\nThe log has to be enabled with netbird up --log-dropped-connections and requires the userspace firewall.",
+	RunE:    debugDrops,
 }
 
 var logCmd = &cobra.Command{
@@ -68,13 +116,139 @@ func debugBundle(cmd *cobra.Command, _ []string) error {
 	resp, err := client.DebugBundle(cmd.Context(), &proto.DebugBundleRequest{
 		Anonymize: anonymizeFlag,
 		Status:    getStatusOutput(cmd),
+		Routes:    getRoutesOutput(cmd),
+		Upload:    debugBundleUpload,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to bundle debug: %v", status.Convert(err).Message())
 	}
 
 	cmd.Println(resp.GetPath())
+	if resp.GetUploadedName() != "" {
+		cmd.Printf("Uploaded to the management server as %s\n", resp.GetUploadedName())
+	}
+	if resp.GetUploadError() != "" {
+		return fmt.Errorf("failed to upload the debug bundle, send the file instead: %s", resp.GetUploadError())
+	}
+
+	return nil
+}
+
+func debugRoutes(cmd *cobra.Command, _ []string) error {
+	conn, err := getClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client := proto.NewDaemonServiceClient(conn)
+	resp, err := client.DebugRoutes(cmd.Context(), &proto.DebugRoutesRequest{})
+	if err != nil {
+		return fmt.Errorf("failed to get routes: %v", status.Convert(err).Message())
+	}
+
+	cmd.Print(parseDebugRoutes(resp))
+
+	return nil
+}
+
+func init() {
+	debugBundleCmd.PersistentFlags().BoolVar(&debugBundleUpload, "upload", false, "Upload the bundle to the management server for support")
+	debugCryptoBenchCmd.PersistentFlags().DurationVar(&cryptoBenchDuration, "duration", 2*time.Second, "How long each cipher is measured")
+	debugCryptoBenchCmd.PersistentFlags().IntVar(&cryptoBenchPacketSize, "packet-size", iface.DefaultMTU, "Payload size of the encrypted packets in bytes")
+	debugPcapCmd.PersistentFlags().DurationVar(&pcapDuration, "duration", 30*time.Second, "How long the packets are captured, at most 10m")
+	debugPcapCmd.PersistentFlags().Uint32Var(&pcapSnapLen, "snaplen", 0, "Number of bytes captured of each packet, 0 captures the whole packet")
+	debugDropsCmd.PersistentFlags().Uint32Var(&dropsLimit, "limit", 50, "Number of the latest connections to show, 0 shows all the logged connections")
+}
+
+func debugPcap(cmd *cobra.Command, _ []string) error {
+	conn, err := getClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	cmd.Printf("Capturing packets for %s...\n", pcapDuration)
+
+	client := proto.NewDaemonServiceClient(conn)
+	resp, err := client.DebugPcap(cmd.Context(), &proto.DebugPcapRequest{
+		Duration: durationpb.New(pcapDuration),
+		SnapLen:  pcapSnapLen,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to capture packets: %v", status.Convert(err).Message())
+	}
+
+	cmd.Printf("Captured %d packets to: %s\n", resp.GetPackets(), resp.GetPath())
+	return nil
+}
+
+func debugDrops(cmd *cobra.Command, _ []string) error {
+	conn, err := getClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client := proto.NewDaemonServiceClient(conn)
+	resp, err := client.DebugDroppedConnections(cmd.Context(), &proto.DebugDroppedConnectionsRequest{Limit: dropsLimit})
+	if err != nil {
+		return fmt.Errorf("failed to get the dropped connections: %v", status.Convert(err).Message())
+	}
+
+	if len(resp.GetConnections()) == 0 {
+		cmd.Println("No dropped connections logged.")
+		return nil
+	}
+
+	cmd.Printf("%-19s  %-19s  %-5s  %-21s  %-6s  %8s  %s\n", "FIRST SEEN", "LAST SEEN", "PROTO", "SOURCE", "PORT", "PACKETS", "POLICIES")
+	for _, c := range resp.GetConnections() {
+		source := c.GetSourceIP()
+		if c.GetSourcePort() != 0 {
+			source = net.JoinHostPort(source, strconv.Itoa(int(c.GetSourcePort())))
+		}
+		port := "-"
+		if c.GetDestinationPort() != 0 {
+			port = strconv.Itoa(int(c.GetDestinationPort()))
+		}
+		policies := "default deny"
+		if len(c.GetPolicyIDs()) > 0 {
+			policies = strings.Join(c.GetPolicyIDs(), ",")
+		}
+		cmd.Printf("%-19s  %-19s  %-5s  %-21s  %-6s  %8d  %s\n",
+			c.GetFirstSeen().AsTime().Local().Format("2006-01-02 15:04:05"),
+			c.GetLastSeen().AsTime().Local().Format("2006-01-02 15:04:05"),
+			c.GetProtocol(),
+			source,
+			port,
+			c.GetPackets(),
+			policies,
+		)
+	}
+
+	return nil
+}
+
+func debugCryptoBench(cmd *cobra.Command, _ []string) error {
+	if cryptoBenchDuration <= 0 {
+		return fmt.Errorf("invalid duration %s", cryptoBenchDuration)
+	}
 
+	cmd.Printf("CPU: %s\n", wgcrypto.DetectFeatures())
+	cmd.Printf("ChaCha20-Poly1305 implementation: %s\n", wgcrypto.Implementation())
+	if !wgcrypto.Accelerated() {
+		cmd.Println("No vector acceleration available, the kernel Wireguard module is recommended for better throughput")
+	}
+	cmd.Printf("Sealing %d byte packets for %s per cipher...\n", cryptoBenchPacketSize, cryptoBenchDuration)
+
+	results, err := wgcrypto.Benchmark(cryptoBenchDuration, cryptoBenchPacketSize)
+	if err != nil {
+		return fmt.Errorf("failed to run the benchmark: %v", err)
+	}
+
+	for _, r := range results {
+		cmd.Printf("  %-32s %10.1f Mbit/s %12.0f packets/s\n", r.Cipher, r.BytesPerSecond()*8/1e6, r.PacketsPerSecond())
+	}
 	return nil
 }
 
@@ -169,6 +343,7 @@ func runForDuration(cmd *cobra.Command, args []string) error {
 
 	headerPreDown := fmt.Sprintf("----- Netbird pre-down - Timestamp: %s - Duration: %s", time.Now().Format(time.RFC3339), duration)
 	statusOutput = fmt.Sprintf("%s\n%s\n%s", statusOutput, headerPreDown, getStatusOutput(cmd))
+	routesOutput := getRoutesOutput(cmd)
 
 	if _, err := client.Down(cmd.Context(), &proto.DownRequest{}); err != nil {
 		return fmt.Errorf("failed to down: %v", status.Convert(err).Message())
@@ -184,6 +359,7 @@ func runForDuration(cmd *cobra.Command, args []string) error {
 	resp, err := client.DebugBundle(cmd.Context(), &proto.DebugBundleRequest{
 		Anonymize: anonymizeFlag,
 		Status:    statusOutput,
+		Routes:    routesOutput,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to bundle debug: %v", status.Convert(err).Message())
@@ -205,6 +381,91 @@ func getStatusOutput(cmd *cobra.Command) string {
 	return statusOutputString
 }
 
+func getRoutesOutput(cmd *cobra.Command) string {
+	conn, err := getClient(cmd.Context())
+	if err != nil {
+		cmd.PrintErrf("Failed to get routes: %v\n", err)
+		return ""
+	}
+	defer conn.Close()
+
+	resp, err := proto.NewDaemonServiceClient(conn).DebugRoutes(cmd.Context(), &proto.DebugRoutesRequest{})
+	if err != nil {
+		cmd.PrintErrf("Failed to get routes: %v\n", status.Convert(err).Message())
+		return ""
+	}
+	return parseDebugRoutes(resp)
+}
+
+func parseDebugRoutes(resp *proto.DebugRoutesResponse) string {
+	var b strings.Builder
+
+	b.WriteString("Client networks:\n")
+	if len(resp.GetClientNetworks()) == 0 {
+		b.WriteString("  -\n")
+	}
+	for _, network := range resp.GetClientNetworks() {
+		routingPeers := "-"
+		if len(network.GetRoutingPeers()) > 0 {
+			routingPeers = strings.Join(network.GetRoutingPeers(), ", ")
+		}
+		fmt.Fprintf(&b, "  %s: %s via %s\n", network.GetID(), network.GetNetwork(), routingPeers)
+	}
+
+	b.WriteString("\nSkipped routes:\n")
+	if len(resp.GetSkippedRoutes()) == 0 {
+		b.WriteString("  -\n")
+	}
+	for _, skipped := range resp.GetSkippedRoutes() {
+		fmt.Fprintf(&b, "  %s: %s (%s)\n", skipped.GetID(), skipped.GetNetwork(), skipped.GetReason())
+	}
+
+	b.WriteString("\nConflicting routes:\n")
+	if len(resp.GetRouteConflicts()) == 0 {
+		b.WriteString("  -\n")
+	}
+	for _, conflict := range resp.GetRouteConflicts() {
+		fmt.Fprintf(&b, "  %s: %s conflicts with %s (%s)\n", conflict.GetID(), conflict.GetNetwork(), conflict.GetConflictsWith(), conflict.GetReason())
+	}
+
+	b.WriteString("\nNext hops:\n")
+	for _, nextHop := range resp.GetNextHops() {
+		if nextHop.GetError() != "" {
+			fmt.Fprintf(&b, "  %s: %s\n", nextHop.GetDestination(), nextHop.GetError())
+			continue
+		}
+		fmt.Fprintf(&b, "  %s: via %s dev %s\n", nextHop.GetDestination(), nextHop.GetNextHop(), nextHop.GetInterface())
+	}
+
+	b.WriteString("\nExclusion routes:\n")
+	if len(resp.GetExclusionRoutes()) == 0 {
+		b.WriteString("  -\n")
+	}
+	for _, exclusion := range resp.GetExclusionRoutes() {
+		fmt.Fprintf(&b, "  %s via %s dev %s, %d references\n", exclusion.GetPrefix(), exclusion.GetNextHop(), exclusion.GetInterface(), exclusion.GetReferences())
+	}
+
+	fmt.Fprintf(&b, "\nRoute cache disabled: %t\n", resp.GetRouteCacheDisabled())
+
+	b.WriteString("\nRouting table:\n")
+	for _, prefix := range resp.GetRoutingTable() {
+		fmt.Fprintf(&b, "  %s\n", prefix)
+	}
+
+	if len(resp.GetErrors()) > 0 {
+		b.WriteString("\nErrors:\n")
+		for _, err := range resp.GetErrors() {
+			fmt.Fprintf(&b, "  %s\n", err)
+		}
+	}
+
+	output := b.String()
+	if anonymizeFlag {
+		output = anonymize.NewAnonymizer(anonymize.DefaultAddresses()).AnonymizeString(output)
+	}
+	return output
+}
+
 func waitForDurationOrCancel(ctx context.Context, duration time.Duration, cmd *cobra.Command) error {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
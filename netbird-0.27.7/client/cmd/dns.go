@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/status"
+
+	"github.com/netbirdio/netbird/client/proto"
+)
+
+var dnsLogLimit uint32
+
+var dnsCmd = &cobra.Command{
+	Use:   "dns",
+	Short: "Inspect the DNS resolver",
+	Long:  `Commands to inspect the DNS resolver of the Netbird daemon.`,
+}
+
+var dnsLogCmd = &cobra.Command{
+	Use:     "log",
+	Short:   "Show the DNS query log",
+	Long:    "Show the latest DNS queries handled by the resolver with how they were answered: local, forwarded or blocked.\nThe query log has to be enabled with netbird up --dns-query-log.",
+	Example: "  netbird dns log\n  netbird dns log --limit 20",
+	RunE:    dnsLog,
+}
+
+func init() {
+	dnsLogCmd.Flags().Uint32Var(&dnsLogLimit, "limit", 50, "Number of the latest queries to show, 0 shows all the recorded queries")
+}
+
+func dnsLog(cmd *cobra.Command, _ []string) error {
+	conn, err := getClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client := proto.NewDaemonServiceClient(conn)
+	resp, err := client.GetDNSQueryLog(cmd.Context(), &proto.GetDNSQueryLogRequest{Limit: dnsLogLimit})
+	if err != nil {
+		return fmt.Errorf("failed to get the DNS query log: %v", status.Convert(err).Message())
+	}
+
+	if len(resp.GetQueries()) == 0 {
+		cmd.Println("No DNS queries recorded.")
+	} else {
+		cmd.Printf("%-23s  %-9s  %-6s  %-8s  %9s  %s\n", "TIME", "DECISION", "TYPE", "RCODE", "LATENCY", "DOMAIN")
+		for _, query := range resp.GetQueries() {
+			rcode := query.GetRcode()
+			if rcode == "" {
+				rcode = "-"
+			}
+			cmd.Printf("%-23s  %-9s  %-6s  %-8s  %9s  %s\n",
+				query.GetTime().AsTime().Local().Format("2006-01-02 15:04:05.000"),
+				query.GetDecision(),
+				query.GetType(),
+				rcode,
+				query.GetLatency().AsDuration().Round(10*time.Microsecond),
+				query.GetDomain(),
+			)
+		}
+	}
+
+	cmd.Printf("\nQueries: %d local, %d forwarded, %d blocked, %d failed, average latency %s\n",
+		resp.GetLocalQueries(),
+		resp.GetForwardedQueries(),
+		resp.GetBlockedQueries(),
+		resp.GetFailedQueries(),
+		resp.GetAverageLatency().AsDuration().Round(10*time.Microsecond),
+	)
+
+	return nil
+}
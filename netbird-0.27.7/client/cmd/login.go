@@ -50,6 +50,8 @@ var loginCmd = &cobra.Command{
 				ManagementURL: managementURL,
 				AdminURL:      adminURL,
 				ConfigPath:    configPath,
+				UCIConfigPath: uciConfigPath,
+				Profile:       profile,
 			}
 			if rootCmd.PersistentFlags().Changed(preSharedKeyFlag) {
 				ic.PreSharedKey = &preSharedKey
@@ -131,6 +133,14 @@ var loginCmd = &cobra.Command{
 }
 
 func foregroundLogin(ctx context.Context, cmd *cobra.Command, config *internal.Config, setupKey string) error {
+	if setupKey == "" {
+		key, err := internal.ReadUCISetupKey(uciConfigPath, profile)
+		if err != nil {
+			return fmt.Errorf("read UCI setup key: %v", err)
+		}
+		setupKey = key
+	}
+
 	needsLogin := false
 
 	err := WithBackOff(func() error {
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// addStructuredOutputFlags adds the --json and --yaml flags selecting a machine-readable output of the command
+func addStructuredOutputFlags(cmd *cobra.Command, what string) {
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, fmt.Sprintf("display %s in json format", what))
+	cmd.Flags().BoolVar(&yamlFlag, "yaml", false, fmt.Sprintf("display %s in yaml format", what))
+	cmd.MarkFlagsMutuallyExclusive("json", "yaml")
+}
+
+// structuredOutput reports whether the --json or --yaml flag is set
+func structuredOutput() bool {
+	return jsonFlag || yamlFlag
+}
+
+// printStructured prints the output in the format selected with the --json or --yaml flag. The fields of the output
+// are always present, so the scripts parsing it can rely on the schema.
+func printStructured(cmd *cobra.Command, output any) error {
+	var (
+		parsed string
+		err    error
+	)
+	if jsonFlag {
+		parsed, err = parseToJSON(output)
+		parsed += "\n"
+	} else {
+		parsed, err = parseToYAML(output)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprint(cmd.OutOrStdout(), parsed)
+	return err
+}
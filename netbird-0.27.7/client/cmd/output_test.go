@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/client/proto"
+)
+
+func printStructuredTo(t *testing.T, json, yaml bool, output any) string {
+	t.Helper()
+
+	jsonFlag, yamlFlag = json, yaml
+	t.Cleanup(func() {
+		jsonFlag, yamlFlag = false, false
+	})
+
+	var buf bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&buf)
+	require.NoError(t, printStructured(cmd, output))
+	return buf.String()
+}
+
+func TestPrintRoutesStructured(t *testing.T) {
+	routes := mapRoutes([]*proto.Route{
+		{ID: "lan", Network: "192.168.1.0/24", Selected: true},
+		{ID: "corp", Domains: []string{"corp.example.com"}},
+	})
+
+	expectedJSON := `{"routes":[` +
+		`{"id":"lan","network":"192.168.1.0/24","domains":[],"selected":true},` +
+		`{"id":"corp","network":"","domains":["corp.example.com"],"selected":false}` +
+		`]}` + "\n"
+	assert.Equal(t, expectedJSON, printStructuredTo(t, true, false, routes))
+
+	expectedYAML := `routes:
+    - id: lan
+      network: 192.168.1.0/24
+      domains: []
+      selected: true
+    - id: corp
+      network: ""
+      domains:
+        - corp.example.com
+      selected: false
+`
+	assert.Equal(t, expectedYAML, printStructuredTo(t, false, true, routes))
+
+	assert.Equal(t, `{"routes":[]}`+"\n", printStructuredTo(t, true, false, mapRoutes(nil)), "no routes should keep the schema")
+}
+
+func TestPrintPeersStructured(t *testing.T) {
+	assert.Equal(t, `{"total":0,"connected":0,"details":[]}`+"\n", printStructuredTo(t, true, false, mapPeers(nil)),
+		"no peers should keep the schema")
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/netbirdio/netbird/client/internal"
+)
+
+var peersCmd = &cobra.Command{
+	Use:   "peers",
+	Short: "Show the peers",
+	Long:  `Commands to show the peers of this peer.`,
+}
+
+var peersListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the peers",
+	Long:    "List the peers this peer connects to and the state of their connections.",
+	Example: "  netbird peers list\n  netbird peers list --filter-by-status connected --json",
+	RunE:    peersList,
+}
+
+func init() {
+	addStructuredOutputFlags(peersListCmd, "the peers")
+	peersListCmd.Flags().StringSliceVar(&ipsFilter, "filter-by-ips", []string{}, "filters the peers by a list of one or more IPs, e.g., --filter-by-ips 100.64.0.100,100.64.0.200")
+	peersListCmd.Flags().StringSliceVar(&prefixNamesFilter, "filter-by-names", []string{}, "filters the peers by a list of one or more peer FQDN or hostnames, e.g., --filter-by-names peer-a,peer-b.netbird.cloud")
+	peersListCmd.Flags().StringVar(&statusFilter, "filter-by-status", "", "filters the peers by connection status(connected|disconnected), e.g., --filter-by-status connected")
+}
+
+func peersList(cmd *cobra.Command, _ []string) error {
+	cmd.SetOut(cmd.OutOrStdout())
+
+	if err := parseFilters(); err != nil {
+		return err
+	}
+
+	resp, err := getStatus(internal.CtxInitState(cmd.Context()))
+	if err != nil {
+		return err
+	}
+
+	localPeerState := resp.GetFullStatus().GetLocalPeerState()
+	peers := mapPeers(resp.GetFullStatus().GetPeers())
+
+	if structuredOutput() {
+		return printStructured(cmd, peers)
+	}
+
+	if len(peers.Details) == 0 {
+		cmd.Println("No peers.")
+		return nil
+	}
+
+	cmd.Printf("Peers: %d/%d Connected\n", peers.Connected, peers.Total)
+	cmd.Print(parsePeers(peers, localPeerState.GetRosenpassEnabled(), localPeerState.GetRosenpassPermissive()))
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/status"
+
+	"github.com/netbirdio/netbird/client/proto"
+)
+
+var pendingSerial uint64
+
+var pendingCmd = &cobra.Command{
+	Use:   "pending",
+	Short: "List the staged route and DNS changes",
+	Long: "List the route and DNS changes of the network map staged for a local approval, they are applied with netbird pending apply.\n" +
+		"The changes are staged with netbird up --stage-network-changes.",
+	Example: "  netbird pending",
+	RunE:    pendingList,
+}
+
+var pendingApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Apply the staged route and DNS changes",
+	Long: "Apply the staged route and DNS changes.\n" +
+		"With --serial the changes are only applied when no newer ones were staged since they were listed.",
+	Example: "  netbird pending apply\n  netbird pending apply --serial 42",
+	RunE:    pendingApply,
+}
+
+var pendingDiscardCmd = &cobra.Command{
+	Use:   "discard",
+	Short: "Discard the staged route and DNS changes",
+	Long: "Discard the staged route and DNS changes and keep the applied ones.\n" +
+		"The same changes aren't staged again, the next different ones of the network map are.",
+	Example: "  netbird pending discard\n  netbird pending discard --serial 42",
+	RunE:    pendingDiscard,
+}
+
+func init() {
+	pendingApplyCmd.Flags().Uint64Var(&pendingSerial, "serial", 0, "Serial of the listed changes, 0 applies the latest ones")
+	pendingDiscardCmd.Flags().Uint64Var(&pendingSerial, "serial", 0, "Serial of the listed changes, 0 discards the latest ones")
+}
+
+func pendingList(cmd *cobra.Command, _ []string) error {
+	conn, err := getClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client := proto.NewDaemonServiceClient(conn)
+	resp, err := client.ListPendingChanges(cmd.Context(), &proto.ListPendingChangesRequest{})
+	if err != nil {
+		return fmt.Errorf("failed to list the pending changes: %v", status.Convert(err).Message())
+	}
+
+	if !resp.GetPending() {
+		cmd.Println("No pending changes.")
+		return nil
+	}
+
+	cmd.Printf("Changes of network map %d staged at %s:\n", resp.GetSerial(), resp.GetStagedAt().AsTime().Local().Format("2006-01-02 15:04:05"))
+	for _, change := range resp.GetChanges() {
+		cmd.Printf("  %-5s  %-7s  %s\n", change.GetKind(), change.GetAction(), change.GetDescription())
+	}
+
+	if resp.GetAutoApplyAt() != nil {
+		cmd.Printf("\nThey are applied at %s unless discarded with: netbird pending discard --serial %d\n",
+			resp.GetAutoApplyAt().AsTime().Local().Format("2006-01-02 15:04:05"), resp.GetSerial())
+	} else {
+		cmd.Printf("\nApply them with: netbird pending apply --serial %d\n", resp.GetSerial())
+	}
+	return nil
+}
+
+func pendingApply(cmd *cobra.Command, _ []string) error {
+	conn, err := getClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client := proto.NewDaemonServiceClient(conn)
+	if _, err := client.ApplyPendingChanges(cmd.Context(), &proto.ApplyPendingChangesRequest{Serial: pendingSerial}); err != nil {
+		return fmt.Errorf("failed to apply the pending changes: %v", status.Convert(err).Message())
+	}
+
+	cmd.Println("Pending changes applied.")
+	return nil
+}
+
+func pendingDiscard(cmd *cobra.Command, _ []string) error {
+	conn, err := getClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client := proto.NewDaemonServiceClient(conn)
+	if _, err := client.DiscardPendingChanges(cmd.Context(), &proto.DiscardPendingChangesRequest{Serial: pendingSerial}); err != nil {
+		return fmt.Errorf("failed to discard the pending changes: %v", status.Convert(err).Message())
+	}
+
+	cmd.Println("Pending changes discarded.")
+	return nil
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/netbirdio/netbird/client/proto"
+)
+
+var (
+	pingCount   int32
+	pingTimeout time.Duration
+)
+
+var pingCmd = &cobra.Command{
+	Use:   "ping <peer>",
+	Short: "Ping a peer over the WireGuard tunnel",
+	Long: "Probe a peer with ICMP echo requests over the WireGuard tunnel and show whether it is reachable, the round trip times and the path it is reached on.\n" +
+		"The peer is the name, FQDN, Netbird IP or WireGuard public key of a peer of the account. The peers missing from the network map are resolved by the management service, which tells whether a policy allows reaching them.",
+	Example: "  netbird ping office-router\n  netbird ping --count 10 100.64.0.12",
+	Args:    cobra.ExactArgs(1),
+	RunE:    ping,
+}
+
+func init() {
+	pingCmd.Flags().Int32Var(&pingCount, "count", 4, "Number of echo requests to send")
+	pingCmd.Flags().DurationVar(&pingTimeout, "timeout", 2*time.Second, "Time to wait for each reply")
+}
+
+func ping(cmd *cobra.Command, args []string) error {
+	conn, err := getClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client := proto.NewDaemonServiceClient(conn)
+	resp, err := client.Ping(cmd.Context(), &proto.PingRequest{
+		Peer:    args[0],
+		Count:   pingCount,
+		Timeout: durationpb.New(pingTimeout),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to ping %s: %v", args[0], status.Convert(err).Message())
+	}
+
+	name := resp.GetFqdn()
+	if name == "" {
+		name = args[0]
+	}
+	cmd.Printf("PING %s (%s) over %s", name, resp.GetIp(), resp.GetPath())
+	if resp.GetLocalCandidate() != "" || resp.GetRemoteCandidate() != "" {
+		cmd.Printf(" (local %s, remote %s)", resp.GetLocalCandidate(), resp.GetRemoteCandidate())
+	}
+	cmd.Println()
+
+	var total, minRTT, maxRTT time.Duration
+	for i, d := range resp.GetRtts() {
+		rtt := d.AsDuration()
+		cmd.Printf("reply from %s: time=%s\n", resp.GetIp(), rtt.Round(10*time.Microsecond))
+		total += rtt
+		if i == 0 || rtt < minRTT {
+			minRTT = rtt
+		}
+		if rtt > maxRTT {
+			maxRTT = rtt
+		}
+	}
+
+	sent, received := resp.GetSent(), resp.GetReceived()
+	var loss float64
+	if sent > 0 {
+		loss = float64(sent-received) / float64(sent) * 100
+	}
+	cmd.Printf("\n%d sent, %d received, %.0f%% loss\n", sent, received, loss)
+	if received == 0 {
+		return fmt.Errorf("%s is not reachable over the tunnel", name)
+	}
+	avg := total / time.Duration(len(resp.GetRtts()))
+	cmd.Printf("rtt min/avg/max = %s/%s/%s\n", minRTT.Round(10*time.Microsecond), avg.Round(10*time.Microsecond), maxRTT.Round(10*time.Microsecond))
+
+	return nil
+}
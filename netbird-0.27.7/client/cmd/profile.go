@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/netbirdio/netbird/client/internal"
+	"github.com/netbirdio/netbird/client/ubus"
+)
+
+const profileFlag = "profile"
+
+// profile is the client profile the command addresses, empty for the default profile
+var profile string
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&profile, profileFlag, "", "Client profile to run or address, an independent client next to the default one with its own config, daemon socket, interface and port, e.g. to join a second NetBird account. Its settings are read from the UCI section named after it")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		SetFlagsFromEnvVars(rootCmd)
+		return applyProfile(rootCmd.PersistentFlags(), profile)
+	}
+}
+
+// applyProfile sets the flags not set by the user to the defaults of the profile, it does nothing for the default
+// profile
+func applyProfile(flags *pflag.FlagSet, profile string) error {
+	if profile == "" {
+		return nil
+	}
+	if err := internal.ValidateProfileName(profile); err != nil {
+		return err
+	}
+
+	defaults := map[string]string{
+		"config":   filepath.Join(defaultConfigPathDir, profile, "config.json"),
+		"log-file": filepath.Join(defaultLogFileDir, "client-"+profile+".log"),
+		"service":  flags.Lookup("service").DefValue + "-" + profile,
+	}
+	if runtime.GOOS != "windows" {
+		defaults["daemon-addr"] = "unix:///var/run/netbird-" + profile + ".sock"
+	} else if !flags.Changed("daemon-addr") {
+		return fmt.Errorf("profile %s needs its own daemon address, set it with --daemon-addr", profile)
+	}
+
+	for name, value := range defaults {
+		if flags.Changed(name) {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("set the %s of profile %s: %w", name, profile, err)
+		}
+	}
+	return nil
+}
+
+// ubusObjectName returns the ubus object of the profile, netbird.<profile>
+func ubusObjectName() string {
+	if profile == "" {
+		return ubus.ObjectName
+	}
+	return ubus.ObjectName + "." + profile
+}
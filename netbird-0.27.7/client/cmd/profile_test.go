@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyProfile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the profiles need an explicit daemon address on Windows")
+	}
+
+	var config, log, service, daemon string
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringVar(&config, "config", defaultConfigPath, "")
+	flags.StringVar(&log, "log-file", defaultLogFile, "")
+	flags.StringVar(&service, "service", "netbird", "")
+	flags.StringVar(&daemon, "daemon-addr", "unix:///var/run/netbird.sock", "")
+
+	require.NoError(t, applyProfile(flags, ""))
+	require.Equal(t, defaultConfigPath, config, "the default profile should keep the defaults")
+
+	require.NoError(t, flags.Set("log-file", "console"))
+	require.NoError(t, applyProfile(flags, "work"))
+	require.Equal(t, filepath.Join(defaultConfigPathDir, "work", "config.json"), config)
+	require.Equal(t, "netbird-work", service)
+	require.Equal(t, "unix:///var/run/netbird-work.sock", daemon)
+	require.Equal(t, "console", log, "the flags set by the user should be kept")
+
+	require.Error(t, applyProfile(flags, "my-work"))
+}
@@ -21,7 +21,10 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 
+	"github.com/netbirdio/netbird/client/collectd"
 	"github.com/netbirdio/netbird/client/internal"
+	"github.com/netbirdio/netbird/client/procd"
+	"github.com/netbirdio/netbird/client/ubus"
 )
 
 const (
@@ -32,6 +35,36 @@ const (
 	preSharedKeyFlag        = "preshared-key"
 	interfaceNameFlag       = "interface-name"
 	wireguardPortFlag       = "wireguard-port"
+	wireguardModeFlag       = "wireguard-mode"
+	mtuFlag                 = "mtu"
+	wireguardPortPoolFlag   = "wireguard-port-pool"
+	portRoamingIntervalFlag = "wireguard-port-roaming-interval"
+	tcpFallbackPortFlag     = "tcp-fallback-port"
+	uplinkFlag              = "uplink"
+	interfaceAddressFlag    = "interface-address"
+	iceDisableCandidateFlag = "ice-disable-candidates"
+	iceExcludeSubnetsFlag   = "ice-exclude-subnets"
+	iceFailedTimeoutFlag    = "ice-failed-timeout"
+	iceDisconnTimeoutFlag   = "ice-disconnected-timeout"
+	pmtuDiscoveryFlag       = "pmtu-discovery"
+	dnsCacheMinTTLFlag      = "dns-cache-min-ttl"
+	dnsCacheMaxTTLFlag      = "dns-cache-max-ttl"
+	dnsQueryLogFlag         = "dns-query-log"
+	logDroppedConnsFlag     = "log-dropped-connections"
+	lazyFirewallRulesFlag   = "lazy-firewall-rules"
+	lazyFirewallIdleFlag    = "lazy-firewall-idle-timeout"
+	lowMemoryFlag           = "low-memory"
+	disableSysctlFlag       = "disable-sysctl"
+	sshRecordingDirFlag     = "ssh-recording-dir"
+	sshRecordingRetFlag     = "ssh-recording-retention"
+	stageNetChangesFlag     = "stage-network-changes"
+	stagedAutoApplyFlag     = "staged-changes-auto-apply"
+	autoUpdateFlag          = "auto-update"
+	updatePublicKeyFlag     = "update-public-key"
+	updateDowngradeFlag     = "update-allow-downgrade"
+	inventoryFieldsFlag     = "inventory-fields"
+	inventoryPackagesFlag   = "inventory-packages"
+	dnsHostOverridesFlag    = "dns-host"
 	networkMonitorFlag      = "network-monitor"
 	disableAutoConnectFlag  = "disable-auto-connect"
 	serverSSHAllowedFlag    = "allow-server-ssh"
@@ -40,6 +73,15 @@ const (
 
 var (
 	configPath              string
+	uciConfigPath           string
+	ubusSocket              string
+	statusAPIAddr           string
+	collectdSocket          string
+	collectdInterval        time.Duration
+	stateFlushInterval      time.Duration
+	stateStagingDir         string
+	procdInstance           string
+	watchdogInterval        time.Duration
 	defaultConfigPathDir    string
 	defaultConfigPath       string
 	oldDefaultConfigPathDir string
@@ -63,6 +105,36 @@ var (
 	serverSSHAllowed        bool
 	interfaceName           string
 	wireguardPort           uint16
+	wireguardMode           string
+	mtu                     uint16
+	wireguardPortPool       string
+	portRoamingInterval     time.Duration
+	tcpFallbackPort         uint16
+	uplink                  string
+	interfaceAddress        string
+	iceDisabledCandidates   []string
+	iceExcludedSubnets      []string
+	iceFailedTimeout        time.Duration
+	iceDisconnTimeout       time.Duration
+	pmtuDiscovery           bool
+	dnsCacheMinTTL          time.Duration
+	dnsCacheMaxTTL          time.Duration
+	dnsQueryLog             bool
+	logDroppedConns         bool
+	lazyFirewallRules       bool
+	lazyFirewallIdle        time.Duration
+	lowMemory               bool
+	disableSysctl           bool
+	sshRecordingDir         string
+	sshRecordingRetention   time.Duration
+	stageNetworkChanges     bool
+	stagedChangesAutoApply  time.Duration
+	autoUpdate              bool
+	updatePublicKey         string
+	updateAllowDowngrade    bool
+	inventoryFields         []string
+	inventoryPackages       []string
+	dnsHostOverrides        []string
 	networkMonitor          bool
 	serviceName             string
 	autoConnectDisabled     bool
@@ -107,6 +179,11 @@ func init() {
 		defaultDaemonAddr = "tcp://127.0.0.1:41731"
 	}
 
+	defaultUCIConfigPath := ""
+	if runtime.GOOS == "linux" {
+		defaultUCIConfigPath = internal.DefaultUCIConfigPath
+	}
+
 	defaultServiceName := "netbird"
 	if runtime.GOOS == "windows" {
 		defaultServiceName = "Netbird"
@@ -117,6 +194,15 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&adminURL, "admin-url", "", fmt.Sprintf("Admin Panel URL [http|https]://[host]:[port] (default \"%s\")", internal.DefaultAdminURL))
 	rootCmd.PersistentFlags().StringVarP(&serviceName, "service", "s", defaultServiceName, "Netbird system service name")
 	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", defaultConfigPath, "Netbird config file location")
+	rootCmd.PersistentFlags().StringVar(&uciConfigPath, "uci-config", defaultUCIConfigPath, "OpenWrt UCI config file location, its settings take precedence over the config file. Used only when it exists")
+	rootCmd.PersistentFlags().StringVar(&ubusSocket, "ubus-socket", ubus.DefaultSocketPath, "OpenWrt ubus socket the daemon registers the netbird object on. Used only when it exists")
+	rootCmd.PersistentFlags().StringVar(&statusAPIAddr, "status-api-addr", "", "Address the daemon serves the JSON status on for LuCI and monitoring scripts [unix|tcp]://[path|host:port], e.g. unix:///var/run/netbird-status.sock. Empty disables it")
+	rootCmd.PersistentFlags().StringVar(&collectdSocket, "collectd-socket", "", "Socket of the collectd unixsock plugin the daemon sends its metrics to, e.g. /var/run/collectd.sock. Empty disables the export")
+	rootCmd.PersistentFlags().DurationVar(&collectdInterval, "collectd-interval", collectd.DefaultInterval, "Interval the daemon sends its metrics to collectd at")
+	rootCmd.PersistentFlags().DurationVar(&stateFlushInterval, "state-flush-interval", 0, "Time the daemon batches the writes of its state files for to spare the flash, e.g. 10m. 0 writes them immediately")
+	rootCmd.PersistentFlags().StringVar(&stateStagingDir, "state-staging-dir", "", "Directory on tmpfs the batched state files are staged in until they are flushed, keeping them over a restart of the daemon, e.g. /var/run/netbird/state. Empty keeps them in memory")
+	rootCmd.PersistentFlags().StringVar(&procdInstance, "procd-instance", procd.DefaultInstance, "procd instance of the daemon the watchdog pings are sent for")
+	rootCmd.PersistentFlags().DurationVar(&watchdogInterval, "watchdog-interval", 0, "Interval the daemon pings the procd watchdog of its instance at, at most half of the watchdog timeout, e.g. 30s. 0 disables the pings")
 	rootCmd.PersistentFlags().StringVarP(&logLevel, "log-level", "l", "info", "sets Netbird log level")
 	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", defaultLogFile, "sets Netbird log path. If console is specified the log will be output to stdout")
 	rootCmd.PersistentFlags().StringVarP(&setupKey, "setup-key", "k", "", "Setup key obtained from the Management Service Dashboard (used to register peer)")
@@ -132,15 +218,34 @@ func init() {
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(sshCmd)
 	rootCmd.AddCommand(routesCmd)
+	rootCmd.AddCommand(peersCmd)
 	rootCmd.AddCommand(debugCmd)
+	rootCmd.AddCommand(dnsCmd)
+	rootCmd.AddCommand(stateCmd)
+	rootCmd.AddCommand(pendingCmd)
+	rootCmd.AddCommand(pingCmd)
+	rootCmd.AddCommand(speedtestCmd)
 
 	serviceCmd.AddCommand(runCmd, startCmd, stopCmd, restartCmd) // service control commands are subcommands of service
 	serviceCmd.AddCommand(installCmd, uninstallCmd)              // service installer commands are subcommands of service
 
 	routesCmd.AddCommand(routesListCmd)
 	routesCmd.AddCommand(routesSelectCmd, routesDeselectCmd)
+	routesCmd.AddCommand(routesProposeLANCmd)
+
+	peersCmd.AddCommand(peersListCmd)
+
+	dnsCmd.AddCommand(dnsLogCmd)
+
+	stateCmd.AddCommand(stateFlushCmd)
+
+	pendingCmd.AddCommand(pendingApplyCmd, pendingDiscardCmd)
 
 	debugCmd.AddCommand(debugBundleCmd)
+	debugCmd.AddCommand(debugRoutesCmd)
+	debugCmd.AddCommand(debugCryptoBenchCmd)
+	debugCmd.AddCommand(debugPcapCmd)
+	debugCmd.AddCommand(debugDropsCmd)
 	debugCmd.AddCommand(logCmd)
 	logCmd.AddCommand(logLevelCmd)
 	debugCmd.AddCommand(forCmd)
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc/status"
@@ -9,19 +10,33 @@ import (
 	"github.com/netbirdio/netbird/client/proto"
 )
 
-var appendFlag bool
+var (
+	appendFlag bool
+	dryRunFlag bool
+)
+
+type routeOutput struct {
+	ID       string   `json:"id" yaml:"id"`
+	Network  string   `json:"network" yaml:"network"`
+	Domains  []string `json:"domains" yaml:"domains"`
+	Selected bool     `json:"selected" yaml:"selected"`
+}
+
+type routesOutput struct {
+	Routes []routeOutput `json:"routes" yaml:"routes"`
+}
 
 var routesCmd = &cobra.Command{
 	Use:   "routes",
 	Short: "Manage network routes",
-	Long:  `Commands to list, select, or deselect network routes.`,
+	Long:  `Commands to list, select, deselect or propose network routes.`,
 }
 
 var routesListCmd = &cobra.Command{
 	Use:     "list",
 	Aliases: []string{"ls"},
 	Short:   "List routes",
-	Example: "  netbird routes list",
+	Example: "  netbird routes list\n  netbird routes list --json",
 	Long:    "List all available network routes.",
 	RunE:    routesList,
 }
@@ -44,8 +59,18 @@ var routesDeselectCmd = &cobra.Command{
 	RunE:    routesDeselect,
 }
 
+var routesProposeLANCmd = &cobra.Command{
+	Use:     "propose-lan",
+	Short:   "Propose local networks as routes",
+	Long:    "Detect the private networks of the local interfaces and propose them to the management service as routes through this peer.\nThe routes are disabled until an admin approves them.",
+	Example: "  netbird routes propose-lan\n  netbird routes propose-lan --dry-run",
+	RunE:    routesProposeLAN,
+}
+
 func init() {
 	routesSelectCmd.PersistentFlags().BoolVarP(&appendFlag, "append", "a", false, "Append to current route selection instead of replacing")
+	addStructuredOutputFlags(routesListCmd, "the routes")
+	routesProposeLANCmd.PersistentFlags().BoolVar(&dryRunFlag, "dry-run", false, "Only show the detected networks without proposing them")
 }
 
 func routesList(cmd *cobra.Command, _ []string) error {
@@ -61,6 +86,10 @@ func routesList(cmd *cobra.Command, _ []string) error {
 		return fmt.Errorf("failed to list routes: %v", status.Convert(err).Message())
 	}
 
+	if structuredOutput() {
+		return printStructured(cmd, mapRoutes(resp.GetRoutes()))
+	}
+
 	if len(resp.Routes) == 0 {
 		cmd.Println("No routes available.")
 		return nil
@@ -72,12 +101,30 @@ func routesList(cmd *cobra.Command, _ []string) error {
 		if route.GetSelected() {
 			selectedStatus = "Selected"
 		}
+		if len(route.GetDomains()) > 0 {
+			cmd.Printf("\n  - ID: %s\n    Domains: %s\n    Status: %s\n", route.GetID(), strings.Join(route.GetDomains(), ", "), selectedStatus)
+			continue
+		}
 		cmd.Printf("\n  - ID: %s\n    Network: %s\n    Status: %s\n", route.GetID(), route.GetNetwork(), selectedStatus)
 	}
 
 	return nil
 }
 
+// mapRoutes converts the routes of the daemon to the output, the network is empty for the domain routes
+func mapRoutes(routes []*proto.Route) routesOutput {
+	output := routesOutput{Routes: make([]routeOutput, 0, len(routes))}
+	for _, route := range routes {
+		output.Routes = append(output.Routes, routeOutput{
+			ID:       route.GetID(),
+			Network:  route.GetNetwork(),
+			Domains:  append([]string{}, route.GetDomains()...),
+			Selected: route.GetSelected(),
+		})
+	}
+	return output
+}
+
 func routesSelect(cmd *cobra.Command, args []string) error {
 	conn, err := getClient(cmd.Context())
 	if err != nil {
@@ -129,3 +176,43 @@ func routesDeselect(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func routesProposeLAN(cmd *cobra.Command, _ []string) error {
+	conn, err := getClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client := proto.NewDaemonServiceClient(conn)
+	resp, err := client.ProposeLANRoutes(cmd.Context(), &proto.ProposeLANRoutesRequest{DryRun: dryRunFlag})
+	if err != nil {
+		return fmt.Errorf("failed to propose routes: %v", status.Convert(err).Message())
+	}
+
+	if len(resp.GetDetected()) == 0 {
+		cmd.Println("No local networks detected.")
+		return nil
+	}
+
+	cmd.Println("Detected local networks:")
+	for _, network := range resp.GetDetected() {
+		cmd.Printf("  - %s\n", network)
+	}
+
+	if dryRunFlag {
+		return nil
+	}
+
+	if len(resp.GetProposed()) == 0 {
+		cmd.Println("\nNo new routes proposed, the networks are already routed by this peer.")
+		return nil
+	}
+
+	cmd.Println("\nRoutes proposed, pending approval by an admin:")
+	for _, network := range resp.GetProposed() {
+		cmd.Printf("  - %s\n", network)
+	}
+
+	return nil
+}
@@ -14,8 +14,13 @@ import (
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
 
+	"github.com/netbirdio/netbird/client/collectd"
+	"github.com/netbirdio/netbird/client/internal/persist"
+	"github.com/netbirdio/netbird/client/procd"
 	"github.com/netbirdio/netbird/client/proto"
 	"github.com/netbirdio/netbird/client/server"
+	"github.com/netbirdio/netbird/client/statusapi"
+	"github.com/netbirdio/netbird/client/ubus"
 	"github.com/netbirdio/netbird/util"
 )
 
@@ -55,13 +60,45 @@ func (p *program) Start(svc service.Service) error {
 			}
 		}
 
-		serverInstance := server.New(p.ctx, configPath, logFile)
+		if err := persist.Configure(persist.Options{Interval: stateFlushInterval, StagingDir: stateStagingDir}); err != nil {
+			log.Errorf("failed to configure the writes of the state files: %v", err)
+		}
+		go persist.Run(p.ctx)
+
+		serverInstance := server.New(p.ctx, configPath, uciConfigPath, profile, logFile)
+		if _, err := os.Stat(ubusSocket); err == nil {
+			ubusService := ubus.NewService(ubusSocket, ubusObjectName(), serverInstance)
+			serverInstance.SetConnectionListener(ubusService)
+			go ubusService.Run(p.ctx)
+		}
 		if err := serverInstance.Start(); err != nil {
 			log.Fatalf("failed to start daemon: %v", err)
 		}
 		proto.RegisterDaemonServiceServer(p.serv, serverInstance)
 
+		if statusAPIAddr != "" {
+			go func() {
+				if err := statusapi.Serve(p.ctx, statusAPIAddr, serverInstance); err != nil {
+					log.Errorf("failed to serve the status API: %v", err)
+				}
+			}()
+		}
+		if collectdSocket != "" {
+			go collectd.NewExporter(serverInstance, collectdSocket, collectdInterval).Run(p.ctx)
+		}
+
+		supervisor := procd.New(procd.Options{
+			Service:    serviceName,
+			Instance:   procdInstance,
+			Watchdog:   watchdogInterval,
+			SocketPath: ubusSocket,
+		})
+		supervisor.NotifyReload(p.ctx, serverInstance.Reload)
+		go supervisor.Run(p.ctx)
+
 		log.Printf("started daemon server: %v", split[1])
+		// the listener is open, the CLI requests are served from now on
+		supervisor.Ready()
 		if err := p.serv.Serve(listen); err != nil {
 			log.Errorf("failed to serve daemon requests: %v", err)
 		}
@@ -76,6 +113,10 @@ func (p *program) Stop(srv service.Service) error {
 		p.serv.Stop()
 	}
 
+	if err := persist.Flush(); err != nil {
+		log.Errorf("failed to flush the state files: %v", err)
+	}
+
 	time.Sleep(time.Second * 2)
 	log.Info("stopped Netbird service") //nolint
 	return nil
@@ -33,6 +33,10 @@ var installCmd = &cobra.Command{
 			logLevel,
 		}
 
+		if profile != "" {
+			svcConfig.Arguments = append(svcConfig.Arguments, "--profile", profile)
+		}
+
 		if managementURL != "" {
 			svcConfig.Arguments = append(svcConfig.Arguments, "--management-url", managementURL)
 		}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/netbirdio/netbird/client/proto"
+)
+
+var (
+	speedtestTime      time.Duration
+	speedtestReverse   bool
+	speedtestUDP       bool
+	speedtestBandwidth string
+	speedtestReport    bool
+)
+
+var speedtestCmd = &cobra.Command{
+	Use:   "speedtest <peer>",
+	Short: "Measure the throughput to a peer over the WireGuard tunnel",
+	Long: "Run an iperf-like test against a peer over the WireGuard tunnel and show the throughput, the UDP loss and the path the peer is reached on, e.g. to validate that the router hardware sustains the expected traffic.\n" +
+		"The peer must be running Netbird and a policy with speed tests enabled must let this peer reach it, the policy rules also need to allow the traffic to the peer: TCP port 51890 and, for UDP tests, the UDP ports of the peer.\n" +
+		"The peer is the name, FQDN, Netbird IP or WireGuard public key of a peer of the account.",
+	Example: "  netbird speedtest office-router\n  netbird speedtest --reverse --udp --bandwidth 50M --report office-router",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runSpeedtest,
+}
+
+func init() {
+	speedtestCmd.Flags().DurationVar(&speedtestTime, "time", 10*time.Second, "Time to send data for, at most 1m")
+	speedtestCmd.Flags().BoolVar(&speedtestReverse, "reverse", false, "Make the peer send the data, measuring the download from it")
+	speedtestCmd.Flags().BoolVar(&speedtestUDP, "udp", false, "Send UDP datagrams at the bandwidth instead of a TCP stream, measuring the loss")
+	speedtestCmd.Flags().StringVar(&speedtestBandwidth, "bandwidth", "10M", "Rate of the UDP test in bits per second, with an optional K, M or G suffix")
+	speedtestCmd.Flags().BoolVar(&speedtestReport, "report", false, "Report the result to the management service, stored as an activity event")
+}
+
+func runSpeedtest(cmd *cobra.Command, args []string) error {
+	bandwidth, err := parseBandwidth(speedtestBandwidth)
+	if err != nil {
+		return err
+	}
+
+	conn, err := getClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	direction := "to"
+	if speedtestReverse {
+		direction = "from"
+	}
+	cmd.Printf("Testing the throughput %s %s for %s...\n", direction, args[0], speedtestTime)
+
+	client := proto.NewDaemonServiceClient(conn)
+	resp, err := client.Speedtest(cmd.Context(), &proto.SpeedtestRequest{
+		Peer:      args[0],
+		Duration:  durationpb.New(speedtestTime),
+		Reverse:   speedtestReverse,
+		Udp:       speedtestUDP,
+		Bandwidth: bandwidth,
+		Report:    speedtestReport,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to test %s: %v", args[0], status.Convert(err).Message())
+	}
+
+	name := resp.GetFqdn()
+	if name == "" {
+		name = args[0]
+	}
+	duration := resp.GetDuration().AsDuration()
+	var mbps float64
+	if duration > 0 {
+		mbps = float64(resp.GetBytes()) * 8 / duration.Seconds() / 1e6
+	}
+
+	cmd.Printf("%s %s %s (%s) over %s\n", strings.ToUpper(resp.GetProtocol()), resp.GetDirection(), name, resp.GetIp(), resp.GetPath())
+	cmd.Printf("  %.1f MB in %s, %.1f Mbit/s\n", float64(resp.GetBytes())/1e6, duration.Round(time.Millisecond), mbps)
+	if resp.GetProtocol() == "udp" {
+		sent, lost := resp.GetPacketsSent(), resp.GetPacketsLost()
+		var loss float64
+		if sent > 0 {
+			loss = float64(lost) / float64(sent) * 100
+		}
+		cmd.Printf("  %d datagrams sent, %d lost, %.1f%% loss\n", sent, lost, loss)
+	}
+
+	if speedtestReport {
+		if resp.GetReportError() != "" {
+			cmd.PrintErrf("failed to report the result to the management service: %s\n", resp.GetReportError())
+		} else {
+			cmd.Println("Reported the result to the management service")
+		}
+	}
+	return nil
+}
+
+// parseBandwidth parses a rate in bits per second with an optional K, M or G suffix
+func parseBandwidth(value string) (int64, error) {
+	multiplier := 1.0
+	number := strings.TrimSpace(value)
+	if number != "" {
+		switch strings.ToUpper(number[len(number)-1:]) {
+		case "K":
+			multiplier = 1e3
+		case "M":
+			multiplier = 1e6
+		case "G":
+			multiplier = 1e9
+		}
+		if multiplier != 1 {
+			number = number[:len(number)-1]
+		}
+	}
+
+	rate, err := strconv.ParseFloat(number, 64)
+	if err != nil || rate <= 0 {
+		return 0, fmt.Errorf("invalid bandwidth %q, expected bits per second like 50M", value)
+	}
+	return int64(rate * multiplier), nil
+}
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBandwidth(t *testing.T) {
+	for value, expected := range map[string]int64{"1000": 1000, "50M": 50_000_000, "1.5g": 1_500_000_000, "64K": 64_000} {
+		rate, err := parseBandwidth(value)
+		require.NoError(t, err, value)
+		require.Equal(t, expected, rate, value)
+	}
+
+	for _, value := range []string{"", "M", "-5M", "fast"} {
+		_, err := parseBandwidth(value)
+		require.Error(t, err, value)
+	}
+}
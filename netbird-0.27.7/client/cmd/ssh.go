@@ -61,6 +61,7 @@ var sshCmd = &cobra.Command{
 
 		config, err := internal.UpdateConfig(internal.ConfigInput{
 			ConfigPath: configPath,
+			Profile:    profile,
 		})
 		if err != nil {
 			return err
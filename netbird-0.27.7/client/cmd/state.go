@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/status"
+
+	"github.com/netbirdio/netbird/client/proto"
+)
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Manage the state files of the daemon",
+	Long:  `Commands to manage the state files the Netbird daemon writes, e.g. its config.`,
+}
+
+var stateFlushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Write the batched state files to the disk",
+	Long: "Write the state files batched in memory or in the staging directory to their path.\n" +
+		"The daemon batches the writes with service run --state-flush-interval, run this before a shutdown or a reboot to keep the latest state.",
+	Example: "  netbird state flush",
+	RunE:    stateFlush,
+}
+
+func stateFlush(cmd *cobra.Command, _ []string) error {
+	conn, err := getClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client := proto.NewDaemonServiceClient(conn)
+	if _, err := client.FlushState(cmd.Context(), &proto.FlushStateRequest{}); err != nil {
+		return fmt.Errorf("failed to flush the state files: %v", status.Convert(err).Message())
+	}
+
+	cmd.Println("State files flushed.")
+	return nil
+}
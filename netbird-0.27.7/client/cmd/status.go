@@ -34,7 +34,9 @@ type peerStateDetailOutput struct {
 	Direct                 bool             `json:"direct" yaml:"direct"`
 	IceCandidateType       iceCandidateType `json:"iceCandidateType" yaml:"iceCandidateType"`
 	IceCandidateEndpoint   iceCandidateType `json:"iceCandidateEndpoint" yaml:"iceCandidateEndpoint"`
+	IceCandidateNetwork    string           `json:"iceCandidateNetwork" yaml:"iceCandidateNetwork"`
 	LastWireguardHandshake time.Time        `json:"lastWireguardHandshake" yaml:"lastWireguardHandshake"`
+	WireguardEndpoint      string           `json:"wireguardEndpoint" yaml:"wireguardEndpoint"`
 	TransferReceived       int64            `json:"transferReceived" yaml:"transferReceived"`
 	TransferSent           int64            `json:"transferSent" yaml:"transferSent"`
 	Latency                time.Duration    `json:"latency" yaml:"latency"`
@@ -84,6 +86,29 @@ type nsServerGroupStateOutput struct {
 	Error   string   `json:"error" yaml:"error"`
 }
 
+type dnsCacheOutput struct {
+	Entries   int           `json:"entries" yaml:"entries"`
+	Capacity  int           `json:"capacity" yaml:"capacity"`
+	Hits      uint64        `json:"hits" yaml:"hits"`
+	Misses    uint64        `json:"misses" yaml:"misses"`
+	Evictions uint64        `json:"evictions" yaml:"evictions"`
+	MinTTL    time.Duration `json:"minTTL" yaml:"minTTL"`
+	MaxTTL    time.Duration `json:"maxTTL" yaml:"maxTTL"`
+}
+
+type failedRouteOutput struct {
+	ID        string    `json:"id" yaml:"id"`
+	Network   string    `json:"network" yaml:"network"`
+	Error     string    `json:"error" yaml:"error"`
+	Attempts  int       `json:"attempts" yaml:"attempts"`
+	NextRetry time.Time `json:"nextRetry" yaml:"nextRetry"`
+}
+
+type loginExpirationOutput struct {
+	ExpiresAt time.Time     `json:"expiresAt" yaml:"expiresAt"`
+	Warning   time.Duration `json:"warning,omitempty" yaml:"warning,omitempty"`
+}
+
 type statusOutputOverview struct {
 	Peers               peersStateOutput           `json:"peers" yaml:"peers"`
 	CliVersion          string                     `json:"cliVersion" yaml:"cliVersion"`
@@ -94,11 +119,16 @@ type statusOutputOverview struct {
 	IP                  string                     `json:"netbirdIp" yaml:"netbirdIp"`
 	PubKey              string                     `json:"publicKey" yaml:"publicKey"`
 	KernelInterface     bool                       `json:"usesKernelInterface" yaml:"usesKernelInterface"`
+	UserspaceReason     string                     `json:"userspaceReason,omitempty" yaml:"userspaceReason,omitempty"`
+	NATType             string                     `json:"natType,omitempty" yaml:"natType,omitempty"`
 	FQDN                string                     `json:"fqdn" yaml:"fqdn"`
 	RosenpassEnabled    bool                       `json:"quantumResistance" yaml:"quantumResistance"`
 	RosenpassPermissive bool                       `json:"quantumResistancePermissive" yaml:"quantumResistancePermissive"`
 	Routes              []string                   `json:"routes" yaml:"routes"`
 	NSServerGroups      []nsServerGroupStateOutput `json:"dnsServers" yaml:"dnsServers"`
+	FailedRoutes        []failedRouteOutput        `json:"failedRoutes,omitempty" yaml:"failedRoutes,omitempty"`
+	DNSCache            *dnsCacheOutput            `json:"dnsCache,omitempty" yaml:"dnsCache,omitempty"`
+	LoginExpiration     *loginExpirationOutput     `json:"loginExpiration,omitempty" yaml:"loginExpiration,omitempty"`
 }
 
 var (
@@ -111,8 +141,13 @@ var (
 	statusFilter         string
 	ipsFilterMap         map[string]struct{}
 	prefixNamesFilterMap map[string]struct{}
+	watchFlag            bool
+	watchInterval        time.Duration
 )
 
+// clearScreen moves the cursor home and clears the terminal before each refresh of the watch mode
+const clearScreen = "\033[H\033[2J"
+
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "status of the Netbird Service",
@@ -127,6 +162,9 @@ func init() {
 	statusCmd.PersistentFlags().BoolVar(&yamlFlag, "yaml", false, "display detailed status information in yaml format")
 	statusCmd.PersistentFlags().BoolVar(&ipv4Flag, "ipv4", false, "display only NetBird IPv4 of this peer, e.g., --ipv4 will output 100.64.0.33")
 	statusCmd.MarkFlagsMutuallyExclusive("detail", "json", "yaml", "ipv4")
+	statusCmd.PersistentFlags().BoolVarP(&watchFlag, "watch", "w", false, "refresh the status information until interrupted, the json and yaml formats print one document per refresh")
+	statusCmd.PersistentFlags().DurationVar(&watchInterval, "watch-interval", 2*time.Second, "time between the refreshes of the watch mode, e.g., --watch-interval 5s")
+	statusCmd.MarkFlagsMutuallyExclusive("watch", "ipv4")
 	statusCmd.PersistentFlags().StringSliceVar(&ipsFilter, "filter-by-ips", []string{}, "filters the detailed output by a list of one or more IPs, e.g., --filter-by-ips 100.64.0.100,100.64.0.200")
 	statusCmd.PersistentFlags().StringSliceVar(&prefixNamesFilter, "filter-by-names", []string{}, "filters the detailed output by a list of one or more peer FQDN or hostnames, e.g., --filter-by-names peer-a,peer-b.netbird.cloud")
 	statusCmd.PersistentFlags().StringVar(&statusFilter, "filter-by-status", "", "filters the detailed output by connection status(connected|disconnected), e.g., --filter-by-status connected")
@@ -149,6 +187,37 @@ func statusFunc(cmd *cobra.Command, args []string) error {
 
 	ctx := internal.CtxInitState(cmd.Context())
 
+	if !watchFlag {
+		return printStatus(ctx, cmd)
+	}
+
+	if watchInterval <= 0 {
+		return fmt.Errorf("watch interval must be positive, got: %s", watchInterval)
+	}
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		if !jsonFlag && !yamlFlag {
+			cmd.Print(clearScreen)
+		}
+		if yamlFlag {
+			cmd.Println("---")
+		}
+		if err := printStatus(ctx, cmd); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// printStatus prints the status of the daemon in the format selected with the flags
+func printStatus(ctx context.Context, cmd *cobra.Command) error {
 	resp, err := getStatus(ctx)
 	if err != nil {
 		return err
@@ -189,6 +258,10 @@ func statusFunc(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if jsonFlag && watchFlag {
+		// one json document per line
+		statusOutputString += "\n"
+	}
 	cmd.Print(statusOutputString)
 
 	return nil
@@ -279,11 +352,16 @@ func convertToStatusOutputOverview(resp *proto.StatusResponse) statusOutputOverv
 		IP:                  pbFullStatus.GetLocalPeerState().GetIP(),
 		PubKey:              pbFullStatus.GetLocalPeerState().GetPubKey(),
 		KernelInterface:     pbFullStatus.GetLocalPeerState().GetKernelInterface(),
+		UserspaceReason:     pbFullStatus.GetLocalPeerState().GetUserspaceReason(),
+		NATType:             pbFullStatus.GetLocalPeerState().GetNatType(),
 		FQDN:                pbFullStatus.GetLocalPeerState().GetFqdn(),
 		RosenpassEnabled:    pbFullStatus.GetLocalPeerState().GetRosenpassEnabled(),
 		RosenpassPermissive: pbFullStatus.GetLocalPeerState().GetRosenpassPermissive(),
 		Routes:              pbFullStatus.GetLocalPeerState().GetRoutes(),
 		NSServerGroups:      mapNSGroups(pbFullStatus.GetDnsServers()),
+		FailedRoutes:        mapFailedRoutes(pbFullStatus.GetFailedRoutes()),
+		DNSCache:            mapDNSCache(pbFullStatus.GetDnsCache()),
+		LoginExpiration:     mapLoginExpiration(pbFullStatus.GetLocalPeerState()),
 	}
 
 	if anonymizeFlag {
@@ -333,22 +411,68 @@ func mapNSGroups(servers []*proto.NSGroupState) []nsServerGroupStateOutput {
 	return mappedNSGroups
 }
 
+func mapDNSCache(dnsCache *proto.DNSCacheState) *dnsCacheOutput {
+	if dnsCache == nil {
+		return nil
+	}
+	return &dnsCacheOutput{
+		Entries:   int(dnsCache.GetEntries()),
+		Capacity:  int(dnsCache.GetCapacity()),
+		Hits:      dnsCache.GetHits(),
+		Misses:    dnsCache.GetMisses(),
+		Evictions: dnsCache.GetEvictions(),
+		MinTTL:    dnsCache.GetMinTTL().AsDuration(),
+		MaxTTL:    dnsCache.GetMaxTTL().AsDuration(),
+	}
+}
+
+func mapLoginExpiration(localPeerState *proto.LocalPeerState) *loginExpirationOutput {
+	if localPeerState.GetLoginExpiresAt() == nil {
+		return nil
+	}
+	return &loginExpirationOutput{
+		ExpiresAt: localPeerState.GetLoginExpiresAt().AsTime(),
+		Warning:   localPeerState.GetLoginExpirationWarning().AsDuration(),
+	}
+}
+
+func mapFailedRoutes(failedRoutes []*proto.FailedRoute) []failedRouteOutput {
+	var mappedFailedRoutes []failedRouteOutput
+	for _, failedRoute := range failedRoutes {
+		mappedFailedRoutes = append(mappedFailedRoutes, failedRouteOutput{
+			ID:        failedRoute.GetID(),
+			Network:   failedRoute.GetNetwork(),
+			Error:     failedRoute.GetError(),
+			Attempts:  int(failedRoute.GetAttempts()),
+			NextRetry: failedRoute.GetNextRetry().AsTime().Local(),
+		})
+	}
+	return mappedFailedRoutes
+}
+
 func mapPeers(peers []*proto.PeerState) peersStateOutput {
-	var peersStateDetail []peerStateDetailOutput
-	localICE := ""
-	remoteICE := ""
-	localICEEndpoint := ""
-	remoteICEEndpoint := ""
-	connType := ""
+	peersStateDetail := make([]peerStateDetailOutput, 0, len(peers))
 	peersConnected := 0
-	lastHandshake := time.Time{}
-	transferReceived := int64(0)
-	transferSent := int64(0)
 	for _, pbPeerState := range peers {
 		isPeerConnected := pbPeerState.ConnStatus == peer.StatusConnected.String()
 		if skipDetailByFilters(pbPeerState, isPeerConnected) {
 			continue
 		}
+
+		// the path details are only shown for the connected peers, each peer with its own
+		var (
+			localICE          string
+			remoteICE         string
+			localICEEndpoint  string
+			remoteICEEndpoint string
+			iceNetwork        string
+			connType          string
+			lastHandshake     time.Time
+			wireguardEndpoint string
+			transferReceived  int64
+			transferSent      int64
+			latency           time.Duration
+		)
 		if isPeerConnected {
 			peersConnected++
 
@@ -356,13 +480,16 @@ func mapPeers(peers []*proto.PeerState) peersStateOutput {
 			remoteICE = pbPeerState.GetRemoteIceCandidateType()
 			localICEEndpoint = pbPeerState.GetLocalIceCandidateEndpoint()
 			remoteICEEndpoint = pbPeerState.GetRemoteIceCandidateEndpoint()
+			iceNetwork = pbPeerState.GetIceCandidateNetwork()
 			connType = "P2P"
 			if pbPeerState.Relayed {
 				connType = "Relayed"
 			}
 			lastHandshake = pbPeerState.GetLastWireguardHandshake().AsTime().Local()
+			wireguardEndpoint = pbPeerState.GetWireguardEndpoint()
 			transferReceived = pbPeerState.GetBytesRx()
 			transferSent = pbPeerState.GetBytesTx()
+			latency = pbPeerState.GetLatency().AsDuration()
 		}
 
 		timeLocal := pbPeerState.GetConnStatusUpdate().AsTime().Local()
@@ -381,11 +508,13 @@ func mapPeers(peers []*proto.PeerState) peersStateOutput {
 				Local:  localICEEndpoint,
 				Remote: remoteICEEndpoint,
 			},
+			IceCandidateNetwork:    iceNetwork,
 			FQDN:                   pbPeerState.GetFqdn(),
 			LastWireguardHandshake: lastHandshake,
+			WireguardEndpoint:      wireguardEndpoint,
 			TransferReceived:       transferReceived,
 			TransferSent:           transferSent,
-			Latency:                pbPeerState.GetLatency().AsDuration(),
+			Latency:                latency,
 			RosenpassEnabled:       pbPeerState.GetRosenpassEnabled(),
 			Routes:                 pbPeerState.GetRoutes(),
 		}
@@ -421,16 +550,16 @@ func parseInterfaceIP(interfaceIP string) string {
 	return fmt.Sprintf("%s\n", ip)
 }
 
-func parseToJSON(overview statusOutputOverview) (string, error) {
-	jsonBytes, err := json.Marshal(overview)
+func parseToJSON(output any) (string, error) {
+	jsonBytes, err := json.Marshal(output)
 	if err != nil {
 		return "", fmt.Errorf("json marshal failed")
 	}
 	return string(jsonBytes), err
 }
 
-func parseToYAML(overview statusOutputOverview) (string, error) {
-	yamlBytes, err := yaml.Marshal(overview)
+func parseToYAML(output any) (string, error) {
+	yamlBytes, err := yaml.Marshal(output)
 	if err != nil {
 		return "", fmt.Errorf("yaml marshal failed")
 	}
@@ -471,6 +600,13 @@ func parseGeneralSummary(overview statusOutputOverview, showURL bool, showRelays
 	} else if overview.IP == "" {
 		interfaceTypeString = "N/A"
 		interfaceIP = "N/A"
+	} else if overview.UserspaceReason != "" {
+		interfaceTypeString = fmt.Sprintf("%s, reason: %s", interfaceTypeString, overview.UserspaceReason)
+	}
+
+	natType := overview.NATType
+	if natType == "" {
+		natType = "N/A"
 	}
 
 	var relaysString string
@@ -533,6 +669,49 @@ func parseGeneralSummary(overview statusOutputOverview, showURL bool, showRelays
 
 	peersCountString := fmt.Sprintf("%d/%d Connected", overview.Peers.Connected, overview.Peers.Total)
 
+	dnsCacheString := ""
+	if showNameServers && overview.DNSCache != nil {
+		dnsCacheString = fmt.Sprintf(
+			"DNS cache: %d/%d entries, %d hits, %d misses, %d evictions, TTL clamped to %s-%s\n",
+			overview.DNSCache.Entries,
+			overview.DNSCache.Capacity,
+			overview.DNSCache.Hits,
+			overview.DNSCache.Misses,
+			overview.DNSCache.Evictions,
+			overview.DNSCache.MinTTL,
+			overview.DNSCache.MaxTTL,
+		)
+	}
+
+	failedRoutesString := ""
+	if len(overview.FailedRoutes) > 0 {
+		failedRoutesString = "Failed routes:"
+		for _, failedRoute := range overview.FailedRoutes {
+			failedRoutesString += fmt.Sprintf(
+				"\n  [%s] %s failed %d times, next retry in %s, reason: %s",
+				failedRoute.ID,
+				failedRoute.Network,
+				failedRoute.Attempts,
+				time.Until(failedRoute.NextRetry).Round(time.Second),
+				failedRoute.Error,
+			)
+		}
+		failedRoutesString += "\n"
+	}
+
+	loginExpirationString := ""
+	if overview.LoginExpiration != nil {
+		loginExpirationString = fmt.Sprintf(
+			"Login expires: %s (in %s)",
+			overview.LoginExpiration.ExpiresAt.Local().Format("2006-01-02 15:04:05"),
+			time.Until(overview.LoginExpiration.ExpiresAt).Round(time.Minute),
+		)
+		if overview.LoginExpiration.Warning != 0 {
+			loginExpirationString += ", log in again to stay connected"
+		}
+		loginExpirationString += "\n"
+	}
+
 	goos := runtime.GOOS
 	goarch := runtime.GOARCH
 	goarm := ""
@@ -548,11 +727,15 @@ func parseGeneralSummary(overview statusOutputOverview, showURL bool, showRelays
 			"Signal: %s\n"+
 			"Relays: %s\n"+
 			"Nameservers: %s\n"+
+			"%s"+
 			"FQDN: %s\n"+
 			"NetBird IP: %s\n"+
 			"Interface type: %s\n"+
+			"NAT type: %s\n"+
 			"Quantum resistance: %s\n"+
 			"Routes: %s\n"+
+			"%s"+
+			"%s"+
 			"Peers count: %s\n",
 		fmt.Sprintf("%s/%s%s", goos, goarch, goarm),
 		overview.DaemonVersion,
@@ -561,11 +744,15 @@ func parseGeneralSummary(overview statusOutputOverview, showURL bool, showRelays
 		signalConnString,
 		relaysString,
 		dnsServersString,
+		dnsCacheString,
 		overview.FQDN,
 		interfaceIP,
 		interfaceTypeString,
+		natType,
 		rosenpassEnabledStatus,
 		routes,
+		failedRoutesString,
+		loginExpirationString,
 		peersCountString,
 	)
 	return summary
@@ -628,6 +815,16 @@ func parsePeers(peers peersStateOutput, rosenpassEnabled, rosenpassPermissive bo
 			}
 		}
 
+		iceNetwork := "-"
+		if peerState.IceCandidateNetwork != "" {
+			iceNetwork = peerState.IceCandidateNetwork
+		}
+
+		wireguardEndpoint := "-"
+		if peerState.WireguardEndpoint != "" {
+			wireguardEndpoint = peerState.WireguardEndpoint
+		}
+
 		routes := "-"
 		if len(peerState.Routes) > 0 {
 			sort.Strings(peerState.Routes)
@@ -644,8 +841,10 @@ func parsePeers(peers peersStateOutput, rosenpassEnabled, rosenpassPermissive bo
 				"  Direct: %t\n"+
 				"  ICE candidate (Local/Remote): %s/%s\n"+
 				"  ICE candidate endpoints (Local/Remote): %s/%s\n"+
+				"  ICE candidate network: %s\n"+
 				"  Last connection update: %s\n"+
 				"  Last WireGuard handshake: %s\n"+
+				"  WireGuard endpoint: %s\n"+
 				"  Transfer status (received/sent) %s/%s\n"+
 				"  Quantum resistance: %s\n"+
 				"  Routes: %s\n"+
@@ -660,8 +859,10 @@ func parsePeers(peers peersStateOutput, rosenpassEnabled, rosenpassPermissive bo
 			remoteICE,
 			localICEEndpoint,
 			remoteICEEndpoint,
+			iceNetwork,
 			timeAgo(peerState.LastStatusUpdate),
 			timeAgo(peerState.LastWireguardHandshake),
+			wireguardEndpoint,
 			toIEC(peerState.TransferReceived),
 			toIEC(peerState.TransferSent),
 			rosenpassEnabledStatus,
@@ -854,5 +1055,12 @@ func anonymizeOverview(a *anonymize.Anonymizer, overview *statusOutputOverview)
 		}
 	}
 
+	for i, failedRoute := range overview.FailedRoutes {
+		if prefix, err := netip.ParsePrefix(failedRoute.Network); err == nil {
+			overview.FailedRoutes[i].Network = fmt.Sprintf("%s/%d", a.AnonymizeIPString(prefix.Addr().String()), prefix.Bits())
+		}
+		overview.FailedRoutes[i].Error = a.AnonymizeString(failedRoute.Error)
+	}
+
 	overview.FQDN = a.AnonymizeDomain(overview.FQDN)
 }
@@ -43,6 +43,7 @@ var resp = &proto.StatusResponse{
 				LocalIceCandidateEndpoint:  "",
 				RemoteIceCandidateEndpoint: "",
 				LastWireguardHandshake:     timestamppb.New(time.Date(2001, time.Month(1), 1, 1, 1, 2, 0, time.UTC)),
+				WireguardEndpoint:          "203.0.113.10:51820",
 				BytesRx:                    200,
 				BytesTx:                    100,
 				Routes: []string{
@@ -62,6 +63,7 @@ var resp = &proto.StatusResponse{
 				RemoteIceCandidateType:     "prflx",
 				LocalIceCandidateEndpoint:  "10.0.0.1:10001",
 				RemoteIceCandidateEndpoint: "10.0.10.1:10002",
+				IceCandidateNetwork:        "udp4",
 				LastWireguardHandshake:     timestamppb.New(time.Date(2002, time.Month(2), 2, 2, 2, 3, 0, time.UTC)),
 				BytesRx:                    2000,
 				BytesTx:                    1000,
@@ -94,6 +96,7 @@ var resp = &proto.StatusResponse{
 			IP:              "192.168.178.100/16",
 			PubKey:          "Some-Pub-Key",
 			KernelInterface: true,
+			NatType:         "endpoint_independent",
 			Fqdn:            "some-localhost.awesome-domain.com",
 			Routes: []string{
 				"10.10.0.0/24",
@@ -146,7 +149,9 @@ var overview = statusOutputOverview{
 					Local:  "",
 					Remote: "",
 				},
+				IceCandidateNetwork:    "",
 				LastWireguardHandshake: time.Date(2001, 1, 1, 1, 1, 2, 0, time.UTC),
+				WireguardEndpoint:      "203.0.113.10:51820",
 				TransferReceived:       200,
 				TransferSent:           100,
 				Routes: []string{
@@ -170,6 +175,7 @@ var overview = statusOutputOverview{
 					Local:  "10.0.0.1:10001",
 					Remote: "10.0.10.1:10002",
 				},
+				IceCandidateNetwork:    "udp4",
 				LastWireguardHandshake: time.Date(2002, 2, 2, 2, 2, 3, 0, time.UTC),
 				TransferReceived:       2000,
 				TransferSent:           1000,
@@ -208,6 +214,7 @@ var overview = statusOutputOverview{
 	IP:              "192.168.178.100/16",
 	PubKey:          "Some-Pub-Key",
 	KernelInterface: true,
+	NATType:         "endpoint_independent",
 	FQDN:            "some-localhost.awesome-domain.com",
 	NSServerGroups: []nsServerGroupStateOutput{
 		{
@@ -266,6 +273,30 @@ func TestSortingOfPeers(t *testing.T) {
 	assert.Equal(t, peers[3].IP, "192.168.178.104")
 }
 
+func TestMapPeersDisconnectedDetails(t *testing.T) {
+	peers := mapPeers([]*proto.PeerState{
+		resp.GetFullStatus().GetPeers()[1],
+		{
+			IP:         "192.168.178.103",
+			PubKey:     "Pubkey3",
+			ConnStatus: "Disconnected",
+			Latency:    durationpb.New(time.Second),
+		},
+	})
+
+	require.Len(t, peers.Details, 2)
+	assert.Equal(t, 1, peers.Connected)
+	assert.Equal(t, "udp4", peers.Details[0].IceCandidateNetwork)
+
+	disconnected := peers.Details[1]
+	assert.Equal(t, "Pubkey3", disconnected.PubKey)
+	assert.Empty(t, disconnected.ConnType, "a disconnected peer shouldn't show the path of another peer")
+	assert.Empty(t, disconnected.IceCandidateType)
+	assert.Empty(t, disconnected.IceCandidateNetwork)
+	assert.Zero(t, disconnected.TransferReceived)
+	assert.Zero(t, disconnected.Latency, "a stale latency shouldn't be shown")
+}
+
 func TestParsingToJSON(t *testing.T) {
 	jsonString, _ := parseToJSON(overview)
 
@@ -292,7 +323,9 @@ func TestParsingToJSON(t *testing.T) {
                   "local": "",
                   "remote": ""
                 },
+                "iceCandidateNetwork": "",
                 "lastWireguardHandshake": "2001-01-01T01:01:02Z",
+                "wireguardEndpoint": "203.0.113.10:51820",
                 "transferReceived": 200,
                 "transferSent": 100,
 				"latency": 10000000,
@@ -317,7 +350,9 @@ func TestParsingToJSON(t *testing.T) {
                   "local": "10.0.0.1:10001",
                   "remote": "10.0.10.1:10002"
                 },
+                "iceCandidateNetwork": "udp4",
                 "lastWireguardHandshake": "2002-02-02T02:02:03Z",
+                "wireguardEndpoint": "",
                 "transferReceived": 2000,
                 "transferSent": 1000,
 				"latency": 10000000,
@@ -357,6 +392,7 @@ func TestParsingToJSON(t *testing.T) {
           "netbirdIp": "192.168.178.100/16",
           "publicKey": "Some-Pub-Key",
           "usesKernelInterface": true,
+          "natType": "endpoint_independent",
           "fqdn": "some-localhost.awesome-domain.com",
           "quantumResistance": false,
           "quantumResistancePermissive": false,
@@ -415,7 +451,9 @@ func TestParsingToYAML(t *testing.T) {
           iceCandidateEndpoint:
             local: ""
             remote: ""
+          iceCandidateNetwork: ""
           lastWireguardHandshake: 2001-01-01T01:01:02Z
+          wireguardEndpoint: 203.0.113.10:51820
           transferReceived: 200
           transferSent: 100
           latency: 10ms
@@ -435,7 +473,9 @@ func TestParsingToYAML(t *testing.T) {
           iceCandidateEndpoint:
             local: 10.0.0.1:10001
             remote: 10.0.10.1:10002
+          iceCandidateNetwork: udp4
           lastWireguardHandshake: 2002-02-02T02:02:03Z
+          wireguardEndpoint: ""
           transferReceived: 2000
           transferSent: 1000
           latency: 10ms
@@ -464,6 +504,7 @@ relays:
 netbirdIp: 192.168.178.100/16
 publicKey: Some-Pub-Key
 usesKernelInterface: true
+natType: endpoint_independent
 fqdn: some-localhost.awesome-domain.com
 quantumResistance: false
 quantumResistancePermissive: false
@@ -508,8 +549,10 @@ func TestParsingToDetail(t *testing.T) {
   Direct: true
   ICE candidate (Local/Remote): -/-
   ICE candidate endpoints (Local/Remote): -/-
+  ICE candidate network: -
   Last connection update: %s
   Last WireGuard handshake: %s
+  WireGuard endpoint: 203.0.113.10:51820
   Transfer status (received/sent) 200 B/100 B
   Quantum resistance: false
   Routes: 10.1.0.0/24
@@ -524,8 +567,10 @@ func TestParsingToDetail(t *testing.T) {
   Direct: false
   ICE candidate (Local/Remote): relay/prflx
   ICE candidate endpoints (Local/Remote): 10.0.0.1:10001/10.0.10.1:10002
+  ICE candidate network: udp4
   Last connection update: %s
   Last WireGuard handshake: %s
+  WireGuard endpoint: -
   Transfer status (received/sent) 2.0 KiB/1000 B
   Quantum resistance: false
   Routes: -
@@ -545,6 +590,7 @@ Nameservers:
 FQDN: some-localhost.awesome-domain.com
 NetBird IP: 192.168.178.100/16
 Interface type: Kernel
+NAT type: endpoint_independent
 Quantum resistance: false
 Routes: 10.10.0.0/24
 Peers count: 2/2 Connected
@@ -566,6 +612,7 @@ Nameservers: 1/2 Available
 FQDN: some-localhost.awesome-domain.com
 NetBird IP: 192.168.178.100/16
 Interface type: Kernel
+NAT type: endpoint_independent
 Quantum resistance: false
 Routes: 10.10.0.0/24
 Peers count: 2/2 Connected
@@ -574,6 +621,20 @@ Peers count: 2/2 Connected
 	assert.Equal(t, expectedString, shortVersion)
 }
 
+func TestParsingLoginExpiration(t *testing.T) {
+	expiringOverview := overview
+	expiringOverview.LoginExpiration = &loginExpirationOutput{
+		ExpiresAt: time.Now().Add(90 * time.Minute),
+		Warning:   2 * time.Hour,
+	}
+
+	shortVersion := parseGeneralSummary(expiringOverview, false, false, false)
+
+	expectedLine := fmt.Sprintf("Login expires: %s (in 1h30m0s), log in again to stay connected\n",
+		expiringOverview.LoginExpiration.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+	assert.Contains(t, shortVersion, expectedLine)
+}
+
 func TestParsingOfIP(t *testing.T) {
 	InterfaceIP := "192.168.178.123/16"
 
@@ -110,7 +110,7 @@ func startClientDaemon(
 	s := grpc.NewServer()
 
 	server := client.New(ctx,
-		configPath, "")
+		configPath, "", "", "")
 	if err := server.Start(); err != nil {
 		t.Fatal(err)
 	}
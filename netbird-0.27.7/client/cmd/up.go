@@ -5,13 +5,13 @@ import (
 	"fmt"
 	"net"
 	"net/netip"
-	"runtime"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc/codes"
 	gstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/netbirdio/netbird/client/internal"
 	"github.com/netbirdio/netbird/client/internal/peer"
@@ -27,6 +27,14 @@ const (
 	interfaceInputType
 )
 
+type upOutput struct {
+	Status           string `json:"status" yaml:"status"`
+	AlreadyConnected bool   `json:"alreadyConnected" yaml:"alreadyConnected"`
+	IP               string `json:"netbirdIp" yaml:"netbirdIp"`
+	PubKey           string `json:"publicKey" yaml:"publicKey"`
+	FQDN             string `json:"fqdn" yaml:"fqdn"`
+}
+
 var (
 	foregroundMode bool
 	upCmd          = &cobra.Command{
@@ -40,15 +48,81 @@ func init() {
 	upCmd.PersistentFlags().BoolVarP(&foregroundMode, "foreground-mode", "F", false, "start service in foreground")
 	upCmd.PersistentFlags().StringVar(&interfaceName, interfaceNameFlag, iface.WgInterfaceDefault, "Wireguard interface name")
 	upCmd.PersistentFlags().Uint16Var(&wireguardPort, wireguardPortFlag, iface.DefaultWgPort, "Wireguard interface listening port")
+	upCmd.PersistentFlags().StringVar(&wireguardMode, wireguardModeFlag, string(iface.WGModeAuto),
+		"Wireguard implementation: auto (kernel if available), kernel or userspace")
+	upCmd.PersistentFlags().StringVar(&wireguardPortPool, wireguardPortPoolFlag, "",
+		"Comma separated list of ports and port ranges the Wireguard port is picked from, e.g. 51820,40000-40100")
+	upCmd.PersistentFlags().DurationVar(&portRoamingInterval, portRoamingIntervalFlag, 0,
+		"How often the Wireguard port is moved to another port of the port pool, e.g. 30m. 0 disables roaming")
+	upCmd.PersistentFlags().Uint16Var(&tcpFallbackPort, tcpFallbackPortFlag, 0,
+		"TCP port to carry Wireguard traffic over when UDP is blocked, e.g. 443. 0 disables the TCP fallback")
+	upCmd.PersistentFlags().StringVar(&uplink, uplinkFlag, "",
+		"Network interface name or local source IP to pin the Wireguard and ICE traffic to on hosts with multiple uplinks, e.g. wan2. Overrides the uplink set by the management service")
+	upCmd.PersistentFlags().StringVar(&interfaceAddress, interfaceAddressFlag, "",
+		"NetBird IP to request for the Wireguard interface, e.g. 100.64.0.10. The management service assigns another IP if it is taken or outside of the network")
+	upCmd.PersistentFlags().StringSliceVar(&iceDisabledCandidates, iceDisableCandidateFlag, nil,
+		`ICE candidate types never gathered: host, srflx or relay, e.g. relay to only connect directly. Overrides the types disabled by the management service. Use --ice-disable-candidates="" to remove them`)
+	upCmd.PersistentFlags().StringSliceVar(&iceExcludedSubnets, iceExcludeSubnetsFlag, nil,
+		`Local subnets no ICE host candidate is gathered from, e.g. 172.17.0.0/16 for a Docker bridge or the subnet of a guest VLAN. Overrides the subnets excluded by the management service. Use --ice-exclude-subnets="" to remove them`)
+	upCmd.PersistentFlags().DurationVar(&iceFailedTimeout, iceFailedTimeoutFlag, 0,
+		"Time the ICE candidates are checked for before a connection attempt fails, e.g. 10s on slow uplinks. Overrides the timeout set by the management service. 0 means unset")
+	upCmd.PersistentFlags().DurationVar(&iceDisconnTimeout, iceDisconnTimeoutFlag, 0,
+		"Time without traffic after which a connection is considered disconnected, e.g. 10s on lossy uplinks. Overrides the timeout set by the management service. 0 means unset")
+	upCmd.PersistentFlags().Uint16Var(&mtu, mtuFlag, 0,
+		fmt.Sprintf("Wireguard interface MTU between %d and %d, overrides the MTU set by the management service. 0 means unset", iface.MinMTU, iface.MaxMTU))
+	upCmd.PersistentFlags().BoolVar(&pmtuDiscovery, pmtuDiscoveryFlag, false,
+		"Probe the path MTU to the management service and lower the Wireguard interface MTU on constrained uplinks, e.g. PPPoE or LTE")
+	upCmd.PersistentFlags().DurationVar(&dnsCacheMinTTL, dnsCacheMinTTLFlag, 0,
+		"Minimum time the DNS responses of the upstream nameservers are cached for, e.g. 1m. 0 keeps the TTL of the records")
+	upCmd.PersistentFlags().DurationVar(&dnsCacheMaxTTL, dnsCacheMaxTTLFlag, 0,
+		"Maximum time the DNS responses of the upstream nameservers are cached for, e.g. 1h. 0 means the default of 24h")
+	upCmd.PersistentFlags().BoolVar(&dnsQueryLog, dnsQueryLogFlag, false,
+		"Log the DNS queries handled by the resolver, shown with netbird dns log, and report their counters to the management service")
+	upCmd.PersistentFlags().BoolVar(&logDroppedConns, logDroppedConnsFlag, false,
+		"Log the inbound connections dropped by the firewall, shown with netbird debug drops, and report them to the management service. Requires the userspace firewall")
+	upCmd.PersistentFlags().BoolVar(&lazyFirewallRules, lazyFirewallRulesFlag, false,
+		"Install the per-peer firewall rules only for the peers with recent traffic, reducing the rules held on routers with many peers")
+	upCmd.PersistentFlags().DurationVar(&lazyFirewallIdle, lazyFirewallIdleFlag, 0,
+		"Time without traffic after which the firewall rules of a peer are removed with lazy firewall rules, e.g. 5m. 0 means the default of 10m")
+	upCmd.PersistentFlags().BoolVar(&lowMemory, lowMemoryFlag, false,
+		"Run in the low memory profile for routers with 64 to 128MB of RAM: lower GC targets and a smaller DNS cache, no SSH server, environment detection and route cache, fewer concurrent ICE agents")
+	upCmd.PersistentFlags().BoolVar(&disableSysctl, disableSysctlFlag, false,
+		"Never change the forwarding, rp_filter and src_valid_mark sysctls, for systems managing them themselves. Routed traffic is only forwarded if the system enables the forwarding")
+	upCmd.PersistentFlags().StringVar(&sshRecordingDir, sshRecordingDirFlag, "",
+		"Directory the terminal output of the sessions of the SSH server is recorded to as typescript files, replayed with cat or scriptreplay. Empty disables the recording")
+	upCmd.PersistentFlags().DurationVar(&sshRecordingRetention, sshRecordingRetFlag, 0,
+		"Time the SSH session recordings are kept for, e.g. 168h. 0 means the default of 30 days")
+	upCmd.PersistentFlags().BoolVar(&stageNetworkChanges, stageNetChangesFlag, false,
+		"Stage the route and DNS changes of the network map until they are approved with netbird pending apply, for routers where an unexpected default route change is catastrophic")
+	upCmd.PersistentFlags().DurationVar(&stagedChangesAutoApply, stagedAutoApplyFlag, 0,
+		"Time after which the staged route and DNS changes are applied without an approval, e.g. 30m. 0 waits for the approval")
+	upCmd.PersistentFlags().BoolVar(&autoUpdate, autoUpdateFlag, false,
+		"Update the client to the version the management service sets for the groups of the peer. Requires --"+updatePublicKeyFlag+" and a service manager restarting the daemon, not supported for profiles")
+	upCmd.PersistentFlags().StringVar(&updatePublicKey, updatePublicKeyFlag, "",
+		"Base64 encoded ed25519 public key the binaries of the auto updates are signed with, binaries with other signatures are rejected")
+	upCmd.PersistentFlags().BoolVar(&updateAllowDowngrade, updateDowngradeFlag, false,
+		"Apply the auto updates to versions at or below the running one, refused otherwise as an older signed release may have known vulnerabilities")
+	upCmd.PersistentFlags().StringSliceVar(&inventoryFields, inventoryFieldsFlag, nil,
+		`Fields of the software inventory reported to the management service at login for the posture checks and the vulnerability reports: patch_level and packages. Use --inventory-fields="" to stop reporting them`)
+	upCmd.PersistentFlags().StringSliceVar(&inventoryPackages, inventoryPackagesFlag, nil,
+		`Packages whose versions are reported with the software inventory, e.g. libopenssl3,dropbear, or * for all the installed packages. Use --inventory-packages="" to stop reporting them`)
+	upCmd.PersistentFlags().StringSliceVar(&dnsHostOverrides, dnsHostOverridesFlag, nil,
+		`Local host records served by the resolver whatever the DNS configuration of the management service, e.g. nas.home.arpa=192.168.1.10. They take precedence over the records of the management service with the same name. Use --dns-host="" to remove them`)
 	upCmd.PersistentFlags().BoolVarP(&networkMonitor, networkMonitorFlag, "N", false, "Enable network monitoring")
 	upCmd.PersistentFlags().StringSliceVar(&extraIFaceBlackList, extraIFaceBlackListFlag, nil, "Extra list of default interfaces to ignore for listening")
+	addStructuredOutputFlags(upCmd, "the connection state")
+	upCmd.MarkFlagsMutuallyExclusive("foreground-mode", "json")
+	upCmd.MarkFlagsMutuallyExclusive("foreground-mode", "yaml")
 }
 
 func upFunc(cmd *cobra.Command, args []string) error {
 	SetFlagsFromEnvVars(rootCmd)
 	SetFlagsFromEnvVars(cmd)
 
-	cmd.SetOut(cmd.OutOrStdout())
+	// with a structured output the messages, e.g. the SSO login prompt, go to stderr and only the result to stdout
+	if !structuredOutput() {
+		cmd.SetOut(cmd.OutOrStdout())
+	}
 
 	err := util.InitLog(logLevel, "console")
 	if err != nil {
@@ -88,6 +162,8 @@ func runInForegroundMode(ctx context.Context, cmd *cobra.Command) error {
 		ManagementURL:       managementURL,
 		AdminURL:            adminURL,
 		ConfigPath:          configPath,
+		UCIConfigPath:       uciConfigPath,
+		Profile:             profile,
 		NATExternalIPs:      natExternalIPs,
 		CustomDNSAddress:    customDNSAddressConverted,
 		ExtraIFaceBlackList: extraIFaceBlackList,
@@ -106,7 +182,7 @@ func runInForegroundMode(ctx context.Context, cmd *cobra.Command) error {
 	}
 
 	if cmd.Flag(interfaceNameFlag).Changed {
-		if err := parseInterfaceName(interfaceName); err != nil {
+		if err := iface.ValidateInterfaceName(interfaceName); err != nil {
 			return err
 		}
 		ic.InterfaceName = &interfaceName
@@ -117,6 +193,131 @@ func runInForegroundMode(ctx context.Context, cmd *cobra.Command) error {
 		ic.WireguardPort = &p
 	}
 
+	if cmd.Flag(wireguardModeFlag).Changed {
+		if _, err := iface.ParseWGMode(wireguardMode); err != nil {
+			return err
+		}
+		ic.WireguardMode = &wireguardMode
+	}
+
+	if cmd.Flag(wireguardPortPoolFlag).Changed {
+		ic.WireguardPortPool = &wireguardPortPool
+	}
+
+	if cmd.Flag(portRoamingIntervalFlag).Changed {
+		ic.PortRoamingInterval = &portRoamingInterval
+	}
+
+	if cmd.Flag(tcpFallbackPortFlag).Changed {
+		p := int(tcpFallbackPort)
+		ic.TCPFallbackPort = &p
+	}
+
+	if cmd.Flag(uplinkFlag).Changed {
+		ic.Uplink = &uplink
+	}
+
+	if cmd.Flag(interfaceAddressFlag).Changed {
+		ic.InterfaceAddress = &interfaceAddress
+	}
+
+	if cmd.Flag(iceDisableCandidateFlag).Changed {
+		ic.ICEDisabledCandidates = append([]string{}, iceDisabledCandidates...)
+	}
+
+	if cmd.Flag(iceExcludeSubnetsFlag).Changed {
+		ic.ICEExcludedSubnets = append([]string{}, iceExcludedSubnets...)
+	}
+
+	if cmd.Flag(iceFailedTimeoutFlag).Changed {
+		ic.ICEFailedTimeout = &iceFailedTimeout
+	}
+
+	if cmd.Flag(iceDisconnTimeoutFlag).Changed {
+		ic.ICEDisconnectedTimeout = &iceDisconnTimeout
+	}
+
+	if cmd.Flag(mtuFlag).Changed {
+		m := int(mtu)
+		ic.MTU = &m
+	}
+
+	if cmd.Flag(pmtuDiscoveryFlag).Changed {
+		ic.PMTUDiscovery = &pmtuDiscovery
+	}
+
+	if cmd.Flag(dnsCacheMinTTLFlag).Changed {
+		ic.DNSCacheMinTTL = &dnsCacheMinTTL
+	}
+
+	if cmd.Flag(dnsCacheMaxTTLFlag).Changed {
+		ic.DNSCacheMaxTTL = &dnsCacheMaxTTL
+	}
+
+	if cmd.Flag(dnsQueryLogFlag).Changed {
+		ic.DNSQueryLog = &dnsQueryLog
+	}
+
+	if cmd.Flag(logDroppedConnsFlag).Changed {
+		ic.DropLog = &logDroppedConns
+	}
+
+	if cmd.Flag(lazyFirewallRulesFlag).Changed {
+		ic.LazyFirewallRules = &lazyFirewallRules
+	}
+
+	if cmd.Flag(lazyFirewallIdleFlag).Changed {
+		ic.LazyFirewallIdleTimeout = &lazyFirewallIdle
+	}
+
+	if cmd.Flag(lowMemoryFlag).Changed {
+		ic.LowMemory = &lowMemory
+	}
+
+	if cmd.Flag(disableSysctlFlag).Changed {
+		ic.DisableSysctl = &disableSysctl
+	}
+
+	if cmd.Flag(sshRecordingDirFlag).Changed {
+		ic.SSHRecordingDir = &sshRecordingDir
+	}
+
+	if cmd.Flag(sshRecordingRetFlag).Changed {
+		ic.SSHRecordingRetention = &sshRecordingRetention
+	}
+
+	if cmd.Flag(stageNetChangesFlag).Changed {
+		ic.StageNetworkChanges = &stageNetworkChanges
+	}
+
+	if cmd.Flag(stagedAutoApplyFlag).Changed {
+		ic.StagedChangesAutoApply = &stagedChangesAutoApply
+	}
+
+	if cmd.Flag(autoUpdateFlag).Changed {
+		ic.AutoUpdate = &autoUpdate
+	}
+
+	if cmd.Flag(updatePublicKeyFlag).Changed {
+		ic.UpdatePublicKey = &updatePublicKey
+	}
+
+	if cmd.Flag(updateDowngradeFlag).Changed {
+		ic.UpdateAllowDowngrade = &updateAllowDowngrade
+	}
+
+	if cmd.Flag(inventoryFieldsFlag).Changed {
+		ic.InventoryFields = append([]string{}, inventoryFields...)
+	}
+
+	if cmd.Flag(inventoryPackagesFlag).Changed {
+		ic.InventoryPackages = append([]string{}, inventoryPackages...)
+	}
+
+	if cmd.Flag(dnsHostOverridesFlag).Changed {
+		ic.DNSHostOverrides = append([]string{}, dnsHostOverrides...)
+	}
+
 	if cmd.Flag(networkMonitorFlag).Changed {
 		ic.NetworkMonitor = &networkMonitor
 	}
@@ -185,6 +386,9 @@ func runInDaemonMode(ctx context.Context, cmd *cobra.Command) error {
 	}
 
 	if status.Status == string(internal.StatusConnected) {
+		if structuredOutput() {
+			return printUpOutput(ctx, cmd, client, true)
+		}
 		cmd.Println("Already connected")
 		return nil
 	}
@@ -222,7 +426,7 @@ func runInDaemonMode(ctx context.Context, cmd *cobra.Command) error {
 	}
 
 	if cmd.Flag(interfaceNameFlag).Changed {
-		if err := parseInterfaceName(interfaceName); err != nil {
+		if err := iface.ValidateInterfaceName(interfaceName); err != nil {
 			return err
 		}
 		loginRequest.InterfaceName = &interfaceName
@@ -233,6 +437,136 @@ func runInDaemonMode(ctx context.Context, cmd *cobra.Command) error {
 		loginRequest.WireguardPort = &wp
 	}
 
+	if cmd.Flag(wireguardModeFlag).Changed {
+		if _, err := iface.ParseWGMode(wireguardMode); err != nil {
+			return err
+		}
+		loginRequest.WireguardMode = &wireguardMode
+	}
+
+	if cmd.Flag(wireguardPortPoolFlag).Changed {
+		loginRequest.WireguardPortPool = &wireguardPortPool
+	}
+
+	if cmd.Flag(portRoamingIntervalFlag).Changed {
+		loginRequest.WireguardPortRoamingInterval = durationpb.New(portRoamingInterval)
+	}
+
+	if cmd.Flag(tcpFallbackPortFlag).Changed {
+		p := int64(tcpFallbackPort)
+		loginRequest.TcpFallbackPort = &p
+	}
+
+	if cmd.Flag(uplinkFlag).Changed {
+		loginRequest.Uplink = &uplink
+	}
+
+	if cmd.Flag(interfaceAddressFlag).Changed {
+		loginRequest.InterfaceAddress = &interfaceAddress
+	}
+
+	if cmd.Flag(iceDisableCandidateFlag).Changed {
+		loginRequest.IceDisabledCandidates = iceDisabledCandidates
+		loginRequest.CleanICEDisabledCandidates = len(iceDisabledCandidates) == 0
+	}
+
+	if cmd.Flag(iceExcludeSubnetsFlag).Changed {
+		loginRequest.IceExcludedSubnets = iceExcludedSubnets
+		loginRequest.CleanICEExcludedSubnets = len(iceExcludedSubnets) == 0
+	}
+
+	if cmd.Flag(iceFailedTimeoutFlag).Changed {
+		loginRequest.IceFailedTimeout = durationpb.New(iceFailedTimeout)
+	}
+
+	if cmd.Flag(iceDisconnTimeoutFlag).Changed {
+		loginRequest.IceDisconnectedTimeout = durationpb.New(iceDisconnTimeout)
+	}
+
+	if cmd.Flag(mtuFlag).Changed {
+		m := int64(mtu)
+		loginRequest.Mtu = &m
+	}
+
+	if cmd.Flag(pmtuDiscoveryFlag).Changed {
+		loginRequest.PmtuDiscovery = &pmtuDiscovery
+	}
+
+	if cmd.Flag(dnsCacheMinTTLFlag).Changed {
+		loginRequest.DnsCacheMinTTL = durationpb.New(dnsCacheMinTTL)
+	}
+
+	if cmd.Flag(dnsCacheMaxTTLFlag).Changed {
+		loginRequest.DnsCacheMaxTTL = durationpb.New(dnsCacheMaxTTL)
+	}
+
+	if cmd.Flag(dnsQueryLogFlag).Changed {
+		loginRequest.DnsQueryLog = &dnsQueryLog
+	}
+
+	if cmd.Flag(logDroppedConnsFlag).Changed {
+		loginRequest.LogDroppedConnections = &logDroppedConns
+	}
+
+	if cmd.Flag(lazyFirewallRulesFlag).Changed {
+		loginRequest.LazyFirewallRules = &lazyFirewallRules
+	}
+
+	if cmd.Flag(lazyFirewallIdleFlag).Changed {
+		loginRequest.LazyFirewallIdleTimeout = durationpb.New(lazyFirewallIdle)
+	}
+
+	if cmd.Flag(lowMemoryFlag).Changed {
+		loginRequest.LowMemory = &lowMemory
+	}
+
+	if cmd.Flag(disableSysctlFlag).Changed {
+		loginRequest.DisableSysctl = &disableSysctl
+	}
+
+	if cmd.Flag(sshRecordingDirFlag).Changed {
+		loginRequest.SshRecordingDir = &sshRecordingDir
+	}
+
+	if cmd.Flag(sshRecordingRetFlag).Changed {
+		loginRequest.SshRecordingRetention = durationpb.New(sshRecordingRetention)
+	}
+
+	if cmd.Flag(stageNetChangesFlag).Changed {
+		loginRequest.StageNetworkChanges = &stageNetworkChanges
+	}
+
+	if cmd.Flag(stagedAutoApplyFlag).Changed {
+		loginRequest.StagedChangesAutoApply = durationpb.New(stagedChangesAutoApply)
+	}
+
+	if cmd.Flag(autoUpdateFlag).Changed {
+		loginRequest.AutoUpdate = &autoUpdate
+	}
+
+	if cmd.Flag(updatePublicKeyFlag).Changed {
+		loginRequest.UpdatePublicKey = &updatePublicKey
+	}
+
+	if cmd.Flag(updateDowngradeFlag).Changed {
+		loginRequest.UpdateAllowDowngrade = &updateAllowDowngrade
+	}
+
+	if cmd.Flag(inventoryFieldsFlag).Changed {
+		loginRequest.InventoryFields = inventoryFields
+		loginRequest.CleanInventoryFields = len(inventoryFields) == 0
+	}
+
+	if cmd.Flag(inventoryPackagesFlag).Changed {
+		loginRequest.InventoryPackages = inventoryPackages
+		loginRequest.CleanInventoryPackages = len(inventoryPackages) == 0
+	}
+
+	if cmd.Flag(dnsHostOverridesFlag).Changed {
+		loginRequest.DnsHostOverrides = dnsHostOverrides
+		loginRequest.CleanDNSHostOverrides = len(dnsHostOverrides) == 0
+	}
+
 	if cmd.Flag(networkMonitorFlag).Changed {
 		loginRequest.NetworkMonitor = &networkMonitor
 	}
@@ -274,10 +608,30 @@ func runInDaemonMode(ctx context.Context, cmd *cobra.Command) error {
 	if _, err := client.Up(ctx, &proto.UpRequest{}); err != nil {
 		return fmt.Errorf("call service up method: %v", err)
 	}
+	if structuredOutput() {
+		return printUpOutput(ctx, cmd, client, false)
+	}
 	cmd.Println("Connected")
 	return nil
 }
 
+// printUpOutput prints the state of the daemon after it was brought up in the format of the --json or --yaml flag
+func printUpOutput(ctx context.Context, cmd *cobra.Command, client proto.DaemonServiceClient, alreadyConnected bool) error {
+	resp, err := client.Status(ctx, &proto.StatusRequest{GetFullPeerStatus: true})
+	if err != nil {
+		return fmt.Errorf("unable to get daemon status: %v", err)
+	}
+
+	localPeerState := resp.GetFullStatus().GetLocalPeerState()
+	return printStructured(cmd, upOutput{
+		Status:           resp.GetStatus(),
+		AlreadyConnected: alreadyConnected,
+		IP:               localPeerState.GetIP(),
+		PubKey:           localPeerState.GetPubKey(),
+		FQDN:             localPeerState.GetFqdn(),
+	})
+}
+
 func validateNATExternalIPs(list []string) error {
 	for _, element := range list {
 		if element == "" {
@@ -308,18 +662,6 @@ func validateNATExternalIPs(list []string) error {
 	return nil
 }
 
-func parseInterfaceName(name string) error {
-	if runtime.GOOS != "darwin" {
-		return nil
-	}
-
-	if strings.HasPrefix(name, "utun") {
-		return nil
-	}
-
-	return fmt.Errorf("invalid interface name %s. Please use the prefix utun followed by a number on MacOS. e.g., utun1 or utun199", name)
-}
-
 func validateElement(element string) (int, error) {
 	if isValidIP(element) {
 		return ipInputType, nil
@@ -0,0 +1,212 @@
+// Package collectd exports the metrics of the client to collectd through the socket of its unixsock plugin, feeding
+// the graphs of luci-app-statistics and the monitoring stacks collecting from collectd. The values are sent with the
+// PUTVAL command of the plain text protocol under the plugin netbird, e.g. <host>/netbird/gauge-peers_connected
+package collectd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/client/proto"
+	"github.com/netbirdio/netbird/client/statusapi"
+)
+
+const (
+	// DefaultInterval is the interval the metrics are sent at, the default interval of collectd
+	DefaultInterval = 10 * time.Second
+
+	plugin        = "netbird"
+	ioTimeout     = 5 * time.Second
+	statusTimeout = 5 * time.Second
+)
+
+// Value is a value of a metric, identified as <host>/<plugin>/<type>-<instance>. The values of types with several
+// data sources, e.g. if_octets with rx and tx, are sent together
+type Value struct {
+	Type     string
+	Instance string
+	Values   []int64
+}
+
+// Exporter sends the metrics of the daemon to collectd
+type Exporter struct {
+	daemon     proto.DaemonServiceServer
+	socketPath string
+	interval   time.Duration
+	host       string
+}
+
+// NewExporter returns an exporter sending the metrics of the daemon to the unixsock socket of collectd every interval,
+// DefaultInterval when zero
+func NewExporter(daemon proto.DaemonServiceServer, socketPath string, interval time.Duration) *Exporter {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+	return &Exporter{
+		daemon:     daemon,
+		socketPath: socketPath,
+		interval:   interval,
+		host:       host,
+	}
+}
+
+// Run sends the metrics every interval until ctx is done. The metrics are skipped while collectd is unreachable
+func (e *Exporter) Run(ctx context.Context) {
+	log.Infof("exporting the metrics to collectd on %s every %s", e.socketPath, e.interval)
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	var conn net.Conn
+	defer func() {
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if conn == nil {
+			var err error
+			if conn, err = net.DialTimeout("unix", e.socketPath, ioTimeout); err != nil {
+				log.Debugf("failed to connect to collectd: %v", err)
+				continue
+			}
+		}
+
+		if err := e.export(ctx, conn); err != nil {
+			log.Debugf("failed to export the metrics to collectd: %v", err)
+			_ = conn.Close()
+			conn = nil
+		}
+	}
+}
+
+func (e *Exporter) export(ctx context.Context, conn net.Conn) error {
+	statusCtx, cancel := context.WithTimeout(ctx, statusTimeout)
+	defer cancel()
+	status, err := statusapi.GetStatus(statusCtx, e.daemon)
+	if err != nil {
+		return err
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(ioTimeout))
+	defer func() {
+		_ = conn.SetDeadline(time.Time{})
+	}()
+	return putValues(conn, e.host, e.interval, Values(status))
+}
+
+// putValues sends the values with PUTVAL and reads the reply of collectd to each of them
+func putValues(conn net.Conn, host string, interval time.Duration, values []Value) error {
+	reader := bufio.NewReader(conn)
+	for _, v := range values {
+		if _, err := fmt.Fprintln(conn, putValCommand(host, interval, v)); err != nil {
+			return err
+		}
+
+		reply, err := reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("read reply: %w", err)
+		}
+		// the replies start with the status, negative on failure, e.g. "0 Success: 1 value has been dispatched."
+		if strings.HasPrefix(reply, "-") {
+			return fmt.Errorf("put %s-%s: %s", v.Type, v.Instance, strings.TrimSpace(reply))
+		}
+	}
+	return nil
+}
+
+func putValCommand(host string, interval time.Duration, v Value) string {
+	// collectd takes the interval in whole seconds
+	seconds := max(int64(interval.Seconds()), 1)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `PUTVAL "%s/%s/%s-%s" interval=%d N`, sanitize(host), plugin, v.Type, sanitize(v.Instance), seconds)
+	for _, value := range v.Values {
+		fmt.Fprintf(&b, ":%d", value)
+	}
+	return b.String()
+}
+
+// sanitize replaces the characters separating the parts of an identifier and quoting it
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '"', ' ', '\\':
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// Values returns the metrics of the status: the number of peers by state and connection type, the relays, the
+// handshake age and the traffic of each peer and the total traffic
+func Values(status *statusapi.Status) []Value {
+	var connected, relayed, direct, rx, tx int64
+	var peerValues []Value
+	for _, p := range status.Peers {
+		name := p.FQDN
+		if name == "" {
+			name = p.IP
+		}
+
+		if p.Status == "Connected" {
+			connected++
+		}
+		switch p.ConnectionType {
+		case "Relayed":
+			relayed++
+		case "P2P":
+			direct++
+		}
+		rx += p.RxBytes
+		tx += p.TxBytes
+
+		peerValues = append(peerValues, Value{
+			Type:     "if_octets",
+			Instance: "peer-" + name,
+			Values:   []int64{p.RxBytes, p.TxBytes},
+		})
+		if p.HandshakeAge != nil {
+			peerValues = append(peerValues, Value{
+				Type:     "gauge",
+				Instance: "handshake_age-" + name,
+				Values:   []int64{*p.HandshakeAge},
+			})
+		}
+	}
+
+	var relaysAvailable int64
+	for _, r := range status.Relays {
+		if r.Available {
+			relaysAvailable++
+		}
+	}
+
+	values := []Value{
+		{Type: "gauge", Instance: "peers_total", Values: []int64{int64(len(status.Peers))}},
+		{Type: "gauge", Instance: "peers_connected", Values: []int64{connected}},
+		{Type: "gauge", Instance: "peers_relayed", Values: []int64{relayed}},
+		{Type: "gauge", Instance: "peers_direct", Values: []int64{direct}},
+		{Type: "gauge", Instance: "relays_total", Values: []int64{int64(len(status.Relays))}},
+		{Type: "gauge", Instance: "relays_available", Values: []int64{relaysAvailable}},
+		{Type: "if_octets", Instance: "total", Values: []int64{rx, tx}},
+	}
+	return append(values, peerValues...)
+}
@@ -0,0 +1,121 @@
+package collectd
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/client/proto"
+	"github.com/netbirdio/netbird/client/statusapi"
+)
+
+type mockDaemon struct {
+	proto.UnimplementedDaemonServiceServer
+}
+
+func (m *mockDaemon) Status(context.Context, *proto.StatusRequest) (*proto.StatusResponse, error) {
+	return &proto.StatusResponse{
+		Status: "Connected",
+		FullStatus: &proto.FullStatus{
+			Peers: []*proto.PeerState{
+				{IP: "100.64.0.2", Fqdn: "nas.netbird.cloud", ConnStatus: "Connected", Direct: true, BytesRx: 100, BytesTx: 200},
+				{IP: "100.64.0.3", ConnStatus: "Connected", Relayed: true, BytesRx: 10, BytesTx: 20},
+			},
+			Relays: []*proto.RelayState{{URI: "turn:turn.netbird.io:443", Available: true}},
+		},
+	}, nil
+}
+
+func TestValues(t *testing.T) {
+	age := int64(42)
+	values := Values(&statusapi.Status{
+		Peers: []statusapi.Peer{
+			{IP: "100.64.0.2", FQDN: "nas.netbird.cloud", Status: "Connected", ConnectionType: "P2P", HandshakeAge: &age, RxBytes: 100, TxBytes: 200},
+			{IP: "100.64.0.3", Status: "Connecting"},
+		},
+		Relays: []statusapi.RelayState{{Available: true}, {Available: false}},
+	})
+
+	byName := make(map[string][]int64)
+	for _, v := range values {
+		byName[v.Type+"-"+v.Instance] = v.Values
+	}
+	require.Equal(t, []int64{2}, byName["gauge-peers_total"])
+	require.Equal(t, []int64{1}, byName["gauge-peers_connected"])
+	require.Equal(t, []int64{1}, byName["gauge-peers_direct"])
+	require.Equal(t, []int64{0}, byName["gauge-peers_relayed"])
+	require.Equal(t, []int64{1}, byName["gauge-relays_available"])
+	require.Equal(t, []int64{100, 200}, byName["if_octets-total"])
+	require.Equal(t, []int64{100, 200}, byName["if_octets-peer-nas.netbird.cloud"])
+	require.Equal(t, []int64{42}, byName["gauge-handshake_age-nas.netbird.cloud"])
+	require.Contains(t, byName, "if_octets-peer-100.64.0.3", "peers without a FQDN should be named by their IP")
+	require.NotContains(t, byName, "gauge-handshake_age-100.64.0.3", "peers without a handshake should have no handshake age")
+}
+
+func TestExport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "collectd.sock")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	commands := make(chan string, 64)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			commands <- scanner.Text()
+			if _, err := conn.Write([]byte("0 Success: 1 value has been dispatched.\n")); err != nil {
+				return
+			}
+		}
+	}()
+
+	e := NewExporter(&mockDaemon{}, path, 10*time.Millisecond)
+	e.host = "router"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.Run(ctx)
+
+	var received []string
+	require.Eventually(t, func() bool {
+		for {
+			select {
+			case cmd := <-commands:
+				received = append(received, cmd)
+			default:
+				return len(received) >= 7
+			}
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, `PUTVAL "router/netbird/gauge-peers_total" interval=1 N:2`, received[0])
+	require.Contains(t, received, `PUTVAL "router/netbird/gauge-peers_relayed" interval=1 N:1`)
+	require.Contains(t, received, `PUTVAL "router/netbird/if_octets-total" interval=1 N:110:220`)
+	for _, cmd := range received {
+		require.True(t, strings.HasPrefix(cmd, `PUTVAL "router/netbird/`), cmd)
+	}
+}
+
+func TestPutValuesFailure(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	go func() {
+		defer server.Close()
+		_, _ = bufio.NewReader(server).ReadString('\n')
+		_, _ = server.Write([]byte("-1 Unknown type\n"))
+	}()
+
+	err := putValues(client, "router", DefaultInterval, []Value{{Type: "unknown", Instance: "x", Values: []int64{1}}})
+	require.ErrorContains(t, err, "Unknown type")
+}
@@ -30,3 +30,6 @@ func NewFirewall(context context.Context, iface IFaceMapper) (firewall.Manager,
 	}
 	return fm, nil
 }
+
+// setProfileTable does nothing, the userspace firewall of each client has its own rules
+func setProfileTable(string) {}
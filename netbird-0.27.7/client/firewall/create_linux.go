@@ -24,11 +24,16 @@ const (
 	IPTABLES
 	// NFTABLES is the value for the nftables firewall type
 	NFTABLES
+	// FW4 is the value for the nftables firewall type integrated into the fw4 table of OpenWrt
+	FW4
 )
 
 // SKIP_NFTABLES_ENV is the environment variable to skip nftables check
 const SKIP_NFTABLES_ENV = "NB_SKIP_NFTABLES_CHECK"
 
+// SKIP_FW4_ENV is the environment variable to skip fw4 check and use a separate nftables table on OpenWrt
+const SKIP_FW4_ENV = "NB_SKIP_FW4_CHECK"
+
 // FWType is the type for the firewall type
 type FWType int
 
@@ -43,6 +48,10 @@ func NewFirewall(context context.Context, iface IFaceMapper) (firewall.Manager,
 	switch check() {
 	case IPTABLES:
 		log.Debug("creating an iptables firewall manager")
+		if profile != "" {
+			log.Warnf("the iptables chains of profile %s are shared with the other NetBird clients of the host, "+
+				"use nftables to run several clients", profile)
+		}
 		fm, errFw = nbiptables.Create(context, iface)
 		if errFw != nil {
 			log.Errorf("failed to create iptables manager: %s", errFw)
@@ -53,6 +62,12 @@ func NewFirewall(context context.Context, iface IFaceMapper) (firewall.Manager,
 		if errFw != nil {
 			log.Errorf("failed to create nftables manager: %s", errFw)
 		}
+	case FW4:
+		log.Debug("creating an nftables firewall manager integrated into fw4")
+		fm, errFw = nbnftables.CreateFw4(context, iface)
+		if errFw != nil {
+			log.Errorf("failed to create fw4 nftables manager: %s", errFw)
+		}
 	default:
 		errFw = fmt.Errorf("no firewall manager found")
 		log.Debug("no firewall manager found, try to use userspace packet filtering firewall")
@@ -83,10 +98,23 @@ func NewFirewall(context context.Context, iface IFaceMapper) (firewall.Manager,
 	return fm, nil
 }
 
+// setProfileTable names the nftables table of the profile netbird-<profile>
+func setProfileTable(name string) {
+	if name == "" {
+		nbnftables.SetTableName("netbird")
+		return
+	}
+	nbnftables.SetTableName("netbird-" + name)
+}
+
 // check returns the firewall type based on common lib checks. It returns UNKNOWN if no firewall is found.
 func check() FWType {
 	nf := nftables.Conn{}
 	if _, err := nf.ListChains(); err == nil && os.Getenv(SKIP_NFTABLES_ENV) != "true" {
+		// the chains installed into fw4 have fixed names, the profiles use a table of their own
+		if os.Getenv(SKIP_FW4_ENV) != "true" && profile == "" && nbnftables.Fw4Available() {
+			return FW4
+		}
 		return NFTABLES
 	}
 
@@ -1,9 +1,13 @@
 package iptables
 
 import (
+	"crypto/md5"
+	"encoding/hex"
 	"fmt"
 	"net"
+	"net/netip"
 	"strconv"
+	"strings"
 
 	"github.com/coreos/go-iptables/iptables"
 	"github.com/google/uuid"
@@ -21,6 +25,9 @@ const (
 	chainNameOutputRules = "NETBIRD-ACL-OUTPUT"
 
 	postRoutingMark = "0x000007e4"
+
+	// counterTagPrefix prefixes the comments identifying the filtering rules when reading their counters
+	counterTagPrefix = "nb-"
 )
 
 type aclManager struct {
@@ -30,6 +37,8 @@ type aclManager struct {
 
 	entries    map[string][][]string
 	ipsetStore *ipsetStore
+
+	blockedNetworks map[netip.Prefix]struct{}
 }
 
 func newAclManager(iptablesClient *iptables.IPTables, wgIface iFaceMapper, routeingFwChainName string) (*aclManager, error) {
@@ -88,6 +97,8 @@ func (m *aclManager) AddFiltering(
 
 	ipsetName = transformIPsetName(ipsetName, sPortVal, dPortVal)
 	specs := filterRuleSpecs(ip, string(protocol), sPortVal, dPortVal, direction, action, ipsetName)
+	counterTag := ruleCounterTag(chain, specs)
+	specs = append([]string{"-m", "comment", "--comment", counterTag}, specs...)
 	if ipsetName != "" {
 		if ipList, ipsetExists := m.ipsetStore.ipset(ipsetName); ipsetExists {
 			if err := ipset.Add(ipsetName, ip.String()); err != nil {
@@ -97,11 +108,12 @@ func (m *aclManager) AddFiltering(
 			// so we need to update IPs in the ruleset and return new fw.Rule object for ACL manager.
 			ipList.addIP(ip.String())
 			return []firewall.Rule{&Rule{
-				ruleID:    uuid.New().String(),
-				ipsetName: ipsetName,
-				ip:        ip.String(),
-				chain:     chain,
-				specs:     specs,
+				ruleID:     uuid.New().String(),
+				ipsetName:  ipsetName,
+				ip:         ip.String(),
+				chain:      chain,
+				specs:      specs,
+				counterTag: counterTag,
 			}}, nil
 		}
 
@@ -132,11 +144,12 @@ func (m *aclManager) AddFiltering(
 	}
 
 	rule := &Rule{
-		ruleID:    uuid.New().String(),
-		specs:     specs,
-		ipsetName: ipsetName,
-		ip:        ip.String(),
-		chain:     chain,
+		ruleID:     uuid.New().String(),
+		specs:      specs,
+		ipsetName:  ipsetName,
+		ip:         ip.String(),
+		chain:      chain,
+		counterTag: counterTag,
 	}
 
 	if !shouldAddToPrerouting(protocol, dPort, direction) {
@@ -199,6 +212,32 @@ DELETERULE:
 	return err
 }
 
+// ReadCounters returns the counters of the filtering rules summed per group
+func (m *aclManager) ReadCounters(groups map[string][]firewall.Rule) (map[string]firewall.Counters, error) {
+	counters := make(map[string]firewall.Counters)
+	for _, chain := range []string{chainNameInputRules, chainNameOutputRules} {
+		stats, err := m.iptablesClient.StructuredStats(tableName, chain)
+		if err != nil {
+			return nil, fmt.Errorf("read stats of chain %s: %w", chain, err)
+		}
+
+		for _, stat := range stats {
+			tag, ok := parseCounterTag(stat.Options)
+			if !ok {
+				continue
+			}
+			counters[tag] = firewall.Counters{Packets: stat.Packets, Bytes: stat.Bytes}
+		}
+	}
+
+	return firewall.SumCounters(groups, counters, func(rule firewall.Rule) string {
+		if r, ok := rule.(*Rule); ok {
+			return r.counterTag
+		}
+		return ""
+	}), nil
+}
+
 func (m *aclManager) Reset() error {
 	return m.cleanChains()
 }
@@ -243,6 +282,10 @@ func (m *aclManager) addPreroutingFilter(ipsetName string, protocol string, port
 
 // todo write less destructive cleanup mechanism
 func (m *aclManager) cleanChains() error {
+	if err := m.removeBlockedNetworks(); err != nil {
+		log.Errorf("failed to remove blocked networks: %s", err)
+	}
+
 	ok, err := m.iptablesClient.ChainExists(tableName, chainNameOutputRules)
 	if err != nil {
 		log.Debugf("failed to list chains: %s", err)
@@ -429,6 +472,7 @@ func (m *aclManager) seedInitialEntries() {
 	m.appendToEntries("NETBIRD-RT-NAT", []string{"-t", "nat", "-s", m.wgIface.Address().String(), "-j", "MASQUERADE"})
 	m.appendToEntries("NETBIRD-RT-NAT", []string{"-t", "nat", "-d", m.wgIface.Address().String(), "-j", "MASQUERADE"})
 
+	// -A NETBIRD-RT-NAT -s 100.118.0.0/16 -j MASQUERADE, regardless of the masquerade flag of the routes
 }
 
 
@@ -475,6 +519,27 @@ func filterRuleSpecs(
 	return append(specs, "-j", actionToStr(action))
 }
 
+// ruleCounterTag returns the comment identifying the filtering rule with the given specs in the listing of the chain.
+// The rules of the peers of an ipset have the same specs and share the tag of their iptables rule
+func ruleCounterTag(chain string, specs []string) string {
+	sum := md5.Sum([]byte(chain + " " + strings.Join(specs, " ")))
+	return counterTagPrefix + hex.EncodeToString(sum[:8])
+}
+
+// parseCounterTag returns the tag from the options of a rule in the listing of a chain, e.g. "/* nb-0123456789abcdef */"
+func parseCounterTag(options string) (string, bool) {
+	start := strings.Index(options, "/* "+counterTagPrefix)
+	if start < 0 {
+		return "", false
+	}
+	tag := options[start+len("/* "):]
+	end := strings.Index(tag, " */")
+	if end < 0 {
+		return "", false
+	}
+	return tag[:end], true
+}
+
 func actionToStr(action firewall.Action) string {
 	if action == firewall.ActionAccept {
 		return "ACCEPT"
@@ -0,0 +1,106 @@
+package iptables
+
+import (
+	"fmt"
+	"net/netip"
+
+	"github.com/nadoo/ipset"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// blockedIpsetName is the ipset of the networks the traffic leaving through the WireGuard interface is dropped to
+	blockedIpsetName = "nbblocked"
+
+	// maxBlockedIpsetEntries is the default maximum number of elements of an ipset, which can't be changed at creation
+	maxBlockedIpsetEntries = 65536
+)
+
+// SetBlockedNetworks replaces the networks the traffic leaving through the WireGuard interface is dropped to
+func (m *Manager) SetBlockedNetworks(networks []netip.Prefix) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.aclMgr.SetBlockedNetworks(networks)
+}
+
+// SetBlockedNetworks updates the blocked ipset with the difference to the previous networks. The drop rules and the
+// ipset are created with the first networks and removed with the last ones
+func (m *aclManager) SetBlockedNetworks(networks []netip.Prefix) error {
+	wanted := make(map[netip.Prefix]struct{}, len(networks))
+	for _, network := range networks {
+		// hash:net sets don't hold zero length networks
+		if !network.Addr().Is4() || network.Bits() == 0 {
+			continue
+		}
+		if len(wanted) == maxBlockedIpsetEntries {
+			log.Warnf("the blocked networks exceed the %d entries of the %s ipset, the remaining are ignored",
+				maxBlockedIpsetEntries, blockedIpsetName)
+			break
+		}
+		wanted[network.Masked()] = struct{}{}
+	}
+
+	if len(wanted) == 0 {
+		return m.removeBlockedNetworks()
+	}
+
+	if m.blockedNetworks == nil {
+		if err := ipset.Create(blockedIpsetName); err != nil {
+			return fmt.Errorf("create ipset %s: %w", blockedIpsetName, err)
+		}
+		m.blockedNetworks = make(map[netip.Prefix]struct{})
+
+		for _, chain := range []string{"OUTPUT", "FORWARD"} {
+			if err := m.iptablesClient.Insert(tableName, chain, 1, m.blockedRule()...); err != nil {
+				return fmt.Errorf("insert blocked networks rule in %s chain: %w", chain, err)
+			}
+		}
+	}
+
+	for network := range m.blockedNetworks {
+		if _, ok := wanted[network]; ok {
+			continue
+		}
+		if err := ipset.DelPrefix(blockedIpsetName, network); err != nil {
+			log.Errorf("failed to delete %s from ipset %s: %v", network, blockedIpsetName, err)
+			continue
+		}
+		delete(m.blockedNetworks, network)
+	}
+
+	for network := range wanted {
+		if _, ok := m.blockedNetworks[network]; ok {
+			continue
+		}
+		if err := ipset.AddPrefix(blockedIpsetName, network); err != nil {
+			return fmt.Errorf("add %s to ipset %s: %w", network, blockedIpsetName, err)
+		}
+		m.blockedNetworks[network] = struct{}{}
+	}
+
+	return nil
+}
+
+// removeBlockedNetworks deletes the drop rules and the blocked ipset, also when left over by a previous run
+func (m *aclManager) removeBlockedNetworks() error {
+	for _, chain := range []string{"OUTPUT", "FORWARD"} {
+		if err := m.iptablesClient.DeleteIfExists(tableName, chain, m.blockedRule()...); err != nil {
+			return fmt.Errorf("delete blocked networks rule from %s chain: %w", chain, err)
+		}
+	}
+
+	if err := ipset.Destroy(blockedIpsetName); err != nil {
+		// the ipset of a previous run might not exist
+		if m.blockedNetworks != nil {
+			return fmt.Errorf("destroy ipset %s: %w", blockedIpsetName, err)
+		}
+		log.Debugf("destroy ipset %s: %v", blockedIpsetName, err)
+	}
+	m.blockedNetworks = nil
+	return nil
+}
+
+func (m *aclManager) blockedRule() []string {
+	return []string{"-o", m.wgIface.Name(), "-m", "set", "--match-set", blockedIpsetName, "dst", "-j", "DROP"}
+}
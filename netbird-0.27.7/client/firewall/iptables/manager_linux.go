@@ -84,6 +84,14 @@ func (m *Manager) DeleteRule(rule firewall.Rule) error {
 	return m.aclMgr.DeleteRule(rule)
 }
 
+// ReadCounters returns the counters of the filtering rules summed per group
+func (m *Manager) ReadCounters(groups map[string][]firewall.Rule) (map[string]firewall.Counters, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.aclMgr.ReadCounters(groups)
+}
+
 func (m *Manager) IsServerRouteSupported() bool {
 	return true
 }
@@ -280,3 +280,16 @@ func TestIptablesCreatePerformance(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCounterTag(t *testing.T) {
+	tag := ruleCounterTag(chainNameInputRules, []string{"-s", "10.20.0.100", "-p", "tcp", "--dport", "22", "-j", "ACCEPT"})
+	require.Equal(t, tag, ruleCounterTag(chainNameInputRules, []string{"-s", "10.20.0.100", "-p", "tcp", "--dport", "22", "-j", "ACCEPT"}))
+	require.NotEqual(t, tag, ruleCounterTag(chainNameOutputRules, []string{"-s", "10.20.0.100", "-p", "tcp", "--dport", "22", "-j", "ACCEPT"}))
+
+	parsed, ok := parseCounterTag("tcp dpt:22 /* " + tag + " */")
+	require.True(t, ok, "tag should be found in the options")
+	require.Equal(t, tag, parsed)
+
+	_, ok = parseCounterTag("tcp dpt:22 /* accept HTTP traffic */")
+	require.False(t, ok, "comments of other rules should be ignored")
+}
@@ -123,10 +123,8 @@ func (i *routerManager) RemoveRoutingRules(pair firewall.RouterPair) error {
 		return err
 	}
 
-	if !pair.Masquerade {
-		return nil
-	}
-
+	// the nat rules are removed regardless of the masquerade flag of the pair,
+	// as they might have been inserted before masquerade was turned off
 	err = i.removeRoutingRule(firewall.NatFormat, tableNat, chainRTNAT, pair)
 	if err != nil {
 		return err
@@ -8,6 +8,8 @@ type Rule struct {
 	specs []string
 	ip    string
 	chain string
+	// counterTag identifies the iptables rule when reading its counters
+	counterTag string
 }
 
 // GetRuleID returns the rule id
@@ -3,6 +3,8 @@ package manager
 import (
 	"fmt"
 	"net"
+	"net/netip"
+	"time"
 )
 
 const (
@@ -83,6 +85,80 @@ type Manager interface {
 	Flush() error
 }
 
+// Counters is the traffic matched by firewall rules since they were installed
+type Counters struct {
+	Packets uint64
+	Bytes   uint64
+}
+
+// CountersReader is implemented by the firewall managers able to read the counters of the rules they installed
+type CountersReader interface {
+	// ReadCounters returns the counters of the rules summed per group.
+	// Rules of a group sharing the same firewall entry, e.g. the rules of the peers of a set, are counted once
+	ReadCounters(groups map[string][]Rule) (map[string]Counters, error)
+}
+
+// SumCounters sums the counters of the firewall entries of the rules per group, counting each entry once per group.
+// The entry function returns the key of the counters of the firewall entry a rule is installed as
+func SumCounters(groups map[string][]Rule, counters map[string]Counters, entry func(Rule) string) map[string]Counters {
+	sums := make(map[string]Counters, len(groups))
+	for group, rules := range groups {
+		var sum Counters
+		counted := make(map[string]struct{}, len(rules))
+		for _, rule := range rules {
+			key := entry(rule)
+			if _, ok := counted[key]; ok {
+				continue
+			}
+			counted[key] = struct{}{}
+
+			sum.Packets += counters[key].Packets
+			sum.Bytes += counters[key].Bytes
+		}
+		sums[group] = sum
+	}
+	return sums
+}
+
+// DroppedConnection is an inbound connection on the Netbird interface dropped by the firewall
+type DroppedConnection struct {
+	SrcIP    net.IP
+	SrcPort  uint16
+	DstPort  uint16
+	Protocol Protocol
+	// RuleID is the ID of the drop rule the connection matched, empty when it was dropped by the default policy
+	RuleID    string
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Packets   uint64
+}
+
+// DropLogger is implemented by the firewall managers able to log the inbound connections they drop
+type DropLogger interface {
+	// SetDropLogEnabled enables or disables the log of the dropped connections, disabling it clears the log
+	SetDropLogEnabled(enabled bool)
+
+	// DroppedConnections returns the logged connections, the oldest first
+	DroppedConnections() []DroppedConnection
+}
+
+// ActivityTracker is implemented by the firewall managers able to track the peers with traffic on the Netbird interface
+type ActivityTracker interface {
+	// EnableActivityTracking installs the base rules recording the peers sending or receiving packets on the interface,
+	// including the packets dropped for lack of a matching rule. Peers without packets for idleTimeout are forgotten
+	EnableActivityTracking(idleTimeout time.Duration) error
+
+	// ActivePeers returns the IPs of the peers with packets within the idle timeout
+	ActivePeers() ([]net.IP, error)
+}
+
+// NetworkBlocker is implemented by the firewall managers able to drop the traffic sent through the Netbird interface to
+// a set of networks, e.g. the networks of a threat feed
+type NetworkBlocker interface {
+	// SetBlockedNetworks replaces the blocked networks with the IPv4 networks, empty networks remove the blocking
+	SetBlockedNetworks(networks []netip.Prefix) error
+}
+
 func GenKey(format string, input string) string {
 	return fmt.Sprintf(format, input)
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"net/netip"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -29,10 +30,12 @@ const (
 	chainNameInputFilter   = "netbird-acl-input-filter"
 	chainNameOutputFilter  = "netbird-acl-output-filter"
 	chainNameForwardFilter = "netbird-acl-forward-filter"
-
-	chainNameRoutingNat2 = "netbird-rt-nat"
+	chainNamePrerouting    = "netbird-acl-prerouting-filter"
 
 	allowNetbirdInputRuleID = "allow Netbird incoming traffic"
+
+	// activitySetName is the set of the peers with recent packets on the interface
+	activitySetName = "nbactive"
 )
 
 var (
@@ -54,6 +57,16 @@ type AclManager struct {
 
 	ipsetStore *ipsetStore
 	rules      map[string]*Rule
+	// ruleSeq orders the rules by creation to insert them again in the same order on fw4 reloads
+	ruleSeq uint64
+	// defaultAllowRules is set when the userspace firewall allowed the netbird interface traffic
+	defaultAllowRules bool
+	// activitySet holds the peers with recent packets, nil unless the activity tracking is enabled
+	activitySet     *nftables.Set
+	activityTimeout time.Duration
+	// blockedSet holds the networks the outgoing traffic is dropped to, nil without blocked networks
+	blockedSet      *nftables.Set
+	blockedElements []nftables.SetElement
 }
 
 // iFaceMapper defines subset methods of interface required for manager
@@ -273,6 +286,7 @@ func (m *AclManager) createDefaultAllowRules() error {
 		log.Debugf("failed to create default allow rules: %s", err)
 		return err
 	}
+	m.defaultAllowRules = true
 	return nil
 }
 
@@ -299,14 +313,85 @@ func (m *AclManager) Flush() error {
 	return nil
 }
 
+// reapply creates the chains, sets and rules again after a fw4 reload flushed them.
+// The rules are inserted in their creation order so they end up in the same positions
+func (m *AclManager) reapply() error {
+	names := []string{
+		chainNameInputRules, chainNameOutputRules,
+		chainNameInputFilter, chainNameOutputFilter, chainNameForwardFilter, chainNamePrerouting,
+	}
+	for _, name := range names {
+		m.rConn.FlushChain(m.createChain(name))
+	}
+	if err := m.rConn.Flush(); err != nil {
+		return fmt.Errorf("flush chains: %w", err)
+	}
+
+	if err := m.createDefaultChains(); err != nil {
+		return fmt.Errorf("create default chains: %w", err)
+	}
+
+	if m.defaultAllowRules {
+		if err := m.createDefaultAllowRules(); err != nil {
+			return fmt.Errorf("create default allow rules: %w", err)
+		}
+	}
+
+	if m.blockedSet != nil {
+		m.blockedSet = nil
+		if err := m.addBlockedNetworks(); err != nil {
+			return fmt.Errorf("add blocked networks: %w", err)
+		}
+	}
+
+	if m.activitySet != nil {
+		if err := m.addActivityTracking(); err != nil {
+			return fmt.Errorf("add activity tracking: %w", err)
+		}
+	}
+
+	rules := make([]*Rule, 0, len(m.rules))
+	sets := make(map[string]*nftables.Set)
+	for _, rule := range m.rules {
+		rules = append(rules, rule)
+		if rule.nftSet != nil {
+			sets[rule.nftSet.Name] = rule.nftSet
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].seq < rules[j].seq
+	})
+
+	for name, set := range sets {
+		ips, _ := m.ipsetStore.ips(name)
+		elements := make([]nftables.SetElement, 0, len(ips))
+		for ip := range ips {
+			elements = append(elements, nftables.SetElement{Key: net.ParseIP(ip).To4()})
+		}
+		if err := m.rConn.AddSet(set, elements); err != nil {
+			return fmt.Errorf("create set %s: %w", name, err)
+		}
+	}
+
+	for _, rule := range rules {
+		rule.nftRule.Handle = 0
+		rule.nftRule.Position = 0
+		rule.nftRule.Flags = 0
+		m.rConn.InsertRule(rule.nftRule)
+	}
+
+	return m.Flush()
+}
+
 func (m *AclManager) addIOFiltering(ip net.IP, proto firewall.Protocol, sPort *firewall.Port, dPort *firewall.Port, direction firewall.RuleDirection, action firewall.Action, ipset *nftables.Set, comment string) (*Rule, error) {
 	ruleId := generateRuleId(ip, sPort, dPort, direction, action, ipset)
 	if r, ok := m.rules[ruleId]; ok {
 		return &Rule{
-			r.nftRule,
-			r.nftSet,
-			r.ruleID,
-			ip,
+			nftRule: r.nftRule,
+			nftSet:  r.nftSet,
+			ruleID:  r.ruleID,
+			ip:      ip,
+			seq:     r.seq,
 		}, nil
 	}
 
@@ -419,6 +504,9 @@ func (m *AclManager) addIOFiltering(ip net.IP, proto firewall.Protocol, sPort *f
 		)
 	}
 
+	// counter of the traffic matched by the rule, read by ReadCounters
+	expressions = append(expressions, &expr.Counter{})
+
 	switch action {
 	case firewall.ActionAccept:
 		expressions = append(expressions, &expr.Verdict{Kind: expr.VerdictAccept})
@@ -442,11 +530,13 @@ func (m *AclManager) addIOFiltering(ip net.IP, proto firewall.Protocol, sPort *f
 		UserData: userData,
 	})
 
+	m.ruleSeq++
 	rule := &Rule{
 		nftRule: nftRule,
 		nftSet:  ipset,
 		ruleID:  ruleId,
 		ip:      ip,
+		seq:     m.ruleSeq,
 	}
 	m.rules[ruleId] = rule
 	if ipset != nil {
@@ -471,10 +561,11 @@ func (m *AclManager) addPreroutingFiltering(ipset *nftables.Set, proto firewall.
 	ruleId := generateRuleIdForMangle(ipset, ip, proto, port)
 	if r, ok := m.rules[ruleId]; ok {
 		return &Rule{
-			r.nftRule,
-			r.nftSet,
-			r.ruleID,
-			ip,
+			nftRule: r.nftRule,
+			nftSet:  r.nftSet,
+			ruleID:  r.ruleID,
+			ip:      ip,
+			seq:     r.seq,
 		}, nil
 	}
 
@@ -567,11 +658,13 @@ func (m *AclManager) addPreroutingFiltering(ipset *nftables.Set, proto firewall.
 		return nil, fmt.Errorf("flush insert rule: %v", err)
 	}
 
+	m.ruleSeq++
 	rule := &Rule{
 		nftRule: nftRule,
 		nftSet:  ipset,
 		ruleID:  ruleId,
 		ip:      ip,
+		seq:     m.ruleSeq,
 	}
 
 	m.rules[ruleId] = rule
@@ -600,8 +693,9 @@ func (m *AclManager) createDefaultChains() (err error) {
 	}
 	m.chainOutputRules = chain
 
-	// netbird-rt-nat
-	chain = m.createNatChainWithHook(chainNameRoutingNat2, nftables.ChainHookPostrouting)
+	// netbird-rt-nat, created by the router
+	// the traffic of the NetBird network is masqueraded regardless of the masquerade flag of the routes
+	chain = &nftables.Chain{Name: chainNameRoutingNat, Table: m.workTable}
 	m.addSrcMasqueradeRule(chain)
 	m.addDstMasqueradeRule(chain)
 
@@ -740,6 +834,11 @@ func (m *AclManager) createChain(name string) *nftables.Chain {
 }
 
 func (m *AclManager) createFilterChainWithHook(name string, hookNum nftables.ChainHook) *nftables.Chain {
+	// the fw4 chains jump to the filter chains, see fw4IncludeFiles
+	if isFw4Table(m.workTable) {
+		return m.createChain(name)
+	}
+
 	polAccept := nftables.ChainPolicyAccept
 	chain := &nftables.Chain{
 		Name:     name,
@@ -753,32 +852,22 @@ func (m *AclManager) createFilterChainWithHook(name string, hookNum nftables.Cha
 	return m.rConn.AddChain(chain)
 }
 
-func (m *AclManager) createNatChainWithHook(name string, hookNum nftables.ChainHook) *nftables.Chain {
-	// polAccept := nftables.ChainPolicyAccept
-	chain := &nftables.Chain{
-		Name:     name,
-		Table:    m.workTable,
-		Hooknum:  hookNum,
-		Priority: nftables.ChainPriorityNATSource - 1,
-		Type:     nftables.ChainTypeNAT,
-	}
-
-	return m.rConn.AddChain(chain)
-}
-
 func (m *AclManager) createPreroutingMangle() *nftables.Chain {
-	polAccept := nftables.ChainPolicyAccept
-	chain := &nftables.Chain{
-		Name:     "netbird-acl-prerouting-filter",
-		Table:    m.workTable,
-		Hooknum:  nftables.ChainHookPrerouting,
-		Priority: nftables.ChainPriorityMangle,
-		Type:     nftables.ChainTypeFilter,
-		Policy:   &polAccept,
+	var chain *nftables.Chain
+	if isFw4Table(m.workTable) {
+		chain = m.createChain(chainNamePrerouting)
+	} else {
+		polAccept := nftables.ChainPolicyAccept
+		chain = m.rConn.AddChain(&nftables.Chain{
+			Name:     chainNamePrerouting,
+			Table:    m.workTable,
+			Hooknum:  nftables.ChainHookPrerouting,
+			Priority: nftables.ChainPriorityMangle,
+			Type:     nftables.ChainTypeFilter,
+			Policy:   &polAccept,
+		})
 	}
 
-	chain = m.rConn.AddChain(chain)
-
 	ip, _ := netip.AddrFromSlice(m.wgIface.Address().Network.IP.To4())
 	expressions := []expr.Any{
 		&expr.Meta{Key: expr.MetaKeyIIFNAME, Register: 1},
@@ -1002,13 +1091,22 @@ func (m *AclManager) addFwdAllow(chain *nftables.Chain, iifname expr.MetaKey) {
 }
 
 func (m *AclManager) addSrcMasqueradeRule(chain *nftables.Chain) {
+	m.addMasqueradeRule(chain, 12)
+}
+
+func (m *AclManager) addDstMasqueradeRule(chain *nftables.Chain) {
+	m.addMasqueradeRule(chain, 16)
+}
+
+// addMasqueradeRule masquerades the packets whose address at the given offset of the IP header is in the NetBird network
+func (m *AclManager) addMasqueradeRule(chain *nftables.Chain, offset uint32) {
 	ip, _ := netip.AddrFromSlice(m.wgIface.Address().Network.IP.To4())
 
 	expressions := []expr.Any{
 		&expr.Payload{
 			DestRegister: 2,
 			Base:         expr.PayloadBaseNetworkHeader,
-			Offset:       12,
+			Offset:       offset,
 			Len:          4,
 		},
 		&expr.Bitwise{
@@ -1021,7 +1119,7 @@ func (m *AclManager) addSrcMasqueradeRule(chain *nftables.Chain) {
 		&expr.Cmp{
 			Op:       expr.CmpOpEq,
 			Register: 2,
-			Data:     ip.AsSlice(),
+			Data:     ip.Unmap().AsSlice(),
 		},
 
 		&expr.Masq{},
@@ -1034,32 +1132,13 @@ func (m *AclManager) addSrcMasqueradeRule(chain *nftables.Chain) {
 	})
 }
 
-func (m *AclManager) addDstMasqueradeRule(chain *nftables.Chain) {
-	ip, _ := netip.AddrFromSlice(m.wgIface.Address().Network.IP.To4())
-
-	expressions := []expr.Any{
-		&expr.Payload{
-			DestRegister: 2,
-			Base:         expr.PayloadBaseNetworkHeader,
-			Offset:       16,
-			Len:          4,
-		},
-		&expr.Bitwise{
-			SourceRegister: 2,
-			DestRegister:   2,
-			Len:            4,
-			Xor:            []byte{0x0, 0x0, 0x0, 0x0},
-			Mask:           m.wgIface.Address().Network.Mask,
-		},
-		&expr.Cmp{
-			Op:       expr.CmpOpEq,
-			Register: 2,
-			Data:     ip.AsSlice(),
+func (m *AclManager) addJumpRule(chain *nftables.Chain, to string, ifaceKey expr.MetaKey) {
+	expressions := append(m.interfaceTrafficExprs(ifaceKey),
+		&expr.Verdict{
+			Kind:  expr.VerdictJump,
+			Chain: to,
 		},
-
-		&expr.Masq{},
-	}
-
+	)
 	_ = m.rConn.AddRule(&nftables.Rule{
 		Table: chain.Table,
 		Chain: chain,
@@ -1067,9 +1146,10 @@ func (m *AclManager) addDstMasqueradeRule(chain *nftables.Chain) {
 	})
 }
 
-func (m *AclManager) addJumpRule(chain *nftables.Chain, to string, ifaceKey expr.MetaKey) {
+// interfaceTrafficExprs matches the packets on the interface with both addresses in the Netbird network
+func (m *AclManager) interfaceTrafficExprs(ifaceKey expr.MetaKey) []expr.Any {
 	ip, _ := netip.AddrFromSlice(m.wgIface.Address().Network.IP.To4())
-	expressions := []expr.Any{
+	return []expr.Any{
 		&expr.Meta{Key: ifaceKey, Register: 1},
 		&expr.Cmp{
 			Op:       expr.CmpOpEq,
@@ -1112,16 +1192,84 @@ func (m *AclManager) addJumpRule(chain *nftables.Chain, to string, ifaceKey expr
 			Register: 2,
 			Data:     ip.Unmap().AsSlice(),
 		},
-		&expr.Verdict{
-			Kind:  expr.VerdictJump,
-			Chain: to,
-		},
 	}
-	_ = m.rConn.AddRule(&nftables.Rule{
-		Table: chain.Table,
-		Chain: chain,
-		Exprs: expressions,
-	})
+}
+
+// EnableActivityTracking adds the rules updating the set of the active peers with the remote address of the packets
+// on the interface. They come first in the filter chains so the packets dropped by the ACL rules are tracked too.
+// The kernel removes the peers from the set after idleTimeout without packets
+func (m *AclManager) EnableActivityTracking(idleTimeout time.Duration) error {
+	m.activityTimeout = idleTimeout
+	return m.addActivityTracking()
+}
+
+func (m *AclManager) addActivityTracking() error {
+	set := &nftables.Set{
+		Name:       activitySetName,
+		Table:      m.workTable,
+		KeyType:    nftables.TypeIPAddr,
+		Dynamic:    true,
+		HasTimeout: true,
+		Timeout:    m.activityTimeout,
+	}
+	if err := m.rConn.AddSet(set, nil); err != nil {
+		return fmt.Errorf("create set: %w", err)
+	}
+
+	trackers := []struct {
+		chain    string
+		ifaceKey expr.MetaKey
+		// addrOffset is the offset of the remote address in the IPv4 header
+		addrOffset uint32
+	}{
+		{chain: chainNameInputFilter, ifaceKey: expr.MetaKeyIIFNAME, addrOffset: 12},
+		{chain: chainNameOutputFilter, ifaceKey: expr.MetaKeyOIFNAME, addrOffset: 16},
+	}
+	for _, t := range trackers {
+		expressions := append(m.interfaceTrafficExprs(t.ifaceKey),
+			&expr.Payload{
+				DestRegister: 1,
+				Base:         expr.PayloadBaseNetworkHeader,
+				Offset:       t.addrOffset,
+				Len:          4,
+			},
+			&expr.Dynset{
+				SrcRegKey: 1,
+				SetName:   set.Name,
+				SetID:     set.ID,
+				Operation: unix.NFT_DYNSET_OP_UPDATE,
+			},
+		)
+		m.rConn.InsertRule(&nftables.Rule{
+			Table: m.workTable,
+			Chain: &nftables.Chain{Name: t.chain, Table: m.workTable},
+			Exprs: expressions,
+		})
+	}
+
+	if err := m.rConn.Flush(); err != nil {
+		return fmt.Errorf("add activity tracking rules: %w", err)
+	}
+	m.activitySet = set
+	return nil
+}
+
+// ActivePeers returns the IPs of the peers in the set of the active peers
+func (m *AclManager) ActivePeers() ([]net.IP, error) {
+	if m.activitySet == nil {
+		return nil, nil
+	}
+
+	elements, err := m.sConn.GetSetElements(m.activitySet)
+	if err != nil {
+		return nil, fmt.Errorf("get elements of set %s: %w", m.activitySet.Name, err)
+	}
+
+	ips := make([]net.IP, 0, len(elements))
+	for _, element := range elements {
+		ips = append(ips, net.IP(element.Key).To16())
+	}
+	return ips, nil
 }
 
 func (m *AclManager) addIpToSet(ipsetName string, ip net.IP) (*nftables.Set, error) {
@@ -1172,6 +1320,31 @@ func (m *AclManager) createSet(table *nftables.Table, name string) (*nftables.Se
 	return ipset, nil
 }
 
+// ReadCounters returns the counters of the filtering rules summed per group
+func (m *AclManager) ReadCounters(groups map[string][]firewall.Rule) (map[string]firewall.Counters, error) {
+	counters := make(map[string]firewall.Counters)
+	for _, chain := range []*nftables.Chain{m.chainInputRules, m.chainOutputRules} {
+		rules, err := m.rConn.GetRules(m.workTable, chain)
+		if err != nil {
+			return nil, fmt.Errorf("get rules of chain %s: %w", chain.Name, err)
+		}
+
+		for _, rule := range rules {
+			if len(rule.UserData) == 0 {
+				continue
+			}
+			ruleID := string(bytes.Split(rule.UserData, []byte(" "))[0])
+			for _, e := range rule.Exprs {
+				if counter, ok := e.(*expr.Counter); ok {
+					counters[ruleID] = firewall.Counters{Packets: counter.Packets, Bytes: counter.Bytes}
+				}
+			}
+		}
+	}
+
+	return firewall.SumCounters(groups, counters, firewall.Rule.GetRuleID), nil
+}
+
 func (m *AclManager) flushWithBackoff() (err error) {
 	backoff := 4
 	backoffTime := 1000 * time.Millisecond
@@ -0,0 +1,214 @@
+package nftables
+
+import (
+	"bytes"
+	"fmt"
+	"net/netip"
+	"slices"
+
+	"github.com/google/nftables"
+	"github.com/google/nftables/expr"
+)
+
+const (
+	// blockedSetName is the interval set of the networks the traffic leaving through the interface is dropped to
+	blockedSetName = "nbblocked"
+	// blockedRuleID tags the rules dropping the traffic to the blocked networks
+	blockedRuleID = "drop the traffic to the blocked networks"
+	// blockedElementsBatch is the number of set elements sent in one netlink message
+	blockedElementsBatch = 1000
+)
+
+// SetBlockedNetworks replaces the networks the traffic leaving through the interface is dropped to
+func (m *Manager) SetBlockedNetworks(networks []netip.Prefix) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.aclManager.SetBlockedNetworks(networks)
+}
+
+// SetBlockedNetworks replaces the elements of the blocked set in one transaction. The set and the drop rules in the
+// output and forward filter chains are created with the first networks and removed with the last ones
+func (m *AclManager) SetBlockedNetworks(networks []netip.Prefix) error {
+	elements := blockedSetElements(networks)
+	if len(elements) == 0 {
+		return m.removeBlockedNetworks()
+	}
+
+	if m.blockedSet == nil {
+		m.blockedElements = elements
+		if err := m.addBlockedNetworks(); err != nil {
+			m.blockedElements = nil
+			return err
+		}
+		return nil
+	}
+
+	m.rConn.FlushSet(m.blockedSet)
+	if err := m.addBlockedElements(m.blockedSet, elements); err != nil {
+		return err
+	}
+	if err := m.rConn.Flush(); err != nil {
+		return fmt.Errorf("update set %s: %w", blockedSetName, err)
+	}
+	m.blockedElements = elements
+	return nil
+}
+
+// addBlockedNetworks creates the blocked set with the current elements and inserts the drop rules referencing it
+func (m *AclManager) addBlockedNetworks() error {
+	set := &nftables.Set{
+		Name:     blockedSetName,
+		Table:    m.workTable,
+		KeyType:  nftables.TypeIPAddr,
+		Interval: true,
+	}
+	if err := m.rConn.AddSet(set, nil); err != nil {
+		return fmt.Errorf("create set: %w", err)
+	}
+	if err := m.addBlockedElements(set, m.blockedElements); err != nil {
+		return err
+	}
+
+	for _, chain := range []string{chainNameOutputFilter, chainNameForwardFilter} {
+		m.rConn.InsertRule(&nftables.Rule{
+			Table: m.workTable,
+			Chain: &nftables.Chain{Name: chain, Table: m.workTable},
+			Exprs: []expr.Any{
+				&expr.Meta{Key: expr.MetaKeyOIFNAME, Register: 1},
+				&expr.Cmp{
+					Op:       expr.CmpOpEq,
+					Register: 1,
+					Data:     ifname(m.wgIface.Name()),
+				},
+				&expr.Payload{
+					DestRegister: 1,
+					Base:         expr.PayloadBaseNetworkHeader,
+					Offset:       16,
+					Len:          4,
+				},
+				&expr.Lookup{
+					SourceRegister: 1,
+					SetName:        set.Name,
+					SetID:          set.ID,
+				},
+				&expr.Counter{},
+				&expr.Verdict{Kind: expr.VerdictDrop},
+			},
+			UserData: []byte(blockedRuleID),
+		})
+	}
+
+	if err := m.rConn.Flush(); err != nil {
+		return fmt.Errorf("add blocked networks rules: %w", err)
+	}
+	m.blockedSet = set
+	return nil
+}
+
+func (m *AclManager) addBlockedElements(set *nftables.Set, elements []nftables.SetElement) error {
+	for start := 0; start < len(elements); start += blockedElementsBatch {
+		end := min(start+blockedElementsBatch, len(elements))
+		if err := m.rConn.SetAddElements(set, elements[start:end]); err != nil {
+			return fmt.Errorf("add elements to set %s: %w", blockedSetName, err)
+		}
+	}
+	return nil
+}
+
+// removeBlockedNetworks deletes the drop rules and the blocked set
+func (m *AclManager) removeBlockedNetworks() error {
+	if m.blockedSet == nil {
+		return nil
+	}
+
+	for _, name := range []string{chainNameOutputFilter, chainNameForwardFilter} {
+		chain := &nftables.Chain{Name: name, Table: m.workTable}
+		rules, err := m.rConn.GetRules(m.workTable, chain)
+		if err != nil {
+			return fmt.Errorf("get rules of chain %s: %w", name, err)
+		}
+		for _, rule := range rules {
+			if bytes.Equal(rule.UserData, []byte(blockedRuleID)) {
+				if err := m.rConn.DelRule(rule); err != nil {
+					return fmt.Errorf("delete rule: %w", err)
+				}
+			}
+		}
+	}
+	m.rConn.DelSet(m.blockedSet)
+
+	if err := m.rConn.Flush(); err != nil {
+		return fmt.Errorf("remove blocked networks: %w", err)
+	}
+	m.blockedSet = nil
+	m.blockedElements = nil
+	return nil
+}
+
+// blockedSetElements converts the IPv4 networks into the intervals of the set, merging the overlapping and adjacent
+// ones. An interval ends at the address following the network, and reaches the end of the address space without it
+func blockedSetElements(networks []netip.Prefix) []nftables.SetElement {
+	type interval struct {
+		first netip.Addr
+		// next is the address following the interval, invalid at the end of the address space
+		next netip.Addr
+	}
+
+	var intervals []interval
+	for _, network := range sortedIPv4Networks(networks) {
+		first := network.Masked().Addr()
+		next := lastAddr(network).Next()
+		if n := len(intervals); n > 0 {
+			last := &intervals[n-1]
+			if !last.next.IsValid() {
+				break
+			}
+			if first.Compare(last.next) <= 0 {
+				if !next.IsValid() || next.Compare(last.next) > 0 {
+					last.next = next
+				}
+				continue
+			}
+		}
+		intervals = append(intervals, interval{first: first, next: next})
+	}
+
+	elements := make([]nftables.SetElement, 0, 2*len(intervals))
+	for _, i := range intervals {
+		elements = append(elements, nftables.SetElement{Key: i.first.AsSlice()})
+		if i.next.IsValid() {
+			elements = append(elements, nftables.SetElement{Key: i.next.AsSlice(), IntervalEnd: true})
+		}
+	}
+	return elements
+}
+
+func sortedIPv4Networks(networks []netip.Prefix) []netip.Prefix {
+	sorted := make([]netip.Prefix, 0, len(networks))
+	for _, network := range networks {
+		if network.IsValid() && network.Addr().Is4() {
+			sorted = append(sorted, network.Masked())
+		}
+	}
+	slices.SortFunc(sorted, func(a, b netip.Prefix) int {
+		return a.Addr().Compare(b.Addr())
+	})
+	return sorted
+}
+
+// lastAddr returns the last address of the network
+func lastAddr(network netip.Prefix) netip.Addr {
+	addr := network.Masked().Addr().As4()
+	bits := network.Bits()
+	for i := range addr {
+		switch {
+		case bits >= 8:
+			bits -= 8
+		default:
+			addr[i] |= byte(0xff >> bits)
+			bits = 0
+		}
+	}
+	return netip.AddrFrom4(addr)
+}
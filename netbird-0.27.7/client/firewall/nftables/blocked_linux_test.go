@@ -0,0 +1,32 @@
+package nftables
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/google/nftables"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockedSetElements(t *testing.T) {
+	elements := blockedSetElements([]netip.Prefix{
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("10.1.0.0/16"),
+		netip.MustParsePrefix("11.0.0.0/8"),
+		netip.MustParsePrefix("198.51.100.10/32"),
+		netip.MustParsePrefix("2001:db8::/32"),
+		netip.MustParsePrefix("255.255.255.0/24"),
+	})
+	require.Equal(t, []nftables.SetElement{
+		{Key: []byte{10, 0, 0, 0}},
+		{Key: []byte{12, 0, 0, 0}, IntervalEnd: true},
+		{Key: []byte{192, 0, 2, 0}},
+		{Key: []byte{192, 0, 3, 0}, IntervalEnd: true},
+		{Key: []byte{198, 51, 100, 10}},
+		{Key: []byte{198, 51, 100, 11}, IntervalEnd: true},
+		{Key: []byte{255, 255, 255, 0}},
+	}, elements, "the contained and adjacent networks should be merged and the last interval left open")
+
+	require.Empty(t, blockedSetElements([]netip.Prefix{netip.MustParsePrefix("2001:db8::/32")}))
+}
@@ -0,0 +1,415 @@
+package nftables
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/nftables"
+	"github.com/google/nftables/expr"
+	"github.com/hashicorp/go-multierror"
+	"github.com/mdlayher/netlink"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// fw4TableName is the table of the OpenWrt firewall
+	fw4TableName = "fw4"
+	// fw4Binary is the OpenWrt firewall, it is only present on systems managed by fw4
+	fw4Binary = "/sbin/fw4"
+	// fw4IncludeName is the name of the include files of the netbird chains
+	fw4IncludeName = "netbird.nft"
+	// fw4ReloadDelay is how long the nftables events are collected before checking for a fw4 reload
+	fw4ReloadDelay = 500 * time.Millisecond
+)
+
+// fw4IncludeDir is where fw4 reads the nftables snippets included in its ruleset
+var fw4IncludeDir = "/usr/share/nftables.d"
+
+// fw4Jump is a jump from a fw4 chain to a netbird chain
+type fw4Jump struct {
+	fw4Chain string
+	chain    string
+}
+
+// fw4Jumps are the fw4 chains that jump to the netbird chains. The netbird rules match IPv4 packets only
+var fw4Jumps = []fw4Jump{
+	{fw4Chain: "input", chain: chainNameInputFilter},
+	{fw4Chain: "forward", chain: chainNameForwardFilter},
+	{fw4Chain: "output", chain: chainNameOutputFilter},
+	{fw4Chain: "mangle_prerouting", chain: chainNamePrerouting},
+	{fw4Chain: "srcnat", chain: chainNameRoutingNat},
+}
+
+// ipsetNameRegexp matches the names of the sets created for the ACL rules
+var ipsetNameRegexp = regexp.MustCompile(`^nb\d{7}$`)
+
+// Fw4Available returns true if the system firewall is OpenWrt fw4 and its table is loaded
+func Fw4Available() bool {
+	if _, err := os.Stat(fw4Binary); err != nil {
+		return false
+	}
+
+	conn := &nftables.Conn{}
+	tables, err := conn.ListTablesOfFamily(nftables.TableFamilyINet)
+	if err != nil {
+		return false
+	}
+	for _, t := range tables {
+		if t.Name == fw4TableName {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateFw4 creates an nftables firewall manager that installs its chains into the fw4 table of OpenWrt rather than
+// a table of its own. The fw4 ruleset includes the jumps to the netbird chains, and the rules flushed by a fw4 reload
+// are applied again
+func CreateFw4(ctx context.Context, wgIface iFaceMapper) (*Manager, error) {
+	m := &Manager{
+		rConn:     &nftables.Conn{},
+		wgIface:   wgIface,
+		workTable: &nftables.Table{Name: fw4TableName, Family: nftables.TableFamilyINet},
+		fw4:       true,
+	}
+
+	if err := m.cleanUpFw4(); err != nil {
+		return nil, fmt.Errorf("clean up fw4 chains: %w", err)
+	}
+
+	if err := writeFw4Includes(fw4IncludeDir); err != nil {
+		return nil, fmt.Errorf("write fw4 include files: %w", err)
+	}
+
+	var err error
+	m.router, err = newRouter(ctx, m.workTable)
+	if err != nil {
+		return nil, err
+	}
+
+	m.aclManager, err = newAclManager(m.workTable, wgIface, m.router.RouteingFwChainName())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.addFw4Jumps(); err != nil {
+		return nil, fmt.Errorf("add fw4 jump rules: %w", err)
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	m.stopFw4Watch = cancel
+	if err := m.watchFw4Reloads(watchCtx); err != nil {
+		log.Warnf("failed to watch fw4 reloads, the netbird rules won't be applied again after a reload: %v", err)
+	}
+
+	return m, nil
+}
+
+// isFw4Table returns true for the fw4 table, its netbird chains are regular chains reached from the fw4 chains
+func isFw4Table(table *nftables.Table) bool {
+	return table.Family == nftables.TableFamilyINet && table.Name == fw4TableName
+}
+
+// fw4IncludeFiles returns the include files by their path relative to the fw4 include directory.
+// The netbird chains are declared at the top of the fw4 table so the jumps of the fw4 chains resolve
+// when fw4 loads its ruleset before the netbird rules are applied again
+func fw4IncludeFiles() map[string]string {
+	chains := []string{chainNameInputRules, chainNameOutputRules, chainNameRouteingFw}
+	for _, jump := range fw4Jumps {
+		chains = append(chains, jump.chain)
+	}
+
+	var declarations strings.Builder
+	declarations.WriteString("# Generated by NetBird, do not edit\n")
+	for _, chain := range chains {
+		fmt.Fprintf(&declarations, "chain %s {\n}\n", chain)
+	}
+
+	files := map[string]string{
+		filepath.Join("table-pre", fw4IncludeName): declarations.String(),
+	}
+	for _, jump := range fw4Jumps {
+		files[filepath.Join("chain-pre", jump.fw4Chain, fw4IncludeName)] = fmt.Sprintf(
+			"# Generated by NetBird, do not edit\nmeta nfproto ipv4 jump %s\n", jump.chain)
+	}
+	return files
+}
+
+func writeFw4Includes(dir string) error {
+	for path, content := range fw4IncludeFiles() {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("create directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func removeFw4Includes(dir string) error {
+	var merr *multierror.Error
+	for path := range fw4IncludeFiles() {
+		path = filepath.Join(dir, path)
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			merr = multierror.Append(merr, fmt.Errorf("remove %s: %w", path, err))
+		}
+	}
+	return merr.ErrorOrNil()
+}
+
+// addFw4Jumps adds the jumps of the fw4 chains that the loaded fw4 ruleset doesn't have yet
+func (m *Manager) addFw4Jumps() error {
+	for _, jump := range fw4Jumps {
+		chain := &nftables.Chain{Name: jump.fw4Chain, Table: m.workTable}
+		rules, err := m.rConn.GetRules(m.workTable, chain)
+		if err != nil {
+			return fmt.Errorf("get rules of chain %s: %w", jump.fw4Chain, err)
+		}
+
+		target := jump.chain
+		if slices.ContainsFunc(rules, func(rule *nftables.Rule) bool { return fw4JumpTarget(rule) == target }) {
+			continue
+		}
+
+		m.rConn.InsertRule(&nftables.Rule{
+			Table: m.workTable,
+			Chain: chain,
+			Exprs: []expr.Any{
+				&expr.Meta{Key: expr.MetaKeyNFPROTO, Register: 1},
+				&expr.Cmp{
+					Op:       expr.CmpOpEq,
+					Register: 1,
+					Data:     []byte{unix.NFPROTO_IPV4},
+				},
+				&expr.Verdict{
+					Kind:  expr.VerdictJump,
+					Chain: jump.chain,
+				},
+			},
+		})
+	}
+	return m.rConn.Flush()
+}
+
+// fw4JumpTarget returns the netbird chain the rule jumps to, or an empty string for the other rules
+func fw4JumpTarget(rule *nftables.Rule) string {
+	for _, e := range rule.Exprs {
+		verdict, ok := e.(*expr.Verdict)
+		if !ok || (verdict.Kind != expr.VerdictJump && verdict.Kind != expr.VerdictGoto) {
+			continue
+		}
+		if strings.HasPrefix(verdict.Chain, "netbird-") {
+			return verdict.Chain
+		}
+	}
+	return ""
+}
+
+// cleanUpFw4 removes the jumps to the netbird chains, the netbird chains and the netbird sets from the fw4 table
+func (m *Manager) cleanUpFw4() error {
+	chains, err := m.rConn.ListChainsOfTableFamily(nftables.TableFamilyINet)
+	if err != nil {
+		return fmt.Errorf("list of chains: %w", err)
+	}
+
+	var owned []*nftables.Chain
+	for _, chain := range chains {
+		if chain.Table.Name != fw4TableName {
+			continue
+		}
+		if strings.HasPrefix(chain.Name, "netbird-") {
+			owned = append(owned, chain)
+			continue
+		}
+		if !slices.ContainsFunc(fw4Jumps, func(jump fw4Jump) bool { return jump.fw4Chain == chain.Name }) {
+			continue
+		}
+
+		rules, err := m.rConn.GetRules(chain.Table, chain)
+		if err != nil {
+			return fmt.Errorf("get rules of chain %s: %w", chain.Name, err)
+		}
+		for _, rule := range rules {
+			if fw4JumpTarget(rule) == "" {
+				continue
+			}
+			if err := m.rConn.DelRule(rule); err != nil {
+				return fmt.Errorf("delete jump rule of chain %s: %w", chain.Name, err)
+			}
+		}
+	}
+
+	for _, chain := range owned {
+		m.rConn.FlushChain(chain)
+	}
+	for _, chain := range owned {
+		m.rConn.DelChain(chain)
+	}
+
+	sets, err := m.rConn.GetSets(m.workTable)
+	if err != nil {
+		return fmt.Errorf("list of sets: %w", err)
+	}
+	for _, set := range sets {
+		if ipsetNameRegexp.MatchString(set.Name) || set.Name == activitySetName || set.Name == blockedSetName {
+			m.rConn.DelSet(set)
+		}
+	}
+
+	return m.rConn.Flush()
+}
+
+// fw4Flushed returns true if a fw4 reload dropped the netbird rules. It returns false while fw4 is stopped
+func (m *Manager) fw4Flushed() (bool, error) {
+	tables, err := m.rConn.ListTablesOfFamily(nftables.TableFamilyINet)
+	if err != nil {
+		return false, fmt.Errorf("list of tables: %w", err)
+	}
+	if !slices.ContainsFunc(tables, isFw4Table) {
+		return false, nil
+	}
+
+	rules, err := m.rConn.GetRules(m.workTable, &nftables.Chain{Name: chainNameInputFilter, Table: m.workTable})
+	if err != nil || len(rules) == 0 {
+		return true, nil
+	}
+	return false, nil
+}
+
+// reapplyFw4 applies the netbird chains, sets and rules again after a fw4 reload
+func (m *Manager) reapplyFw4() error {
+	if err := m.router.reapply(); err != nil {
+		return fmt.Errorf("apply routing rules: %w", err)
+	}
+	if err := m.aclManager.reapply(); err != nil {
+		return fmt.Errorf("apply ACL rules: %w", err)
+	}
+	if err := m.addFw4Jumps(); err != nil {
+		return fmt.Errorf("add fw4 jump rules: %w", err)
+	}
+	return nil
+}
+
+// watchFw4Reloads applies the netbird rules again when a fw4 reload flushed them. Every nftables transaction,
+// including the one loading the fw4 ruleset, is announced with a new generation message on the nftables netlink group
+func (m *Manager) watchFw4Reloads(ctx context.Context) error {
+	conn, err := netlink.Dial(unix.NETLINK_NETFILTER, &netlink.Config{Groups: 1 << (unix.NFNLGRP_NFTABLES - 1)})
+	if err != nil {
+		return fmt.Errorf("dial netfilter netlink: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		if err := conn.Close(); err != nil {
+			log.Debugf("failed to close netfilter netlink connection: %v", err)
+		}
+	}()
+
+	events := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case events <- struct{}{}:
+		default:
+		}
+	}
+
+	newGen := netlink.HeaderType(unix.NFNL_SUBSYS_NFTABLES<<8 | unix.NFT_MSG_NEWGEN)
+	go func() {
+		for {
+			msgs, err := conn.Receive()
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				// the events overflowed the socket buffer, some of them may be a reload
+				if errors.Is(err, unix.ENOBUFS) {
+					notify()
+					continue
+				}
+				log.Errorf("failed to receive nftables events, the netbird rules won't be applied again after a fw4 reload: %v", err)
+				return
+			}
+
+			for _, msg := range msgs {
+				if msg.Header.Type == newGen {
+					notify()
+				}
+			}
+		}
+	}()
+
+	go m.handleFw4Reloads(ctx, events)
+	return nil
+}
+
+func (m *Manager) handleFw4Reloads(ctx context.Context, events <-chan struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-events:
+		}
+
+		// a reload runs several transactions, let them settle before checking the rules
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(fw4ReloadDelay):
+		}
+		select {
+		case <-events:
+		default:
+		}
+
+		m.checkFw4Reload(ctx)
+	}
+}
+
+func (m *Manager) checkFw4Reload(ctx context.Context) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// the manager may have been reset while waiting for the lock
+	if ctx.Err() != nil {
+		return
+	}
+
+	flushed, err := m.fw4Flushed()
+	if err != nil {
+		log.Errorf("failed to check the netbird rules of the fw4 table: %v", err)
+		return
+	}
+	if !flushed {
+		return
+	}
+
+	log.Infof("fw4 reload flushed the netbird rules, applying them again")
+	if err := m.reapplyFw4(); err != nil {
+		log.Errorf("failed to apply the netbird rules after a fw4 reload: %v", err)
+	}
+}
+
+// resetFw4 removes the include files and the netbird chains from the fw4 table
+func (m *Manager) resetFw4() error {
+	if m.stopFw4Watch != nil {
+		m.stopFw4Watch()
+	}
+
+	if err := removeFw4Includes(fw4IncludeDir); err != nil {
+		log.Errorf("failed to remove fw4 include files: %v", err)
+	}
+
+	m.router.ResetForwardRules()
+
+	return m.cleanUpFw4()
+}
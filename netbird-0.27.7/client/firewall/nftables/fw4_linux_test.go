@@ -0,0 +1,38 @@
+package nftables
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFw4Includes(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, writeFw4Includes(dir))
+
+	declarations, err := os.ReadFile(filepath.Join(dir, "table-pre", "netbird.nft"))
+	require.NoError(t, err)
+	for _, chain := range []string{
+		chainNameInputRules, chainNameOutputRules, chainNameInputFilter, chainNameOutputFilter,
+		chainNameForwardFilter, chainNamePrerouting, chainNameRouteingFw, chainNameRoutingNat,
+	} {
+		assert.Contains(t, string(declarations), "chain "+chain+" {\n}\n", "should declare the netbird chains")
+	}
+
+	jump, err := os.ReadFile(filepath.Join(dir, "chain-pre", "input", "netbird.nft"))
+	require.NoError(t, err)
+	assert.Contains(t, string(jump), "meta nfproto ipv4 jump netbird-acl-input-filter\n")
+
+	jump, err = os.ReadFile(filepath.Join(dir, "chain-pre", "srcnat", "netbird.nft"))
+	require.NoError(t, err)
+	assert.Contains(t, string(jump), "meta nfproto ipv4 jump netbird-rt-nat\n")
+
+	require.NoError(t, removeFw4Includes(dir))
+	for path := range fw4IncludeFiles() {
+		assert.NoFileExists(t, filepath.Join(dir, path))
+	}
+	require.NoError(t, removeFw4Includes(dir), "should ignore the files already removed")
+}
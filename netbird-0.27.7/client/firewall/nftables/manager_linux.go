@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"time"
 
 	"github.com/google/nftables"
 	"github.com/google/nftables/expr"
@@ -14,10 +15,14 @@ import (
 	firewall "github.com/netbirdio/netbird/client/firewall/manager"
 )
 
-const (
-	// tableName is the name of the table that is used for filtering by the Netbird client
-	tableName = "netbird"
-)
+// tableName is the name of the table that is used for filtering by the Netbird client
+var tableName = "netbird"
+
+// SetTableName sets the name of the table the managers created afterwards filter in, the client profiles running
+// next to the default client use a table of their own
+func SetTableName(name string) {
+	tableName = name
+}
 
 // Manager of iptables firewall
 type Manager struct {
@@ -25,8 +30,13 @@ type Manager struct {
 	rConn   *nftables.Conn
 	wgIface iFaceMapper
 
+	workTable  *nftables.Table
 	router     *router
 	aclManager *AclManager
+
+	// fw4 is set when the chains are installed into the fw4 table of OpenWrt
+	fw4          bool
+	stopFw4Watch context.CancelFunc
 }
 
 // Create nftables firewall manager
@@ -36,17 +46,18 @@ func Create(context context.Context, wgIface iFaceMapper) (*Manager, error) {
 		wgIface: wgIface,
 	}
 
-	workTable, err := m.createWorkTable()
+	var err error
+	m.workTable, err = m.createWorkTable()
 	if err != nil {
 		return nil, err
 	}
 
-	m.router, err = newRouter(context, workTable)
+	m.router, err = newRouter(context, m.workTable)
 	if err != nil {
 		return nil, err
 	}
 
-	m.aclManager, err = newAclManager(workTable, wgIface, m.router.RouteingFwChainName())
+	m.aclManager, err = newAclManager(m.workTable, wgIface, m.router.RouteingFwChainName())
 	if err != nil {
 		return nil, err
 	}
@@ -162,6 +173,10 @@ func (m *Manager) Reset() error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
+	if m.fw4 {
+		return m.resetFw4()
+	}
+
 	chains, err := m.rConn.ListChains()
 	if err != nil {
 		return fmt.Errorf("list of chains: %w", err)
@@ -200,6 +215,30 @@ func (m *Manager) Reset() error {
 	return m.rConn.Flush()
 }
 
+// ReadCounters returns the counters of the filtering rules summed per group
+func (m *Manager) ReadCounters(groups map[string][]firewall.Rule) (map[string]firewall.Counters, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.aclManager.ReadCounters(groups)
+}
+
+// EnableActivityTracking records the peers with packets on the interface in a set, peers are removed after idleTimeout
+func (m *Manager) EnableActivityTracking(idleTimeout time.Duration) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.aclManager.EnableActivityTracking(idleTimeout)
+}
+
+// ActivePeers returns the IPs of the peers with packets within the idle timeout
+func (m *Manager) ActivePeers() ([]net.IP, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.aclManager.ActivePeers()
+}
+
 // Flush rule/chain/set operations from the buffer
 //
 // Method also get all rules after flush and refreshes handle values in the rulesets
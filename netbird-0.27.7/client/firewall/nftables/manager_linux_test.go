@@ -132,10 +132,15 @@ func TestNftablesManager(t *testing.T) {
 			Register: 1,
 			Data:     []byte{0, 53},
 		},
+		&expr.Counter{},
 		&expr.Verdict{Kind: expr.VerdictDrop},
 	}
 	require.ElementsMatch(t, rules[0].Exprs, expectedExprs, "expected the same expressions")
 
+	counters, err := manager.ReadCounters(map[string][]fw.Rule{"policy": rule})
+	require.NoError(t, err, "failed to read counters")
+	require.Equal(t, map[string]fw.Counters{"policy": {}}, counters, "no traffic should be counted")
+
 	for _, r := range rule {
 		err = manager.DeleteRule(r)
 		require.NoError(t, err, "failed to delete rule")
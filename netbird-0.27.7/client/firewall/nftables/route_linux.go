@@ -118,13 +118,22 @@ func (r *router) createContainers() error {
 		Table: r.workTable,
 	})
 
-	// r.chains[chainNameRoutingNat] = r.conn.AddChain(&nftables.Chain{
-	// 	Name:     chainNameRoutingNat,
-	// 	Table:    r.workTable,
-	// 	Hooknum:  nftables.ChainHookPostrouting,
-	// 	Priority: nftables.ChainPriorityNATSource - 1,
-	// 	Type:     nftables.ChainTypeNAT,
-	// })
+	// source NAT is only applied by the rules of routes with masquerade enabled
+	natChain := &nftables.Chain{
+		Name:     chainNameRoutingNat,
+		Table:    r.workTable,
+		Hooknum:  nftables.ChainHookPostrouting,
+		Priority: nftables.ChainPriorityNATSource - 1,
+		Type:     nftables.ChainTypeNAT,
+	}
+	if isFw4Table(r.workTable) {
+		// the fw4 srcnat chain jumps to the nat chain, see fw4IncludeFiles
+		natChain = &nftables.Chain{
+			Name:  chainNameRoutingNat,
+			Table: r.workTable,
+		}
+	}
+	r.chains[chainNameRoutingNat] = r.conn.AddChain(natChain)
 
 	err := r.refreshRulesMap()
 	if err != nil {
@@ -138,6 +147,27 @@ func (r *router) createContainers() error {
 	return nil
 }
 
+// reapply creates the chains and the routing rules again after a fw4 reload flushed them
+func (r *router) reapply() error {
+	for _, chain := range r.chains {
+		r.conn.AddChain(chain)
+		r.conn.FlushChain(chain)
+	}
+
+	for _, rule := range r.rules {
+		rule.Handle = 0
+		rule.Position = 0
+		rule.Flags = 0
+		r.conn.InsertRule(rule)
+	}
+
+	if err := r.conn.Flush(); err != nil {
+		return fmt.Errorf("nftables: unable to apply routing rules: %v", err)
+	}
+
+	return r.refreshRulesMap()
+}
+
 // InsertRoutingRules inserts a nftable rule pair to the forwarding chain and if enabled, to the nat chain
 func (r *router) InsertRoutingRules(pair manager.RouterPair) error {
 	err := r.refreshRulesMap()
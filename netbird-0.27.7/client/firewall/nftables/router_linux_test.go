@@ -4,6 +4,7 @@ package nftables
 
 import (
 	"context"
+	"net"
 	"testing"
 
 	"github.com/coreos/go-iptables/iptables"
@@ -13,6 +14,7 @@ import (
 
 	firewall "github.com/netbirdio/netbird/client/firewall/manager"
 	"github.com/netbirdio/netbird/client/firewall/test"
+	"github.com/netbirdio/netbird/iface"
 )
 
 const (
@@ -215,6 +217,62 @@ func TestNftablesManager_RemoveRoutingRules(t *testing.T) {
 	}
 }
 
+func TestNftablesManager_RoutingNatRules(t *testing.T) {
+	if check() != NFTABLES {
+		t.Skip("nftables not supported on this OS")
+	}
+
+	mock := &iFaceMock{
+		NameFunc: func() string {
+			return "lo"
+		},
+		AddressFunc: func() iface.WGAddress {
+			return iface.WGAddress{
+				IP: net.ParseIP("100.96.0.1"),
+				Network: &net.IPNet{
+					IP:   net.ParseIP("100.96.0.0"),
+					Mask: net.IPv4Mask(255, 255, 255, 0),
+				},
+			}
+		},
+	}
+
+	for _, testCase := range test.InsertRuleTestCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			manager, err := Create(context.Background(), mock)
+			require.NoError(t, err)
+
+			defer func() {
+				err = manager.Reset()
+				require.NoError(t, err, "failed to reset")
+			}()
+
+			err = manager.InsertRoutingRules(testCase.InputPair)
+			require.NoError(t, err, "failed to insert the routing rules")
+
+			testClient := &nftables.Conn{}
+			rules, err := testClient.GetRules(manager.workTable, manager.router.chains[chainNameRoutingNat])
+			require.NoError(t, err, "failed to get rules")
+
+			var networkRules int
+			ruleKeys := map[string]bool{}
+			for _, rule := range rules {
+				if len(rule.UserData) > 0 {
+					ruleKeys[string(rule.UserData)] = true
+					continue
+				}
+				networkRules++
+			}
+
+			require.Equal(t, 2, networkRules, "the traffic of the NetBird network should always be masqueraded")
+			natRuleKey := firewall.GenKey(firewall.NatFormat, testCase.InputPair.ID)
+			inNatRuleKey := firewall.GenKey(firewall.InNatFormat, firewall.GetInPair(testCase.InputPair).ID)
+			require.Equal(t, testCase.InputPair.Masquerade, ruleKeys[natRuleKey], "the nat rule should only exist with masquerade enabled")
+			require.Equal(t, testCase.InputPair.Masquerade, ruleKeys[inNatRuleKey], "the income nat rule should only exist with masquerade enabled")
+		})
+	}
+}
+
 // check returns the firewall type based on common lib checks. It returns UNKNOWN if no firewall is found.
 func check() int {
 	nf := nftables.Conn{}
@@ -12,6 +12,7 @@ type Rule struct {
 	nftSet  *nftables.Set
 	ruleID  string
 	ip      net.IP
+	seq     uint64
 }
 
 // GetRuleID returns the rule id
@@ -0,0 +1,11 @@
+package firewall
+
+// profile is the name of the client profile the firewall managers are created for, empty for the default profile
+var profile string
+
+// SetProfile makes the firewall managers created afterwards keep the rules of the client profile apart from the
+// ones of the default client, an empty profile being the default client
+func SetProfile(name string) {
+	profile = name
+	setProfileTable(name)
+}
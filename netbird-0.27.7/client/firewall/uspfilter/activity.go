@@ -0,0 +1,66 @@
+package uspfilter
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// activityTracker records the last packet time of the peers
+type activityTracker struct {
+	mutex       sync.Mutex
+	lastSeen    map[string]time.Time
+	idleTimeout time.Duration
+}
+
+func newActivityTracker(idleTimeout time.Duration) *activityTracker {
+	return &activityTracker{
+		lastSeen:    make(map[string]time.Time),
+		idleTimeout: idleTimeout,
+	}
+}
+
+// record updates the last packet time of the peer
+func (t *activityTracker) record(ip net.IP, now time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.lastSeen[ip.String()] = now
+}
+
+// active returns the peers with packets within the idle timeout and forgets the idle ones
+func (t *activityTracker) active(now time.Time) []net.IP {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var ips []net.IP
+	for ip, lastSeen := range t.lastSeen {
+		if now.Sub(lastSeen) > t.idleTimeout {
+			delete(t.lastSeen, ip)
+			continue
+		}
+		ips = append(ips, net.ParseIP(ip))
+	}
+	return ips
+}
+
+// EnableActivityTracking records the peers sending or receiving packets on the interface, the packets are tracked
+// before the rules are matched. Peers without packets for idleTimeout are forgotten
+func (m *Manager) EnableActivityTracking(idleTimeout time.Duration) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.activity = newActivityTracker(idleTimeout)
+	return nil
+}
+
+// ActivePeers returns the IPs of the peers with packets within the idle timeout
+func (m *Manager) ActivePeers() ([]net.IP, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.activity == nil {
+		return nil, nil
+	}
+	return m.activity.active(time.Now()), nil
+}
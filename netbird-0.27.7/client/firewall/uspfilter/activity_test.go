@@ -0,0 +1,25 @@
+package uspfilter
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivityTracker(t *testing.T) {
+	tracker := newActivityTracker(time.Minute)
+	now := time.Now()
+
+	tracker.record(net.ParseIP("100.10.0.1"), now.Add(-2*time.Minute))
+	tracker.record(net.ParseIP("100.10.0.2"), now.Add(-30*time.Second))
+
+	active := tracker.active(now)
+	require.Len(t, active, 1)
+	require.True(t, active[0].Equal(net.ParseIP("100.10.0.2")))
+	require.Len(t, tracker.lastSeen, 1, "idle peers should be forgotten")
+
+	tracker.record(net.ParseIP("100.10.0.1"), now)
+	require.Len(t, tracker.active(now), 2, "peers should be active again after a new packet")
+}
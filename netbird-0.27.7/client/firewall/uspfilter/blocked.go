@@ -0,0 +1,59 @@
+package uspfilter
+
+import (
+	"net"
+	"net/netip"
+	"slices"
+	"sort"
+)
+
+// blockedNetworks are disjoint IPv4 networks sorted by address
+type blockedNetworks []netip.Prefix
+
+// newBlockedNetworks keeps the IPv4 networks and drops the ones contained in another
+func newBlockedNetworks(networks []netip.Prefix) blockedNetworks {
+	sorted := make([]netip.Prefix, 0, len(networks))
+	for _, network := range networks {
+		if network.IsValid() && network.Addr().Is4() {
+			sorted = append(sorted, network.Masked())
+		}
+	}
+	slices.SortFunc(sorted, func(a, b netip.Prefix) int {
+		if c := a.Addr().Compare(b.Addr()); c != 0 {
+			return c
+		}
+		return a.Bits() - b.Bits()
+	})
+
+	var blocked blockedNetworks
+	for _, network := range sorted {
+		if n := len(blocked); n > 0 && blocked[n-1].Contains(network.Addr()) {
+			continue
+		}
+		blocked = append(blocked, network)
+	}
+	return blocked
+}
+
+// contains returns true if the IP is in one of the networks
+func (b blockedNetworks) contains(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip.To4())
+	if !ok || len(b) == 0 {
+		return false
+	}
+
+	// the only candidate is the last network starting at or before the address
+	i := sort.Search(len(b), func(i int) bool {
+		return b[i].Addr().Compare(addr) > 0
+	})
+	return i > 0 && b[i-1].Contains(addr)
+}
+
+// SetBlockedNetworks replaces the networks the outgoing packets are dropped to, before the rules are matched
+func (m *Manager) SetBlockedNetworks(networks []netip.Prefix) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.blocked = newBlockedNetworks(networks)
+	return nil
+}
@@ -0,0 +1,75 @@
+package uspfilter
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/iface"
+)
+
+func TestBlockedNetworks(t *testing.T) {
+	blocked := newBlockedNetworks([]netip.Prefix{
+		netip.MustParsePrefix("198.51.100.10/32"),
+		netip.MustParsePrefix("10.1.0.0/16"),
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	})
+	require.Equal(t, blockedNetworks{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("198.51.100.10/32"),
+	}, blocked, "the contained and IPv6 networks should be dropped")
+
+	for ip, expected := range map[string]bool{
+		"10.200.1.1":      true,
+		"192.0.2.255":     true,
+		"198.51.100.10":   true,
+		"198.51.100.11":   false,
+		"192.0.3.1":       false,
+		"9.255.255.255":   false,
+		"255.255.255.255": false,
+	} {
+		require.Equal(t, expected, blocked.contains(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestManagerSetBlockedNetworks(t *testing.T) {
+	ifaceMock := &IFaceMock{
+		SetFilterFunc: func(iface.PacketFilter) error { return nil },
+	}
+	m, err := Create(ifaceMock)
+	require.NoError(t, err)
+	m.wgNetwork = &net.IPNet{IP: net.ParseIP("100.10.0.0"), Mask: net.CIDRMask(16, 32)}
+
+	packet := func(dst string) []byte {
+		ipv4 := &layers.IPv4{
+			TTL:      64,
+			Version:  4,
+			SrcIP:    net.ParseIP("100.10.0.1"),
+			DstIP:    net.ParseIP(dst),
+			Protocol: layers.IPProtocolUDP,
+		}
+		udp := &layers.UDP{SrcPort: 51334, DstPort: 53}
+		require.NoError(t, udp.SetNetworkLayerForChecksum(ipv4))
+
+		buf := gopacket.NewSerializeBuffer()
+		opts := gopacket.SerializeOptions{ComputeChecksums: true, FixLengths: true}
+		require.NoError(t, gopacket.SerializeLayers(buf, opts, ipv4, udp, gopacket.Payload("query")))
+		return buf.Bytes()
+	}
+
+	require.False(t, m.DropOutgoing(packet("192.0.2.1")), "the routed traffic shouldn't be dropped without blocked networks")
+
+	require.NoError(t, m.SetBlockedNetworks([]netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}))
+	require.True(t, m.DropOutgoing(packet("192.0.2.1")), "the traffic to a blocked network should be dropped")
+	require.False(t, m.DropOutgoing(packet("198.51.100.1")))
+
+	require.NoError(t, m.SetBlockedNetworks(nil))
+	require.False(t, m.DropOutgoing(packet("192.0.2.1")))
+}
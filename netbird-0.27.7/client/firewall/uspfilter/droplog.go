@@ -0,0 +1,133 @@
+package uspfilter
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket/layers"
+
+	firewall "github.com/netbirdio/netbird/client/firewall/manager"
+)
+
+// dropLogSize is the number of the dropped connections kept in the log
+const dropLogSize = 1000
+
+// dropKey identifies a dropped connection, the packets of the same connection update its entry
+type dropKey struct {
+	srcIP    [16]byte
+	srcPort  uint16
+	dstPort  uint16
+	protocol firewall.Protocol
+}
+
+// dropLog is a ring buffer of the latest dropped connections
+type dropLog struct {
+	mutex   sync.Mutex
+	entries []firewall.DroppedConnection
+	keys    []dropKey
+	index   map[dropKey]int
+	// next is the position of the entry replaced by the next new connection once the log is full
+	next int
+	size int
+}
+
+func newDropLog(size int) *dropLog {
+	return &dropLog{
+		index: make(map[dropKey]int),
+		size:  size,
+	}
+}
+
+// record logs a dropped packet, updating the entry of its connection when it is already logged
+func (l *dropLog) record(srcIP net.IP, srcPort, dstPort uint16, protocol firewall.Protocol, ruleID string, now time.Time) {
+	key := dropKey{srcPort: srcPort, dstPort: dstPort, protocol: protocol}
+	copy(key.srcIP[:], srcIP.To16())
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if i, ok := l.index[key]; ok {
+		l.entries[i].LastSeen = now
+		l.entries[i].Packets++
+		l.entries[i].RuleID = ruleID
+		return
+	}
+
+	entry := firewall.DroppedConnection{
+		SrcIP:     append(net.IP(nil), srcIP...),
+		SrcPort:   srcPort,
+		DstPort:   dstPort,
+		Protocol:  protocol,
+		RuleID:    ruleID,
+		FirstSeen: now,
+		LastSeen:  now,
+		Packets:   1,
+	}
+
+	if len(l.entries) < l.size {
+		l.index[key] = len(l.entries)
+		l.entries = append(l.entries, entry)
+		l.keys = append(l.keys, key)
+		return
+	}
+
+	delete(l.index, l.keys[l.next])
+	l.entries[l.next] = entry
+	l.keys[l.next] = key
+	l.index[key] = l.next
+	l.next = (l.next + 1) % l.size
+}
+
+// connections returns a copy of the logged connections, the oldest first
+func (l *dropLog) connections() []firewall.DroppedConnection {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	conns := make([]firewall.DroppedConnection, 0, len(l.entries))
+	conns = append(conns, l.entries[l.next:]...)
+	return append(conns, l.entries[:l.next]...)
+}
+
+// SetDropLogEnabled enables or disables the log of the dropped inbound connections, disabling it clears the log
+func (m *Manager) SetDropLogEnabled(enabled bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !enabled {
+		m.dropLog = nil
+		return
+	}
+	if m.dropLog == nil {
+		m.dropLog = newDropLog(dropLogSize)
+	}
+}
+
+// DroppedConnections returns the logged inbound connections dropped by the filter, the oldest first
+func (m *Manager) DroppedConnections() []firewall.DroppedConnection {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.dropLog == nil {
+		return nil
+	}
+	return m.dropLog.connections()
+}
+
+// logDrop records the dropped incoming packet decoded by d in the drop log
+func (m *Manager) logDrop(srcIP net.IP, d *decoder, ruleID string) {
+	var srcPort, dstPort uint16
+	protocol := firewall.ProtocolUnknown
+	switch d.decoded[1] {
+	case layers.LayerTypeTCP:
+		protocol = firewall.ProtocolTCP
+		srcPort, dstPort = uint16(d.tcp.SrcPort), uint16(d.tcp.DstPort)
+	case layers.LayerTypeUDP:
+		protocol = firewall.ProtocolUDP
+		srcPort, dstPort = uint16(d.udp.SrcPort), uint16(d.udp.DstPort)
+	case layers.LayerTypeICMPv4, layers.LayerTypeICMPv6:
+		protocol = firewall.ProtocolICMP
+	}
+
+	m.dropLog.record(srcIP, srcPort, dstPort, protocol, ruleID, time.Now())
+}
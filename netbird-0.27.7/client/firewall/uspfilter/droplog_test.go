@@ -0,0 +1,103 @@
+package uspfilter
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/require"
+
+	fw "github.com/netbirdio/netbird/client/firewall/manager"
+	"github.com/netbirdio/netbird/iface"
+)
+
+func TestDropLogRing(t *testing.T) {
+	l := newDropLog(2)
+	now := time.Now()
+
+	l.record(net.ParseIP("100.10.0.1"), 1000, 22, fw.ProtocolTCP, "", now)
+	l.record(net.ParseIP("100.10.0.1"), 1000, 22, fw.ProtocolTCP, "", now.Add(time.Second))
+	l.record(net.ParseIP("100.10.0.2"), 1001, 80, fw.ProtocolTCP, "rule", now)
+
+	conns := l.connections()
+	require.Len(t, conns, 2)
+	require.Equal(t, uint64(2), conns[0].Packets, "packets of the same connection should update its entry")
+	require.Equal(t, now.Add(time.Second), conns[0].LastSeen)
+	require.Equal(t, "rule", conns[1].RuleID)
+
+	l.record(net.ParseIP("100.10.0.3"), 1002, 443, fw.ProtocolTCP, "", now)
+
+	conns = l.connections()
+	require.Len(t, conns, 2)
+	require.True(t, conns[0].SrcIP.Equal(net.ParseIP("100.10.0.2")), "oldest connection should be replaced")
+	require.True(t, conns[1].SrcIP.Equal(net.ParseIP("100.10.0.3")))
+
+	l.record(net.ParseIP("100.10.0.1"), 1000, 22, fw.ProtocolTCP, "", now)
+	conns = l.connections()
+	require.True(t, conns[1].SrcIP.Equal(net.ParseIP("100.10.0.1")))
+	require.Equal(t, uint64(1), conns[1].Packets, "replaced connection should be logged again as new")
+}
+
+func TestDropLogIncoming(t *testing.T) {
+	ifaceMock := &IFaceMock{
+		SetFilterFunc: func(iface.PacketFilter) error { return nil },
+	}
+
+	m, err := Create(ifaceMock)
+	require.NoError(t, err)
+	m.wgNetwork = &net.IPNet{
+		IP:   net.ParseIP("100.10.0.0"),
+		Mask: net.CIDRMask(16, 32),
+	}
+
+	rules, err := m.AddFiltering(net.ParseIP("100.10.0.1"), fw.ProtocolTCP, nil, &fw.Port{Values: []int{22}}, fw.RuleDirectionIN, fw.ActionDrop, "", "")
+	require.NoError(t, err)
+
+	packet := func(dstPort layers.TCPPort) []byte {
+		ipv4 := &layers.IPv4{
+			TTL:      64,
+			Version:  4,
+			SrcIP:    net.ParseIP("100.10.0.1"),
+			DstIP:    net.ParseIP("100.10.0.100"),
+			Protocol: layers.IPProtocolTCP,
+		}
+		tcp := &layers.TCP{
+			SrcPort: 51334,
+			DstPort: dstPort,
+			SYN:     true,
+		}
+		require.NoError(t, tcp.SetNetworkLayerForChecksum(ipv4))
+
+		buf := gopacket.NewSerializeBuffer()
+		opts := gopacket.SerializeOptions{
+			ComputeChecksums: true,
+			FixLengths:       true,
+		}
+		require.NoError(t, gopacket.SerializeLayers(buf, opts, ipv4, tcp))
+		return buf.Bytes()
+	}
+
+	require.True(t, m.DropIncoming(packet(22)))
+	require.Empty(t, m.DroppedConnections(), "drops shouldn't be logged while the log is disabled")
+
+	m.SetDropLogEnabled(true)
+	require.True(t, m.DropIncoming(packet(22)))
+	require.True(t, m.DropIncoming(packet(22)))
+	require.True(t, m.DropIncoming(packet(8080)))
+	require.True(t, m.DropOutgoing(packet(22)))
+
+	conns := m.DroppedConnections()
+	require.Len(t, conns, 2)
+	require.Equal(t, uint16(51334), conns[0].SrcPort)
+	require.Equal(t, uint16(22), conns[0].DstPort)
+	require.Equal(t, fw.ProtocolTCP, conns[0].Protocol)
+	require.Equal(t, rules[0].GetRuleID(), conns[0].RuleID)
+	require.Equal(t, uint64(2), conns[0].Packets)
+	require.Equal(t, uint16(8080), conns[1].DstPort)
+	require.Empty(t, conns[1].RuleID, "connection dropped by the default policy shouldn't have a rule")
+
+	m.SetDropLogEnabled(false)
+	require.Empty(t, m.DroppedConnections())
+}
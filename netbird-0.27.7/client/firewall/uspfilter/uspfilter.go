@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"time"
 
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
@@ -37,6 +38,12 @@ type Manager struct {
 	decoders       sync.Pool
 	wgIface        IFaceMapper
 	nativeFirewall firewall.Manager
+	// dropLog logs the dropped incoming connections, nil when disabled
+	dropLog *dropLog
+	// activity tracks the peers with packets on the interface, nil when disabled
+	activity *activityTracker
+	// blocked are the networks the outgoing packets are dropped to
+	blocked blockedNetworks
 
 	mutex sync.RWMutex
 }
@@ -248,6 +255,10 @@ func (m *Manager) dropFilter(packetData []byte, rules map[string]RuleSet, isInco
 
 	ipLayer := d.decoded[0]
 
+	if !isIncomingPacket && ipLayer == layers.LayerTypeIPv4 && m.blocked.contains(d.ip4.DstIP) {
+		return true
+	}
+
 	switch ipLayer {
 	case layers.LayerTypeIPv4:
 		if !m.wgNetwork.Contains(d.ip4.SrcIP) || !m.wgNetwork.Contains(d.ip4.DstIP) {
@@ -278,24 +289,30 @@ func (m *Manager) dropFilter(packetData []byte, rules map[string]RuleSet, isInco
 		}
 	}
 
-	filter, ok := validateRule(ip, packetData, rules[ip.String()], d)
-	if ok {
-		return filter
-	}
-	filter, ok = validateRule(ip, packetData, rules["0.0.0.0"], d)
-	if ok {
-		return filter
-	}
-	filter, ok = validateRule(ip, packetData, rules["::"], d)
-	if ok {
-		return filter
+	if m.activity != nil {
+		m.activity.record(ip, time.Now())
 	}
 
 	// default policy is DROP ALL
-	return true
+	filter, ruleID, hooked := true, "", false
+	for _, key := range []string{ip.String(), "0.0.0.0", "::"} {
+		if id, drop, ok := validateRule(ip, packetData, rules[key], d); ok {
+			filter, ruleID = drop, id
+			// packets consumed by a hook aren't dropped connections
+			hooked = rules[key][id].udpHook != nil
+			break
+		}
+	}
+
+	if filter && !hooked && isIncomingPacket && m.dropLog != nil {
+		m.logDrop(ip, d, ruleID)
+	}
+	return filter
 }
 
-func validateRule(ip net.IP, packetData []byte, rules map[string]Rule, d *decoder) (bool, bool) {
+// validateRule returns the ID of the rule matching the packet and whether the packet should be dropped.
+// The last value is false when no rule matches the packet
+func validateRule(ip net.IP, packetData []byte, rules map[string]Rule, d *decoder) (string, bool, bool) {
 	payloadLayer := d.decoded[1]
 	for _, rule := range rules {
 		if rule.matchByIP && !ip.Equal(rule.ip) {
@@ -303,7 +320,7 @@ func validateRule(ip net.IP, packetData []byte, rules map[string]Rule, d *decode
 		}
 
 		if rule.protoLayer == layerTypeAll {
-			return rule.drop, true
+			return rule.id, rule.drop, true
 		}
 
 		if payloadLayer != rule.protoLayer {
@@ -313,36 +330,36 @@ func validateRule(ip net.IP, packetData []byte, rules map[string]Rule, d *decode
 		switch payloadLayer {
 		case layers.LayerTypeTCP:
 			if rule.sPort == 0 && rule.dPort == 0 {
-				return rule.drop, true
+				return rule.id, rule.drop, true
 			}
 			if rule.sPort != 0 && rule.sPort == uint16(d.tcp.SrcPort) {
-				return rule.drop, true
+				return rule.id, rule.drop, true
 			}
 			if rule.dPort != 0 && rule.dPort == uint16(d.tcp.DstPort) {
-				return rule.drop, true
+				return rule.id, rule.drop, true
 			}
 		case layers.LayerTypeUDP:
 			// if rule has UDP hook (and if we are here we match this rule)
 			// we ignore rule.drop and call this hook
 			if rule.udpHook != nil {
-				return rule.udpHook(packetData), true
+				return rule.id, rule.udpHook(packetData), true
 			}
 
 			if rule.sPort == 0 && rule.dPort == 0 {
-				return rule.drop, true
+				return rule.id, rule.drop, true
 			}
 			if rule.sPort != 0 && rule.sPort == uint16(d.udp.SrcPort) {
-				return rule.drop, true
+				return rule.id, rule.drop, true
 			}
 			if rule.dPort != 0 && rule.dPort == uint16(d.udp.DstPort) {
-				return rule.drop, true
+				return rule.id, rule.drop, true
 			}
-			return rule.drop, true
+			return rule.id, rule.drop, true
 		case layers.LayerTypeICMPv4, layers.LayerTypeICMPv6:
-			return rule.drop, true
+			return rule.id, rule.drop, true
 		}
 	}
-	return false, false
+	return "", false, false
 }
 
 // SetNetwork of the wireguard interface to which filtering applied
@@ -4,7 +4,11 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
+	"maps"
 	"net"
+	"net/netip"
+	"slices"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -19,6 +23,18 @@ import (
 // Manager is a ACL rules manager
 type Manager interface {
 	ApplyFiltering(networkMap *mgmProto.NetworkMap)
+	PolicyCounters() (map[string]firewall.Counters, error)
+	DroppedConnections() []DroppedConnection
+	EnableLazyRules(idleTimeout time.Duration) error
+	RefreshActivePeers()
+}
+
+// DroppedConnection is an inbound connection dropped by the firewall
+type DroppedConnection struct {
+	firewall.DroppedConnection
+	// PolicyIDs are the IDs of the policies of the drop rule the connection matched,
+	// empty when it was dropped because no policy allows it
+	PolicyIDs []string
 }
 
 // DefaultManager uses firewall manager to handle
@@ -26,13 +42,25 @@ type DefaultManager struct {
 	firewall     firewall.Manager
 	ipsetCounter int
 	rulesPairs   map[string][]firewall.Rule
-	mutex        sync.Mutex
+	// rulesPolicies holds the IDs of the policies each rules pair is applied for
+	rulesPolicies map[string]map[string]struct{}
+	mutex         sync.Mutex
+
+	// lazy is set when the rules of a remote peer are installed only while the peer is active
+	lazy bool
+	// activePeers are the IPs of the peers with traffic tracked by the firewall when lazy is set
+	activePeers map[string]struct{}
+	// networkMap is the latest network map applied, its rules are applied again when the active peers change
+	networkMap *mgmProto.NetworkMap
+	// blockedNetworks are the networks the firewall drops the outgoing traffic to
+	blockedNetworks []netip.Prefix
 }
 
 func NewDefaultManager(fm firewall.Manager) *DefaultManager {
 	return &DefaultManager{
-		firewall:   fm,
-		rulesPairs: make(map[string][]firewall.Rule),
+		firewall:      fm,
+		rulesPairs:    make(map[string][]firewall.Rule),
+		rulesPolicies: make(map[string]map[string]struct{}),
 	}
 }
 
@@ -59,13 +87,24 @@ func (d *DefaultManager) ApplyFiltering(networkMap *mgmProto.NetworkMap) {
 		return
 	}
 
+	d.applyFiltering(networkMap)
+}
+
+func (d *DefaultManager) applyFiltering(networkMap *mgmProto.NetworkMap) {
+	d.networkMap = networkMap
+
 	defer func() {
 		if err := d.firewall.Flush(); err != nil {
 			log.Error("failed to flush firewall rules: ", err)
 		}
 	}()
 
+	d.applyBlockedNetworks(networkMap.BlockedNetworks)
+
 	rules, squashedProtocols := d.squashAcceptRules(networkMap)
+	if d.lazy {
+		rules = d.activeRules(rules, keepWarmPeerIPs(networkMap))
+	}
 
 	enableSSH := (networkMap.PeerConfig != nil &&
 		networkMap.PeerConfig.SshConfig != nil &&
@@ -111,6 +150,7 @@ func (d *DefaultManager) ApplyFiltering(networkMap *mgmProto.NetworkMap) {
 	}
 
 	newRulePairs := make(map[string][]firewall.Rule)
+	newRulesPolicies := make(map[string]map[string]struct{})
 	ipsetByRuleSelectors := make(map[string]string)
 
 	for _, r := range rules {
@@ -133,6 +173,12 @@ func (d *DefaultManager) ApplyFiltering(networkMap *mgmProto.NetworkMap) {
 			d.rulesPairs[pairID] = rulePair
 			newRulePairs[pairID] = rulePair
 		}
+		for _, policyID := range rulePolicyIDs(networkMap, r) {
+			if _, ok := newRulesPolicies[pairID]; !ok {
+				newRulesPolicies[pairID] = make(map[string]struct{})
+			}
+			newRulesPolicies[pairID][policyID] = struct{}{}
+		}
 	}
 
 	for pairID, rules := range d.rulesPairs {
@@ -147,6 +193,183 @@ func (d *DefaultManager) ApplyFiltering(networkMap *mgmProto.NetworkMap) {
 		}
 	}
 	d.rulesPairs = newRulePairs
+	d.rulesPolicies = newRulesPolicies
+}
+
+// applyBlockedNetworks makes the firewall drop the outgoing traffic to the IPv4 networks of the external lists
+// when they changed
+func (d *DefaultManager) applyBlockedNetworks(networks []string) {
+	blocked := make([]netip.Prefix, 0, len(networks))
+	for _, network := range networks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			log.Debugf("skipping invalid blocked network %s: %v", network, err)
+			continue
+		}
+		if !prefix.Addr().Is4() {
+			continue
+		}
+		blocked = append(blocked, prefix.Masked())
+	}
+
+	if slices.Equal(blocked, d.blockedNetworks) {
+		return
+	}
+
+	blocker, ok := d.firewall.(firewall.NetworkBlocker)
+	if !ok {
+		// warn once per change of the networks
+		log.Warnf("the firewall can't block networks, ignoring %d blocked networks", len(blocked))
+		d.blockedNetworks = blocked
+		return
+	}
+	if err := blocker.SetBlockedNetworks(blocked); err != nil {
+		log.Errorf("failed to set %d blocked networks: %v", len(blocked), err)
+		return
+	}
+	d.blockedNetworks = blocked
+}
+
+// EnableLazyRules makes the manager install the rules of a remote peer only while the firewall tracks packets to or
+// from it. The rules matching all the peers are always installed. Peers are idle after idleTimeout without packets,
+// their rules are removed by the next RefreshActivePeers call
+func (d *DefaultManager) EnableLazyRules(idleTimeout time.Duration) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	tracker, ok := d.firewall.(firewall.ActivityTracker)
+	if !ok {
+		return fmt.Errorf("the firewall can't track the active peers")
+	}
+	if err := tracker.EnableActivityTracking(idleTimeout); err != nil {
+		return fmt.Errorf("enable activity tracking: %w", err)
+	}
+
+	d.lazy = true
+	d.activePeers = make(map[string]struct{})
+	if d.networkMap != nil {
+		d.applyFiltering(d.networkMap)
+	}
+	return nil
+}
+
+// RefreshActivePeers reads the active peers tracked by the firewall and applies the rules of the latest network map
+// again when they changed, installing the rules of the new active peers and removing the rules of the idle ones
+func (d *DefaultManager) RefreshActivePeers() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if !d.lazy {
+		return
+	}
+
+	ips, err := d.firewall.(firewall.ActivityTracker).ActivePeers()
+	if err != nil {
+		log.Errorf("failed to read the active peers: %v", err)
+		return
+	}
+
+	activePeers := make(map[string]struct{}, len(ips))
+	for _, ip := range ips {
+		activePeers[ip.String()] = struct{}{}
+	}
+	if maps.Equal(activePeers, d.activePeers) {
+		return
+	}
+
+	log.Debugf("active peers changed from %d to %d, applying the firewall rules", len(d.activePeers), len(activePeers))
+	d.activePeers = activePeers
+	if d.networkMap != nil {
+		d.applyFiltering(d.networkMap)
+	}
+}
+
+// activeRules returns the rules matching all the peers and the rules of the active and the keep warm peers
+func (d *DefaultManager) activeRules(rules []*mgmProto.FirewallRule, keepWarm map[string]struct{}) []*mgmProto.FirewallRule {
+	var active []*mgmProto.FirewallRule
+	for _, r := range rules {
+		_, isActive := d.activePeers[r.PeerIP]
+		_, isWarm := keepWarm[r.PeerIP]
+		if isActive || isWarm || r.PeerIP == "0.0.0.0" {
+			active = append(active, r)
+		}
+	}
+	return active
+}
+
+// keepWarmPeerIPs returns the IPs of the remote peers the connections are kept warm to, their rules stay installed
+// so the first packets aren't dropped
+func keepWarmPeerIPs(networkMap *mgmProto.NetworkMap) map[string]struct{} {
+	ips := make(map[string]struct{})
+	for _, p := range networkMap.RemotePeers {
+		if !p.GetKeepWarm() {
+			continue
+		}
+		for _, allowedIP := range p.AllowedIps {
+			if prefix, err := netip.ParsePrefix(allowedIP); err == nil {
+				ips[prefix.Addr().String()] = struct{}{}
+			} else if addr, err := netip.ParseAddr(allowedIP); err == nil {
+				ips[addr.String()] = struct{}{}
+			}
+		}
+	}
+	return ips
+}
+
+// PolicyCounters returns the traffic matched by the firewall rules of each policy applied by the peer.
+// It returns nil when the firewall manager can't read the counters of its rules
+func (d *DefaultManager) PolicyCounters() (map[string]firewall.Counters, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	reader, ok := d.firewall.(firewall.CountersReader)
+	if !ok {
+		return nil, nil
+	}
+
+	groups := make(map[string][]firewall.Rule)
+	for pairID, policies := range d.rulesPolicies {
+		for policyID := range policies {
+			groups[policyID] = append(groups[policyID], d.rulesPairs[pairID]...)
+		}
+	}
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	return reader.ReadCounters(groups)
+}
+
+// DroppedConnections returns the inbound connections logged by the firewall as dropped with the policies they matched.
+// It returns nil when the firewall manager can't log the dropped connections
+func (d *DefaultManager) DroppedConnections() []DroppedConnection {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	logger, ok := d.firewall.(firewall.DropLogger)
+	if !ok {
+		return nil
+	}
+
+	pairByRule := make(map[string]string)
+	for pairID, rules := range d.rulesPairs {
+		for _, rule := range rules {
+			pairByRule[rule.GetRuleID()] = pairID
+		}
+	}
+
+	var conns []DroppedConnection
+	for _, c := range logger.DroppedConnections() {
+		conn := DroppedConnection{DroppedConnection: c}
+		if pairID, ok := pairByRule[c.RuleID]; ok && c.RuleID != "" {
+			for policyID := range d.rulesPolicies[pairID] {
+				conn.PolicyIDs = append(conn.PolicyIDs, policyID)
+			}
+			sort.Strings(conn.PolicyIDs)
+		}
+		conns = append(conns, conn)
+	}
+	return conns
 }
 
 func (d *DefaultManager) protoRuleToFirewallRule(
@@ -407,6 +630,26 @@ func (d *DefaultManager) squashAcceptRules(
 	return append(rules, squashedRules...), squashedProtocols
 }
 
+// rulePolicyIDs returns the IDs of the policies the rule is applied for.
+// Rules squashed by the client are applied for the policies of the accept rules they replace
+func rulePolicyIDs(networkMap *mgmProto.NetworkMap, rule *mgmProto.FirewallRule) []string {
+	if rule.PolicyID != "" {
+		return []string{rule.PolicyID}
+	}
+
+	var policyIDs []string
+	for _, r := range networkMap.FirewallRules {
+		if r.PolicyID == "" || r.Direction != rule.Direction || r.Protocol != rule.Protocol ||
+			r.Action != mgmProto.FirewallRule_ACCEPT || r.Port != "" {
+			continue
+		}
+		if rule.PeerIP == "0.0.0.0" || r.PeerIP == rule.PeerIP {
+			policyIDs = append(policyIDs, r.PolicyID)
+		}
+	}
+	return policyIDs
+}
+
 // getRuleGroupingSelector takes all rule properties except IP address to build selector
 func (d *DefaultManager) getRuleGroupingSelector(rule *mgmProto.FirewallRule) string {
 	return fmt.Sprintf("%v:%v:%v:%s", strconv.Itoa(int(rule.Direction)), rule.Action, rule.Protocol, rule.Port)
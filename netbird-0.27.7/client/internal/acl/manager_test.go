@@ -3,9 +3,12 @@ package acl
 import (
 	"context"
 	"net"
+	"net/netip"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
 
 	"github.com/netbirdio/netbird/client/firewall"
 	"github.com/netbirdio/netbird/client/firewall/manager"
@@ -127,6 +130,169 @@ func TestDefaultManager(t *testing.T) {
 	})
 }
 
+// activityFirewall reports the given active peers on top of a firewall manager
+type activityFirewall struct {
+	manager.Manager
+	active []net.IP
+}
+
+func (f *activityFirewall) EnableActivityTracking(time.Duration) error {
+	return nil
+}
+
+func (f *activityFirewall) ActivePeers() ([]net.IP, error) {
+	return f.active, nil
+}
+
+func TestDefaultManagerLazyRules(t *testing.T) {
+	networkMap := &mgmProto.NetworkMap{
+		FirewallRules: []*mgmProto.FirewallRule{
+			{
+				PeerIP:    "10.93.0.1",
+				Direction: mgmProto.FirewallRule_IN,
+				Action:    mgmProto.FirewallRule_ACCEPT,
+				Protocol:  mgmProto.FirewallRule_TCP,
+				Port:      "80",
+			},
+			{
+				PeerIP:    "10.93.0.2",
+				Direction: mgmProto.FirewallRule_IN,
+				Action:    mgmProto.FirewallRule_ACCEPT,
+				Protocol:  mgmProto.FirewallRule_UDP,
+				Port:      "53",
+			},
+			{
+				PeerIP:    "0.0.0.0",
+				Direction: mgmProto.FirewallRule_IN,
+				Action:    mgmProto.FirewallRule_ACCEPT,
+				Protocol:  mgmProto.FirewallRule_ICMP,
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ifaceMock := mocks.NewMockIFaceMapper(ctrl)
+	ifaceMock.EXPECT().IsUserspaceBind().Return(true).AnyTimes()
+	ifaceMock.EXPECT().SetFilter(gomock.Any())
+	ip, network, err := net.ParseCIDR("172.0.0.1/32")
+	if err != nil {
+		t.Fatalf("failed to parse IP address: %v", err)
+	}
+
+	ifaceMock.EXPECT().Name().Return("lo").AnyTimes()
+	ifaceMock.EXPECT().Address().Return(iface.WGAddress{
+		IP:      ip,
+		Network: network,
+	}).AnyTimes()
+
+	fw, err := firewall.NewFirewall(context.Background(), ifaceMock)
+	if err != nil {
+		t.Errorf("create firewall: %v", err)
+		return
+	}
+	defer func(fw manager.Manager) {
+		_ = fw.Reset()
+	}(fw)
+
+	tracker := &activityFirewall{Manager: fw}
+	acl := NewDefaultManager(tracker)
+	acl.ApplyFiltering(networkMap)
+	if len(acl.rulesPairs) != 3 {
+		t.Fatalf("rules of all the peers should be applied before lazy rules are enabled, got: %v", len(acl.rulesPairs))
+	}
+
+	if err := acl.EnableLazyRules(time.Minute); err != nil {
+		t.Fatalf("enable lazy rules: %v", err)
+	}
+	if len(acl.rulesPairs) != 1 {
+		t.Fatalf("only the rules matching all the peers should be applied without active peers, got: %v", len(acl.rulesPairs))
+	}
+
+	tracker.active = []net.IP{net.ParseIP("10.93.0.2")}
+	acl.RefreshActivePeers()
+	if len(acl.rulesPairs) != 2 {
+		t.Fatalf("rules of the active peer should be applied, got: %v", len(acl.rulesPairs))
+	}
+
+	tracker.active = nil
+	acl.RefreshActivePeers()
+	if len(acl.rulesPairs) != 1 {
+		t.Fatalf("rules of the idle peer should be removed, got: %v", len(acl.rulesPairs))
+	}
+
+	networkMap.RemotePeers = []*mgmProto.RemotePeerConfig{
+		{WgPubKey: "peer1", AllowedIps: []string{"10.93.0.1/32"}, KeepWarm: true},
+		{WgPubKey: "peer2", AllowedIps: []string{"10.93.0.2/32"}},
+	}
+	acl.ApplyFiltering(networkMap)
+	if len(acl.rulesPairs) != 2 {
+		t.Fatalf("rules of the idle keep warm peer should be applied, got: %v", len(acl.rulesPairs))
+	}
+}
+
+// blockingFirewall records the blocked networks on top of a firewall manager
+type blockingFirewall struct {
+	manager.Manager
+	blocked []netip.Prefix
+	calls   int
+}
+
+func (f *blockingFirewall) SetBlockedNetworks(networks []netip.Prefix) error {
+	f.blocked = networks
+	f.calls++
+	return nil
+}
+
+func TestDefaultManagerBlockedNetworks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ifaceMock := mocks.NewMockIFaceMapper(ctrl)
+	ifaceMock.EXPECT().IsUserspaceBind().Return(true).AnyTimes()
+	ifaceMock.EXPECT().SetFilter(gomock.Any())
+	ip, network, err := net.ParseCIDR("172.0.0.1/32")
+	if err != nil {
+		t.Fatalf("failed to parse IP address: %v", err)
+	}
+
+	ifaceMock.EXPECT().Name().Return("lo").AnyTimes()
+	ifaceMock.EXPECT().Address().Return(iface.WGAddress{
+		IP:      ip,
+		Network: network,
+	}).AnyTimes()
+
+	fw, err := firewall.NewFirewall(context.Background(), ifaceMock)
+	if err != nil {
+		t.Fatalf("create firewall: %v", err)
+	}
+	defer func(fw manager.Manager) {
+		_ = fw.Reset()
+	}(fw)
+
+	blocker := &blockingFirewall{Manager: fw}
+	acl := NewDefaultManager(blocker)
+
+	networkMap := &mgmProto.NetworkMap{
+		FirewallRulesIsEmpty: true,
+		BlockedNetworks:      []string{"192.0.2.0/24", "2001:db8::/32", "invalid", "198.51.100.10/32"},
+	}
+	acl.ApplyFiltering(networkMap)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("198.51.100.10/32"),
+	}, blocker.blocked, "the invalid and IPv6 networks should be skipped")
+
+	acl.ApplyFiltering(networkMap)
+	assert.Equal(t, 1, blocker.calls, "the unchanged networks shouldn't be set again")
+
+	networkMap.BlockedNetworks = nil
+	acl.ApplyFiltering(networkMap)
+	assert.Empty(t, blocker.blocked, "the networks should be unblocked")
+	assert.Equal(t, 2, blocker.calls)
+}
+
 func TestDefaultManagerSquashRules(t *testing.T) {
 	networkMap := &mgmProto.NetworkMap{
 		RemotePeers: []*mgmProto.RemotePeerConfig{
@@ -362,3 +528,52 @@ func TestDefaultManagerEnableSSHRules(t *testing.T) {
 		return
 	}
 }
+
+func TestRulePolicyIDs(t *testing.T) {
+	networkMap := &mgmProto.NetworkMap{
+		FirewallRules: []*mgmProto.FirewallRule{
+			{
+				PeerIP:    "10.93.0.1",
+				Direction: mgmProto.FirewallRule_IN,
+				Action:    mgmProto.FirewallRule_ACCEPT,
+				Protocol:  mgmProto.FirewallRule_ALL,
+				PolicyID:  "policy1",
+			},
+			{
+				PeerIP:    "10.93.0.2",
+				Direction: mgmProto.FirewallRule_IN,
+				Action:    mgmProto.FirewallRule_ACCEPT,
+				Protocol:  mgmProto.FirewallRule_ALL,
+				PolicyID:  "policy2",
+			},
+			{
+				PeerIP:    "10.93.0.2",
+				Direction: mgmProto.FirewallRule_IN,
+				Action:    mgmProto.FirewallRule_ACCEPT,
+				Protocol:  mgmProto.FirewallRule_TCP,
+				Port:      "22",
+				PolicyID:  "policy3",
+			},
+		},
+	}
+
+	assert.Equal(t, []string{"policy3"}, rulePolicyIDs(networkMap, networkMap.FirewallRules[2]))
+
+	squashed := &mgmProto.FirewallRule{
+		PeerIP:    "0.0.0.0",
+		Direction: mgmProto.FirewallRule_IN,
+		Action:    mgmProto.FirewallRule_ACCEPT,
+		Protocol:  mgmProto.FirewallRule_ALL,
+	}
+	assert.Equal(t, []string{"policy1", "policy2"}, rulePolicyIDs(networkMap, squashed),
+		"squashed rules should be applied for the policies of the rules they replace")
+
+	ssh := &mgmProto.FirewallRule{
+		PeerIP:    "0.0.0.0",
+		Direction: mgmProto.FirewallRule_IN,
+		Action:    mgmProto.FirewallRule_ACCEPT,
+		Protocol:  mgmProto.FirewallRule_TCP,
+		Port:      "22",
+	}
+	assert.Empty(t, rulePolicyIDs(networkMap, ssh), "rules added by the client should not be applied for any policy")
+}
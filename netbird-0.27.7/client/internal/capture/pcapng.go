@@ -0,0 +1,176 @@
+// Package capture writes the packets tapped from the WireGuard device to a pcapng file
+package capture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	blockTypeSectionHeader   = 0x0A0D0D0A
+	blockTypeInterface       = 0x00000001
+	blockTypeEnhancedPacket  = 0x00000006
+	byteOrderMagic           = 0x1A2B3C4D
+	optionEndOfOptions       = 0
+	optionComment            = 1
+	optionInterfaceName      = 2
+	optionEnhancedPacketFlag = 2
+
+	// linkTypeRaw is LINKTYPE_RAW, the packets start with the IPv4 or IPv6 header
+	linkTypeRaw = 101
+
+	flagInbound  = 0x1
+	flagOutbound = 0x2
+
+	// DefaultSnapLen captures the whole packet for the MTUs the interface supports
+	DefaultSnapLen = 65535
+)
+
+// Writer writes the captured packets in the pcapng format. The direction of each packet is stored in its flags
+// and a comment tells whether it was captured before the encryption, after the decryption or dropped.
+type Writer struct {
+	mu      sync.Mutex
+	buf     *bufio.Writer
+	snapLen int
+	packets uint64
+	err     error
+}
+
+// NewWriter writes the pcapng header for the interface and returns a Writer appending packets to w
+func NewWriter(w io.Writer, ifaceName string, snapLen int) (*Writer, error) {
+	if snapLen <= 0 {
+		snapLen = DefaultSnapLen
+	}
+
+	writer := &Writer{
+		buf:     bufio.NewWriter(w),
+		snapLen: snapLen,
+	}
+
+	// section header: byte order magic, version 1.0 and an unknown section length
+	shb := make([]byte, 16)
+	binary.LittleEndian.PutUint32(shb[0:], byteOrderMagic)
+	binary.LittleEndian.PutUint16(shb[4:], 1)
+	binary.LittleEndian.PutUint16(shb[6:], 0)
+	binary.LittleEndian.PutUint64(shb[8:], 0xFFFFFFFFFFFFFFFF)
+	writer.writeBlock(blockTypeSectionHeader, shb, nil)
+
+	idb := make([]byte, 8)
+	binary.LittleEndian.PutUint16(idb[0:], linkTypeRaw)
+	binary.LittleEndian.PutUint32(idb[4:], uint32(snapLen))
+	writer.writeBlock(blockTypeInterface, idb, []option{{code: optionInterfaceName, value: []byte(ifaceName)}})
+
+	if writer.err != nil {
+		return nil, fmt.Errorf("write pcapng header: %w", writer.err)
+	}
+	return writer, nil
+}
+
+// CapturePacket writes the packet, it implements iface.PacketCapture
+func (w *Writer) CapturePacket(packet []byte, outbound bool, dropped bool) {
+	now := time.Now()
+
+	captured := packet
+	if len(captured) > w.snapLen {
+		captured = captured[:w.snapLen]
+	}
+
+	flags := make([]byte, 4)
+	comment := "inbound, after decryption"
+	if outbound {
+		binary.LittleEndian.PutUint32(flags, flagOutbound)
+		comment = "outbound, before encryption"
+	} else {
+		binary.LittleEndian.PutUint32(flags, flagInbound)
+	}
+	if dropped {
+		comment += ", dropped by the packet filter"
+	}
+
+	// timestamps are in microseconds, the default resolution of the interface
+	ts := uint64(now.UnixMicro())
+	epb := make([]byte, 20, 20+pad4(len(captured)))
+	binary.LittleEndian.PutUint32(epb[0:], 0)
+	binary.LittleEndian.PutUint32(epb[4:], uint32(ts>>32))
+	binary.LittleEndian.PutUint32(epb[8:], uint32(ts))
+	binary.LittleEndian.PutUint32(epb[12:], uint32(len(captured)))
+	binary.LittleEndian.PutUint32(epb[16:], uint32(len(packet)))
+	epb = append(epb, captured...)
+	epb = append(epb, make([]byte, pad4(len(captured))-len(captured))...)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return
+	}
+	w.writeBlock(blockTypeEnhancedPacket, epb, []option{
+		{code: optionEnhancedPacketFlag, value: flags},
+		{code: optionComment, value: []byte(comment)},
+	})
+	if w.err == nil {
+		w.packets++
+	}
+}
+
+// Packets returns the number of packets written
+func (w *Writer) Packets() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.packets
+}
+
+// Flush writes the buffered packets and returns the first write error
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+	w.err = w.buf.Flush()
+	return w.err
+}
+
+type option struct {
+	code  uint16
+	value []byte
+}
+
+// writeBlock writes a block with its options, the body must be padded to 32 bits
+func (w *Writer) writeBlock(blockType uint32, body []byte, options []option) {
+	length := 12 + len(body)
+	if len(options) > 0 {
+		for _, opt := range options {
+			length += 4 + pad4(len(opt.value))
+		}
+		length += 4
+	}
+
+	block := make([]byte, 0, length)
+	block = binary.LittleEndian.AppendUint32(block, blockType)
+	block = binary.LittleEndian.AppendUint32(block, uint32(length))
+	block = append(block, body...)
+	if len(options) > 0 {
+		for _, opt := range options {
+			block = binary.LittleEndian.AppendUint16(block, opt.code)
+			block = binary.LittleEndian.AppendUint16(block, uint16(len(opt.value)))
+			block = append(block, opt.value...)
+			block = append(block, make([]byte, pad4(len(opt.value))-len(opt.value))...)
+		}
+		block = binary.LittleEndian.AppendUint16(block, optionEndOfOptions)
+		block = binary.LittleEndian.AppendUint16(block, 0)
+	}
+	block = binary.LittleEndian.AppendUint32(block, uint32(length))
+
+	_, w.err = w.buf.Write(block)
+}
+
+// pad4 rounds n up to a multiple of 4
+func pad4(n int) int {
+	return (n + 3) &^ 3
+}
@@ -0,0 +1,66 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type block struct {
+	blockType uint32
+	body      []byte
+}
+
+func readBlocks(t *testing.T, data []byte) []block {
+	t.Helper()
+
+	var blocks []block
+	for len(data) > 0 {
+		require.GreaterOrEqual(t, len(data), 12)
+		blockType := binary.LittleEndian.Uint32(data[0:])
+		length := int(binary.LittleEndian.Uint32(data[4:]))
+		require.Zero(t, length%4, "blocks must be padded to 32 bits")
+		require.LessOrEqual(t, length, len(data))
+		require.Equal(t, uint32(length), binary.LittleEndian.Uint32(data[length-4:]), "the trailing length must match")
+
+		blocks = append(blocks, block{blockType: blockType, body: data[8 : length-4]})
+		data = data[length:]
+	}
+	return blocks
+}
+
+func TestWriter(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := NewWriter(&out, "wt0", 64)
+	require.NoError(t, err)
+
+	writer.CapturePacket(bytes.Repeat([]byte{0x45}, 41), true, false)
+	writer.CapturePacket(bytes.Repeat([]byte{0x45}, 100), false, true)
+	require.NoError(t, writer.Flush())
+	assert.Equal(t, uint64(2), writer.Packets())
+
+	blocks := readBlocks(t, out.Bytes())
+	require.Len(t, blocks, 4)
+
+	assert.Equal(t, uint32(blockTypeSectionHeader), blocks[0].blockType)
+	assert.Equal(t, uint32(byteOrderMagic), binary.LittleEndian.Uint32(blocks[0].body))
+
+	assert.Equal(t, uint32(blockTypeInterface), blocks[1].blockType)
+	assert.Equal(t, uint16(linkTypeRaw), binary.LittleEndian.Uint16(blocks[1].body))
+	assert.Equal(t, uint32(64), binary.LittleEndian.Uint32(blocks[1].body[4:]))
+	assert.Contains(t, string(blocks[1].body), "wt0")
+
+	outbound := blocks[2]
+	assert.Equal(t, uint32(blockTypeEnhancedPacket), outbound.blockType)
+	assert.Equal(t, uint32(41), binary.LittleEndian.Uint32(outbound.body[12:]), "captured length")
+	assert.Equal(t, uint32(41), binary.LittleEndian.Uint32(outbound.body[16:]), "original length")
+	assert.Contains(t, string(outbound.body), "outbound, before encryption")
+
+	inbound := blocks[3]
+	assert.Equal(t, uint32(64), binary.LittleEndian.Uint32(inbound.body[12:]), "the packet should be cut to the snap length")
+	assert.Equal(t, uint32(100), binary.LittleEndian.Uint32(inbound.body[16:]), "original length")
+	assert.Contains(t, string(inbound.body), "inbound, after decryption, dropped by the packet filter")
+}
@@ -2,18 +2,26 @@ package internal
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net"
 	"net/url"
 	"os"
 	"reflect"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/netbirdio/netbird/client/internal/dns"
+	"github.com/netbirdio/netbird/client/internal/peer"
+	"github.com/netbirdio/netbird/client/internal/persist"
+	"github.com/netbirdio/netbird/client/internal/updater"
 	"github.com/netbirdio/netbird/client/ssh"
+	"github.com/netbirdio/netbird/client/system"
 	"github.com/netbirdio/netbird/iface"
 	mgm "github.com/netbirdio/netbird/management/client"
 	"github.com/netbirdio/netbird/util"
@@ -39,20 +47,60 @@ var defaultInterfaceBlacklist = []string{
 
 // ConfigInput carries configuration changes to the client
 type ConfigInput struct {
-	ManagementURL       string
-	AdminURL            string
-	ConfigPath          string
-	PreSharedKey        *string
-	ServerSSHAllowed    *bool
-	NATExternalIPs      []string
-	CustomDNSAddress    []byte
-	RosenpassEnabled    *bool
-	RosenpassPermissive *bool
-	InterfaceName       *string
-	WireguardPort       *int
-	NetworkMonitor      *bool
-	DisableAutoConnect  *bool
-	ExtraIFaceBlackList []string
+	ManagementURL           string
+	AdminURL                string
+	ConfigPath              string
+	UCIConfigPath           string
+	Profile                 string
+	PreSharedKey            *string
+	ServerSSHAllowed        *bool
+	NATExternalIPs          []string
+	CustomDNSAddress        []byte
+	RosenpassEnabled        *bool
+	RosenpassPermissive     *bool
+	InterfaceName           *string
+	WireguardPort           *int
+	WireguardMode           *string
+	WireguardPortPool       *string
+	PortRoamingInterval     *time.Duration
+	TCPFallbackPort         *int
+	Uplink                  *string
+	InterfaceAddress        *string
+	ICEDisabledCandidates   []string
+	ICEExcludedSubnets      []string
+	ICEFailedTimeout        *time.Duration
+	ICEDisconnectedTimeout  *time.Duration
+	MTU                     *int
+	PMTUDiscovery           *bool
+	DNSCacheMinTTL          *time.Duration
+	DNSCacheMaxTTL          *time.Duration
+	DNSQueryLog             *bool
+	DNSHostOverrides        []string
+	LogFormat               *string
+	LogComponents           []string
+	LogMaxSize              *int
+	LogMaxBackups           *int
+	LogRotationInterval     *time.Duration
+	DropLog                 *bool
+	LazyFirewallRules       *bool
+	LazyFirewallIdleTimeout *time.Duration
+	DisableDNS              *bool
+	DisableClientRoutes     *bool
+	ControlPlane            []string
+	LowMemory               *bool
+	DisableSysctl           *bool
+	SSHRecordingDir         *string
+	SSHRecordingRetention   *time.Duration
+	StageNetworkChanges     *bool
+	StagedChangesAutoApply  *time.Duration
+	AutoUpdate              *bool
+	UpdatePublicKey         *string
+	UpdateAllowDowngrade    *bool
+	InventoryFields         []string
+	InventoryPackages       []string
+	NetworkMonitor          *bool
+	DisableAutoConnect      *bool
+	ExtraIFaceBlackList     []string
 }
 
 // Config Configuration type
@@ -95,13 +143,124 @@ type Config struct {
 	// DisableAutoConnect determines whether the client should not start with the service
 	// it's set to false by default due to backwards compatibility
 	DisableAutoConnect bool
+
+	// WgMode is the preferred WireGuard implementation: auto, kernel or userspace. Empty means auto
+	WgMode string
+
+	// MTU of the interface, overrides the MTU pushed by the management service. Zero means unset
+	MTU int
+	// PMTUDiscovery enables probing of the path MTU to lower the interface MTU on constrained uplinks
+	PMTUDiscovery bool
+
+	// WgPortPool is a comma separated list of ports and port ranges the WireGuard port is picked from, e.g. 51820,40000-40100
+	WgPortPool string
+	// WgPortRoamingInterval is how often the WireGuard port is moved to another port of WgPortPool, zero disables roaming
+	WgPortRoamingInterval time.Duration
+
+	// TCPFallbackPort is the TCP port WireGuard traffic is carried over when UDP is blocked, zero disables the fallback
+	TCPFallbackPort int
+
+	// Uplink is the network interface name or local source IP the traffic is pinned to, empty means unset
+	Uplink string
+
+	// InterfaceAddress is the NetBird IP requested from the management service, empty means any IP
+	InterfaceAddress string
+
+	// ICEDisabledCandidates are the ICE candidate types never gathered: host, srflx or relay. They override the ones
+	// pushed by the management service
+	ICEDisabledCandidates []string
+	// ICEExcludedSubnets are the local subnets no ICE host candidate is gathered from, e.g. Docker bridges or guest
+	// VLANs. They override the ones pushed by the management service
+	ICEExcludedSubnets []string
+	// ICEFailedTimeout is the time the ICE candidates are checked for before a connection attempt fails, zero means unset
+	ICEFailedTimeout time.Duration
+	// ICEDisconnectedTimeout is the time without traffic after which a connection is considered disconnected, zero means unset
+	ICEDisconnectedTimeout time.Duration
+
+	// DNSCacheMinTTL is the minimum time the DNS responses of the upstream nameservers are cached for, zero keeps the record TTLs
+	DNSCacheMinTTL time.Duration
+	// DNSCacheMaxTTL is the maximum time the DNS responses of the upstream nameservers are cached for, zero means the default
+	DNSCacheMaxTTL time.Duration
+
+	// DNSQueryLog enables the log of the DNS queries handled by the resolver, its counters are reported to the management service
+	DNSQueryLog bool
+
+	// DNSHostOverrides are the local host records served by the resolver in the name=ip form, whatever the DNS
+	// configuration of the management service, e.g. for router-local services
+	DNSHostOverrides []string
+
+	// DropLog enables the log of the inbound connections dropped by the firewall, they are reported to the management service
+	DropLog bool
+
+	// LazyFirewallRules installs the per-peer firewall rules only for the peers with recent traffic
+	LazyFirewallRules bool
+	// LazyFirewallIdleTimeout is the time without traffic after which the rules of a peer are removed, zero means the default
+	LazyFirewallIdleTimeout time.Duration
+
+	// DisableDNS keeps the DNS configuration of the management service from being applied, e.g. when dnsmasq serves the LAN
+	DisableDNS bool
+	// DisableClientRoutes keeps the routes of the management service from being installed on the peer
+	DisableClientRoutes bool
+	// ControlPlane lists the parts of the host the client manages: dns, routes and acl, e.g. only routes to use NetBird
+	// as a mesh while dnsmasq and the firewall are managed by the user. Empty keeps the one of the management service,
+	// which manages all of them unless it is set
+	ControlPlane []string
+
+	// LowMemory enables the low memory profile for routers with 64 to 128MB of RAM
+	LowMemory bool
+
+	// DisableSysctl keeps the client from changing the forwarding, rp_filter and src_valid_mark sysctls
+	DisableSysctl bool
+
+	// SSHRecordingDir is the directory the terminal output of the sessions of the SSH server is recorded to, empty
+	// disables the recording
+	SSHRecordingDir string
+	// SSHRecordingRetention is the time the SSH session recordings are kept for, zero means the default
+	SSHRecordingRetention time.Duration
+
+	// StageNetworkChanges stages the route and DNS changes of the network map until they are approved locally with
+	// netbird pending apply, for routers where an unexpected change of the default route is catastrophic
+	StageNetworkChanges bool
+	// StagedChangesAutoApply is the time after which the staged changes are applied without an approval, zero waits
+	// for the approval
+	StagedChangesAutoApply time.Duration
+
+	// AutoUpdate updates the client to the version the management service sets for the groups of the peer
+	AutoUpdate bool
+	// UpdatePublicKey is the base64 encoded ed25519 public key the binaries of the updates are signed with, no
+	// update is applied without it
+	UpdatePublicKey string
+	// UpdateAllowDowngrade applies the versions at or below the running one the management service sets, refused
+	// otherwise as an older signed release may have known vulnerabilities
+	UpdateAllowDowngrade bool
+
+	// InventoryFields are the fields of the software inventory reported to the management service at login:
+	// patch_level and packages. Empty reports no inventory
+	InventoryFields []string
+	// InventoryPackages are the names of the packages whose versions are reported with the inventory, * for all of them
+	InventoryPackages []string
+
+	// LogFormat is the format of the daemon log: text or json, text when empty
+	LogFormat string
+	// LogComponents are the levels of the components of the daemon log in the component=level form, e.g. dns=debug
+	LogComponents []string
+	// LogMaxSize is the size in megabytes the daemon log file is rotated at and LogMaxBackups the number of rotated
+	// files kept, the defaults when zero
+	LogMaxSize    int
+	LogMaxBackups int
+	// LogRotationInterval rotates the daemon log file at the interval whatever its size, zero rotates it on its size only
+	LogRotationInterval time.Duration
+
+	// Profile is the name of the client profile the config belongs to, empty for the default profile. It is given
+	// by the path of the config and not stored in it
+	Profile string `json:"-"`
 }
 
 // ReadConfig read config file and return with Config. If it is not exists create a new with default values
 func ReadConfig(configPath string) (*Config, error) {
 	if configFileIsExists(configPath) {
 		config := &Config{}
-		if _, err := util.ReadJson(configPath, config); err != nil {
+		if err := readConfigFile(configPath, config); err != nil {
 			return nil, err
 		}
 		// initialize through apply() without changes
@@ -131,19 +290,28 @@ func UpdateConfig(input ConfigInput) (*Config, error) {
 		return nil, status.Errorf(codes.NotFound, "config file doesn't exist")
 	}
 
+	if err := applyUCIConfig(&input); err != nil {
+		return nil, err
+	}
 	return update(input)
 }
 
 // UpdateOrCreateConfig reads existing config or generates a new one
 func UpdateOrCreateConfig(input ConfigInput) (*Config, error) {
+	if err := applyUCIConfig(&input); err != nil {
+		return nil, err
+	}
+
 	if !configFileIsExists(input.ConfigPath) {
 		log.Infof("generating new config %s", input.ConfigPath)
 		cfg, err := createNewConfig(input)
 		if err != nil {
 			return nil, err
 		}
-		err = WriteOutConfig(input.ConfigPath, cfg)
-		return cfg, err
+		if err := WriteOutConfig(input.ConfigPath, cfg); err != nil {
+			return nil, err
+		}
+		return cfg, saveUCIConfig(input.UCIConfigPath, cfg)
 	}
 
 	if isPreSharedKeyHidden(input.PreSharedKey) {
@@ -157,9 +325,22 @@ func CreateInMemoryConfig(input ConfigInput) (*Config, error) {
 	return createNewConfig(input)
 }
 
-// WriteOutConfig write put the prepared config to the given path
+// WriteOutConfig write put the prepared config to the given path, the write is batched with the other state files
 func WriteOutConfig(path string, config *Config) error {
-	return util.WriteJson(path, config)
+	data, err := json.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return err
+	}
+	return persist.WriteFile(path, data, 0600)
+}
+
+// readConfigFile reads the config at path, including the changes not written to the disk yet
+func readConfigFile(path string, config *Config) error {
+	data, err := persist.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, config)
 }
 
 // createNewConfig creates a new config generating a new Wireguard key and saving to file
@@ -179,7 +360,7 @@ func createNewConfig(input ConfigInput) (*Config, error) {
 func update(input ConfigInput) (*Config, error) {
 	config := &Config{}
 
-	if _, err := util.ReadJson(input.ConfigPath, config); err != nil {
+	if err := readConfigFile(input.ConfigPath, config); err != nil {
 		return nil, err
 	}
 
@@ -189,15 +370,39 @@ func update(input ConfigInput) (*Config, error) {
 	}
 
 	if updated {
-		if err := util.WriteJson(input.ConfigPath, config); err != nil {
+		if err := WriteOutConfig(input.ConfigPath, config); err != nil {
 			return nil, err
 		}
 	}
 
+	if err := saveUCIConfig(input.UCIConfigPath, config); err != nil {
+		return nil, err
+	}
+
 	return config, nil
 }
 
+// LogConfig returns the settings of the daemon log of the config, the level of the log is kept
+func (config *Config) LogConfig() util.LogConfig {
+	// the components are validated when they are set
+	components, _ := util.ParseLogComponents(config.LogComponents)
+	return util.LogConfig{
+		Format:           config.LogFormat,
+		Components:       components,
+		MaxSize:          config.LogMaxSize,
+		MaxBackups:       config.LogMaxBackups,
+		RotationInterval: util.Duration{Duration: config.LogRotationInterval},
+	}
+}
+
 func (config *Config) apply(input ConfigInput) (updated bool, err error) {
+	if input.Profile != "" {
+		if err := ValidateProfileName(input.Profile); err != nil {
+			return false, err
+		}
+	}
+	config.Profile = input.Profile
+
 	if config.ManagementURL == nil {
 		log.Infof("using default Management URL %s", DefaultManagementURL)
 		config.ManagementURL, err = parseURL("Management URL", DefaultManagementURL)
@@ -262,18 +467,347 @@ func (config *Config) apply(input ConfigInput) (updated bool, err error) {
 		config.WgPort = *input.WireguardPort
 		updated = true
 	} else if config.WgPort == 0 {
-		config.WgPort = iface.DefaultWgPort
+		config.WgPort = profileWgPort(input.Profile)
 		log.Infof("using default Wireguard port %d", config.WgPort)
 		updated = true
 	}
 
+	if input.WireguardMode != nil {
+		mode, err := iface.ParseWGMode(*input.WireguardMode)
+		if err != nil {
+			return false, err
+		}
+		if string(mode) != config.WgMode {
+			log.Infof("updating Wireguard mode to %s (old value %s)", mode, config.WgMode)
+			config.WgMode = string(mode)
+			updated = true
+		}
+	}
+
+	if input.MTU != nil && *input.MTU != config.MTU {
+		if *input.MTU != 0 && (*input.MTU < iface.MinMTU || *input.MTU > iface.MaxMTU) {
+			return false, fmt.Errorf("invalid MTU %d, must be between %d and %d", *input.MTU, iface.MinMTU, iface.MaxMTU)
+		}
+		log.Infof("updating interface MTU to %d (old value %d)", *input.MTU, config.MTU)
+		config.MTU = *input.MTU
+		updated = true
+	}
+
+	if input.WireguardPortPool != nil && *input.WireguardPortPool != config.WgPortPool {
+		if _, err := parsePortPool(*input.WireguardPortPool); err != nil {
+			return false, fmt.Errorf("invalid Wireguard port pool: %w", err)
+		}
+		log.Infof("updating Wireguard port pool to %q (old value %q)", *input.WireguardPortPool, config.WgPortPool)
+		config.WgPortPool = *input.WireguardPortPool
+		updated = true
+	}
+
+	if input.PortRoamingInterval != nil && *input.PortRoamingInterval != config.WgPortRoamingInterval {
+		if *input.PortRoamingInterval < 0 {
+			return false, fmt.Errorf("invalid port roaming interval %s", *input.PortRoamingInterval)
+		}
+		log.Infof("updating Wireguard port roaming interval to %s (old value %s)", *input.PortRoamingInterval, config.WgPortRoamingInterval)
+		config.WgPortRoamingInterval = *input.PortRoamingInterval
+		updated = true
+	}
+
+	if input.TCPFallbackPort != nil && *input.TCPFallbackPort != config.TCPFallbackPort {
+		if *input.TCPFallbackPort < 0 || *input.TCPFallbackPort > 65535 {
+			return false, fmt.Errorf("invalid TCP fallback port %d", *input.TCPFallbackPort)
+		}
+		log.Infof("updating TCP fallback port to %d (old value %d)", *input.TCPFallbackPort, config.TCPFallbackPort)
+		config.TCPFallbackPort = *input.TCPFallbackPort
+		updated = true
+	}
+
+	if input.Uplink != nil && *input.Uplink != config.Uplink {
+		log.Infof("updating uplink to %q (old value %q)", *input.Uplink, config.Uplink)
+		config.Uplink = *input.Uplink
+		updated = true
+	}
+
+	if input.InterfaceAddress != nil {
+		address, err := parseInterfaceAddress(*input.InterfaceAddress)
+		if err != nil {
+			return false, err
+		}
+		if address != config.InterfaceAddress {
+			log.Infof("updating interface address to %q (old value %q)", address, config.InterfaceAddress)
+			config.InterfaceAddress = address
+			updated = true
+		}
+	}
+
+	if input.ICEDisabledCandidates != nil && !reflect.DeepEqual(config.ICEDisabledCandidates, input.ICEDisabledCandidates) {
+		if _, err := peer.ParseCandidateTypes(input.ICEDisabledCandidates); err != nil {
+			return false, err
+		}
+		log.Infof("updating disabled ICE candidate types to [ %s ] (old value: [ %s ])",
+			strings.Join(input.ICEDisabledCandidates, " "),
+			strings.Join(config.ICEDisabledCandidates, " "))
+		config.ICEDisabledCandidates = input.ICEDisabledCandidates
+		updated = true
+	}
+
+	if input.ICEExcludedSubnets != nil && !reflect.DeepEqual(config.ICEExcludedSubnets, input.ICEExcludedSubnets) {
+		if _, err := peer.ParseSubnets(input.ICEExcludedSubnets); err != nil {
+			return false, err
+		}
+		log.Infof("updating ICE excluded subnets to [ %s ] (old value: [ %s ])",
+			strings.Join(input.ICEExcludedSubnets, " "),
+			strings.Join(config.ICEExcludedSubnets, " "))
+		config.ICEExcludedSubnets = input.ICEExcludedSubnets
+		updated = true
+	}
+
+	if input.ICEFailedTimeout != nil && *input.ICEFailedTimeout != config.ICEFailedTimeout {
+		if *input.ICEFailedTimeout < 0 {
+			return false, fmt.Errorf("invalid ICE failed timeout %s", *input.ICEFailedTimeout)
+		}
+		log.Infof("updating ICE failed timeout to %s (old value %s)", *input.ICEFailedTimeout, config.ICEFailedTimeout)
+		config.ICEFailedTimeout = *input.ICEFailedTimeout
+		updated = true
+	}
+
+	if input.ICEDisconnectedTimeout != nil && *input.ICEDisconnectedTimeout != config.ICEDisconnectedTimeout {
+		if *input.ICEDisconnectedTimeout < 0 {
+			return false, fmt.Errorf("invalid ICE disconnected timeout %s", *input.ICEDisconnectedTimeout)
+		}
+		log.Infof("updating ICE disconnected timeout to %s (old value %s)", *input.ICEDisconnectedTimeout, config.ICEDisconnectedTimeout)
+		config.ICEDisconnectedTimeout = *input.ICEDisconnectedTimeout
+		updated = true
+	}
+
+	if input.DNSCacheMinTTL != nil && *input.DNSCacheMinTTL != config.DNSCacheMinTTL {
+		if *input.DNSCacheMinTTL < 0 {
+			return false, fmt.Errorf("invalid DNS cache minimum TTL %s", *input.DNSCacheMinTTL)
+		}
+		log.Infof("updating DNS cache minimum TTL to %s (old value %s)", *input.DNSCacheMinTTL, config.DNSCacheMinTTL)
+		config.DNSCacheMinTTL = *input.DNSCacheMinTTL
+		updated = true
+	}
+
+	if input.DNSCacheMaxTTL != nil && *input.DNSCacheMaxTTL != config.DNSCacheMaxTTL {
+		if *input.DNSCacheMaxTTL < 0 {
+			return false, fmt.Errorf("invalid DNS cache maximum TTL %s", *input.DNSCacheMaxTTL)
+		}
+		log.Infof("updating DNS cache maximum TTL to %s (old value %s)", *input.DNSCacheMaxTTL, config.DNSCacheMaxTTL)
+		config.DNSCacheMaxTTL = *input.DNSCacheMaxTTL
+		updated = true
+	}
+
+	if config.DNSCacheMaxTTL > 0 && config.DNSCacheMinTTL > config.DNSCacheMaxTTL {
+		return false, fmt.Errorf("DNS cache minimum TTL %s is greater than the maximum TTL %s", config.DNSCacheMinTTL, config.DNSCacheMaxTTL)
+	}
+
+	if input.DNSQueryLog != nil && *input.DNSQueryLog != config.DNSQueryLog {
+		log.Infof("switching DNS query log to %t", *input.DNSQueryLog)
+		config.DNSQueryLog = *input.DNSQueryLog
+		updated = true
+	}
+
+	if input.DNSHostOverrides != nil && !reflect.DeepEqual(config.DNSHostOverrides, input.DNSHostOverrides) {
+		if _, err := dns.ParseHostOverrides(input.DNSHostOverrides); err != nil {
+			return false, err
+		}
+		log.Infof("updating DNS host overrides to [ %s ] (old value: [ %s ])",
+			strings.Join(input.DNSHostOverrides, " "),
+			strings.Join(config.DNSHostOverrides, " "))
+		config.DNSHostOverrides = input.DNSHostOverrides
+		updated = true
+	}
+
+	if input.DropLog != nil && *input.DropLog != config.DropLog {
+		log.Infof("switching log of the dropped connections to %t", *input.DropLog)
+		config.DropLog = *input.DropLog
+		updated = true
+	}
+
+	if input.LazyFirewallRules != nil && *input.LazyFirewallRules != config.LazyFirewallRules {
+		log.Infof("switching lazy firewall rules to %t", *input.LazyFirewallRules)
+		config.LazyFirewallRules = *input.LazyFirewallRules
+		updated = true
+	}
+
+	if input.LazyFirewallIdleTimeout != nil && *input.LazyFirewallIdleTimeout != config.LazyFirewallIdleTimeout {
+		if *input.LazyFirewallIdleTimeout < 0 {
+			return false, fmt.Errorf("invalid lazy firewall idle timeout %s", *input.LazyFirewallIdleTimeout)
+		}
+		log.Infof("updating lazy firewall idle timeout to %s (old value %s)", *input.LazyFirewallIdleTimeout, config.LazyFirewallIdleTimeout)
+		config.LazyFirewallIdleTimeout = *input.LazyFirewallIdleTimeout
+		updated = true
+	}
+
+	if input.DisableDNS != nil && *input.DisableDNS != config.DisableDNS {
+		log.Infof("switching DNS configuration disabled to %t", *input.DisableDNS)
+		config.DisableDNS = *input.DisableDNS
+		updated = true
+	}
+
+	if input.DisableClientRoutes != nil && *input.DisableClientRoutes != config.DisableClientRoutes {
+		log.Infof("switching client routes disabled to %t", *input.DisableClientRoutes)
+		config.DisableClientRoutes = *input.DisableClientRoutes
+		updated = true
+	}
+
+	if input.ControlPlane != nil && !reflect.DeepEqual(config.ControlPlane, input.ControlPlane) {
+		if err := ParseControlPlane(input.ControlPlane); err != nil {
+			return false, err
+		}
+		log.Infof("updating control plane to [ %s ] (old value: [ %s ])",
+			strings.Join(input.ControlPlane, " "),
+			strings.Join(config.ControlPlane, " "))
+		config.ControlPlane = input.ControlPlane
+		updated = true
+	}
+
+	if input.LowMemory != nil && *input.LowMemory != config.LowMemory {
+		log.Infof("switching low memory profile to %t", *input.LowMemory)
+		config.LowMemory = *input.LowMemory
+		updated = true
+	}
+
+	if input.DisableSysctl != nil && *input.DisableSysctl != config.DisableSysctl {
+		log.Infof("switching sysctl changes disabled to %t", *input.DisableSysctl)
+		config.DisableSysctl = *input.DisableSysctl
+		updated = true
+	}
+
+	if input.SSHRecordingDir != nil && *input.SSHRecordingDir != config.SSHRecordingDir {
+		log.Infof("updating SSH session recording directory to %q (old value %q)", *input.SSHRecordingDir, config.SSHRecordingDir)
+		config.SSHRecordingDir = *input.SSHRecordingDir
+		updated = true
+	}
+
+	if input.SSHRecordingRetention != nil && *input.SSHRecordingRetention != config.SSHRecordingRetention {
+		if *input.SSHRecordingRetention < 0 {
+			return false, fmt.Errorf("invalid SSH recording retention %s", *input.SSHRecordingRetention)
+		}
+		log.Infof("updating SSH recording retention to %s (old value %s)", *input.SSHRecordingRetention, config.SSHRecordingRetention)
+		config.SSHRecordingRetention = *input.SSHRecordingRetention
+		updated = true
+	}
+
+	if input.StageNetworkChanges != nil && *input.StageNetworkChanges != config.StageNetworkChanges {
+		log.Infof("switching staging of the network changes to %t", *input.StageNetworkChanges)
+		config.StageNetworkChanges = *input.StageNetworkChanges
+		updated = true
+	}
+
+	if input.StagedChangesAutoApply != nil && *input.StagedChangesAutoApply != config.StagedChangesAutoApply {
+		if *input.StagedChangesAutoApply < 0 {
+			return false, fmt.Errorf("invalid staged changes auto apply delay %s", *input.StagedChangesAutoApply)
+		}
+		log.Infof("updating staged changes auto apply delay to %s (old value %s)", *input.StagedChangesAutoApply, config.StagedChangesAutoApply)
+		config.StagedChangesAutoApply = *input.StagedChangesAutoApply
+		updated = true
+	}
+
+	if input.AutoUpdate != nil && *input.AutoUpdate != config.AutoUpdate {
+		log.Infof("switching auto update to %t", *input.AutoUpdate)
+		config.AutoUpdate = *input.AutoUpdate
+		updated = true
+	}
+
+	if input.UpdatePublicKey != nil && *input.UpdatePublicKey != config.UpdatePublicKey {
+		if *input.UpdatePublicKey != "" {
+			if _, err := updater.ParsePublicKey(*input.UpdatePublicKey); err != nil {
+				return false, err
+			}
+		}
+		log.Infof("updating the update public key to %s", *input.UpdatePublicKey)
+		config.UpdatePublicKey = *input.UpdatePublicKey
+		updated = true
+	}
+
+	if input.UpdateAllowDowngrade != nil && *input.UpdateAllowDowngrade != config.UpdateAllowDowngrade {
+		log.Infof("switching the update downgrades to %t", *input.UpdateAllowDowngrade)
+		config.UpdateAllowDowngrade = *input.UpdateAllowDowngrade
+		updated = true
+	}
+
+	if input.InventoryFields != nil && !reflect.DeepEqual(config.InventoryFields, input.InventoryFields) {
+		if err := system.ParseInventoryFields(input.InventoryFields); err != nil {
+			return false, err
+		}
+		log.Infof("updating inventory fields to [ %s ] (old value: [ %s ])",
+			strings.Join(input.InventoryFields, " "),
+			strings.Join(config.InventoryFields, " "))
+		config.InventoryFields = input.InventoryFields
+		updated = true
+	}
+
+	if input.InventoryPackages != nil && !reflect.DeepEqual(config.InventoryPackages, input.InventoryPackages) {
+		log.Infof("updating inventory packages to [ %s ] (old value: [ %s ])",
+			strings.Join(input.InventoryPackages, " "),
+			strings.Join(config.InventoryPackages, " "))
+		config.InventoryPackages = input.InventoryPackages
+		updated = true
+	}
+
+	if input.LogFormat != nil && *input.LogFormat != config.LogFormat {
+		if err := (util.LogConfig{Format: *input.LogFormat}).Validate(); err != nil {
+			return false, err
+		}
+		log.Infof("updating log format to %q (old value %q)", *input.LogFormat, config.LogFormat)
+		config.LogFormat = *input.LogFormat
+		updated = true
+	}
+
+	if input.LogComponents != nil && !reflect.DeepEqual(config.LogComponents, input.LogComponents) {
+		if _, err := util.ParseLogComponents(input.LogComponents); err != nil {
+			return false, err
+		}
+		log.Infof("updating log components to [ %s ] (old value: [ %s ])",
+			strings.Join(input.LogComponents, " "),
+			strings.Join(config.LogComponents, " "))
+		config.LogComponents = input.LogComponents
+		updated = true
+	}
+
+	if input.LogMaxSize != nil && *input.LogMaxSize != config.LogMaxSize {
+		if *input.LogMaxSize < 0 {
+			return false, fmt.Errorf("invalid log max size %d", *input.LogMaxSize)
+		}
+		log.Infof("updating log max size to %dMB (old value %dMB)", *input.LogMaxSize, config.LogMaxSize)
+		config.LogMaxSize = *input.LogMaxSize
+		updated = true
+	}
+
+	if input.LogMaxBackups != nil && *input.LogMaxBackups != config.LogMaxBackups {
+		if *input.LogMaxBackups < 0 {
+			return false, fmt.Errorf("invalid log max backups %d", *input.LogMaxBackups)
+		}
+		log.Infof("updating log max backups to %d (old value %d)", *input.LogMaxBackups, config.LogMaxBackups)
+		config.LogMaxBackups = *input.LogMaxBackups
+		updated = true
+	}
+
+	if input.LogRotationInterval != nil && *input.LogRotationInterval != config.LogRotationInterval {
+		if *input.LogRotationInterval < 0 {
+			return false, fmt.Errorf("invalid log rotation interval %s", *input.LogRotationInterval)
+		}
+		log.Infof("updating log rotation interval to %s (old value %s)", *input.LogRotationInterval, config.LogRotationInterval)
+		config.LogRotationInterval = *input.LogRotationInterval
+		updated = true
+	}
+
+	if input.PMTUDiscovery != nil && *input.PMTUDiscovery != config.PMTUDiscovery {
+		log.Infof("switching path MTU discovery to %t", *input.PMTUDiscovery)
+		config.PMTUDiscovery = *input.PMTUDiscovery
+		updated = true
+	}
+
 	if input.InterfaceName != nil && *input.InterfaceName != config.WgIface {
+		if err := iface.ValidateInterfaceName(*input.InterfaceName); err != nil {
+			return false, err
+		}
 		log.Infof("updating Wireguard interface %#v (old value %#v)",
 			*input.InterfaceName, config.WgIface)
 		config.WgIface = *input.InterfaceName
 		updated = true
 	} else if config.WgIface == "" {
-		config.WgIface = iface.WgInterfaceDefault
+		config.WgIface = profileInterfaceName(input.Profile)
 		log.Infof("using default Wireguard interface %s", config.WgIface)
 		updated = true
 	}
@@ -388,6 +922,29 @@ func parseURL(serviceName, serviceURL string) (*url.URL, error) {
 	return parsedMgmtURL, err
 }
 
+// parseInterfaceAddress parses the requested interface address, it accepts an IPv4 address with an optional prefix length.
+// The prefix length is dropped because the network is defined by the management service.
+func parseInterfaceAddress(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return "", nil
+	}
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		prefixIP, _, err := net.ParseCIDR(address)
+		if err != nil {
+			return "", fmt.Errorf("invalid interface address %s, expected an IPv4 address e.g. 100.64.0.10", address)
+		}
+		ip = prefixIP
+	}
+
+	if ip.To4() == nil {
+		return "", fmt.Errorf("invalid interface address %s, only IPv4 addresses are supported", address)
+	}
+	return ip.To4().String(), nil
+}
+
 // generateKey generates a new Wireguard private key
 func generateKey() string {
 	key, err := wgtypes.GeneratePrivateKey()
@@ -406,7 +963,7 @@ func isPreSharedKeyHidden(preSharedKey *string) bool {
 }
 
 func configFileIsExists(path string) bool {
-	_, err := os.Stat(path)
+	_, err := persist.ReadFile(path)
 	return !os.IsNotExist(err)
 }
 
@@ -105,6 +105,40 @@ func TestExtraIFaceBlackList(t *testing.T) {
 	assert.Contains(t, readConf.(*Config).IFaceBlackList, "eth1")
 }
 
+func TestInterfaceAddress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	address := "100.64.0.10/16"
+	config, err := UpdateOrCreateConfig(ConfigInput{
+		ConfigPath:       path,
+		InterfaceAddress: &address,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "100.64.0.10", config.InterfaceAddress, "the prefix length should be dropped")
+
+	for _, invalid := range []string{"100.64.0", "fd00::10", "wt0"} {
+		_, err = UpdateConfig(ConfigInput{
+			ConfigPath:       path,
+			InterfaceAddress: &invalid,
+		})
+		assert.Error(t, err, "interface address %s should be rejected", invalid)
+	}
+
+	invalidName := ""
+	_, err = UpdateConfig(ConfigInput{
+		ConfigPath:    path,
+		InterfaceName: &invalidName,
+	})
+	assert.Error(t, err, "an empty interface name should be rejected")
+
+	empty := ""
+	config, err = UpdateConfig(ConfigInput{
+		ConfigPath:       path,
+		InterfaceAddress: &empty,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, config.InterfaceAddress)
+}
+
 func TestHiddenPreSharedKey(t *testing.T) {
 	hidden := "**********"
 	samplePreSharedKey := "mysecretpresharedkey"
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"runtime"
 	"runtime/debug"
 	"strings"
@@ -19,7 +20,9 @@ import (
 	"github.com/netbirdio/netbird/client/internal/dns"
 	"github.com/netbirdio/netbird/client/internal/listener"
 	"github.com/netbirdio/netbird/client/internal/peer"
+	"github.com/netbirdio/netbird/client/internal/powermonitor"
 	"github.com/netbirdio/netbird/client/internal/stdnet"
+	"github.com/netbirdio/netbird/client/internal/updater"
 	"github.com/netbirdio/netbird/client/ssh"
 	"github.com/netbirdio/netbird/client/system"
 	"github.com/netbirdio/netbird/iface"
@@ -36,6 +39,8 @@ type ConnectClient struct {
 	statusRecorder *peer.Status
 	engine         *Engine
 	engineMutex    sync.Mutex
+	// actionHandler runs the remote actions of the management service needing the daemon, nil if not supported
+	actionHandler PeerActionHandler
 }
 
 func NewConnectClient(
@@ -52,6 +57,12 @@ func NewConnectClient(
 	}
 }
 
+// SetPeerActionHandler sets the handler of the remote actions that need the daemon, e.g. collecting a debug bundle.
+// Must be called before the client runs.
+func (c *ConnectClient) SetPeerActionHandler(handler PeerActionHandler) {
+	c.actionHandler = handler
+}
+
 // Run with main logic.
 func (c *ConnectClient) Run() error {
 	return c.run(MobileDependency{}, nil, nil, nil, nil)
@@ -139,8 +150,7 @@ func (c *ConnectClient) run(
 	}()
 
 	wrapErr := state.Wrap
-	myPrivateKey, err := wgtypes.ParseKey(c.config.PrivateKey)
-	if err != nil {
+	if _, err := wgtypes.ParseKey(c.config.PrivateKey); err != nil {
 		log.Errorf("failed parsing Wireguard key %s: [%s]", c.config.PrivateKey, err.Error())
 		return wrapErr(err)
 	}
@@ -155,6 +165,22 @@ func (c *ConnectClient) run(
 		return err
 	}
 
+	isolateProfile(c.config.Profile)
+
+	if c.config.AutoUpdate && c.config.Profile == "" {
+		// an update applied before this start is kept once the client connects to the management service
+		go updater.CheckPending(c.ctx, func() bool {
+			return c.statusRecorder.GetManagementState().Connected
+		})
+	}
+
+	runCtx := c.ctx
+	if c.config.LowMemory {
+		defer applyLowMemoryLimits()()
+		runCtx = context.WithValue(runCtx, system.SkipEnvironmentCtxKey, true)
+	}
+	runCtx = withInventoryConfig(runCtx, c.config)
+
 	defer c.statusRecorder.ClientStop()
 	operation := func() error {
 		// if context cancelled we not start new backoff cycle
@@ -166,7 +192,13 @@ func (c *ConnectClient) run(
 
 		state.Set(StatusConnecting)
 
-		engineCtx, cancel := context.WithCancel(c.ctx)
+		// parsed on every connect, a remote key rotation replaces the key
+		myPrivateKey, err := wgtypes.ParseKey(c.config.PrivateKey)
+		if err != nil {
+			return backoff.Permanent(wrapErr(err))
+		}
+
+		engineCtx, cancel := context.WithCancel(runCtx)
 		defer func() {
 			c.statusRecorder.MarkManagementDisconnected(state.err)
 			c.statusRecorder.CleanLocalPeerState()
@@ -190,7 +222,7 @@ func (c *ConnectClient) run(
 		}()
 
 		// connect (just a connection, no stream yet) and login to Management Service to get an initial global Wiretrustee config
-		loginResp, err := loginToManagement(engineCtx, mgmClient, publicSSHKey)
+		loginResp, err := loginToManagement(engineCtx, mgmClient, publicSSHKey, c.config.InterfaceAddress)
 		if err != nil {
 			log.Debug(err)
 			if s, ok := gstatus.FromError(err); ok && (s.Code() == codes.PermissionDenied) {
@@ -201,6 +233,11 @@ func (c *ConnectClient) run(
 		}
 		c.statusRecorder.MarkManagementConnected()
 
+		if c.config.InterfaceAddress != "" && !strings.HasPrefix(loginResp.GetPeerConfig().GetAddress(), c.config.InterfaceAddress+"/") {
+			log.Warnf("the management service assigned %s instead of the requested interface address %s, the address is taken or outside of the network",
+				loginResp.GetPeerConfig().GetAddress(), c.config.InterfaceAddress)
+		}
+
 		localPeerState := peer.LocalPeerState{
 			IP:              loginResp.GetPeerConfig().GetAddress(),
 			PubKey:          myPrivateKey.PublicKey().String(),
@@ -250,6 +287,10 @@ func (c *ConnectClient) run(
 
 		c.engineMutex.Lock()
 		c.engine = NewEngineWithProbes(engineCtx, cancel, signalClient, mgmClient, engineConfig, mobileDependency, c.statusRecorder, mgmProbe, signalProbe, relayProbe, wgProbe)
+		c.engine.actionHandler = c.actionHandler
+		if c.actionHandler != nil {
+			c.engine.savePrivateKey = c.savePrivateKey
+		}
 		c.engineMutex.Unlock()
 
 		err = c.engine.Start()
@@ -261,6 +302,9 @@ func (c *ConnectClient) run(
 		log.Infof("Netbird engine started, the IP is: %s", peerConfig.GetAddress())
 		state.Set(StatusConnected)
 
+		// the power monitor outlives the engine restarts, it stops with the engine context
+		go powermonitor.New().Start(engineCtx, c.engine.Suspend, c.engine.Resume)
+
 		<-engineCtx.Done()
 		c.statusRecorder.ClientTeardown()
 
@@ -293,6 +337,12 @@ func (c *ConnectClient) run(
 	return nil
 }
 
+// savePrivateKey persists the WireGuard key of a remote key rotation, it is used from the next connect on
+func (c *ConnectClient) savePrivateKey(key wgtypes.Key) error {
+	c.config.PrivateKey = key.String()
+	return c.actionHandler.SaveConfig(c.config)
+}
+
 func (c *ConnectClient) Engine() *Engine {
 	var e *Engine
 	c.engineMutex.Lock()
@@ -304,19 +354,72 @@ func (c *ConnectClient) Engine() *Engine {
 // createEngineConfig converts configuration received from Management Service to EngineConfig
 func createEngineConfig(key wgtypes.Key, config *Config, peerConfig *mgmProto.PeerConfig) (*EngineConfig, error) {
 	engineConf := &EngineConfig{
-		WgIfaceName:          config.WgIface,
-		WgAddr:               peerConfig.Address,
-		IFaceBlackList:       config.IFaceBlackList,
-		DisableIPv6Discovery: config.DisableIPv6Discovery,
-		WgPrivateKey:         key,
-		WgPort:               config.WgPort,
-		NetworkMonitor:       config.NetworkMonitor,
-		SSHKey:               []byte(config.SSHKey),
-		NATExternalIPs:       config.NATExternalIPs,
-		CustomDNSAddress:     config.CustomDNSAddress,
-		RosenpassEnabled:     config.RosenpassEnabled,
-		RosenpassPermissive:  config.RosenpassPermissive,
-		ServerSSHAllowed:     util.ReturnBoolWithDefaultTrue(config.ServerSSHAllowed),
+		WgIfaceName:            config.WgIface,
+		WgAddr:                 peerConfig.Address,
+		IFaceBlackList:         config.IFaceBlackList,
+		DisableIPv6Discovery:   config.DisableIPv6Discovery,
+		WgPrivateKey:           key,
+		WgPort:                 config.WgPort,
+		WgMode:                 iface.WGMode(config.WgMode),
+		MTU:                    config.MTU,
+		ManagementMTU:          int(peerConfig.GetMtu()),
+		PMTUDiscovery:          config.PMTUDiscovery,
+		NetworkMonitor:         config.NetworkMonitor,
+		SSHKey:                 []byte(config.SSHKey),
+		NATExternalIPs:         config.NATExternalIPs,
+		CustomDNSAddress:       config.CustomDNSAddress,
+		DNSCacheMinTTL:         config.DNSCacheMinTTL,
+		DNSCacheMaxTTL:         config.DNSCacheMaxTTL,
+		DNSQueryLog:            config.DNSQueryLog,
+		DNSHostOverrides:       config.DNSHostOverrides,
+		DropLog:                config.DropLog,
+		LazyFirewallRules:      config.LazyFirewallRules,
+		LazyFirewallIdle:       config.LazyFirewallIdleTimeout,
+		DisableDNS:             config.DisableDNS,
+		DisableClientRoutes:    config.DisableClientRoutes,
+		ControlPlane:           config.ControlPlane,
+		ManagementControlPlane: peerConfig.GetControlPlane(),
+		LowMemory:              config.LowMemory,
+		DisableSysctl:          config.DisableSysctl,
+		RosenpassEnabled:       config.RosenpassEnabled,
+		RosenpassPermissive:    config.RosenpassPermissive,
+		ManagementRosenpass:    toRosenpassConfig(peerConfig.GetRosenpass()),
+		ManagementFeatureFlags: toFeatureFlags(peerConfig.GetFeatureFlags()),
+		ServerSSHAllowed:       util.ReturnBoolWithDefaultTrue(config.ServerSSHAllowed) && !config.LowMemory,
+		SSHRecordingDir:        config.SSHRecordingDir,
+		SSHRecordingRetention:  config.SSHRecordingRetention,
+		StageNetworkChanges:    config.StageNetworkChanges,
+		StagedChangesAutoApply: config.StagedChangesAutoApply,
+		AutoUpdate:             config.AutoUpdate && config.Profile == "",
+		UpdatePublicKey:        config.UpdatePublicKey,
+		UpdateAllowDowngrade:   config.UpdateAllowDowngrade,
+		TCPFallbackPort:        config.TCPFallbackPort,
+		Uplink:                 config.Uplink,
+		ManagementUplink:       peerConfig.GetUplink(),
+		ManagementICE:          toICEConfig(peerConfig.GetIce()),
+	}
+
+	ice, err := localICEConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("parse ICE settings: %w", err)
+	}
+	engineConf.ICE = ice
+
+	if config.WgPortPool != "" {
+		pool, err := parsePortPool(config.WgPortPool)
+		if err != nil {
+			return nil, fmt.Errorf("parse Wireguard port pool: %w", err)
+		}
+		engineConf.WgPortPool = pool
+		engineConf.WgPortRoamingInterval = config.WgPortRoamingInterval
+	}
+
+	if config.ManagementURL != nil {
+		port := config.ManagementURL.Port()
+		if port == "" {
+			port = "443"
+		}
+		engineConf.PMTUProbeAddress = net.JoinHostPort(config.ManagementURL.Hostname(), port)
 	}
 
 	if config.PreSharedKey != "" {
@@ -349,7 +452,7 @@ func connectToSignal(ctx context.Context, wtConfig *mgmProto.WiretrusteeConfig,
 }
 
 // loginToManagement creates Management Services client, establishes a connection, logs-in and gets a global Wiretrustee config (signal, turn, stun hosts, etc)
-func loginToManagement(ctx context.Context, client mgm.Client, pubSSHKey []byte) (*mgmProto.LoginResponse, error) {
+func loginToManagement(ctx context.Context, client mgm.Client, pubSSHKey []byte, requestedAddress string) (*mgmProto.LoginResponse, error) {
 
 	serverPublicKey, err := client.GetServerPublicKey()
 	if err != nil {
@@ -357,7 +460,7 @@ func loginToManagement(ctx context.Context, client mgm.Client, pubSSHKey []byte)
 	}
 
 	sysInfo := system.GetInfo(ctx)
-	loginResp, err := client.Login(*serverPublicKey, sysInfo, pubSSHKey)
+	loginResp, err := client.Login(*serverPublicKey, sysInfo, pubSSHKey, requestedAddress)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// The parts of the host the client manages, the control plane lists the ones it manages
+const (
+	// ControlPlaneDNS applies the DNS configuration of the network map
+	ControlPlaneDNS = "dns"
+	// ControlPlaneRoutes installs the routes of the network map, both the client routes and the routes the peer serves
+	ControlPlaneRoutes = "routes"
+	// ControlPlaneACL enforces the firewall rules of the network map. Without it the client installs no firewall rules
+	// at all, the forwarding and masquerading of the routes the peer serves included
+	ControlPlaneACL = "acl"
+)
+
+var controlPlaneParts = []string{ControlPlaneDNS, ControlPlaneRoutes, ControlPlaneACL}
+
+// controlPlane are the parts of the host the engine manages
+type controlPlane struct {
+	dns    bool
+	routes bool
+	acl    bool
+}
+
+// ParseControlPlane validates the parts of a control plane: dns, routes or acl
+func ParseControlPlane(parts []string) error {
+	for i, part := range parts {
+		if !slices.Contains(controlPlaneParts, part) {
+			return fmt.Errorf("unknown control plane part %q, expected one of %v", part, controlPlaneParts)
+		}
+		if slices.Contains(parts[:i], part) {
+			return fmt.Errorf("control plane part %q is listed twice", part)
+		}
+	}
+	return nil
+}
+
+// toControlPlane returns the parts of the host listed, all of them when none is
+func toControlPlane(parts []string) controlPlane {
+	if len(parts) == 0 {
+		return controlPlane{dns: true, routes: true, acl: true}
+	}
+	return controlPlane{
+		dns:    slices.Contains(parts, ControlPlaneDNS),
+		routes: slices.Contains(parts, ControlPlaneRoutes),
+		acl:    slices.Contains(parts, ControlPlaneACL),
+	}
+}
+
+// targetControlPlane returns the parts of the host the engine manages. The locally configured control plane overrides
+// the one pushed by the management service, DisableDNS and DisableClientRoutes still apply
+func (e *Engine) targetControlPlane() controlPlane {
+	parts := e.config.ManagementControlPlane
+	if len(e.config.ControlPlane) > 0 {
+		parts = e.config.ControlPlane
+	}
+	if err := ParseControlPlane(parts); err != nil {
+		log.Warnf("ignoring the control plane %v: %v", parts, err)
+		parts = nil
+	}
+
+	target := toControlPlane(parts)
+	target.dns = target.dns && !e.config.DisableDNS
+	return target
+}
+
+// onManagementControlPlaneChange restarts the engine when the control plane pushed by the management service changes
+// the applied one, as the firewall is set up on start and the DNS configuration and the routes are applied again then
+func (e *Engine) onManagementControlPlaneChange(parts []string) {
+	log.Debugf("management control plane changed from %v to %v", e.config.ManagementControlPlane, parts)
+	e.config.ManagementControlPlane = parts
+
+	if e.targetControlPlane() == e.controlPlane {
+		return
+	}
+
+	log.Infof("control plane changed, restarting engine")
+	e.restart()
+}
@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+)
+
+func TestParseControlPlane(t *testing.T) {
+	assert.NoError(t, ParseControlPlane(nil))
+	assert.NoError(t, ParseControlPlane([]string{ControlPlaneRoutes, ControlPlaneACL}))
+	assert.Error(t, ParseControlPlane([]string{"firewall"}))
+	assert.Error(t, ParseControlPlane([]string{ControlPlaneDNS, ControlPlaneDNS}))
+}
+
+func TestEngine_targetControlPlane(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   EngineConfig
+		expected controlPlane
+	}{
+		{
+			name:     "unset",
+			expected: controlPlane{dns: true, routes: true, acl: true},
+		},
+		{
+			name:     "management",
+			config:   EngineConfig{ManagementControlPlane: []string{ControlPlaneRoutes, ControlPlaneDNS}},
+			expected: controlPlane{dns: true, routes: true},
+		},
+		{
+			name:     "local overrides the management",
+			config:   EngineConfig{ControlPlane: []string{ControlPlaneRoutes}, ManagementControlPlane: []string{ControlPlaneACL}},
+			expected: controlPlane{routes: true},
+		},
+		{
+			name:     "DNS disabled",
+			config:   EngineConfig{DisableDNS: true, ManagementControlPlane: []string{ControlPlaneDNS, ControlPlaneACL}},
+			expected: controlPlane{acl: true},
+		},
+		{
+			name:     "invalid management control plane",
+			config:   EngineConfig{ManagementControlPlane: []string{"sysctl"}},
+			expected: controlPlane{dns: true, routes: true, acl: true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			engine := &Engine{config: &tc.config}
+			assert.Equal(t, tc.expected, engine.targetControlPlane())
+		})
+	}
+}
+
+func TestEngine_toNetworkChanges_ControlPlane(t *testing.T) {
+	networkMap := testNetworkMap(1, testLANRoute)
+	networkMap.DNSConfig = &mgmProto.DNSConfig{ServiceEnable: true}
+
+	engine := &Engine{config: &EngineConfig{ControlPlane: []string{ControlPlaneDNS}}}
+	changes := engine.toNetworkChanges(networkMap)
+	assert.Empty(t, changes.routes, "the routes shouldn't be installed without the routes control plane")
+	assert.True(t, changes.dns.GetServiceEnable())
+
+	engine.config.ControlPlane = []string{ControlPlaneRoutes}
+	changes = engine.toNetworkChanges(networkMap)
+	assert.Len(t, changes.routes, 1)
+	assert.False(t, changes.dns.GetServiceEnable(), "the DNS configuration shouldn't be applied without the dns control plane")
+}
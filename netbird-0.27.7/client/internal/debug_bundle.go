@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+)
+
+// InterfaceDebugInfo describes the WireGuard interface and its peers for the debug bundle. It holds no keys but the
+// public ones.
+func (e *Engine) InterfaceDebugInfo() (string, error) {
+	e.syncMsgMux.Lock()
+	defer e.syncMsgMux.Unlock()
+
+	if e.wgInterface == nil {
+		return "", fmt.Errorf("the WireGuard interface is not up")
+	}
+
+	stats, err := e.wgInterface.GetAllStats()
+	if err != nil {
+		return "", fmt.Errorf("get WireGuard stats: %w", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Interface: %s\n", e.wgInterface.Name())
+	fmt.Fprintf(&b, "Address: %s\n", e.wgInterface.Address().String())
+	fmt.Fprintf(&b, "Listen port: %d\n", e.config.WgPort)
+	fmt.Fprintf(&b, "MTU: %d\n", e.targetMTU())
+	fmt.Fprintf(&b, "Public key: %s\n", e.config.WgPrivateKey.PublicKey().String())
+	if e.wgInterface.IsUserspaceBind() {
+		fmt.Fprintf(&b, "Implementation: userspace (%s)\n", e.wgInterface.UserspaceReason())
+	} else {
+		b.WriteString("Implementation: kernel\n")
+	}
+
+	keys := make([]string, 0, len(e.peerConns))
+	for key := range e.peerConns {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(&b, "\nPeers: %d\n", len(keys))
+	for _, key := range keys {
+		peerStats := stats[key]
+		endpoint := peerStats.Endpoint
+		if endpoint == "" {
+			endpoint = "-"
+		}
+		handshake := "-"
+		if !peerStats.LastHandshake.IsZero() {
+			handshake = peerStats.LastHandshake.UTC().Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(&b, "\n  Public key: %s\n", key)
+		fmt.Fprintf(&b, "  Allowed IPs: %s\n", e.peerConns[key].WgConfig().AllowedIps)
+		fmt.Fprintf(&b, "  Endpoint: %s\n", endpoint)
+		fmt.Fprintf(&b, "  Last handshake: %s\n", handshake)
+		fmt.Fprintf(&b, "  Transfer: %d B received, %d B sent\n", peerStats.RxBytes, peerStats.TxBytes)
+	}
+
+	return b.String(), nil
+}
+
+// UploadDebugBundle sends the debug bundle to the management service for support and returns the name it was stored
+// under
+func (e *Engine) UploadDebugBundle(bundle []byte) (string, error) {
+	resp, err := e.mgmClient.UploadDebugBundle(&mgmProto.DebugBundleUpload{Content: bundle})
+	if err != nil {
+		return "", err
+	}
+	return resp.GetName(), nil
+}
@@ -0,0 +1,298 @@
+package dns
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	log "github.com/sirupsen/logrus"
+
+	nbdns "github.com/netbirdio/netbird/dns"
+)
+
+const (
+	blocklistFetchTimeout = 30 * time.Second
+	// blocklistRetryInterval is how soon a list that couldn't be downloaded is retried
+	blocklistRetryInterval = 5 * time.Minute
+	// maxBlocklistSize caps the size of a downloaded list
+	maxBlocklistSize = 64 << 20
+	// blockedTTL is the TTL of the 0.0.0.0 and :: answers
+	blockedTTL = 60
+)
+
+// hostsLocalNames are the names of the hosts-format lists that point to the host itself rather than blocking a domain
+var hostsLocalNames = map[string]struct{}{
+	"localhost.":             {},
+	"localhost.localdomain.": {},
+	"local.":                 {},
+	"broadcasthost.":         {},
+	"ip6-localhost.":         {},
+	"ip6-loopback.":          {},
+	"ip6-localnet.":          {},
+	"ip6-mcastprefix.":       {},
+	"ip6-allnodes.":          {},
+	"ip6-allrouters.":        {},
+	"ip6-allhosts.":          {},
+	"0.0.0.0.":               {},
+}
+
+// blocklist keeps the domains of the deny lists distributed by the management service and downloads them again on
+// every refresh interval. A list that can't be downloaded keeps the domains of its previous download
+type blocklist struct {
+	ctx   context.Context
+	fetch func(ctx context.Context, url string) (io.ReadCloser, error)
+
+	mu       sync.RWMutex
+	config   nbdns.BlocklistConfig
+	response nbdns.BlockResponse
+	lists    map[string]map[string]struct{}
+	cancel   context.CancelFunc
+}
+
+func newBlocklist(ctx context.Context) *blocklist {
+	return &blocklist{
+		ctx:      ctx,
+		fetch:    fetchBlocklist,
+		response: nbdns.BlockResponseNXDomain,
+		lists:    make(map[string]map[string]struct{}),
+	}
+}
+
+// update applies the deny lists received from the management service, the lists are downloaded in the background
+func (b *blocklist) update(config nbdns.BlocklistConfig) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if slices.Equal(b.config.URLs, config.URLs) && b.config.RefreshInterval == config.RefreshInterval {
+		b.config = config
+		b.response = blockResponse(config.Response)
+		return
+	}
+
+	if b.cancel != nil {
+		b.cancel()
+		b.cancel = nil
+	}
+
+	b.config = config
+	b.response = blockResponse(config.Response)
+	for url := range b.lists {
+		if !slices.Contains(config.URLs, url) {
+			delete(b.lists, url)
+		}
+	}
+
+	if len(config.URLs) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(b.ctx)
+	b.cancel = cancel
+	go b.refresh(ctx, slices.Clone(config.URLs), config.RefreshInterval)
+}
+
+// refresh downloads the lists until the context is done, lists that fail to download are retried sooner
+func (b *blocklist) refresh(ctx context.Context, urls []string, interval time.Duration) {
+	if interval <= 0 {
+		interval = nbdns.DefaultBlocklistRefreshInterval
+	}
+
+	for {
+		wait := interval
+		if !b.load(ctx, urls) {
+			wait = min(interval, blocklistRetryInterval)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// load downloads the lists, it returns false if any of them failed
+func (b *blocklist) load(ctx context.Context, urls []string) bool {
+	loaded := true
+	for _, url := range urls {
+		domains, err := b.download(ctx, url)
+		if ctx.Err() != nil {
+			return true
+		}
+		if err != nil {
+			log.Warnf("failed to download DNS blocklist %s: %v", url, err)
+			loaded = false
+			continue
+		}
+
+		b.mu.Lock()
+		if slices.Contains(b.config.URLs, url) {
+			b.lists[url] = domains
+		}
+		b.mu.Unlock()
+		log.Debugf("loaded %d domains from DNS blocklist %s", len(domains), url)
+	}
+	return loaded
+}
+
+func (b *blocklist) download(ctx context.Context, url string) (map[string]struct{}, error) {
+	body, err := b.fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := body.Close(); err != nil {
+			log.Debugf("failed to close DNS blocklist response body: %v", err)
+		}
+	}()
+
+	return parseHostsBlocklist(io.LimitReader(body, maxBlocklistSize))
+}
+
+// blocked returns true if the name is on one of the lists
+func (b *blocklist) blocked(name string) bool {
+	name = strings.ToLower(dns.Fqdn(name))
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, domains := range b.lists {
+		if _, found := domains[name]; found {
+			return true
+		}
+	}
+	return false
+}
+
+// reply returns the answer to the query of a blocked name
+func (b *blocklist) reply(r *dns.Msg) *dns.Msg {
+	b.mu.RLock()
+	response := b.response
+	b.mu.RUnlock()
+
+	if response != nbdns.BlockResponseZeroIP {
+		return new(dns.Msg).SetRcode(r, dns.RcodeNameError)
+	}
+
+	rm := new(dns.Msg).SetReply(r)
+	question := r.Question[0]
+	header := dns.RR_Header{Name: question.Name, Rrtype: question.Qtype, Class: dns.ClassINET, Ttl: blockedTTL}
+	switch question.Qtype {
+	case dns.TypeA:
+		rm.Answer = append(rm.Answer, &dns.A{Hdr: header, A: net.IPv4zero})
+	case dns.TypeAAAA:
+		rm.Answer = append(rm.Answer, &dns.AAAA{Hdr: header, AAAA: net.IPv6zero})
+	}
+	return rm
+}
+
+func blockResponse(response nbdns.BlockResponse) nbdns.BlockResponse {
+	parsed, err := nbdns.ParseBlockResponse(string(response))
+	if err != nil {
+		log.Warnf("%v, answering the blocked domains with NXDOMAIN", err)
+		return nbdns.BlockResponseNXDomain
+	}
+	return parsed
+}
+
+func fetchBlocklist(ctx context.Context, url string) (io.ReadCloser, error) {
+	ctx, cancel := context.WithTimeout(ctx, blocklistFetchTimeout)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return &cancelReadCloser{ReadCloser: resp.Body, cancel: cancel}, nil
+}
+
+// cancelReadCloser releases the request context when the body is closed
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelReadCloser) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// parseHostsBlocklist returns the domains of a hosts-format list, e.g. "0.0.0.0 ads.example.com".
+// Lines with a single domain are accepted as well, comments and the names of the host itself are skipped
+func parseHostsBlocklist(r io.Reader) (map[string]struct{}, error) {
+	domains := make(map[string]struct{})
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		names := fields
+		if len(fields) > 1 {
+			if _, err := netip.ParseAddr(fields[0]); err != nil {
+				continue
+			}
+			names = fields[1:]
+		}
+
+		for _, name := range names {
+			name = strings.ToLower(dns.Fqdn(name))
+			if _, local := hostsLocalNames[name]; local {
+				continue
+			}
+			if _, ok := dns.IsDomainName(name); !ok {
+				continue
+			}
+			domains[name] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read blocklist: %w", err)
+	}
+
+	return domains, nil
+}
+
+// blocklistHandler answers the queries of the names on the deny lists and passes the other ones to its handler
+type blocklistHandler struct {
+	handler   dns.Handler
+	blocklist *blocklist
+}
+
+// ServeDNS handles a DNS request
+func (h *blocklistHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	if len(r.Question) == 0 || !h.blocklist.blocked(r.Question[0].Name) {
+		h.handler.ServeDNS(w, r)
+		return
+	}
+
+	log.Tracef("blocking query for %s as it is on a DNS blocklist", r.Question[0].Name)
+	setQueryDecision(w, QueryDecisionBlocked)
+	if err := w.WriteMsg(h.blocklist.reply(r)); err != nil {
+		log.Errorf("failed to write DNS response: %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	nbdns "github.com/netbirdio/netbird/dns"
+)
+
+func TestParseHostsBlocklist(t *testing.T) {
+	list := `# hosts-format deny list
+127.0.0.1 localhost
+::1 localhost ip6-localhost ip6-loopback
+0.0.0.0 0.0.0.0
+0.0.0.0 Ads.Example.com # inline comment
+0.0.0.0 tracker.example.com metrics.example.com
+127.0.0.1	tab.example.com
+bare.example.com
+not-an-ip example.org
+
+`
+	domains, err := parseHostsBlocklist(strings.NewReader(list))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]struct{}{
+		"ads.example.com.":     {},
+		"tracker.example.com.": {},
+		"metrics.example.com.": {},
+		"tab.example.com.":     {},
+		"bare.example.com.":    {},
+	}, domains)
+}
+
+// fakeBlocklistFetcher serves the lists from memory and counts the downloads
+type fakeBlocklistFetcher struct {
+	mu        sync.Mutex
+	lists     map[string]string
+	downloads map[string]int
+}
+
+func (f *fakeBlocklistFetcher) fetch(_ context.Context, url string) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.downloads[url]++
+	list, found := f.lists[url]
+	if !found {
+		return nil, fmt.Errorf("unexpected status 404 Not Found")
+	}
+	return io.NopCloser(strings.NewReader(list)), nil
+}
+
+func newTestBlocklist(t *testing.T, lists map[string]string) (*blocklist, *fakeBlocklistFetcher) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	fetcher := &fakeBlocklistFetcher{lists: lists, downloads: make(map[string]int)}
+	b := newBlocklist(ctx)
+	b.fetch = fetcher.fetch
+	return b, fetcher
+}
+
+func TestBlocklist_Update(t *testing.T) {
+	b, fetcher := newTestBlocklist(t, map[string]string{
+		"https://example.com/ads":      "0.0.0.0 ads.example.com",
+		"https://example.com/tracking": "0.0.0.0 tracker.example.com",
+	})
+
+	b.update(nbdns.BlocklistConfig{URLs: []string{"https://example.com/ads", "https://example.com/tracking", "https://example.com/missing"}})
+	require.Eventually(t, func() bool {
+		return b.blocked("ads.example.com") && b.blocked("tracker.example.com.")
+	}, time.Second, 10*time.Millisecond, "should download the lists")
+	assert.True(t, b.blocked("ADS.example.com."), "should match the name case insensitively")
+	assert.False(t, b.blocked("sub.ads.example.com."), "should only match the listed names")
+
+	b.update(nbdns.BlocklistConfig{URLs: []string{"https://example.com/tracking"}})
+	assert.False(t, b.blocked("ads.example.com."), "should drop the removed lists")
+	require.Eventually(t, func() bool {
+		fetcher.mu.Lock()
+		defer fetcher.mu.Unlock()
+		return fetcher.downloads["https://example.com/tracking"] == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.True(t, b.blocked("tracker.example.com."), "should keep the remaining lists")
+
+	b.update(nbdns.BlocklistConfig{})
+	assert.False(t, b.blocked("tracker.example.com."), "should drop all the lists")
+}
+
+func TestBlocklistHandler_ServeDNS(t *testing.T) {
+	b, _ := newTestBlocklist(t, nil)
+	b.lists["https://example.com/ads"] = map[string]struct{}{"ads.example.com.": {}}
+
+	var forwarded int
+	handler := &blocklistHandler{
+		handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+			forwarded++
+			_ = w.WriteMsg(new(dns.Msg).SetReply(r))
+		}),
+		blocklist: b,
+	}
+	queryLog := newQueryLog(10)
+	queryLog.setEnabled(true)
+	logged := newQueryLogHandler(handler, queryLog)
+
+	serve := func(name string, qtype uint16) *dns.Msg {
+		var response *dns.Msg
+		logged.ServeDNS(&mockResponseWriter{
+			WriteMsgFunc: func(m *dns.Msg) error {
+				response = m
+				return nil
+			},
+		}, new(dns.Msg).SetQuestion(name, qtype))
+		require.NotNil(t, response)
+		return response
+	}
+
+	response := serve("ads.example.com.", dns.TypeA)
+	assert.Equal(t, dns.RcodeNameError, response.Rcode, "should answer with NXDOMAIN by default")
+	assert.Equal(t, 0, forwarded)
+
+	serve("www.example.com.", dns.TypeA)
+	assert.Equal(t, 1, forwarded, "should forward the names that aren't listed")
+
+	b.update(nbdns.BlocklistConfig{Response: nbdns.BlockResponseZeroIP})
+	b.lists["https://example.com/ads"] = map[string]struct{}{"ads.example.com.": {}}
+
+	response = serve("ads.example.com.", dns.TypeA)
+	require.Len(t, response.Answer, 1)
+	assert.True(t, response.Answer[0].(*dns.A).A.Equal(net.IPv4zero))
+
+	response = serve("ads.example.com.", dns.TypeAAAA)
+	require.Len(t, response.Answer, 1)
+	assert.True(t, response.Answer[0].(*dns.AAAA).AAAA.Equal(net.IPv6zero))
+
+	response = serve("ads.example.com.", dns.TypeTXT)
+	assert.Equal(t, dns.RcodeSuccess, response.Rcode)
+	assert.Empty(t, response.Answer, "should answer the other types with no data")
+
+	_, stats := queryLog.snapshot()
+	assert.Equal(t, uint64(4), stats.Blocked)
+	assert.Equal(t, uint64(1), stats.Forwarded)
+}
@@ -0,0 +1,266 @@
+package dns
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+)
+
+const (
+	defaultCacheSize   = 4096
+	defaultCacheMaxTTL = 24 * time.Hour
+	// LowMemoryCacheSize is the size of the cache in the low memory profile, it keeps the cache within
+	// LowMemoryCacheCeiling with typical responses
+	LowMemoryCacheSize = 256
+	// LowMemoryCacheCeiling is the heap used by a full cache of LowMemoryCacheSize responses
+	LowMemoryCacheCeiling = 1 << 20
+	// maxNegativeCacheTTL caps the negative answers as suggested by RFC 2308
+	maxNegativeCacheTTL = 3 * time.Hour
+)
+
+// CacheConfig configures the TTL clamps and the size of the upstream response cache
+type CacheConfig struct {
+	// MinTTL is the minimum time a response is cached for, zero keeps the TTL of the records
+	MinTTL time.Duration
+	// MaxTTL is the maximum time a response is cached for, zero means the default of 24 hours
+	MaxTTL time.Duration
+	// MaxEntries is the number of responses the cache holds, zero keeps the size of the cache
+	MaxEntries int
+	// Disabled sends every query to the upstream nameservers
+	Disabled bool
+}
+
+type cacheKey struct {
+	name   string
+	qtype  uint16
+	qclass uint16
+	dnssec bool
+}
+
+type cacheEntry struct {
+	key     cacheKey
+	msg     *dns.Msg
+	stored  time.Time
+	expires time.Time
+}
+
+// responseCache is an LRU cache of the upstream responses, including the negative ones (NXDOMAIN and NODATA).
+// The record TTLs are clamped to the configured range and counted down when a response is served from the cache
+type responseCache struct {
+	mu         sync.Mutex
+	entries    map[cacheKey]*list.Element
+	lru        *list.List
+	maxEntries int
+	minTTL     time.Duration
+	maxTTL     time.Duration
+	disabled   bool
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+
+	now func() time.Time
+}
+
+func newResponseCache(maxEntries int) *responseCache {
+	return &responseCache{
+		entries:    make(map[cacheKey]*list.Element),
+		lru:        list.New(),
+		maxEntries: maxEntries,
+		maxTTL:     defaultCacheMaxTTL,
+		now:        time.Now,
+	}
+}
+
+// setConfig applies the TTL clamps and the size and flushes the responses cached with the previous ones
+func (c *responseCache) setConfig(config CacheConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if config.MaxEntries > 0 {
+		c.maxEntries = config.MaxEntries
+	}
+
+	c.minTTL = config.MinTTL
+	c.maxTTL = config.MaxTTL
+	if c.maxTTL == 0 {
+		c.maxTTL = defaultCacheMaxTTL
+	}
+	if c.minTTL > c.maxTTL {
+		c.minTTL = c.maxTTL
+	}
+	c.disabled = config.Disabled
+	c.flushLocked()
+}
+
+// get returns a copy of the cached response to the query with the remaining TTLs, nil when there is none
+func (c *responseCache) get(r *dns.Msg) *dns.Msg {
+	key, ok := newCacheKey(r)
+	if !ok {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.disabled {
+		return nil
+	}
+
+	element, found := c.entries[key]
+	if !found {
+		c.misses++
+		return nil
+	}
+
+	entry := element.Value.(*cacheEntry)
+	now := c.now()
+	if !now.Before(entry.expires) {
+		c.removeLocked(element)
+		c.misses++
+		return nil
+	}
+
+	c.lru.MoveToFront(element)
+	c.hits++
+
+	elapsed := uint32(now.Sub(entry.stored) / time.Second)
+	rm := entry.msg.Copy()
+	rm.Id = r.Id
+	for _, rrs := range [][]dns.RR{rm.Answer, rm.Ns, rm.Extra} {
+		for _, rr := range rrs {
+			if rr.Header().Rrtype == dns.TypeOPT {
+				continue
+			}
+			rr.Header().Ttl -= min(elapsed, rr.Header().Ttl)
+		}
+	}
+	return rm
+}
+
+// set caches the upstream response to the query if it is cacheable
+func (c *responseCache) set(r, rm *dns.Msg) {
+	key, ok := newCacheKey(r)
+	if !ok || rm.Truncated || len(rm.Question) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.disabled {
+		return
+	}
+
+	ttl, ok := c.responseTTL(rm)
+	if !ok {
+		return
+	}
+
+	msg := rm.Copy()
+	for _, rrs := range [][]dns.RR{msg.Answer, msg.Ns, msg.Extra} {
+		for _, rr := range rrs {
+			if rr.Header().Rrtype == dns.TypeOPT {
+				continue
+			}
+			rr.Header().Ttl = uint32(c.clamp(time.Duration(rr.Header().Ttl)*time.Second) / time.Second)
+		}
+	}
+
+	now := c.now()
+	entry := &cacheEntry{key: key, msg: msg, stored: now, expires: now.Add(ttl)}
+
+	if element, found := c.entries[key]; found {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.removeLocked(c.lru.Back())
+		c.evictions++
+	}
+}
+
+// responseTTL returns how long the response can be cached: the lowest record TTL for positive answers,
+// the SOA minimum for negative ones as described in RFC 2308
+func (c *responseCache) responseTTL(rm *dns.Msg) (time.Duration, bool) {
+	switch {
+	case rm.Rcode == dns.RcodeSuccess && len(rm.Answer) > 0:
+		lowest := rm.Answer[0].Header().Ttl
+		for _, rr := range rm.Answer[1:] {
+			lowest = min(lowest, rr.Header().Ttl)
+		}
+		return c.clamp(time.Duration(lowest) * time.Second), true
+	case rm.Rcode == dns.RcodeSuccess || rm.Rcode == dns.RcodeNameError:
+		for _, rr := range rm.Ns {
+			if soa, ok := rr.(*dns.SOA); ok {
+				ttl := time.Duration(min(soa.Hdr.Ttl, soa.Minttl)) * time.Second
+				return min(c.clamp(ttl), maxNegativeCacheTTL), true
+			}
+		}
+		// negative answers without SOA must not be cached
+		return 0, false
+	default:
+		return 0, false
+	}
+}
+
+func (c *responseCache) clamp(ttl time.Duration) time.Duration {
+	return min(max(ttl, c.minTTL), c.maxTTL)
+}
+
+func (c *responseCache) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flushLocked()
+}
+
+func (c *responseCache) flushLocked() {
+	c.entries = make(map[cacheKey]*list.Element)
+	c.lru.Init()
+}
+
+func (c *responseCache) removeLocked(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry).key)
+}
+
+// stats returns the state of the cache for the status report
+func (c *responseCache) stats() peer.DNSCacheState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return peer.DNSCacheState{
+		Entries:   c.lru.Len(),
+		Capacity:  c.maxEntries,
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		MinTTL:    c.minTTL,
+		MaxTTL:    c.maxTTL,
+	}
+}
+
+func newCacheKey(r *dns.Msg) (cacheKey, bool) {
+	if len(r.Question) != 1 {
+		return cacheKey{}, false
+	}
+
+	question := r.Question[0]
+	key := cacheKey{
+		name:   strings.ToLower(dns.Fqdn(question.Name)),
+		qtype:  question.Qtype,
+		qclass: question.Qclass,
+	}
+	if opt := r.IsEdns0(); opt != nil {
+		key.dnssec = opt.Do()
+	}
+	return key, true
+}
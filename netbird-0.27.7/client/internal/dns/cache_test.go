@@ -0,0 +1,215 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestCache(maxEntries int, config CacheConfig) (*responseCache, *fakeClock) {
+	clock := &fakeClock{now: time.Now()}
+	cache := newResponseCache(maxEntries)
+	cache.setConfig(config)
+	cache.now = clock.Now
+	return cache, clock
+}
+
+func testAResponse(r *dns.Msg, ttl uint32) *dns.Msg {
+	rm := new(dns.Msg).SetReply(r)
+	rm.Answer = append(rm.Answer, &dns.A{
+		Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: ttl},
+		A:   net.ParseIP("10.0.0.1"),
+	})
+	return rm
+}
+
+func testNegativeResponse(r *dns.Msg, rcode int, soaTTL, minTTL uint32) *dns.Msg {
+	rm := new(dns.Msg).SetRcode(r, rcode)
+	rm.Ns = append(rm.Ns, &dns.SOA{
+		Hdr:    dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: soaTTL},
+		Ns:     "ns.example.com.",
+		Mbox:   "admin.example.com.",
+		Minttl: minTTL,
+	})
+	return rm
+}
+
+func TestResponseCache_Positive(t *testing.T) {
+	cache, clock := newTestCache(10, CacheConfig{})
+
+	query := new(dns.Msg).SetQuestion("Host.Example.com.", dns.TypeA)
+	cache.set(query, testAResponse(query, 300))
+
+	clock.now = clock.now.Add(100 * time.Second)
+	other := new(dns.Msg).SetQuestion("host.example.com.", dns.TypeA)
+	cached := cache.get(other)
+	require.NotNil(t, cached, "should match the name case insensitively")
+	assert.Equal(t, other.Id, cached.Id, "should answer with the query ID")
+	assert.Equal(t, uint32(200), cached.Answer[0].Header().Ttl, "should count the TTL down")
+
+	assert.Nil(t, cache.get(new(dns.Msg).SetQuestion("host.example.com.", dns.TypeAAAA)), "should not match another type")
+
+	clock.now = clock.now.Add(200 * time.Second)
+	assert.Nil(t, cache.get(query), "should expire with the TTL")
+
+	stats := cache.stats()
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(2), stats.Misses)
+	assert.Equal(t, 0, stats.Entries)
+}
+
+func TestResponseCache_Disabled(t *testing.T) {
+	cache, _ := newTestCache(10, CacheConfig{})
+
+	query := new(dns.Msg).SetQuestion("host.example.com.", dns.TypeA)
+	cache.set(query, testAResponse(query, 300))
+
+	cache.setConfig(CacheConfig{Disabled: true})
+	cache.set(query, testAResponse(query, 300))
+	assert.Nil(t, cache.get(query), "should not answer from the cache")
+	assert.Equal(t, 0, cache.stats().Entries, "should not cache the responses")
+
+	cache.setConfig(CacheConfig{})
+	cache.set(query, testAResponse(query, 300))
+	assert.NotNil(t, cache.get(query), "should cache again once enabled")
+}
+
+func TestResponseCache_TTLClamp(t *testing.T) {
+	cache, clock := newTestCache(10, CacheConfig{MinTTL: time.Minute, MaxTTL: time.Hour})
+
+	short := new(dns.Msg).SetQuestion("short.example.com.", dns.TypeA)
+	cache.set(short, testAResponse(short, 5))
+	long := new(dns.Msg).SetQuestion("long.example.com.", dns.TypeA)
+	cache.set(long, testAResponse(long, 86400))
+
+	clock.now = clock.now.Add(30 * time.Second)
+	cached := cache.get(short)
+	require.NotNil(t, cached, "should cache for the minimum TTL")
+	assert.Equal(t, uint32(30), cached.Answer[0].Header().Ttl)
+
+	clock.now = clock.now.Add(time.Hour)
+	assert.Nil(t, cache.get(long), "should cache for the maximum TTL")
+}
+
+func TestResponseCache_Negative(t *testing.T) {
+	cache, clock := newTestCache(10, CacheConfig{})
+
+	nxdomain := new(dns.Msg).SetQuestion("missing.example.com.", dns.TypeA)
+	cache.set(nxdomain, testNegativeResponse(nxdomain, dns.RcodeNameError, 3600, 60))
+	nodata := new(dns.Msg).SetQuestion("host.example.com.", dns.TypeAAAA)
+	cache.set(nodata, testNegativeResponse(nodata, dns.RcodeSuccess, 30, 600))
+	noSOA := new(dns.Msg).SetQuestion("nosoa.example.com.", dns.TypeA)
+	cache.set(noSOA, new(dns.Msg).SetRcode(noSOA, dns.RcodeNameError))
+	servfail := new(dns.Msg).SetQuestion("broken.example.com.", dns.TypeA)
+	cache.set(servfail, new(dns.Msg).SetRcode(servfail, dns.RcodeServerFailure))
+
+	cached := cache.get(nxdomain)
+	require.NotNil(t, cached, "should cache NXDOMAIN")
+	assert.Equal(t, dns.RcodeNameError, cached.Rcode)
+	require.NotNil(t, cache.get(nodata), "should cache NODATA")
+	assert.Nil(t, cache.get(noSOA), "should not cache negative answers without SOA")
+	assert.Nil(t, cache.get(servfail), "should not cache failures")
+
+	clock.now = clock.now.Add(45 * time.Second)
+	assert.NotNil(t, cache.get(nxdomain), "should cache for the SOA minimum")
+	assert.Nil(t, cache.get(nodata), "should cache for the SOA TTL when lower than its minimum")
+}
+
+func TestResponseCache_Eviction(t *testing.T) {
+	cache, _ := newTestCache(2, CacheConfig{})
+
+	first := new(dns.Msg).SetQuestion("first.example.com.", dns.TypeA)
+	second := new(dns.Msg).SetQuestion("second.example.com.", dns.TypeA)
+	third := new(dns.Msg).SetQuestion("third.example.com.", dns.TypeA)
+
+	cache.set(first, testAResponse(first, 300))
+	cache.set(second, testAResponse(second, 300))
+	require.NotNil(t, cache.get(first))
+	cache.set(third, testAResponse(third, 300))
+
+	assert.NotNil(t, cache.get(first), "should keep the recently used entry")
+	assert.Nil(t, cache.get(second), "should evict the least recently used entry")
+	assert.NotNil(t, cache.get(third))
+
+	stats := cache.stats()
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, 2, stats.Capacity)
+}
+
+func TestResponseCache_LowMemoryCeiling(t *testing.T) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	cache, _ := newTestCache(defaultCacheSize, CacheConfig{MaxEntries: LowMemoryCacheSize})
+	for i := 0; i < 2*LowMemoryCacheSize; i++ {
+		query := new(dns.Msg).SetQuestion(fmt.Sprintf("host-%d.subdomain.example.com.", i), dns.TypeA)
+		rm := testAResponse(query, 300)
+		for j := 0; j < 3; j++ {
+			rm.Answer = append(rm.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: query.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+				A:   net.IPv4(10, 0, byte(i), byte(j)),
+			})
+		}
+		cache.set(query, rm)
+	}
+
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	require.Equal(t, LowMemoryCacheSize, cache.stats().Entries)
+	heap := int64(after.HeapAlloc) - int64(before.HeapAlloc)
+	assert.Less(t, heap, int64(LowMemoryCacheCeiling), "a full cache should stay within the low memory ceiling")
+	runtime.KeepAlive(cache)
+}
+
+type countingUpstreamClient struct {
+	calls int
+}
+
+func (c *countingUpstreamClient) exchange(_ context.Context, _ string, r *dns.Msg) (*dns.Msg, time.Duration, error) {
+	c.calls++
+	return testAResponse(r, 300), time.Millisecond, nil
+}
+
+func TestUpstreamResolver_ServeFromCache(t *testing.T) {
+	client := &countingUpstreamClient{}
+	resolver := &upstreamResolverBase{
+		ctx:             context.TODO(),
+		upstreamClient:  client,
+		upstreamServers: []string{"10.0.0.53:53"},
+		upstreamTimeout: upstreamTimeout,
+		failsTillDeact:  failsTillDeact,
+		cache:           newResponseCache(defaultCacheSize),
+	}
+
+	var responses []*dns.Msg
+	writer := &mockResponseWriter{
+		WriteMsgFunc: func(m *dns.Msg) error {
+			responses = append(responses, m)
+			return nil
+		},
+	}
+
+	resolver.ServeDNS(writer, new(dns.Msg).SetQuestion("host.example.com.", dns.TypeA))
+	resolver.ServeDNS(writer, new(dns.Msg).SetQuestion("host.example.com.", dns.TypeA))
+
+	assert.Equal(t, 1, client.calls, "should query the upstream once")
+	require.Len(t, responses, 2)
+	assert.Equal(t, responses[0].Answer, responses[1].Answer)
+}
@@ -0,0 +1,271 @@
+//go:build !android
+
+package dns
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	openWrtReleasePath = "/etc/openwrt_release"
+	uciCommand         = "uci"
+	ubusCommand        = "ubus"
+
+	// dnsmasqSection is the UCI section of the dnsmasq instance serving the router and the LAN
+	dnsmasqSection        = "dhcp.@dnsmasq[0]"
+	dnsmasqServerOption   = dnsmasqSection + ".server"
+	dnsmasqRebindOption   = dnsmasqSection + ".rebind_domain"
+	dnsmasqNoResolvOption = dnsmasqSection + ".noresolv"
+	dnsmasqReloadMessage  = `{"name":"dnsmasq","action":"reload"}`
+)
+
+// dnsmasq configures the dnsmasq instance of OpenWrt through UCI, so it forwards the NetBird domains to our resolver
+// instead of us taking over the port 53 or the resolv.conf file
+type dnsmasq struct {
+	uci    func(args ...string) (string, error)
+	reload func() error
+
+	servers          []string
+	rebindDomains    []string
+	routingAll       bool
+	originalNoResolv string
+}
+
+func newDnsmasqConfigurator() (hostManager, error) {
+	if _, err := exec.LookPath(uciCommand); err != nil {
+		return nil, fmt.Errorf("find %s: %w", uciCommand, err)
+	}
+
+	return &dnsmasq{
+		uci:    runUCI,
+		reload: reloadDnsmasq,
+	}, nil
+}
+
+// isDnsmasqManaged returns true on OpenWrt when the DNS of the system is served by a UCI configured dnsmasq
+func isDnsmasqManaged() bool {
+	if _, err := os.Stat(openWrtReleasePath); err != nil {
+		return false
+	}
+	if _, err := exec.LookPath(uciCommand); err != nil {
+		return false
+	}
+	sectionType, err := runUCI("-q", "get", dnsmasqSection)
+	return err == nil && sectionType == "dnsmasq"
+}
+
+func (d *dnsmasq) supportCustomPort() bool {
+	return true
+}
+
+func (d *dnsmasq) applyDNSConfig(config HostDNSConfig) error {
+	servers, rebindDomains := dnsmasqEntries(config)
+	if slices.Equal(servers, d.servers) && slices.Equal(rebindDomains, d.rebindDomains) && config.RouteAll == d.routingAll {
+		return nil
+	}
+
+	// create a backup for unclean shutdown detection before dnsmasq is changed.
+	// The file content itself is not important for dnsmasq restoration
+	if err := createUncleanShutdownIndicator(defaultResolvConfPath, dnsmasqManager, config.ServerIP); err != nil {
+		log.Errorf("failed to create unclean shutdown resolv.conf backup: %s", err)
+	}
+
+	if err := d.removeEntries(); err != nil {
+		return fmt.Errorf("remove previous entries: %w", err)
+	}
+
+	for _, server := range servers {
+		if _, err := d.uci("add_list", dnsmasqServerOption+"="+server); err != nil {
+			return fmt.Errorf("add server %s: %w", server, err)
+		}
+	}
+	d.servers = servers
+
+	// the NetBird names resolve to private addresses, dnsmasq would drop them with the rebind protection enabled
+	for _, domain := range rebindDomains {
+		if _, err := d.uci("add_list", dnsmasqRebindOption+"="+domain); err != nil {
+			return fmt.Errorf("add rebind protection exception %s: %w", domain, err)
+		}
+	}
+	d.rebindDomains = rebindDomains
+
+	if config.RouteAll {
+		if err := d.routeAll(); err != nil {
+			return err
+		}
+		log.Infof("configured %s:%d as main DNS forwarder for this peer", config.ServerIP, config.ServerPort)
+	}
+
+	if err := d.commit(); err != nil {
+		return err
+	}
+
+	log.Infof("added %d dnsmasq servers: %s", len(servers), servers)
+	return nil
+}
+
+// routeAll stops dnsmasq from using the upstreams of the resolv file, so all the queries go to our resolver
+func (d *dnsmasq) routeAll() error {
+	if d.routingAll {
+		return nil
+	}
+
+	// the option is usually unset, the error of the missing option is expected
+	original, _ := d.uci("-q", "get", dnsmasqNoResolvOption)
+	if _, err := d.uci("set", dnsmasqNoResolvOption+"=1"); err != nil {
+		return fmt.Errorf("disable the resolv file: %w", err)
+	}
+	d.originalNoResolv = original
+	d.routingAll = true
+	return nil
+}
+
+func (d *dnsmasq) restoreHostDNS() error {
+	if err := d.removeEntries(); err != nil {
+		return fmt.Errorf("remove entries: %w", err)
+	}
+
+	if err := d.commit(); err != nil {
+		return err
+	}
+
+	if err := removeUncleanShutdownIndicator(); err != nil {
+		log.Errorf("failed to remove unclean shutdown resolv.conf backup: %s", err)
+	}
+
+	return nil
+}
+
+// removeEntries removes the applied entries from the UCI configuration, the other entries are kept
+func (d *dnsmasq) removeEntries() error {
+	for _, server := range d.servers {
+		if _, err := d.uci("del_list", dnsmasqServerOption+"="+server); err != nil {
+			return fmt.Errorf("remove server %s: %w", server, err)
+		}
+	}
+	d.servers = nil
+
+	for _, domain := range d.rebindDomains {
+		if _, err := d.uci("del_list", dnsmasqRebindOption+"="+domain); err != nil {
+			return fmt.Errorf("remove rebind protection exception %s: %w", domain, err)
+		}
+	}
+	d.rebindDomains = nil
+
+	if d.routingAll {
+		var err error
+		if d.originalNoResolv == "" {
+			_, err = d.uci("-q", "delete", dnsmasqNoResolvOption)
+		} else {
+			_, err = d.uci("set", dnsmasqNoResolvOption+"="+d.originalNoResolv)
+		}
+		if err != nil {
+			return fmt.Errorf("restore the resolv file: %w", err)
+		}
+		d.routingAll = false
+	}
+
+	return nil
+}
+
+func (d *dnsmasq) commit() error {
+	if _, err := d.uci("commit", "dhcp"); err != nil {
+		return fmt.Errorf("commit dhcp configuration: %w", err)
+	}
+	if err := d.reload(); err != nil {
+		return fmt.Errorf("reload dnsmasq: %w", err)
+	}
+	return nil
+}
+
+// restoreUncleanShutdownDNS removes the servers pointing to the resolver of the previous run. The rebind protection
+// exceptions are left in place as they can't be told apart from the ones of the user
+func (d *dnsmasq) restoreUncleanShutdownDNS(storedDNSAddress *netip.Addr) error {
+	if storedDNSAddress == nil {
+		return nil
+	}
+
+	current, err := d.uci("-q", "get", dnsmasqServerOption)
+	if err != nil {
+		// no servers configured
+		return removeUncleanShutdownIndicator()
+	}
+
+	for _, server := range strings.Fields(current) {
+		if !isDnsmasqServerOf(server, *storedDNSAddress) {
+			continue
+		}
+		if _, err := d.uci("del_list", dnsmasqServerOption+"="+server); err != nil {
+			return fmt.Errorf("remove server %s: %w", server, err)
+		}
+		if !strings.HasPrefix(server, "/") {
+			// the resolver was the main forwarder, give the resolv file back to dnsmasq
+			if _, err := d.uci("-q", "delete", dnsmasqNoResolvOption); err != nil {
+				log.Warnf("failed to restore the dnsmasq resolv file: %s", err)
+			}
+		}
+	}
+
+	if err := d.commit(); err != nil {
+		return err
+	}
+
+	return removeUncleanShutdownIndicator()
+}
+
+// dnsmasqEntries returns the server entries forwarding the domains to our resolver, with the domains needing
+// a rebind protection exception
+func dnsmasqEntries(config HostDNSConfig) (servers []string, rebindDomains []string) {
+	address := config.ServerIP
+	if config.ServerPort != 0 && config.ServerPort != defaultPort {
+		address += "#" + strconv.Itoa(config.ServerPort)
+	}
+
+	for _, domainConfig := range config.Domains {
+		if domainConfig.Disabled || domainConfig.Domain == "" {
+			continue
+		}
+		servers = append(servers, fmt.Sprintf("/%s/%s", domainConfig.Domain, address))
+		rebindDomains = append(rebindDomains, domainConfig.Domain)
+	}
+
+	if config.RouteAll {
+		servers = append(servers, address)
+	}
+
+	return servers, rebindDomains
+}
+
+// isDnsmasqServerOf returns true when the dnsmasq server entry forwards to the given address
+func isDnsmasqServerOf(server string, address netip.Addr) bool {
+	if index := strings.LastIndex(server, "/"); index != -1 {
+		server = server[index+1:]
+	}
+	host, _, _ := strings.Cut(server, "#")
+	addr, err := netip.ParseAddr(host)
+	return err == nil && addr == address
+}
+
+func runUCI(args ...string) (string, error) {
+	out, err := exec.Command(uciCommand, args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %w", uciCommand, strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func reloadDnsmasq() error {
+	out, err := exec.Command(ubusCommand, "call", "rc", "init", dnsmasqReloadMessage).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s call rc init: %w, output: %s", ubusCommand, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+//go:build !android
+
+package dns
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUCI keeps the options of the dnsmasq section in memory, the list options are handled like by uci
+type fakeUCI struct {
+	options   map[string][]string
+	commits   int
+	reloads   int
+	committed map[string][]string
+}
+
+func newFakeUCI() *fakeUCI {
+	return &fakeUCI{options: map[string][]string{
+		dnsmasqServerOption: {"/lan.example/192.168.1.2"},
+		dnsmasqRebindOption: {"lan.example"},
+	}}
+}
+
+func (f *fakeUCI) run(args ...string) (string, error) {
+	if len(args) > 0 && args[0] == "-q" {
+		args = args[1:]
+	}
+	if len(args) < 2 {
+		return "", fmt.Errorf("invalid uci command %s", args)
+	}
+
+	option, value, _ := strings.Cut(args[1], "=")
+	switch args[0] {
+	case "get":
+		values, found := f.options[option]
+		if !found {
+			return "", fmt.Errorf("entry not found")
+		}
+		return strings.Join(values, " "), nil
+	case "set":
+		f.options[option] = []string{value}
+	case "delete":
+		delete(f.options, option)
+	case "add_list":
+		f.options[option] = append(f.options[option], value)
+	case "del_list":
+		f.options[option] = slices.DeleteFunc(f.options[option], func(v string) bool { return v == value })
+	case "commit":
+		f.commits++
+		f.committed = make(map[string][]string, len(f.options))
+		for k, v := range f.options {
+			f.committed[k] = slices.Clone(v)
+		}
+	default:
+		return "", fmt.Errorf("unsupported uci command %s", args[0])
+	}
+	return "", nil
+}
+
+func newTestDnsmasq(uci *fakeUCI) *dnsmasq {
+	return &dnsmasq{
+		uci: uci.run,
+		reload: func() error {
+			uci.reloads++
+			return nil
+		},
+	}
+}
+
+func TestDnsmasqEntries(t *testing.T) {
+	servers, rebindDomains := dnsmasqEntries(HostDNSConfig{
+		ServerIP:   "100.64.0.1",
+		ServerPort: 5053,
+		RouteAll:   true,
+		Domains: []DomainConfig{
+			{Domain: "netbird.cloud"},
+			{Domain: "corp.example.com", MatchOnly: true},
+			{Domain: "disabled.example.com", Disabled: true},
+		},
+	})
+
+	assert.Equal(t, []string{"/netbird.cloud/100.64.0.1#5053", "/corp.example.com/100.64.0.1#5053", "100.64.0.1#5053"}, servers)
+	assert.Equal(t, []string{"netbird.cloud", "corp.example.com"}, rebindDomains)
+
+	servers, _ = dnsmasqEntries(HostDNSConfig{ServerIP: "100.64.0.1", ServerPort: defaultPort, Domains: []DomainConfig{{Domain: "netbird.cloud"}}})
+	assert.Equal(t, []string{"/netbird.cloud/100.64.0.1"}, servers)
+}
+
+func TestDnsmasq_ApplyAndRestore(t *testing.T) {
+	uci := newFakeUCI()
+	configurator := newTestDnsmasq(uci)
+
+	config := HostDNSConfig{
+		ServerIP:   "100.64.0.1",
+		ServerPort: 5053,
+		RouteAll:   true,
+		Domains:    []DomainConfig{{Domain: "netbird.cloud"}},
+	}
+	require.NoError(t, configurator.applyDNSConfig(config))
+
+	assert.Equal(t, []string{"/lan.example/192.168.1.2", "/netbird.cloud/100.64.0.1#5053", "100.64.0.1#5053"}, uci.committed[dnsmasqServerOption])
+	assert.Equal(t, []string{"lan.example", "netbird.cloud"}, uci.committed[dnsmasqRebindOption])
+	assert.Equal(t, []string{"1"}, uci.committed[dnsmasqNoResolvOption])
+	assert.Equal(t, 1, uci.reloads)
+
+	require.NoError(t, configurator.applyDNSConfig(config))
+	assert.Equal(t, 1, uci.reloads, "should not reload dnsmasq for the same configuration")
+
+	config.RouteAll = false
+	config.Domains = append(config.Domains, DomainConfig{Domain: "corp.example.com", MatchOnly: true})
+	require.NoError(t, configurator.applyDNSConfig(config))
+
+	assert.Equal(t, []string{"/lan.example/192.168.1.2", "/netbird.cloud/100.64.0.1#5053", "/corp.example.com/100.64.0.1#5053"}, uci.committed[dnsmasqServerOption])
+	assert.NotContains(t, uci.committed, dnsmasqNoResolvOption, "should restore the resolv file")
+
+	require.NoError(t, configurator.restoreHostDNS())
+	assert.Equal(t, []string{"/lan.example/192.168.1.2"}, uci.committed[dnsmasqServerOption])
+	assert.Equal(t, []string{"lan.example"}, uci.committed[dnsmasqRebindOption])
+	assert.Equal(t, 3, uci.reloads)
+}
+
+func TestDnsmasq_RestoreUncleanShutdown(t *testing.T) {
+	uci := newFakeUCI()
+	uci.options[dnsmasqServerOption] = append(uci.options[dnsmasqServerOption], "/netbird.cloud/100.64.0.1#5053", "100.64.0.1#5053")
+	uci.options[dnsmasqNoResolvOption] = []string{"1"}
+
+	configurator := newTestDnsmasq(uci)
+	address := netip.MustParseAddr("100.64.0.1")
+	require.NoError(t, configurator.restoreUncleanShutdownDNS(&address))
+
+	assert.Equal(t, []string{"/lan.example/192.168.1.2"}, uci.committed[dnsmasqServerOption])
+	assert.NotContains(t, uci.committed, dnsmasqNoResolvOption)
+	assert.Equal(t, 1, uci.reloads)
+}
@@ -78,6 +78,8 @@ func dnsConfigToHostDNSConfig(dnsConfig nbdns.Config, ip string, port int) HostD
 		ServerIP:   ip,
 		ServerPort: port,
 	}
+	// the search domains follow the order of the nameserver groups, a zone shared by groups is configured once
+	seen := make(map[string]struct{})
 	for _, nsConfig := range dnsConfig.NameServerGroups {
 		if len(nsConfig.NameServers) == 0 {
 			continue
@@ -86,7 +88,12 @@ func dnsConfigToHostDNSConfig(dnsConfig nbdns.Config, ip string, port int) HostD
 			config.RouteAll = true
 		}
 
-		for _, domain := range nsConfig.Domains {
+		for _, domain := range matchDomainZones(nsConfig.Domains) {
+			key := strings.ToLower(strings.TrimSuffix(domain, "."))
+			if _, found := seen[key]; found {
+				continue
+			}
+			seen[key] = struct{}{}
 			config.Domains = append(config.Domains, DomainConfig{
 				Domain:    strings.TrimSuffix(domain, "."),
 				MatchOnly: !nsConfig.SearchDomainsEnabled,
@@ -96,8 +103,9 @@ func dnsConfigToHostDNSConfig(dnsConfig nbdns.Config, ip string, port int) HostD
 
 	for _, customZone := range dnsConfig.CustomZones {
 		config.Domains = append(config.Domains, DomainConfig{
-			Domain:    strings.TrimSuffix(customZone.Domain, "."),
-			MatchOnly: false,
+			Domain: strings.TrimSuffix(customZone.Domain, "."),
+			// the reverse zones only route the PTR queries, they are no search domains
+			MatchOnly: nbdns.IsReverseZone(customZone.Domain),
 		})
 	}
 
@@ -19,6 +19,7 @@ const (
 	networkManager
 	systemdManager
 	resolvConfManager
+	dnsmasqManager
 )
 
 var ErrUnknownOsManagerType = errors.New("unknown os manager type")
@@ -37,6 +38,8 @@ func newOsManagerType(osManager string) (osManagerType, error) {
 		return systemdManager, nil
 	case "resolvconf":
 		return resolvConfManager, nil
+	case "dnsmasq":
+		return dnsmasqManager, nil
 	default:
 		return 0, ErrUnknownOsManagerType
 	}
@@ -54,6 +57,8 @@ func (t osManagerType) String() string {
 		return "systemd"
 	case resolvConfManager:
 		return "resolvconf"
+	case dnsmasqManager:
+		return "dnsmasq"
 	default:
 		return "unknown"
 	}
@@ -77,12 +82,19 @@ func newHostManagerFromType(wgInterface string, osManager osManagerType) (hostMa
 		return newSystemdDbusConfigurator(wgInterface)
 	case resolvConfManager:
 		return newResolvConfConfigurator(wgInterface)
+	case dnsmasqManager:
+		return newDnsmasqConfigurator()
 	default:
 		return newFileConfigurator()
 	}
 }
 
 func getOSDNSManagerType() (osManagerType, error) {
+	// on OpenWrt the resolv.conf file belongs to dnsmasq, which serves the DNS of the router and the LAN
+	if isDnsmasqManaged() {
+		return dnsmasqManager, nil
+	}
+
 	file, err := os.Open(defaultResolvConfPath)
 	if err != nil {
 		return 0, fmt.Errorf("unable to open %s for checking owner, got error: %w", defaultResolvConfPath, err)
@@ -0,0 +1,118 @@
+package dns
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	nbdns "github.com/netbirdio/netbird/dns"
+)
+
+// hostOverrideTTL is the TTL of the answers of the host overrides, kept short as they are edited by hand
+const hostOverrideTTL = 60
+
+// HostOverride is a local host record served by the resolver whatever the DNS configuration of the management service
+type HostOverride struct {
+	// Name is the fully qualified lower case name of the host
+	Name string
+	IP   netip.Addr
+}
+
+// ParseHostOverrides parses the host overrides in the name=ip form, a name may be given once per address
+func ParseHostOverrides(values []string) ([]HostOverride, error) {
+	overrides := make([]HostOverride, 0, len(values))
+	for _, value := range values {
+		name, addr, found := strings.Cut(value, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid host override %q, expected name=ip", value)
+		}
+
+		name = dns.Fqdn(strings.ToLower(strings.TrimSpace(name)))
+		if _, ok := dns.IsDomainName(name); !ok || name == "." {
+			return nil, fmt.Errorf("invalid name of the host override %q", value)
+		}
+
+		ip, err := netip.ParseAddr(strings.TrimSpace(addr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid address of the host override %q: %w", value, err)
+		}
+
+		overrides = append(overrides, HostOverride{Name: name, IP: ip.Unmap()})
+	}
+	return overrides, nil
+}
+
+// hostOverrideZones returns a zone per name of the host overrides with its A and AAAA records
+func hostOverrideZones(overrides []HostOverride) []nbdns.CustomZone {
+	var zones []nbdns.CustomZone
+	index := make(map[string]int)
+	for _, override := range overrides {
+		recordType := dns.TypeA
+		if override.IP.Is6() {
+			recordType = dns.TypeAAAA
+		}
+		record := nbdns.SimpleRecord{
+			Name:  override.Name,
+			Type:  int(recordType),
+			Class: nbdns.DefaultClass,
+			TTL:   hostOverrideTTL,
+			RData: override.IP.String(),
+		}
+
+		i, found := index[override.Name]
+		if !found {
+			i = len(zones)
+			index[override.Name] = i
+			zones = append(zones, nbdns.CustomZone{Domain: override.Name})
+		}
+		zones[i].Records = append(zones[i].Records, record)
+	}
+	return zones
+}
+
+// withoutOverriddenRecords returns the custom zones without the records of the names of the host overrides, the
+// zones left without records are dropped
+func withoutOverriddenRecords(zones []nbdns.CustomZone, overrides []HostOverride) []nbdns.CustomZone {
+	if len(overrides) == 0 {
+		return zones
+	}
+
+	names := make(map[string]struct{}, len(overrides))
+	for _, override := range overrides {
+		names[override.Name] = struct{}{}
+	}
+
+	filtered := make([]nbdns.CustomZone, 0, len(zones))
+	for _, zone := range zones {
+		records := make([]nbdns.SimpleRecord, 0, len(zone.Records))
+		for _, record := range zone.Records {
+			if _, found := names[dns.Fqdn(strings.ToLower(record.Name))]; found {
+				continue
+			}
+			records = append(records, record)
+		}
+		if len(records) == 0 {
+			continue
+		}
+		filtered = append(filtered, nbdns.CustomZone{Domain: zone.Domain, Records: records})
+	}
+	return filtered
+}
+
+// hostOverrideDomains returns the match domains the host routes the names of the host overrides with, they are no
+// search domains
+func hostOverrideDomains(overrides []HostOverride) []DomainConfig {
+	var domains []DomainConfig
+	seen := make(map[string]struct{})
+	for _, override := range overrides {
+		domain := strings.TrimSuffix(override.Name, ".")
+		if _, found := seen[domain]; found {
+			continue
+		}
+		seen[domain] = struct{}{}
+		domains = append(domains, DomainConfig{Domain: domain, MatchOnly: true})
+	}
+	return domains
+}
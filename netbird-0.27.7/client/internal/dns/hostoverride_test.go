@@ -0,0 +1,88 @@
+package dns
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+	nbdns "github.com/netbirdio/netbird/dns"
+)
+
+func TestParseHostOverrides(t *testing.T) {
+	overrides, err := ParseHostOverrides([]string{"NAS.home.arpa=192.168.1.10", "nas.home.arpa.=fd00::10", "printer = ::ffff:192.168.1.20"})
+	require.NoError(t, err)
+	assert.Equal(t, []HostOverride{
+		{Name: "nas.home.arpa.", IP: netip.MustParseAddr("192.168.1.10")},
+		{Name: "nas.home.arpa.", IP: netip.MustParseAddr("fd00::10")},
+		{Name: "printer.", IP: netip.MustParseAddr("192.168.1.20")},
+	}, overrides)
+
+	for _, value := range []string{"nas.home.arpa", "=192.168.1.10", "nas.home.arpa=", "nas..home=192.168.1.10", "nas=192.168.1"} {
+		_, err := ParseHostOverrides([]string{value})
+		assert.Error(t, err, value)
+	}
+}
+
+func TestDefaultServer_SetHostOverrides(t *testing.T) {
+	server := newDefaultServer(context.Background(), &mocWGIface{}, newServiceViaMemory(&mocWGIface{}), &peer.Status{})
+	defer server.ctxCancel()
+
+	var hostConfig HostDNSConfig
+	server.hostManager = &mockHostConfigurator{
+		applyDNSConfigFunc: func(config HostDNSConfig) error {
+			hostConfig = config
+			return nil
+		},
+	}
+
+	lookup := func(name string, qType uint16) []string {
+		msg := new(dns.Msg).SetQuestion(name, qType)
+		var answers []string
+		for _, rr := range server.localResolver.lookupRecords(msg) {
+			switch rr := rr.(type) {
+			case *dns.A:
+				answers = append(answers, rr.A.String())
+			case *dns.AAAA:
+				answers = append(answers, rr.AAAA.String())
+			}
+		}
+		return answers
+	}
+
+	overrides, err := ParseHostOverrides([]string{"nas.home.arpa=192.168.1.10", "peer1.netbird.cloud=10.0.0.1"})
+	require.NoError(t, err)
+	require.NoError(t, server.SetHostOverrides(overrides))
+
+	assert.Equal(t, []string{"192.168.1.10"}, lookup("nas.home.arpa.", dns.TypeA),
+		"the overrides should be served before any update of the management service")
+	assert.Contains(t, hostConfig.Domains, DomainConfig{Domain: "nas.home.arpa", MatchOnly: true})
+
+	err = server.UpdateDNSServer(1, nbdns.Config{
+		ServiceEnable: true,
+		CustomZones: []nbdns.CustomZone{{
+			Domain: "netbird.cloud.",
+			Records: []nbdns.SimpleRecord{
+				{Name: "peer1.netbird.cloud.", Type: int(dns.TypeA), Class: nbdns.DefaultClass, TTL: 300, RData: "100.64.0.1"},
+				{Name: "peer2.netbird.cloud.", Type: int(dns.TypeA), Class: nbdns.DefaultClass, TTL: 300, RData: "100.64.0.2"},
+			},
+		}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"10.0.0.1"}, lookup("peer1.netbird.cloud.", dns.TypeA),
+		"the override should take precedence over the record of the management service")
+	assert.Equal(t, []string{"100.64.0.2"}, lookup("peer2.netbird.cloud.", dns.TypeA))
+	assert.Equal(t, []string{"192.168.1.10"}, lookup("nas.home.arpa.", dns.TypeA))
+	assert.Contains(t, hostConfig.Domains, DomainConfig{Domain: "netbird.cloud"})
+
+	require.NoError(t, server.SetHostOverrides(nil))
+	assert.Equal(t, []string{"100.64.0.1"}, lookup("peer1.netbird.cloud.", dns.TypeA),
+		"the record of the management service should be served again without the override")
+	assert.Empty(t, lookup("nas.home.arpa.", dns.TypeA))
+	assert.NotContains(t, hostConfig.Domains, DomainConfig{Domain: "nas.home.arpa", MatchOnly: true})
+}
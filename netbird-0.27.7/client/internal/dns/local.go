@@ -2,6 +2,7 @@ package dns
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/miekg/dns"
@@ -20,6 +21,9 @@ type localResolver struct {
 func (d *localResolver) stop() {
 }
 
+// maxCNAMEChain limits how many local CNAME records are followed for a single question
+const maxCNAMEChain = 8
+
 // ServeDNS handles a DNS request
 func (d *localResolver) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	log.Tracef("received question: %#v", r.Question[0])
@@ -28,9 +32,9 @@ func (d *localResolver) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	replyMessage.RecursionAvailable = true
 	replyMessage.Rcode = dns.RcodeSuccess
 
-	response := d.lookupRecord(r)
-	if response != nil {
-		replyMessage.Answer = append(replyMessage.Answer, response)
+	response := d.lookupRecords(r)
+	if len(response) > 0 {
+		replyMessage.Answer = append(replyMessage.Answer, response...)
 	} else {
 		replyMessage.Rcode = dns.RcodeNameError
 	}
@@ -41,36 +45,103 @@ func (d *localResolver) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	}
 }
 
-func (d *localResolver) lookupRecord(r *dns.Msg) dns.RR {
+// lookupRecords returns the records matching the question, following local CNAME records
+// when the name has no record of the requested type
+func (d *localResolver) lookupRecords(r *dns.Msg) []dns.RR {
 	question := r.Question[0]
-	record, found := d.records.Load(buildRecordKey(question.Name, question.Qclass, question.Qtype))
+	name := question.Name
+
+	var answers []dns.RR
+	for i := 0; i < maxCNAMEChain; i++ {
+		records := d.loadRecords(buildRecordKey(name, question.Qclass, question.Qtype))
+		if len(records) > 0 {
+			return append(answers, records...)
+		}
+
+		if question.Qtype == dns.TypeCNAME {
+			return answers
+		}
+
+		cnames := d.loadRecords(buildRecordKey(name, question.Qclass, dns.TypeCNAME))
+		if len(cnames) == 0 {
+			return answers
+		}
+
+		answers = append(answers, cnames[0])
+		name = cnames[0].(*dns.CNAME).Target
+	}
+
+	return answers
+}
+
+func (d *localResolver) loadRecords(key string) []dns.RR {
+	records, found := d.records.Load(key)
 	if !found {
 		return nil
 	}
 
-	return record.(dns.RR)
+	return records.([]dns.RR)
 }
 
+// registerRecord adds the record to the records already registered under the same name, class and type
 func (d *localResolver) registerRecord(record nbdns.SimpleRecord) error {
-	fullRecord, err := dns.NewRR(record.String())
+	fullRecord, err := buildRR(record)
 	if err != nil {
-		return fmt.Errorf("register record: %w", err)
+		return err
 	}
 
-	fullRecord.Header().Rdlength = record.Len()
-
 	header := fullRecord.Header()
-	d.records.Store(buildRecordKey(header.Name, header.Class, header.Rrtype), fullRecord)
+	key := buildRecordKey(header.Name, header.Class, header.Rrtype)
+	existing := d.loadRecords(key)
+	for _, rr := range existing {
+		if dns.IsDuplicate(rr, fullRecord) {
+			return nil
+		}
+	}
+
+	d.records.Store(key, append(existing[:len(existing):len(existing)], fullRecord))
 
 	return nil
 }
 
+// setRecords replaces the records registered under the key
+func (d *localResolver) setRecords(key string, records []nbdns.SimpleRecord) error {
+	var rrs []dns.RR
+	for _, record := range records {
+		fullRecord, err := buildRR(record)
+		if err != nil {
+			return err
+		}
+		rrs = append(rrs, fullRecord)
+	}
+
+	if len(rrs) == 0 {
+		d.records.Delete(key)
+		return nil
+	}
+
+	d.records.Store(key, rrs)
+
+	return nil
+}
+
+func buildRR(record nbdns.SimpleRecord) (dns.RR, error) {
+	fullRecord, err := dns.NewRR(record.String())
+	if err != nil {
+		return nil, fmt.Errorf("register record: %w", err)
+	}
+
+	fullRecord.Header().Rdlength = record.Len()
+
+	return fullRecord, nil
+}
+
 func (d *localResolver) deleteRecord(recordKey string) {
-	d.records.Delete(dns.Fqdn(recordKey))
+	d.records.Delete(recordKey)
 }
 
 func buildRecordKey(name string, class, qType uint16) string {
-	key := fmt.Sprintf("%s_%d_%d", name, class, qType)
+	key := fmt.Sprintf("%s_%d_%d", strings.ToLower(name), class, qType)
 	return key
 }
 
@@ -86,3 +86,83 @@ func TestLocalResolver_ServeDNS(t *testing.T) {
 		})
 	}
 }
+
+func TestLocalResolver_MultipleRecordsAndCNAME(t *testing.T) {
+	records := []nbdns.SimpleRecord{
+		{Name: "nas.home.arpa.", Type: int(dns.TypeA), Class: nbdns.DefaultClass, TTL: 300, RData: "192.168.1.10"},
+		{Name: "nas.home.arpa.", Type: int(dns.TypeA), Class: nbdns.DefaultClass, TTL: 300, RData: "192.168.1.11"},
+		{Name: "files.home.arpa.", Type: int(dns.TypeCNAME), Class: nbdns.DefaultClass, TTL: 300, RData: "nas.home.arpa."},
+		{Name: "home.arpa.", Type: int(dns.TypeTXT), Class: nbdns.DefaultClass, TTL: 300, RData: `"v=spf1 -all"`},
+	}
+
+	resolver := &localResolver{
+		registeredMap: make(registrationMap),
+	}
+	for _, record := range records {
+		if err := resolver.registerRecord(record); err != nil {
+			t.Fatalf("failed to register record %s: %v", record.String(), err)
+		}
+	}
+	// registering the same record twice should not duplicate the answer
+	_ = resolver.registerRecord(records[0])
+
+	testCases := []struct {
+		name          string
+		question      *dns.Msg
+		expectedTypes []uint16
+	}{
+		{
+			name:          "Should Return All A Records",
+			question:      new(dns.Msg).SetQuestion("nas.home.arpa.", dns.TypeA),
+			expectedTypes: []uint16{dns.TypeA, dns.TypeA},
+		},
+		{
+			name:          "Should Follow Local CNAME",
+			question:      new(dns.Msg).SetQuestion("Files.Home.Arpa.", dns.TypeA),
+			expectedTypes: []uint16{dns.TypeCNAME, dns.TypeA, dns.TypeA},
+		},
+		{
+			name:          "Should Return CNAME Only When Asked",
+			question:      new(dns.Msg).SetQuestion("files.home.arpa.", dns.TypeCNAME),
+			expectedTypes: []uint16{dns.TypeCNAME},
+		},
+		{
+			name:          "Should Resolve TXT Record",
+			question:      new(dns.Msg).SetQuestion("home.arpa.", dns.TypeTXT),
+			expectedTypes: []uint16{dns.TypeTXT},
+		},
+		{
+			name:     "Should Not Answer Missing Type",
+			question: new(dns.Msg).SetQuestion("nas.home.arpa.", dns.TypeAAAA),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var responseMSG *dns.Msg
+			responseWriter := &mockResponseWriter{
+				WriteMsgFunc: func(m *dns.Msg) error {
+					responseMSG = m
+					return nil
+				},
+			}
+
+			resolver.ServeDNS(responseWriter, testCase.question)
+
+			if responseMSG == nil {
+				t.Fatalf("should write a response message")
+			}
+			if len(responseMSG.Answer) != len(testCase.expectedTypes) {
+				t.Fatalf("expected %d answers, got %d: %v", len(testCase.expectedTypes), len(responseMSG.Answer), responseMSG.Answer)
+			}
+			for i, rr := range responseMSG.Answer {
+				if rr.Header().Rrtype != testCase.expectedTypes[i] {
+					t.Fatalf("expected answer %d to be %s, got %s", i, dns.Type(testCase.expectedTypes[i]), rr)
+				}
+			}
+			if len(testCase.expectedTypes) == 0 && responseMSG.Rcode != dns.RcodeNameError {
+				t.Fatalf("expected NXDOMAIN, got %s", dns.RcodeToString[responseMSG.Rcode])
+			}
+		})
+	}
+}
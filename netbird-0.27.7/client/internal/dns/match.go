@@ -0,0 +1,209 @@
+package dns
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/miekg/dns"
+	log "github.com/sirupsen/logrus"
+
+	nbdns "github.com/netbirdio/netbird/dns"
+)
+
+// matchRule is a wildcard or negated domain of a nameserver group. The dns mux only matches a zone with all its
+// subdomains, so the rules are registered on their zone with a matchHandler deciding between the group and a fallback
+type matchRule struct {
+	zone    string
+	handler handlerWithStop
+	negated bool
+}
+
+// matchHandler serves the zone of a wildcard or negated domain rule. For wildcard rules the subdomains go to the
+// nameserver group handler, everything else goes to the fallback, which is the handler the name would get without
+// the rule
+type matchHandler struct {
+	zone     string
+	handler  handlerWithStop
+	fallback dns.Handler
+}
+
+// ServeDNS handles a DNS request
+func (m *matchHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	if m.handler != nil && len(r.Question) > 0 && !strings.EqualFold(dns.Fqdn(r.Question[0].Name), m.zone) {
+		m.handler.ServeDNS(w, r)
+		return
+	}
+
+	if m.fallback != nil {
+		m.fallback.ServeDNS(w, r)
+		return
+	}
+
+	log.Debugf("no nameserver left for %s after applying the match rules of zone %s", r.Question[0].Name, m.zone)
+	setQueryDecision(w, QueryDecisionBlocked)
+	dns.HandleFailed(w, r)
+}
+
+func (m *matchHandler) stop() {
+	if m.handler != nil {
+		m.handler.stop()
+	}
+}
+
+func (m *matchHandler) probeAvailability() {
+	if m.handler != nil {
+		m.handler.probeAvailability()
+	}
+}
+
+// buildMatchRuleUpdates registers the rules of the nameserver groups on top of the regular domain updates.
+// A rule replaces a regular handler on the same zone, the replaced handler becomes the fallback
+func buildMatchRuleUpdates(domainUpdates []muxUpdate, rules []matchRule) []muxUpdate {
+	ruleUpdates := make([]muxUpdate, 0, len(rules))
+	for _, rule := range rules {
+		ruleUpdates = append(ruleUpdates, muxUpdate{
+			domain: rule.zone,
+			handler: &matchHandler{
+				zone:     dns.Fqdn(strings.ToLower(rule.zone)),
+				handler:  ruleGroupHandler(rule),
+				fallback: findFallbackHandler(rule, domainUpdates, rules),
+			},
+		})
+	}
+
+	// drop the regular handlers replaced by a rule, their groups are reached through the rule fallback
+	replaced := make(map[string]struct{}, len(rules))
+	for _, update := range ruleUpdates {
+		replaced[strings.ToLower(dns.Fqdn(update.domain))] = struct{}{}
+	}
+
+	var updates []muxUpdate
+	for _, update := range domainUpdates {
+		if _, found := replaced[strings.ToLower(dns.Fqdn(update.domain))]; found {
+			continue
+		}
+		updates = append(updates, update)
+	}
+
+	return append(updates, ruleUpdates...)
+}
+
+func ruleGroupHandler(rule matchRule) handlerWithStop {
+	if rule.negated {
+		return nil
+	}
+	return rule.handler
+}
+
+// findFallbackHandler returns the most specific handler of another nameserver group that covers the rule zone
+func findFallbackHandler(rule matchRule, domainUpdates []muxUpdate, rules []matchRule) dns.Handler {
+	zone := dns.Fqdn(rule.zone)
+
+	var fallback dns.Handler
+	bestLabels := -1
+	for _, update := range domainUpdates {
+		if update.handler == rule.handler {
+			continue
+		}
+		parent := dns.Fqdn(update.domain)
+		if !dns.IsSubDomain(parent, zone) {
+			continue
+		}
+		if labels := dns.CountLabel(parent); labels > bestLabels {
+			fallback, bestLabels = update.handler, labels
+		}
+	}
+
+	// a wildcard rule of another group covers the zone when the zone is one of its subdomains
+	for _, other := range rules {
+		if other.negated || other.handler == rule.handler {
+			continue
+		}
+		parent := dns.Fqdn(other.zone)
+		labels := dns.CountLabel(parent)
+		if !dns.IsSubDomain(parent, zone) || dns.CountLabel(zone) == labels {
+			continue
+		}
+		if labels > bestLabels {
+			fallback, bestLabels = other.handler, labels
+		}
+	}
+
+	return fallback
+}
+
+// matchDomainZones returns the zones the nameserver group domains should be configured on the host with,
+// the negated domains are left out as the host can't route them away from the group
+func matchDomainZones(domains []string) []string {
+	var zones []string
+	seen := make(map[string]struct{}, len(domains))
+	for _, domain := range domains {
+		match := nbdns.ParseMatchDomain(domain)
+		if match.Negated {
+			continue
+		}
+		if _, found := seen[match.Zone]; found {
+			continue
+		}
+		seen[match.Zone] = struct{}{}
+		zones = append(zones, match.Zone)
+	}
+	return zones
+}
+
+// prioritizeNameServerGroups orders the nameserver groups by priority and leaves out the domains a group shares with
+// a group of a higher priority, so overlapping groups resolve the same way regardless of the order they are received
+// in. Only the first primary group keeps its primary role and a group left without match domains is dropped
+func prioritizeNameServerGroups(groups []*nbdns.NameServerGroup) []*nbdns.NameServerGroup {
+	sorted := slices.Clone(groups)
+	nbdns.SortNameServerGroups(sorted)
+
+	// the regular domains are registered on their zone, the wildcard and negated ones as a rule on their zone
+	claimed := make(map[string]struct{})
+	var primaryClaimed bool
+
+	prioritized := make([]*nbdns.NameServerGroup, 0, len(sorted))
+	for _, group := range sorted {
+		effective := group.Copy()
+		effective.Domains = nil
+
+		demoted := group.Primary && primaryClaimed
+		if demoted {
+			effective.Primary = false
+			log.Debugf("nameserver group %s is not primary as a group with a higher priority is", group.Name)
+		}
+		primaryClaimed = primaryClaimed || group.Primary
+
+		var matching bool
+		var keys []string
+		for _, domain := range group.Domains {
+			match := nbdns.ParseMatchDomain(domain)
+			// the negated domains of a primary group apply to the root zone it doesn't serve anymore
+			if demoted && match.Negated {
+				continue
+			}
+
+			key := strings.ToLower(dns.Fqdn(match.Zone))
+			if match.Wildcard || match.Negated {
+				key = "rule:" + key
+			}
+			if _, found := claimed[key]; found {
+				log.Debugf("domain %s of nameserver group %s is resolved by a group with a higher priority", domain, group.Name)
+				continue
+			}
+			keys = append(keys, key)
+
+			effective.Domains = append(effective.Domains, domain)
+			matching = matching || !match.Negated
+		}
+
+		if !effective.Primary && !matching && (demoted || len(group.Domains) > 0) {
+			continue
+		}
+		for _, key := range keys {
+			claimed[key] = struct{}{}
+		}
+		prioritized = append(prioritized, effective)
+	}
+	return prioritized
+}
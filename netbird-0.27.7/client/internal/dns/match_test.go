@@ -0,0 +1,126 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	nbdns "github.com/netbirdio/netbird/dns"
+)
+
+type namedTestHandler struct {
+	name   string
+	served *string
+}
+
+func (h *namedTestHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	*h.served = h.name
+	_ = w.WriteMsg(new(dns.Msg).SetReply(r))
+}
+
+func (h *namedTestHandler) stop() {}
+
+func (h *namedTestHandler) probeAvailability() {}
+
+func TestMatchRules(t *testing.T) {
+	var served string
+	primary := &namedTestHandler{name: "primary", served: &served}
+	corp := &namedTestHandler{name: "corp", served: &served}
+	lab := &namedTestHandler{name: "lab", served: &served}
+
+	domainUpdates := []muxUpdate{
+		{domain: nbdns.RootZone, handler: primary},
+		{domain: "corp.example.com", handler: corp},
+	}
+	rules := []matchRule{
+		{zone: "public.corp.example.com", handler: corp, negated: true},
+		{zone: "lab.example.com", handler: lab},
+		{zone: "Isolated.Lab.example.com", handler: lab, negated: true},
+		{zone: "example.org", handler: primary, negated: true},
+	}
+
+	updates := buildMatchRuleUpdates(domainUpdates, rules)
+	require.Len(t, updates, len(domainUpdates)+len(rules))
+
+	mux := dns.NewServeMux()
+	for _, update := range updates {
+		mux.Handle(update.domain, update.handler)
+	}
+
+	testCases := []struct {
+		name     string
+		question string
+		expected string
+		failed   bool
+	}{
+		{name: "regular domain", question: "host.corp.example.com.", expected: "corp"},
+		{name: "negated domain falls back to the primary group", question: "www.public.corp.example.com.", expected: "primary"},
+		{name: "wildcard matches the subdomains", question: "host.lab.example.com.", expected: "lab"},
+		{name: "wildcard doesn't match the zone itself", question: "lab.example.com.", expected: "primary"},
+		{name: "negated subdomain of a wildcard", question: "host.isolated.lab.example.com.", expected: "primary"},
+		{name: "negated domain of the primary group has no fallback", question: "www.example.org.", failed: true},
+		{name: "other domains go to the primary group", question: "netbird.io.", expected: "primary"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			served = ""
+			var response *dns.Msg
+			writer := &mockResponseWriter{
+				WriteMsgFunc: func(m *dns.Msg) error {
+					response = m
+					return nil
+				},
+			}
+
+			mux.ServeDNS(writer, new(dns.Msg).SetQuestion(testCase.question, dns.TypeA))
+
+			require.NotNil(t, response, "should write a response")
+			if testCase.failed {
+				assert.Equal(t, dns.RcodeServerFailure, response.Rcode)
+				assert.Empty(t, served)
+				return
+			}
+			assert.Equal(t, testCase.expected, served)
+		})
+	}
+}
+
+func TestMatchDomainZones(t *testing.T) {
+	zones := matchDomainZones([]string{"corp.example.com", "*.lab.example.com", "!public.corp.example.com", "*.corp.example.com"})
+	assert.Equal(t, []string{"corp.example.com", "lab.example.com"}, zones)
+}
+
+func TestPrioritizeNameServerGroups(t *testing.T) {
+	groups := []*nbdns.NameServerGroup{
+		{Name: "backup", Primary: true, Domains: []string{"!internal.example.com"}, Priority: 50},
+		{Name: "corp-secondary", Domains: []string{"corp.example.com", "*.lab.example.com", "extra.example.com"}, Priority: 20, SearchDomainsEnabled: true},
+		{Name: "default", Primary: true, Domains: []string{"!example.org"}},
+		{Name: "corp", Domains: []string{"Corp.Example.com.", "*.lab.example.com"}, Priority: 10},
+		{Name: "lab-only", Domains: []string{"*.lab.example.com"}, Priority: 30},
+	}
+
+	prioritized := prioritizeNameServerGroups(groups)
+
+	var names []string
+	for _, group := range prioritized {
+		names = append(names, group.Name)
+	}
+	require.Equal(t, []string{"default", "corp", "corp-secondary"}, names,
+		"should order the groups by priority and drop the groups without domains left")
+
+	assert.True(t, prioritized[0].Primary)
+	assert.Equal(t, []string{"!example.org"}, prioritized[0].Domains)
+	assert.Equal(t, []string{"Corp.Example.com.", "*.lab.example.com"}, prioritized[1].Domains)
+	assert.Equal(t, []string{"extra.example.com"}, prioritized[2].Domains, "should leave out the domains of the groups with a higher priority")
+	assert.Len(t, groups[1].Domains, 3, "should not modify the received groups")
+
+	config := dnsConfigToHostDNSConfig(nbdns.Config{NameServerGroups: []*nbdns.NameServerGroup{
+		{Name: "first", Domains: []string{"corp.example.com"}, NameServers: []nbdns.NameServer{{}}, SearchDomainsEnabled: true},
+		{Name: "second", Domains: []string{"*.corp.example.com", "dev.example.com"}, NameServers: []nbdns.NameServer{{}}, SearchDomainsEnabled: true},
+	}}, "100.64.0.1", 53)
+	assert.Equal(t, []DomainConfig{{Domain: "corp.example.com"}, {Domain: "dev.example.com"}}, config.Domains,
+		"should configure the search domains in the group order once")
+}
@@ -11,6 +11,7 @@ type MockServer struct {
 	InitializeFunc      func() error
 	StopFunc            func()
 	UpdateDNSServerFunc func(serial uint64, update nbdns.Config) error
+	SetCacheConfigFunc  func(config CacheConfig)
 }
 
 // Initialize mock implementation of Initialize from Server interface
@@ -37,6 +38,10 @@ func (m *MockServer) OnUpdatedHostDNSServer(strings []string) {
 	panic("implement me")
 }
 
+// OnUpdatedInterfaceAddress mock implementation of OnUpdatedInterfaceAddress from Server interface
+func (m *MockServer) OnUpdatedInterfaceAddress() {
+}
+
 // UpdateDNSServer mock implementation of UpdateDNSServer from Server interface
 func (m *MockServer) UpdateDNSServer(serial uint64, update nbdns.Config) error {
 	if m.UpdateDNSServerFunc != nil {
@@ -52,3 +57,28 @@ func (m *MockServer) SearchDomains() []string {
 // ProbeAvailability mocks implementation of ProbeAvailability from the Server interface
 func (m *MockServer) ProbeAvailability() {
 }
+
+// SetResponseObserver mocks implementation of SetResponseObserver from the Server interface
+func (m *MockServer) SetResponseObserver(ResponseObserver) {
+}
+
+// SetCacheConfig mocks implementation of SetCacheConfig from the Server interface
+func (m *MockServer) SetCacheConfig(config CacheConfig) {
+	if m.SetCacheConfigFunc != nil {
+		m.SetCacheConfigFunc(config)
+	}
+}
+
+// SetQueryLogEnabled mocks implementation of SetQueryLogEnabled from the Server interface
+func (m *MockServer) SetQueryLogEnabled(bool) {
+}
+
+// QueryLog mocks implementation of QueryLog from the Server interface
+func (m *MockServer) QueryLog() ([]QueryLogEntry, QueryStats) {
+	return nil, QueryStats{}
+}
+
+// SetHostOverrides mocks implementation of SetHostOverrides from the Server interface
+func (m *MockServer) SetHostOverrides([]HostOverride) error {
+	return nil
+}
@@ -0,0 +1,192 @@
+package dns
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const defaultQueryLogSize = 1000
+
+// QueryDecision is how the resolver handled a query
+type QueryDecision string
+
+const (
+	// QueryDecisionLocal is a query answered from the peers and custom zones
+	QueryDecisionLocal QueryDecision = "local"
+	// QueryDecisionForwarded is a query forwarded to the upstream nameservers or answered from their cached responses
+	QueryDecisionForwarded QueryDecision = "forwarded"
+	// QueryDecisionBlocked is a query refused by the negated domains of the nameserver groups or the deny lists
+	QueryDecisionBlocked QueryDecision = "blocked"
+)
+
+// QueryLogEntry is a query handled by the resolver
+type QueryLogEntry struct {
+	Time     time.Time
+	Domain   string
+	Type     string
+	Decision QueryDecision
+	// Rcode is the response code of the answer, empty when the query wasn't answered
+	Rcode   string
+	Latency time.Duration
+}
+
+// QueryStats are the counters of the queries handled since the query log was enabled
+type QueryStats struct {
+	Local     uint64
+	Forwarded uint64
+	Blocked   uint64
+	// Failed counts the local and forwarded queries answered with SERVFAIL or left unanswered
+	Failed uint64
+	// TotalLatency is the sum of the latencies of all the queries
+	TotalLatency time.Duration
+}
+
+// Total returns the number of queries handled
+func (s QueryStats) Total() uint64 {
+	return s.Local + s.Forwarded + s.Blocked
+}
+
+// AverageLatency returns the average latency of the queries handled
+func (s QueryStats) AverageLatency() time.Duration {
+	if s.Total() == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Total())
+}
+
+// queryLog keeps the latest queries handled by the resolver in a ring buffer with the counters of all of them.
+// It is opt-in, nothing is recorded while it is disabled
+type queryLog struct {
+	enabled atomic.Bool
+
+	mu      sync.Mutex
+	entries []QueryLogEntry
+	next    int
+	full    bool
+	stats   QueryStats
+}
+
+func newQueryLog(size int) *queryLog {
+	return &queryLog{
+		entries: make([]QueryLogEntry, size),
+	}
+}
+
+// setEnabled enables or disables the query log, the recorded queries and counters are dropped when it is disabled
+func (l *queryLog) setEnabled(enabled bool) {
+	if l.enabled.Swap(enabled) == enabled || enabled {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	clear(l.entries)
+	l.next = 0
+	l.full = false
+	l.stats = QueryStats{}
+}
+
+func (l *queryLog) record(entry QueryLogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+
+	switch entry.Decision {
+	case QueryDecisionLocal:
+		l.stats.Local++
+	case QueryDecisionForwarded:
+		l.stats.Forwarded++
+	case QueryDecisionBlocked:
+		l.stats.Blocked++
+	}
+	failed := entry.Rcode == "" || entry.Rcode == dns.RcodeToString[dns.RcodeServerFailure]
+	if failed && entry.Decision != QueryDecisionBlocked {
+		l.stats.Failed++
+	}
+	l.stats.TotalLatency += entry.Latency
+}
+
+// snapshot returns the recorded queries, the oldest first, with the counters
+func (l *queryLog) snapshot() ([]QueryLogEntry, QueryStats) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var entries []QueryLogEntry
+	if l.full {
+		entries = append(entries, l.entries[l.next:]...)
+	}
+	entries = append(entries, l.entries[:l.next]...)
+	return entries, l.stats
+}
+
+// queryLogHandler records the queries served by a handler registered on the dns mux in the query log
+type queryLogHandler struct {
+	handler  dns.Handler
+	decision QueryDecision
+	log      *queryLog
+}
+
+func newQueryLogHandler(handler dns.Handler, log *queryLog) *queryLogHandler {
+	decision := QueryDecisionForwarded
+	if _, ok := handler.(*localResolver); ok {
+		decision = QueryDecisionLocal
+	}
+	return &queryLogHandler{
+		handler:  handler,
+		decision: decision,
+		log:      log,
+	}
+}
+
+// ServeDNS handles a DNS request
+func (h *queryLogHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	if !h.log.enabled.Load() || len(r.Question) == 0 {
+		h.handler.ServeDNS(w, r)
+		return
+	}
+
+	writer := &queryLogWriter{ResponseWriter: w, decision: h.decision}
+	start := time.Now()
+	h.handler.ServeDNS(writer, r)
+
+	entry := QueryLogEntry{
+		Time:     start,
+		Domain:   strings.ToLower(dns.Fqdn(r.Question[0].Name)),
+		Type:     dns.Type(r.Question[0].Qtype).String(),
+		Decision: writer.decision,
+		Latency:  time.Since(start),
+	}
+	if writer.response != nil {
+		entry.Rcode = dns.RcodeToString[writer.response.Rcode]
+	}
+	h.log.record(entry)
+}
+
+// queryLogWriter keeps the response written by the handler and the decision it took for the query log
+type queryLogWriter struct {
+	dns.ResponseWriter
+	decision QueryDecision
+	response *dns.Msg
+}
+
+// WriteMsg writes a reply back to the client
+func (w *queryLogWriter) WriteMsg(msg *dns.Msg) error {
+	w.response = msg
+	return w.ResponseWriter.WriteMsg(msg)
+}
+
+// setQueryDecision overrides the decision recorded in the query log for the query answered through the writer
+func setQueryDecision(w dns.ResponseWriter, decision QueryDecision) {
+	if writer, ok := w.(*queryLogWriter); ok {
+		writer.decision = decision
+	}
+}
@@ -0,0 +1,60 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryLog_Ring(t *testing.T) {
+	log := newQueryLog(3)
+	log.setEnabled(true)
+
+	for _, domain := range []string{"a.", "b.", "c.", "d."} {
+		log.record(QueryLogEntry{Domain: domain, Decision: QueryDecisionLocal, Rcode: "NOERROR"})
+	}
+
+	entries, stats := log.snapshot()
+	require.Len(t, entries, 3)
+	assert.Equal(t, "b.", entries[0].Domain, "should drop the oldest entry")
+	assert.Equal(t, "d.", entries[2].Domain)
+	assert.Equal(t, uint64(4), stats.Local)
+
+	log.setEnabled(false)
+	entries, stats = log.snapshot()
+	assert.Empty(t, entries, "should drop the entries when disabled")
+	assert.Zero(t, stats.Total())
+}
+
+func TestQueryLogHandler(t *testing.T) {
+	log := newQueryLog(10)
+	local := newQueryLogHandler(&localResolver{registeredMap: make(registrationMap)}, log)
+	blocking := newQueryLogHandler(&matchHandler{zone: "blocked.example.com."}, log)
+	unanswered := newQueryLogHandler(dns.HandlerFunc(func(dns.ResponseWriter, *dns.Msg) {}), log)
+
+	writer := &mockResponseWriter{WriteMsgFunc: func(*dns.Msg) error { return nil }}
+	query := new(dns.Msg).SetQuestion("Host.Example.com.", dns.TypeA)
+
+	local.ServeDNS(writer, query)
+	entries, _ := log.snapshot()
+	assert.Empty(t, entries, "should not record anything while disabled")
+
+	log.setEnabled(true)
+	local.ServeDNS(writer, query)
+	blocking.ServeDNS(writer, new(dns.Msg).SetQuestion("blocked.example.com.", dns.TypeA))
+	unanswered.ServeDNS(writer, query)
+
+	entries, stats := log.snapshot()
+	require.Len(t, entries, 3)
+	assert.Equal(t, "host.example.com.", entries[0].Domain)
+	assert.Equal(t, "A", entries[0].Type)
+	assert.Equal(t, QueryDecisionLocal, entries[0].Decision)
+	assert.Equal(t, "NXDOMAIN", entries[0].Rcode)
+	assert.Equal(t, QueryDecisionBlocked, entries[1].Decision)
+	assert.Equal(t, QueryDecisionForwarded, entries[2].Decision)
+	assert.Empty(t, entries[2].Rcode)
+
+	assert.Equal(t, QueryStats{Local: 1, Forwarded: 1, Blocked: 1, Failed: 1, TotalLatency: stats.TotalLatency}, stats)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/netip"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 
@@ -34,10 +35,19 @@ type Server interface {
 	DnsIP() string
 	UpdateDNSServer(serial uint64, update nbdns.Config) error
 	OnUpdatedHostDNSServer(strings []string)
+	OnUpdatedInterfaceAddress()
 	SearchDomains() []string
 	ProbeAvailability()
+	SetResponseObserver(observer ResponseObserver)
+	SetCacheConfig(config CacheConfig)
+	SetQueryLogEnabled(enabled bool)
+	QueryLog() ([]QueryLogEntry, QueryStats)
+	SetHostOverrides(overrides []HostOverride) error
 }
 
+// ResponseObserver is called with every response received from an upstream nameserver, before it is passed to the client
+type ResponseObserver func(msg *dns.Msg)
+
 type registeredHandlerMap map[string]handlerWithStop
 
 // DefaultServer dns server object
@@ -62,7 +72,15 @@ type DefaultServer struct {
 	searchDomainNotifier *notifier
 	iosDnsManager        IosDnsManager
 
-	statusRecorder *peer.Status
+	statusRecorder   *peer.Status
+	responseObserver ResponseObserver
+	cache            *responseCache
+	queryLog         *queryLog
+	blocklist        *blocklist
+
+	// hostOverrides are served on top of the last configuration received from the management service
+	hostOverrides    []HostOverride
+	managementConfig nbdns.Config
 }
 
 type handlerWithStop interface {
@@ -148,6 +166,13 @@ func newDefaultServer(ctx context.Context, wgInterface WGIface, dnsService servi
 		wgInterface:    wgInterface,
 		statusRecorder: statusRecorder,
 		hostsDNSHolder: newHostsDNSHolder(),
+		cache:          newResponseCache(defaultCacheSize),
+		queryLog:       newQueryLog(defaultQueryLogSize),
+		blocklist:      newBlocklist(ctx),
+	}
+
+	if statusRecorder != nil {
+		statusRecorder.SetDNSCacheStatsProvider(defaultServer.cache.stats)
 	}
 
 	return defaultServer
@@ -198,6 +223,10 @@ func (s *DefaultServer) Stop() {
 	}
 
 	s.service.Stop()
+
+	if s.statusRecorder != nil {
+		s.statusRecorder.SetDNSCacheStatsProvider(nil)
+	}
 }
 
 // OnUpdatedHostDNSServer update the DNS servers addresses for root zones
@@ -214,6 +243,27 @@ func (s *DefaultServer) OnUpdatedHostDNSServer(hostsDnsList []string) {
 	s.addHostRootZone()
 }
 
+// OnUpdatedInterfaceAddress stops the DNS service after the interface address has changed and makes the next
+// update from the management service to be applied in full, so the listener, the upstream resolvers and the
+// host DNS configuration pick up the new address
+func (s *DefaultServer) OnUpdatedInterfaceAddress() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.previousConfigHash = 0
+
+	if s.hostManager == nil {
+		return
+	}
+
+	s.service.Stop()
+	if s.permanent {
+		if err := s.service.Listen(); err != nil {
+			log.Errorf("failed to restart the dns service: %s", err)
+		}
+	}
+}
+
 // UpdateDNSServer processes an update received from the management service
 func (s *DefaultServer) UpdateDNSServer(serial uint64, update nbdns.Config) error {
 	select {
@@ -274,6 +324,45 @@ func (s *DefaultServer) SearchDomains() []string {
 	return searchDomains
 }
 
+// SetResponseObserver sets the observer of the upstream responses, it applies to the upstream resolvers created afterwards
+func (s *DefaultServer) SetResponseObserver(observer ResponseObserver) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.responseObserver = observer
+}
+
+// SetCacheConfig sets the TTL clamps and the size of the upstream response cache, the cached responses are flushed
+func (s *DefaultServer) SetCacheConfig(config CacheConfig) {
+	s.cache.setConfig(config)
+}
+
+// SetQueryLogEnabled enables or disables the query log, the recorded queries are dropped when it is disabled
+func (s *DefaultServer) SetQueryLogEnabled(enabled bool) {
+	s.queryLog.setEnabled(enabled)
+}
+
+// QueryLog returns the latest queries recorded in the query log, the oldest first, with the counters of all of them
+func (s *DefaultServer) QueryLog() ([]QueryLogEntry, QueryStats) {
+	return s.queryLog.snapshot()
+}
+
+// SetHostOverrides sets the local host records served by the resolver, they take precedence over the records of
+// the management service with the same name. They are applied with the next update when the server isn't initialized
+func (s *DefaultServer) SetHostOverrides(overrides []HostOverride) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.hostOverrides = overrides
+	if s.hostManager == nil {
+		return nil
+	}
+
+	if err := s.applyConfiguration(s.managementConfig); err != nil {
+		return fmt.Errorf("apply configuration: %w", err)
+	}
+	return nil
+}
+
 // ProbeAvailability tests each upstream group's servers for availability
 // and deactivates the group if no server responds
 func (s *DefaultServer) ProbeAvailability() {
@@ -289,15 +378,25 @@ func (s *DefaultServer) ProbeAvailability() {
 }
 
 func (s *DefaultServer) applyConfiguration(update nbdns.Config) error {
+	s.managementConfig = update
+
 	// is the service should be Disabled, we stop the listener or fake resolver
-	// and proceed with a regular update to clean up the handlers and records
-	if update.ServiceEnable {
+	// and proceed with a regular update to clean up the handlers and records,
+	// the host overrides are served whatever the management service enables
+	if update.ServiceEnable || len(s.hostOverrides) > 0 {
 		_ = s.service.Listen()
 	} else if !s.permanent {
 		s.service.Stop()
 	}
 
-	localMuxUpdates, localRecords, err := s.buildLocalHandlerUpdate(update.CustomZones)
+	// the overlapping nameserver groups are resolved in the order of their priorities
+	nameServerGroups := update.NameServerGroups
+	update.NameServerGroups = prioritizeNameServerGroups(nameServerGroups)
+
+	update.CustomZones = withoutOverriddenRecords(update.CustomZones, s.hostOverrides)
+	localZones := append(slices.Clip(update.CustomZones), hostOverrideZones(s.hostOverrides)...)
+
+	localMuxUpdates, localRecords, err := s.buildLocalHandlerUpdate(localZones)
 	if err != nil {
 		return fmt.Errorf("not applying dns update, error: %v", err)
 	}
@@ -309,7 +408,11 @@ func (s *DefaultServer) applyConfiguration(update nbdns.Config) error {
 
 	s.updateMux(muxUpdates)
 	s.updateLocalResolver(localRecords)
+	s.blocklist.update(update.Blocklist)
+	// the nameservers of the cached responses might have changed
+	s.cache.flush()
 	s.currentConfig = dnsConfigToHostDNSConfig(update, s.service.RuntimeIP(), s.service.RuntimePort())
+	s.currentConfig.Domains = append(s.currentConfig.Domains, hostOverrideDomains(s.hostOverrides)...)
 
 	hostUpdate := s.currentConfig
 	if s.service.RuntimePort() != defaultPort && !s.hostManager.supportCustomPort() {
@@ -326,14 +429,14 @@ func (s *DefaultServer) applyConfiguration(update nbdns.Config) error {
 		s.searchDomainNotifier.onNewSearchDomains(s.SearchDomains())
 	}
 
-	s.updateNSGroupStates(update.NameServerGroups)
+	s.updateNSGroupStates(nameServerGroups)
 
 	return nil
 }
 
-func (s *DefaultServer) buildLocalHandlerUpdate(customZones []nbdns.CustomZone) ([]muxUpdate, map[string]nbdns.SimpleRecord, error) {
+func (s *DefaultServer) buildLocalHandlerUpdate(customZones []nbdns.CustomZone) ([]muxUpdate, map[string][]nbdns.SimpleRecord, error) {
 	var muxUpdates []muxUpdate
-	localRecords := make(map[string]nbdns.SimpleRecord, 0)
+	localRecords := make(map[string][]nbdns.SimpleRecord, 0)
 
 	for _, customZone := range customZones {
 
@@ -352,7 +455,7 @@ func (s *DefaultServer) buildLocalHandlerUpdate(customZones []nbdns.CustomZone)
 				return nil, nil, fmt.Errorf("received an invalid class type: %s", record.Class)
 			}
 			key := buildRecordKey(record.Name, class, uint16(record.Type))
-			localRecords[key] = record
+			localRecords[key] = append(localRecords[key], record)
 		}
 	}
 	return muxUpdates, localRecords, nil
@@ -361,6 +464,7 @@ func (s *DefaultServer) buildLocalHandlerUpdate(customZones []nbdns.CustomZone)
 func (s *DefaultServer) buildUpstreamHandlerUpdate(nameServerGroups []*nbdns.NameServerGroup) ([]muxUpdate, error) {
 
 	var muxUpdates []muxUpdate
+	var rules []matchRule
 	for _, nsGroup := range nameServerGroups {
 		if len(nsGroup.NameServers) == 0 {
 			log.Warn("received a nameserver group with empty nameserver list")
@@ -378,13 +482,22 @@ func (s *DefaultServer) buildUpstreamHandlerUpdate(nameServerGroups []*nbdns.Nam
 		if err != nil {
 			return nil, fmt.Errorf("unable to create a new upstream resolver, error: %v", err)
 		}
+		handler.responseObserver = s.responseObserver
+		handler.cache = s.cache
 		for _, ns := range nsGroup.NameServers {
-			if ns.NSType != nbdns.UDPNameServerType {
-				log.Warnf("skipping nameserver %s with type %s, this peer supports only %s",
-					ns.IP.String(), ns.NSType.String(), nbdns.UDPNameServerType.String())
-				continue
+			switch ns.NSType {
+			case nbdns.UDPNameServerType:
+				handler.upstreamServers = append(handler.upstreamServers, getNSHostPort(ns))
+			case nbdns.TLSNameServerType, nbdns.HTTPSNameServerType:
+				upstream, err := handler.addSecureUpstream(ns)
+				if err != nil {
+					log.Warnf("skipping nameserver %s with type %s: %v", ns.IP.String(), ns.NSType.String(), err)
+					continue
+				}
+				handler.upstreamServers = append(handler.upstreamServers, upstream)
+			default:
+				log.Warnf("skipping nameserver %s with unsupported type %s", ns.IP.String(), ns.NSType.String())
 			}
-			handler.upstreamServers = append(handler.upstreamServers, getNSHostPort(ns))
 		}
 
 		if len(handler.upstreamServers) == 0 {
@@ -408,6 +521,11 @@ func (s *DefaultServer) buildUpstreamHandlerUpdate(nameServerGroups []*nbdns.Nam
 				domain:  nbdns.RootZone,
 				handler: handler,
 			})
+			for _, domain := range nsGroup.Domains {
+				if match := nbdns.ParseMatchDomain(domain); match.Negated {
+					rules = append(rules, matchRule{zone: match.Zone, handler: handler, negated: true})
+				}
+			}
 			continue
 		}
 
@@ -416,11 +534,27 @@ func (s *DefaultServer) buildUpstreamHandlerUpdate(nameServerGroups []*nbdns.Nam
 			return nil, fmt.Errorf("received a non primary nameserver group with an empty domain list")
 		}
 
+		zones := make(map[string]struct{}, len(nsGroup.Domains))
 		for _, domain := range nsGroup.Domains {
 			if domain == "" {
 				handler.stop()
 				return nil, fmt.Errorf("received a nameserver group with an empty domain element")
 			}
+			if match := nbdns.ParseMatchDomain(domain); !match.Wildcard && !match.Negated {
+				zones[match.Zone] = struct{}{}
+			}
+		}
+
+		for _, domain := range nsGroup.Domains {
+			match := nbdns.ParseMatchDomain(domain)
+			if match.Negated || match.Wildcard {
+				// a wildcard is redundant when the group matches the zone itself as well
+				if _, found := zones[match.Zone]; found && match.Wildcard {
+					continue
+				}
+				rules = append(rules, matchRule{zone: match.Zone, handler: handler, negated: match.Negated})
+				continue
+			}
 			muxUpdates = append(muxUpdates, muxUpdate{
 				domain:  domain,
 				handler: handler,
@@ -428,7 +562,7 @@ func (s *DefaultServer) buildUpstreamHandlerUpdate(nameServerGroups []*nbdns.Nam
 		}
 	}
 
-	return muxUpdates, nil
+	return buildMatchRuleUpdates(muxUpdates, rules), nil
 }
 
 func (s *DefaultServer) updateMux(muxUpdates []muxUpdate) {
@@ -437,7 +571,7 @@ func (s *DefaultServer) updateMux(muxUpdates []muxUpdate) {
 	var isContainRootUpdate bool
 
 	for _, update := range muxUpdates {
-		s.service.RegisterMux(update.domain, update.handler)
+		s.registerMux(update.domain, update.handler)
 		muxUpdateMap[update.domain] = update.handler
 		if existingHandler, ok := s.dnsMuxMap[update.domain]; ok {
 			existingHandler.stop()
@@ -464,7 +598,16 @@ func (s *DefaultServer) updateMux(muxUpdates []muxUpdate) {
 	s.dnsMuxMap = muxUpdateMap
 }
 
-func (s *DefaultServer) updateLocalResolver(update map[string]nbdns.SimpleRecord) {
+// registerMux registers the handler on the dns mux, the queries it serves are recorded in the query log.
+// The queries of the handlers other than the local resolver are filtered by the deny lists
+func (s *DefaultServer) registerMux(domain string, handler dns.Handler) {
+	if _, local := handler.(*localResolver); !local {
+		handler = &blocklistHandler{handler: handler, blocklist: s.blocklist}
+	}
+	s.service.RegisterMux(domain, newQueryLogHandler(handler, s.queryLog))
+}
+
+func (s *DefaultServer) updateLocalResolver(update map[string][]nbdns.SimpleRecord) {
 	for key := range s.localResolver.registeredMap {
 		_, found := update[key]
 		if !found {
@@ -473,10 +616,10 @@ func (s *DefaultServer) updateLocalResolver(update map[string]nbdns.SimpleRecord
 	}
 
 	updatedMap := make(registrationMap)
-	for key, record := range update {
-		err := s.localResolver.registerRecord(record)
+	for key, records := range update {
+		err := s.localResolver.setRecords(key, records)
 		if err != nil {
-			log.Warnf("got an error while registering the record (%s), error: %v", record.String(), err)
+			log.Warnf("got an error while registering the records (%s), error: %v", key, err)
 		}
 		updatedMap[key] = struct{}{}
 	}
@@ -504,7 +647,7 @@ func (s *DefaultServer) upstreamCallbacks(
 		l.Info("Temporarily deactivating nameservers group due to timeout")
 
 		removeIndex = make(map[string]int)
-		for _, domain := range nsGroup.Domains {
+		for _, domain := range matchDomainZones(nsGroup.Domains) {
 			removeIndex[domain] = -1
 		}
 		if nsGroup.Primary {
@@ -541,7 +684,12 @@ func (s *DefaultServer) upstreamCallbacks(
 				continue
 			}
 			s.currentConfig.Domains[i].Disabled = false
-			s.service.RegisterMux(domain, handler)
+			// the zones of wildcard domains are served through their match handler
+			if registered, ok := s.dnsMuxMap[domain]; ok {
+				s.registerMux(domain, registered)
+				continue
+			}
+			s.registerMux(domain, handler)
 		}
 
 		l := log.WithField("nameservers", nsGroup.NameServers)
@@ -549,7 +697,7 @@ func (s *DefaultServer) upstreamCallbacks(
 
 		if nsGroup.Primary {
 			s.currentConfig.RouteAll = true
-			s.service.RegisterMux(nbdns.RootZone, handler)
+			s.registerMux(nbdns.RootZone, handler)
 		}
 		if err := s.hostManager.applyDNSConfig(s.currentConfig); err != nil {
 			l.WithError(err).Error("reactivate temporary disabled nameserver group, DNS update apply")
@@ -574,13 +722,14 @@ func (s *DefaultServer) addHostRootZone() {
 		return
 	}
 
+	handler.cache = s.cache
 	handler.upstreamServers = make([]string, 0)
 	for k := range s.hostsDNSHolder.get() {
 		handler.upstreamServers = append(handler.upstreamServers, k)
 	}
 	handler.deactivate = func(error) {}
 	handler.reactivate = func() {}
-	s.service.RegisterMux(nbdns.RootZone, handler)
+	s.registerMux(nbdns.RootZone, handler)
 }
 
 func (s *DefaultServer) updateNSGroupStates(groups []*nbdns.NameServerGroup) {
@@ -261,7 +261,7 @@ func TestUpdateDNSServer(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			wgIface, err := iface.NewWGIFace(fmt.Sprintf("utun230%d", n), fmt.Sprintf("100.66.100.%d/32", n+1), 33100, privKey.String(), iface.DefaultMTU, newNet, nil)
+			wgIface, err := iface.NewWGIFace(fmt.Sprintf("utun230%d", n), fmt.Sprintf("100.66.100.%d/32", n+1), 33100, privKey.String(), iface.DefaultMTU, newNet, nil, iface.WGModeAuto)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -339,7 +339,7 @@ func TestDNSFakeResolverHandleUpdates(t *testing.T) {
 	}
 
 	privKey, _ := wgtypes.GeneratePrivateKey()
-	wgIface, err := iface.NewWGIFace("utun2301", "100.66.100.1/32", 33100, privKey.String(), iface.DefaultMTU, newNet, nil)
+	wgIface, err := iface.NewWGIFace("utun2301", "100.66.100.1/32", 33100, privKey.String(), iface.DefaultMTU, newNet, nil, iface.WGModeAuto)
 	if err != nil {
 		t.Errorf("build interface wireguard: %v", err)
 		return
@@ -594,6 +594,24 @@ func TestDNSServerUpstreamDeactivateCallback(t *testing.T) {
 	}
 }
 
+func TestDNSServerOnUpdatedInterfaceAddress(t *testing.T) {
+	server := DefaultServer{
+		service: newServiceViaMemory(&mocWGIface{}),
+		localResolver: &localResolver{
+			registeredMap: make(registrationMap),
+		},
+		hostManager:        &mockHostConfigurator{},
+		previousConfigHash: 1234,
+		statusRecorder:     &peer.Status{},
+	}
+
+	server.OnUpdatedInterfaceAddress()
+
+	if server.previousConfigHash != 0 {
+		t.Errorf("the configuration hash should be reset so the next update is applied in full")
+	}
+}
+
 func TestDNSPermanent_updateHostDNS_emptyUpstream(t *testing.T) {
 	wgIFace, err := createWgInterfaceWithBind(t)
 	if err != nil {
@@ -797,7 +815,7 @@ func createWgInterfaceWithBind(t *testing.T) (*iface.WGIface, error) {
 	}
 
 	privKey, _ := wgtypes.GeneratePrivateKey()
-	wgIface, err := iface.NewWGIFace("utun2301", "100.66.100.2/24", 33100, privKey.String(), iface.DefaultMTU, newNet, nil)
+	wgIface, err := iface.NewWGIFace("utun2301", "100.66.100.2/24", 33100, privKey.String(), iface.DefaultMTU, newNet, nil, iface.WGModeAuto)
 	if err != nil {
 		t.Fatalf("build interface wireguard: %v", err)
 		return nil, err
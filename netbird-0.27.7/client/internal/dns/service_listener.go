@@ -95,12 +95,15 @@ func (s *serviceViaListener) Stop() {
 	if err != nil {
 		log.Errorf("stopping dns server listener returned an error: %v", err)
 	}
+	// the listener can be started again right away, e.g. on a new address
+	s.listenerIsRunning = false
 
 	if s.ebpfService != nil {
 		err = s.ebpfService.FreeDNSFwd()
 		if err != nil {
 			log.Errorf("stopping traffic forwarder returned an error: %v", err)
 		}
+		s.ebpfService = nil
 	}
 }
 
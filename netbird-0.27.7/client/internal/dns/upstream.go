@@ -16,6 +16,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/netbirdio/netbird/client/internal/peer"
+	nbdns "github.com/netbirdio/netbird/dns"
 )
 
 const (
@@ -41,6 +42,7 @@ type upstreamResolverBase struct {
 	cancel           context.CancelFunc
 	upstreamClient   upstreamClient
 	upstreamServers  []string
+	secureUpstreams  map[string]*secureUpstream
 	disabled         bool
 	failsCount       atomic.Int32
 	failsTillDeact   int32
@@ -48,9 +50,11 @@ type upstreamResolverBase struct {
 	reactivatePeriod time.Duration
 	upstreamTimeout  time.Duration
 
-	deactivate     func(error)
-	reactivate     func()
-	statusRecorder *peer.Status
+	deactivate       func(error)
+	reactivate       func()
+	statusRecorder   *peer.Status
+	responseObserver ResponseObserver
+	cache            *responseCache
 }
 
 func newUpstreamResolverBase(ctx context.Context, statusRecorder *peer.Status) *upstreamResolverBase {
@@ -69,6 +73,33 @@ func newUpstreamResolverBase(ctx context.Context, statusRecorder *peer.Status) *
 func (u *upstreamResolverBase) stop() {
 	log.Debugf("stopping serving DNS for upstreams %s", u.upstreamServers)
 	u.cancel()
+
+	for _, secure := range u.secureUpstreams {
+		secure.stop()
+	}
+}
+
+// addSecureUpstream registers a DNS over TLS or DNS over HTTPS nameserver and returns its upstream server entry
+func (u *upstreamResolverBase) addSecureUpstream(ns nbdns.NameServer) (string, error) {
+	secure, err := newSecureUpstream(ns)
+	if err != nil {
+		return "", err
+	}
+
+	if u.secureUpstreams == nil {
+		u.secureUpstreams = make(map[string]*secureUpstream)
+	}
+	upstream := secureUpstreamKey(ns)
+	u.secureUpstreams[upstream] = secure
+	return upstream, nil
+}
+
+// exchangeUpstream sends the query to the encrypted upstream if there is one for the entry, otherwise to the plain one
+func (u *upstreamResolverBase) exchangeUpstream(ctx context.Context, upstream string, r *dns.Msg) (*dns.Msg, time.Duration, error) {
+	if secure, ok := u.secureUpstreams[upstream]; ok {
+		return secure.exchange(ctx, r)
+	}
+	return u.upstreamClient.exchange(ctx, upstream, r)
 }
 
 // ServeDNS handles a DNS request
@@ -86,6 +117,19 @@ func (u *upstreamResolverBase) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	default:
 	}
 
+	if u.cache != nil {
+		if rm := u.cache.get(r); rm != nil {
+			log.Tracef("serving the response to %s from the cache", r.Question[0].Name)
+			if u.responseObserver != nil {
+				u.responseObserver(rm)
+			}
+			if err := w.WriteMsg(rm); err != nil {
+				log.WithError(err).Error("got an error while writing the cached response")
+			}
+			return
+		}
+	}
+
 	for _, upstream := range u.upstreamServers {
 		var rm *dns.Msg
 		var t time.Duration
@@ -93,7 +137,7 @@ func (u *upstreamResolverBase) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 		func() {
 			ctx, cancel := context.WithTimeout(u.ctx, u.upstreamTimeout)
 			defer cancel()
-			rm, t, err = u.upstreamClient.exchange(ctx, upstream, r)
+			rm, t, err = u.exchangeUpstream(ctx, upstream, r)
 		}()
 
 		if err != nil {
@@ -122,6 +166,14 @@ func (u *upstreamResolverBase) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 
 		log.Tracef("took %s to query the upstream %s", t, upstream)
 
+		if u.responseObserver != nil {
+			u.responseObserver(rm)
+		}
+
+		if u.cache != nil {
+			u.cache.set(r, rm)
+		}
+
 		err = w.WriteMsg(rm)
 		if err != nil {
 			log.WithError(err).Error("got an error while writing the upstream resolver response")
@@ -275,6 +327,6 @@ func (u *upstreamResolverBase) testNameserver(server string) error {
 
 	r := new(dns.Msg).SetQuestion(testRecord, dns.TypeSOA)
 
-	_, _, err := u.upstreamClient.exchange(ctx, server, r)
+	_, _, err := u.exchangeUpstream(ctx, server, r)
 	return err
 }
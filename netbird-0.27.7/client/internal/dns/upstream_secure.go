@@ -0,0 +1,229 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/miekg/dns"
+
+	nbdns "github.com/netbirdio/netbird/dns"
+)
+
+const (
+	dohMediaType       = "application/dns-message"
+	dohIdleConnTimeout = 90 * time.Second
+)
+
+// secureUpstream forwards the queries to a DNS over TLS or DNS over HTTPS nameserver. The connections always go to
+// the configured IP, the server name is only used to verify the nameserver certificate
+type secureUpstream struct {
+	nsType     nbdns.NameServerType
+	address    string
+	url        string
+	tlsClient  *dns.Client
+	httpClient *http.Client
+}
+
+// secureUpstreamKey returns the upstream server entry of an encrypted nameserver
+func secureUpstreamKey(ns nbdns.NameServer) string {
+	return fmt.Sprintf("%s://%s%s", ns.NSType, getNSHostPort(ns), ns.Path)
+}
+
+func newSecureUpstream(ns nbdns.NameServer) (*secureUpstream, error) {
+	serverName := ns.ServerName
+	if serverName == "" {
+		serverName = ns.IP.String()
+	}
+
+	tlsConfig, err := newUpstreamTLSConfig(serverName, ns.SPKIPins)
+	if err != nil {
+		return nil, err
+	}
+
+	address := net.JoinHostPort(ns.IP.String(), strconv.Itoa(ns.Port))
+	upstream := &secureUpstream{
+		nsType:  ns.NSType,
+		address: address,
+	}
+
+	switch ns.NSType {
+	case nbdns.TLSNameServerType:
+		upstream.tlsClient = &dns.Client{
+			Net:       "tcp-tls",
+			TLSConfig: tlsConfig,
+		}
+	case nbdns.HTTPSNameServerType:
+		path := ns.Path
+		if path == "" {
+			path = nbdns.DefaultDoHPath
+		}
+		upstream.url = "https://" + net.JoinHostPort(serverName, strconv.Itoa(ns.Port)) + path
+
+		dialer := &net.Dialer{}
+		upstream.httpClient = &http.Client{
+			Transport: &http.Transport{
+				// dial the nameserver IP whatever the URL host resolves to, the host is used for TLS and HTTP only
+				DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, network, address)
+				},
+				TLSClientConfig:   tlsConfig,
+				ForceAttemptHTTP2: true,
+				IdleConnTimeout:   dohIdleConnTimeout,
+			},
+		}
+	default:
+		return nil, fmt.Errorf("nameserver type %s is not encrypted", ns.NSType)
+	}
+
+	return upstream, nil
+}
+
+func (s *secureUpstream) exchange(ctx context.Context, r *dns.Msg) (*dns.Msg, time.Duration, error) {
+	if s.tlsClient != nil {
+		return s.tlsClient.ExchangeContext(ctx, r, s.address)
+	}
+	return s.exchangeHTTPS(ctx, r)
+}
+
+// exchangeHTTPS sends the query as described in RFC 8484, with a zero ID to keep the responses cacheable
+func (s *secureUpstream) exchangeHTTPS(ctx context.Context, r *dns.Msg) (*dns.Msg, time.Duration, error) {
+	start := time.Now()
+
+	query := r.Copy()
+	query.Id = 0
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, 0, fmt.Errorf("pack query: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(packed))
+	if err != nil {
+		return nil, 0, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", dohMediaType)
+	req.Header.Set("Accept", dohMediaType)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("unexpected response status %s from %s", resp.Status, s.url)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, dns.MaxMsgSize))
+	if err != nil {
+		return nil, 0, fmt.Errorf("read response: %w", err)
+	}
+
+	rm := new(dns.Msg)
+	if err := rm.Unpack(body); err != nil {
+		return nil, 0, fmt.Errorf("unpack response: %w", err)
+	}
+	rm.Id = r.Id
+
+	return rm, time.Since(start), nil
+}
+
+func (s *secureUpstream) stop() {
+	if s.httpClient != nil {
+		s.httpClient.CloseIdleConnections()
+	}
+}
+
+// newUpstreamTLSConfig returns the TLS configuration of an encrypted nameserver. Without pins the certificate is
+// verified with the system roots. With pins a certificate chain must contain one of the pinned public keys, a pinned
+// leaf certificate is accepted without a trusted chain so self-signed nameservers can be used
+func newUpstreamTLSConfig(serverName string, spkiPins []string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         serverName,
+		ClientSessionCache: tls.NewLRUClientSessionCache(0),
+	}
+
+	if len(spkiPins) == 0 {
+		return tlsConfig, nil
+	}
+
+	pins := make([][]byte, 0, len(spkiPins))
+	for _, pin := range spkiPins {
+		decoded, err := base64.StdEncoding.DecodeString(pin)
+		if err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("invalid SPKI pin %s", pin)
+		}
+		pins = append(pins, decoded)
+	}
+
+	// the default verification would reject the self-signed certificates, the chain is verified by the callback
+	tlsConfig.InsecureSkipVerify = true // #nosec G402
+	tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		return verifyPinnedCertificate(rawCerts, serverName, pins)
+	}
+
+	return tlsConfig, nil
+}
+
+func verifyPinnedCertificate(rawCerts [][]byte, serverName string, pins [][]byte) error {
+	if len(rawCerts) == 0 {
+		return fmt.Errorf("no certificate presented by the nameserver")
+	}
+
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("parse nameserver certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	if matchesSPKIPin(certs[0], pins) {
+		return nil
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	chains, err := certs[0].Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Intermediates: intermediates,
+	})
+	if err != nil {
+		return fmt.Errorf("verify nameserver certificate: %w", err)
+	}
+
+	for _, chain := range chains {
+		for _, cert := range chain {
+			if matchesSPKIPin(cert, pins) {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("nameserver certificate for %s doesn't match any of the SPKI pins", serverName)
+}
+
+func matchesSPKIPin(cert *x509.Certificate, pins [][]byte) bool {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	for _, pin := range pins {
+		if bytes.Equal(sum[:], pin) {
+			return true
+		}
+	}
+	return false
+}
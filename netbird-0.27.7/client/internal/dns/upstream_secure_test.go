@@ -0,0 +1,207 @@
+package dns
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	nbdns "github.com/netbirdio/netbird/dns"
+)
+
+func TestSecureUpstream_Exchange(t *testing.T) {
+	cert, pin := newTestCertificate(t)
+	otherPin := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	tlsAddr := startTestDoTServer(t, cert)
+	httpsAddr := startTestDoHServer(t, cert)
+
+	testCases := []struct {
+		name       string
+		nameServer nbdns.NameServer
+		shouldFail bool
+	}{
+		{
+			name:       "DNS over TLS with a matching pin",
+			nameServer: testNameServer(t, tlsAddr, nbdns.TLSNameServerType, "", pin),
+		},
+		{
+			name:       "DNS over TLS with another pin",
+			nameServer: testNameServer(t, tlsAddr, nbdns.TLSNameServerType, "", otherPin),
+			shouldFail: true,
+		},
+		{
+			name:       "DNS over TLS with an untrusted certificate",
+			nameServer: testNameServer(t, tlsAddr, nbdns.TLSNameServerType, ""),
+			shouldFail: true,
+		},
+		{
+			name:       "DNS over HTTPS with a matching pin",
+			nameServer: testNameServer(t, httpsAddr, nbdns.HTTPSNameServerType, nbdns.DefaultDoHPath, pin),
+		},
+		{
+			name:       "DNS over HTTPS with another pin",
+			nameServer: testNameServer(t, httpsAddr, nbdns.HTTPSNameServerType, nbdns.DefaultDoHPath, otherPin),
+			shouldFail: true,
+		},
+		{
+			name:       "DNS over HTTPS with a wrong path",
+			nameServer: testNameServer(t, httpsAddr, nbdns.HTTPSNameServerType, "/resolve", pin),
+			shouldFail: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			upstream, err := newSecureUpstream(testCase.nameServer)
+			require.NoError(t, err)
+			defer upstream.stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			query := new(dns.Msg).SetQuestion("netbird.cloud.", dns.TypeA)
+			response, _, err := upstream.exchange(ctx, query)
+			if testCase.shouldFail {
+				assert.Error(t, err, "should fail the exchange")
+				return
+			}
+			require.NoError(t, err, "should exchange the query")
+
+			assert.Equal(t, query.Id, response.Id, "should restore the query ID")
+			require.Len(t, response.Answer, 1)
+			assert.Equal(t, "10.0.0.1", response.Answer[0].(*dns.A).A.String())
+		})
+	}
+}
+
+func TestNewSecureUpstream_InvalidPin(t *testing.T) {
+	ns := testNameServer(t, "127.0.0.1:853", nbdns.TLSNameServerType, "", "not-a-pin")
+	_, err := newSecureUpstream(ns)
+	assert.Error(t, err)
+}
+
+func testNameServer(t *testing.T, address string, nsType nbdns.NameServerType, path string, pins ...string) nbdns.NameServer {
+	t.Helper()
+
+	addrPort, err := netip.ParseAddrPort(address)
+	require.NoError(t, err)
+
+	return nbdns.NameServer{
+		IP:         addrPort.Addr(),
+		NSType:     nsType,
+		Port:       int(addrPort.Port()),
+		ServerName: "dns.netbird.test",
+		SPKIPins:   pins,
+		Path:       path,
+	}
+}
+
+func newTestCertificate(t *testing.T) (tls.Certificate, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "dns.netbird.test"},
+		DNSNames:     []string{"dns.netbird.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	parsed, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	sum := sha256.Sum256(parsed.RawSubjectPublicKeyInfo)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func testAnswer(r *dns.Msg) *dns.Msg {
+	rm := new(dns.Msg).SetReply(r)
+	rm.Answer = append(rm.Answer, &dns.A{
+		Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+		A:   net.ParseIP("10.0.0.1"),
+	})
+	return rm
+}
+
+func startTestDoTServer(t *testing.T, cert tls.Certificate) string {
+	t.Helper()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12})
+	require.NoError(t, err)
+
+	server := &dns.Server{
+		Listener: listener,
+		Net:      "tcp-tls",
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+			_ = w.WriteMsg(testAnswer(r))
+		}),
+	}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	t.Cleanup(func() {
+		_ = server.Shutdown()
+	})
+
+	return listener.Addr().String()
+}
+
+func startTestDoHServer(t *testing.T, cert tls.Certificate) string {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != nbdns.DefaultDoHPath || r.Header.Get("Content-Type") != dohMediaType {
+			http.NotFound(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		query := new(dns.Msg)
+		if err := query.Unpack(body); err != nil || query.Id != 0 {
+			http.Error(w, "invalid query", http.StatusBadRequest)
+			return
+		}
+
+		packed, err := testAnswer(query).Pack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", dohMediaType)
+		_, _ = w.Write(packed)
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server.Listener.Addr().String()
+}
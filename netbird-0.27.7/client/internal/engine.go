@@ -4,19 +4,24 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"math/rand"
 	"net"
 	"net/netip"
 	"reflect"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pion/ice/v3"
 	"github.com/pion/stun/v2"
 	log "github.com/sirupsen/logrus"
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/netbirdio/netbird/client/firewall"
 	"github.com/netbirdio/netbird/client/firewall/manager"
@@ -27,11 +32,17 @@ import (
 	"github.com/netbirdio/netbird/client/internal/relay"
 	"github.com/netbirdio/netbird/client/internal/rosenpass"
 	"github.com/netbirdio/netbird/client/internal/routemanager"
+	"github.com/netbirdio/netbird/client/internal/speedtest"
+	"github.com/netbirdio/netbird/client/internal/stdnet"
+	"github.com/netbirdio/netbird/client/internal/tcptransport"
+	"github.com/netbirdio/netbird/client/internal/updater"
 	"github.com/netbirdio/netbird/client/internal/wgproxy"
 	nbssh "github.com/netbirdio/netbird/client/ssh"
+	"github.com/netbirdio/netbird/client/system"
 	nbdns "github.com/netbirdio/netbird/dns"
 	"github.com/netbirdio/netbird/iface"
 	"github.com/netbirdio/netbird/iface/bind"
+	"github.com/netbirdio/netbird/iface/wgcrypto"
 	mgm "github.com/netbirdio/netbird/management/client"
 	mgmProto "github.com/netbirdio/netbird/management/proto"
 	"github.com/netbirdio/netbird/route"
@@ -49,6 +60,30 @@ const (
 	PeerConnectionTimeoutMin = 30000 // ms
 )
 
+// transferStatsReportInterval is how often the WireGuard transfer statistics are reported to the Management Service
+const transferStatsReportInterval = time.Minute
+
+// dnsStatsReportInterval is how often the counters of the DNS query log are reported to the Management Service
+const dnsStatsReportInterval = 5 * time.Minute
+
+// firewallStatsReportInterval is how often the counters of the firewall rules are reported to the Management Service
+const firewallStatsReportInterval = 5 * time.Minute
+
+// peerLoadReportInterval is how often a routing peer reports its load to the Management Service
+const peerLoadReportInterval = 30 * time.Second
+
+// droppedConnsReportInterval is how often the latest dropped connections are reported to the Management Service
+const droppedConnsReportInterval = 5 * time.Minute
+
+// lazyFirewallIdleTimeout is the default time without traffic after which the firewall rules of a peer are removed
+const lazyFirewallIdleTimeout = 10 * time.Minute
+
+// lazyFirewallRefreshInterval is how often the active peers are read from the firewall with lazy firewall rules
+const lazyFirewallRefreshInterval = time.Second
+
+// droppedConnsReportLimit is the number of the latest dropped connections reported to the Management Service
+const droppedConnsReportLimit = 100
+
 var ErrResetConnection = fmt.Errorf("reset connection")
 
 // EngineConfig is a config for the Engine
@@ -56,12 +91,41 @@ type EngineConfig struct {
 	WgPort      int
 	WgIfaceName string
 
+	// WgPortPool is a set of ports the WireGuard listen port is picked from, empty means WgPort is always used
+	WgPortPool []int
+	// WgPortRoamingInterval is how often the listen port is moved to another port of WgPortPool, zero disables roaming
+	WgPortRoamingInterval time.Duration
+	// TCPFallbackPort is the TCP port the WireGuard traffic of peers is accepted on when UDP is blocked,
+	// zero disables the TCP fallback
+	TCPFallbackPort int
+
 	// WgAddr is a Wireguard local address (Netbird Network IP)
 	WgAddr string
 
 	// WgPrivateKey is a Wireguard private key of our peer (it MUST never leave the machine)
 	WgPrivateKey wgtypes.Key
 
+	// WgMode is the preferred WireGuard implementation of the interface
+	WgMode iface.WGMode
+
+	// MTU is the locally configured MTU of the interface, zero means unset
+	MTU int
+	// ManagementMTU is the MTU pushed by the management service, zero means unset
+	ManagementMTU int
+	// PMTUDiscovery enables probing of the path MTU to PMTUProbeAddress
+	PMTUDiscovery    bool
+	PMTUProbeAddress string
+
+	// Uplink is the locally configured interface name or source IP the traffic is pinned to, empty means unset
+	Uplink string
+	// ManagementUplink is the uplink pushed by the management service, empty means unset
+	ManagementUplink string
+
+	// ICE is the locally configured tuning of the ICE negotiation, its set settings override the ones of ManagementICE
+	ICE peer.ICEConfig
+	// ManagementICE is the tuning of the ICE negotiation pushed by the management service
+	ManagementICE peer.ICEConfig
+
 	// NetworkMonitor is a flag to enable network monitoring
 	NetworkMonitor bool
 
@@ -84,10 +148,62 @@ type EngineConfig struct {
 
 	CustomDNSAddress string
 
+	// DNSCacheMinTTL and DNSCacheMaxTTL clamp the time the upstream DNS responses are cached for
+	DNSCacheMinTTL time.Duration
+	DNSCacheMaxTTL time.Duration
+
+	// DNSQueryLog enables the DNS query log and the report of its counters to the Management Service
+	DNSQueryLog bool
+
+	// DNSHostOverrides are the local host records served by the resolver in the name=ip form
+	DNSHostOverrides []string
+
+	// DropLog enables the log of the dropped inbound connections and their report to the Management Service
+	DropLog bool
+
+	// LazyFirewallRules installs the per-peer firewall rules only for the peers with traffic within LazyFirewallIdle
+	LazyFirewallRules bool
+	LazyFirewallIdle  time.Duration
+
+	// DisableDNS and DisableClientRoutes ignore the DNS configuration and the routes of the network map
+	DisableDNS          bool
+	DisableClientRoutes bool
+	// ControlPlane lists the parts of the host the engine manages: dns, routes and acl, all of them when it is empty.
+	// It overrides the ManagementControlPlane pushed by the management service
+	ControlPlane           []string
+	ManagementControlPlane []string
+
+	// StageNetworkChanges stages the route and DNS changes of the network maps until they are approved, they are
+	// applied after StagedChangesAutoApply when it isn't zero
+	StageNetworkChanges    bool
+	StagedChangesAutoApply time.Duration
+
+	// AutoUpdate updates the client to the version pushed by the management service when its binary is signed with
+	// UpdatePublicKey
+	AutoUpdate      bool
+	UpdatePublicKey string
+	// UpdateAllowDowngrade applies the versions at or below the running one
+	UpdateAllowDowngrade bool
+
+	// LowMemory caps the DNS cache and the concurrent ICE agents and disables the route cache
+	LowMemory bool
+	// DisableSysctl keeps the route manager from changing the sysctls
+	DisableSysctl bool
+
+	// RosenpassEnabled and RosenpassPermissive are the locally configured Rosenpass setting
 	RosenpassEnabled    bool
 	RosenpassPermissive bool
+	// ManagementRosenpass is the Rosenpass setting pushed by the management service
+	ManagementRosenpass RosenpassConfig
+
+	// ManagementFeatureFlags are the feature flags pushed by the management service
+	ManagementFeatureFlags map[string]bool
 
 	ServerSSHAllowed bool
+	// SSHRecordingDir is the directory the SSH sessions are recorded to, empty disables the recording
+	SSHRecordingDir string
+	// SSHRecordingRetention is the time the SSH session recordings are kept for, zero means the default
+	SSHRecordingRetention time.Duration
 }
 
 // Engine is a mechanism responsible for reacting on Signal and Management stream events and managing connections to the remote peers.
@@ -104,6 +220,10 @@ type Engine struct {
 
 	// rpManager is a Rosenpass manager
 	rpManager *rosenpass.Manager
+	// rosenpass is the Rosenpass setting applied on start
+	rosenpass RosenpassConfig
+	// remoteRosenpassKeys are the Rosenpass public keys of the remote peers distributed by the management service
+	remoteRosenpassKeys map[string][]byte
 
 	// syncMsgMux is used to guarantee sequential Management Service message processing
 	syncMsgMux *sync.Mutex
@@ -115,6 +235,8 @@ type Engine struct {
 	STUNs []*stun.URI
 	// TURNs is a list of STUN servers used by ICE
 	TURNs []*stun.URI
+	// relayTURNs are the relay servers of the account selected for this peer in the network map, used next to TURNs
+	relayTURNs []*stun.URI
 
 	// clientRoutes is the most recent list of clientRoutes received from the Management Service
 	clientRoutes route.HAMap
@@ -128,18 +250,73 @@ type Engine struct {
 	wgInterface    *iface.WGIface
 	wgProxyFactory *wgproxy.Factory
 
+	// mtu is the MTU currently applied to the interface
+	mtu int
+	// pathMTU is the latest probed path MTU to the management service, zero if unknown
+	pathMTU int
+
+	// roamWgPort requests a new listen port from the port pool on the next start
+	roamWgPort bool
+	// wgPortChanged is set when the listen port changed and the peers have not been notified yet
+	wgPortChanged bool
+
 	udpMux *bind.UniversalUDPMuxDefault
 
+	// tcpTransport carries the WireGuard traffic over TCP when UDP is blocked, nil if the fallback is disabled
+	tcpTransport *tcptransport.Transport
+
+	// uplink is the resolved uplink the traffic is pinned to, nil if any uplink can be used
+	uplink *stdnet.Uplink
+	// ice is the tuning of the ICE negotiation applied to the connections
+	ice peer.ICEConfig
+	// features are the client behaviors applied on start
+	features featureFlags
+	// controlPlane are the parts of the host managed since the engine started
+	controlPlane controlPlane
+
+	// updater applies the client versions pushed by the management service, nil if the auto update is disabled
+	updater *updater.Updater
+
+	// actionHandler runs the remote actions needing the daemon, nil if the client can't run them
+	actionHandler PeerActionHandler
+	// savePrivateKey persists the WireGuard key generated for a key rotation
+	savePrivateKey func(key wgtypes.Key) error
+
+	// iceSlots caps the connections gathering ICE candidates at the same time, nil if unlimited
+	iceSlots chan struct{}
+
 	// networkSerial is the latest CurrentSerial (state ID) of the network sent by the Management service
 	networkSerial uint64
 
+	// routingPeer is set while the network map makes the peer the routing peer of a route, which reports its load
+	routingPeer atomic.Bool
+
+	// appliedNetwork are the routes and the DNS configuration applied last, nil until the first network map
+	appliedNetwork *networkChanges
+	// pendingNetwork are the route and DNS changes staged for an approval with StageNetworkChanges
+	pendingNetwork *pendingNetworkChanges
+	// discardedNetwork is the fingerprint of the changes discarded last, they aren't staged again until they change
+	discardedNetwork []byte
+	// reinstallNetwork is set on start for the applied routes and DNS configuration to be installed again before the
+	// next network map is staged
+	reinstallNetwork bool
+
 	networkWatcher *networkmonitor.NetworkWatcher
 
-	sshServerFunc func(hostKeyPEM []byte, addr string) (nbssh.Server, error)
+	// suspended is true while the system sleeps, the connections are closed until Resume
+	suspended bool
+
+	sshServerFunc func(hostKeyPEM []byte, addr string, config nbssh.ServerConfig) (nbssh.Server, error)
 	sshServer     nbssh.Server
 
+	// speedtestServer answers the throughput tests of the peers a policy allows, nil while none is allowed
+	speedtestServer *speedtest.Server
+
 	statusRecorder *peer.Status
 
+	// loginExpiry warns the user before the login of the peer expires
+	loginExpiry *loginExpirationWarner
+
 	firewall     manager.Manager
 	routeManager routemanager.Manager
 	acl          acl.Manager
@@ -213,6 +390,7 @@ func NewEngineWithProbes(
 		sshServerFunc:  nbssh.DefaultSSHServer,
 		statusRecorder: statusRecorder,
 		networkWatcher: networkmonitor.New(),
+		loginExpiry:    newLoginExpirationWarner(statusRecorder),
 		mgmProbe:       mgmProbe,
 		signalProbe:    signalProbe,
 		relayProbe:     relayProbe,
@@ -237,6 +415,10 @@ func (e *Engine) Stop() error {
 	}
 
 	e.clientRoutes = nil
+	// the changes are staged again from the network map received after a restart
+	e.dropPendingNetworkChanges()
+
+	e.loginExpiry.stop()
 
 	// very ugly but we want to remove peers from the WireGuard interface first before removing interface.
 	// Removing peers happens in the conn.Close() asynchronously
@@ -259,8 +441,26 @@ func (e *Engine) Start() error {
 	}
 	e.ctx, e.cancel = context.WithCancel(e.clientCtx)
 
+	if port := e.selectWgPort(e.roamWgPort); port != e.config.WgPort {
+		log.Infof("using WireGuard port %d (old value %d)", port, e.config.WgPort)
+		e.config.WgPort = port
+		e.wgPortChanged = true
+	}
+	e.roamWgPort = false
+
 	e.wgProxyFactory = wgproxy.NewFactory(e.ctx, e.config.WgPort)
 
+	e.uplink = e.resolveUplink()
+	e.ice = e.targetICE()
+	e.features = e.targetFeatureFlags()
+	e.controlPlane = e.targetControlPlane()
+	e.updater = e.newUpdater()
+	e.reinstallNetwork = e.appliedNetwork != nil
+
+	if err := iface.CheckInterfaceAvailable(e.config.WgIfaceName, e.config.WgPrivateKey.PublicKey().String()); err != nil {
+		return fmt.Errorf("check wg interface: %w", err)
+	}
+
 	wgIface, err := e.newWgIface()
 	if err != nil {
 		log.Errorf("failed creating wireguard interface instance %s: [%s]", e.config.WgIfaceName, err)
@@ -268,9 +468,11 @@ func (e *Engine) Start() error {
 	}
 	e.wgInterface = wgIface
 
-	if e.config.RosenpassEnabled {
+	e.rosenpass = e.targetRosenpass()
+	e.statusRecorder.UpdateRosenpass(e.rosenpass.Enabled, e.rosenpass.Permissive)
+	if e.rosenpass.Enabled {
 		log.Infof("rosenpass is enabled")
-		if e.config.RosenpassPermissive {
+		if e.rosenpass.Permissive {
 			log.Infof("running rosenpass in permissive mode")
 		} else {
 			log.Infof("running rosenpass in strict mode")
@@ -291,8 +493,17 @@ func (e *Engine) Start() error {
 		return fmt.Errorf("create dns server: %w", err)
 	}
 	e.dnsServer = dnsServer
+	e.dnsServer.SetCacheConfig(e.dnsCacheConfig())
+	e.dnsServer.SetQueryLogEnabled(e.config.DNSQueryLog)
+
+	routemanager.SetRouteCacheDisabled(e.config.LowMemory)
+	routemanager.SetSysctlDisabled(e.config.DisableSysctl)
+	if e.config.LowMemory {
+		e.iceSlots = make(chan struct{}, LowMemoryMaxICEAgents)
+	}
 
 	e.routeManager = routemanager.NewManager(e.ctx, e.config.WgPrivateKey.PublicKey().String(), e.wgInterface, e.statusRecorder, initialRoutes)
+	e.dnsServer.SetResponseObserver(e.routeManager.OnDNSResponse)
 	beforePeerHook, afterPeerHook, err := e.routeManager.Init()
 	if err != nil {
 		log.Errorf("Failed to initialize route manager: %s", err)
@@ -310,9 +521,16 @@ func (e *Engine) Start() error {
 		return fmt.Errorf("create wg interface: %w", err)
 	}
 
-	e.firewall, err = firewall.NewFirewall(e.ctx, e.wgInterface)
-	if err != nil {
-		log.Errorf("failed creating firewall manager: %s", err)
+	if e.controlPlane.acl {
+		e.firewall, err = firewall.NewFirewall(e.ctx, e.wgInterface)
+		if err != nil {
+			log.Errorf("failed creating firewall manager: %s", err)
+		}
+	} else {
+		log.Infof("the firewall isn't managed by the control plane, no firewall rule is installed")
+	}
+	if e.firewall != nil {
+		e.setupDropLog(e.firewall)
 	}
 
 	if e.firewall != nil && e.firewall.IsServerRouteSupported() {
@@ -332,6 +550,18 @@ func (e *Engine) Start() error {
 
 	if e.firewall != nil {
 		e.acl = acl.NewDefaultManager(e.firewall)
+		e.setupLazyFirewallRules(e.acl)
+	}
+
+	if e.uplink != nil && !e.wgInterface.IsUserspaceBind() {
+		log.Warnf("the kernel WireGuard interface sends its traffic along the routing table, use the userspace mode to pin it to uplink %s", e.uplink)
+	}
+
+	if e.wgInterface.IsUserspaceBind() {
+		log.Infof("userspace WireGuard uses the %s ChaCha20-Poly1305 implementation on CPU %s", wgcrypto.Implementation(), wgcrypto.DetectFeatures())
+		if !wgcrypto.Accelerated() && runtime.GOOS == "linux" {
+			log.Warnf("the CPU has no vector extensions for the userspace WireGuard encryption, load the kernel WireGuard module for a better throughput")
+		}
 	}
 
 	err = e.dnsServer.Initialize()
@@ -340,10 +570,32 @@ func (e *Engine) Start() error {
 		return fmt.Errorf("initialize dns server: %w", err)
 	}
 
+	if len(e.config.DNSHostOverrides) > 0 {
+		e.applyDNSHostOverrides()
+	}
+
 	e.receiveSignalEvents()
 	e.receiveManagementEvents()
+	e.reportPeerStatus()
+	e.detectNATType()
+	e.reportTransferStats()
+	e.reportPeerLoad()
+	if e.config.DNSQueryLog {
+		e.reportDNSStats()
+	}
+	e.reportFirewallStats()
+	if e.config.DropLog {
+		e.reportDroppedConnections()
+	}
+	if e.features.lazyFirewallRules {
+		e.refreshActivePeers()
+	}
 	e.receiveProbeEvents()
 
+	if e.config.PMTUDiscovery && e.config.PMTUProbeAddress != "" {
+		go e.runPMTUProbe()
+	}
+
 	if e.config.NetworkMonitor {
 		// starting network monitor at the very last to avoid disruptions
 		go e.networkWatcher.Start(e.ctx, func() {
@@ -359,6 +611,14 @@ func (e *Engine) Start() error {
 		log.Infof("Network monitor is disabled, not starting")
 	}
 
+	if e.config.WgPortRoamingInterval > 0 && len(e.config.WgPortPool) > 1 {
+		go e.runPortRoaming()
+	}
+
+	if e.config.TCPFallbackPort > 0 {
+		e.startTCPFallback()
+	}
+
 	return nil
 }
 
@@ -371,10 +631,34 @@ func (e *Engine) modifyPeers(peersUpdate []*mgmProto.RemotePeerConfig) error {
 	for _, p := range peersUpdate {
 		peerPubKey := p.GetWgPubKey()
 		if peerConn, ok := e.peerConns[peerPubKey]; ok {
-			if peerConn.WgConfig().AllowedIps != strings.Join(p.AllowedIps, ",") {
+			if e.presharedKeyChanged(peerConn, p) {
+				modified = append(modified, p)
+				continue
+			}
+			// the candidates gathered depend on the flag, a new ICE agent is needed
+			if peerConn.GetConf().RequireDirect != p.GetRequireDirect() {
 				modified = append(modified, p)
 				continue
 			}
+			// the keepalive interval is set when the connection is established
+			if peerConn.GetConf().KeepWarm != p.GetKeepWarm() {
+				modified = append(modified, p)
+				continue
+			}
+			allowedIPs := strings.Join(p.AllowedIps, ",")
+			if peerConn.WgConfig().AllowedIps != allowedIPs {
+				// Rosenpass addresses the peers by their WireGuard IP, so the connection has to be recreated
+				if e.rpManager != nil {
+					modified = append(modified, p)
+					continue
+				}
+				err := peerConn.UpdateAllowedIPs(allowedIPs)
+				if err != nil {
+					log.Warnf("failed to update allowed IPs of peer %s in place, recreating the connection: %v", peerPubKey, err)
+					modified = append(modified, p)
+					continue
+				}
+			}
 			err := e.statusRecorder.UpdatePeerFQDN(peerPubKey, p.GetFqdn())
 			if err != nil {
 				log.Warnf("error updating peer's %s fqdn in the status recorder, got error: %v", peerPubKey, err)
@@ -504,6 +788,13 @@ func SignalOfferAnswer(offerAnswer peer.OfferAnswer, myKey wgtypes.Key, remoteKe
 		return err
 	}
 
+	if offerAnswer.TCPFallback != nil {
+		msg.Body.TcpFallback = &sProto.TCPFallback{
+			Addresses:  offerAnswer.TCPFallback.Addresses,
+			UdpBlocked: offerAnswer.TCPFallback.UDPBlocked,
+		}
+	}
+
 	err = s.Send(msg)
 	if err != nil {
 		return err
@@ -530,6 +821,10 @@ func (e *Engine) handleSync(update *mgmProto.SyncResponse) error {
 		// todo update signal
 	}
 
+	for _, action := range update.GetActions() {
+		e.runPeerAction(action)
+	}
+
 	if update.GetNetworkMap() != nil {
 		// only apply new changes and ignore old ones
 		err := e.updateNetworkMap(update.GetNetworkMap())
@@ -547,8 +842,14 @@ func isNil(server nbssh.Server) bool {
 
 func (e *Engine) updateSSH(sshConf *mgmProto.SSHConfig) error {
 
-	if !e.config.ServerSSHAllowed {
+	if !e.targetFeatureFlags().sshServer {
 		log.Warnf("running SSH server is not permitted")
+		if !isNil(e.sshServer) {
+			if err := e.sshServer.Stop(); err != nil {
+				log.Warnf("failed to stop SSH server %v", err)
+			}
+			e.sshServer = nil
+		}
 		return nil
 	} else {
 
@@ -562,22 +863,30 @@ func (e *Engine) updateSSH(sshConf *mgmProto.SSHConfig) error {
 				// nil sshServer means it has not yet been started
 				var err error
 				e.sshServer, err = e.sshServerFunc(e.config.SSHKey,
-					fmt.Sprintf("%s:%d", e.wgInterface.Address().IP.String(), nbssh.DefaultSSHPort))
+					fmt.Sprintf("%s:%d", e.wgInterface.Address().IP.String(), nbssh.DefaultSSHPort),
+					nbssh.ServerConfig{
+						RecordingDir:       e.config.SSHRecordingDir,
+						RecordingRetention: e.config.SSHRecordingRetention,
+						OnSessionClosed:    e.reportSSHSession,
+					})
 				if err != nil {
 					return err
 				}
-				go func() {
+				go func(sshServer nbssh.Server) {
 					// blocking
-					err = e.sshServer.Start()
+					err := sshServer.Start()
 					if err != nil {
 						// will throw error when we stop it even if it is a graceful stop
 						log.Debugf("stopped SSH server with error %v", err)
 					}
 					e.syncMsgMux.Lock()
 					defer e.syncMsgMux.Unlock()
-					e.sshServer = nil
+					// the server might have been replaced in the meantime, e.g. after an address change
+					if e.sshServer == sshServer {
+						e.sshServer = nil
+					}
 					log.Infof("stopped SSH server")
-				}()
+				}(e.sshServer)
 			} else {
 				log.Debugf("SSH server is already running")
 			}
@@ -596,14 +905,42 @@ func (e *Engine) updateSSH(sshConf *mgmProto.SSHConfig) error {
 
 func (e *Engine) updateConfig(conf *mgmProto.PeerConfig) error {
 	if e.wgInterface.Address().String() != conf.Address {
-		oldAddr := e.wgInterface.Address().String()
-		log.Debugf("updating peer address from %s to %s", oldAddr, conf.Address)
-		err := e.wgInterface.UpdateAddr(conf.Address)
+		err := e.updateWgAddr(conf.Address)
 		if err != nil {
 			return err
 		}
-		e.config.WgAddr = conf.Address
-		log.Infof("updated peer address from %s to %s", oldAddr, conf.Address)
+	}
+
+	if int(conf.GetMtu()) != e.config.ManagementMTU {
+		log.Debugf("management MTU changed from %d to %d", e.config.ManagementMTU, conf.GetMtu())
+		e.config.ManagementMTU = int(conf.GetMtu())
+		e.applyMTU()
+	}
+
+	if conf.GetUplink() != e.config.ManagementUplink {
+		e.onManagementUplinkChange(conf.GetUplink())
+	}
+
+	if ice := toICEConfig(conf.GetIce()); !reflect.DeepEqual(ice, e.config.ManagementICE) {
+		e.onManagementICEChange(ice)
+	}
+
+	if rosenpass := toRosenpassConfig(conf.GetRosenpass()); rosenpass != e.config.ManagementRosenpass {
+		e.onManagementRosenpassChange(rosenpass)
+	}
+
+	if flags := toFeatureFlags(conf.GetFeatureFlags()); !maps.Equal(flags, e.config.ManagementFeatureFlags) {
+		e.onManagementFeatureFlagsChange(flags)
+	}
+
+	if parts := conf.GetControlPlane(); !slices.Equal(parts, e.config.ManagementControlPlane) {
+		e.onManagementControlPlaneChange(parts)
+	}
+
+	e.loginExpiry.update(toLoginExpiration(conf.GetLoginExpiration()))
+
+	if e.updater != nil {
+		e.updater.Offer(e.ctx, toUpdateTarget(conf.GetClientUpdate()))
 	}
 
 	if conf.GetSshConfig() != nil {
@@ -616,13 +953,81 @@ func (e *Engine) updateConfig(conf *mgmProto.PeerConfig) error {
 	e.statusRecorder.UpdateLocalPeerState(peer.LocalPeerState{
 		IP:              e.config.WgAddr,
 		PubKey:          e.config.WgPrivateKey.PublicKey().String(),
-		KernelInterface: iface.WireGuardModuleIsLoaded(),
+		KernelInterface: !e.wgInterface.IsUserspaceBind(),
+		UserspaceReason: e.wgInterface.UserspaceReason(),
 		FQDN:            conf.GetFqdn(),
 	})
 
 	return nil
 }
 
+// updateWgAddr changes the address of the WireGuard interface in place, so the WireGuard sessions with the remote peers
+// are kept, and refreshes the components bound to the old address. Routes and DNS records are applied again with the
+// network map that comes along with the address change.
+func (e *Engine) updateWgAddr(addr string) error {
+	oldAddr := e.wgInterface.Address().String()
+	log.Debugf("updating peer address from %s to %s", oldAddr, addr)
+
+	err := e.wgInterface.UpdateAddr(addr)
+	if errors.Is(err, iface.ErrAddrUpdateNotSupported) {
+		log.Infof("interface %s doesn't support address updates, restarting the client to apply the address %s", e.wgInterface.Name(), addr)
+		_ = CtxGetState(e.ctx).Wrap(ErrResetConnection)
+		e.clientCancel()
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("update interface address: %w", err)
+	}
+	e.config.WgAddr = addr
+
+	e.resetFirewall()
+	e.dnsServer.OnUpdatedInterfaceAddress()
+
+	// the SSH server listens on the interface address, it is started again by updateSSH
+	if !isNil(e.sshServer) {
+		if err := e.sshServer.Stop(); err != nil {
+			log.Warnf("failed to stop SSH server %v", err)
+		}
+		e.sshServer = nil
+	}
+	// so does the speed test server, it is started again with the network map
+	e.stopSpeedtestServer()
+
+	log.Infof("updated peer address from %s to %s", oldAddr, addr)
+	return nil
+}
+
+// resetFirewall recreates the firewall and the ACL manager, as some of their rules contain the interface address.
+// The rules are applied again with the next network map.
+func (e *Engine) resetFirewall() {
+	if e.firewall == nil {
+		return
+	}
+
+	if err := e.firewall.Reset(); err != nil {
+		log.Warnf("failed to reset firewall: %s", err)
+	}
+	e.acl = nil
+
+	fw, err := firewall.NewFirewall(e.ctx, e.wgInterface)
+	if err != nil {
+		log.Errorf("failed creating firewall manager: %s", err)
+		e.firewall = nil
+		return
+	}
+	e.firewall = fw
+	e.setupDropLog(fw)
+
+	if fw.IsServerRouteSupported() {
+		if err := e.routeManager.EnableServerRouter(fw); err != nil {
+			log.Errorf("failed to enable server router: %s", err)
+		}
+	}
+
+	e.acl = acl.NewDefaultManager(fw)
+	e.setupLazyFirewallRules(e.acl)
+}
+
 // receiveManagementEvents connects to the Management Service event stream to receive updates from the management service
 // E.g. when a new peer has been registered and we are allowed to connect to it.
 func (e *Engine) receiveManagementEvents() {
@@ -640,6 +1045,410 @@ func (e *Engine) receiveManagementEvents() {
 	log.Debugf("connecting to Management Service updates stream")
 }
 
+// reportPeerStatus sends the route conflicts detected by the route manager and the detected NAT type to the
+// Management Service every time they change. The first report clears conflicts left over from a previous session.
+func (e *Engine) reportPeerStatus() {
+	rosenpassPubKey := e.getRosenpassPubKey()
+	go func() {
+		for {
+			conflictsChanged := e.statusRecorder.GetRouteConflictsChangeNotifier()
+			natTypeChanged := e.statusRecorder.GetNATTypeChangeNotifier()
+
+			report := &mgmProto.PeerStatusReport{
+				NatType:         string(e.statusRecorder.GetNATType()),
+				RosenpassPubKey: rosenpassPubKey,
+			}
+			for _, conflict := range e.statusRecorder.GetRouteConflicts() {
+				report.RouteConflicts = append(report.RouteConflicts, &mgmProto.RouteConflict{
+					NetID:         conflict.NetID,
+					Network:       conflict.Network.String(),
+					ConflictsWith: conflict.ConflictsWith,
+					Reason:        conflict.Reason,
+				})
+			}
+
+			if err := e.mgmClient.ReportPeerStatus(report); err != nil {
+				log.Warnf("failed to report peer status to Management Service: %v", err)
+			}
+
+			select {
+			case <-e.ctx.Done():
+				return
+			case <-conflictsChanged:
+			case <-natTypeChanged:
+			}
+		}
+	}()
+}
+
+// reportSSHSession sends an SSH session to the embedded SSH server that ended to the Management Service, which stores
+// it as an audit event
+func (e *Engine) reportSSHSession(session nbssh.SessionInfo) {
+	log.Infof("SSH session of %s from %s ended after %s", session.User, session.RemoteAddr, session.Duration.Round(time.Second))
+
+	report := &mgmProto.SSHSessionReport{
+		WgPubKey:  session.PeerKey,
+		User:      session.User,
+		StartedAt: timestamppb.New(session.StartedAt),
+		Duration:  durationpb.New(session.Duration),
+	}
+	if err := e.mgmClient.ReportSSHSession(report); err != nil {
+		log.Warnf("failed to report SSH session to Management Service: %v", err)
+	}
+}
+
+// reportTransferStats periodically sends the WireGuard transfer statistics of the peer connections to the Management Service
+func (e *Engine) reportTransferStats() {
+	go func() {
+		ticker := time.NewTicker(transferStatsReportInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-e.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			report, err := e.transferStatsReport()
+			if err != nil {
+				log.Debugf("failed to collect transfer stats: %v", err)
+				continue
+			}
+
+			if err := e.mgmClient.ReportTransferStats(report); err != nil {
+				log.Debugf("failed to report transfer stats to Management Service: %v", err)
+			}
+		}
+	}()
+}
+
+// reportPeerLoad periodically sends the CPU, memory and WireGuard throughput of the peer to the Management Service
+// while it is a routing peer, so that the clients of its route groups prefer the members that aren't overloaded
+func (e *Engine) reportPeerLoad() {
+	go func() {
+		ticker := time.NewTicker(peerLoadReportInterval)
+		defer ticker.Stop()
+
+		cpuTimes, err := system.GetCPUTimes()
+		if err != nil {
+			log.Debugf("the load of the peer won't be reported: %v", err)
+			return
+		}
+		wgBytes, _ := e.wgTransferredBytes()
+		sampledAt := time.Now()
+
+		for {
+			select {
+			case <-e.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			currentCPUTimes, err := system.GetCPUTimes()
+			if err != nil {
+				log.Debugf("failed to read the CPU times: %v", err)
+				continue
+			}
+			currentWgBytes, err := e.wgTransferredBytes()
+			if err != nil {
+				log.Debugf("failed to collect transfer stats: %v", err)
+				continue
+			}
+			previousCPUTimes, previousWgBytes, previousSampledAt := cpuTimes, wgBytes, sampledAt
+			cpuTimes, wgBytes, sampledAt = currentCPUTimes, currentWgBytes, time.Now()
+
+			if !e.routingPeer.Load() {
+				continue
+			}
+
+			report := &mgmProto.PeerLoadReport{
+				CpuPercent: cpuTimes.CPUPercent(previousCPUTimes),
+			}
+			if report.MemoryPercent, err = system.GetMemoryPercent(); err != nil {
+				log.Debugf("failed to read the memory usage: %v", err)
+			}
+			// the counters of the removed peers make the total decrease
+			if elapsed := sampledAt.Sub(previousSampledAt).Seconds(); wgBytes >= previousWgBytes && elapsed > 0 {
+				report.ThroughputBps = uint64(float64(wgBytes-previousWgBytes) * 8 / elapsed)
+			}
+
+			if err := e.mgmClient.ReportPeerLoad(report); err != nil {
+				log.Debugf("failed to report peer load to Management Service: %v", err)
+			}
+		}
+	}()
+}
+
+// wgTransferredBytes returns the bytes received and sent over the connections of the WireGuard interface
+func (e *Engine) wgTransferredBytes() (uint64, error) {
+	allStats, err := e.wgInterface.GetAllStats()
+	if err != nil {
+		return 0, err
+	}
+
+	var total uint64
+	for _, stats := range allStats {
+		total += uint64(max(stats.RxBytes, 0) + max(stats.TxBytes, 0))
+	}
+	return total, nil
+}
+
+func (e *Engine) transferStatsReport() (*mgmProto.TransferStatsReport, error) {
+	e.syncMsgMux.Lock()
+	defer e.syncMsgMux.Unlock()
+
+	allStats, err := e.wgInterface.GetAllStats()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &mgmProto.TransferStatsReport{}
+	for key := range e.peerConns {
+		wgStats, ok := allStats[key]
+		if !ok {
+			continue
+		}
+
+		peerStats := &mgmProto.PeerTransferStats{
+			WgPubKey: key,
+			RxBytes:  wgStats.RxBytes,
+			TxBytes:  wgStats.TxBytes,
+			Endpoint: wgStats.Endpoint,
+		}
+		if state, err := e.statusRecorder.GetPeer(key); err == nil {
+			peerStats.Relayed = state.Relayed
+		}
+		if !wgStats.LastHandshake.IsZero() {
+			peerStats.LastHandshake = timestamppb.New(wgStats.LastHandshake)
+		}
+		report.Stats = append(report.Stats, peerStats)
+	}
+	return report, nil
+}
+
+// reportDNSStats periodically sends the counters of the DNS query log to the Management Service
+func (e *Engine) reportDNSStats() {
+	go func() {
+		ticker := time.NewTicker(dnsStatsReportInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-e.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			_, stats := e.dnsServer.QueryLog()
+			report := &mgmProto.DNSStatsReport{
+				LocalQueries:     stats.Local,
+				ForwardedQueries: stats.Forwarded,
+				BlockedQueries:   stats.Blocked,
+				FailedQueries:    stats.Failed,
+				AverageLatency:   durationpb.New(stats.AverageLatency()),
+			}
+			if err := e.mgmClient.ReportDNSStats(report); err != nil {
+				log.Debugf("failed to report DNS stats to Management Service: %v", err)
+			}
+		}
+	}()
+}
+
+// reportFirewallStats periodically sends the counters of the firewall rules by policy to the Management Service
+func (e *Engine) reportFirewallStats() {
+	go func() {
+		ticker := time.NewTicker(firewallStatsReportInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-e.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			report, err := e.firewallStatsReport()
+			if err != nil {
+				log.Debugf("failed to read firewall counters: %v", err)
+				continue
+			}
+			if report == nil {
+				continue
+			}
+			if err := e.mgmClient.ReportFirewallStats(report); err != nil {
+				log.Debugf("failed to report firewall stats to Management Service: %v", err)
+			}
+		}
+	}()
+}
+
+// firewallStatsReport builds the report of the counters of the firewall rules by policy.
+// It returns nil when the firewall can't read the counters of its rules or no policy is applied
+func (e *Engine) firewallStatsReport() (*mgmProto.FirewallStatsReport, error) {
+	e.syncMsgMux.Lock()
+	defer e.syncMsgMux.Unlock()
+
+	if e.acl == nil {
+		return nil, nil
+	}
+
+	counters, err := e.acl.PolicyCounters()
+	if err != nil || len(counters) == 0 {
+		return nil, err
+	}
+
+	report := &mgmProto.FirewallStatsReport{}
+	for policyID, c := range counters {
+		report.Policies = append(report.Policies, &mgmProto.PolicyFirewallStats{
+			PolicyID: policyID,
+			Packets:  c.Packets,
+			Bytes:    c.Bytes,
+		})
+	}
+	return report, nil
+}
+
+// setupDropLog enables the log of the dropped connections on the firewall when it is configured
+func (e *Engine) setupDropLog(fw manager.Manager) {
+	if !e.config.DropLog {
+		return
+	}
+
+	logger, ok := fw.(manager.DropLogger)
+	if !ok {
+		log.Warnf("the firewall can't log the dropped connections, it requires the userspace firewall")
+		return
+	}
+	logger.SetDropLogEnabled(true)
+}
+
+// setupLazyFirewallRules makes the ACL manager install the rules of the active peers only when it is configured
+func (e *Engine) setupLazyFirewallRules(aclManager acl.Manager) {
+	if !e.features.lazyFirewallRules {
+		return
+	}
+
+	idleTimeout := e.config.LazyFirewallIdle
+	if idleTimeout == 0 {
+		idleTimeout = lazyFirewallIdleTimeout
+	}
+	if err := aclManager.EnableLazyRules(idleTimeout); err != nil {
+		log.Warnf("failed to enable lazy firewall rules, installing the rules of all the peers: %v", err)
+	}
+}
+
+// refreshActivePeers periodically updates the firewall rules of the ACL manager to the peers with recent traffic
+func (e *Engine) refreshActivePeers() {
+	go func() {
+		ticker := time.NewTicker(lazyFirewallRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-e.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			e.syncMsgMux.Lock()
+			if e.acl != nil {
+				e.acl.RefreshActivePeers()
+			}
+			e.syncMsgMux.Unlock()
+		}
+	}()
+}
+
+// reportDroppedConnections periodically sends the latest dropped connections to the Management Service
+func (e *Engine) reportDroppedConnections() {
+	go func() {
+		ticker := time.NewTicker(droppedConnsReportInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-e.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			conns, err := e.GetDroppedConnections()
+			if err != nil || len(conns) == 0 {
+				continue
+			}
+			if len(conns) > droppedConnsReportLimit {
+				conns = conns[len(conns)-droppedConnsReportLimit:]
+			}
+
+			report := &mgmProto.DroppedConnectionsReport{}
+			for _, conn := range conns {
+				report.Connections = append(report.Connections, &mgmProto.DroppedConnection{
+					SourceIP:        conn.SrcIP.String(),
+					SourcePort:      uint32(conn.SrcPort),
+					DestinationPort: uint32(conn.DstPort),
+					Protocol:        string(conn.Protocol),
+					PolicyIDs:       conn.PolicyIDs,
+					FirstSeen:       timestamppb.New(conn.FirstSeen),
+					LastSeen:        timestamppb.New(conn.LastSeen),
+					Packets:         conn.Packets,
+				})
+			}
+			if err := e.mgmClient.ReportDroppedConnections(report); err != nil {
+				log.Debugf("failed to report dropped connections to Management Service: %v", err)
+			}
+		}
+	}()
+}
+
+// GetDroppedConnections returns the latest inbound connections dropped by the firewall, the oldest first
+func (e *Engine) GetDroppedConnections() ([]acl.DroppedConnection, error) {
+	e.syncMsgMux.Lock()
+	defer e.syncMsgMux.Unlock()
+
+	if !e.config.DropLog {
+		return nil, fmt.Errorf("log of the dropped connections is disabled, enable it with --log-dropped-connections")
+	}
+	if e.acl == nil {
+		return nil, fmt.Errorf("firewall is not running")
+	}
+	if _, ok := e.firewall.(manager.DropLogger); !ok {
+		return nil, fmt.Errorf("the firewall can't log the dropped connections, it requires the userspace firewall")
+	}
+
+	return e.acl.DroppedConnections(), nil
+}
+
+// GetDNSQueryLog returns the queries recorded in the DNS query log with its counters
+func (e *Engine) GetDNSQueryLog() ([]dns.QueryLogEntry, dns.QueryStats, error) {
+	e.syncMsgMux.Lock()
+	defer e.syncMsgMux.Unlock()
+
+	if e.dnsServer == nil {
+		return nil, dns.QueryStats{}, fmt.Errorf("DNS server is not running")
+	}
+	if !e.config.DNSQueryLog {
+		return nil, dns.QueryStats{}, fmt.Errorf("DNS query log is disabled, enable it with --dns-query-log")
+	}
+
+	entries, stats := e.dnsServer.QueryLog()
+	return entries, stats, nil
+}
+
+// applyDNSHostOverrides serves the local host records of the config with the DNS server
+func (e *Engine) applyDNSHostOverrides() {
+	overrides, err := dns.ParseHostOverrides(e.config.DNSHostOverrides)
+	if err != nil {
+		log.Errorf("failed to parse the DNS host overrides: %v", err)
+		return
+	}
+	if err := e.dnsServer.SetHostOverrides(overrides); err != nil {
+		log.Errorf("failed to apply the DNS host overrides: %v", err)
+		return
+	}
+	log.Infof("serving %d DNS host overrides", len(overrides))
+}
+
 func (e *Engine) updateSTUNs(stuns []*mgmProto.HostConfig) error {
 	if len(stuns) == 0 {
 		return nil
@@ -662,20 +1471,48 @@ func (e *Engine) updateTURNs(turns []*mgmProto.ProtectedHostConfig) error {
 	if len(turns) == 0 {
 		return nil
 	}
-	var newTURNs []*stun.URI
 	log.Debugf("got TURNs update from Management Service, updating")
+	newTURNs, err := toTURNURIs(turns)
+	if err != nil {
+		return err
+	}
+	e.TURNs = newTURNs
+
+	return nil
+}
+
+// updateRelays replaces the relay servers selected for this peer, the credentials of the ones with a secret being
+// rotated by the Management Service
+func (e *Engine) updateRelays(relays []*mgmProto.ProtectedHostConfig) error {
+	relayTURNs, err := toTURNURIs(relays)
+	if err != nil {
+		return err
+	}
+	log.Debugf("got %d relay servers from Management Service", len(relayTURNs))
+	e.relayTURNs = relayTURNs
+
+	return nil
+}
+
+// turnURIs returns the TURN servers of the Management Service followed by the relay servers of the account
+func (e *Engine) turnURIs() []*stun.URI {
+	turns := make([]*stun.URI, 0, len(e.TURNs)+len(e.relayTURNs))
+	turns = append(turns, e.TURNs...)
+	return append(turns, e.relayTURNs...)
+}
+
+func toTURNURIs(turns []*mgmProto.ProtectedHostConfig) ([]*stun.URI, error) {
+	var uris []*stun.URI
 	for _, turn := range turns {
 		url, err := stun.ParseURI(turn.HostConfig.Uri)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		url.Username = turn.User
 		url.Password = turn.Password
-		newTURNs = append(newTURNs, url)
+		uris = append(uris, url)
 	}
-	e.TURNs = newTURNs
-
-	return nil
+	return uris, nil
 }
 
 func (e *Engine) updateNetworkMap(networkMap *mgmProto.NetworkMap) error {
@@ -697,6 +1534,15 @@ func (e *Engine) updateNetworkMap(networkMap *mgmProto.NetworkMap) error {
 	log.Debugf("got peers update from Management Service, total peers to connect to = %d", len(networkMap.GetRemotePeers()))
 
 	e.updateOfflinePeers(networkMap.GetOfflinePeers())
+	e.updateRemoteRosenpassKeys(networkMap.GetRemotePeers())
+
+	// update the relays before the peers for the new connections to use them
+	if len(networkMap.GetRelays()) > 0 || networkMap.GetRelaysIsEmpty() {
+		err := e.updateRelays(networkMap.GetRelays())
+		if err != nil {
+			return err
+		}
+	}
 
 	// cleanup request, most likely our peer has been deleted
 	if networkMap.GetRemotePeersIsEmpty() {
@@ -723,39 +1569,29 @@ func (e *Engine) updateNetworkMap(networkMap *mgmProto.NetworkMap) error {
 
 		e.statusRecorder.FinishPeerListModifications()
 
-		// update SSHServer by adding remote peer SSH keys
+		if e.wgPortChanged {
+			e.wgPortChanged = false
+			e.notifyWgPortChange()
+		}
+
+		// update SSHServer by adding remote peer SSH keys, the management service omits the keys of the peers
+		// the policies don't allow to log in
 		if !isNil(e.sshServer) {
 			for _, config := range networkMap.GetRemotePeers() {
-				if config.GetSshConfig() != nil && config.GetSshConfig().GetSshPubKey() != nil {
-					err := e.sshServer.AddAuthorizedKey(config.WgPubKey, string(config.GetSshConfig().GetSshPubKey()))
-					if err != nil {
-						log.Warnf("failed adding authorized key to SSH DefaultServer %v", err)
-					}
+				if len(config.GetSshConfig().GetSshPubKey()) == 0 {
+					e.sshServer.RemoveAuthorizedKey(config.WgPubKey)
+					continue
+				}
+				err := e.sshServer.AddAuthorizedKey(config.WgPubKey, string(config.GetSshConfig().GetSshPubKey()))
+				if err != nil {
+					log.Warnf("failed adding authorized key to SSH DefaultServer %v", err)
 				}
 			}
 		}
 	}
-	protoRoutes := networkMap.GetRoutes()
-	if protoRoutes == nil {
-		protoRoutes = []*mgmProto.Route{}
-	}
-
-	_, clientRoutes, err := e.routeManager.UpdateRoutes(serial, toRoutes(protoRoutes))
-	if err != nil {
-		log.Errorf("failed to update clientRoutes, err: %v", err)
-	}
-
-	e.clientRoutes = clientRoutes
-
-	protoDNSConfig := networkMap.GetDNSConfig()
-	if protoDNSConfig == nil {
-		protoDNSConfig = &mgmProto.DNSConfig{}
-	}
-
-	err = e.dnsServer.UpdateDNSServer(serial, toDNSConfig(protoDNSConfig))
-	if err != nil {
-		log.Errorf("failed to update dns server, err: %v", err)
-	}
+	e.updateSpeedtestServer(networkMap.GetRemotePeers())
+	e.updateRoutesAndDNS(e.toNetworkChanges(networkMap))
+	e.routingPeer.Store(len(serverRoutes(networkMap.GetRoutes(), e.config.WgPrivateKey.PublicKey().String())) > 0)
 
 	if e.acl != nil {
 		e.acl.ApplyFiltering(networkMap)
@@ -782,6 +1618,9 @@ func toRoutes(protoRoutes []*mgmProto.Route) []*route.Route {
 			Peer:        protoRoute.Peer,
 			Metric:      int(protoRoute.Metric),
 			Masquerade:  protoRoute.Masquerade,
+			Domains:     protoRoute.Domains,
+			KeepRoute:   protoRoute.KeepRoute,
+			Weight:      int(protoRoute.Weight),
 		}
 		routes = append(routes, convertedRoute)
 	}
@@ -817,17 +1656,29 @@ func toDNSConfig(protoDNSConfig *mgmProto.DNSConfig) nbdns.Config {
 			Primary:              nsGroup.GetPrimary(),
 			Domains:              nsGroup.GetDomains(),
 			SearchDomainsEnabled: nsGroup.GetSearchDomainsEnabled(),
+			Priority:             int(nsGroup.GetPriority()),
 		}
 		for _, ns := range nsGroup.GetNameServers() {
 			dnsNS := nbdns.NameServer{
-				IP:     netip.MustParseAddr(ns.GetIP()),
-				NSType: nbdns.NameServerType(ns.GetNSType()),
-				Port:   int(ns.GetPort()),
+				IP:         netip.MustParseAddr(ns.GetIP()),
+				NSType:     nbdns.NameServerType(ns.GetNSType()),
+				Port:       int(ns.GetPort()),
+				ServerName: ns.GetServerName(),
+				SPKIPins:   ns.GetSPKIPins(),
+				Path:       ns.GetPath(),
 			}
 			dnsNSGroup.NameServers = append(dnsNSGroup.NameServers, dnsNS)
 		}
 		dnsUpdate.NameServerGroups = append(dnsUpdate.NameServerGroups, dnsNSGroup)
 	}
+
+	if blocklist := protoDNSConfig.GetBlocklist(); blocklist != nil {
+		dnsUpdate.Blocklist = nbdns.BlocklistConfig{
+			URLs:            blocklist.GetURLs(),
+			Response:        nbdns.BlockResponse(blocklist.GetResponse()),
+			RefreshInterval: blocklist.GetRefreshInterval().AsDuration(),
+		}
+	}
 	return dnsUpdate
 }
 
@@ -863,7 +1714,11 @@ func (e *Engine) addNewPeer(peerConfig *mgmProto.RemotePeerConfig) error {
 	peerKey := peerConfig.GetWgPubKey()
 	peerIPs := peerConfig.GetAllowedIps()
 	if _, ok := e.peerConns[peerKey]; !ok {
-		conn, err := e.createPeerConn(peerKey, strings.Join(peerIPs, ","))
+		presharedKey, err := e.peerPresharedKey(peerConfig)
+		if err != nil {
+			return fmt.Errorf("parse preshared key of peer %s: %w", peerKey, err)
+		}
+		conn, err := e.createPeerConn(peerKey, strings.Join(peerIPs, ","), presharedKey, peerConfig.GetRequireDirect(), peerConfig.GetKeepWarm())
 		if err != nil {
 			return fmt.Errorf("create peer connection: %w", err)
 		}
@@ -905,7 +1760,7 @@ func (e *Engine) connWorker(conn *peer.Conn, peerKey string) {
 
 		// we might have received new STUN and TURN servers meanwhile, so update them
 		e.syncMsgMux.Lock()
-		conn.UpdateStunTurn(append(e.STUNs, e.TURNs...))
+		conn.UpdateStunTurn(append(e.STUNs, e.turnURIs()...))
 		e.syncMsgMux.Unlock()
 
 		err := conn.Open(e.ctx)
@@ -922,6 +1777,40 @@ func (e *Engine) connWorker(conn *peer.Conn, peerKey string) {
 	}
 }
 
+// peerPresharedKey returns the preshared key distributed by the management service for the peer,
+// falling back to the preshared key of the local configuration
+func (e *Engine) peerPresharedKey(peerConfig *mgmProto.RemotePeerConfig) (*wgtypes.Key, error) {
+	if peerConfig.GetPresharedKey() == "" {
+		return e.config.PreSharedKey, nil
+	}
+
+	key, err := wgtypes.ParseKey(peerConfig.GetPresharedKey())
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// presharedKeyChanged checks if the connection has to be recreated to apply the preshared key of the peer.
+// With Rosenpass the key is managed by Rosenpass instead.
+func (e *Engine) presharedKeyChanged(peerConn *peer.Conn, peerConfig *mgmProto.RemotePeerConfig) bool {
+	if e.rosenpass.Enabled {
+		return false
+	}
+
+	presharedKey, err := e.peerPresharedKey(peerConfig)
+	if err != nil {
+		log.Warnf("failed to parse preshared key of peer %s: %v", peerConfig.GetWgPubKey(), err)
+		return false
+	}
+
+	current := peerConn.WgConfig().PreSharedKey
+	if current == nil || presharedKey == nil {
+		return current != presharedKey
+	}
+	return *current != *presharedKey
+}
+
 func (e *Engine) peerExists(peerKey string) bool {
 	e.syncMsgMux.Lock()
 	defer e.syncMsgMux.Unlock()
@@ -929,21 +1818,21 @@ func (e *Engine) peerExists(peerKey string) bool {
 	return ok
 }
 
-func (e *Engine) createPeerConn(pubKey string, allowedIPs string) (*peer.Conn, error) {
+func (e *Engine) createPeerConn(pubKey string, allowedIPs string, presharedKey *wgtypes.Key, requireDirect, keepWarm bool) (*peer.Conn, error) {
 	log.Debugf("creating peer connection %s", pubKey)
 	var stunTurn []*stun.URI
 	stunTurn = append(stunTurn, e.STUNs...)
-	stunTurn = append(stunTurn, e.TURNs...)
+	stunTurn = append(stunTurn, e.turnURIs()...)
 
 	wgConfig := peer.WgConfig{
 		RemoteKey:    pubKey,
 		WgListenPort: e.config.WgPort,
 		WgInterface:  e.wgInterface,
 		AllowedIps:   allowedIPs,
-		PreSharedKey: e.config.PreSharedKey,
+		PreSharedKey: presharedKey,
 	}
 
-	if e.config.RosenpassEnabled && !e.config.RosenpassPermissive {
+	if e.rosenpass.Enabled && !e.rosenpass.Permissive {
 		lk := []byte(e.config.WgPrivateKey.PublicKey().String())
 		rk := []byte(wgConfig.RemoteKey)
 		var keyInput []byte
@@ -980,6 +1869,12 @@ func (e *Engine) createPeerConn(pubKey string, allowedIPs string) (*peer.Conn, e
 		UserspaceBind:        e.wgInterface.IsUserspaceBind(),
 		RosenpassPubKey:      e.getRosenpassPubKey(),
 		RosenpassAddr:        e.getRosenpassAddr(),
+		TCPTransport:         e.tcpTransport,
+		Uplink:               e.uplink,
+		ICESlots:             e.iceSlots,
+		RequireDirect:        requireDirect,
+		KeepWarm:             keepWarm,
+		ICE:                  e.ice,
 	}
 
 	peerConn, err := peer.NewConn(config, e.statusRecorder, e.wgProxyFactory, e.mobileDep.TunAdapter, e.mobileDep.IFaceDiscover)
@@ -1042,12 +1937,7 @@ func (e *Engine) receiveSignalEvents() {
 
 				conn.RegisterProtoSupportMeta(msg.Body.GetFeaturesSupported())
 
-				var rosenpassPubKey []byte
-				rosenpassAddr := ""
-				if msg.GetBody().GetRosenpassConfig() != nil {
-					rosenpassPubKey = msg.GetBody().GetRosenpassConfig().GetRosenpassPubKey()
-					rosenpassAddr = msg.GetBody().GetRosenpassConfig().GetRosenpassServerAddr()
-				}
+				rosenpassPubKey, rosenpassAddr := e.remoteRosenpassConfig(msg)
 				conn.OnRemoteOffer(peer.OfferAnswer{
 					IceCredentials: peer.IceCredentials{
 						UFrag: remoteCred.UFrag,
@@ -1057,6 +1947,7 @@ func (e *Engine) receiveSignalEvents() {
 					Version:         msg.GetBody().GetNetBirdVersion(),
 					RosenpassPubKey: rosenpassPubKey,
 					RosenpassAddr:   rosenpassAddr,
+					TCPFallback:     tcpFallbackFromSignal(msg.GetBody().GetTcpFallback()),
 				})
 			case sProto.Body_ANSWER:
 				remoteCred, err := signal.UnMarshalCredential(msg)
@@ -1066,12 +1957,7 @@ func (e *Engine) receiveSignalEvents() {
 
 				conn.RegisterProtoSupportMeta(msg.GetBody().GetFeaturesSupported())
 
-				var rosenpassPubKey []byte
-				rosenpassAddr := ""
-				if msg.GetBody().GetRosenpassConfig() != nil {
-					rosenpassPubKey = msg.GetBody().GetRosenpassConfig().GetRosenpassPubKey()
-					rosenpassAddr = msg.GetBody().GetRosenpassConfig().GetRosenpassServerAddr()
-				}
+				rosenpassPubKey, rosenpassAddr := e.remoteRosenpassConfig(msg)
 				conn.OnRemoteAnswer(peer.OfferAnswer{
 					IceCredentials: peer.IceCredentials{
 						UFrag: remoteCred.UFrag,
@@ -1081,6 +1967,7 @@ func (e *Engine) receiveSignalEvents() {
 					Version:         msg.GetBody().GetNetBirdVersion(),
 					RosenpassPubKey: rosenpassPubKey,
 					RosenpassAddr:   rosenpassAddr,
+					TCPFallback:     tcpFallbackFromSignal(msg.GetBody().GetTcpFallback()),
 				})
 			case sProto.Body_CANDIDATE:
 				candidate, err := ice.UnmarshalCandidate(msg.GetBody().Payload)
@@ -1089,6 +1976,8 @@ func (e *Engine) receiveSignalEvents() {
 					return err
 				}
 				conn.OnRemoteCandidate(candidate)
+			case sProto.Body_PORT_CHANGE:
+				return e.onRemotePortChange(msg.Key, int(msg.GetBody().GetWgListenPort()))
 			case sProto.Body_MODE:
 			}
 
@@ -1188,6 +2077,7 @@ func (e *Engine) close() {
 			log.Warnf("failed stopping the SSH server: %v", err)
 		}
 	}
+	e.stopSpeedtestServer()
 
 	if e.firewall != nil {
 		err := e.firewall.Reset()
@@ -1199,6 +2089,13 @@ func (e *Engine) close() {
 	if e.rpManager != nil {
 		_ = e.rpManager.Close()
 	}
+
+	if e.tcpTransport != nil {
+		if err := e.tcpTransport.Close(); err != nil {
+			log.Warnf("failed to close the tcp transport: %s", err)
+		}
+		e.tcpTransport = nil
+	}
 }
 
 func (e *Engine) readInitialSettings() ([]*route.Route, *nbdns.Config, error) {
@@ -1217,6 +2114,17 @@ func (e *Engine) newWgIface() (*iface.WGIface, error) {
 		log.Errorf("failed to create pion's stdnet: %s", err)
 	}
 
+	if e.uplink != nil {
+		if err := transportNet.SetUplink(e.uplink); err != nil {
+			log.Errorf("failed to pin pion's stdnet to uplink %s: %s", e.uplink, err)
+		}
+	}
+	if len(e.ice.ExcludedSubnets) > 0 {
+		if err := transportNet.SetExcludedSubnets(e.ice.ExcludedSubnets); err != nil {
+			log.Errorf("failed to exclude subnets from pion's stdnet: %s", err)
+		}
+	}
+
 	var mArgs *iface.MobileIFaceArguments
 	switch runtime.GOOS {
 	case "android":
@@ -1231,7 +2139,8 @@ func (e *Engine) newWgIface() (*iface.WGIface, error) {
 	default:
 	}
 
-	return iface.NewWGIFace(e.config.WgIfaceName, e.config.WgAddr, e.config.WgPort, e.config.WgPrivateKey.String(), iface.DefaultMTU, transportNet, mArgs)
+	e.mtu = e.targetMTU()
+	return iface.NewWGIFace(e.config.WgIfaceName, e.config.WgAddr, e.config.WgPort, e.config.WgPrivateKey.String(), e.mtu, transportNet, mArgs, e.config.WgMode)
 }
 
 func (e *Engine) wgInterfaceCreate() (err error) {
@@ -1299,6 +2208,17 @@ func (e *Engine) GetRouteManager() routemanager.Manager {
 	return e.routeManager
 }
 
+// SetPacketCapture taps the packets of the userspace WireGuard interface, nil stops capturing
+func (e *Engine) SetPacketCapture(capture iface.PacketCapture) error {
+	e.syncMsgMux.Lock()
+	defer e.syncMsgMux.Unlock()
+
+	if e.wgInterface == nil {
+		return fmt.Errorf("the interface is not created")
+	}
+	return e.wgInterface.SetPacketCapture(capture)
+}
+
 func findIPFromInterfaceName(ifaceName string) (net.IP, error) {
 	iface, err := net.InterfaceByName(ifaceName)
 	if err != nil {
@@ -1375,14 +2295,14 @@ func (e *Engine) receiveProbeEvents() {
 		go e.wgProbe.Receive(e.ctx, func() bool {
 			log.Debug("received wg probe request")
 
-			for _, peer := range e.peerConns {
-				key := peer.GetKey()
-				wgStats, err := peer.GetConf().WgConfig.WgInterface.GetStats(key)
-				if err != nil {
-					log.Debugf("failed to get wg stats for peer %s: %s", key, err)
-				}
+			allStats, err := e.wgInterface.GetAllStats()
+			if err != nil {
+				log.Debugf("failed to get wg stats: %s", err)
+			}
+
+			for key := range e.peerConns {
 				// wgStats could be zero value, in which case we just reset the stats
-				if err := e.statusRecorder.UpdateWireGuardPeerState(key, wgStats); err != nil {
+				if err := e.statusRecorder.UpdateWireGuardPeerState(key, allStats[key]); err != nil {
 					log.Debugf("failed to update wg stats for peer %s: %s", key, err)
 				}
 			}
@@ -1397,5 +2317,5 @@ func (e *Engine) probeSTUNs() []relay.ProbeResult {
 }
 
 func (e *Engine) probeTURNs() []relay.ProbeResult {
-	return relay.ProbeAll(e.ctx, relay.ProbeTURN, e.TURNs)
+	return relay.ProbeAll(e.ctx, relay.ProbeTURN, e.turnURIs())
 }
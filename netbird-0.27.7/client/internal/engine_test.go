@@ -13,6 +13,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pion/stun/v2"
 	"github.com/pion/transport/v3/stdnet"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -88,7 +89,7 @@ func TestEngine_SSH(t *testing.T) {
 
 	sshCtx, cancel := context.WithCancel(context.Background())
 
-	engine.sshServerFunc = func(hostKeyPEM []byte, addr string) (ssh.Server, error) {
+	engine.sshServerFunc = func(hostKeyPEM []byte, addr string, config ssh.ServerConfig) (ssh.Server, error) {
 		return &ssh.MockServer{
 			Ctx: sshCtx,
 			StopFunc: func() error {
@@ -161,9 +162,28 @@ func TestEngine_SSH(t *testing.T) {
 	assert.NotNil(t, engine.sshServer)
 	assert.Contains(t, sshKeysAdded, "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFATYCqaQw/9id1Qkq3n16JYhDhXraI6Pc1fgB8ynEfQ")
 
+	// the peer isn't allowed to log in anymore, the management service omits its SSH key
+	networkMap = &mgmtProto.NetworkMap{
+		Serial: 8,
+		PeerConfig: &mgmtProto.PeerConfig{Address: "100.64.0.1/24",
+			SshConfig: &mgmtProto.SSHConfig{SshEnabled: true}},
+		RemotePeers: []*mgmtProto.RemotePeerConfig{
+			{WgPubKey: peerWithSSH.WgPubKey, AllowedIps: peerWithSSH.AllowedIps, SshConfig: &mgmtProto.SSHConfig{}},
+		},
+		RemotePeersIsEmpty: false,
+	}
+
+	err = engine.updateNetworkMap(networkMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, sshPeersRemoved, "MNHf3Ma6z6mdLbriAJbqhX7+nM/B71lgw2+91q3LfhU=")
+	sshPeersRemoved = nil
+
 	// now remove peer
 	networkMap = &mgmtProto.NetworkMap{
-		Serial:             8,
+		Serial:             9,
 		RemotePeers:        []*mgmtProto.RemotePeerConfig{},
 		RemotePeersIsEmpty: false,
 	}
@@ -179,7 +199,7 @@ func TestEngine_SSH(t *testing.T) {
 
 	// now disable SSH server
 	networkMap = &mgmtProto.NetworkMap{
-		Serial: 9,
+		Serial: 10,
 		PeerConfig: &mgmtProto.PeerConfig{Address: "100.64.0.1/24",
 			SshConfig: &mgmtProto.SSHConfig{SshEnabled: false}},
 		RemotePeers:        []*mgmtProto.RemotePeerConfig{peerWithSSH},
@@ -216,7 +236,7 @@ func TestEngine_UpdateNetworkMap(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	engine.wgInterface, err = iface.NewWGIFace("utun102", "100.64.0.1/24", engine.config.WgPort, key.String(), iface.DefaultMTU, newNet, nil)
+	engine.wgInterface, err = iface.NewWGIFace("utun102", "100.64.0.1/24", engine.config.WgPort, key.String(), iface.DefaultMTU, newNet, nil, iface.WGModeAuto)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -570,7 +590,7 @@ func TestEngine_UpdateNetworkMapWithRoutes(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			engine.wgInterface, err = iface.NewWGIFace(wgIfaceName, wgAddr, engine.config.WgPort, key.String(), iface.DefaultMTU, newNet, nil)
+			engine.wgInterface, err = iface.NewWGIFace(wgIfaceName, wgAddr, engine.config.WgPort, key.String(), iface.DefaultMTU, newNet, nil, iface.WGModeAuto)
 			assert.NoError(t, err, "shouldn't return error")
 			input := struct {
 				inputSerial uint64
@@ -739,7 +759,7 @@ func TestEngine_UpdateNetworkMapWithDNSUpdate(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			engine.wgInterface, err = iface.NewWGIFace(wgIfaceName, wgAddr, 33100, key.String(), iface.DefaultMTU, newNet, nil)
+			engine.wgInterface, err = iface.NewWGIFace(wgIfaceName, wgAddr, 33100, key.String(), iface.DefaultMTU, newNet, nil, iface.WGModeAuto)
 			assert.NoError(t, err, "shouldn't return error")
 
 			mockRouteManager := &routemanager.MockManager{
@@ -983,7 +1003,7 @@ func createEngine(ctx context.Context, cancel context.CancelFunc, setupKey strin
 	}
 
 	info := system.GetInfo(ctx)
-	resp, err := mgmtClient.Register(*publicKey, setupKey, "", info, nil)
+	resp, err := mgmtClient.Register(*publicKey, setupKey, "", info, nil, "")
 	if err != nil {
 		return nil, err
 	}
@@ -1091,3 +1111,51 @@ func getPeers(e *Engine) int {
 
 	return len(e.peerConns)
 }
+
+func TestEngine_UpdateRelays(t *testing.T) {
+	staticTURN, err := stun.ParseURI("turn:turn.example.com:3478")
+	require.NoError(t, err)
+	engine := &Engine{TURNs: []*stun.URI{staticTURN}}
+
+	relays := []*mgmtProto.ProtectedHostConfig{
+		{
+			HostConfig: &mgmtProto.HostConfig{Uri: "turns:relay.example.com:5349?transport=tcp"},
+			User:       "1700003600",
+			Password:   "secret",
+		},
+	}
+	require.NoError(t, engine.updateRelays(relays))
+
+	turns := engine.turnURIs()
+	require.Len(t, turns, 2, "the relays should be used next to the TURNs of the Management Service")
+	assert.Equal(t, staticTURN, turns[0])
+	assert.Equal(t, "relay.example.com", turns[1].Host)
+	assert.Equal(t, "1700003600", turns[1].Username)
+	assert.Equal(t, "secret", turns[1].Password)
+
+	require.NoError(t, engine.updateRelays(nil))
+	assert.Equal(t, []*stun.URI{staticTURN}, engine.turnURIs(), "an empty relay list should remove the relays")
+
+	relays[0].HostConfig.Uri = "relay.example.com"
+	assert.Error(t, engine.updateRelays(relays), "invalid relay URIs should fail")
+}
+
+func TestEngine_PeerPresharedKey(t *testing.T) {
+	localKey, err := wgtypes.GenerateKey()
+	require.NoError(t, err)
+	managementKey, err := wgtypes.GenerateKey()
+	require.NoError(t, err)
+
+	engine := &Engine{config: &EngineConfig{PreSharedKey: &localKey}}
+
+	key, err := engine.peerPresharedKey(&mgmtProto.RemotePeerConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, localKey, *key, "the local preshared key should be used if none is distributed")
+
+	key, err = engine.peerPresharedKey(&mgmtProto.RemotePeerConfig{PresharedKey: managementKey.String()})
+	require.NoError(t, err)
+	assert.Equal(t, managementKey, *key, "the distributed preshared key should take precedence")
+
+	_, err = engine.peerPresharedKey(&mgmtProto.RemotePeerConfig{PresharedKey: "invalid"})
+	assert.Error(t, err)
+}
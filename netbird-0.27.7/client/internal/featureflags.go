@@ -0,0 +1,97 @@
+package internal
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/client/internal/dns"
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+)
+
+// The feature flags the management service switches client behaviors with, the unknown ones are ignored
+const (
+	// featureLazyFirewallRules overrides the local lazy firewall rules setting
+	featureLazyFirewallRules = "lazy_firewall_rules"
+	// featureDNSCache disables the cache of the upstream DNS responses when it is off
+	featureDNSCache = "dns_cache"
+	// featureSSHServer keeps the SSH server from running when it is off, it doesn't allow the SSH server on a peer
+	// disallowing it locally
+	featureSSHServer = "ssh_server"
+)
+
+// featureFlags are the client behaviors resulting from the local settings and the feature flags of the management
+// service
+type featureFlags struct {
+	lazyFirewallRules bool
+	dnsCache          bool
+	sshServer         bool
+}
+
+// toFeatureFlags converts the feature flags pushed by the management service
+func toFeatureFlags(flags []*mgmProto.FeatureFlag) map[string]bool {
+	if len(flags) == 0 {
+		return nil
+	}
+	featureFlags := make(map[string]bool, len(flags))
+	for _, flag := range flags {
+		featureFlags[flag.GetName()] = flag.GetEnabled()
+	}
+	return featureFlags
+}
+
+// targetFeatureFlags returns the client behaviors of the engine, the feature flags of the management service
+// override the local settings
+func (e *Engine) targetFeatureFlags() featureFlags {
+	features := featureFlags{
+		lazyFirewallRules: e.config.LazyFirewallRules,
+		dnsCache:          true,
+		sshServer:         e.config.ServerSSHAllowed,
+	}
+
+	mgm := e.config.ManagementFeatureFlags
+	if enabled, ok := mgm[featureLazyFirewallRules]; ok {
+		features.lazyFirewallRules = enabled
+	}
+	if enabled, ok := mgm[featureDNSCache]; ok {
+		features.dnsCache = enabled
+	}
+	if enabled, ok := mgm[featureSSHServer]; ok && !enabled {
+		features.sshServer = false
+	}
+	return features
+}
+
+// onManagementFeatureFlagsChange applies the feature flags pushed by the management service. The DNS cache is switched
+// in place and the SSH server with the SSH config of the same update. The engine is restarted when the lazy firewall
+// rules change, as the ACL manager sets them up on start
+func (e *Engine) onManagementFeatureFlagsChange(flags map[string]bool) {
+	log.Debugf("management feature flags changed from %v to %v", e.config.ManagementFeatureFlags, flags)
+	e.config.ManagementFeatureFlags = flags
+
+	target := e.targetFeatureFlags()
+	if target.lazyFirewallRules != e.features.lazyFirewallRules {
+		log.Infof("lazy firewall rules switched to %t, restarting engine", target.lazyFirewallRules)
+		e.restart()
+		return
+	}
+
+	if target.dnsCache != e.features.dnsCache {
+		log.Infof("DNS cache switched to %t", target.dnsCache)
+		e.features.dnsCache = target.dnsCache
+		if e.dnsServer != nil {
+			e.dnsServer.SetCacheConfig(e.dnsCacheConfig())
+		}
+	}
+}
+
+// dnsCacheConfig returns the configuration of the cache of the upstream DNS responses
+func (e *Engine) dnsCacheConfig() dns.CacheConfig {
+	cacheConfig := dns.CacheConfig{
+		MinTTL:   e.config.DNSCacheMinTTL,
+		MaxTTL:   e.config.DNSCacheMaxTTL,
+		Disabled: !e.features.dnsCache,
+	}
+	if e.config.LowMemory {
+		cacheConfig.MaxEntries = dns.LowMemoryCacheSize
+	}
+	return cacheConfig
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/client/internal/dns"
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+)
+
+func TestToFeatureFlags(t *testing.T) {
+	assert.Nil(t, toFeatureFlags(nil))
+	assert.Equal(t, map[string]bool{featureDNSCache: false, "unknown": true}, toFeatureFlags([]*mgmProto.FeatureFlag{
+		{Name: featureDNSCache},
+		{Name: "unknown", Enabled: true},
+	}))
+}
+
+func TestEngine_targetFeatureFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   EngineConfig
+		expected featureFlags
+	}{
+		{
+			name:     "local settings",
+			config:   EngineConfig{LazyFirewallRules: true, ServerSSHAllowed: true},
+			expected: featureFlags{lazyFirewallRules: true, dnsCache: true, sshServer: true},
+		},
+		{
+			name: "overridden by the management",
+			config: EngineConfig{
+				LazyFirewallRules: true,
+				ServerSSHAllowed:  true,
+				ManagementFeatureFlags: map[string]bool{
+					featureLazyFirewallRules: false,
+					featureDNSCache:          false,
+					featureSSHServer:         false,
+					"unknown":                true,
+				},
+			},
+			expected: featureFlags{},
+		},
+		{
+			name: "SSH server disallowed locally",
+			config: EngineConfig{
+				ManagementFeatureFlags: map[string]bool{featureLazyFirewallRules: true, featureSSHServer: true},
+			},
+			expected: featureFlags{lazyFirewallRules: true, dnsCache: true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			engine := &Engine{config: &tc.config}
+			assert.Equal(t, tc.expected, engine.targetFeatureFlags())
+		})
+	}
+}
+
+func TestEngine_onManagementFeatureFlagsChange(t *testing.T) {
+	var cacheConfig dns.CacheConfig
+	engine := &Engine{
+		config:   &EngineConfig{DNSCacheMinTTL: 60},
+		features: featureFlags{dnsCache: true},
+		dnsServer: &dns.MockServer{
+			SetCacheConfigFunc: func(config dns.CacheConfig) { cacheConfig = config },
+		},
+	}
+
+	engine.onManagementFeatureFlagsChange(map[string]bool{featureDNSCache: false})
+	assert.False(t, engine.features.dnsCache)
+	assert.Equal(t, dns.CacheConfig{MinTTL: 60, Disabled: true}, cacheConfig, "the DNS cache should be switched in place")
+
+	engine.onManagementFeatureFlagsChange(nil)
+	assert.True(t, engine.features.dnsCache)
+	assert.Equal(t, dns.CacheConfig{MinTTL: 60}, cacheConfig)
+}
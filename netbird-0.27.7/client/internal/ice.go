@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"reflect"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+)
+
+// targetICE returns the tuning of the ICE negotiation of the connections. Each locally configured setting takes
+// precedence over the one pushed by the management service
+func (e *Engine) targetICE() peer.ICEConfig {
+	local, mgm := e.config.ICE, e.config.ManagementICE
+	target := mgm
+	if len(local.DisabledCandidateTypes) > 0 {
+		target.DisabledCandidateTypes = local.DisabledCandidateTypes
+	}
+	if len(local.ExcludedSubnets) > 0 {
+		target.ExcludedSubnets = local.ExcludedSubnets
+	}
+	if local.FailedTimeout > 0 {
+		target.FailedTimeout = local.FailedTimeout
+	}
+	if local.DisconnectedTimeout > 0 {
+		target.DisconnectedTimeout = local.DisconnectedTimeout
+	}
+	return target
+}
+
+// toICEConfig converts the ICE settings pushed by the management service, the invalid ones are ignored
+func toICEConfig(conf *mgmProto.ICEConfig) peer.ICEConfig {
+	var ice peer.ICEConfig
+	if conf == nil {
+		return ice
+	}
+
+	if len(conf.GetDisabledCandidateTypes()) > 0 {
+		types, err := peer.ParseCandidateTypes(conf.GetDisabledCandidateTypes())
+		if err != nil {
+			log.Warnf("ignoring the disabled ICE candidate types of the management service: %v", err)
+		} else {
+			ice.DisabledCandidateTypes = types
+		}
+	}
+	if len(conf.GetExcludedSubnets()) > 0 {
+		subnets, err := peer.ParseSubnets(conf.GetExcludedSubnets())
+		if err != nil {
+			log.Warnf("ignoring the ICE excluded subnets of the management service: %v", err)
+		} else {
+			ice.ExcludedSubnets = subnets
+		}
+	}
+	if conf.GetFailedTimeout() != nil {
+		ice.FailedTimeout = conf.GetFailedTimeout().AsDuration()
+	}
+	if conf.GetDisconnectedTimeout() != nil {
+		ice.DisconnectedTimeout = conf.GetDisconnectedTimeout().AsDuration()
+	}
+	return ice
+}
+
+// onManagementICEChange restarts the engine when the ICE settings pushed by the management service change the
+// applied ones, as the sockets gathering the host candidates are created with the excluded subnets
+func (e *Engine) onManagementICEChange(ice peer.ICEConfig) {
+	log.Debugf("management ICE settings changed from %+v to %+v", e.config.ManagementICE, ice)
+	e.config.ManagementICE = ice
+
+	if reflect.DeepEqual(e.targetICE(), e.ice) {
+		return
+	}
+
+	log.Infof("ICE settings changed, restarting engine")
+	e.restart()
+}
+
+// localICEConfig returns the tuning of the ICE negotiation configured on the peer
+func localICEConfig(config *Config) (peer.ICEConfig, error) {
+	ice := peer.ICEConfig{
+		FailedTimeout:       config.ICEFailedTimeout,
+		DisconnectedTimeout: config.ICEDisconnectedTimeout,
+	}
+
+	var err error
+	if len(config.ICEDisabledCandidates) > 0 {
+		ice.DisabledCandidateTypes, err = peer.ParseCandidateTypes(config.ICEDisabledCandidates)
+		if err != nil {
+			return ice, err
+		}
+	}
+	if len(config.ICEExcludedSubnets) > 0 {
+		ice.ExcludedSubnets, err = peer.ParseSubnets(config.ICEExcludedSubnets)
+		if err != nil {
+			return ice, err
+		}
+	}
+	return ice, nil
+}
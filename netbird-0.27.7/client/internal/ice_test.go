@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/pion/ice/v3"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+)
+
+func TestToICEConfig(t *testing.T) {
+	assert.Equal(t, peer.ICEConfig{}, toICEConfig(nil))
+
+	conf := toICEConfig(&mgmProto.ICEConfig{
+		DisabledCandidateTypes: []string{"relay"},
+		ExcludedSubnets:        []string{"invalid"},
+		FailedTimeout:          durationpb.New(10 * time.Second),
+	})
+	assert.Equal(t, peer.ICEConfig{
+		DisabledCandidateTypes: []ice.CandidateType{ice.CandidateTypeRelay},
+		FailedTimeout:          10 * time.Second,
+	}, conf, "invalid settings should be ignored")
+}
+
+func TestEngine_targetICE(t *testing.T) {
+	engine := &Engine{config: &EngineConfig{
+		ICE: peer.ICEConfig{
+			ExcludedSubnets: []netip.Prefix{netip.MustParsePrefix("172.17.0.0/16")},
+			FailedTimeout:   10 * time.Second,
+		},
+		ManagementICE: peer.ICEConfig{
+			DisabledCandidateTypes: []ice.CandidateType{ice.CandidateTypeRelay},
+			ExcludedSubnets:        []netip.Prefix{netip.MustParsePrefix("192.168.3.0/24")},
+			FailedTimeout:          20 * time.Second,
+			DisconnectedTimeout:    5 * time.Second,
+		},
+	}}
+
+	assert.Equal(t, peer.ICEConfig{
+		DisabledCandidateTypes: []ice.CandidateType{ice.CandidateTypeRelay},
+		ExcludedSubnets:        []netip.Prefix{netip.MustParsePrefix("172.17.0.0/16")},
+		FailedTimeout:          10 * time.Second,
+		DisconnectedTimeout:    5 * time.Second,
+	}, engine.targetICE(), "local settings should override the ones of the management")
+}
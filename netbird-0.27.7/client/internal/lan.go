@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+)
+
+// ProposeLANRoutes detects the private IPv4 networks of the local interfaces and proposes them as routes
+// to the Management Service. The routes are enabled once an admin approves them.
+// With dryRun the networks are detected but not proposed. It returns the detected and the newly proposed networks.
+func (e *Engine) ProposeLANRoutes(dryRun bool) ([]netip.Prefix, []netip.Prefix, error) {
+	e.syncMsgMux.Lock()
+	if e.wgInterface == nil {
+		e.syncMsgMux.Unlock()
+		return nil, nil, fmt.Errorf("interface not initialized")
+	}
+	wgNetwork, err := netip.ParsePrefix(e.wgInterface.Address().Network.String())
+	wgIfaceName := e.wgInterface.Name()
+	e.syncMsgMux.Unlock()
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse interface network: %w", err)
+	}
+
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, nil, fmt.Errorf("list interfaces: %w", err)
+	}
+
+	var addrs []netip.Prefix
+	for _, intf := range interfaces {
+		if intf.Name == wgIfaceName || intf.Flags&net.FlagUp == 0 || intf.Flags&net.FlagLoopback != 0 ||
+			intf.Flags&net.FlagPointToPoint != 0 {
+			continue
+		}
+
+		intfAddrs, err := intf.Addrs()
+		if err != nil {
+			log.Debugf("failed to get addresses of interface %s: %v", intf.Name, err)
+			continue
+		}
+		for _, addr := range intfAddrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			prefix, err := netip.ParsePrefix(ipNet.String())
+			if err != nil {
+				continue
+			}
+			addrs = append(addrs, prefix)
+		}
+	}
+
+	detected := lanPrefixes(addrs, wgNetwork)
+	if dryRun || len(detected) == 0 {
+		return detected, nil, nil
+	}
+
+	req := &mgmProto.ProposeRoutesRequest{}
+	for _, prefix := range detected {
+		req.Networks = append(req.Networks, prefix.String())
+	}
+
+	resp, err := e.mgmClient.ProposeRoutes(req)
+	if err != nil {
+		return detected, nil, fmt.Errorf("propose routes: %w", err)
+	}
+
+	var proposed []netip.Prefix
+	for _, network := range resp.GetNetworks() {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			log.Warnf("failed to parse proposed network %s: %v", network, err)
+			continue
+		}
+		proposed = append(proposed, prefix)
+	}
+
+	return detected, proposed, nil
+}
+
+// lanPrefixes returns the private IPv4 networks of the interface addresses, excluding the NetBird network
+func lanPrefixes(addrs []netip.Prefix, wgNetwork netip.Prefix) []netip.Prefix {
+	seen := make(map[netip.Prefix]struct{})
+	var prefixes []netip.Prefix
+	for _, addr := range addrs {
+		if !addr.Addr().Is4() || !addr.Addr().IsPrivate() || addr.Bits() == 32 {
+			continue
+		}
+
+		prefix := addr.Masked()
+		if prefix.Overlaps(wgNetwork) {
+			continue
+		}
+
+		if _, ok := seen[prefix]; ok {
+			continue
+		}
+		seen[prefix] = struct{}{}
+		prefixes = append(prefixes, prefix)
+	}
+
+	sort.Slice(prefixes, func(i, j int) bool {
+		return prefixes[i].String() < prefixes[j].String()
+	})
+
+	return prefixes
+}
@@ -0,0 +1,28 @@
+package internal
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLANPrefixes(t *testing.T) {
+	addrs := []netip.Prefix{
+		netip.MustParsePrefix("192.168.1.1/24"),
+		netip.MustParsePrefix("192.168.1.2/24"),
+		netip.MustParsePrefix("10.10.0.1/16"),
+		netip.MustParsePrefix("10.20.0.1/16"),
+		netip.MustParsePrefix("100.64.0.1/16"),
+		netip.MustParsePrefix("203.0.113.5/24"),
+		netip.MustParsePrefix("172.16.0.1/32"),
+		netip.MustParsePrefix("fd00::1/64"),
+	}
+
+	prefixes := lanPrefixes(addrs, netip.MustParsePrefix("10.10.0.0/16"))
+
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.20.0.0/16"),
+		netip.MustParsePrefix("192.168.1.0/24"),
+	}, prefixes)
+}
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+)
+
+// toLoginExpiration converts the login expiration pushed by the management service, the expiration is zero when the
+// login of the peer never expires
+func toLoginExpiration(conf *mgmProto.LoginExpirationConfig) (time.Time, []time.Duration) {
+	if conf.GetExpiresAt() == nil {
+		return time.Time{}, nil
+	}
+
+	warnings := make([]time.Duration, 0, len(conf.GetWarnings()))
+	for _, warning := range conf.GetWarnings() {
+		warnings = append(warnings, warning.AsDuration())
+	}
+	return conf.GetExpiresAt().AsTime(), warnings
+}
+
+// loginExpirationWarner warns the user at the lead times of the account before the login of the peer expires. The
+// warnings are logged and recorded in the status, the UI notifies the user about them
+type loginExpirationWarner struct {
+	mu             sync.Mutex
+	statusRecorder *peer.Status
+	expiresAt      time.Time
+	warnings       []time.Duration
+	timers         []*time.Timer
+}
+
+func newLoginExpirationWarner(statusRecorder *peer.Status) *loginExpirationWarner {
+	return &loginExpirationWarner{statusRecorder: statusRecorder}
+}
+
+// update schedules the warnings of the login expiration, the warnings whose lead time is already reached are replaced
+// by the latest of them right away
+func (w *loginExpirationWarner) update(expiresAt time.Time, warnings []time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.expiresAt.Equal(expiresAt) && slices.Equal(w.warnings, warnings) {
+		return
+	}
+
+	w.stopTimers()
+	w.expiresAt = expiresAt
+	w.warnings = warnings
+	w.statusRecorder.UpdateLoginExpiration(expiresAt)
+	if expiresAt.IsZero() {
+		return
+	}
+
+	var reached time.Duration
+	for _, lead := range warnings {
+		until := time.Until(expiresAt.Add(-lead))
+		if until <= 0 {
+			if reached == 0 || lead < reached {
+				reached = lead
+			}
+			continue
+		}
+
+		lead := lead
+		w.timers = append(w.timers, time.AfterFunc(until, func() {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			// the expiration changed since the timer was set
+			if !w.expiresAt.Equal(expiresAt) {
+				return
+			}
+			w.warn(lead)
+		}))
+	}
+
+	w.statusRecorder.UpdateLoginExpirationWarning(reached)
+	if reached != 0 {
+		w.warn(reached)
+	}
+}
+
+func (w *loginExpirationWarner) warn(lead time.Duration) {
+	log.Warnf("the login of this peer expires in %s at %s, log in again to stay connected",
+		time.Until(w.expiresAt).Round(time.Second), w.expiresAt.Local().Format(time.RFC1123))
+	w.statusRecorder.UpdateLoginExpirationWarning(lead)
+}
+
+// stop cancels the warnings not reached yet
+func (w *loginExpirationWarner) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopTimers()
+}
+
+func (w *loginExpirationWarner) stopTimers() {
+	for _, timer := range w.timers {
+		timer.Stop()
+	}
+	w.timers = nil
+}
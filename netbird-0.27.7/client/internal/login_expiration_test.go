@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+)
+
+func TestToLoginExpiration(t *testing.T) {
+	expiresAt, warnings := toLoginExpiration(nil)
+	assert.True(t, expiresAt.IsZero(), "a peer without login expiration should never expire")
+	assert.Nil(t, warnings)
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt, warnings = toLoginExpiration(&mgmProto.LoginExpirationConfig{
+		ExpiresAt: timestamppb.New(at),
+		Warnings:  []*durationpb.Duration{durationpb.New(24 * time.Hour), durationpb.New(time.Hour)},
+	})
+	assert.True(t, at.Equal(expiresAt))
+	assert.Equal(t, []time.Duration{24 * time.Hour, time.Hour}, warnings)
+}
+
+func TestLoginExpirationWarner(t *testing.T) {
+	recorder := peer.NewRecorder("https://mgm")
+	warner := newLoginExpirationWarner(recorder)
+	defer warner.stop()
+
+	expiresAt := time.Now().Add(time.Hour + 200*time.Millisecond)
+	warner.update(expiresAt, []time.Duration{24 * time.Hour, 2 * time.Hour, time.Hour})
+
+	state := recorder.GetLoginExpiration()
+	assert.True(t, expiresAt.Equal(state.ExpiresAt))
+	assert.Equal(t, 2*time.Hour, state.Warning, "the latest warning already reached should be recorded right away")
+
+	require.Eventually(t, func() bool {
+		return recorder.GetLoginExpiration().Warning == time.Hour
+	}, 2*time.Second, 20*time.Millisecond, "the next warning should be recorded when its lead time is reached")
+
+	renewed := time.Now().Add(48 * time.Hour)
+	warner.update(renewed, []time.Duration{24 * time.Hour, 2 * time.Hour, time.Hour})
+	state = recorder.GetLoginExpiration()
+	assert.True(t, renewed.Equal(state.ExpiresAt))
+	assert.Zero(t, state.Warning, "the warning should be cleared when the peer logs in again")
+
+	warner.update(time.Time{}, nil)
+	assert.Equal(t, peer.LoginExpirationState{}, recorder.GetLoginExpiration())
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"os"
+	"runtime/debug"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// The low memory profile keeps the client within the memory of routers with 64 to 128MB of RAM. It trades
+// throughput and connection setup time for memory: the garbage collector runs more often and the heap is kept
+// close to LowMemoryLimit, the DNS cache is capped to dns.LowMemoryCacheSize responses, the SSH server, the
+// environment detection and the route cache are disabled and at most LowMemoryMaxICEAgents connections gather and
+// check ICE candidates at the same time
+const (
+	// LowMemoryLimit is the soft memory limit of the Go runtime in the low memory profile
+	LowMemoryLimit = 48 << 20
+	// lowMemoryGCPercent collects the garbage once the heap grew by half since the previous collection
+	lowMemoryGCPercent = 50
+	// LowMemoryMaxICEAgents is the number of connections gathering and checking ICE candidates at the same time in
+	// the low memory profile
+	LowMemoryMaxICEAgents = 4
+)
+
+// applyLowMemoryLimits lowers the GC targets of the Go runtime and returns the function restoring the previous
+// ones. The targets set with the GOGC and GOMEMLIMIT environment variables are kept
+func applyLowMemoryLimits() func() {
+	var restore []func()
+	if _, ok := os.LookupEnv("GOGC"); !ok {
+		gcPercent := debug.SetGCPercent(lowMemoryGCPercent)
+		restore = append(restore, func() { debug.SetGCPercent(gcPercent) })
+	}
+	if _, ok := os.LookupEnv("GOMEMLIMIT"); !ok {
+		memoryLimit := debug.SetMemoryLimit(LowMemoryLimit)
+		restore = append(restore, func() { debug.SetMemoryLimit(memoryLimit) })
+	}
+	log.Infof("running in the low memory profile with a memory limit of %d MiB", debug.SetMemoryLimit(-1)>>20)
+
+	return func() {
+		for _, r := range restore {
+			r()
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"os"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+)
+
+func TestApplyLowMemoryLimits(t *testing.T) {
+	for _, env := range []string{"GOGC", "GOMEMLIMIT"} {
+		if _, ok := os.LookupEnv(env); ok {
+			t.Skipf("%s is set, the profile keeps it", env)
+		}
+	}
+
+	gcPercent := debug.SetGCPercent(100)
+	defer debug.SetGCPercent(gcPercent)
+	memoryLimit := debug.SetMemoryLimit(1 << 30)
+	defer debug.SetMemoryLimit(memoryLimit)
+
+	restore := applyLowMemoryLimits()
+	require.LessOrEqual(t, debug.SetMemoryLimit(-1), int64(LowMemoryLimit), "the heap should be kept within the ceiling")
+	require.Equal(t, lowMemoryGCPercent, debug.SetGCPercent(lowMemoryGCPercent))
+
+	restore()
+	require.Equal(t, int64(1<<30), debug.SetMemoryLimit(-1))
+	require.Equal(t, 100, debug.SetGCPercent(100))
+}
+
+func TestCreateEngineConfigLowMemory(t *testing.T) {
+	key, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	config := &Config{LowMemory: true}
+	engineConfig, err := createEngineConfig(key, config, &mgmProto.PeerConfig{Address: "100.64.0.1/16"})
+	require.NoError(t, err)
+	require.True(t, engineConfig.LowMemory)
+	require.False(t, engineConfig.ServerSSHAllowed, "the SSH server should be disabled in the low memory profile")
+}
@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/iface"
+)
+
+const (
+	// wgOverhead is the overhead WireGuard adds to the packets over an IPv6 uplink, the worst case:
+	// 40 bytes IPv6 header, 8 bytes UDP header and 32 bytes WireGuard header
+	wgOverhead = 80
+
+	pmtuProbeInterval = 5 * time.Minute
+)
+
+// targetMTU returns the MTU the interface should have. A locally configured MTU takes precedence over the MTU
+// pushed by the management service, and both are lowered if the probed path MTU is too small to carry them.
+func (e *Engine) targetMTU() int {
+	mtu := iface.DefaultMTU
+	switch {
+	case e.config.MTU != 0:
+		mtu = e.config.MTU
+	case e.config.ManagementMTU != 0:
+		mtu = e.config.ManagementMTU
+	}
+
+	if e.pathMTU != 0 && e.pathMTU-wgOverhead < mtu {
+		mtu = max(e.pathMTU-wgOverhead, iface.MinMTU)
+	}
+
+	return mtu
+}
+
+// applyMTU updates the MTU of the interface if it differs from the target
+func (e *Engine) applyMTU() {
+	mtu := e.targetMTU()
+	if mtu == e.mtu {
+		return
+	}
+
+	if err := e.wgInterface.UpdateMTU(mtu); err != nil {
+		log.Warnf("failed to update the MTU of interface %s to %d: %v", e.wgInterface.Name(), mtu, err)
+		return
+	}
+
+	log.Infof("updated the MTU of interface %s from %d to %d", e.wgInterface.Name(), e.mtu, mtu)
+	e.mtu = mtu
+}
+
+// runPMTUProbe periodically probes the path MTU to the management service and lowers the MTU of the interface
+// if the uplink can't carry full sized WireGuard packets, e.g. on PPPoE or LTE uplinks
+func (e *Engine) runPMTUProbe() {
+	ticker := time.NewTicker(pmtuProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		e.probePathMTU()
+
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Engine) probePathMTU() {
+	pathMTU, localAddr, err := probePathMTU(e.config.PMTUProbeAddress)
+	if err != nil {
+		log.Debugf("failed to probe the path MTU to %s: %v", e.config.PMTUProbeAddress, err)
+		return
+	}
+
+	e.syncMsgMux.Lock()
+	defer e.syncMsgMux.Unlock()
+
+	if e.ctx.Err() != nil {
+		return
+	}
+
+	// the probe went through the tunnel, its MTU says nothing about the uplink
+	if e.wgInterface.Address().Network.Contains(localAddr) {
+		log.Debugf("path MTU probe to %s is routed through the tunnel, ignoring it", e.config.PMTUProbeAddress)
+		return
+	}
+
+	if pathMTU != e.pathMTU {
+		log.Debugf("path MTU to %s is %d", e.config.PMTUProbeAddress, pathMTU)
+		e.pathMTU = pathMTU
+	}
+	e.applyMTU()
+}
+
+// interfaceMTU returns the MTU of the interface holding the local address
+func interfaceMTU(localAddr net.IP) (int, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, intf := range ifaces {
+		addrs, err := intf.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(localAddr) {
+				return intf.MTU, nil
+			}
+		}
+	}
+
+	return 0, &net.AddrError{Err: "no interface with address", Addr: localAddr.String()}
+}
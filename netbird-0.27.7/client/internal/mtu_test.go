@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/iface"
+)
+
+func TestEngine_TargetMTU(t *testing.T) {
+	testCases := []struct {
+		name          string
+		localMTU      int
+		managementMTU int
+		pathMTU       int
+		expected      int
+	}{
+		{
+			name:     "default",
+			expected: iface.DefaultMTU,
+		},
+		{
+			name:          "management MTU",
+			managementMTU: 1380,
+			expected:      1380,
+		},
+		{
+			name:          "local MTU overrides management MTU",
+			localMTU:      1400,
+			managementMTU: 1380,
+			expected:      1400,
+		},
+		{
+			name:     "large path MTU keeps the configured MTU",
+			localMTU: 1400,
+			pathMTU:  1500,
+			expected: 1400,
+		},
+		{
+			name:     "PPPoE path MTU lowers the MTU",
+			localMTU: 1420,
+			pathMTU:  1492,
+			expected: 1412,
+		},
+		{
+			name:     "path MTU never lowers the MTU below the minimum",
+			pathMTU:  600,
+			expected: iface.MinMTU,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			engine := &Engine{
+				config: &EngineConfig{
+					MTU:           testCase.localMTU,
+					ManagementMTU: testCase.managementMTU,
+				},
+				pathMTU: testCase.pathMTU,
+			}
+			assert.Equal(t, testCase.expected, engine.targetMTU())
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"slices"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/client/internal/relay"
+)
+
+// natDetectionDelay is the time the NAT type detection waits for the STUN servers of the Management Service
+const natDetectionDelay = 10 * time.Second
+
+// natDetectionInterval is how often the NAT type is detected again, e.g. after the ISP changed the NAT
+const natDetectionInterval = 30 * time.Minute
+
+// detectNATType periodically detects the type of the NAT in front of the peer with the STUN servers and records it,
+// it is reported to the Management Service with the peer status
+func (e *Engine) detectNATType() {
+	go func() {
+		timer := time.NewTimer(natDetectionDelay)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-e.ctx.Done():
+				return
+			case <-timer.C:
+			}
+
+			e.syncMsgMux.Lock()
+			stuns := slices.Clone(e.STUNs)
+			e.syncMsgMux.Unlock()
+
+			if len(stuns) == 0 {
+				timer.Reset(natDetectionDelay)
+				continue
+			}
+
+			natType := relay.DetectNATType(e.ctx, stuns)
+			if e.ctx.Err() != nil {
+				return
+			}
+			if natType != e.statusRecorder.GetNATType() {
+				log.Infof("detected NAT type: %s", natType)
+			}
+			e.statusRecorder.UpdateNATType(natType)
+			timer.Reset(natDetectionInterval)
+		}
+	}()
+}
@@ -15,6 +15,7 @@ import (
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 
 	"github.com/netbirdio/netbird/client/internal/stdnet"
+	"github.com/netbirdio/netbird/client/internal/tcptransport"
 	"github.com/netbirdio/netbird/client/internal/wgproxy"
 	"github.com/netbirdio/netbird/iface"
 	"github.com/netbirdio/netbird/iface/bind"
@@ -27,10 +28,14 @@ import (
 const (
 	iceKeepAliveDefault           = 4 * time.Second
 	iceDisconnectedTimeoutDefault = 6 * time.Second
+	iceFailedTimeoutDefault       = 6 * time.Second
 	// iceRelayAcceptanceMinWaitDefault is the same as in the Pion ICE package
 	iceRelayAcceptanceMinWaitDefault = 2 * time.Second
 
 	defaultWgKeepAlive = 25 * time.Second
+	// warmWgKeepAlive keeps the NAT mappings of the keep warm connections open even behind NATs with short UDP
+	// timeouts
+	warmWgKeepAlive = 10 * time.Second
 )
 
 type WgConfig struct {
@@ -75,6 +80,27 @@ type ConnConfig struct {
 	RosenpassPubKey []byte
 	// RosenpassPubKey is this peer's RosenpassAddr server address (IP:port)
 	RosenpassAddr string
+
+	// TCPTransport carries the WireGuard traffic over TCP when UDP is blocked, nil if the fallback is disabled
+	TCPTransport *tcptransport.Transport
+
+	// Uplink restricts the candidate gathering to a single uplink, nil if any uplink can be used
+	Uplink *stdnet.Uplink
+
+	// ICESlots is shared by the connections to cap the ICE agents gathering and checking candidates at the same time
+	// to its capacity, nil if unlimited
+	ICESlots chan struct{}
+
+	// RequireDirect excludes the relay candidates, a policy requiring the connection to the peer to never go through
+	// a relay
+	RequireDirect bool
+
+	// KeepWarm keeps the connection established and ready, the peers share a keep warm group. It doesn't wait for a
+	// free ICE slot and sends the WireGuard keepalives more often
+	KeepWarm bool
+
+	// ICE tunes the candidates gathered and the timeouts of the ICE agent
+	ICE ICEConfig
 }
 
 // OfferAnswer represents a session establishment offer or answer
@@ -93,6 +119,9 @@ type OfferAnswer struct {
 	// RosenpassAddr is the Rosenpass server address (IP:port) of the remote peer when receiving this message
 	// This value is the local Rosenpass server address when sending the message
 	RosenpassAddr string
+	// TCPFallback holds the TCP fallback capabilities of the remote peer when receiving this message, nil if
+	// the peer doesn't support it. This value is the local capabilities when sending the message
+	TCPFallback *TCPFallback
 }
 
 // IceCredentials ICE protocol credentials struct
@@ -143,6 +172,10 @@ type Conn struct {
 	remoteEndpoint *net.UDPAddr
 	remoteConn     *ice.Conn
 
+	// sentTCPFallback is the TCP fallback capabilities sent in the last offer or answer
+	sentTCPFallback *TCPFallback
+	tcpConn         *tcptransport.Conn
+
 	connID               nbnet.ConnectionID
 	beforeAddPeerHooks   []BeforeAddPeerHookFunc
 	afterRemovePeerHooks []AfterRemovePeerHookFunc
@@ -169,6 +202,32 @@ func (conn *Conn) WgConfig() WgConfig {
 	return conn.config.WgConfig
 }
 
+// UpdateAllowedIPs updates the allowed IPs of the remote peer. When the connection is established the allowed IPs of
+// the WireGuard peer are changed in place, so the existing WireGuard session and the routed networks are kept
+func (conn *Conn) UpdateAllowedIPs(allowedIPs string) error {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	oldAllowedIPs := conn.config.WgConfig.AllowedIps
+	conn.config.WgConfig.AllowedIps = allowedIPs
+
+	if conn.status == StatusConnected {
+		removed, added := diffAllowedIPs(oldAllowedIPs, allowedIPs)
+		for _, ip := range added {
+			if err := conn.config.WgConfig.WgInterface.AddAllowedIP(conn.config.WgConfig.RemoteKey, ip); err != nil {
+				return fmt.Errorf("add allowed IP %s: %w", ip, err)
+			}
+		}
+		for _, ip := range removed {
+			if err := conn.config.WgConfig.WgInterface.RemoveAllowedIP(conn.config.WgConfig.RemoteKey, ip); err != nil {
+				return fmt.Errorf("remove allowed IP %s: %w", ip, err)
+			}
+		}
+	}
+
+	return conn.statusRecorder.UpdatePeerIP(conn.config.Key, strings.Split(allowedIPs, "/")[0])
+}
+
 // UpdateStunTurn update the turn and stun addresses
 func (conn *Conn) UpdateStunTurn(turnStun []*stun.URI) {
 	conn.config.StunTurn = turnStun
@@ -196,7 +255,10 @@ func (conn *Conn) reCreateAgent() error {
 	conn.mu.Lock()
 	defer conn.mu.Unlock()
 
-	failedTimeout := 6 * time.Second
+	failedTimeout := iceFailedTimeoutDefault
+	if conn.config.ICE.FailedTimeout > 0 {
+		failedTimeout = conn.config.ICE.FailedTimeout
+	}
 
 	var err error
 	transportNet, err := conn.newStdNet()
@@ -204,8 +266,22 @@ func (conn *Conn) reCreateAgent() error {
 		log.Errorf("failed to create pion's stdnet: %s", err)
 	}
 
+	if conn.config.Uplink != nil {
+		if err := transportNet.SetUplink(conn.config.Uplink); err != nil {
+			log.Errorf("failed to pin pion's stdnet to uplink %s: %s", conn.config.Uplink, err)
+		}
+	}
+	if len(conn.config.ICE.ExcludedSubnets) > 0 {
+		if err := transportNet.SetExcludedSubnets(conn.config.ICE.ExcludedSubnets); err != nil {
+			log.Errorf("failed to exclude subnets from pion's stdnet: %s", err)
+		}
+	}
+
 	iceKeepAlive := iceKeepAlive()
 	iceDisconnectedTimeout := iceDisconnectedTimeout()
+	if conn.config.ICE.DisconnectedTimeout > 0 {
+		iceDisconnectedTimeout = conn.config.ICE.DisconnectedTimeout
+	}
 	iceRelayAcceptanceMinWait := iceRelayAcceptanceMinWait()
 
 	agentConfig := &ice.AgentConfig{
@@ -263,6 +339,21 @@ func (conn *Conn) reCreateAgent() error {
 }
 
 func (conn *Conn) candidateTypes() []ice.CandidateType {
+	types := conn.allowedCandidateTypes()
+	filtered := conn.config.ICE.filterCandidateTypes(types)
+	if len(filtered) == 0 {
+		// the agent would gather every type with an empty list
+		log.Warnf("all the allowed ICE candidate types of peer %s are disabled, ignoring the disabled types", conn.config.Key)
+		return types
+	}
+	return filtered
+}
+
+func (conn *Conn) allowedCandidateTypes() []ice.CandidateType {
+	// the policy of the management wins over the local setting forcing the relay
+	if conn.config.RequireDirect {
+		return []ice.CandidateType{ice.CandidateTypeHost, ice.CandidateTypeServerReflexive}
+	}
 	if hasICEForceRelayConn() {
 		return []ice.CandidateType{ice.CandidateTypeRelay}
 	}
@@ -351,6 +442,20 @@ func (conn *Conn) Open(ctx context.Context) error {
 		log.Warnf("error while updating the state of peer %s,err: %v", conn.config.Key, err)
 	}
 
+	isControlling := conn.config.LocalKey > conn.config.Key
+	if role := tcpFallbackRole(conn.sentTCPFallback, remoteOfferAnswer.TCPFallback, isControlling); role != tcpRoleNone {
+		return conn.openTCP(role, remoteOfferAnswer)
+	}
+
+	releaseICESlot := func() {}
+	if !conn.config.KeepWarm {
+		releaseICESlot, err = conn.acquireICESlot()
+		if err != nil {
+			return err
+		}
+	}
+	defer releaseICESlot()
+
 	err = conn.agent.GatherCandidates()
 	if err != nil {
 		return err
@@ -359,7 +464,6 @@ func (conn *Conn) Open(ctx context.Context) error {
 	// will block until connection succeeded
 	// but it won't release if ICE Agent went into Disconnected or Failed state,
 	// so we have to cancel it with the provided context once agent detected a broken connection
-	isControlling := conn.config.LocalKey > conn.config.Key
 	var remoteConn *ice.Conn
 	if isControlling {
 		remoteConn, err = conn.agent.Dial(conn.ctx, remoteOfferAnswer.IceCredentials.UFrag, remoteOfferAnswer.IceCredentials.Pwd)
@@ -369,6 +473,8 @@ func (conn *Conn) Open(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	// the slot only caps the agents looking for a connection
+	releaseICESlot()
 
 	// dynamically set remote WireGuard port is other side specified a different one from the default one
 	remoteWgPort := iface.DefaultWgPort
@@ -398,6 +504,38 @@ func (conn *Conn) Open(ctx context.Context) error {
 	}
 }
 
+// acquireICESlot waits for a free slot of the ICE slots of the config, it returns the function releasing the slot
+func (conn *Conn) acquireICESlot() (func(), error) {
+	if conn.config.ICESlots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case conn.config.ICESlots <- struct{}{}:
+	case <-time.After(conn.config.Timeout):
+		return nil, NewConnectionTimeoutError(conn.config.Key, conn.config.Timeout)
+	case <-conn.closeCh:
+		return nil, NewConnectionClosedError(conn.config.Key)
+	case <-conn.ctx.Done():
+		return nil, NewConnectionDisconnectedError(conn.config.Key)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-conn.config.ICESlots
+		})
+	}, nil
+}
+
+// wgKeepAlive returns the WireGuard persistent keepalive interval of the connection
+func (conn *Conn) wgKeepAlive() time.Duration {
+	if conn.config.KeepWarm {
+		return warmWgKeepAlive
+	}
+	return defaultWgKeepAlive
+}
+
 func isRelayCandidate(candidate ice.Candidate) bool {
 	return candidate.Type() == ice.CandidateTypeRelay
 }
@@ -445,7 +583,7 @@ func (conn *Conn) configureConnection(remoteConn net.Conn, remoteWgPort int, rem
 		}
 	}
 
-	err = conn.config.WgConfig.WgInterface.UpdatePeer(conn.config.WgConfig.RemoteKey, conn.config.WgConfig.AllowedIps, defaultWgKeepAlive, endpointUdpAddr, conn.config.WgConfig.PreSharedKey)
+	err = conn.config.WgConfig.WgInterface.UpdatePeer(conn.config.WgConfig.RemoteKey, conn.config.WgConfig.AllowedIps, conn.wgKeepAlive(), endpointUdpAddr, conn.config.WgConfig.PreSharedKey)
 	if err != nil {
 		if conn.wgProxy != nil {
 			if err := conn.wgProxy.CloseConn(); err != nil {
@@ -469,6 +607,7 @@ func (conn *Conn) configureConnection(remoteConn net.Conn, remoteWgPort int, rem
 		RemoteIceCandidateType:     pair.Remote.Type().String(),
 		LocalIceCandidateEndpoint:  fmt.Sprintf("%s:%d", pair.Local.Address(), pair.Local.Port()),
 		RemoteIceCandidateEndpoint: fmt.Sprintf("%s:%d", pair.Remote.Address(), pair.Remote.Port()),
+		IceCandidateNetwork:        pair.Local.NetworkType().String(),
 		Direct:                     !isRelayCandidate(pair.Local),
 		RosenpassEnabled:           rosenpassEnabled,
 		Mux:                        new(sync.RWMutex),
@@ -535,6 +674,11 @@ func (conn *Conn) cleanup() error {
 		conn.wgProxy = nil
 	}
 
+	if conn.tcpConn != nil {
+		_ = conn.tcpConn.Close()
+		conn.tcpConn = nil
+	}
+
 	// todo: is it problem if we try to remove a peer what is never existed?
 	err3 = conn.config.WgConfig.WgInterface.RemovePeer(conn.config.WgConfig.RemoteKey)
 
@@ -676,12 +820,14 @@ func (conn *Conn) sendAnswer() error {
 	}
 
 	log.Debugf("sending answer to %s", conn.config.Key)
+	conn.sentTCPFallback = conn.localTCPFallback()
 	err = conn.signalAnswer(OfferAnswer{
 		IceCredentials:  IceCredentials{localUFrag, localPwd},
 		WgListenPort:    conn.config.LocalWgPort,
 		Version:         version.NetbirdVersion(),
 		RosenpassPubKey: conn.config.RosenpassPubKey,
 		RosenpassAddr:   conn.config.RosenpassAddr,
+		TCPFallback:     conn.sentTCPFallback,
 	})
 	if err != nil {
 		return err
@@ -699,12 +845,14 @@ func (conn *Conn) sendOffer() error {
 	if err != nil {
 		return err
 	}
+	conn.sentTCPFallback = conn.localTCPFallback()
 	err = conn.signalOffer(OfferAnswer{
 		IceCredentials:  IceCredentials{localUFrag, localPwd},
 		WgListenPort:    conn.config.LocalWgPort,
 		Version:         version.NetbirdVersion(),
 		RosenpassPubKey: conn.config.RosenpassPubKey,
 		RosenpassAddr:   conn.config.RosenpassAddr,
+		TCPFallback:     conn.sentTCPFallback,
 	})
 	if err != nil {
 		return err
@@ -802,3 +950,32 @@ func (conn *Conn) RegisterProtoSupportMeta(support []uint32) {
 	protoSupport := signal.ParseFeaturesSupported(support)
 	conn.meta.protoSupport = protoSupport
 }
+
+// diffAllowedIPs returns the IPs of the comma separated old list missing in the new one and the IPs new to the list
+func diffAllowedIPs(oldAllowedIPs, newAllowedIPs string) (removed, added []string) {
+	oldIPs := make(map[string]struct{})
+	for _, ip := range strings.Split(oldAllowedIPs, ",") {
+		if ip != "" {
+			oldIPs[ip] = struct{}{}
+		}
+	}
+
+	newIPs := make(map[string]struct{})
+	for _, ip := range strings.Split(newAllowedIPs, ",") {
+		if ip == "" {
+			continue
+		}
+		newIPs[ip] = struct{}{}
+		if _, ok := oldIPs[ip]; !ok {
+			added = append(added, ip)
+		}
+	}
+
+	for _, ip := range strings.Split(oldAllowedIPs, ",") {
+		if _, ok := newIPs[ip]; !ok && ip != "" {
+			removed = append(removed, ip)
+		}
+	}
+
+	return removed, added
+}
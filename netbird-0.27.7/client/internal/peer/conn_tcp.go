@@ -0,0 +1,179 @@
+package peer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/client/internal/tcptransport"
+	nbnet "github.com/netbirdio/netbird/util/net"
+)
+
+const tcpCandidateType = "tcp"
+
+// TCPFallback holds the TCP fallback capabilities of a peer exchanged in offers and answers
+type TCPFallback struct {
+	// Addresses where the peer accepts TCP connections
+	Addresses []string
+	// UDPBlocked indicates that the peer can't reach anything over UDP
+	UDPBlocked bool
+}
+
+type tcpRole int
+
+const (
+	tcpRoleNone tcpRole = iota
+	tcpRoleDial
+	tcpRoleAccept
+)
+
+// tcpFallbackRole decides whether the connection is carried over TCP and which side dials.
+// Both peers come to the same decision as each of them knows the capabilities it sent and the ones it received.
+func tcpFallbackRole(local, remote *TCPFallback, isControlling bool) tcpRole {
+	if local == nil || remote == nil {
+		return tcpRoleNone
+	}
+	if !local.UDPBlocked && !remote.UDPBlocked {
+		return tcpRoleNone
+	}
+
+	canDial := len(remote.Addresses) > 0
+	canAccept := len(local.Addresses) > 0
+	switch {
+	case canDial && canAccept:
+		if isControlling {
+			return tcpRoleDial
+		}
+		return tcpRoleAccept
+	case canDial:
+		return tcpRoleDial
+	case canAccept:
+		return tcpRoleAccept
+	default:
+		return tcpRoleNone
+	}
+}
+
+func (conn *Conn) localTCPFallback() *TCPFallback {
+	transport := conn.config.TCPTransport
+	if transport == nil {
+		return nil
+	}
+	return &TCPFallback{
+		Addresses:  transport.Addresses(),
+		UDPBlocked: transport.UDPBlocked(),
+	}
+}
+
+// openTCP establishes the connection over the TCP transport instead of ICE.
+// Blocks until the connection has been closed or broken.
+func (conn *Conn) openTCP(role tcpRole, remoteOfferAnswer OfferAnswer) error {
+	tcpConn, err := conn.connectTCP(role, remoteOfferAnswer)
+	if err != nil {
+		return fmt.Errorf("tcp fallback: %w", err)
+	}
+
+	endpoint, err := conn.configureTCPConnection(tcpConn, remoteOfferAnswer.RosenpassPubKey, remoteOfferAnswer.RosenpassAddr)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("connected to peer %s over tcp %s, endpoint address: %s", conn.config.Key, tcpConn.RemoteAddr(), endpoint)
+
+	select {
+	case <-conn.closeCh:
+		// closed externally
+		return NewConnectionClosedError(conn.config.Key)
+	case <-conn.ctx.Done():
+		return NewConnectionDisconnectedError(conn.config.Key)
+	case <-tcpConn.Done():
+		return NewConnectionDisconnectedError(conn.config.Key)
+	}
+}
+
+func (conn *Conn) connectTCP(role tcpRole, remoteOfferAnswer OfferAnswer) (*tcptransport.Conn, error) {
+	ctx, cancel := context.WithTimeout(conn.ctx, conn.config.Timeout)
+	defer cancel()
+
+	transport := conn.config.TCPTransport
+	if role == tcpRoleDial {
+		log.Debugf("dialing peer %s over tcp", conn.config.Key)
+		return transport.Dial(ctx, remoteOfferAnswer.TCPFallback.Addresses, conn.config.LocalKey, remoteOfferAnswer.IceCredentials.UFrag)
+	}
+
+	conn.mu.Lock()
+	localUFrag, _, err := conn.agent.GetLocalUserCredentials()
+	conn.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debugf("waiting for peer %s to connect over tcp", conn.config.Key)
+	return transport.Accept(ctx, conn.config.Key, localUFrag)
+}
+
+// configureTCPConnection starts proxying traffic from/to local Wireguard over the TCP connection and sets
+// connection status to StatusConnected
+func (conn *Conn) configureTCPConnection(tcpConn *tcptransport.Conn, remoteRosenpassPubKey []byte, remoteRosenpassAddr string) (net.Addr, error) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	conn.tcpConn = tcpConn
+	conn.wgProxy = conn.wgProxyFactory.GetProxy(conn.ctx)
+	endpoint, err := conn.wgProxy.AddTurnConn(tcpConn)
+	if err != nil {
+		return nil, err
+	}
+
+	endpointUdpAddr, _ := net.ResolveUDPAddr(endpoint.Network(), endpoint.String())
+	conn.remoteEndpoint = endpointUdpAddr
+
+	conn.connID = nbnet.GenerateConnID()
+	if remoteAddr, ok := tcpConn.RemoteAddr().(*net.TCPAddr); ok {
+		for _, hook := range conn.beforeAddPeerHooks {
+			if err := hook(conn.connID, remoteAddr.IP); err != nil {
+				log.Errorf("Before add peer hook failed: %v", err)
+			}
+		}
+	}
+
+	err = conn.config.WgConfig.WgInterface.UpdatePeer(conn.config.WgConfig.RemoteKey, conn.config.WgConfig.AllowedIps, conn.wgKeepAlive(), endpointUdpAddr, conn.config.WgConfig.PreSharedKey)
+	if err != nil {
+		return nil, fmt.Errorf("update peer: %w", err)
+	}
+
+	conn.status = StatusConnected
+
+	peerState := State{
+		PubKey:                     conn.config.Key,
+		ConnStatus:                 conn.status,
+		ConnStatusUpdate:           time.Now(),
+		LocalIceCandidateType:      tcpCandidateType,
+		RemoteIceCandidateType:     tcpCandidateType,
+		LocalIceCandidateEndpoint:  tcpConn.LocalAddr().String(),
+		RemoteIceCandidateEndpoint: tcpConn.RemoteAddr().String(),
+		IceCandidateNetwork:        tcpConn.RemoteAddr().Network(),
+		Direct:                     true,
+		RosenpassEnabled:           remoteRosenpassPubKey != nil,
+		Mux:                        new(sync.RWMutex),
+	}
+	err = conn.statusRecorder.UpdatePeerState(peerState)
+	if err != nil {
+		log.Warnf("unable to save peer's state, got error: %v", err)
+	}
+
+	_, ipNet, err := net.ParseCIDR(conn.config.WgConfig.AllowedIps)
+	if err != nil {
+		return nil, err
+	}
+
+	if conn.onConnected != nil {
+		conn.onConnected(conn.config.Key, remoteRosenpassPubKey, ipNet.IP.String(), remoteRosenpassAddr)
+	}
+
+	return endpoint, nil
+}
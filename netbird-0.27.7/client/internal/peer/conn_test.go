@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/magiconair/properties/assert"
+	"github.com/pion/ice/v3"
 	"github.com/pion/stun/v2"
 
 	"github.com/netbirdio/netbird/client/internal/stdnet"
@@ -50,6 +51,33 @@ func TestConn_GetKey(t *testing.T) {
 	assert.Equal(t, got, connConf.Key, "they should be equal")
 }
 
+func TestConn_candidateTypes_RequireDirect(t *testing.T) {
+	t.Setenv(envICEForceRelayConn, "true")
+
+	conf := connConf
+	conf.RequireDirect = true
+	conn := &Conn{config: conf}
+
+	for _, candidateType := range conn.candidateTypes() {
+		assert.Equal(t, candidateType != ice.CandidateTypeRelay, true, "relay candidates should be excluded")
+	}
+}
+
+func TestConn_candidateTypes_Disabled(t *testing.T) {
+	conf := connConf
+	conf.ICE.DisabledCandidateTypes = []ice.CandidateType{ice.CandidateTypeRelay}
+	conn := &Conn{config: conf}
+	for _, candidateType := range conn.candidateTypes() {
+		assert.Equal(t, candidateType != ice.CandidateTypeRelay, true, "disabled candidates should be excluded")
+	}
+
+	// disabling all the allowed types would let the agent gather all of them
+	conf.RequireDirect = true
+	conf.ICE.DisabledCandidateTypes = []ice.CandidateType{ice.CandidateTypeHost, ice.CandidateTypeServerReflexive}
+	conn = &Conn{config: conf}
+	assert.Equal(t, []ice.CandidateType{ice.CandidateTypeHost, ice.CandidateTypeServerReflexive}, conn.candidateTypes())
+}
+
 func TestConn_OnRemoteOffer(t *testing.T) {
 	wgProxyFactory := wgproxy.NewFactory(context.Background(), connConf.LocalWgPort)
 	defer func() {
@@ -183,3 +211,121 @@ func TestConn_Close(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestConn_UpdateAllowedIPs(t *testing.T) {
+	wgProxyFactory := wgproxy.NewFactory(context.Background(), connConf.LocalWgPort)
+	statusRecorder := NewRecorder("https://mgm")
+	conn, err := NewConn(connConf, statusRecorder, wgProxyFactory, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = statusRecorder.AddPeer(connConf.Key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = conn.UpdateAllowedIPs("100.64.0.20/32")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, conn.WgConfig().AllowedIps, "100.64.0.20/32")
+
+	state, err := statusRecorder.GetPeer(connConf.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, state.IP, "100.64.0.20")
+}
+
+func TestDiffAllowedIPs(t *testing.T) {
+	removed, added := diffAllowedIPs("100.64.0.10/32,10.0.0.0/24", "100.64.0.20/32,10.0.0.0/24")
+	assert.Equal(t, removed, []string{"100.64.0.10/32"})
+	assert.Equal(t, added, []string{"100.64.0.20/32"})
+
+	removed, added = diffAllowedIPs("100.64.0.10/32", "100.64.0.10/32")
+	assert.Equal(t, len(removed), 0)
+	assert.Equal(t, len(added), 0)
+}
+
+func TestTCPFallbackRole(t *testing.T) {
+	listening := &TCPFallback{Addresses: []string{"192.0.2.1:443"}}
+	blockedListening := &TCPFallback{Addresses: []string{"192.0.2.2:443"}, UDPBlocked: true}
+	blocked := &TCPFallback{UDPBlocked: true}
+
+	testCases := []struct {
+		name          string
+		local         *TCPFallback
+		remote        *TCPFallback
+		isControlling bool
+		expected      tcpRole
+	}{
+		{name: "remote without support", local: blocked, remote: nil, expected: tcpRoleNone},
+		{name: "udp not blocked", local: listening, remote: listening, expected: tcpRoleNone},
+		{name: "local blocked dials", local: blocked, remote: listening, expected: tcpRoleDial},
+		{name: "remote blocked accepts", local: listening, remote: blocked, expected: tcpRoleAccept},
+		{name: "both listening controlling dials", local: blockedListening, remote: listening, isControlling: true, expected: tcpRoleDial},
+		{name: "both listening controlled accepts", local: blockedListening, remote: listening, expected: tcpRoleAccept},
+		{name: "nobody listening", local: blocked, remote: blocked, expected: tcpRoleNone},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tcpFallbackRole(tc.local, tc.remote, tc.isControlling), tc.expected)
+		})
+	}
+}
+
+func TestConn_ICESlots(t *testing.T) {
+	slots := make(chan struct{}, 1)
+	config := connConf
+	config.ICESlots = slots
+	config.Timeout = 200 * time.Millisecond
+
+	newConn := func() *Conn {
+		conn, err := NewConn(config, nil, nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.ctx = context.Background()
+		return conn
+	}
+
+	first, second := newConn(), newConn()
+	release, err := first.acquireICESlot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = second.acquireICESlot()
+	if _, ok := err.(*ConnectionTimeoutError); !ok {
+		t.Fatalf("expected a timeout while the slots are taken, got %v", err)
+	}
+
+	release()
+	release()
+	assert.Equal(t, len(slots), 0, "releasing twice should free the slot once")
+
+	releaseSecond, err := second.acquireICESlot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	releaseSecond()
+}
+
+func TestConn_wgKeepAlive(t *testing.T) {
+	config := connConf
+	conn, err := NewConn(config, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, conn.wgKeepAlive(), defaultWgKeepAlive)
+
+	config.KeepWarm = true
+	warmConn, err := NewConn(config, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, warmConn.wgKeepAlive(), warmWgKeepAlive)
+}
@@ -0,0 +1,82 @@
+package peer
+
+import (
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/pion/ice/v3"
+)
+
+// candidateTypeNames are the names of the candidate types that can be disabled
+var candidateTypeNames = map[string]ice.CandidateType{
+	"host":  ice.CandidateTypeHost,
+	"srflx": ice.CandidateTypeServerReflexive,
+	"relay": ice.CandidateTypeRelay,
+}
+
+// ICEConfig tunes the ICE negotiation of the connections, its zero value keeps the defaults
+type ICEConfig struct {
+	// DisabledCandidateTypes are the candidate types never gathered
+	DisabledCandidateTypes []ice.CandidateType
+	// ExcludedSubnets are the local subnets no host candidate is gathered from, e.g. Docker bridges or guest VLANs
+	ExcludedSubnets []netip.Prefix
+	// FailedTimeout is the time the candidates are checked for before the connection attempt fails, zero keeps
+	// the default
+	FailedTimeout time.Duration
+	// DisconnectedTimeout is the time without traffic after which the connection is considered disconnected, zero
+	// keeps the default
+	DisconnectedTimeout time.Duration
+}
+
+// ParseCandidateTypes parses the names of candidate types, host, srflx or relay. Disabling all of them is an error
+func ParseCandidateTypes(names []string) ([]ice.CandidateType, error) {
+	types := make([]ice.CandidateType, 0, len(names))
+	disabled := make(map[ice.CandidateType]struct{})
+	for _, name := range names {
+		candidateType, ok := candidateTypeNames[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid ICE candidate type %q, must be host, srflx or relay", name)
+		}
+		if _, ok := disabled[candidateType]; ok {
+			continue
+		}
+		disabled[candidateType] = struct{}{}
+		types = append(types, candidateType)
+	}
+	if len(disabled) == len(candidateTypeNames) {
+		return nil, fmt.Errorf("at least one ICE candidate type must stay enabled")
+	}
+	return types, nil
+}
+
+// ParseSubnets parses the subnets in CIDR notation, e.g. 172.17.0.0/16
+func ParseSubnets(subnets []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(subnets))
+	for _, subnet := range subnets {
+		prefix, err := netip.ParsePrefix(subnet)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subnet %q: %w", subnet, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// filterCandidateTypes returns the candidate types without the disabled ones
+func (c ICEConfig) filterCandidateTypes(types []ice.CandidateType) []ice.CandidateType {
+	filtered := make([]ice.CandidateType, 0, len(types))
+	for _, candidateType := range types {
+		disabled := false
+		for _, disabledType := range c.DisabledCandidateTypes {
+			if candidateType == disabledType {
+				disabled = true
+				break
+			}
+		}
+		if !disabled {
+			filtered = append(filtered, candidateType)
+		}
+	}
+	return filtered
+}
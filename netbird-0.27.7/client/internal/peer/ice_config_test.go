@@ -0,0 +1,31 @@
+package peer
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/pion/ice/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCandidateTypes(t *testing.T) {
+	types, err := ParseCandidateTypes([]string{"relay", "srflx", "relay"})
+	require.NoError(t, err)
+	assert.Equal(t, []ice.CandidateType{ice.CandidateTypeRelay, ice.CandidateTypeServerReflexive}, types)
+
+	_, err = ParseCandidateTypes([]string{"prflx"})
+	assert.Error(t, err, "unknown candidate types should be rejected")
+
+	_, err = ParseCandidateTypes([]string{"host", "srflx", "relay"})
+	assert.Error(t, err, "disabling all the candidate types should be rejected")
+}
+
+func TestParseSubnets(t *testing.T) {
+	subnets, err := ParseSubnets([]string{"172.17.0.1/16", "fd00::/64"})
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("172.17.0.0/16"), netip.MustParsePrefix("fd00::/64")}, subnets)
+
+	_, err = ParseSubnets([]string{"172.17.0.1"})
+	assert.Error(t, err, "subnets without a prefix length should be rejected")
+}
@@ -2,6 +2,9 @@ package peer
 
 import (
 	"errors"
+	"net/netip"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
@@ -26,7 +29,9 @@ type State struct {
 	RemoteIceCandidateType     string
 	LocalIceCandidateEndpoint  string
 	RemoteIceCandidateEndpoint string
+	IceCandidateNetwork        string
 	LastWireguardHandshake     time.Time
+	WireguardEndpoint          string
 	BytesTx                    int64
 	BytesRx                    int64
 	Latency                    time.Duration
@@ -70,6 +75,8 @@ type LocalPeerState struct {
 	IP              string
 	PubKey          string
 	KernelInterface bool
+	// UserspaceReason holds why the userspace WireGuard implementation is used instead of the kernel one
+	UserspaceReason string
 	FQDN            string
 	Routes          map[string]struct{}
 }
@@ -104,6 +111,43 @@ type NSGroupState struct {
 	Error   error
 }
 
+// DNSCacheState contains the counters and the TTL clamps of the DNS response cache
+type DNSCacheState struct {
+	Entries   int
+	Capacity  int
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	MinTTL    time.Duration
+	MaxTTL    time.Duration
+}
+
+// RouteConflict describes a client route that isn't effective on the system
+// because it conflicts with the local routing configuration.
+type RouteConflict struct {
+	NetID         string
+	Network       netip.Prefix
+	ConflictsWith string
+	Reason        string
+}
+
+// LoginExpirationState describes when the login of the peer expires and the latest warning reached
+type LoginExpirationState struct {
+	// ExpiresAt is when the login of the peer expires, zero when it never expires
+	ExpiresAt time.Time
+	// Warning is the lead time of the latest warning reached, 0 when none is
+	Warning time.Duration
+}
+
+// FailedRoute describes a client route whose installation failed and is retried with backoff
+type FailedRoute struct {
+	NetID     string
+	Network   string
+	Error     string
+	Attempts  int
+	NextRetry time.Time
+}
+
 // FullStatus contains the full state held by the Status instance
 type FullStatus struct {
 	Peers           []State
@@ -113,6 +157,20 @@ type FullStatus struct {
 	RosenpassState  RosenpassState
 	Relays          []relay.ProbeResult
 	NSGroupStates   []NSGroupState
+	RouteConflicts  []RouteConflict
+	FailedRoutes    []FailedRoute
+	DNSCache        *DNSCacheState
+	NATType         relay.NATType
+	LoginExpiration LoginExpirationState
+}
+
+// maxSyncErrors is the number of the latest errors of the management connection kept for the debug bundle
+const maxSyncErrors = 20
+
+// SyncError is an error of the connection to the management service, e.g. of its sync stream
+type SyncError struct {
+	Time  time.Time
+	Error string
 }
 
 // Status holds a state of peers, signal, management connections and relays
@@ -124,6 +182,7 @@ type Status struct {
 	signalError         error
 	managementState     bool
 	managementError     error
+	syncErrors          []SyncError
 	relayStates         []relay.ProbeResult
 	localPeer           LocalPeerState
 	offlinePeers        []State
@@ -133,6 +192,13 @@ type Status struct {
 	rosenpassEnabled    bool
 	rosenpassPermissive bool
 	nsGroupStates       []NSGroupState
+	routeConflicts      map[netip.Prefix]RouteConflict
+	routeConflictNotify chan struct{}
+	failedRoutes        map[string]FailedRoute
+	dnsCacheStats       func() DNSCacheState
+	natType             relay.NATType
+	natTypeNotify       chan struct{}
+	loginExpiration     LoginExpirationState
 
 	// To reduce the number of notification invocation this bool will be true when need to call the notification
 	// Some Peer actions mostly used by in a batch when the network map has been synchronized. In these type of events
@@ -143,11 +209,14 @@ type Status struct {
 // NewRecorder returns a new Status instance
 func NewRecorder(mgmAddress string) *Status {
 	return &Status{
-		peers:        make(map[string]State),
-		changeNotify: make(map[string]chan struct{}),
-		offlinePeers: make([]State, 0),
-		notifier:     newNotifier(),
-		mgmAddress:   mgmAddress,
+		peers:               make(map[string]State),
+		changeNotify:        make(map[string]chan struct{}),
+		offlinePeers:        make([]State, 0),
+		notifier:            newNotifier(),
+		mgmAddress:          mgmAddress,
+		routeConflicts:      make(map[netip.Prefix]RouteConflict),
+		routeConflictNotify: make(chan struct{}),
+		natTypeNotify:       make(chan struct{}),
 	}
 }
 
@@ -237,6 +306,7 @@ func (d *Status) UpdatePeerState(receivedState State) error {
 		peerState.RemoteIceCandidateType = receivedState.RemoteIceCandidateType
 		peerState.LocalIceCandidateEndpoint = receivedState.LocalIceCandidateEndpoint
 		peerState.RemoteIceCandidateEndpoint = receivedState.RemoteIceCandidateEndpoint
+		peerState.IceCandidateNetwork = receivedState.IceCandidateNetwork
 		peerState.RosenpassEnabled = receivedState.RosenpassEnabled
 	}
 
@@ -269,6 +339,7 @@ func (d *Status) UpdateWireGuardPeerState(pubKey string, wgStats iface.WGStats)
 	peerState.LastWireguardHandshake = wgStats.LastHandshake
 	peerState.BytesRx = wgStats.RxBytes
 	peerState.BytesTx = wgStats.TxBytes
+	peerState.WireguardEndpoint = wgStats.Endpoint
 
 	d.peers[pubKey] = peerState
 
@@ -304,6 +375,23 @@ func (d *Status) UpdatePeerFQDN(peerPubKey, fqdn string) error {
 	return nil
 }
 
+// UpdatePeerIP update peer's state IP only
+func (d *Status) UpdatePeerIP(peerPubKey, ip string) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	peerState, ok := d.peers[peerPubKey]
+	if !ok {
+		return errors.New("peer doesn't exist")
+	}
+
+	peerState.IP = ip
+	d.peers[peerPubKey] = peerState
+	d.peerListChangedForNotification = true
+
+	return nil
+}
+
 // FinishPeerListModifications this event invoke the notification
 func (d *Status) FinishPeerListModifications() {
 	d.mux.Lock()
@@ -363,6 +451,21 @@ func (d *Status) MarkManagementDisconnected(err error) {
 
 	d.managementState = false
 	d.managementError = err
+
+	if err != nil {
+		d.syncErrors = append(d.syncErrors, SyncError{Time: time.Now(), Error: err.Error()})
+		if len(d.syncErrors) > maxSyncErrors {
+			d.syncErrors = d.syncErrors[len(d.syncErrors)-maxSyncErrors:]
+		}
+	}
+}
+
+// GetSyncErrors returns the latest errors of the management connection, the oldest first
+func (d *Status) GetSyncErrors() []SyncError {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	return slices.Clone(d.syncErrors)
 }
 
 // MarkManagementConnected sets ManagementState to connected
@@ -429,6 +532,164 @@ func (d *Status) UpdateDNSStates(dnsStates []NSGroupState) {
 	d.nsGroupStates = dnsStates
 }
 
+// SetDNSCacheStatsProvider sets the source of the DNS cache state reported in the full status, nil removes it
+func (d *Status) SetDNSCacheStatsProvider(provider func() DNSCacheState) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.dnsCacheStats = provider
+}
+
+// SetRouteConflict records a conflict for the route network, replacing any previous one
+func (d *Status) SetRouteConflict(conflict RouteConflict) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if current, ok := d.routeConflicts[conflict.Network]; ok && current == conflict {
+		return
+	}
+	if d.routeConflicts == nil {
+		d.routeConflicts = make(map[netip.Prefix]RouteConflict)
+	}
+	d.routeConflicts[conflict.Network] = conflict
+	d.notifyRouteConflictsChanged()
+}
+
+// RemoveRouteConflict removes the conflict recorded for the route network, if any
+func (d *Status) RemoveRouteConflict(network netip.Prefix) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if _, ok := d.routeConflicts[network]; !ok {
+		return
+	}
+	delete(d.routeConflicts, network)
+	d.notifyRouteConflictsChanged()
+}
+
+// SetFailedRoute records a failed installation of the route network, replacing any previous one
+func (d *Status) SetFailedRoute(failedRoute FailedRoute) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if d.failedRoutes == nil {
+		d.failedRoutes = make(map[string]FailedRoute)
+	}
+	d.failedRoutes[failedRoute.Network] = failedRoute
+}
+
+// RemoveFailedRoute removes the failed installation recorded for the route network, if any
+func (d *Status) RemoveFailedRoute(network string) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	delete(d.failedRoutes, network)
+}
+
+// GetFailedRoutes returns the routes whose installation failed ordered by network
+func (d *Status) GetFailedRoutes() []FailedRoute {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	return d.getFailedRoutes()
+}
+
+func (d *Status) getFailedRoutes() []FailedRoute {
+	failedRoutes := make([]FailedRoute, 0, len(d.failedRoutes))
+	for _, failedRoute := range d.failedRoutes {
+		failedRoutes = append(failedRoutes, failedRoute)
+	}
+	sort.Slice(failedRoutes, func(i, j int) bool {
+		return failedRoutes[i].Network < failedRoutes[j].Network
+	})
+	return failedRoutes
+}
+
+// GetRouteConflicts returns the recorded route conflicts ordered by network
+func (d *Status) GetRouteConflicts() []RouteConflict {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	return d.getRouteConflicts()
+}
+
+// GetRouteConflictsChangeNotifier returns a channel that is closed when the recorded route conflicts change
+func (d *Status) GetRouteConflictsChangeNotifier() <-chan struct{} {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	if d.routeConflictNotify == nil {
+		d.routeConflictNotify = make(chan struct{})
+	}
+	return d.routeConflictNotify
+}
+
+func (d *Status) getRouteConflicts() []RouteConflict {
+	conflicts := make([]RouteConflict, 0, len(d.routeConflicts))
+	for _, conflict := range d.routeConflicts {
+		conflicts = append(conflicts, conflict)
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Network.String() < conflicts[j].Network.String()
+	})
+	return conflicts
+}
+
+func (d *Status) notifyRouteConflictsChanged() {
+	if d.routeConflictNotify != nil {
+		close(d.routeConflictNotify)
+	}
+	d.routeConflictNotify = make(chan struct{})
+}
+
+// UpdateNATType records the detected type of the NAT in front of the peer
+func (d *Status) UpdateNATType(natType relay.NATType) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if d.natType == natType {
+		return
+	}
+	d.natType = natType
+	close(d.natTypeNotify)
+	d.natTypeNotify = make(chan struct{})
+}
+
+// GetNATType returns the detected type of the NAT in front of the peer, empty until it is detected
+func (d *Status) GetNATType() relay.NATType {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	return d.natType
+}
+
+// GetNATTypeChangeNotifier returns a channel that is closed when the detected NAT type changes
+func (d *Status) GetNATTypeChangeNotifier() <-chan struct{} {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	return d.natTypeNotify
+}
+
+// UpdateLoginExpiration records when the login of the peer expires, the warning is cleared when the expiration changes
+func (d *Status) UpdateLoginExpiration(expiresAt time.Time) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if d.loginExpiration.ExpiresAt.Equal(expiresAt) {
+		return
+	}
+	d.loginExpiration = LoginExpirationState{ExpiresAt: expiresAt}
+}
+
+// UpdateLoginExpirationWarning records the lead time of the latest login expiration warning reached
+func (d *Status) UpdateLoginExpirationWarning(warning time.Duration) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.loginExpiration.Warning = warning
+}
+
+// GetLoginExpiration returns when the login of the peer expires and the latest warning reached
+func (d *Status) GetLoginExpiration() LoginExpirationState {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	return d.loginExpiration
+}
+
 func (d *Status) GetRosenpassState() RosenpassState {
 	return RosenpassState{
 		d.rosenpassEnabled,
@@ -505,6 +766,15 @@ func (d *Status) GetFullStatus() FullStatus {
 		Relays:          d.GetRelayStates(),
 		RosenpassState:  d.GetRosenpassState(),
 		NSGroupStates:   d.GetDNSStates(),
+		RouteConflicts:  d.getRouteConflicts(),
+		FailedRoutes:    d.getFailedRoutes(),
+		NATType:         d.natType,
+		LoginExpiration: d.loginExpiration,
+	}
+
+	if d.dnsCacheStats != nil {
+		cacheState := d.dnsCacheStats()
+		fullStatus.DNSCache = &cacheState
 	}
 
 	for _, status := range d.peers {
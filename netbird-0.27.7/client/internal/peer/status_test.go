@@ -2,10 +2,14 @@ package peer
 
 import (
 	"errors"
+	"fmt"
+	"net/netip"
 	"testing"
 	"sync"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/client/internal/relay"
 )
 
 func TestAddPeer(t *testing.T) {
@@ -207,6 +211,22 @@ func TestUpdateManagementState(t *testing.T) {
 	}
 }
 
+func TestGetSyncErrors(t *testing.T) {
+	status := NewRecorder("https://management")
+	status.MarkManagementDisconnected(nil)
+	assert.Empty(t, status.GetSyncErrors(), "a disconnect without an error shouldn't be recorded")
+
+	for i := 0; i < maxSyncErrors+5; i++ {
+		status.MarkManagementDisconnected(fmt.Errorf("error %d", i))
+	}
+	status.MarkManagementConnected()
+
+	syncErrors := status.GetSyncErrors()
+	assert.Len(t, syncErrors, maxSyncErrors)
+	assert.Equal(t, "error 5", syncErrors[0].Error)
+	assert.Equal(t, fmt.Sprintf("error %d", maxSyncErrors+4), syncErrors[len(syncErrors)-1].Error)
+}
+
 func TestGetFullStatus(t *testing.T) {
 	key1 := "abc"
 	key2 := "def"
@@ -241,3 +261,72 @@ func TestGetFullStatus(t *testing.T) {
 	assert.Equal(t, signalState, fullStatus.SignalState, "signal status should be equal")
 	assert.ElementsMatch(t, []State{peerState1, peerState2}, fullStatus.Peers, "peers states should match")
 }
+
+func TestRouteConflicts(t *testing.T) {
+	status := NewRecorder("https://mgm")
+	notifier := status.GetRouteConflictsChangeNotifier()
+
+	conflict := RouteConflict{
+		NetID:         "net1",
+		Network:       netip.MustParsePrefix("192.168.1.0/24"),
+		ConflictsWith: "192.168.1.0/24",
+		Reason:        "exists",
+	}
+	status.SetRouteConflict(conflict)
+
+	select {
+	case <-notifier:
+	default:
+		t.Fatal("notifier should be closed after a conflict was added")
+	}
+	assert.Equal(t, []RouteConflict{conflict}, status.GetRouteConflicts())
+
+	notifier = status.GetRouteConflictsChangeNotifier()
+	status.SetRouteConflict(conflict)
+	select {
+	case <-notifier:
+		t.Fatal("notifier shouldn't be closed when the same conflict is set again")
+	default:
+	}
+
+	status.RemoveRouteConflict(conflict.Network)
+	select {
+	case <-notifier:
+	default:
+		t.Fatal("notifier should be closed after a conflict was removed")
+	}
+	assert.Empty(t, status.GetRouteConflicts())
+}
+
+func TestNATType(t *testing.T) {
+	status := NewRecorder("https://mgm")
+	notifier := status.GetNATTypeChangeNotifier()
+
+	status.UpdateNATType(relay.NATTypeSymmetric)
+	select {
+	case <-notifier:
+	default:
+		t.Fatal("notifier should be closed after the NAT type changed")
+	}
+	assert.Equal(t, relay.NATTypeSymmetric, status.GetFullStatus().NATType)
+
+	notifier = status.GetNATTypeChangeNotifier()
+	status.UpdateNATType(relay.NATTypeSymmetric)
+	select {
+	case <-notifier:
+		t.Fatal("notifier shouldn't be closed when the same NAT type is detected again")
+	default:
+	}
+}
+
+func TestFailedRoutes(t *testing.T) {
+	status := NewRecorder("https://mgm")
+
+	failedRoute := FailedRoute{NetID: "net", Network: "10.0.0.0/24", Error: "no such device", Attempts: 1}
+	status.SetFailedRoute(failedRoute)
+	assert.Equal(t, []FailedRoute{failedRoute}, status.GetFailedRoutes())
+	assert.Equal(t, []FailedRoute{failedRoute}, status.GetFullStatus().FailedRoutes)
+
+	status.RemoveFailedRoute(failedRoute.Network)
+	assert.Empty(t, status.GetFailedRoutes())
+}
@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+)
+
+// The remote actions the management service sends with the sync responses
+const (
+	peerActionRestartEngine      = "restart_engine"
+	peerActionResync             = "resync"
+	peerActionRotateKey          = "rotate_wireguard_key"
+	peerActionCollectDebugBundle = "collect_debug_bundle"
+	peerActionPing               = "ping_peer"
+)
+
+// PeerActionHandler runs the remote actions that need the daemon the engine runs in
+type PeerActionHandler interface {
+	// CollectDebugBundle uploads an anonymized debug bundle to the management service and returns its name
+	CollectDebugBundle() (string, error)
+	// SaveConfig persists the config after a remote action changed it
+	SaveConfig(config *Config) error
+}
+
+// runPeerAction runs a remote action requested by the management service and reports its result. A resync is run
+// right away for the network map of the same sync response to be applied in full, the other actions run in the
+// background. Must be called with syncMsgMux held.
+func (e *Engine) runPeerAction(action *mgmProto.PeerAction) {
+	log.Infof("running the remote action %s (%s) requested by the management service", action.GetType(), action.GetId())
+
+	switch action.GetType() {
+	case peerActionResync:
+		// the network map sent with the action is applied even when the serial didn't change
+		e.networkSerial = 0
+		go e.reportPeerAction(action, "", nil)
+	case peerActionRestartEngine:
+		go func() {
+			// reported first, the management stream is closed by the restart
+			e.reportPeerAction(action, "", nil)
+			if err := e.Stop(); err != nil {
+				log.Errorf("failed to stop the engine: %v", err)
+			}
+			if err := e.Start(); err != nil {
+				log.Errorf("failed to start the engine: %v", err)
+			}
+		}()
+	case peerActionRotateKey:
+		if e.savePrivateKey == nil {
+			go e.reportPeerAction(action, "", fmt.Errorf("not supported by this client"))
+			return
+		}
+		go e.rotatePrivateKey(action)
+	case peerActionCollectDebugBundle:
+		if e.actionHandler == nil {
+			go e.reportPeerAction(action, "", fmt.Errorf("not supported by this client"))
+			return
+		}
+		go func() {
+			name, err := e.actionHandler.CollectDebugBundle()
+			e.reportPeerAction(action, name, err)
+		}()
+	case peerActionPing:
+		go e.runPingAction(action)
+	default:
+		go e.reportPeerAction(action, "", fmt.Errorf("unknown action"))
+	}
+}
+
+// rotatePrivateKey replaces the WireGuard key of the peer. The management service records the rotation once it
+// accepted the new public key, only a failure is reported. The client then reconnects with the new key.
+func (e *Engine) rotatePrivateKey(action *mgmProto.PeerAction) {
+	key, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		e.reportPeerAction(action, "", fmt.Errorf("generate key: %w", err))
+		return
+	}
+
+	req := &mgmProto.RotatePeerKeyRequest{ActionId: action.GetId(), WgPubKey: key.PublicKey().String()}
+	if err := e.mgmClient.RotatePeerKey(req); err != nil {
+		e.reportPeerAction(action, "", fmt.Errorf("send key: %w", err))
+		return
+	}
+
+	// the management service only knows the new key now, it is used on the reconnect even if it can't be persisted
+	if err := e.savePrivateKey(key); err != nil {
+		log.Errorf("failed to save the rotated WireGuard key, the peer has to log in again after a restart: %v", err)
+	}
+
+	log.Infof("rotated the WireGuard key to %s, reconnecting", key.PublicKey())
+	_ = CtxGetState(e.ctx).Wrap(ErrResetConnection)
+	e.clientCancel()
+}
+
+func (e *Engine) reportPeerAction(action *mgmProto.PeerAction, debugBundleName string, err error) {
+	e.sendPeerActionResult(action, &mgmProto.PeerActionResult{DebugBundleName: debugBundleName}, err)
+}
+
+// sendPeerActionResult reports the result of the action to the management service with the error it failed with
+func (e *Engine) sendPeerActionResult(action *mgmProto.PeerAction, result *mgmProto.PeerActionResult, err error) {
+	result.Id = action.GetId()
+	result.Type = action.GetType()
+	if err != nil {
+		log.Warnf("remote action %s (%s) failed: %v", action.GetType(), action.GetId(), err)
+		result.Error = err.Error()
+	}
+	if err := e.mgmClient.ReportPeerAction(result); err != nil {
+		log.Warnf("failed to report the result of the remote action %s to Management Service: %v", action.GetId(), err)
+	}
+}
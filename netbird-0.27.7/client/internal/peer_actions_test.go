@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+	mgmt "github.com/netbirdio/netbird/management/client"
+	mgmtProto "github.com/netbirdio/netbird/management/proto"
+	signal "github.com/netbirdio/netbird/signal/client"
+)
+
+type mockPeerActionHandler struct {
+	bundleName string
+}
+
+func (h *mockPeerActionHandler) CollectDebugBundle() (string, error) {
+	return h.bundleName, nil
+}
+
+func (h *mockPeerActionHandler) SaveConfig(*Config) error {
+	return nil
+}
+
+func TestEngine_runPeerAction(t *testing.T) {
+	key, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(CtxInitState(context.Background()))
+	defer cancel()
+
+	results := make(chan *mgmtProto.PeerActionResult, 1)
+	var rotation *mgmtProto.RotatePeerKeyRequest
+	mgmClient := &mgmt.MockClient{
+		ReportPeerActionFunc: func(result *mgmtProto.PeerActionResult) error {
+			results <- result
+			return nil
+		},
+		RotatePeerKeyFunc: func(req *mgmtProto.RotatePeerKeyRequest) error {
+			rotation = req
+			return nil
+		},
+	}
+	engine := NewEngine(ctx, cancel, &signal.MockClient{}, mgmClient, &EngineConfig{
+		WgIfaceName:  "utun109",
+		WgAddr:       "100.64.0.1/24",
+		WgPrivateKey: key,
+		WgPort:       33100,
+	}, MobileDependency{}, peer.NewRecorder("https://mgm"))
+	// set by Start
+	engine.ctx = ctx
+
+	nextResult := func() *mgmtProto.PeerActionResult {
+		t.Helper()
+		select {
+		case result := <-results:
+			return result
+		case <-time.After(time.Second):
+			t.Fatal("no result of the action was reported")
+			return nil
+		}
+	}
+
+	engine.networkSerial = 10
+	engine.runPeerAction(&mgmtProto.PeerAction{Id: "1", Type: peerActionResync})
+	assert.Equal(t, uint64(0), engine.networkSerial, "a resync should apply the next network map in full")
+	assert.Equal(t, &mgmtProto.PeerActionResult{Id: "1", Type: peerActionResync}, nextResult())
+
+	engine.runPeerAction(&mgmtProto.PeerAction{Id: "2", Type: "reboot"})
+	assert.NotEmpty(t, nextResult().GetError(), "an unknown action should fail")
+
+	engine.runPeerAction(&mgmtProto.PeerAction{Id: "3", Type: peerActionCollectDebugBundle})
+	assert.NotEmpty(t, nextResult().GetError(), "an action needing the daemon should fail without a handler")
+
+	engine.actionHandler = &mockPeerActionHandler{bundleName: "bundle.zip"}
+	engine.runPeerAction(&mgmtProto.PeerAction{Id: "4", Type: peerActionCollectDebugBundle})
+	assert.Equal(t, "bundle.zip", nextResult().GetDebugBundleName())
+
+	engine.runPeerAction(&mgmtProto.PeerAction{Id: "5", Type: peerActionPing, Target: "100.64.0.9"})
+	result := nextResult()
+	assert.Equal(t, peerActionPing, result.GetType())
+	assert.NotEmpty(t, result.GetError(), "a ping of a peer no policy allows reaching should fail")
+
+	saved := make(chan wgtypes.Key, 1)
+	engine.savePrivateKey = func(key wgtypes.Key) error {
+		saved <- key
+		return nil
+	}
+	engine.runPeerAction(&mgmtProto.PeerAction{Id: "6", Type: peerActionRotateKey})
+	select {
+	case newKey := <-saved:
+		require.NotNil(t, rotation)
+		assert.Equal(t, "6", rotation.GetActionId())
+		assert.Equal(t, newKey.PublicKey().String(), rotation.GetWgPubKey())
+	case <-time.After(time.Second):
+		t.Fatal("the rotated key was not saved")
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the client should reconnect with the rotated key")
+	}
+	_, err = CtxGetState(ctx).Status()
+	assert.True(t, errors.Is(err, ErrResetConnection))
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/netbirdio/netbird/client/internal/speedtest"
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+)
+
+// SpeedtestResult is a throughput test of a peer with the path the peer is reached on
+type SpeedtestResult struct {
+	speedtest.Result
+	IP   string
+	FQDN string
+	Path string
+	// ReportError is the reason the result couldn't be reported to the management service
+	ReportError string
+}
+
+// RunSpeedtest measures the throughput to the peer over the WireGuard tunnel. The target is resolved like the one of
+// PingPeer and has to run its speed test server, a policy enabling the speed tests lets this peer run them against
+// it. The result is reported to the management service as an activity event when report is set.
+func (e *Engine) RunSpeedtest(ctx context.Context, target string, opts speedtest.Options, report bool) (*SpeedtestResult, error) {
+	resolved, err := e.resolvePeerTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := speedtest.Run(ctx, net.JoinHostPort(resolved.ip, strconv.Itoa(speedtest.Port)), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &SpeedtestResult{Result: *result, IP: resolved.ip, FQDN: resolved.fqdn}
+	resp.Path, _, _ = e.peerPath(resolved.pubKey)
+	if !report {
+		return resp, nil
+	}
+
+	err = e.mgmClient.ReportSpeedtest(&mgmProto.SpeedtestReport{
+		WgPubKey:    resolved.pubKey,
+		Protocol:    result.Protocol,
+		Direction:   result.Direction,
+		Bytes:       result.Bytes,
+		Duration:    durationpb.New(result.Duration),
+		PacketsSent: result.PacketsSent,
+		PacketsLost: result.PacketsLost,
+	})
+	if err != nil {
+		log.Warnf("failed to report the speed test of %s to Management Service: %v", resolved.ip, err)
+		resp.ReportError = err.Error()
+	}
+	return resp, nil
+}
+
+// updateSpeedtestServer runs the speed test server while a policy lets remote peers test against this peer and
+// allows only these peers. Must be called with syncMsgMux held.
+func (e *Engine) updateSpeedtestServer(remotePeers []*mgmProto.RemotePeerConfig) {
+	var allowed []net.IP
+	for _, config := range remotePeers {
+		if !config.GetSpeedtestAllowed() || len(config.GetAllowedIps()) == 0 {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(config.GetAllowedIps()[0])
+		if err != nil {
+			log.Warnf("failed to parse the IP of peer %s: %v", config.GetWgPubKey(), err)
+			continue
+		}
+		allowed = append(allowed, prefix.Addr().AsSlice())
+	}
+
+	if len(allowed) == 0 {
+		e.stopSpeedtestServer()
+		return
+	}
+
+	if e.speedtestServer == nil {
+		address := fmt.Sprintf("%s:%d", e.wgInterface.Address().IP.String(), speedtest.Port)
+		server, err := speedtest.Listen(address)
+		if err != nil {
+			log.Warnf("failed to start the speed test server on %s: %v", address, err)
+			return
+		}
+		log.Infof("started the speed test server on %s", address)
+		e.speedtestServer = server
+	}
+	e.speedtestServer.SetAllowed(allowed)
+}
+
+func (e *Engine) stopSpeedtestServer() {
+	if e.speedtestServer == nil {
+		return
+	}
+	if err := e.speedtestServer.Close(); err != nil {
+		log.Warnf("failed to stop the speed test server: %v", err)
+	}
+	e.speedtestServer = nil
+}
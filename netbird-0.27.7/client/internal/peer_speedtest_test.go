@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+	"github.com/netbirdio/netbird/client/internal/speedtest"
+	mgmt "github.com/netbirdio/netbird/management/client"
+	mgmtProto "github.com/netbirdio/netbird/management/proto"
+)
+
+func TestEngine_RunSpeedtest(t *testing.T) {
+	server, err := speedtest.Listen(fmt.Sprintf("127.0.0.1:%d", speedtest.Port))
+	require.NoError(t, err)
+	defer server.Close()
+	server.SetAllowed([]net.IP{net.ParseIP("127.0.0.1")})
+
+	recorder := peer.NewRecorder("https://mgm")
+	require.NoError(t, recorder.AddPeer("router-key", "office-router.netbird.cloud"))
+	require.NoError(t, recorder.UpdatePeerState(peer.State{
+		PubKey: "router-key", IP: "127.0.0.1", ConnStatus: peer.StatusConnected, Mux: new(sync.RWMutex),
+	}))
+
+	var reported *mgmtProto.SpeedtestReport
+	engine := &Engine{
+		statusRecorder: recorder,
+		mgmClient: &mgmt.MockClient{
+			ReportSpeedtestFunc: func(report *mgmtProto.SpeedtestReport) error {
+				reported = report
+				return nil
+			},
+		},
+	}
+
+	opts := speedtest.Options{Duration: 200 * time.Millisecond}
+	result, err := engine.RunSpeedtest(context.Background(), "office-router", opts, false)
+	require.NoError(t, err)
+	assert.Equal(t, "office-router.netbird.cloud", result.FQDN)
+	assert.Equal(t, PingPathP2P, result.Path)
+	assert.Greater(t, result.Bytes, int64(0))
+	assert.Nil(t, reported, "the result shouldn't be reported unless asked")
+
+	result, err = engine.RunSpeedtest(context.Background(), "office-router", opts, true)
+	require.NoError(t, err)
+	require.NotNil(t, reported)
+	assert.Equal(t, "router-key", reported.GetWgPubKey())
+	assert.Equal(t, speedtest.ProtocolTCP, reported.GetProtocol())
+	assert.Equal(t, result.Bytes, reported.GetBytes())
+	assert.Empty(t, result.ReportError)
+}
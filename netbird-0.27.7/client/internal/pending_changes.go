@@ -0,0 +1,433 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+)
+
+const (
+	// NetworkChangeRoute and NetworkChangeDNS are the kinds of the pending network changes
+	NetworkChangeRoute = "route"
+	NetworkChangeDNS   = "dns"
+
+	networkChangeAdded   = "added"
+	networkChangeRemoved = "removed"
+	networkChangeUpdated = "updated"
+)
+
+var (
+	// ErrNoPendingNetworkChanges is returned when there are no staged network changes to apply or discard
+	ErrNoPendingNetworkChanges = errors.New("no pending network changes")
+	// ErrPendingNetworkChangesReplaced is returned when the staged network changes were replaced by newer ones since
+	// they were listed
+	ErrPendingNetworkChangesReplaced = errors.New("the pending network changes were replaced by newer ones")
+)
+
+// PendingNetworkChanges are the route and DNS changes of the network map staged for a local approval
+type PendingNetworkChanges struct {
+	// Serial is the serial of the network map the changes belong to
+	Serial   uint64
+	StagedAt time.Time
+	// AutoApplyAt is when the changes are applied without an approval, zero when they wait for one
+	AutoApplyAt time.Time
+	Changes     []NetworkChange
+}
+
+// NetworkChange is a route or a DNS setting added, removed or updated by the pending changes
+type NetworkChange struct {
+	// Kind is NetworkChangeRoute or NetworkChangeDNS
+	Kind string
+	// Action is added, removed or updated
+	Action      string
+	Description string
+}
+
+// networkChanges are the routes and the DNS configuration of a network map
+type networkChanges struct {
+	serial uint64
+	routes []*mgmProto.Route
+	dns    *mgmProto.DNSConfig
+	// fingerprint identifies the routes and the DNS configuration regardless of their order
+	fingerprint []byte
+}
+
+// pendingNetworkChanges are the network changes staged until they are approved or auto applied
+type pendingNetworkChanges struct {
+	*networkChanges
+	stagedAt    time.Time
+	autoApplyAt time.Time
+	timer       *time.Timer
+}
+
+// toNetworkChanges returns the routes and the DNS configuration of the network map the peer applies
+func (e *Engine) toNetworkChanges(networkMap *mgmProto.NetworkMap) *networkChanges {
+	changes := &networkChanges{
+		serial: networkMap.GetSerial(),
+		routes: networkMap.GetRoutes(),
+		dns:    networkMap.GetDNSConfig(),
+	}
+	if changes.routes == nil {
+		changes.routes = []*mgmProto.Route{}
+	}
+	target := e.targetControlPlane()
+	switch {
+	case !target.routes:
+		changes.routes = []*mgmProto.Route{}
+	case e.config.DisableClientRoutes:
+		changes.routes = serverRoutes(changes.routes, e.config.WgPrivateKey.PublicKey().String())
+	}
+	if changes.dns == nil || !target.dns {
+		changes.dns = &mgmProto.DNSConfig{}
+	}
+	changes.fingerprint = networkFingerprint(changes.routes, changes.dns)
+	return changes
+}
+
+// updateRoutesAndDNS applies the routes and the DNS configuration of a network map. With StageNetworkChanges the ones
+// changing the applied routes or DNS configuration are staged until they are approved instead. The first network map
+// after the client connects is applied right away, the peer has no routes to keep before it.
+func (e *Engine) updateRoutesAndDNS(changes *networkChanges) {
+	if !e.config.StageNetworkChanges || e.appliedNetwork == nil {
+		e.applyRoutesAndDNS(changes)
+		return
+	}
+
+	if e.reinstallNetwork {
+		// the route manager and the DNS server were recreated by a restart of the engine
+		applied := *e.appliedNetwork
+		applied.serial = changes.serial
+		e.applyRoutesAndDNS(&applied)
+	}
+
+	switch {
+	case bytes.Equal(changes.fingerprint, e.appliedNetwork.fingerprint):
+		e.dropPendingNetworkChanges()
+		e.discardedNetwork = nil
+		e.applyRoutesAndDNS(changes)
+	case bytes.Equal(changes.fingerprint, e.discardedNetwork):
+		log.Debugf("ignoring the discarded route and DNS changes of network map %d", changes.serial)
+	case e.pendingNetwork != nil && bytes.Equal(changes.fingerprint, e.pendingNetwork.fingerprint):
+		e.pendingNetwork.networkChanges = changes
+	default:
+		e.stageNetworkChanges(changes)
+	}
+}
+
+// applyRoutesAndDNS installs the routes and the DNS configuration
+func (e *Engine) applyRoutesAndDNS(changes *networkChanges) {
+	_, clientRoutes, err := e.routeManager.UpdateRoutes(changes.serial, toRoutes(changes.routes))
+	if err != nil {
+		log.Errorf("failed to update clientRoutes, err: %v", err)
+	}
+
+	e.clientRoutes = clientRoutes
+
+	err = e.dnsServer.UpdateDNSServer(changes.serial, toDNSConfig(changes.dns))
+	if err != nil {
+		log.Errorf("failed to update dns server, err: %v", err)
+	}
+
+	e.appliedNetwork = changes
+	e.reinstallNetwork = false
+}
+
+// stageNetworkChanges replaces the pending changes, a pending auto apply starts over for the new ones to be reviewed
+func (e *Engine) stageNetworkChanges(changes *networkChanges) {
+	e.dropPendingNetworkChanges()
+
+	pending := &pendingNetworkChanges{networkChanges: changes, stagedAt: time.Now()}
+	if delay := e.config.StagedChangesAutoApply; delay > 0 {
+		pending.autoApplyAt = pending.stagedAt.Add(delay)
+		pending.timer = time.AfterFunc(delay, func() {
+			e.autoApplyNetworkChanges(pending)
+		})
+		log.Infof("staged the route and DNS changes of network map %d, they are applied in %s unless discarded with netbird pending discard",
+			changes.serial, delay)
+	} else {
+		log.Infof("staged the route and DNS changes of network map %d, review them with netbird pending and apply them with netbird pending apply",
+			changes.serial)
+	}
+	e.pendingNetwork = pending
+}
+
+func (e *Engine) autoApplyNetworkChanges(pending *pendingNetworkChanges) {
+	e.syncMsgMux.Lock()
+	defer e.syncMsgMux.Unlock()
+
+	if e.pendingNetwork != pending {
+		return
+	}
+
+	log.Infof("applying the staged route and DNS changes of network map %d without an approval", pending.serial)
+	e.pendingNetwork = nil
+	e.applyRoutesAndDNS(pending.networkChanges)
+}
+
+// dropPendingNetworkChanges forgets the pending changes and stops their auto apply
+func (e *Engine) dropPendingNetworkChanges() {
+	if e.pendingNetwork == nil {
+		return
+	}
+	if e.pendingNetwork.timer != nil {
+		e.pendingNetwork.timer.Stop()
+	}
+	e.pendingNetwork = nil
+}
+
+// PendingNetworkChanges returns the route and DNS changes staged for an approval, nil when there are none
+func (e *Engine) PendingNetworkChanges() (*PendingNetworkChanges, error) {
+	e.syncMsgMux.Lock()
+	defer e.syncMsgMux.Unlock()
+
+	if !e.config.StageNetworkChanges {
+		return nil, fmt.Errorf("staging of the network changes is disabled, enable it with --stage-network-changes")
+	}
+	if e.pendingNetwork == nil {
+		return nil, nil
+	}
+
+	return &PendingNetworkChanges{
+		Serial:      e.pendingNetwork.serial,
+		StagedAt:    e.pendingNetwork.stagedAt,
+		AutoApplyAt: e.pendingNetwork.autoApplyAt,
+		Changes:     diffNetworkChanges(e.appliedNetwork, e.pendingNetwork.networkChanges),
+	}, nil
+}
+
+// ApplyPendingNetworkChanges applies the staged changes. The serial is the one of the listed changes, so the changes
+// staged after they were listed aren't applied without being reviewed. Zero applies the latest changes.
+func (e *Engine) ApplyPendingNetworkChanges(serial uint64) error {
+	e.syncMsgMux.Lock()
+	defer e.syncMsgMux.Unlock()
+
+	pending, err := e.takePendingNetworkChanges(serial)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("applying the approved route and DNS changes of network map %d", pending.serial)
+	e.applyRoutesAndDNS(pending)
+	return nil
+}
+
+// DiscardPendingNetworkChanges drops the staged changes and keeps the applied routes and DNS configuration. The
+// changes aren't staged again until the management service sends different ones.
+func (e *Engine) DiscardPendingNetworkChanges(serial uint64) error {
+	e.syncMsgMux.Lock()
+	defer e.syncMsgMux.Unlock()
+
+	pending, err := e.takePendingNetworkChanges(serial)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("discarded the route and DNS changes of network map %d", pending.serial)
+	e.discardedNetwork = pending.fingerprint
+	return nil
+}
+
+func (e *Engine) takePendingNetworkChanges(serial uint64) (*networkChanges, error) {
+	if e.pendingNetwork == nil {
+		return nil, ErrNoPendingNetworkChanges
+	}
+	if serial != 0 && serial != e.pendingNetwork.serial {
+		return nil, fmt.Errorf("%w, list them again", ErrPendingNetworkChangesReplaced)
+	}
+
+	pending := e.pendingNetwork.networkChanges
+	e.dropPendingNetworkChanges()
+	return pending, nil
+}
+
+// networkFingerprint encodes the routes and the DNS configuration in a stable order, the management service doesn't
+// keep the order of them between the network maps
+func networkFingerprint(routes []*mgmProto.Route, dns *mgmProto.DNSConfig) []byte {
+	normalized := proto.Clone(dns).(*mgmProto.DNSConfig)
+	normalized.NameServerGroups = sortedByEncoding(normalized.NameServerGroups)
+	for _, zone := range normalized.CustomZones {
+		zone.Records = sortedByEncoding(zone.Records)
+	}
+	normalized.CustomZones = sortedByEncoding(normalized.CustomZones)
+
+	encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(&mgmProto.NetworkMap{
+		Routes:    sortedByEncoding(routes),
+		DNSConfig: normalized,
+	})
+	if err != nil {
+		log.Errorf("failed to encode the routes and the DNS configuration: %v", err)
+	}
+	return encoded
+}
+
+func sortedByEncoding[T proto.Message](messages []T) []T {
+	type encodedMessage struct {
+		message T
+		encoded []byte
+	}
+
+	encoded := make([]encodedMessage, 0, len(messages))
+	for _, message := range messages {
+		b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+		encoded = append(encoded, encodedMessage{message: message, encoded: b})
+	}
+	slices.SortFunc(encoded, func(a, b encodedMessage) int {
+		return bytes.Compare(a.encoded, b.encoded)
+	})
+
+	sorted := make([]T, 0, len(messages))
+	for _, m := range encoded {
+		sorted = append(sorted, m.message)
+	}
+	return sorted
+}
+
+// diffNetworkChanges lists the routes and the DNS settings the pending changes add, remove or update
+func diffNetworkChanges(applied, pending *networkChanges) []NetworkChange {
+	var changes []NetworkChange
+
+	appliedRoutes := make(map[string]*mgmProto.Route, len(applied.routes))
+	for _, r := range applied.routes {
+		appliedRoutes[r.GetID()] = r
+	}
+	pendingRoutes := make(map[string]*mgmProto.Route, len(pending.routes))
+	for _, r := range pending.routes {
+		pendingRoutes[r.GetID()] = r
+	}
+
+	for _, r := range sortedByEncoding(applied.routes) {
+		if _, ok := pendingRoutes[r.GetID()]; !ok {
+			changes = append(changes, NetworkChange{Kind: NetworkChangeRoute, Action: networkChangeRemoved, Description: describeRoute(r)})
+		}
+	}
+	for _, r := range sortedByEncoding(pending.routes) {
+		old, ok := appliedRoutes[r.GetID()]
+		switch {
+		case !ok:
+			changes = append(changes, NetworkChange{Kind: NetworkChangeRoute, Action: networkChangeAdded, Description: describeRoute(r)})
+		case !proto.Equal(old, r):
+			changes = append(changes, NetworkChange{
+				Kind:        NetworkChangeRoute,
+				Action:      networkChangeUpdated,
+				Description: fmt.Sprintf("%s (was %s)", describeRoute(r), describeRoute(old)),
+			})
+		}
+	}
+
+	if applied.dns.GetServiceEnable() != pending.dns.GetServiceEnable() {
+		description := "DNS service disabled"
+		if pending.dns.GetServiceEnable() {
+			description = "DNS service enabled"
+		}
+		changes = append(changes, NetworkChange{Kind: NetworkChangeDNS, Action: networkChangeUpdated, Description: description})
+	}
+
+	changes = append(changes, diffNameServerGroups(applied.dns.GetNameServerGroups(), pending.dns.GetNameServerGroups())...)
+	changes = append(changes, diffCustomZones(applied.dns.GetCustomZones(), pending.dns.GetCustomZones())...)
+
+	if !proto.Equal(applied.dns.GetBlocklist(), pending.dns.GetBlocklist()) {
+		changes = append(changes, NetworkChange{
+			Kind:        NetworkChangeDNS,
+			Action:      networkChangeUpdated,
+			Description: fmt.Sprintf("blocklist of %d lists", len(pending.dns.GetBlocklist().GetURLs())),
+		})
+	}
+
+	return changes
+}
+
+// diffNameServerGroups compares the nameserver groups as a whole, they have no ID to tell an update from a replacement
+func diffNameServerGroups(applied, pending []*mgmProto.NameServerGroup) []NetworkChange {
+	var changes []NetworkChange
+	contains := func(groups []*mgmProto.NameServerGroup, group *mgmProto.NameServerGroup) bool {
+		return slices.ContainsFunc(groups, func(g *mgmProto.NameServerGroup) bool {
+			return proto.Equal(g, group)
+		})
+	}
+
+	for _, group := range sortedByEncoding(applied) {
+		if !contains(pending, group) {
+			changes = append(changes, NetworkChange{Kind: NetworkChangeDNS, Action: networkChangeRemoved, Description: describeNameServerGroup(group)})
+		}
+	}
+	for _, group := range sortedByEncoding(pending) {
+		if !contains(applied, group) {
+			changes = append(changes, NetworkChange{Kind: NetworkChangeDNS, Action: networkChangeAdded, Description: describeNameServerGroup(group)})
+		}
+	}
+	return changes
+}
+
+func diffCustomZones(applied, pending []*mgmProto.CustomZone) []NetworkChange {
+	var changes []NetworkChange
+
+	appliedZones := make(map[string]*mgmProto.CustomZone, len(applied))
+	for _, zone := range applied {
+		appliedZones[zone.GetDomain()] = zone
+	}
+	pendingZones := make(map[string]*mgmProto.CustomZone, len(pending))
+	for _, zone := range pending {
+		pendingZones[zone.GetDomain()] = zone
+	}
+
+	for _, zone := range sortedByEncoding(applied) {
+		if _, ok := pendingZones[zone.GetDomain()]; !ok {
+			changes = append(changes, NetworkChange{Kind: NetworkChangeDNS, Action: networkChangeRemoved, Description: describeCustomZone(zone)})
+		}
+	}
+	for _, zone := range sortedByEncoding(pending) {
+		old, ok := appliedZones[zone.GetDomain()]
+		switch {
+		case !ok:
+			changes = append(changes, NetworkChange{Kind: NetworkChangeDNS, Action: networkChangeAdded, Description: describeCustomZone(zone)})
+		case !bytes.Equal(networkFingerprint(nil, &mgmProto.DNSConfig{CustomZones: []*mgmProto.CustomZone{old}}),
+			networkFingerprint(nil, &mgmProto.DNSConfig{CustomZones: []*mgmProto.CustomZone{zone}})):
+			changes = append(changes, NetworkChange{Kind: NetworkChangeDNS, Action: networkChangeUpdated, Description: describeCustomZone(zone)})
+		}
+	}
+	return changes
+}
+
+func describeRoute(r *mgmProto.Route) string {
+	target := r.GetNetwork()
+	if len(r.GetDomains()) > 0 {
+		target = strings.Join(r.GetDomains(), ",")
+	}
+	return fmt.Sprintf("%s %s via peer %s metric %d", r.GetNetID(), target, r.GetPeer(), r.GetMetric())
+}
+
+func describeNameServerGroup(group *mgmProto.NameServerGroup) string {
+	servers := make([]string, 0, len(group.GetNameServers()))
+	for _, ns := range group.GetNameServers() {
+		servers = append(servers, fmt.Sprintf("%s:%d", ns.GetIP(), ns.GetPort()))
+	}
+
+	scope := "all domains"
+	if !group.GetPrimary() {
+		scope = strings.Join(group.GetDomains(), ",")
+	}
+	return fmt.Sprintf("nameservers %s for %s", strings.Join(servers, ","), scope)
+}
+
+func describeCustomZone(zone *mgmProto.CustomZone) string {
+	return fmt.Sprintf("zone %s with %d records", zone.GetDomain(), len(zone.GetRecords()))
+}
+
+// serverRoutes returns the routes the peer routes itself
+func serverRoutes(routes []*mgmProto.Route, pubKey string) []*mgmProto.Route {
+	own := make([]*mgmProto.Route, 0)
+	for _, r := range routes {
+		if r.GetPeer() == pubKey {
+			own = append(own, r)
+		}
+	}
+	return own
+}
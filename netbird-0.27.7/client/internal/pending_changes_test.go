@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/client/internal/dns"
+	"github.com/netbirdio/netbird/client/internal/routemanager"
+	nbdns "github.com/netbirdio/netbird/dns"
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+	"github.com/netbirdio/netbird/route"
+)
+
+// newStagingEngine returns an engine staging the network changes and the serials of the routes it installed
+func newStagingEngine(autoApply time.Duration) (*Engine, *[]uint64) {
+	var installed []uint64
+	engine := &Engine{
+		config:     &EngineConfig{StageNetworkChanges: true, StagedChangesAutoApply: autoApply},
+		syncMsgMux: &sync.Mutex{},
+		routeManager: &routemanager.MockManager{
+			UpdateRoutesFunc: func(serial uint64, _ []*route.Route) (map[route.ID]*route.Route, route.HAMap, error) {
+				installed = append(installed, serial)
+				return nil, nil, nil
+			},
+		},
+		dnsServer: &dns.MockServer{UpdateDNSServerFunc: func(uint64, nbdns.Config) error { return nil }},
+	}
+	return engine, &installed
+}
+
+func testNetworkMap(serial uint64, routes ...*mgmProto.Route) *mgmProto.NetworkMap {
+	return &mgmProto.NetworkMap{Serial: serial, Routes: routes}
+}
+
+var (
+	testLANRoute     = &mgmProto.Route{ID: "lan", Network: "192.168.1.0/24", NetID: "lan", Peer: "p1", NetworkType: 1, Metric: 9999}
+	testOfficeRoute  = &mgmProto.Route{ID: "office", Network: "10.0.0.0/16", NetID: "office", Peer: "p2", NetworkType: 1, Metric: 9999}
+	testDefaultRoute = &mgmProto.Route{ID: "exit", Network: "0.0.0.0/0", NetID: "exit", Peer: "p3", NetworkType: 1, Metric: 9999}
+)
+
+func TestEngine_StageNetworkChanges(t *testing.T) {
+	engine, installed := newStagingEngine(0)
+
+	// the first network map is applied right away
+	engine.updateRoutesAndDNS(engine.toNetworkChanges(testNetworkMap(1, testLANRoute, testOfficeRoute)))
+	assert.Equal(t, []uint64{1}, *installed)
+
+	// the same routes in another order are no change
+	engine.updateRoutesAndDNS(engine.toNetworkChanges(testNetworkMap(2, testOfficeRoute, testLANRoute)))
+	assert.Equal(t, []uint64{1, 2}, *installed)
+	pending, err := engine.PendingNetworkChanges()
+	require.NoError(t, err)
+	assert.Nil(t, pending)
+
+	// a new default route is staged
+	engine.updateRoutesAndDNS(engine.toNetworkChanges(testNetworkMap(3, testLANRoute, testDefaultRoute)))
+	assert.Equal(t, []uint64{1, 2}, *installed)
+	pending, err = engine.PendingNetworkChanges()
+	require.NoError(t, err)
+	require.NotNil(t, pending)
+	assert.Equal(t, uint64(3), pending.Serial)
+	assert.True(t, pending.AutoApplyAt.IsZero())
+	assert.Equal(t, []NetworkChange{
+		{Kind: NetworkChangeRoute, Action: networkChangeRemoved, Description: "office 10.0.0.0/16 via peer p2 metric 9999"},
+		{Kind: NetworkChangeRoute, Action: networkChangeAdded, Description: "exit 0.0.0.0/0 via peer p3 metric 9999"},
+	}, pending.Changes)
+
+	// the same changes of a newer network map keep the staged ones up to date
+	engine.updateRoutesAndDNS(engine.toNetworkChanges(testNetworkMap(4, testDefaultRoute, testLANRoute)))
+	pending, err = engine.PendingNetworkChanges()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), pending.Serial)
+
+	assert.ErrorIs(t, engine.ApplyPendingNetworkChanges(3), ErrPendingNetworkChangesReplaced)
+	require.NoError(t, engine.ApplyPendingNetworkChanges(4))
+	assert.Equal(t, []uint64{1, 2, 4}, *installed)
+	assert.ErrorIs(t, engine.ApplyPendingNetworkChanges(0), ErrNoPendingNetworkChanges)
+
+	// discarded changes aren't staged again
+	engine.updateRoutesAndDNS(engine.toNetworkChanges(testNetworkMap(5, testLANRoute)))
+	require.NoError(t, engine.DiscardPendingNetworkChanges(0))
+	engine.updateRoutesAndDNS(engine.toNetworkChanges(testNetworkMap(6, testLANRoute)))
+	pending, err = engine.PendingNetworkChanges()
+	require.NoError(t, err)
+	assert.Nil(t, pending)
+	assert.Equal(t, []uint64{1, 2, 4}, *installed)
+}
+
+func TestEngine_StageNetworkChangesAutoApply(t *testing.T) {
+	engine, installed := newStagingEngine(50 * time.Millisecond)
+
+	engine.updateRoutesAndDNS(engine.toNetworkChanges(testNetworkMap(1, testLANRoute)))
+	engine.updateRoutesAndDNS(engine.toNetworkChanges(testNetworkMap(2, testLANRoute, testDefaultRoute)))
+
+	pending, err := engine.PendingNetworkChanges()
+	require.NoError(t, err)
+	require.NotNil(t, pending)
+	assert.False(t, pending.AutoApplyAt.IsZero())
+
+	assert.Eventually(t, func() bool {
+		engine.syncMsgMux.Lock()
+		defer engine.syncMsgMux.Unlock()
+		return len(*installed) == 2 && engine.pendingNetwork == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestEngine_StageNetworkChangesDisabled(t *testing.T) {
+	engine, installed := newStagingEngine(0)
+	engine.config.StageNetworkChanges = false
+
+	engine.updateRoutesAndDNS(engine.toNetworkChanges(testNetworkMap(1, testLANRoute)))
+	engine.updateRoutesAndDNS(engine.toNetworkChanges(testNetworkMap(2, testDefaultRoute)))
+	assert.Equal(t, []uint64{1, 2}, *installed)
+
+	_, err := engine.PendingNetworkChanges()
+	assert.Error(t, err)
+}
+
+func TestDiffNetworkChangesDNS(t *testing.T) {
+	applied := &networkChanges{dns: &mgmProto.DNSConfig{
+		ServiceEnable: true,
+		NameServerGroups: []*mgmProto.NameServerGroup{
+			{NameServers: []*mgmProto.NameServer{{IP: "1.1.1.1", Port: 53}}, Primary: true},
+		},
+		CustomZones: []*mgmProto.CustomZone{{Domain: "netbird.cloud.", Records: []*mgmProto.SimpleRecord{{Name: "a"}}}},
+	}}
+	pending := &networkChanges{dns: &mgmProto.DNSConfig{
+		ServiceEnable: true,
+		NameServerGroups: []*mgmProto.NameServerGroup{
+			{NameServers: []*mgmProto.NameServer{{IP: "9.9.9.9", Port: 53}}, Domains: []string{"corp.example.com"}},
+		},
+		CustomZones: []*mgmProto.CustomZone{{Domain: "netbird.cloud.", Records: []*mgmProto.SimpleRecord{{Name: "a"}, {Name: "b"}}}},
+	}}
+
+	assert.Equal(t, []NetworkChange{
+		{Kind: NetworkChangeDNS, Action: networkChangeRemoved, Description: "nameservers 1.1.1.1:53 for all domains"},
+		{Kind: NetworkChangeDNS, Action: networkChangeAdded, Description: "nameservers 9.9.9.9:53 for corp.example.com"},
+		{Kind: NetworkChangeDNS, Action: networkChangeUpdated, Description: "zone netbird.cloud. with 2 records"},
+	}, diffNetworkChanges(applied, pending))
+}
@@ -0,0 +1,317 @@
+// Package persist batches the writes of the state files of the client to spare the flash of the routers.
+// The writes are kept in memory, or staged on tmpfs to survive a restart of the daemon, and written to their path
+// atomically at most once per flush interval. Files whose content didn't change are not written
+package persist
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Options configure the batching of the writes
+type Options struct {
+	// Interval is the time the writes are batched for before they are flushed, zero writes them immediately
+	Interval time.Duration
+	// StagingDir is the directory, usually on tmpfs, the batched writes are staged in until they are flushed.
+	// Empty keeps them in memory only, they are lost if the daemon stops without a flush
+	StagingDir string
+}
+
+type pendingFile struct {
+	data []byte
+	perm os.FileMode
+}
+
+// Writer batches the writes of files
+type Writer struct {
+	mu      sync.Mutex
+	opts    Options
+	pending map[string]pendingFile
+	// reconfigured wakes up Run when the interval changes
+	reconfigured chan struct{}
+}
+
+// NewWriter returns a writer batching the writes with the options. The files staged by a previous writer in the
+// staging directory are pending again
+func NewWriter(opts Options) (*Writer, error) {
+	w := &Writer{
+		pending:      make(map[string]pendingFile),
+		reconfigured: make(chan struct{}, 1),
+	}
+	if err := w.Configure(opts); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Configure flushes the pending writes and applies the options
+func (w *Writer) Configure(opts Options) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.flushLocked(); err != nil {
+		return err
+	}
+
+	if opts.Interval < 0 {
+		return fmt.Errorf("invalid flush interval %s", opts.Interval)
+	}
+	if opts.StagingDir != "" {
+		if err := os.MkdirAll(opts.StagingDir, 0700); err != nil {
+			return fmt.Errorf("create staging directory: %w", err)
+		}
+	}
+	w.opts = opts
+
+	if err := w.recoverLocked(); err != nil {
+		return err
+	}
+	if opts.Interval == 0 {
+		if err := w.flushLocked(); err != nil {
+			return err
+		}
+	}
+
+	select {
+	case w.reconfigured <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// recoverLocked makes the files left in the staging directory pending
+func (w *Writer) recoverLocked() error {
+	if w.opts.StagingDir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(w.opts.StagingDir)
+	if err != nil {
+		return fmt.Errorf("read staging directory: %w", err)
+	}
+	for _, entry := range entries {
+		path, err := url.PathUnescape(entry.Name())
+		if err != nil || !entry.Type().IsRegular() || !filepath.IsAbs(path) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("stat staged file: %w", err)
+		}
+		data, err := os.ReadFile(filepath.Join(w.opts.StagingDir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("read staged file: %w", err)
+		}
+		if _, ok := w.pending[path]; !ok {
+			log.Infof("recovered the staged state file %s", path)
+			w.pending[path] = pendingFile{data: data, perm: info.Mode().Perm()}
+		}
+	}
+	return nil
+}
+
+func (w *Writer) stagedPath(path string) string {
+	return filepath.Join(w.opts.StagingDir, url.PathEscape(path))
+}
+
+// WriteFile writes the data to the file at path with the next flush, immediately without a flush interval
+func (w *Writer) WriteFile(path string, data []byte, perm os.FileMode) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.opts.Interval == 0 {
+		return writeIfChanged(path, data, perm)
+	}
+
+	if w.opts.StagingDir != "" {
+		if err := writeAtomic(w.stagedPath(path), data, perm); err != nil {
+			return fmt.Errorf("stage %s: %w", path, err)
+		}
+	}
+	w.pending[path] = pendingFile{data: bytes.Clone(data), perm: perm}
+	return nil
+}
+
+// ReadFile returns the content of the file at path, including the writes not flushed yet
+func (w *Writer) ReadFile(path string) ([]byte, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	pending, ok := w.pending[abs]
+	w.mu.Unlock()
+	if ok {
+		return bytes.Clone(pending.data), nil
+	}
+	return os.ReadFile(path)
+}
+
+// Flush writes the pending files to their path
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flushLocked()
+}
+
+func (w *Writer) flushLocked() error {
+	var errs []error
+	for path, file := range w.pending {
+		if err := writeIfChanged(path, file.data, file.perm); err != nil {
+			errs = append(errs, fmt.Errorf("write %s: %w", path, err))
+			continue
+		}
+		delete(w.pending, path)
+
+		if w.opts.StagingDir != "" {
+			if err := os.Remove(w.stagedPath(path)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				log.Debugf("failed to remove the staged file of %s: %v", path, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run flushes the pending writes every flush interval until ctx is done, then flushes them a last time
+func (w *Writer) Run(ctx context.Context) {
+	for {
+		w.mu.Lock()
+		interval := w.opts.Interval
+		w.mu.Unlock()
+
+		// without an interval the writes are immediate, Run only waits for a new interval
+		var tick <-chan time.Time
+		var timer *time.Timer
+		if interval > 0 {
+			timer = time.NewTimer(interval)
+			tick = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			stopTimer(timer)
+			if err := w.Flush(); err != nil {
+				log.Errorf("failed to flush the state files: %v", err)
+			}
+			return
+		case <-w.reconfigured:
+			stopTimer(timer)
+		case <-tick:
+			if err := w.Flush(); err != nil {
+				log.Errorf("failed to flush the state files: %v", err)
+			}
+		}
+	}
+}
+
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+// writeIfChanged writes the data to the file at path atomically unless the file already holds it
+func writeIfChanged(path string, data []byte, perm os.FileMode) error {
+	current, err := os.ReadFile(path)
+	if err == nil && bytes.Equal(current, data) {
+		return nil
+	}
+	return writeAtomic(path, data, perm)
+}
+
+// writeAtomic writes the data to a temporary file synced to the disk and renames it to path, the file is either
+// the previous or the new one after a power loss
+func writeAtomic(path string, data []byte, perm os.FileMode) (err error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	syncDir(dir)
+	return nil
+}
+
+// syncDir persists the rename in the directory, not supported on all the platforms
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	_ = d.Sync()
+}
+
+// defaultWriter writes the state files of the client, immediately until it is configured
+var defaultWriter = &Writer{
+	pending:      make(map[string]pendingFile),
+	reconfigured: make(chan struct{}, 1),
+}
+
+// Configure flushes the pending writes of the state files of the client and applies the options
+func Configure(opts Options) error {
+	return defaultWriter.Configure(opts)
+}
+
+// WriteFile writes a state file of the client with the next flush
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	return defaultWriter.WriteFile(path, data, perm)
+}
+
+// ReadFile returns the content of a state file of the client, including the writes not flushed yet
+func ReadFile(path string) ([]byte, error) {
+	return defaultWriter.ReadFile(path)
+}
+
+// Flush writes the pending state files of the client
+func Flush() error {
+	return defaultWriter.Flush()
+}
+
+// Run flushes the pending state files of the client every flush interval until ctx is done
+func Run(ctx context.Context) {
+	defaultWriter.Run(ctx)
+}
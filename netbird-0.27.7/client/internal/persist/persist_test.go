@@ -0,0 +1,101 @@
+package persist
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriterImmediate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "config.json")
+
+	w, err := NewWriter(Options{})
+	require.NoError(t, err)
+	require.NoError(t, w.WriteFile(path, []byte("first"), 0600))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "first", string(data))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// the same content isn't written again
+	modTime := info.ModTime()
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, w.WriteFile(path, []byte("first"), 0600))
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, modTime, info.ModTime())
+}
+
+func TestWriterBatched(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	staging := filepath.Join(dir, "staging")
+
+	w, err := NewWriter(Options{Interval: time.Hour, StagingDir: staging})
+	require.NoError(t, err)
+
+	require.NoError(t, w.WriteFile(path, []byte("first"), 0600))
+	require.NoError(t, w.WriteFile(path, []byte("second"), 0600))
+
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist, "the write should be batched")
+	data, err := w.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "second", string(data), "the pending write should be read")
+
+	// a new writer, e.g. after a restart of the daemon, recovers the staged write
+	recovered, err := NewWriter(Options{Interval: time.Hour, StagingDir: staging})
+	require.NoError(t, err)
+	data, err = recovered.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "second", string(data))
+
+	require.NoError(t, recovered.Flush())
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "second", string(data))
+
+	entries, err := os.ReadDir(staging)
+	require.NoError(t, err)
+	require.Empty(t, entries, "the flushed files should be removed from the staging directory")
+}
+
+func TestWriterRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	w, err := NewWriter(Options{Interval: time.Hour})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+
+	// the new interval applies to the running flush loop
+	require.NoError(t, w.Configure(Options{Interval: 10 * time.Millisecond}))
+	require.NoError(t, w.WriteFile(path, []byte("flushed"), 0600))
+	require.Eventually(t, func() bool {
+		data, err := os.ReadFile(path)
+		return err == nil && string(data) == "flushed"
+	}, time.Second, 10*time.Millisecond)
+
+	// the pending writes are flushed when the daemon stops
+	require.NoError(t, w.Configure(Options{Interval: time.Hour}))
+	require.NoError(t, w.WriteFile(path, []byte("latest"), 0600))
+	cancel()
+	<-done
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "latest", string(data))
+}
@@ -0,0 +1,239 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+)
+
+const (
+	// the probe a ping_peer action runs
+	peerActionPingCount   = 4
+	peerActionPingTimeout = 2 * time.Second
+
+	pingInterval = time.Second
+)
+
+// The paths a peer is reached on
+const (
+	PingPathP2P          = "p2p"
+	PingPathRelayed      = "relayed"
+	PingPathDisconnected = "disconnected"
+)
+
+// PingResult is the outcome of an ICMP probe of a peer over the WireGuard tunnel
+type PingResult struct {
+	IP   string
+	FQDN string
+	// Sent and Received are the numbers of the echo requests sent and the replies received
+	Sent     int
+	Received int
+	// RTTs are the round trip times of the received replies
+	RTTs []time.Duration
+	// Path is how the peer is reached, one of PingPathP2P, PingPathRelayed or PingPathDisconnected
+	Path string
+	// LocalCandidate and RemoteCandidate are the ICE candidate types of the connection to the peer
+	LocalCandidate  string
+	RemoteCandidate string
+}
+
+// peerTarget is a peer resolved for a probe or a speed test
+type peerTarget struct {
+	ip     string
+	fqdn   string
+	pubKey string
+}
+
+// PingPeer probes the peer with count ICMP echo requests over the WireGuard tunnel, each waiting up to timeout for
+// its reply. The target is the name, FQDN, Netbird IP or WireGuard key of a peer, the peers this peer doesn't know
+// are resolved by the management service which tells whether a policy allows reaching them.
+func (e *Engine) PingPeer(ctx context.Context, target string, count int, timeout time.Duration) (*PingResult, error) {
+	resolved, err := e.resolvePeerTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PingResult{IP: resolved.ip, FQDN: resolved.fqdn}
+	result.Path, result.LocalCandidate, result.RemoteCandidate = e.peerPath(resolved.pubKey)
+
+	result.Sent, result.RTTs, err = pingICMP(ctx, net.ParseIP(resolved.ip), count, timeout)
+	result.Received = len(result.RTTs)
+	return result, err
+}
+
+// resolvePeerTarget looks up the target among the peers of the network map and asks the management service for the
+// ones it doesn't hold. The target is the name, FQDN, Netbird IP or WireGuard key of a peer.
+func (e *Engine) resolvePeerTarget(target string) (*peerTarget, error) {
+	target = strings.TrimSuffix(strings.TrimSpace(target), ".")
+	if target == "" {
+		return nil, fmt.Errorf("no peer to ping")
+	}
+
+	for _, state := range e.statusRecorder.GetFullStatus().Peers {
+		label, _, _ := strings.Cut(state.FQDN, ".")
+		if state.IP == target || state.PubKey == target || strings.EqualFold(state.FQDN, target) ||
+			strings.EqualFold(label, target) {
+			return &peerTarget{ip: state.IP, fqdn: state.FQDN, pubKey: state.PubKey}, nil
+		}
+	}
+
+	resp, err := e.mgmClient.ResolvePeer(&mgmProto.ResolvePeerRequest{Target: target})
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", target, err)
+	}
+	if !resp.GetAccessible() {
+		return nil, fmt.Errorf("no policy allows this peer to reach %s", target)
+	}
+	if !resp.GetConnected() {
+		return nil, fmt.Errorf("%s is offline", target)
+	}
+	return &peerTarget{ip: resp.GetIp(), fqdn: resp.GetFqdn(), pubKey: resp.GetWgPubKey()}, nil
+}
+
+// peerPath returns how the peer is reached with the ICE candidate types of the connection
+func (e *Engine) peerPath(pubKey string) (string, string, string) {
+	state, err := e.statusRecorder.GetPeer(pubKey)
+	if err != nil || state.ConnStatus != peer.StatusConnected {
+		return PingPathDisconnected, "", ""
+	}
+	path := PingPathP2P
+	if state.Relayed {
+		path = PingPathRelayed
+	}
+	return path, state.LocalIceCandidateType, state.RemoteIceCandidateType
+}
+
+// pingICMP sends the ICMP echo requests to the IP, a second apart, and returns the number sent and the round trip
+// times of the replies. The unprivileged ICMP sockets are used when the raw ones aren't allowed.
+func pingICMP(ctx context.Context, ip net.IP, count int, timeout time.Duration) (int, []time.Duration, error) {
+	if ip.To4() == nil {
+		return 0, nil, fmt.Errorf("invalid peer IP %s", ip)
+	}
+
+	privileged := true
+	conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		privileged = false
+		conn, err = icmp.ListenPacket("udp4", "0.0.0.0")
+		if err != nil {
+			return 0, nil, fmt.Errorf("open ICMP socket: %w", err)
+		}
+	}
+	defer conn.Close()
+
+	var dst net.Addr = &net.IPAddr{IP: ip}
+	if !privileged {
+		dst = &net.UDPAddr{IP: ip}
+	}
+	// the kernel sets the ID of the unprivileged sockets
+	id := os.Getpid() & 0xffff
+
+	var sent int
+	var rtts []time.Duration
+	for seq := 1; seq <= count; seq++ {
+		start := time.Now()
+		msg := icmp.Message{
+			Type: ipv4.ICMPTypeEcho,
+			Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("netbird-ping")},
+		}
+		data, err := msg.Marshal(nil)
+		if err != nil {
+			return sent, rtts, err
+		}
+		if _, err := conn.WriteTo(data, dst); err != nil {
+			return sent, rtts, fmt.Errorf("send echo request: %w", err)
+		}
+		sent++
+
+		rtt, err := readEchoReply(conn, ip, id, seq, privileged, start, timeout)
+		if err != nil {
+			log.Debugf("no echo reply %d from %s: %v", seq, ip, err)
+		} else {
+			rtts = append(rtts, rtt)
+		}
+
+		if seq == count {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return sent, rtts, ctx.Err()
+		case <-time.After(time.Until(start.Add(pingInterval))):
+		}
+	}
+
+	return sent, rtts, nil
+}
+
+// readEchoReply waits up to timeout for the reply to the echo request sent at start, skipping the other ICMP messages
+func readEchoReply(conn *icmp.PacketConn, ip net.IP, id, seq int, privileged bool, start time.Time, timeout time.Duration) (time.Duration, error) {
+	if err := conn.SetReadDeadline(start.Add(timeout)); err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return 0, fmt.Errorf("timed out")
+			}
+			return 0, err
+		}
+
+		var fromIP net.IP
+		switch addr := from.(type) {
+		case *net.IPAddr:
+			fromIP = addr.IP
+		case *net.UDPAddr:
+			fromIP = addr.IP
+		}
+		if !fromIP.Equal(ip) {
+			continue
+		}
+
+		msg, err := icmp.ParseMessage(ipv4.ICMPTypeEcho.Protocol(), buf[:n])
+		if err != nil || msg.Type != ipv4.ICMPTypeEchoReply {
+			continue
+		}
+		echo, ok := msg.Body.(*icmp.Echo)
+		if !ok || echo.Seq != seq || (privileged && echo.ID != id) {
+			continue
+		}
+		return time.Since(start), nil
+	}
+}
+
+// runPingAction probes the target of a ping_peer action and reports the result to the management service
+func (e *Engine) runPingAction(action *mgmProto.PeerAction) {
+	result, err := e.PingPeer(e.ctx, action.GetTarget(), peerActionPingCount, peerActionPingTimeout)
+	report := &mgmProto.PeerActionResult{}
+	if result != nil {
+		report.Ping = &mgmProto.PingResult{
+			Ip:              result.IP,
+			Fqdn:            result.FQDN,
+			Sent:            int32(result.Sent),
+			Received:        int32(result.Received),
+			Path:            result.Path,
+			LocalCandidate:  result.LocalCandidate,
+			RemoteCandidate: result.RemoteCandidate,
+		}
+		for _, rtt := range result.RTTs {
+			report.Ping.Rtts = append(report.Ping.Rtts, durationpb.New(rtt))
+		}
+	}
+	e.sendPeerActionResult(action, report, err)
+}
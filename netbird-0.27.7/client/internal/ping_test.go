@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+	mgmt "github.com/netbirdio/netbird/management/client"
+	mgmtProto "github.com/netbirdio/netbird/management/proto"
+)
+
+func TestEngine_resolvePeerTarget(t *testing.T) {
+	recorder := peer.NewRecorder("https://mgm")
+	require.NoError(t, recorder.AddPeer("router-key", "office-router.netbird.cloud"))
+	require.NoError(t, recorder.UpdatePeerState(peer.State{PubKey: "router-key", IP: "100.64.0.2", Mux: new(sync.RWMutex)}))
+
+	resolved := map[string]*mgmtProto.ResolvePeerResponse{
+		"laptop":  {Ip: "100.64.0.3", Fqdn: "laptop.netbird.cloud", WgPubKey: "laptop-key", Connected: true, Accessible: true},
+		"nas":     {Ip: "100.64.0.4", Fqdn: "nas.netbird.cloud", WgPubKey: "nas-key", Accessible: true},
+		"printer": {Ip: "100.64.0.5", Fqdn: "printer.netbird.cloud", WgPubKey: "printer-key", Connected: true},
+	}
+	engine := &Engine{
+		statusRecorder: recorder,
+		mgmClient: &mgmt.MockClient{
+			ResolvePeerFunc: func(req *mgmtProto.ResolvePeerRequest) (*mgmtProto.ResolvePeerResponse, error) {
+				return resolved[req.GetTarget()], nil
+			},
+		},
+	}
+
+	for _, target := range []string{"100.64.0.2", "router-key", "office-router", "Office-Router.netbird.cloud."} {
+		got, err := engine.resolvePeerTarget(target)
+		require.NoError(t, err, target)
+		assert.Equal(t, &peerTarget{ip: "100.64.0.2", fqdn: "office-router.netbird.cloud", pubKey: "router-key"}, got, target)
+	}
+
+	got, err := engine.resolvePeerTarget("laptop")
+	require.NoError(t, err, "a peer missing from the network map should be resolved by the management service")
+	assert.Equal(t, &peerTarget{ip: "100.64.0.3", fqdn: "laptop.netbird.cloud", pubKey: "laptop-key"}, got)
+
+	_, err = engine.resolvePeerTarget("nas")
+	assert.ErrorContains(t, err, "offline")
+	_, err = engine.resolvePeerTarget("printer")
+	assert.ErrorContains(t, err, "no policy")
+	_, err = engine.resolvePeerTarget(" ")
+	assert.Error(t, err)
+}
@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"fmt"
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// probePathMTU returns the path MTU to the address and the local address used to reach it.
+// The kernel reports the MTU of the route including the path MTU learned from ICMP, so it also covers
+// restrictions behind the uplink.
+func probePathMTU(address string) (int, net.IP, error) {
+	conn, err := net.Dial("udp4", address)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer conn.Close()
+
+	localAddr := conn.LocalAddr().(*net.UDPAddr).IP
+
+	rawConn, err := conn.(*net.UDPConn).SyscallConn()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var mtu int
+	var sockErr error
+	err = rawConn.Control(func(fd uintptr) {
+		mtu, sockErr = unix.GetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_MTU)
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	if sockErr != nil {
+		return 0, nil, fmt.Errorf("get IP_MTU: %w", sockErr)
+	}
+
+	return mtu, localAddr, nil
+}
@@ -0,0 +1,25 @@
+//go:build !linux
+
+package internal
+
+import (
+	"net"
+)
+
+// probePathMTU returns the MTU of the interface used to reach the address and the local address used to reach it
+func probePathMTU(address string) (int, net.IP, error) {
+	conn, err := net.Dial("udp4", address)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer conn.Close()
+
+	localAddr := conn.LocalAddr().(*net.UDPAddr).IP
+
+	mtu, err := interfaceMTU(localAddr)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return mtu, localAddr, nil
+}
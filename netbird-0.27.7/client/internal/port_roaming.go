@@ -0,0 +1,198 @@
+package internal
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+	signal "github.com/netbirdio/netbird/signal/client"
+	sProto "github.com/netbirdio/netbird/signal/proto"
+	"github.com/netbirdio/netbird/version"
+)
+
+// maxPortSelectionAttempts limits how many ports of the pool are tried before giving up on finding a free one
+const maxPortSelectionAttempts = 10
+
+// parsePortPool parses a comma separated list of ports and port ranges, e.g. "51820,443,40000-40100"
+func parsePortPool(spec string) ([]int, error) {
+	var ports []int
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		first, last, isRange := strings.Cut(item, "-")
+		start, err := parsePort(first)
+		if err != nil {
+			return nil, err
+		}
+		end := start
+		if isRange {
+			end, err = parsePort(last)
+			if err != nil {
+				return nil, err
+			}
+			if end < start {
+				return nil, fmt.Errorf("invalid port range %s", item)
+			}
+		}
+
+		for port := start; port <= end; port++ {
+			if !slices.Contains(ports, port) {
+				ports = append(ports, port)
+			}
+		}
+	}
+	return ports, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return port, nil
+}
+
+// selectWgPort returns the WireGuard listen port to use. The current port is kept if it belongs to the pool,
+// unless roam is set, in which case another free port of the pool is picked.
+func (e *Engine) selectWgPort(roam bool) int {
+	pool := e.config.WgPortPool
+	if len(pool) == 0 {
+		return e.config.WgPort
+	}
+
+	if !roam && slices.Contains(pool, e.config.WgPort) {
+		return e.config.WgPort
+	}
+
+	candidates := slices.Clone(pool)
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	attempts := 0
+	for _, port := range candidates {
+		if port == e.config.WgPort {
+			continue
+		}
+		if attempts == maxPortSelectionAttempts {
+			break
+		}
+		attempts++
+
+		if isUDPPortFree(port) {
+			return port
+		}
+		log.Debugf("WireGuard port %d is in use, trying another one", port)
+	}
+
+	log.Warnf("failed to find a free WireGuard port in the port pool, keeping port %d", e.config.WgPort)
+	return e.config.WgPort
+}
+
+func isUDPPortFree(port int) bool {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{Port: port})
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// runPortRoaming restarts the engine on a new port of the pool every WgPortRoamingInterval
+func (e *Engine) runPortRoaming() {
+	timer := time.NewTimer(e.config.WgPortRoamingInterval)
+	defer timer.Stop()
+
+	select {
+	case <-e.ctx.Done():
+		return
+	case <-timer.C:
+	}
+
+	log.Infof("roaming WireGuard listen port, restarting engine")
+	e.syncMsgMux.Lock()
+	e.roamWgPort = true
+	e.syncMsgMux.Unlock()
+
+	if err := e.Stop(); err != nil {
+		log.Errorf("Failed to stop engine: %v", err)
+	}
+	if err := e.Start(); err != nil {
+		log.Errorf("Failed to start engine: %v", err)
+	}
+}
+
+// notifyWgPortChange tells the connected peers through signal that the WireGuard listen port has changed
+// so they drop their connections to the old port and reconnect right away
+func (e *Engine) notifyWgPortChange() {
+	peerKeys := make([]wgtypes.Key, 0, len(e.peerConns))
+	for peerKey := range e.peerConns {
+		remoteKey, err := wgtypes.ParseKey(peerKey)
+		if err != nil {
+			log.Warnf("failed to parse the key of peer %s: %v", peerKey, err)
+			continue
+		}
+		peerKeys = append(peerKeys, remoteKey)
+	}
+
+	port := e.config.WgPort
+	go func() {
+		for _, remoteKey := range peerKeys {
+			if err := signalPortChange(port, e.config.WgPrivateKey, remoteKey, e.signal); err != nil {
+				log.Debugf("failed to notify peer %s about the port change: %v", remoteKey, err)
+			}
+		}
+	}()
+}
+
+func signalPortChange(port int, myKey wgtypes.Key, remoteKey wgtypes.Key, s signal.Client) error {
+	return s.Send(&sProto.Message{
+		Key:       myKey.PublicKey().String(),
+		RemoteKey: remoteKey.String(),
+		Body: &sProto.Body{
+			Type:           sProto.Body_PORT_CHANGE,
+			WgListenPort:   uint32(port),
+			NetBirdVersion: version.NetbirdVersion(),
+		},
+	})
+}
+
+// onRemotePortChange recreates an established connection to a peer that moved to another port,
+// the new connection negotiates the new endpoint through signal
+func (e *Engine) onRemotePortChange(peerKey string, port int) error {
+	conn, ok := e.peerConns[peerKey]
+	if !ok || conn.Status() != peer.StatusConnected {
+		return nil
+	}
+
+	log.Infof("peer %s changed its WireGuard port to %d, reconnecting", peerKey, port)
+
+	wgConfig := conn.WgConfig()
+	peerConfig := &mgmProto.RemotePeerConfig{
+		WgPubKey:   peerKey,
+		AllowedIps: strings.Split(wgConfig.AllowedIps, ","),
+	}
+	if state, err := e.statusRecorder.GetPeer(peerKey); err == nil {
+		peerConfig.Fqdn = state.FQDN
+	}
+	if wgConfig.PreSharedKey != nil {
+		peerConfig.PresharedKey = wgConfig.PreSharedKey.String()
+	}
+
+	if err := e.removePeer(peerKey); err != nil {
+		return err
+	}
+	return e.addNewPeer(peerConfig)
+}
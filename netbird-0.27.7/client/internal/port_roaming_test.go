@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePortPool(t *testing.T) {
+	testCases := []struct {
+		name        string
+		spec        string
+		expected    []int
+		expectedErr bool
+	}{
+		{
+			name: "empty",
+			spec: "",
+		},
+		{
+			name:     "single ports",
+			spec:     "51820, 443",
+			expected: []int{51820, 443},
+		},
+		{
+			name:     "ranges and duplicates",
+			spec:     "40000-40002,40001,51820",
+			expected: []int{40000, 40001, 40002, 51820},
+		},
+		{
+			name:        "reversed range",
+			spec:        "40002-40000",
+			expectedErr: true,
+		},
+		{
+			name:        "out of range port",
+			spec:        "70000",
+			expectedErr: true,
+		},
+		{
+			name:        "not a port",
+			spec:        "51820,wg",
+			expectedErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ports, err := parsePortPool(testCase.spec)
+			if testCase.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, ports)
+		})
+	}
+}
+
+func TestEngine_SelectWgPort(t *testing.T) {
+	engine := &Engine{config: &EngineConfig{WgPort: 51820}}
+	assert.Equal(t, 51820, engine.selectWgPort(true), "without a pool the configured port should be used")
+
+	engine.config.WgPortPool = []int{51820, 51821, 51822}
+	assert.Equal(t, 51820, engine.selectWgPort(false), "the current port should be kept if it belongs to the pool")
+
+	port := engine.selectWgPort(true)
+	assert.NotEqual(t, 51820, port, "roaming should move to another port")
+	assert.Contains(t, engine.config.WgPortPool, port)
+
+	engine.config.WgPort = 1000
+	assert.Contains(t, engine.config.WgPortPool, engine.selectWgPort(false), "a port outside of the pool should be replaced")
+
+	busy, err := net.ListenUDP("udp", &net.UDPAddr{})
+	require.NoError(t, err)
+	defer busy.Close()
+
+	busyPort := busy.LocalAddr().(*net.UDPAddr).Port
+	engine.config.WgPort = 1000
+	engine.config.WgPortPool = []int{busyPort}
+	assert.Equal(t, 1000, engine.selectWgPort(true), "busy ports should not be picked")
+}
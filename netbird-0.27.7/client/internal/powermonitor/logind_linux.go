@@ -0,0 +1,102 @@
+//go:build !android
+
+package powermonitor
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+
+	"github.com/godbus/dbus/v5"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	logindDest            = "org.freedesktop.login1"
+	logindPath            = dbus.ObjectPath("/org/freedesktop/login1")
+	logindManager         = "org.freedesktop.login1.Manager"
+	logindPrepareForSleep = logindManager + ".PrepareForSleep"
+	logindInhibit         = logindManager + ".Inhibit"
+)
+
+// watchLogind handles the PrepareForSleep signal of systemd-logind. A delay inhibitor lock is held while the
+// system is awake, so the suspend callback can finish before the system sleeps.
+func watchLogind(ctx context.Context, onSuspend, onResume func()) error {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return fmt.Errorf("connect to system bus: %w", err)
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log.Debugf("Power monitor: failed to close dbus connection: %v", err)
+		}
+	}()
+
+	obj := conn.Object(logindDest, logindPath)
+	if err := obj.CallWithContext(ctx, "org.freedesktop.DBus.Peer.Ping", 0).Store(); err != nil {
+		return fmt.Errorf("ping logind: %w", err)
+	}
+
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(logindPath),
+		dbus.WithMatchInterface(logindManager),
+		dbus.WithMatchMember("PrepareForSleep"),
+	); err != nil {
+		return fmt.Errorf("subscribe to PrepareForSleep: %w", err)
+	}
+
+	signals := make(chan *dbus.Signal, 10)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+
+	release := inhibitSleep(obj)
+	defer func() {
+		release()
+	}()
+
+	handler := &stateHandler{onSuspend: onSuspend, onResume: onResume}
+
+	log.Info("Power monitor: started, listening to logind sleep events")
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case sig, ok := <-signals:
+			if !ok {
+				return fmt.Errorf("dbus connection closed")
+			}
+			if sig.Name != logindPrepareForSleep || len(sig.Body) == 0 {
+				continue
+			}
+			sleeping, ok := sig.Body[0].(bool)
+			if !ok {
+				continue
+			}
+
+			handler.handle(sleeping)
+			if sleeping {
+				// the system sleeps once the lock is released
+				release()
+				release = func() {}
+			} else {
+				release = inhibitSleep(obj)
+			}
+		}
+	}
+}
+
+// inhibitSleep takes a delay inhibitor lock and returns the function releasing it
+func inhibitSleep(obj dbus.BusObject) func() {
+	var fd dbus.UnixFD
+	err := obj.Call(logindInhibit, 0, "sleep", "NetBird", "Closing the tunnel connections", "delay").Store(&fd)
+	if err != nil {
+		log.Warnf("Power monitor: failed to take the sleep inhibitor lock, the connections may not be closed before sleeping: %v", err)
+		return func() {}
+	}
+
+	return func() {
+		if err := syscall.Close(int(fd)); err != nil {
+			log.Debugf("Power monitor: failed to release the sleep inhibitor lock: %v", err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package powermonitor
+
+import (
+	"context"
+	"sync"
+)
+
+// PowerWatcher watches for the system going to sleep and waking up.
+type PowerWatcher struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// New creates a new power monitor.
+func New() *PowerWatcher {
+	return &PowerWatcher{}
+}
+
+// Stop stops the power monitor.
+func (pw *PowerWatcher) Stop() {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	if pw.cancel != nil {
+		pw.cancel()
+		pw.cancel = nil
+	}
+}
+
+func (pw *PowerWatcher) watchContext(ctx context.Context) context.Context {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	if pw.cancel != nil {
+		pw.cancel()
+	}
+	ctx, pw.cancel = context.WithCancel(ctx)
+	return ctx
+}
@@ -0,0 +1,14 @@
+//go:build !linux || android
+
+package powermonitor
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Start is a no-op on this platform, the sleep events are not available
+func (pw *PowerWatcher) Start(context.Context, func(), func()) {
+	log.Debugf("Power monitor: not supported on this platform")
+}
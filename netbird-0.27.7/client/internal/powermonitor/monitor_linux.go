@@ -0,0 +1,54 @@
+//go:build !android
+
+package powermonitor
+
+import (
+	"context"
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Start watches for the system going to sleep and waking up until the context is done.
+// onSuspend is called before the system sleeps and onResume after it woke up.
+// The sleep events come from systemd-logind over D-Bus, on systems without logind, e.g. OpenWrt,
+// they come from the netbird.power ubus event.
+func (pw *PowerWatcher) Start(ctx context.Context, onSuspend, onResume func()) {
+	ctx = pw.watchContext(ctx)
+
+	err := watchLogind(ctx, onSuspend, onResume)
+	if err == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	log.Debugf("Power monitor: logind is not available: %v", err)
+
+	err = watchUbus(ctx, onSuspend, onResume)
+	if err == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	log.Debugf("Power monitor: ubus is not available: %v", err)
+
+	log.Infof("Power monitor: no sleep events available, not starting")
+}
+
+// stateHandler calls the callbacks for the transitions between suspended and resumed
+type stateHandler struct {
+	suspended bool
+	onSuspend func()
+	onResume  func()
+}
+
+func (h *stateHandler) handle(suspend bool) {
+	if suspend == h.suspended {
+		return
+	}
+	h.suspended = suspend
+
+	if suspend {
+		log.Infof("Power monitor: system is going to sleep")
+		h.onSuspend()
+		return
+	}
+	log.Infof("Power monitor: system woke up")
+	h.onResume()
+}
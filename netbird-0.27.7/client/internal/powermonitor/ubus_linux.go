@@ -0,0 +1,84 @@
+//go:build !android
+
+package powermonitor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ubusPowerEvent is the ubus event announcing the sleep state, e.g. sent by a power management script:
+	// ubus send netbird.power '{"state":"suspend"}'
+	ubusPowerEvent = "netbird.power"
+
+	ubusStateSuspend = "suspend"
+	ubusStateResume  = "resume"
+)
+
+type ubusPowerState struct {
+	State string `json:"state"`
+}
+
+// watchUbus handles the netbird.power events of ubus, the OpenWrt system bus
+func watchUbus(ctx context.Context, onSuspend, onResume func()) error {
+	path, err := exec.LookPath("ubus")
+	if err != nil {
+		return fmt.Errorf("find ubus: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, path, "listen", ubusPowerEvent)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("get ubus output: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start ubus listen: %w", err)
+	}
+
+	handler := &stateHandler{onSuspend: onSuspend, onResume: onResume}
+
+	log.Infof("Power monitor: started, listening to %s ubus events", ubusPowerEvent)
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		suspend, ok := parseUbusEvent(scanner.Bytes())
+		if !ok {
+			log.Debugf("Power monitor: ignoring ubus event %s", scanner.Text())
+			continue
+		}
+		handler.handle(suspend)
+	}
+
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return fmt.Errorf("ubus listen exited: %w", err)
+}
+
+// parseUbusEvent parses a line of ubus listen, e.g. { "netbird.power": {"state":"suspend"} }
+func parseUbusEvent(line []byte) (suspend bool, ok bool) {
+	var event map[string]ubusPowerState
+	if err := json.Unmarshal(line, &event); err != nil {
+		return false, false
+	}
+
+	state, found := event[ubusPowerEvent]
+	if !found {
+		return false, false
+	}
+
+	switch state.State {
+	case ubusStateSuspend:
+		return true, true
+	case ubusStateResume:
+		return false, true
+	default:
+		return false, false
+	}
+}
@@ -0,0 +1,46 @@
+package powermonitor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUbusEvent(t *testing.T) {
+	testCases := []struct {
+		name       string
+		line       string
+		expected   bool
+		expectedOk bool
+	}{
+		{name: "suspend", line: `{ "netbird.power": {"state":"suspend"} }`, expected: true, expectedOk: true},
+		{name: "resume", line: `{ "netbird.power": {"state":"resume"} }`, expected: false, expectedOk: true},
+		{name: "unknown state", line: `{ "netbird.power": {"state":"hibernate"} }`},
+		{name: "other event", line: `{ "network.interface": {"action":"ifup"} }`},
+		{name: "not json", line: `ubus: command failed`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			suspend, ok := parseUbusEvent([]byte(tc.line))
+			assert.Equal(t, tc.expectedOk, ok)
+			assert.Equal(t, tc.expected, suspend)
+		})
+	}
+}
+
+func TestStateHandler(t *testing.T) {
+	var suspends, resumes int
+	handler := &stateHandler{
+		onSuspend: func() { suspends++ },
+		onResume:  func() { resumes++ },
+	}
+
+	handler.handle(false)
+	handler.handle(true)
+	handler.handle(true)
+	handler.handle(false)
+
+	assert.Equal(t, 1, suspends, "repeated suspend events should be ignored")
+	assert.Equal(t, 1, resumes, "a resume without a suspend should be ignored")
+}
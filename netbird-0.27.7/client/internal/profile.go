@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"regexp"
+	"runtime"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/client/firewall"
+	"github.com/netbirdio/netbird/iface"
+	nbnet "github.com/netbirdio/netbird/util/net"
+)
+
+// A profile is an independent client running next to the default one on the same host, e.g. a router joined to two
+// NetBird accounts. Each profile has its own config, daemon socket, UCI section, WireGuard interface and port. The
+// default profile has no name and keeps the paths and defaults of a single client
+const (
+	// maxProfileNameLength keeps the interface name of the profile, wt-<profile>, within the 15 characters of Linux
+	maxProfileNameLength = 12
+	// profilePortRange is the number of ports above iface.DefaultWgPort the default ports of the profiles are
+	// picked from
+	profilePortRange = 100
+)
+
+var profileNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// ValidateProfileName returns an error when the name can't name a profile. The names are UCI section names, used
+// in the interface name of the profile
+func ValidateProfileName(name string) error {
+	if !profileNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: only letters, digits and underscores are allowed", name)
+	}
+	if len(name) > maxProfileNameLength {
+		return fmt.Errorf("invalid profile name %q: longer than %d characters", name, maxProfileNameLength)
+	}
+	if name == uciDefaultSectionName {
+		return fmt.Errorf("invalid profile name %q: reserved for the default profile", name)
+	}
+	return nil
+}
+
+// profileHash spreads the profiles over the defaults derived from their name
+func profileHash(profile string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(profile))
+	return h.Sum32()
+}
+
+// profileInterfaceName returns the default WireGuard interface of the profile
+func profileInterfaceName(profile string) string {
+	if profile == "" {
+		return iface.WgInterfaceDefault
+	}
+	if runtime.GOOS == "darwin" {
+		// the interfaces are named by the system on macOS, utun100 being the one of the default profile
+		return "utun" + strconv.Itoa(101+int(profileHash(profile)%profilePortRange))
+	}
+	return "wt-" + profile
+}
+
+// profileWgPort returns the default WireGuard port of the profile: a free UDP port above iface.DefaultWgPort,
+// starting from a port derived from the name of the profile
+func profileWgPort(profile string) int {
+	if profile == "" {
+		return iface.DefaultWgPort
+	}
+
+	start := int(profileHash(profile) % profilePortRange)
+	for i := 0; i < profilePortRange; i++ {
+		port := iface.DefaultWgPort + 1 + (start+i)%profilePortRange
+		if udpPortFree(port) {
+			return port
+		}
+	}
+	return iface.DefaultWgPort + 1 + start
+}
+
+// isolateProfile keeps the routes and the firewall rules of a profile apart from the ones of the default client.
+// The routes go through the interface of the profile instead of the routing table and fwmark of NetBird, and the
+// nftables rules into the table netbird-<profile> instead of the netbird or the fw4 table
+func isolateProfile(profile string) {
+	if profile == "" {
+		return
+	}
+	log.Infof("running as profile %s", profile)
+	nbnet.SetCustomRoutingDisabled(true)
+	firewall.SetProfile(profile)
+}
+
+func udpPortFree(port int) bool {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{Port: port})
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
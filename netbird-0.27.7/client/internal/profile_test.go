@@ -0,0 +1,33 @@
+package internal
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/iface"
+)
+
+func TestValidateProfileName(t *testing.T) {
+	for _, name := range []string{"work", "home_2", "Lab"} {
+		require.NoError(t, ValidateProfileName(name), name)
+	}
+	for _, name := range []string{"", "main", "my-work", "../etc", "averylongprofile"} {
+		require.Error(t, ValidateProfileName(name), name)
+	}
+}
+
+func TestProfileWgPort(t *testing.T) {
+	require.Equal(t, iface.DefaultWgPort, profileWgPort(""))
+
+	port := profileWgPort("work")
+	require.Greater(t, port, iface.DefaultWgPort)
+	require.LessOrEqual(t, port, iface.DefaultWgPort+profilePortRange)
+
+	// the port in use is skipped
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{Port: port})
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NotEqual(t, port, profileWgPort("work"))
+}
@@ -0,0 +1,206 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/pion/stun/v2"
+	log "github.com/sirupsen/logrus"
+
+	nbnet "github.com/netbirdio/netbird/util/net"
+)
+
+// NATType classifies how the NAT in front of the peer maps its UDP sockets. It explains why the connections to some
+// peers are always relayed: two peers behind symmetric NATs can't connect directly
+type NATType string
+
+const (
+	// NATTypeUnknown is reported when the mappings couldn't be compared, e.g. when a single STUN server address answered
+	NATTypeUnknown NATType = "unknown"
+	// NATTypeNone is reported when the STUN servers see a local address of the peer
+	NATTypeNone NATType = "none"
+	// NATTypeEndpointIndependent is reported when the NAT maps a socket to the same address for all destinations
+	NATTypeEndpointIndependent NATType = "endpoint_independent"
+	// NATTypeSymmetric is reported when the NAT maps a socket to a different address for each destination
+	NATTypeSymmetric NATType = "symmetric"
+	// NATTypeUDPBlocked is reported when no STUN server answered
+	NATTypeUDPBlocked NATType = "udp_blocked"
+)
+
+const (
+	natBindingTimeout  = 2 * time.Second
+	natBindingAttempts = 2
+)
+
+// DetectNATType classifies the NAT of the peer by comparing the addresses two STUN servers see a single socket
+// from, the mapping behavior of RFC 5780. The second server is the OTHER-ADDRESS of the first one when it
+// advertises one, otherwise another address of the given servers
+func DetectNATType(ctx context.Context, uris []*stun.URI) NATType {
+	servers := resolveSTUNServers(ctx, uris)
+	if len(servers) == 0 {
+		log.Debugf("no STUN server to detect the NAT type with")
+		return NATTypeUnknown
+	}
+
+	conn, err := nbnet.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		log.Debugf("failed to listen for the NAT type detection: %v", err)
+		return NATTypeUnknown
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log.Debugf("failed to close the NAT type detection socket: %v", err)
+		}
+	}()
+
+	var mappings []netip.AddrPort
+	queried := make(map[netip.Addr]struct{})
+	for len(servers) > 0 && len(mappings) < 2 && ctx.Err() == nil {
+		server := servers[0]
+		servers = servers[1:]
+		// the mapping is only compared between servers with different addresses
+		if _, ok := queried[server.Addr()]; ok {
+			continue
+		}
+		queried[server.Addr()] = struct{}{}
+
+		mapped, other, err := bindingRequest(ctx, conn, server)
+		if err != nil {
+			log.Debugf("NAT type detection binding request to %s failed: %v", server, err)
+			continue
+		}
+		mappings = append(mappings, mapped)
+		if other.IsValid() {
+			servers = append([]netip.AddrPort{other}, servers...)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return NATTypeUnknown
+	}
+	return classifyNAT(localAddresses(), mappings)
+}
+
+// classifyNAT returns the NAT type of the addresses the STUN servers saw the same socket from
+func classifyNAT(local []netip.Addr, mappings []netip.AddrPort) NATType {
+	switch {
+	case len(mappings) == 0:
+		return NATTypeUDPBlocked
+	case slices.Contains(local, mappings[0].Addr()):
+		return NATTypeNone
+	case len(mappings) == 1:
+		return NATTypeUnknown
+	case mappings[0] == mappings[1]:
+		return NATTypeEndpointIndependent
+	default:
+		return NATTypeSymmetric
+	}
+}
+
+// resolveSTUNServers returns the IPv4 addresses of the STUN servers reached over UDP
+func resolveSTUNServers(ctx context.Context, uris []*stun.URI) []netip.AddrPort {
+	var servers []netip.AddrPort
+	for _, uri := range uris {
+		if uri.Scheme != stun.SchemeTypeSTUN || uri.Proto != stun.ProtoTypeUDP {
+			continue
+		}
+
+		addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip4", uri.Host)
+		if err != nil {
+			log.Debugf("failed to resolve STUN server %s: %v", uri.Host, err)
+			continue
+		}
+		for _, addr := range addrs {
+			servers = append(servers, netip.AddrPortFrom(addr.Unmap(), uint16(uri.Port)))
+		}
+	}
+	return servers
+}
+
+// bindingRequest returns the address the server sees the socket from and the OTHER-ADDRESS the server advertises
+func bindingRequest(ctx context.Context, conn net.PacketConn, server netip.AddrPort) (mapped, other netip.AddrPort, err error) {
+	request, err := stun.Build(stun.TransactionID, stun.BindingRequest)
+	if err != nil {
+		return mapped, other, fmt.Errorf("build binding request: %w", err)
+	}
+
+	buf := make([]byte, 1500)
+	for attempt := 0; attempt < natBindingAttempts && ctx.Err() == nil; attempt++ {
+		if _, err := conn.WriteTo(request.Raw, net.UDPAddrFromAddrPort(server)); err != nil {
+			return mapped, other, fmt.Errorf("send binding request: %w", err)
+		}
+		if err := conn.SetReadDeadline(time.Now().Add(natBindingTimeout)); err != nil {
+			return mapped, other, fmt.Errorf("set read deadline: %w", err)
+		}
+
+		response, err := readBindingResponse(conn, buf, request.TransactionID)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			continue
+		}
+		if err != nil {
+			return mapped, other, err
+		}
+
+		var xorAddr stun.XORMappedAddress
+		if err := xorAddr.GetFrom(response); err != nil {
+			return mapped, other, fmt.Errorf("get mapped address: %w", err)
+		}
+		mapped = toAddrPort(xorAddr.IP, xorAddr.Port)
+
+		var otherAddr stun.OtherAddress
+		if err := otherAddr.GetFrom(response); err == nil {
+			other = toAddrPort(otherAddr.IP, otherAddr.Port)
+		}
+		return mapped, other, nil
+	}
+	return mapped, other, fmt.Errorf("no binding response from %s", server)
+}
+
+// readBindingResponse reads the response of the binding request, skipping the late responses of the previous ones
+func readBindingResponse(conn net.PacketConn, buf []byte, transactionID [stun.TransactionIDSize]byte) (*stun.Message, error) {
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		response := &stun.Message{Raw: append([]byte{}, buf[:n]...)}
+		if err := response.Decode(); err != nil || response.TransactionID != transactionID {
+			continue
+		}
+		return response, nil
+	}
+}
+
+func toAddrPort(ip net.IP, port int) netip.AddrPort {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return netip.AddrPort{}
+	}
+	return netip.AddrPortFrom(addr.Unmap(), uint16(port))
+}
+
+// localAddresses returns the addresses of the interfaces of the system
+func localAddresses() []netip.Addr {
+	ifaceAddrs, err := net.InterfaceAddrs()
+	if err != nil {
+		log.Debugf("failed to get the interface addresses: %v", err)
+		return nil
+	}
+
+	addrs := make([]netip.Addr, 0, len(ifaceAddrs))
+	for _, ifaceAddr := range ifaceAddrs {
+		prefix, err := netip.ParsePrefix(ifaceAddr.String())
+		if err != nil {
+			continue
+		}
+		addrs = append(addrs, prefix.Addr().Unmap())
+	}
+	return addrs
+}
@@ -0,0 +1,91 @@
+package relay
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/pion/stun/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyNAT(t *testing.T) {
+	local := []netip.Addr{netip.MustParseAddr("192.168.1.10")}
+	first := netip.MustParseAddrPort("203.0.113.1:40000")
+	second := netip.MustParseAddrPort("203.0.113.1:40001")
+
+	tests := []struct {
+		name     string
+		mappings []netip.AddrPort
+		expected NATType
+	}{
+		{name: "no answer", expected: NATTypeUDPBlocked},
+		{name: "local address", mappings: []netip.AddrPort{netip.MustParseAddrPort("192.168.1.10:51820")}, expected: NATTypeNone},
+		{name: "single server", mappings: []netip.AddrPort{first}, expected: NATTypeUnknown},
+		{name: "same mapping", mappings: []netip.AddrPort{first, first}, expected: NATTypeEndpointIndependent},
+		{name: "new mapping per destination", mappings: []netip.AddrPort{first, second}, expected: NATTypeSymmetric},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, classifyNAT(local, tt.mappings))
+		})
+	}
+}
+
+func TestDetectNATType(t *testing.T) {
+	second := startSTUNServer(t, nil)
+	first := startSTUNServer(t, second.LocalAddr().(*net.UDPAddr))
+
+	uri, err := stun.ParseURI(fmt.Sprintf("stun:127.0.0.1:%d", first.LocalAddr().(*net.UDPAddr).Port))
+	require.NoError(t, err)
+
+	// the servers see the loopback address of the socket
+	assert.Equal(t, NATTypeNone, DetectNATType(context.Background(), []*stun.URI{uri}))
+}
+
+// startSTUNServer starts a STUN server answering binding requests with the address of the client and the other
+// address, if any
+func startSTUNServer(t *testing.T, other *net.UDPAddr) net.PacketConn {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			request := &stun.Message{Raw: buf[:n]}
+			if err := request.Decode(); err != nil {
+				continue
+			}
+
+			udpAddr := addr.(*net.UDPAddr)
+			setters := []stun.Setter{
+				stun.NewTransactionIDSetter(request.TransactionID),
+				stun.BindingSuccess,
+				&stun.XORMappedAddress{IP: udpAddr.IP, Port: udpAddr.Port},
+			}
+			if other != nil {
+				setters = append(setters, &stun.OtherAddress{IP: other.IP, Port: other.Port})
+			}
+			response, err := stun.Build(setters...)
+			if err != nil {
+				continue
+			}
+			_, _ = conn.WriteTo(response.Raw, addr)
+		}
+	}()
+
+	return conn
+}
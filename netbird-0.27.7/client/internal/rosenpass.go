@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"bytes"
+
+	log "github.com/sirupsen/logrus"
+
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+	sProto "github.com/netbirdio/netbird/signal/proto"
+)
+
+// RosenpassConfig is a setting of the post-quantum Rosenpass handshake run alongside WireGuard
+type RosenpassConfig struct {
+	Enabled bool
+	// Permissive keeps the connections to the peers without Rosenpass working without the post-quantum key
+	Permissive bool
+}
+
+// toRosenpassConfig converts the Rosenpass setting pushed by the management service
+func toRosenpassConfig(conf *mgmProto.RosenpassConfig) RosenpassConfig {
+	return RosenpassConfig{
+		Enabled:    conf.GetEnabled(),
+		Permissive: conf.GetPermissive(),
+	}
+}
+
+// targetRosenpass returns the Rosenpass setting of the engine. Rosenpass runs when it is enabled locally or by the
+// management service, and in strict mode when any of the settings enabling it is strict
+func (e *Engine) targetRosenpass() RosenpassConfig {
+	local := RosenpassConfig{Enabled: e.config.RosenpassEnabled, Permissive: e.config.RosenpassPermissive}
+	mgm := e.config.ManagementRosenpass
+
+	switch {
+	case local.Enabled && mgm.Enabled:
+		return RosenpassConfig{Enabled: true, Permissive: local.Permissive && mgm.Permissive}
+	case mgm.Enabled:
+		return mgm
+	case local.Enabled:
+		return local
+	default:
+		return RosenpassConfig{}
+	}
+}
+
+// onManagementRosenpassChange restarts the engine when the Rosenpass setting pushed by the management service changes
+// the applied one, as the Rosenpass server and the preshared keys of the connections are set up on start
+func (e *Engine) onManagementRosenpassChange(rosenpass RosenpassConfig) {
+	log.Debugf("management Rosenpass setting changed from %+v to %+v", e.config.ManagementRosenpass, rosenpass)
+	e.config.ManagementRosenpass = rosenpass
+
+	if e.targetRosenpass() == e.rosenpass {
+		return
+	}
+
+	log.Infof("Rosenpass setting changed, restarting engine")
+	e.restart()
+}
+
+// updateRemoteRosenpassKeys stores the Rosenpass public keys of the remote peers distributed by the management service
+func (e *Engine) updateRemoteRosenpassKeys(remotePeers []*mgmProto.RemotePeerConfig) {
+	keys := make(map[string][]byte)
+	for _, p := range remotePeers {
+		if len(p.GetRosenpassPubKey()) > 0 {
+			keys[p.GetWgPubKey()] = p.GetRosenpassPubKey()
+		}
+	}
+	e.remoteRosenpassKeys = keys
+}
+
+// remoteRosenpassConfig returns the Rosenpass public key and server address the remote peer sent with its offer or
+// answer. A key differing from the one the management service distributed is reported, as the signal service only
+// relays the messages
+func (e *Engine) remoteRosenpassConfig(msg *sProto.Message) ([]byte, string) {
+	rosenpassConfig := msg.GetBody().GetRosenpassConfig()
+	if rosenpassConfig == nil {
+		return nil, ""
+	}
+
+	mgmKey := e.remoteRosenpassKeys[msg.GetKey()]
+	signalKey := rosenpassConfig.GetRosenpassPubKey()
+	if mgmKey != nil && signalKey != nil && !bytes.Equal(mgmKey, signalKey) {
+		log.Warnf("the Rosenpass public key of peer %s received through the Signal service differs from the one "+
+			"distributed by the Management service", msg.GetKey())
+	}
+	return signalKey, rosenpassConfig.GetRosenpassServerAddr()
+}
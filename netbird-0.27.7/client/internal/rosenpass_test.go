@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+	sProto "github.com/netbirdio/netbird/signal/proto"
+)
+
+func TestEngine_targetRosenpass(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   EngineConfig
+		expected RosenpassConfig
+	}{
+		{
+			name:     "disabled",
+			expected: RosenpassConfig{},
+		},
+		{
+			name:     "enabled locally",
+			config:   EngineConfig{RosenpassEnabled: true, RosenpassPermissive: true},
+			expected: RosenpassConfig{Enabled: true, Permissive: true},
+		},
+		{
+			name:     "enabled by the management",
+			config:   EngineConfig{ManagementRosenpass: RosenpassConfig{Enabled: true}},
+			expected: RosenpassConfig{Enabled: true},
+		},
+		{
+			name: "strict mode wins",
+			config: EngineConfig{
+				RosenpassEnabled:    true,
+				RosenpassPermissive: true,
+				ManagementRosenpass: RosenpassConfig{Enabled: true},
+			},
+			expected: RosenpassConfig{Enabled: true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			engine := &Engine{config: &tc.config}
+			assert.Equal(t, tc.expected, engine.targetRosenpass())
+		})
+	}
+}
+
+func TestEngine_remoteRosenpassConfig(t *testing.T) {
+	engine := &Engine{}
+	engine.updateRemoteRosenpassKeys([]*mgmProto.RemotePeerConfig{
+		{WgPubKey: "peerA", RosenpassPubKey: []byte("keyA")},
+		{WgPubKey: "peerB"},
+	})
+	assert.Equal(t, map[string][]byte{"peerA": []byte("keyA")}, engine.remoteRosenpassKeys)
+
+	key, addr := engine.remoteRosenpassConfig(&sProto.Message{Key: "peerA", Body: &sProto.Body{
+		RosenpassConfig: &sProto.RosenpassConfig{RosenpassPubKey: []byte("keyA"), RosenpassServerAddr: ":7000"},
+	}})
+	assert.Equal(t, []byte("keyA"), key)
+	assert.Equal(t, ":7000", addr)
+
+	key, addr = engine.remoteRosenpassConfig(&sProto.Message{Key: "peerA", Body: &sProto.Body{}})
+	assert.Nil(t, key, "the distributed key shouldn't be used when the peer sent no Rosenpass config")
+	assert.Empty(t, addr)
+}
@@ -7,6 +7,7 @@ import (
 	"net/netip"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/netbirdio/netbird/client/internal/peer"
@@ -38,11 +39,19 @@ type clientNetwork struct {
 	peerStateUpdate     chan struct{}
 	routePeersNotifiers map[string]chan struct{}
 	chosenRoute         *route.Route
-	network             netip.Prefix
-	updateSerial        uint64
+	// ecmpPaths holds the routing peer of each default route slice while the network is spread across several peers
+	ecmpPaths map[netip.Prefix]*route.Route
+	// network is the routed prefix, it is not valid for dynamic routes
+	network      netip.Prefix
+	handler      routeHandler
+	updateSerial uint64
+	// retryTimer, retryBackOff and retryAttempts track the retries of a failed route installation
+	retryTimer    *time.Timer
+	retryBackOff  backoff.BackOff
+	retryAttempts int
 }
 
-func newClientNetworkWatcher(ctx context.Context, wgInterface *iface.WGIface, statusRecorder *peer.Status, network netip.Prefix) *clientNetwork {
+func newClientNetworkWatcher(ctx context.Context, wgInterface *iface.WGIface, statusRecorder *peer.Status, rt *route.Route) *clientNetwork {
 	ctx, cancel := context.WithCancel(ctx)
 
 	client := &clientNetwork{
@@ -54,7 +63,8 @@ func newClientNetworkWatcher(ctx context.Context, wgInterface *iface.WGIface, st
 		routePeersNotifiers: make(map[string]chan struct{}),
 		routeUpdate:         make(chan routesUpdate),
 		peerStateUpdate:     make(chan struct{}),
-		network:             network,
+		network:             rt.Network,
+		handler:             newRouteHandler(rt, wgInterface),
 	}
 	return client
 }
@@ -151,7 +161,7 @@ func (c *clientNetwork) getBestRouteFromStatuses(routePeerStatuses map[route.ID]
 			peers = append(peers, r.Peer)
 		}
 
-		log.Warnf("the network %s has not been assigned a routing peer as no peers from the list %s are currently connected", c.network, peers)
+		log.Warnf("the network %s has not been assigned a routing peer as no peers from the list %s are currently connected", c.handler, peers)
 	case chosen != currID:
 		// we compare the current score + 10ms to the chosen score to avoid flapping between routes
 		if currScore != 0 && currScore+0.01 > chosenScore {
@@ -162,7 +172,7 @@ func (c *clientNetwork) getBestRouteFromStatuses(routePeerStatuses map[route.ID]
 		if rt := c.routes[chosen]; rt != nil {
 			p = rt.Peer
 		}
-		log.Infof("new chosen route is %s with peer %s with score %f for network %s", chosen, p, chosenScore, c.network)
+		log.Infof("new chosen route is %s with peer %s with score %f for network %s", chosen, p, chosenScore, c.handler)
 	}
 
 	return chosen
@@ -202,7 +212,7 @@ func (c *clientNetwork) removeRouteFromWireguardPeer(peerKey string) error {
 		return fmt.Errorf("get peer state: %v", err)
 	}
 
-	state.DeleteRoute(c.network.String())
+	state.DeleteRoute(c.handler.String())
 	if err := c.statusRecorder.UpdatePeerState(state); err != nil {
 		log.Warnf("Failed to update peer state: %v", err)
 	}
@@ -211,30 +221,73 @@ func (c *clientNetwork) removeRouteFromWireguardPeer(peerKey string) error {
 		return nil
 	}
 
-	err = c.wgInterface.RemoveAllowedIP(peerKey, c.network.String())
-	if err != nil {
-		return fmt.Errorf("remove allowed IP %s removed for peer %s, err: %v",
-			c.network, c.chosenRoute.Peer, err)
+	if err := c.handler.RemoveAllowedIPs(peerKey); err != nil {
+		return fmt.Errorf("remove allowed IPs of %s for peer %s, err: %v",
+			c.handler, c.chosenRoute.Peer, err)
 	}
 	return nil
 }
 
 func (c *clientNetwork) removeRouteFromPeerAndSystem() error {
-	if c.chosenRoute != nil {
-		if err := removeVPNRoute(c.network, c.getAsInterface()); err != nil {
-			return fmt.Errorf("remove route %s from system, err: %v", c.network, err)
+	if len(c.ecmpPaths) > 0 {
+		c.removeECMPPaths()
+
+		if err := c.handler.RemoveRoute(); err != nil {
+			return fmt.Errorf("remove route %s from system, err: %v", c.handler, err)
 		}
+	}
 
+	if c.chosenRoute != nil {
 		if err := c.removeRouteFromWireguardPeer(c.chosenRoute.Peer); err != nil {
 			return fmt.Errorf("remove route: %v", err)
 		}
+
+		if err := c.handler.RemoveRoute(); err != nil {
+			return fmt.Errorf("remove route %s from system, err: %v", c.handler, err)
+		}
 	}
+	c.statusRecorder.RemoveRouteConflict(c.network)
 	return nil
 }
 
+// updateRouteConflict checks the local routing table for routes that would shadow the network
+// and records the result in the status recorder, so it can be reported to the management service
+func (c *clientNetwork) updateRouteConflict(netID route.NetID) {
+	if !c.network.IsValid() {
+		return
+	}
+
+	conflict, err := findRouteConflict(c.network)
+	if err != nil {
+		log.Warnf("Failed to check route %s for conflicts: %v", c.network, err)
+		return
+	}
+
+	if conflict == nil {
+		c.statusRecorder.RemoveRouteConflict(c.network)
+		return
+	}
+
+	conflict.NetID = string(netID)
+	log.Warnf("Route %s conflicts with local route %s (%s), traffic might not be routed through the tunnel",
+		c.network, conflict.ConflictsWith, conflict.Reason)
+	c.statusRecorder.SetRouteConflict(*conflict)
+}
+
 func (c *clientNetwork) recalculateRouteAndUpdatePeerAndSystem() error {
 	routerPeerStatuses := c.getRouterPeerStatuses()
 
+	if paths := c.getECMPPaths(routerPeerStatuses); len(paths) > 0 {
+		return c.updateECMPPaths(paths)
+	}
+
+	if len(c.ecmpPaths) > 0 {
+		// less than two weighted routing peers are available, fall back to a single one
+		if err := c.removeRouteFromPeerAndSystem(); err != nil {
+			return fmt.Errorf("remove route from peer and system: %v", err)
+		}
+	}
+
 	chosen := c.getBestRouteFromStatuses(routerPeerStatuses)
 
 	// If no route is chosen, remove the route from the peer and system
@@ -262,9 +315,11 @@ func (c *clientNetwork) recalculateRouteAndUpdatePeerAndSystem() error {
 		}
 	} else {
 		// otherwise add the route to the system
-		if err := addVPNRoute(c.network, c.getAsInterface()); err != nil {
+		c.updateRouteConflict(c.routes[chosen].NetID)
+
+		if err := c.handler.AddRoute(c.ctx); err != nil {
 			return fmt.Errorf("route %s couldn't be added for peer %s, err: %v",
-				c.network.String(), c.wgInterface.Address().IP.String(), err)
+				c.handler, c.wgInterface.Address().IP.String(), err)
 		}
 	}
 
@@ -274,15 +329,15 @@ func (c *clientNetwork) recalculateRouteAndUpdatePeerAndSystem() error {
 	if err != nil {
 		log.Errorf("Failed to get peer state: %v", err)
 	} else {
-		state.AddRoute(c.network.String())
+		state.AddRoute(c.handler.String())
 		if err := c.statusRecorder.UpdatePeerState(state); err != nil {
 			log.Warnf("Failed to update peer state: %v", err)
 		}
 	}
 
-	if err := c.wgInterface.AddAllowedIP(c.chosenRoute.Peer, c.network.String()); err != nil {
-		log.Errorf("couldn't add allowed IP %s added for peer %s, err: %v",
-			c.network, c.chosenRoute.Peer, err)
+	if err := c.handler.AddAllowedIPs(c.chosenRoute.Peer); err != nil {
+		log.Errorf("couldn't add allowed IPs of %s for peer %s, err: %v",
+			c.handler, c.chosenRoute.Peer, err)
 	}
 
 	return nil
@@ -318,45 +373,51 @@ func (c *clientNetwork) peersStateAndUpdateWatcher() {
 	for {
 		select {
 		case <-c.ctx.Done():
-			log.Debugf("stopping watcher for network %s", c.network)
+			log.Debugf("stopping watcher for network %s", c.handler)
+			c.stopRetry()
 			err := c.removeRouteFromPeerAndSystem()
 			if err != nil {
-				log.Errorf("Couldn't remove route from peer and system for network %s: %v", c.network, err)
+				log.Errorf("Couldn't remove route from peer and system for network %s: %v", c.handler, err)
 			}
 			return
 		case <-c.peerStateUpdate:
-			err := c.recalculateRouteAndUpdatePeerAndSystem()
-			if err != nil {
-				log.Errorf("Couldn't recalculate route and update peer and system: %v", err)
-			}
+			c.handleRecalculateResult(c.recalculateRouteAndUpdatePeerAndSystem())
+		case <-c.retryC():
+			c.retryTimer = nil
+			c.handleRecalculateResult(c.recalculateRouteAndUpdatePeerAndSystem())
 		case update := <-c.routeUpdate:
 			if update.updateSerial < c.updateSerial {
 				log.Warnf("Received a routes update with smaller serial number, ignoring it")
 				continue
 			}
 
-			log.Debugf("Received a new client network route update for %s", c.network)
+			log.Debugf("Received a new client network route update for %s", c.handler)
 
 			c.handleUpdate(update)
 
 			c.updateSerial = update.updateSerial
 
-			err := c.recalculateRouteAndUpdatePeerAndSystem()
-			if err != nil {
-				log.Errorf("Couldn't recalculate route and update peer and system for network %s: %v", c.network, err)
-			}
+			c.handleRecalculateResult(c.recalculateRouteAndUpdatePeerAndSystem())
 
 			c.startPeersStatusChangeWatcher()
 		}
 	}
 }
 
-func (c *clientNetwork) getAsInterface() *net.Interface {
-	intf, err := net.InterfaceByName(c.wgInterface.Name())
+// netID returns the network identifier of the routes of the client network
+func (c *clientNetwork) netID() route.NetID {
+	for _, r := range c.routes {
+		return r.NetID
+	}
+	return ""
+}
+
+func getVPNInterface(wgInterface *iface.WGIface) *net.Interface {
+	intf, err := net.InterfaceByName(wgInterface.Name())
 	if err != nil {
-		log.Warnf("Couldn't get interface by name %s: %v", c.wgInterface.Name(), err)
+		log.Warnf("Couldn't get interface by name %s: %v", wgInterface.Name(), err)
 		intf = &net.Interface{
-			Name: c.wgInterface.Name(),
+			Name: wgInterface.Name(),
 		}
 	}
 
@@ -341,6 +341,7 @@ func TestGetBestrouteFromStatuses(t *testing.T) {
 			// create new clientNetwork
 			client := &clientNetwork{
 				network:     netip.MustParsePrefix("192.168.0.0/24"),
+				handler:     &staticRoute{prefix: netip.MustParsePrefix("192.168.0.0/24")},
 				routes:      tc.existingRoutes,
 				chosenRoute: currentRoute,
 			}
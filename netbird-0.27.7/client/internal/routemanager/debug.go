@@ -0,0 +1,110 @@
+package routemanager
+
+import (
+	"net/netip"
+	"os"
+	"sort"
+	"sync/atomic"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+	"github.com/netbirdio/netbird/route"
+)
+
+// DebugInfo is a snapshot of the routing state of the client
+type DebugInfo struct {
+	// RoutingTable holds the prefixes of the system routing table NetBird checks routes against
+	RoutingTable []netip.Prefix
+	// NextHops holds the next hops resolved for the default destinations
+	NextHops []NextHop
+	// ExclusionRoutes holds the routes added to keep the connections of the client out of the tunnel
+	ExclusionRoutes []ExclusionRoute
+	// RouteCacheDisabled is true if the routing table cache is disabled with NB_DISABLE_ROUTE_CACHE or the low memory profile
+	RouteCacheDisabled bool
+	Networks           []NetworkInfo
+	SkippedRoutes      []SkippedRoute
+	RouteConflicts     []peer.RouteConflict
+	// Errors holds the errors encountered while collecting the information
+	Errors []string
+}
+
+// NextHop is the next hop and interface the system uses for a destination
+type NextHop struct {
+	Destination netip.Addr
+	NextHop     netip.Addr
+	Interface   string
+	Error       string
+}
+
+// ExclusionRoute is a route through the physical interface for an address the client connects to
+type ExclusionRoute struct {
+	Prefix     netip.Prefix
+	NextHop    netip.Addr
+	Interface  string
+	References int
+}
+
+// NetworkInfo is a client network and the routing peers it is currently routed through
+type NetworkInfo struct {
+	ID           route.HAUniqueID
+	Network      string
+	RoutingPeers []string
+}
+
+// SkippedRoute is a route received from the management service that is not applied by this client
+type SkippedRoute struct {
+	NetID   route.NetID
+	Network string
+	Reason  string
+}
+
+// GetDebugInfo returns the routing state of the client
+func (m *DefaultManager) GetDebugInfo() DebugInfo {
+	info := DebugInfo{
+		RouteCacheDisabled: isCacheDisabled(),
+		RouteConflicts:     m.statusRecorder.GetRouteConflicts(),
+	}
+
+	getSystemDebugInfo(&info)
+
+	fullStatus := m.statusRecorder.GetFullStatus()
+
+	m.mux.Lock()
+	for id, client := range m.clientNetworks {
+		network := client.handler.String()
+		netInfo := NetworkInfo{
+			ID:      id,
+			Network: network,
+		}
+		for _, state := range fullStatus.Peers {
+			if _, ok := state.GetRoutes()[network]; !ok {
+				continue
+			}
+			name := state.FQDN
+			if name == "" {
+				name = state.PubKey
+			}
+			netInfo.RoutingPeers = append(netInfo.RoutingPeers, name)
+		}
+		sort.Strings(netInfo.RoutingPeers)
+		info.Networks = append(info.Networks, netInfo)
+	}
+	info.SkippedRoutes = append(info.SkippedRoutes, m.skippedRoutes...)
+	m.mux.Unlock()
+
+	sort.Slice(info.Networks, func(i, j int) bool {
+		return info.Networks[i].ID < info.Networks[j].ID
+	})
+
+	return info
+}
+
+var routeCacheDisabled atomic.Bool
+
+// SetRouteCacheDisabled disables the cache of the routing table, e.g. in the low memory profile
+func SetRouteCacheDisabled(disabled bool) {
+	routeCacheDisabled.Store(disabled)
+}
+
+func isCacheDisabled() bool {
+	return routeCacheDisabled.Load() || os.Getenv("NB_DISABLE_ROUTE_CACHE") == "true"
+}
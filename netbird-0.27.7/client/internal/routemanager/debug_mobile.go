@@ -0,0 +1,7 @@
+//go:build android || ios
+
+package routemanager
+
+// getSystemDebugInfo is a no-op on mobile systems, the routes are managed by the OS
+func getSystemDebugInfo(*DebugInfo) {
+}
@@ -0,0 +1,34 @@
+//go:build !android && !ios
+
+package routemanager
+
+import (
+	"fmt"
+	"net/netip"
+)
+
+func getSystemDebugInfo(info *DebugInfo) {
+	table, err := getRoutesFromTable()
+	if err != nil {
+		info.Errors = append(info.Errors, fmt.Sprintf("get routing table: %v", err))
+	}
+	info.RoutingTable = table
+
+	for _, destination := range []netip.Addr{netip.IPv4Unspecified(), netip.IPv6Unspecified()} {
+		nextHop := NextHop{Destination: destination}
+		addr, intf, err := GetNextHop(destination)
+		if err != nil {
+			nextHop.Error = err.Error()
+		} else {
+			nextHop.NextHop = addr
+			if intf != nil {
+				nextHop.Interface = intf.Name
+			}
+		}
+		info.NextHops = append(info.NextHops, nextHop)
+	}
+
+	if routeManager != nil {
+		info.ExclusionRoutes = routeManager.References()
+	}
+}
@@ -0,0 +1,39 @@
+//go:build !android && !ios
+
+package routemanager
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteManagerReferences(t *testing.T) {
+	nexthop := netip.MustParseAddr("192.168.0.1")
+	intf := &net.Interface{Name: "eth0"}
+	rm := NewRouteManager(
+		func(netip.Prefix) (netip.Addr, *net.Interface, error) {
+			return nexthop, intf, nil
+		},
+		func(netip.Prefix, netip.Addr, *net.Interface) error {
+			return nil
+		},
+	)
+
+	require.NoError(t, rm.AddRouteRef("conn1", netip.MustParsePrefix("10.0.0.1/32")))
+	require.NoError(t, rm.AddRouteRef("conn2", netip.MustParsePrefix("10.0.0.1/32")))
+	require.NoError(t, rm.AddRouteRef("conn2", netip.MustParsePrefix("10.0.0.2/32")))
+
+	assert.Equal(t, []ExclusionRoute{
+		{Prefix: netip.MustParsePrefix("10.0.0.1/32"), NextHop: nexthop, Interface: "eth0", References: 2},
+		{Prefix: netip.MustParsePrefix("10.0.0.2/32"), NextHop: nexthop, Interface: "eth0", References: 1},
+	}, rm.References())
+
+	require.NoError(t, rm.RemoveRouteRef("conn2"))
+	assert.Equal(t, []ExclusionRoute{
+		{Prefix: netip.MustParsePrefix("10.0.0.1/32"), NextHop: nexthop, Interface: "eth0", References: 1},
+	}, rm.References())
+}
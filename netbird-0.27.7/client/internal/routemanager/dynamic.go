@@ -0,0 +1,287 @@
+package routemanager
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/miekg/dns"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/iface"
+	"github.com/netbirdio/netbird/route"
+)
+
+const (
+	// dynamicRouteResolveInterval is the interval in which the domains of a dynamic route are resolved
+	dynamicRouteResolveInterval = time.Minute
+	// dynamicRouteResolveTimeout is the timeout of a single domain resolution
+	dynamicRouteResolveTimeout = 10 * time.Second
+	// dynamicRouteMinAddrTTL is the minimum time a resolved address stays routed after it was last seen
+	dynamicRouteMinAddrTTL = 5 * time.Minute
+)
+
+type resolveFunc func(ctx context.Context, network, host string) ([]netip.Addr, error)
+
+// dynamicRoute routes the addresses the domains of a route resolve to.
+// Plain domains are resolved periodically, wildcard domains are only learned from the DNS responses
+// passing through the local DNS server.
+type dynamicRoute struct {
+	route       *route.Route
+	wgInterface *iface.WGIface
+	resolve     resolveFunc
+	// addRoute and removeRoute default to the VPN route functions of the system
+	addRoute    func(prefix netip.Prefix) error
+	removeRoute func(prefix netip.Prefix) error
+
+	mux sync.Mutex
+	// prefixes holds the routed host prefixes and the time they expire
+	prefixes map[netip.Prefix]time.Time
+	peerKey  string
+	cancel   context.CancelFunc
+}
+
+func newDynamicRoute(rt *route.Route, wgInterface *iface.WGIface) *dynamicRoute {
+	d := &dynamicRoute{
+		route:       rt,
+		wgInterface: wgInterface,
+		resolve:     net.DefaultResolver.LookupNetIP,
+		prefixes:    make(map[netip.Prefix]time.Time),
+	}
+	d.addRoute = func(prefix netip.Prefix) error {
+		return addVPNRoute(prefix, getVPNInterface(d.wgInterface))
+	}
+	d.removeRoute = func(prefix netip.Prefix) error {
+		return removeVPNRoute(prefix, getVPNInterface(d.wgInterface))
+	}
+	return d
+}
+
+func (d *dynamicRoute) String() string {
+	return d.route.NetString()
+}
+
+// AddRoute starts resolving the route domains and routing the resulting addresses
+func (d *dynamicRoute) AddRoute(ctx context.Context) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if d.cancel != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	d.cancel = cancel
+	go d.watch(ctx)
+
+	return nil
+}
+
+// RemoveRoute stops resolving the route domains and removes the routes of all resolved addresses
+func (d *dynamicRoute) RemoveRoute() error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if d.cancel != nil {
+		d.cancel()
+		d.cancel = nil
+	}
+
+	var merr *multierror.Error
+	for prefix := range d.prefixes {
+		if err := d.removeRoute(prefix); err != nil {
+			merr = multierror.Append(merr, fmt.Errorf("remove route %s: %w", prefix, err))
+		}
+	}
+	d.prefixes = make(map[netip.Prefix]time.Time)
+	d.peerKey = ""
+
+	return merr.ErrorOrNil()
+}
+
+// AddAllowedIPs adds the resolved addresses to the allowed IPs of the peer, newly resolved addresses are added as well
+func (d *dynamicRoute) AddAllowedIPs(peerKey string) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	d.peerKey = peerKey
+
+	var merr *multierror.Error
+	for prefix := range d.prefixes {
+		if err := d.wgInterface.AddAllowedIP(peerKey, prefix.String()); err != nil {
+			merr = multierror.Append(merr, fmt.Errorf("add allowed IP %s: %w", prefix, err))
+		}
+	}
+
+	return merr.ErrorOrNil()
+}
+
+// RemoveAllowedIPs removes the resolved addresses from the allowed IPs of the peer
+func (d *dynamicRoute) RemoveAllowedIPs(peerKey string) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if d.peerKey == peerKey {
+		d.peerKey = ""
+	}
+
+	var merr *multierror.Error
+	for prefix := range d.prefixes {
+		if err := d.wgInterface.RemoveAllowedIP(peerKey, prefix.String()); err != nil {
+			merr = multierror.Append(merr, fmt.Errorf("remove allowed IP %s: %w", prefix, err))
+		}
+	}
+
+	return merr.ErrorOrNil()
+}
+
+// OnDNSResponse routes the addresses of a DNS response for a name matching one of the route domains
+func (d *dynamicRoute) OnDNSResponse(msg *dns.Msg) {
+	if msg == nil || len(msg.Question) == 0 {
+		return
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(msg.Question[0].Name, "."))
+	if !d.matches(name) {
+		return
+	}
+
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	// the route is not active
+	if d.cancel == nil {
+		return
+	}
+
+	now := time.Now()
+	for _, rr := range msg.Answer {
+		a, ok := rr.(*dns.A)
+		if !ok {
+			continue
+		}
+		addr, ok := netip.AddrFromSlice(a.A)
+		if !ok {
+			continue
+		}
+		ttl := time.Duration(a.Hdr.Ttl) * time.Second
+		d.addPrefix(netip.PrefixFrom(addr.Unmap(), 32), now.Add(max(ttl, dynamicRouteMinAddrTTL)))
+	}
+}
+
+// matches returns true if the name is one of the route domains or a subdomain of one of its wildcard domains
+func (d *dynamicRoute) matches(name string) bool {
+	for _, domain := range d.route.Domains {
+		if suffix, ok := strings.CutPrefix(domain, "*"); ok {
+			if strings.HasSuffix(name, suffix) {
+				return true
+			}
+			continue
+		}
+		if name == domain {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *dynamicRoute) watch(ctx context.Context) {
+	ticker := time.NewTicker(dynamicRouteResolveInterval)
+	defer ticker.Stop()
+
+	for {
+		d.resolveDomains(ctx)
+		d.removeExpired(time.Now())
+
+		select {
+		case <-ctx.Done():
+			log.Debugf("stopping resolving domains of route %s", d)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *dynamicRoute) resolveDomains(ctx context.Context) {
+	for _, domain := range d.route.Domains {
+		if strings.HasPrefix(domain, "*.") {
+			continue
+		}
+
+		resolveCtx, cancel := context.WithTimeout(ctx, dynamicRouteResolveTimeout)
+		// TODO: add ipv6
+		addrs, err := d.resolve(resolveCtx, "ip4", domain)
+		cancel()
+		if err != nil {
+			log.Debugf("Failed to resolve domain %s of route %s: %v", domain, d.route.NetID, err)
+			continue
+		}
+
+		d.mux.Lock()
+		// the route has been removed while resolving
+		if ctx.Err() != nil {
+			d.mux.Unlock()
+			return
+		}
+		expires := time.Now().Add(max(2*dynamicRouteResolveInterval, dynamicRouteMinAddrTTL))
+		for _, addr := range addrs {
+			d.addPrefix(netip.PrefixFrom(addr.Unmap(), 32), expires)
+		}
+		d.mux.Unlock()
+	}
+}
+
+// addPrefix routes a resolved address or extends its expiry if it is routed already. Must be called with the lock held.
+func (d *dynamicRoute) addPrefix(prefix netip.Prefix, expires time.Time) {
+	if current, ok := d.prefixes[prefix]; ok {
+		if expires.After(current) {
+			d.prefixes[prefix] = expires
+		}
+		return
+	}
+
+	log.Debugf("Adding route for %s resolved from the domains of route %s", prefix, d.route.NetID)
+	if err := d.addRoute(prefix); err != nil {
+		log.Warnf("Failed to add route for %s of route %s: %v", prefix, d.route.NetID, err)
+	}
+
+	if d.peerKey != "" {
+		if err := d.wgInterface.AddAllowedIP(d.peerKey, prefix.String()); err != nil {
+			log.Warnf("Failed to add allowed IP %s for peer %s: %v", prefix, d.peerKey, err)
+		}
+	}
+
+	d.prefixes[prefix] = expires
+}
+
+// removeExpired removes the routes of addresses that haven't been resolved for a while, unless the route keeps them
+func (d *dynamicRoute) removeExpired(now time.Time) {
+	if d.route.KeepRoute {
+		return
+	}
+
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	for prefix, expires := range d.prefixes {
+		if expires.After(now) {
+			continue
+		}
+
+		log.Debugf("Removing expired route for %s of route %s", prefix, d.route.NetID)
+		if d.peerKey != "" {
+			if err := d.wgInterface.RemoveAllowedIP(d.peerKey, prefix.String()); err != nil {
+				log.Warnf("Failed to remove allowed IP %s for peer %s: %v", prefix, d.peerKey, err)
+			}
+		}
+		if err := d.removeRoute(prefix); err != nil {
+			log.Warnf("Failed to remove route for %s of route %s: %v", prefix, d.route.NetID, err)
+		}
+		delete(d.prefixes, prefix)
+	}
+}
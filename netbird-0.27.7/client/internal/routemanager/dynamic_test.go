@@ -0,0 +1,120 @@
+package routemanager
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/route"
+)
+
+func newTestDynamicRoute(domains []string, keepRoute bool) (*dynamicRoute, map[netip.Prefix]bool) {
+	routed := make(map[netip.Prefix]bool)
+	d := &dynamicRoute{
+		route: &route.Route{
+			NetID:       "dynamic",
+			NetworkType: route.DomainNetwork,
+			Domains:     domains,
+			KeepRoute:   keepRoute,
+		},
+		prefixes: make(map[netip.Prefix]time.Time),
+		addRoute: func(prefix netip.Prefix) error {
+			routed[prefix] = true
+			return nil
+		},
+		removeRoute: func(prefix netip.Prefix) error {
+			delete(routed, prefix)
+			return nil
+		},
+	}
+	return d, routed
+}
+
+func newTestDNSResponse(name string, ips ...string) *dns.Msg {
+	msg := &dns.Msg{}
+	msg.SetQuestion(dns.Fqdn(name), dns.TypeA)
+	for _, ip := range ips {
+		msg.Answer = append(msg.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: dns.Fqdn(name), Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+			A:   net.ParseIP(ip),
+		})
+	}
+	return msg
+}
+
+func TestDynamicRouteMatches(t *testing.T) {
+	d, _ := newTestDynamicRoute([]string{"example.com", "*.example.org"}, false)
+
+	testCases := []struct {
+		name     string
+		expected bool
+	}{
+		{name: "example.com", expected: true},
+		{name: "www.example.com", expected: false},
+		{name: "example.org", expected: false},
+		{name: "www.example.org", expected: true},
+		{name: "a.b.example.org", expected: true},
+		{name: "badexample.org", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, d.matches(tc.name))
+		})
+	}
+}
+
+func TestDynamicRouteOnDNSResponse(t *testing.T) {
+	d, routed := newTestDynamicRoute([]string{"*.example.org"}, false)
+
+	// responses are ignored while the route is not active
+	d.OnDNSResponse(newTestDNSResponse("www.example.org", "10.0.0.1"))
+	assert.Empty(t, routed)
+
+	_, d.cancel = context.WithCancel(context.Background())
+
+	d.OnDNSResponse(newTestDNSResponse("www.example.org", "10.0.0.1", "10.0.0.2"))
+	d.OnDNSResponse(newTestDNSResponse("www.example.com", "10.0.0.3"))
+
+	assert.Equal(t, map[netip.Prefix]bool{
+		netip.MustParsePrefix("10.0.0.1/32"): true,
+		netip.MustParsePrefix("10.0.0.2/32"): true,
+	}, routed)
+
+	d.removeExpired(time.Now().Add(dynamicRouteMinAddrTTL + time.Second))
+	assert.Empty(t, routed)
+	assert.Empty(t, d.prefixes)
+}
+
+func TestDynamicRouteKeepRoute(t *testing.T) {
+	d, routed := newTestDynamicRoute([]string{"example.com"}, true)
+	_, d.cancel = context.WithCancel(context.Background())
+
+	d.OnDNSResponse(newTestDNSResponse("example.com", "10.0.0.1"))
+	d.removeExpired(time.Now().Add(24 * time.Hour))
+	assert.Len(t, routed, 1)
+
+	require.NoError(t, d.RemoveRoute())
+	assert.Empty(t, routed)
+	assert.Nil(t, d.cancel)
+}
+
+func TestDynamicRouteResolveDomains(t *testing.T) {
+	d, routed := newTestDynamicRoute([]string{"example.com", "*.example.org"}, false)
+	var resolved []string
+	d.resolve = func(_ context.Context, _, host string) ([]netip.Addr, error) {
+		resolved = append(resolved, host)
+		return []netip.Addr{netip.MustParseAddr("10.0.0.1")}, nil
+	}
+
+	d.resolveDomains(context.Background())
+
+	assert.Equal(t, []string{"example.com"}, resolved, "wildcard domains should not be resolved")
+	assert.Equal(t, map[netip.Prefix]bool{netip.MustParsePrefix("10.0.0.1/32"): true}, routed)
+}
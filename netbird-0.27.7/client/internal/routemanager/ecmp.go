@@ -0,0 +1,218 @@
+package routemanager
+
+import (
+	"fmt"
+	"net/netip"
+	"runtime"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/route"
+)
+
+// ecmpSliceBits is the prefix length of the slices the default route is split into for ECMP.
+// WireGuard selects the peer by destination address, so the traffic is spread by assigning the slices to different
+// routing peers, which keeps every destination on a single path.
+const ecmpSliceBits = 4
+
+var ecmpSlicesv4 = splitPrefix(defaultv4, ecmpSliceBits)
+
+// splitPrefix splits an IPv4 prefix into equal parts of the given length
+func splitPrefix(prefix netip.Prefix, bits int) []netip.Prefix {
+	count := 1 << (bits - prefix.Bits())
+	step := uint32(1) << (32 - bits)
+	base := prefix.Masked().Addr().As4()
+	start := uint32(base[0])<<24 | uint32(base[1])<<16 | uint32(base[2])<<8 | uint32(base[3])
+
+	prefixes := make([]netip.Prefix, 0, count)
+	for i := 0; i < count; i++ {
+		addr := start + uint32(i)*step
+		prefixes = append(prefixes, netip.PrefixFrom(netip.AddrFrom4([4]byte{byte(addr >> 24), byte(addr >> 16), byte(addr >> 8), byte(addr)}), bits))
+	}
+	return prefixes
+}
+
+// getECMPPaths returns the assignment of the default route slices to the connected routing peers with a weight.
+// It returns nil if ECMP doesn't apply to the network, in which case a single routing peer is chosen.
+func (c *clientNetwork) getECMPPaths(routePeerStatuses map[route.ID]routerPeerStatus) map[netip.Prefix]*route.Route {
+	if runtime.GOOS != "linux" || c.network != defaultv4 {
+		return nil
+	}
+
+	var candidates []*route.Route
+	for _, r := range c.routes {
+		if r.Weight <= 0 {
+			continue
+		}
+		if status, ok := routePeerStatuses[r.ID]; ok && status.connected {
+			candidates = append(candidates, r)
+		}
+	}
+
+	if len(candidates) < 2 {
+		return nil
+	}
+
+	return assignECMPSlices(ecmpSlicesv4, candidates, c.ecmpPaths)
+}
+
+// assignECMPSlices distributes the slices across the routes proportionally to their weights.
+// Slices keep their current route where possible, so that established connections aren't moved to another path.
+func assignECMPSlices(slices []netip.Prefix, routes []*route.Route, current map[netip.Prefix]*route.Route) map[netip.Prefix]*route.Route {
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].ID < routes[j].ID
+	})
+
+	totalWeight := 0
+	for _, r := range routes {
+		totalWeight += r.Weight
+	}
+
+	// largest remainder method, ties are resolved by the route order
+	quotas := make(map[route.ID]int, len(routes))
+	assigned := 0
+	for _, r := range routes {
+		quotas[r.ID] = len(slices) * r.Weight / totalWeight
+		assigned += quotas[r.ID]
+	}
+	byRemainder := make([]*route.Route, len(routes))
+	copy(byRemainder, routes)
+	sort.SliceStable(byRemainder, func(i, j int) bool {
+		return len(slices)*byRemainder[i].Weight%totalWeight > len(slices)*byRemainder[j].Weight%totalWeight
+	})
+	for i := 0; assigned < len(slices); i++ {
+		quotas[byRemainder[i%len(byRemainder)].ID]++
+		assigned++
+	}
+
+	paths := make(map[netip.Prefix]*route.Route, len(slices))
+	counts := make(map[route.ID]int, len(routes))
+	byID := make(map[route.ID]*route.Route, len(routes))
+	for _, r := range routes {
+		byID[r.ID] = r
+	}
+
+	for _, slice := range slices {
+		currentRoute, ok := current[slice]
+		if !ok {
+			continue
+		}
+		r, ok := byID[currentRoute.ID]
+		if !ok || counts[r.ID] >= quotas[r.ID] {
+			continue
+		}
+		paths[slice] = r
+		counts[r.ID]++
+	}
+
+	for _, slice := range slices {
+		if _, ok := paths[slice]; ok {
+			continue
+		}
+		for _, r := range routes {
+			if counts[r.ID] < quotas[r.ID] {
+				paths[slice] = r
+				counts[r.ID]++
+				break
+			}
+		}
+	}
+
+	return paths
+}
+
+// updateECMPPaths moves the default route slices to their assigned routing peers
+func (c *clientNetwork) updateECMPPaths(paths map[netip.Prefix]*route.Route) error {
+	if c.chosenRoute != nil {
+		// switching from a single routing peer to ECMP
+		if err := c.removeRouteFromPeerAndSystem(); err != nil {
+			return fmt.Errorf("remove route from peer and system: %v", err)
+		}
+		c.chosenRoute = nil
+	}
+
+	if len(c.ecmpPaths) == 0 {
+		if err := c.handler.AddRoute(c.ctx); err != nil {
+			return fmt.Errorf("route %s couldn't be added for peer %s, err: %v",
+				c.handler, c.wgInterface.Address().IP.String(), err)
+		}
+	}
+
+	for slice, r := range paths {
+		previous := c.ecmpPaths[slice]
+		if previous != nil && previous.Peer == r.Peer {
+			continue
+		}
+		if previous != nil {
+			if err := c.wgInterface.RemoveAllowedIP(previous.Peer, slice.String()); err != nil {
+				log.Debugf("couldn't remove allowed IP %s from peer %s: %v", slice, previous.Peer, err)
+			}
+		}
+		if err := c.wgInterface.AddAllowedIP(r.Peer, slice.String()); err != nil {
+			log.Errorf("couldn't add allowed IP %s for peer %s, err: %v", slice, r.Peer, err)
+		}
+	}
+
+	c.updateECMPPeerStates(paths)
+	c.ecmpPaths = paths
+
+	log.Debugf("spreading network %s across %d routing peers", c.handler, len(ecmpPeers(paths)))
+
+	return nil
+}
+
+// removeECMPPaths removes the default route slices from the routing peers
+func (c *clientNetwork) removeECMPPaths() {
+	for slice, r := range c.ecmpPaths {
+		if err := c.wgInterface.RemoveAllowedIP(r.Peer, slice.String()); err != nil {
+			log.Debugf("couldn't remove allowed IP %s from peer %s: %v", slice, r.Peer, err)
+		}
+	}
+
+	c.updateECMPPeerStates(nil)
+	c.ecmpPaths = nil
+}
+
+// updateECMPPeerStates records the network on the states of the routing peers in use
+func (c *clientNetwork) updateECMPPeerStates(paths map[netip.Prefix]*route.Route) {
+	previousPeers := ecmpPeers(c.ecmpPaths)
+	newPeers := ecmpPeers(paths)
+
+	for peerKey := range previousPeers {
+		if _, ok := newPeers[peerKey]; ok {
+			continue
+		}
+		state, err := c.statusRecorder.GetPeer(peerKey)
+		if err != nil {
+			continue
+		}
+		state.DeleteRoute(c.handler.String())
+		if err := c.statusRecorder.UpdatePeerState(state); err != nil {
+			log.Warnf("Failed to update peer state: %v", err)
+		}
+	}
+
+	for peerKey := range newPeers {
+		if _, ok := previousPeers[peerKey]; ok {
+			continue
+		}
+		state, err := c.statusRecorder.GetPeer(peerKey)
+		if err != nil {
+			log.Errorf("Failed to get peer state: %v", err)
+			continue
+		}
+		state.AddRoute(c.handler.String())
+		if err := c.statusRecorder.UpdatePeerState(state); err != nil {
+			log.Warnf("Failed to update peer state: %v", err)
+		}
+	}
+}
+
+func ecmpPeers(paths map[netip.Prefix]*route.Route) map[string]struct{} {
+	peers := make(map[string]struct{})
+	for _, r := range paths {
+		peers[r.Peer] = struct{}{}
+	}
+	return peers
+}
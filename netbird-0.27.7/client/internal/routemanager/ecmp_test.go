@@ -0,0 +1,86 @@
+package routemanager
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/route"
+)
+
+func countECMPSlices(paths map[netip.Prefix]*route.Route) map[route.ID]int {
+	counts := make(map[route.ID]int)
+	for _, r := range paths {
+		counts[r.ID]++
+	}
+	return counts
+}
+
+func TestSplitPrefix(t *testing.T) {
+	slices := splitPrefix(defaultv4, ecmpSliceBits)
+	require.Len(t, slices, 16)
+	assert.Equal(t, netip.MustParsePrefix("0.0.0.0/4"), slices[0])
+	assert.Equal(t, netip.MustParsePrefix("16.0.0.0/4"), slices[1])
+	assert.Equal(t, netip.MustParsePrefix("240.0.0.0/4"), slices[15])
+
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/9"),
+		netip.MustParsePrefix("10.128.0.0/9"),
+	}, splitPrefix(netip.MustParsePrefix("10.0.0.0/8"), 9))
+}
+
+func TestAssignECMPSlices(t *testing.T) {
+	testCases := []struct {
+		name     string
+		weights  map[route.ID]int
+		expected map[route.ID]int
+	}{
+		{
+			name:     "Equal Weights",
+			weights:  map[route.ID]int{"a": 10, "b": 10},
+			expected: map[route.ID]int{"a": 8, "b": 8},
+		},
+		{
+			name:     "Different Weights",
+			weights:  map[route.ID]int{"a": 30, "b": 10},
+			expected: map[route.ID]int{"a": 12, "b": 4},
+		},
+		{
+			name:     "Remainder Goes To Largest Fraction",
+			weights:  map[route.ID]int{"a": 1, "b": 1, "c": 1},
+			expected: map[route.ID]int{"a": 6, "b": 5, "c": 5},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var routes []*route.Route
+			for id, weight := range tc.weights {
+				routes = append(routes, &route.Route{ID: id, Peer: string(id), Weight: weight})
+			}
+
+			paths := assignECMPSlices(ecmpSlicesv4, routes, nil)
+			assert.Len(t, paths, len(ecmpSlicesv4))
+			assert.Equal(t, tc.expected, countECMPSlices(paths))
+		})
+	}
+}
+
+func TestAssignECMPSlicesKeepsAssignments(t *testing.T) {
+	a := &route.Route{ID: "a", Peer: "a", Weight: 1}
+	b := &route.Route{ID: "b", Peer: "b", Weight: 1}
+	c := &route.Route{ID: "c", Peer: "c", Weight: 1}
+
+	current := assignECMPSlices(ecmpSlicesv4, []*route.Route{a, b, c}, nil)
+
+	// the path of c fails, only its slices should move
+	paths := assignECMPSlices(ecmpSlicesv4, []*route.Route{a, b}, current)
+	assert.Equal(t, map[route.ID]int{"a": 8, "b": 8}, countECMPSlices(paths))
+	for slice, r := range current {
+		if r.ID != "c" {
+			assert.Equal(t, r.ID, paths[slice].ID, "slice %s should stay on its path", slice)
+		}
+	}
+}
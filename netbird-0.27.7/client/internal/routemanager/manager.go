@@ -9,6 +9,7 @@ import (
 	"runtime"
 	"sync"
 
+	"github.com/miekg/dns"
 	log "github.com/sirupsen/logrus"
 
 	firewall "github.com/netbirdio/netbird/client/firewall/manager"
@@ -35,6 +36,8 @@ type Manager interface {
 	SetRouteChangeListener(listener listener.NetworkChangeListener)
 	InitialRouteRange() []string
 	EnableServerRouter(firewall firewall.Manager) error
+	OnDNSResponse(msg *dns.Msg)
+	GetDebugInfo() DebugInfo
 	Stop()
 }
 
@@ -50,6 +53,8 @@ type DefaultManager struct {
 	wgInterface    *iface.WGIface
 	pubKey         string
 	notifier       *notifier
+	// skippedRoutes holds the routes of the last update this client doesn't apply
+	skippedRoutes []SkippedRoute
 }
 
 func NewManager(ctx context.Context, pubKey string, wgInterface *iface.WGIface, statusRecorder *peer.Status, initialRoutes []*route.Route) *DefaultManager {
@@ -185,13 +190,25 @@ func (m *DefaultManager) TriggerSelection(networks route.HAMap) {
 			continue
 		}
 
-		clientNetworkWatcher := newClientNetworkWatcher(m.ctx, m.wgInterface, m.statusRecorder, routes[0].Network)
+		clientNetworkWatcher := newClientNetworkWatcher(m.ctx, m.wgInterface, m.statusRecorder, routes[0])
 		m.clientNetworks[id] = clientNetworkWatcher
 		go clientNetworkWatcher.peersStateAndUpdateWatcher()
 		clientNetworkWatcher.sendUpdateToClientNetworkWatcher(routesUpdate{routes: routes})
 	}
 }
 
+// OnDNSResponse passes the DNS responses of the local DNS server to the domain routes, to route the addresses they contain
+func (m *DefaultManager) OnDNSResponse(msg *dns.Msg) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	for _, client := range m.clientNetworks {
+		if dynamic, ok := client.handler.(*dynamicRoute); ok {
+			dynamic.OnDNSResponse(msg)
+		}
+	}
+}
+
 // stopObsoleteClients stops the client network watcher for the networks that are not in the new list
 func (m *DefaultManager) stopObsoleteClients(networks route.HAMap) {
 	for id, client := range m.clientNetworks {
@@ -210,7 +227,7 @@ func (m *DefaultManager) updateClientNetworks(updateSerial uint64, networks rout
 	for id, routes := range networks {
 		clientNetworkWatcher, found := m.clientNetworks[id]
 		if !found {
-			clientNetworkWatcher = newClientNetworkWatcher(m.ctx, m.wgInterface, m.statusRecorder, routes[0].Network)
+			clientNetworkWatcher = newClientNetworkWatcher(m.ctx, m.wgInterface, m.statusRecorder, routes[0])
 			m.clientNetworks[id] = clientNetworkWatcher
 			go clientNetworkWatcher.peersStateAndUpdateWatcher()
 		}
@@ -226,6 +243,10 @@ func (m *DefaultManager) classifyRoutes(newRoutes []*route.Route) (map[route.ID]
 	newClientRoutesIDMap := make(route.HAMap)
 	newServerRoutesMap := make(map[route.ID]*route.Route)
 	ownNetworkIDs := make(map[route.HAUniqueID]bool)
+	var skippedRoutes []SkippedRoute
+	skip := func(r *route.Route, reason string) {
+		skippedRoutes = append(skippedRoutes, SkippedRoute{NetID: r.NetID, Network: r.NetString(), Reason: reason})
+	}
 
 	for _, newRoute := range newRoutes {
 		haID := route.GetHAUniqueID(newRoute)
@@ -234,6 +255,7 @@ func (m *DefaultManager) classifyRoutes(newRoutes []*route.Route) (map[route.ID]
 			// only linux is supported for now
 			if runtime.GOOS != "linux" {
 				log.Warnf("received a route to manage, but agent doesn't support router mode on %s OS", runtime.GOOS)
+				skip(newRoute, fmt.Sprintf("router mode is not supported on %s", runtime.GOOS))
 				continue
 			}
 			newServerRoutesMap[newRoute.ID] = newRoute
@@ -243,12 +265,20 @@ func (m *DefaultManager) classifyRoutes(newRoutes []*route.Route) (map[route.ID]
 	for _, newRoute := range newRoutes {
 		haID := route.GetHAUniqueID(newRoute)
 		if !ownNetworkIDs[haID] {
-			if !isPrefixSupported(newRoute.Network) {
+			if newRoute.IsDynamic() {
+				if !isDynamicRouteSupported() {
+					log.Warnf("This agent doesn't support domain routes on %s, skipping route %s", runtime.GOOS, newRoute.NetID)
+					skip(newRoute, fmt.Sprintf("domain routes are not supported on %s", runtime.GOOS))
+					continue
+				}
+			} else if !isPrefixSupported(newRoute.Network) {
+				skip(newRoute, fmt.Sprintf("prefixes of /%d or shorter are not supported with custom routing disabled", minRangeBits))
 				continue
 			}
 			newClientRoutesIDMap[haID] = append(newClientRoutesIDMap[haID], newRoute)
 		}
 	}
+	m.skippedRoutes = skippedRoutes
 
 	return newServerRoutesMap, newClientRoutesIDMap
 }
@@ -262,6 +292,11 @@ func (m *DefaultManager) clientRoutes(initialRoutes []*route.Route) []*route.Rou
 	return rs
 }
 
+// isDynamicRouteSupported returns false on mobile systems, where routes can't be added without recreating the tunnel
+func isDynamicRouteSupported() bool {
+	return runtime.GOOS != "android" && runtime.GOOS != "ios"
+}
+
 func isPrefixSupported(prefix netip.Prefix) bool {
 	if !nbnet.CustomRoutingDisabled() {
 		return true
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/miekg/dns"
+
 	firewall "github.com/netbirdio/netbird/client/firewall/manager"
 	"github.com/netbirdio/netbird/client/internal/listener"
 	"github.com/netbirdio/netbird/client/internal/peer"
@@ -70,3 +72,12 @@ func (m *MockManager) Stop() {
 		m.StopFunc()
 	}
 }
+
+// OnDNSResponse mock implementation of OnDNSResponse from Manager interface
+func (m *MockManager) OnDNSResponse(*dns.Msg) {
+}
+
+// GetDebugInfo mock implementation of GetDebugInfo from Manager interface
+func (m *MockManager) GetDebugInfo() DebugInfo {
+	return DebugInfo{}
+}
@@ -0,0 +1,97 @@
+package routemanager
+
+import (
+	"os"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+)
+
+const (
+	// envRetryInitialInterval sets the interval of the first retry of a failed route installation
+	envRetryInitialInterval = "NB_ROUTE_RETRY_INITIAL_INTERVAL"
+	// envRetryMaxInterval sets the maximum interval between retries of a failed route installation
+	envRetryMaxInterval = "NB_ROUTE_RETRY_MAX_INTERVAL"
+
+	defaultRetryInitialInterval = time.Second
+	defaultRetryMaxInterval     = time.Minute
+)
+
+func newRetryBackOff() backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = durationFromEnv(envRetryInitialInterval, defaultRetryInitialInterval)
+	b.MaxInterval = durationFromEnv(envRetryMaxInterval, defaultRetryMaxInterval)
+	// retry until the route is installed or removed
+	b.MaxElapsedTime = 0
+	b.Reset()
+	return b
+}
+
+func durationFromEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Warnf("invalid duration %q for %s, using default %s", value, key, defaultValue)
+		return defaultValue
+	}
+	return d
+}
+
+// handleRecalculateResult schedules a retry with exponential backoff if the route couldn't be installed,
+// e.g. because the interface went down for a moment, and records the failure in the status recorder
+func (c *clientNetwork) handleRecalculateResult(err error) {
+	if err == nil {
+		if c.retryAttempts > 0 {
+			log.Infof("Route %s installed after %d failed attempts", c.handler, c.retryAttempts)
+		}
+		c.stopRetry()
+		return
+	}
+
+	if c.retryBackOff == nil {
+		c.retryBackOff = newRetryBackOff()
+	}
+	c.retryAttempts++
+	next := c.retryBackOff.NextBackOff()
+
+	if c.retryTimer != nil {
+		c.retryTimer.Stop()
+	}
+	c.retryTimer = time.NewTimer(next)
+
+	log.Warnf("Couldn't install route %s (attempt %d), retrying in %s: %v", c.handler, c.retryAttempts, next, err)
+
+	c.statusRecorder.SetFailedRoute(peer.FailedRoute{
+		NetID:     string(c.netID()),
+		Network:   c.handler.String(),
+		Error:     err.Error(),
+		Attempts:  c.retryAttempts,
+		NextRetry: time.Now().Add(next),
+	})
+}
+
+// stopRetry cancels a scheduled retry and clears the recorded failure
+func (c *clientNetwork) stopRetry() {
+	if c.retryTimer != nil {
+		c.retryTimer.Stop()
+		c.retryTimer = nil
+	}
+	c.retryBackOff = nil
+	c.retryAttempts = 0
+	c.statusRecorder.RemoveFailedRoute(c.handler.String())
+}
+
+// retryC returns the channel of the scheduled retry, a nil channel blocks forever if no retry is scheduled
+func (c *clientNetwork) retryC() <-chan time.Time {
+	if c.retryTimer == nil {
+		return nil
+	}
+	return c.retryTimer.C
+}
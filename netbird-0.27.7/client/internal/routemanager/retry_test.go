@@ -0,0 +1,59 @@
+package routemanager
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+	"github.com/netbirdio/netbird/route"
+)
+
+func TestClientNetworkRetry(t *testing.T) {
+	t.Setenv(envRetryInitialInterval, "1h")
+
+	statusRecorder := peer.NewRecorder("https://mgm")
+	c := &clientNetwork{
+		statusRecorder: statusRecorder,
+		routes: map[route.ID]*route.Route{
+			"route1": {ID: "route1", NetID: "net1", Network: netip.MustParsePrefix("10.0.0.0/24")},
+		},
+		handler: &staticRoute{prefix: netip.MustParsePrefix("10.0.0.0/24")},
+	}
+
+	assert.Nil(t, c.retryC(), "no retry should be scheduled initially")
+
+	c.handleRecalculateResult(errors.New("no such device"))
+	require.NotNil(t, c.retryC())
+
+	failedRoutes := statusRecorder.GetFailedRoutes()
+	require.Len(t, failedRoutes, 1)
+	assert.Equal(t, "net1", failedRoutes[0].NetID)
+	assert.Equal(t, "10.0.0.0/24", failedRoutes[0].Network)
+	assert.Equal(t, "no such device", failedRoutes[0].Error)
+	assert.Equal(t, 1, failedRoutes[0].Attempts)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), failedRoutes[0].NextRetry, 31*time.Minute)
+
+	c.handleRecalculateResult(errors.New("no such device"))
+	assert.Equal(t, 2, statusRecorder.GetFailedRoutes()[0].Attempts)
+
+	c.handleRecalculateResult(nil)
+	assert.Nil(t, c.retryC())
+	assert.Empty(t, statusRecorder.GetFailedRoutes())
+	assert.Zero(t, c.retryAttempts)
+}
+
+func TestDurationFromEnv(t *testing.T) {
+	t.Setenv(envRetryMaxInterval, "")
+	assert.Equal(t, time.Minute, durationFromEnv(envRetryMaxInterval, time.Minute))
+
+	t.Setenv(envRetryMaxInterval, "30s")
+	assert.Equal(t, 30*time.Second, durationFromEnv(envRetryMaxInterval, time.Minute))
+
+	t.Setenv(envRetryMaxInterval, "invalid")
+	assert.Equal(t, time.Minute, durationFromEnv(envRetryMaxInterval, time.Minute))
+}
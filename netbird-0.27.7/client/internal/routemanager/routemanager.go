@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net"
 	"net/netip"
+	"sort"
 	"sync"
 
 	"github.com/hashicorp/go-multierror"
@@ -107,6 +108,31 @@ func (rm *RouteManager) RemoveRouteRef(connID nbnet.ConnectionID) error {
 	return result.ErrorOrNil()
 }
 
+// References returns the routes currently referenced by connections
+func (rm *RouteManager) References() []ExclusionRoute {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	routes := make([]ExclusionRoute, 0, len(rm.refCountMap))
+	for prefix, ref := range rm.refCountMap {
+		route := ExclusionRoute{
+			Prefix:     prefix,
+			NextHop:    ref.nexthop,
+			References: ref.count,
+		}
+		if ref.intf != nil {
+			route.Interface = ref.intf.Name
+		}
+		routes = append(routes, route)
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Prefix.String() < routes[j].Prefix.String()
+	})
+
+	return routes
+}
+
 // Flush removes all references and routes from the system
 func (rm *RouteManager) Flush() error {
 	rm.mutex.Lock()
@@ -50,7 +50,7 @@ func (m *defaultServerRouter) updateRoutes(routesMap map[route.ID]*route.Route)
 		err := m.removeFromServerNetwork(oldRoute)
 		if err != nil {
 			log.Errorf("Unable to remove route id: %s, network %s, from server, got: %v",
-				oldRoute.ID, oldRoute.Network, err)
+				oldRoute.ID, oldRoute.NetString(), err)
 		}
 		delete(m.routes, routeID)
 	}
@@ -70,7 +70,7 @@ func (m *defaultServerRouter) updateRoutes(routesMap map[route.ID]*route.Route)
 	}
 
 	if len(m.routes) > 0 {
-		err := enableIPForwarding()
+		err := enableIPForwarding(m.wgInterface.Name(), m.routedNetworks())
 		if err != nil {
 			return err
 		}
@@ -79,6 +79,19 @@ func (m *defaultServerRouter) updateRoutes(routesMap map[route.ID]*route.Route)
 	return nil
 }
 
+// routedNetworks returns the destinations of the routes, any destination for the domain routes
+func (m *defaultServerRouter) routedNetworks() []netip.Prefix {
+	networks := make([]netip.Prefix, 0, len(m.routes))
+	for _, r := range m.routes {
+		if r.IsDynamic() {
+			networks = append(networks, defaultv4)
+			continue
+		}
+		networks = append(networks, r.Network)
+	}
+	return networks
+}
+
 func (m *defaultServerRouter) removeFromServerNetwork(route *route.Route) error {
 	select {
 	case <-m.ctx.Done():
@@ -101,7 +114,7 @@ func (m *defaultServerRouter) removeFromServerNetwork(route *route.Route) error
 		delete(m.routes, route.ID)
 
 		state := m.statusRecorder.GetLocalPeerState()
-		delete(state.Routes, route.Network.String())
+		delete(state.Routes, route.NetString())
 		m.statusRecorder.UpdateLocalPeerState(state)
 
 		return nil
@@ -126,6 +139,7 @@ func (m *defaultServerRouter) addToServerNetwork(route *route.Route) error {
 		if err != nil {
 			return fmt.Errorf("insert routing rules: %w", err)
 		}
+		log.Debugf("routing network %s with masquerade %t", route.NetString(), routerPair.Masquerade)
 
 		m.routes[route.ID] = route
 
@@ -133,7 +147,7 @@ func (m *defaultServerRouter) addToServerNetwork(route *route.Route) error {
 		if state.Routes == nil {
 			state.Routes = map[string]struct{}{}
 		}
-		state.Routes[route.Network.String()] = struct{}{}
+		state.Routes[route.NetString()] = struct{}{}
 		m.statusRecorder.UpdateLocalPeerState(state)
 
 		return nil
@@ -157,6 +171,10 @@ func (m *defaultServerRouter) cleanUp() {
 
 	}
 
+	if err := restoreIPForwarding(); err != nil {
+		log.Errorf("Failed to restore IP forwarding: %v", err)
+	}
+
 	state := m.statusRecorder.GetLocalPeerState()
 	state.Routes = nil
 	m.statusRecorder.UpdateLocalPeerState(state)
@@ -167,10 +185,18 @@ func routeToRouterPair(source string, route *route.Route) (firewall.RouterPair,
 	if err != nil {
 		return firewall.RouterPair{}, err
 	}
+
+	destination := route.Network.Masked().String()
+	if route.IsDynamic() {
+		// the addresses of domain routes are resolved by the client peers, so we have to forward to any destination
+		// TODO: add ipv6
+		destination = defaultv4.String()
+	}
+
 	return firewall.RouterPair{
 		ID:          string(route.ID),
 		Source:      parsed.String(),
-		Destination: route.Network.Masked().String(),
+		Destination: destination,
 		Masquerade:  route.Masquerade,
 	}, nil
 }
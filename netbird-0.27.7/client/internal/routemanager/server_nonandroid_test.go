@@ -0,0 +1,61 @@
+//go:build !android
+
+package routemanager
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/route"
+)
+
+func TestRouteToRouterPair(t *testing.T) {
+	testCases := []struct {
+		name                string
+		route               *route.Route
+		expectedDestination string
+	}{
+		{
+			name: "Masquerade Enabled",
+			route: &route.Route{
+				ID:         "a",
+				Network:    netip.MustParsePrefix("192.168.1.10/24"),
+				Masquerade: true,
+			},
+			expectedDestination: "192.168.1.0/24",
+		},
+		{
+			name: "Masquerade Disabled",
+			route: &route.Route{
+				ID:         "b",
+				Network:    netip.MustParsePrefix("10.0.0.0/8"),
+				Masquerade: false,
+			},
+			expectedDestination: "10.0.0.0/8",
+		},
+		{
+			name: "Dynamic Route",
+			route: &route.Route{
+				ID:          "c",
+				NetworkType: route.DomainNetwork,
+				Domains:     []string{"example.com"},
+				Masquerade:  true,
+			},
+			expectedDestination: "0.0.0.0/0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pair, err := routeToRouterPair("100.64.0.0/10", tc.route)
+			require.NoError(t, err)
+			assert.Equal(t, string(tc.route.ID), pair.ID)
+			assert.Equal(t, "100.64.0.0/10", pair.Source)
+			assert.Equal(t, tc.expectedDestination, pair.Destination)
+			assert.Equal(t, tc.route.Masquerade, pair.Masquerade)
+		})
+	}
+}
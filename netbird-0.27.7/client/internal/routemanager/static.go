@@ -0,0 +1,67 @@
+package routemanager
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+
+	"github.com/netbirdio/netbird/iface"
+	"github.com/netbirdio/netbird/route"
+)
+
+// routeHandler installs and removes the system routes and the WireGuard allowed IPs of a client network
+type routeHandler interface {
+	String() string
+	AddRoute(ctx context.Context) error
+	RemoveRoute() error
+	AddAllowedIPs(peerKey string) error
+	RemoveAllowedIPs(peerKey string) error
+}
+
+func newRouteHandler(rt *route.Route, wgInterface *iface.WGIface) routeHandler {
+	if rt.IsDynamic() {
+		return newDynamicRoute(rt, wgInterface)
+	}
+	return &staticRoute{
+		prefix:      rt.Network,
+		wgInterface: wgInterface,
+	}
+}
+
+// staticRoute routes a single network prefix
+type staticRoute struct {
+	prefix      netip.Prefix
+	wgInterface *iface.WGIface
+}
+
+func (s *staticRoute) String() string {
+	return s.prefix.String()
+}
+
+func (s *staticRoute) AddRoute(context.Context) error {
+	if err := addVPNRoute(s.prefix, getVPNInterface(s.wgInterface)); err != nil {
+		return fmt.Errorf("add route: %w", err)
+	}
+	return nil
+}
+
+func (s *staticRoute) RemoveRoute() error {
+	if err := removeVPNRoute(s.prefix, getVPNInterface(s.wgInterface)); err != nil {
+		return fmt.Errorf("remove route: %w", err)
+	}
+	return nil
+}
+
+func (s *staticRoute) AddAllowedIPs(peerKey string) error {
+	if err := s.wgInterface.AddAllowedIP(peerKey, s.prefix.String()); err != nil {
+		return fmt.Errorf("add allowed IP %s: %w", s.prefix, err)
+	}
+	return nil
+}
+
+func (s *staticRoute) RemoveAllowedIPs(peerKey string) error {
+	if err := s.wgInterface.RemoveAllowedIP(peerKey, s.prefix.String()); err != nil {
+		return fmt.Errorf("remove allowed IP %s: %w", s.prefix, err)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package routemanager
+
+import "sync/atomic"
+
+// sysctlDisabled keeps the route manager from changing the sysctls, e.g. when the system manages them itself
+var sysctlDisabled atomic.Bool
+
+// SetSysctlDisabled disables the changes of the forwarding, rp_filter and src_valid_mark sysctls
+func SetSysctlDisabled(disabled bool) {
+	sysctlDisabled.Store(disabled)
+}
@@ -28,6 +28,15 @@ var splitDefaultv6_2 = netip.PrefixFrom(netip.AddrFrom16([16]byte{0x80}), 1)
 var ErrRouteNotFound = errors.New("route not found")
 var ErrRouteNotAllowed = errors.New("route not allowed")
 
+const (
+	// conflictReasonExists indicates that the same prefix already exists in the routing table
+	conflictReasonExists = "exists"
+	// conflictReasonOverlaps indicates that a more specific local route shadows (a part of) the prefix
+	conflictReasonOverlaps = "overlaps"
+	// conflictReasonDefaultGateway indicates that the prefix contains the current default gateway
+	conflictReasonDefaultGateway = "default_gateway"
+)
+
 // TODO: fix: for default our wg address now appears as the default gw
 func addRouteForCurrentDefaultGateway(prefix netip.Prefix) error {
 	addr := netip.IPv4Unspecified()
@@ -147,6 +156,60 @@ func isSubRange(prefix netip.Prefix) (bool, error) {
 	return false, nil
 }
 
+// findRouteConflict checks whether a VPN route for the prefix would be shadowed by the local routing table.
+// It returns nil if the prefix doesn't conflict with any local route. Default routes are never reported,
+// as local routes are expected to take precedence over them.
+func findRouteConflict(prefix netip.Prefix) (*peer.RouteConflict, error) {
+	if prefix.Bits() == 0 {
+		return nil, nil
+	}
+
+	routes, err := getRoutesFromTable()
+	if err != nil {
+		return nil, fmt.Errorf("get routes from table: %w", err)
+	}
+
+	for _, tableRoute := range routes {
+		switch tableRoute {
+		case splitDefaultv4_1, splitDefaultv4_2, splitDefaultv6_1, splitDefaultv6_2:
+			// these are the split default routes installed by us in legacy mode
+			continue
+		}
+
+		if tableRoute.Bits() == 0 || !tableRoute.Overlaps(prefix) || tableRoute.Bits() < prefix.Bits() {
+			continue
+		}
+
+		reason := conflictReasonOverlaps
+		if tableRoute == prefix {
+			reason = conflictReasonExists
+		}
+		return &peer.RouteConflict{
+			Network:       prefix,
+			ConflictsWith: tableRoute.String(),
+			Reason:        reason,
+		}, nil
+	}
+
+	addr := netip.IPv4Unspecified()
+	if prefix.Addr().Is6() {
+		addr = netip.IPv6Unspecified()
+	}
+	defaultGateway, _, err := GetNextHop(addr)
+	if err != nil && !errors.Is(err, ErrRouteNotFound) {
+		return nil, fmt.Errorf("get default gateway: %w", err)
+	}
+	if defaultGateway.IsValid() && prefix.Contains(defaultGateway.WithZone("")) {
+		return &peer.RouteConflict{
+			Network:       prefix,
+			ConflictsWith: defaultGateway.String(),
+			Reason:        conflictReasonDefaultGateway,
+		}, nil
+	}
+
+	return nil, nil
+}
+
 // addRouteToNonVPNIntf adds a new route to the routing table for the given prefix and returns the next hop and interface.
 // If the next hop or interface is pointing to the VPN interface, it will return the initial values.
 func addRouteToNonVPNIntf(prefix netip.Prefix, vpnIntf *iface.WGIface, initialNextHop netip.Addr, initialIntf *net.Interface) (netip.Addr, *net.Interface, error) {
@@ -19,11 +19,15 @@ func cleanupRouting() error {
 	return nil
 }
 
-func enableIPForwarding() error {
+func enableIPForwarding(string, []netip.Prefix) error {
 	log.Infof("Enable IP forwarding is not implemented on %s", runtime.GOOS)
 	return nil
 }
 
+func restoreIPForwarding() error {
+	return nil
+}
+
 func addVPNRoute(netip.Prefix, *net.Interface) error {
 	return nil
 }
@@ -31,3 +35,7 @@ func addVPNRoute(netip.Prefix, *net.Interface) error {
 func removeVPNRoute(netip.Prefix, *net.Interface) error {
 	return nil
 }
+
+func findRouteConflict(netip.Prefix) (*peer.RouteConflict, error) {
+	return nil, nil
+}
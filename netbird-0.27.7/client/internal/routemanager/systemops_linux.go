@@ -11,6 +11,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 
 	"github.com/hashicorp/go-multierror"
@@ -33,6 +34,9 @@ const (
 
 	// ipv4ForwardingPath is the path to the file containing the IP forwarding setting.
 	ipv4ForwardingPath = "net.ipv4.ip_forward"
+	// forwardingInterfacePath is the IP forwarding setting of an interface, it takes effect even when the forwarding
+	// is disabled globally
+	forwardingInterfacePath = "net.ipv4.conf.%s.forwarding"
 
 	rpFilterPath          = "net.ipv4.conf.all.rp_filter"
 	rpFilterInterfacePath = "net.ipv4.conf.%s.rp_filter"
@@ -49,6 +53,13 @@ var originalSysctl map[string]int
 // sysctlFailed is used as an indicator to emit a warning when default routes are configured
 var sysctlFailed bool
 
+// originalForwardingSysctl stores the original forwarding sysctl values before they are modified for the routed
+// networks, they are restored when the client stops routing
+var (
+	originalForwardingSysctl   = map[string]int{}
+	originalForwardingSysctlMu sync.Mutex
+)
+
 type ruleParams struct {
 	priority       int
 	fwmark         int
@@ -376,8 +387,63 @@ func flushRoutes(tableID, family int) error {
 	return result.ErrorOrNil()
 }
 
-func enableIPForwarding() error {
-	_, err := setSysctl(ipv4ForwardingPath, 1, false)
+// enableIPForwarding enables the forwarding of the traffic routed to the networks. If the forwarding is disabled
+// globally, it is enabled only on the WireGuard interface and on the interfaces the networks are reached through,
+// instead of on the whole system
+func enableIPForwarding(wgIfaceName string, networks []netip.Prefix) error {
+	enabled, err := getSysctl(ipv4ForwardingPath)
+	if err != nil {
+		return err
+	}
+	if enabled == 1 {
+		return nil
+	}
+	if sysctlDisabled.Load() {
+		log.Warnf("IP forwarding is disabled and the sysctl changes are disabled, the routed traffic is not forwarded")
+		return nil
+	}
+
+	interfaces := []string{wgIfaceName}
+	for _, network := range networks {
+		if !network.Addr().Is4() {
+			continue
+		}
+		_, intf, err := GetNextHop(network.Masked().Addr())
+		if err != nil {
+			log.Warnf("Failed to find the interface of the routed network %s: %v", network, err)
+			continue
+		}
+		if intf != nil && intf.Name != wgIfaceName {
+			interfaces = append(interfaces, intf.Name)
+		}
+	}
+
+	originalForwardingSysctlMu.Lock()
+	defer originalForwardingSysctlMu.Unlock()
+
+	var result *multierror.Error
+	for _, name := range interfaces {
+		key := fmt.Sprintf(forwardingInterfacePath, sysctlInterfaceName(name))
+		oldVal, err := setSysctl(key, 1, false)
+		if err != nil {
+			result = multierror.Append(result, err)
+			continue
+		}
+		if _, ok := originalForwardingSysctl[key]; !ok {
+			originalForwardingSysctl[key] = oldVal
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+// restoreIPForwarding restores the forwarding sysctls changed by enableIPForwarding
+func restoreIPForwarding() error {
+	originalForwardingSysctlMu.Lock()
+	defer originalForwardingSysctlMu.Unlock()
+
+	err := cleanupSysctl(originalForwardingSysctl)
+	originalForwardingSysctl = map[string]int{}
 	return err
 }
 
@@ -512,6 +578,11 @@ func getAddressFamily(prefix netip.Prefix) int {
 // setupSysctl configures sysctl settings for RP filtering and source validation.
 func setupSysctl(wgIface *iface.WGIface) (map[string]int, error) {
 	keys := map[string]int{}
+	if sysctlDisabled.Load() {
+		log.Infof("Sysctl changes are disabled, keeping rp_filter and src_valid_mark")
+		return keys, nil
+	}
+
 	var result *multierror.Error
 
 	oldVal, err := setSysctl(srcValidMarkPath, 1, false)
@@ -538,7 +609,7 @@ func setupSysctl(wgIface *iface.WGIface) (map[string]int, error) {
 			continue
 		}
 
-		i := fmt.Sprintf(rpFilterInterfacePath, intf.Name)
+		i := fmt.Sprintf(rpFilterInterfacePath, sysctlInterfaceName(intf.Name))
 		oldVal, err := setSysctl(i, 2, true)
 		if err != nil {
 			result = multierror.Append(result, err)
@@ -550,10 +621,28 @@ func setupSysctl(wgIface *iface.WGIface) (map[string]int, error) {
 	return keys, result.ErrorOrNil()
 }
 
-// setSysctl sets a sysctl configuration, if onlyIfOne is true it will only set the new value if it's set to 1
-func setSysctl(key string, desiredValue int, onlyIfOne bool) (int, error) {
-	path := fmt.Sprintf("/proc/sys/%s", strings.ReplaceAll(key, ".", "/"))
-	currentValue, err := os.ReadFile(path)
+// sysctlInterfaceName returns the name of the interface as part of a sysctl key, the dots of VLAN interfaces,
+// e.g. eth0.2, are written as slashes as with sysctl(8)
+func sysctlInterfaceName(name string) string {
+	return strings.ReplaceAll(name, ".", "/")
+}
+
+// sysctlPath returns the path of the sysctl key below /proc/sys
+func sysctlPath(key string) string {
+	return "/proc/sys/" + strings.Map(func(r rune) rune {
+		switch r {
+		case '.':
+			return '/'
+		case '/':
+			return '.'
+		}
+		return r
+	}, key)
+}
+
+// getSysctl returns the value of a sysctl configuration
+func getSysctl(key string) (int, error) {
+	currentValue, err := os.ReadFile(sysctlPath(key))
 	if err != nil {
 		return -1, fmt.Errorf("read sysctl %s: %w", key, err)
 	}
@@ -562,13 +651,22 @@ func setSysctl(key string, desiredValue int, onlyIfOne bool) (int, error) {
 	if err != nil && len(currentValue) > 0 {
 		return -1, fmt.Errorf("convert current desiredValue to int: %w", err)
 	}
+	return currentV, nil
+}
+
+// setSysctl sets a sysctl configuration, if onlyIfOne is true it will only set the new value if it's set to 1
+func setSysctl(key string, desiredValue int, onlyIfOne bool) (int, error) {
+	currentV, err := getSysctl(key)
+	if err != nil {
+		return -1, err
+	}
 
 	if currentV == desiredValue || onlyIfOne && currentV != 1 {
 		return currentV, nil
 	}
 
 	//nolint:gosec
-	if err := os.WriteFile(path, []byte(strconv.Itoa(desiredValue)), 0644); err != nil {
+	if err := os.WriteFile(sysctlPath(key), []byte(strconv.Itoa(desiredValue)), 0644); err != nil {
 		return currentV, fmt.Errorf("write sysctl %s: %w", key, err)
 	}
 	log.Debugf("Set sysctl %s from %d to %d", key, currentV, desiredValue)
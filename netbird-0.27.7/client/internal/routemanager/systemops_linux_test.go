@@ -96,6 +96,24 @@ func TestEntryExists(t *testing.T) {
 	}
 }
 
+func TestSysctlPath(t *testing.T) {
+	assert.Equal(t, "/proc/sys/net/ipv4/ip_forward", sysctlPath(ipv4ForwardingPath))
+	assert.Equal(t, "/proc/sys/net/ipv4/conf/br-lan/forwarding",
+		sysctlPath(fmt.Sprintf(forwardingInterfacePath, sysctlInterfaceName("br-lan"))))
+	// the dots of VLAN interfaces are part of the name, not separators
+	assert.Equal(t, "/proc/sys/net/ipv4/conf/eth0.2/rp_filter",
+		sysctlPath(fmt.Sprintf(rpFilterInterfacePath, sysctlInterfaceName("eth0.2"))))
+}
+
+func TestSysctlDisabled(t *testing.T) {
+	SetSysctlDisabled(true)
+	defer SetSysctlDisabled(false)
+
+	original, err := setupSysctl(nil)
+	require.NoError(t, err)
+	assert.Empty(t, original, "no sysctl should be changed")
+}
+
 func createAndSetupDummyInterface(t *testing.T, interfaceName, ipAddressCIDR string) string {
 	t.Helper()
 
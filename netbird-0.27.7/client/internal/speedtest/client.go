@@ -0,0 +1,145 @@
+package speedtest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// helloInterval is the interval the datagrams opening the flow of a reverse UDP test are sent at until the server
+// starts sending
+const helloInterval = 100 * time.Millisecond
+
+// Run runs a test against the server at the address, canceling the context aborts it
+func Run(ctx context.Context, address string, opts Options) (*Result, error) {
+	req := opts.request()
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+
+	dialer := net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("connect to the speed test server of the peer: %w", err)
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.Close()
+	})
+	defer stop()
+
+	reader := bufio.NewReader(conn)
+	if err := writeMessage(conn, req); err != nil {
+		return nil, fmt.Errorf("send the request: %w", err)
+	}
+	var resp response
+	if err := readMessage(conn, reader, controlTimeout, &resp); err != nil {
+		return nil, fmt.Errorf("read the response: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("refused by the peer: %s", resp.Error)
+	}
+
+	result := &Result{Protocol: req.Protocol, Direction: DirectionUpload}
+	if req.Reverse {
+		result.Direction = DirectionDownload
+	}
+	if req.Protocol == ProtocolUDP {
+		err = runUDP(conn, reader, req, resp.UDPPort, result)
+	} else {
+		err = runTCP(conn, reader, req, result)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func runTCP(conn net.Conn, reader *bufio.Reader, req request, result *Result) error {
+	if req.Reverse {
+		received, duration, err := receiveStream(reader, conn, req.Duration+controlTimeout)
+		if err != nil {
+			return fmt.Errorf("receive: %w", err)
+		}
+		result.Bytes = received
+		result.Duration = duration
+		return nil
+	}
+
+	if _, err := sendStream(conn, req.Duration); err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+	// the server reads until the end of the stream and answers with what it received
+	if err := conn.(*net.TCPConn).CloseWrite(); err != nil {
+		return err
+	}
+	var received report
+	if err := readMessage(conn, reader, controlTimeout, &received); err != nil {
+		return fmt.Errorf("read the result: %w", err)
+	}
+	result.Bytes = received.Bytes
+	result.Duration = received.Duration
+	return nil
+}
+
+func runUDP(conn net.Conn, reader *bufio.Reader, req request, port int, result *Result) error {
+	remote := &net.UDPAddr{IP: conn.RemoteAddr().(*net.TCPAddr).IP, Port: port}
+	udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: conn.LocalAddr().(*net.TCPAddr).IP})
+	if err != nil {
+		return err
+	}
+	defer udpConn.Close()
+
+	if !req.Reverse {
+		sent, err := sendDatagrams(udpConn, remote, req.Bandwidth, req.Duration)
+		if err != nil {
+			return fmt.Errorf("send: %w", err)
+		}
+		if err := writeMessage(conn, report{Packets: sent}); err != nil {
+			return err
+		}
+		var received report
+		if err := readMessage(conn, reader, controlTimeout, &received); err != nil {
+			return fmt.Errorf("read the result: %w", err)
+		}
+		result.Bytes = received.Bytes
+		result.Duration = received.Duration
+		result.PacketsSent = sent
+		result.PacketsLost = max(sent-received.Packets, 0)
+		return nil
+	}
+
+	counter := countDatagrams(udpConn)
+	go func() {
+		ticker := time.NewTicker(helloInterval)
+		defer ticker.Stop()
+		for {
+			_, _ = udpConn.WriteTo([]byte("hello"), remote)
+			select {
+			case <-counter.from:
+				return
+			case <-counter.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	var sent report
+	if err := readMessage(conn, reader, req.Duration+2*controlTimeout, &sent); err != nil {
+		return fmt.Errorf("read the result: %w", err)
+	}
+	counter.stop(udpConn)
+
+	received := counter.report()
+	result.Bytes = received.Bytes
+	result.Duration = received.Duration
+	result.PacketsSent = sent.Packets
+	result.PacketsLost = max(sent.Packets-received.Packets, 0)
+	return nil
+}
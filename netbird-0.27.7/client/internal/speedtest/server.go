@@ -0,0 +1,178 @@
+package speedtest
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Server answers the tests of the peers it allows, one at a time
+type Server struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	allowed map[string]struct{}
+	busy    bool
+}
+
+// Listen starts a server on the address, no peer is allowed to test against it until SetAllowed is called
+func Listen(address string) (*Server, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{listener: listener, allowed: map[string]struct{}{}}
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the address the server listens on
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// SetAllowed replaces the IPs of the peers allowed to run tests against the server
+func (s *Server) SetAllowed(ips []net.IP) {
+	allowed := make(map[string]struct{}, len(ips))
+	for _, ip := range ips {
+		allowed[ip.String()] = struct{}{}
+	}
+
+	s.mu.Lock()
+	s.allowed = allowed
+	s.mu.Unlock()
+}
+
+// Close stops the server, a running test ends with an error on the peer
+func (s *Server) Close() error {
+	return s.listener.Close()
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Warnf("speed test server stopped accepting connections: %v", err)
+			}
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// acquire reserves the server for a test of the remote IP, it returns the reason it is refused
+func (s *Server) acquire(ip net.IP) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.allowed[ip.String()]; !ok {
+		return "no policy allows this peer to run speed tests against the peer"
+	}
+	if s.busy {
+		return "another speed test is running"
+	}
+	s.busy = true
+	return ""
+}
+
+func (s *Server) release() {
+	s.mu.Lock()
+	s.busy = false
+	s.mu.Unlock()
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	remoteIP := conn.RemoteAddr().(*net.TCPAddr).IP
+	if reason := s.acquire(remoteIP); reason != "" {
+		log.Debugf("refused the speed test of %s: %s", remoteIP, reason)
+		_ = writeMessage(conn, response{Error: reason})
+		return
+	}
+	defer s.release()
+
+	reader := bufio.NewReader(conn)
+	var req request
+	if err := readMessage(conn, reader, controlTimeout, &req); err != nil {
+		log.Debugf("failed to read the speed test request of %s: %v", remoteIP, err)
+		return
+	}
+	if err := validateRequest(req); err != nil {
+		_ = writeMessage(conn, response{Error: err.Error()})
+		return
+	}
+
+	log.Infof("running a %s speed test of %s for %s, reverse %t", req.Protocol, remoteIP, req.Duration, req.Reverse)
+	var err error
+	if req.Protocol == ProtocolUDP {
+		err = s.handleUDP(conn, reader, req)
+	} else {
+		err = s.handleTCP(conn, reader, req)
+	}
+	if err != nil {
+		log.Warnf("speed test of %s failed: %v", remoteIP, err)
+	}
+}
+
+func (s *Server) handleTCP(conn net.Conn, reader *bufio.Reader, req request) error {
+	if err := writeMessage(conn, response{}); err != nil {
+		return err
+	}
+
+	if req.Reverse {
+		// the peer measures the stream it receives until the connection is closed
+		_, err := sendStream(conn, req.Duration)
+		return err
+	}
+
+	received, duration, err := receiveStream(reader, conn, req.Duration+controlTimeout)
+	if err != nil {
+		return err
+	}
+	return writeMessage(conn, report{Bytes: received, Duration: duration})
+}
+
+func (s *Server) handleUDP(conn net.Conn, reader *bufio.Reader, req request) error {
+	localIP := conn.LocalAddr().(*net.TCPAddr).IP
+	udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: localIP})
+	if err != nil {
+		_ = writeMessage(conn, response{Error: "failed to open the UDP socket"})
+		return err
+	}
+	defer udpConn.Close()
+
+	counter := countDatagrams(udpConn)
+	if err := writeMessage(conn, response{UDPPort: udpConn.LocalAddr().(*net.UDPAddr).Port}); err != nil {
+		return err
+	}
+
+	if !req.Reverse {
+		// the peer tells how many datagrams it sent once it's done
+		var sent report
+		if err := readMessage(conn, reader, req.Duration+controlTimeout, &sent); err != nil {
+			return err
+		}
+		counter.stop(udpConn)
+		return writeMessage(conn, counter.report())
+	}
+
+	// the peer opens the flow with datagrams from the address it receives on
+	var dst net.Addr
+	select {
+	case dst = <-counter.from:
+	case <-time.After(controlTimeout):
+		return errors.New("no datagram received from the peer")
+	}
+	sent, err := sendDatagrams(udpConn, dst, req.Bandwidth, req.Duration)
+	if err != nil {
+		return err
+	}
+	return writeMessage(conn, report{Packets: sent})
+}
@@ -0,0 +1,271 @@
+// Package speedtest measures the throughput between two peers over the WireGuard tunnel, the way iperf does: a
+// control connection to the server of the tested peer negotiates a TCP stream or a flow of UDP datagrams sent in one
+// direction for the duration of the test.
+package speedtest
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// Port is the TCP port the speed test server listens on at the NetBird IP of the peer
+const Port = 51890
+
+// The protocols and the directions of a test, the direction is seen from the peer that runs the test
+const (
+	ProtocolTCP = "tcp"
+	ProtocolUDP = "udp"
+
+	DirectionUpload   = "upload"
+	DirectionDownload = "download"
+)
+
+const (
+	// DefaultDuration is the time the data is sent for when the options don't set it
+	DefaultDuration = 10 * time.Second
+	// MaxDuration is the longest test the server accepts
+	MaxDuration = time.Minute
+	// DefaultBandwidth is the rate the UDP datagrams are sent at when the options don't set it, in bits per second
+	DefaultBandwidth = 10_000_000
+
+	// datagramSize leaves room for the WireGuard overhead in the default MTU of the interface
+	datagramSize = 1200
+	// drainTimeout is the time the datagrams still in flight are waited for after the sender is done
+	drainTimeout = 250 * time.Millisecond
+	dialTimeout  = 5 * time.Second
+	// controlTimeout bounds the messages of the control connection besides the data
+	controlTimeout = 10 * time.Second
+)
+
+// Options of a test
+type Options struct {
+	// Duration is the time the data is sent for
+	Duration time.Duration
+	// Reverse makes the tested peer send the data
+	Reverse bool
+	// UDP sends datagrams at the Bandwidth in bits per second instead of a TCP stream, measuring the loss
+	UDP       bool
+	Bandwidth int64
+}
+
+// Result of a test
+type Result struct {
+	Protocol  string
+	Direction string
+	// Bytes is the amount of data the receiving side got over the Duration
+	Bytes    int64
+	Duration time.Duration
+	// PacketsSent and PacketsLost are the datagrams of an UDP test
+	PacketsSent int64
+	PacketsLost int64
+}
+
+// BitsPerSecond returns the throughput measured by the test
+func (r *Result) BitsPerSecond() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Bytes) * 8 / r.Duration.Seconds()
+}
+
+// LossPercent returns the share of the datagrams of an UDP test that didn't arrive
+func (r *Result) LossPercent() float64 {
+	if r.PacketsSent == 0 {
+		return 0
+	}
+	return float64(r.PacketsLost) / float64(r.PacketsSent) * 100
+}
+
+// request opens a test on the control connection
+type request struct {
+	Protocol  string        `json:"protocol"`
+	Reverse   bool          `json:"reverse"`
+	Duration  time.Duration `json:"duration"`
+	Bandwidth int64         `json:"bandwidth,omitempty"`
+}
+
+// response answers a request, the UDP port is the one the server exchanges the datagrams on
+type response struct {
+	Error   string `json:"error,omitempty"`
+	UDPPort int    `json:"udp_port,omitempty"`
+}
+
+// report is sent by the side that sent the data once it's done, the receiving side answers with its counters
+type report struct {
+	Bytes    int64         `json:"bytes"`
+	Packets  int64         `json:"packets"`
+	Duration time.Duration `json:"duration"`
+}
+
+func (o Options) request() request {
+	req := request{Protocol: ProtocolTCP, Reverse: o.Reverse, Duration: o.Duration}
+	if req.Duration <= 0 {
+		req.Duration = DefaultDuration
+	}
+	if o.UDP {
+		req.Protocol = ProtocolUDP
+		req.Bandwidth = o.Bandwidth
+		if req.Bandwidth <= 0 {
+			req.Bandwidth = DefaultBandwidth
+		}
+	}
+	return req
+}
+
+func writeMessage(conn net.Conn, msg any) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetWriteDeadline(time.Now().Add(controlTimeout)); err != nil {
+		return err
+	}
+	_, err = conn.Write(append(data, '\n'))
+	return err
+}
+
+func readMessage(conn net.Conn, reader *bufio.Reader, timeout time.Duration, msg any) error {
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(line, msg)
+}
+
+// sendStream writes to the TCP connection for the duration and returns the bytes written
+func sendStream(conn net.Conn, duration time.Duration) (int64, error) {
+	buf := make([]byte, 128*1024)
+	deadline := time.Now().Add(duration)
+	if err := conn.SetWriteDeadline(deadline.Add(controlTimeout)); err != nil {
+		return 0, err
+	}
+
+	var sent int64
+	for time.Now().Before(deadline) {
+		n, err := conn.Write(buf)
+		sent += int64(n)
+		if err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// receiveStream reads the TCP stream until the sender closes it and returns the bytes read and the time from the
+// first to the last of them
+func receiveStream(reader io.Reader, conn net.Conn, timeout time.Duration) (int64, time.Duration, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return 0, 0, err
+	}
+
+	buf := make([]byte, 128*1024)
+	var received int64
+	var first, last time.Time
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			last = time.Now()
+			if received == 0 {
+				first = last
+			}
+			received += int64(n)
+		}
+		if err == io.EOF {
+			return received, last.Sub(first), nil
+		}
+		if err != nil {
+			return received, last.Sub(first), err
+		}
+	}
+}
+
+// sendDatagrams paces the datagrams to the bandwidth for the duration and returns the number sent
+func sendDatagrams(conn net.PacketConn, dst net.Addr, bandwidth int64, duration time.Duration) (int64, error) {
+	buf := make([]byte, datagramSize)
+	// a datagram per interval, sent in bursts for the sleeps to stay coarse
+	perSecond := float64(bandwidth) / 8 / datagramSize
+
+	var sent int64
+	start := time.Now()
+	for {
+		elapsed := time.Since(start)
+		if elapsed >= duration {
+			return sent, nil
+		}
+		for due := int64(elapsed.Seconds()*perSecond) + 1; sent < due; sent++ {
+			binary.BigEndian.PutUint64(buf, uint64(sent))
+			if _, err := conn.WriteTo(buf, dst); err != nil {
+				return sent, err
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// datagramCounter counts the datagrams read from the connection until it is closed
+type datagramCounter struct {
+	packets int64
+	bytes   int64
+	first   time.Time
+	last    time.Time
+	// from is the address of the first datagram
+	from chan net.Addr
+	done chan struct{}
+}
+
+func countDatagrams(conn net.PacketConn) *datagramCounter {
+	c := &datagramCounter{from: make(chan net.Addr, 1), done: make(chan struct{})}
+	go func() {
+		defer close(c.done)
+		buf := make([]byte, 64*1024)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			c.last = time.Now()
+			if c.packets == 0 {
+				c.first = c.last
+				c.from <- addr
+			}
+			c.packets++
+			c.bytes += int64(n)
+		}
+	}()
+	return c
+}
+
+// stop waits for the datagrams in flight and closes the connection, the counters are final once it returns
+func (c *datagramCounter) stop(conn net.PacketConn) {
+	time.Sleep(drainTimeout)
+	_ = conn.Close()
+	<-c.done
+}
+
+func (c *datagramCounter) report() report {
+	return report{Bytes: c.bytes, Packets: c.packets, Duration: c.last.Sub(c.first)}
+}
+
+func validateRequest(req request) error {
+	if req.Duration <= 0 || req.Duration > MaxDuration {
+		return fmt.Errorf("the duration must be between 0 and %s", MaxDuration)
+	}
+	switch req.Protocol {
+	case ProtocolTCP:
+	case ProtocolUDP:
+		if req.Bandwidth <= 0 {
+			return fmt.Errorf("the bandwidth of an udp test must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown protocol %q", req.Protocol)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package speedtest
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	server, err := Listen("127.0.0.1:0")
+	require.NoError(t, err)
+	defer server.Close()
+	address := server.Addr().String()
+
+	_, err = Run(context.Background(), address, Options{Duration: 100 * time.Millisecond})
+	assert.ErrorContains(t, err, "no policy", "a peer not allowed by the policies should be refused")
+
+	server.SetAllowed([]net.IP{net.ParseIP("127.0.0.1")})
+
+	testCases := []struct {
+		name      string
+		opts      Options
+		protocol  string
+		direction string
+	}{
+		{"tcp upload", Options{}, ProtocolTCP, DirectionUpload},
+		{"tcp download", Options{Reverse: true}, ProtocolTCP, DirectionDownload},
+		{"udp upload", Options{UDP: true, Bandwidth: 8_000_000}, ProtocolUDP, DirectionUpload},
+		{"udp download", Options{UDP: true, Reverse: true, Bandwidth: 8_000_000}, ProtocolUDP, DirectionDownload},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.Duration = 300 * time.Millisecond
+			result, err := Run(context.Background(), address, tc.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tc.protocol, result.Protocol)
+			assert.Equal(t, tc.direction, result.Direction)
+			assert.Greater(t, result.Bytes, int64(0))
+			assert.Greater(t, result.BitsPerSecond(), float64(0))
+			if tc.opts.UDP {
+				assert.Greater(t, result.PacketsSent, int64(0))
+				assert.LessOrEqual(t, result.PacketsLost, result.PacketsSent)
+			}
+		})
+	}
+
+	_, err = Run(context.Background(), address, Options{Duration: 2 * MaxDuration})
+	assert.Error(t, err)
+}
+
+func TestResult(t *testing.T) {
+	result := &Result{Bytes: 1_250_000, Duration: time.Second, PacketsSent: 200, PacketsLost: 5}
+	assert.Equal(t, float64(10_000_000), result.BitsPerSecond())
+	assert.Equal(t, 2.5, result.LossPercent())
+	assert.Zero(t, (&Result{}).BitsPerSecond())
+}
@@ -10,7 +10,9 @@ import (
 
 // Dial connects to the address on the named network.
 func (n *Net) Dial(network, address string) (net.Conn, error) {
-	return nbnet.NewDialer().Dial(network, address)
+	dialer := nbnet.NewDialer()
+	dialer.Control = n.uplinkControl(dialer.Control)
+	return dialer.Dial(network, address)
 }
 
 // DialUDP connects to the address on the named UDP network.
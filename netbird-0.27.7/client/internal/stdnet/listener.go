@@ -3,18 +3,55 @@ package stdnet
 import (
 	"context"
 	"net"
+	"syscall"
 
 	"github.com/pion/transport/v3"
+	log "github.com/sirupsen/logrus"
 
 	nbnet "github.com/netbirdio/netbird/util/net"
 )
 
 // ListenPacket listens for incoming packets on the given network and address.
 func (n *Net) ListenPacket(network, address string) (net.PacketConn, error) {
-	return nbnet.NewListener().ListenPacket(context.Background(), network, address)
+	listener := nbnet.NewListener()
+	listener.Control = n.uplinkControl(listener.Control)
+	return listener.ListenPacket(context.Background(), network, address)
 }
 
 // ListenUDP acts like ListenPacket for UDP networks.
 func (n *Net) ListenUDP(network string, locAddr *net.UDPAddr) (transport.UDPConn, error) {
-	return nbnet.ListenUDP(network, locAddr)
+	conn, err := nbnet.ListenUDP(network, locAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if sysConn, ok := any(conn).(syscall.Conn); ok && n.uplink != nil {
+		if rawConn, err := sysConn.SyscallConn(); err != nil {
+			log.Debugf("failed to get raw conn: %s", err)
+		} else if err := nbnet.BindToInterface(rawConn, n.uplink.Interface); err != nil {
+			log.Debugf("failed to bind socket to uplink %s: %s", n.uplink, err)
+		}
+	}
+	return conn, nil
+}
+
+// uplinkControl extends the socket control function to pin the socket to the uplink interface.
+// The traffic follows the routing table if pinning fails.
+func (n *Net) uplinkControl(control func(network, address string, c syscall.RawConn) error) func(network, address string, c syscall.RawConn) error {
+	if n.uplink == nil {
+		return control
+	}
+
+	uplink := n.uplink
+	return func(network, address string, c syscall.RawConn) error {
+		if control != nil {
+			if err := control(network, address, c); err != nil {
+				return err
+			}
+		}
+		if err := nbnet.BindToInterface(c, uplink.Interface); err != nil {
+			log.Debugf("failed to bind socket to uplink %s: %s", uplink, err)
+		}
+		return nil
+	}
 }
@@ -5,6 +5,7 @@ package stdnet
 
 import (
 	"fmt"
+	"net/netip"
 
 	"github.com/pion/transport/v3"
 	"github.com/pion/transport/v3/stdnet"
@@ -18,6 +19,10 @@ type Net struct {
 	iFaceDiscover iFaceDiscover
 	// interfaceFilter should return true if the given interfaceName is allowed
 	interfaceFilter func(interfaceName string) bool
+	// uplink restricts the interfaces and the sockets to a single uplink, nil if any uplink can be used
+	uplink *Uplink
+	// excludedSubnets are the subnets the addresses of the interfaces are removed from
+	excludedSubnets []netip.Prefix
 }
 
 // NewNetWithDiscover creates a new StdNet instance.
@@ -51,6 +56,26 @@ func (n *Net) UpdateInterfaces() (err error) {
 	return nil
 }
 
+// SetUplink pins the interfaces and the sockets to the uplink, nil removes the restriction
+func (n *Net) SetUplink(uplink *Uplink) error {
+	n.uplink = uplink
+	return n.UpdateInterfaces()
+}
+
+// SetExcludedSubnets removes the addresses in the subnets from the interfaces, nil removes the restriction
+func (n *Net) SetExcludedSubnets(subnets []netip.Prefix) error {
+	n.excludedSubnets = subnets
+	return n.UpdateInterfaces()
+}
+
+// UplinkInterface returns the name of the interface the sockets are pinned to, empty if there is none
+func (n *Net) UplinkInterface() string {
+	if n == nil || n.uplink == nil {
+		return ""
+	}
+	return n.uplink.Interface
+}
+
 // Interfaces returns a slice of interfaces which are available on the
 // system
 func (n *Net) Interfaces() ([]*transport.Interface, error) {
@@ -84,14 +109,23 @@ func (n *Net) InterfaceByName(name string) (*transport.Interface, error) {
 }
 
 func (n *Net) filterInterfaces(interfaces []*transport.Interface) []*transport.Interface {
-	if n.interfaceFilter == nil {
+	if n.interfaceFilter == nil && n.uplink == nil && len(n.excludedSubnets) == 0 {
 		return interfaces
 	}
 	result := []*transport.Interface{}
 	for _, iface := range interfaces {
-		if n.interfaceFilter(iface.Name) {
-			result = append(result, iface)
+		if n.interfaceFilter != nil && !n.interfaceFilter(iface.Name) {
+			continue
+		}
+		if n.uplink != nil {
+			if iface = n.uplink.filter(iface); iface == nil {
+				continue
+			}
+		}
+		if len(n.excludedSubnets) > 0 {
+			iface = excludeSubnets(iface, n.excludedSubnets)
 		}
+		result = append(result, iface)
 	}
 	return result
 }
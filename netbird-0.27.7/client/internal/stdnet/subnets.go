@@ -0,0 +1,41 @@
+package stdnet
+
+import (
+	"net"
+	"net/netip"
+
+	"github.com/pion/transport/v3"
+)
+
+// excludeSubnets returns the interface without its addresses in the subnets, e.g. a Docker bridge or a guest VLAN
+// that should never be offered as a connection candidate
+func excludeSubnets(iface *transport.Interface, subnets []netip.Prefix) *transport.Interface {
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return iface
+	}
+
+	filtered := transport.NewInterface(iface.Interface)
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			filtered.AddAddress(addr)
+			continue
+		}
+		ip, ok := netip.AddrFromSlice(ipNet.IP)
+		if ok && inSubnets(ip.Unmap(), subnets) {
+			continue
+		}
+		filtered.AddAddress(addr)
+	}
+	return filtered
+}
+
+func inSubnets(ip netip.Addr, subnets []netip.Prefix) bool {
+	for _, subnet := range subnets {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package stdnet
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/pion/transport/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNet_ExcludedSubnets(t *testing.T) {
+	lan := transport.NewInterface(net.Interface{Name: "br-lan"})
+	lan.AddAddress(&net.IPNet{IP: net.ParseIP("192.168.1.1"), Mask: net.CIDRMask(24, 32)})
+	lan.AddAddress(&net.IPNet{IP: net.ParseIP("192.168.3.1"), Mask: net.CIDRMask(24, 32)})
+	lan.AddAddress(&net.IPNet{IP: net.ParseIP("fd00::1"), Mask: net.CIDRMask(64, 128)})
+
+	n := &Net{excludedSubnets: []netip.Prefix{netip.MustParsePrefix("192.168.3.0/24"), netip.MustParsePrefix("fd00::/64")}}
+	filtered := n.filterInterfaces([]*transport.Interface{lan})
+	require.Len(t, filtered, 1)
+	addrs, err := filtered[0].Addrs()
+	require.NoError(t, err)
+	require.Len(t, addrs, 1)
+	assert.Equal(t, "192.168.1.1/24", addrs[0].String())
+}
@@ -0,0 +1,78 @@
+package stdnet
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/pion/transport/v3"
+)
+
+// Uplink is the network interface the NetBird traffic is pinned to on hosts with multiple uplinks
+type Uplink struct {
+	// Interface is the name of the uplink interface
+	Interface string
+	// IP is the local source IP on the interface, nil if all the addresses of the interface are used
+	IP net.IP
+}
+
+// ResolveUplink resolves an interface name or a local source IP to the uplink. An empty value returns nil.
+func ResolveUplink(uplink string) (*Uplink, error) {
+	if uplink == "" {
+		return nil, nil
+	}
+
+	ip := net.ParseIP(uplink)
+	if ip == nil {
+		if _, err := net.InterfaceByName(uplink); err != nil {
+			return nil, fmt.Errorf("uplink interface %s: %w", uplink, err)
+		}
+		return &Uplink{Interface: uplink}, nil
+	}
+
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("list interfaces: %w", err)
+	}
+	for _, iface := range interfaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+				return &Uplink{Interface: iface.Name, IP: ip}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no interface has the uplink address %s", ip)
+}
+
+func (u *Uplink) String() string {
+	if u.IP != nil {
+		return fmt.Sprintf("%s (%s)", u.Interface, u.IP)
+	}
+	return u.Interface
+}
+
+// filter returns the interface restricted to the uplink address, or nil if it isn't the uplink interface
+func (u *Uplink) filter(iface *transport.Interface) *transport.Interface {
+	if iface.Name != u.Interface {
+		return nil
+	}
+	if u.IP == nil {
+		return iface
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil
+	}
+
+	filtered := transport.NewInterface(iface.Interface)
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(u.IP) {
+			filtered.AddAddress(addr)
+		}
+	}
+	return filtered
+}
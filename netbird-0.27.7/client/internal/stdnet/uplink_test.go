@@ -0,0 +1,68 @@
+package stdnet
+
+import (
+	"net"
+	"testing"
+
+	"github.com/pion/transport/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loopbackInterface(t *testing.T) net.Interface {
+	t.Helper()
+
+	interfaces, err := net.Interfaces()
+	require.NoError(t, err)
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			return iface
+		}
+	}
+	t.Skip("no loopback interface")
+	return net.Interface{}
+}
+
+func TestResolveUplink(t *testing.T) {
+	loopback := loopbackInterface(t)
+
+	uplink, err := ResolveUplink("")
+	require.NoError(t, err)
+	assert.Nil(t, uplink)
+
+	uplink, err = ResolveUplink(loopback.Name)
+	require.NoError(t, err)
+	assert.Equal(t, &Uplink{Interface: loopback.Name}, uplink)
+
+	uplink, err = ResolveUplink("127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, loopback.Name, uplink.Interface)
+	assert.True(t, uplink.IP.Equal(net.ParseIP("127.0.0.1")))
+
+	_, err = ResolveUplink("nonexistent0")
+	assert.Error(t, err)
+
+	_, err = ResolveUplink("192.0.2.123")
+	assert.Error(t, err)
+}
+
+func TestUplink_Filter(t *testing.T) {
+	wan := transport.NewInterface(net.Interface{Name: "wan"})
+	wan.AddAddress(&net.IPNet{IP: net.ParseIP("192.0.2.1"), Mask: net.CIDRMask(24, 32)})
+	wan.AddAddress(&net.IPNet{IP: net.ParseIP("192.0.2.2"), Mask: net.CIDRMask(24, 32)})
+	wan2 := transport.NewInterface(net.Interface{Name: "wan2"})
+
+	n := &Net{}
+	assert.Len(t, n.filterInterfaces([]*transport.Interface{wan, wan2}), 2)
+
+	n.uplink = &Uplink{Interface: "wan2"}
+	assert.Equal(t, []*transport.Interface{wan2}, n.filterInterfaces([]*transport.Interface{wan, wan2}))
+
+	n.uplink = &Uplink{Interface: "wan", IP: net.ParseIP("192.0.2.2")}
+	filtered := n.filterInterfaces([]*transport.Interface{wan, wan2})
+	require.Len(t, filtered, 1)
+	addrs, err := filtered[0].Addrs()
+	require.NoError(t, err)
+	require.Len(t, addrs, 1)
+	assert.Equal(t, "192.0.2.2/24", addrs[0].String())
+}
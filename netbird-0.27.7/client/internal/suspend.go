@@ -0,0 +1,60 @@
+package internal
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// Suspend closes the connections before the system sleeps. The Signal and Management streams, the background
+// tasks and the peer connections are stopped and the WireGuard interface is held with its sockets closed.
+// The interface keeps its address and routes, so no traffic leaks while sleeping.
+func (e *Engine) Suspend() {
+	e.syncMsgMux.Lock()
+	defer e.syncMsgMux.Unlock()
+
+	if e.suspended {
+		return
+	}
+
+	log.Infof("suspending the engine")
+
+	// the links going down while sleeping must not restart the engine
+	e.networkWatcher.Stop()
+
+	if e.cancel != nil {
+		e.cancel()
+	}
+
+	if err := e.removeAllPeers(); err != nil {
+		log.Warnf("failed to remove the peers: %v", err)
+	}
+
+	if e.wgInterface != nil {
+		if err := e.wgInterface.Suspend(); err != nil {
+			log.Warnf("failed to suspend the interface %s: %v", e.wgInterface.Name(), err)
+		}
+	}
+
+	e.suspended = true
+}
+
+// Resume reconnects right after the system woke up instead of waiting for the keepalives and the backoff of the
+// Signal and Management streams to notice the dead connections. The engine is restarted, which rebinds the
+// sockets, reconnects the streams immediately and revalidates the routes in the network the system woke up in.
+func (e *Engine) Resume() {
+	e.syncMsgMux.Lock()
+	suspended := e.suspended
+	e.suspended = false
+	e.syncMsgMux.Unlock()
+
+	if !suspended {
+		return
+	}
+
+	log.Infof("resuming the engine")
+	if err := e.Stop(); err != nil {
+		log.Errorf("failed to stop the engine: %v", err)
+	}
+	if err := e.Start(); err != nil {
+		log.Errorf("failed to start the engine: %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/client/internal/networkmonitor"
+	"github.com/netbirdio/netbird/client/internal/peer"
+)
+
+func TestEngine_Suspend(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine := &Engine{
+		ctx:            ctx,
+		cancel:         cancel,
+		syncMsgMux:     &sync.Mutex{},
+		peerConns:      make(map[string]*peer.Conn),
+		networkWatcher: networkmonitor.New(),
+	}
+
+	engine.Suspend()
+	assert.True(t, engine.suspended, "the engine should be suspended")
+	assert.Error(t, ctx.Err(), "the engine context should be cancelled")
+
+	// a second sleep event must not touch the suspended engine
+	engine.Suspend()
+	assert.True(t, engine.suspended)
+}
+
+func TestEngine_ResumeNotSuspended(t *testing.T) {
+	engine := &Engine{
+		syncMsgMux: &sync.Mutex{},
+	}
+
+	// resuming an engine that didn't suspend is a no-op, the engine isn't restarted
+	engine.Resume()
+	assert.False(t, engine.suspended)
+}
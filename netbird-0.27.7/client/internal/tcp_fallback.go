@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+	"github.com/netbirdio/netbird/client/internal/relay"
+	"github.com/netbirdio/netbird/client/internal/stdnet"
+	"github.com/netbirdio/netbird/client/internal/tcptransport"
+	sProto "github.com/netbirdio/netbird/signal/proto"
+)
+
+// udpBlockedCheckInterval is how often the STUN servers are probed to detect whether UDP is blocked
+const udpBlockedCheckInterval = 5 * time.Minute
+
+// runUDPBlockedDetection probes the STUN servers periodically. When none of them answers, UDP is considered blocked
+// and the peers are asked to carry the WireGuard traffic over the TCP transport.
+// The addresses of the TCP listener advertised to the peers are refreshed at the same time.
+func (e *Engine) runUDPBlockedDetection(transport *tcptransport.Transport) {
+	ticker := time.NewTicker(udpBlockedCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		e.checkUDPBlocked(transport)
+
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Engine) checkUDPBlocked(transport *tcptransport.Transport) {
+	e.syncMsgMux.Lock()
+	stuns := slices.Clone(e.STUNs)
+	e.syncMsgMux.Unlock()
+
+	results := relay.ProbeAll(e.ctx, relay.ProbeSTUN, stuns)
+	if e.ctx.Err() != nil {
+		return
+	}
+
+	var publicIPs []net.IP
+	for _, res := range results {
+		if res.Err != nil {
+			continue
+		}
+		if host, _, err := net.SplitHostPort(res.Addr); err == nil {
+			if ip := net.ParseIP(host); ip != nil {
+				publicIPs = append(publicIPs, ip)
+			}
+		}
+	}
+
+	blocked := len(results) > 0 && len(publicIPs) == 0
+	if transport.SetUDPBlocked(blocked) {
+		if blocked {
+			log.Warnf("none of the STUN servers is reachable over UDP, asking peers to connect over tcp")
+		} else {
+			log.Infof("STUN servers are reachable over UDP again")
+		}
+	}
+
+	transport.SetAdvertisedIPs(append(publicIPs, e.tcpFallbackLocalIPs()...))
+}
+
+// tcpFallbackLocalIPs returns the configured external IPs and the IPs of the local interfaces
+func (e *Engine) tcpFallbackLocalIPs() []net.IP {
+	var ips []net.IP
+	for _, external := range e.config.NATExternalIPs {
+		external, _, _ = strings.Cut(external, "/")
+		if ip := net.ParseIP(external); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		log.Debugf("failed to list interfaces: %s", err)
+		return ips
+	}
+
+	allowed := stdnet.InterfaceFilter(e.config.IFaceBlackList)
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		if iface.Name == e.config.WgIfaceName || !allowed(iface.Name) {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || !ipNet.IP.IsGlobalUnicast() {
+				continue
+			}
+			if ipNet.IP.To4() == nil && e.config.DisableIPv6Discovery {
+				continue
+			}
+			ips = append(ips, ipNet.IP)
+		}
+	}
+	return ips
+}
+
+func tcpFallbackFromSignal(fallback *sProto.TCPFallback) *peer.TCPFallback {
+	if fallback == nil {
+		return nil
+	}
+	return &peer.TCPFallback{
+		Addresses:  fallback.GetAddresses(),
+		UDPBlocked: fallback.GetUdpBlocked(),
+	}
+}
+
+func (e *Engine) startTCPFallback() {
+	e.tcpTransport = tcptransport.NewTransport(e.config.TCPFallbackPort)
+	go e.runUDPBlockedDetection(e.tcpTransport)
+}
@@ -0,0 +1,100 @@
+package tcptransport
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+)
+
+const (
+	// maxFrameSize is the size of the largest datagram carried over the connection
+	maxFrameSize    = 1<<16 - 1
+	frameHeaderSize = 2
+)
+
+var errFrameTooLarge = errors.New("datagram exceeds the maximum frame size")
+
+// Conn carries datagrams over a TCP connection, each datagram is prefixed with its length.
+// Reads return a single datagram, a datagram larger than the read buffer is truncated like on a UDP socket.
+type Conn struct {
+	net.Conn
+	reader *bufio.Reader
+
+	writeMu sync.Mutex
+
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+// NewConn wraps the stream connection
+func NewConn(conn net.Conn) *Conn {
+	return &Conn{
+		Conn:   conn,
+		reader: bufio.NewReaderSize(conn, maxFrameSize+frameHeaderSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// Read reads the next datagram
+func (c *Conn) Read(b []byte) (int, error) {
+	var header [frameHeaderSize]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		c.markDone()
+		return 0, err
+	}
+
+	size := int(binary.BigEndian.Uint16(header[:]))
+	n := min(size, len(b))
+	if _, err := io.ReadFull(c.reader, b[:n]); err != nil {
+		c.markDone()
+		return 0, err
+	}
+
+	if size > n {
+		if _, err := c.reader.Discard(size - n); err != nil {
+			c.markDone()
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// Write writes the datagram in a single frame
+func (c *Conn) Write(b []byte) (int, error) {
+	if len(b) > maxFrameSize {
+		return 0, errFrameTooLarge
+	}
+
+	var header [frameHeaderSize]byte
+	binary.BigEndian.PutUint16(header[:], uint16(len(b)))
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	buffers := net.Buffers{header[:], b}
+	if _, err := buffers.WriteTo(c.Conn); err != nil {
+		c.markDone()
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close closes the underlying connection
+func (c *Conn) Close() error {
+	c.markDone()
+	return c.Conn.Close()
+}
+
+// Done returns a channel that is closed when the connection is closed or broken
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *Conn) markDone() {
+	c.doneOnce.Do(func() {
+		close(c.done)
+	})
+}
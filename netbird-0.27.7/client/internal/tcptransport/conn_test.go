@@ -0,0 +1,82 @@
+package tcptransport
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConn_ReadWrite(t *testing.T) {
+	left, right := net.Pipe()
+	sender := NewConn(left)
+	receiver := NewConn(right)
+	defer sender.Close()
+	defer receiver.Close()
+
+	datagrams := [][]byte{
+		[]byte("first"),
+		bytes.Repeat([]byte{1}, 1420),
+		{},
+		[]byte("last"),
+	}
+
+	go func() {
+		for _, d := range datagrams {
+			_, _ = sender.Write(d)
+		}
+	}()
+
+	buf := make([]byte, 1500)
+	for _, expected := range datagrams {
+		n, err := receiver.Read(buf)
+		require.NoError(t, err)
+		require.Equal(t, expected, buf[:n])
+	}
+}
+
+func TestConn_ReadTruncates(t *testing.T) {
+	left, right := net.Pipe()
+	sender := NewConn(left)
+	receiver := NewConn(right)
+	defer sender.Close()
+	defer receiver.Close()
+
+	go func() {
+		_, _ = sender.Write([]byte("a long datagram"))
+		_, _ = sender.Write([]byte("next"))
+	}()
+
+	buf := make([]byte, 6)
+	n, err := receiver.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "a long", string(buf[:n]))
+
+	n, err = receiver.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "next", string(buf[:n]))
+}
+
+func TestConn_WriteTooLarge(t *testing.T) {
+	left, right := net.Pipe()
+	conn := NewConn(left)
+	defer conn.Close()
+	defer right.Close()
+
+	_, err := conn.Write(make([]byte, maxFrameSize+1))
+	require.ErrorIs(t, err, errFrameTooLarge)
+}
+
+func TestConn_Done(t *testing.T) {
+	left, right := net.Pipe()
+	sender := NewConn(left)
+	receiver := NewConn(right)
+
+	require.NoError(t, sender.Close())
+	_, err := receiver.Read(make([]byte, 10))
+	require.Error(t, err)
+
+	<-sender.Done()
+	<-receiver.Done()
+}
@@ -0,0 +1,263 @@
+package tcptransport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	nbnet "github.com/netbirdio/netbird/util/net"
+)
+
+const (
+	handshakePrefix = "NBTCP1"
+	handshakeAck    = "OK"
+
+	// handshakeTimeout limits the time a connecting peer has to send its handshake
+	handshakeTimeout = 10 * time.Second
+	// pendingTimeout limits the time an authenticated connection waits for the local peer connection to accept it
+	pendingTimeout = 10 * time.Second
+)
+
+var errInvalidHandshake = errors.New("invalid handshake")
+
+type waiter struct {
+	token string
+	ch    chan *Conn
+}
+
+type pendingConn struct {
+	token string
+	conn  *Conn
+	timer *time.Timer
+}
+
+// Listener accepts TCP connections from remote peers and hands them over to the peer connections waiting for them.
+// A connecting peer identifies itself with its public key and a token that both peers know from the current
+// offer/answer exchange.
+type Listener struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	waiters map[string]*waiter
+	pending map[string]*pendingConn
+}
+
+// NewListener starts listening on the given TCP port
+func NewListener(port int) (*Listener, error) {
+	listener, err := nbnet.NewListener().Listen(context.Background(), "tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, fmt.Errorf("listen on tcp port %d: %w", port, err)
+	}
+
+	l := &Listener{
+		listener: listener,
+		waiters:  make(map[string]*waiter),
+		pending:  make(map[string]*pendingConn),
+	}
+	go l.acceptLoop()
+
+	return l, nil
+}
+
+// Port returns the port the listener is bound to
+func (l *Listener) Port() int {
+	return l.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Accept waits for the remote peer to connect with the given token
+func (l *Listener) Accept(ctx context.Context, remoteKey, token string) (*Conn, error) {
+	l.mu.Lock()
+	if p, ok := l.pending[remoteKey]; ok && p.token == token {
+		delete(l.pending, remoteKey)
+		p.timer.Stop()
+		l.mu.Unlock()
+		return p.conn, nil
+	}
+
+	w := &waiter{
+		token: token,
+		ch:    make(chan *Conn, 1),
+	}
+	l.waiters[remoteKey] = w
+	l.mu.Unlock()
+
+	select {
+	case conn := <-w.ch:
+		return conn, nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		if l.waiters[remoteKey] == w {
+			delete(l.waiters, remoteKey)
+		}
+		l.mu.Unlock()
+
+		// the connection might have been delivered right before the removal
+		select {
+		case conn := <-w.ch:
+			_ = conn.Close()
+		default:
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// Close stops the listener and closes the connections nobody has accepted yet
+func (l *Listener) Close() error {
+	err := l.listener.Close()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, p := range l.pending {
+		p.timer.Stop()
+		_ = p.conn.Close()
+		delete(l.pending, key)
+	}
+	return err
+}
+
+func (l *Listener) acceptLoop() {
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Warnf("failed to accept tcp transport connection: %s", err)
+			continue
+		}
+		go l.handshake(conn)
+	}
+}
+
+func (l *Listener) handshake(conn net.Conn) {
+	framed := NewConn(conn)
+	if err := conn.SetDeadline(time.Now().Add(handshakeTimeout)); err != nil {
+		log.Debugf("failed to set handshake deadline: %s", err)
+	}
+
+	key, token, err := readHandshake(framed)
+	if err != nil {
+		log.Debugf("tcp transport handshake from %s failed: %s", conn.RemoteAddr(), err)
+		_ = framed.Close()
+		return
+	}
+
+	if _, err := framed.Write([]byte(handshakeAck)); err != nil {
+		log.Debugf("failed to acknowledge tcp transport handshake from %s: %s", conn.RemoteAddr(), err)
+		_ = framed.Close()
+		return
+	}
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		log.Debugf("failed to reset handshake deadline: %s", err)
+	}
+
+	l.deliver(key, token, framed)
+}
+
+func (l *Listener) deliver(key, token string, conn *Conn) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if w, ok := l.waiters[key]; ok {
+		if w.token != token {
+			log.Debugf("closing tcp transport connection of peer %s with a stale token", key)
+			_ = conn.Close()
+			return
+		}
+		delete(l.waiters, key)
+		w.ch <- conn
+		return
+	}
+
+	if old, ok := l.pending[key]; ok {
+		old.timer.Stop()
+		_ = old.conn.Close()
+	}
+
+	p := &pendingConn{
+		token: token,
+		conn:  conn,
+	}
+	p.timer = time.AfterFunc(pendingTimeout, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.pending[key] == p {
+			delete(l.pending, key)
+			_ = conn.Close()
+		}
+	})
+	l.pending[key] = p
+}
+
+// Dial connects to the first reachable address of the remote peer and authenticates with the given token
+func Dial(ctx context.Context, addresses []string, localKey, token string) (*Conn, error) {
+	var errs []error
+	for _, addr := range addresses {
+		conn, err := dial(ctx, addr, localKey, token)
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, fmt.Errorf("dial tcp transport: %w", errors.Join(errs...))
+}
+
+func dial(ctx context.Context, addr, localKey, token string) (*Conn, error) {
+	conn, err := nbnet.NewDialer().DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			log.Debugf("failed to set handshake deadline: %s", err)
+		}
+	}
+
+	framed := NewConn(conn)
+	if _, err := framed.Write([]byte(fmt.Sprintf("%s %s %s", handshakePrefix, localKey, token))); err != nil {
+		_ = framed.Close()
+		return nil, fmt.Errorf("send handshake: %w", err)
+	}
+
+	buf := make([]byte, len(handshakeAck))
+	n, err := framed.Read(buf)
+	if err != nil {
+		_ = framed.Close()
+		return nil, fmt.Errorf("read handshake acknowledgement: %w", err)
+	}
+	if string(buf[:n]) != handshakeAck {
+		_ = framed.Close()
+		return nil, errInvalidHandshake
+	}
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		log.Debugf("failed to reset handshake deadline: %s", err)
+	}
+	return framed, nil
+}
+
+func readHandshake(conn *Conn) (string, string, error) {
+	buf := make([]byte, 256)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return "", "", err
+	}
+
+	fields := strings.Fields(string(buf[:n]))
+	if len(fields) != 3 || fields[0] != handshakePrefix {
+		return "", "", errInvalidHandshake
+	}
+	return fields[1], fields[2], nil
+}
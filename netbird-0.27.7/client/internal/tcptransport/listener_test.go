@@ -0,0 +1,121 @@
+package tcptransport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListener_DialAccept(t *testing.T) {
+	listener, err := NewListener(0)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	addr := fmt.Sprintf("127.0.0.1:%d", listener.Port())
+
+	testCases := []struct {
+		name         string
+		acceptFirst  bool
+		acceptToken  string
+		dialToken    string
+		expectAccept bool
+	}{
+		{
+			name:         "accept before dial",
+			acceptFirst:  true,
+			acceptToken:  "token1",
+			dialToken:    "token1",
+			expectAccept: true,
+		},
+		{
+			name:         "dial before accept",
+			acceptToken:  "token2",
+			dialToken:    "token2",
+			expectAccept: true,
+		},
+		{
+			name:        "token mismatch",
+			acceptFirst: true,
+			acceptToken: "token3",
+			dialToken:   "stale",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			type result struct {
+				conn *Conn
+				err  error
+			}
+			accepted := make(chan result, 1)
+			accept := func() {
+				conn, err := listener.Accept(ctx, "dialer", tc.acceptToken)
+				accepted <- result{conn, err}
+			}
+
+			if tc.acceptFirst {
+				go accept()
+				// give the acceptor time to register
+				time.Sleep(50 * time.Millisecond)
+			}
+
+			dialed, err := Dial(ctx, []string{"127.0.0.1:1", addr}, "dialer", tc.dialToken)
+			require.NoError(t, err)
+			defer dialed.Close()
+
+			if !tc.acceptFirst {
+				go accept()
+			}
+
+			res := <-accepted
+			if !tc.expectAccept {
+				require.ErrorIs(t, res.err, context.DeadlineExceeded)
+				select {
+				case <-dialed.Done():
+				case <-time.After(time.Second):
+					_, err := dialed.Read(make([]byte, 10))
+					require.Error(t, err)
+				}
+				return
+			}
+
+			require.NoError(t, res.err)
+			defer res.conn.Close()
+
+			_, err = dialed.Write([]byte("ping"))
+			require.NoError(t, err)
+
+			buf := make([]byte, 10)
+			n, err := res.conn.Read(buf)
+			require.NoError(t, err)
+			require.Equal(t, "ping", string(buf[:n]))
+		})
+	}
+}
+
+func TestTransport_Addresses(t *testing.T) {
+	transport := NewTransport(0)
+	defer transport.Close()
+
+	transport.SetAdvertisedIPs(nil)
+	require.Empty(t, transport.Addresses())
+
+	transport.SetAdvertisedIPs([]net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::1")})
+	port := transport.listener.Port()
+	require.Equal(t, []string{
+		fmt.Sprintf("192.0.2.1:%d", port),
+		fmt.Sprintf("[2001:db8::1]:%d", port),
+	}, transport.Addresses())
+
+	require.False(t, transport.UDPBlocked())
+	require.True(t, transport.SetUDPBlocked(true))
+	require.False(t, transport.SetUDPBlocked(true))
+	require.True(t, transport.UDPBlocked())
+}
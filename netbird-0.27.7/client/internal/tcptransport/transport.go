@@ -0,0 +1,100 @@
+package tcptransport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var errNotListening = errors.New("tcp transport is not listening")
+
+// Transport carries the WireGuard traffic of peers over TCP when UDP is blocked
+type Transport struct {
+	listener   *Listener
+	udpBlocked atomic.Bool
+
+	mu        sync.Mutex
+	addresses []string
+}
+
+// NewTransport creates a transport listening on the given port. When the port can't be bound,
+// the transport only dials out to the remote peers.
+func NewTransport(port int) *Transport {
+	t := &Transport{}
+
+	listener, err := NewListener(port)
+	if err != nil {
+		log.Warnf("tcp transport fallback will only dial out to peers: %s", err)
+		return t
+	}
+	t.listener = listener
+	log.Infof("tcp transport fallback listening on port %d", listener.Port())
+
+	return t
+}
+
+// SetUDPBlocked records whether UDP traffic to the outside is blocked and reports whether the state changed
+func (t *Transport) SetUDPBlocked(blocked bool) bool {
+	return t.udpBlocked.Swap(blocked) != blocked
+}
+
+// UDPBlocked reports whether UDP traffic to the outside is blocked
+func (t *Transport) UDPBlocked() bool {
+	return t.udpBlocked.Load()
+}
+
+// SetAdvertisedIPs sets the IPs the remote peers can use to reach the listener
+func (t *Transport) SetAdvertisedIPs(ips []net.IP) {
+	if t.listener == nil {
+		return
+	}
+
+	port := strconv.Itoa(t.listener.Port())
+	seen := make(map[string]struct{})
+	addresses := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addr := net.JoinHostPort(ip.String(), port)
+		if _, ok := seen[addr]; ok {
+			continue
+		}
+		seen[addr] = struct{}{}
+		addresses = append(addresses, addr)
+	}
+
+	t.mu.Lock()
+	t.addresses = addresses
+	t.mu.Unlock()
+}
+
+// Addresses returns the addresses the remote peers can connect to
+func (t *Transport) Addresses() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.addresses...)
+}
+
+// Accept waits for the remote peer to connect
+func (t *Transport) Accept(ctx context.Context, remoteKey, token string) (*Conn, error) {
+	if t.listener == nil {
+		return nil, errNotListening
+	}
+	return t.listener.Accept(ctx, remoteKey, token)
+}
+
+// Dial connects to the remote peer
+func (t *Transport) Dial(ctx context.Context, addresses []string, localKey, token string) (*Conn, error) {
+	return Dial(ctx, addresses, localKey, token)
+}
+
+// Close stops the listener
+func (t *Transport) Close() error {
+	if t.listener == nil {
+		return nil
+	}
+	return t.listener.Close()
+}
@@ -0,0 +1,284 @@
+// Package uci reads and writes the config files of the OpenWrt Unified Configuration Interface, e.g. /etc/config/netbird
+package uci
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Section is a config section of a UCI file
+type Section struct {
+	// Type is the section type, e.g. netbird in "config netbird 'main'"
+	Type string
+	// Name is the section name, empty for anonymous sections
+	Name string
+
+	options []option
+}
+
+// option is an option or a list of a section, a list keeps all its values
+type option struct {
+	name   string
+	values []string
+	list   bool
+}
+
+// File is the content of a UCI config file
+type File struct {
+	Sections []*Section
+}
+
+// ReadFile parses the UCI config file at path
+func ReadFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	file, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// Parse parses the content of a UCI config file
+func Parse(r io.Reader) (*File, error) {
+	file := &File{}
+	var section *Section
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		tokens, err := tokenize(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+
+		switch tokens[0] {
+		case "package":
+		case "config":
+			if len(tokens) < 2 || len(tokens) > 3 {
+				return nil, fmt.Errorf("line %d: expected config <type> [name]", line)
+			}
+			section = &Section{Type: tokens[1]}
+			if len(tokens) == 3 {
+				section.Name = tokens[2]
+			}
+			file.Sections = append(file.Sections, section)
+		case "option", "list":
+			if section == nil {
+				return nil, fmt.Errorf("line %d: %s outside of a config section", line, tokens[0])
+			}
+			if len(tokens) != 3 {
+				return nil, fmt.Errorf("line %d: expected %s <name> <value>", line, tokens[0])
+			}
+			if tokens[0] == "option" {
+				section.Set(tokens[1], tokens[2])
+			} else {
+				section.AddList(tokens[1], tokens[2])
+			}
+		default:
+			return nil, fmt.Errorf("line %d: unknown keyword %q", line, tokens[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// tokenize splits a line into its words, unquoting the quoted words and dropping the comments
+func tokenize(line string) ([]string, error) {
+	var tokens []string
+	var token strings.Builder
+	inToken := false
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t':
+			if inToken {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inToken = false
+			}
+		case c == '#' && !inToken:
+			return tokens, nil
+		case c == '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote")
+			}
+			token.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+			inToken = true
+		case c == '"':
+			i++
+			for ; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) {
+					i++
+				}
+				token.WriteByte(line[i])
+			}
+			if i >= len(line) {
+				return nil, fmt.Errorf("unterminated quote")
+			}
+			inToken = true
+		case c == '\\' && i+1 < len(line):
+			i++
+			token.WriteByte(line[i])
+			inToken = true
+		default:
+			token.WriteByte(c)
+			inToken = true
+		}
+	}
+	if inToken {
+		tokens = append(tokens, token.String())
+	}
+
+	return tokens, nil
+}
+
+// Section returns the section of the given type and name, with an empty name the first section of the type
+func (f *File) Section(typ, name string) *Section {
+	for _, s := range f.Sections {
+		if s.Type == typ && (name == "" || s.Name == name) {
+			return s
+		}
+	}
+	return nil
+}
+
+// AddSection appends a new section of the given type and name
+func (f *File) AddSection(typ, name string) *Section {
+	s := &Section{Type: typ, Name: name}
+	f.Sections = append(f.Sections, s)
+	return s
+}
+
+// Get returns the value of an option, ok is false when the option isn't set
+func (s *Section) Get(name string) (value string, ok bool) {
+	for _, o := range s.options {
+		if o.name == name && !o.list {
+			return o.values[0], true
+		}
+	}
+	return "", false
+}
+
+// List returns the values of a list, nil when the list isn't set
+func (s *Section) List(name string) []string {
+	for _, o := range s.options {
+		if o.name == name && o.list {
+			return o.values
+		}
+	}
+	return nil
+}
+
+// Set sets the value of an option, keeping its position when it already exists
+func (s *Section) Set(name, value string) {
+	for i, o := range s.options {
+		if o.name == name {
+			s.options[i] = option{name: name, values: []string{value}}
+			return
+		}
+	}
+	s.options = append(s.options, option{name: name, values: []string{value}})
+}
+
+// AddList appends a value to a list
+func (s *Section) AddList(name, value string) {
+	for i, o := range s.options {
+		if o.name == name && o.list {
+			s.options[i].values = append(o.values, value)
+			return
+		}
+	}
+	s.options = append(s.options, option{name: name, values: []string{value}, list: true})
+}
+
+// SetList replaces the values of a list, an empty list removes it
+func (s *Section) SetList(name string, values []string) {
+	s.Delete(name)
+	for _, v := range values {
+		s.AddList(name, v)
+	}
+}
+
+// Delete removes an option or a list
+func (s *Section) Delete(name string) {
+	options := s.options[:0]
+	for _, o := range s.options {
+		if o.name != name {
+			options = append(options, o)
+		}
+	}
+	s.options = options
+}
+
+// Marshal returns the content of the file in the format written by the uci command
+func (f *File) Marshal() []byte {
+	var b bytes.Buffer
+	for i, s := range f.Sections {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString("config " + s.Type)
+		if s.Name != "" {
+			b.WriteString(" " + quote(s.Name))
+		}
+		b.WriteByte('\n')
+
+		for _, o := range s.options {
+			keyword := "option"
+			if o.list {
+				keyword = "list"
+			}
+			for _, v := range o.values {
+				fmt.Fprintf(&b, "\t%s %s %s\n", keyword, o.name, quote(v))
+			}
+		}
+	}
+	return b.Bytes()
+}
+
+// quote quotes a value in single quotes the way the uci command does
+func quote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// WriteFile writes the file to path, replacing it atomically
+func (f *File) WriteFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(f.Marshal()); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
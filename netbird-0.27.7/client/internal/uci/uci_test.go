@@ -0,0 +1,94 @@
+package uci
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `
+# NetBird client
+config netbird 'main'
+	option management_url 'https://netbird.example.com:443'
+	option interface "wt0" # comment
+	option hostname 'it'\''s router'
+	list blacklist 'br-lan'
+	list blacklist wan
+
+config route
+	option enabled 0
+`
+
+func TestParse(t *testing.T) {
+	file, err := Parse(strings.NewReader(testConfig))
+	require.NoError(t, err)
+	require.Len(t, file.Sections, 2)
+
+	main := file.Section("netbird", "main")
+	require.NotNil(t, main)
+
+	value, ok := main.Get("management_url")
+	require.True(t, ok)
+	require.Equal(t, "https://netbird.example.com:443", value)
+
+	value, _ = main.Get("interface")
+	require.Equal(t, "wt0", value)
+
+	value, _ = main.Get("hostname")
+	require.Equal(t, "it's router", value)
+
+	require.Equal(t, []string{"br-lan", "wan"}, main.List("blacklist"))
+
+	_, ok = main.Get("blacklist")
+	require.False(t, ok, "lists should not be read as options")
+
+	route := file.Section("route", "")
+	require.NotNil(t, route)
+	value, _ = route.Get("enabled")
+	require.Equal(t, "0", value)
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, content := range []string{
+		"option foo 'bar'",
+		"config netbird 'main'\n\toption foo 'bar",
+		"config netbird 'main'\n\tfoo bar",
+		"config netbird 'main'\n\toption foo",
+	} {
+		_, err := Parse(strings.NewReader(content))
+		require.Error(t, err, content)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	file, err := Parse(strings.NewReader(testConfig))
+	require.NoError(t, err)
+
+	main := file.Section("netbird", "main")
+	main.Set("interface", "wt1")
+	main.Set("port", "51821")
+	main.SetList("blacklist", []string{"lan"})
+	main.Delete("management_url")
+
+	path := filepath.Join(t.TempDir(), "netbird")
+	require.NoError(t, file.WriteFile(path))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, `config netbird 'main'
+	option interface 'wt1'
+	option hostname 'it'\''s router'
+	option port '51821'
+	list blacklist 'lan'
+
+config route
+	option enabled '0'
+`, string(content))
+
+	read, err := ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, file.Marshal(), read.Marshal())
+}
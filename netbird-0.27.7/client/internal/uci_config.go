@@ -0,0 +1,377 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/client/internal/uci"
+)
+
+const (
+	// DefaultUCIConfigPath is the UCI config of the client on OpenWrt
+	DefaultUCIConfigPath = "/etc/config/netbird"
+
+	// uciSectionType is the type of the UCI section holding the client settings, e.g. "config netbird 'main'"
+	uciSectionType = "netbird"
+	// uciDefaultSectionName is the name of the UCI section of the default profile, the sections of the other
+	// profiles are named after them, e.g. "config netbird 'work'"
+	uciDefaultSectionName = "main"
+
+	uciManagementURL       = "management_url"
+	uciAdminURL            = "admin_url"
+	uciSetupKeyFile        = "setup_key_file"
+	uciInterface           = "interface"
+	uciPort                = "port"
+	uciDisableDNS          = "disable_dns"
+	uciDisableClientRoutes = "disable_client_routes"
+	uciControlPlane        = "control_plane"
+	uciLowMemory           = "low_memory"
+	uciDisableSysctl       = "disable_sysctl"
+	uciICEDisableCandidate = "ice_disable_candidate"
+	uciICEExcludeSubnet    = "ice_exclude_subnet"
+	uciICEFailedTimeout    = "ice_failed_timeout"
+	uciICEDisconnTimeout   = "ice_disconnected_timeout"
+	uciSSHRecordingDir     = "ssh_recording_dir"
+	uciSSHRecordingRet     = "ssh_recording_retention"
+	uciStageNetChanges     = "stage_network_changes"
+	uciStagedAutoApply     = "staged_changes_auto_apply"
+	uciAutoUpdate          = "auto_update"
+	uciUpdatePublicKey     = "update_public_key"
+	uciUpdateDowngrade     = "update_allow_downgrade"
+	uciInventoryField      = "inventory_field"
+	uciInventoryPackage    = "inventory_package"
+	uciDNSHost             = "dns_host"
+	uciLogFormat           = "log_format"
+	uciLogComponent        = "log_component"
+	uciLogMaxSize          = "log_max_size"
+	uciLogMaxBackups       = "log_max_backups"
+	uciLogRotationInterval = "log_rotation_interval"
+)
+
+// readUCISection returns the section of the settings of the profile in the UCI config, nil when the config or the
+// section doesn't exist. The default profile falls back to the first section of the config when it has no main section
+func readUCISection(path, profile string) (*uci.File, *uci.Section, error) {
+	file, err := uci.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("read UCI config: %w", err)
+	}
+
+	if profile != "" {
+		return file, file.Section(uciSectionType, profile), nil
+	}
+	if section := file.Section(uciSectionType, uciDefaultSectionName); section != nil {
+		return file, section, nil
+	}
+	return file, file.Section(uciSectionType, ""), nil
+}
+
+// uciSectionName returns the name of the UCI section of the profile
+func uciSectionName(profile string) string {
+	if profile == "" {
+		return uciDefaultSectionName
+	}
+	return profile
+}
+
+// applyUCIConfig fills the settings of the input not set by the caller with the options of the UCI config.
+// The settings stay the ones of the JSON config when the UCI config doesn't exist
+func applyUCIConfig(input *ConfigInput) error {
+	if input.UCIConfigPath == "" {
+		return nil
+	}
+
+	_, section, err := readUCISection(input.UCIConfigPath, input.Profile)
+	if err != nil || section == nil {
+		return err
+	}
+
+	if v, ok := section.Get(uciManagementURL); ok && input.ManagementURL == "" {
+		input.ManagementURL = v
+	}
+	if v, ok := section.Get(uciAdminURL); ok && input.AdminURL == "" {
+		input.AdminURL = v
+	}
+	if v, ok := section.Get(uciInterface); ok && input.InterfaceName == nil {
+		input.InterfaceName = &v
+	}
+	if v, ok := section.Get(uciPort); ok && input.WireguardPort == nil {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid UCI option %s %q", uciPort, v)
+		}
+		input.WireguardPort = &port
+	}
+	if v, ok := section.Get(uciDisableDNS); ok && input.DisableDNS == nil {
+		disabled, err := parseUCIBool(uciDisableDNS, v)
+		if err != nil {
+			return err
+		}
+		input.DisableDNS = &disabled
+	}
+	if v, ok := section.Get(uciDisableClientRoutes); ok && input.DisableClientRoutes == nil {
+		disabled, err := parseUCIBool(uciDisableClientRoutes, v)
+		if err != nil {
+			return err
+		}
+		input.DisableClientRoutes = &disabled
+	}
+	if v, ok := section.Get(uciLowMemory); ok && input.LowMemory == nil {
+		enabled, err := parseUCIBool(uciLowMemory, v)
+		if err != nil {
+			return err
+		}
+		input.LowMemory = &enabled
+	}
+	if v, ok := section.Get(uciDisableSysctl); ok && input.DisableSysctl == nil {
+		disabled, err := parseUCIBool(uciDisableSysctl, v)
+		if err != nil {
+			return err
+		}
+		input.DisableSysctl = &disabled
+	}
+	if v := section.List(uciControlPlane); v != nil && input.ControlPlane == nil {
+		input.ControlPlane = v
+	}
+	if v := section.List(uciICEDisableCandidate); v != nil && input.ICEDisabledCandidates == nil {
+		input.ICEDisabledCandidates = v
+	}
+	if v := section.List(uciICEExcludeSubnet); v != nil && input.ICEExcludedSubnets == nil {
+		input.ICEExcludedSubnets = v
+	}
+	if v, ok := section.Get(uciICEFailedTimeout); ok && input.ICEFailedTimeout == nil {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid UCI option %s %q", uciICEFailedTimeout, v)
+		}
+		input.ICEFailedTimeout = &timeout
+	}
+	if v, ok := section.Get(uciICEDisconnTimeout); ok && input.ICEDisconnectedTimeout == nil {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid UCI option %s %q", uciICEDisconnTimeout, v)
+		}
+		input.ICEDisconnectedTimeout = &timeout
+	}
+	if v, ok := section.Get(uciSSHRecordingDir); ok && input.SSHRecordingDir == nil {
+		input.SSHRecordingDir = &v
+	}
+	if v, ok := section.Get(uciSSHRecordingRet); ok && input.SSHRecordingRetention == nil {
+		retention, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid UCI option %s %q", uciSSHRecordingRet, v)
+		}
+		input.SSHRecordingRetention = &retention
+	}
+	if v, ok := section.Get(uciStageNetChanges); ok && input.StageNetworkChanges == nil {
+		enabled, err := parseUCIBool(uciStageNetChanges, v)
+		if err != nil {
+			return err
+		}
+		input.StageNetworkChanges = &enabled
+	}
+	if v, ok := section.Get(uciStagedAutoApply); ok && input.StagedChangesAutoApply == nil {
+		delay, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid UCI option %s %q", uciStagedAutoApply, v)
+		}
+		input.StagedChangesAutoApply = &delay
+	}
+	if v, ok := section.Get(uciAutoUpdate); ok && input.AutoUpdate == nil {
+		enabled, err := parseUCIBool(uciAutoUpdate, v)
+		if err != nil {
+			return err
+		}
+		input.AutoUpdate = &enabled
+	}
+	if v, ok := section.Get(uciUpdatePublicKey); ok && input.UpdatePublicKey == nil {
+		input.UpdatePublicKey = &v
+	}
+	if v, ok := section.Get(uciUpdateDowngrade); ok && input.UpdateAllowDowngrade == nil {
+		allow, err := parseUCIBool(uciUpdateDowngrade, v)
+		if err != nil {
+			return err
+		}
+		input.UpdateAllowDowngrade = &allow
+	}
+	if v := section.List(uciInventoryField); v != nil && input.InventoryFields == nil {
+		input.InventoryFields = v
+	}
+	if v := section.List(uciInventoryPackage); v != nil && input.InventoryPackages == nil {
+		input.InventoryPackages = v
+	}
+	if v := section.List(uciDNSHost); v != nil && input.DNSHostOverrides == nil {
+		input.DNSHostOverrides = v
+	}
+	if v, ok := section.Get(uciLogFormat); ok && input.LogFormat == nil {
+		input.LogFormat = &v
+	}
+	if v := section.List(uciLogComponent); v != nil && input.LogComponents == nil {
+		input.LogComponents = v
+	}
+	if v, ok := section.Get(uciLogMaxSize); ok && input.LogMaxSize == nil {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid UCI option %s %q", uciLogMaxSize, v)
+		}
+		input.LogMaxSize = &size
+	}
+	if v, ok := section.Get(uciLogMaxBackups); ok && input.LogMaxBackups == nil {
+		backups, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid UCI option %s %q", uciLogMaxBackups, v)
+		}
+		input.LogMaxBackups = &backups
+	}
+	if v, ok := section.Get(uciLogRotationInterval); ok && input.LogRotationInterval == nil {
+		interval, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid UCI option %s %q", uciLogRotationInterval, v)
+		}
+		input.LogRotationInterval = &interval
+	}
+
+	return nil
+}
+
+// saveUCIConfig writes the settings of the config to the options of the UCI config, keeping the other options.
+// Nothing is written when the UCI config doesn't exist or already holds the settings
+func saveUCIConfig(path string, config *Config) error {
+	if path == "" {
+		return nil
+	}
+
+	file, section, err := readUCISection(path, config.Profile)
+	if err != nil || file == nil {
+		return err
+	}
+	before := file.Marshal()
+
+	if section == nil {
+		section = file.AddSection(uciSectionType, uciSectionName(config.Profile))
+	}
+	if config.ManagementURL != nil {
+		section.Set(uciManagementURL, config.ManagementURL.String())
+	}
+	if config.AdminURL != nil {
+		section.Set(uciAdminURL, config.AdminURL.String())
+	}
+	section.Set(uciInterface, config.WgIface)
+	section.Set(uciPort, strconv.Itoa(config.WgPort))
+	section.Set(uciDisableDNS, formatUCIBool(config.DisableDNS))
+	section.Set(uciDisableClientRoutes, formatUCIBool(config.DisableClientRoutes))
+	section.SetList(uciControlPlane, config.ControlPlane)
+	section.Set(uciLowMemory, formatUCIBool(config.LowMemory))
+	section.Set(uciDisableSysctl, formatUCIBool(config.DisableSysctl))
+	section.SetList(uciICEDisableCandidate, config.ICEDisabledCandidates)
+	section.SetList(uciICEExcludeSubnet, config.ICEExcludedSubnets)
+	setUCIDuration(section, uciICEFailedTimeout, config.ICEFailedTimeout)
+	setUCIDuration(section, uciICEDisconnTimeout, config.ICEDisconnectedTimeout)
+	if config.SSHRecordingDir != "" {
+		section.Set(uciSSHRecordingDir, config.SSHRecordingDir)
+	} else {
+		section.Delete(uciSSHRecordingDir)
+	}
+	setUCIDuration(section, uciSSHRecordingRet, config.SSHRecordingRetention)
+	section.Set(uciStageNetChanges, formatUCIBool(config.StageNetworkChanges))
+	setUCIDuration(section, uciStagedAutoApply, config.StagedChangesAutoApply)
+	section.Set(uciAutoUpdate, formatUCIBool(config.AutoUpdate))
+	if config.UpdatePublicKey != "" {
+		section.Set(uciUpdatePublicKey, config.UpdatePublicKey)
+	} else {
+		section.Delete(uciUpdatePublicKey)
+	}
+	section.Set(uciUpdateDowngrade, formatUCIBool(config.UpdateAllowDowngrade))
+	section.SetList(uciInventoryField, config.InventoryFields)
+	section.SetList(uciInventoryPackage, config.InventoryPackages)
+	section.SetList(uciDNSHost, config.DNSHostOverrides)
+	if config.LogFormat != "" {
+		section.Set(uciLogFormat, config.LogFormat)
+	} else {
+		section.Delete(uciLogFormat)
+	}
+	section.SetList(uciLogComponent, config.LogComponents)
+	setUCIInt(section, uciLogMaxSize, config.LogMaxSize)
+	setUCIInt(section, uciLogMaxBackups, config.LogMaxBackups)
+	setUCIDuration(section, uciLogRotationInterval, config.LogRotationInterval)
+
+	if bytes.Equal(before, file.Marshal()) {
+		return nil
+	}
+
+	log.Infof("saving the settings to the UCI config %s", path)
+	if err := file.WriteFile(path); err != nil {
+		return fmt.Errorf("write UCI config: %w", err)
+	}
+	return nil
+}
+
+// ReadUCISetupKey returns the setup key of the file referenced by the setup_key_file option of the profile in the
+// UCI config. It returns an empty key when the UCI config or the option doesn't exist
+func ReadUCISetupKey(path, profile string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+
+	_, section, err := readUCISection(path, profile)
+	if err != nil || section == nil {
+		return "", err
+	}
+
+	keyFile, ok := section.Get(uciSetupKeyFile)
+	if !ok || keyFile == "" {
+		return "", nil
+	}
+
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return "", fmt.Errorf("read setup key file: %w", err)
+	}
+	return strings.TrimSpace(string(key)), nil
+}
+
+// setUCIDuration sets a duration option, a zero duration removes it
+func setUCIDuration(section *uci.Section, name string, value time.Duration) {
+	if value == 0 {
+		section.Delete(name)
+		return
+	}
+	section.Set(name, value.String())
+}
+
+// setUCIInt sets an integer option, zero removes it
+func setUCIInt(section *uci.Section, name string, value int) {
+	if value == 0 {
+		section.Delete(name)
+		return
+	}
+	section.Set(name, strconv.Itoa(value))
+}
+
+// parseUCIBool parses a boolean option the way the uci command does
+func parseUCIBool(name, value string) (bool, error) {
+	switch value {
+	case "1", "yes", "on", "true", "enabled":
+		return true, nil
+	case "0", "no", "off", "false", "disabled":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid UCI option %s %q", name, value)
+	}
+}
+
+func formatUCIBool(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
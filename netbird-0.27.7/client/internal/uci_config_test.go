@@ -0,0 +1,190 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/client/internal/uci"
+)
+
+func TestUCIConfig(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	uciPath := filepath.Join(dir, "netbird")
+	keyPath := filepath.Join(dir, "setup.key")
+
+	require.NoError(t, os.WriteFile(keyPath, []byte("A2C8E62B-38F5-4553-B31E-DD66C696CEBB\n"), 0600))
+	require.NoError(t, os.WriteFile(uciPath, []byte(`
+config netbird 'main'
+	option management_url 'https://netbird.example.com'
+	option setup_key_file '`+keyPath+`'
+	option interface 'wt1'
+	option port '51821'
+	option disable_dns '1'
+	option low_memory '1'
+	option disable_sysctl '1'
+	option ice_failed_timeout '10s'
+	option ssh_recording_dir '/mnt/usb/netbird-ssh'
+	option ssh_recording_retention '168h'
+	option stage_network_changes '1'
+	option staged_changes_auto_apply '30m'
+	option auto_update '1'
+	option update_public_key 'pK9kTzH4m1WJxq3b6Qo0GAvXc7RyS2eLfDuN8aBtV5E='
+	option update_allow_downgrade '1'
+	list control_plane 'routes'
+	list ice_disable_candidate 'relay'
+	list ice_exclude_subnet '172.17.0.0/16'
+	list ice_exclude_subnet '192.168.3.0/24'
+	list dns_host 'nas.home.arpa=192.168.1.10'
+	option log_format 'json'
+	list log_component 'dns=debug'
+	option log_max_size '2'
+	option log_rotation_interval '24h'
+	option log_level 'info'
+`), 0644))
+
+	config, err := UpdateOrCreateConfig(ConfigInput{ConfigPath: configPath, UCIConfigPath: uciPath})
+	require.NoError(t, err)
+	require.Equal(t, "https://netbird.example.com:443", config.ManagementURL.String())
+	require.Equal(t, "wt1", config.WgIface)
+	require.Equal(t, 51821, config.WgPort)
+	require.True(t, config.DisableDNS)
+	require.False(t, config.DisableClientRoutes)
+	require.Equal(t, []string{"routes"}, config.ControlPlane)
+	require.True(t, config.LowMemory)
+	require.True(t, config.DisableSysctl)
+	require.Equal(t, []string{"relay"}, config.ICEDisabledCandidates)
+	require.Equal(t, []string{"172.17.0.0/16", "192.168.3.0/24"}, config.ICEExcludedSubnets)
+	require.Equal(t, 10*time.Second, config.ICEFailedTimeout)
+	require.Zero(t, config.ICEDisconnectedTimeout)
+	require.Equal(t, "/mnt/usb/netbird-ssh", config.SSHRecordingDir)
+	require.Equal(t, 168*time.Hour, config.SSHRecordingRetention)
+	require.True(t, config.StageNetworkChanges)
+	require.Equal(t, 30*time.Minute, config.StagedChangesAutoApply)
+	require.True(t, config.AutoUpdate)
+	require.Equal(t, "pK9kTzH4m1WJxq3b6Qo0GAvXc7RyS2eLfDuN8aBtV5E=", config.UpdatePublicKey)
+	require.True(t, config.UpdateAllowDowngrade)
+	require.Equal(t, []string{"nas.home.arpa=192.168.1.10"}, config.DNSHostOverrides)
+	require.Equal(t, "json", config.LogFormat)
+	require.Equal(t, []string{"dns=debug"}, config.LogComponents)
+	require.Equal(t, 2, config.LogMaxSize)
+	require.Zero(t, config.LogMaxBackups)
+	require.Equal(t, 24*time.Hour, config.LogRotationInterval)
+
+	key, err := ReadUCISetupKey(uciPath, "")
+	require.NoError(t, err)
+	require.Equal(t, "A2C8E62B-38F5-4553-B31E-DD66C696CEBB", key)
+
+	// settings of the caller take precedence and are saved to the UCI config
+	iface := "wt2"
+	config, err = UpdateOrCreateConfig(ConfigInput{ConfigPath: configPath, UCIConfigPath: uciPath, InterfaceName: &iface})
+	require.NoError(t, err)
+	require.Equal(t, "wt2", config.WgIface)
+
+	file, err := uci.ReadFile(uciPath)
+	require.NoError(t, err)
+	section := file.Section(uciSectionType, "main")
+	require.NotNil(t, section)
+
+	value, _ := section.Get(uciInterface)
+	require.Equal(t, "wt2", value)
+	value, _ = section.Get(uciManagementURL)
+	require.Equal(t, "https://netbird.example.com:443", value)
+	require.Equal(t, []string{"172.17.0.0/16", "192.168.3.0/24"}, section.List(uciICEExcludeSubnet))
+	value, _ = section.Get(uciICEFailedTimeout)
+	require.Equal(t, "10s", value)
+	_, ok := section.Get(uciICEDisconnTimeout)
+	require.False(t, ok, "unset durations shouldn't be written")
+	value, _ = section.Get("log_level")
+	require.Equal(t, "info", value, "options unknown to the client should be kept")
+
+	// the JSON config keeps the settings without the UCI config
+	require.NoError(t, os.Remove(uciPath))
+	config, err = UpdateConfig(ConfigInput{ConfigPath: configPath, UCIConfigPath: uciPath})
+	require.NoError(t, err)
+	require.Equal(t, "wt2", config.WgIface)
+	require.NoFileExists(t, uciPath)
+
+	key, err = ReadUCISetupKey(uciPath, "")
+	require.NoError(t, err)
+	require.Empty(t, key)
+}
+
+func TestUCIConfigInvalidOption(t *testing.T) {
+	dir := t.TempDir()
+	uciPath := filepath.Join(dir, "netbird")
+	require.NoError(t, os.WriteFile(uciPath, []byte("config netbird\n\toption disable_client_routes 'maybe'\n"), 0644))
+
+	_, err := UpdateOrCreateConfig(ConfigInput{ConfigPath: filepath.Join(dir, "config.json"), UCIConfigPath: uciPath})
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(uciPath, []byte("config netbird\n\toption update_public_key 'c2hvcnQ='\n"), 0644))
+
+	_, err = UpdateOrCreateConfig(ConfigInput{ConfigPath: filepath.Join(dir, "config.json"), UCIConfigPath: uciPath})
+	require.Error(t, err, "an invalid update public key should be rejected")
+
+	require.NoError(t, os.WriteFile(uciPath, []byte("config netbird\n\tlist control_plane 'firewall'\n"), 0644))
+
+	_, err = UpdateOrCreateConfig(ConfigInput{ConfigPath: filepath.Join(dir, "config.json"), UCIConfigPath: uciPath})
+	require.Error(t, err, "an unknown control plane part should be rejected")
+
+	require.NoError(t, os.WriteFile(uciPath, []byte("config netbird\n\tlist dns_host 'nas.home.arpa'\n"), 0644))
+
+	_, err = UpdateOrCreateConfig(ConfigInput{ConfigPath: filepath.Join(dir, "config.json"), UCIConfigPath: uciPath})
+	require.Error(t, err, "a host override without an address should be rejected")
+
+	require.NoError(t, os.WriteFile(uciPath, []byte("config netbird\n\tlist log_component 'firewall=debug'\n"), 0644))
+
+	_, err = UpdateOrCreateConfig(ConfigInput{ConfigPath: filepath.Join(dir, "config.json"), UCIConfigPath: uciPath})
+	require.Error(t, err, "an unknown log component should be rejected")
+}
+
+func TestUCIConfigProfile(t *testing.T) {
+	dir := t.TempDir()
+	uciPath := filepath.Join(dir, "netbird")
+	require.NoError(t, os.WriteFile(uciPath, []byte(`
+config netbird 'main'
+	option interface 'wt0'
+	option port '51820'
+
+config netbird 'work'
+	option management_url 'https://work.example.com'
+	option port '51830'
+`), 0644))
+
+	config, err := UpdateOrCreateConfig(ConfigInput{
+		ConfigPath:    filepath.Join(dir, "work", "config.json"),
+		UCIConfigPath: uciPath,
+		Profile:       "work",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "https://work.example.com:443", config.ManagementURL.String())
+	require.Equal(t, "wt-work", config.WgIface, "the profile should default to its own interface")
+	require.Equal(t, 51830, config.WgPort)
+
+	file, err := uci.ReadFile(uciPath)
+	require.NoError(t, err)
+	value, _ := file.Section(uciSectionType, "work").Get(uciInterface)
+	require.Equal(t, "wt-work", value)
+	value, _ = file.Section(uciSectionType, "main").Get(uciInterface)
+	require.Equal(t, "wt0", value, "the section of the default profile should be kept")
+
+	// a profile without a section gets one
+	config, err = UpdateOrCreateConfig(ConfigInput{
+		ConfigPath:    filepath.Join(dir, "home", "config.json"),
+		UCIConfigPath: uciPath,
+		Profile:       "home",
+	})
+	require.NoError(t, err)
+	file, err = uci.ReadFile(uciPath)
+	require.NoError(t, err)
+	section := file.Section(uciSectionType, "home")
+	require.NotNil(t, section)
+	value, _ = section.Get(uciPort)
+	require.Equal(t, strconv.Itoa(config.WgPort), value)
+}
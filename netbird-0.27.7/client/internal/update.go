@@ -0,0 +1,42 @@
+package internal
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/client/internal/updater"
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+)
+
+// newUpdater creates the updater of the client versions pushed by the management service, nil when the auto update
+// is disabled or no valid public key verifies the binaries
+func (e *Engine) newUpdater() *updater.Updater {
+	if !e.config.AutoUpdate {
+		return nil
+	}
+	if e.updater != nil {
+		return e.updater
+	}
+
+	if e.config.UpdatePublicKey == "" {
+		log.Warnf("auto update is enabled without an update public key, not updating the client")
+		return nil
+	}
+	key, err := updater.ParsePublicKey(e.config.UpdatePublicKey)
+	if err != nil {
+		log.Warnf("auto update is enabled with an invalid update public key, not updating the client: %v", err)
+		return nil
+	}
+	return updater.New(key, e.config.UpdateAllowDowngrade)
+}
+
+// toUpdateTarget converts the client version the management service sets for the peer, nil when it sets none
+func toUpdateTarget(config *mgmProto.ClientUpdateConfig) *updater.Target {
+	if config.GetVersion() == "" || config.GetUrl() == "" {
+		return nil
+	}
+	return &updater.Target{
+		Version: config.GetVersion(),
+		Channel: config.GetChannel(),
+		URL:     config.GetUrl(),
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux || android
+
+package updater
+
+import (
+	"errors"
+)
+
+// restart is not supported on this platform, the update is applied on the next start of the client
+func restart() error {
+	return errors.New("restarting the client is not supported on this platform")
+}
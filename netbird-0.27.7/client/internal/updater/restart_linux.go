@@ -0,0 +1,18 @@
+//go:build !android
+
+package updater
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// restart stops the daemon gracefully, the service manager starts it again with the replaced executable: procd
+// respawns it on OpenWrt and the systemd unit of the service restarts it always
+func restart() error {
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		return fmt.Errorf("signal the daemon: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,478 @@
+// Package updater updates the client to the version the management service sets for the groups of the peer.
+//
+// The binaries are verified with an ed25519 public key configured locally, so a compromised management service can't
+// make the peers run a binary that wasn't signed by the release key. As it could still offer an older release with
+// known vulnerabilities, the versions at or below the running one are refused unless the downgrades are allowed
+// locally.
+//
+// The executable is replaced in place, on OpenWrt the files of the opkg package stay where opkg expects them and a
+// later opkg upgrade replaces the binary as usual. The daemon is restarted by the service manager, procd respawns it
+// on OpenWrt. The replaced binary is kept until the new one connected to the management service and restored when it
+// doesn't within HealthTimeout.
+package updater
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	goversion "github.com/hashicorp/go-version"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/util"
+	"github.com/netbirdio/netbird/version"
+)
+
+const (
+	// HealthTimeout is the time an updated client has to connect to the management service before it is rolled back
+	HealthTimeout = 5 * time.Minute
+	// maxStarts is the number of starts of an updated client without connecting to the management service after
+	// which it is rolled back, e.g. when it crashes before the HealthTimeout
+	maxStarts = 3
+
+	maxBinarySize    = 128 << 20
+	maxSignatureSize = 1 << 10
+	downloadTimeout  = 10 * time.Minute
+	versionTimeout   = 10 * time.Second
+	healthInterval   = 5 * time.Second
+	// retryInterval is the time before a version that failed to download or verify is tried again
+	retryInterval = time.Hour
+
+	stateSuffix    = ".update.json"
+	backupSuffix   = ".prev"
+	downloadSuffix = ".download"
+	signatureExt   = ".sig"
+)
+
+// Target is the client version the management service sets for the peer
+type Target struct {
+	Version string
+	Channel string
+	// URL is the base URL of the binaries, they are at <URL>/<version>/netbird_<os>_<arch> with the ARM version or
+	// the MIPS floating point mode appended to the arch, e.g. netbird_linux_armv7 or netbird_linux_mipsle_softfloat
+	URL string
+}
+
+// state is the progress of an update, it is kept next to the executable
+type state struct {
+	// To and From are the versions of the applied update, empty when no update awaits the health check
+	To        string    `json:"to,omitempty"`
+	From      string    `json:"from,omitempty"`
+	AppliedAt time.Time `json:"appliedAt,omitempty"`
+	Starts    int       `json:"starts,omitempty"`
+	// Failed are the versions that were rolled back, they aren't applied again
+	Failed []string `json:"failed,omitempty"`
+}
+
+// Updater downloads, verifies and applies the client versions offered by the management service
+type Updater struct {
+	publicKey      ed25519.PublicKey
+	allowDowngrade bool
+	client         *http.Client
+
+	// version, executable and restart are replaced in the tests
+	version    string
+	executable func() (string, error)
+	restart    func() error
+
+	mu       sync.Mutex
+	running  bool
+	attempts map[string]time.Time
+}
+
+// New creates an updater that applies the binaries signed with the publicKey. The versions at or below the running
+// one are only applied with allowDowngrade
+func New(publicKey ed25519.PublicKey, allowDowngrade bool) *Updater {
+	return &Updater{
+		publicKey:      publicKey,
+		allowDowngrade: allowDowngrade,
+		client:         &http.Client{Timeout: downloadTimeout},
+		version:        version.NetbirdVersion(),
+		executable:     executable,
+		restart:        restart,
+		attempts:       make(map[string]time.Time),
+	}
+}
+
+// ParsePublicKey parses the base64 encoded ed25519 public key the binaries are signed with
+func ParsePublicKey(key string) (ed25519.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil {
+		return nil, fmt.Errorf("decode update public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid update public key size %d, expected %d", len(raw), ed25519.PublicKeySize)
+	}
+	return raw, nil
+}
+
+// Offer updates the client to the target in the background unless it runs the target version already, the target
+// was rolled back before or an update is in progress. A nil target is ignored.
+func (u *Updater) Offer(ctx context.Context, target *Target) {
+	if target == nil || target.Version == "" || target.URL == "" {
+		return
+	}
+
+	if sameVersion(target.Version, u.version) {
+		return
+	}
+	if u.version == "development" {
+		log.Debugf("not updating a development build to %s", target.Version)
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.running {
+		return
+	}
+	if last, ok := u.attempts[target.Version]; ok && time.Since(last) < retryInterval {
+		return
+	}
+	u.attempts[target.Version] = time.Now()
+	u.running = true
+
+	go func() {
+		defer func() {
+			u.mu.Lock()
+			u.running = false
+			u.mu.Unlock()
+		}()
+
+		if err := u.update(ctx, *target); err != nil {
+			log.Errorf("failed to update the client to %s: %v", target.Version, err)
+		}
+	}()
+}
+
+func (u *Updater) update(ctx context.Context, target Target) error {
+	exe, err := u.executable()
+	if err != nil {
+		return fmt.Errorf("get executable: %w", err)
+	}
+
+	st := u.readState(exe)
+	if slices.ContainsFunc(st.Failed, func(v string) bool { return sameVersion(v, target.Version) }) {
+		log.Debugf("not updating the client to %s, it was rolled back before", target.Version)
+		return nil
+	}
+	if st.To != "" {
+		return fmt.Errorf("the update to %s awaits its health check", st.To)
+	}
+	if !u.allowDowngrade {
+		if err := checkUpgrade(u.version, target.Version); err != nil {
+			return err
+		}
+	}
+
+	log.Infof("updating the client from %s to %s, channel %q", u.version, target.Version, target.Channel)
+
+	download := exe + downloadSuffix
+	defer func() {
+		if err := os.Remove(download); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warnf("failed to remove the update download %s: %v", download, err)
+		}
+	}()
+
+	if err := u.download(ctx, binaryURL(target), download); err != nil {
+		return err
+	}
+	if err := checkVersion(ctx, download, target.Version); err != nil {
+		return err
+	}
+
+	st.To = target.Version
+	st.From = u.version
+	st.AppliedAt = time.Now().UTC()
+	st.Starts = 0
+	if err := util.WriteJson(exe+stateSuffix, st); err != nil {
+		return fmt.Errorf("write update state: %w", err)
+	}
+
+	if err := os.Rename(exe, exe+backupSuffix); err != nil {
+		u.clearState(exe, st)
+		return fmt.Errorf("back up executable: %w", err)
+	}
+	if err := os.Rename(download, exe); err != nil {
+		if rerr := os.Rename(exe+backupSuffix, exe); rerr != nil {
+			log.Errorf("failed to restore the executable %s: %v", exe, rerr)
+		}
+		u.clearState(exe, st)
+		return fmt.Errorf("replace executable: %w", err)
+	}
+
+	log.Infof("updated the client to %s, restarting", target.Version)
+	return u.restart()
+}
+
+// download writes the binary at the url to the path when its signature verifies
+func (u *Updater) download(ctx context.Context, url, path string) error {
+	signature, err := u.fetchSignature(ctx, url+signatureExt)
+	if err != nil {
+		return err
+	}
+
+	body, err := u.get(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return fmt.Errorf("create download: %w", err)
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, hash), io.LimitReader(body, maxBinarySize+1))
+	if err != nil {
+		return fmt.Errorf("download binary: %w", err)
+	}
+	if n > maxBinarySize {
+		return fmt.Errorf("binary exceeds %d bytes", maxBinarySize)
+	}
+	if !ed25519.Verify(u.publicKey, hash.Sum(nil), signature) {
+		return errors.New("invalid binary signature")
+	}
+
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("sync download: %w", err)
+	}
+	return f.Close()
+}
+
+func (u *Updater) fetchSignature(ctx context.Context, url string) ([]byte, error) {
+	body, err := u.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	encoded, err := io.ReadAll(io.LimitReader(body, maxSignatureSize))
+	if err != nil {
+		return nil, fmt.Errorf("download signature: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return nil, fmt.Errorf("decode signature: %w", err)
+	}
+	if len(signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("invalid signature size %d", len(signature))
+	}
+	return signature, nil
+}
+
+func (u *Updater) get(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("get %s: unexpected status %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+var checkPendingOnce sync.Once
+
+// CheckPending finishes an update applied before the start of this process. The update is kept when healthy reports
+// true within HealthTimeout of the start, otherwise the replaced binary is restored and the client restarted. Only
+// the first call of the process checks the update.
+func CheckPending(ctx context.Context, healthy func() bool) {
+	checkPendingOnce.Do(func() {
+		New(nil, false).CheckPending(ctx, healthy)
+	})
+}
+
+// CheckPending finishes an update applied before the start of this client, see the package function
+func (u *Updater) CheckPending(ctx context.Context, healthy func() bool) {
+	exe, err := u.executable()
+	if err != nil {
+		log.Warnf("failed to get the executable to check the update: %v", err)
+		return
+	}
+
+	st := u.readState(exe)
+	if st.To == "" {
+		return
+	}
+	if !sameVersion(st.To, u.version) {
+		// replaced by something else, e.g. an opkg upgrade
+		log.Infof("running %s instead of the update to %s, dropping the update", u.version, st.To)
+		u.removeBackup(exe)
+		u.clearState(exe, st)
+		return
+	}
+
+	st.Starts++
+	if st.Starts > maxStarts {
+		log.Warnf("the update to %s started %d times without connecting to the management service", st.To, st.Starts-1)
+		u.rollback(exe, st)
+		return
+	}
+	if err := util.WriteJson(exe+stateSuffix, st); err != nil {
+		log.Warnf("failed to write the update state: %v", err)
+	}
+
+	// the deadline counts from the start, the clock of a router without RTC may jump until NTP synchronized it
+	deadline := time.Now().Add(HealthTimeout)
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+
+	for !healthy() {
+		if time.Now().After(deadline) {
+			log.Warnf("the update to %s didn't connect to the management service within %s", st.To, HealthTimeout)
+			u.rollback(exe, st)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+
+	log.Infof("the update from %s to %s passed its health check", st.From, st.To)
+	u.removeBackup(exe)
+	u.clearState(exe, st)
+}
+
+// rollback restores the binary replaced by the update and restarts the client
+func (u *Updater) rollback(exe string, st state) {
+	if err := os.Rename(exe+backupSuffix, exe); err != nil {
+		log.Errorf("failed to roll back the update to %s: %v", st.To, err)
+		u.clearState(exe, st)
+		return
+	}
+
+	log.Warnf("rolled back the update to %s, restarting %s", st.To, st.From)
+	st.Failed = append(st.Failed, st.To)
+	u.clearState(exe, st)
+
+	if err := u.restart(); err != nil {
+		log.Errorf("failed to restart the client after the rollback: %v", err)
+	}
+}
+
+func (u *Updater) readState(exe string) state {
+	var st state
+	if _, err := util.ReadJson(exe+stateSuffix, &st); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warnf("failed to read the update state: %v", err)
+	}
+	return st
+}
+
+// clearState forgets the applied update, the file is kept while it lists failed versions
+func (u *Updater) clearState(exe string, st state) {
+	st.To, st.From, st.AppliedAt, st.Starts = "", "", time.Time{}, 0
+
+	path := exe + stateSuffix
+	if len(st.Failed) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warnf("failed to remove the update state: %v", err)
+		}
+		return
+	}
+	if err := util.WriteJson(path, st); err != nil {
+		log.Warnf("failed to write the update state: %v", err)
+	}
+}
+
+func (u *Updater) removeBackup(exe string) {
+	if err := os.Remove(exe + backupSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warnf("failed to remove the replaced binary: %v", err)
+	}
+}
+
+// checkVersion runs the downloaded binary to make sure it runs on this system and is the expected version
+func checkVersion(ctx context.Context, path, expected string) error {
+	ctx, cancel := context.WithTimeout(ctx, versionTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, path, "version").Output()
+	if err != nil {
+		return fmt.Errorf("run downloaded binary: %w", err)
+	}
+	if got := strings.TrimSpace(string(out)); !sameVersion(got, expected) {
+		return fmt.Errorf("downloaded binary is version %q, expected %q", got, expected)
+	}
+	return nil
+}
+
+// checkUpgrade refuses the target versions at or below the current one, a signed older release may have known
+// vulnerabilities
+func checkUpgrade(current, target string) error {
+	currentVersion, err := goversion.NewVersion(current)
+	if err != nil {
+		return fmt.Errorf("parse the running version %q: %w", current, err)
+	}
+	targetVersion, err := goversion.NewVersion(target)
+	if err != nil {
+		return fmt.Errorf("parse the update version %q: %w", target, err)
+	}
+	if !targetVersion.GreaterThan(currentVersion) {
+		return fmt.Errorf("refusing to downgrade the client from %s to %s, the downgrades have to be allowed locally", current, target)
+	}
+	return nil
+}
+
+func binaryURL(target Target) string {
+	return fmt.Sprintf("%s/%s/netbird_%s_%s", strings.TrimSuffix(target.URL, "/"), target.Version, runtime.GOOS, binaryArch())
+}
+
+// binaryArch returns the arch of the binary with the ARM version or the MIPS floating point mode it was built for,
+// the builds of the same arch don't run on every OpenWrt target
+func binaryArch() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return runtime.GOARCH
+	}
+	return archVariant(runtime.GOARCH, info.Settings)
+}
+
+func archVariant(arch string, settings []debug.BuildSetting) string {
+	for _, setting := range settings {
+		switch {
+		case setting.Key == "GOARM" && arch == "arm" && setting.Value != "":
+			// GOARM may carry the floating point mode since Go 1.22, e.g. 7,softfloat
+			armVersion, _, _ := strings.Cut(setting.Value, ",")
+			return arch + "v" + armVersion
+		case setting.Key == "GOMIPS" && (arch == "mips" || arch == "mipsle") && setting.Value != "":
+			return arch + "_" + setting.Value
+		}
+	}
+	return arch
+}
+
+func sameVersion(a, b string) bool {
+	return strings.TrimPrefix(a, "v") == strings.TrimPrefix(b, "v")
+}
+
+func executable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
@@ -0,0 +1,221 @@
+package updater
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/util"
+)
+
+const (
+	oldBinary = "#!/bin/sh\necho 0.28.0\n"
+	newBinary = "#!/bin/sh\necho 0.28.1\n"
+)
+
+type testUpdater struct {
+	*Updater
+	exe      string
+	target   Target
+	restarts int
+}
+
+func newTestUpdater(t *testing.T, binary string, sign func([]byte) []byte) *testUpdater {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the test binaries are shell scripts")
+	}
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	if sign == nil {
+		sign = func(digest []byte) []byte { return ed25519.Sign(private, digest) }
+	}
+
+	path := "/0.28.1/netbird_" + runtime.GOOS + "_" + binaryArch()
+	digest := sha256.Sum256([]byte(binary))
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(binary))
+	})
+	mux.HandleFunc(path+signatureExt, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(base64.StdEncoding.EncodeToString(sign(digest[:]))))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	exe := filepath.Join(t.TempDir(), "netbird")
+	require.NoError(t, os.WriteFile(exe, []byte(oldBinary), 0755))
+
+	u := &testUpdater{
+		Updater: New(public, false),
+		exe:     exe,
+		target:  Target{Version: "0.28.1", Channel: "stable", URL: server.URL + "/"},
+	}
+	u.version = "0.28.0"
+	u.executable = func() (string, error) { return exe, nil }
+	u.restart = func() error {
+		u.restarts++
+		return nil
+	}
+	return u
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestParsePublicKey(t *testing.T) {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	key, err := ParsePublicKey(" " + base64.StdEncoding.EncodeToString(public) + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, public, key)
+
+	_, err = ParsePublicKey("not base64")
+	assert.Error(t, err)
+	_, err = ParsePublicKey(base64.StdEncoding.EncodeToString(public[:16]))
+	assert.Error(t, err)
+}
+
+func TestUpdater_UpdateAndHealthCheck(t *testing.T) {
+	u := newTestUpdater(t, newBinary, nil)
+
+	require.NoError(t, u.update(context.Background(), u.target))
+	assert.Equal(t, newBinary, readFile(t, u.exe))
+	assert.Equal(t, oldBinary, readFile(t, u.exe+backupSuffix))
+	assert.NoFileExists(t, u.exe+downloadSuffix)
+	assert.Equal(t, 1, u.restarts)
+
+	var st state
+	_, err := util.ReadJson(u.exe+stateSuffix, &st)
+	require.NoError(t, err)
+	assert.Equal(t, "0.28.1", st.To)
+	assert.Equal(t, "0.28.0", st.From)
+
+	u.version = "0.28.1"
+	u.CheckPending(context.Background(), func() bool { return true })
+	assert.Equal(t, newBinary, readFile(t, u.exe))
+	assert.NoFileExists(t, u.exe+backupSuffix)
+	assert.NoFileExists(t, u.exe+stateSuffix)
+	assert.Equal(t, 1, u.restarts)
+}
+
+func TestUpdater_Rollback(t *testing.T) {
+	u := newTestUpdater(t, newBinary, nil)
+
+	require.NoError(t, u.update(context.Background(), u.target))
+
+	u.version = "0.28.1"
+	for i := 0; i < maxStarts; i++ {
+		// the client was stopped before it connected
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		u.CheckPending(ctx, func() bool { return false })
+		assert.Equal(t, newBinary, readFile(t, u.exe))
+	}
+
+	u.CheckPending(context.Background(), func() bool { return false })
+	assert.Equal(t, oldBinary, readFile(t, u.exe), "the replaced binary should be restored after too many starts")
+	assert.NoFileExists(t, u.exe+backupSuffix)
+	assert.Equal(t, 2, u.restarts)
+
+	var st state
+	_, err := util.ReadJson(u.exe+stateSuffix, &st)
+	require.NoError(t, err)
+	assert.Equal(t, state{Failed: []string{"0.28.1"}}, st)
+
+	u.version = "0.28.0"
+	require.NoError(t, u.update(context.Background(), u.target))
+	assert.Equal(t, oldBinary, readFile(t, u.exe), "a rolled back version should not be applied again")
+	assert.Equal(t, 2, u.restarts)
+}
+
+func TestUpdater_RejectsInvalidBinaries(t *testing.T) {
+	t.Run("signature", func(t *testing.T) {
+		_, other, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		u := newTestUpdater(t, newBinary, func(digest []byte) []byte { return ed25519.Sign(other, digest) })
+		assert.ErrorContains(t, u.update(context.Background(), u.target), "invalid binary signature")
+		assert.Equal(t, oldBinary, readFile(t, u.exe))
+		assert.NoFileExists(t, u.exe+downloadSuffix)
+		assert.NoFileExists(t, u.exe+stateSuffix)
+		assert.Zero(t, u.restarts)
+	})
+
+	t.Run("version", func(t *testing.T) {
+		u := newTestUpdater(t, "#!/bin/sh\necho 0.27.9\n", nil)
+		assert.ErrorContains(t, u.update(context.Background(), u.target), "expected \"0.28.1\"")
+		assert.Equal(t, oldBinary, readFile(t, u.exe))
+		assert.Zero(t, u.restarts)
+	})
+}
+
+func TestUpdater_Downgrade(t *testing.T) {
+	u := newTestUpdater(t, newBinary, nil)
+	u.version = "0.29.0"
+
+	assert.ErrorContains(t, u.update(context.Background(), u.target), "refusing to downgrade")
+	assert.Equal(t, oldBinary, readFile(t, u.exe), "an older signed release should not be applied")
+	assert.NoFileExists(t, u.exe+stateSuffix)
+	assert.Zero(t, u.restarts)
+
+	u.allowDowngrade = true
+	require.NoError(t, u.update(context.Background(), u.target))
+	assert.Equal(t, newBinary, readFile(t, u.exe), "the downgrade should be applied when allowed")
+	assert.Equal(t, 1, u.restarts)
+}
+
+func TestCheckUpgrade(t *testing.T) {
+	assert.NoError(t, checkUpgrade("0.28.0", "0.28.1"))
+	assert.NoError(t, checkUpgrade("v0.28.9", "0.28.10"))
+	assert.Error(t, checkUpgrade("0.28.1", "0.28.1"))
+	assert.Error(t, checkUpgrade("0.28.1", "0.28.0"))
+	assert.Error(t, checkUpgrade("0.28.1", "latest"), "an unparsable version can't be checked")
+}
+
+func TestArchVariant(t *testing.T) {
+	for _, tc := range []struct {
+		arch     string
+		settings []debug.BuildSetting
+		expected string
+	}{
+		{arch: "amd64", settings: []debug.BuildSetting{{Key: "GOAMD64", Value: "v1"}}, expected: "amd64"},
+		{arch: "arm", settings: []debug.BuildSetting{{Key: "GOARM", Value: "7"}}, expected: "armv7"},
+		{arch: "arm", settings: []debug.BuildSetting{{Key: "GOARM", Value: "6,softfloat"}}, expected: "armv6"},
+		{arch: "arm", expected: "arm"},
+		{arch: "mipsle", settings: []debug.BuildSetting{{Key: "GOMIPS", Value: "softfloat"}}, expected: "mipsle_softfloat"},
+		{arch: "mips", settings: []debug.BuildSetting{{Key: "GOMIPS", Value: "hardfloat"}}, expected: "mips_hardfloat"},
+		{arch: "arm64", settings: []debug.BuildSetting{{Key: "GOARM", Value: "7"}}, expected: "arm64"},
+	} {
+		assert.Equal(t, tc.expected, archVariant(tc.arch, tc.settings), tc.arch)
+	}
+}
+
+func TestCheckPending_ReplacedBinary(t *testing.T) {
+	u := newTestUpdater(t, newBinary, nil)
+	require.NoError(t, u.update(context.Background(), u.target))
+
+	u.version = "0.29.0"
+	u.CheckPending(context.Background(), func() bool { return false })
+	assert.NoFileExists(t, u.exe+stateSuffix, "an update replaced by another version should be dropped")
+	assert.NoFileExists(t, u.exe+backupSuffix)
+	assert.Equal(t, 1, u.restarts)
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/client/internal/stdnet"
+)
+
+// targetUplink returns the uplink the traffic should be pinned to. A locally configured uplink takes precedence over
+// the uplink pushed by the management service.
+func (e *Engine) targetUplink() string {
+	if e.config.Uplink != "" {
+		return e.config.Uplink
+	}
+	return e.config.ManagementUplink
+}
+
+// resolveUplink resolves the target uplink. When the uplink can't be found, e.g. the interface is down,
+// the traffic is not pinned rather than failing to start.
+func (e *Engine) resolveUplink() *stdnet.Uplink {
+	uplink, err := stdnet.ResolveUplink(e.targetUplink())
+	if err != nil {
+		log.Warnf("not pinning the traffic to an uplink: %s", err)
+		return nil
+	}
+	if uplink != nil {
+		log.Infof("pinning the traffic to uplink %s", uplink)
+	}
+	return uplink
+}
+
+// onManagementUplinkChange restarts the engine when the uplink pushed by the management service changes,
+// as the sockets are pinned to the uplink when they are created
+func (e *Engine) onManagementUplinkChange(uplink string) {
+	log.Debugf("management uplink changed from %q to %q", e.config.ManagementUplink, uplink)
+	e.config.ManagementUplink = uplink
+
+	if e.config.Uplink != "" {
+		return
+	}
+
+	log.Infof("uplink changed to %q, restarting engine", uplink)
+	e.restart()
+}
+
+// restart stops and starts the engine again in the background, e.g. to recreate the sockets with new settings
+func (e *Engine) restart() {
+	go func() {
+		if err := e.Stop(); err != nil {
+			log.Errorf("Failed to stop engine: %v", err)
+		}
+		if err := e.Start(); err != nil {
+			log.Errorf("Failed to start engine: %v", err)
+		}
+	}()
+}
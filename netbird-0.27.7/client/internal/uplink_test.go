@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngine_TargetUplink(t *testing.T) {
+	testCases := []struct {
+		name             string
+		localUplink      string
+		managementUplink string
+		expected         string
+	}{
+		{
+			name: "unset",
+		},
+		{
+			name:             "management uplink",
+			managementUplink: "wan2",
+			expected:         "wan2",
+		},
+		{
+			name:             "local uplink overrides management uplink",
+			localUplink:      "192.168.2.10",
+			managementUplink: "wan2",
+			expected:         "192.168.2.10",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			engine := &Engine{
+				config: &EngineConfig{
+					Uplink:           testCase.localUplink,
+					ManagementUplink: testCase.managementUplink,
+				},
+			}
+			assert.Equal(t, testCase.expected, engine.targetUplink())
+		})
+	}
+}
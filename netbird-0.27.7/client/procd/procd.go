@@ -0,0 +1,217 @@
+// Package procd lets procd, the init system of OpenWrt, supervise the NetBird daemons without wrapper scripts. The
+// daemons notify their readiness, ping the watchdog of their procd instance and reload their configuration on the
+// reload_signal of the instance, SIGHUP. The readiness and the watchdog pings are sent to $NOTIFY_SOCKET as well,
+// for the supervisors speaking the sd_notify protocol
+package procd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/client/ubus"
+)
+
+const (
+	// DefaultInstance is the name procd gives to the first instance of a service
+	DefaultInstance = "instance1"
+
+	// serviceObject is the ubus object of procd managing the services
+	serviceObject = "service"
+
+	// notifySocketEnv is the environment variable with the path of the sd_notify socket
+	notifySocketEnv = "NOTIFY_SOCKET"
+)
+
+// Options configure the supervision of a daemon
+type Options struct {
+	// Service is the name of the procd service, the name of the init script, e.g. netbird
+	Service string
+	// Instance is the name of the procd instance of the daemon, DefaultInstance when empty
+	Instance string
+	// Watchdog is the interval of the pings of the procd watchdog, zero disables them. It should be at most half of
+	// the watchdog timeout of the instance
+	Watchdog time.Duration
+	// SocketPath is the path of the ubus socket, ubus.DefaultSocketPath when empty
+	SocketPath string
+}
+
+// Supervisor notifies procd of the state of a daemon
+type Supervisor struct {
+	opts Options
+}
+
+// New returns a supervisor notifying procd of the state of the daemon
+func New(opts Options) *Supervisor {
+	if opts.Instance == "" {
+		opts.Instance = DefaultInstance
+	}
+	if opts.SocketPath == "" {
+		opts.SocketPath = ubus.DefaultSocketPath
+	}
+	return &Supervisor{opts: opts}
+}
+
+// Ready notifies that the daemon serves its requests. The readiness is broadcast as the ubus event <service>.ready,
+// e.g. ubus listen netbird.ready
+func (s *Supervisor) Ready() {
+	if err := notify("READY=1"); err != nil {
+		log.Debugf("failed to notify the readiness on %s: %v", os.Getenv(notifySocketEnv), err)
+	}
+
+	if s.opts.Service == "" || !s.ubusAvailable() {
+		return
+	}
+	err := s.withConn(func(conn *ubus.Conn) error {
+		return conn.SendEvent(s.opts.Service+".ready", map[string]any{
+			"instance": s.opts.Instance,
+			"pid":      int64(os.Getpid()),
+		})
+	})
+	if err != nil {
+		log.Debugf("failed to broadcast the readiness: %v", err)
+	}
+}
+
+// Run pings the procd watchdog of the instance every watchdog interval until ctx is done. It returns immediately
+// without a watchdog interval
+func (s *Supervisor) Run(ctx context.Context) {
+	if s.opts.Watchdog <= 0 {
+		return
+	}
+
+	log.Infof("pinging the procd watchdog of %s.%s every %s", s.opts.Service, s.opts.Instance, s.opts.Watchdog)
+	ticker := time.NewTicker(s.opts.Watchdog)
+	defer ticker.Stop()
+
+	var conn *ubus.Conn
+	defer func() {
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}()
+
+	for {
+		var err error
+		if conn, err = s.ping(conn); err != nil {
+			log.Warnf("failed to ping the procd watchdog: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ping pings the watchdog on conn, connecting to ubus first when conn is nil or lost. It returns the connection to
+// use for the next ping
+func (s *Supervisor) ping(conn *ubus.Conn) (*ubus.Conn, error) {
+	if err := notify("WATCHDOG=1"); err != nil {
+		log.Debugf("failed to ping the watchdog on %s: %v", os.Getenv(notifySocketEnv), err)
+	}
+
+	if s.opts.Service == "" {
+		return conn, nil
+	}
+
+	if conn != nil {
+		select {
+		case <-conn.Done():
+			_ = conn.Close()
+			conn = nil
+		default:
+		}
+	}
+	if conn == nil {
+		var err error
+		if conn, err = ubus.Dial(s.opts.SocketPath); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.pingWatchdog(conn); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (s *Supervisor) pingWatchdog(conn *ubus.Conn) error {
+	id, err := conn.Lookup(serviceObject)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Invoke(id, "watchdog", map[string]any{
+		"name":     s.opts.Service,
+		"instance": s.opts.Instance,
+	})
+	return err
+}
+
+// NotifyReload calls reload on every SIGHUP until ctx is done. The reload is notified on $NOTIFY_SOCKET
+func (s *Supervisor) NotifyReload(ctx context.Context, reload func() error) {
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(hupCh)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hupCh:
+			}
+
+			log.Info("reload signal received")
+			_ = notify("RELOADING=1")
+			if err := reload(); err != nil {
+				log.Errorf("failed to reload: %v", err)
+			}
+			_ = notify("READY=1")
+		}
+	}()
+}
+
+func (s *Supervisor) ubusAvailable() bool {
+	_, err := os.Stat(s.opts.SocketPath)
+	return err == nil
+}
+
+func (s *Supervisor) withConn(f func(conn *ubus.Conn) error) error {
+	conn, err := ubus.Dial(s.opts.SocketPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return f(conn)
+}
+
+// notify sends the state to the sd_notify socket, it does nothing when $NOTIFY_SOCKET isn't set
+func notify(state string) error {
+	path := os.Getenv(notifySocketEnv)
+	if path == "" {
+		return nil
+	}
+	// abstract sockets start with @
+	if path[0] == '@' {
+		path = "\x00" + path[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+//go:build !windows
+
+package procd
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func listenNotify(t *testing.T) *net.UnixConn {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	t.Setenv(notifySocketEnv, path)
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func readNotify(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	return string(buf[:n])
+}
+
+func TestSupervisorNotify(t *testing.T) {
+	conn := listenNotify(t)
+
+	// without a ubus socket only $NOTIFY_SOCKET is notified
+	s := New(Options{Service: "netbird", SocketPath: filepath.Join(t.TempDir(), "ubus.sock")})
+	s.Ready()
+	require.Equal(t, "READY=1", readNotify(t, conn))
+
+	_, err := s.ping(nil)
+	require.Error(t, err, "the procd watchdog should be unreachable")
+	require.Equal(t, "WATCHDOG=1", readNotify(t, conn))
+}
+
+func TestSupervisorNotifyReload(t *testing.T) {
+	conn := listenNotify(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reloaded := make(chan struct{}, 1)
+	New(Options{}).NotifyReload(ctx, func() error {
+		reloaded <- struct{}{}
+		return nil
+	})
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGHUP))
+
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("SIGHUP should reload")
+	}
+	require.Equal(t, "RELOADING=1", readNotify(t, conn))
+	require.Equal(t, "READY=1", readNotify(t, conn))
+}
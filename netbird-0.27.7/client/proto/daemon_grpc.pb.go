@@ -37,10 +37,32 @@ type DaemonServiceClient interface {
 	SelectRoutes(ctx context.Context, in *SelectRoutesRequest, opts ...grpc.CallOption) (*SelectRoutesResponse, error)
 	// Deselect specific routes
 	DeselectRoutes(ctx context.Context, in *SelectRoutesRequest, opts ...grpc.CallOption) (*SelectRoutesResponse, error)
+	// Propose the local networks as routes, pending approval of an admin
+	ProposeLANRoutes(ctx context.Context, in *ProposeLANRoutesRequest, opts ...grpc.CallOption) (*ProposeLANRoutesResponse, error)
 	// DebugBundle creates a debug bundle
 	DebugBundle(ctx context.Context, in *DebugBundleRequest, opts ...grpc.CallOption) (*DebugBundleResponse, error)
 	// SetLogLevel sets the log level of the daemon
 	SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error)
+	// DebugRoutes dumps the routing state of the daemon
+	DebugRoutes(ctx context.Context, in *DebugRoutesRequest, opts ...grpc.CallOption) (*DebugRoutesResponse, error)
+	// DebugPcap captures the tunneled packets for a duration and returns the location of the pcapng file
+	DebugPcap(ctx context.Context, in *DebugPcapRequest, opts ...grpc.CallOption) (*DebugPcapResponse, error)
+	// GetDNSQueryLog returns the latest DNS queries handled by the resolver with the query counters
+	GetDNSQueryLog(ctx context.Context, in *GetDNSQueryLogRequest, opts ...grpc.CallOption) (*GetDNSQueryLogResponse, error)
+	// DebugDroppedConnections returns the latest inbound connections dropped by the firewall
+	DebugDroppedConnections(ctx context.Context, in *DebugDroppedConnectionsRequest, opts ...grpc.CallOption) (*DebugDroppedConnectionsResponse, error)
+	// FlushState writes the state files batched in memory or on tmpfs to their path
+	FlushState(ctx context.Context, in *FlushStateRequest, opts ...grpc.CallOption) (*FlushStateResponse, error)
+	// ListPendingChanges returns the route and DNS changes staged for a local approval
+	ListPendingChanges(ctx context.Context, in *ListPendingChangesRequest, opts ...grpc.CallOption) (*ListPendingChangesResponse, error)
+	// ApplyPendingChanges applies the staged route and DNS changes
+	ApplyPendingChanges(ctx context.Context, in *ApplyPendingChangesRequest, opts ...grpc.CallOption) (*ApplyPendingChangesResponse, error)
+	// DiscardPendingChanges drops the staged route and DNS changes
+	DiscardPendingChanges(ctx context.Context, in *DiscardPendingChangesRequest, opts ...grpc.CallOption) (*DiscardPendingChangesResponse, error)
+	// Ping probes a peer with ICMP echo requests over the WireGuard tunnel
+	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
+	// Speedtest measures the throughput to a peer over the WireGuard tunnel
+	Speedtest(ctx context.Context, in *SpeedtestRequest, opts ...grpc.CallOption) (*SpeedtestResponse, error)
 }
 
 type daemonServiceClient struct {
@@ -132,6 +154,15 @@ func (c *daemonServiceClient) DeselectRoutes(ctx context.Context, in *SelectRout
 	return out, nil
 }
 
+func (c *daemonServiceClient) ProposeLANRoutes(ctx context.Context, in *ProposeLANRoutesRequest, opts ...grpc.CallOption) (*ProposeLANRoutesResponse, error) {
+	out := new(ProposeLANRoutesResponse)
+	err := c.cc.Invoke(ctx, "/daemon.DaemonService/ProposeLANRoutes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *daemonServiceClient) DebugBundle(ctx context.Context, in *DebugBundleRequest, opts ...grpc.CallOption) (*DebugBundleResponse, error) {
 	out := new(DebugBundleResponse)
 	err := c.cc.Invoke(ctx, "/daemon.DaemonService/DebugBundle", in, out, opts...)
@@ -150,6 +181,96 @@ func (c *daemonServiceClient) SetLogLevel(ctx context.Context, in *SetLogLevelRe
 	return out, nil
 }
 
+func (c *daemonServiceClient) DebugRoutes(ctx context.Context, in *DebugRoutesRequest, opts ...grpc.CallOption) (*DebugRoutesResponse, error) {
+	out := new(DebugRoutesResponse)
+	err := c.cc.Invoke(ctx, "/daemon.DaemonService/DebugRoutes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) DebugPcap(ctx context.Context, in *DebugPcapRequest, opts ...grpc.CallOption) (*DebugPcapResponse, error) {
+	out := new(DebugPcapResponse)
+	err := c.cc.Invoke(ctx, "/daemon.DaemonService/DebugPcap", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) GetDNSQueryLog(ctx context.Context, in *GetDNSQueryLogRequest, opts ...grpc.CallOption) (*GetDNSQueryLogResponse, error) {
+	out := new(GetDNSQueryLogResponse)
+	err := c.cc.Invoke(ctx, "/daemon.DaemonService/GetDNSQueryLog", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) DebugDroppedConnections(ctx context.Context, in *DebugDroppedConnectionsRequest, opts ...grpc.CallOption) (*DebugDroppedConnectionsResponse, error) {
+	out := new(DebugDroppedConnectionsResponse)
+	err := c.cc.Invoke(ctx, "/daemon.DaemonService/DebugDroppedConnections", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) FlushState(ctx context.Context, in *FlushStateRequest, opts ...grpc.CallOption) (*FlushStateResponse, error) {
+	out := new(FlushStateResponse)
+	err := c.cc.Invoke(ctx, "/daemon.DaemonService/FlushState", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) ListPendingChanges(ctx context.Context, in *ListPendingChangesRequest, opts ...grpc.CallOption) (*ListPendingChangesResponse, error) {
+	out := new(ListPendingChangesResponse)
+	err := c.cc.Invoke(ctx, "/daemon.DaemonService/ListPendingChanges", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) ApplyPendingChanges(ctx context.Context, in *ApplyPendingChangesRequest, opts ...grpc.CallOption) (*ApplyPendingChangesResponse, error) {
+	out := new(ApplyPendingChangesResponse)
+	err := c.cc.Invoke(ctx, "/daemon.DaemonService/ApplyPendingChanges", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) DiscardPendingChanges(ctx context.Context, in *DiscardPendingChangesRequest, opts ...grpc.CallOption) (*DiscardPendingChangesResponse, error) {
+	out := new(DiscardPendingChangesResponse)
+	err := c.cc.Invoke(ctx, "/daemon.DaemonService/DiscardPendingChanges", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error) {
+	out := new(PingResponse)
+	err := c.cc.Invoke(ctx, "/daemon.DaemonService/Ping", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) Speedtest(ctx context.Context, in *SpeedtestRequest, opts ...grpc.CallOption) (*SpeedtestResponse, error) {
+	out := new(SpeedtestResponse)
+	err := c.cc.Invoke(ctx, "/daemon.DaemonService/Speedtest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DaemonServiceServer is the server API for DaemonService service.
 // All implementations must embed UnimplementedDaemonServiceServer
 // for forward compatibility
@@ -173,10 +294,32 @@ type DaemonServiceServer interface {
 	SelectRoutes(context.Context, *SelectRoutesRequest) (*SelectRoutesResponse, error)
 	// Deselect specific routes
 	DeselectRoutes(context.Context, *SelectRoutesRequest) (*SelectRoutesResponse, error)
+	// Propose the local networks as routes, pending approval of an admin
+	ProposeLANRoutes(context.Context, *ProposeLANRoutesRequest) (*ProposeLANRoutesResponse, error)
 	// DebugBundle creates a debug bundle
 	DebugBundle(context.Context, *DebugBundleRequest) (*DebugBundleResponse, error)
 	// SetLogLevel sets the log level of the daemon
 	SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error)
+	// DebugRoutes dumps the routing state of the daemon
+	DebugRoutes(context.Context, *DebugRoutesRequest) (*DebugRoutesResponse, error)
+	// DebugPcap captures the tunneled packets for a duration and returns the location of the pcapng file
+	DebugPcap(context.Context, *DebugPcapRequest) (*DebugPcapResponse, error)
+	// GetDNSQueryLog returns the latest DNS queries handled by the resolver with the query counters
+	GetDNSQueryLog(context.Context, *GetDNSQueryLogRequest) (*GetDNSQueryLogResponse, error)
+	// DebugDroppedConnections returns the latest inbound connections dropped by the firewall
+	DebugDroppedConnections(context.Context, *DebugDroppedConnectionsRequest) (*DebugDroppedConnectionsResponse, error)
+	// FlushState writes the state files batched in memory or on tmpfs to their path
+	FlushState(context.Context, *FlushStateRequest) (*FlushStateResponse, error)
+	// ListPendingChanges returns the route and DNS changes staged for a local approval
+	ListPendingChanges(context.Context, *ListPendingChangesRequest) (*ListPendingChangesResponse, error)
+	// ApplyPendingChanges applies the staged route and DNS changes
+	ApplyPendingChanges(context.Context, *ApplyPendingChangesRequest) (*ApplyPendingChangesResponse, error)
+	// DiscardPendingChanges drops the staged route and DNS changes
+	DiscardPendingChanges(context.Context, *DiscardPendingChangesRequest) (*DiscardPendingChangesResponse, error)
+	// Ping probes a peer with ICMP echo requests over the WireGuard tunnel
+	Ping(context.Context, *PingRequest) (*PingResponse, error)
+	// Speedtest measures the throughput to a peer over the WireGuard tunnel
+	Speedtest(context.Context, *SpeedtestRequest) (*SpeedtestResponse, error)
 	mustEmbedUnimplementedDaemonServiceServer()
 }
 
@@ -211,12 +354,45 @@ func (UnimplementedDaemonServiceServer) SelectRoutes(context.Context, *SelectRou
 func (UnimplementedDaemonServiceServer) DeselectRoutes(context.Context, *SelectRoutesRequest) (*SelectRoutesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeselectRoutes not implemented")
 }
+func (UnimplementedDaemonServiceServer) ProposeLANRoutes(context.Context, *ProposeLANRoutesRequest) (*ProposeLANRoutesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProposeLANRoutes not implemented")
+}
 func (UnimplementedDaemonServiceServer) DebugBundle(context.Context, *DebugBundleRequest) (*DebugBundleResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DebugBundle not implemented")
 }
 func (UnimplementedDaemonServiceServer) SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevel not implemented")
 }
+func (UnimplementedDaemonServiceServer) DebugRoutes(context.Context, *DebugRoutesRequest) (*DebugRoutesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DebugRoutes not implemented")
+}
+func (UnimplementedDaemonServiceServer) DebugPcap(context.Context, *DebugPcapRequest) (*DebugPcapResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DebugPcap not implemented")
+}
+func (UnimplementedDaemonServiceServer) GetDNSQueryLog(context.Context, *GetDNSQueryLogRequest) (*GetDNSQueryLogResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDNSQueryLog not implemented")
+}
+func (UnimplementedDaemonServiceServer) DebugDroppedConnections(context.Context, *DebugDroppedConnectionsRequest) (*DebugDroppedConnectionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DebugDroppedConnections not implemented")
+}
+func (UnimplementedDaemonServiceServer) FlushState(context.Context, *FlushStateRequest) (*FlushStateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FlushState not implemented")
+}
+func (UnimplementedDaemonServiceServer) ListPendingChanges(context.Context, *ListPendingChangesRequest) (*ListPendingChangesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPendingChanges not implemented")
+}
+func (UnimplementedDaemonServiceServer) ApplyPendingChanges(context.Context, *ApplyPendingChangesRequest) (*ApplyPendingChangesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApplyPendingChanges not implemented")
+}
+func (UnimplementedDaemonServiceServer) DiscardPendingChanges(context.Context, *DiscardPendingChangesRequest) (*DiscardPendingChangesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DiscardPendingChanges not implemented")
+}
+func (UnimplementedDaemonServiceServer) Ping(context.Context, *PingRequest) (*PingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+}
+func (UnimplementedDaemonServiceServer) Speedtest(context.Context, *SpeedtestRequest) (*SpeedtestResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Speedtest not implemented")
+}
 func (UnimplementedDaemonServiceServer) mustEmbedUnimplementedDaemonServiceServer() {}
 
 // UnsafeDaemonServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -392,6 +568,24 @@ func _DaemonService_DeselectRoutes_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DaemonService_ProposeLANRoutes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProposeLANRoutesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).ProposeLANRoutes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/daemon.DaemonService/ProposeLANRoutes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).ProposeLANRoutes(ctx, req.(*ProposeLANRoutesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _DaemonService_DebugBundle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DebugBundleRequest)
 	if err := dec(in); err != nil {
@@ -428,6 +622,186 @@ func _DaemonService_SetLogLevel_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DaemonService_DebugRoutes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DebugRoutesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).DebugRoutes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/daemon.DaemonService/DebugRoutes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).DebugRoutes(ctx, req.(*DebugRoutesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_DebugPcap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DebugPcapRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).DebugPcap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/daemon.DaemonService/DebugPcap",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).DebugPcap(ctx, req.(*DebugPcapRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_GetDNSQueryLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDNSQueryLogRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).GetDNSQueryLog(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/daemon.DaemonService/GetDNSQueryLog",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).GetDNSQueryLog(ctx, req.(*GetDNSQueryLogRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_DebugDroppedConnections_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DebugDroppedConnectionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).DebugDroppedConnections(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/daemon.DaemonService/DebugDroppedConnections",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).DebugDroppedConnections(ctx, req.(*DebugDroppedConnectionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_FlushState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FlushStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).FlushState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/daemon.DaemonService/FlushState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).FlushState(ctx, req.(*FlushStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_ListPendingChanges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPendingChangesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).ListPendingChanges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/daemon.DaemonService/ListPendingChanges",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).ListPendingChanges(ctx, req.(*ListPendingChangesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_ApplyPendingChanges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApplyPendingChangesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).ApplyPendingChanges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/daemon.DaemonService/ApplyPendingChanges",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).ApplyPendingChanges(ctx, req.(*ApplyPendingChangesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_DiscardPendingChanges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiscardPendingChangesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).DiscardPendingChanges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/daemon.DaemonService/DiscardPendingChanges",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).DiscardPendingChanges(ctx, req.(*DiscardPendingChangesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/daemon.DaemonService/Ping",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).Ping(ctx, req.(*PingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_Speedtest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SpeedtestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).Speedtest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/daemon.DaemonService/Speedtest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).Speedtest(ctx, req.(*SpeedtestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // DaemonService_ServiceDesc is the grpc.ServiceDesc for DaemonService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -471,6 +845,10 @@ var DaemonService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeselectRoutes",
 			Handler:    _DaemonService_DeselectRoutes_Handler,
 		},
+		{
+			MethodName: "ProposeLANRoutes",
+			Handler:    _DaemonService_ProposeLANRoutes_Handler,
+		},
 		{
 			MethodName: "DebugBundle",
 			Handler:    _DaemonService_DebugBundle_Handler,
@@ -479,6 +857,46 @@ var DaemonService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SetLogLevel",
 			Handler:    _DaemonService_SetLogLevel_Handler,
 		},
+		{
+			MethodName: "DebugRoutes",
+			Handler:    _DaemonService_DebugRoutes_Handler,
+		},
+		{
+			MethodName: "DebugPcap",
+			Handler:    _DaemonService_DebugPcap_Handler,
+		},
+		{
+			MethodName: "GetDNSQueryLog",
+			Handler:    _DaemonService_GetDNSQueryLog_Handler,
+		},
+		{
+			MethodName: "DebugDroppedConnections",
+			Handler:    _DaemonService_DebugDroppedConnections_Handler,
+		},
+		{
+			MethodName: "FlushState",
+			Handler:    _DaemonService_FlushState_Handler,
+		},
+		{
+			MethodName: "ListPendingChanges",
+			Handler:    _DaemonService_ListPendingChanges_Handler,
+		},
+		{
+			MethodName: "ApplyPendingChanges",
+			Handler:    _DaemonService_ApplyPendingChanges_Handler,
+		},
+		{
+			MethodName: "DiscardPendingChanges",
+			Handler:    _DaemonService_DiscardPendingChanges_Handler,
+		},
+		{
+			MethodName: "Ping",
+			Handler:    _DaemonService_Ping_Handler,
+		},
+		{
+			MethodName: "Speedtest",
+			Handler:    _DaemonService_Speedtest_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "daemon.proto",
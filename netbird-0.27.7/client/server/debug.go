@@ -8,15 +8,29 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/netbirdio/netbird/client/anonymize"
+	"github.com/netbirdio/netbird/client/internal"
+	"github.com/netbirdio/netbird/client/internal/capture"
 	"github.com/netbirdio/netbird/client/internal/peer"
 	"github.com/netbirdio/netbird/client/proto"
+	"github.com/netbirdio/netbird/util"
 )
 
-// DebugBundle creates a debug bundle and returns the location.
+const (
+	defaultPcapDuration = 30 * time.Second
+	// maxPcapDuration limits the capture size, the temp directory is in memory on routers
+	maxPcapDuration = 10 * time.Minute
+	// maxDebugBundleUploadSize is the size of the largest debug bundle the management service accepts
+	maxDebugBundleUploadSize = 3 << 20
+)
+
+// DebugBundle creates a debug bundle and returns the location. With upload the bundle is also sent to the management
+// service for support.
 func (s *Server) DebugBundle(_ context.Context, req *proto.DebugBundleRequest) (resp *proto.DebugBundleResponse, err error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -30,10 +44,6 @@ func (s *Server) DebugBundle(_ context.Context, req *proto.DebugBundleRequest) (
 		return nil, fmt.Errorf("create zip file: %w", err)
 	}
 	defer func() {
-		if err := bundlePath.Close(); err != nil {
-			log.Errorf("failed to close zip file: %v", err)
-		}
-
 		if err != nil {
 			if err2 := os.Remove(bundlePath.Name()); err2 != nil {
 				log.Errorf("Failed to remove zip file: %v", err2)
@@ -41,13 +51,44 @@ func (s *Server) DebugBundle(_ context.Context, req *proto.DebugBundleRequest) (
 		}
 	}()
 
-	archive := zip.NewWriter(bundlePath)
+	err = s.writeDebugBundle(bundlePath, req)
+	if closeErr := bundlePath.Close(); closeErr != nil {
+		log.Errorf("failed to close zip file: %v", closeErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp = &proto.DebugBundleResponse{Path: bundlePath.Name()}
+	if req.GetUpload() {
+		name, err := s.uploadDebugBundle(bundlePath.Name())
+		if err != nil {
+			log.Warnf("failed to upload the debug bundle: %v", err)
+			resp.UploadError = err.Error()
+		} else {
+			log.Infof("uploaded the debug bundle to the management service as %s", name)
+			resp.UploadedName = name
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *Server) writeDebugBundle(bundleFile io.Writer, req *proto.DebugBundleRequest) error {
+	archive := zip.NewWriter(bundleFile)
 	defer func() {
 		if err := archive.Close(); err != nil {
 			log.Errorf("failed to close archive writer: %v", err)
 		}
 	}()
 
+	var anonymizer *anonymize.Anonymizer
+	if req.GetAnonymize() {
+		anonymizer = anonymize.NewAnonymizer(anonymize.DefaultAddresses())
+		status := s.statusRecorder.GetFullStatus()
+		seedFromStatus(anonymizer, &status)
+	}
+
 	if status := req.GetStatus(); status != "" {
 		filename := "status.txt"
 		if req.GetAnonymize() {
@@ -55,13 +96,23 @@ func (s *Server) DebugBundle(_ context.Context, req *proto.DebugBundleRequest) (
 		}
 		statusReader := strings.NewReader(status)
 		if err := addFileToZip(archive, statusReader, filename); err != nil {
-			return nil, fmt.Errorf("add status file to zip: %w", err)
+			return fmt.Errorf("add status file to zip: %w", err)
+		}
+	}
+
+	if routes := req.GetRoutes(); routes != "" {
+		filename := "routes.txt"
+		if req.GetAnonymize() {
+			filename = "routes.anon.txt"
+		}
+		if err := addFileToZip(archive, strings.NewReader(routes), filename); err != nil {
+			return fmt.Errorf("add routes file to zip: %w", err)
 		}
 	}
 
 	logFile, err := os.Open(s.logFile)
 	if err != nil {
-		return nil, fmt.Errorf("open log file: %w", err)
+		return fmt.Errorf("open log file: %w", err)
 	}
 	defer func() {
 		if err := logFile.Close(); err != nil {
@@ -72,36 +123,93 @@ func (s *Server) DebugBundle(_ context.Context, req *proto.DebugBundleRequest) (
 	filename := "client.log.txt"
 	var logReader io.Reader
 	errChan := make(chan error, 1)
-	if req.GetAnonymize() {
+	if anonymizer != nil {
 		filename = "client.anon.log.txt"
 		var writer io.WriteCloser
 		logReader, writer = io.Pipe()
 
-		go s.anonymize(logFile, writer, errChan)
+		go anonymizeLines(anonymizer, logFile, writer, errChan)
 	} else {
 		logReader = logFile
 	}
 	if err := addFileToZip(archive, logReader, filename); err != nil {
-		return nil, fmt.Errorf("add log file to zip: %w", err)
+		return fmt.Errorf("add log file to zip: %w", err)
 	}
 
 	select {
 	case err := <-errChan:
 		if err != nil {
-			return nil, err
+			return err
 		}
 	default:
 	}
 
-	return &proto.DebugBundleResponse{Path: bundlePath.Name()}, nil
+	if err := addTextToZip(archive, s.interfaceDebugInfo(), "interface", anonymizer); err != nil {
+		return fmt.Errorf("add interface file to zip: %w", err)
+	}
+	if err := addTextToZip(archive, firewallRules(), "firewall", anonymizer); err != nil {
+		return fmt.Errorf("add firewall file to zip: %w", err)
+	}
+	if err := addTextToZip(archive, formatSyncErrors(s.statusRecorder.GetSyncErrors()), "sync_errors", anonymizer); err != nil {
+		return fmt.Errorf("add sync errors file to zip: %w", err)
+	}
+
+	return nil
 }
 
-func (s *Server) anonymize(reader io.Reader, writer io.WriteCloser, errChan chan<- error) {
-	scanner := bufio.NewScanner(reader)
-	anonymizer := anonymize.NewAnonymizer(anonymize.DefaultAddresses())
+// interfaceDebugInfo describes the WireGuard interface of the connected engine
+func (s *Server) interfaceDebugInfo() string {
+	if s.connectClient == nil || s.connectClient.Engine() == nil {
+		return "not connected\n"
+	}
+
+	info, err := s.connectClient.Engine().InterfaceDebugInfo()
+	if err != nil {
+		return fmt.Sprintf("failed to get the interface info: %v\n", err)
+	}
+	return info
+}
+
+func (s *Server) uploadDebugBundle(path string) (string, error) {
+	if s.connectClient == nil || s.connectClient.Engine() == nil {
+		return "", fmt.Errorf("not connected")
+	}
 
-	status := s.statusRecorder.GetFullStatus()
-	seedFromStatus(anonymizer, &status)
+	bundle, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read debug bundle: %w", err)
+	}
+	if len(bundle) > maxDebugBundleUploadSize {
+		return "", fmt.Errorf("debug bundle of %d bytes is larger than the upload limit of %d bytes, send the file instead", len(bundle), maxDebugBundleUploadSize)
+	}
+
+	return s.connectClient.Engine().UploadDebugBundle(bundle)
+}
+
+func formatSyncErrors(syncErrors []peer.SyncError) string {
+	if len(syncErrors) == 0 {
+		return "no errors\n"
+	}
+
+	var b strings.Builder
+	for _, syncErr := range syncErrors {
+		fmt.Fprintf(&b, "%s  %s\n", syncErr.Time.UTC().Format(time.RFC3339), syncErr.Error)
+	}
+	return b.String()
+}
+
+// addTextToZip adds the text as name.txt, or anonymized as name.anon.txt when the anonymizer is set
+func addTextToZip(archive *zip.Writer, text, name string, anonymizer *anonymize.Anonymizer) error {
+	filename := name + ".txt"
+	if anonymizer != nil {
+		filename = name + ".anon.txt"
+		text = anonymizer.AnonymizeString(text)
+	}
+	return addFileToZip(archive, strings.NewReader(text), filename)
+}
+
+func anonymizeLines(anonymizer *anonymize.Anonymizer, reader io.Reader, writer io.WriteCloser, errChan chan<- error) {
+	scanner := bufio.NewScanner(reader)
 
 	defer func() {
 		if err := writer.Close(); err != nil {
@@ -128,11 +236,115 @@ func (s *Server) SetLogLevel(_ context.Context, req *proto.SetLogLevelRequest) (
 		return nil, fmt.Errorf("invalid log level: %w", err)
 	}
 
-	log.SetLevel(level)
+	util.SetLogLevel(level)
 	log.Infof("Log level set to %s", level.String())
 	return &proto.SetLogLevelResponse{}, nil
 }
 
+// DebugPcap captures the packets of the tunnel for the requested duration and returns the location of the pcapng file.
+func (s *Server) DebugPcap(ctx context.Context, req *proto.DebugPcapRequest) (*proto.DebugPcapResponse, error) {
+	duration := defaultPcapDuration
+	if req.GetDuration() != nil {
+		duration = req.GetDuration().AsDuration()
+	}
+	if duration <= 0 || duration > maxPcapDuration {
+		return nil, fmt.Errorf("invalid capture duration %s, it has to be between 0 and %s", duration, maxPcapDuration)
+	}
+
+	s.mutex.Lock()
+	var engine *internal.Engine
+	var ifaceName string
+	if s.connectClient != nil && s.config != nil {
+		engine = s.connectClient.Engine()
+		ifaceName = s.config.WgIface
+	}
+	s.mutex.Unlock()
+
+	if engine == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	file, err := os.CreateTemp("", "netbird.capture.*.pcapng")
+	if err != nil {
+		return nil, fmt.Errorf("create capture file: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Errorf("failed to close capture file: %v", err)
+		}
+	}()
+
+	writer, err := capture.NewWriter(file, ifaceName, int(req.GetSnapLen()))
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return nil, err
+	}
+
+	if err := engine.SetPacketCapture(writer); err != nil {
+		_ = os.Remove(file.Name())
+		return nil, fmt.Errorf("start capture: %w", err)
+	}
+	log.Infof("capturing the tunnel packets to %s for %s", file.Name(), duration)
+
+	timer := time.NewTimer(duration)
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		timer.Stop()
+	}
+
+	if err := engine.SetPacketCapture(nil); err != nil {
+		log.Warnf("failed to stop the capture: %v", err)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return nil, fmt.Errorf("write capture file: %w", err)
+	}
+	log.Infof("captured %d packets to %s", writer.Packets(), file.Name())
+
+	return &proto.DebugPcapResponse{Path: file.Name(), Packets: writer.Packets()}, nil
+}
+
+// DebugDroppedConnections returns the latest inbound connections dropped by the firewall
+func (s *Server) DebugDroppedConnections(_ context.Context, req *proto.DebugDroppedConnectionsRequest) (*proto.DebugDroppedConnectionsResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.connectClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	engine := s.connectClient.Engine()
+	if engine == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	conns, err := engine.GetDroppedConnections()
+	if err != nil {
+		return nil, err
+	}
+
+	if limit := int(req.GetLimit()); limit > 0 && len(conns) > limit {
+		conns = conns[len(conns)-limit:]
+	}
+
+	resp := &proto.DebugDroppedConnectionsResponse{}
+	for _, conn := range conns {
+		resp.Connections = append(resp.Connections, &proto.DroppedConnection{
+			SourceIP:        conn.SrcIP.String(),
+			SourcePort:      uint32(conn.SrcPort),
+			DestinationPort: uint32(conn.DstPort),
+			Protocol:        string(conn.Protocol),
+			PolicyIDs:       conn.PolicyIDs,
+			FirstSeen:       timestamppb.New(conn.FirstSeen),
+			LastSeen:        timestamppb.New(conn.LastSeen),
+			Packets:         conn.Packets,
+		})
+	}
+
+	return resp, nil
+}
+
 func addFileToZip(archive *zip.Writer, reader io.Reader, filename string) error {
 	header := &zip.FileHeader{
 		Name:   filename,
@@ -0,0 +1,8 @@
+//go:build !linux || android
+
+package server
+
+// firewallRules isn't supported on this platform, the firewall of the client is only dumped on Linux
+func firewallRules() string {
+	return "not supported on this platform\n"
+}
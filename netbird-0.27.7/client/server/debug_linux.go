@@ -0,0 +1,33 @@
+//go:build !android
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// firewallCommandTimeout is the time a firewall dump command can take
+const firewallCommandTimeout = 10 * time.Second
+
+// firewallRules dumps the iptables and nftables rulesets, the tools missing on the system are reported in the dump
+func firewallRules() string {
+	var b strings.Builder
+	for _, command := range [][]string{{"iptables-save"}, {"ip6tables-save"}, {"nft", "list", "ruleset"}} {
+		ctx, cancel := context.WithTimeout(context.Background(), firewallCommandTimeout)
+		out, err := exec.CommandContext(ctx, command[0], command[1:]...).Output()
+		cancel()
+
+		fmt.Fprintf(&b, "### %s\n", strings.Join(command, " "))
+		if err != nil {
+			fmt.Fprintf(&b, "failed: %v\n\n", err)
+			continue
+		}
+		b.Write(out)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
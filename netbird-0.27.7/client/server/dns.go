@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/netbirdio/netbird/client/proto"
+)
+
+// GetDNSQueryLog returns the latest DNS queries handled by the resolver with the query counters
+func (s *Server) GetDNSQueryLog(_ context.Context, req *proto.GetDNSQueryLogRequest) (*proto.GetDNSQueryLogResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.connectClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	engine := s.connectClient.Engine()
+	if engine == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	entries, stats, err := engine.GetDNSQueryLog()
+	if err != nil {
+		return nil, err
+	}
+
+	if limit := int(req.GetLimit()); limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+
+	resp := &proto.GetDNSQueryLogResponse{
+		LocalQueries:     stats.Local,
+		ForwardedQueries: stats.Forwarded,
+		BlockedQueries:   stats.Blocked,
+		FailedQueries:    stats.Failed,
+		AverageLatency:   durationpb.New(stats.AverageLatency()),
+	}
+	for _, entry := range entries {
+		resp.Queries = append(resp.Queries, &proto.DNSQuery{
+			Time:     timestamppb.New(entry.Time),
+			Domain:   entry.Domain,
+			Type:     entry.Type,
+			Decision: string(entry.Decision),
+			Rcode:    entry.Rcode,
+			Latency:  durationpb.New(entry.Latency),
+		})
+	}
+
+	return resp, nil
+}
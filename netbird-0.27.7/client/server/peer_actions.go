@@ -0,0 +1,35 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/netbirdio/netbird/client/internal"
+	"github.com/netbirdio/netbird/client/internal/persist"
+	"github.com/netbirdio/netbird/client/proto"
+)
+
+// peerActionHandler runs the remote actions of the management service that need the daemon
+type peerActionHandler struct {
+	server *Server
+}
+
+// CollectDebugBundle uploads an anonymized debug bundle to the management service
+func (h *peerActionHandler) CollectDebugBundle() (string, error) {
+	resp, err := h.server.DebugBundle(context.Background(), &proto.DebugBundleRequest{Anonymize: true, Upload: true})
+	if err != nil {
+		return "", err
+	}
+	if resp.GetUploadError() != "" {
+		return "", fmt.Errorf("upload: %s", resp.GetUploadError())
+	}
+	return resp.GetUploadedName(), nil
+}
+
+// SaveConfig writes the config out and flushes it, a rotated key must survive a power loss
+func (h *peerActionHandler) SaveConfig(config *internal.Config) error {
+	if err := internal.WriteOutConfig(h.server.latestConfigInput.ConfigPath, config); err != nil {
+		return err
+	}
+	return persist.Flush()
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	gstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/netbirdio/netbird/client/internal"
+	"github.com/netbirdio/netbird/client/proto"
+)
+
+// ListPendingChanges returns the route and DNS changes of the network map staged for a local approval
+func (s *Server) ListPendingChanges(_ context.Context, _ *proto.ListPendingChangesRequest) (*proto.ListPendingChangesResponse, error) {
+	engine, err := s.pendingChangesEngine()
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := engine.PendingNetworkChanges()
+	if err != nil {
+		return nil, gstatus.Error(codes.FailedPrecondition, err.Error())
+	}
+	if pending == nil {
+		return &proto.ListPendingChangesResponse{}, nil
+	}
+
+	resp := &proto.ListPendingChangesResponse{
+		Pending:  true,
+		Serial:   pending.Serial,
+		StagedAt: timestamppb.New(pending.StagedAt),
+	}
+	if !pending.AutoApplyAt.IsZero() {
+		resp.AutoApplyAt = timestamppb.New(pending.AutoApplyAt)
+	}
+	for _, change := range pending.Changes {
+		resp.Changes = append(resp.Changes, &proto.PendingChange{
+			Kind:        change.Kind,
+			Action:      change.Action,
+			Description: change.Description,
+		})
+	}
+
+	return resp, nil
+}
+
+// ApplyPendingChanges applies the staged route and DNS changes
+func (s *Server) ApplyPendingChanges(_ context.Context, req *proto.ApplyPendingChangesRequest) (*proto.ApplyPendingChangesResponse, error) {
+	engine, err := s.pendingChangesEngine()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := engine.ApplyPendingNetworkChanges(req.GetSerial()); err != nil {
+		return nil, toPendingChangesError(err)
+	}
+	return &proto.ApplyPendingChangesResponse{}, nil
+}
+
+// DiscardPendingChanges drops the staged route and DNS changes and keeps the applied ones
+func (s *Server) DiscardPendingChanges(_ context.Context, req *proto.DiscardPendingChangesRequest) (*proto.DiscardPendingChangesResponse, error) {
+	engine, err := s.pendingChangesEngine()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := engine.DiscardPendingNetworkChanges(req.GetSerial()); err != nil {
+		return nil, toPendingChangesError(err)
+	}
+	return &proto.DiscardPendingChangesResponse{}, nil
+}
+
+func (s *Server) pendingChangesEngine() (*internal.Engine, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.connectClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	engine := s.connectClient.Engine()
+	if engine == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+	return engine, nil
+}
+
+func toPendingChangesError(err error) error {
+	switch {
+	case errors.Is(err, internal.ErrNoPendingNetworkChanges):
+		return gstatus.Error(codes.NotFound, err.Error())
+	case errors.Is(err, internal.ErrPendingNetworkChangesReplaced):
+		return gstatus.Error(codes.Aborted, err.Error())
+	default:
+		return err
+	}
+}
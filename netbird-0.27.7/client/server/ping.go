@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/netbirdio/netbird/client/proto"
+)
+
+const (
+	defaultPingCount   = 4
+	maxPingCount       = 100
+	defaultPingTimeout = 2 * time.Second
+)
+
+// Ping probes a peer with ICMP echo requests over the WireGuard tunnel and returns the replies with the path the
+// peer is reached on
+func (s *Server) Ping(ctx context.Context, req *proto.PingRequest) (*proto.PingResponse, error) {
+	count := int(req.GetCount())
+	if count <= 0 {
+		count = defaultPingCount
+	}
+	if count > maxPingCount {
+		return nil, fmt.Errorf("count must not exceed %d", maxPingCount)
+	}
+	timeout := defaultPingTimeout
+	if req.GetTimeout() != nil && req.GetTimeout().AsDuration() > 0 {
+		timeout = req.GetTimeout().AsDuration()
+	}
+
+	// the probe takes seconds, the lock isn't held for it
+	s.mutex.Lock()
+	if s.connectClient == nil || s.connectClient.Engine() == nil {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("not connected")
+	}
+	engine := s.connectClient.Engine()
+	s.mutex.Unlock()
+
+	result, err := engine.PingPeer(ctx, req.GetPeer(), count, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &proto.PingResponse{
+		Ip:              result.IP,
+		Fqdn:            result.FQDN,
+		Sent:            int32(result.Sent),
+		Received:        int32(result.Received),
+		Path:            result.Path,
+		LocalCandidate:  result.LocalCandidate,
+		RemoteCandidate: result.RemoteCandidate,
+	}
+	for _, rtt := range result.RTTs {
+		resp.Rtts = append(resp.Rtts, durationpb.New(rtt))
+	}
+	return resp, nil
+}
@@ -8,6 +8,7 @@ import (
 
 	"golang.org/x/exp/maps"
 
+	"github.com/netbirdio/netbird/client/internal/routemanager"
 	"github.com/netbirdio/netbird/client/proto"
 	"github.com/netbirdio/netbird/route"
 )
@@ -15,6 +16,7 @@ import (
 type selectRoute struct {
 	NetID    route.NetID
 	Network  netip.Prefix
+	Domains  []string
 	Selected bool
 }
 
@@ -43,6 +45,7 @@ func (s *Server) ListRoutes(ctx context.Context, req *proto.ListRoutesRequest) (
 		route := &selectRoute{
 			NetID:    id,
 			Network:  rt[0].Network,
+			Domains:  rt[0].Domains,
 			Selected: routeSelector.IsSelected(id),
 		}
 		routes = append(routes, route)
@@ -65,9 +68,14 @@ func (s *Server) ListRoutes(ctx context.Context, req *proto.ListRoutesRequest) (
 
 	var pbRoutes []*proto.Route
 	for _, route := range routes {
+		var network string
+		if route.Network.IsValid() {
+			network = route.Network.String()
+		}
 		pbRoutes = append(pbRoutes, &proto.Route{
 			ID:       string(route.NetID),
-			Network:  route.Network.String(),
+			Network:  network,
+			Domains:  route.Domains,
 			Selected: route.Selected,
 		})
 	}
@@ -142,3 +150,112 @@ func toNetIDs(routes []string) []route.NetID {
 	}
 	return netIDs
 }
+
+// DebugRoutes returns the routing state of the client, including the system routes and the routes skipped by the client
+func (s *Server) DebugRoutes(_ context.Context, _ *proto.DebugRoutesRequest) (*proto.DebugRoutesResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.connectClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	engine := s.connectClient.Engine()
+	if engine == nil || engine.GetRouteManager() == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	return toDebugRoutesResponse(engine.GetRouteManager().GetDebugInfo()), nil
+}
+
+func toDebugRoutesResponse(info routemanager.DebugInfo) *proto.DebugRoutesResponse {
+	resp := &proto.DebugRoutesResponse{
+		RouteCacheDisabled: info.RouteCacheDisabled,
+		Errors:             info.Errors,
+	}
+
+	for _, prefix := range info.RoutingTable {
+		resp.RoutingTable = append(resp.RoutingTable, prefix.String())
+	}
+
+	for _, nextHop := range info.NextHops {
+		pbNextHop := &proto.NextHop{
+			Destination: nextHop.Destination.String(),
+			Interface:   nextHop.Interface,
+			Error:       nextHop.Error,
+		}
+		if nextHop.NextHop.IsValid() {
+			pbNextHop.NextHop = nextHop.NextHop.String()
+		}
+		resp.NextHops = append(resp.NextHops, pbNextHop)
+	}
+
+	for _, exclusion := range info.ExclusionRoutes {
+		pbExclusion := &proto.ExclusionRoute{
+			Prefix:     exclusion.Prefix.String(),
+			Interface:  exclusion.Interface,
+			References: int32(exclusion.References),
+		}
+		if exclusion.NextHop.IsValid() {
+			pbExclusion.NextHop = exclusion.NextHop.String()
+		}
+		resp.ExclusionRoutes = append(resp.ExclusionRoutes, pbExclusion)
+	}
+
+	for _, network := range info.Networks {
+		resp.ClientNetworks = append(resp.ClientNetworks, &proto.ClientNetwork{
+			ID:           string(network.ID),
+			Network:      network.Network,
+			RoutingPeers: network.RoutingPeers,
+		})
+	}
+
+	for _, skipped := range info.SkippedRoutes {
+		resp.SkippedRoutes = append(resp.SkippedRoutes, &proto.SkippedRoute{
+			ID:      string(skipped.NetID),
+			Network: skipped.Network,
+			Reason:  skipped.Reason,
+		})
+	}
+
+	for _, conflict := range info.RouteConflicts {
+		resp.RouteConflicts = append(resp.RouteConflicts, &proto.RouteConflict{
+			ID:            conflict.NetID,
+			Network:       conflict.Network.String(),
+			ConflictsWith: conflict.ConflictsWith,
+			Reason:        conflict.Reason,
+		})
+	}
+
+	return resp
+}
+
+// ProposeLANRoutes proposes the local networks of the client as routes to the management service
+func (s *Server) ProposeLANRoutes(_ context.Context, req *proto.ProposeLANRoutesRequest) (*proto.ProposeLANRoutesResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.connectClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	engine := s.connectClient.Engine()
+	if engine == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	detected, proposed, err := engine.ProposeLANRoutes(req.GetDryRun())
+	if err != nil {
+		return nil, fmt.Errorf("propose LAN routes: %w", err)
+	}
+
+	resp := &proto.ProposeLANRoutesResponse{}
+	for _, prefix := range detected {
+		resp.Detected = append(resp.Detected, prefix.String())
+	}
+	for _, prefix := range proposed {
+		resp.Proposed = append(resp.Proposed, prefix.String())
+	}
+
+	return resp, nil
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/netbirdio/netbird/client/internal"
 	"github.com/netbirdio/netbird/client/internal/peer"
 	"github.com/netbirdio/netbird/client/proto"
+	"github.com/netbirdio/netbird/util"
 	"github.com/netbirdio/netbird/version"
 )
 
@@ -67,6 +68,9 @@ type Server struct {
 	relayProbe  *internal.Probe
 	wgProbe     *internal.Probe
 	lastProbe   time.Time
+
+	// connListener is notified about the connection and the peer list changes, e.g. to broadcast them on ubus
+	connListener peer.Listener
 }
 
 type oauthAuthFlow struct {
@@ -76,12 +80,14 @@ type oauthAuthFlow struct {
 	waitCancel context.CancelFunc
 }
 
-// New server instance constructor.
-func New(ctx context.Context, configPath, logFile string) *Server {
+// New server instance constructor. The profile is the client profile the server runs, empty for the default one
+func New(ctx context.Context, configPath, uciConfigPath, profile, logFile string) *Server {
 	return &Server{
 		rootCtx: ctx,
 		latestConfigInput: internal.ConfigInput{
-			ConfigPath: configPath,
+			ConfigPath:    configPath,
+			UCIConfigPath: uciConfigPath,
+			Profile:       profile,
 		},
 		logFile:     logFile,
 		mgmProbe:    internal.NewProbe(),
@@ -91,6 +97,33 @@ func New(ctx context.Context, configPath, logFile string) *Server {
 	}
 }
 
+// SetConnectionListener sets the listener notified about the connection and the peer list changes of the client
+func (s *Server) SetConnectionListener(listener peer.Listener) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.connListener = listener
+	if s.statusRecorder != nil {
+		s.statusRecorder.SetConnectionListener(listener)
+	}
+}
+
+// applyLogConfig sets up the daemon log with the log settings of the config, the log level is kept
+func (s *Server) applyLogConfig(config *internal.Config) {
+	if err := util.InitLogWithConfig(s.logFile, config.LogConfig()); err != nil {
+		log.Errorf("failed to apply the log settings of the config: %v", err)
+	}
+}
+
+// newStatusRecorder creates the status recorder of the daemon notifying the connection listener
+func (s *Server) newStatusRecorder(mgmAddress string) *peer.Status {
+	recorder := peer.NewRecorder(mgmAddress)
+	if s.connListener != nil {
+		recorder.SetConnectionListener(s.connListener)
+	}
+	return recorder
+}
+
 func (s *Server) Start() error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -131,9 +164,10 @@ func (s *Server) Start() error {
 	config, _ = internal.UpdateOldManagementURL(ctx, config, s.latestConfigInput.ConfigPath)
 
 	s.config = config
+	s.applyLogConfig(config)
 
 	if s.statusRecorder == nil {
-		s.statusRecorder = peer.NewRecorder(config.ManagementURL.String())
+		s.statusRecorder = s.newStatusRecorder(config.ManagementURL.String())
 	}
 	s.statusRecorder.UpdateManagementAddress(config.ManagementURL.String())
 	s.statusRecorder.UpdateRosenpass(config.RosenpassEnabled, config.RosenpassPermissive)
@@ -185,6 +219,7 @@ func (s *Server) connectWithRetryRuns(ctx context.Context, config *internal.Conf
 	runOperation := func() error {
 		log.Tracef("running client connection")
 		s.connectClient = internal.NewConnectClient(ctx, config, statusRecorder)
+		s.connectClient.SetPeerActionHandler(&peerActionHandler{server: s})
 		err := s.connectClient.RunWithProbes(mgmProbe, signalProbe, relayProbe, wgProbe)
 		if err != nil {
 			log.Debugf("run client connection exited with error: %v. Will retry in the background", err)
@@ -355,6 +390,181 @@ func (s *Server) Login(callerCtx context.Context, msg *proto.LoginRequest) (*pro
 		s.latestConfigInput.WireguardPort = &port
 	}
 
+	if msg.WireguardMode != nil {
+		inputConfig.WireguardMode = msg.WireguardMode
+		s.latestConfigInput.WireguardMode = msg.WireguardMode
+	}
+
+	if msg.WireguardPortPool != nil {
+		inputConfig.WireguardPortPool = msg.WireguardPortPool
+		s.latestConfigInput.WireguardPortPool = msg.WireguardPortPool
+	}
+
+	if msg.WireguardPortRoamingInterval != nil {
+		interval := msg.WireguardPortRoamingInterval.AsDuration()
+		inputConfig.PortRoamingInterval = &interval
+		s.latestConfigInput.PortRoamingInterval = &interval
+	}
+
+	if msg.TcpFallbackPort != nil {
+		port := int(*msg.TcpFallbackPort)
+		inputConfig.TCPFallbackPort = &port
+		s.latestConfigInput.TCPFallbackPort = &port
+	}
+
+	if msg.Uplink != nil {
+		inputConfig.Uplink = msg.Uplink
+		s.latestConfigInput.Uplink = msg.Uplink
+	}
+
+	if msg.InterfaceAddress != nil {
+		inputConfig.InterfaceAddress = msg.InterfaceAddress
+		s.latestConfigInput.InterfaceAddress = msg.InterfaceAddress
+	}
+
+	if msg.CleanICEDisabledCandidates {
+		inputConfig.ICEDisabledCandidates = make([]string, 0)
+		s.latestConfigInput.ICEDisabledCandidates = nil
+	} else if len(msg.IceDisabledCandidates) > 0 {
+		inputConfig.ICEDisabledCandidates = msg.IceDisabledCandidates
+		s.latestConfigInput.ICEDisabledCandidates = msg.IceDisabledCandidates
+	}
+
+	if msg.CleanICEExcludedSubnets {
+		inputConfig.ICEExcludedSubnets = make([]string, 0)
+		s.latestConfigInput.ICEExcludedSubnets = nil
+	} else if len(msg.IceExcludedSubnets) > 0 {
+		inputConfig.ICEExcludedSubnets = msg.IceExcludedSubnets
+		s.latestConfigInput.ICEExcludedSubnets = msg.IceExcludedSubnets
+	}
+
+	if msg.IceFailedTimeout != nil {
+		timeout := msg.IceFailedTimeout.AsDuration()
+		inputConfig.ICEFailedTimeout = &timeout
+		s.latestConfigInput.ICEFailedTimeout = &timeout
+	}
+
+	if msg.IceDisconnectedTimeout != nil {
+		timeout := msg.IceDisconnectedTimeout.AsDuration()
+		inputConfig.ICEDisconnectedTimeout = &timeout
+		s.latestConfigInput.ICEDisconnectedTimeout = &timeout
+	}
+
+	if msg.Mtu != nil {
+		mtu := int(*msg.Mtu)
+		inputConfig.MTU = &mtu
+		s.latestConfigInput.MTU = &mtu
+	}
+
+	if msg.PmtuDiscovery != nil {
+		inputConfig.PMTUDiscovery = msg.PmtuDiscovery
+		s.latestConfigInput.PMTUDiscovery = msg.PmtuDiscovery
+	}
+
+	if msg.DnsCacheMinTTL != nil {
+		ttl := msg.DnsCacheMinTTL.AsDuration()
+		inputConfig.DNSCacheMinTTL = &ttl
+		s.latestConfigInput.DNSCacheMinTTL = &ttl
+	}
+
+	if msg.DnsCacheMaxTTL != nil {
+		ttl := msg.DnsCacheMaxTTL.AsDuration()
+		inputConfig.DNSCacheMaxTTL = &ttl
+		s.latestConfigInput.DNSCacheMaxTTL = &ttl
+	}
+
+	if msg.DnsQueryLog != nil {
+		inputConfig.DNSQueryLog = msg.DnsQueryLog
+		s.latestConfigInput.DNSQueryLog = msg.DnsQueryLog
+	}
+
+	if msg.LogDroppedConnections != nil {
+		inputConfig.DropLog = msg.LogDroppedConnections
+		s.latestConfigInput.DropLog = msg.LogDroppedConnections
+	}
+
+	if msg.LazyFirewallRules != nil {
+		inputConfig.LazyFirewallRules = msg.LazyFirewallRules
+		s.latestConfigInput.LazyFirewallRules = msg.LazyFirewallRules
+	}
+
+	if msg.LazyFirewallIdleTimeout != nil {
+		timeout := msg.LazyFirewallIdleTimeout.AsDuration()
+		inputConfig.LazyFirewallIdleTimeout = &timeout
+		s.latestConfigInput.LazyFirewallIdleTimeout = &timeout
+	}
+
+	if msg.LowMemory != nil {
+		inputConfig.LowMemory = msg.LowMemory
+		s.latestConfigInput.LowMemory = msg.LowMemory
+	}
+
+	if msg.DisableSysctl != nil {
+		inputConfig.DisableSysctl = msg.DisableSysctl
+		s.latestConfigInput.DisableSysctl = msg.DisableSysctl
+	}
+
+	if msg.SshRecordingDir != nil {
+		inputConfig.SSHRecordingDir = msg.SshRecordingDir
+		s.latestConfigInput.SSHRecordingDir = msg.SshRecordingDir
+	}
+
+	if msg.SshRecordingRetention != nil {
+		retention := msg.SshRecordingRetention.AsDuration()
+		inputConfig.SSHRecordingRetention = &retention
+		s.latestConfigInput.SSHRecordingRetention = &retention
+	}
+
+	if msg.StageNetworkChanges != nil {
+		inputConfig.StageNetworkChanges = msg.StageNetworkChanges
+		s.latestConfigInput.StageNetworkChanges = msg.StageNetworkChanges
+	}
+
+	if msg.StagedChangesAutoApply != nil {
+		delay := msg.StagedChangesAutoApply.AsDuration()
+		inputConfig.StagedChangesAutoApply = &delay
+		s.latestConfigInput.StagedChangesAutoApply = &delay
+	}
+
+	if msg.AutoUpdate != nil {
+		inputConfig.AutoUpdate = msg.AutoUpdate
+		s.latestConfigInput.AutoUpdate = msg.AutoUpdate
+	}
+
+	if msg.UpdatePublicKey != nil {
+		inputConfig.UpdatePublicKey = msg.UpdatePublicKey
+		s.latestConfigInput.UpdatePublicKey = msg.UpdatePublicKey
+	}
+
+	if msg.UpdateAllowDowngrade != nil {
+		inputConfig.UpdateAllowDowngrade = msg.UpdateAllowDowngrade
+		s.latestConfigInput.UpdateAllowDowngrade = msg.UpdateAllowDowngrade
+	}
+
+	if msg.CleanInventoryFields {
+		inputConfig.InventoryFields = make([]string, 0)
+		s.latestConfigInput.InventoryFields = nil
+	} else if len(msg.InventoryFields) > 0 {
+		inputConfig.InventoryFields = msg.InventoryFields
+		s.latestConfigInput.InventoryFields = msg.InventoryFields
+	}
+
+	if msg.CleanInventoryPackages {
+		inputConfig.InventoryPackages = make([]string, 0)
+		s.latestConfigInput.InventoryPackages = nil
+	} else if len(msg.InventoryPackages) > 0 {
+		inputConfig.InventoryPackages = msg.InventoryPackages
+		s.latestConfigInput.InventoryPackages = msg.InventoryPackages
+	}
+
+	if msg.CleanDNSHostOverrides {
+		inputConfig.DNSHostOverrides = make([]string, 0)
+		s.latestConfigInput.DNSHostOverrides = nil
+	} else if len(msg.DnsHostOverrides) > 0 {
+		inputConfig.DNSHostOverrides = msg.DnsHostOverrides
+		s.latestConfigInput.DNSHostOverrides = msg.DnsHostOverrides
+	}
+
 	if msg.NetworkMonitor != nil {
 		inputConfig.NetworkMonitor = msg.NetworkMonitor
 		s.latestConfigInput.NetworkMonitor = msg.NetworkMonitor
@@ -384,6 +594,7 @@ func (s *Server) Login(callerCtx context.Context, msg *proto.LoginRequest) (*pro
 
 	s.mutex.Lock()
 	s.config = config
+	s.applyLogConfig(config)
 	s.mutex.Unlock()
 
 	if _, err := s.loginAttempt(ctx, "", ""); err == nil {
@@ -393,7 +604,15 @@ func (s *Server) Login(callerCtx context.Context, msg *proto.LoginRequest) (*pro
 
 	state.Set(internal.StatusConnecting)
 
-	if msg.SetupKey == "" {
+	setupKey := msg.SetupKey
+	if setupKey == "" {
+		setupKey, err = internal.ReadUCISetupKey(s.latestConfigInput.UCIConfigPath, s.latestConfigInput.Profile)
+		if err != nil {
+			log.Warnf("failed to read the setup key of the UCI config: %v", err)
+		}
+	}
+
+	if setupKey == "" {
 		oAuthFlow, err := auth.NewOAuthFlow(ctx, config, msg.IsLinuxDesktopClient)
 		if err != nil {
 			state.Set(internal.StatusLoginFailed)
@@ -439,7 +658,7 @@ func (s *Server) Login(callerCtx context.Context, msg *proto.LoginRequest) (*pro
 		}, nil
 	}
 
-	if loginStatus, err := s.loginAttempt(ctx, msg.SetupKey, ""); err != nil {
+	if loginStatus, err := s.loginAttempt(ctx, setupKey, ""); err != nil {
 		state.Set(loginStatus)
 		return nil, err
 	}
@@ -565,7 +784,7 @@ func (s *Server) Up(callerCtx context.Context, _ *proto.UpRequest) (*proto.UpRes
 	}
 
 	if s.statusRecorder == nil {
-		s.statusRecorder = peer.NewRecorder(s.config.ManagementURL.String())
+		s.statusRecorder = s.newStatusRecorder(s.config.ManagementURL.String())
 	}
 	s.statusRecorder.UpdateManagementAddress(s.config.ManagementURL.String())
 	s.statusRecorder.UpdateRosenpass(s.config.RosenpassEnabled, s.config.RosenpassPermissive)
@@ -590,6 +809,39 @@ func (s *Server) Down(_ context.Context, _ *proto.DownRequest) (*proto.DownRespo
 	return &proto.DownResponse{}, nil
 }
 
+// Reload reads the configuration again, e.g. after the UCI config changed, and reconnects with it when the client
+// is connected or connecting. It is called on the reload signal of the daemon
+func (s *Server) Reload() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	config, err := internal.UpdateConfig(s.latestConfigInput)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+	s.config = config
+	s.applyLogConfig(config)
+	log.Infof("reloaded the configuration from %s", s.latestConfigInput.ConfigPath)
+
+	state := internal.CtxGetState(s.rootCtx)
+	status, err := state.Status()
+	if err != nil || s.actCancel == nil || status == internal.StatusIdle || status == internal.StatusNeedsLogin {
+		return nil
+	}
+
+	log.Infof("reconnecting with the reloaded configuration")
+	s.actCancel()
+	state.Set(internal.StatusIdle)
+
+	ctx, cancel := context.WithCancel(s.rootCtx)
+	s.actCancel = cancel
+	s.statusRecorder.UpdateManagementAddress(config.ManagementURL.String())
+	s.statusRecorder.UpdateRosenpass(config.RosenpassEnabled, config.RosenpassPermissive)
+
+	go s.connectWithRetryRuns(ctx, config, s.statusRecorder, s.mgmProbe, s.signalProbe, s.relayProbe, s.wgProbe)
+	return nil
+}
+
 // Status returns the daemon status
 func (s *Server) Status(
 	_ context.Context,
@@ -606,10 +858,11 @@ func (s *Server) Status(
 	statusResponse := proto.StatusResponse{Status: string(status), DaemonVersion: version.NetbirdVersion()}
 
 	if s.statusRecorder == nil {
-		s.statusRecorder = peer.NewRecorder(s.config.ManagementURL.String())
+		s.statusRecorder = s.newStatusRecorder(s.config.ManagementURL.String())
+		// the running engine updates it with the setting it applied, which the management service may enable
+		s.statusRecorder.UpdateRosenpass(s.config.RosenpassEnabled, s.config.RosenpassPermissive)
 	}
 	s.statusRecorder.UpdateManagementAddress(s.config.ManagementURL.String())
-	s.statusRecorder.UpdateRosenpass(s.config.RosenpassEnabled, s.config.RosenpassPermissive)
 
 	if msg.GetFullPeerStatus {
 		s.runProbes()
@@ -703,10 +956,18 @@ func toProtoFullStatus(fullStatus peer.FullStatus) *proto.FullStatus {
 	pbFullStatus.LocalPeerState.IP = fullStatus.LocalPeerState.IP
 	pbFullStatus.LocalPeerState.PubKey = fullStatus.LocalPeerState.PubKey
 	pbFullStatus.LocalPeerState.KernelInterface = fullStatus.LocalPeerState.KernelInterface
+	pbFullStatus.LocalPeerState.UserspaceReason = fullStatus.LocalPeerState.UserspaceReason
+	pbFullStatus.LocalPeerState.NatType = string(fullStatus.NATType)
 	pbFullStatus.LocalPeerState.Fqdn = fullStatus.LocalPeerState.FQDN
 	pbFullStatus.LocalPeerState.RosenpassPermissive = fullStatus.RosenpassState.Permissive
 	pbFullStatus.LocalPeerState.RosenpassEnabled = fullStatus.RosenpassState.Enabled
 	pbFullStatus.LocalPeerState.Routes = maps.Keys(fullStatus.LocalPeerState.Routes)
+	if loginExpiration := fullStatus.LoginExpiration; !loginExpiration.ExpiresAt.IsZero() {
+		pbFullStatus.LocalPeerState.LoginExpiresAt = timestamppb.New(loginExpiration.ExpiresAt)
+		if loginExpiration.Warning != 0 {
+			pbFullStatus.LocalPeerState.LoginExpirationWarning = durationpb.New(loginExpiration.Warning)
+		}
+	}
 
 	for _, peerState := range fullStatus.Peers {
 		pbPeerState := &proto.PeerState{
@@ -720,6 +981,7 @@ func toProtoFullStatus(fullStatus peer.FullStatus) *proto.FullStatus {
 			RemoteIceCandidateType:     peerState.RemoteIceCandidateType,
 			LocalIceCandidateEndpoint:  peerState.LocalIceCandidateEndpoint,
 			RemoteIceCandidateEndpoint: peerState.RemoteIceCandidateEndpoint,
+			IceCandidateNetwork:        peerState.IceCandidateNetwork,
 			Fqdn:                       peerState.FQDN,
 			LastWireguardHandshake:     timestamppb.New(peerState.LastWireguardHandshake),
 			BytesRx:                    peerState.BytesRx,
@@ -727,6 +989,7 @@ func toProtoFullStatus(fullStatus peer.FullStatus) *proto.FullStatus {
 			RosenpassEnabled:           peerState.RosenpassEnabled,
 			Routes:                     maps.Keys(peerState.GetRoutes()),
 			Latency:                    durationpb.New(peerState.Latency),
+			WireguardEndpoint:          peerState.WireguardEndpoint,
 		}
 		pbFullStatus.Peers = append(pbFullStatus.Peers, pbPeerState)
 	}
@@ -756,6 +1019,28 @@ func toProtoFullStatus(fullStatus peer.FullStatus) *proto.FullStatus {
 		pbFullStatus.DnsServers = append(pbFullStatus.DnsServers, pbDnsState)
 	}
 
+	for _, failedRoute := range fullStatus.FailedRoutes {
+		pbFullStatus.FailedRoutes = append(pbFullStatus.FailedRoutes, &proto.FailedRoute{
+			ID:        failedRoute.NetID,
+			Network:   failedRoute.Network,
+			Error:     failedRoute.Error,
+			Attempts:  int32(failedRoute.Attempts),
+			NextRetry: timestamppb.New(failedRoute.NextRetry),
+		})
+	}
+
+	if cache := fullStatus.DNSCache; cache != nil {
+		pbFullStatus.DnsCache = &proto.DNSCacheState{
+			Entries:   int32(cache.Entries),
+			Capacity:  int32(cache.Capacity),
+			Hits:      cache.Hits,
+			Misses:    cache.Misses,
+			Evictions: cache.Evictions,
+			MinTTL:    durationpb.New(cache.MinTTL),
+			MaxTTL:    durationpb.New(cache.MaxTTL),
+		}
+	}
+
 	return &pbFullStatus
 }
 
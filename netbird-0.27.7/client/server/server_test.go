@@ -56,7 +56,7 @@ func TestConnectWithRetryRuns(t *testing.T) {
 	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(30*time.Second))
 	defer cancel()
 	// create new server
-	s := New(ctx, t.TempDir()+"/config.json", "debug")
+	s := New(ctx, t.TempDir()+"/config.json", "", "", "debug")
 	s.latestConfigInput.ManagementURL = "http://" + mgmtAddr
 	config, err := internal.UpdateOrCreateConfig(s.latestConfigInput)
 	if err != nil {
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/netbirdio/netbird/client/internal/speedtest"
+	"github.com/netbirdio/netbird/client/proto"
+)
+
+// Speedtest measures the throughput to a peer over the WireGuard tunnel, optionally reporting it to the management
+// service
+func (s *Server) Speedtest(ctx context.Context, req *proto.SpeedtestRequest) (*proto.SpeedtestResponse, error) {
+	opts := speedtest.Options{
+		Duration:  req.GetDuration().AsDuration(),
+		Reverse:   req.GetReverse(),
+		UDP:       req.GetUdp(),
+		Bandwidth: req.GetBandwidth(),
+	}
+	if opts.Duration > speedtest.MaxDuration {
+		return nil, fmt.Errorf("duration must not exceed %s", speedtest.MaxDuration)
+	}
+
+	// the test takes seconds, the lock isn't held for it
+	s.mutex.Lock()
+	if s.connectClient == nil || s.connectClient.Engine() == nil {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("not connected")
+	}
+	engine := s.connectClient.Engine()
+	s.mutex.Unlock()
+
+	result, err := engine.RunSpeedtest(ctx, req.GetPeer(), opts, req.GetReport())
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.SpeedtestResponse{
+		Ip:          result.IP,
+		Fqdn:        result.FQDN,
+		Protocol:    result.Protocol,
+		Direction:   result.Direction,
+		Bytes:       result.Bytes,
+		Duration:    durationpb.New(result.Duration),
+		PacketsSent: result.PacketsSent,
+		PacketsLost: result.PacketsLost,
+		Path:        result.Path,
+		ReportError: result.ReportError,
+	}, nil
+}
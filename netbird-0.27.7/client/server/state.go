@@ -0,0 +1,22 @@
+package server
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	gstatus "google.golang.org/grpc/status"
+
+	"github.com/netbirdio/netbird/client/internal/persist"
+	"github.com/netbirdio/netbird/client/proto"
+)
+
+// FlushState writes the state files batched in memory or on tmpfs to their path, e.g. before a shutdown
+func (s *Server) FlushState(context.Context, *proto.FlushStateRequest) (*proto.FlushStateResponse, error) {
+	if err := persist.Flush(); err != nil {
+		log.Errorf("failed to flush the state files: %v", err)
+		return nil, gstatus.Errorf(codes.Internal, "flush the state files: %v", err)
+	}
+	log.Debugf("flushed the state files")
+	return &proto.FlushStateResponse{}, nil
+}
@@ -0,0 +1,107 @@
+package ssh
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultRecordingRetention is the time the session recordings are kept for when no retention is set
+const DefaultRecordingRetention = 30 * 24 * time.Hour
+
+// recordingFileSuffix is the suffix of the recording files, the files without it are never pruned
+const recordingFileSuffix = ".typescript"
+
+const recordingTimeFormat = "2006-01-02 15:04:05-07:00"
+
+// recording writes the terminal output of a session to a typescript file in the format of script(1),
+// replayed with cat or scriptreplay
+type recording struct {
+	file *os.File
+	err  error
+}
+
+// newRecording creates the typescript file of a session in the directory, named after the start of the session, the
+// local user and the remote IP
+func newRecording(dir, user string, remote net.Addr, term string, start time.Time) (*recording, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("create recording directory: %w", err)
+	}
+
+	host := remote.String()
+	if addr, ok := remote.(*net.TCPAddr); ok {
+		host = addr.IP.String()
+	}
+	name := fmt.Sprintf("%s-%s-%s%s", start.UTC().Format("20060102T150405Z"), user, host, recordingFileSuffix)
+	name = strings.ReplaceAll(name, string(filepath.Separator), "_")
+
+	file, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("create recording file: %w", err)
+	}
+
+	_, err = fmt.Fprintf(file, "Script started on %s [USER=%q REMOTE=%q TERM=%q]\n",
+		start.Format(recordingTimeFormat), user, remote.String(), term)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("write recording header: %w", err)
+	}
+
+	return &recording{file: file}, nil
+}
+
+// Write records the output of the session. It never fails so that a full disk doesn't break the session, the
+// recording stops at the first write error
+func (r *recording) Write(p []byte) (int, error) {
+	if r.err != nil {
+		return len(p), nil
+	}
+
+	if _, r.err = r.file.Write(p); r.err != nil {
+		log.Warnf("stopped recording SSH session to %s: %v", r.file.Name(), r.err)
+	}
+	return len(p), nil
+}
+
+// Close writes the footer of the typescript and closes its file
+func (r *recording) Close() error {
+	if r.err == nil {
+		_, _ = fmt.Fprintf(r.file, "\nScript done on %s\n", time.Now().Format(recordingTimeFormat))
+	}
+	return r.file.Close()
+}
+
+// pruneRecordings removes the recordings of the directory older than the retention
+func pruneRecordings(dir string, retention time.Duration) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("failed to read SSH recording directory %s: %v", dir, err)
+		}
+		return
+	}
+
+	expiry := time.Now().Add(-retention)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), recordingFileSuffix) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(expiry) {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		if err := os.Remove(path); err != nil {
+			log.Warnf("failed to remove expired SSH recording %s: %v", path, err)
+			continue
+		}
+		log.Debugf("removed expired SSH recording %s", path)
+	}
+}
@@ -0,0 +1,63 @@
+package ssh
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecording(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "recordings")
+	remote := &net.TCPAddr{IP: net.ParseIP("100.64.0.5"), Port: 40000}
+	start := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	rec, err := newRecording(dir, "root", remote, "xterm", start)
+	require.NoError(t, err)
+	_, err = rec.Write([]byte("root@router:~# uptime\r\n"))
+	require.NoError(t, err)
+	require.NoError(t, rec.Close())
+
+	path := filepath.Join(dir, "20240501T123000Z-root-100.64.0.5"+recordingFileSuffix)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	lines := strings.Split(string(content), "\n")
+	assert.Equal(t, `Script started on 2024-05-01 12:30:00+00:00 [USER="root" REMOTE="100.64.0.5:40000" TERM="xterm"]`, lines[0])
+	assert.Equal(t, "root@router:~# uptime\r", lines[1])
+	assert.True(t, strings.HasPrefix(lines[3], "Script done on "), "the typescript should end with the footer")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "recordings should only be readable by root")
+}
+
+func TestPruneRecordings(t *testing.T) {
+	dir := t.TempDir()
+	expired := time.Now().Add(-48 * time.Hour)
+
+	files := map[string]time.Time{
+		"old" + recordingFileSuffix:    expired,
+		"recent" + recordingFileSuffix: time.Now(),
+		"notes.txt":                    expired,
+	}
+	for name, modTime := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, nil, 0600))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	pruneRecordings(dir, 24*time.Hour)
+
+	assert.NoFileExists(t, filepath.Join(dir, "old"+recordingFileSuffix))
+	assert.FileExists(t, filepath.Join(dir, "recent"+recordingFileSuffix))
+	assert.FileExists(t, filepath.Join(dir, "notes.txt"), "files other than recordings should be kept")
+
+	// a missing directory is not an error, it is created with the first recording
+	pruneRecordings(filepath.Join(dir, "missing"), time.Hour)
+}
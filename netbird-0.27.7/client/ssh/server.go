@@ -26,9 +26,35 @@ const TerminalTimeout = 10 * time.Second
 // TerminalBackoffDelay is the delay between terminal session readiness checks
 const TerminalBackoffDelay = 500 * time.Millisecond
 
+type contextKey string
+
+// peerKeyContextKey is the key of the WireGuard public key of the authenticated peer in the context of a connection
+const peerKeyContextKey contextKey = "netbird-peer-key"
+
 // DefaultSSHServer is a function that creates DefaultServer
-func DefaultSSHServer(hostKeyPEM []byte, addr string) (Server, error) {
-	return newDefaultServer(hostKeyPEM, addr)
+func DefaultSSHServer(hostKeyPEM []byte, addr string, config ServerConfig) (Server, error) {
+	return newDefaultServer(hostKeyPEM, addr, config)
+}
+
+// ServerConfig holds the optional settings of the SSH server
+type ServerConfig struct {
+	// RecordingDir is the directory the terminal output of the sessions is recorded to, empty disables the recording
+	RecordingDir string
+	// RecordingRetention is the time the recordings are kept for, zero means DefaultRecordingRetention
+	RecordingRetention time.Duration
+	// OnSessionClosed is called when a session ends, e.g. to report it to the management service
+	OnSessionClosed func(SessionInfo)
+}
+
+// SessionInfo describes an SSH session that ended
+type SessionInfo struct {
+	// PeerKey is the WireGuard public key of the remote peer the session was opened from
+	PeerKey string
+	// User is the local user the remote peer logged in as
+	User       string
+	RemoteAddr net.Addr
+	StartedAt  time.Time
+	Duration   time.Duration
 }
 
 // Server is an interface of SSH server
@@ -51,16 +77,20 @@ type DefaultServer struct {
 	mu             sync.Mutex
 	hostKeyPEM     []byte
 	sessions       []ssh.Session
+	config         ServerConfig
 }
 
 // newDefaultServer creates new server with provided host key
-func newDefaultServer(hostKeyPEM []byte, addr string) (*DefaultServer, error) {
+func newDefaultServer(hostKeyPEM []byte, addr string, config ServerConfig) (*DefaultServer, error) {
 	ln, err := net.Listen("tcp", addr)
 	if err != nil {
 		return nil, err
 	}
+	if config.RecordingRetention == 0 {
+		config.RecordingRetention = DefaultRecordingRetention
+	}
 	allowedKeys := make(map[string]ssh.PublicKey)
-	return &DefaultServer{listener: ln, mu: sync.Mutex{}, hostKeyPEM: hostKeyPEM, authorizedKeys: allowedKeys, sessions: make([]ssh.Session, 0)}, nil
+	return &DefaultServer{listener: ln, mu: sync.Mutex{}, hostKeyPEM: hostKeyPEM, authorizedKeys: allowedKeys, sessions: make([]ssh.Session, 0), config: config}, nil
 }
 
 // RemoveAuthorizedKey removes SSH key of a given peer from the authorized keys
@@ -107,8 +137,11 @@ func (srv *DefaultServer) publicKeyHandler(ctx ssh.Context, key ssh.PublicKey) b
 	srv.mu.Lock()
 	defer srv.mu.Unlock()
 
-	for _, allowed := range srv.authorizedKeys {
+	for peer, allowed := range srv.authorizedKeys {
 		if ssh.KeysEqual(allowed, key) {
+			if ctx != nil {
+				ctx.SetValue(peerKeyContextKey, peer)
+			}
 			return true
 		}
 	}
@@ -158,6 +191,8 @@ func (srv *DefaultServer) sessionHandler(session ssh.Session) {
 		return
 	}
 
+	defer srv.sessionClosed(session, localUser.Username, time.Now())
+
 	ptyReq, winCh, isPty := session.Pty()
 	if isPty {
 		loginCmd, loginArgs, err := getLoginCmd(localUser.Username, session.RemoteAddr())
@@ -194,7 +229,17 @@ func (srv *DefaultServer) sessionHandler(session ssh.Session) {
 			}
 		}()
 
-		srv.stdInOut(file, session)
+		var output io.Writer = session
+		if rec := srv.startRecording(localUser.Username, session, ptyReq.Term); rec != nil {
+			defer func() {
+				if err := rec.Close(); err != nil {
+					log.Warnf("failed closing SSH session recording: %v", err)
+				}
+			}()
+			output = io.MultiWriter(session, rec)
+		}
+
+		srv.stdInOut(file, session, output)
 
 		err = cmd.Wait()
 		if err != nil {
@@ -213,7 +258,41 @@ func (srv *DefaultServer) sessionHandler(session ssh.Session) {
 	log.Debugf("SSH session ended")
 }
 
-func (srv *DefaultServer) stdInOut(file *os.File, session ssh.Session) {
+// startRecording starts the recording of the output of the session when a recording directory is set, it returns nil
+// when the session isn't recorded
+func (srv *DefaultServer) startRecording(user string, session ssh.Session, term string) *recording {
+	if srv.config.RecordingDir == "" {
+		return nil
+	}
+
+	pruneRecordings(srv.config.RecordingDir, srv.config.RecordingRetention)
+
+	rec, err := newRecording(srv.config.RecordingDir, user, session.RemoteAddr(), term, time.Now())
+	if err != nil {
+		log.Warnf("failed recording SSH session from %s: %v", session.RemoteAddr(), err)
+		return nil
+	}
+	log.Infof("recording SSH session from %s to %s", session.RemoteAddr(), rec.file.Name())
+	return rec
+}
+
+// sessionClosed notifies the end of the session to the OnSessionClosed callback
+func (srv *DefaultServer) sessionClosed(session ssh.Session, user string, start time.Time) {
+	if srv.config.OnSessionClosed == nil {
+		return
+	}
+
+	peerKey, _ := session.Context().Value(peerKeyContextKey).(string)
+	srv.config.OnSessionClosed(SessionInfo{
+		PeerKey:    peerKey,
+		User:       user,
+		RemoteAddr: session.RemoteAddr(),
+		StartedAt:  start,
+		Duration:   time.Since(start),
+	})
+}
+
+func (srv *DefaultServer) stdInOut(file *os.File, session ssh.Session, output io.Writer) {
 	go func() {
 		// stdin
 		_, err := io.Copy(file, session)
@@ -233,7 +312,7 @@ func (srv *DefaultServer) stdInOut(file *os.File, session ssh.Session) {
 			return
 		default:
 			// stdout
-			writtenBytes, err := io.Copy(session, file)
+			writtenBytes, err := io.Copy(output, file)
 			if err != nil && writtenBytes != 0 {
 				_ = session.Exit(0)
 				return
@@ -247,6 +326,10 @@ func (srv *DefaultServer) stdInOut(file *os.File, session ssh.Session) {
 func (srv *DefaultServer) Start() error {
 	log.Infof("starting SSH server on addr: %s", srv.listener.Addr().String())
 
+	if srv.config.RecordingDir != "" {
+		pruneRecordings(srv.config.RecordingDir, srv.config.RecordingRetention)
+	}
+
 	publicKeyOption := ssh.PublicKeyAuth(srv.publicKeyHandler)
 	hostKeyPEM := ssh.HostKeyPEM(srv.hostKeyPEM)
 	err := ssh.Serve(srv.listener, srv.sessionHandler, publicKeyOption, hostKeyPEM)
@@ -13,7 +13,7 @@ func TestServer_AddAuthorizedKey(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	server, err := newDefaultServer(key, "localhost:")
+	server, err := newDefaultServer(key, "localhost:", ServerConfig{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -53,7 +53,7 @@ func TestServer_RemoveAuthorizedKey(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	server, err := newDefaultServer(key, "localhost:")
+	server, err := newDefaultServer(key, "localhost:", ServerConfig{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -83,7 +83,7 @@ func TestServer_PubKeyHandler(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	server, err := newDefaultServer(key, "localhost:")
+	server, err := newDefaultServer(key, "localhost:", ServerConfig{})
 	if err != nil {
 		t.Fatal(err)
 	}
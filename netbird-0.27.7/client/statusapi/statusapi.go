@@ -0,0 +1,330 @@
+// Package statusapi serves the status of the client as versioned JSON on a local HTTP endpoint for the LuCI app and
+// the monitoring scripts. Fields are only added to a version, changes of their meaning bump the version
+package statusapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/client/proto"
+)
+
+// Version is the version of the status document, served on /v1/status
+const Version = 1
+
+// StatusPath is the path of the status document
+const StatusPath = "/v1/status"
+
+const (
+	readHeaderTimeout = 5 * time.Second
+	shutdownTimeout   = 5 * time.Second
+)
+
+// Status is the status document
+type Status struct {
+	Version       int               `json:"version"`
+	DaemonVersion string            `json:"daemonVersion"`
+	State         string            `json:"state"`
+	Management    ServerState       `json:"management"`
+	Signal        ServerState       `json:"signal"`
+	LocalPeer     LocalPeer         `json:"localPeer"`
+	Peers         []Peer            `json:"peers"`
+	Routes        []Route           `json:"routes"`
+	DNS           DNS               `json:"dns"`
+	FailedRoutes  []FailedRoute     `json:"failedRoutes"`
+	Relays        []RelayState      `json:"relays"`
+	GeneratedAt   time.Time         `json:"generatedAt"`
+	Errors        map[string]string `json:"errors,omitempty"`
+}
+
+// ServerState is the connection state to the management or the signal service
+type ServerState struct {
+	URL       string `json:"url"`
+	Connected bool   `json:"connected"`
+	Error     string `json:"error,omitempty"`
+}
+
+// LocalPeer is the state of the local peer
+type LocalPeer struct {
+	IP              string   `json:"ip"`
+	FQDN            string   `json:"fqdn"`
+	PubKey          string   `json:"pubKey"`
+	KernelInterface bool     `json:"kernelInterface"`
+	Routes          []string `json:"routes"`
+}
+
+// Peer is the state of a remote peer
+type Peer struct {
+	IP             string `json:"ip"`
+	FQDN           string `json:"fqdn"`
+	PubKey         string `json:"pubKey"`
+	Status         string `json:"status"`
+	ConnectionType string `json:"connectionType,omitempty"`
+	Endpoint       string `json:"endpoint,omitempty"`
+	// HandshakeAge is the number of seconds since the latest WireGuard handshake, nil without a handshake
+	HandshakeAge *int64 `json:"handshakeAge"`
+	// LatencyMs is the latency to the peer in milliseconds, nil when unknown
+	LatencyMs *int64   `json:"latencyMs"`
+	RxBytes   int64    `json:"rxBytes"`
+	TxBytes   int64    `json:"txBytes"`
+	Rosenpass bool     `json:"rosenpass"`
+	Routes    []string `json:"routes"`
+}
+
+// Route is a network route of the peer and its selection
+type Route struct {
+	ID       string   `json:"id"`
+	Network  string   `json:"network"`
+	Domains  []string `json:"domains"`
+	Selected bool     `json:"selected"`
+}
+
+// FailedRoute is a route whose installation failed
+type FailedRoute struct {
+	ID       string `json:"id"`
+	Network  string `json:"network"`
+	Error    string `json:"error"`
+	Attempts int32  `json:"attempts"`
+}
+
+// RelayState is the availability of a relay
+type RelayState struct {
+	URI       string `json:"uri"`
+	Available bool   `json:"available"`
+	Error     string `json:"error,omitempty"`
+}
+
+// DNS is the state of the nameserver groups and of the response cache
+type DNS struct {
+	Servers []NameServerGroup `json:"servers"`
+	Cache   *DNSCache         `json:"cache,omitempty"`
+}
+
+// NameServerGroup is the state of a nameserver group
+type NameServerGroup struct {
+	Servers []string `json:"servers"`
+	Domains []string `json:"domains"`
+	Enabled bool     `json:"enabled"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// DNSCache holds the counters of the DNS response cache
+type DNSCache struct {
+	Entries   int32  `json:"entries"`
+	Capacity  int32  `json:"capacity"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+}
+
+// NewHandler returns the HTTP handler of the status document of the daemon
+func NewHandler(daemon proto.DaemonServiceServer) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(StatusPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		status, err := GetStatus(r.Context(), daemon)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			log.Debugf("failed to write the status document: %v", err)
+		}
+	})
+	return mux
+}
+
+// GetStatus builds the status document from the status and the routes of the daemon
+func GetStatus(ctx context.Context, daemon proto.DaemonServiceServer) (*Status, error) {
+	resp, err := daemon.Status(ctx, &proto.StatusRequest{GetFullPeerStatus: true})
+	if err != nil {
+		return nil, fmt.Errorf("get daemon status: %w", err)
+	}
+
+	now := time.Now()
+	full := resp.GetFullStatus()
+	status := &Status{
+		Version:       Version,
+		DaemonVersion: resp.GetDaemonVersion(),
+		State:         resp.GetStatus(),
+		Management: ServerState{
+			URL:       full.GetManagementState().GetURL(),
+			Connected: full.GetManagementState().GetConnected(),
+			Error:     full.GetManagementState().GetError(),
+		},
+		Signal: ServerState{
+			URL:       full.GetSignalState().GetURL(),
+			Connected: full.GetSignalState().GetConnected(),
+			Error:     full.GetSignalState().GetError(),
+		},
+		LocalPeer: LocalPeer{
+			IP:              full.GetLocalPeerState().GetIP(),
+			FQDN:            full.GetLocalPeerState().GetFqdn(),
+			PubKey:          full.GetLocalPeerState().GetPubKey(),
+			KernelInterface: full.GetLocalPeerState().GetKernelInterface(),
+			Routes:          nonNil(full.GetLocalPeerState().GetRoutes()),
+		},
+		Peers:        make([]Peer, 0, len(full.GetPeers())),
+		Routes:       []Route{},
+		DNS:          DNS{Servers: make([]NameServerGroup, 0, len(full.GetDnsServers()))},
+		FailedRoutes: make([]FailedRoute, 0, len(full.GetFailedRoutes())),
+		Relays:       make([]RelayState, 0, len(full.GetRelays())),
+		GeneratedAt:  now.UTC(),
+	}
+
+	for _, p := range full.GetPeers() {
+		status.Peers = append(status.Peers, toPeer(p, now))
+	}
+
+	for _, ns := range full.GetDnsServers() {
+		status.DNS.Servers = append(status.DNS.Servers, NameServerGroup{
+			Servers: nonNil(ns.GetServers()),
+			Domains: nonNil(ns.GetDomains()),
+			Enabled: ns.GetEnabled(),
+			Error:   ns.GetError(),
+		})
+	}
+	if cache := full.GetDnsCache(); cache != nil {
+		status.DNS.Cache = &DNSCache{
+			Entries:   cache.GetEntries(),
+			Capacity:  cache.GetCapacity(),
+			Hits:      cache.GetHits(),
+			Misses:    cache.GetMisses(),
+			Evictions: cache.GetEvictions(),
+		}
+	}
+
+	for _, r := range full.GetFailedRoutes() {
+		status.FailedRoutes = append(status.FailedRoutes, FailedRoute{
+			ID:       r.GetID(),
+			Network:  r.GetNetwork(),
+			Error:    r.GetError(),
+			Attempts: r.GetAttempts(),
+		})
+	}
+
+	for _, r := range full.GetRelays() {
+		status.Relays = append(status.Relays, RelayState{
+			URI:       r.GetURI(),
+			Available: r.GetAvailable(),
+			Error:     r.GetError(),
+		})
+	}
+
+	// the routes are unavailable while the client is down, the document is still served
+	routes, err := daemon.ListRoutes(ctx, &proto.ListRoutesRequest{})
+	if err != nil {
+		status.Errors = map[string]string{"routes": err.Error()}
+	}
+	for _, r := range routes.GetRoutes() {
+		status.Routes = append(status.Routes, Route{
+			ID:       r.GetID(),
+			Network:  r.GetNetwork(),
+			Domains:  nonNil(r.GetDomains()),
+			Selected: r.GetSelected(),
+		})
+	}
+
+	return status, nil
+}
+
+func toPeer(p *proto.PeerState, now time.Time) Peer {
+	peer := Peer{
+		IP:        p.GetIP(),
+		FQDN:      p.GetFqdn(),
+		PubKey:    p.GetPubKey(),
+		Status:    p.GetConnStatus(),
+		Endpoint:  p.GetWireguardEndpoint(),
+		RxBytes:   p.GetBytesRx(),
+		TxBytes:   p.GetBytesTx(),
+		Rosenpass: p.GetRosenpassEnabled(),
+		Routes:    nonNil(p.GetRoutes()),
+	}
+
+	switch {
+	case p.GetRelayed():
+		peer.ConnectionType = "Relayed"
+	case p.GetDirect():
+		peer.ConnectionType = "P2P"
+	}
+
+	if handshake := p.GetLastWireguardHandshake(); handshake != nil && handshake.AsTime().Unix() > 0 {
+		age := int64(now.Sub(handshake.AsTime()).Seconds())
+		peer.HandshakeAge = &age
+	}
+	if latency := p.GetLatency(); latency != nil && latency.AsDuration() > 0 {
+		ms := latency.AsDuration().Milliseconds()
+		peer.LatencyMs = &ms
+	}
+
+	return peer
+}
+
+// nonNil keeps empty lists as [] in the document
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// Serve serves the status document on addr until ctx is done. The address is [unix|tcp]://[path|host:port]
+func Serve(ctx context.Context, addr string, daemon proto.DaemonServiceServer) error {
+	network, address, ok := strings.Cut(addr, "://")
+	if !ok || (network != "unix" && network != "tcp") {
+		return fmt.Errorf("unsupported status API address %s, expected [unix|tcp]://[path|host:port]", addr)
+	}
+
+	if network == "unix" {
+		if stat, err := os.Stat(address); err == nil && !stat.IsDir() {
+			if err := os.Remove(address); err != nil {
+				log.Debugf("remove status API socket: %v", err)
+			}
+		}
+	}
+
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", addr, err)
+	}
+	if network == "unix" {
+		if err := os.Chmod(address, 0666); err != nil {
+			_ = listener.Close()
+			return fmt.Errorf("set status API socket permissions: %w", err)
+		}
+	}
+
+	server := &http.Server{
+		Handler:           NewHandler(daemon),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.Infof("serving the status API on %s", addr)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package statusapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/netbirdio/netbird/client/proto"
+)
+
+type mockDaemon struct {
+	proto.UnimplementedDaemonServiceServer
+	routesErr error
+}
+
+func (m *mockDaemon) Status(context.Context, *proto.StatusRequest) (*proto.StatusResponse, error) {
+	return &proto.StatusResponse{
+		Status:        "Connected",
+		DaemonVersion: "0.27.7",
+		FullStatus: &proto.FullStatus{
+			ManagementState: &proto.ManagementState{URL: "https://api.netbird.io:443", Connected: true},
+			LocalPeerState:  &proto.LocalPeerState{IP: "100.64.0.1/16", Fqdn: "router.netbird.cloud"},
+			Peers: []*proto.PeerState{
+				{
+					IP:                     "100.64.0.2",
+					ConnStatus:             "Connected",
+					Direct:                 true,
+					LastWireguardHandshake: timestamppb.New(time.Now().Add(-30 * time.Second)),
+					Latency:                durationpb.New(12 * time.Millisecond),
+				},
+				{IP: "100.64.0.3", ConnStatus: "Connecting"},
+			},
+			DnsServers: []*proto.NSGroupState{{Servers: []string{"1.1.1.1:53"}, Enabled: true}},
+		},
+	}, nil
+}
+
+func (m *mockDaemon) ListRoutes(context.Context, *proto.ListRoutesRequest) (*proto.ListRoutesResponse, error) {
+	if m.routesErr != nil {
+		return nil, m.routesErr
+	}
+	return &proto.ListRoutesResponse{
+		Routes: []*proto.Route{{ID: "lan", Network: "192.168.1.0/24", Selected: true}},
+	}, nil
+}
+
+func TestStatusHandler(t *testing.T) {
+	handler := NewHandler(&mockDaemon{})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, StatusPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var status Status
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	require.Equal(t, Version, status.Version)
+	require.Equal(t, "Connected", status.State)
+	require.True(t, status.Management.Connected)
+	require.Equal(t, "router.netbird.cloud", status.LocalPeer.FQDN)
+
+	require.Len(t, status.Peers, 2)
+	require.Equal(t, "P2P", status.Peers[0].ConnectionType)
+	require.NotNil(t, status.Peers[0].HandshakeAge)
+	require.InDelta(t, 30, *status.Peers[0].HandshakeAge, 2)
+	require.Equal(t, int64(12), *status.Peers[0].LatencyMs)
+	require.Nil(t, status.Peers[1].HandshakeAge, "peers without a handshake should have no handshake age")
+
+	require.Equal(t, []Route{{ID: "lan", Network: "192.168.1.0/24", Domains: []string{}, Selected: true}}, status.Routes)
+	require.Len(t, status.DNS.Servers, 1)
+	require.Empty(t, status.Errors)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, StatusPath, nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestStatusWithoutRoutes(t *testing.T) {
+	status, err := GetStatus(context.Background(), &mockDaemon{routesErr: errors.New("not connected")})
+	require.NoError(t, err)
+	require.Empty(t, status.Routes)
+	require.Equal(t, "not connected", status.Errors["routes"])
+
+	// empty lists are kept as [] for the consumers of the document
+	doc, err := json.Marshal(status)
+	require.NoError(t, err)
+	require.Contains(t, string(doc), `"routes":[]`)
+	require.Contains(t, string(doc), `"failedRoutes":[]`)
+}
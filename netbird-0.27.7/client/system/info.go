@@ -20,6 +20,9 @@ const OsVersionCtxKey = "OsVersion"
 // OsNameCtxKey context key for operating system name
 const OsNameCtxKey = "OsName"
 
+// SkipEnvironmentCtxKey context key to skip the detection of the cloud and the platform, e.g. on low memory devices
+const SkipEnvironmentCtxKey = "skipEnvironment"
+
 type NetworkAddress struct {
 	NetIP netip.Prefix
 	Mac   string
@@ -48,6 +51,8 @@ type Info struct {
 	SystemProductName  string
 	SystemManufacturer string
 	Environment        Environment
+	// Inventory is the software inventory selected by the InventoryConfig of the context, nil without one
+	Inventory *Inventory
 }
 
 // extractUserAgent extracts Netbird's agent (client) name and version from the outgoing context
@@ -75,6 +80,12 @@ func extractDeviceName(ctx context.Context, defaultName string) string {
 	return v
 }
 
+// skipEnvironment returns true if the detection of the environment is disabled in the context
+func skipEnvironment(ctx context.Context) bool {
+	skip, _ := ctx.Value(SkipEnvironmentCtxKey).(bool)
+	return skip
+}
+
 // GetDesktopUIUserAgent returns the Desktop ui user agent
 func GetDesktopUIUserAgent() string {
 	return "netbird-desktop-ui/" + version.NetbirdVersion()
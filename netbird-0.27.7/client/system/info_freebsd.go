@@ -26,9 +26,12 @@ func GetInfo(ctx context.Context) *Info {
 	osStr = strings.Replace(osStr, "\r\n", "", -1)
 	osInfo := strings.Split(osStr, " ")
 
-	env := Environment{
-		Cloud:    detect_cloud.Detect(ctx),
-		Platform: detect_platform.Detect(ctx),
+	var env Environment
+	if !skipEnvironment(ctx) {
+		env = Environment{
+			Cloud:    detect_cloud.Detect(ctx),
+			Platform: detect_platform.Detect(ctx),
+		}
 	}
 
 	gio := &Info{Kernel: osInfo[0], Platform: runtime.GOARCH, OS: osInfo[2], GoOS: runtime.GOOS, CPUs: runtime.NumCPU(), KernelVersion: osInfo[1], Environment: env}
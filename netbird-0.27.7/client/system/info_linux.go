@@ -63,9 +63,12 @@ func GetInfo(ctx context.Context) *Info {
 
 	serialNum, prodName, manufacturer := sysInfo()
 
-	env := Environment{
-		Cloud:    detect_cloud.Detect(ctx),
-		Platform: detect_platform.Detect(ctx),
+	var env Environment
+	if !skipEnvironment(ctx) {
+		env = Environment{
+			Cloud:    detect_cloud.Detect(ctx),
+			Platform: detect_platform.Detect(ctx),
+		}
 	}
 
 	gio := &Info{
@@ -84,6 +87,7 @@ func GetInfo(ctx context.Context) *Info {
 		SystemProductName:  prodName,
 		SystemManufacturer: manufacturer,
 		Environment:        env,
+		Inventory:          getInventory(ctx),
 	}
 
 	return gio
@@ -57,9 +57,12 @@ func GetInfo(ctx context.Context) *Info {
 		log.Warnf("failed to get system manufacturer: %s", err)
 	}
 
-	env := Environment{
-		Cloud:    detect_cloud.Detect(ctx),
-		Platform: detect_platform.Detect(ctx),
+	var env Environment
+	if !skipEnvironment(ctx) {
+		env = Environment{
+			Cloud:    detect_cloud.Detect(ctx),
+			Platform: detect_platform.Detect(ctx),
+		}
 	}
 
 	gio := &Info{
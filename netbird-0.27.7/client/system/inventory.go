@@ -0,0 +1,196 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// InventoryCtxKey context key for the InventoryConfig of the software inventory reported with the system information
+const InventoryCtxKey = "inventory"
+
+// The fields of the software inventory
+const (
+	// InventoryFieldPatchLevel is the release and the build revision of the OS, e.g. the revision of an OpenWrt image
+	InventoryFieldPatchLevel = "patch_level"
+	// InventoryFieldPackages is the package manager and the number of packages installed with it
+	InventoryFieldPackages = "packages"
+)
+
+var inventoryFields = []string{InventoryFieldPatchLevel, InventoryFieldPackages}
+
+// InventoryAllPackages reports the versions of all the installed packages
+const InventoryAllPackages = "*"
+
+// InventoryConfig selects the fields of the software inventory and the packages whose versions are reported
+type InventoryConfig struct {
+	Fields   []string
+	Packages []string
+}
+
+// ParseInventoryFields validates the fields of a software inventory: patch_level or packages
+func ParseInventoryFields(fields []string) error {
+	for i, field := range fields {
+		if !slices.Contains(inventoryFields, field) {
+			return fmt.Errorf("unknown inventory field %q, expected one of %v", field, inventoryFields)
+		}
+		if slices.Contains(fields[:i], field) {
+			return fmt.Errorf("inventory field %q is listed twice", field)
+		}
+	}
+	return nil
+}
+
+// Enabled returns whether the config selects any part of the inventory
+func (c InventoryConfig) Enabled() bool {
+	return len(c.Fields) > 0 || len(c.Packages) > 0
+}
+
+func (c InventoryConfig) hasField(field string) bool {
+	return slices.Contains(c.Fields, field)
+}
+
+// reportsPackage returns whether the version of the package is reported
+func (c InventoryConfig) reportsPackage(name string) bool {
+	for _, p := range c.Packages {
+		if p == name || p == InventoryAllPackages {
+			return true
+		}
+	}
+	return false
+}
+
+// Package is an installed package with its version
+type Package struct {
+	Name    string
+	Version string
+}
+
+// Inventory is the summary of the software of the system the management service collects for the posture checks and
+// the vulnerability reports
+type Inventory struct {
+	PatchLevel     string
+	PackageManager string
+	PackagesCount  int
+	// Packages are the installed packages of the config, sorted by name
+	Packages []Package
+}
+
+// getInventory collects the software inventory selected by the InventoryConfig of the context, nil without a config
+func getInventory(ctx context.Context) *Inventory {
+	config, ok := ctx.Value(InventoryCtxKey).(InventoryConfig)
+	if !ok || !config.Enabled() {
+		return nil
+	}
+	return collectInventory(config)
+}
+
+// newInventory filters the installed packages with the config
+func newInventory(config InventoryConfig, patchLevel, packageManager string, installed []Package) *Inventory {
+	inventory := &Inventory{}
+	if config.hasField(InventoryFieldPatchLevel) {
+		inventory.PatchLevel = patchLevel
+	}
+	if config.hasField(InventoryFieldPackages) {
+		inventory.PackageManager = packageManager
+		inventory.PackagesCount = len(installed)
+	}
+	for _, p := range installed {
+		if config.reportsPackage(p.Name) {
+			inventory.Packages = append(inventory.Packages, p)
+		}
+	}
+	sort.Slice(inventory.Packages, func(i, j int) bool {
+		return inventory.Packages[i].Name < inventory.Packages[j].Name
+	})
+	return inventory
+}
+
+// parseControlStatus parses the installed packages of a dpkg or opkg status file, made of the paragraphs of the
+// control fields of the packages
+func parseControlStatus(r io.Reader) ([]Package, error) {
+	var packages []Package
+	var current Package
+	var installed bool
+	flush := func() {
+		if current.Name != "" && installed {
+			packages = append(packages, current)
+		}
+		current, installed = Package{}, false
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.HasPrefix(line, " ") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Package":
+			current.Name = value
+		case "Version":
+			current.Version = value
+		case "Status":
+			// e.g. install ok installed, or install user installed for opkg
+			installed = strings.HasSuffix(value, " installed")
+		}
+	}
+	flush()
+	return packages, scanner.Err()
+}
+
+// parseAPKInstalled parses the installed packages of an apk database, made of the paragraphs of the single letter
+// fields of the packages
+func parseAPKInstalled(r io.Reader) ([]Package, error) {
+	var packages []Package
+	var current Package
+	flush := func() {
+		if current.Name != "" {
+			packages = append(packages, current)
+		}
+		current = Package{}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "P:"):
+			current.Name = line[2:]
+		case strings.HasPrefix(line, "V:"):
+			current.Version = line[2:]
+		}
+	}
+	flush()
+	return packages, scanner.Err()
+}
+
+// parseShellVars parses the variables of a shell file like /etc/os-release, removing the quotes of their values
+func parseShellVars(r io.Reader) map[string]string {
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		vars[key] = strings.Trim(value, `"'`)
+	}
+	return vars
+}
@@ -0,0 +1,75 @@
+//go:build !android
+// +build !android
+
+package system
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// packageDatabase is the database of the installed packages of a package manager
+type packageDatabase struct {
+	manager string
+	path    string
+	parse   func(r io.Reader) ([]Package, error)
+}
+
+// packageDatabases are tried in order, OpenWrt moves from opkg to apk
+var packageDatabases = []packageDatabase{
+	{manager: "apk", path: "/lib/apk/db/installed", parse: parseAPKInstalled},
+	{manager: "opkg", path: "/usr/lib/opkg/status", parse: parseControlStatus},
+	{manager: "dpkg", path: "/var/lib/dpkg/status", parse: parseControlStatus},
+}
+
+func collectInventory(config InventoryConfig) *Inventory {
+	var manager string
+	var installed []Package
+	if config.hasField(InventoryFieldPackages) || len(config.Packages) > 0 {
+		manager, installed = installedPackages()
+	}
+	return newInventory(config, patchLevel(), manager, installed)
+}
+
+// installedPackages reads the installed packages from the database of the first package manager found
+func installedPackages() (string, []Package) {
+	for _, db := range packageDatabases {
+		f, err := os.Open(db.path)
+		if err != nil {
+			continue
+		}
+		packages, err := db.parse(f)
+		_ = f.Close()
+		if err != nil {
+			log.Warnf("failed to read the installed packages from %s: %v", db.path, err)
+			continue
+		}
+		return db.manager, packages
+	}
+	return "", nil
+}
+
+// patchLevel returns the release and the revision of an OpenWrt image, the point release of Debian or the version of
+// the os-release file otherwise
+func patchLevel() string {
+	if f, err := os.Open("/etc/openwrt_release"); err == nil {
+		vars := parseShellVars(f)
+		_ = f.Close()
+		return strings.TrimSpace(vars["DISTRIB_RELEASE"] + " " + vars["DISTRIB_REVISION"])
+	}
+	if data, err := os.ReadFile("/etc/debian_version"); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+	if f, err := os.Open("/etc/os-release"); err == nil {
+		vars := parseShellVars(f)
+		_ = f.Close()
+		if vars["VERSION"] != "" {
+			return vars["VERSION"]
+		}
+		return vars["VERSION_ID"]
+	}
+	return ""
+}
@@ -0,0 +1,9 @@
+//go:build !linux || android
+// +build !linux android
+
+package system
+
+// collectInventory isn't supported, the inventory is only collected on Linux
+func collectInventory(InventoryConfig) *Inventory {
+	return nil
+}
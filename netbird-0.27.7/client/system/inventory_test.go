@@ -0,0 +1,86 @@
+package system
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseControlStatus(t *testing.T) {
+	status := `Package: libopenssl3
+Version: 3.0.13-1
+Depends: libc
+Status: install user installed
+Architecture: mipsel_24kc
+
+Package: dropbear
+Version: 2022.82-6
+Status: install ok installed
+Description: a small SSH server
+ spanning several lines
+ Package: not-a-package
+
+Package: removed
+Version: 1.0
+Status: deinstall ok config-files
+`
+	packages, err := parseControlStatus(strings.NewReader(status))
+	require.NoError(t, err)
+	assert.Equal(t, []Package{
+		{Name: "libopenssl3", Version: "3.0.13-1"},
+		{Name: "dropbear", Version: "2022.82-6"},
+	}, packages)
+}
+
+func TestParseAPKInstalled(t *testing.T) {
+	db := "C:Q1abc=\nP:libopenssl3\nV:3.0.14-r1\nA:aarch64_cortex-a53\n\nP:busybox\nV:1.36.1-r2\n"
+	packages, err := parseAPKInstalled(strings.NewReader(db))
+	require.NoError(t, err)
+	assert.Equal(t, []Package{
+		{Name: "libopenssl3", Version: "3.0.14-r1"},
+		{Name: "busybox", Version: "1.36.1-r2"},
+	}, packages)
+}
+
+func TestParseShellVars(t *testing.T) {
+	vars := parseShellVars(strings.NewReader("# comment\nDISTRIB_RELEASE='23.05.3'\nDISTRIB_REVISION=\"r23809-234f1a2efa\"\n"))
+	assert.Equal(t, map[string]string{"DISTRIB_RELEASE": "23.05.3", "DISTRIB_REVISION": "r23809-234f1a2efa"}, vars)
+}
+
+func TestNewInventory(t *testing.T) {
+	installed := []Package{
+		{Name: "libopenssl3", Version: "3.0.13-1"},
+		{Name: "dropbear", Version: "2022.82-6"},
+		{Name: "busybox", Version: "1.36.1-1"},
+	}
+
+	inventory := newInventory(InventoryConfig{Fields: []string{InventoryFieldPatchLevel}, Packages: []string{"libopenssl3", "missing"}},
+		"23.05.3 r23809", "opkg", installed)
+	assert.Equal(t, &Inventory{
+		PatchLevel: "23.05.3 r23809",
+		Packages:   []Package{{Name: "libopenssl3", Version: "3.0.13-1"}},
+	}, inventory)
+
+	inventory = newInventory(InventoryConfig{Fields: []string{InventoryFieldPackages}, Packages: []string{InventoryAllPackages}},
+		"23.05.3 r23809", "opkg", installed)
+	assert.Equal(t, "opkg", inventory.PackageManager)
+	assert.Equal(t, 3, inventory.PackagesCount)
+	assert.Empty(t, inventory.PatchLevel)
+	assert.Equal(t, []string{"busybox", "dropbear", "libopenssl3"},
+		[]string{inventory.Packages[0].Name, inventory.Packages[1].Name, inventory.Packages[2].Name})
+}
+
+func TestGetInventory_Disabled(t *testing.T) {
+	assert.Nil(t, getInventory(context.Background()))
+	//nolint
+	assert.Nil(t, getInventory(context.WithValue(context.Background(), InventoryCtxKey, InventoryConfig{})))
+}
+
+func TestParseInventoryFields(t *testing.T) {
+	assert.NoError(t, ParseInventoryFields([]string{InventoryFieldPatchLevel, InventoryFieldPackages}))
+	assert.Error(t, ParseInventoryFields([]string{"kernel"}))
+	assert.Error(t, ParseInventoryFields([]string{InventoryFieldPackages, InventoryFieldPackages}))
+}
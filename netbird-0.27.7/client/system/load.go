@@ -0,0 +1,92 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CPUTimes are the cumulated busy and total CPU times of the system, in clock ticks
+type CPUTimes struct {
+	Busy  uint64
+	Total uint64
+}
+
+// CPUPercent returns the share of the CPU time the system spent busy between the previous times and these ones
+func (t CPUTimes) CPUPercent(previous CPUTimes) float64 {
+	if t.Total <= previous.Total || t.Busy < previous.Busy {
+		return 0
+	}
+	return min(float64(t.Busy-previous.Busy)*100/float64(t.Total-previous.Total), 100)
+}
+
+// parseCPUTimes parses the aggregated cpu line of /proc/stat. The idle and iowait times are the idle ones, the guest
+// times are already part of the user times.
+func parseCPUTimes(r io.Reader) (CPUTimes, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+
+		var times CPUTimes
+		for i, field := range fields[1:min(len(fields), 9)] {
+			value, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return CPUTimes{}, fmt.Errorf("parse cpu time %q: %w", field, err)
+			}
+			times.Total += value
+			// idle and iowait
+			if i != 3 && i != 4 {
+				times.Busy += value
+			}
+		}
+		return times, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return CPUTimes{}, err
+	}
+	return CPUTimes{}, fmt.Errorf("no cpu line")
+}
+
+// parseMemoryPercent returns the share of the memory in use from /proc/meminfo, the available memory being the
+// memory the system can reclaim without swapping
+func parseMemoryPercent(r io.Reader) (float64, error) {
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		name := strings.TrimSuffix(fields[0], ":")
+		switch name {
+		case "MemTotal", "MemFree", "MemAvailable", "Buffers", "Cached":
+			value, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("parse %s %q: %w", name, fields[1], err)
+			}
+			values[name] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	total := values["MemTotal"]
+	if total == 0 {
+		return 0, fmt.Errorf("no MemTotal")
+	}
+	available, ok := values["MemAvailable"]
+	if !ok {
+		// kernels before 3.14
+		available = values["MemFree"] + values["Buffers"] + values["Cached"]
+	}
+	if available > total {
+		return 0, nil
+	}
+	return float64(total-available) * 100 / float64(total), nil
+}
@@ -0,0 +1,30 @@
+//go:build !android
+// +build !android
+
+package system
+
+import (
+	"os"
+)
+
+// GetCPUTimes returns the cumulated CPU times of the system
+func GetCPUTimes() (CPUTimes, error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return CPUTimes{}, err
+	}
+	defer f.Close()
+
+	return parseCPUTimes(f)
+}
+
+// GetMemoryPercent returns the share of the memory of the system in use
+func GetMemoryPercent() (float64, error) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return parseMemoryPercent(f)
+}
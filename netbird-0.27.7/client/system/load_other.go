@@ -0,0 +1,19 @@
+//go:build !linux || android
+// +build !linux android
+
+package system
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// GetCPUTimes isn't supported, the load is only collected on Linux
+func GetCPUTimes() (CPUTimes, error) {
+	return CPUTimes{}, fmt.Errorf("the CPU load isn't supported on %s", runtime.GOOS)
+}
+
+// GetMemoryPercent isn't supported, the load is only collected on Linux
+func GetMemoryPercent() (float64, error) {
+	return 0, fmt.Errorf("the memory load isn't supported on %s", runtime.GOOS)
+}
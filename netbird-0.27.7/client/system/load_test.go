@@ -0,0 +1,45 @@
+package system
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCPUTimes(t *testing.T) {
+	stat := `cpu  100 10 50 800 20 5 15 0 30 0
+cpu0 50 5 25 400 10 2 8 0 15 0
+intr 12345
+`
+	times, err := parseCPUTimes(strings.NewReader(stat))
+	require.NoError(t, err)
+	assert.Equal(t, CPUTimes{Busy: 180, Total: 1000}, times, "the guest times shouldn't be counted twice")
+
+	later := CPUTimes{Busy: 230, Total: 1200}
+	assert.Equal(t, 25.0, later.CPUPercent(times))
+	assert.Equal(t, 0.0, times.CPUPercent(times), "no CPU time elapsed")
+
+	_, err = parseCPUTimes(strings.NewReader("intr 12345\n"))
+	assert.Error(t, err)
+}
+
+func TestParseMemoryPercent(t *testing.T) {
+	meminfo := `MemTotal:         1000 kB
+MemFree:           100 kB
+MemAvailable:      250 kB
+Buffers:            50 kB
+Cached:            200 kB
+`
+	percent, err := parseMemoryPercent(strings.NewReader(meminfo))
+	require.NoError(t, err)
+	assert.Equal(t, 75.0, percent)
+
+	percent, err = parseMemoryPercent(strings.NewReader("MemTotal: 1000 kB\nMemFree: 100 kB\nBuffers: 50 kB\nCached: 350 kB\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 50.0, percent, "the free, buffers and cached memory should be available without MemAvailable")
+
+	_, err = parseMemoryPercent(strings.NewReader("MemFree: 100 kB\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,257 @@
+package ubus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// blob attribute header, see libubox blob.h
+const (
+	blobAttrIDMask   = 0x7f000000
+	blobAttrIDShift  = 24
+	blobAttrLenMask  = 0x00ffffff
+	blobAttrExtended = 0x80000000
+	blobAttrHdrLen   = 4
+)
+
+// blobmsg types, see libubox blobmsg.h
+const (
+	blobmsgTypeArray  = 1
+	blobmsgTypeTable  = 2
+	blobmsgTypeString = 3
+	blobmsgTypeInt64  = 4
+	blobmsgTypeInt32  = 5
+	blobmsgTypeInt16  = 6
+	blobmsgTypeInt8   = 7
+	blobmsgTypeDouble = 8
+)
+
+// blobAttr is a decoded blob attribute
+type blobAttr struct {
+	id       uint8
+	extended bool
+	data     []byte
+}
+
+func blobAlign(n int) int {
+	return (n + 3) &^ 3
+}
+
+// appendBlobAttr appends an attribute with the given ID and payload, padded to 4 bytes
+func appendBlobAttr(buf []byte, id uint8, extended bool, data []byte) []byte {
+	idLen := uint32(id)<<blobAttrIDShift | uint32(blobAttrHdrLen+len(data))
+	if extended {
+		idLen |= blobAttrExtended
+	}
+	buf = binary.BigEndian.AppendUint32(buf, idLen)
+	buf = append(buf, data...)
+	for len(buf)%4 != 0 {
+		buf = append(buf, 0)
+	}
+	return buf
+}
+
+func appendBlobUint32(buf []byte, id uint8, value uint32) []byte {
+	return appendBlobAttr(buf, id, false, binary.BigEndian.AppendUint32(nil, value))
+}
+
+func appendBlobString(buf []byte, id uint8, value string) []byte {
+	return appendBlobAttr(buf, id, false, append([]byte(value), 0))
+}
+
+// parseBlobAttrs splits the payload of a blob into its attributes
+func parseBlobAttrs(data []byte) ([]blobAttr, error) {
+	var attrs []blobAttr
+	for len(data) > 0 {
+		if len(data) < blobAttrHdrLen {
+			return nil, fmt.Errorf("truncated blob attribute header")
+		}
+		idLen := binary.BigEndian.Uint32(data)
+		length := int(idLen & blobAttrLenMask)
+		if length < blobAttrHdrLen || length > len(data) {
+			return nil, fmt.Errorf("invalid blob attribute length %d", length)
+		}
+		attrs = append(attrs, blobAttr{
+			id:       uint8((idLen & blobAttrIDMask) >> blobAttrIDShift),
+			extended: idLen&blobAttrExtended != 0,
+			data:     data[blobAttrHdrLen:length],
+		})
+
+		padded := blobAlign(length)
+		if padded > len(data) {
+			padded = len(data)
+		}
+		data = data[padded:]
+	}
+	return attrs, nil
+}
+
+// appendBlobmsg appends a named blobmsg attribute of the given type and value payload
+func appendBlobmsg(buf []byte, typ uint8, name string, value []byte) []byte {
+	hdrLen := blobAlign(2 + len(name) + 1)
+	data := make([]byte, hdrLen, hdrLen+len(value))
+	binary.BigEndian.PutUint16(data, uint16(len(name)))
+	copy(data[2:], name)
+	data = append(data, value...)
+	return appendBlobAttr(buf, typ, true, data)
+}
+
+// encodeTable encodes the entries of a table as blobmsg attributes sorted by name
+func encodeTable(table map[string]any) ([]byte, error) {
+	names := make([]string, 0, len(table))
+	for name := range table {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf []byte
+	for _, name := range names {
+		var err error
+		buf, err = appendValue(buf, name, table[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return buf, nil
+}
+
+// appendValue appends a value as a named blobmsg attribute
+func appendValue(buf []byte, name string, value any) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		return appendBlobmsg(buf, blobmsgTypeString, name, append([]byte(v), 0)), nil
+	case bool:
+		var b byte
+		if v {
+			b = 1
+		}
+		return appendBlobmsg(buf, blobmsgTypeInt8, name, []byte{b}), nil
+	case int:
+		return appendBlobmsg(buf, blobmsgTypeInt64, name, binary.BigEndian.AppendUint64(nil, uint64(v))), nil
+	case int32:
+		return appendBlobmsg(buf, blobmsgTypeInt32, name, binary.BigEndian.AppendUint32(nil, uint32(v))), nil
+	case uint32:
+		return appendBlobmsg(buf, blobmsgTypeInt32, name, binary.BigEndian.AppendUint32(nil, v)), nil
+	case int64:
+		return appendBlobmsg(buf, blobmsgTypeInt64, name, binary.BigEndian.AppendUint64(nil, uint64(v))), nil
+	case uint64:
+		return appendBlobmsg(buf, blobmsgTypeInt64, name, binary.BigEndian.AppendUint64(nil, v)), nil
+	case float64:
+		return appendBlobmsg(buf, blobmsgTypeDouble, name, binary.BigEndian.AppendUint64(nil, math.Float64bits(v))), nil
+	case map[string]any:
+		table, err := encodeTable(v)
+		if err != nil {
+			return nil, err
+		}
+		return appendBlobmsg(buf, blobmsgTypeTable, name, table), nil
+	case []any:
+		var array []byte
+		for i, item := range v {
+			var err error
+			if array, err = appendValue(array, "", item); err != nil {
+				return nil, fmt.Errorf("%d: %w", i, err)
+			}
+		}
+		return appendBlobmsg(buf, blobmsgTypeArray, name, array), nil
+	case []string:
+		items := make([]any, 0, len(v))
+		for _, s := range v {
+			items = append(items, s)
+		}
+		return appendValue(buf, name, items)
+	case []map[string]any:
+		items := make([]any, 0, len(v))
+		for _, t := range v {
+			items = append(items, t)
+		}
+		return appendValue(buf, name, items)
+	default:
+		return nil, fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+// decodeTable decodes blobmsg attributes into a table
+func decodeTable(data []byte) (map[string]any, error) {
+	attrs, err := parseBlobAttrs(data)
+	if err != nil {
+		return nil, err
+	}
+
+	table := make(map[string]any, len(attrs))
+	for _, attr := range attrs {
+		name, value, err := decodeValue(attr)
+		if err != nil {
+			return nil, err
+		}
+		table[name] = value
+	}
+	return table, nil
+}
+
+// decodeValue decodes the name and the value of a blobmsg attribute
+func decodeValue(attr blobAttr) (string, any, error) {
+	if !attr.extended || len(attr.data) < 2 {
+		return "", nil, fmt.Errorf("invalid blobmsg attribute")
+	}
+	nameLen := int(binary.BigEndian.Uint16(attr.data))
+	hdrLen := blobAlign(2 + nameLen + 1)
+	if hdrLen > len(attr.data) {
+		return "", nil, fmt.Errorf("truncated blobmsg header")
+	}
+	name := string(attr.data[2 : 2+nameLen])
+	data := attr.data[hdrLen:]
+
+	switch attr.id {
+	case blobmsgTypeString:
+		if len(data) == 0 {
+			return name, "", nil
+		}
+		return name, string(data[:len(data)-1]), nil
+	case blobmsgTypeInt8:
+		if len(data) < 1 {
+			return "", nil, fmt.Errorf("truncated value of %s", name)
+		}
+		return name, data[0] != 0, nil
+	case blobmsgTypeInt16:
+		if len(data) < 2 {
+			return "", nil, fmt.Errorf("truncated value of %s", name)
+		}
+		return name, int64(int16(binary.BigEndian.Uint16(data))), nil
+	case blobmsgTypeInt32:
+		if len(data) < 4 {
+			return "", nil, fmt.Errorf("truncated value of %s", name)
+		}
+		return name, int64(int32(binary.BigEndian.Uint32(data))), nil
+	case blobmsgTypeInt64:
+		if len(data) < 8 {
+			return "", nil, fmt.Errorf("truncated value of %s", name)
+		}
+		return name, int64(binary.BigEndian.Uint64(data)), nil
+	case blobmsgTypeDouble:
+		if len(data) < 8 {
+			return "", nil, fmt.Errorf("truncated value of %s", name)
+		}
+		return name, math.Float64frombits(binary.BigEndian.Uint64(data)), nil
+	case blobmsgTypeTable:
+		table, err := decodeTable(data)
+		return name, table, err
+	case blobmsgTypeArray:
+		attrs, err := parseBlobAttrs(data)
+		if err != nil {
+			return "", nil, err
+		}
+		array := make([]any, 0, len(attrs))
+		for _, a := range attrs {
+			_, v, err := decodeValue(a)
+			if err != nil {
+				return "", nil, err
+			}
+			array = append(array, v)
+		}
+		return name, array, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported blobmsg type %d of %s", attr.id, name)
+	}
+}
@@ -0,0 +1,425 @@
+// Package ubus implements the ubus protocol of the OpenWrt micro bus daemon, letting LuCI and the other OpenWrt
+// components call the client and receive its events
+package ubus
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultSocketPath is the socket of the ubus daemon on OpenWrt
+const DefaultSocketPath = "/var/run/ubus/ubus.sock"
+
+// message types, see ubusmsg.h
+const (
+	msgHello     = 0
+	msgStatus    = 1
+	msgData      = 2
+	msgLookup    = 4
+	msgInvoke    = 5
+	msgAddObject = 6
+)
+
+// message attributes, see ubusmsg.h
+const (
+	attrStatus    = 1
+	attrObjPath   = 2
+	attrObjID     = 3
+	attrMethod    = 4
+	attrSignature = 6
+	attrData      = 7
+	attrNoReply   = 10
+)
+
+// status codes of the replies, see ubusmsg.h
+const (
+	StatusOK              = 0
+	StatusInvalidArgument = 2
+	StatusMethodNotFound  = 3
+	StatusNotFound        = 4
+	StatusUnknownError    = 9
+)
+
+// systemObjectEvent is the ID of the ubus object the events are sent to
+const systemObjectEvent = 1
+
+const (
+	msgHdrLen      = 8
+	requestTimeout = 10 * time.Second
+	maxMessageLen  = 1 << 20
+)
+
+// Handler serves a method call of an object, returning the reply data
+type Handler func(ctx context.Context, args map[string]any) (map[string]any, error)
+
+// StatusError is an error replied with a ubus status code
+type StatusError struct {
+	Status uint32
+	Err    error
+}
+
+func (e *StatusError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// message is a ubus message: its header and the attributes of its blob
+type message struct {
+	typ   uint8
+	seq   uint16
+	peer  uint32
+	attrs []blobAttr
+}
+
+func (m *message) attr(id uint8) []byte {
+	for _, a := range m.attrs {
+		if a.id == id {
+			return a.data
+		}
+	}
+	return nil
+}
+
+func (m *message) uint32Attr(id uint8) (uint32, bool) {
+	data := m.attr(id)
+	if len(data) < 4 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(data), true
+}
+
+// request collects the replies of a request until its status
+type request struct {
+	data []*message
+	done chan uint32
+}
+
+// Conn is a connection to the ubus daemon
+type Conn struct {
+	conn net.Conn
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	seq     uint16
+	pending map[uint16]*request
+	objects map[uint32]map[string]Handler
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Dial connects to the ubus daemon on the socket path and starts reading its messages
+func Dial(path string) (*Conn, error) {
+	nc, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Conn{
+		conn:    nc,
+		pending: make(map[uint16]*request),
+		objects: make(map[uint32]map[string]Handler),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	_ = nc.SetReadDeadline(time.Now().Add(requestTimeout))
+	hello, err := c.read()
+	if err != nil {
+		_ = nc.Close()
+		return nil, fmt.Errorf("read hello: %w", err)
+	}
+	if hello.typ != msgHello {
+		_ = nc.Close()
+		return nil, fmt.Errorf("unexpected message type %d instead of hello", hello.typ)
+	}
+	_ = nc.SetReadDeadline(time.Time{})
+
+	go c.readLoop()
+	return c, nil
+}
+
+// Done is closed when the connection is closed
+func (c *Conn) Done() <-chan struct{} {
+	return c.ctx.Done()
+}
+
+// Close closes the connection
+func (c *Conn) Close() error {
+	c.cancel()
+	return c.conn.Close()
+}
+
+// AddObject registers an object with the given methods, the method calls are served until the connection is closed
+func (c *Conn) AddObject(name string, methods map[string]Handler) error {
+	var signature []byte
+	for method := range methods {
+		signature = appendBlobmsg(signature, blobmsgTypeTable, method, nil)
+	}
+
+	var buf []byte
+	buf = appendBlobString(buf, attrObjPath, name)
+	buf = appendBlobAttr(buf, attrSignature, false, signature)
+
+	replies, err := c.request(msgAddObject, 0, buf)
+	if err != nil {
+		return fmt.Errorf("add object %s: %w", name, err)
+	}
+
+	for _, reply := range replies {
+		if id, ok := reply.uint32Attr(attrObjID); ok {
+			c.mu.Lock()
+			c.objects[id] = methods
+			c.mu.Unlock()
+			return nil
+		}
+	}
+	return fmt.Errorf("add object %s: no object ID in the reply", name)
+}
+
+// SendEvent broadcasts an event with the given ID and data to the ubus listeners
+func (c *Conn) SendEvent(id string, data map[string]any) error {
+	if _, err := c.Invoke(systemObjectEvent, "send", map[string]any{"id": id, "data": data}); err != nil {
+		return fmt.Errorf("send event %s: %w", id, err)
+	}
+	return nil
+}
+
+// Lookup returns the ID of the object registered with the name, e.g. service
+func (c *Conn) Lookup(name string) (uint32, error) {
+	replies, err := c.request(msgLookup, 0, appendBlobString(nil, attrObjPath, name))
+	if err != nil {
+		return 0, fmt.Errorf("lookup object %s: %w", name, err)
+	}
+
+	for _, reply := range replies {
+		if id, ok := reply.uint32Attr(attrObjID); ok {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("lookup object %s: not found", name)
+}
+
+// Invoke calls the method of the object with the ID and returns the data of its reply, nil without data
+func (c *Conn) Invoke(objID uint32, method string, args map[string]any) (map[string]any, error) {
+	data, err := encodeTable(args)
+	if err != nil {
+		return nil, fmt.Errorf("encode arguments: %w", err)
+	}
+
+	var buf []byte
+	buf = appendBlobUint32(buf, attrObjID, objID)
+	buf = appendBlobString(buf, attrMethod, method)
+	buf = appendBlobAttr(buf, attrData, false, data)
+
+	replies, err := c.request(msgInvoke, objID, buf)
+	if err != nil {
+		return nil, err
+	}
+	for _, reply := range replies {
+		if data := reply.attr(attrData); data != nil {
+			return decodeTable(data)
+		}
+	}
+	return nil, nil
+}
+
+// request sends a message and waits for its status, returning the data messages replied before it
+func (c *Conn) request(typ uint8, peer uint32, buf []byte) ([]*message, error) {
+	req := &request{done: make(chan uint32, 1)}
+
+	c.mu.Lock()
+	c.seq++
+	seq := c.seq
+	c.pending[seq] = req
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, seq)
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(typ, seq, peer, buf); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(requestTimeout)
+	defer timer.Stop()
+
+	select {
+	case status := <-req.done:
+		if status != StatusOK {
+			return nil, fmt.Errorf("ubus status %d", status)
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return req.data, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("request timed out")
+	case <-c.ctx.Done():
+		return nil, net.ErrClosed
+	}
+}
+
+func (c *Conn) write(typ uint8, seq uint16, peer uint32, buf []byte) error {
+	msg := make([]byte, msgHdrLen, msgHdrLen+blobAttrHdrLen+len(buf))
+	msg[1] = typ
+	binary.BigEndian.PutUint16(msg[2:], seq)
+	binary.BigEndian.PutUint32(msg[4:], peer)
+	msg = appendBlobAttr(msg, 0, false, buf)
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_, err := c.conn.Write(msg)
+	return err
+}
+
+func (c *Conn) read() (*message, error) {
+	hdr := make([]byte, msgHdrLen+blobAttrHdrLen)
+	if _, err := io.ReadFull(c.conn, hdr); err != nil {
+		return nil, err
+	}
+
+	length := int(binary.BigEndian.Uint32(hdr[msgHdrLen:]) & blobAttrLenMask)
+	if length < blobAttrHdrLen || length > maxMessageLen {
+		return nil, fmt.Errorf("invalid message length %d", length)
+	}
+	data := make([]byte, blobAlign(length)-blobAttrHdrLen)
+	if _, err := io.ReadFull(c.conn, data); err != nil {
+		return nil, err
+	}
+
+	attrs, err := parseBlobAttrs(data[:length-blobAttrHdrLen])
+	if err != nil {
+		return nil, err
+	}
+
+	return &message{
+		typ:   hdr[1],
+		seq:   binary.BigEndian.Uint16(hdr[2:]),
+		peer:  binary.BigEndian.Uint32(hdr[4:]),
+		attrs: attrs,
+	}, nil
+}
+
+func (c *Conn) readLoop() {
+	defer c.cancel()
+
+	for {
+		msg, err := c.read()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) && !errors.Is(err, io.EOF) {
+				log.Warnf("failed to read ubus message: %v", err)
+			}
+			return
+		}
+
+		switch msg.typ {
+		case msgStatus, msgData:
+			c.handleReply(msg)
+		case msgInvoke:
+			go c.handleInvoke(msg)
+		}
+	}
+}
+
+func (c *Conn) handleReply(msg *message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	req, ok := c.pending[msg.seq]
+	if !ok {
+		return
+	}
+	if msg.typ == msgData {
+		req.data = append(req.data, msg)
+		return
+	}
+
+	status, _ := msg.uint32Attr(attrStatus)
+	select {
+	case req.done <- status:
+	default:
+	}
+}
+
+// handleInvoke serves a method call and replies with its data and status
+func (c *Conn) handleInvoke(msg *message) {
+	objID, _ := msg.uint32Attr(attrObjID)
+	method := string(trimNul(msg.attr(attrMethod)))
+
+	c.mu.Lock()
+	handler, ok := c.objects[objID][method]
+	c.mu.Unlock()
+
+	status := uint32(StatusOK)
+	var reply map[string]any
+	switch {
+	case !ok:
+		status = StatusMethodNotFound
+	default:
+		args, err := decodeTable(msg.attr(attrData))
+		if err != nil {
+			status = StatusInvalidArgument
+			break
+		}
+		reply, err = handler(c.ctx, args)
+		if err != nil {
+			log.Debugf("ubus call %s failed: %v", method, err)
+			status = StatusUnknownError
+			var statusErr *StatusError
+			if errors.As(err, &statusErr) {
+				status = statusErr.Status
+			}
+		}
+	}
+
+	if noReply, ok := msg.uint32Attr(attrNoReply); ok && noReply != 0 {
+		return
+	}
+
+	if status == StatusOK && reply != nil {
+		data, err := encodeTable(reply)
+		if err != nil {
+			log.Errorf("failed to encode the reply of ubus call %s: %v", method, err)
+			status = StatusUnknownError
+		} else {
+			var buf []byte
+			buf = appendBlobUint32(buf, attrObjID, objID)
+			buf = appendBlobAttr(buf, attrData, false, data)
+			if err := c.write(msgData, msg.seq, msg.peer, buf); err != nil {
+				log.Debugf("failed to reply to ubus call %s: %v", method, err)
+				return
+			}
+		}
+	}
+
+	var buf []byte
+	buf = appendBlobUint32(buf, attrStatus, status)
+	buf = appendBlobUint32(buf, attrObjID, objID)
+	if err := c.write(msgStatus, msg.seq, msg.peer, buf); err != nil {
+		log.Debugf("failed to reply to ubus call %s: %v", method, err)
+	}
+}
+
+func trimNul(data []byte) []byte {
+	for len(data) > 0 && data[len(data)-1] == 0 {
+		data = data[:len(data)-1]
+	}
+	return data
+}
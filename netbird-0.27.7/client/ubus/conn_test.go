@@ -0,0 +1,255 @@
+package ubus
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/client/proto"
+)
+
+func TestBlobmsgRoundTrip(t *testing.T) {
+	table := map[string]any{
+		"string": "value",
+		"bool":   true,
+		"int":    int64(-42),
+		"double": 1.5,
+		"table":  map[string]any{"nested": "yes"},
+		"array":  []any{"a", "b"},
+		"empty":  "",
+	}
+
+	data, err := encodeTable(table)
+	require.NoError(t, err)
+	require.Zero(t, len(data)%4, "attributes should be padded to 4 bytes")
+
+	decoded, err := decodeTable(data)
+	require.NoError(t, err)
+	require.Equal(t, table, decoded)
+
+	_, err = encodeTable(map[string]any{"unsupported": struct{}{}})
+	require.Error(t, err)
+}
+
+// fakeUbusd is the ubus daemon side of a connection
+type fakeUbusd struct {
+	t    *testing.T
+	conn net.Conn
+}
+
+func (f *fakeUbusd) send(typ uint8, seq uint16, peer uint32, buf []byte) {
+	msg := make([]byte, msgHdrLen)
+	msg[1] = typ
+	binary.BigEndian.PutUint16(msg[2:], seq)
+	binary.BigEndian.PutUint32(msg[4:], peer)
+	msg = appendBlobAttr(msg, 0, false, buf)
+	_, err := f.conn.Write(msg)
+	require.NoError(f.t, err)
+}
+
+func (f *fakeUbusd) receive() *message {
+	hdr := make([]byte, msgHdrLen+blobAttrHdrLen)
+	_, err := io.ReadFull(f.conn, hdr)
+	require.NoError(f.t, err)
+
+	length := int(binary.BigEndian.Uint32(hdr[msgHdrLen:]) & blobAttrLenMask)
+	data := make([]byte, blobAlign(length)-blobAttrHdrLen)
+	_, err = io.ReadFull(f.conn, data)
+	require.NoError(f.t, err)
+
+	attrs, err := parseBlobAttrs(data[:length-blobAttrHdrLen])
+	require.NoError(f.t, err)
+
+	return &message{typ: hdr[1], seq: binary.BigEndian.Uint16(hdr[2:]), peer: binary.BigEndian.Uint32(hdr[4:]), attrs: attrs}
+}
+
+type mockDaemon struct {
+	proto.UnimplementedDaemonServiceServer
+}
+
+func (m *mockDaemon) Status(context.Context, *proto.StatusRequest) (*proto.StatusResponse, error) {
+	return &proto.StatusResponse{
+		Status: "Connected",
+		FullStatus: &proto.FullStatus{
+			LocalPeerState: &proto.LocalPeerState{IP: "100.64.0.1/16", Fqdn: "router.netbird.cloud"},
+			Peers: []*proto.PeerState{
+				{IP: "100.64.0.2", ConnStatus: "Connected"},
+				{IP: "100.64.0.3", ConnStatus: "Connecting"},
+			},
+		},
+	}, nil
+}
+
+func TestConnServeObject(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ubus.sock")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	ubusdConns := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			ubusdConns <- conn
+		}
+	}()
+
+	dialed := make(chan *Conn, 1)
+	go func() {
+		conn, err := Dial(path)
+		require.NoError(t, err)
+		dialed <- conn
+	}()
+
+	ubusd := &fakeUbusd{t: t, conn: <-ubusdConns}
+	defer ubusd.conn.Close()
+	_ = ubusd.conn.SetDeadline(time.Now().Add(10 * time.Second))
+	ubusd.send(msgHello, 0, 0x1234, nil)
+	conn := <-dialed
+	defer conn.Close()
+
+	service := NewService(path, ObjectName, &mockDaemon{})
+	added := make(chan error, 1)
+	go func() {
+		added <- conn.AddObject(ObjectName, service.methods())
+	}()
+
+	// register the object
+	msg := ubusd.receive()
+	require.Equal(t, uint8(msgAddObject), msg.typ)
+	require.Equal(t, ObjectName, string(trimNul(msg.attr(attrObjPath))))
+	signature, err := decodeTable(msg.attr(attrSignature))
+	require.NoError(t, err)
+	require.Contains(t, signature, "status")
+
+	ubusd.send(msgData, msg.seq, 0, appendBlobUint32(nil, attrObjID, 0x42))
+	ubusd.send(msgStatus, msg.seq, 0, appendBlobUint32(nil, attrStatus, StatusOK))
+	require.NoError(t, <-added)
+
+	// call the status method
+	var buf []byte
+	buf = appendBlobUint32(buf, attrObjID, 0x42)
+	buf = appendBlobString(buf, attrMethod, "status")
+	buf = appendBlobAttr(buf, attrData, false, nil)
+	ubusd.send(msgInvoke, 7, 0x99, buf)
+
+	msg = ubusd.receive()
+	require.Equal(t, uint8(msgData), msg.typ)
+	require.Equal(t, uint16(7), msg.seq)
+	require.Equal(t, uint32(0x99), msg.peer)
+	reply, err := decodeTable(msg.attr(attrData))
+	require.NoError(t, err)
+	require.Equal(t, "Connected", reply["status"])
+	require.Equal(t, "router.netbird.cloud", reply["fqdn"])
+	require.Equal(t, int64(1), reply["peers_connected"])
+	require.Equal(t, int64(2), reply["peers_total"])
+
+	msg = ubusd.receive()
+	require.Equal(t, uint8(msgStatus), msg.typ)
+	status, _ := msg.uint32Attr(attrStatus)
+	require.Equal(t, uint32(StatusOK), status)
+
+	// call an unknown method
+	buf = nil
+	buf = appendBlobUint32(buf, attrObjID, 0x42)
+	buf = appendBlobString(buf, attrMethod, "unknown")
+	ubusd.send(msgInvoke, 8, 0x99, buf)
+
+	msg = ubusd.receive()
+	require.Equal(t, uint8(msgStatus), msg.typ)
+	status, _ = msg.uint32Attr(attrStatus)
+	require.Equal(t, uint32(StatusMethodNotFound), status)
+
+	// broadcast an event
+	sent := make(chan error, 1)
+	go func() {
+		sent <- conn.SendEvent(eventState, map[string]any{"state": "connected"})
+	}()
+
+	msg = ubusd.receive()
+	require.Equal(t, uint8(msgInvoke), msg.typ)
+	require.Equal(t, uint32(systemObjectEvent), msg.peer)
+	require.Equal(t, "send", string(trimNul(msg.attr(attrMethod))))
+	event, err := decodeTable(msg.attr(attrData))
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"id": eventState, "data": map[string]any{"state": "connected"}}, event)
+
+	ubusd.send(msgStatus, msg.seq, 0, appendBlobUint32(nil, attrStatus, StatusOK))
+	require.NoError(t, <-sent)
+}
+
+func TestConnLookupInvoke(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ubus.sock")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	ubusdConns := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			ubusdConns <- conn
+		}
+	}()
+
+	dialed := make(chan *Conn, 1)
+	go func() {
+		conn, err := Dial(path)
+		require.NoError(t, err)
+		dialed <- conn
+	}()
+
+	ubusd := &fakeUbusd{t: t, conn: <-ubusdConns}
+	defer ubusd.conn.Close()
+	_ = ubusd.conn.SetDeadline(time.Now().Add(10 * time.Second))
+	ubusd.send(msgHello, 0, 0x1234, nil)
+	conn := <-dialed
+	defer conn.Close()
+
+	// look up the object
+	looked := make(chan uint32, 1)
+	go func() {
+		id, err := conn.Lookup("service")
+		require.NoError(t, err)
+		looked <- id
+	}()
+
+	msg := ubusd.receive()
+	require.Equal(t, uint8(msgLookup), msg.typ)
+	require.Equal(t, "service", string(trimNul(msg.attr(attrObjPath))))
+
+	var buf []byte
+	buf = appendBlobString(buf, attrObjPath, "service")
+	buf = appendBlobUint32(buf, attrObjID, 0x77)
+	ubusd.send(msgData, msg.seq, 0, buf)
+	ubusd.send(msgStatus, msg.seq, 0, appendBlobUint32(nil, attrStatus, StatusOK))
+	require.Equal(t, uint32(0x77), <-looked)
+
+	// call one of its methods
+	invoked := make(chan map[string]any, 1)
+	go func() {
+		reply, err := conn.Invoke(0x77, "watchdog", map[string]any{"name": "netbird", "instance": "instance1"})
+		require.NoError(t, err)
+		invoked <- reply
+	}()
+
+	msg = ubusd.receive()
+	require.Equal(t, uint8(msgInvoke), msg.typ)
+	require.Equal(t, uint32(0x77), msg.peer)
+	require.Equal(t, "watchdog", string(trimNul(msg.attr(attrMethod))))
+	args, err := decodeTable(msg.attr(attrData))
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"name": "netbird", "instance": "instance1"}, args)
+
+	data, err := encodeTable(map[string]any{"status": int64(1)})
+	require.NoError(t, err)
+	ubusd.send(msgData, msg.seq, 0, appendBlobAttr(nil, attrData, false, data))
+	ubusd.send(msgStatus, msg.seq, 0, appendBlobUint32(nil, attrStatus, StatusOK))
+	require.Equal(t, map[string]any{"status": int64(1)}, <-invoked)
+}
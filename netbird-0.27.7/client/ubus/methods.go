@@ -0,0 +1,124 @@
+package ubus
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/netbirdio/netbird/client/proto"
+)
+
+var errConnectionLost = errors.New("connection to ubus lost")
+
+// methods returns the handlers of the methods of the netbird object
+func (s *Service) methods() map[string]Handler {
+	return map[string]Handler{
+		"up":     s.up,
+		"down":   s.down,
+		"status": s.status,
+		"peers":  s.peers,
+		"routes": s.routes,
+	}
+}
+
+func (s *Service) up(ctx context.Context, _ map[string]any) (map[string]any, error) {
+	if _, err := s.daemon.Up(ctx, &proto.UpRequest{}); err != nil {
+		return nil, err
+	}
+	return map[string]any{}, nil
+}
+
+func (s *Service) down(ctx context.Context, _ map[string]any) (map[string]any, error) {
+	if _, err := s.daemon.Down(ctx, &proto.DownRequest{}); err != nil {
+		return nil, err
+	}
+	return map[string]any{}, nil
+}
+
+func (s *Service) status(ctx context.Context, _ map[string]any) (map[string]any, error) {
+	resp, err := s.daemon.Status(ctx, &proto.StatusRequest{GetFullPeerStatus: true})
+	if err != nil {
+		return nil, err
+	}
+
+	full := resp.GetFullStatus()
+	connected := 0
+	for _, p := range full.GetPeers() {
+		if p.GetConnStatus() == "Connected" {
+			connected++
+		}
+	}
+
+	local := full.GetLocalPeerState()
+	return map[string]any{
+		"status":  resp.GetStatus(),
+		"version": resp.GetDaemonVersion(),
+		"ip":      local.GetIP(),
+		"fqdn":    local.GetFqdn(),
+		"pubkey":  local.GetPubKey(),
+		"kernel":  local.GetKernelInterface(),
+		"management": map[string]any{
+			"url":       full.GetManagementState().GetURL(),
+			"connected": full.GetManagementState().GetConnected(),
+			"error":     full.GetManagementState().GetError(),
+		},
+		"signal": map[string]any{
+			"url":       full.GetSignalState().GetURL(),
+			"connected": full.GetSignalState().GetConnected(),
+			"error":     full.GetSignalState().GetError(),
+		},
+		"peers_connected": connected,
+		"peers_total":     len(full.GetPeers()),
+	}, nil
+}
+
+func (s *Service) peers(ctx context.Context, _ map[string]any) (map[string]any, error) {
+	resp, err := s.daemon.Status(ctx, &proto.StatusRequest{GetFullPeerStatus: true})
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make([]map[string]any, 0, len(resp.GetFullStatus().GetPeers()))
+	for _, p := range resp.GetFullStatus().GetPeers() {
+		peer := map[string]any{
+			"ip":       p.GetIP(),
+			"fqdn":     p.GetFqdn(),
+			"pubkey":   p.GetPubKey(),
+			"status":   p.GetConnStatus(),
+			"direct":   p.GetDirect(),
+			"relayed":  p.GetRelayed(),
+			"endpoint": p.GetWireguardEndpoint(),
+			"rx_bytes": p.GetBytesRx(),
+			"tx_bytes": p.GetBytesTx(),
+			"routes":   p.GetRoutes(),
+		}
+		if p.GetLatency() != nil {
+			peer["latency_ms"] = p.GetLatency().AsDuration().Milliseconds()
+		}
+		if handshake := p.GetLastWireguardHandshake(); handshake != nil && handshake.AsTime().Unix() > 0 {
+			peer["handshake_age"] = int64(time.Since(handshake.AsTime()).Seconds())
+		}
+		peers = append(peers, peer)
+	}
+
+	return map[string]any{"peers": peers}, nil
+}
+
+func (s *Service) routes(ctx context.Context, _ map[string]any) (map[string]any, error) {
+	resp, err := s.daemon.ListRoutes(ctx, &proto.ListRoutesRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]map[string]any, 0, len(resp.GetRoutes()))
+	for _, r := range resp.GetRoutes() {
+		routes = append(routes, map[string]any{
+			"id":       r.GetID(),
+			"network":  r.GetNetwork(),
+			"domains":  r.GetDomains(),
+			"selected": r.GetSelected(),
+		})
+	}
+
+	return map[string]any{"routes": routes}, nil
+}
@@ -0,0 +1,152 @@
+package ubus
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/client/proto"
+)
+
+const (
+	// ObjectName is the name of the ubus object of the client, e.g. ubus call netbird status. The objects of the
+	// client profiles are named netbird.<profile>
+	ObjectName = "netbird"
+
+	// eventState, eventAddress and eventPeers are the suffixes of the IDs of the events broadcast on the client
+	// changes, appended to the object name, e.g. ubus listen netbird.state
+	eventState   = "state"
+	eventAddress = "address"
+	eventPeers   = "peers"
+
+	// eventQueueSize is the number of events kept while the connection to the ubus daemon is down
+	eventQueueSize = 32
+)
+
+type event struct {
+	id   string
+	data map[string]any
+}
+
+// Service exposes the client operations of the daemon on a ubus object and broadcasts the connection changes it
+// is notified about as ubus events
+type Service struct {
+	daemon     proto.DaemonServiceServer
+	socketPath string
+	objectName string
+	events     chan event
+}
+
+// NewService returns a ubus service calling the daemon on the object objectName, it connects to the ubus daemon on
+// socketPath with Run
+func NewService(socketPath, objectName string, daemon proto.DaemonServiceServer) *Service {
+	return &Service{
+		daemon:     daemon,
+		socketPath: socketPath,
+		objectName: objectName,
+		events:     make(chan event, eventQueueSize),
+	}
+}
+
+// Run registers the object on the ubus daemon and serves its calls until ctx is done, connecting again when
+// the ubus daemon restarts
+func (s *Service) Run(ctx context.Context) {
+	bo := backoff.WithContext(&backoff.ExponentialBackOff{
+		InitialInterval:     time.Second,
+		RandomizationFactor: backoff.DefaultRandomizationFactor,
+		Multiplier:          backoff.DefaultMultiplier,
+		MaxInterval:         time.Minute,
+		MaxElapsedTime:      0,
+		Stop:                backoff.Stop,
+		Clock:               backoff.SystemClock,
+	}, ctx)
+
+	operation := func() error {
+		conn, err := Dial(s.socketPath)
+		if err != nil {
+			log.Debugf("failed to connect to ubus: %v", err)
+			return err
+		}
+		defer conn.Close()
+
+		if err := conn.AddObject(s.objectName, s.methods()); err != nil {
+			log.Warnf("failed to register the ubus object: %v", err)
+			return err
+		}
+		log.Infof("registered the ubus object %s", s.objectName)
+		bo.Reset()
+
+		s.sendEvents(ctx, conn)
+		if ctx.Err() != nil {
+			return backoff.Permanent(ctx.Err())
+		}
+		log.Warnf("lost the connection to ubus, reconnecting")
+		return errConnectionLost
+	}
+
+	_ = backoff.Retry(operation, bo)
+}
+
+// sendEvents broadcasts the queued events until ctx is done or the connection is closed
+func (s *Service) sendEvents(ctx context.Context, conn *Conn) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-conn.Done():
+			return
+		case e := <-s.events:
+			if err := conn.SendEvent(e.id, e.data); err != nil {
+				log.Debugf("failed to send ubus event: %v", err)
+			}
+		}
+	}
+}
+
+// queueEvent queues an event without blocking the notifier, the oldest event is dropped when the queue is full
+func (s *Service) queueEvent(id string, data map[string]any) {
+	e := event{id: s.objectName + "." + id, data: data}
+	for {
+		select {
+		case s.events <- e:
+			return
+		default:
+		}
+		select {
+		case <-s.events:
+		default:
+		}
+	}
+}
+
+// OnConnected implements peer.Listener
+func (s *Service) OnConnected() {
+	s.queueEvent(eventState, map[string]any{"state": "connected"})
+}
+
+// OnDisconnected implements peer.Listener
+func (s *Service) OnDisconnected() {
+	s.queueEvent(eventState, map[string]any{"state": "disconnected"})
+}
+
+// OnConnecting implements peer.Listener
+func (s *Service) OnConnecting() {
+	s.queueEvent(eventState, map[string]any{"state": "connecting"})
+}
+
+// OnDisconnecting implements peer.Listener
+func (s *Service) OnDisconnecting() {
+	s.queueEvent(eventState, map[string]any{"state": "disconnecting"})
+}
+
+// OnAddressChanged implements peer.Listener
+func (s *Service) OnAddressChanged(fqdn, ip string) {
+	s.queueEvent(eventAddress, map[string]any{"fqdn": fqdn, "ip": ip})
+}
+
+// OnPeersListChanged implements peer.Listener
+func (s *Service) OnPeersListChanged(numOfPeers int) {
+	s.queueEvent(eventPeers, map[string]any{"count": numOfPeers})
+}
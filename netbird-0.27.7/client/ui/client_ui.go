@@ -142,6 +142,8 @@ type serviceClient struct {
 	wSettings        fyne.Window
 	showSettings     bool
 	sendNotification bool
+	// loginExpirationWarning is the lead time of the latest login expiration warning the user was notified about
+	loginExpirationWarning time.Duration
 
 	// input elements for settings form
 	iMngURL       *widget.Entry
@@ -396,7 +398,7 @@ func (s *serviceClient) updateStatus() error {
 		return err
 	}
 	err = backoff.Retry(func() error {
-		status, err := conn.Status(s.ctx, &proto.StatusRequest{})
+		status, err := conn.Status(s.ctx, &proto.StatusRequest{GetFullPeerStatus: true})
 		if err != nil {
 			log.Errorf("get service status: %v", err)
 			return err
@@ -410,6 +412,9 @@ func (s *serviceClient) updateStatus() error {
 			s.onSessionExpire()
 		}
 
+		// warn the user before the login of the peer expires
+		s.onLoginExpirationWarning(status.GetFullStatus().GetLocalPeerState())
+
 		var systrayIconState bool
 		if status.Status == string(internal.StatusConnected) && !s.mUp.Disabled() {
 			s.connected = true
@@ -699,6 +704,30 @@ func (s *serviceClient) onSessionExpire() {
 	}
 }
 
+// onLoginExpirationWarning sends a notification to the user once per login expiration warning reached.
+func (s *serviceClient) onLoginExpirationWarning(localPeerState *proto.LocalPeerState) {
+	warning := localPeerState.GetLoginExpirationWarning().AsDuration()
+	if warning == s.loginExpirationWarning {
+		return
+	}
+	s.loginExpirationWarning = warning
+	if warning == 0 {
+		return
+	}
+
+	title := "Login expires soon"
+	if runtime.GOOS == "darwin" {
+		title = "NetBird login expires soon"
+	}
+	expiresIn := time.Until(localPeerState.GetLoginExpiresAt().AsTime()).Round(time.Minute)
+	s.app.SendNotification(
+		fyne.NewNotification(
+			title,
+			fmt.Sprintf("The login of this peer expires in %s, please re-authenticate to stay connected", expiresIn),
+		),
+	)
+}
+
 func openURL(url string) error {
 	var err error
 	switch runtime.GOOS {
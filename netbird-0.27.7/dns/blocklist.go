@@ -0,0 +1,72 @@
+package dns
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	// DefaultBlocklistRefreshInterval is how often the peers download the deny lists when no interval is configured
+	DefaultBlocklistRefreshInterval = 24 * time.Hour
+	// MinBlocklistRefreshInterval is the shortest refresh interval accepted for the deny lists
+	MinBlocklistRefreshInterval = 15 * time.Minute
+)
+
+// BlockResponse is how the resolver answers the queries of the blocked domains
+type BlockResponse string
+
+const (
+	// BlockResponseNXDomain answers the blocked domains with NXDOMAIN
+	BlockResponseNXDomain BlockResponse = "nxdomain"
+	// BlockResponseZeroIP answers the blocked domains with 0.0.0.0 and ::
+	BlockResponseZeroIP BlockResponse = "zero_ip"
+)
+
+// Blocklist is a hosts-format deny list downloaded by the peers
+type Blocklist struct {
+	// Name of the list
+	Name string
+	// URL the list is downloaded from, over HTTP or HTTPS
+	URL string
+	// Enabled indicates if the list is enforced
+	Enabled bool
+}
+
+// Validate checks the name and the URL of the list
+func (b Blocklist) Validate() error {
+	if b.Name == "" {
+		return fmt.Errorf("blocklist name shouldn't be empty")
+	}
+
+	parsed, err := url.Parse(b.URL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %s of blocklist %s: %w", b.URL, b.Name, err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid URL %s of blocklist %s: only HTTP and HTTPS URLs are supported", b.URL, b.Name)
+	}
+	return nil
+}
+
+// BlocklistConfig is the deny lists enforced by the resolver of a peer
+type BlocklistConfig struct {
+	// URLs of the hosts-format lists
+	URLs []string
+	// Response is how the blocked domains are answered, NXDOMAIN when empty
+	Response BlockResponse
+	// RefreshInterval is how often the lists are downloaded, DefaultBlocklistRefreshInterval when zero
+	RefreshInterval time.Duration
+}
+
+// ParseBlockResponse returns the block response of its string representation, NXDOMAIN for an empty string
+func ParseBlockResponse(response string) (BlockResponse, error) {
+	switch BlockResponse(response) {
+	case "", BlockResponseNXDomain:
+		return BlockResponseNXDomain, nil
+	case BlockResponseZeroIP:
+		return BlockResponseZeroIP, nil
+	default:
+		return "", fmt.Errorf("invalid block response %s", response)
+	}
+}
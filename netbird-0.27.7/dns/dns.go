@@ -31,6 +31,8 @@ type Config struct {
 	NameServerGroups []*NameServerGroup
 	// CustomZones contains a list of custom zone
 	CustomZones []CustomZone
+	// Blocklist contains the deny lists enforced by the resolver
+	Blocklist BlocklistConfig
 }
 
 // CustomZone represents a custom zone to be resolved by the dns server
@@ -41,11 +43,11 @@ type CustomZone struct {
 	Records []SimpleRecord
 }
 
-// SimpleRecord provides a simple DNS record specification for CNAME, A and AAAA records
+// SimpleRecord provides a simple DNS record specification for CNAME, A, AAAA, PTR and TXT records
 type SimpleRecord struct {
 	// Name domain name
 	Name string
-	// Type of record, 1 for A, 5 for CNAME, 28 for AAAA. see https://pkg.go.dev/github.com/miekg/dns@v1.1.41#pkg-constants
+	// Type of record, 1 for A, 5 for CNAME, 12 for PTR, 16 for TXT, 28 for AAAA. see https://pkg.go.dev/github.com/miekg/dns@v1.1.41#pkg-constants
 	Type int
 	// Class dns class, currently use the DefaultClass for all records
 	Class string
@@ -71,7 +73,7 @@ func (s SimpleRecord) Len() uint16 {
 			return 0
 		}
 		return net.IPv4len
-	case 5:
+	case 5, 12:
 		if emptyString || s.RData == "." {
 			return 1
 		}
@@ -1,9 +1,11 @@
 package dns
 
 import (
+	"cmp"
 	"fmt"
 	"net/netip"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -13,6 +15,10 @@ const (
 	InvalidNameServerType NameServerType = iota
 	// UDPNameServerType udp nameserver type
 	UDPNameServerType
+	// TLSNameServerType DNS over TLS nameserver type
+	TLSNameServerType
+	// HTTPSNameServerType DNS over HTTPS nameserver type
+	HTTPSNameServerType
 )
 
 const (
@@ -22,6 +28,18 @@ const (
 	InvalidNameServerTypeString = "invalid"
 	// UDPNameServerTypeString udp nameserver type as string
 	UDPNameServerTypeString = "udp"
+	// TLSNameServerTypeString DNS over TLS nameserver type as string
+	TLSNameServerTypeString = "tls"
+	// HTTPSNameServerTypeString DNS over HTTPS nameserver type as string
+	HTTPSNameServerTypeString = "https"
+	// DefaultDoHPath is the path of the DNS over HTTPS endpoint when none is configured
+	DefaultDoHPath = "/dns-query"
+	// WildcardDomainPrefix marks a match domain that applies to the subdomains only, e.g., *.corp.example.com
+	WildcardDomainPrefix = "*."
+	// NegatedDomainPrefix marks a match domain that must not be forwarded to the nameserver group, e.g., !public.corp.example.com
+	NegatedDomainPrefix = "!"
+	// MaxNameServerGroupPriority is the highest priority value of a nameserver group, lower values take precedence
+	MaxNameServerGroupPriority = 9999
 )
 
 // NameServerType nameserver type
@@ -32,6 +50,10 @@ func (n NameServerType) String() string {
 	switch n {
 	case UDPNameServerType:
 		return UDPNameServerTypeString
+	case TLSNameServerType:
+		return TLSNameServerTypeString
+	case HTTPSNameServerType:
+		return HTTPSNameServerTypeString
 	default:
 		return InvalidNameServerTypeString
 	}
@@ -42,6 +64,10 @@ func ToNameServerType(typeString string) NameServerType {
 	switch typeString {
 	case UDPNameServerTypeString:
 		return UDPNameServerType
+	case TLSNameServerTypeString:
+		return TLSNameServerType
+	case HTTPSNameServerTypeString:
+		return HTTPSNameServerType
 	default:
 		return InvalidNameServerType
 	}
@@ -69,6 +95,9 @@ type NameServerGroup struct {
 	Enabled bool
 	// SearchDomainsEnabled indicates whether to add match domains to search domains list or not
 	SearchDomainsEnabled bool
+	// Priority orders the nameserver groups, a lower value takes precedence for the domains shared with other groups
+	// and puts the search domains of the group first
+	Priority int
 }
 
 // NameServer represents a DNS nameserver
@@ -79,6 +108,12 @@ type NameServer struct {
 	NSType NameServerType
 	// Port nameserver listening port
 	Port int
+	// ServerName is the name the certificate of an encrypted nameserver is verified against, the IP when empty
+	ServerName string
+	// SPKIPins are base64 encoded SHA-256 hashes of the certificate public keys accepted from an encrypted nameserver
+	SPKIPins []string
+	// Path is the DNS over HTTPS endpoint path
+	Path string
 }
 
 // EventMeta returns activity event meta related to the nameserver group
@@ -88,21 +123,73 @@ func (g *NameServerGroup) EventMeta() map[string]any {
 
 // Copy copies a nameserver object
 func (n *NameServer) Copy() *NameServer {
-	return &NameServer{
-		IP:     n.IP,
-		NSType: n.NSType,
-		Port:   n.Port,
+	ns := &NameServer{
+		IP:         n.IP,
+		NSType:     n.NSType,
+		Port:       n.Port,
+		ServerName: n.ServerName,
+		Path:       n.Path,
 	}
+	if n.SPKIPins != nil {
+		ns.SPKIPins = make([]string, len(n.SPKIPins))
+		copy(ns.SPKIPins, n.SPKIPins)
+	}
+	return ns
 }
 
 // IsEqual compares one nameserver with the other
 func (n *NameServer) IsEqual(other *NameServer) bool {
 	return other.IP == n.IP &&
 		other.NSType == n.NSType &&
-		other.Port == n.Port
+		other.Port == n.Port &&
+		other.ServerName == n.ServerName &&
+		other.Path == n.Path &&
+		slices.Equal(other.SPKIPins, n.SPKIPins)
+}
+
+// IsEncrypted returns true for the DNS over TLS and DNS over HTTPS nameservers
+func (n *NameServer) IsEncrypted() bool {
+	return n.NSType == TLSNameServerType || n.NSType == HTTPSNameServerType
+}
+
+// MatchDomain is a parsed nameserver group match domain
+type MatchDomain struct {
+	// Zone is the domain without the wildcard or negation prefixes
+	Zone string
+	// Wildcard indicates that only the subdomains of the zone match
+	Wildcard bool
+	// Negated indicates that the zone and its subdomains are excluded from the group
+	Negated bool
 }
 
-// ParseNameServerURL parses a nameserver url in the format <type>://<ip>:<port>, e.g., udp://1.1.1.1:53
+// ParseMatchDomain parses a nameserver group domain entry, supporting the wildcard and the negation prefixes
+func ParseMatchDomain(domain string) MatchDomain {
+	var match MatchDomain
+	if strings.HasPrefix(domain, NegatedDomainPrefix) {
+		match.Negated = true
+		domain = strings.TrimPrefix(domain, NegatedDomainPrefix)
+	}
+	if strings.HasPrefix(domain, WildcardDomainPrefix) {
+		match.Wildcard = true
+		domain = strings.TrimPrefix(domain, WildcardDomainPrefix)
+	}
+	match.Zone = domain
+	return match
+}
+
+// SortNameServerGroups sorts the nameserver groups by priority, the groups with the same priority by ID.
+// The sort is stable, so groups without IDs keep their order within a priority
+func SortNameServerGroups(groups []*NameServerGroup) {
+	slices.SortStableFunc(groups, func(a, b *NameServerGroup) int {
+		if a.Priority != b.Priority {
+			return cmp.Compare(a.Priority, b.Priority)
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+}
+
+// ParseNameServerURL parses a nameserver url in the format <type>://<ip>:<port>[/path], e.g., udp://1.1.1.1:53
+// or https://1.1.1.1:443/dns-query
 func ParseNameServerURL(nsURL string) (NameServer, error) {
 	parsedURL, err := url.Parse(nsURL)
 	if err != nil {
@@ -129,6 +216,10 @@ func ParseNameServerURL(nsURL string) (NameServer, error) {
 
 	ns.IP = parsedAddr
 
+	if nsType == HTTPSNameServerType {
+		ns.Path = parsedURL.Path
+	}
+
 	return ns, nil
 }
 
@@ -144,9 +235,12 @@ func (g *NameServerGroup) Copy() *NameServerGroup {
 		Primary:              g.Primary,
 		Domains:              make([]string, len(g.Domains)),
 		SearchDomainsEnabled: g.SearchDomainsEnabled,
+		Priority:             g.Priority,
 	}
 
-	copy(nsGroup.NameServers, g.NameServers)
+	for i, ns := range g.NameServers {
+		nsGroup.NameServers[i] = *ns.Copy()
+	}
 	copy(nsGroup.Groups, g.Groups)
 	copy(nsGroup.Domains, g.Domains)
 
@@ -160,6 +254,7 @@ func (g *NameServerGroup) IsEqual(other *NameServerGroup) bool {
 		other.Description == g.Description &&
 		other.Primary == g.Primary &&
 		other.SearchDomainsEnabled == g.SearchDomainsEnabled &&
+		other.Priority == g.Priority &&
 		compareNameServerList(g.NameServers, other.NameServers) &&
 		compareGroupsList(g.Groups, other.Groups) &&
 		compareGroupsList(g.Domains, other.Domains)
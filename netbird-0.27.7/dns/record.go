@@ -0,0 +1,141 @@
+package dns
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// RecordTypeA IPv4 address record type
+	RecordTypeA = "A"
+	// RecordTypeAAAA IPv6 address record type
+	RecordTypeAAAA = "AAAA"
+	// RecordTypeCNAME canonical name record type
+	RecordTypeCNAME = "CNAME"
+	// RecordTypeTXT text record type
+	RecordTypeTXT = "TXT"
+	// ZoneApex is the record name used for records at the zone apex
+	ZoneApex = "@"
+	// maxTXTStringLen maximum length of a single character-string in a TXT record
+	maxTXTStringLen = 255
+)
+
+// CustomRecord is a static dns record managed by the account administrators
+type CustomRecord struct {
+	// ID identifier of the record
+	ID string `gorm:"primaryKey"`
+	// AccountID is a reference to Account that this object belongs
+	AccountID string `gorm:"index"`
+	// Name record name relative to its zone, ZoneApex for the zone itself
+	Name string
+	// Zone the record belongs to, empty for the account dns domain
+	Zone string
+	// Type record type, one of A, AAAA, CNAME and TXT
+	Type string
+	// Value is an address for A and AAAA records, a domain name for CNAME records and free text for TXT records
+	Value string
+	// TTL time-to-live of the record in seconds
+	TTL int
+	// Enabled record status
+	Enabled bool
+}
+
+// RecordTypeFromString returns the dns type of the supported custom record types or dns.TypeNone
+func RecordTypeFromString(recordType string) uint16 {
+	switch strings.ToUpper(recordType) {
+	case RecordTypeA:
+		return dns.TypeA
+	case RecordTypeAAAA:
+		return dns.TypeAAAA
+	case RecordTypeCNAME:
+		return dns.TypeCNAME
+	case RecordTypeTXT:
+		return dns.TypeTXT
+	default:
+		return dns.TypeNone
+	}
+}
+
+// ZoneFQDN returns the fully qualified zone of the record, falling back to the default zone when the record has none
+func (r *CustomRecord) ZoneFQDN(defaultZone string) string {
+	zone := r.Zone
+	if zone == "" {
+		zone = defaultZone
+	}
+	if zone == "" {
+		return ""
+	}
+	return dns.Fqdn(strings.ToLower(zone))
+}
+
+// FQDN returns the fully qualified record name, or an empty string when the record has no zone
+func (r *CustomRecord) FQDN(defaultZone string) string {
+	zone := r.ZoneFQDN(defaultZone)
+	if zone == "" {
+		return ""
+	}
+	if r.Name == ZoneApex || r.Name == "" {
+		return zone
+	}
+	return strings.ToLower(r.Name) + "." + zone
+}
+
+// ToSimpleRecord converts the record to the representation distributed to peers
+func (r *CustomRecord) ToSimpleRecord(defaultZone string) SimpleRecord {
+	rData := r.Value
+	switch RecordTypeFromString(r.Type) {
+	case dns.TypeCNAME:
+		rData = dns.Fqdn(strings.ToLower(r.Value))
+	case dns.TypeTXT:
+		rData = quoteTXT(r.Value)
+	}
+
+	return SimpleRecord{
+		Name:  r.FQDN(defaultZone),
+		Type:  int(RecordTypeFromString(r.Type)),
+		Class: DefaultClass,
+		TTL:   r.TTL,
+		RData: rData,
+	}
+}
+
+// EventMeta returns activity event meta related to the record
+func (r *CustomRecord) EventMeta() map[string]any {
+	return map[string]any{"name": r.Name, "zone": r.Zone, "type": r.Type}
+}
+
+// Copy copies a custom record object
+func (r *CustomRecord) Copy() *CustomRecord {
+	return &CustomRecord{
+		ID:        r.ID,
+		AccountID: r.AccountID,
+		Name:      r.Name,
+		Zone:      r.Zone,
+		Type:      r.Type,
+		Value:     r.Value,
+		TTL:       r.TTL,
+		Enabled:   r.Enabled,
+	}
+}
+
+// quoteTXT formats a text value in the zone file presentation format,
+// splitting it into character-strings of at most 255 bytes
+func quoteTXT(value string) string {
+	var parts []string
+	for {
+		chunk := value
+		if len(chunk) > maxTXTStringLen {
+			chunk = chunk[:maxTXTStringLen]
+		}
+		value = value[len(chunk):]
+
+		escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(chunk)
+		parts = append(parts, `"`+escaped+`"`)
+
+		if value == "" {
+			break
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,49 @@
+package dns
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// ReverseZoneSuffix is the parent zone of the IPv4 reverse zones
+	ReverseZoneSuffix = "in-addr.arpa."
+	// reverseZoneIPv6Suffix is the parent zone of the IPv6 reverse zones
+	reverseZoneIPv6Suffix = "ip6.arpa."
+)
+
+// ReverseZone returns the reverse zone covering the IPv4 network. Classless networks get the zone of the enclosing
+// octet boundary, e.g. 64.100.in-addr.arpa. for 100.64.0.0/16 and 100.in-addr.arpa. for 100.64.0.0/10
+func ReverseZone(network netip.Prefix) (string, error) {
+	if !network.Addr().Is4() {
+		return "", fmt.Errorf("reverse zone of %s: only IPv4 networks are supported", network)
+	}
+
+	octets := network.Bits() / 8
+	if octets == 0 {
+		return "", fmt.Errorf("reverse zone of %s: the network is too large", network)
+	}
+
+	addr := network.Masked().Addr().As4()
+	labels := make([]string, 0, octets+1)
+	for i := octets - 1; i >= 0; i-- {
+		labels = append(labels, fmt.Sprintf("%d", addr[i]))
+	}
+	labels = append(labels, ReverseZoneSuffix)
+
+	return strings.Join(labels, "."), nil
+}
+
+// ReverseName returns the PTR record name of the IP, e.g. 1.0.64.100.in-addr.arpa. for 100.64.0.1
+func ReverseName(ip netip.Addr) (string, error) {
+	return dns.ReverseAddr(ip.Unmap().String())
+}
+
+// IsReverseZone returns true for the zones of the reverse DNS trees
+func IsReverseZone(zone string) bool {
+	zone = strings.ToLower(dns.Fqdn(zone))
+	return dns.IsSubDomain(ReverseZoneSuffix, zone) || dns.IsSubDomain(reverseZoneIPv6Suffix, zone)
+}
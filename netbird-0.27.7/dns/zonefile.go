@@ -0,0 +1,40 @@
+package dns
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	zoneFileTTL     = 300
+	zoneFileRefresh = 3600
+	zoneFileRetry   = 600
+	zoneFileExpire  = 86400
+)
+
+// WriteZoneFile writes the zone in the RFC 1035 master file format, starting with an SOA record carrying the serial
+func WriteZoneFile(w io.Writer, zone CustomZone, serial uint32) error {
+	origin := dns.Fqdn(zone.Domain)
+	soa := &dns.SOA{
+		Hdr:     dns.RR_Header{Name: origin, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: zoneFileTTL},
+		Ns:      origin,
+		Mbox:    "hostmaster." + origin,
+		Serial:  serial,
+		Refresh: zoneFileRefresh,
+		Retry:   zoneFileRetry,
+		Expire:  zoneFileExpire,
+		Minttl:  zoneFileTTL,
+	}
+
+	if _, err := fmt.Fprintf(w, "$ORIGIN %s\n%s\n", origin, soa.String()); err != nil {
+		return fmt.Errorf("write zone %s: %w", origin, err)
+	}
+	for _, record := range zone.Records {
+		if _, err := fmt.Fprintln(w, record.String()); err != nil {
+			return fmt.Errorf("write zone %s: %w", origin, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,413 @@
+// Package acmedns issues and renews Let's Encrypt certificates with the DNS-01 challenge, for servers the CA can't reach
+// on the HTTP or TLS-ALPN challenges, e.g. behind CGNAT.
+package acmedns
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/acme"
+
+	"github.com/netbirdio/netbird/util"
+)
+
+const (
+	defaultPropagationTimeout = 2 * time.Minute
+	propagationCheckInterval  = 5 * time.Second
+	// renewBefore is how long before its expiration the certificate is renewed, Let's Encrypt issues them for 90 days
+	renewBefore         = 30 * 24 * time.Hour
+	renewRetryInterval  = time.Hour
+	accountKeyFile      = "dns01-account.key"
+	challengeRecordName = "_acme-challenge."
+)
+
+// Config configures the certificate issued with the DNS-01 challenge
+type Config struct {
+	// Provider creates the challenge records: cloudflare or rfc2136
+	Provider string
+	// Email is the contact of the ACME account, optional
+	Email string
+	// DirectoryURL is the directory of the ACME CA. Defaults to Let's Encrypt
+	DirectoryURL string
+	// PropagationTimeout is how long the challenge record is waited for to be resolvable before the CA is asked to
+	// validate it. Defaults to 2 minutes
+	PropagationTimeout util.Duration
+	// Cloudflare configures the cloudflare provider
+	Cloudflare *CloudflareConfig
+	// RFC2136 configures the rfc2136 provider
+	RFC2136 *RFC2136Config
+}
+
+// Provider creates and removes the TXT records of the challenges
+type Provider interface {
+	// Present creates the TXT record fqdn with the value
+	Present(ctx context.Context, fqdn, value string) error
+	// CleanUp removes the TXT record fqdn with the value
+	CleanUp(ctx context.Context, fqdn, value string) error
+}
+
+// NewProvider creates the provider of the config
+func NewProvider(cfg Config) (Provider, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "cloudflare":
+		if cfg.Cloudflare == nil {
+			return nil, errors.New("the cloudflare provider requires the Cloudflare config")
+		}
+		return NewCloudflareProvider(*cfg.Cloudflare)
+	case "rfc2136":
+		if cfg.RFC2136 == nil {
+			return nil, errors.New("the rfc2136 provider requires the RFC2136 config")
+		}
+		return NewRFC2136Provider(*cfg.RFC2136)
+	default:
+		return nil, fmt.Errorf("unsupported DNS provider %q, supported are cloudflare and rfc2136", cfg.Provider)
+	}
+}
+
+// Manager serves the certificate of a domain, issued with the DNS-01 challenge and renewed before it expires
+type Manager struct {
+	domain             string
+	certDir            string
+	email              string
+	directoryURL       string
+	propagationTimeout time.Duration
+	provider           Provider
+	// lookupTXT resolves the challenge records to check they have propagated
+	lookupTXT func(ctx context.Context, name string) ([]string, error)
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// NewManager creates the certificate manager of the domain. The certificate and the ACME account key are stored in
+// the letsencrypt directory of datadir.
+func NewManager(datadir, domain string, cfg Config) (*Manager, error) {
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	certDir := filepath.Join(datadir, "letsencrypt")
+	if err := os.MkdirAll(certDir, 0700); err != nil {
+		return nil, err
+	}
+
+	propagationTimeout := cfg.PropagationTimeout.Duration
+	if propagationTimeout <= 0 {
+		propagationTimeout = defaultPropagationTimeout
+	}
+
+	log.Infof("running with LetsEncrypt DNS-01 challenge (%s) through %s. Cert will be stored in %s", domain, cfg.Provider, certDir)
+
+	return &Manager{
+		domain:             domain,
+		certDir:            certDir,
+		email:              cfg.Email,
+		directoryURL:       cfg.DirectoryURL,
+		propagationTimeout: propagationTimeout,
+		provider:           provider,
+		lookupTXT:          net.DefaultResolver.LookupTXT,
+	}, nil
+}
+
+// Start loads the stored certificate and obtains a new one when there is none or it expires soon, then renews it in the
+// background until the context is done. A stored certificate that is still valid is served when the renewal fails.
+func (m *Manager) Start(ctx context.Context) error {
+	if err := m.load(); err != nil {
+		log.Infof("no stored certificate for %s: %v", m.domain, err)
+	}
+
+	next := m.renewAt()
+	if !time.Now().Before(next) {
+		if err := m.obtain(ctx); err != nil {
+			if !m.valid() {
+				return fmt.Errorf("obtain certificate for %s: %w", m.domain, err)
+			}
+			log.Errorf("failed renewing the certificate for %s, retrying in %s: %v", m.domain, renewRetryInterval, err)
+			next = time.Now().Add(renewRetryInterval)
+		} else {
+			next = m.renewAt()
+		}
+	}
+
+	go m.renew(ctx, next)
+	return nil
+}
+
+// TLSConfig returns a TLS config serving the certificate of the manager
+func (m *Manager) TLSConfig() *tls.Config {
+	return &tls.Config{
+		GetCertificate: m.GetCertificate,
+		NextProtos: []string{
+			"h2", "http/1.1", // enable HTTP/2
+		},
+	}
+}
+
+// GetCertificate returns the latest certificate
+func (m *Manager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.cert == nil {
+		return nil, fmt.Errorf("no certificate for %s yet", m.domain)
+	}
+	return m.cert, nil
+}
+
+func (m *Manager) renew(ctx context.Context, next time.Time) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		if err := m.obtain(ctx); err != nil {
+			log.Errorf("failed renewing the certificate for %s, retrying in %s: %v", m.domain, renewRetryInterval, err)
+			next = time.Now().Add(renewRetryInterval)
+			continue
+		}
+		next = m.renewAt()
+	}
+}
+
+// renewAt returns when the current certificate has to be renewed
+func (m *Manager) renewAt() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.cert == nil || m.cert.Leaf == nil {
+		return time.Now()
+	}
+	return m.cert.Leaf.NotAfter.Add(-renewBefore)
+}
+
+// valid returns whether the current certificate hasn't expired yet
+func (m *Manager) valid() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cert != nil && m.cert.Leaf != nil && time.Now().Before(m.cert.Leaf.NotAfter)
+}
+
+func (m *Manager) certFile() string {
+	return filepath.Join(m.certDir, "dns01-"+strings.ReplaceAll(m.domain, "*", "wildcard")+".pem")
+}
+
+// load loads the stored certificate
+func (m *Manager) load() error {
+	data, err := os.ReadFile(m.certFile())
+	if err != nil {
+		return err
+	}
+	cert, err := tls.X509KeyPair(data, data)
+	if err != nil {
+		return err
+	}
+	return m.setCertificate(&cert)
+}
+
+func (m *Manager) setCertificate(cert *tls.Certificate) error {
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return err
+	}
+	if err := leaf.VerifyHostname(strings.Replace(m.domain, "*", "wildcard", 1)); err != nil {
+		return err
+	}
+	cert.Leaf = leaf
+
+	m.mu.Lock()
+	m.cert = cert
+	m.mu.Unlock()
+	return nil
+}
+
+// obtain orders a certificate for the domain, fulfills its DNS-01 challenges and stores the issued certificate
+func (m *Manager) obtain(ctx context.Context) error {
+	accountKey, err := m.accountKey()
+	if err != nil {
+		return fmt.Errorf("load ACME account key: %w", err)
+	}
+	client := &acme.Client{Key: accountKey, DirectoryURL: m.directoryURL}
+
+	account := &acme.Account{}
+	if m.email != "" {
+		account.Contact = []string{"mailto:" + m.email}
+	}
+	if _, err := client.Register(ctx, account, acme.AcceptTOS); err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return fmt.Errorf("register ACME account: %w", err)
+	}
+
+	order, err := client.AuthorizeOrder(ctx, acme.DomainIDs(m.domain))
+	if err != nil {
+		return fmt.Errorf("create order: %w", err)
+	}
+	for _, authzURL := range order.AuthzURLs {
+		if err := m.authorize(ctx, client, authzURL); err != nil {
+			return err
+		}
+	}
+	if _, err := client.WaitOrder(ctx, order.URI); err != nil {
+		return fmt.Errorf("wait for order: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: []string{m.domain}}, key)
+	if err != nil {
+		return err
+	}
+	der, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return fmt.Errorf("finalize order: %w", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	for _, c := range der {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c})...)
+	}
+
+	cert, err := tls.X509KeyPair(data, data)
+	if err != nil {
+		return err
+	}
+	if err := m.setCertificate(&cert); err != nil {
+		return err
+	}
+	if err := writeFile(m.certFile(), data); err != nil {
+		return fmt.Errorf("store certificate: %w", err)
+	}
+
+	log.Infof("obtained a certificate for %s valid until %s", m.domain, cert.Leaf.NotAfter)
+	return nil
+}
+
+// authorize fulfills the DNS-01 challenge of an authorization of the order
+func (m *Manager) authorize(ctx context.Context, client *acme.Client, authzURL string) error {
+	authz, err := client.GetAuthorization(ctx, authzURL)
+	if err != nil {
+		return fmt.Errorf("get authorization: %w", err)
+	}
+	if authz.Status == acme.StatusValid {
+		return nil
+	}
+
+	var challenge *acme.Challenge
+	for _, c := range authz.Challenges {
+		if c.Type == "dns-01" {
+			challenge = c
+			break
+		}
+	}
+	if challenge == nil {
+		return fmt.Errorf("the CA offers no DNS-01 challenge for %s", authz.Identifier.Value)
+	}
+
+	value, err := client.DNS01ChallengeRecord(challenge.Token)
+	if err != nil {
+		return err
+	}
+	fqdn := challengeRecordName + strings.TrimSuffix(authz.Identifier.Value, ".") + "."
+
+	if err := m.provider.Present(ctx, fqdn, value); err != nil {
+		return fmt.Errorf("create challenge record %s: %w", fqdn, err)
+	}
+	defer func() {
+		if err := m.provider.CleanUp(ctx, fqdn, value); err != nil {
+			log.Warnf("failed removing the challenge record %s: %v", fqdn, err)
+		}
+	}()
+
+	m.waitPropagation(ctx, fqdn, value)
+
+	if _, err := client.Accept(ctx, challenge); err != nil {
+		return fmt.Errorf("accept challenge: %w", err)
+	}
+	if _, err := client.WaitAuthorization(ctx, authz.URI); err != nil {
+		return fmt.Errorf("wait for authorization of %s: %w", authz.Identifier.Value, err)
+	}
+	return nil
+}
+
+// waitPropagation waits until the challenge record resolves or the propagation timeout passes. The CA is asked to
+// validate the record either way since the resolvers of the CA may see it before the local one.
+func (m *Manager) waitPropagation(ctx context.Context, fqdn, value string) {
+	ctx, cancel := context.WithTimeout(ctx, m.propagationTimeout)
+	defer cancel()
+
+	for {
+		values, err := m.lookupTXT(ctx, fqdn)
+		if err == nil {
+			for _, v := range values {
+				if v == value {
+					return
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Warnf("the challenge record %s hasn't propagated in %s", fqdn, m.propagationTimeout)
+			return
+		case <-time.After(propagationCheckInterval):
+		}
+	}
+}
+
+// accountKey loads the key of the ACME account or generates it on the first run
+func (m *Manager) accountKey() (crypto.Signer, error) {
+	path := filepath.Join(m.certDir, accountKeyFile)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no PEM key in %s", path)
+		}
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// writeFile replaces the file atomically, only readable by the user the server runs as
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
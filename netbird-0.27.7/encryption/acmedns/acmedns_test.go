@@ -0,0 +1,159 @@
+package acmedns
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/util"
+)
+
+type mockProvider struct{}
+
+func (mockProvider) Present(context.Context, string, string) error { return nil }
+func (mockProvider) CleanUp(context.Context, string, string) error { return nil }
+
+func newTestManager(t *testing.T, domain string) *Manager {
+	t.Helper()
+	certDir := t.TempDir()
+	return &Manager{
+		domain:             domain,
+		certDir:            certDir,
+		directoryURL:       "http://127.0.0.1:1/directory",
+		propagationTimeout: time.Second,
+		provider:           mockProvider{},
+		lookupTXT: func(context.Context, string) ([]string, error) {
+			return nil, nil
+		},
+	}
+}
+
+// storeCertificate stores a self-signed certificate of the domain valid for the duration
+func storeCertificate(t *testing.T, m *Manager, dnsName string, validFor time.Duration) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: dnsName},
+		DNSNames:     []string{dnsName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validFor),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	require.NoError(t, os.WriteFile(m.certFile(), data, 0600))
+}
+
+func TestNewProvider(t *testing.T) {
+	_, err := NewProvider(Config{Provider: "route53"})
+	assert.Error(t, err, "the provider is unsupported")
+
+	_, err = NewProvider(Config{Provider: "cloudflare"})
+	assert.Error(t, err, "the cloudflare config is missing")
+
+	_, err = NewProvider(Config{Provider: "rfc2136", RFC2136: &RFC2136Config{Nameserver: "127.0.0.1", TSIGKeyName: "acme"}})
+	assert.Error(t, err, "the TSIG key has no secret")
+
+	provider, err := NewProvider(Config{Provider: "Cloudflare", Cloudflare: &CloudflareConfig{APIToken: "token"}})
+	require.NoError(t, err)
+	assert.IsType(t, &CloudflareProvider{}, provider)
+
+	provider, err = NewProvider(Config{Provider: "rfc2136", RFC2136: &RFC2136Config{Nameserver: "127.0.0.1"}})
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:53", provider.(*RFC2136Provider).nameserver)
+}
+
+func TestNewManager(t *testing.T) {
+	datadir := t.TempDir()
+	m, err := NewManager(datadir, "mgmt.example.com", Config{
+		Provider:           "cloudflare",
+		Cloudflare:         &CloudflareConfig{APIToken: "token"},
+		PropagationTimeout: util.Duration{Duration: time.Minute},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, m.propagationTimeout)
+	assert.DirExists(t, filepath.Join(datadir, "letsencrypt"))
+
+	_, err = m.GetCertificate(nil)
+	assert.Error(t, err, "no certificate was obtained yet")
+}
+
+func TestManager_Start(t *testing.T) {
+	t.Run("serves the stored certificate", func(t *testing.T) {
+		m := newTestManager(t, "mgmt.example.com")
+		storeCertificate(t, m, "mgmt.example.com", 60*24*time.Hour)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		require.NoError(t, m.Start(ctx), "the CA shouldn't be contacted")
+
+		cert, err := m.TLSConfig().GetCertificate(nil)
+		require.NoError(t, err)
+		assert.Equal(t, "mgmt.example.com", cert.Leaf.DNSNames[0])
+		assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), m.renewAt(), time.Minute)
+	})
+
+	t.Run("serves the wildcard certificate", func(t *testing.T) {
+		m := newTestManager(t, "*.example.com")
+		storeCertificate(t, m, "*.example.com", 60*24*time.Hour)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		require.NoError(t, m.Start(ctx))
+	})
+
+	t.Run("keeps serving the stored certificate when the renewal fails", func(t *testing.T) {
+		m := newTestManager(t, "mgmt.example.com")
+		storeCertificate(t, m, "mgmt.example.com", 10*24*time.Hour)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		require.NoError(t, m.Start(ctx))
+
+		_, err := m.GetCertificate(nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("fails without a valid certificate", func(t *testing.T) {
+		m := newTestManager(t, "mgmt.example.com")
+		storeCertificate(t, m, "other.example.com", 60*24*time.Hour)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		assert.Error(t, m.Start(ctx), "the stored certificate is for another domain and the CA is unreachable")
+	})
+}
+
+func TestManager_waitPropagation(t *testing.T) {
+	m := newTestManager(t, "mgmt.example.com")
+	lookups := 0
+	m.lookupTXT = func(_ context.Context, name string) ([]string, error) {
+		assert.Equal(t, "_acme-challenge.mgmt.example.com.", name)
+		lookups++
+		return []string{"value"}, nil
+	}
+	m.propagationTimeout = time.Minute
+
+	start := time.Now()
+	m.waitPropagation(context.Background(), "_acme-challenge.mgmt.example.com.", "value")
+	assert.Less(t, time.Since(start), time.Second, "the record resolves right away")
+	assert.Equal(t, 1, lookups)
+}
@@ -0,0 +1,152 @@
+package acmedns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const cloudflareAPIURL = "https://api.cloudflare.com/client/v4"
+
+// CloudflareConfig configures the records created through the Cloudflare API
+type CloudflareConfig struct {
+	// APIToken is a token with the Zone.DNS edit permission on the zone of the domain
+	APIToken string
+	// ZoneID is the zone of the domain, looked up by the domain when empty
+	ZoneID string
+}
+
+// CloudflareProvider creates the challenge records through the Cloudflare API
+type CloudflareProvider struct {
+	apiURL string
+	token  string
+	zoneID string
+	client *http.Client
+}
+
+type cloudflareRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+}
+
+type cloudflareResponse struct {
+	Success bool                       `json:"success"`
+	Errors  []struct{ Message string } `json:"errors"`
+	Result  json.RawMessage            `json:"result"`
+}
+
+// NewCloudflareProvider creates the Cloudflare provider
+func NewCloudflareProvider(cfg CloudflareConfig) (*CloudflareProvider, error) {
+	if cfg.APIToken == "" {
+		return nil, errors.New("the cloudflare provider requires an API token")
+	}
+	return &CloudflareProvider{
+		apiURL: cloudflareAPIURL,
+		token:  cfg.APIToken,
+		zoneID: cfg.ZoneID,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Present creates the TXT record in the zone of the domain
+func (p *CloudflareProvider) Present(ctx context.Context, fqdn, value string) error {
+	zoneID, err := p.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	record := cloudflareRecord{Type: "TXT", Name: strings.TrimSuffix(fqdn, "."), Content: value, TTL: 120}
+	return p.do(ctx, http.MethodPost, "/zones/"+zoneID+"/dns_records", record, nil)
+}
+
+// CleanUp removes the TXT records with the value from the zone of the domain
+func (p *CloudflareProvider) CleanUp(ctx context.Context, fqdn, value string) error {
+	zoneID, err := p.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{"type": {"TXT"}, "name": {strings.TrimSuffix(fqdn, ".")}, "content": {value}}
+	var records []cloudflareRecord
+	if err := p.do(ctx, http.MethodGet, "/zones/"+zoneID+"/dns_records?"+query.Encode(), nil, &records); err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := p.do(ctx, http.MethodDelete, "/zones/"+zoneID+"/dns_records/"+record.ID, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findZone returns the configured zone or the closest zone of the account holding the domain
+func (p *CloudflareProvider) findZone(ctx context.Context, fqdn string) (string, error) {
+	if p.zoneID != "" {
+		return p.zoneID, nil
+	}
+
+	labels := strings.Split(strings.TrimSuffix(fqdn, "."), ".")
+	for i := 0; i < len(labels)-1; i++ {
+		var zones []struct{ ID string }
+		query := url.Values{"name": {strings.Join(labels[i:], ".")}}
+		if err := p.do(ctx, http.MethodGet, "/zones?"+query.Encode(), nil, &zones); err != nil {
+			return "", err
+		}
+		if len(zones) > 0 {
+			p.zoneID = zones[0].ID
+			return p.zoneID, nil
+		}
+	}
+	return "", fmt.Errorf("no cloudflare zone found for %s", fqdn)
+}
+
+func (p *CloudflareProvider) do(ctx context.Context, method, path string, body, result any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.apiURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var apiResp cloudflareResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return fmt.Errorf("decode cloudflare response of %s %s: %w", method, path, err)
+	}
+	if !apiResp.Success {
+		messages := make([]string, 0, len(apiResp.Errors))
+		for _, e := range apiResp.Errors {
+			messages = append(messages, e.Message)
+		}
+		return fmt.Errorf("cloudflare %s %s failed with status %d: %s", method, path, resp.StatusCode, strings.Join(messages, ", "))
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(apiResp.Result, result)
+}
@@ -0,0 +1,68 @@
+package acmedns
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloudflareProvider(t *testing.T) {
+	records := map[string]cloudflareRecord{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		var result any
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/zones":
+			// only the apex is a zone of the account
+			zones := []map[string]string{}
+			if r.URL.Query().Get("name") == "example.com" {
+				zones = append(zones, map[string]string{"id": "zone1"})
+			}
+			result = zones
+		case r.Method == http.MethodPost && r.URL.Path == "/zones/zone1/dns_records":
+			var record cloudflareRecord
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&record))
+			record.ID = "record1"
+			records[record.ID] = record
+			result = record
+		case r.Method == http.MethodGet && r.URL.Path == "/zones/zone1/dns_records":
+			found := []cloudflareRecord{}
+			for _, record := range records {
+				if record.Name == r.URL.Query().Get("name") && record.Content == r.URL.Query().Get("content") {
+					found = append(found, record)
+				}
+			}
+			result = found
+		case r.Method == http.MethodDelete && r.URL.Path == "/zones/zone1/dns_records/record1":
+			delete(records, "record1")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]any{"success": false, "errors": []map[string]string{{"message": "not found"}}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "result": result})
+	}))
+	defer server.Close()
+
+	provider, err := NewCloudflareProvider(CloudflareConfig{APIToken: "token"})
+	require.NoError(t, err)
+	provider.apiURL = server.URL
+
+	ctx := context.Background()
+	require.NoError(t, provider.Present(ctx, "_acme-challenge.mgmt.example.com.", "value"))
+	assert.Equal(t, "zone1", provider.zoneID)
+	require.Len(t, records, 1)
+	assert.Equal(t, cloudflareRecord{ID: "record1", Type: "TXT", Name: "_acme-challenge.mgmt.example.com", Content: "value", TTL: 120}, records["record1"])
+
+	require.NoError(t, provider.CleanUp(ctx, "_acme-challenge.mgmt.example.com.", "value"))
+	assert.Empty(t, records)
+
+	provider.zoneID = "missing"
+	assert.ErrorContains(t, provider.Present(ctx, "_acme-challenge.mgmt.example.com.", "value"), "not found")
+}
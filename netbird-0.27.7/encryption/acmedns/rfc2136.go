@@ -0,0 +1,133 @@
+package acmedns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const defaultTSIGAlgorithm = dns.HmacSHA256
+
+// RFC2136Config configures the records created with dynamic updates, e.g. to a BIND or Knot server of the domain.
+// dnsmasq doesn't accept dynamic updates.
+type RFC2136Config struct {
+	// Nameserver is the host:port of the server accepting the updates, e.g. 127.0.0.1:53
+	Nameserver string
+	// Zone is the zone updated, e.g. example.com. Looked up with a SOA query to the nameserver when empty
+	Zone string
+	// TSIGKeyName is the name of the key signing the updates, the updates are unsigned when empty
+	TSIGKeyName string
+	// TSIGAlgorithm is the algorithm of the key, e.g. hmac-sha512. Defaults to hmac-sha256
+	TSIGAlgorithm string
+	// TSIGSecret is the base64 secret of the key
+	TSIGSecret string
+}
+
+// RFC2136Provider creates the challenge records with RFC 2136 dynamic updates
+type RFC2136Provider struct {
+	nameserver    string
+	zone          string
+	tsigKeyName   string
+	tsigAlgorithm string
+	tsigSecret    string
+	client        *dns.Client
+}
+
+// NewRFC2136Provider creates the RFC 2136 provider
+func NewRFC2136Provider(cfg RFC2136Config) (*RFC2136Provider, error) {
+	if cfg.Nameserver == "" {
+		return nil, errors.New("the rfc2136 provider requires a nameserver")
+	}
+	nameserver := cfg.Nameserver
+	if _, _, err := net.SplitHostPort(nameserver); err != nil {
+		nameserver = net.JoinHostPort(nameserver, "53")
+	}
+
+	p := &RFC2136Provider{
+		nameserver: nameserver,
+		client:     &dns.Client{Timeout: 10 * time.Second},
+	}
+	if cfg.Zone != "" {
+		p.zone = dns.Fqdn(cfg.Zone)
+	}
+	if cfg.TSIGKeyName != "" {
+		if cfg.TSIGSecret == "" {
+			return nil, errors.New("the TSIG key requires a secret")
+		}
+		p.tsigKeyName = dns.Fqdn(strings.ToLower(cfg.TSIGKeyName))
+		p.tsigAlgorithm = defaultTSIGAlgorithm
+		if cfg.TSIGAlgorithm != "" {
+			p.tsigAlgorithm = dns.Fqdn(strings.ToLower(cfg.TSIGAlgorithm))
+		}
+		p.tsigSecret = cfg.TSIGSecret
+		p.client.TsigSecret = map[string]string{p.tsigKeyName: p.tsigSecret}
+	}
+	return p, nil
+}
+
+// Present adds the TXT record to the zone
+func (p *RFC2136Provider) Present(ctx context.Context, fqdn, value string) error {
+	return p.update(ctx, fqdn, value, true)
+}
+
+// CleanUp removes the TXT record from the zone
+func (p *RFC2136Provider) CleanUp(ctx context.Context, fqdn, value string) error {
+	return p.update(ctx, fqdn, value, false)
+}
+
+func (p *RFC2136Provider) update(ctx context.Context, fqdn, value string, insert bool) error {
+	zone, err := p.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	rr := &dns.TXT{
+		Hdr: dns.RR_Header{Name: dns.Fqdn(fqdn), Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 120},
+		Txt: []string{value},
+	}
+	msg := new(dns.Msg)
+	msg.SetUpdate(zone)
+	if insert {
+		msg.Insert([]dns.RR{rr})
+	} else {
+		msg.Remove([]dns.RR{rr})
+	}
+	if p.tsigKeyName != "" {
+		msg.SetTsig(p.tsigKeyName, p.tsigAlgorithm, 300, time.Now().Unix())
+	}
+
+	resp, _, err := p.client.ExchangeContext(ctx, msg, p.nameserver)
+	if err != nil {
+		return fmt.Errorf("update %s on %s: %w", fqdn, p.nameserver, err)
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("update %s on %s: %s", fqdn, p.nameserver, dns.RcodeToString[resp.Rcode])
+	}
+	return nil
+}
+
+// findZone returns the configured zone or the zone of the SOA record the nameserver answers for the domain
+func (p *RFC2136Provider) findZone(ctx context.Context, fqdn string) (string, error) {
+	if p.zone != "" {
+		return p.zone, nil
+	}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(fqdn), dns.TypeSOA)
+	resp, _, err := p.client.ExchangeContext(ctx, msg, p.nameserver)
+	if err != nil {
+		return "", fmt.Errorf("find zone of %s on %s: %w", fqdn, p.nameserver, err)
+	}
+	for _, rr := range append(resp.Answer, resp.Ns...) {
+		if soa, ok := rr.(*dns.SOA); ok {
+			p.zone = soa.Hdr.Name
+			return p.zone, nil
+		}
+	}
+	return "", fmt.Errorf("no zone found for %s on %s", fqdn, p.nameserver)
+}
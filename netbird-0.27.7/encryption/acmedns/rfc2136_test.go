@@ -0,0 +1,97 @@
+package acmedns
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTSIGSecret = "c2VjcmV0LXNlY3JldC1zZWNyZXQtc2VjcmV0"
+
+// startUpdateServer starts a nameserver of example.com accepting the updates signed with the acme key
+func startUpdateServer(t *testing.T) (string, func() map[string]string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	records := map[string]string{}
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		resp := new(dns.Msg)
+		resp.SetReply(r)
+
+		switch r.Opcode {
+		case dns.OpcodeQuery:
+			resp.Ns = []dns.RR{&dns.SOA{Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeSOA, Class: dns.ClassINET}, Ns: "ns.example.com.", Mbox: "admin.example.com."}}
+		case dns.OpcodeUpdate:
+			if r.IsTsig() == nil || w.TsigStatus() != nil || r.Question[0].Name != "example.com." {
+				resp.Rcode = dns.RcodeRefused
+				break
+			}
+			mu.Lock()
+			for _, rr := range r.Ns {
+				txt := rr.(*dns.TXT)
+				if txt.Hdr.Class == dns.ClassNONE {
+					delete(records, txt.Hdr.Name)
+				} else {
+					records[txt.Hdr.Name] = txt.Txt[0]
+				}
+			}
+			mu.Unlock()
+		}
+
+		if r.IsTsig() != nil {
+			resp.SetTsig(r.IsTsig().Hdr.Name, dns.HmacSHA256, 300, int64(r.IsTsig().TimeSigned))
+		}
+		_ = w.WriteMsg(resp)
+	})
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	started := make(chan struct{})
+	server := &dns.Server{
+		PacketConn:        conn,
+		Handler:           handler,
+		TsigSecret:        map[string]string{"acme.": testTSIGSecret},
+		NotifyStartedFunc: func() { close(started) },
+		// the default accept func rejects the updates
+		MsgAcceptFunc: func(dns.Header) dns.MsgAcceptAction { return dns.MsgAccept },
+	}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	<-started
+	t.Cleanup(func() { _ = server.Shutdown() })
+
+	return conn.LocalAddr().String(), func() map[string]string {
+		mu.Lock()
+		defer mu.Unlock()
+		copied := map[string]string{}
+		for k, v := range records {
+			copied[k] = v
+		}
+		return copied
+	}
+}
+
+func TestRFC2136Provider(t *testing.T) {
+	nameserver, records := startUpdateServer(t)
+	ctx := context.Background()
+
+	provider, err := NewRFC2136Provider(RFC2136Config{Nameserver: nameserver, TSIGKeyName: "acme", TSIGSecret: testTSIGSecret})
+	require.NoError(t, err)
+
+	require.NoError(t, provider.Present(ctx, "_acme-challenge.mgmt.example.com.", "value"))
+	assert.Equal(t, "example.com.", provider.zone, "the zone should be looked up")
+	assert.Equal(t, map[string]string{"_acme-challenge.mgmt.example.com.": "value"}, records())
+
+	require.NoError(t, provider.CleanUp(ctx, "_acme-challenge.mgmt.example.com.", "value"))
+	assert.Empty(t, records())
+
+	unsigned, err := NewRFC2136Provider(RFC2136Config{Nameserver: nameserver, Zone: "example.com"})
+	require.NoError(t, err)
+	assert.ErrorContains(t, unsigned.Present(ctx, "_acme-challenge.mgmt.example.com.", "value"), "REFUSED")
+}
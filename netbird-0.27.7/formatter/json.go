@@ -0,0 +1,20 @@
+package formatter
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NewJSONFormatter creates a formatter rendering the entries as JSON objects, one per line, the source of the
+// entry is given by the source field
+func NewJSONFormatter() *logrus.JSONFormatter {
+	return &logrus.JSONFormatter{
+		TimestampFormat: time.RFC3339,
+		CallerPrettyfier: func(*runtime.Frame) (string, string) {
+			// the source field of the context hook replaces the func and file fields
+			return "", ""
+		},
+	}
+}
@@ -6,12 +6,29 @@ import "github.com/sirupsen/logrus"
 func SetTextFormatter(logger *logrus.Logger) {
 	logger.Formatter = NewTextFormatter()
 	logger.ReportCaller = true
-	logger.AddHook(NewContextHook())
+	addContextHook(logger)
+}
+
+// SetJSONFormatter set the JSON formatter for given logger.
+func SetJSONFormatter(logger *logrus.Logger) {
+	logger.Formatter = NewJSONFormatter()
+	logger.ReportCaller = true
+	addContextHook(logger)
 }
 
 // SetLogcatFormatter set the logcat formatter for given logger.
 func SetLogcatFormatter(logger *logrus.Logger) {
 	logger.Formatter = NewLogcatFormatter()
 	logger.ReportCaller = true
+	addContextHook(logger)
+}
+
+// addContextHook adds the context hook to the logger unless it already has it, e.g. when the formatter is changed
+func addContextHook(logger *logrus.Logger) {
+	for _, hook := range logger.Hooks[logrus.InfoLevel] {
+		if _, ok := hook.(*ContextHook); ok {
+			return
+		}
+	}
 	logger.AddHook(NewContextHook())
 }
@@ -3,7 +3,6 @@ package bind
 import (
 	"fmt"
 	"net"
-	"runtime"
 	"sync"
 
 	"github.com/pion/stun/v2"
@@ -11,6 +10,8 @@ import (
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/ipv4"
 	wgConn "golang.zx2c4.com/wireguard/conn"
+
+	nbnet "github.com/netbirdio/netbird/util/net"
 )
 
 type receiverCreator struct {
@@ -21,6 +22,11 @@ func (rc receiverCreator) CreateIPv4ReceiverFn(msgPool *sync.Pool, pc *ipv4.Pack
 	return rc.iceBind.createIPv4ReceiverFn(msgPool, pc, conn)
 }
 
+// uplinkNet is implemented by the transport nets that pin the sockets to an uplink interface
+type uplinkNet interface {
+	UplinkInterface() string
+}
+
 type ICEBind struct {
 	*wgConn.StdNetBind
 
@@ -63,44 +69,100 @@ func (s *ICEBind) createIPv4ReceiverFn(ipv4MsgsPool *sync.Pool, pc *ipv4.PacketC
 			Net:     s.transportNet,
 		},
 	)
+
+	s.bindToUplink(conn)
+
+	rxOffload := setupUDPGRO(conn)
 	return func(bufs [][]byte, sizes []int, eps []wgConn.Endpoint) (n int, err error) {
-		msgs := ipv4MsgsPool.Get().(*[]ipv4.Message)
-		defer ipv4MsgsPool.Put(msgs)
-		for i := range bufs {
-			(*msgs)[i].Buffers[0] = bufs[i]
+		return s.receiveIPv4(ipv4MsgsPool, pc, conn, rxOffload, bufs, sizes, eps)
+	}
+}
+
+// bindToUplink pins the WireGuard socket to the uplink interface of the transport net, if there is one
+func (s *ICEBind) bindToUplink(conn *net.UDPConn) {
+	n, ok := s.transportNet.(uplinkNet)
+	if !ok || n.UplinkInterface() == "" {
+		return
+	}
+
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		log.Warnf("failed to get raw conn of the WireGuard socket: %s", err)
+		return
+	}
+	if err := nbnet.BindToInterface(rawConn, n.UplinkInterface()); err != nil {
+		log.Warnf("failed to bind the WireGuard socket to uplink %s: %s", n.UplinkInterface(), err)
+		return
+	}
+	log.Infof("bound the WireGuard socket to uplink %s", n.UplinkInterface())
+}
+
+// receiveIPv4 reads a batch of datagrams with a single syscall if the packet conn is available (Linux), otherwise a
+// single datagram. With rxOffload the kernel may coalesce datagrams of the same flow, they are split into the bufs.
+// STUN messages are passed to the UDP mux and reported with size 0.
+func (s *ICEBind) receiveIPv4(ipv4MsgsPool *sync.Pool, pc *ipv4.PacketConn, conn *net.UDPConn, rxOffload bool, bufs [][]byte, sizes []int, eps []wgConn.Endpoint) (int, error) {
+	msgs := ipv4MsgsPool.Get().(*[]ipv4.Message)
+	defer putMessages(ipv4MsgsPool, msgs)
+	for i := range bufs {
+		(*msgs)[i].Buffers[0] = bufs[i]
+		(*msgs)[i].OOB = (*msgs)[i].OOB[:cap((*msgs)[i].OOB)]
+	}
+	batch := (*msgs)[:len(bufs)]
+
+	var numMsgs int
+	var err error
+	switch {
+	case pc != nil && rxOffload:
+		// read the coalesced messages to the end of the batch, the segments are copied to the beginning of it
+		readAt := len(batch) - max(len(batch)/udpSegmentMaxDatagrams, 1)
+		_, err = pc.ReadBatch(batch[readAt:], 0)
+		if err != nil {
+			return 0, err
 		}
-		var numMsgs int
-		if runtime.GOOS == "linux" {
-			numMsgs, err = pc.ReadBatch(*msgs, 0)
-			if err != nil {
-				return 0, err
-			}
-		} else {
-			msg := &(*msgs)[0]
-			msg.N, msg.NN, _, msg.Addr, err = conn.ReadMsgUDP(msg.Buffers[0], msg.OOB)
-			if err != nil {
-				return 0, err
-			}
-			numMsgs = 1
+		numMsgs, err = splitCoalescedMessages(batch, readAt, getGSOSize)
+		if err != nil {
+			return 0, err
+		}
+	case pc != nil:
+		numMsgs, err = pc.ReadBatch(batch, 0)
+		if err != nil {
+			return 0, err
 		}
-		for i := 0; i < numMsgs; i++ {
-			msg := &(*msgs)[i]
-
-			// todo: handle err
-			ok, _ := s.filterOutStunMessages(msg.Buffers, msg.N, msg.Addr)
-			if ok {
-				sizes[i] = 0
-			} else {
-				sizes[i] = msg.N
-			}
-
-			addrPort := msg.Addr.(*net.UDPAddr).AddrPort()
-			ep := &wgConn.StdNetEndpoint{AddrPort: addrPort} // TODO: remove allocation
-			wgConn.GetSrcFromControl(msg.OOB[:msg.NN], ep)
-			eps[i] = ep
+	default:
+		msg := &batch[0]
+		msg.N, msg.NN, _, msg.Addr, err = conn.ReadMsgUDP(msg.Buffers[0], msg.OOB)
+		if err != nil {
+			return 0, err
 		}
-		return numMsgs, nil
+		numMsgs = 1
+	}
+
+	for i := 0; i < numMsgs; i++ {
+		msg := &batch[i]
+
+		// todo: handle err
+		ok, _ := s.filterOutStunMessages(msg.Buffers, msg.N, msg.Addr)
+		if ok {
+			sizes[i] = 0
+		} else {
+			sizes[i] = msg.N
+		}
+
+		addrPort := msg.Addr.(*net.UDPAddr).AddrPort()
+		ep := &wgConn.StdNetEndpoint{AddrPort: addrPort} // TODO: remove allocation
+		wgConn.GetSrcFromControl(msg.OOB[:msg.NN], ep)
+		eps[i] = ep
+	}
+	return numMsgs, nil
+}
+
+// putMessages resets the messages before returning them to the pool, the pool is shared with the WireGuard bind
+func putMessages(pool *sync.Pool, msgs *[]ipv4.Message) {
+	for i := range *msgs {
+		(*msgs)[i].OOB = (*msgs)[i].OOB[:0]
+		(*msgs)[i] = ipv4.Message{Buffers: (*msgs)[i].Buffers, OOB: (*msgs)[i].OOB}
 	}
+	pool.Put(msgs)
 }
 
 func (s *ICEBind) filterOutStunMessages(buffers [][]byte, n int, addr net.Addr) (bool, error) {
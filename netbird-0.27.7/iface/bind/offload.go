@@ -0,0 +1,80 @@
+package bind
+
+/*
+ The splitting of coalesced messages was copied from https://git.zx2c4.com/wireguard-go and modified to fulfill
+ NetBird's requirements.
+*/
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/net/ipv4"
+)
+
+const (
+	// envDisableUDPGRO disables the UDP generic receive offload of the bind, the kernel delivers the datagrams one by one
+	envDisableUDPGRO = "NB_DISABLE_UDP_GRO"
+
+	// udpSegmentMaxDatagrams is the maximum number of datagrams the kernel coalesces into a single message
+	udpSegmentMaxDatagrams = 64
+)
+
+var errSplitOverflow = errors.New("splitting coalesced packet resulted in overflow")
+
+// getGSOFunc returns the segment size of a coalesced message from its control data, 0 if the message is not coalesced
+type getGSOFunc func(control []byte) (int, error)
+
+func udpGRODisabledByEnv() bool {
+	return os.Getenv(envDisableUDPGRO) == "true"
+}
+
+// splitCoalescedMessages splits the coalesced messages read at msgs[firstMsgAt:] into the messages from the beginning
+// of msgs and returns the number of the resulting messages
+func splitCoalescedMessages(msgs []ipv4.Message, firstMsgAt int, getGSO getGSOFunc) (int, error) {
+	n := 0
+	for i := firstMsgAt; i < len(msgs); i++ {
+		msg := &msgs[i]
+		if msg.N == 0 {
+			return n, nil
+		}
+
+		gsoSize, err := getGSO(msg.OOB[:msg.NN])
+		if err != nil {
+			return n, err
+		}
+
+		start, end, numToSplit := 0, msg.N, 1
+		if gsoSize > 0 {
+			numToSplit = (msg.N + gsoSize - 1) / gsoSize
+			end = gsoSize
+		}
+
+		for j := 0; j < numToSplit; j++ {
+			if n > i {
+				return n, errSplitOverflow
+			}
+
+			dst := &msgs[n]
+			dst.N = copy(dst.Buffers[0], msg.Buffers[0][start:end])
+			dst.Addr = msg.Addr
+			if n != i {
+				// keep the sticky source of the coalesced message for each segment
+				dst.NN = copy(dst.OOB[:cap(dst.OOB)], msg.OOB[:msg.NN])
+			}
+
+			start = end
+			end += gsoSize
+			if end > msg.N {
+				end = msg.N
+			}
+			n++
+		}
+
+		// the message might be the destination of the last segment
+		if i != n-1 {
+			msg.N = 0
+		}
+	}
+	return n, nil
+}
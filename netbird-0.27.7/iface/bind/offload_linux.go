@@ -0,0 +1,57 @@
+package bind
+
+import (
+	"fmt"
+	"net"
+	"unsafe"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const sizeOfGSOData = 2
+
+// setupUDPGRO reports whether the kernel coalesces the datagrams received on the connection. WireGuard enables the
+// offload when it creates the socket if the kernel supports it, it is turned off again when disabled by the environment
+func setupUDPGRO(conn *net.UDPConn) bool {
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return false
+	}
+
+	enabled := false
+	disable := udpGRODisabledByEnv()
+	err = rc.Control(func(fd uintptr) {
+		if disable {
+			if err := unix.SetsockoptInt(int(fd), unix.IPPROTO_UDP, unix.UDP_GRO, 0); err != nil {
+				log.Debugf("failed to disable UDP GRO: %s", err)
+			}
+		}
+		opt, err := unix.GetsockoptInt(int(fd), unix.IPPROTO_UDP, unix.UDP_GRO)
+		enabled = err == nil && opt == 1
+	})
+	if err != nil {
+		return false
+	}
+
+	log.Debugf("UDP GRO enabled on %s: %t", conn.LocalAddr(), enabled)
+	return enabled
+}
+
+// getGSOSize parses the control data for UDP_GRO and returns the segment size if found
+func getGSOSize(control []byte) (int, error) {
+	rem := control
+	for len(rem) > unix.SizeofCmsghdr {
+		hdr, data, remainder, err := unix.ParseOneSocketControlMessage(rem)
+		if err != nil {
+			return 0, fmt.Errorf("parse socket control message: %w", err)
+		}
+		if hdr.Level == unix.SOL_UDP && hdr.Type == unix.UDP_GRO && len(data) >= sizeOfGSOData {
+			var gso uint16
+			copy(unsafe.Slice((*byte)(unsafe.Pointer(&gso)), sizeOfGSOData), data[:sizeOfGSOData])
+			return int(gso), nil
+		}
+		rem = remainder
+	}
+	return 0, nil
+}
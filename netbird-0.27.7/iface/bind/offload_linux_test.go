@@ -0,0 +1,193 @@
+package bind
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/sys/unix"
+	wgConn "golang.zx2c4.com/wireguard/conn"
+)
+
+const (
+	testSegmentSize = 1200
+	// maxCoalescedSegments is the number of segments fitting the maximum UDP payload
+	maxCoalescedSegments = (1<<16 - 1 - 20 - 8) / testSegmentSize
+)
+
+var errGSONotSupported = errors.New("UDP GSO is not supported")
+
+// sendCoalesced sends the payload with a single syscall, the kernel splits it into datagrams of segmentSize
+func sendCoalesced(conn *net.UDPConn, dst *net.UDPAddr, payload []byte, segmentSize int) error {
+	oob := make([]byte, unix.CmsgSpace(sizeOfGSOData))
+	hdr := (*unix.Cmsghdr)(unsafe.Pointer(&oob[0]))
+	hdr.Level = unix.SOL_UDP
+	hdr.Type = unix.UDP_SEGMENT
+	hdr.SetLen(unix.CmsgLen(sizeOfGSOData))
+	binary.NativeEndian.PutUint16(oob[unix.CmsgLen(0):], uint16(segmentSize))
+
+	_, _, err := conn.WriteMsgUDP(payload, oob, dst)
+	if errors.Is(err, unix.EIO) || errors.Is(err, unix.EINVAL) || errors.Is(err, unix.ENOPROTOOPT) {
+		return errGSONotSupported
+	}
+	return err
+}
+
+func newTestPayload(segments int) []byte {
+	payload := make([]byte, 0, segments*testSegmentSize)
+	for i := 0; i < segments; i++ {
+		payload = append(payload, bytes.Repeat([]byte{byte(i)}, testSegmentSize)...)
+	}
+	return payload
+}
+
+func newTestBufs() ([][]byte, []int, []wgConn.Endpoint) {
+	bufs := make([][]byte, wgConn.IdealBatchSize)
+	for i := range bufs {
+		bufs[i] = make([]byte, 1<<16-1)
+	}
+	return bufs, make([]int, wgConn.IdealBatchSize), make([]wgConn.Endpoint, wgConn.IdealBatchSize)
+}
+
+func TestICEBind_ReceiveCoalesced(t *testing.T) {
+	for _, disableGRO := range []bool{false, true} {
+		name := "gro"
+		if disableGRO {
+			name = "no gro"
+		}
+		t.Run(name, func(t *testing.T) {
+			if disableGRO {
+				t.Setenv(envDisableUDPGRO, "true")
+			}
+
+			iceBind := NewICEBind(nil)
+			fns, port, err := iceBind.Open(0)
+			require.NoError(t, err)
+			defer iceBind.Close()
+
+			sender, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+			require.NoError(t, err)
+			defer sender.Close()
+
+			const segments = 10
+			dst := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: int(port)}
+			err = sendCoalesced(sender, dst, newTestPayload(segments), testSegmentSize)
+			if errors.Is(err, errGSONotSupported) {
+				t.Skip(err)
+			}
+			require.NoError(t, err)
+
+			mux, err := iceBind.GetICEMux()
+			require.NoError(t, err)
+			require.NoError(t, mux.params.UDPConn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+			bufs, sizes, eps := newTestBufs()
+			received := 0
+			for received < segments {
+				n, err := fns[0](bufs, sizes, eps)
+				require.NoError(t, err)
+				for i := 0; i < n; i++ {
+					require.Equal(t, testSegmentSize, sizes[i], "datagram %d should not be coalesced", received)
+					require.Equal(t, byte(received), bufs[i][0], "datagram %d has unexpected content", received)
+					require.Equal(t, sender.LocalAddr().(*net.UDPAddr).Port, int(eps[i].(*wgConn.StdNetEndpoint).Port()))
+					received++
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkICEBind_Receive compares the receive throughput of a single read per call, batched reads with recvmmsg and
+// batched reads of the datagrams coalesced by the kernel with UDP GRO
+func BenchmarkICEBind_Receive(b *testing.B) {
+	benchmarks := []struct {
+		name  string
+		batch bool
+		gro   bool
+	}{
+		{name: "single"},
+		{name: "batch", batch: true},
+		{name: "batch gro", batch: true, gro: true},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+			require.NoError(b, err)
+			defer conn.Close()
+			_ = conn.SetReadBuffer(8 << 20)
+
+			rc, err := conn.SyscallConn()
+			require.NoError(b, err)
+			groOpt := 0
+			if bm.gro {
+				groOpt = 1
+			}
+			require.NoError(b, rc.Control(func(fd uintptr) {
+				err = unix.SetsockoptInt(int(fd), unix.IPPROTO_UDP, unix.UDP_GRO, groOpt)
+			}))
+			if err != nil {
+				b.Skipf("UDP GRO is not supported: %s", err)
+			}
+
+			var pc *ipv4.PacketConn
+			if bm.batch {
+				pc = ipv4.NewPacketConn(conn)
+			}
+
+			pool := &sync.Pool{
+				New: func() any {
+					msgs := make([]ipv4.Message, wgConn.IdealBatchSize)
+					for i := range msgs {
+						msgs[i].Buffers = make(net.Buffers, 1)
+						msgs[i].OOB = make([]byte, 0, 64)
+					}
+					return &msgs
+				},
+			}
+
+			sender, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+			require.NoError(b, err)
+			defer sender.Close()
+
+			payload := newTestPayload(maxCoalescedSegments)
+			dst := conn.LocalAddr().(*net.UDPAddr)
+			if err := sendCoalesced(sender, dst, payload, testSegmentSize); err != nil {
+				b.Skip(err)
+			}
+
+			done := make(chan struct{})
+			defer close(done)
+			go func() {
+				for {
+					select {
+					case <-done:
+						return
+					default:
+						_ = sendCoalesced(sender, dst, payload, testSegmentSize)
+					}
+				}
+			}()
+
+			iceBind := &ICEBind{}
+			bufs, sizes, eps := newTestBufs()
+
+			b.SetBytes(testSegmentSize)
+			b.ResetTimer()
+			for received := 0; received < b.N; {
+				n, err := iceBind.receiveIPv4(pool, pc, conn, bm.gro, bufs, sizes, eps)
+				if err != nil {
+					b.Fatal(err)
+				}
+				received += n
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package bind
+
+import "net"
+
+// setupUDPGRO reports false, UDP generic receive offload is supported on Linux only
+func setupUDPGRO(*net.UDPConn) bool {
+	return false
+}
+
+func getGSOSize([]byte) (int, error) {
+	return 0, nil
+}
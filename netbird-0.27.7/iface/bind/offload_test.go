@@ -0,0 +1,71 @@
+package bind
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/ipv4"
+)
+
+func newTestMessages(count, size int) []ipv4.Message {
+	msgs := make([]ipv4.Message, count)
+	for i := range msgs {
+		msgs[i].Buffers = [][]byte{make([]byte, size)}
+		msgs[i].OOB = make([]byte, 0, 64)
+	}
+	return msgs
+}
+
+func Test_splitCoalescedMessages(t *testing.T) {
+	addr := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 51820}
+	gsoSizes := map[int]int{}
+	getGSO := func(control []byte) (int, error) {
+		return gsoSizes[len(control)], nil
+	}
+
+	msgs := newTestMessages(8, 1024)
+	readAt := 6
+
+	// the first message carries 3 segments of 100 bytes and a trailing segment of 50 bytes
+	coalesced := &msgs[readAt]
+	for i := 0; i < 350; i++ {
+		coalesced.Buffers[0][i] = byte(i / 100)
+	}
+	coalesced.N = 350
+	coalesced.NN = 1
+	coalesced.OOB = coalesced.OOB[:1]
+	coalesced.Addr = addr
+	gsoSizes[1] = 100
+
+	// the second message is not coalesced
+	single := &msgs[readAt+1]
+	single.Buffers[0][0] = 9
+	single.N = 20
+	single.Addr = addr
+
+	n, err := splitCoalescedMessages(msgs, readAt, getGSO)
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+
+	expectedSizes := []int{100, 100, 100, 50, 20}
+	expectedFirstBytes := []byte{0, 1, 2, 3, 9}
+	for i := 0; i < n; i++ {
+		assert.Equal(t, expectedSizes[i], msgs[i].N, "size of message %d", i)
+		assert.Equal(t, expectedFirstBytes[i], msgs[i].Buffers[0][0], "content of message %d", i)
+		assert.Equal(t, addr, msgs[i].Addr, "address of message %d", i)
+	}
+	assert.Equal(t, 0, msgs[readAt].N, "the source message should be reset")
+}
+
+func Test_splitCoalescedMessagesOverflow(t *testing.T) {
+	msgs := newTestMessages(2, 1024)
+	msgs[1].N = 300
+	getGSO := func([]byte) (int, error) {
+		return 100, nil
+	}
+
+	_, err := splitCoalescedMessages(msgs, 1, getGSO)
+	assert.ErrorIs(t, err, errSplitOverflow)
+}
@@ -28,11 +28,20 @@ type PacketFilter interface {
 	SetNetwork(*net.IPNet)
 }
 
+// PacketCapture receives the packets passing the device, e.g. to write them to a pcap file
+type PacketCapture interface {
+	// CapturePacket is called for every packet. Outbound packets are captured before they are encrypted and
+	// inbound packets after they are decrypted. dropped is true if the packet filter dropped the packet.
+	// The packet must not be retained after the call returns.
+	CapturePacket(packet []byte, outbound bool, dropped bool)
+}
+
 // DeviceWrapper to override Read or Write of packets
 type DeviceWrapper struct {
 	tun.Device
-	filter PacketFilter
-	mutex  sync.RWMutex
+	filter  PacketFilter
+	capture PacketCapture
+	mutex   sync.RWMutex
 }
 
 // newDeviceWrapper constructor function
@@ -49,14 +58,25 @@ func (d *DeviceWrapper) Read(bufs [][]byte, sizes []int, offset int) (n int, err
 	}
 	d.mutex.RLock()
 	filter := d.filter
+	capture := d.capture
 	d.mutex.RUnlock()
 
 	if filter == nil {
+		if capture != nil {
+			for i := 0; i < n; i++ {
+				capture.CapturePacket(bufs[i][offset:offset+sizes[i]], true, false)
+			}
+		}
 		return
 	}
 
 	for i := 0; i < n; i++ {
-		if filter.DropOutgoing(bufs[i][offset : offset+sizes[i]]) {
+		packet := bufs[i][offset : offset+sizes[i]]
+		drop := filter.DropOutgoing(packet)
+		if capture != nil {
+			capture.CapturePacket(packet, true, drop)
+		}
+		if drop {
 			bufs = append(bufs[:i], bufs[i+1:]...)
 			sizes = append(sizes[:i], sizes[i+1:]...)
 			n--
@@ -71,16 +91,26 @@ func (d *DeviceWrapper) Read(bufs [][]byte, sizes []int, offset int) (n int, err
 func (d *DeviceWrapper) Write(bufs [][]byte, offset int) (int, error) {
 	d.mutex.RLock()
 	filter := d.filter
+	capture := d.capture
 	d.mutex.RUnlock()
 
 	if filter == nil {
+		if capture != nil {
+			for _, buf := range bufs {
+				capture.CapturePacket(buf[offset:], false, false)
+			}
+		}
 		return d.Device.Write(bufs, offset)
 	}
 
 	filteredBufs := make([][]byte, 0, len(bufs))
 	dropped := 0
 	for _, buf := range bufs {
-		if !filter.DropIncoming(buf[offset:]) {
+		drop := filter.DropIncoming(buf[offset:])
+		if capture != nil {
+			capture.CapturePacket(buf[offset:], false, drop)
+		}
+		if !drop {
 			filteredBufs = append(filteredBufs, buf)
 			dropped++
 		}
@@ -97,3 +127,10 @@ func (d *DeviceWrapper) SetFilter(filter PacketFilter) {
 	d.filter = filter
 	d.mutex.Unlock()
 }
+
+// SetCapture sets the packet capture of the device, nil stops capturing
+func (d *DeviceWrapper) SetCapture(capture PacketCapture) {
+	d.mutex.Lock()
+	d.capture = capture
+	d.mutex.Unlock()
+}
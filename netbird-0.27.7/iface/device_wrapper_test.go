@@ -220,3 +220,69 @@ func TestDeviceWrapperRead(t *testing.T) {
 		}
 	})
 }
+
+type capturedPacket struct {
+	size     int
+	outbound bool
+	dropped  bool
+}
+
+type recordingCapture struct {
+	packets []capturedPacket
+}
+
+func (c *recordingCapture) CapturePacket(packet []byte, outbound bool, dropped bool) {
+	c.packets = append(c.packets, capturedPacket{size: len(packet), outbound: outbound, dropped: dropped})
+}
+
+func TestDeviceWrapperCapture(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	packet := []byte{0x45, 0, 0, 20, 0, 0, 0, 0, 64, 17, 0, 0, 100, 200, 0, 1, 100, 200, 0, 2}
+
+	t.Run("capture read", func(t *testing.T) {
+		tun := mocks.NewMockDevice(ctrl)
+		tun.EXPECT().Read(gomock.Any(), gomock.Any(), 0).
+			DoAndReturn(func(bufs [][]byte, sizes []int, offset int) (int, error) {
+				bufs[0] = packet
+				sizes[0] = len(packet)
+				return 1, nil
+			})
+
+		capture := &recordingCapture{}
+		wrapped := newDeviceWrapper(tun)
+		wrapped.SetCapture(capture)
+
+		n, err := wrapped.Read([][]byte{{}}, []int{0}, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n != 1 {
+			t.Fatalf("expected n=1, got %d", n)
+		}
+		if len(capture.packets) != 1 || capture.packets[0] != (capturedPacket{size: len(packet), outbound: true}) {
+			t.Errorf("unexpected captured packets: %+v", capture.packets)
+		}
+	})
+
+	t.Run("capture dropped write", func(t *testing.T) {
+		tun := mocks.NewMockDevice(ctrl)
+		tun.EXPECT().Write([][]byte{}, 0).Return(0, nil)
+
+		filter := mocks.NewMockPacketFilter(ctrl)
+		filter.EXPECT().DropIncoming(gomock.Any()).Return(true)
+
+		capture := &recordingCapture{}
+		wrapped := newDeviceWrapper(tun)
+		wrapped.filter = filter
+		wrapped.SetCapture(capture)
+
+		if _, err := wrapped.Write([][]byte{packet}, 0); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(capture.packets) != 1 || capture.packets[0] != (capturedPacket{size: len(packet), dropped: true}) {
+			t.Errorf("unexpected captured packets: %+v", capture.packets)
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package iface
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"sync"
@@ -15,13 +16,24 @@ import (
 const (
 	DefaultMTU    = 1280
 	DefaultWgPort = 51820
+	// MinMTU is the smallest MTU the interface can be configured with
+	MinMTU = 576
+	// MaxMTU is the largest MTU the interface can be configured with
+	MaxMTU = 9000
 )
 
+var errMTUUpdateNotSupported = errors.New("updating the MTU is not supported on this device")
+
+// ErrAddrUpdateNotSupported is returned by UpdateAddr when the device can't change its address in place
+var ErrAddrUpdateNotSupported = errors.New("updating the address is not supported on this device")
+
 // WGIface represents a interface instance
 type WGIface struct {
 	tun           wgTunDevice
 	userspaceBind bool
-	mu            sync.Mutex
+	// userspaceReason holds why the userspace implementation is used where a kernel implementation exists
+	userspaceReason string
+	mu              sync.Mutex
 
 	configurer wgConfigurer
 	filter     PacketFilter
@@ -31,6 +43,8 @@ type WGStats struct {
 	LastHandshake time.Time
 	TxBytes       int64
 	RxBytes       int64
+	// Endpoint is the current remote address of the peer, empty if it is unknown
+	Endpoint string
 }
 
 // IsUserspaceBind indicates whether this interfaces is userspace with bind.ICEBind
@@ -38,6 +52,12 @@ func (w *WGIface) IsUserspaceBind() bool {
 	return w.userspaceBind
 }
 
+// UserspaceReason returns why the interface doesn't use the kernel implementation.
+// It is empty if the kernel implementation is used or the platform doesn't have one.
+func (w *WGIface) UserspaceReason() string {
+	return w.userspaceReason
+}
+
 // Name returns the interface name
 func (w *WGIface) Name() string {
 	return w.tun.DeviceName()
@@ -57,7 +77,7 @@ func (w *WGIface) Up() (*bind.UniversalUDPMuxDefault, error) {
 	return w.tun.Up()
 }
 
-// UpdateAddr updates address of the interface
+// UpdateAddr updates address of the interface, the WireGuard peers and their sessions are kept
 func (w *WGIface) UpdateAddr(newAddr string) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -70,6 +90,18 @@ func (w *WGIface) UpdateAddr(newAddr string) error {
 	return w.tun.UpdateAddr(addr)
 }
 
+// UpdateMTU updates the MTU of the interface
+func (w *WGIface) UpdateMTU(mtu int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if mtu < MinMTU || mtu > MaxMTU {
+		return fmt.Errorf("MTU %d is out of range [%d, %d]", mtu, MinMTU, MaxMTU)
+	}
+
+	return w.tun.UpdateMTU(mtu)
+}
+
 // UpdatePeer updates existing Wireguard Peer or creates a new one if doesn't exist
 // Endpoint is optional
 func (w *WGIface) UpdatePeer(peerKey string, allowedIps string, keepAlive time.Duration, endpoint *net.UDPAddr, preSharedKey *wgtypes.Key) error {
@@ -107,6 +139,29 @@ func (w *WGIface) RemoveAllowedIP(peerKey string, allowedIP string) error {
 	return w.configurer.removeAllowedIP(peerKey, allowedIP)
 }
 
+// Suspend holds the interface before the system sleeps. The userspace implementation closes its sockets and stops
+// its timers, the interface keeps its address and routes. Resume brings the sockets back.
+func (w *WGIface) Suspend() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.configurer == nil {
+		return fmt.Errorf("interface %s is not created yet", w.tun.DeviceName())
+	}
+
+	log.Debugf("suspending interface %s", w.tun.DeviceName())
+	return w.configurer.suspend()
+}
+
+// Resume rebinds the sockets of a suspended interface and returns the UDP mux the ICE agents have to use from now on
+func (w *WGIface) Resume() (*bind.UniversalUDPMuxDefault, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	log.Debugf("resuming interface %s", w.tun.DeviceName())
+	return w.tun.Up()
+}
+
 // Close closes the tunnel interface
 func (w *WGIface) Close() error {
 	w.mu.Lock()
@@ -130,6 +185,19 @@ func (w *WGIface) SetFilter(filter PacketFilter) error {
 	return nil
 }
 
+// SetPacketCapture taps the packets of the userspace implementation, nil stops capturing
+func (w *WGIface) SetPacketCapture(capture PacketCapture) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.tun.Wrapper() == nil {
+		return fmt.Errorf("packet capture is only supported by the userspace implementation")
+	}
+
+	w.tun.Wrapper().SetCapture(capture)
+	return nil
+}
+
 // GetFilter returns packet filter used by interface if it uses userspace device implementation
 func (w *WGIface) GetFilter() PacketFilter {
 	w.mu.Lock()
@@ -150,3 +218,8 @@ func (w *WGIface) GetDevice() *DeviceWrapper {
 func (w *WGIface) GetStats(peerKey string) (WGStats, error) {
 	return w.configurer.getStats(peerKey)
 }
+
+// GetAllStats returns the transfer statistics of all peers of the interface keyed by the peer's public key
+func (w *WGIface) GetAllStats() (map[string]WGStats, error) {
+	return w.configurer.getAllStats()
+}
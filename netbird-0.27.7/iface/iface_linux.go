@@ -7,12 +7,13 @@ import (
 	"fmt"
 
 	"github.com/pion/transport/v3"
+	log "github.com/sirupsen/logrus"
 
 	"github.com/netbirdio/netbird/iface/netstack"
 )
 
 // NewWGIFace Creates a new WireGuard interface instance
-func NewWGIFace(iFaceName string, address string, wgPort int, wgPrivKey string, mtu int, transportNet transport.Net, args *MobileIFaceArguments) (*WGIface, error) {
+func NewWGIFace(iFaceName string, address string, wgPort int, wgPrivKey string, mtu int, transportNet transport.Net, args *MobileIFaceArguments, mode WGMode) (*WGIface, error) {
 	wgAddress, err := parseWGAddress(address)
 	if err != nil {
 		return nil, err
@@ -22,15 +23,25 @@ func NewWGIFace(iFaceName string, address string, wgPort int, wgPrivKey string,
 
 	// move the kernel/usp/netstack preference evaluation to upper layer
 	if netstack.IsEnabled() {
-		wgIFace.tun = newTunNetstackDevice(iFaceName, wgAddress, wgPort, wgPrivKey, mtu, transportNet, netstack.ListenAddr())
+		wgIFace.tun = newTunNetstackDevice(iFaceName, wgAddress, wgPort, wgPrivKey, mtu, transportNet, netstack.ListenAddr(), netstack.HTTPProxyListenAddr())
 		wgIFace.userspaceBind = true
+		wgIFace.userspaceReason = "netstack mode enabled"
 		return wgIFace, nil
 	}
 
-	if WireGuardModuleIsLoaded() {
+	switch {
+	case mode == WGModeUserspace:
+		wgIFace.userspaceReason = "userspace mode configured"
+	case WireGuardModuleIsLoaded():
+		log.Infof("using kernel WireGuard")
 		wgIFace.tun = newTunDevice(iFaceName, wgAddress, wgPort, wgPrivKey, mtu, transportNet)
 		wgIFace.userspaceBind = false
 		return wgIFace, nil
+	case mode == WGModeKernel:
+		return nil, fmt.Errorf("kernel mode configured but the WireGuard kernel module is not available")
+	default:
+		wgIFace.userspaceReason = "WireGuard kernel module not available"
+		log.Infof("WireGuard kernel module not available, falling back to userspace WireGuard")
 	}
 
 	if !tunModuleIsLoaded() {
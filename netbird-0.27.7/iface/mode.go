@@ -0,0 +1,32 @@
+package iface
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WGMode is the preferred WireGuard implementation of the interface
+type WGMode string
+
+const (
+	// WGModeAuto uses the kernel implementation if available and falls back to userspace
+	WGModeAuto WGMode = "auto"
+	// WGModeKernel requires the kernel implementation
+	WGModeKernel WGMode = "kernel"
+	// WGModeUserspace always uses the userspace implementation
+	WGModeUserspace WGMode = "userspace"
+)
+
+// ParseWGMode parses a WireGuard mode, an empty string is treated as WGModeAuto
+func ParseWGMode(mode string) (WGMode, error) {
+	switch WGMode(strings.ToLower(strings.TrimSpace(mode))) {
+	case "", WGModeAuto:
+		return WGModeAuto, nil
+	case WGModeKernel:
+		return WGModeKernel, nil
+	case WGModeUserspace:
+		return WGModeUserspace, nil
+	default:
+		return "", fmt.Errorf("invalid WireGuard mode %q, expected one of %s, %s or %s", mode, WGModeAuto, WGModeKernel, WGModeUserspace)
+	}
+}
@@ -0,0 +1,34 @@
+package iface
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWGMode(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected WGMode
+		wantErr  bool
+	}{
+		{input: "", expected: WGModeAuto},
+		{input: "auto", expected: WGModeAuto},
+		{input: "Kernel", expected: WGModeKernel},
+		{input: " userspace ", expected: WGModeUserspace},
+		{input: "netstack", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			mode, err := ParseWGMode(tc.input)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, mode)
+		})
+	}
+}
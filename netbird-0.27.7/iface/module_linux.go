@@ -46,6 +46,9 @@ var (
 	// ErrModuleNotFound is the error resulting if a module can't be found.
 	ErrModuleNotFound = errors.New("module not found")
 	moduleLibDir      = defaultModuleDir
+	// sysModuleDir lists the loaded and built-in modules. It is checked as well because distributions like OpenWrt
+	// ship neither modules.builtin nor modules.dep
+	sysModuleDir = "/sys/module"
 	// get the root directory for the kernel modules. If this line panics,
 	// it's because getModuleRoot has failed to get the uname of the running
 	// kernel (likely a non-POSIX system, but maybe a broken kernel?)
@@ -144,11 +147,19 @@ func tryToLoadModule(moduleName string) (bool, error) {
 }
 
 func isModuleEnabled(name string) bool {
+	if isModuleInSysfs(name) {
+		return true
+	}
 	builtin, builtinErr := isBuiltinModule(name)
 	state, statusErr := moduleStatus(name)
 	return (builtinErr == nil && builtin) || (statusErr == nil && state >= loading)
 }
 
+func isModuleInSysfs(name string) bool {
+	_, err := os.Stat(filepath.Join(sysModuleDir, name))
+	return err == nil
+}
+
 func getModulePath(name string) (string, error) {
 	var foundPath string
 	skipRemainingDirs := false
@@ -270,6 +281,11 @@ func moduleStatus(name string) (status, error) {
 
 func loadModuleWithDependencies(name, path string) error {
 	deps, err := getModuleDependencies(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		// without modules.dep (e.g. on OpenWrt) the dependencies are expected to be loaded already
+		log.Debugf("modules.dep not found, loading module %s without dependencies", name)
+		return loadModule(name, path)
+	}
 	if err != nil {
 		return fmt.Errorf("couldn't load list of module %s dependencies", name)
 	}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -126,9 +127,31 @@ func TestModuleStatus(t *testing.T) {
 	}
 }
 
+func TestIsModuleEnabledFromSysfs(t *testing.T) {
+	defer resetGlobals()
+	_, _ = createFiles(t)
+
+	sysModuleDir = t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(sysModuleDir, "wireguard"), 0755))
+
+	require.True(t, isModuleEnabled("wireguard"), "module listed in sysfs should be enabled")
+	require.False(t, isModuleEnabled("not_loaded_module"), "module missing in sysfs should not be enabled")
+}
+
+func TestGetModuleDependenciesWithoutModulesDep(t *testing.T) {
+	defer resetGlobals()
+	_, _ = createFiles(t)
+
+	require.NoError(t, os.Remove(filepath.Join(moduleRoot, "/modules.dep")))
+
+	_, err := getModuleDependencies("foo")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
 func resetGlobals() {
 	moduleLibDir = defaultModuleDir
 	moduleRoot = getModuleRoot()
+	sysModuleDir = "/sys/module"
 }
 
 func createFiles(t *testing.T) (string, []module) {
@@ -0,0 +1,8 @@
+//go:build !linux || android
+
+package iface
+
+// CheckInterfaceAvailable is a no-op on this platform, the interface creation fails if the name is taken
+func CheckInterfaceAvailable(_ string, _ string) error {
+	return nil
+}
@@ -0,0 +1,42 @@
+//go:build linux && !android
+
+package iface
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"golang.zx2c4.com/wireguard/wgctrl"
+)
+
+// CheckInterfaceAvailable verifies that the interface name isn't used by another interface.
+// An existing WireGuard interface with the same public key is a leftover of a previous run and can be reused.
+func CheckInterfaceAvailable(name string, publicKey string) error {
+	if _, err := net.InterfaceByName(name); err != nil {
+		// the interface doesn't exist
+		return nil
+	}
+
+	wg, err := wgctrl.New()
+	if err != nil {
+		return fmt.Errorf("create wgctrl client: %w", err)
+	}
+	defer func() {
+		_ = wg.Close()
+	}()
+
+	device, err := wg.Device(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("interface %s already exists and is not a WireGuard interface, please choose another interface name", name)
+	}
+	if err != nil {
+		return fmt.Errorf("get WireGuard device %s: %w", name, err)
+	}
+
+	if device.PublicKey.String() != publicKey {
+		return fmt.Errorf("interface %s is already used by another WireGuard tunnel, please choose another interface name", name)
+	}
+	return nil
+}
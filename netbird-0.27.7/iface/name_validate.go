@@ -0,0 +1,47 @@
+package iface
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+const (
+	// maxLinuxInterfaceNameLength is IFNAMSIZ without the terminating NUL byte
+	maxLinuxInterfaceNameLength = 15
+	// maxWindowsInterfaceNameLength is the limit of the adapter name on Windows
+	maxWindowsInterfaceNameLength = 127
+)
+
+var utunNameRegex = regexp.MustCompile(`^utun[0-9]+$`)
+
+// ValidateInterfaceName checks that the name can be used for the WireGuard interface on the current platform
+func ValidateInterfaceName(name string) error {
+	return validateInterfaceName(runtime.GOOS, name)
+}
+
+func validateInterfaceName(goos, name string) error {
+	if name == "" {
+		return fmt.Errorf("interface name can't be empty")
+	}
+
+	switch goos {
+	case "darwin", "ios":
+		if !utunNameRegex.MatchString(name) {
+			return fmt.Errorf("invalid interface name %s. Please use the prefix utun followed by a number on MacOS. e.g., utun1 or utun199", name)
+		}
+	case "windows":
+		if len(name) > maxWindowsInterfaceNameLength {
+			return fmt.Errorf("invalid interface name %s. It can't be longer than %d characters", name, maxWindowsInterfaceNameLength)
+		}
+	default:
+		if len(name) > maxLinuxInterfaceNameLength {
+			return fmt.Errorf("invalid interface name %s. It can't be longer than %d characters", name, maxLinuxInterfaceNameLength)
+		}
+		if name == "." || name == ".." || strings.ContainsAny(name, "/: \t\n\x00") {
+			return fmt.Errorf("invalid interface name %s. It can't contain slashes, colons or whitespaces", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package iface
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateInterfaceName(t *testing.T) {
+	testCases := []struct {
+		name    string
+		goos    string
+		iface   string
+		wantErr bool
+	}{
+		{name: "linux default", goos: "linux", iface: "wt0"},
+		{name: "linux custom", goos: "linux", iface: "nbwg0"},
+		{name: "linux empty", goos: "linux", iface: "", wantErr: true},
+		{name: "linux too long", goos: "linux", iface: "netbird-tunnel-01", wantErr: true},
+		{name: "linux slash", goos: "linux", iface: "wt/0", wantErr: true},
+		{name: "linux alias", goos: "linux", iface: "eth0:1", wantErr: true},
+		{name: "linux space", goos: "linux", iface: "wt 0", wantErr: true},
+		{name: "linux dot", goos: "linux", iface: "..", wantErr: true},
+		{name: "darwin utun", goos: "darwin", iface: "utun100"},
+		{name: "darwin no number", goos: "darwin", iface: "utun", wantErr: true},
+		{name: "darwin wrong prefix", goos: "darwin", iface: "wt0", wantErr: true},
+		{name: "windows long", goos: "windows", iface: "NetBird Tunnel"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateInterfaceName(tc.goos, tc.iface)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -28,6 +28,22 @@ func ListenAddr() string {
 	return listenAddr(port)
 }
 
+// HTTPProxyListenAddr returns the listen address of the HTTP proxy, the proxy is disabled if it is empty
+func HTTPProxyListenAddr() string {
+	sPort, ok := os.LookupEnv("NB_HTTP_PROXY_LISTENER_PORT")
+	if !ok || sPort == "" {
+		return ""
+	}
+
+	port, err := strconv.Atoi(sPort)
+	if err != nil || port < 1 || port > 65535 {
+		log.Warnf("invalid http proxy listener port %q, it should be in the range 1-65535, http proxy is disabled", sPort)
+		return ""
+	}
+
+	return listenAddr(port)
+}
+
 func listenAddr(port int) string {
 	return fmt.Sprintf("0.0.0.0:%d", port)
 }
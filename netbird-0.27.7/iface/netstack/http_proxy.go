@@ -0,0 +1,147 @@
+package netstack
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	httpProxyReadHeaderTimeout = 30 * time.Second
+	httpProxyIdleConnTimeout   = 90 * time.Second
+)
+
+// HTTPProxy is a forward HTTP proxy that dials through the netstack. It supports CONNECT tunnels
+// and plain HTTP requests with an absolute URL
+type HTTPProxy struct {
+	dialer Dialer
+	server *http.Server
+	// the transport is shared by the requests so the connections through the netstack are reused
+	transport    *http.Transport
+	reverseProxy *httputil.ReverseProxy
+}
+
+func NewHTTPProxy(dialer Dialer) *HTTPProxy {
+	p := &HTTPProxy{
+		dialer: dialer,
+		transport: &http.Transport{
+			DialContext:     dialer.Dial,
+			IdleConnTimeout: httpProxyIdleConnTimeout,
+		},
+	}
+	p.reverseProxy = &httputil.ReverseProxy{
+		Director:  func(*http.Request) {},
+		Transport: p.transport,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.Debugf("failed to proxy request to %s: %s", r.URL.Host, err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	p.server = &http.Server{
+		Handler:           p,
+		ReadHeaderTimeout: httpProxyReadHeaderTimeout,
+	}
+	return p
+}
+
+func (p *HTTPProxy) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Errorf("failed to create listener for http proxy: %s", err)
+		return err
+	}
+
+	err = p.server.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func (p *HTTPProxy) Close() error {
+	err := p.server.Close()
+	p.transport.CloseIdleConnections()
+	return err
+}
+
+func (p *HTTPProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.serveConnect(w, r)
+		return
+	}
+
+	if !r.URL.IsAbs() {
+		http.Error(w, "this is a proxy, requests must use an absolute URL", http.StatusBadRequest)
+		return
+	}
+
+	p.reverseProxy.ServeHTTP(w, r)
+}
+
+func (p *HTTPProxy) serveConnect(w http.ResponseWriter, r *http.Request) {
+	remote, err := p.dialer.Dial(r.Context(), "tcp", r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		_ = remote.Close()
+		http.Error(w, "hijacking is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	client, buf, err := hijacker.Hijack()
+	if err != nil {
+		_ = remote.Close()
+		log.Errorf("failed to hijack http proxy connection: %s", err)
+		return
+	}
+
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+		_ = remote.Close()
+		_ = client.Close()
+		return
+	}
+
+	// forward the data the client might have sent along with the CONNECT request
+	if buf.Reader.Buffered() > 0 {
+		if _, err := io.CopyN(remote, buf, int64(buf.Reader.Buffered())); err != nil {
+			_ = remote.Close()
+			_ = client.Close()
+			return
+		}
+	}
+
+	pipe(client, remote)
+}
+
+// pipe copies data in both directions until one side is done
+func pipe(a, b net.Conn) {
+	var once sync.Once
+	closeBoth := func() {
+		_ = a.Close()
+		_ = b.Close()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(a, b)
+		once.Do(closeBoth)
+	}()
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(b, a)
+		once.Do(closeBoth)
+	}()
+	wg.Wait()
+}
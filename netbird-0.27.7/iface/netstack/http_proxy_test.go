@@ -0,0 +1,125 @@
+package netstack
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testDialer struct {
+	dialed []string
+}
+
+func (d *testDialer) Dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	d.dialed = append(d.dialed, addr)
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, network, addr)
+}
+
+func TestHTTPProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "hello from the mesh")
+	}))
+	defer backend.Close()
+
+	tlsBackend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "hello over tls")
+	}))
+	defer tlsBackend.Close()
+
+	dialer := &testDialer{}
+	proxyServer := httptest.NewServer(NewHTTPProxy(dialer))
+	defer proxyServer.Close()
+
+	proxyURL, err := url.Parse(proxyServer.URL)
+	require.NoError(t, err)
+
+	t.Run("plain request", func(t *testing.T) {
+		client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+		resp, err := client.Get(backend.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "hello from the mesh", string(body))
+	})
+
+	t.Run("connect tunnel", func(t *testing.T) {
+		transport := tlsBackend.Client().Transport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(proxyURL)
+		client := &http.Client{Transport: transport}
+		resp, err := client.Get(tlsBackend.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "hello over tls", string(body))
+	})
+
+	t.Run("relative url", func(t *testing.T) {
+		resp, err := http.Get(proxyServer.URL + "/path")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	assert.Contains(t, dialer.dialed, backend.Listener.Addr().String())
+	assert.Contains(t, dialer.dialed, tlsBackend.Listener.Addr().String())
+}
+
+func TestHTTPProxyReusesConnections(t *testing.T) {
+	var mu sync.Mutex
+	connStates := map[net.Conn]http.ConnState{}
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "hello from the mesh")
+	}))
+	backend.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		mu.Lock()
+		defer mu.Unlock()
+		connStates[conn] = state
+	}
+	backend.Start()
+	defer backend.Close()
+
+	dialer := &testDialer{}
+	proxy := NewHTTPProxy(dialer)
+	proxyServer := httptest.NewServer(proxy)
+	defer proxyServer.Close()
+
+	proxyURL, err := url.Parse(proxyServer.URL)
+	require.NoError(t, err)
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+	for i := 0; i < 5; i++ {
+		resp, err := client.Get(backend.URL)
+		require.NoError(t, err)
+		_, err = io.Copy(io.Discard, resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	assert.Len(t, dialer.dialed, 1, "the requests should reuse the connection to the backend")
+
+	require.NoError(t, proxy.Close())
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, state := range connStates {
+			if state != http.StateClosed {
+				return false
+			}
+		}
+		return len(connStates) == 1
+	}, 5*time.Second, 10*time.Millisecond, "closing the proxy should close the idle connections to the backend")
+}
@@ -9,19 +9,24 @@ import (
 )
 
 type NetStackTun struct { //nolint:revive
-	address       string
-	mtu           int
-	listenAddress string
+	address           string
+	mtu               int
+	listenAddress     string
+	httpListenAddress string
 
-	proxy  *Proxy
-	tundev tun.Device
+	proxy     *Proxy
+	httpProxy *HTTPProxy
+	tundev    tun.Device
 }
 
-func NewNetStackTun(listenAddress string, address string, mtu int) *NetStackTun {
+// NewNetStackTun creates a netstack device with a SOCKS5 proxy on listenAddress and,
+// if httpListenAddress is not empty, an HTTP proxy on httpListenAddress
+func NewNetStackTun(listenAddress string, httpListenAddress string, address string, mtu int) *NetStackTun {
 	return &NetStackTun{
-		address:       address,
-		mtu:           mtu,
-		listenAddress: listenAddress,
+		address:           address,
+		mtu:               mtu,
+		listenAddress:     listenAddress,
+		httpListenAddress: httpListenAddress,
 	}
 }
 
@@ -49,6 +54,16 @@ func (t *NetStackTun) Create() (tun.Device, error) {
 		}
 	}()
 
+	if t.httpListenAddress != "" {
+		t.httpProxy = NewHTTPProxy(dialer)
+		go func() {
+			err := t.httpProxy.ListenAndServe(t.httpListenAddress)
+			if err != nil {
+				log.Errorf("error in http proxy serving: %s", err)
+			}
+		}()
+	}
+
 	return nsTunDev, nil
 }
 
@@ -62,6 +77,14 @@ func (t *NetStackTun) Close() error {
 		}
 	}
 
+	if t.httpProxy != nil {
+		pErr := t.httpProxy.Close()
+		if pErr != nil {
+			log.Errorf("failed to close http proxy: %s", pErr)
+			err = pErr
+		}
+	}
+
 	if t.tundev != nil {
 		dErr := t.tundev.Close()
 		if dErr != nil {
@@ -11,6 +11,7 @@ type wgTunDevice interface {
 	Create() (wgConfigurer, error)
 	Up() (*bind.UniversalUDPMuxDefault, error)
 	UpdateAddr(address WGAddress) error
+	UpdateMTU(mtu int) error
 	WgAddress() WGAddress
 	DeviceName() string
 	Close() error
@@ -94,10 +94,17 @@ func (t *wgTunDevice) Up() (*bind.UniversalUDPMuxDefault, error) {
 }
 
 func (t *wgTunDevice) UpdateAddr(addr WGAddress) error {
-	// todo implement
+	if err := t.tunAdapter.UpdateAddr(addr.String()); err != nil {
+		return err
+	}
+	t.address = addr
 	return nil
 }
 
+func (t *wgTunDevice) UpdateMTU(int) error {
+	return errMTUUpdateNotSupported
+}
+
 func (t *wgTunDevice) Close() error {
 	if t.configurer != nil {
 		t.configurer.close()
@@ -5,6 +5,8 @@ package iface
 
 import (
 	"os/exec"
+	"strconv"
+	"strings"
 
 	"github.com/pion/transport/v3"
 	log "github.com/sirupsen/logrus"
@@ -89,6 +91,16 @@ func (t *tunDevice) UpdateAddr(address WGAddress) error {
 	return t.assignAddr()
 }
 
+func (t *tunDevice) UpdateMTU(mtu int) error {
+	cmd := exec.Command("ifconfig", t.name, "mtu", strconv.Itoa(mtu))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		log.Infof(`setting MTU command "%v" failed with output %s and error: `, cmd.String(), out)
+		return err
+	}
+	t.mtu = mtu
+	return nil
+}
+
 func (t *tunDevice) Close() error {
 	if t.configurer != nil {
 		t.configurer.close()
@@ -127,6 +139,10 @@ func (t *tunDevice) assignAddr() error {
 
 	routeCmd := exec.Command("route", "add", "-net", t.address.Network.String(), "-interface", t.name)
 	if out, err := routeCmd.CombinedOutput(); err != nil {
+		// the network route is still in place when the address is updated within the same network
+		if strings.Contains(string(out), "File exists") {
+			return nil
+		}
 		log.Printf(`adding route command "%v" failed with output %s and error: `, routeCmd.String(), out)
 		return err
 	}
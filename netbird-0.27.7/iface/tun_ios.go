@@ -123,9 +123,12 @@ func (t *tunDevice) WgAddress() WGAddress {
 	return t.address
 }
 
-func (t *tunDevice) UpdateAddr(addr WGAddress) error {
-	// todo implement
-	return nil
+func (t *tunDevice) UpdateAddr(WGAddress) error {
+	return ErrAddrUpdateNotSupported
+}
+
+func (t *tunDevice) UpdateMTU(int) error {
+	return errMTUUpdateNotSupported
 }
 
 func (t *tunDevice) Wrapper() *DeviceWrapper {
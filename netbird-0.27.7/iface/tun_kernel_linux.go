@@ -136,6 +136,19 @@ func (t *tunKernelDevice) UpdateAddr(address WGAddress) error {
 	return t.assignAddr()
 }
 
+func (t *tunKernelDevice) UpdateMTU(mtu int) error {
+	if t.link == nil {
+		return fmt.Errorf("device is not ready yet")
+	}
+
+	log.Debugf("setting MTU: %d interface: %s", mtu, t.name)
+	if err := netlink.LinkSetMTU(t.link, mtu); err != nil {
+		return err
+	}
+	t.mtu = mtu
+	return nil
+}
+
 func (t *tunKernelDevice) Close() error {
 	if t.link == nil {
 		return nil
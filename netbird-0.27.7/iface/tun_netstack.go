@@ -21,6 +21,7 @@ type tunNetstackDevice struct {
 	key           string
 	mtu           int
 	listenAddress string
+	httpAddress   string
 	iceBind       *bind.ICEBind
 
 	device     *device.Device
@@ -30,7 +31,7 @@ type tunNetstackDevice struct {
 	configurer wgConfigurer
 }
 
-func newTunNetstackDevice(name string, address WGAddress, wgPort int, key string, mtu int, transportNet transport.Net, listenAddress string) wgTunDevice {
+func newTunNetstackDevice(name string, address WGAddress, wgPort int, key string, mtu int, transportNet transport.Net, listenAddress string, httpAddress string) wgTunDevice {
 	return &tunNetstackDevice{
 		name:          name,
 		address:       address,
@@ -38,13 +39,14 @@ func newTunNetstackDevice(name string, address WGAddress, wgPort int, key string
 		key:           key,
 		mtu:           mtu,
 		listenAddress: listenAddress,
+		httpAddress:   httpAddress,
 		iceBind:       bind.NewICEBind(transportNet),
 	}
 }
 
 func (t *tunNetstackDevice) Create() (wgConfigurer, error) {
 	log.Info("create netstack tun interface")
-	t.nsTun = netstack.NewNetStackTun(t.listenAddress, t.address.IP.String(), t.mtu)
+	t.nsTun = netstack.NewNetStackTun(t.listenAddress, t.httpAddress, t.address.IP.String(), t.mtu)
 	tunIface, err := t.nsTun.Create()
 	if err != nil {
 		return nil, err
@@ -87,8 +89,13 @@ func (t *tunNetstackDevice) Up() (*bind.UniversalUDPMuxDefault, error) {
 	return udpMux, nil
 }
 
+// UpdateAddr is not supported because the address of the netstack is fixed when the stack is created
 func (t *tunNetstackDevice) UpdateAddr(WGAddress) error {
-	return nil
+	return ErrAddrUpdateNotSupported
+}
+
+func (t *tunNetstackDevice) UpdateMTU(int) error {
+	return errMTUUpdateNotSupported
 }
 
 func (t *tunNetstackDevice) Close() error {
@@ -98,6 +98,21 @@ func (t *tunUSPDevice) UpdateAddr(address WGAddress) error {
 	return t.assignAddr()
 }
 
+// UpdateMTU sets the MTU of the tun device, wireguard-go picks up the change from the netlink events
+func (t *tunUSPDevice) UpdateMTU(mtu int) error {
+	link, err := netlink.LinkByName(t.name)
+	if err != nil {
+		return fmt.Errorf("get link %s: %w", t.name, err)
+	}
+
+	log.Debugf("setting MTU: %d interface: %s", mtu, t.name)
+	if err := netlink.LinkSetMTU(link, mtu); err != nil {
+		return err
+	}
+	t.mtu = mtu
+	return nil
+}
+
 func (t *tunUSPDevice) Close() error {
 	if t.configurer != nil {
 		t.configurer.close()
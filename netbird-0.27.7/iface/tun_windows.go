@@ -106,6 +106,26 @@ func (t *tunDevice) UpdateAddr(address WGAddress) error {
 	return t.assignAddr()
 }
 
+func (t *tunDevice) UpdateMTU(mtu int) error {
+	if t.nativeTunDevice == nil {
+		return fmt.Errorf("device is not ready yet")
+	}
+
+	luid := winipcfg.LUID(t.nativeTunDevice.LUID())
+	nbiface, err := luid.IPInterface(windows.AF_INET)
+	if err != nil {
+		return fmt.Errorf("got error when getting ip interface %s", err)
+	}
+
+	nbiface.NLMTU = uint32(mtu)
+	if err := nbiface.Set(); err != nil {
+		return fmt.Errorf("got error when getting setting the interface mtu: %s", err)
+	}
+	t.nativeTunDevice.ForceMTU(mtu)
+	t.mtu = mtu
+	return nil
+}
+
 func (t *tunDevice) Close() error {
 	if t.configurer != nil {
 		t.configurer.close()
@@ -14,5 +14,7 @@ type wgConfigurer interface {
 	addAllowedIP(peerKey string, allowedIP string) error
 	removeAllowedIP(peerKey string, allowedIP string) error
 	close()
+	suspend() error
 	getStats(peerKey string) (WGStats, error)
+	getAllStats() (map[string]WGStats, error)
 }
@@ -208,14 +208,51 @@ func (c *wgKernelConfigurer) configure(config wgtypes.Config) error {
 func (c *wgKernelConfigurer) close() {
 }
 
+// suspend is a no-op, the kernel owns the sockets and the peer timers stop once the peers are removed
+func (c *wgKernelConfigurer) suspend() error {
+	return nil
+}
+
 func (c *wgKernelConfigurer) getStats(peerKey string) (WGStats, error) {
 	peer, err := c.getPeer(c.deviceName, peerKey)
 	if err != nil {
 		return WGStats{}, fmt.Errorf("get wireguard stats: %w", err)
 	}
-	return WGStats{
+	return kernelPeerStats(peer), nil
+}
+
+func (c *wgKernelConfigurer) getAllStats() (map[string]WGStats, error) {
+	wg, err := wgctrl.New()
+	if err != nil {
+		return nil, fmt.Errorf("wgctl: %w", err)
+	}
+	defer func() {
+		err = wg.Close()
+		if err != nil {
+			log.Errorf("got error while closing wgctl: %v", err)
+		}
+	}()
+
+	wgDevice, err := wg.Device(c.deviceName)
+	if err != nil {
+		return nil, fmt.Errorf("get wireguard device: %w", err)
+	}
+
+	stats := make(map[string]WGStats, len(wgDevice.Peers))
+	for _, peer := range wgDevice.Peers {
+		stats[peer.PublicKey.String()] = kernelPeerStats(peer)
+	}
+	return stats, nil
+}
+
+func kernelPeerStats(peer wgtypes.Peer) WGStats {
+	stats := WGStats{
 		LastHandshake: peer.LastHandshakeTime,
 		TxBytes:       peer.TransmitBytes,
 		RxBytes:       peer.ReceiveBytes,
-	}, nil
+	}
+	if peer.Endpoint != nil {
+		stats.Endpoint = peer.Endpoint.String()
+	}
+	return stats
 }
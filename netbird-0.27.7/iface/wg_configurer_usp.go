@@ -204,6 +204,11 @@ func (t *wgUSPConfigurer) startUAPI() {
 	}(t.uapiListener)
 }
 
+// suspend brings the wireguard-go device down, it closes the bind sockets and stops the peer timers
+func (t *wgUSPConfigurer) suspend() error {
+	return t.device.Down()
+}
+
 func (t *wgUSPConfigurer) close() {
 	if t.uapiListener != nil {
 		err := t.uapiListener.Close()
@@ -253,11 +258,87 @@ func (t *wgUSPConfigurer) getStats(peerKey string) (WGStats, error) {
 		return WGStats{}, fmt.Errorf("parse rx_bytes: %w", err)
 	}
 
-	return WGStats{
+	peerStats := WGStats{
 		LastHandshake: time.Unix(sec, nsec),
 		TxBytes:       txBytes,
 		RxBytes:       rxBytes,
-	}, nil
+	}
+
+	// the endpoint is only present once the peer has one
+	if endpoint, err := findPeerInfo(ipc, peerKey, []string{"endpoint"}); err == nil {
+		peerStats.Endpoint = endpoint["endpoint"]
+	}
+
+	return peerStats, nil
+}
+
+func (t *wgUSPConfigurer) getAllStats() (map[string]WGStats, error) {
+	ipc, err := t.device.IpcGet()
+	if err != nil {
+		return nil, fmt.Errorf("ipc get: %w", err)
+	}
+
+	return parseAllPeerStats(ipc)
+}
+
+// parseAllPeerStats parses the transfer statistics of all peers from the output of the userspace device's IpcGet
+func parseAllPeerStats(ipcInput string) (map[string]WGStats, error) {
+	stats := map[string]WGStats{}
+
+	var peerKey string
+	var peerStats WGStats
+	var handshakeSec, handshakeNsec int64
+	flush := func() {
+		if peerKey == "" {
+			return
+		}
+		if handshakeSec != 0 || handshakeNsec != 0 {
+			peerStats.LastHandshake = time.Unix(handshakeSec, handshakeNsec)
+		}
+		stats[peerKey] = peerStats
+	}
+
+	for _, line := range strings.Split(ipcInput, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+
+		var err error
+		switch key {
+		case "public_key":
+			flush()
+			var rawKey []byte
+			rawKey, err = hex.DecodeString(value)
+			if err != nil {
+				return nil, fmt.Errorf("parse public key: %w", err)
+			}
+			var wgKey wgtypes.Key
+			wgKey, err = wgtypes.NewKey(rawKey)
+			if err != nil {
+				return nil, fmt.Errorf("parse public key: %w", err)
+			}
+			peerKey = wgKey.String()
+			peerStats = WGStats{}
+			handshakeSec, handshakeNsec = 0, 0
+		case "endpoint":
+			peerStats.Endpoint = value
+		case "last_handshake_time_sec":
+			handshakeSec, err = strconv.ParseInt(value, 10, 64)
+		case "last_handshake_time_nsec":
+			handshakeNsec, err = strconv.ParseInt(value, 10, 64)
+		case "tx_bytes":
+			peerStats.TxBytes, err = strconv.ParseInt(value, 10, 64)
+		case "rx_bytes":
+			peerStats.RxBytes, err = strconv.ParseInt(value, 10, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", key, err)
+		}
+	}
+	flush()
+
+	return stats, nil
 }
 
 func findPeerInfo(ipcInput string, peerKey string, searchConfigKeys []string) (map[string]string, error) {
@@ -102,3 +102,28 @@ func Test_findPeerInfo(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseAllPeerStats(t *testing.T) {
+	stats, err := parseAllPeerStats(ipcFixture)
+	require.NoError(t, err)
+	require.Len(t, stats, 3)
+
+	peerKey := func(hexKey string) string {
+		raw, err := hex.DecodeString(hexKey)
+		require.NoError(t, err)
+		key, err := wgtypes.NewKey(raw)
+		require.NoError(t, err)
+		return key.String()
+	}
+
+	first := stats[peerKey("b85996fecc9c7f1fc6d2572a76eda11d59bcd20be8e543b15ce4bd85a8e75a33")]
+	assert.Equal(t, "[abcd:23::33%2]:51820", first.Endpoint)
+	assert.Zero(t, first.TxBytes)
+	assert.True(t, first.LastHandshake.IsZero())
+
+	second := stats[peerKey("58402e695ba1772b1cc9309755f043251ea77fdcf10fbe63989ceb7e19321376")]
+	assert.Equal(t, WGStats{TxBytes: 38333, RxBytes: 2224, Endpoint: "182.122.22.19:3233"}, second)
+
+	third := stats[peerKey("662e14fd594556f522604703340351258903b64f35553763f19426ab2a515c58")]
+	assert.Equal(t, WGStats{TxBytes: 1212111, RxBytes: 1929999999, Endpoint: "5.152.198.39:51820"}, third)
+}
@@ -0,0 +1,103 @@
+package wgcrypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Result is the throughput of a cipher measured by Benchmark
+type Result struct {
+	// Cipher is the name of the cipher
+	Cipher string
+	// Packets is the number of packets sealed
+	Packets int
+	// Duration is the time spent sealing the packets
+	Duration time.Duration
+	// PacketSize is the payload size of each packet in bytes
+	PacketSize int
+}
+
+// BytesPerSecond returns the payload throughput
+func (r Result) BytesPerSecond() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Packets) * float64(r.PacketSize) / r.Duration.Seconds()
+}
+
+// PacketsPerSecond returns the packet rate
+func (r Result) PacketsPerSecond() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Packets) / r.Duration.Seconds()
+}
+
+// Benchmark seals packets of the given size with ChaCha20-Poly1305, the cipher of the WireGuard data path,
+// and with AES-256-GCM for reference, for the given duration each
+func Benchmark(duration time.Duration, packetSize int) ([]Result, error) {
+	if packetSize <= 0 {
+		return nil, fmt.Errorf("invalid packet size %d", packetSize)
+	}
+
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+
+	chacha, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, fmt.Errorf("create ChaCha20-Poly1305 cipher: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create AES cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create AES-GCM cipher: %w", err)
+	}
+
+	return []Result{
+		seal("ChaCha20-Poly1305 (WireGuard)", chacha, duration, packetSize),
+		seal("AES-256-GCM (reference)", gcm, duration, packetSize),
+	}, nil
+}
+
+func seal(name string, aead cipher.AEAD, duration time.Duration, packetSize int) Result {
+	nonce := make([]byte, aead.NonceSize())
+	packet := make([]byte, packetSize)
+	out := make([]byte, 0, packetSize+aead.Overhead())
+
+	// the clock is checked every batch of packets to keep its cost out of the measurement
+	const batch = 64
+
+	var packets int
+	start := time.Now()
+	deadline := start.Add(duration)
+	for {
+		for i := 0; i < batch; i++ {
+			// the nonce is a counter like in WireGuard
+			nonce[4]++
+			out = aead.Seal(out[:0], nonce, packet, nil)
+		}
+		packets += batch
+
+		if time.Now().After(deadline) {
+			break
+		}
+	}
+
+	return Result{
+		Cipher:     name,
+		Packets:    packets,
+		Duration:   time.Since(start),
+		PacketSize: packetSize,
+	}
+}
@@ -0,0 +1,31 @@
+package wgcrypto
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBenchmark(t *testing.T) {
+	results, err := Benchmark(10*time.Millisecond, 1420)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	for _, r := range results {
+		assert.Positive(t, r.Packets, r.Cipher)
+		assert.Equal(t, 1420, r.PacketSize, r.Cipher)
+		assert.Positive(t, r.BytesPerSecond(), r.Cipher)
+	}
+
+	_, err = Benchmark(time.Millisecond, 0)
+	assert.Error(t, err)
+}
+
+func TestDetectFeatures(t *testing.T) {
+	f := DetectFeatures()
+	assert.NotEmpty(t, f.Arch)
+	assert.NotEmpty(t, f.String())
+	assert.NotEmpty(t, Implementation())
+}
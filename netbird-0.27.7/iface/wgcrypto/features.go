@@ -0,0 +1,122 @@
+// Package wgcrypto reports how the userspace WireGuard data path encrypts packets on the running CPU
+// and benchmarks the ciphers.
+//
+// WireGuard always uses ChaCha20-Poly1305. golang.org/x/crypto picks the assembly implementation at
+// runtime from the CPU features, this package detects the same features to tell which one is in use.
+package wgcrypto
+
+import (
+	"runtime"
+	"strings"
+
+	"golang.org/x/sys/cpu"
+)
+
+// Features are the CPU features relevant for the packet encryption
+type Features struct {
+	// Arch is the CPU architecture, e.g. arm64 or mipsle
+	Arch string
+	// Vector is true if the CPU has vector instructions, e.g. NEON or AVX2
+	Vector bool
+	// AES is true if the CPU has AES instructions
+	AES bool
+	// Flags are the names of the detected features
+	Flags []string
+}
+
+// String returns the architecture followed by the detected features
+func (f Features) String() string {
+	if len(f.Flags) == 0 {
+		return f.Arch + " (no crypto or vector extensions)"
+	}
+	return f.Arch + " (" + strings.Join(f.Flags, ", ") + ")"
+}
+
+// DetectFeatures detects the CPU features of the running system
+func DetectFeatures() Features {
+	f := Features{Arch: runtime.GOARCH}
+
+	add := func(name string, vector, aes bool) {
+		f.Flags = append(f.Flags, name)
+		f.Vector = f.Vector || vector
+		f.AES = f.AES || aes
+	}
+
+	switch runtime.GOARCH {
+	case "amd64", "386":
+		if cpu.X86.HasSSSE3 {
+			add("ssse3", true, false)
+		}
+		if cpu.X86.HasAVX2 {
+			add("avx2", true, false)
+		}
+		if cpu.X86.HasBMI2 {
+			add("bmi2", false, false)
+		}
+		if cpu.X86.HasAES {
+			add("aes", false, true)
+		}
+	case "arm64":
+		if cpu.ARM64.HasASIMD {
+			add("neon", true, false)
+		}
+		if cpu.ARM64.HasAES {
+			add("aes", false, true)
+		}
+		if cpu.ARM64.HasPMULL {
+			add("pmull", false, false)
+		}
+	case "arm":
+		if cpu.ARM.HasNEON {
+			add("neon", true, false)
+		}
+		if cpu.ARM.HasAES {
+			add("aes", false, true)
+		}
+		if cpu.ARM.HasPMULL {
+			add("pmull", false, false)
+		}
+	case "ppc64le":
+		if cpu.PPC64.IsPOWER8 {
+			add("vsx", true, false)
+		}
+	case "s390x":
+		if cpu.S390X.HasVX {
+			add("vx", true, false)
+		}
+		if cpu.S390X.HasAES {
+			add("aes", false, true)
+		}
+	}
+
+	return f
+}
+
+// Implementation returns the ChaCha20-Poly1305 implementation golang.org/x/crypto uses on this CPU
+func Implementation() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		if cpu.X86.HasAVX2 && cpu.X86.HasBMI2 {
+			return "AVX2"
+		}
+		if cpu.X86.HasSSSE3 {
+			return "SSSE3"
+		}
+	case "arm64":
+		// ChaCha20 has a NEON implementation, Poly1305 is always generic on arm64
+		return "NEON ChaCha20, generic Poly1305"
+	case "ppc64le":
+		return "VSX"
+	case "s390x":
+		if cpu.S390X.HasVX {
+			return "vector"
+		}
+	}
+	return "generic"
+}
+
+// Accelerated returns true if the ChaCha20-Poly1305 implementation in use relies on vector instructions.
+// Without them the userspace data path is usually limited by the CPU on low-end routers.
+func Accelerated() bool {
+	return Implementation() != "generic"
+}
@@ -14,10 +14,23 @@ type Client interface {
 	io.Closer
 	Sync(ctx context.Context, msgHandler func(msg *proto.SyncResponse) error) error
 	GetServerPublicKey() (*wgtypes.Key, error)
-	Register(serverKey wgtypes.Key, setupKey string, jwtToken string, sysInfo *system.Info, sshKey []byte) (*proto.LoginResponse, error)
-	Login(serverKey wgtypes.Key, sysInfo *system.Info, sshKey []byte) (*proto.LoginResponse, error)
+	Register(serverKey wgtypes.Key, setupKey string, jwtToken string, sysInfo *system.Info, sshKey []byte, requestedAddress string) (*proto.LoginResponse, error)
+	Login(serverKey wgtypes.Key, sysInfo *system.Info, sshKey []byte, requestedAddress string) (*proto.LoginResponse, error)
 	GetDeviceAuthorizationFlow(serverKey wgtypes.Key) (*proto.DeviceAuthorizationFlow, error)
 	GetPKCEAuthorizationFlow(serverKey wgtypes.Key) (*proto.PKCEAuthorizationFlow, error)
 	GetNetworkMap() (*proto.NetworkMap, error)
+	ReportPeerStatus(report *proto.PeerStatusReport) error
+	ProposeRoutes(req *proto.ProposeRoutesRequest) (*proto.ProposeRoutesResponse, error)
+	ReportTransferStats(report *proto.TransferStatsReport) error
+	ReportDNSStats(report *proto.DNSStatsReport) error
+	ReportFirewallStats(report *proto.FirewallStatsReport) error
+	ReportDroppedConnections(report *proto.DroppedConnectionsReport) error
+	ReportSSHSession(report *proto.SSHSessionReport) error
+	ReportPeerAction(result *proto.PeerActionResult) error
+	RotatePeerKey(req *proto.RotatePeerKeyRequest) error
+	UploadDebugBundle(upload *proto.DebugBundleUpload) (*proto.DebugBundleUploadResponse, error)
+	ResolvePeer(req *proto.ResolvePeerRequest) (*proto.ResolvePeerResponse, error)
+	ReportSpeedtest(report *proto.SpeedtestReport) error
+	ReportPeerLoad(report *proto.PeerLoadReport) error
 	IsHealthy() bool
 }
@@ -29,6 +29,9 @@ import (
 
 const ConnectTimeout = 10 * time.Second
 
+// debugBundleUploadTimeout is the time a debug bundle upload can take
+const debugBundleUploadTimeout = 2 * time.Minute
+
 // ConnStateNotifier is a wrapper interface of the status recorders
 type ConnStateNotifier interface {
 	MarkManagementDisconnected(error)
@@ -346,21 +349,22 @@ func (c *GrpcClient) login(serverKey wgtypes.Key, req *proto.LoginRequest) (*pro
 // Register registers peer on Management Server. It actually calls a Login endpoint with a provided setup key
 // Takes care of encrypting and decrypting messages.
 // This method will also collect system info and send it with the request (e.g. hostname, os, etc)
-func (c *GrpcClient) Register(serverKey wgtypes.Key, setupKey string, jwtToken string, sysInfo *system.Info, pubSSHKey []byte) (*proto.LoginResponse, error) {
+// The requested address is the NetBird IP the peer asks for, it can be empty.
+func (c *GrpcClient) Register(serverKey wgtypes.Key, setupKey string, jwtToken string, sysInfo *system.Info, pubSSHKey []byte, requestedAddress string) (*proto.LoginResponse, error) {
 	keys := &proto.PeerKeys{
 		SshPubKey: pubSSHKey,
 		WgPubKey:  []byte(c.key.PublicKey().String()),
 	}
-	return c.login(serverKey, &proto.LoginRequest{SetupKey: setupKey, Meta: infoToMetaData(sysInfo), JwtToken: jwtToken, PeerKeys: keys})
+	return c.login(serverKey, &proto.LoginRequest{SetupKey: setupKey, Meta: infoToMetaData(sysInfo), JwtToken: jwtToken, PeerKeys: keys, RequestedAddress: requestedAddress})
 }
 
 // Login attempts login to Management Server. Takes care of encrypting and decrypting messages.
-func (c *GrpcClient) Login(serverKey wgtypes.Key, sysInfo *system.Info, pubSSHKey []byte) (*proto.LoginResponse, error) {
+func (c *GrpcClient) Login(serverKey wgtypes.Key, sysInfo *system.Info, pubSSHKey []byte, requestedAddress string) (*proto.LoginResponse, error) {
 	keys := &proto.PeerKeys{
 		SshPubKey: pubSSHKey,
 		WgPubKey:  []byte(c.key.PublicKey().String()),
 	}
-	return c.login(serverKey, &proto.LoginRequest{Meta: infoToMetaData(sysInfo), PeerKeys: keys})
+	return c.login(serverKey, &proto.LoginRequest{Meta: infoToMetaData(sysInfo), PeerKeys: keys, RequestedAddress: requestedAddress})
 }
 
 // GetDeviceAuthorizationFlow returns a device authorization flow information.
@@ -431,6 +435,385 @@ func (c *GrpcClient) GetPKCEAuthorizationFlow(serverKey wgtypes.Key) (*proto.PKC
 	return flowInfoResp, nil
 }
 
+// ReportPeerStatus sends the runtime state of the peer to the Management Service.
+// It also takes care of encrypting the message.
+func (c *GrpcClient) ReportPeerStatus(report *proto.PeerStatusReport) error {
+	if !c.ready() {
+		return fmt.Errorf("no connection to management in order to report peer status")
+	}
+
+	serverPubKey, err := c.GetServerPublicKey()
+	if err != nil {
+		log.Debugf("failed getting Management Service public key: %s", err)
+		return err
+	}
+
+	encryptedMSG, err := encryption.EncryptMessage(*serverPubKey, c.key, report)
+	if err != nil {
+		return err
+	}
+
+	mgmCtx, cancel := context.WithTimeout(c.ctx, ConnectTimeout)
+	defer cancel()
+	_, err = c.realClient.ReportPeerStatus(mgmCtx, &proto.EncryptedMessage{
+		WgPubKey: c.key.PublicKey().String(),
+		Body:     encryptedMSG,
+	})
+	return err
+}
+
+// ProposeRoutes proposes networks of the local network of the peer as routes to the Management Service.
+// It also takes care of encrypting and decrypting messages.
+func (c *GrpcClient) ProposeRoutes(req *proto.ProposeRoutesRequest) (*proto.ProposeRoutesResponse, error) {
+	if !c.ready() {
+		return nil, fmt.Errorf("no connection to management in order to propose routes")
+	}
+
+	serverPubKey, err := c.GetServerPublicKey()
+	if err != nil {
+		log.Debugf("failed getting Management Service public key: %s", err)
+		return nil, err
+	}
+
+	encryptedMSG, err := encryption.EncryptMessage(*serverPubKey, c.key, req)
+	if err != nil {
+		return nil, err
+	}
+
+	mgmCtx, cancel := context.WithTimeout(c.ctx, ConnectTimeout)
+	defer cancel()
+	resp, err := c.realClient.ProposeRoutes(mgmCtx, &proto.EncryptedMessage{
+		WgPubKey: c.key.PublicKey().String(),
+		Body:     encryptedMSG,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	proposeResp := &proto.ProposeRoutesResponse{}
+	if err := encryption.DecryptMessage(*serverPubKey, c.key, resp.Body, proposeResp); err != nil {
+		return nil, fmt.Errorf("failed to decrypt propose routes response: %w", err)
+	}
+
+	return proposeResp, nil
+}
+
+// ReportTransferStats sends the WireGuard transfer statistics of the peer to the Management Service.
+// It also takes care of encrypting the message.
+func (c *GrpcClient) ReportTransferStats(report *proto.TransferStatsReport) error {
+	if !c.ready() {
+		return fmt.Errorf("no connection to management in order to report transfer stats")
+	}
+
+	serverPubKey, err := c.GetServerPublicKey()
+	if err != nil {
+		log.Debugf("failed getting Management Service public key: %s", err)
+		return err
+	}
+
+	encryptedMSG, err := encryption.EncryptMessage(*serverPubKey, c.key, report)
+	if err != nil {
+		return err
+	}
+
+	mgmCtx, cancel := context.WithTimeout(c.ctx, ConnectTimeout)
+	defer cancel()
+	_, err = c.realClient.ReportTransferStats(mgmCtx, &proto.EncryptedMessage{
+		WgPubKey: c.key.PublicKey().String(),
+		Body:     encryptedMSG,
+	})
+	return err
+}
+
+// ReportDNSStats sends the counters of the DNS query log of the peer to the Management Service.
+// It also takes care of encrypting the message.
+func (c *GrpcClient) ReportDNSStats(report *proto.DNSStatsReport) error {
+	if !c.ready() {
+		return fmt.Errorf("no connection to management in order to report DNS stats")
+	}
+
+	serverPubKey, err := c.GetServerPublicKey()
+	if err != nil {
+		log.Debugf("failed getting Management Service public key: %s", err)
+		return err
+	}
+
+	encryptedMSG, err := encryption.EncryptMessage(*serverPubKey, c.key, report)
+	if err != nil {
+		return err
+	}
+
+	mgmCtx, cancel := context.WithTimeout(c.ctx, ConnectTimeout)
+	defer cancel()
+	_, err = c.realClient.ReportDNSStats(mgmCtx, &proto.EncryptedMessage{
+		WgPubKey: c.key.PublicKey().String(),
+		Body:     encryptedMSG,
+	})
+	return err
+}
+
+// ReportFirewallStats sends the counters of the firewall rules of the peer by policy to the Management Service.
+// It also takes care of encrypting the message.
+func (c *GrpcClient) ReportFirewallStats(report *proto.FirewallStatsReport) error {
+	if !c.ready() {
+		return fmt.Errorf("no connection to management in order to report firewall stats")
+	}
+
+	serverPubKey, err := c.GetServerPublicKey()
+	if err != nil {
+		log.Debugf("failed getting Management Service public key: %s", err)
+		return err
+	}
+
+	encryptedMSG, err := encryption.EncryptMessage(*serverPubKey, c.key, report)
+	if err != nil {
+		return err
+	}
+
+	mgmCtx, cancel := context.WithTimeout(c.ctx, ConnectTimeout)
+	defer cancel()
+	_, err = c.realClient.ReportFirewallStats(mgmCtx, &proto.EncryptedMessage{
+		WgPubKey: c.key.PublicKey().String(),
+		Body:     encryptedMSG,
+	})
+	return err
+}
+
+// ReportDroppedConnections sends the latest inbound connections dropped by the firewall of the peer to the Management Service.
+// It also takes care of encrypting the message.
+func (c *GrpcClient) ReportDroppedConnections(report *proto.DroppedConnectionsReport) error {
+	if !c.ready() {
+		return fmt.Errorf("no connection to management in order to report dropped connections")
+	}
+
+	serverPubKey, err := c.GetServerPublicKey()
+	if err != nil {
+		log.Debugf("failed getting Management Service public key: %s", err)
+		return err
+	}
+
+	encryptedMSG, err := encryption.EncryptMessage(*serverPubKey, c.key, report)
+	if err != nil {
+		return err
+	}
+
+	mgmCtx, cancel := context.WithTimeout(c.ctx, ConnectTimeout)
+	defer cancel()
+	_, err = c.realClient.ReportDroppedConnections(mgmCtx, &proto.EncryptedMessage{
+		WgPubKey: c.key.PublicKey().String(),
+		Body:     encryptedMSG,
+	})
+	return err
+}
+
+// ReportSSHSession sends an SSH session to the embedded SSH server of the peer that ended to the Management Service.
+// It also takes care of encrypting the message.
+func (c *GrpcClient) ReportSSHSession(report *proto.SSHSessionReport) error {
+	if !c.ready() {
+		return fmt.Errorf("no connection to management in order to report the SSH session")
+	}
+
+	serverPubKey, err := c.GetServerPublicKey()
+	if err != nil {
+		log.Debugf("failed getting Management Service public key: %s", err)
+		return err
+	}
+
+	encryptedMSG, err := encryption.EncryptMessage(*serverPubKey, c.key, report)
+	if err != nil {
+		return err
+	}
+
+	mgmCtx, cancel := context.WithTimeout(c.ctx, ConnectTimeout)
+	defer cancel()
+	_, err = c.realClient.ReportSSHSession(mgmCtx, &proto.EncryptedMessage{
+		WgPubKey: c.key.PublicKey().String(),
+		Body:     encryptedMSG,
+	})
+	return err
+}
+
+// ReportPeerAction sends the result of a remote action the Management Service requested.
+// It also takes care of encrypting the message.
+func (c *GrpcClient) ReportPeerAction(result *proto.PeerActionResult) error {
+	if !c.ready() {
+		return fmt.Errorf("no connection to management in order to report the peer action")
+	}
+
+	serverPubKey, err := c.GetServerPublicKey()
+	if err != nil {
+		log.Debugf("failed getting Management Service public key: %s", err)
+		return err
+	}
+
+	encryptedMSG, err := encryption.EncryptMessage(*serverPubKey, c.key, result)
+	if err != nil {
+		return err
+	}
+
+	mgmCtx, cancel := context.WithTimeout(c.ctx, ConnectTimeout)
+	defer cancel()
+	_, err = c.realClient.ReportPeerAction(mgmCtx, &proto.EncryptedMessage{
+		WgPubKey: c.key.PublicKey().String(),
+		Body:     encryptedMSG,
+	})
+	return err
+}
+
+// RotatePeerKey sends the WireGuard public key the peer generated for a key rotation to the Management Service,
+// the request is signed with the current key.
+// It also takes care of encrypting the message.
+func (c *GrpcClient) RotatePeerKey(req *proto.RotatePeerKeyRequest) error {
+	if !c.ready() {
+		return fmt.Errorf("no connection to management in order to rotate the peer key")
+	}
+
+	serverPubKey, err := c.GetServerPublicKey()
+	if err != nil {
+		log.Debugf("failed getting Management Service public key: %s", err)
+		return err
+	}
+
+	encryptedMSG, err := encryption.EncryptMessage(*serverPubKey, c.key, req)
+	if err != nil {
+		return err
+	}
+
+	mgmCtx, cancel := context.WithTimeout(c.ctx, ConnectTimeout)
+	defer cancel()
+	_, err = c.realClient.RotatePeerKey(mgmCtx, &proto.EncryptedMessage{
+		WgPubKey: c.key.PublicKey().String(),
+		Body:     encryptedMSG,
+	})
+	return err
+}
+
+// UploadDebugBundle sends a debug bundle of the peer to the Management Service for support.
+// It also takes care of encrypting the message.
+func (c *GrpcClient) UploadDebugBundle(upload *proto.DebugBundleUpload) (*proto.DebugBundleUploadResponse, error) {
+	if !c.ready() {
+		return nil, fmt.Errorf("no connection to management in order to upload the debug bundle")
+	}
+
+	serverPubKey, err := c.GetServerPublicKey()
+	if err != nil {
+		log.Debugf("failed getting Management Service public key: %s", err)
+		return nil, err
+	}
+
+	encryptedMSG, err := encryption.EncryptMessage(*serverPubKey, c.key, upload)
+	if err != nil {
+		return nil, err
+	}
+
+	// the bundle takes longer than the other requests on slow uplinks
+	mgmCtx, cancel := context.WithTimeout(c.ctx, debugBundleUploadTimeout)
+	defer cancel()
+	resp, err := c.realClient.UploadDebugBundle(mgmCtx, &proto.EncryptedMessage{
+		WgPubKey: c.key.PublicKey().String(),
+		Body:     encryptedMSG,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uploadResp := &proto.DebugBundleUploadResponse{}
+	if err := encryption.DecryptMessage(*serverPubKey, c.key, resp.Body, uploadResp); err != nil {
+		return nil, fmt.Errorf("failed to decrypt debug bundle upload response: %w", err)
+	}
+
+	return uploadResp, nil
+}
+
+// ResolvePeer looks up a peer of the account by its name, FQDN, IP or WireGuard key on the Management Service
+func (c *GrpcClient) ResolvePeer(req *proto.ResolvePeerRequest) (*proto.ResolvePeerResponse, error) {
+	if !c.ready() {
+		return nil, fmt.Errorf("no connection to management in order to resolve the peer")
+	}
+
+	serverPubKey, err := c.GetServerPublicKey()
+	if err != nil {
+		log.Debugf("failed getting Management Service public key: %s", err)
+		return nil, err
+	}
+
+	encryptedMSG, err := encryption.EncryptMessage(*serverPubKey, c.key, req)
+	if err != nil {
+		return nil, err
+	}
+
+	mgmCtx, cancel := context.WithTimeout(c.ctx, ConnectTimeout)
+	defer cancel()
+	resp, err := c.realClient.ResolvePeer(mgmCtx, &proto.EncryptedMessage{
+		WgPubKey: c.key.PublicKey().String(),
+		Body:     encryptedMSG,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resolveResp := &proto.ResolvePeerResponse{}
+	if err := encryption.DecryptMessage(*serverPubKey, c.key, resp.Body, resolveResp); err != nil {
+		return nil, fmt.Errorf("failed to decrypt resolve peer response: %w", err)
+	}
+
+	return resolveResp, nil
+}
+
+// ReportSpeedtest sends the result of a throughput test the peer ran against another peer to the Management Service.
+// It also takes care of encrypting the message.
+func (c *GrpcClient) ReportSpeedtest(report *proto.SpeedtestReport) error {
+	if !c.ready() {
+		return fmt.Errorf("no connection to management in order to report the speed test")
+	}
+
+	serverPubKey, err := c.GetServerPublicKey()
+	if err != nil {
+		log.Debugf("failed getting Management Service public key: %s", err)
+		return err
+	}
+
+	encryptedMSG, err := encryption.EncryptMessage(*serverPubKey, c.key, report)
+	if err != nil {
+		return err
+	}
+
+	mgmCtx, cancel := context.WithTimeout(c.ctx, ConnectTimeout)
+	defer cancel()
+	_, err = c.realClient.ReportSpeedtest(mgmCtx, &proto.EncryptedMessage{
+		WgPubKey: c.key.PublicKey().String(),
+		Body:     encryptedMSG,
+	})
+	return err
+}
+
+// ReportPeerLoad sends the load of the routing peer to the Management Service.
+// It also takes care of encrypting the message.
+func (c *GrpcClient) ReportPeerLoad(report *proto.PeerLoadReport) error {
+	if !c.ready() {
+		return fmt.Errorf("no connection to management in order to report the peer load")
+	}
+
+	serverPubKey, err := c.GetServerPublicKey()
+	if err != nil {
+		log.Debugf("failed getting Management Service public key: %s", err)
+		return err
+	}
+
+	encryptedMSG, err := encryption.EncryptMessage(*serverPubKey, c.key, report)
+	if err != nil {
+		return err
+	}
+
+	mgmCtx, cancel := context.WithTimeout(c.ctx, ConnectTimeout)
+	defer cancel()
+	_, err = c.realClient.ReportPeerLoad(mgmCtx, &proto.EncryptedMessage{
+		WgPubKey: c.key.PublicKey().String(),
+		Body:     encryptedMSG,
+	})
+	return err
+}
+
 func (c *GrpcClient) notifyDisconnected(err error) {
 	c.connStateCallbackLock.RLock()
 	defer c.connStateCallbackLock.RUnlock()
@@ -483,5 +866,27 @@ func infoToMetaData(info *system.Info) *proto.PeerSystemMeta {
 			Cloud:    info.Environment.Cloud,
 			Platform: info.Environment.Platform,
 		},
+		Inventory: inventoryToProto(info.Inventory),
+	}
+}
+
+func inventoryToProto(inventory *system.Inventory) *proto.Inventory {
+	if inventory == nil {
+		return nil
+	}
+
+	packages := make([]*proto.Package, 0, len(inventory.Packages))
+	for _, p := range inventory.Packages {
+		packages = append(packages, &proto.Package{
+			Name:    p.Name,
+			Version: p.Version,
+		})
+	}
+
+	return &proto.Inventory{
+		PackageManager: inventory.PackageManager,
+		PatchLevel:     inventory.PatchLevel,
+		PackagesCount:  int32(inventory.PackagesCount),
+		Packages:       packages,
 	}
 }
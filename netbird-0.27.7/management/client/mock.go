@@ -13,10 +13,23 @@ type MockClient struct {
 	CloseFunc                      func() error
 	SyncFunc                       func(ctx context.Context, msgHandler func(msg *proto.SyncResponse) error) error
 	GetServerPublicKeyFunc         func() (*wgtypes.Key, error)
-	RegisterFunc                   func(serverKey wgtypes.Key, setupKey string, jwtToken string, info *system.Info, sshKey []byte) (*proto.LoginResponse, error)
-	LoginFunc                      func(serverKey wgtypes.Key, info *system.Info, sshKey []byte) (*proto.LoginResponse, error)
+	RegisterFunc                   func(serverKey wgtypes.Key, setupKey string, jwtToken string, info *system.Info, sshKey []byte, requestedAddress string) (*proto.LoginResponse, error)
+	LoginFunc                      func(serverKey wgtypes.Key, info *system.Info, sshKey []byte, requestedAddress string) (*proto.LoginResponse, error)
 	GetDeviceAuthorizationFlowFunc func(serverKey wgtypes.Key) (*proto.DeviceAuthorizationFlow, error)
 	GetPKCEAuthorizationFlowFunc   func(serverKey wgtypes.Key) (*proto.PKCEAuthorizationFlow, error)
+	ReportPeerStatusFunc           func(report *proto.PeerStatusReport) error
+	ProposeRoutesFunc              func(req *proto.ProposeRoutesRequest) (*proto.ProposeRoutesResponse, error)
+	ReportTransferStatsFunc        func(report *proto.TransferStatsReport) error
+	ReportDNSStatsFunc             func(report *proto.DNSStatsReport) error
+	ReportFirewallStatsFunc        func(report *proto.FirewallStatsReport) error
+	ReportDroppedConnectionsFunc   func(report *proto.DroppedConnectionsReport) error
+	ReportSSHSessionFunc           func(report *proto.SSHSessionReport) error
+	ReportPeerActionFunc           func(result *proto.PeerActionResult) error
+	RotatePeerKeyFunc              func(req *proto.RotatePeerKeyRequest) error
+	UploadDebugBundleFunc          func(upload *proto.DebugBundleUpload) (*proto.DebugBundleUploadResponse, error)
+	ResolvePeerFunc                func(req *proto.ResolvePeerRequest) (*proto.ResolvePeerResponse, error)
+	ReportSpeedtestFunc            func(report *proto.SpeedtestReport) error
+	ReportPeerLoadFunc             func(report *proto.PeerLoadReport) error
 }
 
 func (m *MockClient) IsHealthy() bool {
@@ -44,18 +57,18 @@ func (m *MockClient) GetServerPublicKey() (*wgtypes.Key, error) {
 	return m.GetServerPublicKeyFunc()
 }
 
-func (m *MockClient) Register(serverKey wgtypes.Key, setupKey string, jwtToken string, info *system.Info, sshKey []byte) (*proto.LoginResponse, error) {
+func (m *MockClient) Register(serverKey wgtypes.Key, setupKey string, jwtToken string, info *system.Info, sshKey []byte, requestedAddress string) (*proto.LoginResponse, error) {
 	if m.RegisterFunc == nil {
 		return nil, nil
 	}
-	return m.RegisterFunc(serverKey, setupKey, jwtToken, info, sshKey)
+	return m.RegisterFunc(serverKey, setupKey, jwtToken, info, sshKey, requestedAddress)
 }
 
-func (m *MockClient) Login(serverKey wgtypes.Key, info *system.Info, sshKey []byte) (*proto.LoginResponse, error) {
+func (m *MockClient) Login(serverKey wgtypes.Key, info *system.Info, sshKey []byte, requestedAddress string) (*proto.LoginResponse, error) {
 	if m.LoginFunc == nil {
 		return nil, nil
 	}
-	return m.LoginFunc(serverKey, info, sshKey)
+	return m.LoginFunc(serverKey, info, sshKey, requestedAddress)
 }
 
 func (m *MockClient) GetDeviceAuthorizationFlow(serverKey wgtypes.Key) (*proto.DeviceAuthorizationFlow, error) {
@@ -76,3 +89,107 @@ func (m *MockClient) GetPKCEAuthorizationFlow(serverKey wgtypes.Key) (*proto.PKC
 func (m *MockClient) GetNetworkMap() (*proto.NetworkMap, error) {
 	return nil, nil
 }
+
+// ReportPeerStatus mock implementation of ReportPeerStatus from mgm.Client interface
+func (m *MockClient) ReportPeerStatus(report *proto.PeerStatusReport) error {
+	if m.ReportPeerStatusFunc == nil {
+		return nil
+	}
+	return m.ReportPeerStatusFunc(report)
+}
+
+// ProposeRoutes mock implementation of ProposeRoutes from mgm.Client interface
+func (m *MockClient) ProposeRoutes(req *proto.ProposeRoutesRequest) (*proto.ProposeRoutesResponse, error) {
+	if m.ProposeRoutesFunc == nil {
+		return &proto.ProposeRoutesResponse{}, nil
+	}
+	return m.ProposeRoutesFunc(req)
+}
+
+// ReportTransferStats mock implementation of ReportTransferStats from mgm.Client interface
+func (m *MockClient) ReportTransferStats(report *proto.TransferStatsReport) error {
+	if m.ReportTransferStatsFunc == nil {
+		return nil
+	}
+	return m.ReportTransferStatsFunc(report)
+}
+
+// ReportDNSStats mock implementation of ReportDNSStats from mgm.Client interface
+func (m *MockClient) ReportDNSStats(report *proto.DNSStatsReport) error {
+	if m.ReportDNSStatsFunc == nil {
+		return nil
+	}
+	return m.ReportDNSStatsFunc(report)
+}
+
+// ReportFirewallStats mock implementation of ReportFirewallStats from mgm.Client interface
+func (m *MockClient) ReportFirewallStats(report *proto.FirewallStatsReport) error {
+	if m.ReportFirewallStatsFunc == nil {
+		return nil
+	}
+	return m.ReportFirewallStatsFunc(report)
+}
+
+// ReportDroppedConnections mock implementation of ReportDroppedConnections from mgm.Client interface
+func (m *MockClient) ReportDroppedConnections(report *proto.DroppedConnectionsReport) error {
+	if m.ReportDroppedConnectionsFunc == nil {
+		return nil
+	}
+	return m.ReportDroppedConnectionsFunc(report)
+}
+
+// ReportSSHSession mock implementation of ReportSSHSession from mgm.Client interface
+func (m *MockClient) ReportSSHSession(report *proto.SSHSessionReport) error {
+	if m.ReportSSHSessionFunc == nil {
+		return nil
+	}
+	return m.ReportSSHSessionFunc(report)
+}
+
+// ReportPeerAction mock implementation of ReportPeerAction from mgm.Client interface
+func (m *MockClient) ReportPeerAction(result *proto.PeerActionResult) error {
+	if m.ReportPeerActionFunc == nil {
+		return nil
+	}
+	return m.ReportPeerActionFunc(result)
+}
+
+// RotatePeerKey mock implementation of RotatePeerKey from mgm.Client interface
+func (m *MockClient) RotatePeerKey(req *proto.RotatePeerKeyRequest) error {
+	if m.RotatePeerKeyFunc == nil {
+		return nil
+	}
+	return m.RotatePeerKeyFunc(req)
+}
+
+// UploadDebugBundle mock implementation of UploadDebugBundle from mgm.Client interface
+func (m *MockClient) UploadDebugBundle(upload *proto.DebugBundleUpload) (*proto.DebugBundleUploadResponse, error) {
+	if m.UploadDebugBundleFunc == nil {
+		return nil, nil
+	}
+	return m.UploadDebugBundleFunc(upload)
+}
+
+// ResolvePeer mock implementation of ResolvePeer from mgm.Client interface
+func (m *MockClient) ResolvePeer(req *proto.ResolvePeerRequest) (*proto.ResolvePeerResponse, error) {
+	if m.ResolvePeerFunc == nil {
+		return nil, nil
+	}
+	return m.ResolvePeerFunc(req)
+}
+
+// ReportSpeedtest mock implementation of ReportSpeedtest from mgm.Client interface
+func (m *MockClient) ReportSpeedtest(report *proto.SpeedtestReport) error {
+	if m.ReportSpeedtestFunc == nil {
+		return nil
+	}
+	return m.ReportSpeedtestFunc(report)
+}
+
+// ReportPeerLoad mock implementation of ReportPeerLoad from mgm.Client interface
+func (m *MockClient) ReportPeerLoad(report *proto.PeerLoadReport) error {
+	if m.ReportPeerLoadFunc == nil {
+		return nil
+	}
+	return m.ReportPeerLoadFunc(report)
+}
@@ -0,0 +1,613 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/spf13/cobra"
+
+	"github.com/netbirdio/netbird/management/debug"
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/idp"
+)
+
+const (
+	// checkTimeout bounds each request the config check sends to the IdP and each lookup of a host
+	checkTimeout = 10 * time.Second
+	// certExpiryWarning is how long before its expiry a certificate is reported
+	certExpiryWarning = 30 * 24 * time.Hour
+)
+
+var checkConfigCmd = &cobra.Command{
+	Use:   "check-config [--config file] [--datadir directory]",
+	Short: "Check the config of the Management service and the services it depends on",
+	Long: "Checks the config file of the Management service for syntax errors and unknown keys, then its TLS " +
+		"certificate, the reachability of its IdP, its store and its STUN, TURN and Signal servers, without starting " +
+		"the service. It reads the store read-only, so it can check the config of a running service.\n" +
+		"It exits with an error when a check failed, the warnings are settings that work but are likely wrong.",
+	Example: "  netbird-mgmt check-config --config /etc/netbird/management.json",
+	RunE:    checkConfig,
+}
+
+func init() {
+	checkConfigCmd.Flags().StringVar(&mgmtConfig, "config", defaultMgmtConfig, "Netbird config file location")
+	checkConfigCmd.Flags().StringVar(&mgmtDataDir, "datadir", defaultMgmtDataDir, "server data directory location, it has a precedence over the Datadir of the config like for the management command")
+	rootCmd.AddCommand(checkConfigCmd)
+}
+
+// configCheckSeverity is the outcome of a config check
+type configCheckSeverity int
+
+const (
+	configCheckOK configCheckSeverity = iota
+	configCheckWarning
+	configCheckError
+)
+
+func (s configCheckSeverity) String() string {
+	switch s {
+	case configCheckWarning:
+		return "WARN"
+	case configCheckError:
+		return "ERROR"
+	default:
+		return "OK"
+	}
+}
+
+// configCheckResult is the outcome of a config check with a message telling how to fix it
+type configCheckResult struct {
+	Area     string
+	Severity configCheckSeverity
+	Message  string
+}
+
+// configChecker collects the results of the checks of a config
+type configChecker struct {
+	results []configCheckResult
+}
+
+func (c *configChecker) ok(area, format string, a ...interface{}) {
+	c.add(area, configCheckOK, format, a...)
+}
+
+func (c *configChecker) warn(area, format string, a ...interface{}) {
+	c.add(area, configCheckWarning, format, a...)
+}
+
+func (c *configChecker) fail(area, format string, a ...interface{}) {
+	c.add(area, configCheckError, format, a...)
+}
+
+func (c *configChecker) add(area string, severity configCheckSeverity, format string, a ...interface{}) {
+	c.results = append(c.results, configCheckResult{Area: area, Severity: severity, Message: fmt.Sprintf(format, a...)})
+}
+
+// count returns the number of results with the severity
+func (c *configChecker) count(severity configCheckSeverity) int {
+	var n int
+	for _, r := range c.results {
+		if r.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+func checkConfig(cmd *cobra.Command, _ []string) error {
+	checker := &configChecker{}
+	config := checker.checkConfigFile(mgmtConfig)
+	if config != nil {
+		if mgmtDataDir != "" {
+			config.Datadir = mgmtDataDir
+		}
+		checker.checkTLS(config)
+		checker.checkIdP(cmd.Context(), config)
+		checker.checkStore(config)
+		checker.checkRelays(cmd.Context(), config)
+		checker.checkLog(config)
+		checker.checkMetrics(config)
+		checker.checkDebug(config)
+	}
+
+	for _, r := range checker.results {
+		cmd.Printf("[%-5s] %s: %s\n", r.Severity, r.Area, r.Message)
+	}
+
+	errs, warnings := checker.count(configCheckError), checker.count(configCheckWarning)
+	if errs > 0 {
+		return fmt.Errorf("%d checks failed and %d warnings", errs, warnings)
+	}
+	cmd.Printf("\nThe config is valid with %d warnings\n", warnings)
+	return nil
+}
+
+// checkConfigFile parses the config file and reports its syntax errors and the keys the Management service ignores,
+// usually typos or settings of another version. It returns nil when the config can't be parsed
+func (c *configChecker) checkConfigFile(path string) *server.Config {
+	const area = "config"
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		c.fail(area, "failed to read %s: %v", path, err)
+		return nil
+	}
+
+	config := &server.Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		c.fail(area, "failed to parse %s: %s", path, describeJSONError(data, err))
+		return nil
+	}
+	c.ok(area, "parsed %s", path)
+
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err == nil {
+		for _, key := range unknownConfigKeys(raw, reflect.TypeOf(config), "") {
+			c.warn(area, "unknown key %s is ignored, check its spelling and the version of the Management service", key)
+		}
+	}
+
+	if config.HttpConfig == nil {
+		c.fail(area, "HttpConfig is missing, the Management service needs it to authenticate the users")
+		return nil
+	}
+	return config
+}
+
+// describeJSONError adds the line and the column of the syntax and type errors of the JSON data
+func describeJSONError(data []byte, err error) string {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+		if typeErr.Field != "" {
+			return fmt.Sprintf("%s holds a JSON %s but needs a %s at line %d", typeErr.Field, typeErr.Value,
+				typeErr.Type, jsonLine(data, offset))
+		}
+	default:
+		return err.Error()
+	}
+	return fmt.Sprintf("%v at line %d", err, jsonLine(data, offset))
+}
+
+func jsonLine(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// unknownConfigKeys returns the paths of the keys of the decoded JSON value that no field of the type matches. Like
+// encoding/json the keys match the field names case-insensitively
+func unknownConfigKeys(value interface{}, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return nil
+	}
+
+	var unknown []string
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for key, v := range obj {
+			keyPath := joinConfigPath(path, key)
+			field, ok := jsonField(t, key)
+			if !ok {
+				unknown = append(unknown, keyPath)
+				continue
+			}
+			unknown = append(unknown, unknownConfigKeys(v, field.Type, keyPath)...)
+		}
+	case reflect.Map:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for key, v := range obj {
+			unknown = append(unknown, unknownConfigKeys(v, t.Elem(), joinConfigPath(path, key))...)
+		}
+	case reflect.Slice, reflect.Array:
+		arr, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, v := range arr {
+			unknown = append(unknown, unknownConfigKeys(v, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	default:
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// jsonField returns the field of the struct type encoding/json decodes the key into, including the fields of the
+// embedded structs
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if f, ok := jsonField(embedded, key); ok {
+					return f, true
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func joinConfigPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// checkTLS checks the certificate files of the config or its Let's Encrypt domain
+func (c *configChecker) checkTLS(config *server.Config) {
+	const area = "tls"
+	httpConfig := config.HttpConfig
+
+	switch {
+	case httpConfig.CertFile != "" || httpConfig.CertKey != "":
+		if httpConfig.CertFile == "" || httpConfig.CertKey == "" {
+			c.fail(area, "HttpConfig.CertFile and HttpConfig.CertKey have to be set together")
+			return
+		}
+		c.checkCertificate(area, httpConfig.CertFile, httpConfig.CertKey)
+		if httpConfig.LetsEncryptDomain != "" {
+			c.warn(area, "HttpConfig.LetsEncryptDomain is set too, its certificate takes precedence over the files")
+		}
+	case httpConfig.LetsEncryptDomain != "":
+		if _, ok := dns.IsDomainName(httpConfig.LetsEncryptDomain); !ok {
+			c.fail(area, "HttpConfig.LetsEncryptDomain %s isn't a valid domain name", httpConfig.LetsEncryptDomain)
+			return
+		}
+		if httpConfig.LetsEncryptDNS != nil {
+			c.ok(area, "the certificate of %s is issued by Let's Encrypt with the DNS-01 challenge", httpConfig.LetsEncryptDomain)
+			return
+		}
+		c.ok(area, "the certificate of %s is issued by Let's Encrypt, the port 443 of this host has to be reachable from the internet",
+			httpConfig.LetsEncryptDomain)
+	default:
+		c.warn(area, "TLS is disabled, the APIs are served in plain text unless a reverse proxy terminates TLS in front of them")
+	}
+}
+
+func (c *configChecker) checkCertificate(area, certFile, certKey string) {
+	pair, err := tls.LoadX509KeyPair(certFile, certKey)
+	if err != nil {
+		c.fail(area, "failed to load the certificate %s with the key %s: %v", certFile, certKey, err)
+		return
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		c.fail(area, "failed to parse the certificate %s: %v", certFile, err)
+		return
+	}
+
+	now := time.Now()
+	names := strings.Join(cert.DNSNames, ", ")
+	switch {
+	case now.Before(cert.NotBefore):
+		c.fail(area, "the certificate %s of %s isn't valid before %s, check the clock of this host", certFile, names,
+			cert.NotBefore.Format(time.RFC3339))
+	case now.After(cert.NotAfter):
+		c.fail(area, "the certificate %s of %s expired at %s, renew it", certFile, names, cert.NotAfter.Format(time.RFC3339))
+	case cert.NotAfter.Sub(now) < certExpiryWarning:
+		c.warn(area, "the certificate %s of %s expires at %s, renew it", certFile, names, cert.NotAfter.Format(time.RFC3339))
+	default:
+		c.ok(area, "the certificate %s of %s is valid until %s", certFile, names, cert.NotAfter.Format(time.RFC3339))
+	}
+}
+
+// checkIdP checks that the OIDC configuration and the keys of the IdP can be fetched and that the IdP manager config
+// is complete
+func (c *configChecker) checkIdP(ctx context.Context, config *server.Config) {
+	const area = "idp"
+	httpConfig := config.HttpConfig
+
+	issuer, keysLocation := httpConfig.AuthIssuer, httpConfig.AuthKeysLocation
+	if endpoint := httpConfig.OIDCConfigEndpoint; endpoint != "" {
+		oidcConfig, err := fetchOIDCConfig(endpoint)
+		if err != nil {
+			c.fail(area, "HttpConfig.OIDCConfigEndpoint isn't reachable, check the URL and the DNS and firewall of this host: %v", err)
+			return
+		}
+		c.ok(area, "fetched the OIDC configuration of the issuer %s from %s", oidcConfig.Issuer, endpoint)
+		issuer, keysLocation = oidcConfig.Issuer, oidcConfig.JwksURI
+	}
+
+	if issuer == "" {
+		c.fail(area, "HttpConfig.AuthIssuer is empty, set it or HttpConfig.OIDCConfigEndpoint")
+	}
+	if httpConfig.AuthAudience == "" {
+		c.fail(area, "HttpConfig.AuthAudience is empty, set it to the client ID of the dashboard in the IdP")
+	}
+	if keysLocation == "" {
+		c.fail(area, "HttpConfig.AuthKeysLocation is empty, set it or HttpConfig.OIDCConfigEndpoint")
+	} else if n, err := fetchJWKSKeys(ctx, keysLocation); err != nil {
+		c.fail(area, "the signing keys of the IdP can't be fetched from %s, the users can't log in: %v", keysLocation, err)
+	} else {
+		c.ok(area, "fetched %d signing keys of the IdP from %s", n, keysLocation)
+	}
+
+	if config.IdpManagerConfig == nil {
+		c.warn(area, "IdpManagerConfig is missing, the users are shown without their names and emails and can't be invited")
+		return
+	}
+	if _, err := idp.NewManager(*config.IdpManagerConfig, nil); err != nil {
+		c.fail(area, "the IdpManagerConfig of %s is invalid: %v", config.IdpManagerConfig.ManagerType, err)
+		return
+	}
+	c.ok(area, "the IdpManagerConfig of %s is complete", config.IdpManagerConfig.ManagerType)
+}
+
+// fetchJWKSKeys fetches the JSON web key set from its location and returns its number of keys
+func fetchJWKSKeys(ctx context.Context, location string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return 0, err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("the request returned status %d", res.StatusCode)
+	}
+	var jwks struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&jwks); err != nil {
+		return 0, fmt.Errorf("failed to decode the key set: %v", err)
+	}
+	if len(jwks.Keys) == 0 {
+		return 0, errors.New("the key set has no keys")
+	}
+	return len(jwks.Keys), nil
+}
+
+// checkStore checks that the datadir is writable and that the store can be read
+func (c *configChecker) checkStore(config *server.Config) {
+	const area = "store"
+
+	if config.Datadir == "" {
+		c.fail(area, "Datadir is empty, set it in the config or with --datadir")
+		return
+	}
+	if err := checkWritableDir(config.Datadir); err != nil {
+		c.fail(area, "the datadir %s isn't writable by this user: %v", config.Datadir, err)
+		return
+	}
+
+	switch config.StoreConfig.Engine {
+	case "", server.FileStoreEngine, server.SqliteStoreEngine:
+	default:
+		c.fail(area, "StoreConfig.Engine %s isn't supported, use %s or %s", config.StoreConfig.Engine,
+			server.SqliteStoreEngine, server.FileStoreEngine)
+		return
+	}
+
+	engine := server.ResolveStoreEngine(config.StoreConfig.Engine, config.Datadir)
+	accounts, err := server.CheckStore(engine, config.Datadir)
+	if err != nil {
+		c.fail(area, "failed to read the %s store in %s: %v", engine, config.Datadir, err)
+		return
+	}
+	c.ok(area, "the %s store in %s has %d accounts", engine, config.Datadir, accounts)
+}
+
+func (c *configChecker) checkLog(config *server.Config) {
+	const area = "log"
+
+	if config.Log == nil {
+		return
+	}
+	if err := config.Log.Validate(); err != nil {
+		c.fail(area, "invalid Log: %v", err)
+		return
+	}
+	c.ok(area, "the log config is valid")
+}
+
+func (c *configChecker) checkMetrics(config *server.Config) {
+	const area = "metrics"
+
+	address := metricsAddress(config)
+	exposeConfig, err := metricsExposeConfig(config.Metrics, address)
+	if err != nil {
+		c.fail(area, "%v", err)
+		return
+	}
+
+	if address == "" {
+		address = fmt.Sprintf(":%d", mgmtMetricsPort)
+	}
+	if !exposeConfig.HasAuth() && !isLocalAddress(address) {
+		c.warn(area, "the metrics on %s are served without authentication, set a Metrics.BearerToken or a local Metrics.Address", address)
+		return
+	}
+	c.ok(area, "the metrics are served on %s", address)
+}
+
+func (c *configChecker) checkDebug(config *server.Config) {
+	const area = "debug"
+
+	if config.HttpConfig == nil || config.HttpConfig.Debug == nil {
+		return
+	}
+	if err := debug.ValidateConfig(*config.HttpConfig.Debug); err != nil {
+		c.fail(area, "invalid HttpConfig.Debug: %v", err)
+		return
+	}
+	c.ok(area, "the diagnostics are served under %s to the requests with the admin token", debug.PathPrefix)
+}
+
+// checkWritableDir checks that a file can be created in the directory, the directory doesn't have to exist yet
+func checkWritableDir(dir string) error {
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s isn't a directory", dir)
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		// the service creates the missing directories
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return err
+		}
+		dir = parent
+	}
+
+	f, err := os.CreateTemp(dir, ".check-config-")
+	if err != nil {
+		return err
+	}
+	_ = f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkRelays checks the STUN, TURN and Signal servers the peers are given
+func (c *configChecker) checkRelays(ctx context.Context, config *server.Config) {
+	if len(config.Stuns) == 0 {
+		c.warn("stun", "Stuns is empty, the peers can't discover their public addresses to connect directly")
+	}
+	for i, host := range config.Stuns {
+		c.checkHost(ctx, "stun", fmt.Sprintf("Stuns[%d]", i), host, "stun", "stuns")
+	}
+
+	turn := config.TURNConfig
+	switch {
+	case turn == nil || len(turn.Turns) == 0:
+		c.warn("turn", "TURNConfig has no Turns, the peers that can't connect directly won't connect unless the "+
+			"management command runs a relay server with --relay-listen-address")
+	case turn.TimeBasedCredentials && turn.Secret == "":
+		c.fail("turn", "TURNConfig.Secret is empty, the time based credentials need the static-auth-secret of the TURN server")
+	case turn.TimeBasedCredentials && turn.CredentialsTTL.Duration <= 0:
+		c.fail("turn", "TURNConfig.CredentialsTTL is empty, set how long the time based credentials last, e.g. 12h")
+	}
+	if turn != nil {
+		for i, host := range turn.Turns {
+			name := fmt.Sprintf("TURNConfig.Turns[%d]", i)
+			if !turn.TimeBasedCredentials && (host.Username == "" || host.Password == "") {
+				c.fail("turn", "%s has no Username or Password, set the credentials of the TURN server or enable "+
+					"TURNConfig.TimeBasedCredentials", name)
+			}
+			c.checkHost(ctx, "turn", name, host, "turn", "turns")
+		}
+	}
+
+	if config.Signal == nil {
+		c.fail("signal", "Signal is missing, the peers can't exchange their connection offers")
+		return
+	}
+	c.checkHost(ctx, "signal", "Signal", config.Signal, "")
+}
+
+// checkHost checks the protocol and the URI of the host and resolves its hostname. The URI has one of the schemes, a
+// bare host:port when the schemes are empty
+func (c *configChecker) checkHost(ctx context.Context, area, name string, host *server.Host, schemes ...string) {
+	if host == nil {
+		c.fail(area, "%s is empty", name)
+		return
+	}
+
+	switch host.Proto {
+	case server.UDP, server.TCP, server.DTLS, server.HTTP, server.HTTPS:
+	default:
+		c.fail(area, "%s.Proto %q isn't one of udp, tcp, dtls, http or https", name, host.Proto)
+	}
+
+	address := host.URI
+	if schemes[0] != "" {
+		scheme, rest, ok := strings.Cut(host.URI, ":")
+		if !ok || !containsString(schemes, scheme) {
+			c.fail(area, "%s.URI %q has to start with %s:, e.g. %s:example.com:3478", name, host.URI,
+				strings.Join(schemes, ": or "), schemes[0])
+			return
+		}
+		// the transport parameter of the TURN URIs, e.g. turn:example.com:3478?transport=tcp
+		address, _, _ = strings.Cut(rest, "?")
+	} else if u, err := url.Parse(host.URI); err == nil && u.Host != "" {
+		c.fail(area, "%s.URI %q has to be host:port without a scheme, the scheme is set by %s.Proto", name, host.URI, name)
+		return
+	}
+
+	hostname, _, err := net.SplitHostPort(address)
+	if err != nil {
+		c.fail(area, "%s.URI %q has no valid host:port: %v", name, host.URI, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	if _, err := net.DefaultResolver.LookupHost(ctx, hostname); err != nil {
+		c.warn(area, "%s.URI host %s doesn't resolve from this host, the peers may fail to reach it: %v", name, hostname, err)
+		return
+	}
+	c.ok(area, "%s %s", name, host.URI)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/util"
+)
+
+func TestCheckConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "management.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+  "Datadirr": "/var/lib/netbird",
+  "HttpConfig": {
+    "AuthAudience": "dashboard",
+    "accesslog": {"SampleRatee": 0.5}
+  },
+  "TURNConfig": {"Turns": [{"Proto": "udp", "URI": "turn:example.com:3478", "Passwd": "secret"}], "CredentialsTTL": "12h"},
+  "APIQuotas": {"Accounts": {"account-1": {"RequestsPerMinute": 10, "RequestsPerHour": 100}}}
+}`), 0600))
+
+	checker := &configChecker{}
+	config := checker.checkConfigFile(path)
+	require.NotNil(t, config, "the config should be parsed")
+	assert.Equal(t, "dashboard", config.HttpConfig.AuthAudience)
+
+	var warnings []string
+	for _, r := range checker.results {
+		if r.Severity == configCheckWarning {
+			warnings = append(warnings, r.Message)
+		}
+	}
+	assert.Len(t, warnings, 4, "each unknown key should be reported: %v", warnings)
+	for i, key := range []string{
+		"APIQuotas.Accounts.account-1.RequestsPerHour",
+		"Datadirr",
+		"HttpConfig.accesslog.SampleRatee",
+		"TURNConfig.Turns[0].Passwd",
+	} {
+		assert.Contains(t, warnings[i], key)
+	}
+}
+
+func TestCheckConfigFile_Invalid(t *testing.T) {
+	tt := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "syntax error",
+			content:  "{\n  \"Datadir\": \"/var/lib/netbird\",\n}",
+			expected: "at line 3",
+		},
+		{
+			name:     "type error",
+			content:  "{\n  \"HttpConfig\": {\n    \"AuthAudience\": 1\n  }\n}",
+			expected: "HttpConfig.AuthAudience holds a JSON number but needs a string at line 3",
+		},
+		{
+			name:     "missing http config",
+			content:  `{"Datadir": "/var/lib/netbird"}`,
+			expected: "HttpConfig is missing",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "management.json")
+			require.NoError(t, os.WriteFile(path, []byte(tc.content), 0600))
+
+			checker := &configChecker{}
+			assert.Nil(t, checker.checkConfigFile(path))
+			require.Equal(t, 1, checker.count(configCheckError))
+			assert.Contains(t, checker.results[len(checker.results)-1].Message, tc.expected)
+		})
+	}
+}
+
+func TestUnknownConfigKeys(t *testing.T) {
+	type embedded struct {
+		Inner string
+	}
+	type config struct {
+		embedded
+		Name    string `json:"name"`
+		Skipped string `json:"-"`
+		Hosts   []*server.Host
+	}
+
+	var raw interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"inner": "a", "NAME": "b", "Skipped": "c",
+		"Hosts": [{"URI": "stun:example.com:3478"}, {"Url": "stun:example.com:3478"}]}`), &raw))
+	assert.Equal(t, []string{"Hosts[1].Url", "Skipped"}, unknownConfigKeys(raw, reflect.TypeOf(config{}), ""))
+}
+
+func TestCheckRelays(t *testing.T) {
+	config := &server.Config{
+		Stuns: []*server.Host{
+			{Proto: server.UDP, URI: "stun:localhost:3478"},
+			{Proto: server.UDP, URI: "localhost:3478"},
+		},
+		TURNConfig: &server.TURNConfig{
+			TimeBasedCredentials: true,
+			Turns: []*server.Host{
+				{Proto: server.UDP, URI: "turn:localhost:3478?transport=udp"},
+			},
+		},
+		Signal: &server.Host{Proto: "grpc", URI: "https://localhost:10000"},
+	}
+
+	checker := &configChecker{}
+	checker.checkRelays(context.Background(), config)
+
+	var failures []string
+	for _, r := range checker.results {
+		if r.Severity == configCheckError {
+			failures = append(failures, r.Message)
+		}
+	}
+	require.Len(t, failures, 4, "unexpected failures: %v", failures)
+	assert.Contains(t, failures[0], "Stuns[1].URI")
+	assert.Contains(t, failures[1], "TURNConfig.Secret is empty")
+	assert.Contains(t, failures[2], "Signal.Proto")
+	assert.Contains(t, failures[3], "Signal.URI")
+}
+
+func TestCheckStore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The SQLite store is not properly supported by Windows yet")
+	}
+
+	dataDir := t.TempDir()
+	store, err := server.NewSqliteStore(dataDir, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.SaveAccount(&server.Account{Id: "account-1", Domain: "example.com"}))
+	require.NoError(t, store.Close())
+
+	checker := &configChecker{}
+	checker.checkStore(&server.Config{Datadir: dataDir, StoreConfig: server.StoreConfig{Engine: server.SqliteStoreEngine}})
+	require.Len(t, checker.results, 1)
+	assert.Equal(t, configCheckOK, checker.results[0].Severity)
+	assert.Contains(t, checker.results[0].Message, "has 1 accounts")
+
+	checker = &configChecker{}
+	checker.checkStore(&server.Config{Datadir: dataDir, StoreConfig: server.StoreConfig{Engine: "postgres"}})
+	require.Len(t, checker.results, 1)
+	assert.Equal(t, configCheckError, checker.results[0].Severity)
+}
+
+func TestCheckLog(t *testing.T) {
+	checker := &configChecker{}
+	checker.checkLog(&server.Config{})
+	assert.Empty(t, checker.results)
+
+	checker = &configChecker{}
+	checker.checkLog(&server.Config{Log: &util.LogConfig{Format: util.LogFormatJSON, Components: map[string]string{"store": "debug"}}})
+	require.Len(t, checker.results, 1)
+	assert.Equal(t, configCheckOK, checker.results[0].Severity)
+
+	checker = &configChecker{}
+	checker.checkLog(&server.Config{Log: &util.LogConfig{Components: map[string]string{"storage": "debug"}}})
+	require.Len(t, checker.results, 1)
+	assert.Equal(t, configCheckError, checker.results[0].Severity)
+	assert.Contains(t, checker.results[0].Message, "unknown log component")
+}
+
+func TestCheckMetrics(t *testing.T) {
+	checker := &configChecker{}
+	checker.checkMetrics(&server.Config{})
+	require.Len(t, checker.results, 1)
+	assert.Equal(t, configCheckWarning, checker.results[0].Severity)
+
+	checker = &configChecker{}
+	checker.checkMetrics(&server.Config{Metrics: &server.MetricsConfig{Address: "127.0.0.1:8081", Pprof: true}})
+	require.Len(t, checker.results, 1)
+	assert.Equal(t, configCheckOK, checker.results[0].Severity)
+
+	checker = &configChecker{}
+	checker.checkMetrics(&server.Config{Metrics: &server.MetricsConfig{BasicAuthUsername: "prometheus"}})
+	require.Len(t, checker.results, 1)
+	assert.Equal(t, configCheckError, checker.results[0].Severity)
+}
+
+func TestCheckDebug(t *testing.T) {
+	checker := &configChecker{}
+	checker.checkDebug(&server.Config{HttpConfig: &server.HttpServerConfig{}})
+	assert.Empty(t, checker.results)
+
+	checker = &configChecker{}
+	checker.checkDebug(&server.Config{HttpConfig: &server.HttpServerConfig{Debug: &server.DebugConfig{AdminToken: "short"}}})
+	require.Len(t, checker.results, 1)
+	assert.Equal(t, configCheckError, checker.results[0].Severity)
+
+	checker = &configChecker{}
+	checker.checkDebug(&server.Config{HttpConfig: &server.HttpServerConfig{Debug: &server.DebugConfig{AdminToken: strings.Repeat("a", 32)}}})
+	require.Len(t, checker.results, 1)
+	assert.Equal(t, configCheckOK, checker.results[0].Severity)
+}
@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	ctlProto "github.com/netbirdio/netbird/management/ctl/proto"
+	"github.com/netbirdio/netbird/management/server"
+)
+
+var (
+	ctlAddr            string
+	ctlAccountID       string
+	setupKeyName       string
+	setupKeyType       string
+	setupKeyExpiresIn  time.Duration
+	setupKeyAutoGroups []string
+	setupKeyUsageLimit int
+	setupKeyEphemeral  bool
+	ctlCmd             = &cobra.Command{
+		Use:   "ctl",
+		Short: "Manage the running Management service through its local admin socket",
+		Long: "Commands to list and approve peers, create setup keys, dump the network map of a peer and resync the peers " +
+			"of the Management service running on this host, without the dashboard.\n" +
+			"The account can be omitted when the server has a single account.",
+	}
+)
+
+var ctlPeersCmd = &cobra.Command{
+	Use:   "peers",
+	Short: "Manage the peers",
+}
+
+var ctlPeersListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the peers",
+	Example: "  netbird-mgmt ctl peers list",
+	RunE:    ctlPeersList,
+}
+
+var ctlPeersApproveCmd = &cobra.Command{
+	Use:     "approve peer-id",
+	Short:   "Approve a peer waiting for approval",
+	Example: "  netbird-mgmt ctl peers approve cq3m1ikc6p3c73elbc3g",
+	Args:    cobra.ExactArgs(1),
+	RunE:    ctlPeersApprove,
+}
+
+var ctlSetupKeysCmd = &cobra.Command{
+	Use:     "setup-keys",
+	Aliases: []string{"setup-key"},
+	Short:   "Manage the setup keys",
+}
+
+var ctlSetupKeysCreateCmd = &cobra.Command{
+	Use:     "create",
+	Short:   "Create a setup key",
+	Long:    "Create a setup key and print it. The key can't be shown again afterwards.",
+	Example: "  netbird-mgmt ctl setup-keys create --name routers --type reusable --expires-in 720h --auto-groups cq3m1ikc6p3c73elbc40",
+	RunE:    ctlSetupKeysCreate,
+}
+
+var ctlNetworkMapCmd = &cobra.Command{
+	Use:     "network-map peer-id",
+	Short:   "Dump the network map of a peer",
+	Long:    "Print the network map the Management service sends to a peer as JSON.",
+	Example: "  netbird-mgmt ctl network-map cq3m1ikc6p3c73elbc3g",
+	Args:    cobra.ExactArgs(1),
+	RunE:    ctlNetworkMap,
+}
+
+var ctlResyncCmd = &cobra.Command{
+	Use:     "resync",
+	Short:   "Resend the network map to the connected peers",
+	Example: "  netbird-mgmt ctl resync",
+	RunE:    ctlResync,
+}
+
+func init() {
+	ctlCmd.PersistentFlags().StringVar(&ctlAddr, "ctl-addr", defaultCtlAddr, "Local admin socket of the Management service")
+	ctlCmd.PersistentFlags().StringVar(&ctlAccountID, "account", "", "ID of the account, required when the server has more than one account")
+
+	ctlSetupKeysCreateCmd.Flags().StringVar(&setupKeyName, "name", "", "Name of the setup key")
+	ctlSetupKeysCreateCmd.Flags().StringVar(&setupKeyType, "type", string(server.SetupKeyReusable), "Type of the setup key, reusable or one-off")
+	ctlSetupKeysCreateCmd.Flags().DurationVar(&setupKeyExpiresIn, "expires-in", server.DefaultSetupKeyDuration, "Time the setup key is valid for")
+	ctlSetupKeysCreateCmd.Flags().StringSliceVar(&setupKeyAutoGroups, "auto-groups", nil, "IDs of the groups the peers registered with the key are added to")
+	ctlSetupKeysCreateCmd.Flags().IntVar(&setupKeyUsageLimit, "usage-limit", 0, "Number of times the setup key can be used, 0 for unlimited")
+	ctlSetupKeysCreateCmd.Flags().BoolVar(&setupKeyEphemeral, "ephemeral", false, "Register the peers as ephemeral peers, removed once offline")
+	ctlSetupKeysCreateCmd.MarkFlagRequired("name") //nolint
+
+	ctlPeersCmd.AddCommand(ctlPeersListCmd, ctlPeersApproveCmd)
+	ctlSetupKeysCmd.AddCommand(ctlSetupKeysCreateCmd)
+	ctlCmd.AddCommand(ctlPeersCmd, ctlSetupKeysCmd, ctlNetworkMapCmd, ctlResyncCmd)
+}
+
+// serveCtl serves the local admin gRPC API on a unix socket, or a TCP address for the tcp:// scheme. The socket is
+// only accessible to the user the server runs as, because the requests aren't authenticated.
+func serveCtl(ctlServer *server.CtlServer, addr string) (*grpc.Server, error) {
+	network, address, ok := strings.Cut(addr, "://")
+	if !ok {
+		return nil, fmt.Errorf("local admin socket address %s must start with unix:// or tcp://", addr)
+	}
+	var listener net.Listener
+	var err error
+	switch network {
+	case "unix":
+		listener, err = listenUnixSocket(address, 0600)
+	case "tcp":
+		listener, err = net.Listen(network, address)
+	default:
+		return nil, fmt.Errorf("unsupported local admin socket protocol: %s", network)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on the local admin socket %s: %v", addr, err)
+	}
+
+	ctlGRPCServer := grpc.NewServer()
+	ctlProto.RegisterCtlServiceServer(ctlGRPCServer, ctlServer)
+	go func() {
+		if err := ctlGRPCServer.Serve(listener); err != nil {
+			notifyStop(fmt.Sprintf("failed running the local admin gRPC server on %s: %v", addr, err))
+		}
+	}()
+	return ctlGRPCServer, nil
+}
+
+func getCtlClient(ctx context.Context) (ctlProto.CtlServiceClient, *grpc.ClientConn, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, strings.TrimPrefix(ctlAddr, "tcp://"),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithBlock(),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to the Management service on %s, is it running? %v", ctlAddr, err)
+	}
+	return ctlProto.NewCtlServiceClient(conn), conn, nil
+}
+
+func ctlPeersList(cmd *cobra.Command, _ []string) error {
+	client, conn, err := getCtlClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	resp, err := client.ListPeers(cmd.Context(), &ctlProto.AccountRequest{AccountId: ctlAccountID})
+	if err != nil {
+		return fmt.Errorf("failed to list peers: %v", status.Convert(err).Message())
+	}
+
+	if len(resp.GetPeers()) == 0 {
+		cmd.Println("No peers registered.")
+		return nil
+	}
+
+	cmd.Print(formatCtlPeers(resp.GetPeers()))
+	return nil
+}
+
+func formatCtlPeers(peers []*ctlProto.Peer) string {
+	var b strings.Builder
+	for _, p := range peers {
+		state := "offline"
+		if p.GetConnected() {
+			state = "online"
+		}
+		if p.GetApprovalRequired() {
+			state += ", approval required"
+		}
+		fmt.Fprintf(&b, "\n  ID: %s\n  Name: %s\n  IP: %s\n  DNS label: %s\n  Status: %s\n", p.GetId(), p.GetName(), p.GetIp(), p.GetDnsLabel(), state)
+		if !p.GetConnected() && p.GetLastSeen().AsTime().After(time.Unix(0, 0)) {
+			fmt.Fprintf(&b, "  Last seen: %s\n", p.GetLastSeen().AsTime().Local().Format(time.RFC3339))
+		}
+		fmt.Fprintf(&b, "  OS: %s\n  Version: %s\n  Groups: %s\n", p.GetOs(), p.GetVersion(), strings.Join(p.GetGroups(), ", "))
+	}
+	return b.String()
+}
+
+func ctlPeersApprove(cmd *cobra.Command, args []string) error {
+	client, conn, err := getCtlClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	peer, err := client.ApprovePeer(cmd.Context(), &ctlProto.PeerRequest{AccountId: ctlAccountID, PeerId: args[0]})
+	if err != nil {
+		return fmt.Errorf("failed to approve peer: %v", status.Convert(err).Message())
+	}
+
+	cmd.Printf("Peer %s (%s) approved\n", peer.GetName(), peer.GetId())
+	return nil
+}
+
+func ctlSetupKeysCreate(cmd *cobra.Command, _ []string) error {
+	client, conn, err := getCtlClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	key, err := client.CreateSetupKey(cmd.Context(), &ctlProto.CreateSetupKeyRequest{
+		AccountId:  ctlAccountID,
+		Name:       setupKeyName,
+		Type:       setupKeyType,
+		ExpiresIn:  int64(setupKeyExpiresIn.Seconds()),
+		AutoGroups: setupKeyAutoGroups,
+		UsageLimit: int32(setupKeyUsageLimit),
+		Ephemeral:  setupKeyEphemeral,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create setup key: %v", status.Convert(err).Message())
+	}
+
+	cmd.Printf("Setup key %s (%s) created, it expires at %s:\n%s\n", key.GetName(), key.GetId(),
+		key.GetExpires().AsTime().Local().Format(time.RFC3339), key.GetKey())
+	return nil
+}
+
+func ctlNetworkMap(cmd *cobra.Command, args []string) error {
+	client, conn, err := getCtlClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	resp, err := client.GetNetworkMap(cmd.Context(), &ctlProto.PeerRequest{AccountId: ctlAccountID, PeerId: args[0]})
+	if err != nil {
+		return fmt.Errorf("failed to get network map: %v", status.Convert(err).Message())
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, resp.GetNetworkMap(), "", "  "); err != nil {
+		return fmt.Errorf("failed to decode network map: %v", err)
+	}
+	cmd.Println(out.String())
+	return nil
+}
+
+func ctlResync(cmd *cobra.Command, _ []string) error {
+	client, conn, err := getCtlClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := client.Resync(cmd.Context(), &ctlProto.AccountRequest{AccountId: ctlAccountID}); err != nil {
+		return fmt.Errorf("failed to resync peers: %v", status.Convert(err).Message())
+	}
+
+	cmd.Println("The network map was sent to the connected peers")
+	return nil
+}
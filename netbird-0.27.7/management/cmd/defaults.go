@@ -15,4 +15,5 @@ const (
 	oldDefaultLogFile    = oldDefaultLogDir + "/management.log"
 
 	defaultSingleAccModeDomain = "netbird.selfhosted"
+	defaultCtlAddr             = "unix:///var/run/netbird-mgmt.sock"
 )
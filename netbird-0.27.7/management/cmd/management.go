@@ -15,8 +15,11 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -34,15 +37,29 @@ import (
 
 	"github.com/netbirdio/management-integrations/integrations"
 
+	"github.com/netbirdio/netbird/client/procd"
 	"github.com/netbirdio/netbird/encryption"
+	"github.com/netbirdio/netbird/encryption/acmedns"
+	adminProto "github.com/netbirdio/netbird/management/admin/proto"
+	"github.com/netbirdio/netbird/management/dashboard"
+	"github.com/netbirdio/netbird/management/debug"
 	mgmtProto "github.com/netbirdio/netbird/management/proto"
 	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/activity/archive"
+	"github.com/netbirdio/netbird/management/server/activity/sink"
 	"github.com/netbirdio/netbird/management/server/geolocation"
 	httpapi "github.com/netbirdio/netbird/management/server/http"
+	"github.com/netbirdio/netbird/management/server/http/middleware"
+	httputil "github.com/netbirdio/netbird/management/server/http/util"
 	"github.com/netbirdio/netbird/management/server/idp"
 	"github.com/netbirdio/netbird/management/server/jwtclaims"
 	"github.com/netbirdio/netbird/management/server/metrics"
+	"github.com/netbirdio/netbird/management/server/notification"
+	"github.com/netbirdio/netbird/management/server/relay"
 	"github.com/netbirdio/netbird/management/server/telemetry"
+	signalProto "github.com/netbirdio/netbird/signal/proto"
+	signalServer "github.com/netbirdio/netbird/signal/server"
 	"github.com/netbirdio/netbird/util"
 	"github.com/netbirdio/netbird/version"
 )
@@ -54,6 +71,7 @@ const ManagementLegacyPort = 33073
 var (
 	mgmtPort                int
 	mgmtMetricsPort         int
+	mgmtMetricsAddress      string
 	mgmtLetsencryptDomain   string
 	mgmtSingleAccModeDomain string
 	certFile                string
@@ -90,6 +108,16 @@ var (
 				return fmt.Errorf("failed reading provided config file: %s: %v", mgmtConfig, err)
 			}
 
+			if config.Log != nil {
+				logConfig := *config.Log
+				if cmd.Flag("log-level").Changed || logConfig.Level == "" {
+					logConfig.Level = logLevel
+				}
+				if err := util.InitLogWithConfig(logFile, logConfig); err != nil {
+					return fmt.Errorf("failed initializing log with the config %v", err)
+				}
+			}
+
 			if cmd.Flag(idpSignKeyRefreshEnabledFlagName).Changed {
 				config.HttpConfig.IdpSignKeyRefreshEnabled = idpSignKeyRefreshEnabled
 			}
@@ -98,6 +126,9 @@ var (
 			if mgmtLetsencryptDomain != "" || (config.HttpConfig.CertFile != "" && config.HttpConfig.CertKey != "") {
 				tlsEnabled = true
 			}
+			if config.HttpConfig.LetsEncryptDomain != "" && config.HttpConfig.LetsEncryptDNS != nil {
+				tlsEnabled = true
+			}
 
 			if !userPort {
 				// different defaults for port when tls enabled/disabled
@@ -108,6 +139,15 @@ var (
 				}
 			}
 
+			if relayListenAddress != "" && relayPublicAddress == "" {
+				return fmt.Errorf("the relay server needs its public address, set it with --relay-public-address")
+			}
+
+			httpSocketPerm, err = parseFileMode(httpSocketMode)
+			if err != nil {
+				return fmt.Errorf("invalid --http-socket-mode: %v", err)
+			}
+
 			_, valid := dns.IsDomainName(dnsDomain)
 			if !valid || len(dnsDomain) > 192 {
 				return fmt.Errorf("failed parsing the provided dns-domain. Valid status: %t, Length: %d", valid, len(dnsDomain))
@@ -131,7 +171,16 @@ var (
 			if err != nil {
 				return err
 			}
-			err = appMetrics.Expose(mgmtMetricsPort, "/metrics")
+			address := metricsAddress(config)
+			exposeConfig, err := metricsExposeConfig(config.Metrics, address)
+			if err != nil {
+				return err
+			}
+			metricsListener, err := listenMetrics(address, mgmtMetricsPort)
+			if err != nil {
+				return fmt.Errorf("failed listening for the metrics: %v", err)
+			}
+			err = appMetrics.Expose(metricsListener, "/metrics", exposeConfig)
 			if err != nil {
 				return err
 			}
@@ -166,6 +215,11 @@ var (
 				}
 			}
 
+			eventStore, err = newEventSinkStore(config, eventStore, appMetrics)
+			if err != nil {
+				return fmt.Errorf("failed creating activity event sink: %v", err)
+			}
+
 			geo, err := geolocation.NewGeolocation(config.Datadir)
 			if err != nil {
 				log.Warnf("could not initialize geo location service: %v, we proceed without geo support", err)
@@ -183,6 +237,21 @@ var (
 				return fmt.Errorf("failed to build default manager: %v", err)
 			}
 
+			if singlePort {
+				err = setSinglePortSignal(config)
+				if err != nil {
+					return err
+				}
+			}
+
+			var relayServer *relay.Server
+			if relayListenAddress != "" {
+				relayServer, err = startRelayServer(config)
+				if err != nil {
+					return fmt.Errorf("failed to start the relay server: %v", err)
+				}
+			}
+
 			turnManager := server.NewTimeBasedAuthSecretsManager(peersUpdateManager, config.TURNConfig)
 
 			trustedPeers := config.ReverseProxy.TrustedPeers
@@ -209,11 +278,33 @@ var (
 				grpc.ChainUnaryInterceptor(realip.UnaryServerInterceptorOpts(realipOpts...)),
 				grpc.ChainStreamInterceptor(realip.StreamServerInterceptorOpts(realipOpts...)),
 			}
+			if config.SourceFilter != nil {
+				// chained after the realip interceptors, so the peers behind a trusted proxy are filtered by their real IP
+				grpcFilter := server.NewSourceFilter("grpc", config.SourceFilter.GRPC)
+				gRPCOpts = append(gRPCOpts,
+					grpc.ChainUnaryInterceptor(grpcFilter.UnaryServerInterceptor()),
+					grpc.ChainStreamInterceptor(grpcFilter.StreamServerInterceptor()),
+				)
+			}
 
 			var certManager *autocert.Manager
 			var tlsConfig *tls.Config
+			var certReloader *certificateReloader
 			tlsEnabled := false
-			if config.HttpConfig.LetsEncryptDomain != "" {
+			if config.HttpConfig.LetsEncryptDomain != "" && config.HttpConfig.LetsEncryptDNS != nil {
+				// the DNS-01 challenge doesn't need the CA to reach the server, the certificate is served like a loaded one
+				dnsCertManager, err := acmedns.NewManager(config.Datadir, config.HttpConfig.LetsEncryptDomain, *config.HttpConfig.LetsEncryptDNS)
+				if err != nil {
+					return fmt.Errorf("failed creating LetsEncrypt DNS-01 cert manager: %v", err)
+				}
+				if err := dnsCertManager.Start(cmd.Context()); err != nil {
+					return fmt.Errorf("failed obtaining LetsEncrypt certificate: %v", err)
+				}
+				tlsConfig = dnsCertManager.TLSConfig()
+				transportCredentials := credentials.NewTLS(tlsConfig)
+				gRPCOpts = append(gRPCOpts, grpc.Creds(transportCredentials))
+				tlsEnabled = true
+			} else if config.HttpConfig.LetsEncryptDomain != "" {
 				certManager, err = encryption.CreateCertManager(config.Datadir, config.HttpConfig.LetsEncryptDomain)
 				if err != nil {
 					return fmt.Errorf("failed creating LetsEncrypt cert manager: %v", err)
@@ -222,7 +313,7 @@ var (
 				gRPCOpts = append(gRPCOpts, grpc.Creds(transportCredentials))
 				tlsEnabled = true
 			} else if config.HttpConfig.CertFile != "" && config.HttpConfig.CertKey != "" {
-				tlsConfig, err = loadTLSConfig(config.HttpConfig.CertFile, config.HttpConfig.CertKey)
+				tlsConfig, certReloader, err = loadTLSConfig(config.HttpConfig.CertFile, config.HttpConfig.CertKey)
 				if err != nil {
 					log.Errorf("cannot load TLS credentials: %v", err)
 					return err
@@ -249,15 +340,72 @@ var (
 				KeysLocation: config.HttpConfig.AuthKeysLocation,
 			}
 
+			httputil.SetErrorDocumentationURL(config.HttpConfig.ErrorDocumentationURL)
+
 			ctx, cancel := context.WithCancel(cmd.Context())
 			defer cancel()
-			httpAPIHandler, err := httpapi.APIHandler(ctx, accountManager, geo, *jwtValidator, appMetrics, httpAPIAuthCfg, integratedPeerValidator)
+			httpAPIHandler, err := httpapi.APIHandler(ctx, accountManager, geo, *jwtValidator, appMetrics, httpAPIAuthCfg, config.HttpConfig.AccessLog, integratedPeerValidator)
 			if err != nil {
 				return fmt.Errorf("failed creating HTTP API handler: %v", err)
 			}
+			if config.HttpConfig.Dashboard != nil {
+				httpAPIHandler, err = withDashboard(httpAPIHandler, *config.HttpConfig.Dashboard)
+				if err != nil {
+					return fmt.Errorf("failed creating dashboard handler: %v", err)
+				}
+			}
+			if config.HttpConfig.Debug != nil {
+				httpAPIHandler, err = withDebug(httpAPIHandler, *config.HttpConfig.Debug, store, peersUpdateManager)
+				if err != nil {
+					return fmt.Errorf("failed creating debug handler: %v", err)
+				}
+				log.Warnf("serving the runtime diagnostics under %s to the requests with the admin token", debug.PathPrefix)
+			}
+			if config.SourceFilter != nil {
+				httpAPIHandler = middleware.NewSourceFilter(config.SourceFilter.HTTP).Handler(httpAPIHandler)
+			}
 
 			ephemeralManager := server.NewEphemeralManager(store, accountManager)
-			ephemeralManager.LoadInitialPeers()
+
+			eventsPruner, err := newEventsPruner(config, store, eventStore)
+			if err != nil {
+				return fmt.Errorf("failed creating activity events pruner: %v", err)
+			}
+
+			var election *server.LeaderElection
+			if config.LeaderElection != nil {
+				if store.GetStoreEngine() == server.FileStoreEngine {
+					log.Warn("the file store can't be shared by instances, every instance is the leader running the background jobs")
+				}
+				election = server.NewLeaderElection(store, config.LeaderElection.InstanceID, config.LeaderElection.LeaseTTL.Duration)
+				accountManager.EnableLeaderElection(ctx, election, config.LeaderElection.ResyncInterval.Duration)
+				ephemeralManager.EnableLeaderElection(ctx, election)
+				eventsPruner.EnableLeaderElection(election)
+				election.Start(ctx)
+				log.Infof("running the background jobs when instance %s is elected the leader", election.Holder())
+			} else {
+				ephemeralManager.LoadInitialPeers()
+			}
+			eventsPruner.Start()
+
+			notifier, err := newNotifier(config)
+			if err != nil {
+				return fmt.Errorf("failed creating email notifier: %v", err)
+			}
+			if notifier != nil {
+				accountManager.EnableNotifications(ctx, notifier)
+			}
+			accountManager.EnableDebugBundles(filepath.Join(config.Datadir, "debug-bundles"))
+			accountManager.EnableAccountExports(filepath.Join(config.Datadir, "account-exports"))
+			if config.ClientUpdates != nil {
+				accountManager.EnableClientUpdates(config.ClientUpdates)
+			}
+			if config.APIQuotas != nil {
+				accountManager.EnableAPIQuotas(config.APIQuotas, appMetrics.APIQuotaMetrics())
+			}
+			if err := accountManager.EnableExternalListMetrics(appMetrics.ExternalListMetrics()); err != nil {
+				return fmt.Errorf("failed registering external list metrics: %v", err)
+			}
 
 			gRPCAPIHandler := grpc.NewServer(gRPCOpts...)
 			srv, err := server.NewServer(config, accountManager, peersUpdateManager, turnManager, appMetrics, ephemeralManager)
@@ -265,6 +413,23 @@ var (
 				return fmt.Errorf("failed creating gRPC API handler: %v", err)
 			}
 			mgmtProto.RegisterManagementServiceServer(gRPCAPIHandler, srv)
+			adminSrv, err := server.NewAdminServer(config, accountManager)
+			if err != nil {
+				return fmt.Errorf("failed creating admin gRPC API handler: %v", err)
+			}
+			adminProto.RegisterAdminServiceServer(gRPCAPIHandler, adminSrv)
+			if singlePort {
+				registerSinglePortSignal(gRPCAPIHandler)
+			}
+
+			var ctlGRPCServer *grpc.Server
+			if ctlAddr != "" {
+				ctlGRPCServer, err = serveCtl(server.NewCtlServer(store, accountManager), ctlAddr)
+				if err != nil {
+					return err
+				}
+				log.Infof("running local admin gRPC server: %s", ctlAddr)
+			}
 
 			installationID, err := getInstallationID(store)
 			if err != nil {
@@ -293,6 +458,17 @@ var (
 			}
 
 			rootHandler := handlerFunc(gRPCAPIHandler, httpAPIHandler)
+			var socketListener net.Listener
+			if httpSocket != "" {
+				socketListener, err = listenUnixSocket(httpSocket, httpSocketPerm)
+				if err != nil {
+					return fmt.Errorf("failed creating unix socket listener %s: %v", httpSocket, err)
+				}
+				// the socket is reached by local clients like a reverse proxy, so it is served without TLS and without the
+				// redirects of the LetsEncrypt handler
+				log.Infof("running HTTP server and gRPC server on the unix socket: %s", httpSocket)
+				serveGRPCWithHTTP(socketListener, rootHandler, false)
+			}
 			var listener net.Listener
 			if certManager != nil {
 				// a call to certManager.Listener() always creates a new listener so we do it once
@@ -322,23 +498,55 @@ var (
 			}
 
 			log.Infof("management server version %s", version.NetbirdVersion())
-			log.Infof("running HTTP server and gRPC server on the same port: %s", listener.Addr().String())
+			if singlePort {
+				log.Infof("running HTTP server and Management and Signal gRPC servers on the same port: %s", listener.Addr().String())
+			} else {
+				log.Infof("running HTTP server and gRPC server on the same port: %s", listener.Addr().String())
+			}
 			serveGRPCWithHTTP(listener, rootHandler, tlsEnabled)
 
 			SetupCloseHandler()
 
+			supervisor := procd.New(procd.Options{
+				Service:  procdService,
+				Instance: procdInstance,
+				Watchdog: watchdogInterval,
+			})
+			// a renewed certificate is loaded on the reload signal without dropping the connected peers
+			supervisor.NotifyReload(ctx, func() error {
+				if certReloader == nil {
+					return nil
+				}
+				return certReloader.Reload()
+			})
+			go supervisor.Run(ctx)
+			supervisor.Ready()
+
 			<-stopCh
 			integratedPeerValidator.Stop()
 			if geo != nil {
 				_ = geo.Stop()
 			}
 			ephemeralManager.Stop()
+			eventsPruner.Stop()
+			if election != nil {
+				election.Stop()
+			}
+			if relayServer != nil {
+				_ = relayServer.Close()
+			}
 			_ = appMetrics.Close()
 			_ = listener.Close()
+			if socketListener != nil {
+				_ = socketListener.Close()
+			}
 			if certManager != nil {
 				_ = certManager.Listener().Close()
 			}
 			gRPCAPIHandler.Stop()
+			if ctlGRPCServer != nil {
+				ctlGRPCServer.Stop()
+			}
 			_ = store.Close()
 			_ = eventStore.Close()
 			log.Infof("stopped Management Service")
@@ -385,6 +593,36 @@ func serveGRPC(grpcServer *grpc.Server, port int) (net.Listener, error) {
 	return listener, nil
 }
 
+// parseFileMode parses octal file permissions, e.g. 0660
+func parseFileMode(mode string) (os.FileMode, error) {
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || perm > 0777 {
+		return 0, fmt.Errorf("%s aren't octal file permissions, e.g. 0660", mode)
+	}
+	return os.FileMode(perm), nil
+}
+
+// listenUnixSocket listens on the unix socket path with the permissions, replacing the socket left by a previous run
+func listenUnixSocket(path string, perm os.FileMode) (net.Listener, error) {
+	// cleanup failed close
+	if stat, err := os.Stat(path); err == nil && stat.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			log.Debugf("remove socket file: %v", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// the permissions are set before the listener is served, so no request is answered with the default ones
+	if err := os.Chmod(path, perm); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("set socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
 func serveHTTP(httpListener net.Listener, handler http.Handler) {
 	go func() {
 		err := http.Serve(httpListener, handler)
@@ -419,6 +657,31 @@ func serveGRPCWithHTTP(listener net.Listener, handler http.Handler, tlsEnabled b
 	}()
 }
 
+// withDashboard serves the dashboard next to the HTTP API, which keeps its path whatever the dashboard path prefix is
+func withDashboard(httpAPIHandler http.Handler, cfg server.DashboardConfig) (http.Handler, error) {
+	dashboardHandler, err := dashboard.NewHandler(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/", httpAPIHandler)
+	mux.Handle("/", dashboardHandler)
+	return mux, nil
+}
+
+func withDebug(httpHandler http.Handler, cfg server.DebugConfig, store server.Store, updateManager *server.PeersUpdateManager) (http.Handler, error) {
+	debugHandler, err := debug.NewHandler(cfg, store, updateManager)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(debug.PathPrefix, debugHandler)
+	mux.Handle("/", httpHandler)
+	return mux, nil
+}
+
 func handlerFunc(gRPCHandler *grpc.Server, httpHandler http.Handler) http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 		grpcHeader := strings.HasPrefix(request.Header.Get("Content-Type"), "application/grpc") ||
@@ -501,6 +764,143 @@ func loadMgmtConfig(mgmtConfigPath string) (*server.Config, error) {
 	return loadedConfig, err
 }
 
+// startRelayServer starts the relay server co-located with the Management service and adds it to the TURN servers
+// of the config. With time based credentials it accepts the ones derived from the TURN secret, generated with the
+// relay credentials when the config has none, else the peers get its generated static credentials
+func startRelayServer(config *server.Config) (*relay.Server, error) {
+	credentials, err := relay.LoadOrGenerateCredentials(config.Datadir)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.TURNConfig == nil {
+		config.TURNConfig = &server.TURNConfig{}
+	}
+
+	var secret string
+	if config.TURNConfig.TimeBasedCredentials {
+		if config.TURNConfig.Secret == "" {
+			config.TURNConfig.Secret = credentials.Secret
+		}
+		if config.TURNConfig.CredentialsTTL.Duration == 0 {
+			config.TURNConfig.CredentialsTTL = util.Duration{Duration: server.DefaultRelayCredentialsTTL}
+		}
+		secret = config.TURNConfig.Secret
+	}
+
+	relayServer, err := relay.New(relayListenAddress, relayPublicAddress, *credentials, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	config.TURNConfig.Turns = append(config.TURNConfig.Turns, &server.Host{
+		Proto:    server.UDP,
+		URI:      relayServer.URI(),
+		Username: credentials.Username,
+		Password: credentials.Password,
+	})
+	return relayServer, nil
+}
+
+// newEventSinkStore wraps the event store to publish the events to the Kafka or NATS sink of the config when one is set
+func newEventSinkStore(config *server.Config, eventStore activity.Store, appMetrics telemetry.AppMetrics) (activity.Store, error) {
+	sinkConfig := config.EventSink
+	if sinkConfig == nil {
+		return eventStore, nil
+	}
+
+	var publisher sink.Publisher
+	switch {
+	case sinkConfig.Kafka != nil && sinkConfig.NATS != nil:
+		return nil, fmt.Errorf("only one of the Kafka and NATS event sinks can be configured")
+	case sinkConfig.Kafka != nil:
+		kafkaPublisher, err := sink.NewKafkaPublisher(*sinkConfig.Kafka)
+		if err != nil {
+			return nil, err
+		}
+		publisher = kafkaPublisher
+		log.Infof("activity events are published to the Kafka topic %s", sinkConfig.Kafka.Topic)
+	case sinkConfig.NATS != nil:
+		natsPublisher, err := sink.NewNATSPublisher(*sinkConfig.NATS)
+		if err != nil {
+			return nil, err
+		}
+		publisher = natsPublisher
+		log.Infof("activity events are published to the NATS subject %s", sinkConfig.NATS.Subject)
+	default:
+		return eventStore, nil
+	}
+
+	return sink.NewStore(eventStore, publisher, sinkConfig.QueueSize, sinkConfig.MaxRetryTime.Duration, appMetrics), nil
+}
+
+// newEventsPruner creates the pruner of the expired activity events, archiving them to the S3 bucket or the local
+// directory of the config when one is set
+func newEventsPruner(config *server.Config, store server.Store, eventStore activity.Store) (*server.EventsPruner, error) {
+	retentionConfig := config.EventsRetention
+	if retentionConfig == nil {
+		return server.NewEventsPruner(store, eventStore, nil, server.DefaultEventsPruneInterval), nil
+	}
+
+	var exporter archive.Exporter
+	switch {
+	case retentionConfig.ArchiveS3 != nil:
+		s3Exporter, err := archive.NewS3Exporter(*retentionConfig.ArchiveS3)
+		if err != nil {
+			return nil, err
+		}
+		exporter = s3Exporter
+		log.Infof("expired activity events are archived to the S3 bucket %s", retentionConfig.ArchiveS3.Bucket)
+	case retentionConfig.ArchiveDir != "":
+		exporter = archive.NewLocalExporter(retentionConfig.ArchiveDir)
+		log.Infof("expired activity events are archived to %s", retentionConfig.ArchiveDir)
+	}
+
+	return server.NewEventsPruner(store, eventStore, exporter, retentionConfig.PruneInterval.Duration), nil
+}
+
+// newNotifier creates the notifier sending the email notifications through the SMTP server of the config, nil when
+// none is set
+func newNotifier(config *server.Config) (*notification.Notifier, error) {
+	notificationsConfig := config.EmailNotifications
+	if notificationsConfig == nil || notificationsConfig.SMTP.Host == "" {
+		return nil, nil
+	}
+
+	mailer, err := notification.NewSMTPMailer(notificationsConfig.SMTP)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("email notifications are sent through the SMTP server %s", notificationsConfig.SMTP.Host)
+
+	return notification.NewNotifier(mailer, notificationsConfig.DashboardURL), nil
+}
+
+// setSinglePortSignal points the peers to the Signal service served on the management port. The Signal.URI of the
+// config is kept, e.g. the public address of a router forwarding 443 to another port, else the Let's Encrypt domain
+// and the management port are used
+func setSinglePortSignal(config *server.Config) error {
+	if config.Signal != nil && config.Signal.URI != "" {
+		log.Infof("the Signal service is served on the management port, peers are given its address %s", config.Signal.URI)
+		return nil
+	}
+	if config.HttpConfig == nil || config.HttpConfig.LetsEncryptDomain == "" {
+		return fmt.Errorf("the Signal service on the management port needs its public address, set Signal.URI in the config")
+	}
+
+	config.Signal = &server.Host{
+		Proto: server.HTTPS,
+		URI:   fmt.Sprintf("%s:%d", config.HttpConfig.LetsEncryptDomain, mgmtPort),
+	}
+	return nil
+}
+
+// registerSinglePortSignal serves the Signal service on the management gRPC server, the requests are told apart from the
+// Management ones by their path
+func registerSinglePortSignal(gRPCAPIHandler *grpc.Server) {
+	signalProto.RegisterSignalExchangeServer(gRPCAPIHandler, signalServer.NewServer())
+}
+
 func updateMgmtConfig(path string, config *server.Config) error {
 	return util.DirectWriteJson(path, config)
 }
@@ -547,23 +947,57 @@ func fetchOIDCConfig(oidcEndpoint string) (OIDCConfigResponse, error) {
 	return config, nil
 }
 
-func loadTLSConfig(certFile string, certKey string) (*tls.Config, error) {
+func loadTLSConfig(certFile string, certKey string) (*tls.Config, *certificateReloader, error) {
 	// Load server's certificate and private key
-	serverCert, err := tls.LoadX509KeyPair(certFile, certKey)
-	if err != nil {
-		return nil, err
+	reloader := &certificateReloader{certFile: certFile, certKey: certKey}
+	if err := reloader.Reload(); err != nil {
+		return nil, nil, err
 	}
 
 	// NewDefaultAppMetrics the credentials and return it
 	config := &tls.Config{
-		Certificates: []tls.Certificate{serverCert},
-		ClientAuth:   tls.NoClientCert,
+		GetCertificate: reloader.GetCertificate,
+		ClientAuth:     tls.NoClientCert,
 		NextProtos: []string{
 			"h2", "http/1.1", // enable HTTP/2
 		},
 	}
 
-	return config, nil
+	return config, reloader, nil
+}
+
+// certificateReloader serves the server certificate, loaded again from its files on Reload, e.g. after a renewal
+type certificateReloader struct {
+	certFile string
+	certKey  string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// Reload loads the certificate and its private key from their files, the previous certificate is kept on failure
+func (r *certificateReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.certKey)
+	if err != nil {
+		return fmt.Errorf("load certificate %s: %w", r.certFile, err)
+	}
+
+	r.mu.Lock()
+	reloaded := r.cert != nil
+	r.cert = &cert
+	r.mu.Unlock()
+
+	if reloaded {
+		log.Infof("reloaded the certificate %s", r.certFile)
+	}
+	return nil
+}
+
+// GetCertificate returns the latest loaded certificate
+func (r *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
 }
 
 func handleRebrand(cmd *cobra.Command) error {
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/netbirdio/netbird/management/server"
+	signalProto "github.com/netbirdio/netbird/signal/proto"
+)
+
+func TestParseFileMode(t *testing.T) {
+	perm, err := parseFileMode("0660")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0660), perm)
+
+	for _, mode := range []string{"", "rw-rw----", "0999", "01777"} {
+		_, err := parseFileMode(mode)
+		assert.Error(t, err, mode)
+	}
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mgmt.sock")
+
+	// a socket left by a previous run is replaced
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	listener, err := listenUnixSocket(path, 0660)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	stat, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0660), stat.Mode().Perm())
+
+	go func() {
+		_ = http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("api"))
+		}))
+	}()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://unix/api/peers")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "api", string(body))
+
+	_, err = listenUnixSocket(filepath.Join(t.TempDir(), "missing", "mgmt.sock"), 0660)
+	assert.Error(t, err, "the directory of the socket doesn't exist")
+}
+
+func TestSetSinglePortSignal(t *testing.T) {
+	previousPort := mgmtPort
+	mgmtPort = 443
+	t.Cleanup(func() { mgmtPort = previousPort })
+
+	t.Run("Let's Encrypt domain and management port by default", func(t *testing.T) {
+		config := &server.Config{HttpConfig: &server.HttpServerConfig{LetsEncryptDomain: "mgmt.example.com"}}
+		require.NoError(t, setSinglePortSignal(config))
+		assert.Equal(t, &server.Host{Proto: server.HTTPS, URI: "mgmt.example.com:443"}, config.Signal)
+	})
+
+	t.Run("no default without a Let's Encrypt domain", func(t *testing.T) {
+		config := &server.Config{HttpConfig: &server.HttpServerConfig{}}
+		assert.Error(t, setSinglePortSignal(config), "the peers can't be given the address of the Signal service")
+		assert.Nil(t, config.Signal)
+
+		assert.Error(t, setSinglePortSignal(&server.Config{Signal: &server.Host{Proto: server.HTTPS}}))
+	})
+
+	t.Run("explicit Signal URI is kept", func(t *testing.T) {
+		signal := &server.Host{Proto: server.HTTPS, URI: "router.example.com:8443"}
+		config := &server.Config{Signal: signal, HttpConfig: &server.HttpServerConfig{LetsEncryptDomain: "mgmt.example.com"}}
+		require.NoError(t, setSinglePortSignal(config))
+		assert.Equal(t, &server.Host{Proto: server.HTTPS, URI: "router.example.com:8443"}, config.Signal)
+
+		config = &server.Config{Signal: signal}
+		require.NoError(t, setSinglePortSignal(config), "the Let's Encrypt domain isn't needed with an explicit Signal URI")
+		assert.Equal(t, "router.example.com:8443", config.Signal.URI)
+	})
+}
+
+func TestSinglePortSignal(t *testing.T) {
+	flag := mgmtCmd.Flags().Lookup("single-port")
+	require.NotNil(t, flag)
+	assert.Equal(t, "false", flag.DefValue, "the Signal service is only served on the management port on demand")
+
+	gRPCAPIHandler := grpc.NewServer()
+	registerSinglePortSignal(gRPCAPIHandler)
+	assert.Contains(t, gRPCAPIHandler.GetServiceInfo(), "signalexchange.SignalExchange")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	httpAPIHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("api"))
+	})
+	serveGRPCWithHTTP(listener, handlerFunc(gRPCAPIHandler, httpAPIHandler), false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	// the message of a peer that isn't connected is refused by the Signal service itself
+	_, err = signalProto.NewSignalExchangeClient(conn).Send(ctx, &signalProto.EncryptedMessage{Key: "peerKey", RemoteKey: "remoteKey"})
+	require.Error(t, err)
+	assert.NotEqual(t, codes.Unimplemented, status.Code(err), "the Signal service should be served on the management port")
+	assert.Contains(t, status.Convert(err).Message(), "not registered")
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/api/peers")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "api", string(body), "the HTTP API should still be served on the management port")
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/telemetry"
+)
+
+// metricsAddress returns the address the metrics are served on, the --metrics-address flag takes precedence over the
+// config. Empty serves them on every address of the --metrics-port
+func metricsAddress(config *server.Config) string {
+	if mgmtMetricsAddress != "" {
+		return mgmtMetricsAddress
+	}
+	if config.Metrics != nil {
+		return config.Metrics.Address
+	}
+	return ""
+}
+
+// listenMetrics listens on the TCP or the unix:// address of the metrics, every address of the port when it is empty
+func listenMetrics(address string, port int) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(address, "unix://"); ok {
+		return listenUnixSocket(path, 0660)
+	}
+	if address == "" {
+		return net.Listen("tcp4", fmt.Sprintf(":%d", port))
+	}
+	return net.Listen("tcp", address)
+}
+
+// metricsExposeConfig returns the authentication, the TLS and the runtime profiles of the metrics config served on
+// the address
+func metricsExposeConfig(config *server.MetricsConfig, address string) (telemetry.ExposeConfig, error) {
+	if config == nil {
+		return telemetry.ExposeConfig{}, nil
+	}
+
+	if (config.BasicAuthUsername == "") != (config.BasicAuthPassword == "") {
+		return telemetry.ExposeConfig{}, fmt.Errorf("the basic authentication of the metrics needs both BasicAuthUsername and BasicAuthPassword")
+	}
+	if (config.CertFile == "") != (config.CertKey == "") {
+		return telemetry.ExposeConfig{}, fmt.Errorf("the TLS of the metrics needs both CertFile and CertKey")
+	}
+
+	exposeConfig := telemetry.ExposeConfig{
+		BearerToken:       config.BearerToken,
+		BasicAuthUsername: config.BasicAuthUsername,
+		BasicAuthPassword: config.BasicAuthPassword,
+		Pprof:             config.Pprof,
+	}
+
+	if config.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.CertKey)
+		if err != nil {
+			return telemetry.ExposeConfig{}, fmt.Errorf("failed loading the certificate of the metrics: %v", err)
+		}
+		exposeConfig.TLSConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+
+	if config.Pprof && !exposeConfig.HasAuth() && !isLocalAddress(address) {
+		return telemetry.ExposeConfig{}, fmt.Errorf("the runtime profiles need an authentication of the metrics or a loopback or unix:// metrics address")
+	}
+
+	return exposeConfig, nil
+}
+
+// isLocalAddress tells whether the address is only reachable from the host: a unix socket or a loopback address
+func isLocalAddress(address string) bool {
+	if strings.HasPrefix(address, "unix://") {
+		return true
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip, err := netip.ParseAddr(host)
+	return err == nil && ip.IsLoopback()
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/telemetry"
+)
+
+func TestMetricsExposeConfig(t *testing.T) {
+	exposeConfig, err := metricsExposeConfig(nil, "")
+	require.NoError(t, err)
+	assert.False(t, exposeConfig.HasAuth())
+
+	exposeConfig, err = metricsExposeConfig(&server.MetricsConfig{BearerToken: "secret", Pprof: true}, "")
+	require.NoError(t, err)
+	assert.True(t, exposeConfig.HasAuth())
+	assert.True(t, exposeConfig.Pprof)
+
+	_, err = metricsExposeConfig(&server.MetricsConfig{Pprof: true}, "")
+	assert.Error(t, err, "the runtime profiles shouldn't be exposed on every address without authentication")
+	_, err = metricsExposeConfig(&server.MetricsConfig{Pprof: true}, "127.0.0.1:8081")
+	assert.NoError(t, err)
+	_, err = metricsExposeConfig(&server.MetricsConfig{Pprof: true}, "unix:///var/run/netbird-mgmt-metrics.sock")
+	assert.NoError(t, err)
+
+	_, err = metricsExposeConfig(&server.MetricsConfig{BasicAuthUsername: "prometheus"}, "")
+	assert.Error(t, err, "a username without a password should be rejected")
+	_, err = metricsExposeConfig(&server.MetricsConfig{CertFile: "/etc/netbird/metrics.crt"}, "")
+	assert.Error(t, err, "a certificate without a key should be rejected")
+	_, err = metricsExposeConfig(&server.MetricsConfig{CertFile: "/nonexistent.crt", CertKey: "/nonexistent.key"}, "")
+	assert.Error(t, err)
+}
+
+func TestIsLocalAddress(t *testing.T) {
+	for address, local := range map[string]bool{
+		"127.0.0.1:8081":          true,
+		"[::1]:8081":              true,
+		"localhost:8081":          true,
+		"unix:///var/run/metrics": true,
+		":8081":                   false,
+		"0.0.0.0:8081":            false,
+		"192.168.1.1:8081":        false,
+		"":                        false,
+	} {
+		assert.Equal(t, local, isLocalAddress(address), address)
+	}
+}
+
+func TestMetricsAddress(t *testing.T) {
+	assert.Empty(t, metricsAddress(&server.Config{}))
+	assert.Equal(t, "127.0.0.1:8081", metricsAddress(&server.Config{Metrics: &server.MetricsConfig{Address: "127.0.0.1:8081"}}))
+
+	mgmtMetricsAddress = "unix:///var/run/netbird-mgmt-metrics.sock"
+	t.Cleanup(func() { mgmtMetricsAddress = "" })
+	assert.Equal(t, mgmtMetricsAddress, metricsAddress(&server.Config{Metrics: &server.MetricsConfig{Address: "127.0.0.1:8081"}}),
+		"the flag should take precedence over the config")
+}
+
+func TestExposeMetrics(t *testing.T) {
+	appMetrics, err := telemetry.NewDefaultAppMetrics(context.Background())
+	require.NoError(t, err)
+
+	socket := filepath.Join(t.TempDir(), "metrics.sock")
+	listener, err := listenMetrics("unix://"+socket, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	exposeConfig, err := metricsExposeConfig(&server.MetricsConfig{
+		BearerToken:       "secret",
+		BasicAuthUsername: "prometheus",
+		BasicAuthPassword: "password",
+		Pprof:             true,
+	}, "unix://"+socket)
+	require.NoError(t, err)
+	require.NoError(t, appMetrics.Expose(listener, "/metrics", exposeConfig))
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	get := func(path string, auth func(r *http.Request)) int {
+		req, err := http.NewRequest(http.MethodGet, "http://metrics"+path, nil)
+		require.NoError(t, err)
+		auth(req)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	noAuth := func(*http.Request) {}
+	bearer := func(token string) func(r *http.Request) {
+		return func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }
+	}
+	basic := func(username, password string) func(r *http.Request) {
+		return func(r *http.Request) { r.SetBasicAuth(username, password) }
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, get("/metrics", noAuth))
+	assert.Equal(t, http.StatusUnauthorized, get("/metrics", bearer("wrong")))
+	assert.Equal(t, http.StatusUnauthorized, get("/metrics", basic("prometheus", "wrong")))
+	assert.Equal(t, http.StatusOK, get("/metrics", bearer("secret")))
+	assert.Equal(t, http.StatusOK, get("/metrics", basic("prometheus", "password")))
+	assert.Equal(t, http.StatusUnauthorized, get("/debug/pprof/cmdline", noAuth))
+	assert.Equal(t, http.StatusOK, get("/debug/pprof/cmdline", bearer("secret")))
+	assert.Equal(t, http.StatusOK, get("/debug/pprof/goroutine", bearer("secret")))
+}
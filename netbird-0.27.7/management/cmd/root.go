@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/netbirdio/netbird/client/procd"
 	"github.com/netbirdio/netbird/version"
 )
 
@@ -26,6 +29,16 @@ var (
 	disableSingleAccMode     bool
 	idpSignKeyRefreshEnabled bool
 	userDeleteFromIDPEnabled bool
+	procdService             string
+	procdInstance            string
+	watchdogInterval         time.Duration
+	relayListenAddress       string
+	relayPublicAddress       string
+	singlePort               bool
+	httpSocket               string
+	httpSocketMode           string
+	// httpSocketPerm is the --http-socket-mode parsed when the flags are validated
+	httpSocketPerm os.FileMode
 
 	rootCmd = &cobra.Command{
 		Use:          "netbird-mgmt",
@@ -54,6 +67,7 @@ func init() {
 	stopCh = make(chan int)
 	mgmtCmd.Flags().IntVar(&mgmtPort, "port", 80, "server port to listen on (defaults to 443 if TLS is enabled, 80 otherwise")
 	mgmtCmd.Flags().IntVar(&mgmtMetricsPort, "metrics-port", 8081, "metrics endpoint http port. Metrics are accessible under host:metrics-port/metrics")
+	mgmtCmd.Flags().StringVar(&mgmtMetricsAddress, "metrics-address", "", "Address the metrics endpoint is served on instead of every address of the --metrics-port, e.g. 127.0.0.1:8081 or unix:///var/run/netbird-mgmt-metrics.sock. Takes precedence over the Metrics.Address of the config")
 	mgmtCmd.Flags().StringVar(&mgmtDataDir, "datadir", defaultMgmtDataDir, "server data directory location")
 	mgmtCmd.Flags().StringVar(&mgmtConfig, "config", defaultMgmtConfig, "Netbird config file location. Config params specified via command line (e.g. datadir) have a precedence over configuration from this file")
 	mgmtCmd.Flags().StringVar(&mgmtLetsencryptDomain, "letsencrypt-domain", "", "a domain to issue Let's Encrypt certificate for. Enables TLS using Let's Encrypt. Will fetch and renew certificate, and run the server with TLS")
@@ -65,6 +79,15 @@ func init() {
 	mgmtCmd.Flags().StringVar(&dnsDomain, "dns-domain", defaultSingleAccModeDomain, fmt.Sprintf("Domain used for peer resolution. This is appended to the peer's name, e.g. pi-server. %s. Max length is 192 characters to allow appending to a peer name with up to 63 characters.", defaultSingleAccModeDomain))
 	mgmtCmd.Flags().BoolVar(&idpSignKeyRefreshEnabled, idpSignKeyRefreshEnabledFlagName, false, "Enable cache headers evaluation to determine signing key rotation period. This will refresh the signing key upon expiry.")
 	mgmtCmd.Flags().BoolVar(&userDeleteFromIDPEnabled, "user-delete-from-idp", false, "Allows to delete user from IDP when user is deleted from account")
+	mgmtCmd.Flags().StringVar(&procdService, "procd-service", "", "procd service of the server on OpenWrt, e.g. netbird-mgmt. Its readiness is broadcast as the ubus event <service>.ready and its watchdog is pinged. Empty disables the procd integration")
+	mgmtCmd.Flags().StringVar(&procdInstance, "procd-instance", procd.DefaultInstance, "procd instance of the server the watchdog pings are sent for")
+	mgmtCmd.Flags().StringVar(&relayListenAddress, "relay-listen-address", "", "UDP address of a TURN relay server run next to the Management service, e.g. :3478. Its credentials are generated in the datadir and it is distributed to the peers with the TURN servers of the config. Empty disables the relay server")
+	mgmtCmd.Flags().StringVar(&relayPublicAddress, "relay-public-address", "", "Public host[:port] the peers reach the relay server at, e.g. router.example.com:3478. Required with --relay-listen-address")
+	mgmtCmd.Flags().BoolVar(&singlePort, "single-port", false, "Serve the Signal service next to the Management gRPC service and the HTTP API on the management port, e.g. when only 443 can be forwarded to the server. HTTP/2 is negotiated with ALPN and the services are told apart by the path of the requests. The peers are given the Signal.URI of the config, the Let's Encrypt domain and the management port if it is empty")
+	mgmtCmd.Flags().DurationVar(&watchdogInterval, "watchdog-interval", 0, "Interval the server pings the procd watchdog of its instance at, at most half of the watchdog timeout, e.g. 30s. 0 disables the pings")
+	mgmtCmd.Flags().StringVar(&ctlAddr, "ctl-addr", defaultCtlAddr, "Local admin socket the netbird-mgmt ctl commands are served on, unix:// or tcp://. Its requests aren't authenticated so a unix socket is only accessible to the user the server runs as. Empty disables it")
+	mgmtCmd.Flags().StringVar(&httpSocket, "http-socket", "", "Unix socket path the HTTP API and the gRPC API are also served on without TLS, e.g. /var/run/netbird-mgmt-http.sock, so a co-located reverse proxy or LuCI backend can reach them without a local port. Empty disables it")
+	mgmtCmd.Flags().StringVar(&httpSocketMode, "http-socket-mode", "0660", "Octal permissions of the --http-socket file")
 	rootCmd.MarkFlagRequired("config") //nolint
 
 	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "")
@@ -78,12 +101,13 @@ func init() {
 	migrationCmd.AddCommand(downCmd)
 
 	rootCmd.AddCommand(migrationCmd)
+	rootCmd.AddCommand(ctlCmd)
 }
 
 // SetupCloseHandler handles SIGTERM signal and exits with success
 func SetupCloseHandler() {
 	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		for range c {
 			fmt.Println("\r- Ctrl+C pressed in Terminal")
@@ -0,0 +1,126 @@
+// Package dashboard serves the dashboard single page application from the Management service.
+//
+// The dashboard build is read from a directory or embedded in the binary: copy the build into management/dashboard/dist
+// and build the Management service with the dashboard build tag.
+package dashboard
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/netbirdio/netbird/management/server"
+)
+
+const (
+	indexFile          = "index.html"
+	defaultCacheMaxAge = 24 * time.Hour
+)
+
+// embedded is the dashboard build embedded in the binary, nil when built without the dashboard build tag
+var embedded fs.FS
+
+// Handler serves the files of the dashboard under its path prefix. The paths that aren't files of the build are
+// answered with the index, so the routes of the application work when loaded directly.
+type Handler struct {
+	files        fs.FS
+	prefix       string
+	cacheControl string
+}
+
+// NewHandler creates the dashboard handler from the directory of the config or the embedded build
+func NewHandler(cfg server.DashboardConfig) (*Handler, error) {
+	files := embedded
+	if cfg.Directory != "" {
+		files = os.DirFS(cfg.Directory)
+	}
+	if files == nil {
+		return nil, errors.New("the binary was built without the dashboard, set the directory of a dashboard build or build with the dashboard tag")
+	}
+	if _, err := fs.Stat(files, indexFile); err != nil {
+		return nil, fmt.Errorf("the dashboard build has no %s: %w", indexFile, err)
+	}
+
+	cacheMaxAge := cfg.CacheMaxAge.Duration
+	if cacheMaxAge <= 0 {
+		cacheMaxAge = defaultCacheMaxAge
+	}
+
+	return &Handler{
+		files:        files,
+		prefix:       strings.TrimSuffix(path.Clean("/"+cfg.PathPrefix), "/"),
+		cacheControl: fmt.Sprintf("public, max-age=%d", int(cacheMaxAge.Seconds())),
+	}, nil
+}
+
+// ServeHTTP serves the file of the build the request path points to, or the index
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	urlPath := path.Clean("/" + r.URL.Path)
+	if urlPath == h.prefix {
+		http.Redirect(w, r, h.prefix+"/", http.StatusMovedPermanently)
+		return
+	}
+	name, ok := strings.CutPrefix(urlPath, h.prefix+"/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if name == "" || name == indexFile {
+		h.serveIndex(w, r)
+		return
+	}
+
+	stat, err := fs.Stat(h.files, name)
+	if err != nil || stat.IsDir() {
+		// the missing assets aren't answered with the index, the browser would fail parsing it anyway
+		if path.Ext(name) != "" {
+			http.NotFound(w, r)
+			return
+		}
+		h.serveIndex(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", h.cacheControl)
+	h.serveFile(w, r, name, stat)
+}
+
+// serveIndex serves the index revalidated on every load, so a new build is picked up right away
+func (h *Handler) serveIndex(w http.ResponseWriter, r *http.Request) {
+	stat, err := fs.Stat(h.files, indexFile)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	h.serveFile(w, r, indexFile, stat)
+}
+
+func (h *Handler) serveFile(w http.ResponseWriter, r *http.Request, name string, stat fs.FileInfo) {
+	f, err := h.files.Open(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	http.ServeContent(w, r, name, stat.ModTime(), content)
+}
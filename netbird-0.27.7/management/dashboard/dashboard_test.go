@@ -0,0 +1,137 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/util"
+)
+
+func writeBuild(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "static"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte("<html>index</html>"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "static", "main.js"), []byte("console.log()"), 0644))
+	return dir
+}
+
+func TestNewHandler(t *testing.T) {
+	_, err := NewHandler(server.DashboardConfig{})
+	assert.Error(t, err, "the tests are built without the dashboard")
+
+	_, err = NewHandler(server.DashboardConfig{Directory: t.TempDir()})
+	assert.Error(t, err, "a build without index should be rejected")
+
+	_, err = NewHandler(server.DashboardConfig{Directory: writeBuild(t)})
+	assert.NoError(t, err)
+}
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	dir := writeBuild(t)
+
+	tt := []struct {
+		name                 string
+		prefix               string
+		method               string
+		path                 string
+		expectedStatus       int
+		expectedBody         string
+		expectedCacheControl string
+		expectedLocation     string
+	}{
+		{
+			name:                 "serves the index at the root",
+			path:                 "/",
+			expectedStatus:       http.StatusOK,
+			expectedBody:         "<html>index</html>",
+			expectedCacheControl: "no-cache",
+		},
+		{
+			name:                 "serves the assets with the cache max age",
+			path:                 "/static/main.js",
+			expectedStatus:       http.StatusOK,
+			expectedBody:         "console.log()",
+			expectedCacheControl: "public, max-age=3600",
+		},
+		{
+			name:                 "falls back to the index for the routes of the application",
+			path:                 "/peers/details",
+			expectedStatus:       http.StatusOK,
+			expectedBody:         "<html>index</html>",
+			expectedCacheControl: "no-cache",
+		},
+		{
+			name:           "doesn't fall back to the index for the missing assets",
+			path:           "/static/missing.js",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:                 "doesn't serve files out of the build",
+			path:                 "/../../etc/passwd",
+			expectedStatus:       http.StatusOK,
+			expectedBody:         "<html>index</html>",
+			expectedCacheControl: "no-cache",
+		},
+		{
+			name:           "rejects the modifying requests",
+			method:         http.MethodPost,
+			path:           "/",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:                 "serves the files under the prefix",
+			prefix:               "/dashboard/",
+			path:                 "/dashboard/static/main.js",
+			expectedStatus:       http.StatusOK,
+			expectedBody:         "console.log()",
+			expectedCacheControl: "public, max-age=3600",
+		},
+		{
+			name:             "redirects the prefix to the index",
+			prefix:           "dashboard",
+			path:             "/dashboard",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "/dashboard/",
+		},
+		{
+			name:           "doesn't serve the paths out of the prefix",
+			prefix:         "/dashboard",
+			path:           "/peers",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, err := NewHandler(server.DashboardConfig{
+				PathPrefix:  tc.prefix,
+				Directory:   dir,
+				CacheMaxAge: util.Duration{Duration: time.Hour},
+			})
+			require.NoError(t, err)
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(method, tc.path, nil))
+
+			assert.Equal(t, tc.expectedStatus, recorder.Code)
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, recorder.Body.String())
+			}
+			assert.Equal(t, tc.expectedCacheControl, recorder.Header().Get("Cache-Control"))
+			assert.Equal(t, tc.expectedLocation, recorder.Header().Get("Location"))
+		})
+	}
+}
@@ -0,0 +1,19 @@
+//go:build dashboard
+
+package dashboard
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed all:dist
+var dist embed.FS
+
+func init() {
+	files, err := fs.Sub(dist, "dist")
+	if err != nil {
+		panic(err)
+	}
+	embedded = files
+}
@@ -0,0 +1,137 @@
+// Package debug serves the runtime diagnostics of the Management service to the holders of its admin token.
+//
+// The endpoints are meant to diagnose stalls in production: the profiles of net/http/pprof, the dump of the
+// goroutines and the stats of the store and of the update channels of the connected peers.
+package debug
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	rpprof "runtime/pprof"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/util"
+)
+
+const (
+	// PathPrefix is the path the endpoints are served under
+	PathPrefix = "/debug/"
+
+	minAdminTokenLength = 32
+)
+
+// Handler serves the diagnostics endpoints to the requests authenticated with the admin token
+type Handler struct {
+	adminToken    string
+	store         server.Store
+	updateManager *server.PeersUpdateManager
+	started       time.Time
+	mux           *http.ServeMux
+}
+
+// RuntimeStats are the state of the Go runtime of the server
+type RuntimeStats struct {
+	Uptime         string `json:"uptime"`
+	Goroutines     int    `json:"goroutines"`
+	HeapAlloc      uint64 `json:"heap_alloc_bytes"`
+	HeapObjects    uint64 `json:"heap_objects"`
+	Sys            uint64 `json:"sys_bytes"`
+	NumGC          uint32 `json:"num_gc"`
+	GCPauseTotalNs uint64 `json:"gc_pause_total_ns"`
+}
+
+// ValidateConfig checks the admin token of the config
+func ValidateConfig(cfg server.DebugConfig) error {
+	if len(cfg.AdminToken) < minAdminTokenLength {
+		return fmt.Errorf("the admin token of the debug endpoint has to be at least %d characters long", minAdminTokenLength)
+	}
+	return nil
+}
+
+// NewHandler creates the diagnostics handler reading the stats of the store and of the update manager
+func NewHandler(cfg server.DebugConfig, store server.Store, updateManager *server.PeersUpdateManager) (*Handler, error) {
+	if err := ValidateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	h := &Handler{
+		adminToken:    cfg.AdminToken,
+		store:         store,
+		updateManager: updateManager,
+		started:       time.Now(),
+		mux:           http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc(PathPrefix+"pprof/", pprof.Index)
+	h.mux.HandleFunc(PathPrefix+"pprof/cmdline", pprof.Cmdline)
+	h.mux.HandleFunc(PathPrefix+"pprof/profile", pprof.Profile)
+	h.mux.HandleFunc(PathPrefix+"pprof/symbol", pprof.Symbol)
+	h.mux.HandleFunc(PathPrefix+"pprof/trace", pprof.Trace)
+	h.mux.HandleFunc(PathPrefix+"goroutines", h.getGoroutines)
+	h.mux.HandleFunc(PathPrefix+"runtime", h.getRuntimeStats)
+	h.mux.HandleFunc(PathPrefix+"store", h.getStoreStats)
+	h.mux.HandleFunc(PathPrefix+"update-channels", h.getUpdateChannelStats)
+
+	return h, nil
+}
+
+// ServeHTTP answers the requests without the admin token with 401 and routes the others to the endpoints
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		log.Warnf("unauthorized request to the debug endpoint %s from %s", r.URL.Path, r.RemoteAddr)
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) == 1
+}
+
+// getGoroutines writes the stacks of all the goroutines with the time they have been blocked for
+func (h *Handler) getGoroutines(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err := rpprof.Lookup("goroutine").WriteTo(w, 2); err != nil {
+		log.Errorf("failed writing the goroutine dump: %v", err)
+	}
+}
+
+func (h *Handler) getRuntimeStats(w http.ResponseWriter, _ *http.Request) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	util.WriteJSONObject(w, &RuntimeStats{
+		Uptime:         time.Since(h.started).Round(time.Second).String(),
+		Goroutines:     runtime.NumGoroutine(),
+		HeapAlloc:      memStats.HeapAlloc,
+		HeapObjects:    memStats.HeapObjects,
+		Sys:            memStats.Sys,
+		NumGC:          memStats.NumGC,
+		GCPauseTotalNs: memStats.PauseTotalNs,
+	})
+}
+
+func (h *Handler) getStoreStats(w http.ResponseWriter, _ *http.Request) {
+	stats, err := h.store.GetStoreStats()
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+	util.WriteJSONObject(w, stats)
+}
+
+func (h *Handler) getUpdateChannelStats(w http.ResponseWriter, _ *http.Request) {
+	util.WriteJSONObject(w, h.updateManager.Stats())
+}
@@ -0,0 +1,97 @@
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server"
+)
+
+const adminToken = "0123456789abcdef0123456789abcdef"
+
+func newTestHandler(t *testing.T) (*Handler, *server.PeersUpdateManager) {
+	t.Helper()
+	store, err := server.NewFileStore(t.TempDir(), nil)
+	require.NoError(t, err)
+	updateManager := server.NewPeersUpdateManager(nil)
+
+	handler, err := NewHandler(server.DebugConfig{AdminToken: adminToken}, store, updateManager)
+	require.NoError(t, err)
+	return handler, updateManager
+}
+
+func serve(handler http.Handler, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestNewHandler(t *testing.T) {
+	_, err := NewHandler(server.DebugConfig{}, nil, nil)
+	assert.Error(t, err, "the endpoint shouldn't be served without an admin token")
+
+	_, err = NewHandler(server.DebugConfig{AdminToken: "short"}, nil, nil)
+	assert.Error(t, err, "a short admin token should be rejected")
+}
+
+func TestHandler_Authentication(t *testing.T) {
+	handler, _ := newTestHandler(t)
+
+	for _, path := range []string{"/debug/pprof/", "/debug/goroutines", "/debug/store", "/debug/update-channels"} {
+		rec := serve(handler, path, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, path)
+		assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"), path)
+
+		rec = serve(handler, path, strings.ToUpper(adminToken))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, path)
+	}
+}
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	handler, updateManager := newTestHandler(t)
+	_ = updateManager.CreateChannel("peer")
+	updateManager.SendUpdate("peer", &server.UpdateMessage{})
+
+	rec := serve(handler, "/debug/pprof/cmdline", adminToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serve(handler, "/debug/goroutines", adminToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "goroutine ")
+
+	rec = serve(handler, "/debug/runtime", adminToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var runtimeStats RuntimeStats
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &runtimeStats))
+	assert.Positive(t, runtimeStats.Goroutines)
+
+	rec = serve(handler, "/debug/store", adminToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var storeStats server.StoreStats
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &storeStats))
+	assert.Equal(t, server.FileStoreEngine, storeStats.Engine)
+	assert.Nil(t, storeStats.Connections)
+
+	rec = serve(handler, "/debug/update-channels", adminToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var channelStats server.UpdateChannelStats
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &channelStats))
+	assert.Equal(t, 1, channelStats.Channels)
+	assert.Equal(t, 1, channelStats.QueuedUpdates)
+	assert.Equal(t, uint64(1), channelStats.SentUpdates)
+	require.Len(t, channelStats.Busiest, 1)
+	assert.Equal(t, "peer", channelStats.Busiest[0].PeerID)
+
+	rec = serve(handler, "/debug/unknown", adminToken)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -43,6 +43,48 @@ type ManagementServiceClient interface {
 	// EncryptedMessage of the request has a body of PKCEAuthorizationFlowRequest.
 	// EncryptedMessage of the response has a body of PKCEAuthorizationFlow.
 	GetPKCEAuthorizationFlow(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*EncryptedMessage, error)
+	// Reports the runtime state of a peer that can't be derived by the Management Service,
+	// e.g. routes that couldn't be installed on the peer's system.
+	// EncryptedMessage of the request has a body of PeerStatusReport.
+	ReportPeerStatus(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error)
+	// Proposes networks of the peer's local network as routes, they are enabled once an admin approves them.
+	// EncryptedMessage of the request has a body of ProposeRoutesRequest.
+	// EncryptedMessage of the response has a body of ProposeRoutesResponse.
+	ProposeRoutes(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*EncryptedMessage, error)
+	// Reports the WireGuard transfer statistics of the peer's connections to its remote peers.
+	// EncryptedMessage of the request has a body of TransferStatsReport.
+	ReportTransferStats(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error)
+	// Reports the counters of the DNS queries handled by the peer's resolver, sent only when the peer enabled its query log.
+	// EncryptedMessage of the request has a body of DNSStatsReport.
+	ReportDNSStats(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error)
+	// Reports the traffic matched by the firewall rules of the policies applied by the peer.
+	// EncryptedMessage of the request has a body of FirewallStatsReport.
+	ReportFirewallStats(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error)
+	// Reports the latest inbound connections dropped by the peer's firewall, sent only when the peer enabled the log of the dropped connections.
+	// EncryptedMessage of the request has a body of DroppedConnectionsReport.
+	ReportDroppedConnections(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error)
+	// Reports an SSH session to the peer's embedded SSH server that ended, stored as an audit event.
+	// EncryptedMessage of the request has a body of SSHSessionReport.
+	ReportSSHSession(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error)
+	// Uploads a debug bundle of the peer for support, stored on the management server.
+	// EncryptedMessage of the request has a body of DebugBundleUpload, of the response a body of DebugBundleUploadResponse.
+	UploadDebugBundle(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*EncryptedMessage, error)
+	// Reports the result of a remote action the peer ran, stored as an activity event.
+	// EncryptedMessage of the request has a body of PeerActionResult.
+	ReportPeerAction(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error)
+	// Replaces the WireGuard public key of the peer for a rotate_wireguard_key action, the request is encrypted with
+	// the old key. EncryptedMessage of the request has a body of RotatePeerKeyRequest.
+	RotatePeerKey(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error)
+	// Resolves a peer of the account the peer wants to probe, e.g. for netbird ping.
+	// EncryptedMessage of the request has a body of ResolvePeerRequest, of the response a body of ResolvePeerResponse.
+	ResolvePeer(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*EncryptedMessage, error)
+	// Reports the result of a throughput test the peer ran against another peer, stored as an activity event.
+	// EncryptedMessage of the request has a body of SpeedtestReport.
+	ReportSpeedtest(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error)
+	// Reports the load of a routing peer, which the Management Service uses to make the clients prefer the members of
+	// a route group that aren't overloaded.
+	// EncryptedMessage of the request has a body of PeerLoadReport.
+	ReportPeerLoad(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error)
 }
 
 type managementServiceClient struct {
@@ -130,6 +172,123 @@ func (c *managementServiceClient) GetPKCEAuthorizationFlow(ctx context.Context,
 	return out, nil
 }
 
+func (c *managementServiceClient) ReportPeerStatus(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/management.ManagementService/ReportPeerStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managementServiceClient) ProposeRoutes(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*EncryptedMessage, error) {
+	out := new(EncryptedMessage)
+	err := c.cc.Invoke(ctx, "/management.ManagementService/ProposeRoutes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managementServiceClient) ReportTransferStats(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/management.ManagementService/ReportTransferStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managementServiceClient) ReportDNSStats(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/management.ManagementService/ReportDNSStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managementServiceClient) ReportFirewallStats(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/management.ManagementService/ReportFirewallStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managementServiceClient) ReportDroppedConnections(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/management.ManagementService/ReportDroppedConnections", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managementServiceClient) ReportSSHSession(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/management.ManagementService/ReportSSHSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managementServiceClient) UploadDebugBundle(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*EncryptedMessage, error) {
+	out := new(EncryptedMessage)
+	err := c.cc.Invoke(ctx, "/management.ManagementService/UploadDebugBundle", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managementServiceClient) ReportPeerAction(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/management.ManagementService/ReportPeerAction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managementServiceClient) RotatePeerKey(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/management.ManagementService/RotatePeerKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managementServiceClient) ResolvePeer(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*EncryptedMessage, error) {
+	out := new(EncryptedMessage)
+	err := c.cc.Invoke(ctx, "/management.ManagementService/ResolvePeer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managementServiceClient) ReportSpeedtest(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/management.ManagementService/ReportSpeedtest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managementServiceClient) ReportPeerLoad(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/management.ManagementService/ReportPeerLoad", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ManagementServiceServer is the server API for ManagementService service.
 // All implementations must embed UnimplementedManagementServiceServer
 // for forward compatibility
@@ -159,6 +318,48 @@ type ManagementServiceServer interface {
 	// EncryptedMessage of the request has a body of PKCEAuthorizationFlowRequest.
 	// EncryptedMessage of the response has a body of PKCEAuthorizationFlow.
 	GetPKCEAuthorizationFlow(context.Context, *EncryptedMessage) (*EncryptedMessage, error)
+	// Reports the runtime state of a peer that can't be derived by the Management Service,
+	// e.g. routes that couldn't be installed on the peer's system.
+	// EncryptedMessage of the request has a body of PeerStatusReport.
+	ReportPeerStatus(context.Context, *EncryptedMessage) (*Empty, error)
+	// Proposes networks of the peer's local network as routes, they are enabled once an admin approves them.
+	// EncryptedMessage of the request has a body of ProposeRoutesRequest.
+	// EncryptedMessage of the response has a body of ProposeRoutesResponse.
+	ProposeRoutes(context.Context, *EncryptedMessage) (*EncryptedMessage, error)
+	// Reports the WireGuard transfer statistics of the peer's connections to its remote peers.
+	// EncryptedMessage of the request has a body of TransferStatsReport.
+	ReportTransferStats(context.Context, *EncryptedMessage) (*Empty, error)
+	// Reports the counters of the DNS queries handled by the peer's resolver, sent only when the peer enabled its query log.
+	// EncryptedMessage of the request has a body of DNSStatsReport.
+	ReportDNSStats(context.Context, *EncryptedMessage) (*Empty, error)
+	// Reports the traffic matched by the firewall rules of the policies applied by the peer.
+	// EncryptedMessage of the request has a body of FirewallStatsReport.
+	ReportFirewallStats(context.Context, *EncryptedMessage) (*Empty, error)
+	// Reports the latest inbound connections dropped by the peer's firewall, sent only when the peer enabled the log of the dropped connections.
+	// EncryptedMessage of the request has a body of DroppedConnectionsReport.
+	ReportDroppedConnections(context.Context, *EncryptedMessage) (*Empty, error)
+	// Reports an SSH session to the peer's embedded SSH server that ended, stored as an audit event.
+	// EncryptedMessage of the request has a body of SSHSessionReport.
+	ReportSSHSession(context.Context, *EncryptedMessage) (*Empty, error)
+	// Uploads a debug bundle of the peer for support, stored on the management server.
+	// EncryptedMessage of the request has a body of DebugBundleUpload, of the response a body of DebugBundleUploadResponse.
+	UploadDebugBundle(context.Context, *EncryptedMessage) (*EncryptedMessage, error)
+	// Reports the result of a remote action the peer ran, stored as an activity event.
+	// EncryptedMessage of the request has a body of PeerActionResult.
+	ReportPeerAction(context.Context, *EncryptedMessage) (*Empty, error)
+	// Replaces the WireGuard public key of the peer for a rotate_wireguard_key action, the request is encrypted with
+	// the old key. EncryptedMessage of the request has a body of RotatePeerKeyRequest.
+	RotatePeerKey(context.Context, *EncryptedMessage) (*Empty, error)
+	// Resolves a peer of the account the peer wants to probe, e.g. for netbird ping.
+	// EncryptedMessage of the request has a body of ResolvePeerRequest, of the response a body of ResolvePeerResponse.
+	ResolvePeer(context.Context, *EncryptedMessage) (*EncryptedMessage, error)
+	// Reports the result of a throughput test the peer ran against another peer, stored as an activity event.
+	// EncryptedMessage of the request has a body of SpeedtestReport.
+	ReportSpeedtest(context.Context, *EncryptedMessage) (*Empty, error)
+	// Reports the load of a routing peer, which the Management Service uses to make the clients prefer the members of
+	// a route group that aren't overloaded.
+	// EncryptedMessage of the request has a body of PeerLoadReport.
+	ReportPeerLoad(context.Context, *EncryptedMessage) (*Empty, error)
 	mustEmbedUnimplementedManagementServiceServer()
 }
 
@@ -184,6 +385,45 @@ func (UnimplementedManagementServiceServer) GetDeviceAuthorizationFlow(context.C
 func (UnimplementedManagementServiceServer) GetPKCEAuthorizationFlow(context.Context, *EncryptedMessage) (*EncryptedMessage, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetPKCEAuthorizationFlow not implemented")
 }
+func (UnimplementedManagementServiceServer) ReportPeerStatus(context.Context, *EncryptedMessage) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportPeerStatus not implemented")
+}
+func (UnimplementedManagementServiceServer) ProposeRoutes(context.Context, *EncryptedMessage) (*EncryptedMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProposeRoutes not implemented")
+}
+func (UnimplementedManagementServiceServer) ReportTransferStats(context.Context, *EncryptedMessage) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportTransferStats not implemented")
+}
+func (UnimplementedManagementServiceServer) ReportDNSStats(context.Context, *EncryptedMessage) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportDNSStats not implemented")
+}
+func (UnimplementedManagementServiceServer) ReportFirewallStats(context.Context, *EncryptedMessage) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportFirewallStats not implemented")
+}
+func (UnimplementedManagementServiceServer) ReportDroppedConnections(context.Context, *EncryptedMessage) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportDroppedConnections not implemented")
+}
+func (UnimplementedManagementServiceServer) ReportSSHSession(context.Context, *EncryptedMessage) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportSSHSession not implemented")
+}
+func (UnimplementedManagementServiceServer) UploadDebugBundle(context.Context, *EncryptedMessage) (*EncryptedMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UploadDebugBundle not implemented")
+}
+func (UnimplementedManagementServiceServer) ReportPeerAction(context.Context, *EncryptedMessage) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportPeerAction not implemented")
+}
+func (UnimplementedManagementServiceServer) RotatePeerKey(context.Context, *EncryptedMessage) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RotatePeerKey not implemented")
+}
+func (UnimplementedManagementServiceServer) ResolvePeer(context.Context, *EncryptedMessage) (*EncryptedMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResolvePeer not implemented")
+}
+func (UnimplementedManagementServiceServer) ReportSpeedtest(context.Context, *EncryptedMessage) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportSpeedtest not implemented")
+}
+func (UnimplementedManagementServiceServer) ReportPeerLoad(context.Context, *EncryptedMessage) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportPeerLoad not implemented")
+}
 func (UnimplementedManagementServiceServer) mustEmbedUnimplementedManagementServiceServer() {}
 
 // UnsafeManagementServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -308,6 +548,240 @@ func _ManagementService_GetPKCEAuthorizationFlow_Handler(srv interface{}, ctx co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ManagementService_ReportPeerStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptedMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagementServiceServer).ReportPeerStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/management.ManagementService/ReportPeerStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagementServiceServer).ReportPeerStatus(ctx, req.(*EncryptedMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ManagementService_ProposeRoutes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptedMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagementServiceServer).ProposeRoutes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/management.ManagementService/ProposeRoutes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagementServiceServer).ProposeRoutes(ctx, req.(*EncryptedMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ManagementService_ReportTransferStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptedMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagementServiceServer).ReportTransferStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/management.ManagementService/ReportTransferStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagementServiceServer).ReportTransferStats(ctx, req.(*EncryptedMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ManagementService_ReportDNSStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptedMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagementServiceServer).ReportDNSStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/management.ManagementService/ReportDNSStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagementServiceServer).ReportDNSStats(ctx, req.(*EncryptedMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ManagementService_ReportFirewallStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptedMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagementServiceServer).ReportFirewallStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/management.ManagementService/ReportFirewallStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagementServiceServer).ReportFirewallStats(ctx, req.(*EncryptedMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ManagementService_ReportDroppedConnections_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptedMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagementServiceServer).ReportDroppedConnections(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/management.ManagementService/ReportDroppedConnections",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagementServiceServer).ReportDroppedConnections(ctx, req.(*EncryptedMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ManagementService_ReportSSHSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptedMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagementServiceServer).ReportSSHSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/management.ManagementService/ReportSSHSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagementServiceServer).ReportSSHSession(ctx, req.(*EncryptedMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ManagementService_UploadDebugBundle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptedMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagementServiceServer).UploadDebugBundle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/management.ManagementService/UploadDebugBundle",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagementServiceServer).UploadDebugBundle(ctx, req.(*EncryptedMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ManagementService_ReportPeerAction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptedMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagementServiceServer).ReportPeerAction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/management.ManagementService/ReportPeerAction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagementServiceServer).ReportPeerAction(ctx, req.(*EncryptedMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ManagementService_RotatePeerKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptedMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagementServiceServer).RotatePeerKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/management.ManagementService/RotatePeerKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagementServiceServer).RotatePeerKey(ctx, req.(*EncryptedMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ManagementService_ResolvePeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptedMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagementServiceServer).ResolvePeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/management.ManagementService/ResolvePeer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagementServiceServer).ResolvePeer(ctx, req.(*EncryptedMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ManagementService_ReportSpeedtest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptedMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagementServiceServer).ReportSpeedtest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/management.ManagementService/ReportSpeedtest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagementServiceServer).ReportSpeedtest(ctx, req.(*EncryptedMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ManagementService_ReportPeerLoad_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptedMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagementServiceServer).ReportPeerLoad(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/management.ManagementService/ReportPeerLoad",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagementServiceServer).ReportPeerLoad(ctx, req.(*EncryptedMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ManagementService_ServiceDesc is the grpc.ServiceDesc for ManagementService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -335,6 +809,58 @@ var ManagementService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetPKCEAuthorizationFlow",
 			Handler:    _ManagementService_GetPKCEAuthorizationFlow_Handler,
 		},
+		{
+			MethodName: "ReportPeerStatus",
+			Handler:    _ManagementService_ReportPeerStatus_Handler,
+		},
+		{
+			MethodName: "ProposeRoutes",
+			Handler:    _ManagementService_ProposeRoutes_Handler,
+		},
+		{
+			MethodName: "ReportTransferStats",
+			Handler:    _ManagementService_ReportTransferStats_Handler,
+		},
+		{
+			MethodName: "ReportDNSStats",
+			Handler:    _ManagementService_ReportDNSStats_Handler,
+		},
+		{
+			MethodName: "ReportFirewallStats",
+			Handler:    _ManagementService_ReportFirewallStats_Handler,
+		},
+		{
+			MethodName: "ReportDroppedConnections",
+			Handler:    _ManagementService_ReportDroppedConnections_Handler,
+		},
+		{
+			MethodName: "ReportSSHSession",
+			Handler:    _ManagementService_ReportSSHSession_Handler,
+		},
+		{
+			MethodName: "UploadDebugBundle",
+			Handler:    _ManagementService_UploadDebugBundle_Handler,
+		},
+		{
+			MethodName: "ReportPeerAction",
+			Handler:    _ManagementService_ReportPeerAction_Handler,
+		},
+		{
+			MethodName: "RotatePeerKey",
+			Handler:    _ManagementService_RotatePeerKey_Handler,
+		},
+		{
+			MethodName: "ResolvePeer",
+			Handler:    _ManagementService_ResolvePeer_Handler,
+		},
+		{
+			MethodName: "ReportSpeedtest",
+			Handler:    _ManagementService_ReportSpeedtest_Handler,
+		},
+		{
+			MethodName: "ReportPeerLoad",
+			Handler:    _ManagementService_ReportPeerLoad_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -6,11 +6,13 @@ import (
 	b64 "encoding/base64"
 	"fmt"
 	"hash/crc32"
+	"maps"
 	"math/rand"
 	"net"
 	"net/netip"
 	"reflect"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -23,6 +25,7 @@ import (
 
 	"github.com/netbirdio/netbird/base62"
 	nbdns "github.com/netbirdio/netbird/dns"
+	"github.com/netbirdio/netbird/management/proto"
 	"github.com/netbirdio/netbird/management/server/account"
 	"github.com/netbirdio/netbird/management/server/activity"
 	"github.com/netbirdio/netbird/management/server/geolocation"
@@ -31,9 +34,11 @@ import (
 	"github.com/netbirdio/netbird/management/server/integrated_validator"
 	"github.com/netbirdio/netbird/management/server/integration_reference"
 	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/notification"
 	nbpeer "github.com/netbirdio/netbird/management/server/peer"
 	"github.com/netbirdio/netbird/management/server/posture"
 	"github.com/netbirdio/netbird/management/server/status"
+	"github.com/netbirdio/netbird/management/server/telemetry"
 	"github.com/netbirdio/netbird/route"
 )
 
@@ -44,6 +49,9 @@ const (
 	CacheExpirationMax         = 7 * 24 * 3600 * time.Second // 7 days
 	CacheExpirationMin         = 3 * 24 * 3600 * time.Second // 3 days
 	DefaultPeerLoginExpiration = 24 * time.Hour
+
+	minInterfaceMTU = 576
+	maxInterfaceMTU = 9000
 )
 
 type userLoggedInOnce bool
@@ -60,6 +68,8 @@ type AccountManager interface {
 	CreateSetupKey(accountID string, keyName string, keyType SetupKeyType, expiresIn time.Duration,
 		autoGroups []string, usageLimit int, userID string, ephemeral bool) (*SetupKey, error)
 	SaveSetupKey(accountID string, key *SetupKey, userID string) (*SetupKey, error)
+	ReplaceSetupKey(accountID, userID, keyID string) (*SetupKey, error)
+	ListStaleSetupKeys(accountID, userID string, maxAge time.Duration) ([]*SetupKey, error)
 	CreateUser(accountID, initiatorUserID string, key *UserInfo) (*UserInfo, error)
 	DeleteUser(accountID, initiatorUserID string, targetUserID string) error
 	InviteUser(accountID string, initiatorUserID string, targetUserID string) error
@@ -69,9 +79,15 @@ type AccountManager interface {
 	GetSetupKey(accountID, userID, keyID string) (*SetupKey, error)
 	GetAccountByUserOrAccountID(userID, accountID, domain string) (*Account, error)
 	GetAccountFromToken(claims jwtclaims.AuthorizationClaims) (*Account, *User, error)
+	GetAccountIDFromToken(claims jwtclaims.AuthorizationClaims) (string, string, error)
 	CheckUserAccessByJWTGroups(claims jwtclaims.AuthorizationClaims) error
 	GetAccountFromPAT(pat string) (*Account, *User, *PersonalAccessToken, error)
 	DeleteAccount(accountID, userID string) error
+	RequestAccountDeletion(accountID, userID string) (*AccountDeletion, error)
+	ConfirmAccountDeletion(accountID, userID, token string) (*AccountDeletion, error)
+	GetAccountDeletion(accountID, userID string) (*AccountDeletion, error)
+	CancelAccountDeletion(accountID, userID string) error
+	GetAccountExport(accountID, userID string) (string, []byte, error)
 	MarkPATUsed(tokenID string) error
 	GetUser(claims jwtclaims.AuthorizationClaims) (*User, error)
 	ListUsers(accountID string) ([]*User, error)
@@ -80,16 +96,46 @@ type AccountManager interface {
 	DeletePeer(accountID, peerID, userID string) error
 	UpdatePeer(accountID, userID string, peer *nbpeer.Peer) (*nbpeer.Peer, error)
 	GetNetworkMap(peerID string) (*NetworkMap, error)
+	ResyncAccountPeers(accountID, userID string) error
 	GetPeerNetwork(peerID string) (*Network, error)
 	AddPeer(setupKey, userID string, peer *nbpeer.Peer) (*nbpeer.Peer, *NetworkMap, error)
 	CreatePAT(accountID string, initiatorUserID string, targetUserID string, tokenName string, expiresIn int) (*PersonalAccessTokenGenerated, error)
 	DeletePAT(accountID string, initiatorUserID string, targetUserID string, tokenID string) error
 	GetPAT(accountID string, initiatorUserID string, targetUserID string, tokenID string) (*PersonalAccessToken, error)
 	GetAllPATs(accountID string, initiatorUserID string, targetUserID string) ([]*PersonalAccessToken, error)
+	GetUserNotifications(accountID, initiatorUserID, targetUserID string) ([]string, error)
+	UpdateUserNotifications(accountID, initiatorUserID, targetUserID string, kinds []string) ([]string, error)
 	UpdatePeerSSHKey(peerID string, sshKey string) error
+	UpdatePeerRouteConflicts(peerPubKey string, conflicts []nbpeer.RouteConflict) error   // used by peer gRPC API
+	UpdatePeerNATType(peerPubKey string, natType string) error                            // used by peer gRPC API
+	UpdatePeerRosenpassPubKey(peerPubKey string, rosenpassPubKey []byte) error            // used by peer gRPC API
+	ProposePeerRoutes(peerPubKey string, networks []netip.Prefix) ([]*route.Route, error) // used by peer gRPC API
+	UpdatePeerTransferStats(peerPubKey string, stats []nbpeer.TransferStats) error        // used by peer gRPC API
+	GetPeerTransferStats(accountID, peerID, userID string) ([]nbpeer.TransferStats, error)
+	GetRelayedConnections(accountID, userID string) ([]RelayedConnection, error)
+	CountRelayedConnections() int                                      // used by telemetry
+	UpdatePeerDNSStats(peerPubKey string, stats nbpeer.DNSStats) error // used by peer gRPC API
+	GetPeerDNSStats(accountID, peerID, userID string) (*nbpeer.DNSStats, error)
+	UpdatePeerFirewallStats(peerPubKey string, stats []nbpeer.FirewallStats) error // used by peer gRPC API
+	GetPolicyStats(accountID, policyID, userID string) (*PolicyStats, error)
+	UpdatePeerDroppedConnections(peerPubKey string, conns []nbpeer.DroppedConnection) error // used by peer gRPC API
+	StorePeerSSHSession(peerPubKey string, session nbpeer.SSHSession) error                 // used by peer gRPC API
+	StorePeerSpeedtest(peerPubKey string, result nbpeer.SpeedtestResult) error              // used by peer gRPC API
+	StorePeerDebugBundle(peerPubKey string, bundle []byte) (string, error)                  // used by peer gRPC API
+	GetPeerDiagnostics(accountID, peerID, userID string) (*nbpeer.Diagnostics, error)
+	GetPeerNetworkMapDump(accountID, peerID, userID string) (*proto.NetworkMap, error)
+	RequestPeerAction(accountID, peerID, userID, action string) (string, error)
+	ReportPeerAction(peerPubKey string, result PeerActionResult) error // used by peer gRPC API
+	RotatePeerKey(peerPubKey, actionID, newPubKey string) error        // used by peer gRPC API
+	RequestPeerPing(accountID, peerID, userID, targetPeerID string) (string, error)
+	GetPeerPing(accountID, peerID, userID, pingID string) (*PeerPing, error)
+	ResolvePeer(peerPubKey, target string) (*nbpeer.Peer, bool, error) // used by peer gRPC API
 	GetUsersFromAccount(accountID, userID string) ([]*UserInfo, error)
 	GetGroup(accountId, groupID, userID string) (*nbgroup.Group, error)
 	GetAllGroups(accountID, userID string) ([]*nbgroup.Group, error)
+	GetGroupsForUser(accountID, userID string) ([]*nbgroup.Group, map[string]*nbpeer.Peer, error)
+	GetGroupsSummaryForUser(accountID, userID string) ([]*nbgroup.Group, error)
+	GetGroupPeersForUser(accountID, userID, groupID string, offset, limit int) ([]*nbpeer.Peer, int, error)
 	GetGroupByName(groupName, accountID string) (*nbgroup.Group, error)
 	SaveGroup(accountID, userID string, group *nbgroup.Group) error
 	DeleteGroup(accountId, userId, groupID string) error
@@ -100,19 +146,67 @@ type AccountManager interface {
 	SavePolicy(accountID, userID string, policy *Policy) error
 	DeletePolicy(accountID, policyID, userID string) error
 	ListPolicies(accountID, userID string) ([]*Policy, error)
+	GetPoliciesForUser(accountID, userID string) ([]*Policy, map[string]*nbgroup.Group, error)
 	GetRoute(accountID string, routeID route.ID, userID string) (*route.Route, error)
-	CreateRoute(accountID, prefix, peerID string, peerGroupIDs []string, description string, netID route.NetID, masquerade bool, metric int, groups []string, enabled bool, userID string) (*route.Route, error)
+	CreateRoute(accountID, prefix string, domains []string, keepRoute bool, peerID string, peerGroupIDs []string, description string, netID route.NetID, masquerade bool, metric, weight int, groups []string, enabled bool, userID string) (*route.Route, error)
 	SaveRoute(accountID, userID string, route *route.Route) error
+	ApproveRoute(accountID string, routeID route.ID, userID string) (*route.Route, error)
 	DeleteRoute(accountID string, routeID route.ID, userID string) error
 	ListRoutes(accountID, userID string) ([]*route.Route, error)
 	GetNameServerGroup(accountID, userID, nsGroupID string) (*nbdns.NameServerGroup, error)
-	CreateNameServerGroup(accountID string, name, description string, nameServerList []nbdns.NameServer, groups []string, primary bool, domains []string, enabled bool, userID string, searchDomainsEnabled bool) (*nbdns.NameServerGroup, error)
+	CreateNameServerGroup(accountID string, name, description string, nameServerList []nbdns.NameServer, groups []string, primary bool, domains []string, enabled bool, userID string, searchDomainsEnabled bool, priority int) (*nbdns.NameServerGroup, error)
 	SaveNameServerGroup(accountID, userID string, nsGroupToSave *nbdns.NameServerGroup) error
 	DeleteNameServerGroup(accountID, nsGroupID, userID string) error
 	ListNameServerGroups(accountID string, userID string) ([]*nbdns.NameServerGroup, error)
+	GetDNSRecord(accountID, userID, recordID string) (*nbdns.CustomRecord, error)
+	CreateDNSRecord(accountID, userID string, record *nbdns.CustomRecord) (*nbdns.CustomRecord, error)
+	SaveDNSRecord(accountID, userID string, record *nbdns.CustomRecord) error
+	DeleteDNSRecord(accountID, userID, recordID string) error
+	ListDNSRecords(accountID, userID string) ([]*nbdns.CustomRecord, error)
+	ExportDNSZones(accountID, userID string) ([]nbdns.CustomZone, error)
+	GetRelayServer(accountID, userID, relayID string) (*RelayServer, error)
+	CreateRelayServer(accountID, userID string, relay *RelayServer) (*RelayServer, error)
+	SaveRelayServer(accountID, userID string, relay *RelayServer) error
+	DeleteRelayServer(accountID, userID, relayID string) error
+	ListRelayServers(accountID, userID string) ([]*RelayServer, error)
+	GetClaimGroupMapping(accountID, userID, mappingID string) (*ClaimGroupMapping, error)
+	CreateClaimGroupMapping(accountID, userID string, mapping *ClaimGroupMapping) (*ClaimGroupMapping, error)
+	SaveClaimGroupMapping(accountID, userID string, mapping *ClaimGroupMapping) error
+	DeleteClaimGroupMapping(accountID, userID, mappingID string) error
+	ListClaimGroupMappings(accountID, userID string) ([]*ClaimGroupMapping, error)
+	GetRouteGroup(accountID, userID, routeGroupID string) (*RouteGroup, error)
+	CreateRouteGroup(accountID, userID string, routeGroup *RouteGroup) (*RouteGroup, error)
+	SaveRouteGroup(accountID, userID string, routeGroup *RouteGroup) error
+	DeleteRouteGroup(accountID, userID, routeGroupID string) error
+	ListRouteGroups(accountID, userID string) ([]*RouteGroup, error)
+	GetRouteGroupMembersStatus(accountID, userID string) (map[string][]RouteGroupMemberStatus, error)
+	UpdatePeerLoad(peerPubKey string, load nbpeer.Load) error // used by peer gRPC API
+	GetRoutingPeersLoad(accountID, userID string) (map[string]nbpeer.Load, error)
+	GetPeerGeofence(accountID, userID, peerID string) (*PeerGeofence, error)
+	RequestPeerGeofenceOverride(accountID, userID, peerID, reason string) (*PeerGeofence, error)
+	ApprovePeerGeofenceOverride(accountID, userID, peerID string, duration time.Duration) (*PeerGeofence, error)
+	RevokePeerGeofenceOverride(accountID, userID, peerID string) (*PeerGeofence, error)
+	GetExternalList(accountID, userID, listID string) (*ExternalList, error)
+	CreateExternalList(accountID, userID string, list *ExternalList) (*ExternalList, error)
+	SaveExternalList(accountID, userID string, list *ExternalList) error
+	DeleteExternalList(accountID, userID, listID string) error
+	ListExternalLists(accountID, userID string) ([]*ExternalList, error)
+	RefreshExternalList(accountID, userID, listID string) (*ExternalList, error)
+	SyncUserClaimGroups(accountID string, claims jwtclaims.AuthorizationClaims) error
+	GetPeerQuotaUsage(accountID, userID string) (*PeerQuotaUsage, error)
+	CountAPIRequest(claims jwtclaims.AuthorizationClaims) error
+	GetAPIUsage(accountID, userID string) (*APIUsage, error)
+	RefreshPeerRelays(peerID string) ([]*RelayServer, error) // used by peer gRPC API
 	GetDNSDomain() string
 	StoreEvent(initiatorID, targetID, accountID string, activityID activity.ActivityDescriber, meta map[string]any)
 	GetEvents(accountID, userID string) ([]*activity.Event, error)
+	GetUsageReport(accountID, userID string, from, to time.Time, period UsageReportPeriod) (*UsageReport, error)
+	GetPeersInventory(accountID, userID string, filter InventoryFilter) ([]*nbpeer.Peer, error)
+	GetPackagesReport(accountID, userID string) ([]*PackageVersionReport, error)
+	GetPeerDNSLabels(accountID, userID string) ([]PeerDNSLabel, error)
+	UpdatePeerDNSLabel(accountID, userID, peerID, label string) (*nbpeer.Peer, error)
+	GetIPPool(accountID, userID string) (*IPPool, error)
+	AssignPeerIP(accountID, userID, peerID string, ip net.IP) (*nbpeer.Peer, error)
 	GetDNSSettings(accountID string, userID string) (*DNSSettings, error)
 	SaveDNSSettings(accountID string, userID string, dnsSettingsToSave *DNSSettings) error
 	GetPeer(accountID, peerID, userID string) (*nbpeer.Peer, error)
@@ -157,11 +251,74 @@ type DefaultAccountManager struct {
 	// dnsDomain is used for peer resolution. This is appended to the peer's name
 	dnsDomain       string
 	peerLoginExpiry Scheduler
+	peerKeyRotation Scheduler
 
 	// userDeleteFromIDPEnabled allows to delete user from IDP when user is deleted from account
 	userDeleteFromIDPEnabled bool
 
 	integratedPeerValidator integrated_validator.IntegratedValidator
+
+	// notifier sends the email notifications, nil when they aren't enabled
+	notifier *notification.Notifier
+
+	// debugBundleDir is the directory the debug bundles uploaded by the peers are stored in, empty when the uploads
+	// aren't enabled
+	debugBundleDir string
+
+	// clientUpdates gives the versions of the update channels pushed to the auto updating peers, nil when the peers
+	// aren't told to update
+	clientUpdates *ClientUpdatesConfig
+
+	// transferStats holds the latest transfer statistics reported by the peers, keyed by the peer's WireGuard public key
+	transferStats    map[string][]nbpeer.TransferStats
+	transferStatsMux sync.RWMutex
+
+	// dnsStats holds the latest DNS query counters reported by the peers, keyed by the peer's WireGuard public key
+	dnsStats    map[string]nbpeer.DNSStats
+	dnsStatsMux sync.RWMutex
+
+	// firewallStats holds the latest firewall rule counters reported by the peers, keyed by the peer's WireGuard public key
+	firewallStats    map[string][]nbpeer.FirewallStats
+	firewallStatsMux sync.RWMutex
+
+	// diagnostics holds the latest diagnostic data reported by the peers, keyed by the peer's WireGuard public key
+	diagnostics    map[string]nbpeer.Diagnostics
+	diagnosticsMux sync.RWMutex
+
+	// peerLoads holds the latest load reported by the routing peers, keyed by the peer's WireGuard public key
+	peerLoads    map[string]nbpeer.Load
+	peerLoadsMux sync.RWMutex
+
+	// keyRotations holds the rotate_wireguard_key actions sent to the peers, keyed by the peer ID
+	keyRotations    map[string]keyRotation
+	keyRotationsMux sync.Mutex
+
+	// peerPings holds the pings the users requested the peers to run, keyed by the action ID
+	peerPings    map[string]*PeerPing
+	peerPingsMux sync.Mutex
+
+	accountDeletion Scheduler
+	// accountDeletionTokens holds the confirmation tokens issued to the owners deleting their account, keyed by the
+	// account ID
+	accountDeletionTokens map[string]accountDeletionToken
+	accountDeletionsMux   sync.Mutex
+	// accountExportDir is the directory the accounts scheduled for deletion are exported to, empty when they aren't
+	// exported
+	accountExportDir string
+
+	// leaderElection elects the instance running the background jobs, nil when every instance runs them
+	leaderElection *LeaderElection
+
+	// apiQuotas limits the HTTP API requests of the accounts, nil when they aren't metered
+	apiQuotas       *APIQuotasConfig
+	apiQuotaMetrics *telemetry.APIQuotaMetrics
+	// apiUsage holds the HTTP API requests counted of the accounts, keyed by the account ID
+	apiUsage    map[string]*apiUsage
+	apiUsageMux sync.Mutex
+
+	externalListRefresh Scheduler
+	// externalListMetrics counts the refreshes of the external lists, nil when they aren't reported
+	externalListMetrics *telemetry.ExternalListMetrics
 }
 
 // Settings represents Account settings structure that can be modified via API and Dashboard
@@ -191,18 +348,115 @@ type Settings struct {
 
 	// Extra is a dictionary of Account settings
 	Extra *account.ExtraSettings `gorm:"embedded;embeddedPrefix:extra_"`
+
+	// PresharedKeyMode defines how the WireGuard preshared keys distributed to the peers are generated.
+	// Preshared keys aren't distributed if it's empty
+	PresharedKeyMode string
+
+	// PresharedKeySecret is the secret the preshared keys are derived from. It is never exposed through the API
+	PresharedKeySecret string
+
+	// InterfaceMTU is the MTU of the WireGuard interface pushed to the peers. 0 keeps the default of the clients
+	InterfaceMTU int
+
+	// DNSScopedGroups groups whose peers only resolve the names of the peers they can reach through the policies
+	DNSScopedGroups []string `gorm:"serializer:json"`
+
+	// ICEDisabledCandidateTypes are the ICE candidate types the peers never gather: host, srflx or relay
+	ICEDisabledCandidateTypes []string `gorm:"serializer:json"`
+
+	// ICEExcludedSubnets are the local subnets the peers gather no ICE host candidate from, e.g. Docker bridges
+	ICEExcludedSubnets []string `gorm:"serializer:json"`
+
+	// ICEFailedTimeout is the time the ICE candidates are checked for before a connection attempt fails. 0 keeps the
+	// default of the clients
+	ICEFailedTimeout time.Duration
+
+	// ICEDisconnectedTimeout is the time without traffic after which a connection is considered disconnected. 0 keeps
+	// the default of the clients
+	ICEDisconnectedTimeout time.Duration
+
+	// RosenpassEnabled makes the peers run the post-quantum Rosenpass handshake alongside WireGuard
+	RosenpassEnabled bool
+
+	// RosenpassPermissive keeps the connections to the peers without Rosenpass working without the post-quantum key
+	RosenpassPermissive bool
+
+	// EventsRetention is the time the activity events are kept for before they are archived and pruned. 0 keeps them
+	// forever
+	EventsRetention time.Duration
+
+	// PeerLoginExpirationWarnings are the lead times before the login expiration the peers warn their users at
+	PeerLoginExpirationWarnings []time.Duration `gorm:"serializer:json"`
+
+	// DNSLabelCollisionStrategy is how a peer added or renamed with the DNS label of another peer is handled: suffix,
+	// reject or replace-oldest. Empty behaves as suffix
+	DNSLabelCollisionStrategy string
+
+	// PeersPerUserLimit is the maximum number of peers each user can register. 0 is unlimited
+	PeersPerUserLimit int
+
+	// FeatureFlags switch client behaviors for all the peers, e.g. lazy_firewall_rules. The flags of the groups of a
+	// peer override them
+	FeatureFlags map[string]bool `gorm:"serializer:json"`
+
+	// PeerActions are the remote actions the admins can run on the peers, e.g. restart_engine. Empty allows none
+	PeerActions []string `gorm:"serializer:json"`
+
+	// PeerKeyRotation is the interval the peers rotate their WireGuard keys at. 0 disables the rotation
+	PeerKeyRotation time.Duration
+
+	// PeerControlPlane lists the parts of the host the peers manage: dns, routes and acl, e.g. only routes to use the
+	// peers as a mesh while DNS and the firewall are managed by the user. Empty manages all of them
+	PeerControlPlane []string `gorm:"serializer:json"`
+
+	// GeofenceCountries are the ISO 3166-1 alpha-2 codes of the countries the peers can be located in. Empty disables
+	// the geofence
+	GeofenceCountries []string `gorm:"serializer:json"`
+
+	// GeofenceGroups are the groups whose peers the geofence applies to. Empty applies it to all the peers
+	GeofenceGroups []string `gorm:"serializer:json"`
+
+	// GeofenceAction is applied to the peers located outside the geofence: block or quarantine. Empty behaves as block
+	GeofenceAction string
+
+	// GeofenceQuarantineGroup is the group of the peers the quarantined peers can still connect to, e.g. remediation
+	// servers
+	GeofenceQuarantineGroup string
 }
 
 // Copy copies the Settings struct
 func (s *Settings) Copy() *Settings {
 	settings := &Settings{
-		PeerLoginExpirationEnabled: s.PeerLoginExpirationEnabled,
-		PeerLoginExpiration:        s.PeerLoginExpiration,
-		JWTGroupsEnabled:           s.JWTGroupsEnabled,
-		JWTGroupsClaimName:         s.JWTGroupsClaimName,
-		GroupsPropagationEnabled:   s.GroupsPropagationEnabled,
-		JWTAllowGroups:             s.JWTAllowGroups,
-		RegularUsersViewBlocked:    s.RegularUsersViewBlocked,
+		PeerLoginExpirationEnabled:  s.PeerLoginExpirationEnabled,
+		PeerLoginExpiration:         s.PeerLoginExpiration,
+		JWTGroupsEnabled:            s.JWTGroupsEnabled,
+		JWTGroupsClaimName:          s.JWTGroupsClaimName,
+		GroupsPropagationEnabled:    s.GroupsPropagationEnabled,
+		JWTAllowGroups:              s.JWTAllowGroups,
+		RegularUsersViewBlocked:     s.RegularUsersViewBlocked,
+		PresharedKeyMode:            s.PresharedKeyMode,
+		PresharedKeySecret:          s.PresharedKeySecret,
+		InterfaceMTU:                s.InterfaceMTU,
+		DNSScopedGroups:             slices.Clone(s.DNSScopedGroups),
+		ICEDisabledCandidateTypes:   slices.Clone(s.ICEDisabledCandidateTypes),
+		ICEExcludedSubnets:          slices.Clone(s.ICEExcludedSubnets),
+		ICEFailedTimeout:            s.ICEFailedTimeout,
+		ICEDisconnectedTimeout:      s.ICEDisconnectedTimeout,
+		RosenpassEnabled:            s.RosenpassEnabled,
+		RosenpassPermissive:         s.RosenpassPermissive,
+		EventsRetention:             s.EventsRetention,
+		PeerLoginExpirationWarnings: slices.Clone(s.PeerLoginExpirationWarnings),
+		DNSLabelCollisionStrategy:   s.DNSLabelCollisionStrategy,
+		PeersPerUserLimit:           s.PeersPerUserLimit,
+		FeatureFlags:                maps.Clone(s.FeatureFlags),
+		PeerActions:                 slices.Clone(s.PeerActions),
+		PeerKeyRotation:             s.PeerKeyRotation,
+		PeerControlPlane:            slices.Clone(s.PeerControlPlane),
+		GeofenceCountries:           slices.Clone(s.GeofenceCountries),
+		GeofenceGroups:              slices.Clone(s.GeofenceGroups),
+		GeofenceAction:              s.GeofenceAction,
+		GeofenceQuarantineGroup:     s.GeofenceQuarantineGroup,
 	}
 	if s.Extra != nil {
 		settings.Extra = s.Extra.Copy()
@@ -236,9 +490,24 @@ type Account struct {
 	NameServerGroups       map[string]*nbdns.NameServerGroup `gorm:"-"`
 	NameServerGroupsG      []nbdns.NameServerGroup           `json:"-" gorm:"foreignKey:AccountID;references:id"`
 	DNSSettings            DNSSettings                       `gorm:"embedded;embeddedPrefix:dns_settings_"`
+	CustomDNSRecords       map[string]*nbdns.CustomRecord    `gorm:"-"`
+	CustomDNSRecordsG      []nbdns.CustomRecord              `json:"-" gorm:"foreignKey:AccountID;references:id"`
+	RelayServers           map[string]*RelayServer           `gorm:"-"`
+	RelayServersG          []RelayServer                     `json:"-" gorm:"foreignKey:AccountID;references:id"`
+	ClaimGroupMappings     map[string]*ClaimGroupMapping     `gorm:"-"`
+	ClaimGroupMappingsG    []ClaimGroupMapping               `json:"-" gorm:"foreignKey:AccountID;references:id"`
+	RouteGroups            map[string]*RouteGroup            `gorm:"-"`
+	RouteGroupsG           []RouteGroup                      `json:"-" gorm:"foreignKey:AccountID;references:id"`
+	ExternalLists          map[string]*ExternalList          `gorm:"-"`
+	ExternalListsG         []ExternalList                    `json:"-" gorm:"foreignKey:AccountID;references:id"`
 	PostureChecks          []*posture.Checks                 `gorm:"foreignKey:AccountID;references:id"`
 	// Settings is a dictionary of Account settings
 	Settings *Settings `gorm:"embedded;embeddedPrefix:settings_"`
+	// DeletionScheduledAt is the time the account is deleted at once its owner confirmed the deletion, zero when no
+	// deletion is scheduled
+	DeletionScheduledAt time.Time
+	// DeletionRequestedBy is the owner who confirmed the scheduled deletion
+	DeletionRequestedBy string
 }
 
 type UserPermissions struct {
@@ -371,9 +640,14 @@ func (a *Account) getRoutingPeerRoutes(peerID string) (enabledRoutes []*route.Ro
 
 // GetRoutesByPrefix return list of routes by account and route prefix
 func (a *Account) GetRoutesByPrefix(prefix netip.Prefix) []*route.Route {
+	return a.getRoutesByNetString(prefix.String())
+}
+
+// getRoutesByNetString returns the list of routes with the given network range or domains, see route.Route.NetString
+func (a *Account) getRoutesByNetString(network string) []*route.Route {
 	var routes []*route.Route
 	for _, r := range a.Routes {
-		if r.Network.String() == prefix.String() {
+		if r.NetString() == network {
 			routes = append(routes, r)
 		}
 	}
@@ -415,6 +689,7 @@ func (a *Account) GetPeerNetworkMap(peerID, dnsDomain string, validatedPeersMap
 	}
 
 	routesUpdate := a.getRoutesToSync(peerID, peersToConnect)
+	sshEnabled, sshAuthorizedPeers := a.getPeerSSHAccess(peer, validatedPeersMap)
 
 	dnsManagementStatus := a.getPeerDNSManagementStatus(peerID)
 	dnsUpdate := nbdns.Config{
@@ -422,22 +697,33 @@ func (a *Account) GetPeerNetworkMap(peerID, dnsDomain string, validatedPeersMap
 	}
 
 	if dnsManagementStatus {
-		var zones []nbdns.CustomZone
-		peersCustomZone := getPeersCustomZone(a, dnsDomain)
-		if peersCustomZone.Domain != "" {
-			zones = append(zones, peersCustomZone)
-		}
-		dnsUpdate.CustomZones = zones
+		dnsUpdate.CustomZones = a.getDNSZones(dnsDomain, a.getPeerDNSVisiblePeers(peerID, aclPeers))
 		dnsUpdate.NameServerGroups = getPeerNSGroups(a, peerID)
+		dnsUpdate.Blocklist = a.getPeerDNSBlocklist(peerID)
 	}
 
 	return &NetworkMap{
-		Peers:         peersToConnect,
-		Network:       a.Network.Copy(),
-		Routes:        routesUpdate,
-		DNSConfig:     dnsUpdate,
-		OfflinePeers:  expiredPeers,
-		FirewallRules: firewallRules,
+		Peers:              peersToConnect,
+		Network:            a.Network.Copy(),
+		Routes:             routesUpdate,
+		DNSConfig:          dnsUpdate,
+		OfflinePeers:       expiredPeers,
+		FirewallRules:      firewallRules,
+		PresharedKeys:      a.getPresharedKeys(peer, peersToConnect, expiredPeers),
+		MTU:                a.Settings.InterfaceMTU,
+		Relays:             selectPeerRelays(peer, a.RelayServers),
+		DirectOnlyPeers:    a.getDirectOnlyPeers(peerID, validatedPeersMap),
+		KeepWarmPeers:      a.getKeepWarmPeers(peerID, peersToConnect),
+		ICE:                a.Settings.iceSettings(),
+		Rosenpass:          a.Settings.rosenpassSettings(),
+		LoginExpiration:    a.getPeerLoginExpiration(peer),
+		SSHEnabled:         sshEnabled,
+		SSHAuthorizedPeers: sshAuthorizedPeers,
+		SpeedtestPeers:     a.getPeerSpeedtestPeers(peer, validatedPeersMap),
+		FeatureFlags:       a.getPeerFeatureFlags(peerID),
+		ClientUpdate:       a.getPeerClientUpdate(peerID),
+		ControlPlane:       slices.Clone(a.Settings.PeerControlPlane),
+		BlockedNetworks:    a.getPeerBlockedNetworks(peerID),
 	}
 }
 
@@ -608,12 +894,14 @@ func (a *Account) GetPeerGroupsList(peerID string) []string {
 	return grps
 }
 
+// getUserGroups returns the groups to assign to the peers registered by the user, its auto groups and the groups the
+// claim group mappings assigned to it at its last login
 func (a *Account) getUserGroups(userID string) ([]string, error) {
 	user, err := a.FindUser(userID)
 	if err != nil {
 		return nil, err
 	}
-	return user.AutoGroups, nil
+	return append(slices.Clone(user.AutoGroups), difference(user.ClaimGroups, user.AutoGroups)...), nil
 }
 
 func (a *Account) getPeerDNSManagementStatus(peerID string) bool {
@@ -707,6 +995,31 @@ func (a *Account) Copy() *Account {
 
 	dnsSettings := a.DNSSettings.Copy()
 
+	customDNSRecords := map[string]*nbdns.CustomRecord{}
+	for id, record := range a.CustomDNSRecords {
+		customDNSRecords[id] = record.Copy()
+	}
+
+	relayServers := map[string]*RelayServer{}
+	for id, relay := range a.RelayServers {
+		relayServers[id] = relay.Copy()
+	}
+
+	claimGroupMappings := map[string]*ClaimGroupMapping{}
+	for id, mapping := range a.ClaimGroupMappings {
+		claimGroupMappings[id] = mapping.Copy()
+	}
+
+	routeGroups := map[string]*RouteGroup{}
+	for id, group := range a.RouteGroups {
+		routeGroups[id] = group.Copy()
+	}
+
+	externalLists := map[string]*ExternalList{}
+	for id, list := range a.ExternalLists {
+		externalLists[id] = list.Copy()
+	}
+
 	var settings *Settings
 	if a.Settings != nil {
 		settings = a.Settings.Copy()
@@ -733,8 +1046,15 @@ func (a *Account) Copy() *Account {
 		Routes:                 routes,
 		NameServerGroups:       nsGroups,
 		DNSSettings:            dnsSettings,
+		CustomDNSRecords:       customDNSRecords,
+		RelayServers:           relayServers,
+		ClaimGroupMappings:     claimGroupMappings,
+		RouteGroups:            routeGroups,
+		ExternalLists:          externalLists,
 		PostureChecks:          postureChecks,
 		Settings:               settings,
+		DeletionScheduledAt:    a.DeletionScheduledAt,
+		DeletionRequestedBy:    a.DeletionRequestedBy,
 	}
 }
 
@@ -875,8 +1195,19 @@ func BuildManager(store Store, peersUpdateManager *PeersUpdateManager, idpManage
 		dnsDomain:                dnsDomain,
 		eventStore:               eventStore,
 		peerLoginExpiry:          NewDefaultScheduler(),
+		peerKeyRotation:          NewDefaultScheduler(),
 		userDeleteFromIDPEnabled: userDeleteFromIDPEnabled,
 		integratedPeerValidator:  integratedPeerValidator,
+		transferStats:            map[string][]nbpeer.TransferStats{},
+		dnsStats:                 map[string]nbpeer.DNSStats{},
+		firewallStats:            map[string][]nbpeer.FirewallStats{},
+		diagnostics:              map[string]nbpeer.Diagnostics{},
+		keyRotations:             map[string]keyRotation{},
+		peerPings:                map[string]*PeerPing{},
+		accountDeletion:          NewDefaultScheduler(),
+		accountDeletionTokens:    map[string]accountDeletionToken{},
+		apiUsage:                 map[string]*apiUsage{},
+		externalListRefresh:      NewDefaultScheduler(),
 	}
 	allAccounts := store.GetAllAccounts()
 	// enable single account mode only if configured by user and number of existing accounts is not grater than 1
@@ -911,6 +1242,9 @@ func BuildManager(store Store, peersUpdateManager *PeersUpdateManager, idpManage
 				return nil, err
 			}
 		}
+
+		am.checkAndScheduleAccountDeletion(account)
+		am.checkAndScheduleExternalListRefresh(account)
 	}
 
 	goCacheClient := gocache.New(CacheExpirationMax, 30*time.Minute)
@@ -960,6 +1294,42 @@ func (am *DefaultAccountManager) UpdateAccountSettings(accountID, userID string,
 		return nil, status.Errorf(status.InvalidArgument, "peer login expiration can't be smaller than one hour")
 	}
 
+	if err := validatePresharedKeyMode(newSettings.PresharedKeyMode); err != nil {
+		return nil, err
+	}
+
+	if newSettings.InterfaceMTU != 0 && (newSettings.InterfaceMTU < minInterfaceMTU || newSettings.InterfaceMTU > maxInterfaceMTU) {
+		return nil, status.Errorf(status.InvalidArgument, "interface MTU must be between %d and %d", minInterfaceMTU, maxInterfaceMTU)
+	}
+
+	if err := validateICESettings(newSettings.iceSettings()); err != nil {
+		return nil, err
+	}
+
+	if err := validateRosenpassSettings(newSettings.rosenpassSettings()); err != nil {
+		return nil, err
+	}
+
+	if newSettings.EventsRetention != 0 && newSettings.EventsRetention < minEventsRetention {
+		return nil, status.Errorf(status.InvalidArgument, "events retention can't be smaller than one day")
+	}
+
+	if err := validatePeerLoginExpirationWarnings(newSettings.PeerLoginExpirationWarnings); err != nil {
+		return nil, err
+	}
+
+	if err := validateDNSLabelCollisionStrategy(newSettings.DNSLabelCollisionStrategy); err != nil {
+		return nil, err
+	}
+
+	if newSettings.PeersPerUserLimit < 0 {
+		return nil, status.Errorf(status.InvalidArgument, "peers per user limit can't be negative")
+	}
+
+	if newSettings.PeerKeyRotation != 0 && newSettings.PeerKeyRotation < minPeerKeyRotation {
+		return nil, status.Errorf(status.InvalidArgument, "peer key rotation interval can't be smaller than one hour")
+	}
+
 	unlock := am.Store.AcquireAccountWriteLock(accountID)
 	defer unlock()
 
@@ -977,6 +1347,28 @@ func (am *DefaultAccountManager) UpdateAccountSettings(accountID, userID string,
 		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to update account")
 	}
 
+	if len(newSettings.DNSScopedGroups) != 0 {
+		if err = validateGroups(newSettings.DNSScopedGroups, account.Groups); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = validateFeatureFlags(newSettings.FeatureFlags); err != nil {
+		return nil, err
+	}
+
+	if err = validatePeerActions(newSettings.PeerActions); err != nil {
+		return nil, err
+	}
+
+	if err = validatePeerControlPlane(newSettings.PeerControlPlane); err != nil {
+		return nil, err
+	}
+
+	if err = validateGeofenceSettings(newSettings, account.Groups); err != nil {
+		return nil, err
+	}
+
 	err = am.integratedPeerValidator.ValidateExtraSettings(newSettings.Extra, account.Settings.Extra, account.Peers, userID, accountID)
 	if err != nil {
 		return nil, err
@@ -999,13 +1391,123 @@ func (am *DefaultAccountManager) UpdateAccountSettings(accountID, userID string,
 		am.checkAndSchedulePeerLoginExpiration(account)
 	}
 
+	// the secret is never set through the API, keep it so that the keys of the peers don't change
+	newSettings.PresharedKeySecret = oldSettings.PresharedKeySecret
+	if newSettings.PresharedKeyMode != "" && newSettings.PresharedKeySecret == "" {
+		newSettings.PresharedKeySecret, err = newPresharedKeySecret()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	presharedKeysUpdated := oldSettings.PresharedKeyMode != newSettings.PresharedKeyMode
+	if presharedKeysUpdated {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountPresharedKeysUpdated,
+			map[string]any{"mode": newSettings.PresharedKeyMode})
+	}
+
+	mtuUpdated := oldSettings.InterfaceMTU != newSettings.InterfaceMTU
+	if mtuUpdated {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountInterfaceMTUUpdated,
+			map[string]any{"mtu": newSettings.InterfaceMTU})
+	}
+
+	dnsScopedGroupsUpdated := !slices.Equal(oldSettings.DNSScopedGroups, newSettings.DNSScopedGroups)
+	if dnsScopedGroupsUpdated {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountDNSScopedGroupsUpdated,
+			map[string]any{"groups": newSettings.DNSScopedGroups})
+	}
+
+	iceUpdated := !oldSettings.iceSettings().equal(newSettings.iceSettings())
+	if iceUpdated {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountICESettingsUpdated, nil)
+	}
+
+	rosenpassUpdated := oldSettings.rosenpassSettings() != newSettings.rosenpassSettings()
+	if rosenpassUpdated {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountRosenpassSettingsUpdated,
+			map[string]any{"enabled": newSettings.RosenpassEnabled, "permissive": newSettings.RosenpassPermissive})
+	}
+
+	if oldSettings.EventsRetention != newSettings.EventsRetention {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountEventsRetentionUpdated,
+			map[string]any{"retention": newSettings.EventsRetention.String()})
+	}
+
+	// the peers are pushed the time their login expires at along with the warnings
+	loginExpirationUpdated := oldSettings.PeerLoginExpirationEnabled != newSettings.PeerLoginExpirationEnabled ||
+		oldSettings.PeerLoginExpiration != newSettings.PeerLoginExpiration
+	if !slices.Equal(oldSettings.PeerLoginExpirationWarnings, newSettings.PeerLoginExpirationWarnings) {
+		loginExpirationUpdated = true
+		warnings := make([]string, 0, len(newSettings.PeerLoginExpirationWarnings))
+		for _, warning := range newSettings.PeerLoginExpirationWarnings {
+			warnings = append(warnings, warning.String())
+		}
+		am.StoreEvent(userID, accountID, accountID, activity.AccountPeerLoginExpirationWarningsUpdated,
+			map[string]any{"warnings": warnings})
+	}
+
+	if oldSettings.DNSLabelCollisionStrategy != newSettings.DNSLabelCollisionStrategy {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountDNSLabelCollisionStrategyUpdated,
+			map[string]any{"strategy": newSettings.DNSLabelCollisionStrategy})
+	}
+
+	if oldSettings.PeersPerUserLimit != newSettings.PeersPerUserLimit {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountPeersPerUserLimitUpdated,
+			map[string]any{"limit": newSettings.PeersPerUserLimit})
+	}
+
+	featureFlagsUpdated := !maps.Equal(oldSettings.FeatureFlags, newSettings.FeatureFlags)
+	if featureFlagsUpdated {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountFeatureFlagsUpdated,
+			map[string]any{"feature_flags": newSettings.FeatureFlags})
+	}
+
+	if !slices.Equal(oldSettings.PeerActions, newSettings.PeerActions) {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountPeerActionsUpdated,
+			map[string]any{"actions": newSettings.PeerActions})
+	}
+
+	controlPlaneUpdated := !slices.Equal(oldSettings.PeerControlPlane, newSettings.PeerControlPlane)
+	if controlPlaneUpdated {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountPeerControlPlaneUpdated,
+			map[string]any{"control_plane": newSettings.PeerControlPlane})
+	}
+
+	keyRotationUpdated := oldSettings.PeerKeyRotation != newSettings.PeerKeyRotation
+	if keyRotationUpdated {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountPeerKeyRotationUpdated,
+			map[string]any{"interval": newSettings.PeerKeyRotation.String()})
+	}
+
+	geofenceUpdated := !geofenceSettingsEqual(oldSettings, newSettings)
+	if geofenceUpdated {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountGeofenceUpdated, map[string]any{
+			"countries": newSettings.GeofenceCountries, "groups": newSettings.GeofenceGroups,
+			"action": newSettings.GeofenceAction, "quarantine_group": newSettings.GeofenceQuarantineGroup,
+		})
+	}
+
 	updatedAccount := account.UpdateSettings(newSettings)
 
+	if geofenceUpdated {
+		am.updatePeersGeofence(account)
+	}
+
 	err = am.Store.SaveAccount(account)
 	if err != nil {
 		return nil, err
 	}
 
+	if keyRotationUpdated {
+		am.checkAndSchedulePeerKeyRotation(account)
+	}
+
+	if presharedKeysUpdated || mtuUpdated || dnsScopedGroupsUpdated || iceUpdated || rosenpassUpdated || loginExpirationUpdated ||
+		featureFlagsUpdated || controlPlaneUpdated || geofenceUpdated {
+		am.updateAccountPeers(account)
+	}
+
 	return updatedAccount, nil
 }
 
@@ -1129,6 +1631,12 @@ func (am *DefaultAccountManager) DeleteAccount(accountID, userID string) error {
 	if user.Role != UserRoleOwner {
 		return status.Errorf(status.PermissionDenied, "user is not allowed to delete account. Only account owner can delete account")
 	}
+
+	return am.deleteAccount(account, userID)
+}
+
+// deleteAccount deletes the users of the account, the owner deleting it last, and then the account
+func (am *DefaultAccountManager) deleteAccount(account *Account, userID string) error {
 	for _, otherUser := range account.Users {
 		if otherUser.IsServiceUser {
 			continue
@@ -1144,7 +1652,7 @@ func (am *DefaultAccountManager) DeleteAccount(accountID, userID string) error {
 		}
 	}
 
-	err = am.deleteRegularUser(account, userID, userID)
+	err := am.deleteRegularUser(account, userID, userID)
 	if err != nil {
 		log.Errorf("failed deleting user %s. error: %s", userID, err)
 		return err
@@ -1152,13 +1660,16 @@ func (am *DefaultAccountManager) DeleteAccount(accountID, userID string) error {
 
 	err = am.Store.DeleteAccount(account)
 	if err != nil {
-		log.Errorf("failed deleting account %s. error: %s", accountID, err)
+		log.Errorf("failed deleting account %s. error: %s", account.Id, err)
 		return err
 	}
 	// cancel peer login expiry job
 	am.peerLoginExpiry.Cancel([]string{account.Id})
+	am.peerKeyRotation.Cancel([]string{account.Id})
+	am.accountDeletion.Cancel([]string{account.Id})
+	am.externalListRefresh.Cancel([]string{account.Id})
 
-	log.Debugf("account %s deleted", accountID)
+	log.Debugf("account %s deleted", account.Id)
 	return nil
 }
 
@@ -1750,6 +2261,51 @@ func (am *DefaultAccountManager) GetAccountFromToken(claims jwtclaims.Authorizat
 	return account, user, nil
 }
 
+// GetAccountIDFromToken returns the IDs of the account and of the user associated with this token without loading
+// the account. It falls back to GetAccountFromToken when the token has to update the account: on the first login of
+// the user, when redeeming an invite or when the groups of the user are synced from the JWT claims.
+func (am *DefaultAccountManager) GetAccountIDFromToken(claims jwtclaims.AuthorizationClaims) (string, string, error) {
+	if claims.UserId == "" {
+		return "", "", fmt.Errorf("user ID is empty")
+	}
+
+	accountID, err := am.Store.GetAccountIDByUserID(claims.UserId)
+	if err != nil {
+		if s, ok := status.FromError(err); !ok || s.Type() != status.NotFound {
+			return "", "", err
+		}
+		return am.getAccountIDFromTokenFallback(claims)
+	}
+
+	if claims.AccountId != "" && claims.AccountId != accountID {
+		return "", "", fmt.Errorf("user %s is not part of the account id %s", claims.UserId, claims.AccountId)
+	}
+
+	if claims.Invited {
+		return am.getAccountIDFromTokenFallback(claims)
+	}
+
+	settings, err := am.Store.GetAccountSettings(accountID)
+	if err != nil {
+		return "", "", err
+	}
+	if settings.JWTGroupsEnabled && settings.JWTGroupsClaimName != "" {
+		return am.getAccountIDFromTokenFallback(claims)
+	}
+
+	return accountID, claims.UserId, nil
+}
+
+// getAccountIDFromTokenFallback returns the IDs of the account and of the user associated with this token using
+// GetAccountFromToken
+func (am *DefaultAccountManager) getAccountIDFromTokenFallback(claims jwtclaims.AuthorizationClaims) (string, string, error) {
+	account, user, err := am.GetAccountFromToken(claims)
+	if err != nil {
+		return "", "", err
+	}
+	return account.Id, user.Id, nil
+}
+
 // getAccountWithAuthorizationClaims retrievs an account using JWT Claims.
 // if domain is of the PrivateCategory category, it will evaluate
 // if account is new, existing or if there is another account with the same domain
@@ -1856,11 +2412,25 @@ func (am *DefaultAccountManager) SyncAndMarkPeer(peerPubKey string, realIP net.I
 		return nil, nil, mapError(err)
 	}
 
+	geofenceViolation := peer.GeofenceViolation
 	err = am.MarkPeerConnected(peerPubKey, true, realIP, account)
 	if err != nil {
 		log.Warnf("failed marking peer as connected %s %v", peerPubKey, err)
 	}
 
+	// the location the peer connected from moved it across the geofence, its network map changed
+	if connectedPeer := account.GetPeer(peer.ID); connectedPeer != nil && connectedPeer.GeofenceViolation != geofenceViolation {
+		validatedPeersMap, err := am.GetValidatedPeers(account)
+		if err != nil {
+			return nil, nil, err
+		}
+		netMap = account.GetPeerNetworkMap(peer.ID, am.dnsDomain, validatedPeersMap)
+	}
+
+	if account.Settings.PeerKeyRotation != 0 {
+		am.checkAndSchedulePeerKeyRotation(account)
+	}
+
 	return peer, netMap, nil
 }
 
@@ -2020,10 +2590,11 @@ func newAccountWithId(accountID, userID, domain string) *Account {
 		NameServerGroups: nameServersGroups,
 		DNSSettings:      dnsSettings,
 		Settings: &Settings{
-			PeerLoginExpirationEnabled: true,
-			PeerLoginExpiration:        DefaultPeerLoginExpiration,
-			GroupsPropagationEnabled:   true,
-			RegularUsersViewBlocked:    true,
+			PeerLoginExpirationEnabled:  true,
+			PeerLoginExpiration:         DefaultPeerLoginExpiration,
+			GroupsPropagationEnabled:    true,
+			RegularUsersViewBlocked:     true,
+			PeerLoginExpirationWarnings: slices.Clone(DefaultPeerLoginExpirationWarnings),
 		},
 	}
 
@@ -0,0 +1,437 @@
+package server
+
+import (
+	"archive/zip"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	b "github.com/hashicorp/go-secure-stdlib/base62"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	// AccountDeletionGracePeriod is the time between the confirmation of an account deletion and the deletion, the
+	// owner can cancel it and download the export of the account in the meantime
+	AccountDeletionGracePeriod = 7 * 24 * time.Hour
+	// accountDeletionTokenTTL is the time the owner has to confirm the deletion of the account
+	accountDeletionTokenTTL = 15 * time.Minute
+	// accountDeletionTokenLength is the number of characters of a confirmation token
+	accountDeletionTokenLength = 32
+	// accountDeletionRetry is the time after which a deletion that failed, e.g. on exporting the account, is run again
+	accountDeletionRetry = time.Hour
+	// minAccountDeletionDelay leaves the management service the time to start up before running an overdue deletion
+	minAccountDeletionDelay = time.Minute
+)
+
+// AccountDeletion is the state of the deletion of an account
+type AccountDeletion struct {
+	// ConfirmationToken confirms the deletion, only set when the deletion was just requested
+	ConfirmationToken string
+	// ConfirmationExpiresAt is the time the confirmation token expires at
+	ConfirmationExpiresAt time.Time
+	// ScheduledAt is the time the account is deleted at, zero when no deletion is scheduled
+	ScheduledAt time.Time
+	// RequestedBy is the owner who confirmed the deletion
+	RequestedBy string
+	// ExportName is the name of the latest export of the account, empty when it wasn't exported yet
+	ExportName string
+}
+
+// accountDeletionToken is a confirmation token issued to the owner of an account
+type accountDeletionToken struct {
+	token     string
+	userID    string
+	expiresAt time.Time
+}
+
+// EnableAccountExports makes the account manager export the accounts scheduled for deletion to the directory, one
+// subdirectory per account
+func (am *DefaultAccountManager) EnableAccountExports(dir string) {
+	am.accountExportDir = dir
+}
+
+// RequestAccountDeletion issues the token the owner confirms the deletion of the account with. The account isn't
+// changed until the deletion is confirmed.
+func (am *DefaultAccountManager) RequestAccountDeletion(accountID, userID string) (*AccountDeletion, error) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkAccountDeletionAllowed(account, userID); err != nil {
+		return nil, err
+	}
+
+	if !account.DeletionScheduledAt.IsZero() {
+		return nil, status.Errorf(status.PreconditionFailed, "the deletion of the account is already scheduled at %s",
+			account.DeletionScheduledAt.Format(time.RFC3339))
+	}
+
+	token, err := b.Random(accountDeletionTokenLength)
+	if err != nil {
+		return nil, fmt.Errorf("generate confirmation token: %w", err)
+	}
+	expiresAt := time.Now().UTC().Add(accountDeletionTokenTTL)
+
+	am.accountDeletionsMux.Lock()
+	am.accountDeletionTokens[accountID] = accountDeletionToken{token: token, userID: userID, expiresAt: expiresAt}
+	am.accountDeletionsMux.Unlock()
+
+	am.StoreEvent(userID, accountID, accountID, activity.AccountDeletionRequested,
+		map[string]any{"confirmation_expires_at": expiresAt})
+
+	return &AccountDeletion{ConfirmationToken: token, ConfirmationExpiresAt: expiresAt}, nil
+}
+
+// ConfirmAccountDeletion schedules the deletion of the account after AccountDeletionGracePeriod with the token issued
+// by RequestAccountDeletion to the same owner. The account is exported right away.
+func (am *DefaultAccountManager) ConfirmAccountDeletion(accountID, userID, token string) (*AccountDeletion, error) {
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkAccountDeletionAllowed(account, userID); err != nil {
+		return nil, err
+	}
+
+	am.accountDeletionsMux.Lock()
+	issued, ok := am.accountDeletionTokens[accountID]
+	valid := ok && issued.userID == userID && time.Now().Before(issued.expiresAt) &&
+		subtle.ConstantTimeCompare([]byte(issued.token), []byte(token)) == 1
+	if valid {
+		delete(am.accountDeletionTokens, accountID)
+	}
+	am.accountDeletionsMux.Unlock()
+	if !valid {
+		return nil, status.Errorf(status.PermissionDenied, "invalid or expired confirmation token, request the deletion again")
+	}
+
+	account.DeletionScheduledAt = time.Now().UTC().Add(AccountDeletionGracePeriod)
+	account.DeletionRequestedBy = userID
+	if err := am.Store.SaveAccount(account); err != nil {
+		return nil, err
+	}
+	log.Infof("the deletion of account %s was scheduled at %s by %s", accountID, account.DeletionScheduledAt, userID)
+
+	am.StoreEvent(userID, accountID, accountID, activity.AccountDeletionScheduled,
+		map[string]any{"scheduled_at": account.DeletionScheduledAt})
+
+	am.checkAndScheduleAccountDeletion(account)
+
+	go func() {
+		if _, err := am.exportAccount(accountID); err != nil {
+			log.Errorf("failed exporting account %s scheduled for deletion: %v", accountID, err)
+		}
+	}()
+
+	return &AccountDeletion{ScheduledAt: account.DeletionScheduledAt, RequestedBy: userID}, nil
+}
+
+// GetAccountDeletion returns the scheduled deletion of the account along with its latest export
+func (am *DefaultAccountManager) GetAccountDeletion(accountID, userID string) (*AccountDeletion, error) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkAccountDeletionAllowed(account, userID); err != nil {
+		return nil, err
+	}
+
+	deletion := &AccountDeletion{
+		ScheduledAt: account.DeletionScheduledAt,
+		RequestedBy: account.DeletionRequestedBy,
+	}
+	deletion.ExportName, _, err = am.latestAccountExport(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return deletion, nil
+}
+
+// CancelAccountDeletion cancels the scheduled deletion of the account, its exports are kept
+func (am *DefaultAccountManager) CancelAccountDeletion(accountID, userID string) error {
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkAccountDeletionAllowed(account, userID); err != nil {
+		return err
+	}
+
+	if account.DeletionScheduledAt.IsZero() {
+		return status.Errorf(status.PreconditionFailed, "no deletion of the account is scheduled")
+	}
+
+	scheduledAt := account.DeletionScheduledAt
+	account.DeletionScheduledAt = time.Time{}
+	account.DeletionRequestedBy = ""
+	if err := am.Store.SaveAccount(account); err != nil {
+		return err
+	}
+	am.accountDeletion.Cancel([]string{accountID})
+	log.Infof("the deletion of account %s was canceled by %s", accountID, userID)
+
+	am.StoreEvent(userID, accountID, accountID, activity.AccountDeletionCanceled,
+		map[string]any{"scheduled_at": scheduledAt})
+
+	return nil
+}
+
+// GetAccountExport returns the name and the content of the latest export of the account
+func (am *DefaultAccountManager) GetAccountExport(accountID, userID string) (string, []byte, error) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := checkAccountDeletionAllowed(account, userID); err != nil {
+		return "", nil, err
+	}
+
+	name, path, err := am.latestAccountExport(accountID)
+	if err != nil {
+		return "", nil, err
+	}
+	if name == "" {
+		return "", nil, status.Errorf(status.NotFound, "the account wasn't exported yet")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("read account export: %w", err)
+	}
+
+	return name, data, nil
+}
+
+// checkAccountDeletionAllowed returns an error if the user isn't the owner of the account
+func checkAccountDeletionAllowed(account *Account, userID string) error {
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.HasAdminPower() || user.Role != UserRoleOwner {
+		return status.Errorf(status.PermissionDenied, "only the account owner can delete the account")
+	}
+
+	return nil
+}
+
+func (am *DefaultAccountManager) accountDeletionJob(accountID string) func() (time.Duration, bool) {
+	return func() (time.Duration, bool) {
+		if next, ok := am.getNextAccountDeletion(accountID); next > 0 || !ok {
+			return next, ok
+		}
+
+		// the final export reads the events, it is taken before locking the account
+		exportName, err := am.exportAccount(accountID)
+		if err != nil {
+			// the account isn't deleted without its data being exported
+			log.Errorf("failed exporting account %s before deleting it, retrying in %s: %v", accountID,
+				accountDeletionRetry, err)
+			return accountDeletionRetry, true
+		}
+
+		unlock := am.Store.AcquireAccountWriteLock(accountID)
+		defer unlock()
+
+		account, err := am.Store.GetAccount(accountID)
+		if err != nil {
+			log.Errorf("failed getting account %s to delete: %v", accountID, err)
+			return 0, false
+		}
+		// canceled while exporting
+		if account.DeletionScheduledAt.IsZero() {
+			return 0, false
+		}
+
+		if err := am.deleteAccount(account, account.DeletionRequestedBy); err != nil {
+			log.Errorf("failed deleting account %s, retrying in %s: %v", accountID, accountDeletionRetry, err)
+			return accountDeletionRetry, true
+		}
+		log.Infof("deleted account %s scheduled for deletion by %s, exported to %s", accountID,
+			account.DeletionRequestedBy, exportName)
+
+		return 0, false
+	}
+}
+
+// getNextAccountDeletion returns the time until the account is deleted, false if no deletion is scheduled
+func (am *DefaultAccountManager) getNextAccountDeletion(accountID string) (time.Duration, bool) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		log.Errorf("failed getting account %s to delete: %v", accountID, err)
+		return 0, false
+	}
+	if account.DeletionScheduledAt.IsZero() {
+		return 0, false
+	}
+	return max(time.Until(account.DeletionScheduledAt), 0), true
+}
+
+func (am *DefaultAccountManager) checkAndScheduleAccountDeletion(account *Account) {
+	am.accountDeletion.Cancel([]string{account.Id})
+	if account.DeletionScheduledAt.IsZero() {
+		return
+	}
+	nextRun := max(time.Until(account.DeletionScheduledAt), minAccountDeletionDelay)
+	go am.accountDeletion.Schedule(nextRun, account.Id, am.accountDeletionJob(account.Id))
+}
+
+// exportedEvent is the exported form of an activity event
+type exportedEvent struct {
+	Timestamp   time.Time      `json:"timestamp"`
+	Activity    string         `json:"activity"`
+	Message     string         `json:"message"`
+	InitiatorID string         `json:"initiator_id"`
+	TargetID    string         `json:"target_id"`
+	Meta        map[string]any `json:"meta,omitempty"`
+}
+
+// exportAccount writes the account and its activity events to a zip archive in the account export directory and
+// returns its name. The hashed personal access tokens aren't exported.
+func (am *DefaultAccountManager) exportAccount(accountID string) (string, error) {
+	if am.accountExportDir == "" {
+		return "", status.Errorf(status.PreconditionFailed, "the management service doesn't export accounts")
+	}
+
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	account, err := am.Store.GetAccount(accountID)
+	unlock()
+	if err != nil {
+		return "", err
+	}
+	for _, user := range account.Users {
+		for _, pat := range user.PATs {
+			pat.HashedToken = ""
+		}
+	}
+
+	events, err := am.eventStore.Get(accountID, 0, math.MaxInt32, false)
+	if err != nil {
+		return "", fmt.Errorf("get events: %w", err)
+	}
+	exportedEvents := make([]exportedEvent, 0, len(events))
+	for _, event := range events {
+		exportedEvents = append(exportedEvents, exportedEvent{
+			Timestamp:   event.Timestamp,
+			Activity:    event.Activity.StringCode(),
+			Message:     event.Activity.Message(),
+			InitiatorID: event.InitiatorID,
+			TargetID:    event.TargetID,
+			Meta:        event.Meta,
+		})
+	}
+
+	dir := filepath.Join(am.accountExportDir, accountID)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("create account export directory: %w", err)
+	}
+
+	name := fmt.Sprintf("account-%s.zip", time.Now().UTC().Format("20060102T150405.000Z"))
+	path := filepath.Join(dir, name)
+	// written to a temporary file first so that a failed export never leaves a truncated archive behind
+	if err := writeAccountExport(path+".tmp", account, exportedEvents); err != nil {
+		_ = os.Remove(path + ".tmp")
+		return "", err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		_ = os.Remove(path + ".tmp")
+		return "", fmt.Errorf("rename account export: %w", err)
+	}
+	log.Infof("exported account %s to %s", accountID, path)
+
+	am.StoreEvent(activity.SystemInitiator, accountID, accountID, activity.AccountExported,
+		map[string]any{"export": name, "events": len(exportedEvents)})
+
+	return name, nil
+}
+
+func writeAccountExport(path string, account *Account, events []exportedEvent) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("create account export: %w", err)
+	}
+	defer f.Close()
+
+	archive := zip.NewWriter(f)
+	for name, content := range map[string]any{"account.json": account, "events.json": events} {
+		w, err := archive.Create(name)
+		if err != nil {
+			return fmt.Errorf("create %s: %w", name, err)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(content); err != nil {
+			return fmt.Errorf("encode %s: %w", name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("write account export: %w", err)
+	}
+	return f.Close()
+}
+
+// latestAccountExport returns the name and the path of the latest export of the account, an empty name when there is
+// none. The names sort by their export time.
+func (am *DefaultAccountManager) latestAccountExport(accountID string) (string, string, error) {
+	if am.accountExportDir == "" {
+		return "", "", nil
+	}
+
+	dir := filepath.Join(am.accountExportDir, accountID)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", "", nil
+		}
+		return "", "", fmt.Errorf("read account export directory: %w", err)
+	}
+
+	var exports []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "account-") && strings.HasSuffix(entry.Name(), ".zip") {
+			exports = append(exports, entry.Name())
+		}
+	}
+	if len(exports) == 0 {
+		return "", "", nil
+	}
+
+	slices.Sort(exports)
+	name := exports[len(exports)-1]
+	return name, filepath.Join(dir, name), nil
+}
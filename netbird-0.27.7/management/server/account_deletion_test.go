@@ -0,0 +1,80 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+)
+
+func TestDefaultAccountManager_AccountDeletion(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+	manager.EnableAccountExports(t.TempDir())
+
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	_, err = manager.ConfirmAccountDeletion(account.Id, userID, "not-requested")
+	require.Error(t, err, "a deletion should not be confirmed without a token")
+
+	requested, err := manager.RequestAccountDeletion(account.Id, userID)
+	require.NoError(t, err)
+	require.NotEmpty(t, requested.ConfirmationToken)
+	getEvent(t, account.Id, manager, activity.AccountDeletionRequested)
+
+	_, err = manager.ConfirmAccountDeletion(account.Id, userID, "wrong-token")
+	require.Error(t, err)
+
+	scheduled, err := manager.ConfirmAccountDeletion(account.Id, userID, requested.ConfirmationToken)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(AccountDeletionGracePeriod), scheduled.ScheduledAt, time.Minute)
+	getEvent(t, account.Id, manager, activity.AccountDeletionScheduled)
+
+	_, err = manager.ConfirmAccountDeletion(account.Id, userID, requested.ConfirmationToken)
+	require.Error(t, err, "a confirmation token should only be used once")
+
+	_, err = manager.RequestAccountDeletion(account.Id, userID)
+	require.Error(t, err, "a deletion should not be requested while one is scheduled")
+
+	assert.Eventually(t, func() bool {
+		deletion, err := manager.GetAccountDeletion(account.Id, userID)
+		return err == nil && deletion.ExportName != ""
+	}, 5*time.Second, 10*time.Millisecond, "the account should be exported once its deletion is scheduled")
+
+	name, data, err := manager.GetAccountExport(account.Id, userID)
+	require.NoError(t, err)
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err, "%s should be a zip archive", name)
+	var files []string
+	for _, f := range archive.File {
+		files = append(files, f.Name)
+	}
+	assert.ElementsMatch(t, []string{"account.json", "events.json"}, files)
+
+	next, ok := manager.accountDeletionJob(account.Id)()
+	assert.True(t, ok)
+	assert.Greater(t, next, AccountDeletionGracePeriod-time.Minute, "the account should not be deleted before the grace period")
+
+	require.NoError(t, manager.CancelAccountDeletion(account.Id, userID))
+	getEvent(t, account.Id, manager, activity.AccountDeletionCanceled)
+	deletion, err := manager.GetAccountDeletion(account.Id, userID)
+	require.NoError(t, err)
+	assert.True(t, deletion.ScheduledAt.IsZero())
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	account.DeletionScheduledAt = time.Now().Add(-time.Minute)
+	account.DeletionRequestedBy = userID
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	_, ok = manager.accountDeletionJob(account.Id)()
+	assert.False(t, ok)
+	_, err = manager.Store.GetAccount(account.Id)
+	assert.Error(t, err, "the account should be deleted after the grace period")
+}
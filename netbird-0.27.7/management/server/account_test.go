@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"net/netip"
 	"reflect"
 	"sync"
 	"testing"
@@ -726,6 +727,48 @@ func TestDefaultAccountManager_GetGroupsFromTheToken(t *testing.T) {
 	})
 }
 
+func TestDefaultAccountManager_GetAccountIDFromToken(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+
+	claims := jwtclaims.AuthorizationClaims{
+		Domain:         "test.domain",
+		UserId:         "user-id",
+		DomainCategory: "test-category",
+		Raw:            jwt.MapClaims{"idp-groups": []interface{}{"group1"}},
+	}
+
+	accountID, userID, err := manager.GetAccountIDFromToken(claims)
+	require.NoError(t, err, "the account should be created on the first login")
+	require.Equal(t, claims.UserId, userID)
+
+	account, err := manager.Store.GetAccount(accountID)
+	require.NoError(t, err, "the account should be stored")
+	require.Contains(t, account.Users, userID)
+
+	id, _, err := manager.GetAccountIDFromToken(claims)
+	require.NoError(t, err, "get account ID by token failed")
+	require.Equal(t, accountID, id, "the account of the user should be returned")
+
+	claims.AccountId = "other-account"
+	_, _, err = manager.GetAccountIDFromToken(claims)
+	require.Error(t, err, "the user isn't part of the account in the claims")
+	claims.AccountId = accountID
+
+	account.Settings.JWTGroupsEnabled = true
+	account.Settings.JWTGroupsClaimName = "idp-groups"
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	_, _, err = manager.GetAccountIDFromToken(claims)
+	require.NoError(t, err, "get account ID by token failed")
+	account, err = manager.Store.GetAccount(accountID)
+	require.NoError(t, err)
+	require.Len(t, account.Groups, 2, "the groups from the JWT should still be synced")
+
+	_, _, err = manager.GetAccountIDFromToken(jwtclaims.AuthorizationClaims{})
+	require.Error(t, err, "the user ID is required")
+}
+
 func TestAccountManager_GetAccountFromPAT(t *testing.T) {
 	store := newStore(t)
 	account := newAccountWithId("account_id", "testuser", "")
@@ -1589,6 +1632,50 @@ func TestAccount_Copy(t *testing.T) {
 			},
 		},
 		DNSSettings: DNSSettings{DisabledManagementGroups: []string{}},
+		CustomDNSRecords: map[string]*nbdns.CustomRecord{
+			"record1": {
+				ID:    "record1",
+				Name:  "nas",
+				Type:  nbdns.RecordTypeA,
+				Value: "192.168.1.10",
+			},
+		},
+		RelayServers: map[string]*RelayServer{
+			"relay1": {
+				ID:        "relay1",
+				Name:      "relay",
+				Addresses: []string{"turn:relay.example.com"},
+				GeoLabels: []string{"DE"},
+			},
+		},
+		ClaimGroupMappings: map[string]*ClaimGroupMapping{
+			"mapping1": {
+				ID:     "mapping1",
+				Name:   "engineering",
+				Claim:  "department",
+				Values: []string{"engineering"},
+				Groups: []string{"group1"},
+			},
+		},
+		RouteGroups: map[string]*RouteGroup{
+			"routeGroup1": {
+				ID:             "routeGroup1",
+				Name:           "office",
+				Network:        netip.MustParsePrefix("10.0.0.0/24"),
+				FailoverPolicy: RouteGroupFailoverPriority,
+				Members:        []RouteGroupMember{{PeerID: "peer1", Priority: 1}},
+				Groups:         []string{"group1"},
+			},
+		},
+		ExternalLists: map[string]*ExternalList{
+			"list1": {
+				ID:       "list1",
+				Name:     "feed",
+				URL:      "https://example.com/drop.txt",
+				Format:   ExternalListFormatCIDR,
+				Networks: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+			},
+		},
 		PostureChecks: []*posture.Checks{
 			{
 				ID: "posture Checks1",
@@ -1834,6 +1921,60 @@ func TestDefaultAccountManager_UpdateAccountSettings(t *testing.T) {
 		PeerLoginExpirationEnabled: false,
 	})
 	require.Error(t, err, "expecting to fail when providing PeerLoginExpiration more than 180 days")
+
+	updated, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration: time.Hour,
+		InterfaceMTU:        1380,
+	})
+	require.NoError(t, err, "expecting to update the interface MTU successfully")
+	assert.Equal(t, 1380, updated.Settings.InterfaceMTU)
+
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration: time.Hour,
+		InterfaceMTU:        100,
+	})
+	require.Error(t, err, "expecting to fail when providing an interface MTU below the minimum")
+
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration: time.Hour,
+		InterfaceMTU:        10000,
+	})
+	require.Error(t, err, "expecting to fail when providing an interface MTU above the maximum")
+
+	updated, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration:       time.Hour,
+		ICEDisabledCandidateTypes: []string{"relay"},
+		ICEExcludedSubnets:        []string{"172.17.0.0/16"},
+		ICEFailedTimeout:          10 * time.Second,
+	})
+	require.NoError(t, err, "expecting to update the ICE settings successfully")
+	assert.Equal(t, []string{"relay"}, updated.Settings.ICEDisabledCandidateTypes)
+	assert.Equal(t, []string{"172.17.0.0/16"}, updated.Settings.ICEExcludedSubnets)
+	assert.Equal(t, 10*time.Second, updated.Settings.ICEFailedTimeout)
+
+	for _, settings := range []*Settings{
+		{PeerLoginExpiration: time.Hour, ICEDisabledCandidateTypes: []string{"prflx"}},
+		{PeerLoginExpiration: time.Hour, ICEDisabledCandidateTypes: []string{"host", "srflx", "relay"}},
+		{PeerLoginExpiration: time.Hour, ICEExcludedSubnets: []string{"172.17.0.0"}},
+		{PeerLoginExpiration: time.Hour, ICEDisconnectedTimeout: -time.Second},
+	} {
+		_, err = manager.UpdateAccountSettings(account.Id, userID, settings)
+		require.Error(t, err, "expecting to fail when providing invalid ICE settings")
+	}
+
+	updated, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration: time.Hour,
+		RosenpassEnabled:    true,
+	})
+	require.NoError(t, err, "expecting to enable Rosenpass successfully")
+	assert.True(t, updated.Settings.RosenpassEnabled)
+	assert.True(t, toRosenpassConfig(updated.Settings.rosenpassSettings()).GetEnabled(), "Rosenpass should be pushed to the peers")
+
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration: time.Hour,
+		RosenpassPermissive: true,
+	})
+	require.Error(t, err, "expecting to fail when enabling the Rosenpass permissive mode without Rosenpass")
 }
 
 func TestAccount_GetExpiredPeers(t *testing.T) {
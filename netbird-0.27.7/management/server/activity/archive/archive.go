@@ -0,0 +1,110 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+)
+
+const objectTimeFormat = "20060102T150405Z"
+
+// Exporter archives the activity events of an account before they are pruned
+type Exporter interface {
+	// Export archives the events of the account, ordered ascending by a timestamp
+	Export(accountID string, events []*activity.Event) error
+}
+
+// record is the archived form of an event
+type record struct {
+	ID             uint64         `json:"id"`
+	Timestamp      time.Time      `json:"timestamp"`
+	Activity       string         `json:"activity"`
+	Message        string         `json:"message"`
+	InitiatorID    string         `json:"initiator_id"`
+	InitiatorName  string         `json:"initiator_name,omitempty"`
+	InitiatorEmail string         `json:"initiator_email,omitempty"`
+	TargetID       string         `json:"target_id"`
+	AccountID      string         `json:"account_id"`
+	Meta           map[string]any `json:"meta,omitempty"`
+}
+
+// objectName returns the name of the archive of the events of an account, made of the account ID and the time range
+// of the events so that archiving the same events again replaces the archive
+func objectName(accountID string, events []*activity.Event) string {
+	first := events[0].Timestamp.UTC().Format(objectTimeFormat)
+	last := events[len(events)-1].Timestamp.UTC().Format(objectTimeFormat)
+	return fmt.Sprintf("%s/events-%s-%s.json.gz", accountID, first, last)
+}
+
+// encode returns the events as a gzip compressed JSON array
+func encode(events []*activity.Event) ([]byte, error) {
+	records := make([]record, 0, len(events))
+	for _, event := range events {
+		records = append(records, record{
+			ID:             event.ID,
+			Timestamp:      event.Timestamp,
+			Activity:       event.Activity.StringCode(),
+			Message:        event.Activity.Message(),
+			InitiatorID:    event.InitiatorID,
+			InitiatorName:  event.InitiatorName,
+			InitiatorEmail: event.InitiatorEmail,
+			TargetID:       event.TargetID,
+			AccountID:      event.AccountID,
+			Meta:           event.Meta,
+		})
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(records); err != nil {
+		return nil, fmt.Errorf("encode events: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("compress events: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// LocalExporter archives the events to gzip compressed JSON files in a local directory, one subdirectory per account
+type LocalExporter struct {
+	dir string
+}
+
+// NewLocalExporter creates an exporter writing to the directory, created on the first export
+func NewLocalExporter(dir string) *LocalExporter {
+	return &LocalExporter{dir: dir}
+}
+
+// Export writes the events to a file of the account directory. The file is written to a temporary file first so that
+// a failed export never leaves a truncated archive behind
+func (e *LocalExporter) Export(accountID string, events []*activity.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	data, err := encode(events)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(e.dir, filepath.FromSlash(objectName(accountID, events)))
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("create archive directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write archive: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("rename archive: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package archive
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+)
+
+const (
+	s3Service       = "s3"
+	s3Algorithm     = "AWS4-HMAC-SHA256"
+	amzDateFormat   = "20060102T150405Z"
+	amzScopeFormat  = "20060102"
+	s3UploadTimeout = time.Minute
+)
+
+// S3Config is the bucket the S3 exporter uploads the archives to
+type S3Config struct {
+	// Endpoint of an S3 compatible service, e.g. https://minio.example.com. The objects are addressed path-style
+	// then. Empty uses AWS S3 with virtual-hosted addressing
+	Endpoint string
+	// Region of the bucket, used to sign the requests
+	Region string
+	// Bucket the archives are uploaded to
+	Bucket string
+	// Prefix prepended to the object names
+	Prefix string
+	// AccessKeyID of the credentials allowed to put objects in the bucket
+	AccessKeyID string
+	// SecretAccessKey of the credentials allowed to put objects in the bucket
+	SecretAccessKey string
+}
+
+// S3Exporter archives the events to gzip compressed JSON objects in an S3 bucket, signing the uploads with AWS
+// Signature Version 4
+type S3Exporter struct {
+	config     S3Config
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewS3Exporter creates an exporter uploading to the bucket of the config
+func NewS3Exporter(config S3Config) (*S3Exporter, error) {
+	if config.Bucket == "" || config.Region == "" {
+		return nil, fmt.Errorf("the S3 bucket and region are required")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("the S3 access key ID and secret access key are required")
+	}
+	if config.Endpoint != "" {
+		if _, err := url.Parse(config.Endpoint); err != nil {
+			return nil, fmt.Errorf("invalid S3 endpoint %s: %w", config.Endpoint, err)
+		}
+	}
+
+	return &S3Exporter{
+		config:     config,
+		httpClient: &http.Client{Timeout: s3UploadTimeout},
+		now:        time.Now,
+	}, nil
+}
+
+// Export uploads the events as an object named after the account and the time range of the events
+func (e *S3Exporter) Export(accountID string, events []*activity.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	data, err := encode(events)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, e.objectURL(path.Join(e.config.Prefix, objectName(accountID, events))), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("create S3 request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/gzip")
+	e.sign(req, data)
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("upload archive to S3: %w", err)
+	}
+	defer resp.Body.Close() //nolint
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("upload archive to S3: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// objectURL returns the URL of the object, path-style on a custom endpoint and virtual-hosted on AWS
+func (e *S3Exporter) objectURL(key string) string {
+	if e.config.Endpoint != "" {
+		return strings.TrimSuffix(e.config.Endpoint, "/") + "/" + e.config.Bucket + "/" + uriEncode(key, false)
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", e.config.Bucket, e.config.Region, uriEncode(key, false))
+}
+
+// sign adds the AWS Signature Version 4 authorization of the request to its headers, signing the host and all the
+// headers already set
+func (e *S3Exporter) sign(req *http.Request, payload []byte) {
+	now := e.now().UTC()
+	payloadHash := sha256.Sum256(payload)
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := strings.Join([]string{now.Format(amzScopeFormat), e.config.Region, s3Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{s3Algorithm, now.Format(amzDateFormat), scope, hex.EncodeToString(canonicalRequestHash[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+e.config.SecretAccessKey), now.Format(amzScopeFormat))
+	key = hmacSHA256(key, e.config.Region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, e.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery returns the query parameters sorted by name with their names and values URI encoded
+func canonicalQuery(query url.Values) string {
+	params := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// uriEncode encodes all the characters but the unreserved ones the way AWS expects it. The slashes are kept unless
+// encodeSlash is set, as in the query parameters
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -139,6 +139,127 @@ const (
 	PostureCheckUpdated Activity = 61
 	// PostureCheckDeleted indicates that the user deleted a posture check
 	PostureCheckDeleted Activity = 62
+	// RouteProposed indicates that a peer proposed a route for its local network
+	RouteProposed Activity = 63
+	// RouteApproved indicates that a user approved a route proposed by a peer
+	RouteApproved Activity = 64
+	// AccountPresharedKeysUpdated indicates that a user changed how WireGuard preshared keys are distributed for the account
+	AccountPresharedKeysUpdated Activity = 65
+	// AccountInterfaceMTUUpdated indicates that a user changed the MTU of the WireGuard interface of the peers
+	AccountInterfaceMTUUpdated Activity = 66
+	// PeerIPUpdated indicates that a user changed the NetBird IP of a peer
+	PeerIPUpdated Activity = 67
+	// PeerUplinkUpdated indicates that a user changed the uplink a peer pins its NetBird traffic to
+	PeerUplinkUpdated Activity = 68
+	// DNSRecordCreated indicates that a user created a custom DNS record
+	DNSRecordCreated Activity = 69
+	// DNSRecordUpdated indicates that a user updated a custom DNS record
+	DNSRecordUpdated Activity = 70
+	// DNSRecordDeleted indicates that a user deleted a custom DNS record
+	DNSRecordDeleted Activity = 71
+	// AccountDNSScopedGroupsUpdated indicates that a user changed the groups whose DNS visibility is scoped
+	AccountDNSScopedGroupsUpdated Activity = 72
+	// DNSBlocklistsUpdated indicates that a user changed the DNS deny lists settings
+	DNSBlocklistsUpdated Activity = 73
+	// RelayServerCreated indicates that a user created a relay server
+	RelayServerCreated Activity = 74
+	// RelayServerUpdated indicates that a user updated a relay server
+	RelayServerUpdated Activity = 75
+	// RelayServerDeleted indicates that a user deleted a relay server
+	RelayServerDeleted Activity = 76
+	// AccountICESettingsUpdated indicates that a user changed the ICE settings pushed to the peers
+	AccountICESettingsUpdated Activity = 77
+	// AccountRosenpassSettingsUpdated indicates that a user enabled, disabled or changed the mode of Rosenpass for the account
+	AccountRosenpassSettingsUpdated Activity = 78
+	// PeerSSHSessionClosed indicates that an SSH session from a peer to the embedded SSH server of another peer ended
+	PeerSSHSessionClosed Activity = 79
+	// AccountEventsRetentionUpdated indicates that a user changed the time the activity events of the account are kept for
+	AccountEventsRetentionUpdated Activity = 80
+	// UserNotificationsUpdated indicates that a user changed the email notifications of a user
+	UserNotificationsUpdated Activity = 81
+	// AccountPeerLoginExpirationWarningsUpdated indicates that a user changed the lead times the peers warn at before their login expires
+	AccountPeerLoginExpirationWarningsUpdated Activity = 82
+	// PeerDNSLabelCollision indicates that a peer was added or renamed with a DNS label already used by another peer
+	PeerDNSLabelCollision Activity = 83
+	// PeerDNSLabelUpdated indicates that a user changed the DNS label of a peer
+	PeerDNSLabelUpdated Activity = 84
+	// AccountDNSLabelCollisionStrategyUpdated indicates that a user changed how the DNS label collisions of the peers are resolved
+	AccountDNSLabelCollisionStrategyUpdated Activity = 85
+	// ClaimGroupMappingCreated indicates that a user created a claim group mapping
+	ClaimGroupMappingCreated Activity = 86
+	// ClaimGroupMappingUpdated indicates that a user updated a claim group mapping
+	ClaimGroupMappingUpdated Activity = 87
+	// ClaimGroupMappingDeleted indicates that a user deleted a claim group mapping
+	ClaimGroupMappingDeleted Activity = 88
+	// AccountPeersPerUserLimitUpdated indicates that a user changed the maximum number of peers of each user
+	AccountPeersPerUserLimitUpdated Activity = 89
+	// SetupKeyPeerLimitUpdated indicates that a user changed the maximum number of peers registered with a setup key
+	SetupKeyPeerLimitUpdated Activity = 90
+	// RouteGroupCreated indicates that a user created a route group
+	RouteGroupCreated Activity = 91
+	// RouteGroupUpdated indicates that a user updated a route group
+	RouteGroupUpdated Activity = 92
+	// RouteGroupDeleted indicates that a user deleted a route group
+	RouteGroupDeleted Activity = 93
+	// AccountPeersResynced indicates that the network map was resent to the connected peers of the account
+	AccountPeersResynced Activity = 94
+	// AccountFeatureFlagsUpdated indicates that a user changed the feature flags of the account
+	AccountFeatureFlagsUpdated Activity = 95
+	// GroupFeatureFlagsUpdated indicates that a user changed the feature flags of a group
+	GroupFeatureFlagsUpdated Activity = 96
+	// PeerDebugBundleUploaded indicates that a peer uploaded a debug bundle for support
+	PeerDebugBundleUploaded Activity = 97
+	// GroupClientUpdateChanged indicates that a user changed the client version or update channel of a group
+	GroupClientUpdateChanged Activity = 98
+	// PeerActionRequested indicates that a user requested a remote action on a peer
+	PeerActionRequested Activity = 99
+	// PeerActionSucceeded indicates that a peer ran a remote action
+	PeerActionSucceeded Activity = 100
+	// PeerActionFailed indicates that a peer failed to run a remote action
+	PeerActionFailed Activity = 101
+	// AccountPeerActionsUpdated indicates that a user changed the remote actions allowed on the peers
+	AccountPeerActionsUpdated Activity = 102
+	// AccountPeerKeyRotationUpdated indicates that a user changed the interval the peers rotate their keys at
+	AccountPeerKeyRotationUpdated Activity = 103
+	// PeerKeyRotationReverted indicates that a peer logged in with its previous key after a key rotation
+	PeerKeyRotationReverted Activity = 104
+	// SetupKeyReplaced indicates that a user revoked a setup key and issued a successor with the same settings
+	SetupKeyReplaced Activity = 105
+	// AccountDeletionRequested indicates that the owner requested the token confirming the deletion of the account
+	AccountDeletionRequested Activity = 106
+	// AccountDeletionScheduled indicates that the owner confirmed the deletion of the account, it is deleted after a grace period
+	AccountDeletionScheduled Activity = 107
+	// AccountDeletionCanceled indicates that the owner canceled the scheduled deletion of the account
+	AccountDeletionCanceled Activity = 108
+	// AccountExported indicates that the account was exported before its deletion
+	AccountExported Activity = 109
+	// PeerSpeedtestRun indicates that a peer reported a throughput test it ran against another peer
+	PeerSpeedtestRun Activity = 110
+	// AccountPeerControlPlaneUpdated indicates that a user changed the parts of the host the peers manage
+	AccountPeerControlPlaneUpdated Activity = 111
+	// ExternalListCreated indicates that a user created an external list
+	ExternalListCreated Activity = 112
+	// ExternalListUpdated indicates that a user updated an external list
+	ExternalListUpdated Activity = 113
+	// ExternalListDeleted indicates that a user deleted an external list
+	ExternalListDeleted Activity = 114
+	// RouteGroupMemberOverloaded indicates that the load of a routing peer exceeded the load thresholds of a route group
+	RouteGroupMemberOverloaded Activity = 115
+	// RouteGroupMemberRecovered indicates that the load of an overloaded routing peer fell below the load thresholds of
+	// a route group
+	RouteGroupMemberRecovered Activity = 116
+	// AccountGeofenceUpdated indicates that a user changed the geofence of the account
+	AccountGeofenceUpdated Activity = 117
+	// PeerGeofenceViolated indicates that a peer was located in a country outside the geofence of the account
+	PeerGeofenceViolated Activity = 118
+	// PeerGeofenceCleared indicates that a peer located outside the geofence complies with it again
+	PeerGeofenceCleared Activity = 119
+	// PeerGeofenceOverrideRequested indicates that a user requested a geofence override for a peer
+	PeerGeofenceOverrideRequested Activity = 120
+	// PeerGeofenceOverrideApproved indicates that a user approved the geofence override of a peer
+	PeerGeofenceOverrideApproved Activity = 121
+	// PeerGeofenceOverrideRevoked indicates that a user rejected or revoked the geofence override of a peer
+	PeerGeofenceOverrideRevoked Activity = 122
 )
 
 var activityMap = map[Activity]Code{
@@ -205,6 +326,66 @@ var activityMap = map[Activity]Code{
 	PostureCheckCreated:                       {"Posture check created", "posture.check.created"},
 	PostureCheckUpdated:                       {"Posture check updated", "posture.check.updated"},
 	PostureCheckDeleted:                       {"Posture check deleted", "posture.check.deleted"},
+	RouteProposed:                             {"Route proposed", "route.propose"},
+	RouteApproved:                             {"Route approved", "route.approve"},
+	AccountPresharedKeysUpdated:               {"Account preshared keys updated", "account.setting.preshared.keys.update"},
+	AccountInterfaceMTUUpdated:                {"Account interface MTU updated", "account.setting.interface.mtu.update"},
+	PeerIPUpdated:                             {"Peer IP updated", "peer.ip.update"},
+	PeerUplinkUpdated:                         {"Peer uplink updated", "peer.uplink.update"},
+	DNSRecordCreated:                          {"DNS record created", "dns.record.create"},
+	DNSRecordUpdated:                          {"DNS record updated", "dns.record.update"},
+	DNSRecordDeleted:                          {"DNS record deleted", "dns.record.delete"},
+	AccountDNSScopedGroupsUpdated:             {"Account DNS scoped groups updated", "account.setting.dns.scoped.groups.update"},
+	DNSBlocklistsUpdated:                      {"DNS blocklists updated", "dns.setting.blocklists.update"},
+	RelayServerCreated:                        {"Relay server created", "relay.create"},
+	RelayServerUpdated:                        {"Relay server updated", "relay.update"},
+	RelayServerDeleted:                        {"Relay server deleted", "relay.delete"},
+	AccountICESettingsUpdated:                 {"Account ICE settings updated", "account.setting.ice.update"},
+	AccountRosenpassSettingsUpdated:           {"Account Rosenpass settings updated", "account.setting.rosenpass.update"},
+	PeerSSHSessionClosed:                      {"Peer SSH session closed", "peer.ssh.session.close"},
+	AccountEventsRetentionUpdated:             {"Account events retention updated", "account.setting.events.retention.update"},
+	UserNotificationsUpdated:                  {"User notifications updated", "user.notifications.update"},
+	AccountPeerLoginExpirationWarningsUpdated: {"Account peer login expiration warnings updated", "account.setting.peer.login.expiration.warnings.update"},
+	PeerDNSLabelCollision:                     {"Peer DNS label collision", "peer.dns.label.collision"},
+	PeerDNSLabelUpdated:                       {"Peer DNS label updated", "peer.dns.label.update"},
+	AccountDNSLabelCollisionStrategyUpdated:   {"Account DNS label collision strategy updated", "account.setting.dns.label.collision.strategy.update"},
+	ClaimGroupMappingCreated:                  {"Claim group mapping created", "claim.group.mapping.create"},
+	ClaimGroupMappingUpdated:                  {"Claim group mapping updated", "claim.group.mapping.update"},
+	ClaimGroupMappingDeleted:                  {"Claim group mapping deleted", "claim.group.mapping.delete"},
+	AccountPeersPerUserLimitUpdated:           {"Account peers per user limit updated", "account.setting.peers.per.user.limit.update"},
+	SetupKeyPeerLimitUpdated:                  {"Setup key peer limit updated", "setupkey.peer.limit.update"},
+	RouteGroupCreated:                         {"Route group created", "route.group.add"},
+	RouteGroupUpdated:                         {"Route group updated", "route.group.update"},
+	RouteGroupDeleted:                         {"Route group deleted", "route.group.delete"},
+	AccountPeersResynced:                      {"Account peers resynced", "account.peers.resync"},
+	AccountFeatureFlagsUpdated:                {"Account feature flags updated", "account.setting.feature.flags.update"},
+	GroupFeatureFlagsUpdated:                  {"Group feature flags updated", "group.feature.flags.update"},
+	PeerDebugBundleUploaded:                   {"Peer uploaded a debug bundle", "peer.debug.bundle.upload"},
+	GroupClientUpdateChanged:                  {"Group client update changed", "group.client.update.change"},
+	PeerActionRequested:                       {"Peer action requested", "peer.action.request"},
+	PeerActionSucceeded:                       {"Peer action succeeded", "peer.action.succeed"},
+	PeerActionFailed:                          {"Peer action failed", "peer.action.fail"},
+	AccountPeerActionsUpdated:                 {"Account peer actions updated", "account.setting.peer.actions.update"},
+	AccountPeerKeyRotationUpdated:             {"Account peer key rotation updated", "account.setting.peer.key.rotation.update"},
+	PeerKeyRotationReverted:                   {"Peer key rotation reverted", "peer.key.rotation.revert"},
+	SetupKeyReplaced:                          {"Setup key replaced", "setupkey.replace"},
+	AccountDeletionRequested:                  {"Account deletion requested", "account.deletion.request"},
+	AccountDeletionScheduled:                  {"Account deletion scheduled", "account.deletion.schedule"},
+	AccountDeletionCanceled:                   {"Account deletion canceled", "account.deletion.cancel"},
+	AccountExported:                           {"Account exported", "account.export"},
+	PeerSpeedtestRun:                          {"Peer speed test run", "peer.speedtest.run"},
+	AccountPeerControlPlaneUpdated:            {"Account peer control plane updated", "account.setting.peer.control.plane.update"},
+	ExternalListCreated:                       {"External list created", "external.list.create"},
+	ExternalListUpdated:                       {"External list updated", "external.list.update"},
+	ExternalListDeleted:                       {"External list deleted", "external.list.delete"},
+	RouteGroupMemberOverloaded:                {"Route group member overloaded", "route.group.member.overload"},
+	RouteGroupMemberRecovered:                 {"Route group member recovered", "route.group.member.recover"},
+	AccountGeofenceUpdated:                    {"Account geofence updated", "account.setting.geofence.update"},
+	PeerGeofenceViolated:                      {"Peer located outside the geofence", "peer.geofence.violate"},
+	PeerGeofenceCleared:                       {"Peer located inside the geofence", "peer.geofence.clear"},
+	PeerGeofenceOverrideRequested:             {"Peer geofence override requested", "peer.geofence.override.request"},
+	PeerGeofenceOverrideApproved:              {"Peer geofence override approved", "peer.geofence.override.approve"},
+	PeerGeofenceOverrideRevoked:               {"Peer geofence override revoked", "peer.geofence.override.revoke"},
 }
 
 // StringCode returns a string code of the activity
@@ -0,0 +1,131 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+)
+
+const (
+	kafkaJSONContentType = "application/vnd.kafka.json.v2+json"
+	kafkaAccept          = "application/vnd.kafka.v2+json"
+)
+
+// KafkaConfig is the Kafka REST Proxy the events are produced to Kafka through. The management server talks HTTP to
+// the proxy instead of the Kafka protocol to keep its dependencies and footprint small
+type KafkaConfig struct {
+	// RESTProxyURL is the URL of a Confluent compatible REST Proxy, e.g. http://kafka-rest:8082
+	RESTProxyURL string
+	// Topic the events are produced to, keyed by their account ID so that the events of an account stay ordered
+	Topic string
+	// Username and Password authenticate to the proxy with basic authentication when set
+	Username string
+	Password string
+}
+
+type kafkaRecord struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+type kafkaProduceRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		Partition int     `json:"partition"`
+		Offset    int64   `json:"offset"`
+		ErrorCode *int    `json:"error_code"`
+		Error     *string `json:"error"`
+	} `json:"offsets"`
+}
+
+// KafkaPublisher produces the events to a Kafka topic through the REST Proxy
+type KafkaPublisher struct {
+	config     KafkaConfig
+	topicURL   string
+	httpClient *http.Client
+}
+
+// NewKafkaPublisher creates a publisher to the topic of the config
+func NewKafkaPublisher(config KafkaConfig) (*KafkaPublisher, error) {
+	if config.Topic == "" {
+		return nil, fmt.Errorf("the Kafka topic is required")
+	}
+	proxyURL, err := url.ParseRequestURI(config.RESTProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Kafka REST Proxy URL %s: %w", config.RESTProxyURL, err)
+	}
+
+	return &KafkaPublisher{
+		config:     config,
+		topicURL:   strings.TrimSuffix(proxyURL.String(), "/") + "/topics/" + url.PathEscape(config.Topic),
+		httpClient: &http.Client{},
+	}, nil
+}
+
+// Publish produces the event to the topic. The client errors of the proxy aren't retried, except timeouts and rate
+// limits
+func (p *KafkaPublisher) Publish(ctx context.Context, event *activity.Event, payload []byte) error {
+	body, err := json.Marshal(kafkaProduceRequest{Records: []kafkaRecord{{Key: event.AccountID, Value: payload}}})
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.topicURL, bytes.NewReader(body))
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+	req.Header.Set("Content-Type", kafkaJSONContentType)
+	req.Header.Set("Accept", kafkaAccept)
+	if p.config.Username != "" {
+		req.SetBasicAuth(p.config.Username, p.config.Password)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("produce to Kafka: %w", err)
+	}
+	defer resp.Body.Close() //nolint
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		err := fmt.Errorf("produce to Kafka: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			return backoff.Permanent(err)
+		}
+		return err
+	}
+
+	var produced kafkaProduceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&produced); err != nil {
+		return fmt.Errorf("parse Kafka REST Proxy response: %w", err)
+	}
+	for _, offset := range produced.Offsets {
+		if offset.ErrorCode != nil || offset.Error != nil {
+			msg := ""
+			if offset.Error != nil {
+				msg = *offset.Error
+			}
+			return fmt.Errorf("produce to Kafka partition %d: %s", offset.Partition, msg)
+		}
+	}
+	return nil
+}
+
+// Close releases the idle connections to the proxy
+func (p *KafkaPublisher) Close() error {
+	p.httpClient.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,72 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+)
+
+func TestKafkaPublisher(t *testing.T) {
+	var received kafkaProduceRequest
+	var path, contentType, username string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		contentType = r.Header.Get("Content-Type")
+		username, _, _ = r.BasicAuth()
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_, _ = w.Write([]byte(`{"offsets":[{"partition":0,"offset":42,"error_code":null,"error":null}]}`))
+	}))
+	defer server.Close()
+
+	publisher, err := NewKafkaPublisher(KafkaConfig{RESTProxyURL: server.URL, Topic: "netbird-events", Username: "netbird", Password: "secret"})
+	require.NoError(t, err)
+	defer publisher.Close() //nolint
+
+	event := &activity.Event{Activity: activity.PeerAddedByUser, AccountID: "account1"}
+	require.NoError(t, publisher.Publish(context.Background(), event, []byte(`{"schema_version":1}`)))
+
+	assert.Equal(t, "/topics/netbird-events", path)
+	assert.Equal(t, kafkaJSONContentType, contentType)
+	assert.Equal(t, "netbird", username)
+	require.Len(t, received.Records, 1)
+	assert.Equal(t, "account1", received.Records[0].Key, "the events should be keyed by account")
+	assert.JSONEq(t, `{"schema_version":1}`, string(received.Records[0].Value))
+}
+
+func TestKafkaPublisher_Errors(t *testing.T) {
+	status := http.StatusNotFound
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status == http.StatusOK {
+			_, _ = w.Write([]byte(`{"offsets":[{"partition":1,"offset":null,"error_code":50002,"error":"leader not available"}]}`))
+			return
+		}
+		http.Error(w, `{"error_code":40401,"message":"Topic not found"}`, status)
+	}))
+	defer server.Close()
+
+	publisher, err := NewKafkaPublisher(KafkaConfig{RESTProxyURL: server.URL, Topic: "missing"})
+	require.NoError(t, err)
+	event := &activity.Event{Activity: activity.PeerAddedByUser, AccountID: "account1"}
+
+	err = publisher.Publish(context.Background(), event, []byte(`{}`))
+	var permanent *backoff.PermanentError
+	assert.ErrorAs(t, err, &permanent, "a missing topic shouldn't be retried")
+
+	status = http.StatusServiceUnavailable
+	err = publisher.Publish(context.Background(), event, []byte(`{}`))
+	require.Error(t, err)
+	assert.False(t, errors.As(err, &permanent), "an unavailable proxy should be retried")
+
+	status = http.StatusOK
+	err = publisher.Publish(context.Background(), event, []byte(`{}`))
+	assert.ErrorContains(t, err, "leader not available")
+}
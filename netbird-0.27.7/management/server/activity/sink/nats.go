@@ -0,0 +1,257 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/version"
+)
+
+// NATSConfig is the NATS server the events are published to
+type NATSConfig struct {
+	// URL of the server, nats://host:4222 or tls://host:4222. Credentials in the URL are used as User and Password
+	URL string
+	// Subject the events are published under as <Subject>.<account ID>.<activity code>, e.g.
+	// netbird.events.<account ID>.peer.user.add
+	Subject string
+	// Token authenticates the connection when set
+	Token string
+	// User and Password authenticate the connection when set
+	User     string
+	Password string
+}
+
+// natsInfo is the part of the INFO message of the server the publisher uses
+type natsInfo struct {
+	TLSRequired bool `json:"tls_required"`
+	MaxPayload  int  `json:"max_payload"`
+}
+
+// natsConnect is the CONNECT message of the client
+type natsConnect struct {
+	Verbose     bool   `json:"verbose"`
+	Pedantic    bool   `json:"pedantic"`
+	TLSRequired bool   `json:"tls_required"`
+	Name        string `json:"name"`
+	Lang        string `json:"lang"`
+	Version     string `json:"version"`
+	Protocol    int    `json:"protocol"`
+	AuthToken   string `json:"auth_token,omitempty"`
+	User        string `json:"user,omitempty"`
+	Pass        string `json:"pass,omitempty"`
+}
+
+// NATSPublisher publishes the events with the core NATS protocol. Every publish is followed by a PING so that it
+// only succeeds once the server processed the message. The connection is opened on the first publish and reopened
+// on the next one after a failure
+type NATSPublisher struct {
+	config  NATSConfig
+	address string
+	useTLS  bool
+	tlsHost string
+
+	mu         sync.Mutex
+	conn       net.Conn
+	reader     *bufio.Reader
+	maxPayload int
+}
+
+// NewNATSPublisher creates a publisher to the server of the config
+func NewNATSPublisher(config NATSConfig) (*NATSPublisher, error) {
+	if config.Subject == "" {
+		return nil, fmt.Errorf("the NATS subject is required")
+	}
+	if strings.ContainsAny(config.Subject, " \t\r\n*>") {
+		return nil, fmt.Errorf("invalid NATS subject %q", config.Subject)
+	}
+
+	serverURL, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NATS URL %s: %w", config.URL, err)
+	}
+
+	p := &NATSPublisher{config: config, address: serverURL.Host, tlsHost: serverURL.Hostname()}
+	switch serverURL.Scheme {
+	case "nats":
+	case "tls":
+		p.useTLS = true
+	default:
+		return nil, fmt.Errorf("unsupported NATS URL scheme %q, use nats or tls", serverURL.Scheme)
+	}
+	if serverURL.Port() == "" {
+		p.address = net.JoinHostPort(serverURL.Hostname(), "4222")
+	}
+
+	if serverURL.User != nil && p.config.User == "" {
+		p.config.User = serverURL.User.Username()
+		p.config.Password, _ = serverURL.User.Password()
+	}
+	return p, nil
+}
+
+// Publish sends the event to the subject of its account and activity
+func (p *NATSPublisher) Publish(ctx context.Context, event *activity.Event, payload []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		if err := p.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	if p.maxPayload > 0 && len(payload) > p.maxPayload {
+		return backoff.Permanent(fmt.Errorf("event of %d bytes exceeds the maximum NATS payload of %d bytes", len(payload), p.maxPayload))
+	}
+
+	p.setDeadline(ctx)
+	_, err := fmt.Fprintf(p.conn, "PUB %s %d\r\n%s\r\nPING\r\n", p.subject(event), len(payload), payload)
+	if err == nil {
+		err = p.waitPong()
+	}
+	if err != nil {
+		p.closeConn()
+		return fmt.Errorf("publish to NATS: %w", err)
+	}
+	return nil
+}
+
+// Close the connection to the server
+func (p *NATSPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closeConn()
+	return nil
+}
+
+func (p *NATSPublisher) subject(event *activity.Event) string {
+	accountID := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(" \t\r\n.*>", r) {
+			return '_'
+		}
+		return r
+	}, event.AccountID)
+	return fmt.Sprintf("%s.%s.%s", p.config.Subject, accountID, event.Activity.StringCode())
+}
+
+// connect opens the connection, upgrades it to TLS when needed and authenticates
+func (p *NATSPublisher) connect(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		return fmt.Errorf("connect to NATS: %w", err)
+	}
+	p.conn = conn
+	p.reader = bufio.NewReader(conn)
+	p.setDeadline(ctx)
+
+	// the server sends its INFO in plain text before the TLS handshake
+	line, err := p.readLine()
+	if err != nil {
+		p.closeConn()
+		return fmt.Errorf("read NATS server info: %w", err)
+	}
+	infoJSON, found := strings.CutPrefix(line, "INFO ")
+	if !found {
+		p.closeConn()
+		return fmt.Errorf("unexpected NATS server greeting: %s", line)
+	}
+	var info natsInfo
+	if err := json.Unmarshal([]byte(infoJSON), &info); err != nil {
+		p.closeConn()
+		return fmt.Errorf("parse NATS server info: %w", err)
+	}
+	p.maxPayload = info.MaxPayload
+
+	useTLS := p.useTLS || info.TLSRequired
+	if useTLS {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: p.tlsHost, MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			p.closeConn()
+			return fmt.Errorf("NATS TLS handshake: %w", err)
+		}
+		p.conn = tlsConn
+		p.reader = bufio.NewReader(tlsConn)
+	}
+
+	connect, err := json.Marshal(natsConnect{
+		TLSRequired: useTLS,
+		Name:        "netbird-management",
+		Lang:        "go",
+		Version:     version.NetbirdVersion(),
+		Protocol:    1,
+		AuthToken:   p.config.Token,
+		User:        p.config.User,
+		Pass:        p.config.Password,
+	})
+	if err != nil {
+		p.closeConn()
+		return err
+	}
+
+	_, err = fmt.Fprintf(p.conn, "CONNECT %s\r\nPING\r\n", connect)
+	if err == nil {
+		err = p.waitPong()
+	}
+	if err != nil {
+		p.closeConn()
+		return fmt.Errorf("connect to NATS: %w", err)
+	}
+	return nil
+}
+
+// waitPong reads the messages of the server until the PONG answering the last PING, answering its PINGs
+func (p *NATSPublisher) waitPong() error {
+	for {
+		line, err := p.readLine()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("server error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+func (p *NATSPublisher) readLine() (string, error) {
+	line, err := p.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (p *NATSPublisher) setDeadline(ctx context.Context) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(publishTimeout)
+	}
+	_ = p.conn.SetDeadline(deadline)
+}
+
+func (p *NATSPublisher) closeConn() {
+	if p.conn != nil {
+		_ = p.conn.Close()
+		p.conn = nil
+		p.reader = nil
+	}
+}
@@ -0,0 +1,102 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+)
+
+type natsPub struct {
+	subject string
+	payload string
+}
+
+// serveNATS runs a minimal NATS server accepting one connection, sending the messages it receives to pubs
+func serveNATS(t *testing.T, connect chan<- string, pubs chan<- natsPub) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		_, _ = fmt.Fprintf(conn, "INFO {\"server_id\":\"test\",\"max_payload\":1024}\r\n")
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch {
+			case strings.HasPrefix(line, "CONNECT "):
+				connect <- strings.TrimPrefix(line, "CONNECT ")
+			case line == "PING":
+				_, _ = conn.Write([]byte("PONG\r\n"))
+			case strings.HasPrefix(line, "PUB "):
+				fields := strings.Fields(line)
+				size, _ := strconv.Atoi(fields[2])
+				payload := make([]byte, size+2)
+				if _, err := io.ReadFull(reader, payload); err != nil {
+					return
+				}
+				pubs <- natsPub{subject: fields[1], payload: string(payload[:size])}
+			}
+		}
+	}()
+
+	return "nats://token-user:secret@" + listener.Addr().String()
+}
+
+func TestNATSPublisher(t *testing.T) {
+	connect := make(chan string, 1)
+	pubs := make(chan natsPub, 1)
+	serverURL := serveNATS(t, connect, pubs)
+
+	publisher, err := NewNATSPublisher(NATSConfig{URL: serverURL, Subject: "netbird.events"})
+	require.NoError(t, err)
+	defer publisher.Close() //nolint
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	event := &activity.Event{Activity: activity.PeerAddedByUser, AccountID: "account1"}
+	require.NoError(t, publisher.Publish(ctx, event, []byte(`{"schema_version":1}`)))
+
+	assert.Contains(t, <-connect, `"user":"token-user","pass":"secret"`, "the credentials of the URL should be used")
+	pub := <-pubs
+	assert.Equal(t, "netbird.events.account1.user.peer.add", pub.subject)
+	assert.Equal(t, `{"schema_version":1}`, pub.payload)
+
+	err = publisher.Publish(ctx, event, make([]byte, 2048))
+	assert.Error(t, err, "a payload larger than the maximum of the server should be rejected")
+}
+
+func TestNewNATSPublisher(t *testing.T) {
+	_, err := NewNATSPublisher(NATSConfig{URL: "nats://localhost", Subject: "netbird.>"})
+	assert.Error(t, err, "a wildcard subject should be rejected")
+
+	_, err = NewNATSPublisher(NATSConfig{URL: "http://localhost", Subject: "netbird"})
+	assert.Error(t, err, "an unsupported scheme should be rejected")
+
+	publisher, err := NewNATSPublisher(NATSConfig{URL: "tls://nats.example.com", Subject: "netbird"})
+	require.NoError(t, err)
+	assert.Equal(t, "nats.example.com:4222", publisher.address)
+	assert.True(t, publisher.useTLS)
+}
@@ -0,0 +1,225 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/telemetry"
+)
+
+// SchemaVersion is the version of the Message schema. It is increased on every change that isn't backward compatible
+const SchemaVersion = 1
+
+const (
+	// DefaultQueueSize is the number of events waiting for their delivery kept in memory when no size is configured
+	DefaultQueueSize = 10000
+	// DefaultMaxRetryTime is the time the delivery of an event is retried for when no time is configured
+	DefaultMaxRetryTime = 5 * time.Minute
+
+	defaultRetryInterval = time.Second
+	maxRetryInterval     = time.Minute
+	publishTimeout       = 10 * time.Second
+	closeTimeout         = 10 * time.Second
+)
+
+// Publisher delivers the events to a message broker
+type Publisher interface {
+	// Publish delivers the encoded event and returns once the broker accepted it. Errors wrapped with
+	// backoff.Permanent aren't retried
+	Publish(ctx context.Context, event *activity.Event, payload []byte) error
+	// Close the connection to the broker
+	Close() error
+}
+
+// Message is the JSON form of the events published to the sink
+type Message struct {
+	SchemaVersion int            `json:"schema_version"`
+	ID            uint64         `json:"id"`
+	Timestamp     time.Time      `json:"timestamp"`
+	Activity      string         `json:"activity"`
+	Message       string         `json:"message"`
+	InitiatorID   string         `json:"initiator_id"`
+	TargetID      string         `json:"target_id"`
+	AccountID     string         `json:"account_id"`
+	Meta          map[string]any `json:"meta,omitempty"`
+}
+
+func encode(event *activity.Event) ([]byte, error) {
+	return json.Marshal(Message{
+		SchemaVersion: SchemaVersion,
+		ID:            event.ID,
+		Timestamp:     event.Timestamp,
+		Activity:      event.Activity.StringCode(),
+		Message:       event.Activity.Message(),
+		InitiatorID:   event.InitiatorID,
+		TargetID:      event.TargetID,
+		AccountID:     event.AccountID,
+		Meta:          event.Meta,
+	})
+}
+
+// Store is an activity.Store publishing every event it saves in the background. The events are queued in memory and
+// their delivery is retried with an exponential backoff so that an unavailable broker never blocks or fails the
+// management operations. The events that don't fit in the queue or run out of retries are only kept in the store.
+type Store struct {
+	activity.Store
+
+	publisher     Publisher
+	metrics       telemetry.AppMetrics
+	maxRetryTime  time.Duration
+	retryInterval time.Duration
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan *activity.Event
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewStore wraps the store to publish the events it saves with the publisher. A zero queue size or retry time uses
+// the defaults. The metrics can be nil
+func NewStore(store activity.Store, publisher Publisher, queueSize int, maxRetryTime time.Duration, metrics telemetry.AppMetrics) *Store {
+	return newStore(store, publisher, queueSize, maxRetryTime, defaultRetryInterval, metrics)
+}
+
+func newStore(store activity.Store, publisher Publisher, queueSize int, maxRetryTime, retryInterval time.Duration, metrics telemetry.AppMetrics) *Store {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	if maxRetryTime <= 0 {
+		maxRetryTime = DefaultMaxRetryTime
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Store{
+		Store:         store,
+		publisher:     publisher,
+		metrics:       metrics,
+		maxRetryTime:  maxRetryTime,
+		retryInterval: retryInterval,
+		queue:         make(chan *activity.Event, queueSize),
+		ctx:           ctx,
+		cancel:        cancel,
+		done:          make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Save an event in the wrapped store and queue it for publishing
+func (s *Store) Save(event *activity.Event) (*activity.Event, error) {
+	saved, err := s.Store.Save(event)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return saved, nil
+	}
+
+	select {
+	case s.queue <- saved:
+		if s.metrics != nil {
+			s.metrics.EventSinkMetrics().CountQueued(1)
+		}
+	default:
+		log.Warnf("event sink queue is full, activity event %d is not published", saved.ID)
+		if s.metrics != nil {
+			s.metrics.EventSinkMetrics().CountDropped()
+		}
+	}
+	return saved, nil
+}
+
+// Close publishes the queued events for a little while, then closes the publisher and the wrapped store
+func (s *Store) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.queue)
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+	case <-time.After(closeTimeout):
+		log.Warnf("event sink closed with %d activity events left unpublished", len(s.queue))
+		s.cancel()
+		<-s.done
+	}
+	s.cancel()
+
+	if err := s.publisher.Close(); err != nil {
+		log.Warnf("failed to close the event sink publisher: %v", err)
+	}
+	return s.Store.Close()
+}
+
+func (s *Store) run() {
+	defer close(s.done)
+	for event := range s.queue {
+		if s.metrics != nil {
+			s.metrics.EventSinkMetrics().CountQueued(-1)
+		}
+		s.publish(event)
+	}
+}
+
+// publish delivers the event, retrying until the maximum retry time elapses or the store is closed
+func (s *Store) publish(event *activity.Event) {
+	if s.ctx.Err() != nil {
+		s.countDropped()
+		return
+	}
+
+	payload, err := encode(event)
+	if err != nil {
+		log.Errorf("failed to encode activity event %d for the event sink: %v", event.ID, err)
+		s.countDropped()
+		return
+	}
+
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = s.retryInterval
+	bo.MaxInterval = maxRetryInterval
+	bo.MaxElapsedTime = s.maxRetryTime
+
+	operation := func() error {
+		ctx, cancel := context.WithTimeout(s.ctx, publishTimeout)
+		defer cancel()
+		return s.publisher.Publish(ctx, event, payload)
+	}
+	notify := func(err error, next time.Duration) {
+		log.Debugf("failed to publish activity event %d, retrying in %s: %v", event.ID, next, err)
+		if s.metrics != nil {
+			s.metrics.EventSinkMetrics().CountPublishRetry()
+		}
+	}
+
+	if err := backoff.RetryNotify(operation, backoff.WithContext(bo, s.ctx), notify); err != nil {
+		log.Errorf("failed to publish activity event %d to the event sink: %v", event.ID, err)
+		s.countDropped()
+		return
+	}
+
+	if s.metrics != nil {
+		s.metrics.EventSinkMetrics().CountPublishLag(time.Since(event.Timestamp))
+	}
+}
+
+func (s *Store) countDropped() {
+	if s.metrics != nil {
+		s.metrics.EventSinkMetrics().CountDropped()
+	}
+}
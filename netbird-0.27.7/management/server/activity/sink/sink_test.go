@@ -0,0 +1,106 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+)
+
+type mockPublisher struct {
+	mu        sync.Mutex
+	failures  int
+	err       error
+	attempts  int
+	published []Message
+	closed    bool
+}
+
+func (p *mockPublisher) Publish(_ context.Context, _ *activity.Event, payload []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.attempts++
+	if p.err != nil {
+		return p.err
+	}
+	if p.failures > 0 {
+		p.failures--
+		return fmt.Errorf("broker unavailable")
+	}
+
+	var msg Message
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return backoff.Permanent(err)
+	}
+	p.published = append(p.published, msg)
+	return nil
+}
+
+func (p *mockPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+func TestStore(t *testing.T) {
+	publisher := &mockPublisher{failures: 2}
+	eventStore := &activity.InMemoryEventStore{}
+	store := newStore(eventStore, publisher, 0, time.Minute, time.Millisecond, nil)
+
+	_, err := store.Save(&activity.Event{
+		Timestamp:   time.Now().UTC(),
+		Activity:    activity.PeerAddedByUser,
+		InitiatorID: "user1",
+		TargetID:    "peer1",
+		AccountID:   "account1",
+		Meta:        map[string]any{"name": "router"},
+	})
+	require.NoError(t, err)
+
+	events, err := eventStore.Get("account1", 0, 10, false)
+	require.NoError(t, err)
+	assert.Len(t, events, 1, "the event should be saved in the wrapped store")
+
+	require.NoError(t, store.Close())
+
+	require.Len(t, publisher.published, 1, "the event should be published once the broker is back")
+	assert.Equal(t, 3, publisher.attempts)
+	assert.True(t, publisher.closed)
+
+	msg := publisher.published[0]
+	assert.Equal(t, SchemaVersion, msg.SchemaVersion)
+	assert.Equal(t, "user.peer.add", msg.Activity)
+	assert.Equal(t, "account1", msg.AccountID)
+	assert.Equal(t, "router", msg.Meta["name"])
+}
+
+func TestStore_PermanentError(t *testing.T) {
+	publisher := &mockPublisher{err: backoff.Permanent(fmt.Errorf("topic not found"))}
+	store := newStore(&activity.InMemoryEventStore{}, publisher, 0, time.Minute, time.Millisecond, nil)
+
+	_, err := store.Save(&activity.Event{Timestamp: time.Now().UTC(), Activity: activity.PeerAddedByUser, AccountID: "account1"})
+	require.NoError(t, err, "a publish failure should never fail the save")
+	require.NoError(t, store.Close())
+
+	assert.Equal(t, 1, publisher.attempts, "a permanent error shouldn't be retried")
+	assert.Empty(t, publisher.published)
+}
+
+func TestStore_SaveAfterClose(t *testing.T) {
+	publisher := &mockPublisher{}
+	store := newStore(&activity.InMemoryEventStore{}, publisher, 0, time.Minute, time.Millisecond, nil)
+	require.NoError(t, store.Close())
+
+	_, err := store.Save(&activity.Event{Timestamp: time.Now().UTC(), Activity: activity.PeerAddedByUser, AccountID: "account1"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, publisher.attempts)
+}
@@ -57,6 +57,23 @@ const (
 		WHERE account_id = ? 
 		ORDER BY timestamp ASC LIMIT ? OFFSET ?;`
 
+	selectBeforeQuery = `SELECT events.id, activity, timestamp, initiator_id, i.name as "initiator_name", i.email as "initiator_email", target_id, t.name as "target_name", t.email as "target_email", account_id, meta
+		FROM events 
+		LEFT JOIN (
+		    SELECT id, MAX(name) as name, MAX(email) as email 
+		    FROM deleted_users
+		    GROUP BY id
+		) i ON events.initiator_id = i.id 
+		LEFT JOIN (
+		    SELECT id, MAX(name) as name, MAX(email) as email 
+		    FROM deleted_users
+		    GROUP BY id
+		) t ON events.target_id = t.id
+		WHERE account_id = ? AND timestamp < ?
+		ORDER BY timestamp ASC;`
+
+	deleteBeforeQuery = "DELETE FROM events WHERE account_id = ? AND timestamp < ?"
+
 	insertQuery = "INSERT INTO events(activity, timestamp, initiator_id, target_id, account_id, meta) " +
 		"VALUES(?, ?, ?, ?, ?, ?)"
 
@@ -82,6 +99,8 @@ type Store struct {
 	insertStatement     *sql.Stmt
 	selectAscStatement  *sql.Stmt
 	selectDescStatement *sql.Stmt
+	selectBeforeStmt    *sql.Stmt
+	deleteBeforeStmt    *sql.Stmt
 	deleteUserStmt      *sql.Stmt
 }
 
@@ -135,6 +154,18 @@ func NewSQLiteStore(dataDir string, encryptionKey string) (*Store, error) {
 		return nil, err
 	}
 
+	selectBeforeStmt, err := db.Prepare(selectBeforeQuery)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	deleteBeforeStmt, err := db.Prepare(deleteBeforeQuery)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
 	deleteUserStmt, err := db.Prepare(insertDeleteUserQuery)
 	if err != nil {
 		_ = db.Close()
@@ -147,6 +178,8 @@ func NewSQLiteStore(dataDir string, encryptionKey string) (*Store, error) {
 		insertStatement:     insertStmt,
 		selectDescStatement: selectDescStmt,
 		selectAscStatement:  selectAscStmt,
+		selectBeforeStmt:    selectBeforeStmt,
+		deleteBeforeStmt:    deleteBeforeStmt,
 		deleteUserStmt:      deleteUserStmt,
 	}
 
@@ -257,6 +290,27 @@ func (store *Store) Get(accountID string, offset, limit int, descending bool) ([
 	return store.processResult(result)
 }
 
+// GetBefore returns all the events of the account older than the given time ordered ascending by a timestamp
+func (store *Store) GetBefore(accountID string, before time.Time) ([]*activity.Event, error) {
+	result, err := store.selectBeforeStmt.Query(accountID, before.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close() //nolint
+	return store.processResult(result)
+}
+
+// DeleteBefore deletes the events of the account older than the given time. The deleted users table is kept as it
+// is shared by the events of all the accounts
+func (store *Store) DeleteBefore(accountID string, before time.Time) (int64, error) {
+	result, err := store.deleteBeforeStmt.Exec(accountID, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 // Save an event in the SQLite events table end encrypt the "email" element in meta map
 func (store *Store) Save(event *activity.Event) (*activity.Event, error) {
 	var jsonMeta string
@@ -54,3 +54,58 @@ func TestNewSQLiteStore(t *testing.T) {
 	assert.Len(t, result, 5)
 	assert.True(t, result[0].Timestamp.After(result[len(result)-1].Timestamp))
 }
+
+func TestStore_DeleteBefore(t *testing.T) {
+	key, _ := GenerateKey()
+	store, err := NewSQLiteStore(t.TempDir(), key)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer store.Close() //nolint
+
+	now := time.Now().UTC()
+	for i, accountID := range []string{"account_1", "account_1", "account_1", "account_2"} {
+		_, err = store.Save(&activity.Event{
+			Timestamp:   now.Add(-time.Duration(i) * 24 * time.Hour),
+			Activity:    activity.PeerAddedByUser,
+			InitiatorID: "user_" + fmt.Sprint(i),
+			TargetID:    "peer_" + fmt.Sprint(i),
+			AccountID:   accountID,
+		})
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+	}
+
+	cutoff := now.Add(-12 * time.Hour)
+	expired, err := store.GetBefore("account_1", cutoff)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	assert.Len(t, expired, 2)
+	assert.Equal(t, "peer_2", expired[0].TargetID, "the oldest event should come first")
+
+	deleted, err := store.DeleteBefore("account_1", cutoff)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	assert.Equal(t, int64(2), deleted)
+
+	result, err := store.Get("account_1", 0, 10, false)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	assert.Len(t, result, 1)
+
+	result, err = store.Get("account_2", 0, 10, false)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	assert.Len(t, result, 1, "the events of the other accounts should be kept")
+}
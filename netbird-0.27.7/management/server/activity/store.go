@@ -1,6 +1,9 @@
 package activity
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 // Store provides an interface to store or stream events.
 type Store interface {
@@ -8,6 +11,10 @@ type Store interface {
 	Save(event *Event) (*Event, error)
 	// Get returns "limit" number of events from the "offset" index ordered descending or ascending by a timestamp
 	Get(accountID string, offset, limit int, descending bool) ([]*Event, error)
+	// GetBefore returns the events of the account older than the given time ordered ascending by a timestamp
+	GetBefore(accountID string, before time.Time) ([]*Event, error)
+	// DeleteBefore deletes the events of the account older than the given time and returns how many were deleted
+	DeleteBefore(accountID string, before time.Time) (int64, error)
 	// Close the sink flushing events if necessary
 	Close() error
 }
@@ -45,6 +52,34 @@ func (store *InMemoryEventStore) Get(accountID string, offset, limit int, descen
 	return events, nil
 }
 
+// GetBefore returns the events of the given accountID older than the given time in the order they were saved
+func (store *InMemoryEventStore) GetBefore(accountID string, before time.Time) ([]*Event, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	events := make([]*Event, 0)
+	for _, event := range store.events {
+		if event.AccountID == accountID && event.Timestamp.Before(before) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// DeleteBefore removes the events of the given accountID older than the given time
+func (store *InMemoryEventStore) DeleteBefore(accountID string, before time.Time) (int64, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	kept := make([]*Event, 0, len(store.events))
+	for _, event := range store.events {
+		if event.AccountID != accountID || !event.Timestamp.Before(before) {
+			kept = append(kept, event)
+		}
+	}
+	deleted := int64(len(store.events) - len(kept))
+	store.events = kept
+	return deleted, nil
+}
+
 // Close cleans up the event list
 func (store *InMemoryEventStore) Close() error {
 	store.mu.Lock()
@@ -0,0 +1,695 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/xid"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	adminProto "github.com/netbirdio/netbird/management/admin/proto"
+	nbgroup "github.com/netbirdio/netbird/management/server/group"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	internalStatus "github.com/netbirdio/netbird/management/server/status"
+	"github.com/netbirdio/netbird/route"
+)
+
+// AdminServer an instance of the Management admin gRPC API server. It exposes the operations of the HTTP API on
+// peers, groups, policies and routes with the same authentication and permissions.
+type AdminServer struct {
+	adminProto.UnimplementedAdminServiceServer
+	accountManager     AccountManager
+	jwtValidator       *jwtclaims.JWTValidator
+	jwtClaimsExtractor *jwtclaims.ClaimsExtractor
+}
+
+// NewAdminServer creates a new Management admin gRPC API server
+func NewAdminServer(config *Config, accountManager AccountManager) (*AdminServer, error) {
+	jwtValidator, err := newJWTValidator(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AdminServer{
+		accountManager:     accountManager,
+		jwtValidator:       jwtValidator,
+		jwtClaimsExtractor: newJWTClaimsExtractor(config),
+	}, nil
+}
+
+// authenticate returns the account and the user the request was made by, it is authenticated by a JWT or a personal
+// access token in the authorization metadata. Modifying requests are allowed only to users with admin power.
+func (s *AdminServer) authenticate(ctx context.Context, modify bool) (*Account, *User, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get("authorization")) == 0 {
+		return nil, nil, status.Errorf(codes.Unauthenticated, "no valid authentication provided")
+	}
+
+	auth := strings.Split(md.Get("authorization")[0], " ")
+	if len(auth) != 2 {
+		return nil, nil, status.Errorf(codes.Unauthenticated, "authorization metadata format must be Bearer {token} or Token {token}")
+	}
+
+	authType := strings.ToLower(auth[0])
+	// fallback to token when receive pat as bearer
+	if authType == "bearer" && strings.HasPrefix(auth[1], "nbp_") {
+		authType = "token"
+	}
+
+	var claims jwtclaims.AuthorizationClaims
+	switch authType {
+	case "bearer":
+		if s.jwtValidator == nil {
+			return nil, nil, status.Errorf(codes.Unauthenticated, "no jwt validator set")
+		}
+		token, err := s.jwtValidator.ValidateAndParse(auth[1])
+		if err != nil {
+			log.Errorf("Error when validating JWT claims: %s", err.Error())
+			return nil, nil, status.Errorf(codes.Unauthenticated, "token invalid")
+		}
+		claims = s.jwtClaimsExtractor.FromToken(token)
+		if err := s.accountManager.CheckUserAccessByJWTGroups(claims); err != nil {
+			return nil, nil, status.Errorf(codes.PermissionDenied, err.Error())
+		}
+	case "token":
+		account, user, pat, err := s.accountManager.GetAccountFromPAT(auth[1])
+		if err != nil || time.Now().After(pat.ExpirationDate) {
+			log.Debugf("Error when validating PAT claims: %v", err)
+			return nil, nil, status.Errorf(codes.Unauthenticated, "token invalid")
+		}
+		if err := s.accountManager.MarkPATUsed(pat.ID); err != nil {
+			return nil, nil, mapAdminError(err)
+		}
+		claims = jwtclaims.AuthorizationClaims{
+			UserId:         user.Id,
+			AccountId:      account.Id,
+			Domain:         account.Domain,
+			DomainCategory: account.DomainCategory,
+		}
+	default:
+		return nil, nil, status.Errorf(codes.Unauthenticated, "no valid authentication provided")
+	}
+
+	account, user, err := s.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		return nil, nil, mapAdminError(err)
+	}
+
+	if user.IsBlocked() {
+		return nil, nil, status.Errorf(codes.PermissionDenied, "the user has no access to the API or is blocked")
+	}
+
+	if modify && !user.HasAdminPower() {
+		return nil, nil, status.Errorf(codes.PermissionDenied, "only users with admin power can perform this operation")
+	}
+
+	return account, user, nil
+}
+
+// ListPeers streams the peers of the account
+func (s *AdminServer) ListPeers(_ *adminProto.ListRequest, srv adminProto.AdminService_ListPeersServer) error {
+	account, user, err := s.authenticate(srv.Context(), false)
+	if err != nil {
+		return err
+	}
+
+	peers, err := s.accountManager.GetPeers(account.Id, user.Id)
+	if err != nil {
+		return mapAdminError(err)
+	}
+
+	for _, peer := range peers {
+		if err := srv.Send(toAdminPeer(account, peer)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPeer returns a peer of the account
+func (s *AdminServer) GetPeer(ctx context.Context, req *adminProto.ResourceRequest) (*adminProto.Peer, error) {
+	account, user, err := s.authenticate(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	peer, err := s.accountManager.GetPeer(account.Id, req.GetId(), user.Id)
+	if err != nil {
+		return nil, mapAdminError(err)
+	}
+	return toAdminPeer(account, peer), nil
+}
+
+// UpdatePeer updates the name, the SSH and the login expiration settings of a peer
+func (s *AdminServer) UpdatePeer(ctx context.Context, req *adminProto.Peer) (*adminProto.Peer, error) {
+	account, user, err := s.authenticate(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	current := account.GetPeer(req.GetId())
+	if current == nil {
+		return nil, status.Errorf(codes.NotFound, "peer with ID %s not found", req.GetId())
+	}
+
+	peer, err := s.accountManager.UpdatePeer(account.Id, user.Id, &nbpeer.Peer{
+		ID:                     req.GetId(),
+		Name:                   req.GetName(),
+		SSHEnabled:             req.GetSshEnabled(),
+		LoginExpirationEnabled: req.GetLoginExpirationEnabled(),
+		Uplink:                 current.Uplink,
+	})
+	if err != nil {
+		return nil, mapAdminError(err)
+	}
+	return toAdminPeer(account, peer), nil
+}
+
+// DeletePeer deletes a peer of the account
+func (s *AdminServer) DeletePeer(ctx context.Context, req *adminProto.ResourceRequest) (*adminProto.Empty, error) {
+	account, user, err := s.authenticate(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.accountManager.DeletePeer(account.Id, req.GetId(), user.Id); err != nil {
+		return nil, mapAdminError(err)
+	}
+	return &adminProto.Empty{}, nil
+}
+
+// ListGroups streams the groups of the account
+func (s *AdminServer) ListGroups(_ *adminProto.ListRequest, srv adminProto.AdminService_ListGroupsServer) error {
+	account, user, err := s.authenticate(srv.Context(), false)
+	if err != nil {
+		return err
+	}
+
+	groups, err := s.accountManager.GetAllGroups(account.Id, user.Id)
+	if err != nil {
+		return mapAdminError(err)
+	}
+
+	for _, group := range groups {
+		if err := srv.Send(toAdminGroup(group)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetGroup returns a group of the account
+func (s *AdminServer) GetGroup(ctx context.Context, req *adminProto.ResourceRequest) (*adminProto.Group, error) {
+	account, user, err := s.authenticate(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := s.accountManager.GetGroup(account.Id, req.GetId(), user.Id)
+	if err != nil {
+		return nil, mapAdminError(err)
+	}
+	return toAdminGroup(group), nil
+}
+
+// CreateGroup creates a group issued by the API
+func (s *AdminServer) CreateGroup(ctx context.Context, req *adminProto.Group) (*adminProto.Group, error) {
+	account, user, err := s.authenticate(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetName() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "group name shouldn't be empty")
+	}
+
+	group := &nbgroup.Group{
+		Name:   req.GetName(),
+		Peers:  append(make([]string, 0, len(req.GetPeers())), req.GetPeers()...),
+		Issued: nbgroup.GroupIssuedAPI,
+	}
+	if err := s.accountManager.SaveGroup(account.Id, user.Id, group); err != nil {
+		return nil, mapAdminError(err)
+	}
+	return toAdminGroup(group), nil
+}
+
+// UpdateGroup updates the name and the peers of a group
+func (s *AdminServer) UpdateGroup(ctx context.Context, req *adminProto.Group) (*adminProto.Group, error) {
+	account, user, err := s.authenticate(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	existingGroup, ok := account.Groups[req.GetId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "couldn't find group with ID %s", req.GetId())
+	}
+
+	allGroup, err := account.GetGroupAll()
+	if err != nil {
+		return nil, mapAdminError(err)
+	}
+	if allGroup.ID == existingGroup.ID {
+		return nil, status.Errorf(codes.InvalidArgument, "updating group ALL is not allowed")
+	}
+
+	if req.GetName() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "group name shouldn't be empty")
+	}
+
+	group := UpdatedGroup(existingGroup, req.GetName(), req.GetPeers())
+	if err := s.accountManager.SaveGroup(account.Id, user.Id, group); err != nil {
+		return nil, mapAdminError(err)
+	}
+	return toAdminGroup(group), nil
+}
+
+// DeleteGroup deletes a group of the account
+func (s *AdminServer) DeleteGroup(ctx context.Context, req *adminProto.ResourceRequest) (*adminProto.Empty, error) {
+	account, user, err := s.authenticate(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.accountManager.DeleteGroup(account.Id, user.Id, req.GetId()); err != nil {
+		return nil, mapAdminError(err)
+	}
+	return &adminProto.Empty{}, nil
+}
+
+// ListPolicies streams the policies of the account
+func (s *AdminServer) ListPolicies(_ *adminProto.ListRequest, srv adminProto.AdminService_ListPoliciesServer) error {
+	account, user, err := s.authenticate(srv.Context(), false)
+	if err != nil {
+		return err
+	}
+
+	policies, err := s.accountManager.ListPolicies(account.Id, user.Id)
+	if err != nil {
+		return mapAdminError(err)
+	}
+
+	for _, policy := range policies {
+		if err := srv.Send(toAdminPolicy(policy)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPolicy returns a policy of the account
+func (s *AdminServer) GetPolicy(ctx context.Context, req *adminProto.ResourceRequest) (*adminProto.Policy, error) {
+	account, user, err := s.authenticate(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.accountManager.GetPolicy(account.Id, req.GetId(), user.Id)
+	if err != nil {
+		return nil, mapAdminError(err)
+	}
+	return toAdminPolicy(policy), nil
+}
+
+// CreatePolicy creates a policy
+func (s *AdminServer) CreatePolicy(ctx context.Context, req *adminProto.Policy) (*adminProto.Policy, error) {
+	return s.savePolicy(ctx, req, "")
+}
+
+// UpdatePolicy updates a policy of the account
+func (s *AdminServer) UpdatePolicy(ctx context.Context, req *adminProto.Policy) (*adminProto.Policy, error) {
+	if req.GetId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "policy ID can't be empty")
+	}
+	return s.savePolicy(ctx, req, req.GetId())
+}
+
+func (s *AdminServer) savePolicy(ctx context.Context, req *adminProto.Policy, policyID string) (*adminProto.Policy, error) {
+	account, user, err := s.authenticate(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if policyID != "" && !policyExists(account, policyID) {
+		return nil, status.Errorf(codes.NotFound, "couldn't find policy with ID %s", policyID)
+	}
+
+	policy, err := toPolicy(account, req, policyID)
+	if err != nil {
+		return nil, mapAdminError(err)
+	}
+
+	if err := s.accountManager.SavePolicy(account.Id, user.Id, policy); err != nil {
+		return nil, mapAdminError(err)
+	}
+	return toAdminPolicy(policy), nil
+}
+
+// DeletePolicy deletes a policy of the account
+func (s *AdminServer) DeletePolicy(ctx context.Context, req *adminProto.ResourceRequest) (*adminProto.Empty, error) {
+	account, user, err := s.authenticate(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.accountManager.DeletePolicy(account.Id, req.GetId(), user.Id); err != nil {
+		return nil, mapAdminError(err)
+	}
+	return &adminProto.Empty{}, nil
+}
+
+// ListRoutes streams the routes of the account
+func (s *AdminServer) ListRoutes(_ *adminProto.ListRequest, srv adminProto.AdminService_ListRoutesServer) error {
+	account, user, err := s.authenticate(srv.Context(), false)
+	if err != nil {
+		return err
+	}
+
+	routes, err := s.accountManager.ListRoutes(account.Id, user.Id)
+	if err != nil {
+		return mapAdminError(err)
+	}
+
+	for _, r := range routes {
+		if err := srv.Send(toAdminRoute(r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetRoute returns a route of the account
+func (s *AdminServer) GetRoute(ctx context.Context, req *adminProto.ResourceRequest) (*adminProto.Route, error) {
+	account, user, err := s.authenticate(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := s.accountManager.GetRoute(account.Id, route.ID(req.GetId()), user.Id)
+	if err != nil {
+		return nil, mapAdminError(err)
+	}
+	return toAdminRoute(r), nil
+}
+
+// CreateRoute creates a route
+func (s *AdminServer) CreateRoute(ctx context.Context, req *adminProto.Route) (*adminProto.Route, error) {
+	account, user, err := s.authenticate(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateAdminRoute(account, req); err != nil {
+		return nil, mapAdminError(err)
+	}
+
+	newRoute, err := s.accountManager.CreateRoute(
+		account.Id, req.GetNetwork(), req.GetDomains(), req.GetKeepRoute(), req.GetPeer(), req.GetPeerGroups(),
+		req.GetDescription(), route.NetID(req.GetNetworkId()), req.GetMasquerade(), int(req.GetMetric()),
+		int(req.GetWeight()), req.GetGroups(), req.GetEnabled(), user.Id,
+	)
+	if err != nil {
+		return nil, mapAdminError(err)
+	}
+	return toAdminRoute(newRoute), nil
+}
+
+// UpdateRoute updates a route of the account
+func (s *AdminServer) UpdateRoute(ctx context.Context, req *adminProto.Route) (*adminProto.Route, error) {
+	account, user, err := s.authenticate(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := account.Routes[route.ID(req.GetId())]; !ok {
+		return nil, status.Errorf(codes.NotFound, "couldn't find route for ID %s", req.GetId())
+	}
+
+	if err := validateAdminRoute(account, req); err != nil {
+		return nil, mapAdminError(err)
+	}
+
+	newRoute := &route.Route{
+		ID:          route.ID(req.GetId()),
+		NetID:       route.NetID(req.GetNetworkId()),
+		Description: req.GetDescription(),
+		NetworkType: route.DomainNetwork,
+		Domains:     req.GetDomains(),
+		KeepRoute:   req.GetKeepRoute(),
+		Peer:        req.GetPeer(),
+		PeerGroups:  req.GetPeerGroups(),
+		Metric:      int(req.GetMetric()),
+		Weight:      int(req.GetWeight()),
+		Masquerade:  req.GetMasquerade(),
+		Enabled:     req.GetEnabled(),
+		Groups:      req.GetGroups(),
+	}
+	if req.GetNetwork() != "" {
+		newRoute.NetworkType, newRoute.Network, err = route.ParseNetwork(req.GetNetwork())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "couldn't parse update prefix %s for route ID %s", req.GetNetwork(), req.GetId())
+		}
+	}
+
+	if err := s.accountManager.SaveRoute(account.Id, user.Id, newRoute); err != nil {
+		return nil, mapAdminError(err)
+	}
+	return toAdminRoute(newRoute), nil
+}
+
+// DeleteRoute deletes a route of the account
+func (s *AdminServer) DeleteRoute(ctx context.Context, req *adminProto.ResourceRequest) (*adminProto.Empty, error) {
+	account, user, err := s.authenticate(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.accountManager.DeleteRoute(account.Id, route.ID(req.GetId()), user.Id); err != nil {
+		return nil, mapAdminError(err)
+	}
+	return &adminProto.Empty{}, nil
+}
+
+// validateAdminRoute checks the destination, the identifier and the routing peers of a route request
+func validateAdminRoute(account *Account, req *adminProto.Route) error {
+	if (req.GetNetwork() != "") == (len(req.GetDomains()) != 0) {
+		return internalStatus.Errorf(internalStatus.InvalidArgument, "either network or domains should be provided")
+	}
+
+	if utf8.RuneCountInString(req.GetNetworkId()) > route.MaxNetIDChar || req.GetNetworkId() == "" {
+		return internalStatus.Errorf(internalStatus.InvalidArgument, "identifier should be between 1 and %d", route.MaxNetIDChar)
+	}
+
+	if (req.GetPeer() != "") == (len(req.GetPeerGroups()) != 0) {
+		return internalStatus.Errorf(internalStatus.InvalidArgument, "only one peer or peer_groups should be provided")
+	}
+
+	// do not allow non Linux peers
+	if peer := account.GetPeer(req.GetPeer()); peer != nil && peer.Meta.GoOS != "linux" {
+		return internalStatus.Errorf(internalStatus.InvalidArgument, "non-linux peers are non supported as network routes")
+	}
+
+	return nil
+}
+
+// toPolicy converts a policy request to a policy, the groups and the posture checks unknown to the account are skipped
+func toPolicy(account *Account, req *adminProto.Policy, policyID string) (*Policy, error) {
+	if req.GetName() == "" {
+		return nil, internalStatus.Errorf(internalStatus.InvalidArgument, "policy name shouldn't be empty")
+	}
+
+	if len(req.GetRules()) == 0 {
+		return nil, internalStatus.Errorf(internalStatus.InvalidArgument, "policy rules shouldn't be empty")
+	}
+
+	if policyID == "" {
+		policyID = xid.New().String()
+	}
+
+	policy := &Policy{
+		ID:          policyID,
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+		Enabled:     req.GetEnabled(),
+	}
+
+	for _, r := range req.GetRules() {
+		rule := &PolicyRule{
+			ID:            policyID,
+			Name:          r.GetName(),
+			Description:   r.GetDescription(),
+			Enabled:       r.GetEnabled(),
+			Action:        PolicyTrafficActionType(r.GetAction()),
+			Bidirectional: r.GetBidirectional(),
+			Protocol:      PolicyRuleProtocolType(r.GetProtocol()),
+			Ports:         r.GetPorts(),
+			Sources:       existingGroups(account, r.GetSources()),
+			Destinations:  existingGroups(account, r.GetDestinations()),
+		}
+
+		switch rule.Action {
+		case PolicyTrafficActionAccept, PolicyTrafficActionDrop:
+		default:
+			return nil, internalStatus.Errorf(internalStatus.InvalidArgument, "unknown action type")
+		}
+
+		switch rule.Protocol {
+		case PolicyRuleProtocolALL, PolicyRuleProtocolTCP, PolicyRuleProtocolUDP, PolicyRuleProtocolICMP:
+		default:
+			return nil, internalStatus.Errorf(internalStatus.InvalidArgument, "unknown protocol type: %v", rule.Protocol)
+		}
+
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+
+		policy.Rules = append(policy.Rules, rule)
+	}
+
+	for _, id := range req.GetSourcePostureChecks() {
+		for _, checks := range account.PostureChecks {
+			if checks.ID == id {
+				policy.SourcePostureChecks = append(policy.SourcePostureChecks, id)
+				break
+			}
+		}
+	}
+
+	return policy, nil
+}
+
+func policyExists(account *Account, policyID string) bool {
+	for _, policy := range account.Policies {
+		if policy.ID == policyID {
+			return true
+		}
+	}
+	return false
+}
+
+// existingGroups returns the IDs of the groups of the account among the given IDs
+func existingGroups(account *Account, groupIDs []string) []string {
+	result := make([]string, 0, len(groupIDs))
+	for _, id := range groupIDs {
+		if _, ok := account.Groups[id]; ok {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func toAdminPeer(account *Account, peer *nbpeer.Peer) *adminProto.Peer {
+	osVersion := peer.Meta.OSVersion
+	if osVersion == "" {
+		osVersion = peer.Meta.Core
+	}
+
+	p := &adminProto.Peer{
+		Id:                     peer.ID,
+		Name:                   peer.Name,
+		Ip:                     peer.IP.String(),
+		DnsLabel:               peer.DNSLabel,
+		Os:                     strings.TrimSpace(peer.Meta.OS + " " + osVersion),
+		Version:                peer.Meta.WtVersion,
+		Hostname:               peer.Meta.Hostname,
+		SshEnabled:             peer.SSHEnabled,
+		LoginExpirationEnabled: peer.LoginExpirationEnabled,
+	}
+	if peer.Status != nil {
+		p.Connected = peer.Status.Connected
+		p.LastSeen = timestamppb.New(peer.Status.LastSeen)
+	}
+
+	for _, group := range account.Groups {
+		for _, id := range group.Peers {
+			if id == peer.ID {
+				p.Groups = append(p.Groups, group.ID)
+				break
+			}
+		}
+	}
+
+	return p
+}
+
+func toAdminGroup(group *nbgroup.Group) *adminProto.Group {
+	return &adminProto.Group{
+		Id:     group.ID,
+		Name:   group.Name,
+		Issued: group.Issued,
+		Peers:  group.Peers,
+	}
+}
+
+func toAdminPolicy(policy *Policy) *adminProto.Policy {
+	p := &adminProto.Policy{
+		Id:                  policy.ID,
+		Name:                policy.Name,
+		Description:         policy.Description,
+		Enabled:             policy.Enabled,
+		SourcePostureChecks: policy.SourcePostureChecks,
+	}
+	for _, r := range policy.Rules {
+		p.Rules = append(p.Rules, &adminProto.PolicyRule{
+			Id:            r.ID,
+			Name:          r.Name,
+			Description:   r.Description,
+			Enabled:       r.Enabled,
+			Action:        string(r.Action),
+			Bidirectional: r.Bidirectional,
+			Protocol:      string(r.Protocol),
+			Ports:         r.Ports,
+			Sources:       r.Sources,
+			Destinations:  r.Destinations,
+		})
+	}
+	return p
+}
+
+func toAdminRoute(r *route.Route) *adminProto.Route {
+	ar := &adminProto.Route{
+		Id:          string(r.ID),
+		NetworkId:   string(r.NetID),
+		Description: r.Description,
+		Domains:     r.Domains,
+		KeepRoute:   r.KeepRoute,
+		Peer:        r.Peer,
+		PeerGroups:  r.PeerGroups,
+		Metric:      int32(r.Metric),
+		Weight:      int32(r.Weight),
+		Masquerade:  r.Masquerade,
+		Enabled:     r.Enabled,
+		Groups:      r.Groups,
+	}
+	if !r.IsDynamic() && r.Network != (netip.Prefix{}) {
+		ar.Network = r.Network.String()
+	}
+	return ar
+}
+
+// mapAdminError maps the internal errors of the account manager to the gRPC errors of the admin API
+func mapAdminError(err error) error {
+	var linkErr *GroupLinkError
+	if errors.As(err, &linkErr) {
+		return status.Errorf(codes.FailedPrecondition, linkErr.Error())
+	}
+	if e, ok := internalStatus.FromError(err); ok {
+		switch e.Type() {
+		case internalStatus.InvalidArgument:
+			return status.Errorf(codes.InvalidArgument, e.Message)
+		case internalStatus.AlreadyExists:
+			return status.Errorf(codes.AlreadyExists, e.Message)
+		}
+	}
+	return mapError(err)
+}
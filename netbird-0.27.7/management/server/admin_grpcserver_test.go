@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	adminProto "github.com/netbirdio/netbird/management/admin/proto"
+)
+
+func startAdminServer(t *testing.T, manager *DefaultAccountManager) adminProto.AdminServiceClient {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	adminServer, err := NewAdminServer(&Config{}, manager)
+	require.NoError(t, err)
+
+	s := grpc.NewServer()
+	adminProto.RegisterAdminServiceServer(s, adminServer)
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	t.Cleanup(s.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return adminProto.NewAdminServiceClient(conn)
+}
+
+func TestAdminServer(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err, "unable to create an account")
+
+	account.Users["regular"] = NewRegularUser("regular")
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	adminPAT, err := manager.CreatePAT(account.Id, userID, userID, "admin", 30)
+	require.NoError(t, err)
+	regularPAT, err := manager.CreatePAT(account.Id, "regular", "regular", "regular", 30)
+	require.NoError(t, err)
+
+	client := startAdminServer(t, manager)
+	adminCtx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Token "+adminPAT.PlainToken)
+	regularCtx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Token "+regularPAT.PlainToken)
+
+	t.Run("requests without a valid token are rejected", func(t *testing.T) {
+		_, err := client.GetGroup(context.Background(), &adminProto.ResourceRequest{Id: "any"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Token nbp_invalid")
+		_, err = client.GetGroup(ctx, &adminProto.ResourceRequest{Id: "any"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer some.jwt.token")
+		_, err = client.GetGroup(ctx, &adminProto.ResourceRequest{Id: "any"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err), "JWTs can't be validated without an IdP")
+	})
+
+	t.Run("only users with admin power can modify", func(t *testing.T) {
+		_, err := client.CreateGroup(regularCtx, &adminProto.Group{Name: "devs"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	var group *adminProto.Group
+	t.Run("manages the groups", func(t *testing.T) {
+		_, err := client.CreateGroup(adminCtx, &adminProto.Group{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), "the group name is required")
+
+		group, err = client.CreateGroup(adminCtx, &adminProto.Group{Name: "devs"})
+		require.NoError(t, err)
+		assert.NotEmpty(t, group.Id)
+		assert.Equal(t, "api", group.Issued)
+
+		group.Name = "developers"
+		_, err = client.UpdateGroup(adminCtx, group)
+		require.NoError(t, err)
+
+		stream, err := client.ListGroups(adminCtx, &adminProto.ListRequest{})
+		require.NoError(t, err)
+		names := map[string]string{}
+		for {
+			g, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			names[g.Id] = g.Name
+		}
+		assert.Len(t, names, 2, "the All group and the created group should be streamed")
+		assert.Equal(t, "developers", names[group.Id])
+	})
+
+	t.Run("manages the policies", func(t *testing.T) {
+		policy := &adminProto.Policy{
+			Name:    "devs",
+			Enabled: true,
+			Rules: []*adminProto.PolicyRule{{
+				Name: "devs", Enabled: true, Action: "accept", Protocol: "any", Bidirectional: true,
+				Sources: []string{group.Id}, Destinations: []string{group.Id, "missing"},
+			}},
+		}
+		_, err := client.CreatePolicy(adminCtx, policy)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), "the protocol is unknown")
+
+		policy.Rules[0].Protocol = "tcp"
+		policy.Rules[0].Ports = []string{"70000"}
+		_, err = client.CreatePolicy(adminCtx, policy)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), "the port is out of range")
+
+		policy.Rules[0].Ports = []string{"22"}
+		created, err := client.CreatePolicy(adminCtx, policy)
+		require.NoError(t, err)
+		assert.Equal(t, []string{group.Id}, created.Rules[0].Destinations, "the unknown groups should be skipped")
+
+		got, err := client.GetPolicy(adminCtx, &adminProto.ResourceRequest{Id: created.Id})
+		require.NoError(t, err)
+		assert.Equal(t, "devs", got.Name)
+
+		_, err = client.DeleteGroup(adminCtx, &adminProto.ResourceRequest{Id: group.Id})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err), "a group linked to a policy can't be deleted")
+
+		_, err = client.DeletePolicy(adminCtx, &adminProto.ResourceRequest{Id: created.Id})
+		require.NoError(t, err)
+		_, err = client.GetPolicy(adminCtx, &adminProto.ResourceRequest{Id: created.Id})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("validates the routes", func(t *testing.T) {
+		_, err := client.CreateRoute(adminCtx, &adminProto.Route{NetworkId: "office", Groups: []string{group.Id}, PeerGroups: []string{group.Id}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), "the route needs a network or domains")
+
+		_, err = client.CreateRoute(adminCtx, &adminProto.Route{NetworkId: "office", Network: "10.0.0.0/24", Groups: []string{group.Id}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), "the route needs a peer or peer groups")
+	})
+}
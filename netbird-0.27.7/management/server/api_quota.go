@@ -0,0 +1,159 @@
+package server
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/status"
+	"github.com/netbirdio/netbird/management/server/telemetry"
+)
+
+// The periods of the API quotas
+const (
+	APIQuotaPeriodMinute = "minute"
+	APIQuotaPeriodDay    = "day"
+)
+
+// APIUsage is the number of HTTP API requests of an account in the current minute and UTC day against its quota
+type APIUsage struct {
+	Quota          APIQuota
+	MinuteRequests int
+	DayRequests    int
+	// MinuteResetsAt and DayResetsAt are when the counts of the current minute and day start over
+	MinuteResetsAt time.Time
+	DayResetsAt    time.Time
+	// TotalRequests and RejectedRequests are the requests served and rejected since the management service started
+	TotalRequests    int64
+	RejectedRequests int64
+}
+
+// apiUsage counts the HTTP API requests of an account in fixed windows
+type apiUsage struct {
+	minute           time.Time
+	minuteRequests   int
+	day              time.Time
+	dayRequests      int
+	totalRequests    int64
+	rejectedRequests int64
+}
+
+// roll starts the counts over when the minute or the day of now differs from the ones counted
+func (u *apiUsage) roll(now time.Time) {
+	minute := now.Truncate(time.Minute)
+	if !u.minute.Equal(minute) {
+		u.minute = minute
+		u.minuteRequests = 0
+	}
+	day := now.Truncate(24 * time.Hour)
+	if !u.day.Equal(day) {
+		u.day = day
+		u.dayRequests = 0
+	}
+}
+
+// quota returns the quota of the account, the default one when the account has none
+func (c *APIQuotasConfig) quota(accountID string) APIQuota {
+	if quota, ok := c.Accounts[accountID]; ok {
+		return quota
+	}
+	return c.Default
+}
+
+// EnableAPIQuotas makes the account manager meter the HTTP API requests of the accounts and reject the ones exceeding
+// the quotas of the config. The metrics can be nil
+func (am *DefaultAccountManager) EnableAPIQuotas(config *APIQuotasConfig, metrics *telemetry.APIQuotaMetrics) {
+	am.apiQuotas = config
+	am.apiQuotaMetrics = metrics
+}
+
+// CountAPIRequest counts an HTTP API request of the account of the claims and returns a ResourceExhausted error when
+// the account exceeded its quota of the current minute or day. The requests aren't counted when the quotas aren't
+// enabled
+func (am *DefaultAccountManager) CountAPIRequest(claims jwtclaims.AuthorizationClaims) error {
+	if am.apiQuotas == nil {
+		return nil
+	}
+
+	accountID, _, err := am.GetAccountIDFromToken(claims)
+	if err != nil {
+		// the handler of the request fails on the unknown account
+		log.Debugf("failed to get the account of the API request of user %s: %v", claims.UserId, err)
+		return nil
+	}
+	quota := am.apiQuotas.quota(accountID)
+
+	am.apiUsageMux.Lock()
+	defer am.apiUsageMux.Unlock()
+
+	usage, ok := am.apiUsage[accountID]
+	if !ok {
+		usage = &apiUsage{}
+		am.apiUsage[accountID] = usage
+	}
+	usage.roll(time.Now().UTC())
+
+	var period string
+	var limit int
+	switch {
+	case quota.RequestsPerMinute > 0 && usage.minuteRequests >= quota.RequestsPerMinute:
+		period, limit = APIQuotaPeriodMinute, quota.RequestsPerMinute
+	case quota.RequestsPerDay > 0 && usage.dayRequests >= quota.RequestsPerDay:
+		period, limit = APIQuotaPeriodDay, quota.RequestsPerDay
+	}
+	if period != "" {
+		usage.rejectedRequests++
+		if am.apiQuotaMetrics != nil {
+			am.apiQuotaMetrics.CountRejectedRequest(accountID, period)
+		}
+		return status.Errorf(status.ResourceExhausted, "the account exceeded its quota of %d API requests per %s", limit, period)
+	}
+
+	usage.minuteRequests++
+	usage.dayRequests++
+	usage.totalRequests++
+	if am.apiQuotaMetrics != nil {
+		am.apiQuotaMetrics.CountRequest(accountID)
+	}
+	return nil
+}
+
+// GetAPIUsage returns the HTTP API requests of the account against its quota. Only users with admin power can view it.
+func (am *DefaultAccountManager) GetAPIUsage(accountID, userID string) (*APIUsage, error) {
+	user, err := am.Store.GetUserByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.AccountID != accountID {
+		return nil, status.Errorf(status.PermissionDenied, "user %s is not part of the account %s", userID, accountID)
+	}
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view the API usage")
+	}
+
+	if am.apiQuotas == nil {
+		return nil, status.Errorf(status.PreconditionFailed, "the API requests aren't metered")
+	}
+
+	now := time.Now().UTC()
+	am.apiUsageMux.Lock()
+	defer am.apiUsageMux.Unlock()
+
+	usage, ok := am.apiUsage[accountID]
+	if !ok {
+		usage = &apiUsage{}
+	}
+	counted := *usage
+	counted.roll(now)
+
+	return &APIUsage{
+		Quota:            am.apiQuotas.quota(accountID),
+		MinuteRequests:   counted.minuteRequests,
+		DayRequests:      counted.dayRequests,
+		MinuteResetsAt:   counted.minute.Add(time.Minute),
+		DayResetsAt:      counted.day.Add(24 * time.Hour),
+		TotalRequests:    counted.totalRequests,
+		RejectedRequests: counted.rejectedRequests,
+	}, nil
+}
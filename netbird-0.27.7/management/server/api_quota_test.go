@@ -0,0 +1,60 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestDefaultAccountManager_CountAPIRequest(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err, "unable to create an account")
+	claims := jwtclaims.AuthorizationClaims{UserId: userID}
+
+	require.NoError(t, manager.CountAPIRequest(claims), "the requests shouldn't be counted without quotas")
+	_, err = manager.GetAPIUsage(account.Id, userID)
+	sErr, ok := status.FromError(err)
+	require.True(t, ok, "expected a status error, got %v", err)
+	assert.Equal(t, status.PreconditionFailed, sErr.Type())
+
+	manager.EnableAPIQuotas(&APIQuotasConfig{
+		Default:  APIQuota{RequestsPerMinute: 1},
+		Accounts: map[string]APIQuota{account.Id: {RequestsPerMinute: 100, RequestsPerDay: 2}},
+	}, nil)
+
+	require.NoError(t, manager.CountAPIRequest(claims))
+	require.NoError(t, manager.CountAPIRequest(claims))
+	requireResourceExhausted(t, manager.CountAPIRequest(claims))
+
+	usage, err := manager.GetAPIUsage(account.Id, userID)
+	require.NoError(t, err, "unable to get the API usage")
+	assert.Equal(t, APIQuota{RequestsPerMinute: 100, RequestsPerDay: 2}, usage.Quota, "the account should have its own quota")
+	assert.Equal(t, 2, usage.DayRequests)
+	assert.Equal(t, int64(2), usage.TotalRequests)
+	assert.Equal(t, int64(1), usage.RejectedRequests)
+	assert.True(t, usage.MinuteResetsAt.After(time.Now()), "the minute should reset in the future")
+	assert.Equal(t, time.Duration(0), usage.DayResetsAt.Sub(usage.DayResetsAt.Truncate(24*time.Hour)),
+		"the day should reset at midnight UTC")
+}
+
+func TestAPIUsage_Roll(t *testing.T) {
+	now := time.Date(2024, 5, 1, 23, 59, 30, 0, time.UTC)
+	usage := &apiUsage{}
+	usage.roll(now)
+	usage.minuteRequests, usage.dayRequests = 3, 10
+
+	usage.roll(now.Add(10 * time.Second))
+	assert.Equal(t, 3, usage.minuteRequests, "the minute count should be kept within the minute")
+	assert.Equal(t, 10, usage.dayRequests, "the day count should be kept within the day")
+
+	usage.roll(now.Add(time.Minute))
+	assert.Equal(t, 0, usage.minuteRequests, "the minute count should start over")
+	assert.Equal(t, 0, usage.dayRequests, "the day count should start over at midnight UTC")
+}
@@ -0,0 +1,355 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/rs/xid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// ClaimGroupMapping maps a claim of the JWT of the users to groups. The peers of a user whose JWT has the claim with one
+// of the values of an enabled mapping are added to its groups when the user logs them in, and removed from them when
+// the claim no longer matches at a later login, e.g. after a token refresh
+type ClaimGroupMapping struct {
+	// ID of the claim group mapping
+	ID string `gorm:"primaryKey"`
+	// AccountID is a reference to Account that this object belongs
+	AccountID string `gorm:"index"`
+	// Name of the claim group mapping
+	Name string
+	// Claim is the name of the JWT claim, e.g. department or team
+	Claim string
+	// Values are the claim values the mapping matches, a claim holding a list matches when one of its items does
+	Values []string `gorm:"serializer:json"`
+	// Groups are the IDs of the groups the peers of the matching users are added to
+	Groups []string `gorm:"serializer:json"`
+	// Enabled tells whether the mapping is evaluated at login
+	Enabled bool
+}
+
+// EventMeta returns activity event meta related to the claim group mapping
+func (m *ClaimGroupMapping) EventMeta() map[string]any {
+	return map[string]any{"name": m.Name, "claim": m.Claim}
+}
+
+// Copy copies a claim group mapping object
+func (m *ClaimGroupMapping) Copy() *ClaimGroupMapping {
+	c := *m
+	c.Values = slices.Clone(m.Values)
+	c.Groups = slices.Clone(m.Groups)
+	return &c
+}
+
+// matches tells whether the claims have the claim of the mapping with one of its values
+func (m *ClaimGroupMapping) matches(claims map[string]interface{}) bool {
+	claim, ok := claims[m.Claim]
+	if !ok {
+		return false
+	}
+
+	items, ok := claim.([]interface{})
+	if !ok {
+		items = []interface{}{claim}
+	}
+	for _, item := range items {
+		if slices.Contains(m.Values, fmt.Sprint(item)) {
+			return true
+		}
+	}
+	return false
+}
+
+// getClaimGroups returns the sorted IDs of the existing groups of the enabled claim group mappings the claims match
+func (a *Account) getClaimGroups(claims map[string]interface{}) []string {
+	groups := make(lookupMap)
+	for _, mapping := range a.ClaimGroupMappings {
+		if !mapping.Enabled || !mapping.matches(claims) {
+			continue
+		}
+		for _, groupID := range mapping.Groups {
+			if _, ok := a.Groups[groupID]; ok {
+				groups[groupID] = struct{}{}
+			}
+		}
+	}
+
+	groupIDs := make([]string, 0, len(groups))
+	for groupID := range groups {
+		groupIDs = append(groupIDs, groupID)
+	}
+	sort.Strings(groupIDs)
+	return groupIDs
+}
+
+// SyncUserClaimGroups evaluates the claim group mappings of the account against the claims of the JWT the user logged in
+// a peer with. The peers of the user are added to the groups of the mappings that match and removed from the groups of
+// the ones that matched at the previous login, except for the auto groups of the user.
+func (am *DefaultAccountManager) SyncUserClaimGroups(accountID string, claims jwtclaims.AuthorizationClaims) error {
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	user, err := account.FindUser(claims.UserId)
+	if err != nil {
+		return err
+	}
+
+	claimGroups := account.getClaimGroups(claims.Raw)
+	if slices.Equal(claimGroups, user.ClaimGroups) {
+		return nil
+	}
+
+	addedGroups := difference(claimGroups, user.ClaimGroups)
+	removedGroups := difference(difference(user.ClaimGroups, claimGroups), user.AutoGroups)
+	user.ClaimGroups = claimGroups
+	account.UserGroupsAddToPeers(user.Id, addedGroups...)
+	account.UserGroupsRemoveFromPeers(user.Id, removedGroups...)
+
+	account.Network.IncSerial()
+	if err := am.Store.SaveAccount(account); err != nil {
+		return err
+	}
+
+	am.updateAccountPeers(account)
+
+	for _, peer := range account.Peers {
+		if peer.UserID != user.Id {
+			continue
+		}
+		am.storeClaimGroupPeerEvents(account, peer, user.Id, activity.GroupAddedToPeer, addedGroups)
+		am.storeClaimGroupPeerEvents(account, peer, user.Id, activity.GroupRemovedFromPeer, removedGroups)
+	}
+
+	log.Debugf("claim group mappings assigned groups %v to the peers of user %s", claimGroups, user.Id)
+
+	return nil
+}
+
+// storeClaimGroupPeerEvents records the groups the claim group mappings added the peer to or removed it from
+func (am *DefaultAccountManager) storeClaimGroupPeerEvents(account *Account, peer *nbpeer.Peer, userID string, activityID activity.Activity, groupIDs []string) {
+	for _, groupID := range groupIDs {
+		group := account.GetGroup(groupID)
+		if group == nil {
+			continue
+		}
+		am.StoreEvent(userID, peer.ID, account.Id, activityID,
+			map[string]any{
+				"group": group.Name, "group_id": group.ID, "peer_ip": peer.IP.String(),
+				"peer_fqdn": peer.FQDN(am.GetDNSDomain()), "claim_mapping": true,
+			})
+	}
+}
+
+// GetClaimGroupMapping gets a claim group mapping object from account and mapping IDs
+func (am *DefaultAccountManager) GetClaimGroupMapping(accountID, userID, mappingID string) (*ClaimGroupMapping, error) {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view claim group mappings")
+	}
+
+	mapping, found := account.ClaimGroupMappings[mappingID]
+	if found {
+		return mapping.Copy(), nil
+	}
+
+	return nil, status.Errorf(status.NotFound, "claim group mapping with ID %s not found", mappingID)
+}
+
+// CreateClaimGroupMapping validates and saves a new claim group mapping, it applies to the users from their next login
+func (am *DefaultAccountManager) CreateClaimGroupMapping(accountID, userID string, mapping *ClaimGroupMapping) (*ClaimGroupMapping, error) {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	if mapping == nil {
+		return nil, status.Errorf(status.InvalidArgument, "claim group mapping provided is nil")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	newMapping := mapping.Copy()
+	newMapping.ID = xid.New().String()
+	newMapping.AccountID = accountID
+
+	err = validateClaimGroupMapping(false, newMapping, account)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.ClaimGroupMappings == nil {
+		account.ClaimGroupMappings = make(map[string]*ClaimGroupMapping)
+	}
+
+	account.ClaimGroupMappings[newMapping.ID] = newMapping
+
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return nil, err
+	}
+
+	am.StoreEvent(userID, newMapping.ID, accountID, activity.ClaimGroupMappingCreated, newMapping.EventMeta())
+
+	return newMapping.Copy(), nil
+}
+
+// SaveClaimGroupMapping validates and updates an existing claim group mapping, it applies to the users from their next
+// login
+func (am *DefaultAccountManager) SaveClaimGroupMapping(accountID, userID string, mappingToSave *ClaimGroupMapping) error {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	if mappingToSave == nil {
+		return status.Errorf(status.InvalidArgument, "claim group mapping provided is nil")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	mapping := mappingToSave.Copy()
+	mapping.AccountID = accountID
+
+	err = validateClaimGroupMapping(true, mapping, account)
+	if err != nil {
+		return err
+	}
+
+	account.ClaimGroupMappings[mapping.ID] = mapping
+
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return err
+	}
+
+	am.StoreEvent(userID, mapping.ID, accountID, activity.ClaimGroupMappingUpdated, mapping.EventMeta())
+
+	return nil
+}
+
+// DeleteClaimGroupMapping deletes the claim group mapping with mappingID. The peers stay in its groups until the next
+// login of their users
+func (am *DefaultAccountManager) DeleteClaimGroupMapping(accountID, userID, mappingID string) error {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	mapping := account.ClaimGroupMappings[mappingID]
+	if mapping == nil {
+		return status.Errorf(status.NotFound, "claim group mapping %s wasn't found", mappingID)
+	}
+	delete(account.ClaimGroupMappings, mappingID)
+
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return err
+	}
+
+	am.StoreEvent(userID, mapping.ID, accountID, activity.ClaimGroupMappingDeleted, mapping.EventMeta())
+
+	return nil
+}
+
+// ListClaimGroupMappings returns a list of the claim group mappings from account
+func (am *DefaultAccountManager) ListClaimGroupMappings(accountID, userID string) ([]*ClaimGroupMapping, error) {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view claim group mappings")
+	}
+
+	mappings := make([]*ClaimGroupMapping, 0, len(account.ClaimGroupMappings))
+	for _, item := range account.ClaimGroupMappings {
+		mappings = append(mappings, item.Copy())
+	}
+
+	return mappings, nil
+}
+
+// validateClaimGroupMapping checks the claim group mapping against the account
+func validateClaimGroupMapping(existingMapping bool, mapping *ClaimGroupMapping, account *Account) error {
+	if existingMapping {
+		_, found := account.ClaimGroupMappings[mapping.ID]
+		if !found {
+			return status.Errorf(status.NotFound, "claim group mapping with ID %s was not found", mapping.ID)
+		}
+	}
+
+	if mapping.Name == "" || len(mapping.Name) > 40 {
+		return status.Errorf(status.InvalidArgument, "claim group mapping name should be between 1 and 40 characters")
+	}
+
+	for _, other := range account.ClaimGroupMappings {
+		if other.ID != mapping.ID && other.Name == mapping.Name {
+			return status.Errorf(status.InvalidArgument, "a claim group mapping with name %s already exists", mapping.Name)
+		}
+	}
+
+	if mapping.Claim == "" {
+		return status.Errorf(status.InvalidArgument, "claim group mapping %s should have a claim", mapping.Name)
+	}
+
+	if len(mapping.Values) == 0 {
+		return status.Errorf(status.InvalidArgument, "claim group mapping %s should have at least one claim value", mapping.Name)
+	}
+
+	if len(mapping.Groups) == 0 {
+		return status.Errorf(status.InvalidArgument, "claim group mapping %s should have at least one group", mapping.Name)
+	}
+	for _, groupID := range mapping.Groups {
+		group, ok := account.Groups[groupID]
+		if !ok {
+			return status.Errorf(status.InvalidArgument, "group %s doesn't exist", groupID)
+		}
+		if group.Name == "All" {
+			return status.Errorf(status.InvalidArgument, "claim group mapping %s can't assign the All group", mapping.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbgroup "github.com/netbirdio/netbird/management/server/group"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+func TestClaimGroupMapping_matches(t *testing.T) {
+	mapping := &ClaimGroupMapping{Claim: "team", Values: []string{"platform", "42"}}
+
+	testCases := []struct {
+		name     string
+		claims   map[string]interface{}
+		expected bool
+	}{
+		{name: "string claim", claims: map[string]interface{}{"team": "platform"}, expected: true},
+		{name: "list claim", claims: map[string]interface{}{"team": []interface{}{"sales", "platform"}}, expected: true},
+		{name: "number claim", claims: map[string]interface{}{"team": float64(42)}, expected: true},
+		{name: "other value", claims: map[string]interface{}{"team": "sales"}, expected: false},
+		{name: "values are case sensitive", claims: map[string]interface{}{"team": "Platform"}, expected: false},
+		{name: "missing claim", claims: map[string]interface{}{"department": "platform"}, expected: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, mapping.matches(testCase.claims))
+		})
+	}
+}
+
+func TestCreateClaimGroupMapping(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err, "unable to create an account")
+	require.NoError(t, manager.SaveGroup(account.Id, userID, &nbgroup.Group{ID: "eng", Name: "eng"}))
+	groupAll, err := account.GetGroupAll()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		mapping    *ClaimGroupMapping
+		shouldFail bool
+	}{
+		{
+			name:    "valid mapping",
+			mapping: &ClaimGroupMapping{Name: "eng", Claim: "department", Values: []string{"engineering"}, Groups: []string{"eng"}, Enabled: true},
+		},
+		{
+			name:       "duplicated name",
+			mapping:    &ClaimGroupMapping{Name: "eng", Claim: "team", Values: []string{"platform"}, Groups: []string{"eng"}},
+			shouldFail: true,
+		},
+		{
+			name:       "without claim",
+			mapping:    &ClaimGroupMapping{Name: "no claim", Values: []string{"platform"}, Groups: []string{"eng"}},
+			shouldFail: true,
+		},
+		{
+			name:       "without values",
+			mapping:    &ClaimGroupMapping{Name: "no values", Claim: "team", Groups: []string{"eng"}},
+			shouldFail: true,
+		},
+		{
+			name:       "unknown group",
+			mapping:    &ClaimGroupMapping{Name: "unknown", Claim: "team", Values: []string{"platform"}, Groups: []string{"missing"}},
+			shouldFail: true,
+		},
+		{
+			name:       "All group",
+			mapping:    &ClaimGroupMapping{Name: "all", Claim: "team", Values: []string{"platform"}, Groups: []string{groupAll.ID}},
+			shouldFail: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			mapping, err := manager.CreateClaimGroupMapping(account.Id, userID, testCase.mapping)
+			if testCase.shouldFail {
+				require.Error(t, err, "should fail creating the claim group mapping")
+				return
+			}
+			require.NoError(t, err, "should create the claim group mapping")
+			assert.NotEmpty(t, mapping.ID)
+
+			stored, err := manager.GetClaimGroupMapping(account.Id, userID, mapping.ID)
+			require.NoError(t, err)
+			assert.Equal(t, mapping, stored)
+
+			ev := getEvent(t, account.Id, manager, activity.ClaimGroupMappingCreated)
+			assert.Equal(t, mapping.ID, ev.TargetID)
+		})
+	}
+
+	err = manager.DeleteGroup(account.Id, userID, "eng")
+	require.Error(t, err, "a group used by a claim group mapping shouldn't be deleted")
+}
+
+func TestDefaultAccountManager_SyncUserClaimGroups(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err, "unable to create an account")
+
+	require.NoError(t, manager.SaveGroup(account.Id, userID, &nbgroup.Group{ID: "eng", Name: "eng"}))
+	require.NoError(t, manager.SaveGroup(account.Id, userID, &nbgroup.Group{ID: "sre", Name: "sre"}))
+	_, err = manager.CreateClaimGroupMapping(account.Id, userID, &ClaimGroupMapping{
+		Name: "engineering", Claim: "department", Values: []string{"engineering"}, Groups: []string{"eng"}, Enabled: true,
+	})
+	require.NoError(t, err)
+	_, err = manager.CreateClaimGroupMapping(account.Id, userID, &ClaimGroupMapping{
+		Name: "sre", Claim: "teams", Values: []string{"sre"}, Groups: []string{"sre"}, Enabled: true,
+	})
+	require.NoError(t, err)
+
+	claims := jwtclaims.AuthorizationClaims{
+		UserId: userID,
+		Raw:    jwt.MapClaims{"department": "engineering", "teams": []interface{}{"platform"}},
+	}
+	require.NoError(t, manager.SyncUserClaimGroups(account.Id, claims))
+
+	key, err := wgtypes.GenerateKey()
+	require.NoError(t, err, "unable to generate WireGuard key")
+	peer, _, err := manager.AddPeer("", userID, &nbpeer.Peer{
+		Key:  key.PublicKey().String(),
+		Meta: nbpeer.PeerSystemMeta{Hostname: "test-peer"},
+	})
+	require.NoError(t, err, "unable to add peer")
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"eng"}, account.Users[userID].ClaimGroups)
+	assert.Contains(t, account.Groups["eng"].Peers, peer.ID, "the peer should be added to the groups of the user's claims")
+	assert.NotContains(t, account.Groups["sre"].Peers, peer.ID)
+
+	// the claims changed at a token refresh
+	claims.Raw = jwt.MapClaims{"department": "sales", "teams": []interface{}{"platform", "sre"}}
+	require.NoError(t, manager.SyncUserClaimGroups(account.Id, claims))
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sre"}, account.Users[userID].ClaimGroups)
+	assert.NotContains(t, account.Groups["eng"].Peers, peer.ID, "the peer should be removed from the groups of the claims that no longer match")
+	assert.Contains(t, account.Groups["sre"].Peers, peer.ID)
+
+	ev := getEvent(t, account.Id, manager, activity.GroupRemovedFromPeer)
+	assert.Equal(t, peer.ID, ev.TargetID)
+	assert.Equal(t, "eng", ev.Meta["group_id"])
+}
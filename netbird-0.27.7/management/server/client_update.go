@@ -0,0 +1,105 @@
+package server
+
+import (
+	"regexp"
+	"slices"
+
+	goversion "github.com/hashicorp/go-version"
+
+	"github.com/netbirdio/netbird/management/proto"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// ClientUpdateChannelStable is the update channel followed by a peer whose groups follow different channels
+const ClientUpdateChannelStable = "stable"
+
+var clientUpdateChannelPattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// ClientUpdateSettings is the client version the groups of a peer set, either pinned or given by an update channel
+type ClientUpdateSettings struct {
+	Channel string
+	Version string
+}
+
+// EnableClientUpdates makes the account manager tell the auto updating peers the versions of the update channels of
+// the config when it pushes their network maps
+func (am *DefaultAccountManager) EnableClientUpdates(config *ClientUpdatesConfig) {
+	am.clientUpdates = config
+}
+
+func validateClientUpdate(channel, version string) error {
+	if channel != "" && version != "" {
+		return status.Errorf(status.InvalidArgument, "a group either pins a client version or follows an update channel")
+	}
+	if channel != "" && !clientUpdateChannelPattern.MatchString(channel) {
+		return status.Errorf(status.InvalidArgument, "invalid update channel %q, expected up to 32 lowercase letters, "+
+			"digits, dashes and underscores", channel)
+	}
+	if version != "" {
+		if _, err := goversion.NewVersion(version); err != nil {
+			return status.Errorf(status.InvalidArgument, "invalid client version %q: %v", version, err)
+		}
+	}
+	return nil
+}
+
+// getPeerClientUpdate returns the client version the groups of the peer set. A version pinned by a group takes
+// precedence over the channels, the lowest one when the groups pin several. Of several channels the stable one is
+// followed, otherwise the first by name, so a peer only moves ahead of the others when all of its groups agree.
+func (a *Account) getPeerClientUpdate(peerID string) ClientUpdateSettings {
+	var (
+		settings ClientUpdateSettings
+		pinned   *goversion.Version
+	)
+	for _, group := range a.Groups {
+		if (group.ClientUpdateChannel == "" && group.ClientVersion == "") || !slices.Contains(group.Peers, peerID) {
+			continue
+		}
+
+		if group.ClientVersion != "" {
+			v, err := goversion.NewVersion(group.ClientVersion)
+			if err != nil {
+				continue
+			}
+			if pinned == nil || v.LessThan(pinned) {
+				pinned = v
+				settings.Version = group.ClientVersion
+			}
+			continue
+		}
+
+		switch {
+		case settings.Channel == "", group.ClientUpdateChannel == ClientUpdateChannelStable:
+			settings.Channel = group.ClientUpdateChannel
+		case settings.Channel != ClientUpdateChannelStable && group.ClientUpdateChannel < settings.Channel:
+			settings.Channel = group.ClientUpdateChannel
+		}
+	}
+
+	if settings.Version != "" {
+		settings.Channel = ""
+	}
+	return settings
+}
+
+// toClientUpdateConfig resolves the client version of a peer with the update channels of the config, nil when the
+// peer has no version to update to
+func toClientUpdateConfig(config *ClientUpdatesConfig, settings ClientUpdateSettings) *proto.ClientUpdateConfig {
+	if config == nil || config.URL == "" {
+		return nil
+	}
+
+	version := settings.Version
+	if version == "" {
+		version = config.Channels[settings.Channel]
+	}
+	if version == "" {
+		return nil
+	}
+
+	return &proto.ClientUpdateConfig{
+		Version: version,
+		Channel: settings.Channel,
+		Url:     config.URL,
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/proto"
+	nbgroup "github.com/netbirdio/netbird/management/server/group"
+)
+
+func TestAccount_getPeerClientUpdate(t *testing.T) {
+	account := &Account{
+		Groups: map[string]*nbgroup.Group{
+			"beta":    {ID: "beta", Peers: []string{"peer1", "peer2", "peer4"}, ClientUpdateChannel: "beta"},
+			"stable":  {ID: "stable", Peers: []string{"peer2"}, ClientUpdateChannel: ClientUpdateChannelStable},
+			"alpha":   {ID: "alpha", Peers: []string{"peer4"}, ClientUpdateChannel: "alpha"},
+			"pinned":  {ID: "pinned", Peers: []string{"peer3", "peer4"}, ClientVersion: "0.28.10"},
+			"pinned2": {ID: "pinned2", Peers: []string{"peer3"}, ClientVersion: "0.28.9"},
+			"all":     {ID: "all", Peers: []string{"peer1", "peer2", "peer3", "peer4", "peer5"}},
+		},
+	}
+
+	assert.Equal(t, ClientUpdateSettings{Channel: "beta"}, account.getPeerClientUpdate("peer1"))
+	assert.Equal(t, ClientUpdateSettings{Channel: ClientUpdateChannelStable}, account.getPeerClientUpdate("peer2"),
+		"the stable channel should be followed when the groups of the peer follow different channels")
+	assert.Equal(t, ClientUpdateSettings{Version: "0.28.9"}, account.getPeerClientUpdate("peer3"),
+		"the lowest pinned version should be used")
+	assert.Equal(t, ClientUpdateSettings{Version: "0.28.10"}, account.getPeerClientUpdate("peer4"),
+		"a pinned version should take precedence over the channels")
+	assert.Equal(t, ClientUpdateSettings{}, account.getPeerClientUpdate("peer5"))
+
+	delete(account.Groups, "pinned")
+	assert.Equal(t, ClientUpdateSettings{Channel: "alpha"}, account.getPeerClientUpdate("peer4"),
+		"the first channel by name should be followed without a stable one")
+}
+
+func TestValidateClientUpdate(t *testing.T) {
+	assert.NoError(t, validateClientUpdate("", ""))
+	assert.NoError(t, validateClientUpdate("beta_2", ""))
+	assert.NoError(t, validateClientUpdate("", "0.28.1"))
+
+	assert.Error(t, validateClientUpdate("stable", "0.28.1"), "a group should not pin a version and follow a channel")
+	for _, channel := range []string{"Stable", "new channel", string(make([]byte, 33))} {
+		assert.Error(t, validateClientUpdate(channel, ""), channel)
+	}
+	assert.Error(t, validateClientUpdate("", "latest"))
+}
+
+func TestToClientUpdateConfig(t *testing.T) {
+	config := &ClientUpdatesConfig{
+		URL:      "https://pkgs.example.com/netbird",
+		Channels: map[string]string{ClientUpdateChannelStable: "0.28.1", "beta": "0.29.0-rc1"},
+	}
+
+	assert.Equal(t, &proto.ClientUpdateConfig{Version: "0.29.0-rc1", Channel: "beta", Url: config.URL},
+		toClientUpdateConfig(config, ClientUpdateSettings{Channel: "beta"}))
+	assert.Equal(t, &proto.ClientUpdateConfig{Version: "0.27.7", Url: config.URL},
+		toClientUpdateConfig(config, ClientUpdateSettings{Version: "0.27.7"}))
+
+	assert.Nil(t, toClientUpdateConfig(config, ClientUpdateSettings{}))
+	assert.Nil(t, toClientUpdateConfig(config, ClientUpdateSettings{Channel: "nightly"}), "an unknown channel should not update the peer")
+	assert.Nil(t, toClientUpdateConfig(nil, ClientUpdateSettings{Version: "0.27.7"}))
+	assert.Nil(t, toClientUpdateConfig(&ClientUpdatesConfig{}, ClientUpdateSettings{Version: "0.27.7"}))
+}
+
+func TestDefaultAccountManager_SaveGroupClientUpdate(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err)
+
+	group := &nbgroup.Group{Name: "canary", Issued: nbgroup.GroupIssuedAPI, ClientUpdateChannel: "beta", ClientVersion: "0.28.1"}
+	assert.Error(t, manager.SaveGroup(account.Id, userID, group), "a group should not pin a version and follow a channel")
+
+	group.ClientVersion = ""
+	require.NoError(t, manager.SaveGroup(account.Id, userID, group))
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "beta", account.Groups[group.ID].ClientUpdateChannel)
+}
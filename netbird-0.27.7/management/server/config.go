@@ -4,7 +4,11 @@ import (
 	"net/netip"
 	"net/url"
 
+	"github.com/netbirdio/netbird/encryption/acmedns"
+	"github.com/netbirdio/netbird/management/server/activity/archive"
+	"github.com/netbirdio/netbird/management/server/activity/sink"
 	"github.com/netbirdio/netbird/management/server/idp"
+	"github.com/netbirdio/netbird/management/server/notification"
 	"github.com/netbirdio/netbird/util"
 )
 
@@ -50,6 +54,34 @@ type Config struct {
 	StoreConfig StoreConfig
 
 	ReverseProxy ReverseProxy
+
+	// SourceFilter restricts the source addresses the APIs accept requests from, disabled when nil
+	SourceFilter *SourceFilterConfig
+
+	EventsRetention *EventsRetentionConfig
+
+	EventSink *EventSinkConfig
+
+	EmailNotifications *EmailNotificationsConfig
+
+	// ClientUpdates gives the versions of the update channels the groups of the peers follow, the peers aren't told
+	// to update when nil
+	ClientUpdates *ClientUpdatesConfig
+
+	// LeaderElection makes the instances sharing the store elect the one running the background jobs, every instance
+	// runs them when nil
+	LeaderElection *LeaderElectionConfig
+
+	// APIQuotas limits the HTTP API requests of the accounts and meters them, the requests aren't metered when nil
+	APIQuotas *APIQuotasConfig
+
+	// Metrics secures the endpoint of the metrics and exposes the runtime profiles, the metrics are served without
+	// authentication on every address of the --metrics-port when nil
+	Metrics *MetricsConfig
+
+	// Log sets the format, the rotation and the levels of the components of the log, the --log-level flag takes
+	// precedence over its level. The flags alone set up the log when nil
+	Log *util.LogConfig
 }
 
 // GetAuthAudiences returns the audience from the http config and device authorization flow config
@@ -74,6 +106,9 @@ type TURNConfig struct {
 // HttpServerConfig is a config of the HTTP Management service server
 type HttpServerConfig struct {
 	LetsEncryptDomain string
+	// LetsEncryptDNS issues the certificate of LetsEncryptDomain with the DNS-01 challenge instead of the HTTP and
+	// TLS-ALPN ones, for servers the CA can't reach e.g. behind CGNAT
+	LetsEncryptDNS *acmedns.Config
 	// CertFile is the location of the certificate
 	CertFile string
 	// CertKey is the location of the certificate private key
@@ -90,6 +125,50 @@ type HttpServerConfig struct {
 	OIDCConfigEndpoint string
 	// IdpSignKeyRefreshEnabled identifies the signing key is currently being rotated or not
 	IdpSignKeyRefreshEnabled bool
+	// AccessLog configures the access logs of the HTTP API, disabled when nil
+	AccessLog *AccessLogConfig
+	// Dashboard configures the dashboard served next to the HTTP API, disabled when nil
+	Dashboard *DashboardConfig
+	// ErrorDocumentationURL is the page documenting the error codes of the HTTP API, the error responses link to it
+	// with the error code as fragment when set
+	ErrorDocumentationURL string
+	// Debug serves the profiles, goroutine dumps and the stats of the store and the update channels under /debug/ to
+	// diagnose stalls, disabled when nil
+	Debug *DebugConfig
+}
+
+// AccessLogConfig configures the structured access logs of the HTTP API
+type AccessLogConfig struct {
+	// SampleRate is the share of the requests answered with a status below 400 that are logged, from 0 to 1. The
+	// requests answered with an error are always logged. All requests are logged when 0.
+	SampleRate float64
+	// RedactQueryParams are the query parameters whose values are replaced in the logged path, in addition to the
+	// ones that usually hold secrets like token and setup_key
+	RedactQueryParams []string
+	// RedactUserIDs replaces the user IDs with a hash of them, for IdPs whose user IDs are email addresses
+	RedactUserIDs bool
+}
+
+// DashboardConfig configures the dashboard single page application served by the Management service itself, so small
+// deployments don't need a separate web server for it
+type DashboardConfig struct {
+	// PathPrefix is the path the dashboard is served under, e.g. /dashboard. The dashboard has to be built with the
+	// same base path. Defaults to /, the HTTP API keeps its /api path either way
+	PathPrefix string
+	// Directory holds the dashboard build to serve. Defaults to the build embedded in the binary with the dashboard
+	// build tag
+	Directory string
+	// CacheMaxAge is how long the browsers cache the assets of the dashboard, the index is always revalidated so a
+	// new build is picked up. Defaults to a day
+	CacheMaxAge util.Duration
+}
+
+// DebugConfig configures the runtime diagnostics endpoint of the HTTP server. It is separate from the HTTP API
+// authentication so it can be used while the IdP or the store is stalled
+type DebugConfig struct {
+	// AdminToken is the token the requests authenticate with in their Authorization header as a bearer token. It
+	// has to be at least 32 characters long
+	AdminToken string
 }
 
 // Host represents a Wiretrustee host (e.g. STUN, TURN, Signal)
@@ -146,6 +225,61 @@ type StoreConfig struct {
 	Engine StoreEngine
 }
 
+// EventsRetentionConfig configures the pruning of the activity events older than the retention of their account
+type EventsRetentionConfig struct {
+	// PruneInterval is how often the expired events are pruned, DefaultEventsPruneInterval when empty
+	PruneInterval util.Duration
+	// ArchiveDir is a local directory the expired events are exported to before they are pruned
+	ArchiveDir string
+	// ArchiveS3 is an S3 bucket the expired events are exported to before they are pruned. It takes precedence over
+	// ArchiveDir
+	ArchiveS3 *archive.S3Config
+}
+
+// LeaderElectionConfig configures the election of the instance running the background jobs: the peer login
+// expiration, the peer key rotation, the account deletion, the ephemeral peer cleanup, the events pruning and the
+// setup key expiry notifications
+type LeaderElectionConfig struct {
+	// InstanceID identifies the instance in the store, the host name with a random suffix when empty
+	InstanceID string
+	// LeaseTTL is how long the lease of the leader lasts without being renewed, DefaultLeaderLeaseTTL when empty
+	LeaseTTL util.Duration
+	// ResyncInterval is how often the leader reschedules the jobs of the accounts changed through the other
+	// instances, DefaultLeaderResyncInterval when empty
+	ResyncInterval util.Duration
+}
+
+// EventSinkConfig configures the publishing of every activity event to a message broker, Kafka or NATS
+type EventSinkConfig struct {
+	// Kafka is the Kafka REST Proxy the events are produced through
+	Kafka *sink.KafkaConfig
+	// NATS is the NATS server the events are published to
+	NATS *sink.NATSConfig
+	// QueueSize is the number of events waiting for their delivery kept in memory, sink.DefaultQueueSize when 0
+	QueueSize int
+	// MaxRetryTime is the time the delivery of an event is retried for, sink.DefaultMaxRetryTime when empty
+	MaxRetryTime util.Duration
+}
+
+// EmailNotificationsConfig configures the email notifications the users subscribe to
+type EmailNotificationsConfig struct {
+	// SMTP is the server the notifications are sent through
+	SMTP notification.SMTPConfig
+	// DashboardURL is the address of the dashboard linked in the notifications
+	DashboardURL string
+}
+
+// ClientUpdatesConfig configures where the auto updating peers download the client binaries from
+type ClientUpdatesConfig struct {
+	// URL is the base URL the signed client binaries are downloaded from, the binary of a version is at
+	// <URL>/<version>/netbird_<os>_<arch>, with the ARM version or the MIPS floating point mode appended to the arch,
+	// e.g. netbird_linux_armv7 or netbird_linux_mipsle_softfloat. The peers verify the binaries with their own public
+	// key
+	URL string
+	// Channels maps the update channels to the version their peers run, e.g. {"stable": "0.28.1"}
+	Channels map[string]string
+}
+
 // ReverseProxy contains reverse proxy configuration in front of management.
 type ReverseProxy struct {
 	// TrustedHTTPProxies represents a list of trusted HTTP proxies by their IP prefixes.
@@ -167,6 +301,58 @@ type ReverseProxy struct {
 	TrustedPeers []netip.Prefix
 }
 
+// APIQuotasConfig limits the number of HTTP API requests of the accounts. Every instance of the management service
+// counts the requests it serves in memory, the counts start over on a restart
+type APIQuotasConfig struct {
+	// Default is the quota of the accounts without their own
+	Default APIQuota
+	// Accounts are the quotas of the accounts overriding the default, keyed by the account ID
+	Accounts map[string]APIQuota
+}
+
+// APIQuota is the number of HTTP API requests an account can make in a minute and in a UTC day, unlimited when 0
+type APIQuota struct {
+	RequestsPerMinute int
+	RequestsPerDay    int
+}
+
+// MetricsConfig is the listener of the metrics endpoint and of the runtime profiles. The requests authenticate with
+// the bearer token or the basic credentials when one of them is set
+type MetricsConfig struct {
+	// Address is the address the endpoints are served on instead of every address of the --metrics-port: host:port,
+	// e.g. 127.0.0.1:8081, or unix:// and the path of a socket. The --metrics-address flag takes precedence over it
+	Address string
+	// BearerToken is the token the requests may authenticate with in their Authorization header
+	BearerToken string
+	// BasicAuthUsername and BasicAuthPassword are the credentials the requests may authenticate with instead
+	BasicAuthUsername string
+	BasicAuthPassword string
+	// CertFile and CertKey serve the endpoints over HTTPS
+	CertFile string
+	CertKey  string
+	// Pprof exposes the runtime profiles of the server under /debug/pprof/. It requires an authentication or a
+	// loopback or unix socket address
+	Pprof bool
+}
+
+// SourceFilterConfig restricts the source addresses of the requests to the APIs, e.g. when the server listens on a WAN
+// address. The HTTP API checks the address of the connection, the gRPC API the real IP resolved with the ReverseProxy
+// config. The requests over a unix socket aren't filtered.
+type SourceFilterConfig struct {
+	// HTTP filters the requests to the HTTP API and the dashboard
+	HTTP SourceFilterRules
+	// GRPC filters the requests of the peers to the gRPC API
+	GRPC SourceFilterRules
+}
+
+// SourceFilterRules are the networks the requests are allowed or denied from
+type SourceFilterRules struct {
+	// Allow are the networks allowed, every source that isn't denied is allowed when empty
+	Allow []netip.Prefix
+	// Deny are the networks denied, even when they are in Allow
+	Deny []netip.Prefix
+}
+
 // validateURL validates input http url
 func validateURL(httpURL string) bool {
 	_, err := url.ParseRequestURI(httpURL)
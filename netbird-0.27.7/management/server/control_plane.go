@@ -0,0 +1,31 @@
+package server
+
+import (
+	"slices"
+
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// The parts of the host a peer manages, the PeerControlPlane of the account settings lists the ones the peers manage
+const (
+	// ControlPlaneDNS applies the DNS configuration of the account on the peer
+	ControlPlaneDNS = "dns"
+	// ControlPlaneRoutes installs the routes of the account on the peer
+	ControlPlaneRoutes = "routes"
+	// ControlPlaneACL enforces the policies of the account with the firewall of the peer
+	ControlPlaneACL = "acl"
+)
+
+var controlPlaneParts = []string{ControlPlaneDNS, ControlPlaneRoutes, ControlPlaneACL}
+
+func validatePeerControlPlane(parts []string) error {
+	for i, part := range parts {
+		if !slices.Contains(controlPlaneParts, part) {
+			return status.Errorf(status.InvalidArgument, "unknown control plane part %q, expected one of %v", part, controlPlaneParts)
+		}
+		if slices.Contains(parts[:i], part) {
+			return status.Errorf(status.InvalidArgument, "control plane part %q is listed twice", part)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+func TestValidatePeerControlPlane(t *testing.T) {
+	assert.NoError(t, validatePeerControlPlane(nil))
+	assert.NoError(t, validatePeerControlPlane([]string{ControlPlaneRoutes, ControlPlaneDNS}))
+	assert.Error(t, validatePeerControlPlane([]string{ControlPlaneRoutes, "sysctl"}))
+	assert.Error(t, validatePeerControlPlane([]string{ControlPlaneACL, ControlPlaneACL}))
+}
+
+func TestDefaultAccountManager_UpdateAccountSettings_PeerControlPlane(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account, err := manager.GetOrCreateAccountByUser(userID, "")
+	require.NoError(t, err)
+
+	settings := account.Settings.Copy()
+	settings.PeerControlPlane = []string{"firewall"}
+	_, err = manager.UpdateAccountSettings(account.Id, userID, settings)
+	require.Error(t, err, "an unknown part should be rejected")
+
+	settings.PeerControlPlane = []string{ControlPlaneRoutes}
+	updated, err := manager.UpdateAccountSettings(account.Id, userID, settings)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ControlPlaneRoutes}, updated.Settings.PeerControlPlane)
+
+	ev := getEvent(t, account.Id, manager, activity.AccountPeerControlPlaneUpdated)
+	assert.Equal(t, []string{ControlPlaneRoutes}, ev.Meta["control_plane"])
+
+	stored, err := manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	stored.Peers["peerA"] = &nbpeer.Peer{ID: "peerA", Key: "peerAKey", IP: net.ParseIP("100.64.0.1"), Status: &nbpeer.PeerStatus{}}
+	networkMap := stored.GetPeerNetworkMap("peerA", manager.GetDNSDomain(), validatedPeers(stored))
+	peerConfig := toPeerConfig(stored.Peers["peerA"], stored.Network, "", 0, ICESettings{}, RosenpassSettings{}, LoginExpirationSettings{}, false, nil, nil, networkMap.ControlPlane)
+	assert.Equal(t, []string{ControlPlaneRoutes}, peerConfig.GetControlPlane(), "the control plane should be pushed to the peers")
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	ctlProto "github.com/netbirdio/netbird/management/ctl/proto"
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+// CtlServer serves the netbird-mgmt ctl commands on the local admin socket. The requests aren't authenticated, the
+// socket is only accessible to the user the server runs as, and the changes are made on behalf of the system.
+type CtlServer struct {
+	ctlProto.UnimplementedCtlServiceServer
+	store          Store
+	accountManager AccountManager
+}
+
+// NewCtlServer creates a new local admin gRPC API server
+func NewCtlServer(store Store, accountManager AccountManager) *CtlServer {
+	return &CtlServer{
+		store:          store,
+		accountManager: accountManager,
+	}
+}
+
+// getAccount returns the account with the given ID or the single account of the server when the ID is empty
+func (s *CtlServer) getAccount(accountID string) (*Account, error) {
+	if accountID != "" {
+		account, err := s.store.GetAccount(accountID)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		return account, nil
+	}
+
+	accounts := s.store.GetAllAccounts()
+	switch len(accounts) {
+	case 0:
+		return nil, status.Errorf(codes.NotFound, "the server has no account yet")
+	case 1:
+		return accounts[0], nil
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "the server has %d accounts, the account ID is required", len(accounts))
+	}
+}
+
+// ListPeers returns the peers of the account along with their approval state
+func (s *CtlServer) ListPeers(_ context.Context, req *ctlProto.AccountRequest) (*ctlProto.ListPeersResponse, error) {
+	account, err := s.getAccount(req.GetAccountId())
+	if err != nil {
+		return nil, err
+	}
+
+	validatedPeers, err := s.accountManager.GetValidatedPeers(account)
+	if err != nil {
+		log.Errorf("failed to get the validated peers of account %s: %v", account.Id, err)
+		return nil, status.Errorf(codes.Internal, "failed getting the approval state of the peers")
+	}
+
+	resp := &ctlProto.ListPeersResponse{}
+	for _, peer := range account.GetPeers() {
+		_, validated := validatedPeers[peer.ID]
+		resp.Peers = append(resp.Peers, toCtlPeer(account, peer, !validated))
+	}
+	return resp, nil
+}
+
+// ApprovePeer approves a peer waiting for the approval of an administrator
+func (s *CtlServer) ApprovePeer(_ context.Context, req *ctlProto.PeerRequest) (*ctlProto.Peer, error) {
+	account, err := s.getAccount(req.GetAccountId())
+	if err != nil {
+		return nil, err
+	}
+
+	current := account.GetPeer(req.GetPeerId())
+	if current == nil {
+		return nil, status.Errorf(codes.NotFound, "peer with ID %s not found", req.GetPeerId())
+	}
+
+	peer, err := s.accountManager.UpdatePeer(account.Id, activity.SystemInitiator, &nbpeer.Peer{
+		ID:                     current.ID,
+		Name:                   current.Name,
+		SSHEnabled:             current.SSHEnabled,
+		LoginExpirationEnabled: current.LoginExpirationEnabled,
+		Uplink:                 current.Uplink,
+		Status:                 &nbpeer.PeerStatus{RequiresApproval: false},
+	})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return toCtlPeer(account, peer, false), nil
+}
+
+// CreateSetupKey creates a setup key in the account, the plain key is only returned here
+func (s *CtlServer) CreateSetupKey(_ context.Context, req *ctlProto.CreateSetupKeyRequest) (*ctlProto.SetupKey, error) {
+	account, err := s.getAccount(req.GetAccountId())
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetName() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "setup key name shouldn't be empty")
+	}
+	keyType := SetupKeyType(req.GetType())
+	if keyType != SetupKeyReusable && keyType != SetupKeyOneOff {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown setup key type %s", keyType)
+	}
+	if req.GetExpiresIn() < 0 || req.GetUsageLimit() < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "expiration and usage limit can't be negative")
+	}
+
+	key, err := s.accountManager.CreateSetupKey(account.Id, req.GetName(), keyType, time.Duration(req.GetExpiresIn())*time.Second,
+		req.GetAutoGroups(), int(req.GetUsageLimit()), activity.SystemInitiator, req.GetEphemeral())
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &ctlProto.SetupKey{
+		Id:         key.Id,
+		Key:        key.Key,
+		Name:       key.Name,
+		Type:       string(key.Type),
+		Expires:    timestamppb.New(key.ExpiresAt),
+		AutoGroups: key.AutoGroups,
+		UsageLimit: int32(key.UsageLimit),
+		Ephemeral:  key.Ephemeral,
+	}, nil
+}
+
+// GetNetworkMap returns the network map the peer is sent, encoded as JSON
+func (s *CtlServer) GetNetworkMap(_ context.Context, req *ctlProto.PeerRequest) (*ctlProto.NetworkMapResponse, error) {
+	account, err := s.getAccount(req.GetAccountId())
+	if err != nil {
+		return nil, err
+	}
+
+	if account.GetPeer(req.GetPeerId()) == nil {
+		return nil, status.Errorf(codes.NotFound, "peer with ID %s not found", req.GetPeerId())
+	}
+
+	networkMap, err := s.accountManager.GetNetworkMap(req.GetPeerId())
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	encoded, err := json.Marshal(networkMap)
+	if err != nil {
+		log.Errorf("failed to encode the network map of peer %s: %v", req.GetPeerId(), err)
+		return nil, status.Errorf(codes.Internal, "failed encoding the network map")
+	}
+	return &ctlProto.NetworkMapResponse{NetworkMap: encoded}, nil
+}
+
+// Resync sends the current network map to all the connected peers of the account
+func (s *CtlServer) Resync(_ context.Context, req *ctlProto.AccountRequest) (*ctlProto.Empty, error) {
+	account, err := s.getAccount(req.GetAccountId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.accountManager.ResyncAccountPeers(account.Id, activity.SystemInitiator); err != nil {
+		return nil, mapError(err)
+	}
+	return &ctlProto.Empty{}, nil
+}
+
+func toCtlPeer(account *Account, peer *nbpeer.Peer, approvalRequired bool) *ctlProto.Peer {
+	p := &ctlProto.Peer{
+		Id:               peer.ID,
+		Name:             peer.Name,
+		Ip:               peer.IP.String(),
+		DnsLabel:         peer.DNSLabel,
+		Os:               peer.Meta.OS,
+		Version:          peer.Meta.WtVersion,
+		UserId:           peer.UserID,
+		ApprovalRequired: approvalRequired,
+		Groups:           account.GetPeerGroupsList(peer.ID),
+	}
+	if peer.Status != nil {
+		p.Connected = peer.Status.Connected
+		p.LastSeen = timestamppb.New(peer.Status.LastSeen)
+	}
+	return p
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	ctlProto "github.com/netbirdio/netbird/management/ctl/proto"
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+func startCtlServer(t *testing.T, manager *DefaultAccountManager) ctlProto.CtlServiceClient {
+	t.Helper()
+
+	lis, err := net.Listen("unix", t.TempDir()+"/ctl.sock")
+	require.NoError(t, err)
+
+	s := grpc.NewServer()
+	ctlProto.RegisterCtlServiceServer(s, NewCtlServer(manager.Store, manager))
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	t.Cleanup(s.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, "unix://"+lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return ctlProto.NewCtlServiceClient(conn)
+}
+
+func TestCtlServer(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+
+	client := startCtlServer(t, manager)
+	ctx := context.Background()
+
+	_, err = client.ListPeers(ctx, &ctlProto.AccountRequest{})
+	assert.Equal(t, codes.NotFound, status.Code(err), "the server has no account yet")
+
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err, "unable to create an account")
+
+	var setupKey *ctlProto.SetupKey
+	t.Run("creates setup keys", func(t *testing.T) {
+		_, err := client.CreateSetupKey(ctx, &ctlProto.CreateSetupKeyRequest{Name: "routers", Type: "unknown"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = client.CreateSetupKey(ctx, &ctlProto.CreateSetupKeyRequest{Name: "routers", Type: "reusable", AutoGroups: []string{"missing"}})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		setupKey, err = client.CreateSetupKey(ctx, &ctlProto.CreateSetupKeyRequest{Name: "routers", Type: "reusable", ExpiresIn: 3600, UsageLimit: 5})
+		require.NoError(t, err)
+		assert.NotEmpty(t, setupKey.Key)
+		assert.EqualValues(t, 5, setupKey.UsageLimit)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), setupKey.Expires.AsTime(), time.Minute)
+	})
+
+	peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+		Key:  "BhRPtynAAYRDy08+q4HTMsos8fs4plTP4NOSh7C1ry8=",
+		Meta: nbpeer.PeerSystemMeta{Hostname: "router"},
+	})
+	require.NoError(t, err)
+
+	t.Run("lists the peers", func(t *testing.T) {
+		resp, err := client.ListPeers(ctx, &ctlProto.AccountRequest{AccountId: account.Id})
+		require.NoError(t, err)
+		require.Len(t, resp.Peers, 1)
+		assert.Equal(t, peer.ID, resp.Peers[0].Id)
+		assert.False(t, resp.Peers[0].ApprovalRequired)
+		assert.NotEmpty(t, resp.Peers[0].Groups, "the peer is in the All group")
+
+		_, err = client.ListPeers(ctx, &ctlProto.AccountRequest{AccountId: "missing"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("approves the peers", func(t *testing.T) {
+		_, err := client.ApprovePeer(ctx, &ctlProto.PeerRequest{PeerId: "missing"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		approved, err := client.ApprovePeer(ctx, &ctlProto.PeerRequest{PeerId: peer.ID})
+		require.NoError(t, err)
+		assert.Equal(t, peer.Name, approved.Name, "the other settings of the peer should be kept")
+	})
+
+	t.Run("dumps the network map of a peer", func(t *testing.T) {
+		resp, err := client.GetNetworkMap(ctx, &ctlProto.PeerRequest{PeerId: peer.ID})
+		require.NoError(t, err)
+
+		var networkMap NetworkMap
+		require.NoError(t, json.Unmarshal(resp.NetworkMap, &networkMap))
+		assert.Equal(t, account.Network.Identifier, networkMap.Network.Identifier)
+	})
+
+	t.Run("resyncs the peers", func(t *testing.T) {
+		_, err := client.Resync(ctx, &ctlProto.AccountRequest{})
+		require.NoError(t, err)
+
+		events, err := manager.GetEvents(account.Id, userID)
+		require.NoError(t, err)
+		var resynced bool
+		for _, event := range events {
+			if event.Activity == activity.AccountPeersResynced {
+				resynced = event.InitiatorID == activity.SystemInitiator
+			}
+		}
+		assert.True(t, resynced, "the resync should be recorded on behalf of the system")
+	})
+
+	t.Run("requires the account with several accounts", func(t *testing.T) {
+		_, err := manager.GetAccountByUserOrAccountID("otherUser", "", "")
+		require.NoError(t, err)
+
+		_, err = client.Resync(ctx, &ctlProto.AccountRequest{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	// MaxDebugBundleSize is the size of the largest debug bundle a peer can upload, the gRPC messages are limited to 4MB
+	MaxDebugBundleSize = 3 << 20
+	// maxDebugBundlesPerPeer is the number of the latest debug bundles of a peer kept
+	maxDebugBundlesPerPeer = 5
+)
+
+// EnableDebugBundles makes the account manager accept the debug bundles uploaded by the peers and store them in the
+// directory, one subdirectory per account
+func (am *DefaultAccountManager) EnableDebugBundles(dir string) {
+	am.debugBundleDir = dir
+}
+
+// StorePeerDebugBundle stores a debug bundle the peer uploaded for support and returns the name it is stored under.
+// Only the latest bundles of a peer are kept.
+func (am *DefaultAccountManager) StorePeerDebugBundle(peerPubKey string, bundle []byte) (string, error) {
+	if am.debugBundleDir == "" {
+		return "", status.Errorf(status.PreconditionFailed, "the management service doesn't accept debug bundles")
+	}
+	if len(bundle) > MaxDebugBundleSize {
+		return "", status.Errorf(status.InvalidArgument, "debug bundle of %d bytes exceeds the limit of %d bytes", len(bundle), MaxDebugBundleSize)
+	}
+	if _, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle))); err != nil {
+		return "", status.Errorf(status.InvalidArgument, "debug bundle isn't a zip archive: %v", err)
+	}
+
+	accountID, err := am.Store.GetAccountIDByPeerPubKey(peerPubKey)
+	if err != nil {
+		return "", err
+	}
+
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return "", err
+	}
+
+	peer, err := account.FindPeerByPubKey(peerPubKey)
+	if err != nil {
+		return "", status.Errorf(status.NotFound, "peer with key %s not found", peerPubKey)
+	}
+
+	dir := filepath.Join(am.debugBundleDir, accountID)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("create debug bundle directory: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.zip", peer.ID, time.Now().UTC().Format("20060102T150405.000Z"))
+	if err := os.WriteFile(filepath.Join(dir, name), bundle, 0600); err != nil {
+		return "", fmt.Errorf("write debug bundle: %w", err)
+	}
+	log.Infof("stored the debug bundle uploaded by peer %s in %s", peer.ID, filepath.Join(dir, name))
+
+	if err := pruneDebugBundles(dir, peer.ID); err != nil {
+		log.Warnf("failed to remove the old debug bundles of peer %s: %v", peer.ID, err)
+	}
+
+	meta := peer.EventMeta(am.GetDNSDomain())
+	meta["bundle"] = name
+	meta["size"] = len(bundle)
+	am.StoreEvent(peer.ID, peer.ID, accountID, activity.PeerDebugBundleUploaded, meta)
+
+	return name, nil
+}
+
+// pruneDebugBundles removes the debug bundles of the peer but the latest ones, the names sort by their upload time
+func pruneDebugBundles(dir, peerID string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var bundles []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), peerID+"-") && strings.HasSuffix(entry.Name(), ".zip") {
+			bundles = append(bundles, entry.Name())
+		}
+	}
+	if len(bundles) <= maxDebugBundlesPerPeer {
+		return nil
+	}
+
+	slices.Sort(bundles)
+	for _, name := range bundles[:len(bundles)-maxDebugBundlesPerPeer] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
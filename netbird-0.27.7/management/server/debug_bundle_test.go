@@ -0,0 +1,87 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func testDebugBundle(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	w, err := archive.Create("client.log.txt")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("log line\n"))
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+	return buf.Bytes()
+}
+
+func TestDefaultAccountManager_StorePeerDebugBundle(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account := newAccountWithId("test_account", userID, "")
+	err = manager.Store.SaveAccount(account)
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	key, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+		Key:  key.PublicKey().String(),
+		Meta: nbpeer.PeerSystemMeta{Hostname: "test-router"},
+	})
+	require.NoError(t, err)
+
+	bundle := testDebugBundle(t)
+
+	_, err = manager.StorePeerDebugBundle(peer.Key, bundle)
+	sErr, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.PreconditionFailed, sErr.Type(), "the uploads should be rejected until enabled")
+
+	dir := t.TempDir()
+	manager.EnableDebugBundles(dir)
+
+	_, err = manager.StorePeerDebugBundle(peer.Key, []byte("not a zip"))
+	sErr, ok = status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.InvalidArgument, sErr.Type())
+
+	name, err := manager.StorePeerDebugBundle(peer.Key, bundle)
+	require.NoError(t, err)
+	stored, err := os.ReadFile(filepath.Join(dir, account.Id, name))
+	require.NoError(t, err)
+	assert.Equal(t, bundle, stored)
+
+	ev := getEvent(t, account.Id, manager, activity.PeerDebugBundleUploaded)
+	assert.Equal(t, peer.ID, ev.TargetID)
+	assert.Equal(t, name, ev.Meta["bundle"])
+
+	for i := 0; i < maxDebugBundlesPerPeer+2; i++ {
+		_, err = manager.StorePeerDebugBundle(peer.Key, bundle)
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond)
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, account.Id))
+	require.NoError(t, err)
+	assert.Len(t, entries, maxDebugBundlesPerPeer, "only the latest bundles should be kept")
+	_, err = os.Stat(filepath.Join(dir, account.Id, name))
+	assert.ErrorIs(t, err, os.ErrNotExist, "the oldest bundle should be removed")
+}
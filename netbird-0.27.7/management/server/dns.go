@@ -2,14 +2,20 @@ package server
 
 import (
 	"fmt"
+	"net/netip"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/miekg/dns"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	nbdns "github.com/netbirdio/netbird/dns"
 	"github.com/netbirdio/netbird/management/proto"
 	"github.com/netbirdio/netbird/management/server/activity"
+	nbgroup "github.com/netbirdio/netbird/management/server/group"
 	nbpeer "github.com/netbirdio/netbird/management/server/peer"
 	"github.com/netbirdio/netbird/management/server/status"
 )
@@ -22,17 +28,37 @@ type lookupMap map[string]struct{}
 type DNSSettings struct {
 	// DisabledManagementGroups groups whose DNS management is disabled
 	DisabledManagementGroups []string `gorm:"serializer:json"`
+	// Blocklists hosts-format deny lists enforced by the resolver of the peers
+	Blocklists []nbdns.Blocklist `gorm:"serializer:json"`
+	// BlocklistResponse is how the blocked domains are answered, NXDOMAIN when empty
+	BlocklistResponse nbdns.BlockResponse
+	// BlocklistRefreshInterval is how often the peers download the deny lists, 24 hours when zero
+	BlocklistRefreshInterval time.Duration
+	// BlocklistOverrideGroups groups whose peers aren't filtered by the deny lists
+	BlocklistOverrideGroups []string `gorm:"serializer:json"`
 }
 
 // Copy returns a copy of the DNS settings
 func (d DNSSettings) Copy() DNSSettings {
 	settings := DNSSettings{
 		DisabledManagementGroups: make([]string, len(d.DisabledManagementGroups)),
+		Blocklists:               slices.Clone(d.Blocklists),
+		BlocklistResponse:        d.BlocklistResponse,
+		BlocklistRefreshInterval: d.BlocklistRefreshInterval,
+		BlocklistOverrideGroups:  slices.Clone(d.BlocklistOverrideGroups),
 	}
 	copy(settings.DisabledManagementGroups, d.DisabledManagementGroups)
 	return settings
 }
 
+// blocklistChanged returns true when the deny lists settings differ
+func (d DNSSettings) blocklistChanged(other DNSSettings) bool {
+	return !slices.Equal(d.Blocklists, other.Blocklists) ||
+		d.BlocklistResponse != other.BlocklistResponse ||
+		d.BlocklistRefreshInterval != other.BlocklistRefreshInterval ||
+		!slices.Equal(d.BlocklistOverrideGroups, other.BlocklistOverrideGroups)
+}
+
 // GetDNSSettings validates a user role and returns the DNS settings for the provided account ID
 func (am *DefaultAccountManager) GetDNSSettings(accountID string, userID string) (*DNSSettings, error) {
 	unlock := am.Store.AcquireAccountWriteLock(accountID)
@@ -55,6 +81,42 @@ func (am *DefaultAccountManager) GetDNSSettings(accountID string, userID string)
 	return &dnsSettings, nil
 }
 
+// ExportDNSZones validates a user role and returns the zones served to the peers of the account,
+// including the reverse zone of the peers. The records are sorted so the mirrors of the zones only
+// see changes when the records change
+func (am *DefaultAccountManager) ExportDNSZones(accountID, userID string) ([]nbdns.CustomZone, error) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power are allowed to export DNS zones")
+	}
+
+	zones := account.getDNSZones(am.GetDNSDomain(), nil)
+	for _, zone := range zones {
+		slices.SortFunc(zone.Records, func(a, b nbdns.SimpleRecord) int {
+			if a.Name != b.Name {
+				return strings.Compare(a.Name, b.Name)
+			}
+			if a.Type != b.Type {
+				return a.Type - b.Type
+			}
+			return strings.Compare(a.RData, b.RData)
+		})
+	}
+	return zones, nil
+}
+
 // SaveDNSSettings validates a user role and updates the account's DNS settings
 func (am *DefaultAccountManager) SaveDNSSettings(accountID string, userID string, dnsSettingsToSave *DNSSettings) error {
 	unlock := am.Store.AcquireAccountWriteLock(accountID)
@@ -85,6 +147,10 @@ func (am *DefaultAccountManager) SaveDNSSettings(accountID string, userID string
 		}
 	}
 
+	if err = validateDNSBlocklists(dnsSettingsToSave, account.Groups); err != nil {
+		return err
+	}
+
 	oldSettings := account.DNSSettings.Copy()
 	account.DNSSettings = dnsSettingsToSave.Copy()
 
@@ -107,14 +173,80 @@ func (am *DefaultAccountManager) SaveDNSSettings(accountID string, userID string
 		am.StoreEvent(userID, accountID, accountID, activity.GroupRemovedFromDisabledManagementGroups, meta)
 	}
 
+	if oldSettings.blocklistChanged(account.DNSSettings) {
+		am.StoreEvent(userID, accountID, accountID, activity.DNSBlocklistsUpdated, nil)
+	}
+
 	am.updateAccountPeers(account)
 
 	return nil
 }
 
+// validateDNSBlocklists validates the deny lists, the block response, the refresh interval and the override groups
+func validateDNSBlocklists(settings *DNSSettings, groups map[string]*nbgroup.Group) error {
+	urls := make(map[string]struct{}, len(settings.Blocklists))
+	for _, blocklist := range settings.Blocklists {
+		if err := blocklist.Validate(); err != nil {
+			return status.Errorf(status.InvalidArgument, "%s", err)
+		}
+		if _, found := urls[blocklist.URL]; found {
+			return status.Errorf(status.InvalidArgument, "blocklist URL %s is duplicated", blocklist.URL)
+		}
+		urls[blocklist.URL] = struct{}{}
+	}
+
+	if _, err := nbdns.ParseBlockResponse(string(settings.BlocklistResponse)); err != nil {
+		return status.Errorf(status.InvalidArgument, "%s", err)
+	}
+
+	if settings.BlocklistRefreshInterval != 0 && settings.BlocklistRefreshInterval < nbdns.MinBlocklistRefreshInterval {
+		return status.Errorf(status.InvalidArgument, "blocklist refresh interval should be at least %s", nbdns.MinBlocklistRefreshInterval)
+	}
+
+	if len(settings.BlocklistOverrideGroups) != 0 {
+		return validateGroups(settings.BlocklistOverrideGroups, groups)
+	}
+	return nil
+}
+
+// getPeerDNSBlocklist returns the deny lists enforced by the resolver of the peer, none when the peer belongs to
+// one of the override groups
+func (a *Account) getPeerDNSBlocklist(peerID string) nbdns.BlocklistConfig {
+	var config nbdns.BlocklistConfig
+	for _, blocklist := range a.DNSSettings.Blocklists {
+		if blocklist.Enabled {
+			config.URLs = append(config.URLs, blocklist.URL)
+		}
+	}
+	if len(config.URLs) == 0 {
+		return nbdns.BlocklistConfig{}
+	}
+
+	peerGroups := a.getPeerGroups(peerID)
+	for _, groupID := range a.DNSSettings.BlocklistOverrideGroups {
+		if _, ok := peerGroups[groupID]; ok {
+			return nbdns.BlocklistConfig{}
+		}
+	}
+
+	config.Response = a.DNSSettings.BlocklistResponse
+	config.RefreshInterval = a.DNSSettings.BlocklistRefreshInterval
+	return config
+}
+
 func toProtocolDNSConfig(update nbdns.Config) *proto.DNSConfig {
 	protoUpdate := &proto.DNSConfig{ServiceEnable: update.ServiceEnable}
 
+	if len(update.Blocklist.URLs) > 0 {
+		protoUpdate.Blocklist = &proto.DNSBlocklist{
+			URLs:     update.Blocklist.URLs,
+			Response: string(update.Blocklist.Response),
+		}
+		if update.Blocklist.RefreshInterval > 0 {
+			protoUpdate.Blocklist.RefreshInterval = durationpb.New(update.Blocklist.RefreshInterval)
+		}
+	}
+
 	for _, zone := range update.CustomZones {
 		protoZone := &proto.CustomZone{Domain: zone.Domain}
 		for _, record := range zone.Records {
@@ -134,12 +266,16 @@ func toProtocolDNSConfig(update nbdns.Config) *proto.DNSConfig {
 			Primary:              nsGroup.Primary,
 			Domains:              nsGroup.Domains,
 			SearchDomainsEnabled: nsGroup.SearchDomainsEnabled,
+			Priority:             int64(nsGroup.Priority),
 		}
 		for _, ns := range nsGroup.NameServers {
 			protoNS := &proto.NameServer{
-				IP:     ns.IP.String(),
-				Port:   int64(ns.Port),
-				NSType: int64(ns.NSType),
+				IP:         ns.IP.String(),
+				Port:       int64(ns.Port),
+				NSType:     int64(ns.NSType),
+				ServerName: ns.ServerName,
+				SPKIPins:   ns.SPKIPins,
+				Path:       ns.Path,
 			}
 			protoGroup.NameServers = append(protoGroup.NameServers, protoNS)
 		}
@@ -149,7 +285,8 @@ func toProtocolDNSConfig(update nbdns.Config) *proto.DNSConfig {
 	return protoUpdate
 }
 
-func getPeersCustomZone(account *Account, dnsDomain string) nbdns.CustomZone {
+// getPeersCustomZone returns the A records of the peers, only the ones of the visible peers when the set is not nil
+func getPeersCustomZone(account *Account, dnsDomain string, visiblePeers lookupMap) nbdns.CustomZone {
 	if dnsDomain == "" {
 		log.Errorf("no dns domain is set, returning empty zone")
 		return nbdns.CustomZone{}
@@ -160,6 +297,10 @@ func getPeersCustomZone(account *Account, dnsDomain string) nbdns.CustomZone {
 	}
 
 	for _, peer := range account.Peers {
+		if !isPeerVisible(peer.ID, visiblePeers) {
+			continue
+		}
+
 		if peer.DNSLabel == "" {
 			log.Errorf("found a peer with empty dns label. It was probably caused by a invalid character in its name. Peer Name: %s", peer.Name)
 			continue
@@ -177,6 +318,106 @@ func getPeersCustomZone(account *Account, dnsDomain string) nbdns.CustomZone {
 	return customZone
 }
 
+// getPeersReverseZone returns the PTR records of the peers in the reverse zone of the account network, only the ones
+// of the visible peers when the set is not nil
+func getPeersReverseZone(account *Account, dnsDomain string, visiblePeers lookupMap) nbdns.CustomZone {
+	if dnsDomain == "" || account.Network == nil {
+		return nbdns.CustomZone{}
+	}
+
+	network, ok := netip.AddrFromSlice(account.Network.Net.IP)
+	if !ok {
+		return nbdns.CustomZone{}
+	}
+	ones, _ := account.Network.Net.Mask.Size()
+
+	zone, err := nbdns.ReverseZone(netip.PrefixFrom(network.Unmap(), ones))
+	if err != nil {
+		log.Errorf("unable to build the reverse zone of account %s: %v", account.Id, err)
+		return nbdns.CustomZone{}
+	}
+
+	customZone := nbdns.CustomZone{
+		Domain: zone,
+	}
+
+	for _, peer := range account.Peers {
+		if peer.DNSLabel == "" || !isPeerVisible(peer.ID, visiblePeers) {
+			continue
+		}
+
+		ip, ok := netip.AddrFromSlice(peer.IP)
+		if !ok {
+			continue
+		}
+		name, err := nbdns.ReverseName(ip)
+		if err != nil {
+			log.Errorf("unable to build the reverse name of peer %s: %v", peer.ID, err)
+			continue
+		}
+
+		customZone.Records = append(customZone.Records, nbdns.SimpleRecord{
+			Name:  name,
+			Type:  int(dns.TypePTR),
+			Class: nbdns.DefaultClass,
+			TTL:   defaultTTL,
+			RData: dns.Fqdn(peer.DNSLabel + "." + dnsDomain),
+		})
+	}
+
+	return customZone
+}
+
+// getDNSZones returns the zones served by the peers of the account: the peers zone with its reverse zone
+// and the zones of the custom records. The peer records are limited to the visible peers when the set is not nil
+func (a *Account) getDNSZones(dnsDomain string, visiblePeers lookupMap) []nbdns.CustomZone {
+	var zones []nbdns.CustomZone
+	peersCustomZone := getPeersCustomZone(a, dnsDomain, visiblePeers)
+	if peersCustomZone.Domain != "" {
+		zones = append(zones, peersCustomZone)
+	}
+	reverseZone := getPeersReverseZone(a, dnsDomain, visiblePeers)
+	if reverseZone.Domain != "" {
+		zones = append(zones, reverseZone)
+	}
+	return mergeCustomRecordsZones(zones, a, dnsDomain)
+}
+
+// getPeerDNSVisiblePeers returns the peers whose names the peer can resolve when it belongs to a DNS scoped group:
+// itself and the peers it can reach through the policies. It returns nil when the peer can resolve all the names
+func (a *Account) getPeerDNSVisiblePeers(peerID string, aclPeers []*nbpeer.Peer) lookupMap {
+	if len(a.Settings.DNSScopedGroups) == 0 {
+		return nil
+	}
+
+	peerGroups := a.getPeerGroups(peerID)
+	scoped := false
+	for _, groupID := range a.Settings.DNSScopedGroups {
+		if _, ok := peerGroups[groupID]; ok {
+			scoped = true
+			break
+		}
+	}
+	if !scoped {
+		return nil
+	}
+
+	visiblePeers := make(lookupMap, len(aclPeers)+1)
+	visiblePeers[peerID] = struct{}{}
+	for _, peer := range aclPeers {
+		visiblePeers[peer.ID] = struct{}{}
+	}
+	return visiblePeers
+}
+
+func isPeerVisible(peerID string, visiblePeers lookupMap) bool {
+	if visiblePeers == nil {
+		return true
+	}
+	_, ok := visiblePeers[peerID]
+	return ok
+}
+
 func getPeerNSGroups(account *Account, peerID string) []*nbdns.NameServerGroup {
 	groupList := account.getPeerGroups(peerID)
 
@@ -197,6 +438,9 @@ func getPeerNSGroups(account *Account, peerID string) []*nbdns.NameServerGroup {
 		}
 	}
 
+	// the map iteration order is random, the peers get the groups in the order they resolve the shared domains in
+	nbdns.SortNameServerGroups(peerNSGroups)
+
 	return peerNSGroups
 }
 
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"sort"
+
+	nbdns "github.com/netbirdio/netbird/dns"
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	// DNSLabelCollisionSuffix gives the peer its label with the lowest free numeric suffix, e.g. router-1
+	DNSLabelCollisionSuffix = "suffix"
+	// DNSLabelCollisionReject rejects the peer whose label is already used by another peer
+	DNSLabelCollisionReject = "reject"
+	// DNSLabelCollisionReplaceOldest gives the label to the peer and the lowest free numeric suffix to the peer that
+	// used it before
+	DNSLabelCollisionReplaceOldest = "replace-oldest"
+)
+
+// PeerDNSLabel is the DNS label of a peer, the peer is resolved as the label under the DNS domain
+type PeerDNSLabel struct {
+	PeerID   string
+	PeerName string
+	Label    string
+}
+
+// dnsLabelCollision describes a label requested by a peer and already used by another one
+type dnsLabelCollision struct {
+	// Label is the label the peer requested
+	Label string
+	// PeerID is the peer that used the label
+	PeerID string
+	// PeerLabel is the label of the peer that used the label after the collision
+	PeerLabel string
+}
+
+func validateDNSLabelCollisionStrategy(strategy string) error {
+	switch strategy {
+	case "", DNSLabelCollisionSuffix, DNSLabelCollisionReject, DNSLabelCollisionReplaceOldest:
+		return nil
+	default:
+		return status.Errorf(status.InvalidArgument, "invalid DNS label collision strategy %s, expected %s, %s or %s",
+			strategy, DNSLabelCollisionSuffix, DNSLabelCollisionReject, DNSLabelCollisionReplaceOldest)
+	}
+}
+
+// getPeerDNSLabel returns the DNS label of the peer named name following the collision strategy of the account, the
+// collision is nil when no other peer used the label. peerID is empty for a peer being added.
+// With the replace-oldest strategy the label of the peer that used it is changed.
+func (a *Account) getPeerDNSLabel(peerID, name string) (string, *dnsLabelCollision, error) {
+	label, err := nbdns.GetParsedDomainLabel(name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var holder *nbpeer.Peer
+	peerLabels := make(lookupMap)
+	for _, peer := range a.Peers {
+		if peer.ID == peerID || peer.DNSLabel == "" {
+			continue
+		}
+		peerLabels[peer.DNSLabel] = struct{}{}
+		if peer.DNSLabel == label {
+			holder = peer
+		}
+	}
+
+	if holder == nil {
+		return label, nil, nil
+	}
+
+	collision := &dnsLabelCollision{Label: label, PeerID: holder.ID, PeerLabel: label}
+	switch a.Settings.DNSLabelCollisionStrategy {
+	case DNSLabelCollisionReject:
+		return "", collision, status.Errorf(status.AlreadyExists, "DNS label %s is already used by peer %s", label, holder.Name)
+	case DNSLabelCollisionReplaceOldest:
+		holderLabel := getUniqueHostLabel(label, peerLabels)
+		if holderLabel == "" {
+			return "", collision, status.Errorf(status.PreconditionFailed, "couldn't find a unique valid label for peer %s", holder.Name)
+		}
+		holder.DNSLabel = holderLabel
+		collision.PeerLabel = holderLabel
+		return label, collision, nil
+	default:
+		uniqueLabel := getUniqueHostLabel(label, peerLabels)
+		if uniqueLabel == "" {
+			return "", collision, status.Errorf(status.PreconditionFailed, "couldn't find a unique valid label for %s, parsed label %s", name, label)
+		}
+		return uniqueLabel, collision, nil
+	}
+}
+
+// storeDNSLabelCollisionEvent records the collision of the label of the peer with another peer, if any. A peer without
+// a label was rejected, the event targets the colliding peer when the rejected peer wasn't added.
+func (am *DefaultAccountManager) storeDNSLabelCollisionEvent(initiatorID string, account *Account, peer *nbpeer.Peer, collision *dnsLabelCollision) {
+	if collision == nil {
+		return
+	}
+
+	targetID := peer.ID
+	if targetID == "" {
+		targetID = collision.PeerID
+	}
+
+	meta := peer.EventMeta(am.GetDNSDomain())
+	meta["requested_label"] = collision.Label
+	meta["rejected"] = peer.DNSLabel == ""
+	meta["strategy"] = account.Settings.DNSLabelCollisionStrategy
+	meta["colliding_peer_id"] = collision.PeerID
+	meta["colliding_peer_label"] = collision.PeerLabel
+	if peer.Key != "" {
+		meta["peer_key"] = peer.Key
+	}
+	am.StoreEvent(initiatorID, targetID, account.Id, activity.PeerDNSLabelCollision, meta)
+}
+
+// GetPeerDNSLabels returns the DNS labels of the peers of the account sorted by label.
+// Only users with admin power can view them.
+func (am *DefaultAccountManager) GetPeerDNSLabels(accountID, userID string) ([]PeerDNSLabel, error) {
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view the peer DNS labels")
+	}
+
+	labels := make([]PeerDNSLabel, 0, len(account.Peers))
+	for _, peer := range account.Peers {
+		labels = append(labels, PeerDNSLabel{PeerID: peer.ID, PeerName: peer.Name, Label: peer.DNSLabel})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Label < labels[j].Label
+	})
+	return labels, nil
+}
+
+// UpdatePeerDNSLabel changes the DNS label of the peer. The label must be a valid DNS label not used by another peer,
+// whatever the collision strategy of the account. Only users with admin power can change it.
+func (am *DefaultAccountManager) UpdatePeerDNSLabel(accountID, userID, peerID, label string) (*nbpeer.Peer, error) {
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasAdminPower() {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can change the peer DNS labels")
+	}
+
+	peer := account.GetPeer(peerID)
+	if peer == nil {
+		return nil, status.Errorf(status.NotFound, "peer %s not found", peerID)
+	}
+
+	parsedLabel, err := nbdns.GetParsedDomainLabel(label)
+	if err != nil || parsedLabel != label {
+		return nil, status.Errorf(status.InvalidArgument, "invalid DNS label %s", label)
+	}
+
+	if peer.DNSLabel == label {
+		return peer.Copy(), nil
+	}
+
+	for _, other := range account.Peers {
+		if other.ID != peer.ID && other.DNSLabel == label {
+			return nil, status.Errorf(status.AlreadyExists, "DNS label %s is already used by peer %s", label, other.Name)
+		}
+	}
+
+	meta := peer.EventMeta(am.GetDNSDomain())
+	meta["old_label"] = peer.DNSLabel
+	peer.DNSLabel = label
+	meta["label"] = label
+	meta["fqdn"] = peer.FQDN(am.GetDNSDomain())
+	// the peers have to receive the new DNS records even if nothing else changed
+	account.Network.IncSerial()
+
+	if err = am.Store.SaveAccount(account); err != nil {
+		return nil, err
+	}
+
+	am.StoreEvent(userID, peer.ID, accountID, activity.PeerDNSLabelUpdated, meta)
+
+	am.updateAccountPeers(account)
+
+	return peer.Copy(), nil
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestAccount_getPeerDNSLabel(t *testing.T) {
+	newAccount := func(strategy string) *Account {
+		account := newAccountWithId("test_account", userID, "")
+		account.Settings.DNSLabelCollisionStrategy = strategy
+		account.Peers["router"] = &nbpeer.Peer{ID: "router", Name: "router", DNSLabel: "router"}
+		account.Peers["router1"] = &nbpeer.Peer{ID: "router1", Name: "router", DNSLabel: "router-1"}
+		return account
+	}
+
+	t.Run("free label", func(t *testing.T) {
+		account := newAccount(DNSLabelCollisionReject)
+		label, collision, err := account.getPeerDNSLabel("", "Laptop")
+		require.NoError(t, err)
+		assert.Equal(t, "laptop", label)
+		assert.Nil(t, collision)
+	})
+
+	t.Run("own label", func(t *testing.T) {
+		account := newAccount(DNSLabelCollisionReject)
+		label, collision, err := account.getPeerDNSLabel("router", "Router")
+		require.NoError(t, err)
+		assert.Equal(t, "router", label, "a peer renamed to its own label shouldn't collide with itself")
+		assert.Nil(t, collision)
+	})
+
+	for _, strategy := range []string{"", DNSLabelCollisionSuffix} {
+		t.Run("suffix "+strategy, func(t *testing.T) {
+			account := newAccount(strategy)
+			label, collision, err := account.getPeerDNSLabel("", "router")
+			require.NoError(t, err)
+			assert.Equal(t, "router-2", label)
+			assert.Equal(t, &dnsLabelCollision{Label: "router", PeerID: "router", PeerLabel: "router"}, collision)
+		})
+	}
+
+	t.Run("reject", func(t *testing.T) {
+		account := newAccount(DNSLabelCollisionReject)
+		_, collision, err := account.getPeerDNSLabel("", "router")
+		sErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, status.AlreadyExists, sErr.Type())
+		assert.Equal(t, "router", collision.PeerID)
+	})
+
+	t.Run("replace oldest", func(t *testing.T) {
+		account := newAccount(DNSLabelCollisionReplaceOldest)
+		label, collision, err := account.getPeerDNSLabel("", "router")
+		require.NoError(t, err)
+		assert.Equal(t, "router", label)
+		assert.Equal(t, &dnsLabelCollision{Label: "router", PeerID: "router", PeerLabel: "router-2"}, collision)
+		assert.Equal(t, "router-2", account.Peers["router"].DNSLabel, "the peer that used the label should get a suffix")
+	})
+}
+
+func TestDefaultAccountManager_AddPeer_DNSLabelCollision(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account, err := manager.GetOrCreateAccountByUser(userID, "")
+	require.NoError(t, err)
+
+	addPeer := func() (*nbpeer.Peer, error) {
+		key, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+		peer, _, err := manager.AddPeer("", userID, &nbpeer.Peer{
+			Key:  key.PublicKey().String(),
+			Meta: nbpeer.PeerSystemMeta{Hostname: "router"},
+		})
+		return peer, err
+	}
+
+	first, err := addPeer()
+	require.NoError(t, err)
+	assert.Equal(t, "router", first.DNSLabel)
+
+	second, err := addPeer()
+	require.NoError(t, err)
+	assert.Equal(t, "router-1", second.DNSLabel, "the peers should get a suffix by default")
+
+	ev := getEvent(t, account.Id, manager, activity.PeerDNSLabelCollision)
+	assert.Equal(t, second.ID, ev.TargetID)
+	assert.Equal(t, first.ID, ev.Meta["colliding_peer_id"])
+
+	settings := account.Settings.Copy()
+	settings.DNSLabelCollisionStrategy = "rename"
+	_, err = manager.UpdateAccountSettings(account.Id, userID, settings)
+	require.Error(t, err, "unknown strategies should be rejected")
+
+	settings.DNSLabelCollisionStrategy = DNSLabelCollisionReject
+	_, err = manager.UpdateAccountSettings(account.Id, userID, settings)
+	require.NoError(t, err)
+
+	_, err = addPeer()
+	sErr, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.AlreadyExists, sErr.Type(), "the peer should be rejected")
+
+	settings.DNSLabelCollisionStrategy = DNSLabelCollisionReplaceOldest
+	_, err = manager.UpdateAccountSettings(account.Id, userID, settings)
+	require.NoError(t, err)
+
+	third, err := addPeer()
+	require.NoError(t, err)
+	assert.Equal(t, "router", third.DNSLabel, "the newest peer should get the label")
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "router-2", account.Peers[first.ID].DNSLabel)
+}
+
+func TestDefaultAccountManager_UpdatePeerDNSLabel(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account := newAccountWithId("test_account", userID, "")
+	account.Users["regularUser"] = NewRegularUser("regularUser")
+	account.Peers["router"] = &nbpeer.Peer{ID: "router", Key: "routerKey", Name: "router", DNSLabel: "router", Status: &nbpeer.PeerStatus{}}
+	account.Peers["laptop"] = &nbpeer.Peer{ID: "laptop", Key: "laptopKey", Name: "laptop", DNSLabel: "laptop", Status: &nbpeer.PeerStatus{}}
+	err = manager.Store.SaveAccount(account)
+	require.NoError(t, err)
+
+	labels, err := manager.GetPeerDNSLabels(account.Id, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []PeerDNSLabel{
+		{PeerID: "laptop", PeerName: "laptop", Label: "laptop"},
+		{PeerID: "router", PeerName: "router", Label: "router"},
+	}, labels)
+
+	_, err = manager.GetPeerDNSLabels(account.Id, "regularUser")
+	require.Error(t, err, "regular users shouldn't view the DNS labels")
+
+	_, err = manager.UpdatePeerDNSLabel(account.Id, "regularUser", "router", "gateway")
+	require.Error(t, err, "regular users shouldn't change the DNS labels")
+
+	_, err = manager.UpdatePeerDNSLabel(account.Id, userID, "router", "Gate_way")
+	require.Error(t, err, "invalid labels should be rejected")
+
+	_, err = manager.UpdatePeerDNSLabel(account.Id, userID, "router", "laptop")
+	sErr, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.AlreadyExists, sErr.Type(), "labels used by other peers should be rejected")
+
+	peer, err := manager.UpdatePeerDNSLabel(account.Id, userID, "router", "gateway")
+	require.NoError(t, err)
+	assert.Equal(t, "gateway", peer.DNSLabel)
+
+	ev := getEvent(t, account.Id, manager, activity.PeerDNSLabelUpdated)
+	assert.Equal(t, "router", ev.Meta["old_label"])
+	assert.Equal(t, "gateway", ev.Meta["label"])
+}
@@ -0,0 +1,365 @@
+package server
+
+import (
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/rs/xid"
+
+	nbdns "github.com/netbirdio/netbird/dns"
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	recordLabelPattern = `^(?i)[a-z0-9_]([a-z0-9_-]{0,61}[a-z0-9_])?$`
+	// maxRecordTTL is one week, the records are static and peers get updates through the network map anyway
+	maxRecordTTL = 604800
+	// maxTXTRecordLen limits the size of the TXT values distributed to every peer
+	maxTXTRecordLen = 1024
+)
+
+var recordLabelMatcher = regexp.MustCompile(recordLabelPattern)
+
+// GetDNSRecord gets a custom DNS record object from account and record IDs
+func (am *DefaultAccountManager) GetDNSRecord(accountID, userID, recordID string) (*nbdns.CustomRecord, error) {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view DNS records")
+	}
+
+	record, found := account.CustomDNSRecords[recordID]
+	if found {
+		return record.Copy(), nil
+	}
+
+	return nil, status.Errorf(status.NotFound, "DNS record with ID %s not found", recordID)
+}
+
+// CreateDNSRecord validates and saves a new custom DNS record
+func (am *DefaultAccountManager) CreateDNSRecord(accountID, userID string, record *nbdns.CustomRecord) (*nbdns.CustomRecord, error) {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	if record == nil {
+		return nil, status.Errorf(status.InvalidArgument, "DNS record provided is nil")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	newRecord := record.Copy()
+	newRecord.ID = xid.New().String()
+	newRecord.AccountID = accountID
+
+	err = validateDNSRecord(false, newRecord, account, am.dnsDomain)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.CustomDNSRecords == nil {
+		account.CustomDNSRecords = make(map[string]*nbdns.CustomRecord)
+	}
+
+	account.CustomDNSRecords[newRecord.ID] = newRecord
+
+	account.Network.IncSerial()
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return nil, err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, newRecord.ID, accountID, activity.DNSRecordCreated, newRecord.EventMeta())
+
+	return newRecord.Copy(), nil
+}
+
+// SaveDNSRecord validates and updates an existing custom DNS record
+func (am *DefaultAccountManager) SaveDNSRecord(accountID, userID string, recordToSave *nbdns.CustomRecord) error {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	if recordToSave == nil {
+		return status.Errorf(status.InvalidArgument, "DNS record provided is nil")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	record := recordToSave.Copy()
+	record.AccountID = accountID
+
+	err = validateDNSRecord(true, record, account, am.dnsDomain)
+	if err != nil {
+		return err
+	}
+
+	account.CustomDNSRecords[record.ID] = record
+
+	account.Network.IncSerial()
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, record.ID, accountID, activity.DNSRecordUpdated, record.EventMeta())
+
+	return nil
+}
+
+// DeleteDNSRecord deletes the custom DNS record with recordID
+func (am *DefaultAccountManager) DeleteDNSRecord(accountID, userID, recordID string) error {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	record := account.CustomDNSRecords[recordID]
+	if record == nil {
+		return status.Errorf(status.NotFound, "DNS record %s wasn't found", recordID)
+	}
+	delete(account.CustomDNSRecords, recordID)
+
+	account.Network.IncSerial()
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, record.ID, accountID, activity.DNSRecordDeleted, record.EventMeta())
+
+	return nil
+}
+
+// ListDNSRecords returns a list of the custom DNS records from account
+func (am *DefaultAccountManager) ListDNSRecords(accountID, userID string) ([]*nbdns.CustomRecord, error) {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view DNS records")
+	}
+
+	records := make([]*nbdns.CustomRecord, 0, len(account.CustomDNSRecords))
+	for _, item := range account.CustomDNSRecords {
+		records = append(records, item.Copy())
+	}
+
+	return records, nil
+}
+
+// validateDNSRecord checks the record against the account and normalizes its name, zone, type and TTL
+func validateDNSRecord(existingRecord bool, record *nbdns.CustomRecord, account *Account, dnsDomain string) error {
+	if existingRecord {
+		_, found := account.CustomDNSRecords[record.ID]
+		if !found {
+			return status.Errorf(status.NotFound, "DNS record with ID %s was not found", record.ID)
+		}
+	}
+
+	record.Type = strings.ToUpper(record.Type)
+	recordType := nbdns.RecordTypeFromString(record.Type)
+	if recordType == dns.TypeNone {
+		return status.Errorf(status.InvalidArgument, "unsupported DNS record type %q, supported types are A, AAAA, CNAME and TXT", record.Type)
+	}
+
+	record.Zone = strings.ToLower(strings.TrimSuffix(record.Zone, "."))
+	if record.Zone == "" && dnsDomain == "" {
+		return status.Errorf(status.InvalidArgument, "the management service has no DNS domain configured, the record requires a zone")
+	}
+	if record.Zone != "" {
+		if err := validateDomain(record.Zone); err != nil {
+			return status.Errorf(status.InvalidArgument, "DNS record got an invalid zone: %s %q", record.Zone, err)
+		}
+	}
+
+	record.Name = strings.ToLower(strings.TrimSuffix(record.Name, "."))
+	if record.Name == "" {
+		record.Name = nbdns.ZoneApex
+	}
+	if err := validateRecordName(record.Name); err != nil {
+		return err
+	}
+
+	if record.TTL == 0 {
+		record.TTL = defaultTTL
+	}
+	if record.TTL < 0 || record.TTL > maxRecordTTL {
+		return status.Errorf(status.InvalidArgument, "DNS record TTL should be between 1 and %d seconds", maxRecordTTL)
+	}
+
+	if err := validateRecordValue(recordType, record); err != nil {
+		return err
+	}
+
+	return validateRecordConflicts(recordType, record, account, dnsDomain)
+}
+
+func validateRecordName(name string) error {
+	if name == nbdns.ZoneApex {
+		return nil
+	}
+
+	if _, valid := dns.IsDomainName(name); !valid {
+		return status.Errorf(status.InvalidArgument, "invalid DNS record name %s", name)
+	}
+
+	for _, label := range dns.SplitDomainName(name) {
+		if !recordLabelMatcher.MatchString(label) {
+			return status.Errorf(status.InvalidArgument, "DNS record name %s should consist of letters, numbers, "+
+				"hyphens and underscores with no leading or trailing hyphens", name)
+		}
+	}
+
+	return nil
+}
+
+func validateRecordValue(recordType uint16, record *nbdns.CustomRecord) error {
+	switch recordType {
+	case dns.TypeA, dns.TypeAAAA:
+		addr, err := netip.ParseAddr(record.Value)
+		if err != nil || addr.Zone() != "" {
+			return status.Errorf(status.InvalidArgument, "DNS record value %q is not a valid IP address", record.Value)
+		}
+		if recordType == dns.TypeA && !addr.Is4() {
+			return status.Errorf(status.InvalidArgument, "A record value %s should be an IPv4 address", record.Value)
+		}
+		if recordType == dns.TypeAAAA && (!addr.Is6() || addr.Is4In6()) {
+			return status.Errorf(status.InvalidArgument, "AAAA record value %s should be an IPv6 address", record.Value)
+		}
+		record.Value = addr.String()
+	case dns.TypeCNAME:
+		record.Value = strings.ToLower(strings.TrimSuffix(record.Value, "."))
+		if labels, valid := dns.IsDomainName(record.Value); !valid || labels < 2 {
+			return status.Errorf(status.InvalidArgument, "CNAME record value %q should be a domain name with at least two labels", record.Value)
+		}
+	case dns.TypeTXT:
+		if record.Value == "" || len(record.Value) > maxTXTRecordLen {
+			return status.Errorf(status.InvalidArgument, "TXT record value should be between 1 and %d bytes", maxTXTRecordLen)
+		}
+	}
+
+	return nil
+}
+
+// validateRecordConflicts rejects records that would shadow peer names or break the CNAME exclusivity rule
+func validateRecordConflicts(recordType uint16, record *nbdns.CustomRecord, account *Account, dnsDomain string) error {
+	fqdn := record.FQDN(dnsDomain)
+
+	if recordType == dns.TypeCNAME {
+		if record.Name == nbdns.ZoneApex {
+			return status.Errorf(status.InvalidArgument, "CNAME records are not allowed at the zone apex")
+		}
+		if dns.Fqdn(record.Value) == fqdn {
+			return status.Errorf(status.InvalidArgument, "CNAME record %s points to itself", fqdn)
+		}
+	}
+
+	if recordType != dns.TypeTXT && record.ZoneFQDN(dnsDomain) == dns.Fqdn(strings.ToLower(dnsDomain)) {
+		for _, peer := range account.Peers {
+			if peer.DNSLabel != "" && dns.Fqdn(peer.DNSLabel+"."+dnsDomain) == fqdn {
+				return status.Errorf(status.InvalidArgument, "DNS record %s conflicts with the name of peer %s", fqdn, peer.Name)
+			}
+		}
+	}
+
+	for _, other := range account.CustomDNSRecords {
+		if other.ID == record.ID || other.FQDN(dnsDomain) != fqdn {
+			continue
+		}
+
+		otherType := nbdns.RecordTypeFromString(other.Type)
+		if recordType == dns.TypeCNAME || otherType == dns.TypeCNAME {
+			return status.Errorf(status.InvalidArgument, "DNS record %s already exists, a CNAME record can't coexist with other records", fqdn)
+		}
+		if otherType == recordType && other.Value == record.Value {
+			return status.Errorf(status.InvalidArgument, "an identical %s record %s already exists", record.Type, fqdn)
+		}
+	}
+
+	return nil
+}
+
+// mergeCustomRecordsZones adds the enabled custom records of the account to the zones distributed to peers,
+// records of the account DNS domain join the peers zone and other zones are appended
+func mergeCustomRecordsZones(zones []nbdns.CustomZone, account *Account, dnsDomain string) []nbdns.CustomZone {
+	records := make([]*nbdns.CustomRecord, 0, len(account.CustomDNSRecords))
+	for _, record := range account.CustomDNSRecords {
+		if record.Enabled {
+			records = append(records, record)
+		}
+	}
+	if len(records) == 0 {
+		return zones
+	}
+
+	// keep the network map stable between updates
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ID < records[j].ID
+	})
+
+	zoneIndex := make(map[string]int, len(zones))
+	for i, zone := range zones {
+		zoneIndex[strings.ToLower(zone.Domain)] = i
+	}
+
+	for _, record := range records {
+		zoneName := record.ZoneFQDN(dnsDomain)
+		if zoneName == "" {
+			continue
+		}
+
+		i, found := zoneIndex[zoneName]
+		if !found {
+			zones = append(zones, nbdns.CustomZone{Domain: zoneName})
+			i = len(zones) - 1
+			zoneIndex[zoneName] = i
+		}
+		zones[i].Records = append(zones[i].Records, record.ToSimpleRecord(dnsDomain))
+	}
+
+	return zones
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	nbdns "github.com/netbirdio/netbird/dns"
+)
+
+func TestCreateDNSRecord(t *testing.T) {
+	am, err := createNSManager(t)
+	require.NoError(t, err, "failed to create account manager")
+
+	account, err := initTestNSAccount(t, am)
+	require.NoError(t, err, "failed to init testing account")
+
+	stored, err := am.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	var peerLabel string
+	for _, peer := range stored.Peers {
+		peerLabel = peer.DNSLabel
+		break
+	}
+
+	testCases := []struct {
+		name          string
+		record        *nbdns.CustomRecord
+		shouldFail    bool
+		expectedFQDN  string
+		expectedValue string
+	}{
+		{
+			name:          "A record in the account domain",
+			record:        &nbdns.CustomRecord{Name: "NAS", Type: "a", Value: "192.168.1.10", Enabled: true},
+			expectedFQDN:  "nas.netbird.selfhosted.",
+			expectedValue: "192.168.1.10",
+		},
+		{
+			name:          "AAAA record in a custom zone",
+			record:        &nbdns.CustomRecord{Name: "nas", Zone: "home.arpa.", Type: nbdns.RecordTypeAAAA, Value: "fd00::0010", Enabled: true},
+			expectedFQDN:  "nas.home.arpa.",
+			expectedValue: "fd00::10",
+		},
+		{
+			name:          "TXT record at the zone apex",
+			record:        &nbdns.CustomRecord{Name: nbdns.ZoneApex, Zone: "home.arpa", Type: nbdns.RecordTypeTXT, Value: "v=spf1 -all", Enabled: true},
+			expectedFQDN:  "home.arpa.",
+			expectedValue: "v=spf1 -all",
+		},
+		{
+			name:          "CNAME record",
+			record:        &nbdns.CustomRecord{Name: "files", Zone: "home.arpa", Type: nbdns.RecordTypeCNAME, Value: "NAS.home.arpa.", Enabled: true},
+			expectedFQDN:  "files.home.arpa.",
+			expectedValue: "nas.home.arpa",
+		},
+		{
+			name:       "unsupported type",
+			record:     &nbdns.CustomRecord{Name: "mail", Type: "MX", Value: "mail.example.com", Enabled: true},
+			shouldFail: true,
+		},
+		{
+			name:       "IPv6 value for an A record",
+			record:     &nbdns.CustomRecord{Name: "bad", Type: nbdns.RecordTypeA, Value: "fd00::1", Enabled: true},
+			shouldFail: true,
+		},
+		{
+			name:       "invalid name",
+			record:     &nbdns.CustomRecord{Name: "-bad-", Type: nbdns.RecordTypeA, Value: "10.0.0.1", Enabled: true},
+			shouldFail: true,
+		},
+		{
+			name:       "invalid zone",
+			record:     &nbdns.CustomRecord{Name: "host", Zone: "local", Type: nbdns.RecordTypeA, Value: "10.0.0.1", Enabled: true},
+			shouldFail: true,
+		},
+		{
+			name:       "TTL over the limit",
+			record:     &nbdns.CustomRecord{Name: "host", Type: nbdns.RecordTypeA, Value: "10.0.0.1", TTL: maxRecordTTL + 1, Enabled: true},
+			shouldFail: true,
+		},
+		{
+			name:       "CNAME at the zone apex",
+			record:     &nbdns.CustomRecord{Name: nbdns.ZoneApex, Zone: "corp.internal", Type: nbdns.RecordTypeCNAME, Value: "example.com", Enabled: true},
+			shouldFail: true,
+		},
+		{
+			name:       "CNAME next to an existing record",
+			record:     &nbdns.CustomRecord{Name: "nas", Zone: "home.arpa", Type: nbdns.RecordTypeCNAME, Value: "example.com", Enabled: true},
+			shouldFail: true,
+		},
+		{
+			name:       "record next to an existing CNAME",
+			record:     &nbdns.CustomRecord{Name: "files", Zone: "home.arpa", Type: nbdns.RecordTypeA, Value: "10.0.0.1", Enabled: true},
+			shouldFail: true,
+		},
+		{
+			name:       "duplicated record",
+			record:     &nbdns.CustomRecord{Name: "nas", Type: nbdns.RecordTypeA, Value: "192.168.1.10", Enabled: true},
+			shouldFail: true,
+		},
+		{
+			name:       "record shadowing a peer name",
+			record:     &nbdns.CustomRecord{Name: peerLabel, Type: nbdns.RecordTypeA, Value: "10.0.0.1", Enabled: true},
+			shouldFail: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			record, err := am.CreateDNSRecord(account.Id, testUserID, testCase.record)
+			if testCase.shouldFail {
+				require.Error(t, err, "should fail creating the record")
+				return
+			}
+			require.NoError(t, err, "should create the record")
+
+			assert.NotEmpty(t, record.ID)
+			assert.Equal(t, testCase.expectedFQDN, record.FQDN(am.GetDNSDomain()))
+			assert.Equal(t, testCase.expectedValue, record.Value)
+			assert.Equal(t, defaultTTL, record.TTL)
+		})
+	}
+}
+
+func TestSaveAndDeleteDNSRecord(t *testing.T) {
+	am, err := createNSManager(t)
+	require.NoError(t, err, "failed to create account manager")
+
+	account, err := initTestNSAccount(t, am)
+	require.NoError(t, err, "failed to init testing account")
+
+	record, err := am.CreateDNSRecord(account.Id, testUserID, &nbdns.CustomRecord{
+		Name: "nas", Zone: "home.arpa", Type: nbdns.RecordTypeA, Value: "192.168.1.10", Enabled: true,
+	})
+	require.NoError(t, err)
+
+	record.Value = "192.168.1.20"
+	record.TTL = 60
+	require.NoError(t, am.SaveDNSRecord(account.Id, testUserID, record))
+
+	saved, err := am.GetDNSRecord(account.Id, testUserID, record.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "192.168.1.20", saved.Value)
+	assert.Equal(t, 60, saved.TTL)
+
+	missing := record.Copy()
+	missing.ID = "missing"
+	assert.Error(t, am.SaveDNSRecord(account.Id, testUserID, missing), "should fail saving an unknown record")
+
+	records, err := am.ListDNSRecords(account.Id, testUserID)
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	require.NoError(t, am.DeleteDNSRecord(account.Id, testUserID, record.ID))
+	_, err = am.GetDNSRecord(account.Id, testUserID, record.ID)
+	assert.Error(t, err, "record should be deleted")
+	assert.Error(t, am.DeleteDNSRecord(account.Id, testUserID, record.ID), "should fail deleting a deleted record")
+}
+
+func TestDNSRecordsNetworkMap(t *testing.T) {
+	am, err := createNSManager(t)
+	require.NoError(t, err, "failed to create account manager")
+
+	account, err := initTestNSAccount(t, am)
+	require.NoError(t, err, "failed to init testing account")
+
+	records := []*nbdns.CustomRecord{
+		{Name: "nas", Type: nbdns.RecordTypeA, Value: "192.168.1.10", Enabled: true},
+		{Name: "printer", Zone: "home.arpa", Type: nbdns.RecordTypeA, Value: "192.168.1.20", Enabled: true},
+		{Name: "files", Zone: "home.arpa", Type: nbdns.RecordTypeCNAME, Value: "nas.netbird.selfhosted", Enabled: true},
+		{Name: "disabled", Zone: "corp.internal", Type: nbdns.RecordTypeTXT, Value: "hidden", Enabled: false},
+	}
+	for _, record := range records {
+		_, err = am.CreateDNSRecord(account.Id, testUserID, record)
+		require.NoError(t, err)
+	}
+
+	stored, err := am.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+
+	var peerID string
+	for id := range stored.Peers {
+		peerID = id
+		break
+	}
+
+	networkMap := stored.GetPeerNetworkMap(peerID, am.GetDNSDomain(), validatedPeers(stored))
+	zones := make(map[string]nbdns.CustomZone)
+	for _, zone := range networkMap.DNSConfig.CustomZones {
+		zones[zone.Domain] = zone
+	}
+
+	require.Len(t, zones, 3, "disabled records should not create zones")
+
+	accountZone := zones["netbird.selfhosted."]
+	assert.Contains(t, accountZone.Records, nbdns.SimpleRecord{
+		Name: "nas.netbird.selfhosted.", Type: int(dns.TypeA), Class: nbdns.DefaultClass, TTL: defaultTTL, RData: "192.168.1.10",
+	})
+	assert.Len(t, accountZone.Records, len(stored.Peers)+1, "the custom record should join the peers zone")
+
+	homeZone := zones["home.arpa."]
+	assert.ElementsMatch(t, []nbdns.SimpleRecord{
+		{Name: "printer.home.arpa.", Type: int(dns.TypeA), Class: nbdns.DefaultClass, TTL: defaultTTL, RData: "192.168.1.20"},
+		{Name: "files.home.arpa.", Type: int(dns.TypeCNAME), Class: nbdns.DefaultClass, TTL: defaultTTL, RData: "nas.netbird.selfhosted."},
+	}, homeZone.Records)
+}
+
+func validatedPeers(account *Account) map[string]struct{} {
+	validated := make(map[string]struct{}, len(account.Peers))
+	for id := range account.Peers {
+		validated[id] = struct{}{}
+	}
+	return validated
+}
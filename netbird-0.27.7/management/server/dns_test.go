@@ -1,8 +1,12 @@
 package server
 
 import (
+	"net"
 	"net/netip"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -109,6 +113,59 @@ func TestSaveDNSSettings(t *testing.T) {
 			},
 			shouldFail: true,
 		},
+		{
+			name:   "Saving Blocklists Should Be OK",
+			userID: dnsAdminUserID,
+			inputSettings: &DNSSettings{
+				Blocklists:               []dns.Blocklist{{Name: "ads", URL: "https://example.com/hosts", Enabled: true}},
+				BlocklistResponse:        dns.BlockResponseZeroIP,
+				BlocklistRefreshInterval: time.Hour,
+				BlocklistOverrideGroups:  []string{dnsGroup2ID},
+			},
+		},
+		{
+			name:   "Should Not Update Settings If Blocklist URL Is Invalid",
+			userID: dnsAdminUserID,
+			inputSettings: &DNSSettings{
+				Blocklists: []dns.Blocklist{{Name: "ads", URL: "ftp://example.com/hosts", Enabled: true}},
+			},
+			shouldFail: true,
+		},
+		{
+			name:   "Should Not Update Settings If Blocklist URL Is Duplicated",
+			userID: dnsAdminUserID,
+			inputSettings: &DNSSettings{
+				Blocklists: []dns.Blocklist{
+					{Name: "ads", URL: "https://example.com/hosts", Enabled: true},
+					{Name: "ads copy", URL: "https://example.com/hosts", Enabled: true},
+				},
+			},
+			shouldFail: true,
+		},
+		{
+			name:   "Should Not Update Settings If Block Response Is Invalid",
+			userID: dnsAdminUserID,
+			inputSettings: &DNSSettings{
+				BlocklistResponse: "refused",
+			},
+			shouldFail: true,
+		},
+		{
+			name:   "Should Not Update Settings If Blocklist Refresh Interval Is Too Short",
+			userID: dnsAdminUserID,
+			inputSettings: &DNSSettings{
+				BlocklistRefreshInterval: time.Minute,
+			},
+			shouldFail: true,
+		},
+		{
+			name:   "Should Not Update Settings If Blocklist Override Group Is Invalid",
+			userID: dnsAdminUserID,
+			inputSettings: &DNSSettings{
+				BlocklistOverrideGroups: []string{"non-existing-group"},
+			},
+			shouldFail: true,
+		},
 	}
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
@@ -137,6 +194,9 @@ func TestSaveDNSSettings(t *testing.T) {
 
 			require.ElementsMatchf(t, testCase.inputSettings.DisabledManagementGroups, updatedAccount.DNSSettings.DisabledManagementGroups,
 				"resulting DNS settings should match input")
+			require.Equal(t, testCase.inputSettings.Blocklists, updatedAccount.DNSSettings.Blocklists)
+			require.Equal(t, testCase.inputSettings.BlocklistResponse, updatedAccount.DNSSettings.BlocklistResponse)
+			require.Equal(t, testCase.inputSettings.BlocklistRefreshInterval, updatedAccount.DNSSettings.BlocklistRefreshInterval)
 
 		})
 	}
@@ -166,7 +226,7 @@ func TestGetNetworkMap_DNSConfigSync(t *testing.T) {
 
 	newAccountDNSConfig, err := am.GetNetworkMap(peer1.ID)
 	require.NoError(t, err)
-	require.Len(t, newAccountDNSConfig.DNSConfig.CustomZones, 1, "default DNS config should have one custom zone for peers")
+	require.Len(t, newAccountDNSConfig.DNSConfig.CustomZones, 2, "default DNS config should have the peers zone and its reverse zone")
 	require.True(t, newAccountDNSConfig.DNSConfig.ServiceEnable, "default DNS config should have local DNS service enabled")
 	require.Len(t, newAccountDNSConfig.DNSConfig.NameServerGroups, 0, "updated DNS config should have no nameserver groups since peer 1 is NS for the only existing NS group")
 
@@ -182,11 +242,36 @@ func TestGetNetworkMap_DNSConfigSync(t *testing.T) {
 	require.False(t, updatedAccountDNSConfig.DNSConfig.ServiceEnable, "updated DNS config should have local DNS service disabled when peer belongs to a disabled group")
 	peer2AccountDNSConfig, err := am.GetNetworkMap(peer2.ID)
 	require.NoError(t, err)
-	require.Len(t, peer2AccountDNSConfig.DNSConfig.CustomZones, 1, "DNS config should have one custom zone for peers not in the disabled group")
+	require.Len(t, peer2AccountDNSConfig.DNSConfig.CustomZones, 2, "DNS config should have the peers zones for peers not in the disabled group")
 	require.True(t, peer2AccountDNSConfig.DNSConfig.ServiceEnable, "DNS config should have DNS service enabled for peers not in the disabled group")
 	require.Len(t, peer2AccountDNSConfig.DNSConfig.NameServerGroups, 1, "updated DNS config should have 1 nameserver groups since peer 2 is part of the group All")
 }
 
+func TestGetPeersReverseZone(t *testing.T) {
+	account := &Account{
+		Id: dnsAccountID,
+		Network: &Network{
+			Net: net.IPNet{IP: net.ParseIP("100.72.0.0").To4(), Mask: net.CIDRMask(16, 32)},
+		},
+		Peers: map[string]*nbpeer.Peer{
+			"peer1": {ID: "peer1", IP: net.ParseIP("100.72.1.2").To4(), DNSLabel: "peer1"},
+			"peer2": {ID: "peer2", IP: net.ParseIP("100.72.3.4").To4()},
+		},
+	}
+
+	zone := getPeersReverseZone(account, "netbird.test", nil)
+	require.Equal(t, "72.100.in-addr.arpa.", zone.Domain)
+	require.Equal(t, []dns.SimpleRecord{{
+		Name:  "2.1.72.100.in-addr.arpa.",
+		Type:  12,
+		Class: dns.DefaultClass,
+		TTL:   defaultTTL,
+		RData: "peer1.netbird.test.",
+	}}, zone.Records, "should skip the peers without DNS label")
+
+	require.Empty(t, getPeersReverseZone(account, "", nil).Domain, "should not build the zone without DNS domain")
+}
+
 func createDNSManager(t *testing.T) (*DefaultAccountManager, error) {
 	t.Helper()
 	store, err := createDNSStore(t)
@@ -318,3 +403,145 @@ func initTestDNSAccount(t *testing.T, am *DefaultAccountManager) (*Account, erro
 
 	return am.Store.GetAccount(account.Id)
 }
+
+func TestExportDNSZones(t *testing.T) {
+	am, err := createDNSManager(t)
+	require.NoError(t, err, "failed to create the account manager")
+
+	account, err := initTestDNSAccount(t, am)
+	require.NoError(t, err, "failed to init the testing account")
+
+	zones, err := am.ExportDNSZones(account.Id, dnsAdminUserID)
+	require.NoError(t, err, "should export the zones for an admin")
+	require.Len(t, zones, 2, "should export the peers zone and its reverse zone")
+	require.True(t, dns.IsReverseZone(zones[1].Domain))
+	for _, zone := range zones {
+		require.True(t, slices.IsSortedFunc(zone.Records, func(a, b dns.SimpleRecord) int {
+			return strings.Compare(a.Name, b.Name)
+		}), "the records of zone %s should be sorted by name", zone.Domain)
+	}
+
+	_, err = am.ExportDNSZones(account.Id, dnsRegularUserID)
+	require.Error(t, err, "should not export the zones for a regular user")
+}
+
+func TestGetPeerNetworkMap_DNSScopedGroups(t *testing.T) {
+	account := &Account{
+		Id: dnsAccountID,
+		Network: &Network{
+			Net: net.IPNet{IP: net.ParseIP("100.72.0.0").To4(), Mask: net.CIDRMask(16, 32)},
+		},
+		Peers: map[string]*nbpeer.Peer{
+			"contractor": {ID: "contractor", IP: net.ParseIP("100.72.0.1").To4(), DNSLabel: "contractor"},
+			"server":     {ID: "server", IP: net.ParseIP("100.72.0.2").To4(), DNSLabel: "server"},
+			"laptop":     {ID: "laptop", IP: net.ParseIP("100.72.0.3").To4(), DNSLabel: "laptop"},
+		},
+		Groups: map[string]*group.Group{
+			"contractors": {ID: "contractors", Name: "contractors", Peers: []string{"contractor"}},
+			"servers":     {ID: "servers", Name: "servers", Peers: []string{"server"}},
+			"staff":       {ID: "staff", Name: "staff", Peers: []string{"laptop"}},
+		},
+		Policies: []*Policy{
+			{
+				ID:      "contractors-servers",
+				Enabled: true,
+				Rules: []*PolicyRule{{
+					ID:            "contractors-servers",
+					Enabled:       true,
+					Action:        PolicyTrafficActionAccept,
+					Sources:       []string{"contractors"},
+					Destinations:  []string{"servers"},
+					Bidirectional: true,
+					Protocol:      PolicyRuleProtocolALL,
+				}},
+			},
+		},
+		Settings: &Settings{DNSScopedGroups: []string{"contractors"}},
+	}
+	validatedPeers := map[string]struct{}{"contractor": {}, "server": {}, "laptop": {}}
+
+	recordNames := func(zone dns.CustomZone) []string {
+		var names []string
+		for _, record := range zone.Records {
+			names = append(names, record.Name)
+		}
+		return names
+	}
+
+	networkMap := account.GetPeerNetworkMap("contractor", "netbird.test", validatedPeers)
+	require.Len(t, networkMap.DNSConfig.CustomZones, 2)
+	require.ElementsMatch(t, []string{"contractor.netbird.test.", "server.netbird.test."}, recordNames(networkMap.DNSConfig.CustomZones[0]),
+		"should only resolve the reachable peers")
+	require.ElementsMatch(t, []string{"1.0.72.100.in-addr.arpa.", "2.0.72.100.in-addr.arpa."}, recordNames(networkMap.DNSConfig.CustomZones[1]),
+		"should only resolve the addresses of the reachable peers")
+
+	networkMap = account.GetPeerNetworkMap("laptop", "netbird.test", validatedPeers)
+	require.Len(t, networkMap.DNSConfig.CustomZones, 2)
+	require.Len(t, networkMap.DNSConfig.CustomZones[0].Records, 3, "should resolve all the peers outside of the scoped groups")
+}
+
+func TestGetPeerNetworkMap_DNSBlocklist(t *testing.T) {
+	account := &Account{
+		Id: dnsAccountID,
+		Network: &Network{
+			Net: net.IPNet{IP: net.ParseIP("100.72.0.0").To4(), Mask: net.CIDRMask(16, 32)},
+		},
+		Peers: map[string]*nbpeer.Peer{
+			"router": {ID: "router", IP: net.ParseIP("100.72.0.1").To4(), DNSLabel: "router"},
+			"admin":  {ID: "admin", IP: net.ParseIP("100.72.0.2").To4(), DNSLabel: "admin"},
+		},
+		Groups: map[string]*group.Group{
+			"routers": {ID: "routers", Name: "routers", Peers: []string{"router"}},
+			"admins":  {ID: "admins", Name: "admins", Peers: []string{"admin"}},
+		},
+		Settings: &Settings{},
+		DNSSettings: DNSSettings{
+			Blocklists: []dns.Blocklist{
+				{Name: "ads", URL: "https://example.com/ads", Enabled: true},
+				{Name: "tracking", URL: "https://example.com/tracking", Enabled: false},
+			},
+			BlocklistResponse:        dns.BlockResponseZeroIP,
+			BlocklistRefreshInterval: time.Hour,
+			BlocklistOverrideGroups:  []string{"admins"},
+		},
+	}
+	validatedPeers := map[string]struct{}{"router": {}, "admin": {}}
+
+	networkMap := account.GetPeerNetworkMap("router", "netbird.test", validatedPeers)
+	require.Equal(t, dns.BlocklistConfig{
+		URLs:            []string{"https://example.com/ads"},
+		Response:        dns.BlockResponseZeroIP,
+		RefreshInterval: time.Hour,
+	}, networkMap.DNSConfig.Blocklist, "should only distribute the enabled lists")
+
+	networkMap = account.GetPeerNetworkMap("admin", "netbird.test", validatedPeers)
+	require.Empty(t, networkMap.DNSConfig.Blocklist.URLs, "should not filter the peers of the override groups")
+
+	protoConfig := toProtocolDNSConfig(account.GetPeerNetworkMap("router", "netbird.test", validatedPeers).DNSConfig)
+	require.Equal(t, []string{"https://example.com/ads"}, protoConfig.Blocklist.URLs)
+	require.Equal(t, time.Hour, protoConfig.Blocklist.RefreshInterval.AsDuration())
+}
+
+func TestGetPeerNSGroups_Priority(t *testing.T) {
+	nameServers := []dns.NameServer{{IP: netip.MustParseAddr("1.1.1.1"), NSType: dns.UDPNameServerType, Port: dns.DefaultDNSPort}}
+	account := &Account{
+		Peers: map[string]*nbpeer.Peer{
+			"peer": {ID: "peer", IP: net.ParseIP("100.72.0.1").To4()},
+		},
+		Groups: map[string]*group.Group{
+			"all": {ID: "all", Name: "All", Peers: []string{"peer"}},
+		},
+		NameServerGroups: map[string]*dns.NameServerGroup{
+			"c": {ID: "c", NameServers: nameServers, Groups: []string{"all"}, Domains: []string{"corp.example.com"}, Enabled: true, Priority: 10},
+			"b": {ID: "b", NameServers: nameServers, Groups: []string{"all"}, Domains: []string{"corp.example.com"}, Enabled: true, Priority: 20},
+			"a": {ID: "a", NameServers: nameServers, Groups: []string{"all"}, Domains: []string{"corp.example.com"}, Enabled: true, Priority: 20},
+			"d": {ID: "d", NameServers: nameServers, Groups: []string{"all"}, Primary: true, Enabled: true},
+		},
+	}
+
+	var ids []string
+	for _, nsGroup := range getPeerNSGroups(account, "peer") {
+		ids = append(ids, nsGroup.ID)
+	}
+	require.Equal(t, []string{"d", "c", "a", "b"}, ids, "should order the groups by priority and ID")
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -12,6 +13,8 @@ import (
 
 const (
 	ephemeralLifeTime = 10 * time.Minute
+	// ephemeralSweepInterval is how often the leader looks for the expired ephemeral peers in the store
+	ephemeralSweepInterval = time.Minute
 )
 
 var (
@@ -38,6 +41,10 @@ type EphemeralManager struct {
 	tailPeer  *ephemeralPeer
 	peersLock sync.Mutex
 	timer     *time.Timer
+
+	// election lets only the leader delete the ephemeral peers, every instance deletes the peers that disconnected from
+	// it when nil
+	election *LeaderElection
 }
 
 // NewEphemeralManager instantiate new EphemeralManager
@@ -61,6 +68,30 @@ func (e *EphemeralManager) LoadInitialPeers() {
 	}
 }
 
+// EnableLeaderElection makes the leader delete the ephemeral peers instead of the instances they disconnected from.
+// A peer disconnecting from one instance may reconnect to another one, so the leader sweeps the store for the peers
+// disconnected for ephemeralLifeTime until the context is done. It replaces LoadInitialPeers
+func (e *EphemeralManager) EnableLeaderElection(ctx context.Context, election *LeaderElection) {
+	e.peersLock.Lock()
+	e.election = election
+	e.peersLock.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(ephemeralSweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if election.IsLeader() {
+					e.sweep()
+				}
+			}
+		}
+	}()
+}
+
 // Stop timer
 func (e *EphemeralManager) Stop() {
 	e.peersLock.Lock()
@@ -99,6 +130,13 @@ func (e *EphemeralManager) OnPeerDisconnected(peer *nbpeer.Peer) {
 		return
 	}
 
+	e.peersLock.Lock()
+	swept := e.election != nil
+	e.peersLock.Unlock()
+	if swept {
+		return
+	}
+
 	log.Tracef("add peer to ephemeral list: %s", peer.ID)
 
 	a, err := e.store.GetAccountByPeerID(peer.ID)
@@ -170,6 +208,23 @@ func (e *EphemeralManager) cleanup() {
 	}
 }
 
+// sweep deletes the ephemeral peers the store tells are disconnected for ephemeralLifeTime
+func (e *EphemeralManager) sweep() {
+	deadline := timeNow().Add(-ephemeralLifeTime)
+	for _, account := range e.store.GetAllAccounts() {
+		for id, p := range account.Peers {
+			if !p.Ephemeral || p.Status == nil || p.Status.Connected || p.Status.LastSeen.After(deadline) {
+				continue
+			}
+			log.Debugf("delete ephemeral peer: %s", id)
+			err := e.accountManager.DeletePeer(account.Id, id, activity.SystemInitiator)
+			if err != nil {
+				log.Errorf("failed to delete ephemeral peer: %s", err)
+			}
+		}
+	}
+}
+
 func (e *EphemeralManager) addPeer(id string, account *Account, deadline time.Time) {
 	ep := &ephemeralPeer{
 		id:       id,
@@ -5,6 +5,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+
 	nbpeer "github.com/netbirdio/netbird/management/server/peer"
 )
 
@@ -142,3 +144,29 @@ func seedPeers(store *MockStore, numberOfPeers int, numberOfEphemeralPeers int)
 		store.account.Peers[p.ID] = p
 	}
 }
+
+func TestEphemeralManager_Sweep(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time {
+		return now
+	}
+	defer func() { timeNow = time.Now }()
+
+	store := &MockStore{account: newAccountWithId("account", "user", "")}
+	store.account.Peers["expired"] = &nbpeer.Peer{ID: "expired", Ephemeral: true,
+		Status: &nbpeer.PeerStatus{LastSeen: now.Add(-ephemeralLifeTime - time.Second)}}
+	store.account.Peers["recent"] = &nbpeer.Peer{ID: "recent", Ephemeral: true,
+		Status: &nbpeer.PeerStatus{LastSeen: now.Add(-time.Minute)}}
+	store.account.Peers["connected"] = &nbpeer.Peer{ID: "connected", Ephemeral: true,
+		Status: &nbpeer.PeerStatus{Connected: true, LastSeen: now.Add(-time.Hour)}}
+	store.account.Peers["permanent"] = &nbpeer.Peer{ID: "permanent",
+		Status: &nbpeer.PeerStatus{LastSeen: now.Add(-time.Hour)}}
+
+	mgr := NewEphemeralManager(store, MocAccountManager{store: store})
+	mgr.sweep()
+
+	assert.NotContains(t, store.account.Peers, "expired", "the ephemeral peer disconnected for its lifetime should be deleted")
+	assert.Contains(t, store.account.Peers, "recent")
+	assert.Contains(t, store.account.Peers, "connected")
+	assert.Contains(t, store.account.Peers, "permanent")
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/activity/archive"
+)
+
+const (
+	// DefaultEventsPruneInterval is how often the expired activity events are pruned when no interval is configured
+	DefaultEventsPruneInterval = time.Hour
+
+	// minEventsRetention is the smallest retention of the activity events an account can set
+	minEventsRetention = 24 * time.Hour
+)
+
+// EventsPruner periodically deletes the activity events older than the retention of their account, exporting them to
+// the archive first when an exporter is set. The events of an account are kept when their export fails so that they
+// are archived on the next run instead of being lost.
+type EventsPruner struct {
+	store      Store
+	eventStore activity.Store
+	exporter   archive.Exporter
+	interval   time.Duration
+	// election lets only the leader prune the events, every instance prunes them when nil
+	election *LeaderElection
+
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+// NewEventsPruner instantiates a new EventsPruner. The exporter can be nil, the expired events are deleted without
+// being archived then
+func NewEventsPruner(store Store, eventStore activity.Store, exporter archive.Exporter, interval time.Duration) *EventsPruner {
+	if interval <= 0 {
+		interval = DefaultEventsPruneInterval
+	}
+	return &EventsPruner{
+		store:      store,
+		eventStore: eventStore,
+		exporter:   exporter,
+		interval:   interval,
+	}
+}
+
+// EnableLeaderElection makes only the leader prune the events, it has to be called before Start
+func (p *EventsPruner) EnableLeaderElection(election *LeaderElection) {
+	p.election = election
+}
+
+// Start prunes the expired events right away and then on every interval
+func (p *EventsPruner) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.timer = time.AfterFunc(0, p.run)
+}
+
+// Stop timer
+func (p *EventsPruner) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+}
+
+func (p *EventsPruner) run() {
+	if p.election.IsLeader() {
+		p.prune()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.timer != nil {
+		p.timer.Reset(p.interval)
+	}
+}
+
+// prune deletes the expired events of all the accounts with a retention
+func (p *EventsPruner) prune() {
+	now := timeNow()
+	for _, account := range p.store.GetAllAccounts() {
+		if account.Settings == nil || account.Settings.EventsRetention <= 0 {
+			continue
+		}
+
+		if err := p.pruneAccount(account.Id, now.Add(-account.Settings.EventsRetention)); err != nil {
+			log.Errorf("failed to prune the activity events of account %s: %v", account.Id, err)
+		}
+	}
+}
+
+func (p *EventsPruner) pruneAccount(accountID string, before time.Time) error {
+	if p.exporter != nil {
+		events, err := p.eventStore.GetBefore(accountID, before)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		if err := p.exporter.Export(accountID, events); err != nil {
+			return err
+		}
+		log.Debugf("archived %d activity events of account %s", len(events), accountID)
+	}
+
+	deleted, err := p.eventStore.DeleteBefore(accountID, before)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Infof("pruned %d activity events of account %s older than %s", deleted, accountID, before.Format(time.RFC3339))
+	}
+	return nil
+}
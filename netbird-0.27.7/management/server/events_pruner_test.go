@@ -0,0 +1,90 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+)
+
+type mockExporter struct {
+	exported map[string][]*activity.Event
+	err      error
+}
+
+func (e *mockExporter) Export(accountID string, events []*activity.Event) error {
+	if e.err != nil {
+		return e.err
+	}
+	e.exported[accountID] = append(e.exported[accountID], events...)
+	return nil
+}
+
+func TestEventsPruner_prune(t *testing.T) {
+	store, err := createStore(t)
+	require.NoError(t, err)
+
+	retained := newAccountWithId("retained", userID, "")
+	retained.Settings.EventsRetention = 7 * 24 * time.Hour
+	require.NoError(t, store.SaveAccount(retained))
+	require.NoError(t, store.SaveAccount(newAccountWithId("forever", userID, "")))
+
+	eventStore := &activity.InMemoryEventStore{}
+	for _, accountID := range []string{"retained", "forever"} {
+		for _, age := range []time.Duration{time.Hour, 10 * 24 * time.Hour, 30 * 24 * time.Hour} {
+			_, err = eventStore.Save(&activity.Event{
+				Timestamp: time.Now().UTC().Add(-age),
+				Activity:  activity.PeerAddedByUser,
+				TargetID:  fmt.Sprintf("peer-%s", age),
+				AccountID: accountID,
+			})
+			require.NoError(t, err)
+		}
+	}
+
+	exporter := &mockExporter{exported: map[string][]*activity.Event{}, err: fmt.Errorf("bucket unreachable")}
+	pruner := NewEventsPruner(store, eventStore, exporter, 0)
+	pruner.prune()
+
+	events, err := eventStore.Get("retained", 0, 10, false)
+	require.NoError(t, err)
+	assert.Len(t, events, 3, "the events should be kept when their export fails")
+
+	exporter.err = nil
+	pruner.prune()
+
+	events, err = eventStore.Get("retained", 0, 10, false)
+	require.NoError(t, err)
+	assert.Len(t, events, 1, "the events older than the retention should be pruned")
+	assert.Len(t, exporter.exported["retained"], 2, "the pruned events should be archived")
+
+	events, err = eventStore.Get("forever", 0, 10, false)
+	require.NoError(t, err)
+	assert.Len(t, events, 3, "the events of an account without retention should be kept")
+	assert.Empty(t, exporter.exported["forever"])
+}
+
+func TestDefaultAccountManager_UpdateAccountSettings_EventsRetention(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account, err := manager.GetOrCreateAccountByUser(userID, "")
+	require.NoError(t, err)
+
+	settings := account.Settings.Copy()
+	settings.EventsRetention = time.Hour
+	_, err = manager.UpdateAccountSettings(account.Id, userID, settings)
+	require.Error(t, err, "a retention shorter than a day should be rejected")
+
+	settings.EventsRetention = 90 * 24 * time.Hour
+	updated, err := manager.UpdateAccountSettings(account.Id, userID, settings)
+	require.NoError(t, err)
+	assert.Equal(t, 90*24*time.Hour, updated.Settings.EventsRetention)
+
+	ev := getEvent(t, account.Id, manager, activity.AccountEventsRetentionUpdated)
+	assert.Equal(t, "2160h0m0s", ev.Meta["retention"])
+}
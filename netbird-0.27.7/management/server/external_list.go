@@ -0,0 +1,817 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/xid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/status"
+	"github.com/netbirdio/netbird/management/server/telemetry"
+)
+
+const (
+	// ExternalListFormatCIDR is a plain text list with one IPv4 address or CIDR per line, text after # or ; is a comment
+	ExternalListFormatCIDR = "cidr"
+	// ExternalListFormatMISP is the JSON response of the MISP attributes restSearch API, the ip-src, ip-dst,
+	// ip-src|port and ip-dst|port attributes are used
+	ExternalListFormatMISP = "misp"
+)
+
+const (
+	// DefaultExternalListRefreshInterval is the refresh interval of the external lists created without one
+	DefaultExternalListRefreshInterval = time.Hour
+	minExternalListRefreshInterval     = 5 * time.Minute
+	// externalListRetry is the time after which a list that failed to refresh is fetched again, at most its interval
+	externalListRetry = 15 * time.Minute
+	// externalListStaleIntervals is the number of refresh intervals after which a list that didn't refresh is stale
+	externalListStaleIntervals = 3
+	// minExternalListRefreshDelay spreads the refresh of the lists that are due at once, e.g. on start
+	minExternalListRefreshDelay = 10 * time.Second
+	externalListFetchTimeout    = time.Minute
+	// maxExternalListSize is the size of the largest list downloaded
+	maxExternalListSize = 32 << 20
+
+	// DefaultExternalListMaxEntries is the maximum number of entries of the external lists created without one
+	DefaultExternalListMaxEntries = 10000
+	// MaxExternalListEntries is the highest maximum number of entries of an external list
+	MaxExternalListEntries = 100000
+	// maxPeerBlockedNetworks caps the networks sent to a peer, the client firewalls can't hold more
+	maxPeerBlockedNetworks = 200000
+)
+
+// The results of the refresh of an external list counted by the telemetry
+const (
+	externalListRefreshSuccess  = "success"
+	externalListRefreshFailure  = "failure"
+	externalListRefreshTooLarge = "too_large"
+)
+
+var errExternalListTooLarge = errors.New("the list exceeds its maximum number of entries")
+
+var externalListClient = &http.Client{Timeout: externalListFetchTimeout}
+
+// ExternalList is a destination of the policy rules made of the networks of a list the management service downloads
+// from a URL and refreshes on a schedule, e.g. a threat feed. The peers drop the traffic they send to the networks
+// through the interface.
+type ExternalList struct {
+	// ID of the external list
+	ID string `gorm:"primaryKey"`
+	// AccountID is a reference to Account that this object belongs
+	AccountID string `gorm:"index"`
+	// Name of the external list
+	Name string
+	// Description of the external list
+	Description string
+	// URL the list is downloaded from, http or https
+	URL string
+	// Format is either ExternalListFormatCIDR or ExternalListFormatMISP
+	Format string
+	// Authorization is the value of the Authorization header sent with the download, e.g. the key of a MISP user. It is
+	// never returned by the API.
+	Authorization string
+	// RefreshInterval is how often the list is downloaded
+	RefreshInterval time.Duration
+	// MaxEntries is the maximum number of networks of the list, a download exceeding it fails the refresh
+	MaxEntries int
+	// Enabled tells whether the list is refreshed and its networks are sent to the peers
+	Enabled bool
+	// Networks are the IPv4 networks of the last successful refresh, sorted and without overlaps
+	Networks []netip.Prefix `gorm:"serializer:json"`
+	// LastRefreshedAt is the time of the last successful refresh, zero when the list was never downloaded
+	LastRefreshedAt time.Time
+	// LastAttemptAt is the time of the last refresh, successful or not
+	LastAttemptAt time.Time
+	// LastError is the error of the last refresh, empty when it succeeded
+	LastError string
+}
+
+// EventMeta returns activity event meta related to the external list
+func (l *ExternalList) EventMeta() map[string]any {
+	return map[string]any{"name": l.Name, "url": l.URL, "format": l.Format}
+}
+
+// Copy copies an external list object
+func (l *ExternalList) Copy() *ExternalList {
+	c := *l
+	c.Networks = slices.Clone(l.Networks)
+	return &c
+}
+
+// Stale returns whether the enabled list didn't refresh for externalListStaleIntervals intervals, or failed to
+// download the first time
+func (l *ExternalList) Stale(now time.Time) bool {
+	if !l.Enabled {
+		return false
+	}
+	if l.LastRefreshedAt.IsZero() {
+		return !l.LastAttemptAt.IsZero()
+	}
+	return now.Sub(l.LastRefreshedAt) > externalListStaleIntervals*l.RefreshInterval
+}
+
+// nextRefresh returns the time until the list has to be downloaded, a failed list is retried after externalListRetry
+func (l *ExternalList) nextRefresh(now time.Time) time.Duration {
+	if l.LastAttemptAt.IsZero() {
+		return 0
+	}
+	interval := l.RefreshInterval
+	if l.LastError != "" {
+		interval = min(interval, externalListRetry)
+	}
+	return l.LastAttemptAt.Add(interval).Sub(now)
+}
+
+// parseExternalList parses the IPv4 networks of a list in the format, the invalid and IPv6 entries are skipped. It
+// fails when the list has entries but none is valid, e.g. an HTML error page.
+func parseExternalList(format string, r io.Reader) ([]netip.Prefix, error) {
+	switch format {
+	case ExternalListFormatCIDR:
+		return parseCIDRList(r)
+	case ExternalListFormatMISP:
+		return parseMISPAttributes(r)
+	default:
+		return nil, fmt.Errorf("unknown list format %s", format)
+	}
+}
+
+// parseCIDRList parses a list with one address or CIDR per line
+func parseCIDRList(r io.Reader) ([]netip.Prefix, error) {
+	var networks []netip.Prefix
+	invalid := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexAny(line, "#;"); i >= 0 {
+			line = line[:i]
+		}
+		// some feeds add fields after the network, e.g. the number of addresses of the Spamhaus DROP list
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		network, ok := parseExternalListEntry(fields[0])
+		if !ok {
+			invalid++
+			continue
+		}
+		if network.Addr().Is4() {
+			networks = append(networks, network)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(networks) == 0 && invalid > 0 {
+		return nil, fmt.Errorf("the list has no valid entry, %d invalid", invalid)
+	}
+	return normalizeNetworks(networks), nil
+}
+
+// mispAttributes is the response of the MISP attributes restSearch API
+type mispAttributes struct {
+	Response struct {
+		Attribute []struct {
+			Type  string `json:"type"`
+			Value string `json:"value"`
+		} `json:"Attribute"`
+	} `json:"response"`
+}
+
+// parseMISPAttributes parses the IP attributes of a MISP restSearch response
+func parseMISPAttributes(r io.Reader) ([]netip.Prefix, error) {
+	var attributes mispAttributes
+	if err := json.NewDecoder(r).Decode(&attributes); err != nil {
+		return nil, fmt.Errorf("failed decoding the MISP attributes: %v", err)
+	}
+
+	var networks []netip.Prefix
+	invalid := 0
+	for _, attribute := range attributes.Response.Attribute {
+		switch attribute.Type {
+		case "ip-src", "ip-dst", "ip-src|port", "ip-dst|port":
+		default:
+			continue
+		}
+		value, _, _ := strings.Cut(attribute.Value, "|")
+		network, ok := parseExternalListEntry(strings.TrimSpace(value))
+		if !ok {
+			invalid++
+			continue
+		}
+		if network.Addr().Is4() {
+			networks = append(networks, network)
+		}
+	}
+	if len(networks) == 0 && invalid > 0 {
+		return nil, fmt.Errorf("the list has no valid entry, %d invalid", invalid)
+	}
+	return normalizeNetworks(networks), nil
+}
+
+// parseExternalListEntry parses an address or a CIDR, an address is a single address network
+func parseExternalListEntry(entry string) (netip.Prefix, bool) {
+	if strings.Contains(entry, "/") {
+		network, err := netip.ParsePrefix(entry)
+		return network.Masked(), err == nil
+	}
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), true
+}
+
+// normalizeNetworks sorts the networks and removes the duplicates and the networks contained in others
+func normalizeNetworks(networks []netip.Prefix) []netip.Prefix {
+	sort.Slice(networks, func(i, j int) bool {
+		a, b := networks[i], networks[j]
+		if c := a.Addr().Compare(b.Addr()); c != 0 {
+			return c < 0
+		}
+		return a.Bits() < b.Bits()
+	})
+
+	normalized := make([]netip.Prefix, 0, len(networks))
+	for _, network := range networks {
+		// the networks are sorted by address, a network contained in another follows it
+		if n := len(normalized); n > 0 && normalized[n-1].Overlaps(network) {
+			continue
+		}
+		normalized = append(normalized, network)
+	}
+	return normalized
+}
+
+// fetchExternalList downloads and parses the networks of the list
+func fetchExternalList(ctx context.Context, list *ExternalList) ([]netip.Prefix, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, list.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if list.Authorization != "" {
+		req.Header.Set("Authorization", list.Authorization)
+	}
+	if list.Format == ExternalListFormatMISP {
+		req.Header.Set("Accept", "application/json")
+	}
+
+	resp, err := externalListClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxExternalListSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxExternalListSize {
+		return nil, fmt.Errorf("the list is larger than %d bytes", maxExternalListSize)
+	}
+
+	networks, err := parseExternalList(list.Format, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if len(networks) > list.MaxEntries {
+		return nil, fmt.Errorf("%w: %d networks, %d allowed", errExternalListTooLarge, len(networks), list.MaxEntries)
+	}
+	return networks, nil
+}
+
+// externalListFetch is the result of the download of an external list
+type externalListFetch struct {
+	listID string
+	// url and format are the source downloaded, the result is dropped when the list changed meanwhile
+	url      string
+	format   string
+	networks []netip.Prefix
+	err      error
+	at       time.Time
+}
+
+// fetchExternalLists downloads the lists without holding the account lock
+func fetchExternalLists(lists []*ExternalList) []externalListFetch {
+	fetches := make([]externalListFetch, 0, len(lists))
+	for _, list := range lists {
+		ctx, cancel := context.WithTimeout(context.Background(), externalListFetchTimeout)
+		networks, err := fetchExternalList(ctx, list)
+		cancel()
+		fetches = append(fetches, externalListFetch{
+			listID:   list.ID,
+			url:      list.URL,
+			format:   list.Format,
+			networks: networks,
+			err:      err,
+			at:       time.Now().UTC(),
+		})
+	}
+	return fetches
+}
+
+// applyExternalListFetches saves the downloaded lists to the account and sends the network maps to the peers when the
+// networks of a list changed. The previous networks of a list that failed to download are kept.
+func (am *DefaultAccountManager) applyExternalListFetches(accountID string, fetches []externalListFetch) (*Account, error) {
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := false
+	for _, fetch := range fetches {
+		list := account.ExternalLists[fetch.listID]
+		if list == nil || list.URL != fetch.url || list.Format != fetch.format {
+			continue
+		}
+
+		list.LastAttemptAt = fetch.at
+		if fetch.err != nil {
+			log.Warnf("failed refreshing external list %s of account %s: %v", list.ID, accountID, fetch.err)
+			list.LastError = fetch.err.Error()
+			am.countExternalListRefresh(fetch.err)
+			continue
+		}
+
+		if !slices.Equal(list.Networks, fetch.networks) {
+			changed = changed || list.Enabled
+			list.Networks = fetch.networks
+		}
+		list.LastRefreshedAt = fetch.at
+		list.LastError = ""
+		am.countExternalListRefresh(nil)
+	}
+
+	if changed {
+		account.Network.IncSerial()
+	}
+	if err = am.Store.SaveAccount(account); err != nil {
+		return nil, err
+	}
+
+	if changed {
+		am.updateAccountPeers(account)
+	}
+
+	return account, nil
+}
+
+func (am *DefaultAccountManager) countExternalListRefresh(err error) {
+	if am.externalListMetrics == nil {
+		return
+	}
+	switch {
+	case err == nil:
+		am.externalListMetrics.CountRefresh(externalListRefreshSuccess)
+	case errors.Is(err, errExternalListTooLarge):
+		am.externalListMetrics.CountRefresh(externalListRefreshTooLarge)
+	default:
+		am.externalListMetrics.CountRefresh(externalListRefreshFailure)
+	}
+}
+
+func (am *DefaultAccountManager) externalListRefreshJob(accountID string) func() (time.Duration, bool) {
+	return func() (time.Duration, bool) {
+		unlock := am.Store.AcquireAccountReadLock(accountID)
+		account, err := am.Store.GetAccount(accountID)
+		unlock()
+		if err != nil {
+			log.Errorf("failed getting account %s refreshing external lists: %v", accountID, err)
+			return 0, false
+		}
+
+		now := time.Now().UTC()
+		var due []*ExternalList
+		for _, list := range account.ExternalLists {
+			if list.Enabled && list.nextRefresh(now) <= 0 {
+				due = append(due, list)
+			}
+		}
+
+		if len(due) > 0 {
+			account, err = am.applyExternalListFetches(accountID, fetchExternalLists(due))
+			if err != nil {
+				log.Errorf("failed saving the external lists of account %s: %v", accountID, err)
+				return externalListRetry, true
+			}
+		}
+
+		return getNextExternalListRefresh(account)
+	}
+}
+
+// getNextExternalListRefresh returns the time until an enabled external list of the account has to be downloaded
+// next, false if the account has none
+func getNextExternalListRefresh(account *Account) (time.Duration, bool) {
+	now := time.Now().UTC()
+	var next *time.Duration
+	for _, list := range account.ExternalLists {
+		if !list.Enabled {
+			continue
+		}
+		in := list.nextRefresh(now)
+		if next == nil || in < *next {
+			next = &in
+		}
+	}
+
+	if next == nil {
+		return 0, false
+	}
+	return max(*next, minExternalListRefreshDelay), true
+}
+
+func (am *DefaultAccountManager) checkAndScheduleExternalListRefresh(account *Account) {
+	am.externalListRefresh.Cancel([]string{account.Id})
+	if nextRun, ok := getNextExternalListRefresh(account); ok {
+		go am.externalListRefresh.Schedule(nextRun, account.Id, am.externalListRefreshJob(account.Id))
+	}
+}
+
+// EnableExternalListMetrics makes the account manager count the refreshes of the external lists and report the stale
+// ones to the metrics
+func (am *DefaultAccountManager) EnableExternalListMetrics(metrics *telemetry.ExternalListMetrics) error {
+	am.externalListMetrics = metrics
+	return metrics.RegisterStaleness(am.getExternalListsStaleness)
+}
+
+// getExternalListsStaleness returns the number of stale external lists of all the accounts and the age in seconds of
+// the least recently refreshed enabled one
+func (am *DefaultAccountManager) getExternalListsStaleness() (int64, int64) {
+	now := time.Now().UTC()
+	var stale, maxAge int64
+	for _, account := range am.Store.GetAllAccounts() {
+		for _, list := range account.ExternalLists {
+			if !list.Enabled {
+				continue
+			}
+			if list.Stale(now) {
+				stale++
+			}
+			refreshedAt := list.LastRefreshedAt
+			if refreshedAt.IsZero() {
+				// never downloaded, the age counts from its first attempt
+				refreshedAt = list.LastAttemptAt
+			}
+			if !refreshedAt.IsZero() {
+				maxAge = max(maxAge, int64(now.Sub(refreshedAt).Seconds()))
+			}
+		}
+	}
+	return stale, maxAge
+}
+
+// GetExternalList gets an external list object from account and external list IDs
+func (am *DefaultAccountManager) GetExternalList(accountID, userID, listID string) (*ExternalList, error) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view external lists")
+	}
+
+	list, found := account.ExternalLists[listID]
+	if found {
+		return list.Copy(), nil
+	}
+
+	return nil, status.Errorf(status.NotFound, "external list with ID %s not found", listID)
+}
+
+// CreateExternalList validates and saves a new external list, it is downloaded shortly after
+func (am *DefaultAccountManager) CreateExternalList(accountID, userID string, list *ExternalList) (*ExternalList, error) {
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	if list == nil {
+		return nil, status.Errorf(status.InvalidArgument, "external list provided is nil")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can create external lists")
+	}
+
+	newList := list.Copy()
+	newList.ID = xid.New().String()
+	newList.AccountID = accountID
+	newList.Networks = nil
+	newList.LastRefreshedAt = time.Time{}
+	newList.LastAttemptAt = time.Time{}
+	newList.LastError = ""
+
+	err = validateExternalList(false, newList, account)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.ExternalLists == nil {
+		account.ExternalLists = make(map[string]*ExternalList)
+	}
+	account.ExternalLists[newList.ID] = newList
+
+	if err = am.Store.SaveAccount(account); err != nil {
+		return nil, err
+	}
+
+	am.checkAndScheduleExternalListRefresh(account)
+
+	am.StoreEvent(userID, newList.ID, accountID, activity.ExternalListCreated, newList.EventMeta())
+
+	return newList.Copy(), nil
+}
+
+// SaveExternalList validates and updates an existing external list. The networks and the refresh status are kept,
+// the list is downloaded again when its URL or format changed.
+func (am *DefaultAccountManager) SaveExternalList(accountID, userID string, listToSave *ExternalList) error {
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	if listToSave == nil {
+		return status.Errorf(status.InvalidArgument, "external list provided is nil")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return status.Errorf(status.PermissionDenied, "only users with admin power can update external lists")
+	}
+
+	list := listToSave.Copy()
+	list.AccountID = accountID
+
+	err = validateExternalList(true, list, account)
+	if err != nil {
+		return err
+	}
+
+	existing := account.ExternalLists[list.ID]
+	list.Networks = existing.Networks
+	list.LastRefreshedAt = existing.LastRefreshedAt
+	list.LastAttemptAt = existing.LastAttemptAt
+	list.LastError = existing.LastError
+	if list.URL != existing.URL || list.Format != existing.Format {
+		list.LastAttemptAt = time.Time{}
+	}
+	account.ExternalLists[list.ID] = list
+
+	// the networks of a list enabled or disabled are added to or removed from the peers
+	if list.Enabled != existing.Enabled {
+		account.Network.IncSerial()
+	}
+	if err = am.Store.SaveAccount(account); err != nil {
+		return err
+	}
+
+	if list.Enabled != existing.Enabled {
+		am.updateAccountPeers(account)
+	}
+
+	am.checkAndScheduleExternalListRefresh(account)
+
+	am.StoreEvent(userID, list.ID, accountID, activity.ExternalListUpdated, list.EventMeta())
+
+	return nil
+}
+
+// DeleteExternalList deletes the external list with listID, it fails when a policy rule uses it
+func (am *DefaultAccountManager) DeleteExternalList(accountID, userID, listID string) error {
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return status.Errorf(status.PermissionDenied, "only users with admin power can delete external lists")
+	}
+
+	list := account.ExternalLists[listID]
+	if list == nil {
+		return status.Errorf(status.NotFound, "external list %s wasn't found", listID)
+	}
+
+	for _, policy := range account.Policies {
+		for _, rule := range policy.Rules {
+			if slices.Contains(rule.DestinationLists, listID) {
+				return status.Errorf(status.PreconditionFailed, "external list %s is used by policy %s", list.Name, policy.Name)
+			}
+		}
+	}
+
+	delete(account.ExternalLists, listID)
+	if err = am.Store.SaveAccount(account); err != nil {
+		return err
+	}
+
+	am.checkAndScheduleExternalListRefresh(account)
+
+	am.StoreEvent(userID, list.ID, accountID, activity.ExternalListDeleted, list.EventMeta())
+
+	return nil
+}
+
+// ListExternalLists returns a list of the external lists from account
+func (am *DefaultAccountManager) ListExternalLists(accountID, userID string) ([]*ExternalList, error) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view external lists")
+	}
+
+	lists := make([]*ExternalList, 0, len(account.ExternalLists))
+	for _, list := range account.ExternalLists {
+		lists = append(lists, list.Copy())
+	}
+	sort.Slice(lists, func(i, j int) bool {
+		return lists[i].Name < lists[j].Name
+	})
+
+	return lists, nil
+}
+
+// RefreshExternalList downloads the external list with listID now and returns it with the result of the refresh.
+// A failed download is recorded in its LastError rather than returned. Only users with admin power can refresh it.
+func (am *DefaultAccountManager) RefreshExternalList(accountID, userID, listID string) (*ExternalList, error) {
+	list, err := am.GetExternalList(accountID, userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := am.applyExternalListFetches(accountID, fetchExternalLists([]*ExternalList{list}))
+	if err != nil {
+		return nil, err
+	}
+
+	am.checkAndScheduleExternalListRefresh(account)
+
+	refreshed, found := account.ExternalLists[listID]
+	if !found {
+		return nil, status.Errorf(status.NotFound, "external list with ID %s not found", listID)
+	}
+	return refreshed.Copy(), nil
+}
+
+// validateExternalList checks the external list against the account and sets its default refresh interval and
+// maximum number of entries
+func validateExternalList(existingList bool, list *ExternalList, account *Account) error {
+	if existingList {
+		_, found := account.ExternalLists[list.ID]
+		if !found {
+			return status.Errorf(status.NotFound, "external list with ID %s was not found", list.ID)
+		}
+	}
+
+	if list.Name == "" {
+		return status.FieldErrorf(status.InvalidArgument, "name", "external list name shouldn't be empty")
+	}
+
+	for _, other := range account.ExternalLists {
+		if other.ID != list.ID && other.Name == list.Name {
+			return status.FieldErrorf(status.InvalidArgument, "name", "an external list with name %s already exists", list.Name)
+		}
+	}
+
+	u, err := url.Parse(list.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return status.FieldErrorf(status.InvalidArgument, "url", "external list URL %s should be an http or https URL", list.URL)
+	}
+
+	switch list.Format {
+	case ExternalListFormatCIDR, ExternalListFormatMISP:
+	default:
+		return status.FieldErrorf(status.InvalidArgument, "format", "unknown external list format %s, should be %s or %s",
+			list.Format, ExternalListFormatCIDR, ExternalListFormatMISP)
+	}
+
+	if list.RefreshInterval == 0 {
+		list.RefreshInterval = DefaultExternalListRefreshInterval
+	}
+	if list.RefreshInterval < minExternalListRefreshInterval {
+		return status.FieldErrorf(status.InvalidArgument, "refresh_interval", "external list refresh interval should be at least %s",
+			minExternalListRefreshInterval)
+	}
+
+	if list.MaxEntries == 0 {
+		list.MaxEntries = DefaultExternalListMaxEntries
+	}
+	if list.MaxEntries < 0 || list.MaxEntries > MaxExternalListEntries {
+		return status.FieldErrorf(status.InvalidArgument, "max_entries", "external list maximum number of entries should be between 1 and %d",
+			MaxExternalListEntries)
+	}
+
+	return nil
+}
+
+// getPeerBlockedNetworks returns the networks of the enabled external lists of the enabled policy rules with the peer
+// in their sources. The posture checks don't apply, a peer failing them still drops the traffic.
+func (a *Account) getPeerBlockedNetworks(peerID string) []netip.Prefix {
+	var lists []string
+	for _, policy := range a.Policies {
+		if !policy.Enabled {
+			continue
+		}
+		for _, rule := range policy.Rules {
+			if !rule.Enabled || len(rule.DestinationLists) == 0 {
+				continue
+			}
+			if !a.peerInGroups(peerID, rule.Sources) {
+				continue
+			}
+			lists = append(lists, rule.DestinationLists...)
+		}
+	}
+	if len(lists) == 0 {
+		return nil
+	}
+
+	var networks []netip.Prefix
+	seen := make(map[string]struct{}, len(lists))
+	for _, listID := range lists {
+		if _, ok := seen[listID]; ok {
+			continue
+		}
+		seen[listID] = struct{}{}
+		list := a.ExternalLists[listID]
+		if list == nil || !list.Enabled {
+			continue
+		}
+		networks = append(networks, list.Networks...)
+	}
+
+	networks = normalizeNetworks(networks)
+	if len(networks) > maxPeerBlockedNetworks {
+		log.Warnf("peer %s of account %s blocks %d networks of external lists, only the first %d are sent",
+			peerID, a.Id, len(networks), maxPeerBlockedNetworks)
+		networks = networks[:maxPeerBlockedNetworks]
+	}
+	return networks
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestParseCIDRList(t *testing.T) {
+	list := `# Spamhaus DROP-like list
+1.10.16.0/20 ; SBL256894
+1.19.0.0/16 ; SBL434604
+1.19.5.0/24
+203.0.113.7
+2001:db8::/32
+not-a-network
+
+10.1.2.3/8
+`
+	networks, err := parseCIDRList(strings.NewReader(list))
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("1.10.16.0/20"),
+		netip.MustParsePrefix("1.19.0.0/16"),
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("203.0.113.7/32"),
+	}, networks, "the networks should be masked, sorted, and contained and IPv6 networks dropped")
+
+	_, err = parseCIDRList(strings.NewReader("<html><body>Not found</body></html>\n"))
+	assert.Error(t, err, "a list without a valid entry should fail")
+
+	networks, err = parseCIDRList(strings.NewReader("# empty list\n"))
+	require.NoError(t, err)
+	assert.Empty(t, networks)
+}
+
+func TestParseMISPAttributes(t *testing.T) {
+	attributes := `{"response": {"Attribute": [
+		{"type": "ip-dst", "value": "198.51.100.10"},
+		{"type": "ip-src|port", "value": "192.0.2.0/24|443"},
+		{"type": "domain", "value": "example.com"},
+		{"type": "ip-dst", "value": "2001:db8::1"}
+	]}}`
+	networks, err := parseMISPAttributes(strings.NewReader(attributes))
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("198.51.100.10/32"),
+	}, networks)
+
+	_, err = parseMISPAttributes(strings.NewReader("not json"))
+	assert.Error(t, err)
+}
+
+func TestExternalList_Stale(t *testing.T) {
+	now := time.Now().UTC()
+	list := &ExternalList{Enabled: true, RefreshInterval: time.Hour}
+	assert.False(t, list.Stale(now), "a list not downloaded yet shouldn't be stale")
+
+	list.LastAttemptAt = now
+	assert.True(t, list.Stale(now), "a list that failed its first download should be stale")
+
+	list.LastRefreshedAt = now.Add(-2 * time.Hour)
+	assert.False(t, list.Stale(now))
+
+	list.LastRefreshedAt = now.Add(-4 * time.Hour)
+	assert.True(t, list.Stale(now), "a list not refreshed for 3 intervals should be stale")
+
+	list.Enabled = false
+	assert.False(t, list.Stale(now), "a disabled list shouldn't be stale")
+}
+
+func TestDefaultAccountManager_ExternalList(t *testing.T) {
+	feed := "192.0.2.0/24\n198.51.100.10\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "misp-key" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = fmt.Fprint(w, feed)
+	}))
+	defer server.Close()
+
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err, "unable to create an account")
+	peer, _, err := manager.AddPeer("", userID, newQuotaTestPeer(t, "router"))
+	require.NoError(t, err, "unable to add peer")
+
+	_, err = manager.CreateExternalList(account.Id, userID, &ExternalList{Name: "feed", URL: "ftp://example.com/feed", Format: ExternalListFormatCIDR})
+	require.Error(t, err, "only http and https URLs should be accepted")
+
+	list, err := manager.CreateExternalList(account.Id, userID, &ExternalList{
+		Name:    "feed",
+		URL:     server.URL,
+		Format:  ExternalListFormatCIDR,
+		Enabled: true,
+	})
+	require.NoError(t, err, "unable to create the external list")
+	assert.Equal(t, DefaultExternalListRefreshInterval, list.RefreshInterval)
+	assert.Equal(t, DefaultExternalListMaxEntries, list.MaxEntries)
+	ev := getEvent(t, account.Id, manager, activity.ExternalListCreated)
+	assert.Equal(t, list.ID, ev.TargetID)
+
+	list, err = manager.RefreshExternalList(account.Id, userID, list.ID)
+	require.NoError(t, err)
+	assert.Contains(t, list.LastError, "403", "the download without the authorization should fail")
+	assert.Empty(t, list.Networks)
+	assert.True(t, list.Stale(time.Now()))
+
+	list.Authorization = "misp-key"
+	require.NoError(t, manager.SaveExternalList(account.Id, userID, list))
+	list, err = manager.RefreshExternalList(account.Id, userID, list.ID)
+	require.NoError(t, err)
+	assert.Empty(t, list.LastError)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24"), netip.MustParsePrefix("198.51.100.10/32")}, list.Networks)
+
+	groupAll, err := account.GetGroupAll()
+	require.NoError(t, err)
+	err = manager.SavePolicy(account.Id, userID, &Policy{
+		ID:      "block-feed",
+		Name:    "block feed",
+		Enabled: true,
+		Rules: []*PolicyRule{{
+			ID:               "block-feed",
+			Enabled:          true,
+			Action:           PolicyTrafficActionDrop,
+			Protocol:         PolicyRuleProtocolALL,
+			Egress:           true,
+			Sources:          []string{groupAll.ID},
+			DestinationLists: []string{list.ID},
+		}},
+	})
+	require.NoError(t, err)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	validatedPeers := map[string]struct{}{peer.ID: {}}
+	networkMap := account.GetPeerNetworkMap(peer.ID, "netbird.cloud", validatedPeers)
+	assert.Equal(t, list.Networks, networkMap.BlockedNetworks, "the source peers should block the networks of the list")
+
+	list.MaxEntries = 1
+	require.NoError(t, manager.SaveExternalList(account.Id, userID, list))
+	list, err = manager.RefreshExternalList(account.Id, userID, list.ID)
+	require.NoError(t, err)
+	assert.Contains(t, list.LastError, "exceeds")
+	assert.Len(t, list.Networks, 2, "the networks should be kept when the list exceeds its maximum")
+
+	err = manager.DeleteExternalList(account.Id, userID, list.ID)
+	sErr, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.PreconditionFailed, sErr.Type(), "a list used by a policy shouldn't be deleted")
+
+	list.Enabled = false
+	require.NoError(t, manager.SaveExternalList(account.Id, userID, list))
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	networkMap = account.GetPeerNetworkMap(peer.ID, "netbird.cloud", validatedPeers)
+	assert.Empty(t, networkMap.BlockedNetworks, "the networks of a disabled list shouldn't be blocked")
+
+	require.NoError(t, manager.DeletePolicy(account.Id, "block-feed", userID))
+	require.NoError(t, manager.DeleteExternalList(account.Id, userID, list.ID))
+	_, err = manager.GetExternalList(account.Id, userID, list.ID)
+	assert.Error(t, err)
+}
+
+func TestPolicyRule_ValidateDestinationLists(t *testing.T) {
+	rule := &PolicyRule{
+		Action:           PolicyTrafficActionDrop,
+		Protocol:         PolicyRuleProtocolALL,
+		Egress:           true,
+		DestinationLists: []string{"feed"},
+	}
+	assert.NoError(t, rule.Validate())
+
+	rule.Action = PolicyTrafficActionAccept
+	assert.Error(t, rule.Validate(), "a rule with destination lists should drop the traffic")
+
+	rule.Action = PolicyTrafficActionDrop
+	rule.Protocol = PolicyRuleProtocolTCP
+	rule.Ports = []string{"443"}
+	assert.Error(t, rule.Validate(), "a rule with destination lists should drop all the protocols")
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+
+	"github.com/netbirdio/netbird/management/proto"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	// FeatureLazyFirewallRules makes the peers install the firewall rules of the remote peers with recent traffic only
+	FeatureLazyFirewallRules = "lazy_firewall_rules"
+	// FeatureDNSCache makes the peers cache the responses of the upstream nameservers
+	FeatureDNSCache = "dns_cache"
+	// FeatureSSHServer lets the peers run the NetBird SSH server, a peer disallowing it locally doesn't run it either way
+	FeatureSSHServer = "ssh_server"
+)
+
+// featureFlagNamePattern is the format of the feature flag names, the clients ignore the flags they don't know so new
+// flags can be pushed ahead of the client releases using them
+var featureFlagNamePattern = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+func validateFeatureFlags(flags map[string]bool) error {
+	for name := range flags {
+		if !featureFlagNamePattern.MatchString(name) {
+			return status.Errorf(status.InvalidArgument, "invalid feature flag name %q, expected up to 64 lowercase "+
+				"letters, digits and underscores", name)
+		}
+	}
+	return nil
+}
+
+// getPeerFeatureFlags returns the feature flags of the peer: the flags of the account overridden by the flags of the
+// groups of the peer. A flag set differently by the groups of the peer is disabled, so a group can opt its peers out
+// of a flag rolled out to another group.
+func (a *Account) getPeerFeatureFlags(peerID string) map[string]bool {
+	flags := make(map[string]bool)
+	if a.Settings != nil {
+		maps.Copy(flags, a.Settings.FeatureFlags)
+	}
+
+	groupFlags := make(map[string]bool)
+	for _, group := range a.Groups {
+		if len(group.FeatureFlags) == 0 || !slices.Contains(group.Peers, peerID) {
+			continue
+		}
+		for name, enabled := range group.FeatureFlags {
+			if set, ok := groupFlags[name]; ok {
+				enabled = enabled && set
+			}
+			groupFlags[name] = enabled
+		}
+	}
+	maps.Copy(flags, groupFlags)
+
+	return flags
+}
+
+func toProtocolFeatureFlags(flags map[string]bool) []*proto.FeatureFlag {
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	featureFlags := make([]*proto.FeatureFlag, 0, len(names))
+	for _, name := range names {
+		featureFlags = append(featureFlags, &proto.FeatureFlag{Name: name, Enabled: flags[name]})
+	}
+	return featureFlags
+}
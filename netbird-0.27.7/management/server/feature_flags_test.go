@@ -0,0 +1,92 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/proto"
+	nbgroup "github.com/netbirdio/netbird/management/server/group"
+)
+
+func TestAccount_getPeerFeatureFlags(t *testing.T) {
+	account := &Account{
+		Settings: &Settings{FeatureFlags: map[string]bool{
+			FeatureLazyFirewallRules: true,
+			FeatureDNSCache:          true,
+		}},
+		Groups: map[string]*nbgroup.Group{
+			"canary": {ID: "canary", Peers: []string{"peer1", "peer2"}, FeatureFlags: map[string]bool{
+				FeatureSSHServer: true,
+				FeatureDNSCache:  true,
+			}},
+			"opt-out": {ID: "opt-out", Peers: []string{"peer2"}, FeatureFlags: map[string]bool{
+				FeatureSSHServer:         false,
+				FeatureLazyFirewallRules: false,
+			}},
+			"all": {ID: "all", Peers: []string{"peer1", "peer2", "peer3"}},
+		},
+	}
+
+	assert.Equal(t, map[string]bool{
+		FeatureLazyFirewallRules: true,
+		FeatureDNSCache:          true,
+		FeatureSSHServer:         true,
+	}, account.getPeerFeatureFlags("peer1"))
+
+	assert.Equal(t, map[string]bool{
+		FeatureLazyFirewallRules: false,
+		FeatureDNSCache:          true,
+		FeatureSSHServer:         false,
+	}, account.getPeerFeatureFlags("peer2"), "a flag set differently by the groups of the peer should be disabled")
+
+	assert.Equal(t, map[string]bool{
+		FeatureLazyFirewallRules: true,
+		FeatureDNSCache:          true,
+	}, account.getPeerFeatureFlags("peer3"))
+}
+
+func TestValidateFeatureFlags(t *testing.T) {
+	assert.NoError(t, validateFeatureFlags(nil))
+	assert.NoError(t, validateFeatureFlags(map[string]bool{FeatureLazyFirewallRules: true, "new_flag2": false}))
+
+	for _, name := range []string{"", "DNS_cache", "dns-cache", "dns cache", string(make([]byte, 65))} {
+		assert.Error(t, validateFeatureFlags(map[string]bool{name: true}), name)
+	}
+}
+
+func TestToProtocolFeatureFlags(t *testing.T) {
+	flags := toProtocolFeatureFlags(map[string]bool{FeatureSSHServer: false, FeatureDNSCache: true})
+	require.Len(t, flags, 2)
+	assert.Equal(t, &proto.FeatureFlag{Name: FeatureDNSCache, Enabled: true}, flags[0])
+	assert.Equal(t, &proto.FeatureFlag{Name: FeatureSSHServer, Enabled: false}, flags[1])
+}
+
+func TestDefaultAccountManager_UpdateFeatureFlags(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err)
+
+	settings := account.Settings.Copy()
+	settings.FeatureFlags = map[string]bool{"Lazy Rules": true}
+	_, err = manager.UpdateAccountSettings(account.Id, userID, settings)
+	assert.Error(t, err, "the invalid flag names should be rejected")
+
+	settings.FeatureFlags = map[string]bool{FeatureLazyFirewallRules: true}
+	updated, err := manager.UpdateAccountSettings(account.Id, userID, settings)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{FeatureLazyFirewallRules: true}, updated.Settings.FeatureFlags)
+
+	group := &nbgroup.Group{Name: "canary", Issued: nbgroup.GroupIssuedAPI, FeatureFlags: map[string]bool{"dns-cache": false}}
+	assert.Error(t, manager.SaveGroup(account.Id, userID, group), "the invalid flag names should be rejected")
+
+	group.FeatureFlags = map[string]bool{FeatureDNSCache: false}
+	require.NoError(t, manager.SaveGroup(account.Id, userID, group))
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{FeatureDNSCache: false}, account.Groups[group.ID].FeatureFlags)
+}
@@ -77,6 +77,21 @@ func NewFilestoreFromSqliteStore(sqlitestore *SqliteStore, dataDir string, metri
 
 // restore the state of the store from the file.
 // Creates a new empty store file if doesn't exist
+// checkFileStore reads the accounts of the store file of the datadir without restoring the store, 0 when the file
+// doesn't exist
+func checkFileStore(dataDir string) (int, error) {
+	file := filepath.Join(dataDir, storeFileName)
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	store := &FileStore{}
+	if _, err := util.ReadJson(file, store); err != nil {
+		return 0, err
+	}
+	return len(store.Accounts), nil
+}
+
 func restore(file string) (*FileStore, error) {
 	if _, err := os.Stat(file); os.IsNotExist(err) {
 		// create a new FileStore if previously didn't exist (e.g. first run)
@@ -131,6 +146,9 @@ func restore(file string) (*FileStore, error) {
 
 		for _, peer := range account.Peers {
 			store.PeerKeyID2AccountID[peer.Key] = accountID
+			if peer.PreviousKey != "" {
+				store.PeerKeyID2AccountID[peer.PreviousKey] = accountID
+			}
 			store.PeerID2AccountID[peer.ID] = accountID
 		}
 		for _, user := range account.Users {
@@ -322,6 +340,9 @@ func (s *FileStore) SaveAccount(account *Account) error {
 	// enforce peer to account index and delete peer to route indexes for rebuild
 	for _, peer := range accountCopy.Peers {
 		s.PeerKeyID2AccountID[peer.Key] = accountCopy.Id
+		if peer.PreviousKey != "" {
+			s.PeerKeyID2AccountID[peer.PreviousKey] = accountCopy.Id
+		}
 		s.PeerID2AccountID[peer.ID] = accountCopy.Id
 	}
 
@@ -355,6 +376,7 @@ func (s *FileStore) DeleteAccount(account *Account) error {
 	// enforce peer to account index and delete peer to route indexes for rebuild
 	for _, peer := range account.Peers {
 		delete(s.PeerKeyID2AccountID, peer.Key)
+		delete(s.PeerKeyID2AccountID, peer.PreviousKey)
 		delete(s.PeerID2AccountID, peer.ID)
 	}
 
@@ -578,6 +600,8 @@ func (s *FileStore) GetAccountByPeerPubKey(peerKey string) (*Account, error) {
 	return account.Copy(), nil
 }
 
+// GetAccountIDByPeerPubKey returns the ID of the account of the peer with the WireGuard public key, or with the
+// previous key of a key rotation
 func (s *FileStore) GetAccountIDByPeerPubKey(peerKey string) (string, error) {
 	s.mux.Lock()
 	defer s.mux.Unlock()
@@ -590,6 +614,164 @@ func (s *FileStore) GetAccountIDByPeerPubKey(peerKey string) (string, error) {
 	return accountID, nil
 }
 
+// GetAccountIDByUserID returns the ID of the account of the user
+func (s *FileStore) GetAccountIDByUserID(userID string) (string, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	accountID, ok := s.UserID2AccountID[userID]
+	if !ok {
+		return "", status.Errorf(status.NotFound, "account not found")
+	}
+
+	return accountID, nil
+}
+
+// GetUserByUserID returns the user without its personal access tokens
+func (s *FileStore) GetUserByUserID(userID string) (*User, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	accountID, ok := s.UserID2AccountID[userID]
+	if !ok {
+		return nil, status.Errorf(status.NotFound, "user %s not found", userID)
+	}
+
+	account, err := s.getAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, ok := account.Users[userID]
+	if !ok {
+		return nil, status.Errorf(status.NotFound, "user %s not found", userID)
+	}
+
+	userCopy := user.Copy()
+	userCopy.AccountID = accountID
+	userCopy.PATs = nil
+	return userCopy, nil
+}
+
+// GetAccountSettings returns the settings of the account
+func (s *FileStore) GetAccountSettings(accountID string) (*Settings, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	account, err := s.getAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.Settings == nil {
+		return &Settings{}, nil
+	}
+
+	return account.Settings.Copy(), nil
+}
+
+// GetAccountGroups returns the groups of the account
+func (s *FileStore) GetAccountGroups(accountID string) ([]*nbgroup.Group, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	account, err := s.getAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]*nbgroup.Group, 0, len(account.Groups))
+	for _, group := range account.Groups {
+		groups = append(groups, group.Copy())
+	}
+
+	return groups, nil
+}
+
+// GetAccountGroup returns the group of the account
+func (s *FileStore) GetAccountGroup(accountID, groupID string) (*nbgroup.Group, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	account, err := s.getAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	group, ok := account.Groups[groupID]
+	if !ok {
+		return nil, status.Errorf(status.NotFound, "group %s not found", groupID)
+	}
+
+	return group.Copy(), nil
+}
+
+// GetAccountPolicies returns the policies of the account
+func (s *FileStore) GetAccountPolicies(accountID string) ([]*Policy, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	account, err := s.getAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]*Policy, 0, len(account.Policies))
+	for _, policy := range account.Policies {
+		policies = append(policies, policy.Copy())
+	}
+
+	return policies, nil
+}
+
+// GetAccountPeers returns the peers of the account
+func (s *FileStore) GetAccountPeers(accountID string) ([]*nbpeer.Peer, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	account, err := s.getAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make([]*nbpeer.Peer, 0, len(account.Peers))
+	for _, peer := range account.Peers {
+		peers = append(peers, peer.Copy())
+	}
+
+	return peers, nil
+}
+
+// GetAccountPeersByIDs returns the peers of the account with the IDs
+func (s *FileStore) GetAccountPeersByIDs(accountID string, peerIDs []string) ([]*nbpeer.Peer, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	account, err := s.getAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make([]*nbpeer.Peer, 0, len(peerIDs))
+	for _, peerID := range peerIDs {
+		if peer, ok := account.Peers[peerID]; ok {
+			peers = append(peers, peer.Copy())
+		}
+	}
+
+	return peers, nil
+}
+
+// AcquireLease always grants the lease, the file store can't be shared by instances of the management service
+func (s *FileStore) AcquireLease(_, _ string, _ time.Duration) (bool, error) {
+	return true, nil
+}
+
+// ReleaseLease does nothing, the leases of the file store aren't persisted
+func (s *FileStore) ReleaseLease(_, _ string) error {
+	return nil
+}
+
 // GetInstallationID returns the installation ID from the store
 func (s *FileStore) GetInstallationID() string {
 	return s.InstallationID
@@ -647,6 +829,29 @@ func (s *FileStore) SavePeerLocation(accountID string, peerWithLocation *nbpeer.
 	return nil
 }
 
+// SavePeerGeofence stores the geofence violation and override of the peer in memory. It doesn't attempt to persist data
+// to speed up things, the violation is evaluated again from the location of the peer when it connects.
+func (s *FileStore) SavePeerGeofence(accountID string, peerWithGeofence *nbpeer.Peer) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	account, err := s.getAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	peer := account.Peers[peerWithGeofence.ID]
+	if peer == nil {
+		return status.Errorf(status.NotFound, "peer %s not found", peerWithGeofence.ID)
+	}
+
+	peer.GeofenceViolation = peerWithGeofence.GeofenceViolation
+	peer.GeofenceViolatedAt = peerWithGeofence.GeofenceViolatedAt
+	peer.GeofenceOverride = peerWithGeofence.GeofenceOverride.Copy()
+
+	return nil
+}
+
 // SaveUserLastLogin stores the last login time for a user in memory. It doesn't attempt to persist data to speed up things.
 func (s *FileStore) SaveUserLastLogin(accountID, userID string, lastLogin time.Time) error {
 	s.mux.Lock()
@@ -681,3 +886,21 @@ func (s *FileStore) Close() error {
 func (s *FileStore) GetStoreEngine() StoreEngine {
 	return FileStoreEngine
 }
+
+// GetStoreStats counts the accounts, peers and users held in memory
+func (s *FileStore) GetStoreStats() (*StoreStats, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	stats := &StoreStats{
+		Engine:       FileStoreEngine,
+		Accounts:     len(s.Accounts),
+		AccountLocks: countLocks(&s.accountLocks),
+	}
+	for _, account := range s.Accounts {
+		stats.Peers += len(account.Peers)
+		stats.Users += len(account.Users)
+	}
+
+	return stats, nil
+}
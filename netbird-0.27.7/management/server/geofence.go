@@ -0,0 +1,407 @@
+package server
+
+import (
+	"slices"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbgroup "github.com/netbirdio/netbird/management/server/group"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// The actions applied to the peers located outside the geofence of the account
+const (
+	// GeofenceActionBlock removes the peer from the network maps of the account, like a peer pending approval
+	GeofenceActionBlock = "block"
+	// GeofenceActionQuarantine restricts the connections of the peer to the peers of the quarantine group
+	GeofenceActionQuarantine = "quarantine"
+)
+
+// PeerGeofence is the geofence state of a peer
+type PeerGeofence struct {
+	// PeerID is the ID of the peer
+	PeerID string
+	// CountryCode is the country the peer was last located in, empty if it is unknown
+	CountryCode string
+	// Geofenced is true if the geofence of the account applies to the peer
+	Geofenced bool
+	// Violation is the country the peer is located in outside the geofence, empty if it complies with it
+	Violation string
+	// ViolatedAt is the time the peer was first located outside the geofence
+	ViolatedAt time.Time
+	// Action is the action applied to the peer while it is outside the geofence, empty if it complies with it
+	Action string
+	// Override is the exception to the geofence requested for the peer, nil if none was requested
+	Override *nbpeer.GeofenceOverride
+}
+
+// geofenceAction returns the action applied to the peers outside the geofence, block unless quarantine is set
+func (s *Settings) geofenceAction() string {
+	if s.GeofenceAction == "" {
+		return GeofenceActionBlock
+	}
+	return s.GeofenceAction
+}
+
+func validateGeofenceSettings(settings *Settings, groups map[string]*nbgroup.Group) error {
+	for i, country := range settings.GeofenceCountries {
+		if len(country) != 2 || country[0] < 'A' || country[0] > 'Z' || country[1] < 'A' || country[1] > 'Z' {
+			return status.Errorf(status.InvalidArgument, "invalid geofence country %q, expected an uppercase ISO 3166-1 alpha-2 code", country)
+		}
+		if slices.Contains(settings.GeofenceCountries[:i], country) {
+			return status.Errorf(status.InvalidArgument, "geofence country %s is listed twice", country)
+		}
+	}
+
+	if len(settings.GeofenceGroups) != 0 {
+		if err := validateGroups(settings.GeofenceGroups, groups); err != nil {
+			return err
+		}
+	}
+
+	switch settings.GeofenceAction {
+	case "", GeofenceActionBlock:
+	case GeofenceActionQuarantine:
+		if settings.GeofenceQuarantineGroup == "" {
+			return status.Errorf(status.InvalidArgument, "the quarantine geofence action requires a quarantine group")
+		}
+	default:
+		return status.Errorf(status.InvalidArgument, "unknown geofence action %q, expected %s or %s",
+			settings.GeofenceAction, GeofenceActionBlock, GeofenceActionQuarantine)
+	}
+
+	if settings.GeofenceQuarantineGroup != "" {
+		if _, ok := groups[settings.GeofenceQuarantineGroup]; !ok {
+			return status.Errorf(status.InvalidArgument, "geofence quarantine group %s doesn't exist", settings.GeofenceQuarantineGroup)
+		}
+	}
+
+	return nil
+}
+
+// geofenceSettingsEqual returns true if the two settings have the same geofence
+func geofenceSettingsEqual(a, b *Settings) bool {
+	return slices.Equal(a.GeofenceCountries, b.GeofenceCountries) && slices.Equal(a.GeofenceGroups, b.GeofenceGroups) &&
+		a.GeofenceAction == b.GeofenceAction && a.GeofenceQuarantineGroup == b.GeofenceQuarantineGroup
+}
+
+// isPeerGeofenced returns true if the geofence of the account applies to the peer
+func (a *Account) isPeerGeofenced(peer *nbpeer.Peer) bool {
+	if len(a.Settings.GeofenceCountries) == 0 {
+		return false
+	}
+	if len(a.Settings.GeofenceGroups) == 0 {
+		return true
+	}
+	for _, groupID := range a.Settings.GeofenceGroups {
+		if group, ok := a.Groups[groupID]; ok && slices.Contains(group.Peers, peer.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// getPeerGeofenceViolation returns the country the peer is located in outside the geofence, empty if it complies with
+// it. A peer with an unknown location complies with it
+func (a *Account) getPeerGeofenceViolation(peer *nbpeer.Peer, now time.Time) string {
+	country := peer.Location.CountryCode
+	if country == "" || !a.isPeerGeofenced(peer) || slices.Contains(a.Settings.GeofenceCountries, country) {
+		return ""
+	}
+	if peer.GeofenceOverride.Allows(country, now) {
+		return ""
+	}
+	return country
+}
+
+// isPeerGeofenceBlocked returns true if the peer is outside the geofence and the account blocks such peers
+func (a *Account) isPeerGeofenceBlocked(peerID string) bool {
+	peer := a.Peers[peerID]
+	return peer != nil && peer.GeofenceViolation != "" && a.Settings.geofenceAction() == GeofenceActionBlock
+}
+
+// isPeerGeofenceQuarantined returns true if the peer is outside the geofence and the account quarantines such peers
+func (a *Account) isPeerGeofenceQuarantined(peer *nbpeer.Peer) bool {
+	return peer.GeofenceViolation != "" && a.Settings.geofenceAction() == GeofenceActionQuarantine
+}
+
+// geofenceAllowsConnection returns false if one of the peers is quarantined and the other isn't in the quarantine group
+func (a *Account) geofenceAllowsConnection(peerID, remotePeerID string) bool {
+	if a.Settings == nil || a.Settings.geofenceAction() != GeofenceActionQuarantine {
+		return true
+	}
+
+	peer, remotePeer := a.Peers[peerID], a.Peers[remotePeerID]
+	if peer == nil || remotePeer == nil {
+		return true
+	}
+
+	var quarantinePeers []string
+	if group, ok := a.Groups[a.Settings.GeofenceQuarantineGroup]; ok {
+		quarantinePeers = group.Peers
+	}
+
+	if a.isPeerGeofenceQuarantined(peer) && !slices.Contains(quarantinePeers, remotePeer.ID) {
+		return false
+	}
+	return !a.isPeerGeofenceQuarantined(remotePeer) || slices.Contains(quarantinePeers, peer.ID)
+}
+
+func (a *Account) getPeerGeofence(peer *nbpeer.Peer) *PeerGeofence {
+	geofence := &PeerGeofence{
+		PeerID:      peer.ID,
+		CountryCode: peer.Location.CountryCode,
+		Geofenced:   a.isPeerGeofenced(peer),
+		Violation:   peer.GeofenceViolation,
+		ViolatedAt:  peer.GeofenceViolatedAt,
+		Override:    peer.GeofenceOverride.Copy(),
+	}
+	if peer.GeofenceViolation != "" {
+		geofence.Action = a.Settings.geofenceAction()
+	}
+	return geofence
+}
+
+// updatePeerGeofence evaluates the geofence for the location of the peer and stores an event when the peer crosses it.
+// It returns true if the violation of the peer changed
+func (am *DefaultAccountManager) updatePeerGeofence(account *Account, peer *nbpeer.Peer) bool {
+	now := time.Now().UTC()
+	violation := account.getPeerGeofenceViolation(peer, now)
+	if violation == peer.GeofenceViolation {
+		return false
+	}
+
+	previous := peer.GeofenceViolation
+	peer.GeofenceViolation = violation
+
+	meta := peer.EventMeta(am.GetDNSDomain())
+	if violation == "" {
+		peer.GeofenceViolatedAt = time.Time{}
+		meta["country"] = previous
+		am.StoreEvent(activity.SystemInitiator, peer.ID, account.Id, activity.PeerGeofenceCleared, meta)
+		return true
+	}
+
+	if previous == "" {
+		peer.GeofenceViolatedAt = now
+	}
+	meta["country"] = violation
+	meta["action"] = account.Settings.geofenceAction()
+	am.StoreEvent(activity.SystemInitiator, peer.ID, account.Id, activity.PeerGeofenceViolated, meta)
+
+	return true
+}
+
+// updatePeersGeofence evaluates the geofence for all the peers of the account and returns true if any violation changed
+func (am *DefaultAccountManager) updatePeersGeofence(account *Account) bool {
+	changed := false
+	for _, peer := range account.Peers {
+		if am.updatePeerGeofence(account, peer) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// updateConnectedPeerGeofence evaluates the geofence for the location the peer connected from and stores the result
+func (am *DefaultAccountManager) updateConnectedPeerGeofence(account *Account, peer *nbpeer.Peer) bool {
+	if !am.updatePeerGeofence(account, peer) {
+		return false
+	}
+
+	if err := am.Store.SavePeerGeofence(account.Id, peer); err != nil {
+		log.Warnf("could not store the geofence violation of peer %s: %s", peer.ID, err)
+	}
+
+	return true
+}
+
+// GetPeerGeofence returns the geofence state of the peer. Only users with admin power and the owner of the peer can
+// view it.
+func (am *DefaultAccountManager) GetPeerGeofence(accountID, userID, peerID string) (*PeerGeofence, error) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	peer, _, err := account.findGeofencePeer(userID, peerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return account.getPeerGeofence(peer), nil
+}
+
+// RequestPeerGeofenceOverride requests an exception to the geofence for the country the peer is located in outside of
+// it. The override applies once an admin approves it. Only users with admin power and the owner of the peer can request
+// it.
+func (am *DefaultAccountManager) RequestPeerGeofenceOverride(accountID, userID, peerID, reason string) (*PeerGeofence, error) {
+	if reason == "" {
+		return nil, status.Errorf(status.InvalidArgument, "a reason is required to request a geofence override")
+	}
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	peer, _, err := account.findGeofencePeer(userID, peerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if peer.GeofenceViolation == "" {
+		return nil, status.Errorf(status.PreconditionFailed, "peer %s is not located outside the geofence", peerID)
+	}
+
+	peer.GeofenceOverride = &nbpeer.GeofenceOverride{
+		Country:     peer.GeofenceViolation,
+		Reason:      reason,
+		RequestedBy: userID,
+		RequestedAt: time.Now().UTC(),
+	}
+
+	if err = am.Store.SaveAccount(account); err != nil {
+		return nil, err
+	}
+
+	meta := peer.EventMeta(am.GetDNSDomain())
+	meta["country"] = peer.GeofenceOverride.Country
+	meta["reason"] = reason
+	am.StoreEvent(userID, peer.ID, accountID, activity.PeerGeofenceOverrideRequested, meta)
+
+	return account.getPeerGeofence(peer), nil
+}
+
+// ApprovePeerGeofenceOverride approves the pending geofence override of the peer for the given duration, 0 approves
+// it until it is revoked. Only users with admin power can approve it.
+func (am *DefaultAccountManager) ApprovePeerGeofenceOverride(accountID, userID, peerID string, duration time.Duration) (*PeerGeofence, error) {
+	if duration < 0 {
+		return nil, status.Errorf(status.InvalidArgument, "geofence override duration can't be negative")
+	}
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	peer, user, err := account.findGeofencePeer(userID, peerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasAdminPower() {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can approve geofence overrides")
+	}
+
+	override := peer.GeofenceOverride
+	if override == nil || override.Approved() {
+		return nil, status.Errorf(status.PreconditionFailed, "peer %s has no pending geofence override", peerID)
+	}
+
+	override.ApprovedBy = userID
+	override.ApprovedAt = time.Now().UTC()
+	if duration != 0 {
+		override.ExpiresAt = override.ApprovedAt.Add(duration)
+	}
+
+	meta := peer.EventMeta(am.GetDNSDomain())
+	meta["country"] = override.Country
+	if !override.ExpiresAt.IsZero() {
+		meta["expires_at"] = override.ExpiresAt
+	}
+	am.StoreEvent(userID, peer.ID, accountID, activity.PeerGeofenceOverrideApproved, meta)
+
+	if err = am.saveGeofenceOverride(account, peer); err != nil {
+		return nil, err
+	}
+
+	return account.getPeerGeofence(peer), nil
+}
+
+// RevokePeerGeofenceOverride rejects the pending geofence override of the peer or revokes the approved one. Only users
+// with admin power can revoke it.
+func (am *DefaultAccountManager) RevokePeerGeofenceOverride(accountID, userID, peerID string) (*PeerGeofence, error) {
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	peer, user, err := account.findGeofencePeer(userID, peerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasAdminPower() {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can revoke geofence overrides")
+	}
+
+	if peer.GeofenceOverride == nil {
+		return nil, status.Errorf(status.NotFound, "peer %s has no geofence override", peerID)
+	}
+
+	meta := peer.EventMeta(am.GetDNSDomain())
+	meta["country"] = peer.GeofenceOverride.Country
+	meta["approved"] = peer.GeofenceOverride.Approved()
+	peer.GeofenceOverride = nil
+	am.StoreEvent(userID, peer.ID, accountID, activity.PeerGeofenceOverrideRevoked, meta)
+
+	if err = am.saveGeofenceOverride(account, peer); err != nil {
+		return nil, err
+	}
+
+	return account.getPeerGeofence(peer), nil
+}
+
+// saveGeofenceOverride evaluates the geofence for the peer after its override changed, stores the account and updates
+// the peers when the peer crossed the geofence
+func (am *DefaultAccountManager) saveGeofenceOverride(account *Account, peer *nbpeer.Peer) error {
+	changed := am.updatePeerGeofence(account, peer)
+	if changed {
+		account.Network.IncSerial()
+	}
+
+	if err := am.Store.SaveAccount(account); err != nil {
+		return err
+	}
+
+	if changed {
+		am.updateAccountPeers(account)
+	}
+
+	return nil
+}
+
+// findGeofencePeer returns the peer and the user if the user has admin power or owns the peer
+func (a *Account) findGeofencePeer(userID, peerID string) (*nbpeer.Peer, *User, error) {
+	user, err := a.FindUser(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	peer := a.GetPeer(peerID)
+	if peer == nil {
+		return nil, nil, status.Errorf(status.NotFound, "peer %s not found", peerID)
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) && peer.UserID != userID {
+		return nil, nil, status.Errorf(status.PermissionDenied, "only users with admin power and the owner of the peer can access its geofence")
+	}
+
+	return peer, user, nil
+}
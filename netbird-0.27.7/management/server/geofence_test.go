@@ -0,0 +1,174 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbgroup "github.com/netbirdio/netbird/management/server/group"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestValidateGeofenceSettings(t *testing.T) {
+	groups := map[string]*nbgroup.Group{"remediation": {ID: "remediation"}}
+
+	assert.NoError(t, validateGeofenceSettings(&Settings{}, groups))
+	assert.NoError(t, validateGeofenceSettings(&Settings{GeofenceCountries: []string{"DE", "NL"}}, groups))
+	assert.NoError(t, validateGeofenceSettings(&Settings{
+		GeofenceCountries:       []string{"DE"},
+		GeofenceAction:          GeofenceActionQuarantine,
+		GeofenceQuarantineGroup: "remediation",
+	}, groups))
+
+	assert.Error(t, validateGeofenceSettings(&Settings{GeofenceCountries: []string{"de"}}, groups))
+	assert.Error(t, validateGeofenceSettings(&Settings{GeofenceCountries: []string{"DEU"}}, groups))
+	assert.Error(t, validateGeofenceSettings(&Settings{GeofenceCountries: []string{"DE", "DE"}}, groups))
+	assert.Error(t, validateGeofenceSettings(&Settings{GeofenceGroups: []string{"unknown"}}, groups))
+	assert.Error(t, validateGeofenceSettings(&Settings{GeofenceAction: "drop"}, groups))
+	assert.Error(t, validateGeofenceSettings(&Settings{GeofenceAction: GeofenceActionQuarantine}, groups),
+		"the quarantine action should require a quarantine group")
+	assert.Error(t, validateGeofenceSettings(&Settings{
+		GeofenceAction:          GeofenceActionQuarantine,
+		GeofenceQuarantineGroup: "unknown",
+	}, groups))
+}
+
+func TestDefaultAccountManager_Geofence(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err, "unable to create an account")
+
+	traveller, _, err := manager.AddPeer("", userID, newQuotaTestPeer(t, "traveller"))
+	require.NoError(t, err, "unable to add peer")
+	office, _, err := manager.AddPeer("", userID, newQuotaTestPeer(t, "office"))
+	require.NoError(t, err, "unable to add peer")
+	remediation, _, err := manager.AddPeer("", userID, newQuotaTestPeer(t, "remediation"))
+	require.NoError(t, err, "unable to add peer")
+	require.NoError(t, manager.SaveGroup(account.Id, userID, &nbgroup.Group{
+		ID: "remediation", Name: "remediation", Peers: []string{remediation.ID},
+	}))
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	account.Peers[traveller.ID].Location.CountryCode = "FR"
+	account.Peers[office.ID].Location.CountryCode = "DE"
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	settings := account.Settings.Copy()
+	settings.GeofenceCountries = []string{"de"}
+	_, err = manager.UpdateAccountSettings(account.Id, userID, settings)
+	require.Error(t, err, "an invalid country should be rejected")
+
+	settings.GeofenceCountries = []string{"DE"}
+	_, err = manager.UpdateAccountSettings(account.Id, userID, settings)
+	require.NoError(t, err)
+	getEvent(t, account.Id, manager, activity.AccountGeofenceUpdated)
+	ev := getEvent(t, account.Id, manager, activity.PeerGeofenceViolated)
+	assert.Equal(t, traveller.ID, ev.TargetID)
+	assert.Equal(t, "FR", ev.Meta["country"])
+
+	networkMap := func(peerID string) map[string]bool {
+		account, err := manager.Store.GetAccount(account.Id)
+		require.NoError(t, err)
+		validatedPeersMap, err := manager.GetValidatedPeers(account)
+		require.NoError(t, err)
+		peers := make(map[string]bool)
+		for _, peer := range account.GetPeerNetworkMap(peerID, manager.GetDNSDomain(), validatedPeersMap).Peers {
+			peers[peer.ID] = true
+		}
+		return peers
+	}
+
+	assert.Empty(t, networkMap(traveller.ID), "the blocked peer should get an empty network map")
+	assert.Equal(t, map[string]bool{remediation.ID: true}, networkMap(office.ID),
+		"the blocked peer should be left out of the network maps")
+
+	geofence, err := manager.GetPeerGeofence(account.Id, userID, traveller.ID)
+	require.NoError(t, err)
+	assert.True(t, geofence.Geofenced)
+	assert.Equal(t, "FR", geofence.Violation)
+	assert.Equal(t, GeofenceActionBlock, geofence.Action)
+	assert.False(t, geofence.ViolatedAt.IsZero())
+
+	_, err = manager.RequestPeerGeofenceOverride(account.Id, userID, office.ID, "visiting")
+	sErr, ok := status.FromError(err)
+	require.True(t, ok, "expected a status error, got %v", err)
+	assert.Equal(t, status.PreconditionFailed, sErr.Type(), "a peer inside the geofence shouldn't need an override")
+
+	_, err = manager.ApprovePeerGeofenceOverride(account.Id, userID, traveller.ID, 0)
+	assert.Error(t, err, "an override that wasn't requested shouldn't be approved")
+
+	geofence, err = manager.RequestPeerGeofenceOverride(account.Id, userID, traveller.ID, "visiting the Paris office")
+	require.NoError(t, err)
+	require.NotNil(t, geofence.Override)
+	assert.Equal(t, "FR", geofence.Override.Country)
+	assert.False(t, geofence.Override.Approved())
+	assert.Equal(t, "FR", geofence.Violation, "a pending override shouldn't lift the block")
+	getEvent(t, account.Id, manager, activity.PeerGeofenceOverrideRequested)
+
+	geofence, err = manager.ApprovePeerGeofenceOverride(account.Id, userID, traveller.ID, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, geofence.Override.Approved())
+	assert.WithinDuration(t, time.Now().Add(time.Hour), geofence.Override.ExpiresAt, time.Minute)
+	assert.Empty(t, geofence.Violation)
+	getEvent(t, account.Id, manager, activity.PeerGeofenceOverrideApproved)
+	getEvent(t, account.Id, manager, activity.PeerGeofenceCleared)
+	assert.True(t, networkMap(office.ID)[traveller.ID], "the approved override should let the peer connect")
+
+	geofence, err = manager.RevokePeerGeofenceOverride(account.Id, userID, traveller.ID)
+	require.NoError(t, err)
+	assert.Nil(t, geofence.Override)
+	assert.Equal(t, "FR", geofence.Violation)
+	getEvent(t, account.Id, manager, activity.PeerGeofenceOverrideRevoked)
+
+	settings.GeofenceAction = GeofenceActionQuarantine
+	settings.GeofenceQuarantineGroup = "remediation"
+	_, err = manager.UpdateAccountSettings(account.Id, userID, settings)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{remediation.ID: true}, networkMap(traveller.ID),
+		"the quarantined peer should only connect to the quarantine group")
+	assert.Equal(t, map[string]bool{remediation.ID: true}, networkMap(office.ID))
+	assert.Equal(t, map[string]bool{traveller.ID: true, office.ID: true}, networkMap(remediation.ID))
+
+	err = manager.DeleteGroup(account.Id, userID, "remediation")
+	assert.Error(t, err, "the quarantine group shouldn't be deleted")
+
+	// the location is evaluated again when the peer connects
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	account.Peers[office.ID].Location.CountryCode = "CN"
+	require.NoError(t, manager.MarkPeerConnected(office.Key, true, nil, account))
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "CN", account.Peers[office.ID].GeofenceViolation)
+
+	settings.GeofenceCountries = nil
+	_, err = manager.UpdateAccountSettings(account.Id, userID, settings)
+	require.NoError(t, err)
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	for _, peer := range account.Peers {
+		assert.Empty(t, peer.GeofenceViolation, "disabling the geofence should clear the violations")
+	}
+}
+
+func TestGeofenceOverride_Allows(t *testing.T) {
+	now := time.Now()
+	var override *nbpeer.GeofenceOverride
+	assert.False(t, override.Allows("FR", now))
+
+	override = &nbpeer.GeofenceOverride{Country: "FR"}
+	assert.False(t, override.Allows("FR", now), "a pending override shouldn't apply")
+
+	override.ApprovedBy = userID
+	assert.True(t, override.Allows("FR", now))
+	assert.False(t, override.Allows("ES", now))
+
+	override.ExpiresAt = now.Add(-time.Minute)
+	assert.False(t, override.Allows("FR", now), "an expired override shouldn't apply")
+}
@@ -2,12 +2,15 @@ package server
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/rs/xid"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/netbirdio/netbird/management/server/activity"
 	nbgroup "github.com/netbirdio/netbird/management/server/group"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
 	"github.com/netbirdio/netbird/management/server/status"
 )
 
@@ -74,6 +77,109 @@ func (am *DefaultAccountManager) GetAllGroups(accountID string, userID string) (
 	return groups, nil
 }
 
+// GetGroupsForUser returns the groups of the account along with the peers of the account by ID, loading only them
+// instead of the whole account
+func (am *DefaultAccountManager) GetGroupsForUser(accountID, userID string) ([]*nbgroup.Group, map[string]*nbpeer.Peer, error) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	if err := am.checkGroupsViewAllowed(accountID, userID); err != nil {
+		return nil, nil, err
+	}
+
+	groups, err := am.Store.GetAccountGroups(accountID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	accountPeers, err := am.Store.GetAccountPeers(accountID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	peers := make(map[string]*nbpeer.Peer, len(accountPeers))
+	for _, peer := range accountPeers {
+		peers[peer.ID] = peer
+	}
+
+	return groups, peers, nil
+}
+
+// GetGroupsSummaryForUser returns the groups of the account without loading their peers
+func (am *DefaultAccountManager) GetGroupsSummaryForUser(accountID, userID string) ([]*nbgroup.Group, error) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	if err := am.checkGroupsViewAllowed(accountID, userID); err != nil {
+		return nil, err
+	}
+
+	return am.Store.GetAccountGroups(accountID)
+}
+
+// GetGroupPeersForUser returns the peers of the group from the offset up to the limit, in the order they were added
+// to the group, along with the number of peers of the group. Only the peers of the page are loaded.
+func (am *DefaultAccountManager) GetGroupPeersForUser(accountID, userID, groupID string, offset, limit int) ([]*nbpeer.Peer, int, error) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	if err := am.checkGroupsViewAllowed(accountID, userID); err != nil {
+		return nil, 0, err
+	}
+
+	group, err := am.Store.GetAccountGroup(accountID, groupID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := len(group.Peers)
+	if offset >= total {
+		return []*nbpeer.Peer{}, total, nil
+	}
+	pageIDs := group.Peers[offset:min(offset+limit, total)]
+
+	peers, err := am.Store.GetAccountPeersByIDs(accountID, pageIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// the store doesn't keep the order of the IDs
+	byID := make(map[string]*nbpeer.Peer, len(peers))
+	for _, peer := range peers {
+		byID[peer.ID] = peer
+	}
+	page := make([]*nbpeer.Peer, 0, len(peers))
+	for _, peerID := range pageIDs {
+		if peer, ok := byID[peerID]; ok {
+			page = append(page, peer)
+		}
+	}
+
+	return page, total, nil
+}
+
+// checkGroupsViewAllowed returns an error if the user isn't allowed to view the groups of the account
+func (am *DefaultAccountManager) checkGroupsViewAllowed(accountID, userID string) error {
+	user, err := am.Store.GetUserByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if user.AccountID != accountID {
+		return status.Errorf(status.PermissionDenied, "user %s doesn't belong to account %s", userID, accountID)
+	}
+
+	settings, err := am.Store.GetAccountSettings(accountID)
+	if err != nil {
+		return err
+	}
+
+	if !user.HasAdminPower() && !user.IsServiceUser && settings.RegularUsersViewBlocked {
+		return status.Errorf(status.PermissionDenied, "groups are blocked for users")
+	}
+
+	return nil
+}
+
 // GetGroupByName filters all groups in an account by name and returns the one with the most peers
 func (am *DefaultAccountManager) GetGroupByName(groupName, accountID string) (*nbgroup.Group, error) {
 	unlock := am.Store.AcquireAccountWriteLock(accountID)
@@ -107,6 +213,15 @@ func (am *DefaultAccountManager) GetGroupByName(groupName, accountID string) (*n
 	return groupWithMostPeers, nil
 }
 
+// UpdatedGroup returns a copy of the existing group with the name and the peers of an update request. The fields the
+// update requests don't replace, like the issuer, the client settings and the integration reference, are kept
+func UpdatedGroup(existing *nbgroup.Group, name string, peers []string) *nbgroup.Group {
+	group := existing.Copy()
+	group.Name = name
+	group.Peers = append(make([]string, 0, len(peers)), peers...)
+	return group
+}
+
 // SaveGroup object of the peers
 func (am *DefaultAccountManager) SaveGroup(accountID, userID string, newGroup *nbgroup.Group) error {
 	unlock := am.Store.AcquireAccountWriteLock(accountID)
@@ -146,6 +261,14 @@ func (am *DefaultAccountManager) SaveGroup(accountID, userID string, newGroup *n
 		}
 	}
 
+	if err = validateFeatureFlags(newGroup.FeatureFlags); err != nil {
+		return err
+	}
+
+	if err = validateClientUpdate(newGroup.ClientUpdateChannel, newGroup.ClientVersion); err != nil {
+		return err
+	}
+
 	oldGroup, exists := account.Groups[newGroup.ID]
 	account.Groups[newGroup.ID] = newGroup
 
@@ -168,6 +291,20 @@ func (am *DefaultAccountManager) SaveGroup(accountID, userID string, newGroup *n
 		am.StoreEvent(userID, newGroup.ID, accountID, activity.GroupCreated, newGroup.EventMeta())
 	}
 
+	if (exists && !maps.Equal(oldGroup.FeatureFlags, newGroup.FeatureFlags)) || (!exists && len(newGroup.FeatureFlags) != 0) {
+		meta := newGroup.EventMeta()
+		meta["feature_flags"] = newGroup.FeatureFlags
+		am.StoreEvent(userID, newGroup.ID, accountID, activity.GroupFeatureFlagsUpdated, meta)
+	}
+
+	if (exists && (oldGroup.ClientUpdateChannel != newGroup.ClientUpdateChannel || oldGroup.ClientVersion != newGroup.ClientVersion)) ||
+		(!exists && (newGroup.ClientUpdateChannel != "" || newGroup.ClientVersion != "")) {
+		meta := newGroup.EventMeta()
+		meta["channel"] = newGroup.ClientUpdateChannel
+		meta["version"] = newGroup.ClientVersion
+		am.StoreEvent(userID, newGroup.ID, accountID, activity.GroupClientUpdateChanged, meta)
+	}
+
 	for _, p := range addedPeers {
 		peer := account.Peers[p]
 		if peer == nil {
@@ -291,6 +428,15 @@ func (am *DefaultAccountManager) DeleteGroup(accountId, userId, groupID string)
 		}
 	}
 
+	// check claim group mapping links
+	for _, mapping := range account.ClaimGroupMappings {
+		for _, grp := range mapping.Groups {
+			if grp == groupID {
+				return &GroupLinkError{"claim group mapping", mapping.Name}
+			}
+		}
+	}
+
 	// check DisabledManagementGroups
 	for _, disabledMgmGrp := range account.DNSSettings.DisabledManagementGroups {
 		if disabledMgmGrp == groupID {
@@ -298,6 +444,25 @@ func (am *DefaultAccountManager) DeleteGroup(accountId, userId, groupID string)
 		}
 	}
 
+	// check DNS blocklist override groups
+	for _, overrideGrp := range account.DNSSettings.BlocklistOverrideGroups {
+		if overrideGrp == groupID {
+			return &GroupLinkError{"DNS blocklist override groups", g.Name}
+		}
+	}
+
+	// check DNS scoped groups
+	for _, dnsScopedGrp := range account.Settings.DNSScopedGroups {
+		if dnsScopedGrp == groupID {
+			return &GroupLinkError{"DNS scoped groups", g.Name}
+		}
+	}
+
+	// check geofence groups
+	if slices.Contains(account.Settings.GeofenceGroups, groupID) || account.Settings.GeofenceQuarantineGroup == groupID {
+		return &GroupLinkError{"geofence", g.Name}
+	}
+
 	// check integrated peer validator groups
 	if account.Settings.Extra != nil {
 		for _, integratedPeerValidatorGroups := range account.Settings.Extra.IntegratedValidatorGroups {
@@ -404,3 +569,24 @@ func (am *DefaultAccountManager) GroupDeletePeer(accountID, groupID, peerID stri
 
 	return nil
 }
+
+// getKeepWarmPeers returns the WireGuard keys of the given remote peers sharing a keep warm group with the peer
+func (a *Account) getKeepWarmPeers(peerID string, peers []*nbpeer.Peer) map[string]struct{} {
+	warmPeerIDs := make(map[string]struct{})
+	for _, group := range a.Groups {
+		if !group.KeepWarm || !slices.Contains(group.Peers, peerID) {
+			continue
+		}
+		for _, id := range group.Peers {
+			warmPeerIDs[id] = struct{}{}
+		}
+	}
+
+	keepWarm := make(map[string]struct{})
+	for _, peer := range peers {
+		if _, ok := warmPeerIDs[peer.ID]; ok && peer.ID != peerID {
+			keepWarm[peer.Key] = struct{}{}
+		}
+	}
+	return keepWarm
+}
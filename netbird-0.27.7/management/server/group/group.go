@@ -1,6 +1,10 @@
 package group
 
-import "github.com/netbirdio/netbird/management/server/integration_reference"
+import (
+	"maps"
+
+	"github.com/netbirdio/netbird/management/server/integration_reference"
+)
 
 const (
 	GroupIssuedAPI         = "api"
@@ -25,6 +29,18 @@ type Group struct {
 	// Peers list of the group
 	Peers []string `gorm:"serializer:json"`
 
+	// KeepWarm makes the peers of the group keep their connections to each other established and ready, trading idle
+	// keepalive traffic for the latency of the first packets
+	KeepWarm bool
+
+	// FeatureFlags switch client behaviors for the peers of the group, overriding the feature flags of the account
+	FeatureFlags map[string]bool `gorm:"serializer:json"`
+
+	// ClientUpdateChannel is the update channel the auto updating peers of the group follow, e.g. stable
+	ClientUpdateChannel string
+	// ClientVersion pins the version the auto updating peers of the group run, it takes precedence over the channel
+	ClientVersion string
+
 	IntegrationReference integration_reference.IntegrationReference `gorm:"embedded;embeddedPrefix:integration_ref_"`
 }
 
@@ -39,6 +55,10 @@ func (g *Group) Copy() *Group {
 		Name:                 g.Name,
 		Issued:               g.Issued,
 		Peers:                make([]string, len(g.Peers)),
+		KeepWarm:             g.KeepWarm,
+		FeatureFlags:         maps.Clone(g.FeatureFlags),
+		ClientUpdateChannel:  g.ClientUpdateChannel,
+		ClientVersion:        g.ClientVersion,
 		IntegrationReference: g.IntegrationReference,
 	}
 	copy(group.Peers, g.Peers)
@@ -4,8 +4,13 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	nbdns "github.com/netbirdio/netbird/dns"
 	nbgroup "github.com/netbirdio/netbird/management/server/group"
+	"github.com/netbirdio/netbird/management/server/integration_reference"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
 	"github.com/netbirdio/netbird/management/server/status"
 	"github.com/netbirdio/netbird/route"
 )
@@ -228,3 +233,144 @@ func initTestGroupAccount(am *DefaultAccountManager) (*Account, error) {
 
 	return am.Store.GetAccount(account.Id)
 }
+
+func TestDefaultAccountManager_GetGroupsForUser(t *testing.T) {
+	am, err := createManager(t)
+	require.NoError(t, err, "failed to create account manager")
+
+	account, err := initTestGroupAccount(am)
+	require.NoError(t, err, "failed to init testing account")
+
+	groups, peers, err := am.GetGroupsForUser(account.Id, groupAdminUserID)
+	require.NoError(t, err, "admins should view the groups")
+	require.Len(t, groups, len(account.Groups))
+	require.Len(t, peers, len(account.Peers))
+
+	account.Settings.RegularUsersViewBlocked = true
+	require.NoError(t, am.Store.SaveAccount(account))
+
+	_, _, err = am.GetGroupsForUser(account.Id, "example user")
+	require.Error(t, err, "the groups are blocked for regular users")
+
+	account.Settings.RegularUsersViewBlocked = false
+	require.NoError(t, am.Store.SaveAccount(account))
+
+	_, _, err = am.GetGroupsForUser(account.Id, "example user")
+	require.NoError(t, err, "regular users should view the groups when they aren't blocked")
+}
+
+func TestDefaultAccountManager_GetGroupPeersForUser(t *testing.T) {
+	am, err := createManager(t)
+	require.NoError(t, err, "failed to create account manager")
+
+	account, err := initTestGroupAccount(am)
+	require.NoError(t, err, "failed to init testing account")
+
+	group := &nbgroup.Group{ID: "grp-paged", Name: "Paged", Issued: nbgroup.GroupIssuedAPI}
+	for _, id := range []string{"peerC", "peerA", "missing", "peerB"} {
+		if id != "missing" {
+			account.Peers[id] = &nbpeer.Peer{ID: id, AccountID: account.Id, Key: id + "Key", Name: id + "-name"}
+		}
+		group.Peers = append(group.Peers, id)
+	}
+	account.Groups[group.ID] = group
+	require.NoError(t, am.Store.SaveAccount(account))
+
+	groups, err := am.GetGroupsSummaryForUser(account.Id, groupAdminUserID)
+	require.NoError(t, err)
+	require.Len(t, groups, len(account.Groups))
+
+	peers, total, err := am.GetGroupPeersForUser(account.Id, groupAdminUserID, group.ID, 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+	require.Len(t, peers, 2)
+	assert.Equal(t, "peerC", peers[0].ID, "the peers should be in the order of the group")
+	assert.Equal(t, "peerA-name", peers[1].Name)
+
+	peers, _, err = am.GetGroupPeersForUser(account.Id, groupAdminUserID, group.ID, 2, 2)
+	require.NoError(t, err)
+	require.Len(t, peers, 1, "the missing peers should be skipped")
+	assert.Equal(t, "peerB", peers[0].ID)
+
+	peers, _, err = am.GetGroupPeersForUser(account.Id, groupAdminUserID, group.ID, 4, 2)
+	require.NoError(t, err)
+	assert.Empty(t, peers)
+
+	_, _, err = am.GetGroupPeersForUser(account.Id, groupAdminUserID, "unknown", 0, 2)
+	require.Error(t, err)
+
+	account.Settings.RegularUsersViewBlocked = true
+	require.NoError(t, am.Store.SaveAccount(account))
+	_, _, err = am.GetGroupPeersForUser(account.Id, "example user", group.ID, 0, 2)
+	require.Error(t, err, "the groups are blocked for regular users")
+}
+
+func TestAccount_getKeepWarmPeers(t *testing.T) {
+	account := &Account{
+		Peers: map[string]*nbpeer.Peer{
+			"peerA": {ID: "peerA", Key: "peerAKey"},
+			"peerB": {ID: "peerB", Key: "peerBKey"},
+			"peerC": {ID: "peerC", Key: "peerCKey"},
+			"peerD": {ID: "peerD", Key: "peerDKey"},
+		},
+		Groups: map[string]*nbgroup.Group{
+			"GroupWarm": {ID: "GroupWarm", Peers: []string{"peerA", "peerB", "peerD"}, KeepWarm: true},
+			"GroupCold": {ID: "GroupCold", Peers: []string{"peerA", "peerC"}},
+		},
+	}
+	remotePeers := func(ids ...string) []*nbpeer.Peer {
+		var peers []*nbpeer.Peer
+		for _, id := range ids {
+			peers = append(peers, account.Peers[id])
+		}
+		return peers
+	}
+
+	t.Run("peer of a keep warm group", func(t *testing.T) {
+		keepWarm := account.getKeepWarmPeers("peerA", remotePeers("peerB", "peerC"))
+		assert.Equal(t, map[string]struct{}{"peerBKey": {}}, keepWarm, "only the connected peers of the keep warm group")
+	})
+
+	t.Run("peer outside of keep warm groups", func(t *testing.T) {
+		keepWarm := account.getKeepWarmPeers("peerC", remotePeers("peerA"))
+		assert.Empty(t, keepWarm)
+	})
+
+	t.Run("keep warm disabled", func(t *testing.T) {
+		account.Groups["GroupWarm"].KeepWarm = false
+		defer func() { account.Groups["GroupWarm"].KeepWarm = true }()
+		keepWarm := account.getKeepWarmPeers("peerA", remotePeers("peerB", "peerD"))
+		assert.Empty(t, keepWarm)
+	})
+}
+
+func TestUpdatedGroup(t *testing.T) {
+	existing := &nbgroup.Group{
+		ID:                  "group1",
+		Name:                "old name",
+		Issued:              nbgroup.GroupIssuedIntegration,
+		Peers:               []string{"peerA"},
+		KeepWarm:            true,
+		FeatureFlags:        map[string]bool{"lazy": true},
+		ClientUpdateChannel: "beta",
+		ClientVersion:       "0.27.7",
+		IntegrationReference: integration_reference.IntegrationReference{
+			ID:              1,
+			IntegrationType: "azure",
+		},
+	}
+
+	group := UpdatedGroup(existing, "new name", []string{"peerB", "peerC"})
+
+	expected := existing.Copy()
+	expected.Name = "new name"
+	expected.Peers = []string{"peerB", "peerC"}
+	assert.Equal(t, expected, group, "the fields the update doesn't replace should be kept")
+
+	group.FeatureFlags["lazy"] = false
+	assert.True(t, existing.FeatureFlags["lazy"], "the existing group shouldn't be changed")
+
+	group = UpdatedGroup(existing, "new name", nil)
+	assert.NotNil(t, group.Peers, "a group without peers should have an empty list")
+	assert.Empty(t, group.Peers)
+}
@@ -45,20 +45,9 @@ func NewServer(config *Config, accountManager AccountManager, peersUpdateManager
 		return nil, err
 	}
 
-	var jwtValidator *jwtclaims.JWTValidator
-
-	if config.HttpConfig != nil && config.HttpConfig.AuthIssuer != "" && config.HttpConfig.AuthAudience != "" && validateURL(config.HttpConfig.AuthKeysLocation) {
-		jwtValidator, err = jwtclaims.NewJWTValidator(
-			config.HttpConfig.AuthIssuer,
-			config.GetAuthAudiences(),
-			config.HttpConfig.AuthKeysLocation,
-			config.HttpConfig.IdpSignKeyRefreshEnabled,
-		)
-		if err != nil {
-			return nil, status.Errorf(codes.Internal, "unable to create new jwt middleware, err: %v", err)
-		}
-	} else {
-		log.Debug("unable to use http config to create new jwt middleware")
+	jwtValidator, err := newJWTValidator(config)
+	if err != nil {
+		return nil, err
 	}
 
 	if appMetrics != nil {
@@ -69,17 +58,15 @@ func NewServer(config *Config, accountManager AccountManager, peersUpdateManager
 		if err != nil {
 			return nil, err
 		}
-	}
 
-	var audience, userIDClaim string
-	if config.HttpConfig != nil {
-		audience = config.HttpConfig.AuthAudience
-		userIDClaim = config.HttpConfig.AuthUserIDClaim
+		// the connections between peers falling back to a relay, as last reported by the peers
+		err = appMetrics.GRPCMetrics().RegisterRelayedConnections(func() int64 {
+			return int64(accountManager.CountRelayedConnections())
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
-	jwtClaimsExtractor := jwtclaims.NewClaimsExtractor(
-		jwtclaims.WithAudience(audience),
-		jwtclaims.WithUserIDClaim(userIDClaim),
-	)
 
 	return &GRPCServer{
 		wgKey: key,
@@ -89,12 +76,45 @@ func NewServer(config *Config, accountManager AccountManager, peersUpdateManager
 		config:                 config,
 		turnCredentialsManager: turnCredentialsManager,
 		jwtValidator:           jwtValidator,
-		jwtClaimsExtractor:     jwtClaimsExtractor,
+		jwtClaimsExtractor:     newJWTClaimsExtractor(config),
 		appMetrics:             appMetrics,
 		ephemeralManager:       ephemeralManager,
 	}, nil
 }
 
+// newJWTValidator creates the validator of the JWTs issued by the IdP configured for the HTTP API, it is nil when the
+// HTTP API has no IdP configured
+func newJWTValidator(config *Config) (*jwtclaims.JWTValidator, error) {
+	if config.HttpConfig == nil || config.HttpConfig.AuthIssuer == "" || config.HttpConfig.AuthAudience == "" || !validateURL(config.HttpConfig.AuthKeysLocation) {
+		log.Debug("unable to use http config to create new jwt middleware")
+		return nil, nil
+	}
+
+	jwtValidator, err := jwtclaims.NewJWTValidator(
+		config.HttpConfig.AuthIssuer,
+		config.GetAuthAudiences(),
+		config.HttpConfig.AuthKeysLocation,
+		config.HttpConfig.IdpSignKeyRefreshEnabled,
+	)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to create new jwt middleware, err: %v", err)
+	}
+	return jwtValidator, nil
+}
+
+// newJWTClaimsExtractor creates the extractor of the claims of the JWTs issued by the IdP configured for the HTTP API
+func newJWTClaimsExtractor(config *Config) *jwtclaims.ClaimsExtractor {
+	var audience, userIDClaim string
+	if config.HttpConfig != nil {
+		audience = config.HttpConfig.AuthAudience
+		userIDClaim = config.HttpConfig.AuthUserIDClaim
+	}
+	return jwtclaims.NewClaimsExtractor(
+		jwtclaims.WithAudience(audience),
+		jwtclaims.WithUserIDClaim(userIDClaim),
+	)
+}
+
 func (s *GRPCServer) GetServerKey(ctx context.Context, req *proto.Empty) (*proto.ServerKeyResponse, error) {
 	// todo introduce something more meaningful with the key expiration/rotation
 	if s.appMetrics != nil {
@@ -153,6 +173,11 @@ func (s *GRPCServer) Sync(req *proto.EncryptedMessage, srv proto.ManagementServi
 		s.turnCredentialsManager.SetupRefresh(peer.ID)
 	}
 
+	// the credentials of the relays of the account expire, the network map is sent again before they do
+	peerRelays := netMap.Relays
+	relayRefresh := newRelayRefreshTimer(peerRelays)
+	defer relayRefresh.Stop()
+
 	if s.appMetrics != nil {
 		s.appMetrics.GRPCMetrics().CountSyncRequestDuration(time.Since(reqStart))
 	}
@@ -189,6 +214,20 @@ func (s *GRPCServer) Sync(req *proto.EncryptedMessage, srv proto.ManagementServi
 				return status.Errorf(codes.Internal, "failed sending update message")
 			}
 			log.Debugf("sent an update to peer %s", peerKey.String())
+
+			// the update carries the relays of the peer with fresh credentials
+			if update.NetworkMap != nil {
+				peerRelays = update.NetworkMap.Relays
+				relayRefresh.Reset(peerRelays)
+			}
+		case <-relayRefresh.C:
+			relays, err := s.accountManager.RefreshPeerRelays(peer.ID)
+			if err != nil {
+				log.Warnf("failed to refresh the relay credentials of peer %s: %v", peerKey.String(), err)
+			} else {
+				peerRelays = relays
+			}
+			relayRefresh.Reset(peerRelays)
 		// condition when client <-> server connection has been terminated
 		case <-srv.Context().Done():
 			// happens when connection drops, e.g. client disconnects
@@ -217,7 +256,7 @@ func (s *GRPCServer) validateToken(jwtToken string) (string, error) {
 	}
 	claims := s.jwtClaimsExtractor.FromToken(token)
 	// we need to call this method because if user is new, we will automatically add it to existing or create a new account
-	_, _, err = s.accountManager.GetAccountFromToken(claims)
+	account, _, err := s.accountManager.GetAccountFromToken(claims)
 	if err != nil {
 		return "", status.Errorf(codes.Internal, "unable to fetch account with claims, err: %v", err)
 	}
@@ -226,6 +265,11 @@ func (s *GRPCServer) validateToken(jwtToken string) (string, error) {
 		return "", status.Errorf(codes.PermissionDenied, err.Error())
 	}
 
+	// the groups of the user's peers follow the claims of the token, re-evaluated at every login
+	if err := s.accountManager.SyncUserClaimGroups(account.Id, claims); err != nil {
+		return "", status.Errorf(codes.Internal, "unable to apply the claim group mappings, err: %v", err)
+	}
+
 	return claims.UserId, nil
 }
 
@@ -243,6 +287,8 @@ func mapError(err error) error {
 			return status.Errorf(codes.FailedPrecondition, e.Message)
 		case internalStatus.NotFound:
 			return status.Errorf(codes.NotFound, e.Message)
+		case internalStatus.ResourceExhausted:
+			return status.Errorf(codes.ResourceExhausted, e.Message)
 		default:
 		}
 	}
@@ -287,6 +333,30 @@ func extractPeerMeta(loginReq *proto.LoginRequest) nbpeer.PeerSystemMeta {
 			Cloud:    loginReq.GetMeta().GetEnvironment().GetCloud(),
 			Platform: loginReq.GetMeta().GetEnvironment().GetPlatform(),
 		},
+		Inventory: extractPeerInventory(loginReq.GetMeta().GetInventory()),
+	}
+}
+
+func extractPeerInventory(inventory *proto.Inventory) nbpeer.Inventory {
+	packages := make([]nbpeer.Package, 0, len(inventory.GetPackages()))
+	for _, p := range inventory.GetPackages() {
+		if p.GetName() == "" {
+			continue
+		}
+		packages = append(packages, nbpeer.Package{
+			Name:    p.GetName(),
+			Version: p.GetVersion(),
+		})
+	}
+	if len(packages) == 0 {
+		packages = nil
+	}
+
+	return nbpeer.Inventory{
+		PackageManager: inventory.GetPackageManager(),
+		PatchLevel:     inventory.GetPatchLevel(),
+		PackagesCount:  int(inventory.GetPackagesCount()),
+		Packages:       packages,
 	}
 }
 
@@ -365,6 +435,7 @@ func (s *GRPCServer) Login(ctx context.Context, req *proto.EncryptedMessage) (*p
 		UserID:          userID,
 		SetupKey:        loginReq.GetSetupKey(),
 		ConnectionIP:    realIP,
+		RequestedIP:     net.ParseIP(loginReq.GetRequestedAddress()),
 	})
 
 	if err != nil {
@@ -380,7 +451,7 @@ func (s *GRPCServer) Login(ctx context.Context, req *proto.EncryptedMessage) (*p
 	// if peer has reached this point then it has logged in
 	loginResp := &proto.LoginResponse{
 		WiretrusteeConfig: toWiretrusteeConfig(s.config, nil),
-		PeerConfig:        toPeerConfig(peer, netMap.Network, s.accountManager.GetDNSDomain()),
+		PeerConfig:        toPeerConfig(peer, netMap.Network, s.accountManager.GetDNSDomain(), netMap.MTU, netMap.ICE, netMap.Rosenpass, netMap.LoginExpiration, netMap.SSHEnabled, netMap.FeatureFlags, toClientUpdateConfig(s.config.ClientUpdates, netMap.ClientUpdate), netMap.ControlPlane),
 	}
 	encryptedResp, err := encryption.EncryptMessage(peerKey, s.wgKey, loginResp)
 	if err != nil {
@@ -453,45 +524,68 @@ func toWiretrusteeConfig(config *Config, turnCredentials *TURNCredentials) *prot
 	}
 }
 
-func toPeerConfig(peer *nbpeer.Peer, network *Network, dnsName string) *proto.PeerConfig {
+func toPeerConfig(peer *nbpeer.Peer, network *Network, dnsName string, mtu int, ice ICESettings, rosenpass RosenpassSettings, loginExpiration LoginExpirationSettings, sshEnabled bool, featureFlags map[string]bool, clientUpdate *proto.ClientUpdateConfig, controlPlane []string) *proto.PeerConfig {
 	netmask, _ := network.Net.Mask.Size()
 	fqdn := peer.FQDN(dnsName)
 	return &proto.PeerConfig{
-		Address:   fmt.Sprintf("%s/%d", peer.IP.String(), netmask), // take it from the network
-		SshConfig: &proto.SSHConfig{SshEnabled: peer.SSHEnabled},
-		Fqdn:      fqdn,
+		Address:         fmt.Sprintf("%s/%d", peer.IP.String(), netmask), // take it from the network
+		SshConfig:       &proto.SSHConfig{SshEnabled: peer.SSHEnabled || sshEnabled},
+		Fqdn:            fqdn,
+		Mtu:             int64(mtu),
+		Uplink:          peer.Uplink,
+		Ice:             toICEConfig(ice),
+		Rosenpass:       toRosenpassConfig(rosenpass),
+		LoginExpiration: toLoginExpirationConfig(loginExpiration),
+		FeatureFlags:    toProtocolFeatureFlags(featureFlags),
+		ClientUpdate:    clientUpdate,
+		ControlPlane:    controlPlane,
 	}
 }
 
-func toRemotePeerConfig(peers []*nbpeer.Peer, dnsName string) []*proto.RemotePeerConfig {
+func toRemotePeerConfig(peers []*nbpeer.Peer, dnsName string, presharedKeys map[string]string, directOnlyPeers, keepWarmPeers, sshAuthorizedPeers, speedtestPeers map[string]struct{}) []*proto.RemotePeerConfig {
 	remotePeers := []*proto.RemotePeerConfig{}
 	for _, rPeer := range peers {
 		fqdn := rPeer.FQDN(dnsName)
+		_, requireDirect := directOnlyPeers[rPeer.Key]
+		_, keepWarm := keepWarmPeers[rPeer.Key]
+		_, speedtestAllowed := speedtestPeers[rPeer.Key]
+		// the SSH key of a remote peer authorizes it to log in, it is only pushed when the peer is allowed to
+		sshKey := rPeer.SSHKey
+		if _, ok := sshAuthorizedPeers[rPeer.Key]; sshAuthorizedPeers != nil && !ok {
+			sshKey = ""
+		}
 		remotePeers = append(remotePeers, &proto.RemotePeerConfig{
-			WgPubKey:   rPeer.Key,
-			AllowedIps: []string{fmt.Sprintf(AllowedIPsFormat, rPeer.IP)},
-			SshConfig:  &proto.SSHConfig{SshPubKey: []byte(rPeer.SSHKey)},
-			Fqdn:       fqdn,
+			WgPubKey:         rPeer.Key,
+			AllowedIps:       []string{fmt.Sprintf(AllowedIPsFormat, rPeer.IP)},
+			SshConfig:        &proto.SSHConfig{SshPubKey: []byte(sshKey)},
+			Fqdn:             fqdn,
+			PresharedKey:     presharedKeys[rPeer.Key],
+			RequireDirect:    requireDirect,
+			KeepWarm:         keepWarm,
+			RosenpassPubKey:  rPeer.RosenpassPubKey,
+			SpeedtestAllowed: speedtestAllowed,
 		})
 	}
 	return remotePeers
 }
 
-func toSyncResponse(config *Config, peer *nbpeer.Peer, turnCredentials *TURNCredentials, networkMap *NetworkMap, dnsName string) *proto.SyncResponse {
+func toSyncResponse(config *Config, peer *nbpeer.Peer, turnCredentials *TURNCredentials, networkMap *NetworkMap, dnsName string, clientUpdates *ClientUpdatesConfig) *proto.SyncResponse {
 	wtConfig := toWiretrusteeConfig(config, turnCredentials)
 
-	pConfig := toPeerConfig(peer, networkMap.Network, dnsName)
+	pConfig := toPeerConfig(peer, networkMap.Network, dnsName, networkMap.MTU, networkMap.ICE, networkMap.Rosenpass, networkMap.LoginExpiration, networkMap.SSHEnabled, networkMap.FeatureFlags, toClientUpdateConfig(clientUpdates, networkMap.ClientUpdate), networkMap.ControlPlane)
 
-	remotePeers := toRemotePeerConfig(networkMap.Peers, dnsName)
+	remotePeers := toRemotePeerConfig(networkMap.Peers, dnsName, networkMap.PresharedKeys, networkMap.DirectOnlyPeers, networkMap.KeepWarmPeers, networkMap.SSHAuthorizedPeers, networkMap.SpeedtestPeers)
 
 	routesUpdate := toProtocolRoutes(networkMap.Routes)
 
 	dnsUpdate := toProtocolDNSConfig(networkMap.DNSConfig)
 
-	offlinePeers := toRemotePeerConfig(networkMap.OfflinePeers, dnsName)
+	offlinePeers := toRemotePeerConfig(networkMap.OfflinePeers, dnsName, networkMap.PresharedKeys, networkMap.DirectOnlyPeers, networkMap.KeepWarmPeers, networkMap.SSHAuthorizedPeers, networkMap.SpeedtestPeers)
 
 	firewallRules := toProtocolFirewallRules(networkMap.FirewallRules)
 
+	relays := toProtocolRelays(networkMap.Relays, time.Now())
+
 	return &proto.SyncResponse{
 		WiretrusteeConfig:  wtConfig,
 		PeerConfig:         pConfig,
@@ -507,10 +601,25 @@ func toSyncResponse(config *Config, peer *nbpeer.Peer, turnCredentials *TURNCred
 			DNSConfig:            dnsUpdate,
 			FirewallRules:        firewallRules,
 			FirewallRulesIsEmpty: len(firewallRules) == 0,
+			Relays:               relays,
+			RelaysIsEmpty:        len(relays) == 0,
+			BlockedNetworks:      toProtocolNetworks(networkMap.BlockedNetworks),
 		},
 	}
 }
 
+// toProtocolNetworks converts the networks to their CIDR notation
+func toProtocolNetworks(networks []netip.Prefix) []string {
+	if len(networks) == 0 {
+		return nil
+	}
+	protoNetworks := make([]string, 0, len(networks))
+	for _, network := range networks {
+		protoNetworks = append(protoNetworks, network.String())
+	}
+	return protoNetworks
+}
+
 // IsHealthy indicates whether the service is healthy
 func (s *GRPCServer) IsHealthy(ctx context.Context, req *proto.Empty) (*proto.Empty, error) {
 	return &proto.Empty{}, nil
@@ -526,7 +635,7 @@ func (s *GRPCServer) sendInitialSync(peerKey wgtypes.Key, peer *nbpeer.Peer, net
 	} else {
 		turnCredentials = nil
 	}
-	plainResp := toSyncResponse(s.config, peer, turnCredentials, networkMap, s.accountManager.GetDNSDomain())
+	plainResp := toSyncResponse(s.config, peer, turnCredentials, networkMap, s.accountManager.GetDNSDomain(), s.config.ClientUpdates)
 
 	encryptedResp, err := encryption.EncryptMessage(peerKey, s.wgKey, plainResp)
 	if err != nil {
@@ -643,3 +752,377 @@ func (s *GRPCServer) GetPKCEAuthorizationFlow(_ context.Context, req *proto.Encr
 		Body:     encryptedResp,
 	}, nil
 }
+
+// ReportPeerStatus stores the runtime state reported by the peer, e.g. routes that couldn't be installed on the peer's system
+func (s *GRPCServer) ReportPeerStatus(ctx context.Context, req *proto.EncryptedMessage) (*proto.Empty, error) {
+	report := &proto.PeerStatusReport{}
+	peerKey, err := s.parseRequest(req, report)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := make([]nbpeer.RouteConflict, 0, len(report.GetRouteConflicts()))
+	for _, conflict := range report.GetRouteConflicts() {
+		prefix, err := netip.ParsePrefix(conflict.GetNetwork())
+		if err != nil {
+			log.Warnf("failed to parse route conflict network %s reported by peer %s: %v", conflict.GetNetwork(), peerKey, err)
+			continue
+		}
+		conflicts = append(conflicts, nbpeer.RouteConflict{
+			NetID:         conflict.GetNetID(),
+			Network:       prefix,
+			ConflictsWith: conflict.GetConflictsWith(),
+			Reason:        conflict.GetReason(),
+		})
+	}
+
+	if err := s.accountManager.UpdatePeerRouteConflicts(peerKey.String(), conflicts); err != nil {
+		log.Warnf("failed updating route conflicts of peer %s: %v", peerKey, err)
+		return nil, mapError(err)
+	}
+
+	if err := s.accountManager.UpdatePeerRosenpassPubKey(peerKey.String(), report.GetRosenpassPubKey()); err != nil {
+		log.Warnf("failed updating Rosenpass public key of peer %s: %v", peerKey, err)
+		return nil, mapError(err)
+	}
+
+	if natType := report.GetNatType(); natType != "" {
+		if err := s.accountManager.UpdatePeerNATType(peerKey.String(), natType); err != nil {
+			log.Warnf("failed updating NAT type of peer %s: %v", peerKey, err)
+			return nil, mapError(err)
+		}
+	}
+
+	return &proto.Empty{}, nil
+}
+
+// ProposeRoutes registers the networks proposed by the peer as routes pending admin approval
+func (s *GRPCServer) ProposeRoutes(ctx context.Context, req *proto.EncryptedMessage) (*proto.EncryptedMessage, error) {
+	proposal := &proto.ProposeRoutesRequest{}
+	peerKey, err := s.parseRequest(req, proposal)
+	if err != nil {
+		return nil, err
+	}
+
+	networks := make([]netip.Prefix, 0, len(proposal.GetNetworks()))
+	for _, network := range proposal.GetNetworks() {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid network %s", network)
+		}
+		networks = append(networks, prefix)
+	}
+
+	routes, err := s.accountManager.ProposePeerRoutes(peerKey.String(), networks)
+	if err != nil {
+		log.Warnf("failed registering routes proposed by peer %s: %v", peerKey, err)
+		return nil, mapError(err)
+	}
+
+	resp := &proto.ProposeRoutesResponse{}
+	for _, r := range routes {
+		resp.Networks = append(resp.Networks, r.Network.String())
+	}
+
+	encryptedResp, err := encryption.EncryptMessage(peerKey, s.wgKey, resp)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to encrypt proposed routes response")
+	}
+
+	return &proto.EncryptedMessage{
+		WgPubKey: s.wgKey.PublicKey().String(),
+		Body:     encryptedResp,
+	}, nil
+}
+
+// ReportTransferStats stores the WireGuard transfer statistics reported by the peer
+func (s *GRPCServer) ReportTransferStats(ctx context.Context, req *proto.EncryptedMessage) (*proto.Empty, error) {
+	report := &proto.TransferStatsReport{}
+	peerKey, err := s.parseRequest(req, report)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]nbpeer.TransferStats, 0, len(report.GetStats()))
+	for _, peerStats := range report.GetStats() {
+		var lastHandshake time.Time
+		if peerStats.GetLastHandshake() != nil {
+			lastHandshake = peerStats.GetLastHandshake().AsTime()
+		}
+		stats = append(stats, nbpeer.TransferStats{
+			RemotePeerKey: peerStats.GetWgPubKey(),
+			RxBytes:       peerStats.GetRxBytes(),
+			TxBytes:       peerStats.GetTxBytes(),
+			LastHandshake: lastHandshake,
+			Endpoint:      peerStats.GetEndpoint(),
+			Relayed:       peerStats.GetRelayed(),
+		})
+	}
+
+	if err := s.accountManager.UpdatePeerTransferStats(peerKey.String(), stats); err != nil {
+		log.Warnf("failed updating transfer stats of peer %s: %v", peerKey, err)
+		return nil, mapError(err)
+	}
+
+	return &proto.Empty{}, nil
+}
+
+// ReportDNSStats stores the DNS query counters reported by the peer
+func (s *GRPCServer) ReportDNSStats(ctx context.Context, req *proto.EncryptedMessage) (*proto.Empty, error) {
+	report := &proto.DNSStatsReport{}
+	peerKey, err := s.parseRequest(req, report)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := nbpeer.DNSStats{
+		LocalQueries:     report.GetLocalQueries(),
+		ForwardedQueries: report.GetForwardedQueries(),
+		BlockedQueries:   report.GetBlockedQueries(),
+		FailedQueries:    report.GetFailedQueries(),
+		AverageLatency:   report.GetAverageLatency().AsDuration(),
+		ReportedAt:       time.Now().UTC(),
+	}
+
+	if err := s.accountManager.UpdatePeerDNSStats(peerKey.String(), stats); err != nil {
+		log.Warnf("failed updating DNS stats of peer %s: %v", peerKey, err)
+		return nil, mapError(err)
+	}
+
+	return &proto.Empty{}, nil
+}
+
+// ReportFirewallStats stores the firewall rule counters reported by the peer
+func (s *GRPCServer) ReportFirewallStats(ctx context.Context, req *proto.EncryptedMessage) (*proto.Empty, error) {
+	report := &proto.FirewallStatsReport{}
+	peerKey, err := s.parseRequest(req, report)
+	if err != nil {
+		return nil, err
+	}
+
+	reportedAt := time.Now().UTC()
+	stats := make([]nbpeer.FirewallStats, 0, len(report.GetPolicies()))
+	for _, policyStats := range report.GetPolicies() {
+		stats = append(stats, nbpeer.FirewallStats{
+			PolicyID:   policyStats.GetPolicyID(),
+			Packets:    policyStats.GetPackets(),
+			Bytes:      policyStats.GetBytes(),
+			ReportedAt: reportedAt,
+		})
+	}
+
+	if err := s.accountManager.UpdatePeerFirewallStats(peerKey.String(), stats); err != nil {
+		log.Warnf("failed updating firewall stats of peer %s: %v", peerKey, err)
+		return nil, mapError(err)
+	}
+
+	return &proto.Empty{}, nil
+}
+
+// ReportDroppedConnections stores the dropped connections reported by the peer
+func (s *GRPCServer) ReportDroppedConnections(ctx context.Context, req *proto.EncryptedMessage) (*proto.Empty, error) {
+	report := &proto.DroppedConnectionsReport{}
+	peerKey, err := s.parseRequest(req, report)
+	if err != nil {
+		return nil, err
+	}
+
+	conns := make([]nbpeer.DroppedConnection, 0, len(report.GetConnections()))
+	for _, conn := range report.GetConnections() {
+		conns = append(conns, nbpeer.DroppedConnection{
+			SourceIP:        conn.GetSourceIP(),
+			SourcePort:      uint16(conn.GetSourcePort()),
+			DestinationPort: uint16(conn.GetDestinationPort()),
+			Protocol:        conn.GetProtocol(),
+			PolicyIDs:       conn.GetPolicyIDs(),
+			FirstSeen:       conn.GetFirstSeen().AsTime(),
+			LastSeen:        conn.GetLastSeen().AsTime(),
+			Packets:         conn.GetPackets(),
+		})
+	}
+
+	if err := s.accountManager.UpdatePeerDroppedConnections(peerKey.String(), conns); err != nil {
+		log.Warnf("failed updating dropped connections of peer %s: %v", peerKey, err)
+		return nil, mapError(err)
+	}
+
+	return &proto.Empty{}, nil
+}
+
+// ReportSSHSession stores the SSH session to the embedded SSH server reported by the peer as an audit event
+func (s *GRPCServer) ReportSSHSession(ctx context.Context, req *proto.EncryptedMessage) (*proto.Empty, error) {
+	report := &proto.SSHSessionReport{}
+	peerKey, err := s.parseRequest(req, report)
+	if err != nil {
+		return nil, err
+	}
+
+	session := nbpeer.SSHSession{
+		PeerKey:   report.GetWgPubKey(),
+		User:      report.GetUser(),
+		StartedAt: report.GetStartedAt().AsTime(),
+		Duration:  report.GetDuration().AsDuration(),
+	}
+	if err := s.accountManager.StorePeerSSHSession(peerKey.String(), session); err != nil {
+		log.Warnf("failed storing SSH session of peer %s: %v", peerKey, err)
+		return nil, mapError(err)
+	}
+
+	return &proto.Empty{}, nil
+}
+
+// ReportSpeedtest stores the throughput test the peer ran against another peer as an activity event
+func (s *GRPCServer) ReportSpeedtest(ctx context.Context, req *proto.EncryptedMessage) (*proto.Empty, error) {
+	report := &proto.SpeedtestReport{}
+	peerKey, err := s.parseRequest(req, report)
+	if err != nil {
+		return nil, err
+	}
+
+	result := nbpeer.SpeedtestResult{
+		PeerKey:     report.GetWgPubKey(),
+		Protocol:    report.GetProtocol(),
+		Direction:   report.GetDirection(),
+		Bytes:       report.GetBytes(),
+		Duration:    report.GetDuration().AsDuration(),
+		PacketsSent: report.GetPacketsSent(),
+		PacketsLost: report.GetPacketsLost(),
+	}
+	if err := s.accountManager.StorePeerSpeedtest(peerKey.String(), result); err != nil {
+		log.Warnf("failed storing speed test of peer %s: %v", peerKey, err)
+		return nil, mapError(err)
+	}
+
+	return &proto.Empty{}, nil
+}
+
+// ReportPeerLoad stores the load reported by a routing peer
+func (s *GRPCServer) ReportPeerLoad(ctx context.Context, req *proto.EncryptedMessage) (*proto.Empty, error) {
+	report := &proto.PeerLoadReport{}
+	peerKey, err := s.parseRequest(req, report)
+	if err != nil {
+		return nil, err
+	}
+
+	load := nbpeer.Load{
+		CPUPercent:    report.GetCpuPercent(),
+		MemoryPercent: report.GetMemoryPercent(),
+		ThroughputBps: report.GetThroughputBps(),
+		ReportedAt:    time.Now().UTC(),
+	}
+	if err := s.accountManager.UpdatePeerLoad(peerKey.String(), load); err != nil {
+		log.Warnf("failed updating load of peer %s: %v", peerKey, err)
+		return nil, mapError(err)
+	}
+
+	return &proto.Empty{}, nil
+}
+
+// ReportPeerAction stores the result of a remote action the peer ran as an activity event
+func (s *GRPCServer) ReportPeerAction(ctx context.Context, req *proto.EncryptedMessage) (*proto.Empty, error) {
+	report := &proto.PeerActionResult{}
+	peerKey, err := s.parseRequest(req, report)
+	if err != nil {
+		return nil, err
+	}
+
+	result := PeerActionResult{
+		ID:              report.GetId(),
+		Type:            report.GetType(),
+		Error:           report.GetError(),
+		DebugBundleName: report.GetDebugBundleName(),
+	}
+	if ping := report.GetPing(); ping != nil {
+		result.Ping = &PeerPingResult{
+			IP:              ping.GetIp(),
+			FQDN:            ping.GetFqdn(),
+			Sent:            int(ping.GetSent()),
+			Received:        int(ping.GetReceived()),
+			Path:            ping.GetPath(),
+			LocalCandidate:  ping.GetLocalCandidate(),
+			RemoteCandidate: ping.GetRemoteCandidate(),
+		}
+		for _, rtt := range ping.GetRtts() {
+			result.Ping.RTTs = append(result.Ping.RTTs, rtt.AsDuration())
+		}
+	}
+	if err := s.accountManager.ReportPeerAction(peerKey.String(), result); err != nil {
+		log.Warnf("failed storing result of action %s of peer %s: %v", result.ID, peerKey, err)
+		return nil, mapError(err)
+	}
+
+	return &proto.Empty{}, nil
+}
+
+// RotatePeerKey replaces the WireGuard key of the peer with the one it generated for a rotate_wireguard_key action
+func (s *GRPCServer) RotatePeerKey(ctx context.Context, req *proto.EncryptedMessage) (*proto.Empty, error) {
+	rotation := &proto.RotatePeerKeyRequest{}
+	peerKey, err := s.parseRequest(req, rotation)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.accountManager.RotatePeerKey(peerKey.String(), rotation.GetActionId(), rotation.GetWgPubKey()); err != nil {
+		log.Warnf("failed rotating WireGuard key of peer %s: %v", peerKey, err)
+		return nil, mapError(err)
+	}
+
+	return &proto.Empty{}, nil
+}
+
+// UploadDebugBundle stores the debug bundle the peer uploaded for support
+func (s *GRPCServer) UploadDebugBundle(ctx context.Context, req *proto.EncryptedMessage) (*proto.EncryptedMessage, error) {
+	upload := &proto.DebugBundleUpload{}
+	peerKey, err := s.parseRequest(req, upload)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := s.accountManager.StorePeerDebugBundle(peerKey.String(), upload.GetContent())
+	if err != nil {
+		log.Warnf("failed storing debug bundle of peer %s: %v", peerKey, err)
+		return nil, mapError(err)
+	}
+
+	encryptedResp, err := encryption.EncryptMessage(peerKey, s.wgKey, &proto.DebugBundleUploadResponse{Name: name})
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to encrypt debug bundle upload response")
+	}
+
+	return &proto.EncryptedMessage{
+		WgPubKey: s.wgKey.PublicKey().String(),
+		Body:     encryptedResp,
+	}, nil
+}
+
+// ResolvePeer looks up a peer of the account by its name, FQDN, IP or key for the peer to probe it over the tunnel
+func (s *GRPCServer) ResolvePeer(ctx context.Context, req *proto.EncryptedMessage) (*proto.EncryptedMessage, error) {
+	resolve := &proto.ResolvePeerRequest{}
+	peerKey, err := s.parseRequest(req, resolve)
+	if err != nil {
+		return nil, err
+	}
+
+	peer, accessible, err := s.accountManager.ResolvePeer(peerKey.String(), resolve.GetTarget())
+	if err != nil {
+		log.Debugf("failed resolving %s for peer %s: %v", resolve.GetTarget(), peerKey, err)
+		return nil, mapError(err)
+	}
+
+	resp := &proto.ResolvePeerResponse{
+		Ip:         peer.IP.String(),
+		Fqdn:       peer.FQDN(s.accountManager.GetDNSDomain()),
+		WgPubKey:   peer.Key,
+		Connected:  peer.Status.Connected,
+		Accessible: accessible,
+	}
+	encryptedResp, err := encryption.EncryptMessage(peerKey, s.wgKey, resp)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to encrypt resolve peer response")
+	}
+
+	return &proto.EncryptedMessage{
+		WgPubKey: s.wgKey.PublicKey().String(),
+		Body:     encryptedResp,
+	}, nil
+}
@@ -2,10 +2,12 @@ package http
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
 
 	"github.com/netbirdio/netbird/management/server"
 	"github.com/netbirdio/netbird/management/server/account"
@@ -69,7 +71,7 @@ func (h *AccountsHandler) UpdateAccount(w http.ResponseWriter, r *http.Request)
 	var req api.PutApiAccountsAccountIdJSONRequestBody
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		util.WriteJSONParseError(err, w)
 		return
 	}
 
@@ -95,6 +97,75 @@ func (h *AccountsHandler) UpdateAccount(w http.ResponseWriter, r *http.Request)
 	if req.Settings.JwtAllowGroups != nil {
 		settings.JWTAllowGroups = *req.Settings.JwtAllowGroups
 	}
+	if req.Settings.PresharedKeyMode != nil {
+		settings.PresharedKeyMode = string(*req.Settings.PresharedKeyMode)
+	}
+	if req.Settings.InterfaceMtu != nil {
+		settings.InterfaceMTU = *req.Settings.InterfaceMtu
+	}
+	if req.Settings.DnsScopedGroups != nil {
+		settings.DNSScopedGroups = *req.Settings.DnsScopedGroups
+	}
+	if req.Settings.IceDisabledCandidateTypes != nil {
+		settings.ICEDisabledCandidateTypes = *req.Settings.IceDisabledCandidateTypes
+	}
+	if req.Settings.IceExcludedSubnets != nil {
+		settings.ICEExcludedSubnets = *req.Settings.IceExcludedSubnets
+	}
+	if req.Settings.IceFailedTimeout != nil {
+		settings.ICEFailedTimeout = time.Duration(*req.Settings.IceFailedTimeout) * time.Second
+	}
+	if req.Settings.IceDisconnectedTimeout != nil {
+		settings.ICEDisconnectedTimeout = time.Duration(*req.Settings.IceDisconnectedTimeout) * time.Second
+	}
+	if req.Settings.RosenpassEnabled != nil {
+		settings.RosenpassEnabled = *req.Settings.RosenpassEnabled
+	}
+	if req.Settings.RosenpassPermissive != nil {
+		settings.RosenpassPermissive = *req.Settings.RosenpassPermissive
+	}
+	if req.Settings.EventsRetentionDays != nil {
+		settings.EventsRetention = time.Duration(*req.Settings.EventsRetentionDays) * 24 * time.Hour
+	}
+	if req.Settings.PeerLoginExpirationWarnings != nil {
+		for _, warning := range *req.Settings.PeerLoginExpirationWarnings {
+			settings.PeerLoginExpirationWarnings = append(settings.PeerLoginExpirationWarnings, time.Duration(warning)*time.Second)
+		}
+	}
+
+	if req.Settings.DnsLabelCollisionStrategy != nil {
+		settings.DNSLabelCollisionStrategy = string(*req.Settings.DnsLabelCollisionStrategy)
+	}
+	if req.Settings.PeersPerUserLimit != nil {
+		settings.PeersPerUserLimit = *req.Settings.PeersPerUserLimit
+	}
+	if req.Settings.FeatureFlags != nil {
+		settings.FeatureFlags = *req.Settings.FeatureFlags
+	}
+	if req.Settings.PeerActions != nil {
+		settings.PeerActions = *req.Settings.PeerActions
+	}
+	if req.Settings.PeerControlPlane != nil {
+		settings.PeerControlPlane = make([]string, 0, len(*req.Settings.PeerControlPlane))
+		for _, part := range *req.Settings.PeerControlPlane {
+			settings.PeerControlPlane = append(settings.PeerControlPlane, string(part))
+		}
+	}
+	if req.Settings.GeofenceCountries != nil {
+		settings.GeofenceCountries = *req.Settings.GeofenceCountries
+	}
+	if req.Settings.GeofenceGroups != nil {
+		settings.GeofenceGroups = *req.Settings.GeofenceGroups
+	}
+	if req.Settings.GeofenceAction != nil {
+		settings.GeofenceAction = string(*req.Settings.GeofenceAction)
+	}
+	if req.Settings.GeofenceQuarantineGroup != nil {
+		settings.GeofenceQuarantineGroup = *req.Settings.GeofenceQuarantineGroup
+	}
+	if req.Settings.PeerKeyRotationInterval != nil {
+		settings.PeerKeyRotation = time.Duration(*req.Settings.PeerKeyRotationInterval) * time.Second
+	}
 
 	updatedAccount, err := h.accountManager.UpdateAccountSettings(accountID, user.Id, settings)
 	if err != nil {
@@ -122,7 +193,52 @@ func (h *AccountsHandler) DeleteAccount(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err := h.accountManager.DeleteAccount(targetAccountID, claims.UserId)
+	var (
+		deletion *server.AccountDeletion
+		err      error
+	)
+	// the first call issues the token the second one confirms the deletion with
+	if token := r.URL.Query().Get("confirmation_token"); token != "" {
+		deletion, err = h.accountManager.ConfirmAccountDeletion(targetAccountID, claims.UserId, token)
+	} else {
+		deletion, err = h.accountManager.RequestAccountDeletion(targetAccountID, claims.UserId)
+	}
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toAccountDeletionResponse(deletion))
+}
+
+// GetAccountDeletion is a GET request returning the scheduled deletion of an account
+func (h *AccountsHandler) GetAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	targetAccountID := mux.Vars(r)["accountId"]
+	if len(targetAccountID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid account ID"), w)
+		return
+	}
+
+	deletion, err := h.accountManager.GetAccountDeletion(targetAccountID, claims.UserId)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toAccountDeletionResponse(deletion))
+}
+
+// CancelAccountDeletion is a DELETE request canceling the scheduled deletion of an account
+func (h *AccountsHandler) CancelAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	targetAccountID := mux.Vars(r)["accountId"]
+	if len(targetAccountID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid account ID"), w)
+		return
+	}
+
+	err := h.accountManager.CancelAccountDeletion(targetAccountID, claims.UserId)
 	if err != nil {
 		util.WriteError(err, w)
 		return
@@ -131,6 +247,45 @@ func (h *AccountsHandler) DeleteAccount(w http.ResponseWriter, r *http.Request)
 	util.WriteJSONObject(w, emptyObject{})
 }
 
+// GetAccountExport is a GET request downloading the latest export of an account scheduled for deletion
+func (h *AccountsHandler) GetAccountExport(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	targetAccountID := mux.Vars(r)["accountId"]
+	if len(targetAccountID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid account ID"), w)
+		return
+	}
+
+	name, data, err := h.accountManager.GetAccountExport(targetAccountID, claims.UserId)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		log.Errorf("failed writing the export of account %s: %v", targetAccountID, err)
+	}
+}
+
+func toAccountDeletionResponse(deletion *server.AccountDeletion) *api.AccountDeletion {
+	resp := &api.AccountDeletion{}
+	if deletion.ConfirmationToken != "" {
+		resp.ConfirmationToken = &deletion.ConfirmationToken
+		resp.ConfirmationExpiresAt = &deletion.ConfirmationExpiresAt
+	}
+	if !deletion.ScheduledAt.IsZero() {
+		resp.ScheduledAt = &deletion.ScheduledAt
+		resp.RequestedBy = &deletion.RequestedBy
+	}
+	if deletion.ExportName != "" {
+		resp.ExportName = &deletion.ExportName
+	}
+	return resp
+}
+
 func toAccountResponse(account *server.Account) *api.Account {
 	jwtAllowGroups := account.Settings.JWTAllowGroups
 	if jwtAllowGroups == nil {
@@ -147,6 +302,102 @@ func toAccountResponse(account *server.Account) *api.Account {
 		RegularUsersViewBlocked:    account.Settings.RegularUsersViewBlocked,
 	}
 
+	if account.Settings.InterfaceMTU != 0 {
+		settings.InterfaceMtu = &account.Settings.InterfaceMTU
+	}
+
+	if len(account.Settings.DNSScopedGroups) != 0 {
+		settings.DnsScopedGroups = &account.Settings.DNSScopedGroups
+	}
+
+	if len(account.Settings.ICEDisabledCandidateTypes) != 0 {
+		settings.IceDisabledCandidateTypes = &account.Settings.ICEDisabledCandidateTypes
+	}
+
+	if len(account.Settings.ICEExcludedSubnets) != 0 {
+		settings.IceExcludedSubnets = &account.Settings.ICEExcludedSubnets
+	}
+
+	if account.Settings.ICEFailedTimeout != 0 {
+		failedTimeout := int(account.Settings.ICEFailedTimeout.Seconds())
+		settings.IceFailedTimeout = &failedTimeout
+	}
+
+	if account.Settings.ICEDisconnectedTimeout != 0 {
+		disconnectedTimeout := int(account.Settings.ICEDisconnectedTimeout.Seconds())
+		settings.IceDisconnectedTimeout = &disconnectedTimeout
+	}
+
+	if account.Settings.RosenpassEnabled {
+		settings.RosenpassEnabled = &account.Settings.RosenpassEnabled
+		settings.RosenpassPermissive = &account.Settings.RosenpassPermissive
+	}
+
+	if account.Settings.EventsRetention != 0 {
+		retentionDays := int(account.Settings.EventsRetention / (24 * time.Hour))
+		settings.EventsRetentionDays = &retentionDays
+	}
+
+	if len(account.Settings.PeerLoginExpirationWarnings) != 0 {
+		warnings := make([]int, 0, len(account.Settings.PeerLoginExpirationWarnings))
+		for _, warning := range account.Settings.PeerLoginExpirationWarnings {
+			warnings = append(warnings, int(warning.Seconds()))
+		}
+		settings.PeerLoginExpirationWarnings = &warnings
+	}
+
+	if account.Settings.PresharedKeyMode != "" {
+		presharedKeyMode := api.AccountSettingsPresharedKeyMode(account.Settings.PresharedKeyMode)
+		settings.PresharedKeyMode = &presharedKeyMode
+	}
+
+	if account.Settings.DNSLabelCollisionStrategy != "" {
+		strategy := api.AccountSettingsDnsLabelCollisionStrategy(account.Settings.DNSLabelCollisionStrategy)
+		settings.DnsLabelCollisionStrategy = &strategy
+	}
+
+	if account.Settings.PeersPerUserLimit != 0 {
+		settings.PeersPerUserLimit = &account.Settings.PeersPerUserLimit
+	}
+
+	if len(account.Settings.FeatureFlags) != 0 {
+		settings.FeatureFlags = &account.Settings.FeatureFlags
+	}
+
+	if len(account.Settings.PeerActions) != 0 {
+		settings.PeerActions = &account.Settings.PeerActions
+	}
+
+	if len(account.Settings.PeerControlPlane) != 0 {
+		controlPlane := make([]api.AccountSettingsPeerControlPlane, 0, len(account.Settings.PeerControlPlane))
+		for _, part := range account.Settings.PeerControlPlane {
+			controlPlane = append(controlPlane, api.AccountSettingsPeerControlPlane(part))
+		}
+		settings.PeerControlPlane = &controlPlane
+	}
+
+	if len(account.Settings.GeofenceCountries) != 0 {
+		settings.GeofenceCountries = &account.Settings.GeofenceCountries
+	}
+
+	if len(account.Settings.GeofenceGroups) != 0 {
+		settings.GeofenceGroups = &account.Settings.GeofenceGroups
+	}
+
+	if account.Settings.GeofenceAction != "" {
+		geofenceAction := api.AccountSettingsGeofenceAction(account.Settings.GeofenceAction)
+		settings.GeofenceAction = &geofenceAction
+	}
+
+	if account.Settings.GeofenceQuarantineGroup != "" {
+		settings.GeofenceQuarantineGroup = &account.Settings.GeofenceQuarantineGroup
+	}
+
+	if account.Settings.PeerKeyRotation != 0 {
+		keyRotation := int(account.Settings.PeerKeyRotation.Seconds())
+		settings.PeerKeyRotationInterval = &keyRotation
+	}
+
 	if account.Settings.Extra != nil {
 		settings.Extra = &api.AccountExtraSettings{PeerApprovalEnabled: &account.Settings.Extra.PeerApprovalEnabled}
 	}
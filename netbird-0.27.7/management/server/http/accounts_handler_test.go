@@ -58,6 +58,7 @@ func TestAccounts_AccountsHandler(t *testing.T) {
 
 	sr := func(v string) *string { return &v }
 	br := func(v bool) *bool { return &v }
+	ir := func(v int) *int { return &v }
 
 	handler := initAccountsTestData(&server.Account{
 		Id:      accountID,
@@ -159,6 +160,47 @@ func TestAccounts_AccountsHandler(t *testing.T) {
 			expectedArray: false,
 			expectedID:    accountID,
 		},
+		{
+			name:           "PutAccount OK with Rosenpass",
+			expectedBody:   true,
+			requestType:    http.MethodPut,
+			requestPath:    "/api/accounts/" + accountID,
+			requestBody:    bytes.NewBufferString("{\"settings\": {\"peer_login_expiration\": 15552000,\"peer_login_expiration_enabled\": true,\"rosenpass_enabled\":true,\"rosenpass_permissive\":true}}"),
+			expectedStatus: http.StatusOK,
+			expectedSettings: api.AccountSettings{
+				PeerLoginExpiration:        15552000,
+				PeerLoginExpirationEnabled: true,
+				GroupsPropagationEnabled:   br(false),
+				JwtGroupsClaimName:         sr(""),
+				JwtGroupsEnabled:           br(false),
+				JwtAllowGroups:             &[]string{},
+				RegularUsersViewBlocked:    false,
+				RosenpassEnabled:           br(true),
+				RosenpassPermissive:        br(true),
+			},
+			expectedArray: false,
+			expectedID:    accountID,
+		},
+		{
+			name:           "PutAccount OK with events retention",
+			expectedBody:   true,
+			requestType:    http.MethodPut,
+			requestPath:    "/api/accounts/" + accountID,
+			requestBody:    bytes.NewBufferString("{\"settings\": {\"peer_login_expiration\": 15552000,\"peer_login_expiration_enabled\": true,\"events_retention_days\":90}}"),
+			expectedStatus: http.StatusOK,
+			expectedSettings: api.AccountSettings{
+				PeerLoginExpiration:        15552000,
+				PeerLoginExpirationEnabled: true,
+				GroupsPropagationEnabled:   br(false),
+				JwtGroupsClaimName:         sr(""),
+				JwtGroupsEnabled:           br(false),
+				JwtAllowGroups:             &[]string{},
+				RegularUsersViewBlocked:    false,
+				EventsRetentionDays:        ir(90),
+			},
+			expectedArray: false,
+			expectedID:    accountID,
+		},
 		{
 			name:           "Update account failure with high peer_login_expiration more than 180 days",
 			expectedBody:   true,
@@ -12,6 +12,72 @@ const (
 	TokenAuthScopes  = "TokenAuth.Scopes"
 )
 
+// Defines values for AccountSettingsPresharedKeyMode.
+const (
+	AccountSettingsPresharedKeyModeAccount  AccountSettingsPresharedKeyMode = "account"
+	AccountSettingsPresharedKeyModeEmpty    AccountSettingsPresharedKeyMode = ""
+	AccountSettingsPresharedKeyModePeerPair AccountSettingsPresharedKeyMode = "peer_pair"
+)
+
+// Defines values for AccountSettingsDnsLabelCollisionStrategy.
+const (
+	AccountSettingsDnsLabelCollisionStrategyReject        AccountSettingsDnsLabelCollisionStrategy = "reject"
+	AccountSettingsDnsLabelCollisionStrategyReplaceOldest AccountSettingsDnsLabelCollisionStrategy = "replace-oldest"
+	AccountSettingsDnsLabelCollisionStrategySuffix        AccountSettingsDnsLabelCollisionStrategy = "suffix"
+)
+
+// Defines values for AccountSettingsPeerControlPlane.
+const (
+	AccountSettingsPeerControlPlaneAcl    AccountSettingsPeerControlPlane = "acl"
+	AccountSettingsPeerControlPlaneDns    AccountSettingsPeerControlPlane = "dns"
+	AccountSettingsPeerControlPlaneRoutes AccountSettingsPeerControlPlane = "routes"
+)
+
+// Defines values for AccountSettingsGeofenceAction.
+const (
+	AccountSettingsGeofenceActionBlock      AccountSettingsGeofenceAction = "block"
+	AccountSettingsGeofenceActionQuarantine AccountSettingsGeofenceAction = "quarantine"
+)
+
+// Defines values for DNSRecordType.
+const (
+	DNSRecordTypeA     DNSRecordType = "A"
+	DNSRecordTypeAAAA  DNSRecordType = "AAAA"
+	DNSRecordTypeCNAME DNSRecordType = "CNAME"
+	DNSRecordTypeTXT   DNSRecordType = "TXT"
+)
+
+// Defines values for DNSRecordRequestType.
+const (
+	DNSRecordRequestTypeA     DNSRecordRequestType = "A"
+	DNSRecordRequestTypeAAAA  DNSRecordRequestType = "AAAA"
+	DNSRecordRequestTypeCNAME DNSRecordRequestType = "CNAME"
+	DNSRecordRequestTypeTXT   DNSRecordRequestType = "TXT"
+)
+
+// Defines values for DNSSettingsBlocklistResponse.
+const (
+	DNSSettingsBlocklistResponseNxdomain DNSSettingsBlocklistResponse = "nxdomain"
+	DNSSettingsBlocklistResponseZeroIp   DNSSettingsBlocklistResponse = "zero_ip"
+)
+
+// Defines values for ErrorResponseErrorCode.
+const (
+	ErrorResponseErrorCodeAlreadyExists      ErrorResponseErrorCode = "already_exists"
+	ErrorResponseErrorCodeBadRequest         ErrorResponseErrorCode = "bad_request"
+	ErrorResponseErrorCodeInternal           ErrorResponseErrorCode = "internal"
+	ErrorResponseErrorCodeInvalidArgument    ErrorResponseErrorCode = "invalid_argument"
+	ErrorResponseErrorCodeInvalidJson        ErrorResponseErrorCode = "invalid_json"
+	ErrorResponseErrorCodeMethodNotAllowed   ErrorResponseErrorCode = "method_not_allowed"
+	ErrorResponseErrorCodeNotFound           ErrorResponseErrorCode = "not_found"
+	ErrorResponseErrorCodePermissionDenied   ErrorResponseErrorCode = "permission_denied"
+	ErrorResponseErrorCodePreconditionFailed ErrorResponseErrorCode = "precondition_failed"
+	ErrorResponseErrorCodeResourceExhausted  ErrorResponseErrorCode = "resource_exhausted"
+	ErrorResponseErrorCodeUnauthenticated    ErrorResponseErrorCode = "unauthenticated"
+	ErrorResponseErrorCodeUnauthorized       ErrorResponseErrorCode = "unauthorized"
+	ErrorResponseErrorCodeUserAlreadyExists  ErrorResponseErrorCode = "user_already_exists"
+)
+
 // Defines values for EventActivityCode.
 const (
 	EventActivityCodeAccountCreate                            EventActivityCode = "account.create"
@@ -63,6 +129,18 @@ const (
 	EventActivityCodeUserUnblock                              EventActivityCode = "user.unblock"
 )
 
+// Defines values for ExternalListFormat.
+const (
+	ExternalListFormatCidr ExternalListFormat = "cidr"
+	ExternalListFormatMisp ExternalListFormat = "misp"
+)
+
+// Defines values for ExternalListRequestFormat.
+const (
+	ExternalListRequestFormatCidr ExternalListRequestFormat = "cidr"
+	ExternalListRequestFormatMisp ExternalListRequestFormat = "misp"
+)
+
 // Defines values for GeoLocationCheckAction.
 const (
 	GeoLocationCheckActionAllow GeoLocationCheckAction = "allow"
@@ -85,7 +163,24 @@ const (
 
 // Defines values for NameserverNsType.
 const (
-	NameserverNsTypeUdp NameserverNsType = "udp"
+	NameserverNsTypeHttps NameserverNsType = "https"
+	NameserverNsTypeTls   NameserverNsType = "tls"
+	NameserverNsTypeUdp   NameserverNsType = "udp"
+)
+
+// Defines values for PeerActionRequestAction.
+const (
+	PeerActionRequestActionCollectDebugBundle PeerActionRequestAction = "collect_debug_bundle"
+	PeerActionRequestActionRestartEngine      PeerActionRequestAction = "restart_engine"
+	PeerActionRequestActionResync             PeerActionRequestAction = "resync"
+	PeerActionRequestActionRotateWireguardKey PeerActionRequestAction = "rotate_wireguard_key"
+)
+
+// Defines values for PeerGeofenceAction.
+const (
+	PeerGeofenceActionBlock      PeerGeofenceAction = "block"
+	PeerGeofenceActionEmpty      PeerGeofenceAction = ""
+	PeerGeofenceActionQuarantine PeerGeofenceAction = "quarantine"
 )
 
 // Defines values for PeerNetworkRangeCheckAction.
@@ -94,6 +189,13 @@ const (
 	PeerNetworkRangeCheckActionDeny  PeerNetworkRangeCheckAction = "deny"
 )
 
+// Defines values for PeerPingStatus.
+const (
+	PeerPingStatusDone    PeerPingStatus = "done"
+	PeerPingStatusFailed  PeerPingStatus = "failed"
+	PeerPingStatusPending PeerPingStatus = "pending"
+)
+
 // Defines values for PolicyRuleAction.
 const (
 	PolicyRuleActionAccept PolicyRuleAction = "accept"
@@ -136,6 +238,32 @@ const (
 	PolicyRuleUpdateProtocolUdp  PolicyRuleUpdateProtocol = "udp"
 )
 
+// Defines values for RouteConflictReason.
+const (
+	RouteConflictReasonDefaultGateway RouteConflictReason = "default_gateway"
+	RouteConflictReasonExists         RouteConflictReason = "exists"
+	RouteConflictReasonOverlaps       RouteConflictReason = "overlaps"
+)
+
+// Defines values for RouteGroupFailoverPolicy.
+const (
+	RouteGroupFailoverPolicyEcmp     RouteGroupFailoverPolicy = "ecmp"
+	RouteGroupFailoverPolicyPriority RouteGroupFailoverPolicy = "priority"
+)
+
+// Defines values for RouteGroupRequestFailoverPolicy.
+const (
+	RouteGroupRequestFailoverPolicyEcmp     RouteGroupRequestFailoverPolicy = "ecmp"
+	RouteGroupRequestFailoverPolicyPriority RouteGroupRequestFailoverPolicy = "priority"
+)
+
+// Defines values for UsageReportPeriod.
+const (
+	UsageReportPeriodDay   UsageReportPeriod = "day"
+	UsageReportPeriodMonth UsageReportPeriod = "month"
+	UsageReportPeriodWeek  UsageReportPeriod = "week"
+)
+
 // Defines values for UserStatus.
 const (
 	UserStatusActive  UserStatus = "active"
@@ -143,6 +271,25 @@ const (
 	UserStatusInvited UserStatus = "invited"
 )
 
+// Defines values for GetApiPeersInventoryParamsFormat.
+const (
+	GetApiPeersInventoryParamsFormatCsv  GetApiPeersInventoryParamsFormat = "csv"
+	GetApiPeersInventoryParamsFormatJson GetApiPeersInventoryParamsFormat = "json"
+)
+
+// Defines values for GetApiReportsUsageParamsPeriod.
+const (
+	GetApiReportsUsageParamsPeriodDay   GetApiReportsUsageParamsPeriod = "day"
+	GetApiReportsUsageParamsPeriodMonth GetApiReportsUsageParamsPeriod = "month"
+	GetApiReportsUsageParamsPeriodWeek  GetApiReportsUsageParamsPeriod = "week"
+)
+
+// Defines values for GetApiReportsUsageParamsFormat.
+const (
+	GetApiReportsUsageParamsFormatCsv  GetApiReportsUsageParamsFormat = "csv"
+	GetApiReportsUsageParamsFormatJson GetApiReportsUsageParamsFormat = "json"
+)
+
 // Defines values for UserPermissionsDashboardView.
 const (
 	UserPermissionsDashboardViewBlocked UserPermissionsDashboardView = "blocked"
@@ -150,6 +297,37 @@ const (
 	UserPermissionsDashboardViewLimited UserPermissionsDashboardView = "limited"
 )
 
+// APIQuota defines model for APIQuota.
+type APIQuota struct {
+	// RequestsPerDay Maximum number of HTTP API requests of the account in a UTC day, 0 is unlimited
+	RequestsPerDay int `json:"requests_per_day"`
+
+	// RequestsPerMinute Maximum number of HTTP API requests of the account in a minute, 0 is unlimited
+	RequestsPerMinute int `json:"requests_per_minute"`
+}
+
+// APIUsage defines model for APIUsage.
+type APIUsage struct {
+	// DayRequests Number of HTTP API requests of the account in the current UTC day
+	DayRequests int `json:"day_requests"`
+
+	// DayResetsAt Time the count of the current day starts over
+	DayResetsAt time.Time `json:"day_resets_at"`
+
+	// MinuteRequests Number of HTTP API requests of the account in the current minute
+	MinuteRequests int `json:"minute_requests"`
+
+	// MinuteResetsAt Time the count of the current minute starts over
+	MinuteResetsAt time.Time `json:"minute_resets_at"`
+	Quota          APIQuota  `json:"quota"`
+
+	// RejectedRequests Number of HTTP API requests of the account rejected for exceeding its quota since the management service started
+	RejectedRequests int64 `json:"rejected_requests"`
+
+	// TotalRequests Number of HTTP API requests of the account served since the management service started
+	TotalRequests int64 `json:"total_requests"`
+}
+
 // AccessiblePeer defines model for AccessiblePeer.
 type AccessiblePeer struct {
 	// DnsLabel Peer's DNS label is the parsed peer name for domain resolution. It is used to form an FQDN by appending the account's domain to the peer label. e.g. peer-dns-label.netbird.cloud
@@ -175,6 +353,24 @@ type Account struct {
 	Settings AccountSettings `json:"settings"`
 }
 
+// AccountDeletion defines model for AccountDeletion.
+type AccountDeletion struct {
+	// ConfirmationExpiresAt Time the confirmation token expires at
+	ConfirmationExpiresAt *time.Time `json:"confirmation_expires_at,omitempty"`
+
+	// ConfirmationToken Token confirming the deletion, only returned by the first deletion call
+	ConfirmationToken *string `json:"confirmation_token,omitempty"`
+
+	// ExportName Name of the latest export of the account, downloaded from /api/accounts/{accountId}/export
+	ExportName *string `json:"export_name,omitempty"`
+
+	// RequestedBy ID of the owner who confirmed the deletion
+	RequestedBy *string `json:"requested_by,omitempty"`
+
+	// ScheduledAt Time the account is deleted at, missing when no deletion is scheduled
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+}
+
 // AccountExtraSettings defines model for AccountExtraSettings.
 type AccountExtraSettings struct {
 	// PeerApprovalEnabled (Cloud only) Enables or disables peer approval globally. If enabled, all peers added will be in pending state until approved by an admin.
@@ -188,11 +384,49 @@ type AccountRequest struct {
 
 // AccountSettings defines model for AccountSettings.
 type AccountSettings struct {
-	Extra *AccountExtraSettings `json:"extra,omitempty"`
+	// DnsLabelCollisionStrategy How a peer added or renamed with the DNS label of another peer is handled. With "suffix" the peer gets the label with a numeric suffix, with "reject" the peer is rejected and with "replace-oldest" the peer gets the label and the peer that used it gets a numeric suffix. Defaults to suffix.
+	DnsLabelCollisionStrategy *AccountSettingsDnsLabelCollisionStrategy `json:"dns_label_collision_strategy,omitempty"`
+
+	// DnsScopedGroups Group IDs whose peers only resolve the DNS names of the peers they can reach through the policies
+	DnsScopedGroups *[]string `json:"dns_scoped_groups,omitempty"`
+
+	// EventsRetentionDays Days the activity events are kept for before they are archived and deleted. 0 keeps them forever.
+	EventsRetentionDays *int                  `json:"events_retention_days,omitempty"`
+	Extra               *AccountExtraSettings `json:"extra,omitempty"`
+
+	// FeatureFlags Client behaviors switched for all the peers, e.g. lazy_firewall_rules, dns_cache or ssh_server. The flags of the groups of a peer override them. The flags not set keep the local setting of the peers, the peers ignore the flags they don't know.
+	FeatureFlags *map[string]bool `json:"feature_flags,omitempty"`
+
+	// GeofenceAction Action applied to the peers located outside the geofence. With "block" the peer is left out of the network maps like a peer pending approval, with "quarantine" it only connects to the peers of geofence_quarantine_group. Defaults to block.
+	GeofenceAction *AccountSettingsGeofenceAction `json:"geofence_action,omitempty"`
+
+	// GeofenceCountries ISO 3166-1 alpha-2 codes of the countries the peers can be located in, based on the geolocation of the IP they connect to the management service from. The peers with an unknown location comply with it. Empty disables the geofence.
+	GeofenceCountries *[]string `json:"geofence_countries,omitempty"`
+
+	// GeofenceGroups Group IDs whose peers the geofence applies to. Empty applies it to all the peers.
+	GeofenceGroups *[]string `json:"geofence_groups,omitempty"`
+
+	// GeofenceQuarantineGroup Group ID of the peers the quarantined peers can still connect to through the policies, e.g. remediation servers. Required by the quarantine action.
+	GeofenceQuarantineGroup *string `json:"geofence_quarantine_group,omitempty"`
 
 	// GroupsPropagationEnabled Allows propagate the new user auto groups to peers that belongs to the user
 	GroupsPropagationEnabled *bool `json:"groups_propagation_enabled,omitempty"`
 
+	// IceDisabledCandidateTypes ICE candidate types the peers never gather, one of host, srflx or relay. At least one type must stay enabled. Peers with locally disabled candidate types ignore it.
+	IceDisabledCandidateTypes *[]string `json:"ice_disabled_candidate_types,omitempty"`
+
+	// IceDisconnectedTimeout Seconds without traffic after which a connection is considered disconnected. 0 keeps the default of the clients.
+	IceDisconnectedTimeout *int `json:"ice_disconnected_timeout,omitempty"`
+
+	// IceExcludedSubnets Local subnets the peers gather no ICE host candidate from, e.g. Docker bridges or guest VLANs. Peers with locally excluded subnets ignore it.
+	IceExcludedSubnets *[]string `json:"ice_excluded_subnets,omitempty"`
+
+	// IceFailedTimeout Seconds the ICE candidates are checked for before a connection attempt fails. 0 keeps the default of the clients.
+	IceFailedTimeout *int `json:"ice_failed_timeout,omitempty"`
+
+	// InterfaceMtu MTU of the WireGuard interface pushed to the peers. 0 keeps the default of the clients. Peers with a locally configured MTU ignore it.
+	InterfaceMtu *int `json:"interface_mtu,omitempty"`
+
 	// JwtAllowGroups List of groups to which users are allowed access
 	JwtAllowGroups *[]string `json:"jwt_allow_groups,omitempty"`
 
@@ -202,16 +436,52 @@ type AccountSettings struct {
 	// JwtGroupsEnabled Allows extract groups from JWT claim and add it to account groups.
 	JwtGroupsEnabled *bool `json:"jwt_groups_enabled,omitempty"`
 
+	// PeerActions Remote actions the users with admin power can run on the peers, one of restart_engine, resync, rotate_wireguard_key or collect_debug_bundle. Empty allows none.
+	PeerActions *[]string `json:"peer_actions,omitempty"`
+
+	// PeerControlPlane Parts of the host the peers manage, any of dns, routes and acl, e.g. only routes to use the peers as a mesh while DNS and the firewall are managed by the user. Without acl the peers install no firewall rules, including the forwarding and masquerading of the routes they serve. Peers with a locally configured control plane ignore it. Empty manages all of them.
+	PeerControlPlane *[]AccountSettingsPeerControlPlane `json:"peer_control_plane,omitempty"`
+
+	// PeerKeyRotationInterval Seconds after which the connected peers rotate their WireGuard keys, at least one hour. The previous key of a peer stays valid for a day after a rotation, a peer logging in with it reverts the rotation. 0 disables the rotation.
+	PeerKeyRotationInterval *int `json:"peer_key_rotation_interval,omitempty"`
+
 	// PeerLoginExpiration Period of time after which peer login expires (seconds).
 	PeerLoginExpiration int `json:"peer_login_expiration"`
 
 	// PeerLoginExpirationEnabled Enables or disables peer login expiration globally. After peer's login has expired the user has to log in (authenticate). Applies only to peers that were added by a user (interactive SSO login).
 	PeerLoginExpirationEnabled bool `json:"peer_login_expiration_enabled"`
 
+	// PeerLoginExpirationWarnings Lead times before the login expiration the peers warn their users at (seconds). Each must be at least one minute, the lead times not shorter than peer_login_expiration are ignored.
+	PeerLoginExpirationWarnings *[]int `json:"peer_login_expiration_warnings,omitempty"`
+
+	// PeersPerUserLimit Maximum number of peers each user can register, the registrations over it are rejected. 0 is unlimited.
+	PeersPerUserLimit *int `json:"peers_per_user_limit,omitempty"`
+
+	// PresharedKeyMode Enables WireGuard preshared keys distributed to the peers. With "account" all peers share one key, with "peer_pair" every pair of peers gets its own key. Empty disables the distribution.
+	PresharedKeyMode *AccountSettingsPresharedKeyMode `json:"preshared_key_mode,omitempty"`
+
 	// RegularUsersViewBlocked Allows blocking regular users from viewing parts of the system.
 	RegularUsersViewBlocked bool `json:"regular_users_view_blocked"`
+
+	// RosenpassEnabled Makes the peers run the post-quantum Rosenpass handshake alongside WireGuard. Peers with Rosenpass enabled locally keep it enabled.
+	RosenpassEnabled *bool `json:"rosenpass_enabled,omitempty"`
+
+	// RosenpassPermissive Keeps the connections to the peers without Rosenpass working without the post-quantum key. Requires rosenpass_enabled.
+	RosenpassPermissive *bool `json:"rosenpass_permissive,omitempty"`
 }
 
+// AccountSettingsDnsLabelCollisionStrategy How a peer added or renamed with the DNS label of another peer is handled. With "suffix" the peer gets the label with a numeric suffix, with "reject" the peer is rejected and with "replace-oldest" the peer gets the label and the peer that used it gets a numeric suffix. Defaults to suffix.
+type AccountSettingsDnsLabelCollisionStrategy string
+
+// AccountSettingsGeofenceAction Action applied to the peers located outside the geofence. With "block" the peer is left out of the network maps like a peer pending approval, with "quarantine" it only connects to the peers of geofence_quarantine_group. Defaults to block.
+type AccountSettingsGeofenceAction string
+
+// AccountSettingsPeerControlPlane defines model for AccountSettings.PeerControlPlane.
+type AccountSettingsPeerControlPlane string
+
+// AccountSettingsPresharedKeyMode Enables WireGuard preshared keys distributed to the peers. With "account" all peers share one key, with "peer_pair" every pair of peers gets its own key. Empty disables the distribution.
+type AccountSettingsPresharedKeyMode string
+
 // Checks List of objects that perform the actual checks
 type Checks struct {
 	// GeoLocationCheck Posture check for geo location
@@ -223,10 +493,52 @@ type Checks struct {
 	// OsVersionCheck Posture check for the version of operating system
 	OsVersionCheck *OSVersionCheck `json:"os_version_check,omitempty"`
 
+	// PackageVersionCheck Posture check failing the peers with a package installed below its minimum version, based on the software inventory of the peers
+	PackageVersionCheck *PackageVersionCheck `json:"package_version_check,omitempty"`
+
 	// PeerNetworkRangeCheck Posture check for allow or deny access based on peer local network addresses
 	PeerNetworkRangeCheck *PeerNetworkRangeCheck `json:"peer_network_range_check,omitempty"`
 }
 
+// ClaimGroupMapping defines model for ClaimGroupMapping.
+type ClaimGroupMapping struct {
+	// Claim Name of the JWT claim of the users
+	Claim string `json:"claim"`
+
+	// Enabled Claim group mapping status
+	Enabled bool `json:"enabled"`
+
+	// Groups Group IDs the peers of the matching users are added to when the users log them in
+	Groups []string `json:"groups"`
+
+	// Id Claim group mapping ID
+	Id string `json:"id"`
+
+	// Name Claim group mapping name
+	Name string `json:"name"`
+
+	// Values Claim values the mapping matches, a claim holding a list matches when one of its items does
+	Values []string `json:"values"`
+}
+
+// ClaimGroupMappingRequest defines model for ClaimGroupMappingRequest.
+type ClaimGroupMappingRequest struct {
+	// Claim Name of the JWT claim of the users
+	Claim string `json:"claim"`
+
+	// Enabled Claim group mapping status
+	Enabled bool `json:"enabled"`
+
+	// Groups Group IDs the peers of the matching users are added to when the users log them in
+	Groups []string `json:"groups"`
+
+	// Name Claim group mapping name
+	Name string `json:"name"`
+
+	// Values Claim values the mapping matches, a claim holding a list matches when one of its items does
+	Values []string `json:"values"`
+}
+
 // City Describe city geographical location information
 type City struct {
 	// CityName Commonly used English name of the city
@@ -251,12 +563,141 @@ type Country struct {
 // CountryCode 2-letter ISO 3166-1 alpha-2 code that represents the country
 type CountryCode = string
 
+// DNSBlocklist defines model for DNSBlocklist.
+type DNSBlocklist struct {
+	// Enabled Deny list status
+	Enabled bool `json:"enabled"`
+
+	// Name Name of the deny list
+	Name string `json:"name"`
+
+	// Url HTTP or HTTPS URL of the hosts-format deny list
+	Url string `json:"url"`
+}
+
+// DNSRecord defines model for DNSRecord.
+type DNSRecord struct {
+	// Enabled Record status
+	Enabled bool `json:"enabled"`
+
+	// Fqdn Fully qualified name of the record
+	Fqdn string `json:"fqdn"`
+
+	// Id DNS record ID
+	Id string `json:"id"`
+
+	// Name Record name relative to its zone, "@" for the zone apex
+	Name string `json:"name"`
+
+	// Ttl Record time-to-live in seconds. Zero uses the default of 300 seconds.
+	Ttl int `json:"ttl"`
+
+	// Type Record type
+	Type DNSRecordType `json:"type"`
+
+	// Value An IPv4 address for A records, an IPv6 address for AAAA records, a domain name for CNAME records and free text for TXT records
+	Value string `json:"value"`
+
+	// Zone Zone of the record. Empty to use the account DNS domain.
+	Zone string `json:"zone"`
+}
+
+// DNSRecordType Record type
+type DNSRecordType string
+
+// DNSRecordRequest defines model for DNSRecordRequest.
+type DNSRecordRequest struct {
+	// Enabled Record status
+	Enabled bool `json:"enabled"`
+
+	// Name Record name relative to its zone, "@" for the zone apex
+	Name string `json:"name"`
+
+	// Ttl Record time-to-live in seconds. Zero uses the default of 300 seconds.
+	Ttl *int `json:"ttl,omitempty"`
+
+	// Type Record type
+	Type DNSRecordRequestType `json:"type"`
+
+	// Value An IPv4 address for A records, an IPv6 address for AAAA records, a domain name for CNAME records and free text for TXT records
+	Value string `json:"value"`
+
+	// Zone Zone of the record. Empty to use the account DNS domain.
+	Zone *string `json:"zone,omitempty"`
+}
+
+// DNSRecordRequestType Record type
+type DNSRecordRequestType string
+
 // DNSSettings defines model for DNSSettings.
 type DNSSettings struct {
+	// BlocklistOverrideGroups Groups whose peers aren't filtered by the deny lists
+	BlocklistOverrideGroups *[]string `json:"blocklist_override_groups,omitempty"`
+
+	// BlocklistRefreshInterval How often the peers download the deny lists in seconds, 24 hours when 0. Minimum is 900
+	BlocklistRefreshInterval *int `json:"blocklist_refresh_interval,omitempty"`
+
+	// BlocklistResponse How the blocked domains are answered, with NXDOMAIN or with the 0.0.0.0 and :: addresses
+	BlocklistResponse *DNSSettingsBlocklistResponse `json:"blocklist_response,omitempty"`
+
+	// Blocklists Hosts-format deny lists enforced by the resolver of the peers
+	Blocklists *[]DNSBlocklist `json:"blocklists,omitempty"`
+
 	// DisabledManagementGroups Groups whose DNS management is disabled
 	DisabledManagementGroups []string `json:"disabled_management_groups"`
 }
 
+// DNSSettingsBlocklistResponse How the blocked domains are answered, with NXDOMAIN or with the 0.0.0.0 and :: addresses
+type DNSSettingsBlocklistResponse string
+
+// DNSZone defines model for DNSZone.
+type DNSZone struct {
+	// Domain Fully qualified domain of the zone
+	Domain string `json:"domain"`
+
+	// Records Records of the zone sorted by name, type and value
+	Records []DNSZoneRecord `json:"records"`
+
+	// Serial Serial of the zone, changing when the network of the account changes
+	Serial int `json:"serial"`
+}
+
+// DNSZoneRecord defines model for DNSZoneRecord.
+type DNSZoneRecord struct {
+	// Name Fully qualified name of the record
+	Name string `json:"name"`
+
+	// Ttl Record time-to-live in seconds
+	Ttl int `json:"ttl"`
+
+	// Type Record type
+	Type string `json:"type"`
+
+	// Value Record data in the zone file presentation format
+	Value string `json:"value"`
+}
+
+// ErrorResponse defines model for ErrorResponse.
+type ErrorResponse struct {
+	// Code HTTP status of the response
+	Code int `json:"code"`
+
+	// DocumentationUrl Link to the documentation of the error code, omitted when the management service isn't configured with one
+	DocumentationUrl *string `json:"documentation_url,omitempty"`
+
+	// ErrorCode Machine-readable code of the error the clients can branch on. The errors of a request whose JSON body couldn't be decoded have the code invalid_json, the others have the code of their HTTP status.
+	ErrorCode ErrorResponseErrorCode `json:"error_code"`
+
+	// Field Field of the request the error is about, omitted when the error isn't about a single field
+	Field *string `json:"field,omitempty"`
+
+	// Message Description of the error for humans, it may change between versions
+	Message string `json:"message"`
+}
+
+// ErrorResponseErrorCode Machine-readable code of the error the clients can branch on. The errors of a request whose JSON body couldn't be decoded have the code invalid_json, the others have the code of their HTTP status.
+type ErrorResponseErrorCode string
+
 // Event defines model for Event.
 type Event struct {
 	// Activity The activity that occurred during the event
@@ -290,6 +731,87 @@ type Event struct {
 // EventActivityCode The string code of the activity that occurred during the event
 type EventActivityCode string
 
+// ExternalList defines model for ExternalList.
+type ExternalList struct {
+	// Authorization Value of the Authorization header sent with the download, e.g. the key of a MISP user. It is never returned. Omitted on update it keeps the current value, empty it removes it
+	Authorization *string `json:"authorization,omitempty"`
+
+	// AuthorizationSet Indicates whether an Authorization header is sent with the download
+	AuthorizationSet bool `json:"authorization_set"`
+
+	// Description External list description
+	Description string `json:"description"`
+
+	// Enabled External list status, a disabled list isn't downloaded and its networks aren't sent to the peers
+	Enabled bool `json:"enabled"`
+
+	// Entries Number of networks of the last successful download
+	Entries int `json:"entries"`
+
+	// Format Format of the list, "cidr" is one IPv4 address or CIDR per line and "misp" is the JSON response of the MISP attributes restSearch API
+	Format ExternalListFormat `json:"format"`
+
+	// Id External list ID
+	Id string `json:"id"`
+
+	// LastAttemptAt Time of the last download, successful or not
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"`
+
+	// LastError Error of the last download, empty when it succeeded
+	LastError string `json:"last_error"`
+
+	// LastRefreshedAt Time of the last successful download, absent when the list was never downloaded
+	LastRefreshedAt *time.Time `json:"last_refreshed_at,omitempty"`
+
+	// MaxEntries Maximum number of networks of the list, a download exceeding it fails and the previous networks are kept. Defaults to 10000
+	MaxEntries *int `json:"max_entries,omitempty"`
+
+	// Name External list name
+	Name string `json:"name"`
+
+	// RefreshInterval Seconds between the downloads of the list, at least 300. Defaults to 3600
+	RefreshInterval *int `json:"refresh_interval,omitempty"`
+
+	// Stale Indicates whether the enabled list didn't download for 3 refresh intervals or never downloaded
+	Stale bool `json:"stale"`
+
+	// Url HTTP or HTTPS URL the list is downloaded from
+	Url string `json:"url"`
+}
+
+// ExternalListFormat Format of the list, "cidr" is one IPv4 address or CIDR per line and "misp" is the JSON response of the MISP attributes restSearch API
+type ExternalListFormat string
+
+// ExternalListRequest defines model for ExternalListRequest.
+type ExternalListRequest struct {
+	// Authorization Value of the Authorization header sent with the download, e.g. the key of a MISP user. It is never returned. Omitted on update it keeps the current value, empty it removes it
+	Authorization *string `json:"authorization,omitempty"`
+
+	// Description External list description
+	Description string `json:"description"`
+
+	// Enabled External list status, a disabled list isn't downloaded and its networks aren't sent to the peers
+	Enabled bool `json:"enabled"`
+
+	// Format Format of the list, "cidr" is one IPv4 address or CIDR per line and "misp" is the JSON response of the MISP attributes restSearch API
+	Format ExternalListRequestFormat `json:"format"`
+
+	// MaxEntries Maximum number of networks of the list, a download exceeding it fails and the previous networks are kept. Defaults to 10000
+	MaxEntries *int `json:"max_entries,omitempty"`
+
+	// Name External list name
+	Name string `json:"name"`
+
+	// RefreshInterval Seconds between the downloads of the list, at least 300. Defaults to 3600
+	RefreshInterval *int `json:"refresh_interval,omitempty"`
+
+	// Url HTTP or HTTPS URL the list is downloaded from
+	Url string `json:"url"`
+}
+
+// ExternalListRequestFormat Format of the list, "cidr" is one IPv4 address or CIDR per line and "misp" is the JSON response of the MISP attributes restSearch API
+type ExternalListRequestFormat string
+
 // GeoLocationCheck Posture check for geo location
 type GeoLocationCheck struct {
 	// Action Action to take upon policy match
@@ -304,12 +826,24 @@ type GeoLocationCheckAction string
 
 // Group defines model for Group.
 type Group struct {
+	// ClientUpdateChannel Update channel the auto updating peers of the group follow
+	ClientUpdateChannel *string `json:"client_update_channel,omitempty"`
+
+	// ClientVersion Client version the auto updating peers of the group run
+	ClientVersion *string `json:"client_version,omitempty"`
+
+	// FeatureFlags Client behaviors switched for the peers of the group, overriding the feature flags of the account
+	FeatureFlags *map[string]bool `json:"feature_flags,omitempty"`
+
 	// Id Group ID
 	Id string `json:"id"`
 
 	// Issued How the group was issued (api, integration, jwt)
 	Issued *GroupIssued `json:"issued,omitempty"`
 
+	// KeepWarm Indicates whether the peers of the group keep their connections to each other established and ready
+	KeepWarm bool `json:"keep_warm"`
+
 	// Name Group Name identifier
 	Name string `json:"name"`
 
@@ -341,8 +875,35 @@ type GroupMinimum struct {
 // GroupMinimumIssued How the group was issued (api, integration, jwt)
 type GroupMinimumIssued string
 
+// GroupPeers defines model for GroupPeers.
+type GroupPeers struct {
+	// Page Page of the peers, starting at 1
+	Page int `json:"page"`
+
+	// PageSize Maximum number of peers per page
+	PageSize int `json:"page_size"`
+
+	// Peers Peers of the page
+	Peers []PeerMinimum `json:"peers"`
+
+	// Total Count of peers associated to the group
+	Total int `json:"total"`
+}
+
 // GroupRequest defines model for GroupRequest.
 type GroupRequest struct {
+	// ClientUpdateChannel Update channel the auto updating peers of the group follow, e.g. stable, its version is set in the management config. A peer in groups following different channels follows stable, otherwise the first channel by name. Empty stops following a channel. Unchanged when omitted on update.
+	ClientUpdateChannel *string `json:"client_update_channel,omitempty"`
+
+	// ClientVersion Client version the auto updating peers of the group run, it takes precedence over the channels of the other groups of a peer. A peer in groups pinning different versions runs the lowest one. Empty removes the pin. Unchanged when omitted on update.
+	ClientVersion *string `json:"client_version,omitempty"`
+
+	// FeatureFlags Client behaviors switched for the peers of the group, overriding the feature flags of the account. A flag set differently by the groups of a peer is disabled. Unchanged when omitted on update.
+	FeatureFlags *map[string]bool `json:"feature_flags,omitempty"`
+
+	// KeepWarm Makes the peers of the group keep their connections to each other established and ready, trading idle keepalive traffic for the latency of the first packets. Unchanged when omitted on update.
+	KeepWarm *bool `json:"keep_warm,omitempty"`
+
 	// Name Group name identifier
 	Name string `json:"name"`
 
@@ -350,6 +911,57 @@ type GroupRequest struct {
 	Peers *[]string `json:"peers,omitempty"`
 }
 
+// IPAllocation defines model for IPAllocation.
+type IPAllocation struct {
+	// Ip NetBird IP of the peer
+	Ip string `json:"ip"`
+
+	// PeerId Peer ID
+	PeerId string `json:"peer_id"`
+
+	// PeerName Peer's name
+	PeerName string `json:"peer_name"`
+
+	// Static Whether the IP was assigned by an administrator or requested by the peer rather than allocated automatically
+	Static bool `json:"static"`
+}
+
+// IPAllocationRequest defines model for IPAllocationRequest.
+type IPAllocationRequest struct {
+	// Ip Static NetBird IP of the peer, it has to be a free host address of the account network. Connected peers apply the new address without a restart
+	Ip string `json:"ip"`
+}
+
+// IPPool defines model for IPPool.
+type IPPool struct {
+	// Allocations NetBird IPs of the peers sorted by IP
+	Allocations []IPAllocation `json:"allocations"`
+
+	// Capacity Number of addresses the automatic allocation picks from
+	Capacity int `json:"capacity"`
+
+	// Free Number of addresses left to the automatic allocation
+	Free int `json:"free"`
+
+	// Network Network the peers get their NetBird IPs from
+	Network string `json:"network"`
+
+	// Used Number of addresses of the automatic allocation in use
+	Used int `json:"used"`
+
+	// Utilization Share of the addresses of the automatic allocation in use, in percent
+	Utilization float64 `json:"utilization"`
+}
+
+// InstalledPackage defines model for InstalledPackage.
+type InstalledPackage struct {
+	// Name Package name
+	Name string `json:"name"`
+
+	// Version Installed version
+	Version string `json:"version"`
+}
+
 // Location Describe geographical location information
 type Location struct {
 	// CityName Commonly used English name of the city
@@ -365,6 +977,15 @@ type MinKernelVersionCheck struct {
 	MinKernelVersion string `json:"min_kernel_version"`
 }
 
+// MinPackageVersion defines model for MinPackageVersion.
+type MinPackageVersion struct {
+	// MinVersion Minimum acceptable version
+	MinVersion string `json:"min_version"`
+
+	// Name Package name
+	Name string `json:"name"`
+}
+
 // MinVersionCheck Posture check for the version of operating system
 type MinVersionCheck struct {
 	// MinVersion Minimum acceptable version
@@ -379,14 +1000,23 @@ type Nameserver struct {
 	// Ip Nameserver IP
 	Ip string `json:"ip"`
 
-	// NsType Nameserver Type
+	// NsType Nameserver Type, "tls" for DNS over TLS and "https" for DNS over HTTPS
 	NsType NameserverNsType `json:"ns_type"`
 
+	// Path DNS over HTTPS endpoint path
+	Path *string `json:"path,omitempty"`
+
 	// Port Nameserver Port
 	Port int `json:"port"`
+
+	// ServerName Name the certificate of a DNS over TLS or DNS over HTTPS nameserver is verified against. The IP is used when empty.
+	ServerName *string `json:"server_name,omitempty"`
+
+	// SpkiPins Base64 encoded SHA-256 hashes of the accepted certificate public keys of a DNS over TLS or DNS over HTTPS nameserver. A server certificate with a pinned key is accepted without a trusted chain.
+	SpkiPins *[]string `json:"spki_pins,omitempty"`
 }
 
-// NameserverNsType Nameserver Type
+// NameserverNsType Nameserver Type, "tls" for DNS over TLS and "https" for DNS over HTTPS
 type NameserverNsType string
 
 // NameserverGroup defines model for NameserverGroup.
@@ -394,7 +1024,7 @@ type NameserverGroup struct {
 	// Description Description of the nameserver group
 	Description string `json:"description"`
 
-	// Domains Match domain list. It should be empty only if primary is true.
+	// Domains Match domain list. It should be empty only if primary is true. A "*." prefix matches the subdomains only and a "!" prefix excludes a subdomain of the other match domains, or any domain for primary groups, from the nameserver group.
 	Domains []string `json:"domains"`
 
 	// Enabled Nameserver group status
@@ -415,6 +1045,9 @@ type NameserverGroup struct {
 	// Primary Defines if a nameserver group is primary that resolves all domains. It should be true only if domains list is empty.
 	Primary bool `json:"primary"`
 
+	// Priority Resolution order of the nameserver group. For match domains shared with other groups, and for the primary role, the group with the lowest value is used, groups with the same value are ordered by ID. The search domains of the groups are ordered the same way.
+	Priority *int `json:"priority,omitempty"`
+
 	// SearchDomainsEnabled Search domain status for match domains. It should be true only if domains list is not empty.
 	SearchDomainsEnabled bool `json:"search_domains_enabled"`
 }
@@ -424,7 +1057,7 @@ type NameserverGroupRequest struct {
 	// Description Description of the nameserver group
 	Description string `json:"description"`
 
-	// Domains Match domain list. It should be empty only if primary is true.
+	// Domains Match domain list. It should be empty only if primary is true. A "*." prefix matches the subdomains only and a "!" prefix excludes a subdomain of the other match domains, or any domain for primary groups, from the nameserver group.
 	Domains []string `json:"domains"`
 
 	// Enabled Nameserver group status
@@ -442,6 +1075,9 @@ type NameserverGroupRequest struct {
 	// Primary Defines if a nameserver group is primary that resolves all domains. It should be true only if domains list is empty.
 	Primary bool `json:"primary"`
 
+	// Priority Resolution order of the nameserver group. For match domains shared with other groups, and for the primary role, the group with the lowest value is used, groups with the same value are ordered by ID. The search domains of the groups are ordered the same way.
+	Priority *int `json:"priority,omitempty"`
+
 	// SearchDomainsEnabled Search domain status for match domains. It should be true only if domains list is not empty.
 	SearchDomainsEnabled bool `json:"search_domains_enabled"`
 }
@@ -464,6 +1100,36 @@ type OSVersionCheck struct {
 	Windows *MinKernelVersionCheck `json:"windows,omitempty"`
 }
 
+// PackageVersionCheck Posture check failing the peers with a package installed below its minimum version, based on the software inventory of the peers
+type PackageVersionCheck struct {
+	// Packages Minimum versions of the packages, compared like dpkg, opkg and apk do
+	Packages []MinPackageVersion `json:"packages"`
+
+	// RequireInventory Fail the peers that don't report a software inventory, otherwise they pass the check
+	RequireInventory *bool `json:"require_inventory,omitempty"`
+}
+
+// PackageVersionReport defines model for PackageVersionReport.
+type PackageVersionReport struct {
+	// MinVersion Highest minimum version of the package among the package version posture checks, absent when none lists it
+	MinVersion *string `json:"min_version,omitempty"`
+
+	// Name Package name
+	Name string `json:"name"`
+
+	// Peers IDs of the peers with the version installed
+	Peers []string `json:"peers"`
+
+	// PeersCount Number of peers with the version installed
+	PeersCount int `json:"peers_count"`
+
+	// Version Installed version
+	Version string `json:"version"`
+
+	// Vulnerable Whether the version is below the minimum version of the posture checks
+	Vulnerable bool `json:"vulnerable"`
+}
+
 // Peer defines model for Peer.
 type Peer struct {
 	// AccessiblePeers List of accessible peers
@@ -517,12 +1183,24 @@ type Peer struct {
 	// LoginExpired Indicates whether peer's login expired or not
 	LoginExpired bool `json:"login_expired"`
 
+	// LoginExpiresAt Time the login of the peer expires at, unset when it never expires
+	LoginExpiresAt *time.Time `json:"login_expires_at,omitempty"`
+
 	// Name Peer's hostname
 	Name string `json:"name"`
 
+	// NatType Type of the NAT in front of the peer detected with the STUN servers: none, endpoint_independent, symmetric, udp_blocked or unknown. Peers behind symmetric NATs can't connect directly to each other. Empty until the peer reported it
+	NatType string `json:"nat_type"`
+
 	// Os Peer's operating system and version
 	Os string `json:"os"`
 
+	// RosenpassEnabled Indicates whether the peer runs the post-quantum Rosenpass handshake, reported with its status
+	RosenpassEnabled bool `json:"rosenpass_enabled"`
+
+	// RouteConflicts Routes that the peer reported as not installed because they conflict with its local routing
+	RouteConflicts []RouteConflict `json:"route_conflicts"`
+
 	// SerialNumber System serial number
 	SerialNumber string `json:"serial_number"`
 
@@ -532,6 +1210,9 @@ type Peer struct {
 	// UiVersion Peer's desktop UI version
 	UiVersion string `json:"ui_version"`
 
+	// Uplink Network interface name or local source IP the peer pins its NetBird traffic to, empty if the peer picks the uplink
+	Uplink string `json:"uplink"`
+
 	// UserId User ID of the user that enrolled this peer
 	UserId string `json:"user_id"`
 
@@ -539,6 +1220,21 @@ type Peer struct {
 	Version string `json:"version"`
 }
 
+// PeerActionRequest defines model for PeerActionRequest.
+type PeerActionRequest struct {
+	// Action Remote action to run on the peer, it has to be allowed by the peer_actions of the account settings
+	Action PeerActionRequestAction `json:"action"`
+}
+
+// PeerActionRequestAction Remote action to run on the peer, it has to be allowed by the peer_actions of the account settings
+type PeerActionRequestAction string
+
+// PeerActionResponse defines model for PeerActionResponse.
+type PeerActionResponse struct {
+	// Id Identifier of the action, the activity events of the action carry it as action_id
+	Id string `json:"id"`
+}
+
 // PeerBase defines model for PeerBase.
 type PeerBase struct {
 	// ApprovalRequired (Cloud only) Indicates whether peer needs approval
@@ -589,12 +1285,24 @@ type PeerBase struct {
 	// LoginExpired Indicates whether peer's login expired or not
 	LoginExpired bool `json:"login_expired"`
 
+	// LoginExpiresAt Time the login of the peer expires at, unset when it never expires
+	LoginExpiresAt *time.Time `json:"login_expires_at,omitempty"`
+
 	// Name Peer's hostname
 	Name string `json:"name"`
 
+	// NatType Type of the NAT in front of the peer detected with the STUN servers: none, endpoint_independent, symmetric, udp_blocked or unknown. Peers behind symmetric NATs can't connect directly to each other. Empty until the peer reported it
+	NatType string `json:"nat_type"`
+
 	// Os Peer's operating system and version
 	Os string `json:"os"`
 
+	// RosenpassEnabled Indicates whether the peer runs the post-quantum Rosenpass handshake, reported with its status
+	RosenpassEnabled bool `json:"rosenpass_enabled"`
+
+	// RouteConflicts Routes that the peer reported as not installed because they conflict with its local routing
+	RouteConflicts []RouteConflict `json:"route_conflicts"`
+
 	// SerialNumber System serial number
 	SerialNumber string `json:"serial_number"`
 
@@ -604,6 +1312,9 @@ type PeerBase struct {
 	// UiVersion Peer's desktop UI version
 	UiVersion string `json:"ui_version"`
 
+	// Uplink Network interface name or local source IP the peer pins its NetBird traffic to, empty if the peer picks the uplink
+	Uplink string `json:"uplink"`
+
 	// UserId User ID of the user that enrolled this peer
 	UserId string `json:"user_id"`
 
@@ -664,26 +1375,230 @@ type PeerBatch struct {
 	// LoginExpired Indicates whether peer's login expired or not
 	LoginExpired bool `json:"login_expired"`
 
+	// LoginExpiresAt Time the login of the peer expires at, unset when it never expires
+	LoginExpiresAt *time.Time `json:"login_expires_at,omitempty"`
+
 	// Name Peer's hostname
 	Name string `json:"name"`
 
+	// NatType Type of the NAT in front of the peer detected with the STUN servers: none, endpoint_independent, symmetric, udp_blocked or unknown. Peers behind symmetric NATs can't connect directly to each other. Empty until the peer reported it
+	NatType string `json:"nat_type"`
+
 	// Os Peer's operating system and version
 	Os string `json:"os"`
 
+	// RosenpassEnabled Indicates whether the peer runs the post-quantum Rosenpass handshake, reported with its status
+	RosenpassEnabled bool `json:"rosenpass_enabled"`
+
+	// RouteConflicts Routes that the peer reported as not installed because they conflict with its local routing
+	RouteConflicts []RouteConflict `json:"route_conflicts"`
+
 	// SerialNumber System serial number
 	SerialNumber string `json:"serial_number"`
 
 	// SshEnabled Indicates whether SSH server is enabled on this peer
 	SshEnabled bool `json:"ssh_enabled"`
 
-	// UiVersion Peer's desktop UI version
-	UiVersion string `json:"ui_version"`
+	// UiVersion Peer's desktop UI version
+	UiVersion string `json:"ui_version"`
+
+	// Uplink Network interface name or local source IP the peer pins its NetBird traffic to, empty if the peer picks the uplink
+	Uplink string `json:"uplink"`
+
+	// UserId User ID of the user that enrolled this peer
+	UserId string `json:"user_id"`
+
+	// Version Peer's daemon or cli version
+	Version string `json:"version"`
+}
+
+// PeerDNSLabel defines model for PeerDNSLabel.
+type PeerDNSLabel struct {
+	// Fqdn Peer's fully qualified domain name
+	Fqdn string `json:"fqdn"`
+
+	// Label Peer's DNS label, the peer resolves as the label under the DNS domain
+	Label string `json:"label"`
+
+	// PeerId Peer ID
+	PeerId string `json:"peer_id"`
+
+	// PeerName Peer's name
+	PeerName string `json:"peer_name"`
+}
+
+// PeerDNSLabelRequest defines model for PeerDNSLabelRequest.
+type PeerDNSLabelRequest struct {
+	// Label Peer's new DNS label, it must be a valid DNS label not used by another peer
+	Label string `json:"label"`
+}
+
+// PeerDNSStats defines model for PeerDNSStats.
+type PeerDNSStats struct {
+	// AverageLatency Average latency of the queries in milliseconds
+	AverageLatency int64 `json:"average_latency"`
+
+	// BlockedQueries Queries refused by the negated domains of the nameserver groups
+	BlockedQueries int64 `json:"blocked_queries"`
+
+	// FailedQueries Local and forwarded queries answered with SERVFAIL or left unanswered
+	FailedQueries int64 `json:"failed_queries"`
+
+	// ForwardedQueries Queries forwarded to the upstream nameservers or answered from their cached responses
+	ForwardedQueries int64 `json:"forwarded_queries"`
+
+	// LocalQueries Queries answered from the peers and custom zones
+	LocalQueries int64 `json:"local_queries"`
+
+	// ReportedAt Time the peer reported the counters
+	ReportedAt time.Time `json:"reported_at"`
+}
+
+// PeerDiagnostics defines model for PeerDiagnostics.
+type PeerDiagnostics struct {
+	// DroppedConnections Latest inbound connections dropped by the firewall of the peer, the oldest first
+	DroppedConnections []PeerDroppedConnection `json:"dropped_connections"`
+
+	// DroppedConnectionsReportedAt Time the peer reported the dropped connections, absent when it never did
+	DroppedConnectionsReportedAt *time.Time `json:"dropped_connections_reported_at,omitempty"`
+}
+
+// PeerDroppedConnection defines model for PeerDroppedConnection.
+type PeerDroppedConnection struct {
+	// DestinationPort Destination port of the connection on the peer, 0 for ICMP
+	DestinationPort int `json:"destination_port"`
+
+	// FirstSeen Time the first packet of the connection was dropped
+	FirstSeen time.Time `json:"first_seen"`
+
+	// LastSeen Time the last packet of the connection was dropped
+	LastSeen time.Time `json:"last_seen"`
+
+	// Packets Number of packets of the connection dropped
+	Packets int64 `json:"packets"`
+
+	// PolicyIds IDs of the policies of the drop rule the connection matched, empty when no policy allows the connection
+	PolicyIds []string `json:"policy_ids"`
+
+	// Protocol Protocol of the connection
+	Protocol string `json:"protocol"`
+
+	// SourceIp NetBird IP of the peer that initiated the connection
+	SourceIp string `json:"source_ip"`
+
+	// SourcePort Source port of the connection, 0 for ICMP
+	SourcePort int `json:"source_port"`
+}
+
+// PeerGeofence Geofence state of a peer
+type PeerGeofence struct {
+	// Action Action applied to the peer while it is outside the geofence, empty if it complies with it
+	Action PeerGeofenceAction `json:"action"`
+
+	// CountryCode 2-letter ISO 3166-1 alpha-2 code of the country the peer was last located in, empty if it is unknown
+	CountryCode string `json:"country_code"`
+
+	// Geofenced Whether the geofence of the account applies to the peer
+	Geofenced bool `json:"geofenced"`
+
+	// Override Exception to the geofence letting a peer located in a country outside of it connect as usual once approved
+	Override *PeerGeofenceOverride `json:"override,omitempty"`
+
+	// PeerId Identifier of the peer
+	PeerId string `json:"peer_id"`
+
+	// ViolatedAt Time the peer was first located outside the geofence
+	ViolatedAt *time.Time `json:"violated_at,omitempty"`
+
+	// Violation Country the peer is located in outside the geofence, empty if it complies with it
+	Violation string `json:"violation"`
+}
+
+// PeerGeofenceAction Action applied to the peer while it is outside the geofence, empty if it complies with it
+type PeerGeofenceAction string
+
+// PeerGeofenceOverride Exception to the geofence letting a peer located in a country outside of it connect as usual once approved
+type PeerGeofenceOverride struct {
+	// Approved Whether the override was approved, it applies only once approved
+	Approved bool `json:"approved"`
+
+	// ApprovedAt Time the override was approved
+	ApprovedAt *time.Time `json:"approved_at,omitempty"`
+
+	// ApprovedBy Identifier of the admin who approved the override
+	ApprovedBy *string `json:"approved_by,omitempty"`
+
+	// Country Country the override applies to
+	Country string `json:"country"`
+
+	// ExpiresAt Time the approved override ends, absent if it doesn't expire
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// Reason Why the override was requested
+	Reason string `json:"reason"`
+
+	// RequestedAt Time the override was requested
+	RequestedAt time.Time `json:"requested_at"`
+
+	// RequestedBy Identifier of the user who requested the override
+	RequestedBy string `json:"requested_by"`
+}
+
+// PeerGeofenceOverrideApproval defines model for PeerGeofenceOverrideApproval.
+type PeerGeofenceOverrideApproval struct {
+	// Duration Seconds the override applies for once approved. 0 or absent applies it until it is revoked.
+	Duration *int `json:"duration,omitempty"`
+}
+
+// PeerGeofenceOverrideRequest defines model for PeerGeofenceOverrideRequest.
+type PeerGeofenceOverrideRequest struct {
+	// Reason Why the peer needs to connect from outside the geofence
+	Reason string `json:"reason"`
+}
+
+// PeerInventory Software inventory a peer reported at login
+type PeerInventory struct {
+	// LastLogin Last time the peer logged in, when it reported the inventory
+	LastLogin time.Time `json:"last_login"`
+
+	// Os Peer's operating system
+	Os string `json:"os"`
+
+	// OsVersion Peer's operating system version
+	OsVersion string `json:"os_version"`
+
+	// PackageManager Package manager of the peer, empty when the peer doesn't report its packages
+	PackageManager string `json:"package_manager"`
+
+	// Packages Installed packages the peer reports the versions of
+	Packages []InstalledPackage `json:"packages"`
+
+	// PackagesCount Number of packages installed with the package manager
+	PackagesCount int `json:"packages_count"`
+
+	// PatchLevel Release and build revision of the operating system
+	PatchLevel string `json:"patch_level"`
+
+	// PeerId Peer ID
+	PeerId string `json:"peer_id"`
+
+	// PeerName Peer name
+	PeerName string `json:"peer_name"`
+}
+
+// PeerLoad defines model for PeerLoad.
+type PeerLoad struct {
+	// CpuPercent Share of the CPU time the routing peer spent busy since its previous report
+	CpuPercent float64 `json:"cpu_percent"`
+
+	// MemoryPercent Share of the memory of the routing peer in use
+	MemoryPercent float64 `json:"memory_percent"`
 
-	// UserId User ID of the user that enrolled this peer
-	UserId string `json:"user_id"`
+	// ReportedAt Time the routing peer reported the load
+	ReportedAt time.Time `json:"reported_at"`
 
-	// Version Peer's daemon or cli version
-	Version string `json:"version"`
+	// ThroughputMbps WireGuard traffic of the routing peer in megabits per second since its previous report, including the traffic it forwards for the clients of its routes
+	ThroughputMbps float64 `json:"throughput_mbps"`
 }
 
 // PeerMinimum defines model for PeerMinimum.
@@ -707,13 +1622,118 @@ type PeerNetworkRangeCheck struct {
 // PeerNetworkRangeCheckAction Action to take upon policy match
 type PeerNetworkRangeCheckAction string
 
+// PeerPing defines model for PeerPing.
+type PeerPing struct {
+	// Error Reason the ping failed
+	Error *string `json:"error,omitempty"`
+
+	// Id Identifier of the ping
+	Id string `json:"id"`
+
+	// RequestedAt Time the ping was requested
+	RequestedAt time.Time `json:"requested_at"`
+
+	// Result Outcome of a probe a peer ran over the WireGuard tunnel
+	Result *PeerPingResult `json:"result,omitempty"`
+
+	// Status Whether the peer reported the ping, pending until it does
+	Status PeerPingStatus `json:"status"`
+
+	// TargetPeerId Identifier of the pinged peer
+	TargetPeerId string `json:"target_peer_id"`
+}
+
+// PeerPingStatus Whether the peer reported the ping, pending until it does
+type PeerPingStatus string
+
+// PeerPingRequest defines model for PeerPingRequest.
+type PeerPingRequest struct {
+	// TargetPeerId Identifier of the peer to ping
+	TargetPeerId string `json:"target_peer_id"`
+}
+
+// PeerPingResult Outcome of a probe a peer ran over the WireGuard tunnel
+type PeerPingResult struct {
+	// Fqdn FQDN of the pinged peer
+	Fqdn string `json:"fqdn"`
+
+	// Ip Netbird IP the pinged peer was probed on
+	Ip string `json:"ip"`
+
+	// LocalCandidate ICE candidate type of the pinging peer, host, srflx, prflx or relay
+	LocalCandidate string `json:"local_candidate"`
+
+	// Path How the pinging peer reaches the pinged one, p2p, relayed or disconnected
+	Path string `json:"path"`
+
+	// Received Number of echo replies received
+	Received int `json:"received"`
+
+	// RemoteCandidate ICE candidate type of the pinged peer, host, srflx, prflx or relay
+	RemoteCandidate string `json:"remote_candidate"`
+
+	// RttAvgMs Average round trip time in milliseconds, 0 when no reply was received
+	RttAvgMs float64 `json:"rtt_avg_ms"`
+
+	// RttMaxMs Longest round trip time in milliseconds
+	RttMaxMs float64 `json:"rtt_max_ms"`
+
+	// RttMinMs Shortest round trip time in milliseconds
+	RttMinMs float64 `json:"rtt_min_ms"`
+
+	// Sent Number of echo requests sent
+	Sent int `json:"sent"`
+}
+
+// PeerQuotaUsage defines model for PeerQuotaUsage.
+type PeerQuotaUsage struct {
+	// PeersPerUserLimit Maximum number of peers each user can register, 0 is unlimited
+	PeersPerUserLimit int `json:"peers_per_user_limit"`
+
+	// SetupKeys Number of peers registered with each setup key
+	SetupKeys []SetupKeyPeerQuota `json:"setup_keys"`
+
+	// Users Number of peers registered by each user
+	Users []UserPeerQuota `json:"users"`
+}
+
 // PeerRequest defines model for PeerRequest.
 type PeerRequest struct {
 	// ApprovalRequired (Cloud only) Indicates whether peer needs approval
-	ApprovalRequired       *bool  `json:"approval_required,omitempty"`
-	LoginExpirationEnabled bool   `json:"login_expiration_enabled"`
-	Name                   string `json:"name"`
-	SshEnabled             bool   `json:"ssh_enabled"`
+	ApprovalRequired *bool `json:"approval_required,omitempty"`
+
+	// Ip Peer's NetBird IP address, it has to be a free address of the account network. Connected peers apply the new address without a restart
+	Ip                     *string `json:"ip,omitempty"`
+	LoginExpirationEnabled bool    `json:"login_expiration_enabled"`
+	Name                   string  `json:"name"`
+	SshEnabled             bool    `json:"ssh_enabled"`
+
+	// Uplink Network interface name or local source IP the peer pins its NetBird traffic to, e.g. on multi-WAN routers. Empty lets the peer pick the uplink. A locally configured uplink takes precedence
+	Uplink *string `json:"uplink,omitempty"`
+}
+
+// PeerTransferStats defines model for PeerTransferStats.
+type PeerTransferStats struct {
+	// Endpoint Current remote address of the connection
+	Endpoint string `json:"endpoint"`
+
+	// LastHandshake Last WireGuard handshake with the remote peer
+	LastHandshake time.Time `json:"last_handshake"`
+
+	// PeerId Remote peer ID, empty if the remote peer is unknown to the account
+	PeerId string `json:"peer_id"`
+
+	// PeerPubKey Remote peer's WireGuard public key
+	PeerPubKey string `json:"peer_pub_key"`
+
+	// Relayed Whether the connection goes through a relay
+	Relayed bool `json:"relayed"`
+
+	// RxBytes Bytes received from the remote peer
+	RxBytes int64 `json:"rx_bytes"`
+
+	// TxBytes Bytes sent to the remote peer
+	TxBytes int64 `json:"tx_bytes"`
 }
 
 // PersonalAccessToken defines model for PersonalAccessToken.
@@ -768,11 +1788,19 @@ type Policy struct {
 	// Name Policy name identifier
 	Name string `json:"name"`
 
+	// RequireDirect Peers connected by the policy must connect directly to each other, their traffic never going through a relay
+	RequireDirect *bool `json:"require_direct,omitempty"`
+
 	// Rules Policy rule object for policy UI editor
 	Rules []PolicyRule `json:"rules"`
 
 	// SourcePostureChecks Posture checks ID's applied to policy source groups
 	SourcePostureChecks []string `json:"source_posture_checks"`
+	// SpeedtestEnabled Allows the source peers to run throughput tests against the destination peers with netbird speedtest, both ways for bidirectional rules. The rules must also allow the traffic to TCP port 51890 and, for UDP tests, the UDP ports of the peers
+	SpeedtestEnabled *bool `json:"speedtest_enabled,omitempty"`
+
+	// SshEnabled Enables the SSH server of the destination peers and allows the source peers to log in to them, both ways for bidirectional rules
+	SshEnabled *bool `json:"ssh_enabled,omitempty"`
 }
 
 // PolicyMinimum defines model for PolicyMinimum.
@@ -788,6 +1816,15 @@ type PolicyMinimum struct {
 
 	// Name Policy name identifier
 	Name string `json:"name"`
+
+	// RequireDirect Peers connected by the policy must connect directly to each other, their traffic never going through a relay
+	RequireDirect *bool `json:"require_direct,omitempty"`
+
+	// SpeedtestEnabled Allows the source peers to run throughput tests against the destination peers with netbird speedtest, both ways for bidirectional rules. The rules must also allow the traffic to TCP port 51890 and, for UDP tests, the UDP ports of the peers
+	SpeedtestEnabled *bool `json:"speedtest_enabled,omitempty"`
+
+	// SshEnabled Enables the SSH server of the destination peers and allows the source peers to log in to them, both ways for bidirectional rules
+	SshEnabled *bool `json:"ssh_enabled,omitempty"`
 }
 
 // PolicyRule defines model for PolicyRule.
@@ -801,9 +1838,15 @@ type PolicyRule struct {
 	// Description Policy rule friendly description
 	Description *string `json:"description,omitempty"`
 
+	// DestinationLists External list IDs whose networks the source peers drop the traffic to. The rule should drop all the protocols
+	DestinationLists *[]string `json:"destination_lists,omitempty"`
+
 	// Destinations Policy rule destination group IDs
 	Destinations []GroupMinimum `json:"destinations"`
 
+	// Egress Define if the rule is enforced only by the source peers on the connections they initiate toward the destinations. Destination peers don't get firewall rules for egress rules, so they can't be bidirectional.
+	Egress *bool `json:"egress,omitempty"`
+
 	// Enabled Policy rule status
 	Enabled bool `json:"enabled"`
 
@@ -840,6 +1883,12 @@ type PolicyRuleMinimum struct {
 	// Description Policy rule friendly description
 	Description *string `json:"description,omitempty"`
 
+	// DestinationLists External list IDs whose networks the source peers drop the traffic to. The rule should drop all the protocols
+	DestinationLists *[]string `json:"destination_lists,omitempty"`
+
+	// Egress Define if the rule is enforced only by the source peers on the connections they initiate toward the destinations. Destination peers don't get firewall rules for egress rules, so they can't be bidirectional.
+	Egress *bool `json:"egress,omitempty"`
+
 	// Enabled Policy rule status
 	Enabled bool `json:"enabled"`
 
@@ -873,9 +1922,15 @@ type PolicyRuleUpdate struct {
 	// Description Policy rule friendly description
 	Description *string `json:"description,omitempty"`
 
+	// DestinationLists External list IDs whose networks the source peers drop the traffic to. The rule should drop all the protocols
+	DestinationLists *[]string `json:"destination_lists,omitempty"`
+
 	// Destinations Policy rule destination group IDs
 	Destinations []string `json:"destinations"`
 
+	// Egress Define if the rule is enforced only by the source peers on the connections they initiate toward the destinations. Destination peers don't get firewall rules for egress rules, so they can't be bidirectional.
+	Egress *bool `json:"egress,omitempty"`
+
 	// Enabled Policy rule status
 	Enabled bool `json:"enabled"`
 
@@ -901,6 +1956,24 @@ type PolicyRuleUpdateAction string
 // PolicyRuleUpdateProtocol Policy rule type of the traffic
 type PolicyRuleUpdateProtocol string
 
+// PolicyStats defines model for PolicyStats.
+type PolicyStats struct {
+	// Bytes Bytes matched by the firewall rules of the policy on the peers since they were installed
+	Bytes int64 `json:"bytes"`
+
+	// LastReportedAt Time the latest counters were reported, absent when no peer reported them
+	LastReportedAt *time.Time `json:"last_reported_at,omitempty"`
+
+	// Packets Packets matched by the firewall rules of the policy on the peers since they were installed
+	Packets int64 `json:"packets"`
+
+	// Peers Number of peers that reported the counters of the policy
+	Peers int `json:"peers"`
+
+	// PolicyId Policy ID
+	PolicyId string `json:"policy_id"`
+}
+
 // PolicyUpdate defines model for PolicyUpdate.
 type PolicyUpdate struct {
 	// Description Policy friendly description
@@ -915,11 +1988,19 @@ type PolicyUpdate struct {
 	// Name Policy name identifier
 	Name string `json:"name"`
 
+	// RequireDirect Peers connected by the policy must connect directly to each other, their traffic never going through a relay
+	RequireDirect *bool `json:"require_direct,omitempty"`
+
 	// Rules Policy rule object for policy UI editor
 	Rules []PolicyRuleUpdate `json:"rules"`
 
 	// SourcePostureChecks Posture checks ID's applied to policy source groups
 	SourcePostureChecks *[]string `json:"source_posture_checks,omitempty"`
+	// SpeedtestEnabled Allows the source peers to run throughput tests against the destination peers with netbird speedtest, both ways for bidirectional rules. The rules must also allow the traffic to TCP port 51890 and, for UDP tests, the UDP ports of the peers
+	SpeedtestEnabled *bool `json:"speedtest_enabled,omitempty"`
+
+	// SshEnabled Enables the SSH server of the destination peers and allows the source peers to log in to them, both ways for bidirectional rules
+	SshEnabled *bool `json:"ssh_enabled,omitempty"`
 }
 
 // PostureCheck defines model for PostureCheck.
@@ -949,11 +2030,89 @@ type PostureCheckUpdate struct {
 	Name string `json:"name"`
 }
 
+// RelayServer defines model for RelayServer.
+type RelayServer struct {
+	// Addresses TURN URIs of the relay server
+	Addresses []string `json:"addresses"`
+
+	// CredentialsTtl Lifetime in seconds of the credentials derived from the secret, zero for static credentials
+	CredentialsTtl int `json:"credentials_ttl"`
+
+	// Description Relay server description
+	Description string `json:"description"`
+
+	// Enabled Relay server status
+	Enabled bool `json:"enabled"`
+
+	// GeoLabels ISO 3166-1 alpha-2 codes of the countries of the peers the relay server serves, empty to serve the peers of any location
+	GeoLabels []CountryCode `json:"geo_labels"`
+
+	// Id Relay server ID
+	Id string `json:"id"`
+
+	// Name Relay server name
+	Name string `json:"name"`
+
+	// TimeBasedCredentials Indicates whether the credentials of the peers are derived from a secret and rotated
+	TimeBasedCredentials bool `json:"time_based_credentials"`
+
+	// Username Static username of the relay server, empty when it has a secret
+	Username string `json:"username"`
+}
+
+// RelayServerRequest defines model for RelayServerRequest.
+type RelayServerRequest struct {
+	// Addresses TURN URIs of the relay server
+	Addresses []string `json:"addresses"`
+
+	// CredentialsTtl Lifetime in seconds of the credentials derived from the secret, they are rotated at 3/4 of it. Zero uses the default of 12 hours.
+	CredentialsTtl *int `json:"credentials_ttl,omitempty"`
+
+	// Description Relay server description
+	Description *string `json:"description,omitempty"`
+
+	// Enabled Relay server status
+	Enabled bool `json:"enabled"`
+
+	// GeoLabels ISO 3166-1 alpha-2 codes of the countries of the peers the relay server serves, empty to serve the peers of any location
+	GeoLabels *[]CountryCode `json:"geo_labels,omitempty"`
+
+	// Name Relay server name
+	Name string `json:"name"`
+
+	// Password Static password of the relay server, used when it has no secret. Omit it on update to keep the current one.
+	Password *string `json:"password,omitempty"`
+
+	// Secret Shared secret the time limited credentials of the peers are derived from, as in the TURN REST API of coturn. Omit it on update to keep the current one.
+	Secret *string `json:"secret,omitempty"`
+
+	// Username Static username of the relay server, used when it has no secret
+	Username *string `json:"username,omitempty"`
+}
+
+// RelayedConnection defines model for RelayedConnection.
+type RelayedConnection struct {
+	// PeerId ID of one of the peers of the connection
+	PeerId string `json:"peer_id"`
+
+	// PeerName Name of one of the peers of the connection
+	PeerName string `json:"peer_name"`
+
+	// RemotePeerId ID of the other peer of the connection
+	RemotePeerId string `json:"remote_peer_id"`
+
+	// RemotePeerName Name of the other peer of the connection
+	RemotePeerName string `json:"remote_peer_name"`
+}
+
 // Route defines model for Route.
 type Route struct {
 	// Description Route description
 	Description string `json:"description"`
 
+	// Domains Domain names the route destinations are resolved from. A domain starting with `*.` matches all its subdomains. This property can not be set together with `network`
+	Domains *[]string `json:"domains,omitempty"`
+
 	// Enabled Route status
 	Enabled bool `json:"enabled"`
 
@@ -963,19 +2122,22 @@ type Route struct {
 	// Id Route Id
 	Id string `json:"id"`
 
+	// KeepRoute Keep the routes of resolved addresses after they disappear from the DNS responses. Only applies to routes with `domains`
+	KeepRoute *bool `json:"keep_route,omitempty"`
+
 	// Masquerade Indicate if peer should masquerade traffic to this route's prefix
 	Masquerade bool `json:"masquerade"`
 
 	// Metric Route metric number. Lowest number has higher priority
 	Metric int `json:"metric"`
 
-	// Network Network range in CIDR format
-	Network string `json:"network"`
+	// Network Network range in CIDR format. This property can not be set together with `domains`
+	Network *string `json:"network,omitempty"`
 
 	// NetworkId Route network identifier, to group HA routes
 	NetworkId string `json:"network_id"`
 
-	// NetworkType Network type indicating if it is IPv4 or IPv6
+	// NetworkType Network type indicating if it is IPv4, IPv6 or Domain
 	NetworkType string `json:"network_type"`
 
 	// Peer Peer Identifier associated with route. This property can not be set together with `peer_groups`
@@ -983,27 +2145,183 @@ type Route struct {
 
 	// PeerGroups Peers Group Identifier associated with route. This property can not be set together with `peer`
 	PeerGroups *[]string `json:"peer_groups,omitempty"`
+
+	// PeerLoad Latest load the routing peer of the route reported, absent for the routes with peer groups and the peers that didn't report any
+	PeerLoad *PeerLoad `json:"peer_load,omitempty"`
+
+	// PendingApproval Indicates that the route was proposed by its routing peer and stays disabled until an admin approves it
+	PendingApproval bool `json:"pending_approval"`
+
+	// RouteGroupId ID of the route group the route is a member route of. Member routes can only be changed through their route group
+	RouteGroupId *string `json:"route_group_id,omitempty"`
+
+	// Weight ECMP weight of the routing peer. When set on the routes of a default network, Linux peers spread the traffic across all connected routing peers of the network proportionally to their weights. 0 disables ECMP
+	Weight *int `json:"weight,omitempty"`
+}
+
+// RouteConflict defines model for RouteConflict.
+type RouteConflict struct {
+	// ConflictsWith Local route or gateway address the route conflicts with
+	ConflictsWith string `json:"conflicts_with"`
+
+	// Network Route network range in CIDR format
+	Network string `json:"network"`
+
+	// NetworkId Route network identifier
+	NetworkId string `json:"network_id"`
+
+	// Reason Conflict reason
+	Reason RouteConflictReason `json:"reason"`
+}
+
+// RouteConflictReason Conflict reason
+type RouteConflictReason string
+
+// RouteGroup defines model for RouteGroup.
+type RouteGroup struct {
+	// Description Route group description
+	Description string `json:"description"`
+
+	// Enabled Route group status
+	Enabled bool `json:"enabled"`
+
+	// FailoverPolicy Failover policy between the members, "priority" routes through the connected member with the lowest priority and "ecmp" spreads the traffic across the connected members. The ecmp policy is supported for the 0.0.0.0/0 network only
+	FailoverPolicy RouteGroupFailoverPolicy `json:"failover_policy"`
+
+	// Groups Group IDs of the peers the network is distributed to
+	Groups []string `json:"groups"`
+
+	// Id Route group ID
+	Id string `json:"id"`
+
+	// LoadThresholds Loads above which the members are overloaded, the clients prefer the members that aren't. The routing peers report their load every 30 seconds. No threshold is set by default
+	LoadThresholds *RouteGroupLoadThresholds `json:"load_thresholds,omitempty"`
+
+	// Masquerade Indicate if the routing peers should masquerade the traffic to the network
+	Masquerade bool `json:"masquerade"`
+
+	// Members Routing peers of the route group
+	Members []RouteGroupMemberRequest `json:"members"`
+
+	// MembersStatus Health of the members of the route group in the order of the members
+	MembersStatus []RouteGroupMemberStatus `json:"members_status"`
+
+	// Name Route group name, it is the network identifier of the member routes
+	Name string `json:"name"`
+
+	// Network Network range in CIDR format
+	Network string `json:"network"`
+}
+
+// RouteGroupFailoverPolicy Failover policy between the members, "priority" routes through the connected member with the lowest priority and "ecmp" spreads the traffic across the connected members. The ecmp policy is supported for the 0.0.0.0/0 network only
+type RouteGroupFailoverPolicy string
+
+// RouteGroupLoadThresholds defines model for RouteGroupLoadThresholds.
+type RouteGroupLoadThresholds struct {
+	// CpuPercent CPU load above which a member is overloaded, 0 disables the threshold
+	CpuPercent float64 `json:"cpu_percent"`
+
+	// MemoryPercent Memory load above which a member is overloaded, 0 disables the threshold
+	MemoryPercent float64 `json:"memory_percent"`
+
+	// ThroughputMbps WireGuard throughput in megabits per second above which a member is overloaded, 0 disables the threshold
+	ThroughputMbps int `json:"throughput_mbps"`
+}
+
+// RouteGroupMemberRequest defines model for RouteGroupMemberRequest.
+type RouteGroupMemberRequest struct {
+	// PeerId Routing peer ID
+	PeerId string `json:"peer_id"`
+
+	// Priority Priority of the member with the priority failover policy, the lowest is preferred. It is the metric of the member route. Defaults to the position of the member in the list
+	Priority *int `json:"priority,omitempty"`
+
+	// Weight Share of the traffic of the member with the ecmp failover policy. Defaults to 1
+	Weight *int `json:"weight,omitempty"`
+}
+
+// RouteGroupMemberStatus defines model for RouteGroupMemberStatus.
+type RouteGroupMemberStatus struct {
+	// Active Indicates whether the clients route through the member, the connected members with the lowest priority with the priority failover policy and all the connected members with the ecmp one, the members that aren't overloaded first
+	Active bool `json:"active"`
+
+	// Connected Indicates whether the routing peer is connected to the management service
+	Connected bool `json:"connected"`
+
+	// LastSeen Last time the routing peer was connected to the management service
+	LastSeen time.Time `json:"last_seen"`
+
+	// Load Latest load the routing peer reported, absent when it didn't report any
+	Load *PeerLoad `json:"load,omitempty"`
+
+	// Overloaded Indicates whether the load of the member exceeds the load thresholds of the route group. The clients route through an overloaded member only when all the members are overloaded
+	Overloaded bool `json:"overloaded"`
+
+	// PeerId Routing peer ID
+	PeerId string `json:"peer_id"`
+
+	// RouteId ID of the member route of the routing peer
+	RouteId string `json:"route_id"`
+}
+
+// RouteGroupRequest defines model for RouteGroupRequest.
+type RouteGroupRequest struct {
+	// Description Route group description
+	Description string `json:"description"`
+
+	// Enabled Route group status
+	Enabled bool `json:"enabled"`
+
+	// FailoverPolicy Failover policy between the members, "priority" routes through the connected member with the lowest priority and "ecmp" spreads the traffic across the connected members. The ecmp policy is supported for the 0.0.0.0/0 network only
+	FailoverPolicy RouteGroupRequestFailoverPolicy `json:"failover_policy"`
+
+	// Groups Group IDs of the peers the network is distributed to
+	Groups []string `json:"groups"`
+
+	// LoadThresholds Loads above which the members are overloaded, the clients prefer the members that aren't. The routing peers report their load every 30 seconds. No threshold is set by default
+	LoadThresholds *RouteGroupLoadThresholds `json:"load_thresholds,omitempty"`
+
+	// Masquerade Indicate if the routing peers should masquerade the traffic to the network
+	Masquerade bool `json:"masquerade"`
+
+	// Members Routing peers of the route group
+	Members []RouteGroupMemberRequest `json:"members"`
+
+	// Name Route group name, it is the network identifier of the member routes
+	Name string `json:"name"`
+
+	// Network Network range in CIDR format
+	Network string `json:"network"`
 }
 
+// RouteGroupRequestFailoverPolicy Failover policy between the members, "priority" routes through the connected member with the lowest priority and "ecmp" spreads the traffic across the connected members. The ecmp policy is supported for the 0.0.0.0/0 network only
+type RouteGroupRequestFailoverPolicy string
+
 // RouteRequest defines model for RouteRequest.
 type RouteRequest struct {
 	// Description Route description
 	Description string `json:"description"`
 
+	// Domains Domain names the route destinations are resolved from. A domain starting with `*.` matches all its subdomains. This property can not be set together with `network`
+	Domains *[]string `json:"domains,omitempty"`
+
 	// Enabled Route status
 	Enabled bool `json:"enabled"`
 
 	// Groups Group IDs containing routing peers
 	Groups []string `json:"groups"`
 
+	// KeepRoute Keep the routes of resolved addresses after they disappear from the DNS responses. Only applies to routes with `domains`
+	KeepRoute *bool `json:"keep_route,omitempty"`
+
 	// Masquerade Indicate if peer should masquerade traffic to this route's prefix
 	Masquerade bool `json:"masquerade"`
 
 	// Metric Route metric number. Lowest number has higher priority
 	Metric int `json:"metric"`
 
-	// Network Network range in CIDR format
-	Network string `json:"network"`
+	// Network Network range in CIDR format. This property can not be set together with `domains`
+	Network *string `json:"network,omitempty"`
 
 	// NetworkId Route network identifier, to group HA routes
 	NetworkId string `json:"network_id"`
@@ -1013,6 +2331,24 @@ type RouteRequest struct {
 
 	// PeerGroups Peers Group Identifier associated with route. This property can not be set together with `peer`
 	PeerGroups *[]string `json:"peer_groups,omitempty"`
+
+	// Weight ECMP weight of the routing peer. When set on the routes of a default network, Linux peers spread the traffic across all connected routing peers of the network proportionally to their weights. 0 disables ECMP
+	Weight *int `json:"weight,omitempty"`
+}
+
+// RoutingPeerUsage defines model for RoutingPeerUsage.
+type RoutingPeerUsage struct {
+	// PeerId Routing peer ID
+	PeerId string `json:"peer_id"`
+
+	// PeerName Routing peer name
+	PeerName string `json:"peer_name"`
+
+	// RxBytes Bytes received by the routing peer from the other peers since its WireGuard interface came up
+	RxBytes int64 `json:"rx_bytes"`
+
+	// TxBytes Bytes sent by the routing peer to the other peers since its WireGuard interface came up
+	TxBytes int64 `json:"tx_bytes"`
 }
 
 // SetupKey defines model for SetupKey.
@@ -1038,6 +2374,9 @@ type SetupKey struct {
 	// Name Setup key name identifier
 	Name string `json:"name"`
 
+	// PeerLimit Maximum number of peers registered with this key at the same time, the deleted peers don't count. The value of 0 indicates no limit.
+	PeerLimit int `json:"peer_limit"`
+
 	// Revoked Setup key revocation status
 	Revoked bool `json:"revoked"`
 
@@ -1060,6 +2399,21 @@ type SetupKey struct {
 	Valid bool `json:"valid"`
 }
 
+// SetupKeyPeerQuota defines model for SetupKeyPeerQuota.
+type SetupKeyPeerQuota struct {
+	// Limit Maximum number of peers registered with the setup key at the same time, 0 is unlimited
+	Limit int `json:"limit"`
+
+	// Name Setup key name identifier
+	Name string `json:"name"`
+
+	// Peers Number of peers registered with the setup key
+	Peers int `json:"peers"`
+
+	// SetupKeyId Setup Key ID
+	SetupKeyId string `json:"setup_key_id"`
+}
+
 // SetupKeyRequest defines model for SetupKeyRequest.
 type SetupKeyRequest struct {
 	// AutoGroups List of group IDs to auto-assign to peers registered with this key
@@ -1074,6 +2428,9 @@ type SetupKeyRequest struct {
 	// Name Setup Key name
 	Name string `json:"name"`
 
+	// PeerLimit Maximum number of peers registered with this key at the same time, the deleted peers don't count. The value of 0 indicates no limit. Omit it on update to keep the current one.
+	PeerLimit *int `json:"peer_limit,omitempty"`
+
 	// Revoked Setup key revocation status
 	Revoked bool `json:"revoked"`
 
@@ -1084,6 +2441,45 @@ type SetupKeyRequest struct {
 	UsageLimit int `json:"usage_limit"`
 }
 
+// UsagePeriod defines model for UsagePeriod.
+type UsagePeriod struct {
+	// ActivePeers Number of peers added, logged in or last seen during the period, or connected during the current period
+	ActivePeers int `json:"active_peers"`
+
+	// End End of the period, excluded
+	End time.Time `json:"end"`
+
+	// NewPeers Number of peers added during the period, including the ones deleted since
+	NewPeers int `json:"new_peers"`
+
+	// PolicyChanges Number of policies added, updated or deleted during the period
+	PolicyChanges int `json:"policy_changes"`
+
+	// RoutingPeers Data transferred by the routing peers as last reported by them, only set for the current period
+	RoutingPeers *[]RoutingPeerUsage `json:"routing_peers,omitempty"`
+
+	// Start Start of the period, included
+	Start time.Time `json:"start"`
+}
+
+// UsageReport defines model for UsageReport.
+type UsageReport struct {
+	// From Start of the report
+	From time.Time `json:"from"`
+
+	// Period Length of the periods of the report, aligned on UTC days, weeks starting on Monday or months
+	Period UsageReportPeriod `json:"period"`
+
+	// Periods Usage of the account per period
+	Periods []UsagePeriod `json:"periods"`
+
+	// To End of the report
+	To time.Time `json:"to"`
+}
+
+// UsageReportPeriod Length of the periods of the report, aligned on UTC days, weeks starting on Monday or months
+type UsageReportPeriod string
+
 // User defines model for User.
 type User struct {
 	// AutoGroups Group IDs to auto-assign to peers registered by this user
@@ -1142,6 +2538,24 @@ type UserCreateRequest struct {
 	Role string `json:"role"`
 }
 
+// UserNotifications defines model for UserNotifications.
+type UserNotifications struct {
+	// EmailNotifications Kinds of notifications the user receives by email, one of peer_approval_pending, peer_login_expired, setup_key_expiring and user_invited
+	EmailNotifications []string `json:"email_notifications"`
+}
+
+// UserPeerQuota defines model for UserPeerQuota.
+type UserPeerQuota struct {
+	// Limit Maximum number of peers the user can register, 0 is unlimited
+	Limit int `json:"limit"`
+
+	// Peers Number of peers registered by the user
+	Peers int `json:"peers"`
+
+	// UserId User ID
+	UserId string `json:"user_id"`
+}
+
 // UserPermissions defines model for UserPermissions.
 type UserPermissions struct {
 	// DashboardView User's permission to view the dashboard
@@ -1163,6 +2577,105 @@ type UserRequest struct {
 	Role string `json:"role"`
 }
 
+// DeleteApiAccountsAccountIdParams defines parameters for DeleteApiAccountsAccountId.
+type DeleteApiAccountsAccountIdParams struct {
+	// ConfirmationToken Token returned by the first call, confirming the deletion
+	ConfirmationToken *string `form:"confirmation_token,omitempty" json:"confirmation_token,omitempty"`
+}
+
+// GetApiDnsZonesParams defines parameters for GetApiDnsZones.
+type GetApiDnsZonesParams struct {
+	// IfNoneMatch ETag of a previous response, the zones aren't returned again while the serial is unchanged
+	IfNoneMatch *string `json:"If-None-Match,omitempty"`
+}
+
+// GetApiDnsZonesExportParams defines parameters for GetApiDnsZonesExport.
+type GetApiDnsZonesExportParams struct {
+	// Zone Only exports the zone with the domain, a file loadable by DNS servers reading a single zone per file
+	Zone *string `form:"zone,omitempty" json:"zone,omitempty"`
+
+	// IfNoneMatch ETag of a previous response, the zones aren't returned again while the serial is unchanged
+	IfNoneMatch *string `json:"If-None-Match,omitempty"`
+}
+
+// GetApiGroupsParams defines parameters for GetApiGroups.
+type GetApiGroupsParams struct {
+	// Summary Returns the groups with the count of their peers only, the peers of a group are listed by /api/groups/{groupId}/peers
+	Summary *bool `form:"summary,omitempty" json:"summary,omitempty"`
+}
+
+// GetApiGroupsGroupIdPeersParams defines parameters for GetApiGroupsGroupIdPeers.
+type GetApiGroupsGroupIdPeersParams struct {
+	// Page Page to return, starting at 1
+	Page *int `form:"page,omitempty" json:"page,omitempty"`
+
+	// PageSize Number of peers per page
+	PageSize *int `form:"page_size,omitempty" json:"page_size,omitempty"`
+}
+
+// GetApiPeersParams defines parameters for GetApiPeers.
+type GetApiPeersParams struct {
+	// ExpiringWithin Only returns the peers whose login hasn't expired yet and expires within the duration, e.g. 24h
+	ExpiringWithin *string `form:"expiring_within,omitempty" json:"expiring_within,omitempty"`
+}
+
+// GetApiPeersInventoryParams defines parameters for GetApiPeersInventory.
+type GetApiPeersInventoryParams struct {
+	// Os Only the peers running the operating system, case-insensitive
+	Os *string `form:"os,omitempty" json:"os,omitempty"`
+
+	// Package Only the peers with the package installed
+	Package *string `form:"package,omitempty" json:"package,omitempty"`
+
+	// VersionBelow Only the peers with the package installed below the version, requires the package filter
+	VersionBelow *string `form:"version_below,omitempty" json:"version_below,omitempty"`
+
+	// PatchLevel Only the peers with a patch level starting with it
+	PatchLevel *string `form:"patch_level,omitempty" json:"patch_level,omitempty"`
+
+	// Format Format of the inventory, the CSV inventory has a row per reported package of each peer
+	Format *GetApiPeersInventoryParamsFormat `form:"format,omitempty" json:"format,omitempty"`
+}
+
+// GetApiPeersInventoryParamsFormat defines parameters for GetApiPeersInventory.
+type GetApiPeersInventoryParamsFormat string
+
+// GetApiReportsPackagesParams defines parameters for GetApiReportsPackages.
+type GetApiReportsPackagesParams struct {
+	// Name Only the versions of the package
+	Name *string `form:"name,omitempty" json:"name,omitempty"`
+
+	// Vulnerable Only the versions below the minimum version of the posture checks
+	Vulnerable *bool `form:"vulnerable,omitempty" json:"vulnerable,omitempty"`
+}
+
+// GetApiReportsUsageParams defines parameters for GetApiReportsUsage.
+type GetApiReportsUsageParams struct {
+	// From Start of the report as a date or a RFC 3339 time, defaults to the start of the period the report ends in
+	From *string `form:"from,omitempty" json:"from,omitempty"`
+
+	// To End of the report as a date or a RFC 3339 time, defaults to now
+	To *string `form:"to,omitempty" json:"to,omitempty"`
+
+	// Period Length of the periods of the report
+	Period *GetApiReportsUsageParamsPeriod `form:"period,omitempty" json:"period,omitempty"`
+
+	// Format Format of the report, the CSV report has a row per metric of each period
+	Format *GetApiReportsUsageParamsFormat `form:"format,omitempty" json:"format,omitempty"`
+}
+
+// GetApiReportsUsageParamsPeriod defines parameters for GetApiReportsUsage.
+type GetApiReportsUsageParamsPeriod string
+
+// GetApiReportsUsageParamsFormat defines parameters for GetApiReportsUsage.
+type GetApiReportsUsageParamsFormat string
+
+// GetApiSetupKeysStaleParams defines parameters for GetApiSetupKeysStale.
+type GetApiSetupKeysStaleParams struct {
+	// MaxAge Age after which a setup key is stale, e.g. 720h, defaults to 90 days
+	MaxAge *string `form:"max_age,omitempty" json:"max_age,omitempty"`
+}
+
 // GetApiUsersParams defines parameters for GetApiUsers.
 type GetApiUsersParams struct {
 	// ServiceUser Filters users and returns either regular users or service users
@@ -1172,24 +2685,60 @@ type GetApiUsersParams struct {
 // PutApiAccountsAccountIdJSONRequestBody defines body for PutApiAccountsAccountId for application/json ContentType.
 type PutApiAccountsAccountIdJSONRequestBody = AccountRequest
 
+// PostApiClaimGroupMappingsJSONRequestBody defines body for PostApiClaimGroupMappings for application/json ContentType.
+type PostApiClaimGroupMappingsJSONRequestBody = ClaimGroupMappingRequest
+
+// PutApiClaimGroupMappingsMappingIdJSONRequestBody defines body for PutApiClaimGroupMappingsMappingId for application/json ContentType.
+type PutApiClaimGroupMappingsMappingIdJSONRequestBody = ClaimGroupMappingRequest
+
 // PostApiDnsNameserversJSONRequestBody defines body for PostApiDnsNameservers for application/json ContentType.
 type PostApiDnsNameserversJSONRequestBody = NameserverGroupRequest
 
 // PutApiDnsNameserversNsgroupIdJSONRequestBody defines body for PutApiDnsNameserversNsgroupId for application/json ContentType.
 type PutApiDnsNameserversNsgroupIdJSONRequestBody = NameserverGroupRequest
 
+// PutApiDnsLabelsPeerIdJSONRequestBody defines body for PutApiDnsLabelsPeerId for application/json ContentType.
+type PutApiDnsLabelsPeerIdJSONRequestBody = PeerDNSLabelRequest
+
+// PostApiDnsRecordsJSONRequestBody defines body for PostApiDnsRecords for application/json ContentType.
+type PostApiDnsRecordsJSONRequestBody = DNSRecordRequest
+
+// PutApiDnsRecordsRecordIdJSONRequestBody defines body for PutApiDnsRecordsRecordId for application/json ContentType.
+type PutApiDnsRecordsRecordIdJSONRequestBody = DNSRecordRequest
+
 // PutApiDnsSettingsJSONRequestBody defines body for PutApiDnsSettings for application/json ContentType.
 type PutApiDnsSettingsJSONRequestBody = DNSSettings
 
+// PostApiExternalListsJSONRequestBody defines body for PostApiExternalLists for application/json ContentType.
+type PostApiExternalListsJSONRequestBody = ExternalListRequest
+
+// PutApiExternalListsListIdJSONRequestBody defines body for PutApiExternalListsListId for application/json ContentType.
+type PutApiExternalListsListIdJSONRequestBody = ExternalListRequest
+
 // PostApiGroupsJSONRequestBody defines body for PostApiGroups for application/json ContentType.
 type PostApiGroupsJSONRequestBody = GroupRequest
 
 // PutApiGroupsGroupIdJSONRequestBody defines body for PutApiGroupsGroupId for application/json ContentType.
 type PutApiGroupsGroupIdJSONRequestBody = GroupRequest
 
+// PutApiIpamAllocationsPeerIdJSONRequestBody defines body for PutApiIpamAllocationsPeerId for application/json ContentType.
+type PutApiIpamAllocationsPeerIdJSONRequestBody = IPAllocationRequest
+
 // PutApiPeersPeerIdJSONRequestBody defines body for PutApiPeersPeerId for application/json ContentType.
 type PutApiPeersPeerIdJSONRequestBody = PeerRequest
 
+// PostApiPeersPeerIdActionsJSONRequestBody defines body for PostApiPeersPeerIdActions for application/json ContentType.
+type PostApiPeersPeerIdActionsJSONRequestBody = PeerActionRequest
+
+// PostApiPeersPeerIdPingJSONRequestBody defines body for PostApiPeersPeerIdPing for application/json ContentType.
+type PostApiPeersPeerIdPingJSONRequestBody = PeerPingRequest
+
+// PostApiPeersPeerIdGeofenceOverrideJSONRequestBody defines body for PostApiPeersPeerIdGeofenceOverride for application/json ContentType.
+type PostApiPeersPeerIdGeofenceOverrideJSONRequestBody = PeerGeofenceOverrideRequest
+
+// PutApiPeersPeerIdGeofenceOverrideJSONRequestBody defines body for PutApiPeersPeerIdGeofenceOverride for application/json ContentType.
+type PutApiPeersPeerIdGeofenceOverrideJSONRequestBody = PeerGeofenceOverrideApproval
+
 // PostApiPoliciesJSONRequestBody defines body for PostApiPolicies for application/json ContentType.
 type PostApiPoliciesJSONRequestBody = PolicyUpdate
 
@@ -1202,6 +2751,18 @@ type PostApiPostureChecksJSONRequestBody = PostureCheckUpdate
 // PutApiPostureChecksPostureCheckIdJSONRequestBody defines body for PutApiPostureChecksPostureCheckId for application/json ContentType.
 type PutApiPostureChecksPostureCheckIdJSONRequestBody = PostureCheckUpdate
 
+// PostApiRelayServersJSONRequestBody defines body for PostApiRelayServers for application/json ContentType.
+type PostApiRelayServersJSONRequestBody = RelayServerRequest
+
+// PutApiRelayServersRelayIdJSONRequestBody defines body for PutApiRelayServersRelayId for application/json ContentType.
+type PutApiRelayServersRelayIdJSONRequestBody = RelayServerRequest
+
+// PostApiRouteGroupsJSONRequestBody defines body for PostApiRouteGroups for application/json ContentType.
+type PostApiRouteGroupsJSONRequestBody = RouteGroupRequest
+
+// PutApiRouteGroupsRouteGroupIdJSONRequestBody defines body for PutApiRouteGroupsRouteGroupId for application/json ContentType.
+type PutApiRouteGroupsRouteGroupIdJSONRequestBody = RouteGroupRequest
+
 // PostApiRoutesJSONRequestBody defines body for PostApiRoutes for application/json ContentType.
 type PostApiRoutesJSONRequestBody = RouteRequest
 
@@ -1220,5 +2781,8 @@ type PostApiUsersJSONRequestBody = UserCreateRequest
 // PutApiUsersUserIdJSONRequestBody defines body for PutApiUsersUserId for application/json ContentType.
 type PutApiUsersUserIdJSONRequestBody = UserRequest
 
+// PutApiUsersUserIdNotificationsJSONRequestBody defines body for PutApiUsersUserIdNotifications for application/json ContentType.
+type PutApiUsersUserIdNotificationsJSONRequestBody = UserNotifications
+
 // PostApiUsersUserIdTokensJSONRequestBody defines body for PostApiUsersUserIdTokens for application/json ContentType.
 type PostApiUsersUserIdTokensJSONRequestBody = PersonalAccessTokenRequest
@@ -0,0 +1,190 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/http/util"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// ClaimGroupMappingsHandler is the handler of the mappings of the JWT claims of the users to groups
+type ClaimGroupMappingsHandler struct {
+	accountManager  server.AccountManager
+	claimsExtractor *jwtclaims.ClaimsExtractor
+}
+
+// NewClaimGroupMappingsHandler returns a new instance of ClaimGroupMappingsHandler handler
+func NewClaimGroupMappingsHandler(accountManager server.AccountManager, authCfg AuthCfg) *ClaimGroupMappingsHandler {
+	return &ClaimGroupMappingsHandler{
+		accountManager: accountManager,
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithAudience(authCfg.Audience),
+			jwtclaims.WithUserIDClaim(authCfg.UserIDClaim),
+		),
+	}
+}
+
+// GetAllClaimGroupMappings returns the list of claim group mappings for the account
+func (h *ClaimGroupMappingsHandler) GetAllClaimGroupMappings(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	mappings, err := h.accountManager.ListClaimGroupMappings(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	apiMappings := make([]*api.ClaimGroupMapping, 0)
+	for _, mapping := range mappings {
+		apiMappings = append(apiMappings, toClaimGroupMappingResponse(mapping))
+	}
+
+	util.WriteJSONObject(w, apiMappings)
+}
+
+// CreateClaimGroupMapping handles claim group mapping creation request
+func (h *ClaimGroupMappingsHandler) CreateClaimGroupMapping(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	var req api.PostApiClaimGroupMappingsJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteJSONParseError(err, w)
+		return
+	}
+
+	mapping, err := h.accountManager.CreateClaimGroupMapping(account.Id, user.Id, toServerClaimGroupMapping("", req))
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toClaimGroupMappingResponse(mapping)
+
+	util.WriteJSONObject(w, &resp)
+}
+
+// UpdateClaimGroupMapping handles update to a claim group mapping identified by a given ID
+func (h *ClaimGroupMappingsHandler) UpdateClaimGroupMapping(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	mappingID := mux.Vars(r)["mappingId"]
+	if len(mappingID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid claim group mapping ID"), w)
+		return
+	}
+
+	var req api.PutApiClaimGroupMappingsMappingIdJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteJSONParseError(err, w)
+		return
+	}
+
+	mapping := toServerClaimGroupMapping(mappingID, req)
+	err = h.accountManager.SaveClaimGroupMapping(account.Id, user.Id, mapping)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toClaimGroupMappingResponse(mapping)
+
+	util.WriteJSONObject(w, &resp)
+}
+
+// DeleteClaimGroupMapping handles claim group mapping deletion request
+func (h *ClaimGroupMappingsHandler) DeleteClaimGroupMapping(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	mappingID := mux.Vars(r)["mappingId"]
+	if len(mappingID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid claim group mapping ID"), w)
+		return
+	}
+
+	err = h.accountManager.DeleteClaimGroupMapping(account.Id, user.Id, mappingID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, emptyObject{})
+}
+
+// GetClaimGroupMapping handles a claim group mapping Get request identified by ID
+func (h *ClaimGroupMappingsHandler) GetClaimGroupMapping(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	mappingID := mux.Vars(r)["mappingId"]
+	if len(mappingID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid claim group mapping ID"), w)
+		return
+	}
+
+	mapping, err := h.accountManager.GetClaimGroupMapping(account.Id, user.Id, mappingID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toClaimGroupMappingResponse(mapping)
+
+	util.WriteJSONObject(w, &resp)
+}
+
+func toServerClaimGroupMapping(mappingID string, req api.ClaimGroupMappingRequest) *server.ClaimGroupMapping {
+	return &server.ClaimGroupMapping{
+		ID:      mappingID,
+		Name:    req.Name,
+		Claim:   req.Claim,
+		Values:  req.Values,
+		Groups:  req.Groups,
+		Enabled: req.Enabled,
+	}
+}
+
+func toClaimGroupMappingResponse(mapping *server.ClaimGroupMapping) *api.ClaimGroupMapping {
+	return &api.ClaimGroupMapping{
+		Id:      mapping.ID,
+		Name:    mapping.Name,
+		Claim:   mapping.Claim,
+		Values:  mapping.Values,
+		Groups:  mapping.Groups,
+		Enabled: mapping.Enabled,
+	}
+}
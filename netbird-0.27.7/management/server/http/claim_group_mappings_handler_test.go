@@ -0,0 +1,206 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/mock_server"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	existingClaimGroupMappingID = "existingClaimGroupMappingID"
+	notFoundClaimGroupMappingID = "notFoundClaimGroupMappingID"
+)
+
+var baseExistingClaimGroupMapping = &server.ClaimGroupMapping{
+	ID:      existingClaimGroupMappingID,
+	Name:    "Engineering",
+	Claim:   "department",
+	Values:  []string{"engineering"},
+	Groups:  []string{"group1"},
+	Enabled: true,
+}
+
+func initClaimGroupMappingsTestData() *ClaimGroupMappingsHandler {
+	return &ClaimGroupMappingsHandler{
+		accountManager: &mock_server.MockAccountManager{
+			GetClaimGroupMappingFunc: func(_, _, mappingID string) (*server.ClaimGroupMapping, error) {
+				if mappingID == existingClaimGroupMappingID {
+					return baseExistingClaimGroupMapping.Copy(), nil
+				}
+				return nil, status.Errorf(status.NotFound, "claim group mapping with ID %s not found", mappingID)
+			},
+			CreateClaimGroupMappingFunc: func(_, _ string, mapping *server.ClaimGroupMapping) (*server.ClaimGroupMapping, error) {
+				if mapping.Claim == "" {
+					return nil, status.Errorf(status.InvalidArgument, "claim group mapping should have a claim")
+				}
+				created := mapping.Copy()
+				created.ID = existingClaimGroupMappingID
+				return created, nil
+			},
+			SaveClaimGroupMappingFunc: func(_, _ string, mapping *server.ClaimGroupMapping) error {
+				if mapping.ID != existingClaimGroupMappingID {
+					return status.Errorf(status.NotFound, "claim group mapping with ID %s was not found", mapping.ID)
+				}
+				return nil
+			},
+			DeleteClaimGroupMappingFunc: func(_, _, mappingID string) error {
+				return nil
+			},
+			ListClaimGroupMappingsFunc: func(_, _ string) ([]*server.ClaimGroupMapping, error) {
+				return []*server.ClaimGroupMapping{baseExistingClaimGroupMapping.Copy()}, nil
+			},
+			GetAccountFromTokenFunc: func(_ jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error) {
+				return testingNSAccount, testingAccount.Users["test_user"], nil
+			},
+		},
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+				return jwtclaims.AuthorizationClaims{
+					UserId:    "test_user",
+					Domain:    "hotmail.com",
+					AccountId: testNSGroupAccountID,
+				}
+			}),
+		),
+	}
+}
+
+func TestClaimGroupMappingsHandlers(t *testing.T) {
+	tt := []struct {
+		name            string
+		expectedStatus  int
+		expectedBody    bool
+		expectedMapping *api.ClaimGroupMapping
+		requestType     string
+		requestPath     string
+		requestBody     io.Reader
+	}{
+		{
+			name:           "Get Existing Claim Group Mapping",
+			requestType:    http.MethodGet,
+			requestPath:    "/api/claim-group-mappings/" + existingClaimGroupMappingID,
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedMapping: &api.ClaimGroupMapping{
+				Id:      existingClaimGroupMappingID,
+				Name:    "Engineering",
+				Claim:   "department",
+				Values:  []string{"engineering"},
+				Groups:  []string{"group1"},
+				Enabled: true,
+			},
+		},
+		{
+			name:           "Get Not Existing Claim Group Mapping",
+			requestType:    http.MethodGet,
+			requestPath:    "/api/claim-group-mappings/" + notFoundClaimGroupMappingID,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:        "POST OK",
+			requestType: http.MethodPost,
+			requestPath: "/api/claim-group-mappings",
+			requestBody: bytes.NewBufferString(`{"name":"Platform team","claim":"team","values":["platform","sre"],` +
+				`"groups":["group2"],"enabled":true}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedMapping: &api.ClaimGroupMapping{
+				Id:      existingClaimGroupMappingID,
+				Name:    "Platform team",
+				Claim:   "team",
+				Values:  []string{"platform", "sre"},
+				Groups:  []string{"group2"},
+				Enabled: true,
+			},
+		},
+		{
+			name:           "POST Without Claim",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/claim-group-mappings",
+			requestBody:    bytes.NewBufferString(`{"name":"Platform team","values":["platform"],"groups":["group2"],"enabled":true}`),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "PUT OK",
+			requestType: http.MethodPut,
+			requestPath: "/api/claim-group-mappings/" + existingClaimGroupMappingID,
+			requestBody: bytes.NewBufferString(`{"name":"Engineering","claim":"department","values":["engineering","research"],` +
+				`"groups":["group1"],"enabled":false}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedMapping: &api.ClaimGroupMapping{
+				Id:      existingClaimGroupMappingID,
+				Name:    "Engineering",
+				Claim:   "department",
+				Values:  []string{"engineering", "research"},
+				Groups:  []string{"group1"},
+				Enabled: false,
+			},
+		},
+		{
+			name:           "PUT Not Existing Claim Group Mapping",
+			requestType:    http.MethodPut,
+			requestPath:    "/api/claim-group-mappings/" + notFoundClaimGroupMappingID,
+			requestBody:    bytes.NewBufferString(`{"name":"Engineering","claim":"department","values":["engineering"],"groups":["group1"],"enabled":true}`),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "DELETE Claim Group Mapping",
+			requestType:    http.MethodDelete,
+			requestPath:    "/api/claim-group-mappings/" + existingClaimGroupMappingID,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	p := initClaimGroupMappingsTestData()
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.requestType, tc.requestPath, tc.requestBody)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/claim-group-mappings/{mappingId}", p.GetClaimGroupMapping).Methods("GET")
+			router.HandleFunc("/api/claim-group-mappings", p.CreateClaimGroupMapping).Methods("POST")
+			router.HandleFunc("/api/claim-group-mappings/{mappingId}", p.DeleteClaimGroupMapping).Methods("DELETE")
+			router.HandleFunc("/api/claim-group-mappings/{mappingId}", p.UpdateClaimGroupMapping).Methods("PUT")
+			router.ServeHTTP(recorder, req)
+
+			res := recorder.Result()
+			defer res.Body.Close()
+
+			content, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("I don't know what I expected; %v", err)
+			}
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v, content: %s",
+					status, tc.expectedStatus, string(content))
+				return
+			}
+
+			if !tc.expectedBody {
+				return
+			}
+
+			got := &api.ClaimGroupMapping{}
+			if err = json.Unmarshal(content, &got); err != nil {
+				t.Fatalf("Sent content is not in correct json format; %v", err)
+			}
+			assert.Equal(t, tc.expectedMapping, got)
+		})
+	}
+}
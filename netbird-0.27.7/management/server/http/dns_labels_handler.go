@@ -0,0 +1,101 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/http/util"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// DNSLabelsHandler is the handler of the DNS labels of the peers of the account
+type DNSLabelsHandler struct {
+	accountManager  server.AccountManager
+	claimsExtractor *jwtclaims.ClaimsExtractor
+}
+
+// NewDNSLabelsHandler returns a new instance of DNSLabelsHandler handler
+func NewDNSLabelsHandler(accountManager server.AccountManager, authCfg AuthCfg) *DNSLabelsHandler {
+	return &DNSLabelsHandler{
+		accountManager: accountManager,
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithAudience(authCfg.Audience),
+			jwtclaims.WithUserIDClaim(authCfg.UserIDClaim),
+		),
+	}
+}
+
+// GetAllDNSLabels returns the DNS labels of the peers of the account
+func (h *DNSLabelsHandler) GetAllDNSLabels(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	labels, err := h.accountManager.GetPeerDNSLabels(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	dnsDomain := h.accountManager.GetDNSDomain()
+	resp := make([]api.PeerDNSLabel, 0, len(labels))
+	for _, label := range labels {
+		resp = append(resp, toPeerDNSLabelResponse(label, dnsDomain))
+	}
+
+	util.WriteJSONObject(w, resp)
+}
+
+// UpdateDNSLabel changes the DNS label of a peer
+func (h *DNSLabelsHandler) UpdateDNSLabel(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	peerID := mux.Vars(r)["peerId"]
+	if len(peerID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid peer ID"), w)
+		return
+	}
+
+	var req api.PutApiDnsLabelsPeerIdJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteJSONParseError(err, w)
+		return
+	}
+
+	peer, err := h.accountManager.UpdatePeerDNSLabel(account.Id, user.Id, peerID, req.Label)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toPeerDNSLabelResponse(server.PeerDNSLabel{PeerID: peer.ID, PeerName: peer.Name, Label: peer.DNSLabel}, h.accountManager.GetDNSDomain())
+
+	util.WriteJSONObject(w, &resp)
+}
+
+func toPeerDNSLabelResponse(label server.PeerDNSLabel, dnsDomain string) api.PeerDNSLabel {
+	fqdn := label.Label
+	if dnsDomain != "" {
+		fqdn = label.Label + "." + dnsDomain
+	}
+	return api.PeerDNSLabel{
+		PeerId:   label.PeerID,
+		PeerName: label.PeerName,
+		Label:    label.Label,
+		Fqdn:     fqdn,
+	}
+}
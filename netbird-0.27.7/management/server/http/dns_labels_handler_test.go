@@ -0,0 +1,124 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/mock_server"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func initDNSLabelsTestData() *DNSLabelsHandler {
+	return &DNSLabelsHandler{
+		accountManager: &mock_server.MockAccountManager{
+			GetPeerDNSLabelsFunc: func(accountID, userID string) ([]server.PeerDNSLabel, error) {
+				return []server.PeerDNSLabel{{PeerID: "router", PeerName: "Router", Label: "router"}}, nil
+			},
+			UpdatePeerDNSLabelFunc: func(accountID, userID, peerID, label string) (*nbpeer.Peer, error) {
+				if label == "laptop" {
+					return nil, status.Errorf(status.AlreadyExists, "DNS label %s is already used by peer laptop", label)
+				}
+				return &nbpeer.Peer{ID: peerID, Name: "Router", DNSLabel: label}, nil
+			},
+			GetDNSDomainFunc: func() string {
+				return "netbird.selfhosted"
+			},
+			GetAccountFromTokenFunc: func(claims jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error) {
+				user := server.NewAdminUser("test_user")
+				return &server.Account{
+					Id:    claims.AccountId,
+					Users: map[string]*server.User{user.Id: user},
+				}, user, nil
+			},
+		},
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+				return jwtclaims.AuthorizationClaims{
+					UserId:    "test_user",
+					Domain:    "hotmail.com",
+					AccountId: "test_account",
+				}
+			}),
+		),
+	}
+}
+
+func TestDNSLabelsHandlers(t *testing.T) {
+	tt := []struct {
+		name           string
+		requestType    string
+		requestPath    string
+		requestBody    string
+		expectedStatus int
+		expectedBody   any
+	}{
+		{
+			name:           "GetAllDNSLabels",
+			requestType:    http.MethodGet,
+			requestPath:    "/api/dns/labels",
+			expectedStatus: http.StatusOK,
+			expectedBody:   []api.PeerDNSLabel{{PeerId: "router", PeerName: "Router", Label: "router", Fqdn: "router.netbird.selfhosted"}},
+		},
+		{
+			name:           "UpdateDNSLabel",
+			requestType:    http.MethodPut,
+			requestPath:    "/api/dns/labels/router",
+			requestBody:    `{"label":"gateway"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   api.PeerDNSLabel{PeerId: "router", PeerName: "Router", Label: "gateway", Fqdn: "gateway.netbird.selfhosted"},
+		},
+		{
+			name:           "UpdateDNSLabel with a used label",
+			requestType:    http.MethodPut,
+			requestPath:    "/api/dns/labels/router",
+			requestBody:    `{"label":"laptop"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "UpdateDNSLabel with an invalid body",
+			requestType:    http.MethodPut,
+			requestPath:    "/api/dns/labels/router",
+			requestBody:    `{`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	handler := initDNSLabelsTestData()
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.requestType, tc.requestPath, bytes.NewBufferString(tc.requestBody))
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/dns/labels", handler.GetAllDNSLabels).Methods("GET")
+			router.HandleFunc("/api/dns/labels/{peerId}", handler.UpdateDNSLabel).Methods("PUT")
+			router.ServeHTTP(recorder, req)
+
+			res := recorder.Result()
+			defer res.Body.Close()
+			require.Equal(t, tc.expectedStatus, res.StatusCode)
+			if tc.expectedBody == nil {
+				return
+			}
+
+			expected, err := json.Marshal(tc.expectedBody)
+			require.NoError(t, err)
+			got := new(bytes.Buffer)
+			_, err = got.ReadFrom(res.Body)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(expected), got.String())
+		})
+	}
+}
@@ -0,0 +1,322 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/miekg/dns"
+	log "github.com/sirupsen/logrus"
+
+	nbdns "github.com/netbirdio/netbird/dns"
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/http/util"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// DNSRecordsHandler is the custom DNS records handler of the account
+type DNSRecordsHandler struct {
+	accountManager  server.AccountManager
+	claimsExtractor *jwtclaims.ClaimsExtractor
+}
+
+// NewDNSRecordsHandler returns a new instance of DNSRecordsHandler handler
+func NewDNSRecordsHandler(accountManager server.AccountManager, authCfg AuthCfg) *DNSRecordsHandler {
+	return &DNSRecordsHandler{
+		accountManager: accountManager,
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithAudience(authCfg.Audience),
+			jwtclaims.WithUserIDClaim(authCfg.UserIDClaim),
+		),
+	}
+}
+
+// GetAllDNSRecords returns the list of custom DNS records for the account
+func (h *DNSRecordsHandler) GetAllDNSRecords(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	records, err := h.accountManager.ListDNSRecords(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	dnsDomain := h.accountManager.GetDNSDomain()
+	apiRecords := make([]*api.DNSRecord, 0)
+	for _, record := range records {
+		apiRecords = append(apiRecords, toDNSRecordResponse(record, dnsDomain))
+	}
+
+	util.WriteJSONObject(w, apiRecords)
+}
+
+// CreateDNSRecord handles custom DNS record creation request
+func (h *DNSRecordsHandler) CreateDNSRecord(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	var req api.PostApiDnsRecordsJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteJSONParseError(err, w)
+		return
+	}
+
+	record, err := h.accountManager.CreateDNSRecord(account.Id, user.Id, toServerDNSRecord("", req))
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toDNSRecordResponse(record, h.accountManager.GetDNSDomain())
+
+	util.WriteJSONObject(w, &resp)
+}
+
+// UpdateDNSRecord handles update to a custom DNS record identified by a given ID
+func (h *DNSRecordsHandler) UpdateDNSRecord(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	recordID := mux.Vars(r)["recordId"]
+	if len(recordID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid DNS record ID"), w)
+		return
+	}
+
+	var req api.PutApiDnsRecordsRecordIdJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteJSONParseError(err, w)
+		return
+	}
+
+	err = h.accountManager.SaveDNSRecord(account.Id, user.Id, toServerDNSRecord(recordID, req))
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	// the account manager normalizes the record, return the stored version
+	record, err := h.accountManager.GetDNSRecord(account.Id, user.Id, recordID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toDNSRecordResponse(record, h.accountManager.GetDNSDomain())
+
+	util.WriteJSONObject(w, &resp)
+}
+
+// DeleteDNSRecord handles custom DNS record deletion request
+func (h *DNSRecordsHandler) DeleteDNSRecord(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	recordID := mux.Vars(r)["recordId"]
+	if len(recordID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid DNS record ID"), w)
+		return
+	}
+
+	err = h.accountManager.DeleteDNSRecord(account.Id, user.Id, recordID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, emptyObject{})
+}
+
+// GetDNSRecord handles a custom DNS record Get request identified by ID
+func (h *DNSRecordsHandler) GetDNSRecord(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	recordID := mux.Vars(r)["recordId"]
+	if len(recordID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid DNS record ID"), w)
+		return
+	}
+
+	record, err := h.accountManager.GetDNSRecord(account.Id, user.Id, recordID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toDNSRecordResponse(record, h.accountManager.GetDNSDomain())
+
+	util.WriteJSONObject(w, &resp)
+}
+
+// GetAllDNSZones returns the zones served to the peers with their records, for DNS servers mirroring them
+func (h *DNSRecordsHandler) GetAllDNSZones(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	serial := uint32(account.Network.CurrentSerial())
+	if dnsZonesNotModified(w, r, serial) {
+		return
+	}
+
+	zones, err := h.accountManager.ExportDNSZones(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	apiZones := make([]api.DNSZone, 0, len(zones))
+	for _, zone := range zones {
+		apiZones = append(apiZones, toDNSZoneResponse(zone, serial))
+	}
+
+	util.WriteJSONObject(w, apiZones)
+}
+
+// ExportDNSZones handles the export of the zones served to the peers in the zone file format, for tools
+// fetching the peer names like an AXFR would. The zone query parameter exports a single zone
+func (h *DNSRecordsHandler) ExportDNSZones(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	serial := uint32(account.Network.CurrentSerial())
+	if dnsZonesNotModified(w, r, serial) {
+		return
+	}
+
+	zones, err := h.accountManager.ExportDNSZones(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	if zoneName := r.URL.Query().Get("zone"); zoneName != "" {
+		zoneName = dns.Fqdn(strings.ToLower(zoneName))
+		i := slices.IndexFunc(zones, func(zone nbdns.CustomZone) bool {
+			return strings.ToLower(zone.Domain) == zoneName
+		})
+		if i < 0 {
+			util.WriteError(status.Errorf(status.NotFound, "DNS zone %s not found", zoneName), w)
+			return
+		}
+		zones = zones[i : i+1]
+	}
+
+	var buf bytes.Buffer
+	for _, zone := range zones {
+		if err := nbdns.WriteZoneFile(&buf, zone, serial); err != nil {
+			util.WriteError(err, w)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Errorf("failed to write the DNS zones export: %v", err)
+	}
+}
+
+// dnsZonesNotModified sets the ETag of the zones, the serial of the account network, and answers with the
+// not modified status when the request has it already
+func dnsZonesNotModified(w http.ResponseWriter, r *http.Request, serial uint32) bool {
+	etag := `"` + strconv.FormatUint(uint64(serial), 10) + `"`
+	w.Header().Set("ETag", etag)
+
+	for _, match := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		if strings.TrimPrefix(strings.TrimSpace(match), "W/") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+func toDNSZoneResponse(zone nbdns.CustomZone, serial uint32) api.DNSZone {
+	records := make([]api.DNSZoneRecord, 0, len(zone.Records))
+	for _, record := range zone.Records {
+		records = append(records, api.DNSZoneRecord{
+			Name:  dns.Fqdn(record.Name),
+			Type:  dns.Type(record.Type).String(),
+			Ttl:   record.TTL,
+			Value: record.RData,
+		})
+	}
+	return api.DNSZone{
+		Domain:  dns.Fqdn(zone.Domain),
+		Serial:  int(serial),
+		Records: records,
+	}
+}
+
+func toServerDNSRecord(recordID string, req api.DNSRecordRequest) *nbdns.CustomRecord {
+	record := &nbdns.CustomRecord{
+		ID:      recordID,
+		Name:    req.Name,
+		Type:    string(req.Type),
+		Value:   req.Value,
+		Enabled: req.Enabled,
+	}
+
+	if req.Zone != nil {
+		record.Zone = *req.Zone
+	}
+
+	if req.Ttl != nil {
+		record.TTL = *req.Ttl
+	}
+
+	return record
+}
+
+func toDNSRecordResponse(record *nbdns.CustomRecord, dnsDomain string) *api.DNSRecord {
+	return &api.DNSRecord{
+		Id:      record.ID,
+		Name:    record.Name,
+		Zone:    record.Zone,
+		Fqdn:    record.FQDN(dnsDomain),
+		Type:    api.DNSRecordType(record.Type),
+		Value:   record.Value,
+		Ttl:     record.TTL,
+		Enabled: record.Enabled,
+	}
+}
@@ -0,0 +1,326 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	nbdns "github.com/netbirdio/netbird/dns"
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/mock_server"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	existingDNSRecordID = "existingDNSRecordID"
+	notFoundDNSRecordID = "notFoundDNSRecordID"
+)
+
+var baseExistingDNSRecord = &nbdns.CustomRecord{
+	ID:      existingDNSRecordID,
+	Name:    "nas",
+	Zone:    "home.arpa",
+	Type:    nbdns.RecordTypeA,
+	Value:   "192.168.1.10",
+	TTL:     300,
+	Enabled: true,
+}
+
+func initDNSRecordsTestData() *DNSRecordsHandler {
+	return &DNSRecordsHandler{
+		accountManager: &mock_server.MockAccountManager{
+			GetDNSRecordFunc: func(_, _, recordID string) (*nbdns.CustomRecord, error) {
+				if recordID == existingDNSRecordID {
+					return baseExistingDNSRecord.Copy(), nil
+				}
+				return nil, status.Errorf(status.NotFound, "DNS record with ID %s not found", recordID)
+			},
+			CreateDNSRecordFunc: func(_, _ string, record *nbdns.CustomRecord) (*nbdns.CustomRecord, error) {
+				if record.Type != nbdns.RecordTypeA {
+					return nil, status.Errorf(status.InvalidArgument, "unsupported DNS record type")
+				}
+				created := record.Copy()
+				created.ID = existingDNSRecordID
+				return created, nil
+			},
+			SaveDNSRecordFunc: func(_, _ string, record *nbdns.CustomRecord) error {
+				if record.ID == existingDNSRecordID {
+					return nil
+				}
+				return status.Errorf(status.NotFound, "DNS record with ID %s was not found", record.ID)
+			},
+			DeleteDNSRecordFunc: func(_, _, recordID string) error {
+				return nil
+			},
+			GetDNSDomainFunc: func() string {
+				return "netbird.selfhosted"
+			},
+			GetAccountFromTokenFunc: func(_ jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error) {
+				return testingNSAccount, testingAccount.Users["test_user"], nil
+			},
+		},
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+				return jwtclaims.AuthorizationClaims{
+					UserId:    "test_user",
+					Domain:    "hotmail.com",
+					AccountId: testNSGroupAccountID,
+				}
+			}),
+		),
+	}
+}
+
+func TestDNSRecordsHandlers(t *testing.T) {
+	tt := []struct {
+		name           string
+		expectedStatus int
+		expectedBody   bool
+		expectedRecord *api.DNSRecord
+		requestType    string
+		requestPath    string
+		requestBody    io.Reader
+	}{
+		{
+			name:           "Get Existing DNS Record",
+			requestType:    http.MethodGet,
+			requestPath:    "/api/dns/records/" + existingDNSRecordID,
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedRecord: &api.DNSRecord{
+				Id:      existingDNSRecordID,
+				Name:    "nas",
+				Zone:    "home.arpa",
+				Fqdn:    "nas.home.arpa.",
+				Type:    api.DNSRecordTypeA,
+				Value:   "192.168.1.10",
+				Ttl:     300,
+				Enabled: true,
+			},
+		},
+		{
+			name:           "Get Not Existing DNS Record",
+			requestType:    http.MethodGet,
+			requestPath:    "/api/dns/records/" + notFoundDNSRecordID,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "POST OK",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/dns/records",
+			requestBody:    bytes.NewBufferString(`{"name":"printer","type":"A","value":"192.168.1.20","ttl":60,"enabled":true}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedRecord: &api.DNSRecord{
+				Id:      existingDNSRecordID,
+				Name:    "printer",
+				Fqdn:    "printer.netbird.selfhosted.",
+				Type:    api.DNSRecordTypeA,
+				Value:   "192.168.1.20",
+				Ttl:     60,
+				Enabled: true,
+			},
+		},
+		{
+			name:           "POST Invalid Type",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/dns/records",
+			requestBody:    bytes.NewBufferString(`{"name":"mail","type":"MX","value":"mail.example.com","enabled":true}`),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "PUT OK",
+			requestType:    http.MethodPut,
+			requestPath:    "/api/dns/records/" + existingDNSRecordID,
+			requestBody:    bytes.NewBufferString(`{"name":"nas","zone":"home.arpa","type":"A","value":"192.168.1.10","enabled":true}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedRecord: toDNSRecordResponse(baseExistingDNSRecord, "netbird.selfhosted"),
+		},
+		{
+			name:           "PUT Not Existing DNS Record",
+			requestType:    http.MethodPut,
+			requestPath:    "/api/dns/records/" + notFoundDNSRecordID,
+			requestBody:    bytes.NewBufferString(`{"name":"nas","type":"A","value":"192.168.1.10","enabled":true}`),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "DELETE DNS Record",
+			requestType:    http.MethodDelete,
+			requestPath:    "/api/dns/records/" + existingDNSRecordID,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	p := initDNSRecordsTestData()
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.requestType, tc.requestPath, tc.requestBody)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/dns/records/{recordId}", p.GetDNSRecord).Methods("GET")
+			router.HandleFunc("/api/dns/records", p.CreateDNSRecord).Methods("POST")
+			router.HandleFunc("/api/dns/records/{recordId}", p.DeleteDNSRecord).Methods("DELETE")
+			router.HandleFunc("/api/dns/records/{recordId}", p.UpdateDNSRecord).Methods("PUT")
+			router.ServeHTTP(recorder, req)
+
+			res := recorder.Result()
+			defer res.Body.Close()
+
+			content, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("I don't know what I expected; %v", err)
+			}
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v, content: %s",
+					status, tc.expectedStatus, string(content))
+				return
+			}
+
+			if !tc.expectedBody {
+				return
+			}
+
+			got := &api.DNSRecord{}
+			if err = json.Unmarshal(content, &got); err != nil {
+				t.Fatalf("Sent content is not in correct json format; %v", err)
+			}
+			assert.Equal(t, tc.expectedRecord, got)
+		})
+	}
+}
+
+func TestExportDNSZones(t *testing.T) {
+	p := initDNSRecordsTestData()
+	accountManager := p.accountManager.(*mock_server.MockAccountManager)
+	accountManager.GetAccountFromTokenFunc = func(_ jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error) {
+		account := &server.Account{
+			Id:      testNSGroupAccountID,
+			Network: &server.Network{Serial: 7},
+		}
+		return account, testingAccount.Users["test_user"], nil
+	}
+	accountManager.ExportDNSZonesFunc = func(_, _ string) ([]nbdns.CustomZone, error) {
+		return []nbdns.CustomZone{
+			{
+				Domain: "64.100.in-addr.arpa.",
+				Records: []nbdns.SimpleRecord{
+					{Name: "1.0.64.100.in-addr.arpa.", Type: 12, Class: nbdns.DefaultClass, TTL: 300, RData: "peer.netbird.selfhosted."},
+				},
+			},
+		}, nil
+	}
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/dns/zones/export", nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/dns/zones/export", p.ExportDNSZones).Methods("GET")
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "$ORIGIN 64.100.in-addr.arpa.\n"+
+		"64.100.in-addr.arpa.\t300\tIN\tSOA\t64.100.in-addr.arpa. hostmaster.64.100.in-addr.arpa. 7 3600 600 86400 300\n"+
+		"1.0.64.100.in-addr.arpa. 300 IN PTR peer.netbird.selfhosted.\n", recorder.Body.String())
+}
+
+func initDNSZonesTestData() *DNSRecordsHandler {
+	p := initDNSRecordsTestData()
+	accountManager := p.accountManager.(*mock_server.MockAccountManager)
+	accountManager.GetAccountFromTokenFunc = func(_ jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error) {
+		account := &server.Account{
+			Id:      testNSGroupAccountID,
+			Network: &server.Network{Serial: 7},
+		}
+		return account, testingAccount.Users["test_user"], nil
+	}
+	accountManager.ExportDNSZonesFunc = func(_, _ string) ([]nbdns.CustomZone, error) {
+		return []nbdns.CustomZone{
+			{
+				Domain: "netbird.selfhosted.",
+				Records: []nbdns.SimpleRecord{
+					{Name: "peer.netbird.selfhosted.", Type: 1, Class: nbdns.DefaultClass, TTL: 300, RData: "100.64.0.1"},
+					{Name: "peer.netbird.selfhosted.", Type: 16, Class: nbdns.DefaultClass, TTL: 60, RData: `"v=spf1 -all"`},
+				},
+			},
+			{
+				Domain: "64.100.in-addr.arpa.",
+				Records: []nbdns.SimpleRecord{
+					{Name: "1.0.64.100.in-addr.arpa.", Type: 12, Class: nbdns.DefaultClass, TTL: 300, RData: "peer.netbird.selfhosted."},
+				},
+			},
+		}, nil
+	}
+	return p
+}
+
+func TestGetAllDNSZones(t *testing.T) {
+	p := initDNSZonesTestData()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/dns/zones", p.GetAllDNSZones).Methods("GET")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/dns/zones", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `"7"`, recorder.Header().Get("ETag"))
+
+	var zones []api.DNSZone
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &zones))
+	require.Len(t, zones, 2)
+	assert.Equal(t, api.DNSZone{
+		Domain: "netbird.selfhosted.",
+		Serial: 7,
+		Records: []api.DNSZoneRecord{
+			{Name: "peer.netbird.selfhosted.", Type: "A", Ttl: 300, Value: "100.64.0.1"},
+			{Name: "peer.netbird.selfhosted.", Type: "TXT", Ttl: 60, Value: `"v=spf1 -all"`},
+		},
+	}, zones[0])
+
+	req := httptest.NewRequest(http.MethodGet, "/api/dns/zones", nil)
+	req.Header.Set("If-None-Match", `W/"7"`)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotModified, recorder.Code, "the unchanged zones shouldn't be returned again")
+	assert.Empty(t, recorder.Body.String())
+}
+
+func TestExportDNSZone(t *testing.T) {
+	p := initDNSZonesTestData()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/dns/zones/export", p.ExportDNSZones).Methods("GET")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/dns/zones/export?zone=64.100.IN-ADDR.arpa", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "$ORIGIN 64.100.in-addr.arpa.\n"+
+		"64.100.in-addr.arpa.\t300\tIN\tSOA\t64.100.in-addr.arpa. hostmaster.64.100.in-addr.arpa. 7 3600 600 86400 300\n"+
+		"1.0.64.100.in-addr.arpa. 300 IN PTR peer.netbird.selfhosted.\n", recorder.Body.String(),
+		"only the requested zone should be exported")
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/dns/zones/export?zone=example.com", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/dns/zones/export", nil)
+	req.Header.Set("If-None-Match", `"6"`)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code, "the zones should be returned when the serial changed")
+	assert.Equal(t, 2, strings.Count(recorder.Body.String(), "$ORIGIN"))
+}
@@ -3,9 +3,11 @@ package http
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	nbdns "github.com/netbirdio/netbird/dns"
 	"github.com/netbirdio/netbird/management/server"
 	"github.com/netbirdio/netbird/management/server/http/api"
 	"github.com/netbirdio/netbird/management/server/http/util"
@@ -45,11 +47,7 @@ func (h *DNSSettingsHandler) GetDNSSettings(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	apiDNSSettings := &api.DNSSettings{
-		DisabledManagementGroups: dnsSettings.DisabledManagementGroups,
-	}
-
-	util.WriteJSONObject(w, apiDNSSettings)
+	util.WriteJSONObject(w, toDNSSettingsResponse(dnsSettings))
 }
 
 // UpdateDNSSettings handles update to DNS settings of an account
@@ -64,23 +62,67 @@ func (h *DNSSettingsHandler) UpdateDNSSettings(w http.ResponseWriter, r *http.Re
 	var req api.PutApiDnsSettingsJSONRequestBody
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		util.WriteJSONParseError(err, w)
 		return
 	}
 
-	updateDNSSettings := &server.DNSSettings{
-		DisabledManagementGroups: req.DisabledManagementGroups,
+	// the blocklist settings omitted from the request keep their current values
+	updateDNSSettings := account.DNSSettings.Copy()
+	updateDNSSettings.DisabledManagementGroups = req.DisabledManagementGroups
+	if req.Blocklists != nil {
+		updateDNSSettings.Blocklists = make([]nbdns.Blocklist, 0, len(*req.Blocklists))
+		for _, blocklist := range *req.Blocklists {
+			updateDNSSettings.Blocklists = append(updateDNSSettings.Blocklists, nbdns.Blocklist{
+				Name:    blocklist.Name,
+				URL:     blocklist.Url,
+				Enabled: blocklist.Enabled,
+			})
+		}
+	}
+	if req.BlocklistResponse != nil {
+		updateDNSSettings.BlocklistResponse = nbdns.BlockResponse(*req.BlocklistResponse)
+	}
+	if req.BlocklistRefreshInterval != nil {
+		updateDNSSettings.BlocklistRefreshInterval = time.Duration(*req.BlocklistRefreshInterval) * time.Second
+	}
+	if req.BlocklistOverrideGroups != nil {
+		updateDNSSettings.BlocklistOverrideGroups = *req.BlocklistOverrideGroups
 	}
 
-	err = h.accountManager.SaveDNSSettings(account.Id, user.Id, updateDNSSettings)
+	err = h.accountManager.SaveDNSSettings(account.Id, user.Id, &updateDNSSettings)
 	if err != nil {
 		util.WriteError(err, w)
 		return
 	}
 
-	resp := api.DNSSettings{
-		DisabledManagementGroups: updateDNSSettings.DisabledManagementGroups,
+	util.WriteJSONObject(w, toDNSSettingsResponse(&updateDNSSettings))
+}
+
+func toDNSSettingsResponse(settings *server.DNSSettings) *api.DNSSettings {
+	blocklists := make([]api.DNSBlocklist, 0, len(settings.Blocklists))
+	for _, blocklist := range settings.Blocklists {
+		blocklists = append(blocklists, api.DNSBlocklist{
+			Name:    blocklist.Name,
+			Url:     blocklist.URL,
+			Enabled: blocklist.Enabled,
+		})
 	}
 
-	util.WriteJSONObject(w, &resp)
+	response := api.DNSSettingsBlocklistResponse(nbdns.BlockResponseNXDomain)
+	if settings.BlocklistResponse != "" {
+		response = api.DNSSettingsBlocklistResponse(settings.BlocklistResponse)
+	}
+	refreshInterval := int(settings.BlocklistRefreshInterval.Seconds())
+	overrideGroups := settings.BlocklistOverrideGroups
+	if overrideGroups == nil {
+		overrideGroups = []string{}
+	}
+
+	return &api.DNSSettings{
+		DisabledManagementGroups: settings.DisabledManagementGroups,
+		Blocklists:               &blocklists,
+		BlocklistResponse:        &response,
+		BlocklistRefreshInterval: &refreshInterval,
+		BlocklistOverrideGroups:  &overrideGroups,
+	}
 }
@@ -10,6 +10,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	nbdns "github.com/netbirdio/netbird/dns"
 	"github.com/netbirdio/netbird/management/server/http/api"
 	"github.com/netbirdio/netbird/management/server/status"
 
@@ -28,6 +29,19 @@ const (
 
 var baseExistingDNSSettings = server.DNSSettings{
 	DisabledManagementGroups: []string{testDNSSettingsExistingGroup},
+	Blocklists:               []nbdns.Blocklist{{Name: "ads", URL: "https://example.com/hosts", Enabled: true}},
+}
+
+var baseExistingAPIBlocklists = []api.DNSBlocklist{{Name: "ads", Url: "https://example.com/hosts", Enabled: true}}
+
+func testDNSSettingsResponse(disabledManagementGroups []string, blocklists []api.DNSBlocklist, response api.DNSSettingsBlocklistResponse, refreshInterval int, overrideGroups []string) *api.DNSSettings {
+	return &api.DNSSettings{
+		DisabledManagementGroups: disabledManagementGroups,
+		Blocklists:               &blocklists,
+		BlocklistResponse:        &response,
+		BlocklistRefreshInterval: &refreshInterval,
+		BlocklistOverrideGroups:  &overrideGroups,
+	}
 }
 
 var testingDNSSettingsAccount = &server.Account{
@@ -83,9 +97,8 @@ func TestDNSSettingsHandlers(t *testing.T) {
 			requestPath:    "/api/dns/settings",
 			expectedStatus: http.StatusOK,
 			expectedBody:   true,
-			expectedDNSSettings: &api.DNSSettings{
-				DisabledManagementGroups: baseExistingDNSSettings.DisabledManagementGroups,
-			},
+			expectedDNSSettings: testDNSSettingsResponse(baseExistingDNSSettings.DisabledManagementGroups,
+				baseExistingAPIBlocklists, api.DNSSettingsBlocklistResponseNxdomain, 0, []string{}),
 		},
 		{
 			name:        "Update DNS Settings",
@@ -95,9 +108,21 @@ func TestDNSSettingsHandlers(t *testing.T) {
 				[]byte("{\"disabled_management_groups\":[\"group1\",\"group2\"]}")),
 			expectedStatus: http.StatusOK,
 			expectedBody:   true,
-			expectedDNSSettings: &api.DNSSettings{
-				DisabledManagementGroups: []string{"group1", "group2"},
-			},
+			expectedDNSSettings: testDNSSettingsResponse([]string{"group1", "group2"},
+				baseExistingAPIBlocklists, api.DNSSettingsBlocklistResponseNxdomain, 0, []string{}),
+		},
+		{
+			name:        "Update DNS Blocklist Settings",
+			requestType: http.MethodPut,
+			requestPath: "/api/dns/settings",
+			requestBody: bytes.NewBuffer(
+				[]byte("{\"disabled_management_groups\":[],\"blocklists\":[{\"name\":\"malware\",\"url\":\"https://example.org/hosts\",\"enabled\":false}]," +
+					"\"blocklist_response\":\"zero_ip\",\"blocklist_refresh_interval\":3600,\"blocklist_override_groups\":[\"group1\"]}")),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedDNSSettings: testDNSSettingsResponse([]string{},
+				[]api.DNSBlocklist{{Name: "malware", Url: "https://example.org/hosts", Enabled: false}},
+				api.DNSSettingsBlocklistResponseZeroIp, 3600, []string{"group1"}),
 		},
 		{
 			name:        "Update DNS Settings Empty Body",
@@ -105,9 +130,10 @@ func TestDNSSettingsHandlers(t *testing.T) {
 			requestPath: "/api/dns/settings",
 			requestBody: bytes.NewBuffer(
 				[]byte("{}")),
-			expectedStatus:      http.StatusOK,
-			expectedBody:        true,
-			expectedDNSSettings: &api.DNSSettings{},
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedDNSSettings: testDNSSettingsResponse(nil,
+				baseExistingAPIBlocklists, api.DNSSettingsBlocklistResponseNxdomain, 0, []string{}),
 		},
 	}
 
@@ -0,0 +1,259 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/http/util"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// ExternalListsHandler is the handler of the external lists, the networks downloaded from URLs the policy rules drop
+// the traffic to
+type ExternalListsHandler struct {
+	accountManager  server.AccountManager
+	claimsExtractor *jwtclaims.ClaimsExtractor
+}
+
+// NewExternalListsHandler returns a new instance of ExternalListsHandler handler
+func NewExternalListsHandler(accountManager server.AccountManager, authCfg AuthCfg) *ExternalListsHandler {
+	return &ExternalListsHandler{
+		accountManager: accountManager,
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithAudience(authCfg.Audience),
+			jwtclaims.WithUserIDClaim(authCfg.UserIDClaim),
+		),
+	}
+}
+
+// GetAllExternalLists returns the list of external lists for the account
+func (h *ExternalListsHandler) GetAllExternalLists(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	lists, err := h.accountManager.ListExternalLists(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	now := time.Now().UTC()
+	apiLists := make([]*api.ExternalList, 0, len(lists))
+	for _, list := range lists {
+		apiLists = append(apiLists, toExternalListResponse(list, now))
+	}
+
+	util.WriteJSONObject(w, apiLists)
+}
+
+// CreateExternalList handles external list creation request
+func (h *ExternalListsHandler) CreateExternalList(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	var req api.PostApiExternalListsJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteJSONParseError(err, w)
+		return
+	}
+
+	list := toServerExternalList("", req)
+	if req.Authorization != nil {
+		list.Authorization = *req.Authorization
+	}
+
+	list, err = h.accountManager.CreateExternalList(account.Id, user.Id, list)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toExternalListResponse(list, time.Now().UTC()))
+}
+
+// UpdateExternalList handles update to an external list identified by a given ID, the authorization omitted from the
+// request is kept
+func (h *ExternalListsHandler) UpdateExternalList(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	listID := mux.Vars(r)["listId"]
+	if len(listID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid external list ID"), w)
+		return
+	}
+
+	var req api.PutApiExternalListsListIdJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteJSONParseError(err, w)
+		return
+	}
+
+	existing, err := h.accountManager.GetExternalList(account.Id, user.Id, listID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	list := toServerExternalList(listID, req)
+	list.Authorization = existing.Authorization
+	if req.Authorization != nil {
+		list.Authorization = *req.Authorization
+	}
+
+	err = h.accountManager.SaveExternalList(account.Id, user.Id, list)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	// the saved list has the default refresh interval and maximum number of entries, and its refresh status
+	list, err = h.accountManager.GetExternalList(account.Id, user.Id, listID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toExternalListResponse(list, time.Now().UTC()))
+}
+
+// DeleteExternalList handles external list deletion request
+func (h *ExternalListsHandler) DeleteExternalList(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	listID := mux.Vars(r)["listId"]
+	if len(listID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid external list ID"), w)
+		return
+	}
+
+	err = h.accountManager.DeleteExternalList(account.Id, user.Id, listID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, emptyObject{})
+}
+
+// GetExternalList handles an external list Get request identified by ID
+func (h *ExternalListsHandler) GetExternalList(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	listID := mux.Vars(r)["listId"]
+	if len(listID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid external list ID"), w)
+		return
+	}
+
+	list, err := h.accountManager.GetExternalList(account.Id, user.Id, listID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toExternalListResponse(list, time.Now().UTC()))
+}
+
+// RefreshExternalList handles the request to download an external list identified by ID now
+func (h *ExternalListsHandler) RefreshExternalList(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	listID := mux.Vars(r)["listId"]
+	if len(listID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid external list ID"), w)
+		return
+	}
+
+	list, err := h.accountManager.RefreshExternalList(account.Id, user.Id, listID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toExternalListResponse(list, time.Now().UTC()))
+}
+
+func toServerExternalList(listID string, req api.ExternalListRequest) *server.ExternalList {
+	list := &server.ExternalList{
+		ID:          listID,
+		Name:        req.Name,
+		Description: req.Description,
+		URL:         req.Url,
+		Format:      string(req.Format),
+		Enabled:     req.Enabled,
+	}
+	if req.RefreshInterval != nil {
+		list.RefreshInterval = time.Duration(*req.RefreshInterval) * time.Second
+	}
+	if req.MaxEntries != nil {
+		list.MaxEntries = *req.MaxEntries
+	}
+	return list
+}
+
+func toExternalListResponse(list *server.ExternalList, now time.Time) *api.ExternalList {
+	refreshInterval := int(list.RefreshInterval.Seconds())
+	maxEntries := list.MaxEntries
+	resp := &api.ExternalList{
+		Id:               list.ID,
+		Name:             list.Name,
+		Description:      list.Description,
+		Url:              list.URL,
+		Format:           api.ExternalListFormat(list.Format),
+		AuthorizationSet: list.Authorization != "",
+		RefreshInterval:  &refreshInterval,
+		MaxEntries:       &maxEntries,
+		Enabled:          list.Enabled,
+		Entries:          len(list.Networks),
+		LastError:        list.LastError,
+		Stale:            list.Stale(now),
+	}
+	if !list.LastRefreshedAt.IsZero() {
+		lastRefreshedAt := list.LastRefreshedAt
+		resp.LastRefreshedAt = &lastRefreshedAt
+	}
+	if !list.LastAttemptAt.IsZero() {
+		lastAttemptAt := list.LastAttemptAt
+		resp.LastAttemptAt = &lastAttemptAt
+	}
+	return resp
+}
@@ -0,0 +1,310 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/mock_server"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	existingExternalListID = "existingExternalListID"
+	notFoundExternalListID = "notFoundExternalListID"
+	createdExternalListID  = "createdExternalListID"
+)
+
+var externalListRefreshedAt = time.Date(2024, 6, 20, 10, 0, 0, 0, time.UTC)
+
+// newExternalListsTestAccount returns the test account with a MISP feed authenticated with an API key
+func newExternalListsTestAccount() *server.Account {
+	return newTestAccountBuilder().withExternalList(&server.ExternalList{
+		ID:              existingExternalListID,
+		Name:            "feed",
+		URL:             "https://misp.example.com/attributes/restSearch",
+		Format:          server.ExternalListFormatMISP,
+		Authorization:   "misp-key",
+		RefreshInterval: time.Hour,
+		MaxEntries:      server.DefaultExternalListMaxEntries,
+		Enabled:         true,
+		Networks:        []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+		LastRefreshedAt: externalListRefreshedAt,
+		LastAttemptAt:   externalListRefreshedAt,
+	}).build()
+}
+
+// newExternalListsAccountManager returns the fake account manager serving the external lists of the account to the
+// users with admin power, like the real one
+func newExternalListsAccountManager(account *server.Account) *mock_server.MockAccountManager {
+	checkAdmin := func(userID string) error {
+		if user, ok := account.Users[userID]; !ok || !user.HasAdminPower() {
+			return status.Errorf(status.PermissionDenied, "only users with admin power can view external lists")
+		}
+		return nil
+	}
+	getList := func(userID, listID string) (*server.ExternalList, error) {
+		if err := checkAdmin(userID); err != nil {
+			return nil, err
+		}
+		list, ok := account.ExternalLists[listID]
+		if !ok {
+			return nil, status.Errorf(status.NotFound, "external list with ID %s not found", listID)
+		}
+		return list.Copy(), nil
+	}
+
+	accountManager := newFakeAccountManager(account)
+	accountManager.GetExternalListFunc = func(_, userID, listID string) (*server.ExternalList, error) {
+		return getList(userID, listID)
+	}
+	accountManager.ListExternalListsFunc = func(_, userID string) ([]*server.ExternalList, error) {
+		if err := checkAdmin(userID); err != nil {
+			return nil, err
+		}
+		return sortedValues(account.ExternalLists), nil
+	}
+	accountManager.CreateExternalListFunc = func(_, userID string, list *server.ExternalList) (*server.ExternalList, error) {
+		if err := checkAdmin(userID); err != nil {
+			return nil, err
+		}
+		if list.Format != server.ExternalListFormatCIDR && list.Format != server.ExternalListFormatMISP {
+			return nil, status.Errorf(status.InvalidArgument, "unknown external list format %s", list.Format)
+		}
+		created := list.Copy()
+		created.ID = createdExternalListID
+		created.RefreshInterval = server.DefaultExternalListRefreshInterval
+		created.MaxEntries = server.DefaultExternalListMaxEntries
+		return created, nil
+	}
+	accountManager.SaveExternalListFunc = func(_, userID string, list *server.ExternalList) error {
+		if _, err := getList(userID, list.ID); err != nil {
+			return err
+		}
+		account.ExternalLists[list.ID] = list.Copy()
+		return nil
+	}
+	accountManager.DeleteExternalListFunc = func(_, userID, listID string) error {
+		if _, err := getList(userID, listID); err != nil {
+			return err
+		}
+		delete(account.ExternalLists, listID)
+		return nil
+	}
+	accountManager.RefreshExternalListFunc = func(_, userID, listID string) (*server.ExternalList, error) {
+		list, err := getList(userID, listID)
+		if err != nil {
+			return nil, err
+		}
+		list.LastAttemptAt = externalListRefreshedAt.Add(time.Hour)
+		list.LastError = "unexpected status 403 Forbidden"
+		return list, nil
+	}
+	return accountManager
+}
+
+func TestExternalListsHandlers(t *testing.T) {
+	hour, maxEntries := 3600, server.DefaultExternalListMaxEntries
+	refreshedAt, attemptAt := externalListRefreshedAt, externalListRefreshedAt.Add(time.Hour)
+	existing := api.ExternalList{
+		Id:               existingExternalListID,
+		Name:             "feed",
+		Url:              "https://misp.example.com/attributes/restSearch",
+		Format:           api.ExternalListFormatMisp,
+		AuthorizationSet: true,
+		RefreshInterval:  &hour,
+		MaxEntries:       &maxEntries,
+		Enabled:          true,
+		Entries:          1,
+		LastRefreshedAt:  &refreshedAt,
+		LastAttemptAt:    &refreshedAt,
+		Stale:            true,
+	}
+	refreshed := existing
+	refreshed.LastAttemptAt = &attemptAt
+	refreshed.LastError = "unexpected status 403 Forbidden"
+
+	expectList := func(expected api.ExternalList) func(t *testing.T, recorder *httptest.ResponseRecorder) {
+		return func(t *testing.T, recorder *httptest.ResponseRecorder) {
+			assert.Equal(t, expected, decodeTestResponse[api.ExternalList](t, recorder))
+			assert.NotContains(t, recorder.Body.String(), "misp-key", "the authorization should never be returned")
+		}
+	}
+
+	tests := []struct {
+		route      string
+		newHandler func(handler *ExternalListsHandler) http.HandlerFunc
+		tests      []handlerTest
+	}{
+		{
+			route:      "/api/external-lists",
+			newHandler: func(handler *ExternalListsHandler) http.HandlerFunc { return handler.GetAllExternalLists },
+			tests: []handlerTest{
+				{
+					name:           "list the external lists",
+					method:         http.MethodGet,
+					path:           "/api/external-lists",
+					expectedStatus: http.StatusOK,
+					check: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+						assert.Equal(t, []api.ExternalList{existing}, decodeTestResponse[[]api.ExternalList](t, recorder))
+						assert.NotContains(t, recorder.Body.String(), "misp-key", "the authorization should never be returned")
+					},
+				},
+				{
+					name:           "list the external lists as a regular user",
+					userID:         fakeRegularUserID,
+					method:         http.MethodGet,
+					path:           "/api/external-lists",
+					expectedStatus: http.StatusForbidden,
+				},
+			},
+		},
+		{
+			route:      "/api/external-lists/{listId}",
+			newHandler: func(handler *ExternalListsHandler) http.HandlerFunc { return handler.GetExternalList },
+			tests: []handlerTest{
+				{
+					name:           "get an existing external list",
+					method:         http.MethodGet,
+					path:           "/api/external-lists/" + existingExternalListID,
+					expectedStatus: http.StatusOK,
+					check:          expectList(existing),
+				},
+				{
+					name:           "get a missing external list",
+					method:         http.MethodGet,
+					path:           "/api/external-lists/" + notFoundExternalListID,
+					expectedStatus: http.StatusNotFound,
+				},
+				{
+					name:           "get an external list as a regular user",
+					userID:         fakeRegularUserID,
+					method:         http.MethodGet,
+					path:           "/api/external-lists/" + existingExternalListID,
+					expectedStatus: http.StatusForbidden,
+				},
+			},
+		},
+		{
+			route:      "/api/external-lists",
+			newHandler: func(handler *ExternalListsHandler) http.HandlerFunc { return handler.CreateExternalList },
+			tests: []handlerTest{
+				{
+					name:           "create an external list",
+					method:         http.MethodPost,
+					path:           "/api/external-lists",
+					body:           `{"name":"drop","description":"","url":"https://example.com/drop.txt","format":"cidr","enabled":true}`,
+					expectedStatus: http.StatusOK,
+					check: expectList(api.ExternalList{
+						Id:              createdExternalListID,
+						Name:            "drop",
+						Url:             "https://example.com/drop.txt",
+						Format:          api.ExternalListFormatCidr,
+						RefreshInterval: &hour,
+						MaxEntries:      &maxEntries,
+						Enabled:         true,
+					}),
+				},
+				{
+					name:           "create an external list of an unknown format",
+					method:         http.MethodPost,
+					path:           "/api/external-lists",
+					body:           `{"name":"drop","url":"https://example.com/drop.txt","format":"stix","enabled":true}`,
+					expectedStatus: http.StatusUnprocessableEntity,
+				},
+			},
+		},
+		{
+			route:      "/api/external-lists/{listId}",
+			newHandler: func(handler *ExternalListsHandler) http.HandlerFunc { return handler.UpdateExternalList },
+			tests: []handlerTest{
+				{
+					name:           "update a missing external list",
+					method:         http.MethodPut,
+					path:           "/api/external-lists/" + notFoundExternalListID,
+					body:           `{"name":"drop","url":"https://example.com/drop.txt","format":"cidr","enabled":true}`,
+					expectedStatus: http.StatusNotFound,
+				},
+			},
+		},
+		{
+			route:      "/api/external-lists/{listId}/refresh",
+			newHandler: func(handler *ExternalListsHandler) http.HandlerFunc { return handler.RefreshExternalList },
+			tests: []handlerTest{
+				{
+					name:           "refresh an external list",
+					method:         http.MethodPost,
+					path:           "/api/external-lists/" + existingExternalListID + "/refresh",
+					expectedStatus: http.StatusOK,
+					check:          expectList(refreshed),
+				},
+			},
+		},
+		{
+			route:      "/api/external-lists/{listId}",
+			newHandler: func(handler *ExternalListsHandler) http.HandlerFunc { return handler.DeleteExternalList },
+			tests: []handlerTest{
+				{
+					name:           "delete an external list as a regular user",
+					userID:         fakeRegularUserID,
+					method:         http.MethodDelete,
+					path:           "/api/external-lists/" + existingExternalListID,
+					expectedStatus: http.StatusForbidden,
+				},
+				{
+					name:           "delete an external list",
+					method:         http.MethodDelete,
+					path:           "/api/external-lists/" + existingExternalListID,
+					expectedStatus: http.StatusOK,
+				},
+			},
+		},
+	}
+
+	for _, route := range tests {
+		accountManager := newExternalListsAccountManager(newExternalListsTestAccount())
+		runHandlerTests(t, route.route, func(claimsExtractor *jwtclaims.ClaimsExtractor) http.HandlerFunc {
+			return route.newHandler(&ExternalListsHandler{accountManager: accountManager, claimsExtractor: claimsExtractor})
+		}, route.tests)
+	}
+}
+
+func TestExternalListsHandler_UpdateKeepsAuthorization(t *testing.T) {
+	account := newExternalListsTestAccount()
+	accountManager := newExternalListsAccountManager(account)
+
+	runHandlerTests(t, "/api/external-lists/{listId}", func(claimsExtractor *jwtclaims.ClaimsExtractor) http.HandlerFunc {
+		handler := &ExternalListsHandler{accountManager: accountManager, claimsExtractor: claimsExtractor}
+		return handler.UpdateExternalList
+	}, []handlerTest{
+		{
+			name:           "omitted authorization",
+			method:         http.MethodPut,
+			path:           "/api/external-lists/" + existingExternalListID,
+			body:           `{"name":"feed","description":"","url":"https://misp.example.com/attributes/restSearch","format":"misp","enabled":true}`,
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, _ *httptest.ResponseRecorder) {
+				assert.Equal(t, "misp-key", account.ExternalLists[existingExternalListID].Authorization,
+					"the omitted authorization should be kept")
+			},
+		},
+		{
+			name:           "empty authorization",
+			method:         http.MethodPut,
+			path:           "/api/external-lists/" + existingExternalListID,
+			body:           `{"name":"feed","description":"","url":"https://misp.example.com/attributes/restSearch","format":"misp","enabled":true,"authorization":""}`,
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, _ *httptest.ResponseRecorder) {
+				assert.Empty(t, account.ExternalLists[existingExternalListID].Authorization,
+					"an empty authorization should remove it")
+			},
+		},
+	})
+}
@@ -2,7 +2,9 @@ package http
 
 import (
 	"encoding/json"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
@@ -12,9 +14,15 @@ import (
 	"github.com/netbirdio/netbird/management/server/http/api"
 	"github.com/netbirdio/netbird/management/server/http/util"
 	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
 	"github.com/netbirdio/netbird/management/server/status"
 )
 
+const (
+	defaultGroupPeersPageSize = 100
+	maxGroupPeersPageSize     = 1000
+)
+
 // GroupsHandler is a handler that returns groups of the account
 type GroupsHandler struct {
 	accountManager  server.AccountManager
@@ -35,14 +43,26 @@ func NewGroupsHandler(accountManager server.AccountManager, authCfg AuthCfg) *Gr
 // GetAllGroups list for the account
 func (h *GroupsHandler) GetAllGroups(w http.ResponseWriter, r *http.Request) {
 	claims := h.claimsExtractor.FromRequestContext(r)
-	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	accountID, userID, err := h.accountManager.GetAccountIDFromToken(claims)
 	if err != nil {
 		log.Error(err)
 		http.Redirect(w, r, "/", http.StatusInternalServerError)
 		return
 	}
 
-	groups, err := h.accountManager.GetAllGroups(account.Id, user.Id)
+	if param := r.URL.Query().Get("summary"); param != "" {
+		summary, err := strconv.ParseBool(param)
+		if err != nil {
+			util.WriteError(status.Errorf(status.InvalidArgument, "invalid summary query parameter"), w)
+			return
+		}
+		if summary {
+			h.getGroupsSummary(w, accountID, userID)
+			return
+		}
+	}
+
+	groups, peers, err := h.accountManager.GetGroupsForUser(accountID, userID)
 	if err != nil {
 		util.WriteError(err, w)
 		return
@@ -50,12 +70,94 @@ func (h *GroupsHandler) GetAllGroups(w http.ResponseWriter, r *http.Request) {
 
 	groupsResponse := make([]*api.Group, 0, len(groups))
 	for _, group := range groups {
-		groupsResponse = append(groupsResponse, toGroupResponse(account, group))
+		groupsResponse = append(groupsResponse, toGroupResponse(peers, group))
+	}
+
+	util.WriteJSONObject(w, groupsResponse)
+}
+
+// getGroupsSummary writes the groups with the count of their peers, without resolving the peers
+func (h *GroupsHandler) getGroupsSummary(w http.ResponseWriter, accountID, userID string) {
+	groups, err := h.accountManager.GetGroupsSummaryForUser(accountID, userID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	groupsResponse := make([]*api.GroupMinimum, 0, len(groups))
+	for _, group := range groups {
+		groupsResponse = append(groupsResponse, &api.GroupMinimum{
+			Id:         group.ID,
+			Name:       group.Name,
+			Issued:     (*api.GroupMinimumIssued)(&group.Issued),
+			PeersCount: len(group.Peers),
+		})
 	}
 
 	util.WriteJSONObject(w, groupsResponse)
 }
 
+// GetGroupPeers returns a page of the peers of a group
+func (h *GroupsHandler) GetGroupPeers(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	accountID, userID, err := h.accountManager.GetAccountIDFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	groupID := mux.Vars(r)["groupId"]
+	if len(groupID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid group ID"), w)
+		return
+	}
+
+	page, err := intQueryParam(r, "page", 1, 1, math.MaxInt32)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+	pageSize, err := intQueryParam(r, "page_size", defaultGroupPeersPageSize, 1, maxGroupPeersPageSize)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	peers, total, err := h.accountManager.GetGroupPeersForUser(accountID, userID, groupID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := api.GroupPeers{
+		Page:     page,
+		PageSize: pageSize,
+		Peers:    make([]api.PeerMinimum, 0, len(peers)),
+		Total:    total,
+	}
+	for _, peer := range peers {
+		resp.Peers = append(resp.Peers, api.PeerMinimum{Id: peer.ID, Name: peer.Name})
+	}
+
+	util.WriteJSONObject(w, resp)
+}
+
+// intQueryParam returns the integer query parameter, the default value when it is missing
+func intQueryParam(r *http.Request, name string, defaultValue, minValue, maxValue int) (int, error) {
+	param := r.URL.Query().Get(name)
+	if param == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(param)
+	if err != nil || value < minValue || value > maxValue {
+		return 0, status.Errorf(status.InvalidArgument, "invalid %s query parameter, expected a number between %d and %d",
+			name, minValue, maxValue)
+	}
+
+	return value, nil
+}
+
 // UpdateGroup handles update to a group identified by a given ID
 func (h *GroupsHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
 	claims := h.claimsExtractor.FromRequestContext(r)
@@ -95,36 +197,40 @@ func (h *GroupsHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
 	var req api.PutApiGroupsGroupIdJSONRequestBody
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		util.WriteJSONParseError(err, w)
 		return
 	}
 
 	if req.Name == "" {
-		util.WriteError(status.Errorf(status.InvalidArgument, "group name shouldn't be empty"), w)
+		util.WriteError(status.FieldErrorf(status.InvalidArgument, "name", "group name shouldn't be empty"), w)
 		return
 	}
 
 	var peers []string
-	if req.Peers == nil {
-		peers = make([]string, 0)
-	} else {
+	if req.Peers != nil {
 		peers = *req.Peers
 	}
-	group := nbgroup.Group{
-		ID:                   groupID,
-		Name:                 req.Name,
-		Peers:                peers,
-		Issued:               eg.Issued,
-		IntegrationReference: eg.IntegrationReference,
+	group := server.UpdatedGroup(eg, req.Name, peers)
+	if req.KeepWarm != nil {
+		group.KeepWarm = *req.KeepWarm
+	}
+	if req.FeatureFlags != nil {
+		group.FeatureFlags = *req.FeatureFlags
+	}
+	if req.ClientUpdateChannel != nil {
+		group.ClientUpdateChannel = *req.ClientUpdateChannel
+	}
+	if req.ClientVersion != nil {
+		group.ClientVersion = *req.ClientVersion
 	}
 
-	if err := h.accountManager.SaveGroup(account.Id, user.Id, &group); err != nil {
+	if err := h.accountManager.SaveGroup(account.Id, user.Id, group); err != nil {
 		log.Errorf("failed updating group %s under account %s %v", groupID, account.Id, err)
 		util.WriteError(err, w)
 		return
 	}
 
-	util.WriteJSONObject(w, toGroupResponse(account, &group))
+	util.WriteJSONObject(w, toGroupResponse(account.Peers, group))
 }
 
 // CreateGroup handles group creation request
@@ -139,12 +245,12 @@ func (h *GroupsHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 	var req api.PostApiGroupsJSONRequestBody
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		util.WriteJSONParseError(err, w)
 		return
 	}
 
 	if req.Name == "" {
-		util.WriteError(status.Errorf(status.InvalidArgument, "group name shouldn't be empty"), w)
+		util.WriteError(status.FieldErrorf(status.InvalidArgument, "name", "group name shouldn't be empty"), w)
 		return
 	}
 
@@ -159,6 +265,18 @@ func (h *GroupsHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 		Peers:  peers,
 		Issued: nbgroup.GroupIssuedAPI,
 	}
+	if req.KeepWarm != nil {
+		group.KeepWarm = *req.KeepWarm
+	}
+	if req.FeatureFlags != nil {
+		group.FeatureFlags = *req.FeatureFlags
+	}
+	if req.ClientUpdateChannel != nil {
+		group.ClientUpdateChannel = *req.ClientUpdateChannel
+	}
+	if req.ClientVersion != nil {
+		group.ClientVersion = *req.ClientVersion
+	}
 
 	err = h.accountManager.SaveGroup(account.Id, user.Id, &group)
 	if err != nil {
@@ -166,7 +284,7 @@ func (h *GroupsHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	util.WriteJSONObject(w, toGroupResponse(account, &group))
+	util.WriteJSONObject(w, toGroupResponse(account.Peers, &group))
 }
 
 // DeleteGroup handles group deletion request
@@ -200,7 +318,7 @@ func (h *GroupsHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		_, ok := err.(*server.GroupLinkError)
 		if ok {
-			util.WriteErrorResponse(err.Error(), http.StatusBadRequest, w)
+			util.WriteBadRequest(err, w)
 			return
 		}
 		util.WriteError(err, w)
@@ -233,25 +351,35 @@ func (h *GroupsHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		util.WriteJSONObject(w, toGroupResponse(account, group))
+		util.WriteJSONObject(w, toGroupResponse(account.Peers, group))
 	default:
 		util.WriteError(status.Errorf(status.NotFound, "HTTP method not found"), w)
 		return
 	}
 }
 
-func toGroupResponse(account *server.Account, group *nbgroup.Group) *api.Group {
+func toGroupResponse(peers map[string]*nbpeer.Peer, group *nbgroup.Group) *api.Group {
 	cache := make(map[string]api.PeerMinimum)
 	gr := api.Group{
-		Id:     group.ID,
-		Name:   group.Name,
-		Issued: (*api.GroupIssued)(&group.Issued),
+		Id:       group.ID,
+		Name:     group.Name,
+		Issued:   (*api.GroupIssued)(&group.Issued),
+		KeepWarm: group.KeepWarm,
+	}
+	if len(group.FeatureFlags) != 0 {
+		gr.FeatureFlags = &group.FeatureFlags
+	}
+	if group.ClientUpdateChannel != "" {
+		gr.ClientUpdateChannel = &group.ClientUpdateChannel
+	}
+	if group.ClientVersion != "" {
+		gr.ClientVersion = &group.ClientVersion
 	}
 
 	for _, pid := range group.Peers {
 		_, ok := cache[pid]
 		if !ok {
-			peer, ok := account.Peers[pid]
+			peer, ok := peers[pid]
 			if !ok {
 				continue
 			}
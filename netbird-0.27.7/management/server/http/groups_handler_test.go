@@ -70,6 +70,37 @@ func initGroupTestData(user *server.User, _ ...*nbgroup.Group) *GroupsHandler {
 					},
 				}, user, nil
 			},
+			GetAccountIDFromTokenFunc: func(claims jwtclaims.AuthorizationClaims) (string, string, error) {
+				return claims.AccountId, user.Id, nil
+			},
+			GetGroupsForUserFunc: func(_, _ string) ([]*nbgroup.Group, map[string]*nbpeer.Peer, error) {
+				peers := make(map[string]*nbpeer.Peer, len(TestPeers))
+				for _, peer := range TestPeers {
+					peers[peer.ID] = peer
+				}
+				return []*nbgroup.Group{
+					{ID: "id-existed", Name: "Existed", Peers: []string{"peer-A-ID", "peer-B-ID", "missing"}, Issued: nbgroup.GroupIssuedAPI},
+					{ID: "id-all", Name: "All", Issued: nbgroup.GroupIssuedAPI},
+				}, peers, nil
+			},
+			GetGroupsSummaryForUserFunc: func(_, _ string) ([]*nbgroup.Group, error) {
+				return []*nbgroup.Group{
+					{ID: "id-existed", Name: "Existed", Peers: []string{"peer-A-ID", "peer-B-ID"}, Issued: nbgroup.GroupIssuedAPI},
+				}, nil
+			},
+			GetGroupPeersForUserFunc: func(_, _, groupID string, offset, limit int) ([]*nbpeer.Peer, int, error) {
+				if groupID != "id-existed" {
+					return nil, 0, status.Errorf(status.NotFound, "group %s not found", groupID)
+				}
+				peers := []*nbpeer.Peer{
+					{ID: "peer-A-ID", Name: "peer-a"},
+					{ID: "peer-B-ID", Name: "peer-b"},
+				}
+				if offset >= len(peers) {
+					return []*nbpeer.Peer{}, len(peers), nil
+				}
+				return peers[offset:min(offset+limit, len(peers))], len(peers), nil
+			},
 			DeleteGroupFunc: func(accountID, userId, groupID string) error {
 				if groupID == "linked-grp" {
 					return &server.GroupLinkError{
@@ -165,6 +196,155 @@ func TestGetGroup(t *testing.T) {
 	}
 }
 
+func TestGetAllGroups(t *testing.T) {
+	adminUser := server.NewAdminUser("test_user")
+	p := initGroupTestData(adminUser)
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/groups", nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/groups", p.GetAllGroups).Methods("GET")
+	router.ServeHTTP(recorder, req)
+
+	res := recorder.Result()
+	defer res.Body.Close()
+
+	if status := recorder.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	content, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("I don't know what I expected; %v", err)
+	}
+
+	var got []api.Group
+	if err = json.Unmarshal(content, &got); err != nil {
+		t.Fatalf("Sent content is not in correct json format; %v", err)
+	}
+
+	assert.Equal(t, len(got), 2)
+	assert.Equal(t, got[0].Id, "id-existed")
+	assert.Equal(t, got[0].PeersCount, 2)
+	assert.Equal(t, got[1].Id, "id-all")
+	assert.Equal(t, got[1].PeersCount, 0)
+}
+
+func TestGetAllGroupsSummary(t *testing.T) {
+	adminUser := server.NewAdminUser("test_user")
+	p := initGroupTestData(adminUser)
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/groups?summary=true", nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/groups", p.GetAllGroups).Methods("GET")
+	router.ServeHTTP(recorder, req)
+
+	res := recorder.Result()
+	defer res.Body.Close()
+
+	if status := recorder.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	content, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("I don't know what I expected; %v", err)
+	}
+
+	var got []map[string]any
+	if err = json.Unmarshal(content, &got); err != nil {
+		t.Fatalf("Sent content is not in correct json format; %v", err)
+	}
+
+	assert.Equal(t, len(got), 1)
+	assert.Equal(t, got[0]["id"], "id-existed")
+	assert.Equal(t, got[0]["peers_count"], float64(2))
+	_, ok := got[0]["peers"]
+	assert.Equal(t, ok, false, "a summary shouldn't list the peers")
+}
+
+func TestGetGroupPeers(t *testing.T) {
+	tt := []struct {
+		name           string
+		requestPath    string
+		expectedStatus int
+		expectedPeers  []string
+	}{
+		{
+			name:           "first page",
+			requestPath:    "/api/groups/id-existed/peers?page_size=1",
+			expectedStatus: http.StatusOK,
+			expectedPeers:  []string{"peer-a"},
+		},
+		{
+			name:           "second page",
+			requestPath:    "/api/groups/id-existed/peers?page=2&page_size=1",
+			expectedStatus: http.StatusOK,
+			expectedPeers:  []string{"peer-b"},
+		},
+		{
+			name:           "default page size",
+			requestPath:    "/api/groups/id-existed/peers",
+			expectedStatus: http.StatusOK,
+			expectedPeers:  []string{"peer-a", "peer-b"},
+		},
+		{
+			name:           "invalid page",
+			requestPath:    "/api/groups/id-existed/peers?page=0",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "page size over the maximum",
+			requestPath:    "/api/groups/id-existed/peers?page_size=1001",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "group not found",
+			requestPath:    "/api/groups/notexists/peers",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	adminUser := server.NewAdminUser("test_user")
+	p := initGroupTestData(adminUser)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.requestPath, nil)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/groups/{groupId}/peers", p.GetGroupPeers).Methods("GET")
+			router.ServeHTTP(recorder, req)
+
+			res := recorder.Result()
+			defer res.Body.Close()
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var got api.GroupPeers
+			if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+				t.Fatalf("Sent content is not in correct json format; %v", err)
+			}
+
+			assert.Equal(t, got.Total, 2)
+			names := make([]string, 0, len(got.Peers))
+			for _, peer := range got.Peers {
+				names = append(names, peer.Name)
+			}
+			assert.Equal(t, names, tc.expectedPeers)
+		})
+	}
+}
+
 func TestWriteGroup(t *testing.T) {
 	groupIssuedAPI := "api"
 	groupIssuedJWT := "jwt"
@@ -191,6 +371,36 @@ func TestWriteGroup(t *testing.T) {
 				Issued: (*api.GroupIssued)(&groupIssuedAPI),
 			},
 		},
+		{
+			name:        "Write Group POST Keep Warm",
+			requestType: http.MethodPost,
+			requestPath: "/api/groups",
+			requestBody: bytes.NewBuffer(
+				[]byte(`{"Name":"Warm Group","keep_warm":true}`)),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedGroup: &api.Group{
+				Id:       "id-was-set",
+				Name:     "Warm Group",
+				Issued:   (*api.GroupIssued)(&groupIssuedAPI),
+				KeepWarm: true,
+			},
+		},
+		{
+			name:        "Write Group POST Feature Flags",
+			requestType: http.MethodPost,
+			requestPath: "/api/groups",
+			requestBody: bytes.NewBuffer(
+				[]byte(`{"Name":"Canary Group","feature_flags":{"lazy_firewall_rules":true}}`)),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedGroup: &api.Group{
+				Id:           "id-was-set",
+				Name:         "Canary Group",
+				Issued:       (*api.GroupIssued)(&groupIssuedAPI),
+				FeatureFlags: &map[string]bool{"lazy_firewall_rules": true},
+			},
+		},
 		{
 			name:        "Write Group POST Invalid Name",
 			requestType: http.MethodPost,
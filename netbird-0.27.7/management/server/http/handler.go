@@ -39,7 +39,7 @@ type emptyObject struct {
 }
 
 // APIHandler creates the Management service HTTP API handler registering all the available endpoints.
-func APIHandler(ctx context.Context, accountManager s.AccountManager, LocationManager *geolocation.Geolocation, jwtValidator jwtclaims.JWTValidator, appMetrics telemetry.AppMetrics, authCfg AuthCfg, integratedValidator integrated_validator.IntegratedValidator) (http.Handler, error) {
+func APIHandler(ctx context.Context, accountManager s.AccountManager, LocationManager *geolocation.Geolocation, jwtValidator jwtclaims.JWTValidator, appMetrics telemetry.AppMetrics, authCfg AuthCfg, accessLogCfg *s.AccessLogConfig, integratedValidator integrated_validator.IntegratedValidator) (http.Handler, error) {
 	claimsExtractor := jwtclaims.NewClaimsExtractor(
 		jwtclaims.WithAudience(authCfg.Audience),
 		jwtclaims.WithUserIDClaim(authCfg.UserIDClaim),
@@ -62,12 +62,20 @@ func APIHandler(ctx context.Context, accountManager s.AccountManager, LocationMa
 		authCfg.UserIDClaim,
 		accountManager.GetUser)
 
+	// counts the requests of the users the access control let through
+	quotaMiddleware := middleware.NewAPIQuota(accountManager.CountAPIRequest, claimsExtractor)
+
 	rootRouter := mux.NewRouter()
 	metricsMiddleware := appMetrics.HTTPMiddleware()
 
 	prefix := apiPrefix
 	router := rootRouter.PathPrefix(prefix).Subrouter()
-	router.Use(metricsMiddleware.Handler, corsMiddleware.Handler, authMiddleware.Handler, acMiddleware.Handler)
+	middlewares := []mux.MiddlewareFunc{metricsMiddleware.Handler}
+	if accessLogCfg != nil {
+		middlewares = append(middlewares, middleware.NewAccessLog(*accessLogCfg, claimsExtractor).Handler)
+	}
+	router.Use(append(middlewares, corsMiddleware.Handler, authMiddleware.Handler, acMiddleware.Handler,
+		quotaMiddleware.Handler)...)
 
 	api := apiHandler{
 		Router:             router,
@@ -89,8 +97,17 @@ func APIHandler(ctx context.Context, accountManager s.AccountManager, LocationMa
 	api.addGroupsEndpoint()
 	api.addRoutesEndpoint()
 	api.addDNSNameserversEndpoint()
+	api.addDNSRecordsEndpoint()
+	api.addDNSLabelsEndpoint()
 	api.addDNSSettingEndpoint()
+	api.addIPAMEndpoint()
+	api.addRelayServersEndpoint()
+	api.addClaimGroupMappingsEndpoint()
+	api.addRouteGroupsEndpoint()
+	api.addExternalListsEndpoint()
 	api.addEventsEndpoint()
+	api.addReportsEndpoint()
+	api.addPeerQuotasEndpoint()
 	api.addPostureCheckEndpoint()
 	api.addLocationsEndpoint()
 
@@ -122,14 +139,29 @@ func (apiHandler *apiHandler) addAccountsEndpoint() {
 	accountsHandler := NewAccountsHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
 	apiHandler.Router.HandleFunc("/accounts/{accountId}", accountsHandler.UpdateAccount).Methods("PUT", "OPTIONS")
 	apiHandler.Router.HandleFunc("/accounts/{accountId}", accountsHandler.DeleteAccount).Methods("DELETE", "OPTIONS")
+	apiHandler.Router.HandleFunc("/accounts/{accountId}/deletion", accountsHandler.GetAccountDeletion).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/accounts/{accountId}/deletion", accountsHandler.CancelAccountDeletion).Methods("DELETE", "OPTIONS")
+	apiHandler.Router.HandleFunc("/accounts/{accountId}/export", accountsHandler.GetAccountExport).Methods("GET", "OPTIONS")
 	apiHandler.Router.HandleFunc("/accounts", accountsHandler.GetAllAccounts).Methods("GET", "OPTIONS")
 }
 
 func (apiHandler *apiHandler) addPeersEndpoint() {
 	peersHandler := NewPeersHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
 	apiHandler.Router.HandleFunc("/peers", peersHandler.GetAllPeers).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/peers/relayed-connections", peersHandler.GetRelayedConnections).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/peers/inventory", peersHandler.GetPeersInventory).Methods("GET", "OPTIONS")
 	apiHandler.Router.HandleFunc("/peers/{peerId}", peersHandler.HandlePeer).
 		Methods("GET", "PUT", "DELETE", "OPTIONS")
+	apiHandler.Router.HandleFunc("/peers/{peerId}/stats", peersHandler.GetPeerTransferStats).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/peers/{peerId}/dns-stats", peersHandler.GetPeerDNSStats).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/peers/{peerId}/diagnostics", peersHandler.GetPeerDiagnostics).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/peers/{peerId}/network-map", peersHandler.GetPeerNetworkMap).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/peers/{peerId}/actions", peersHandler.RequestPeerAction).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/peers/{peerId}/ping", peersHandler.RequestPeerPing).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/peers/{peerId}/ping/{pingId}", peersHandler.GetPeerPing).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/peers/{peerId}/geofence", peersHandler.GetPeerGeofence).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/peers/{peerId}/geofence/override", peersHandler.HandlePeerGeofenceOverride).
+		Methods("POST", "PUT", "DELETE", "OPTIONS")
 }
 
 func (apiHandler *apiHandler) addUsersEndpoint() {
@@ -139,6 +171,8 @@ func (apiHandler *apiHandler) addUsersEndpoint() {
 	apiHandler.Router.HandleFunc("/users/{userId}", userHandler.DeleteUser).Methods("DELETE", "OPTIONS")
 	apiHandler.Router.HandleFunc("/users", userHandler.CreateUser).Methods("POST", "OPTIONS")
 	apiHandler.Router.HandleFunc("/users/{userId}/invite", userHandler.InviteUser).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/users/{userId}/notifications", userHandler.GetUserNotifications).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/users/{userId}/notifications", userHandler.UpdateUserNotifications).Methods("PUT", "OPTIONS")
 }
 
 func (apiHandler *apiHandler) addUsersTokensEndpoint() {
@@ -153,7 +187,9 @@ func (apiHandler *apiHandler) addSetupKeysEndpoint() {
 	keysHandler := NewSetupKeysHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
 	apiHandler.Router.HandleFunc("/setup-keys", keysHandler.GetAllSetupKeys).Methods("GET", "OPTIONS")
 	apiHandler.Router.HandleFunc("/setup-keys", keysHandler.CreateSetupKey).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/setup-keys/stale", keysHandler.GetStaleSetupKeys).Methods("GET", "OPTIONS")
 	apiHandler.Router.HandleFunc("/setup-keys/{keyId}", keysHandler.GetSetupKey).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/setup-keys/{keyId}/replace", keysHandler.ReplaceSetupKey).Methods("POST", "OPTIONS")
 	apiHandler.Router.HandleFunc("/setup-keys/{keyId}", keysHandler.UpdateSetupKey).Methods("PUT", "OPTIONS")
 }
 
@@ -164,6 +200,7 @@ func (apiHandler *apiHandler) addPoliciesEndpoint() {
 	apiHandler.Router.HandleFunc("/policies/{policyId}", policiesHandler.UpdatePolicy).Methods("PUT", "OPTIONS")
 	apiHandler.Router.HandleFunc("/policies/{policyId}", policiesHandler.GetPolicy).Methods("GET", "OPTIONS")
 	apiHandler.Router.HandleFunc("/policies/{policyId}", policiesHandler.DeletePolicy).Methods("DELETE", "OPTIONS")
+	apiHandler.Router.HandleFunc("/policies/{policyId}/stats", policiesHandler.GetPolicyStats).Methods("GET", "OPTIONS")
 }
 
 func (apiHandler *apiHandler) addGroupsEndpoint() {
@@ -173,6 +210,7 @@ func (apiHandler *apiHandler) addGroupsEndpoint() {
 	apiHandler.Router.HandleFunc("/groups/{groupId}", groupsHandler.UpdateGroup).Methods("PUT", "OPTIONS")
 	apiHandler.Router.HandleFunc("/groups/{groupId}", groupsHandler.GetGroup).Methods("GET", "OPTIONS")
 	apiHandler.Router.HandleFunc("/groups/{groupId}", groupsHandler.DeleteGroup).Methods("DELETE", "OPTIONS")
+	apiHandler.Router.HandleFunc("/groups/{groupId}/peers", groupsHandler.GetGroupPeers).Methods("GET", "OPTIONS")
 }
 
 func (apiHandler *apiHandler) addRoutesEndpoint() {
@@ -182,6 +220,7 @@ func (apiHandler *apiHandler) addRoutesEndpoint() {
 	apiHandler.Router.HandleFunc("/routes/{routeId}", routesHandler.UpdateRoute).Methods("PUT", "OPTIONS")
 	apiHandler.Router.HandleFunc("/routes/{routeId}", routesHandler.GetRoute).Methods("GET", "OPTIONS")
 	apiHandler.Router.HandleFunc("/routes/{routeId}", routesHandler.DeleteRoute).Methods("DELETE", "OPTIONS")
+	apiHandler.Router.HandleFunc("/routes/{routeId}/approve", routesHandler.ApproveRoute).Methods("POST", "OPTIONS")
 }
 
 func (apiHandler *apiHandler) addDNSNameserversEndpoint() {
@@ -193,17 +232,89 @@ func (apiHandler *apiHandler) addDNSNameserversEndpoint() {
 	apiHandler.Router.HandleFunc("/dns/nameservers/{nsgroupId}", nameserversHandler.DeleteNameserverGroup).Methods("DELETE", "OPTIONS")
 }
 
+func (apiHandler *apiHandler) addDNSRecordsEndpoint() {
+	dnsRecordsHandler := NewDNSRecordsHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
+	apiHandler.Router.HandleFunc("/dns/records", dnsRecordsHandler.GetAllDNSRecords).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/dns/records", dnsRecordsHandler.CreateDNSRecord).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/dns/records/{recordId}", dnsRecordsHandler.UpdateDNSRecord).Methods("PUT", "OPTIONS")
+	apiHandler.Router.HandleFunc("/dns/records/{recordId}", dnsRecordsHandler.GetDNSRecord).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/dns/records/{recordId}", dnsRecordsHandler.DeleteDNSRecord).Methods("DELETE", "OPTIONS")
+	apiHandler.Router.HandleFunc("/dns/zones", dnsRecordsHandler.GetAllDNSZones).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/dns/zones/export", dnsRecordsHandler.ExportDNSZones).Methods("GET", "OPTIONS")
+}
+
+func (apiHandler *apiHandler) addRelayServersEndpoint() {
+	relayServersHandler := NewRelayServersHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
+	apiHandler.Router.HandleFunc("/relay-servers", relayServersHandler.GetAllRelayServers).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/relay-servers", relayServersHandler.CreateRelayServer).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/relay-servers/{relayId}", relayServersHandler.UpdateRelayServer).Methods("PUT", "OPTIONS")
+	apiHandler.Router.HandleFunc("/relay-servers/{relayId}", relayServersHandler.GetRelayServer).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/relay-servers/{relayId}", relayServersHandler.DeleteRelayServer).Methods("DELETE", "OPTIONS")
+}
+
+func (apiHandler *apiHandler) addClaimGroupMappingsEndpoint() {
+	claimGroupMappingsHandler := NewClaimGroupMappingsHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
+	apiHandler.Router.HandleFunc("/claim-group-mappings", claimGroupMappingsHandler.GetAllClaimGroupMappings).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/claim-group-mappings", claimGroupMappingsHandler.CreateClaimGroupMapping).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/claim-group-mappings/{mappingId}", claimGroupMappingsHandler.UpdateClaimGroupMapping).Methods("PUT", "OPTIONS")
+	apiHandler.Router.HandleFunc("/claim-group-mappings/{mappingId}", claimGroupMappingsHandler.GetClaimGroupMapping).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/claim-group-mappings/{mappingId}", claimGroupMappingsHandler.DeleteClaimGroupMapping).Methods("DELETE", "OPTIONS")
+}
+
+func (apiHandler *apiHandler) addRouteGroupsEndpoint() {
+	routeGroupsHandler := NewRouteGroupsHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
+	apiHandler.Router.HandleFunc("/route-groups", routeGroupsHandler.GetAllRouteGroups).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/route-groups", routeGroupsHandler.CreateRouteGroup).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/route-groups/{routeGroupId}", routeGroupsHandler.UpdateRouteGroup).Methods("PUT", "OPTIONS")
+	apiHandler.Router.HandleFunc("/route-groups/{routeGroupId}", routeGroupsHandler.GetRouteGroup).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/route-groups/{routeGroupId}", routeGroupsHandler.DeleteRouteGroup).Methods("DELETE", "OPTIONS")
+}
+
+func (apiHandler *apiHandler) addExternalListsEndpoint() {
+	externalListsHandler := NewExternalListsHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
+	apiHandler.Router.HandleFunc("/external-lists", externalListsHandler.GetAllExternalLists).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/external-lists", externalListsHandler.CreateExternalList).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/external-lists/{listId}", externalListsHandler.UpdateExternalList).Methods("PUT", "OPTIONS")
+	apiHandler.Router.HandleFunc("/external-lists/{listId}", externalListsHandler.GetExternalList).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/external-lists/{listId}", externalListsHandler.DeleteExternalList).Methods("DELETE", "OPTIONS")
+	apiHandler.Router.HandleFunc("/external-lists/{listId}/refresh", externalListsHandler.RefreshExternalList).Methods("POST", "OPTIONS")
+}
+
+func (apiHandler *apiHandler) addDNSLabelsEndpoint() {
+	dnsLabelsHandler := NewDNSLabelsHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
+	apiHandler.Router.HandleFunc("/dns/labels", dnsLabelsHandler.GetAllDNSLabels).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/dns/labels/{peerId}", dnsLabelsHandler.UpdateDNSLabel).Methods("PUT", "OPTIONS")
+}
+
 func (apiHandler *apiHandler) addDNSSettingEndpoint() {
 	dnsSettingsHandler := NewDNSSettingsHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
 	apiHandler.Router.HandleFunc("/dns/settings", dnsSettingsHandler.GetDNSSettings).Methods("GET", "OPTIONS")
 	apiHandler.Router.HandleFunc("/dns/settings", dnsSettingsHandler.UpdateDNSSettings).Methods("PUT", "OPTIONS")
 }
 
+func (apiHandler *apiHandler) addIPAMEndpoint() {
+	ipamHandler := NewIPAMHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
+	apiHandler.Router.HandleFunc("/ipam", ipamHandler.GetIPPool).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/ipam/allocations/{peerId}", ipamHandler.AssignPeerIP).Methods("PUT", "OPTIONS")
+}
+
 func (apiHandler *apiHandler) addEventsEndpoint() {
 	eventsHandler := NewEventsHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
 	apiHandler.Router.HandleFunc("/events", eventsHandler.GetAllEvents).Methods("GET", "OPTIONS")
 }
 
+func (apiHandler *apiHandler) addReportsEndpoint() {
+	reportsHandler := NewReportsHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
+	apiHandler.Router.HandleFunc("/reports/usage", reportsHandler.GetUsageReport).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/reports/packages", reportsHandler.GetPackagesReport).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/usage", reportsHandler.GetAPIUsage).Methods("GET", "OPTIONS")
+}
+
+func (apiHandler *apiHandler) addPeerQuotasEndpoint() {
+	peerQuotasHandler := NewPeerQuotasHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
+	apiHandler.Router.HandleFunc("/peer-quotas", peerQuotasHandler.GetPeerQuotas).Methods("GET", "OPTIONS")
+}
+
 func (apiHandler *apiHandler) addPostureCheckEndpoint() {
 	postureCheckHandler := NewPostureChecksHandler(apiHandler.AccountManager, apiHandler.geolocationManager, apiHandler.AuthCfg)
 	apiHandler.Router.HandleFunc("/posture-checks", postureCheckHandler.GetAllPostureChecks).Methods("GET", "OPTIONS")
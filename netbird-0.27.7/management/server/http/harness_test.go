@@ -0,0 +1,349 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server"
+	nbgroup "github.com/netbirdio/netbird/management/server/group"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/mock_server"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// The identities of the accounts built by testAccountBuilder
+const (
+	fakeAccountID     = "test_account"
+	fakeAdminUserID   = "test_user"
+	fakeRegularUserID = "regular_user"
+	fakeDomain        = "hotmail.com"
+	fakeGroupAllID    = "group-all"
+)
+
+// testAccountBuilder builds the account the handler tests run against: an admin and a regular user, the All group
+// and the peers, groups and policies added with its methods
+type testAccountBuilder struct {
+	account *server.Account
+}
+
+func newTestAccountBuilder() *testAccountBuilder {
+	admin := server.NewAdminUser(fakeAdminUserID)
+	admin.AccountID = fakeAccountID
+	regular := server.NewRegularUser(fakeRegularUserID)
+	regular.AccountID = fakeAccountID
+
+	return &testAccountBuilder{account: &server.Account{
+		Id:     fakeAccountID,
+		Domain: fakeDomain,
+		Users: map[string]*server.User{
+			admin.Id:   admin,
+			regular.Id: regular,
+		},
+		Peers: map[string]*nbpeer.Peer{},
+		Groups: map[string]*nbgroup.Group{
+			fakeGroupAllID: {ID: fakeGroupAllID, Name: "All", Issued: nbgroup.GroupIssuedAPI},
+		},
+		Settings: &server.Settings{},
+	}}
+}
+
+// withPeers adds n peers peer-1 to peer-n owned by the regular user to the account and the All group
+func (b *testAccountBuilder) withPeers(n int) *testAccountBuilder {
+	start := len(b.account.Peers)
+	for i := start + 1; i <= start+n; i++ {
+		id := fmt.Sprintf("peer-%d", i)
+		b.account.Peers[id] = &nbpeer.Peer{
+			ID:       id,
+			Key:      fmt.Sprintf("key-%d", i),
+			Name:     id,
+			DNSLabel: id,
+			UserID:   fakeRegularUserID,
+			IP:       net.IPv4(100, 64, byte(i>>8), byte(i)),
+			Meta:     nbpeer.PeerSystemMeta{Hostname: id},
+			Status:   &nbpeer.PeerStatus{Connected: true},
+		}
+		allGroup := b.account.Groups[fakeGroupAllID]
+		allGroup.Peers = append(allGroup.Peers, id)
+	}
+	return b
+}
+
+// withGroups adds n groups group-1 to group-n to the account, the peers are spread over them
+func (b *testAccountBuilder) withGroups(n int) *testAccountBuilder {
+	for i := 1; i <= n; i++ {
+		id := fmt.Sprintf("group-%d", i)
+		b.account.Groups[id] = &nbgroup.Group{ID: id, Name: id, Issued: nbgroup.GroupIssuedAPI}
+	}
+	for i, peerID := range sortedKeys(b.account.Peers) {
+		group := b.account.Groups[fmt.Sprintf("group-%d", i%n+1)]
+		group.Peers = append(group.Peers, peerID)
+	}
+	return b
+}
+
+// withPolicies adds n policies policy-1 to policy-n to the account, each with a rule accepting the traffic between the
+// peers of a group, the All group when the account has no other
+func (b *testAccountBuilder) withPolicies(n int) *testAccountBuilder {
+	var groupIDs []string
+	for _, id := range sortedKeys(b.account.Groups) {
+		if id != fakeGroupAllID {
+			groupIDs = append(groupIDs, id)
+		}
+	}
+	if len(groupIDs) == 0 {
+		groupIDs = []string{fakeGroupAllID}
+	}
+
+	for i := 1; i <= n; i++ {
+		id := fmt.Sprintf("policy-%d", i)
+		group := groupIDs[(i-1)%len(groupIDs)]
+		b.account.Policies = append(b.account.Policies, &server.Policy{
+			ID:      id,
+			Name:    id,
+			Enabled: true,
+			Rules: []*server.PolicyRule{{
+				ID:            id,
+				Name:          id,
+				Enabled:       true,
+				Action:        server.PolicyTrafficActionAccept,
+				Sources:       []string{group},
+				Destinations:  []string{group},
+				Bidirectional: true,
+				Protocol:      server.PolicyRuleProtocolALL,
+			}},
+		})
+	}
+	return b
+}
+
+// withUser adds the user to the account
+func (b *testAccountBuilder) withUser(user *server.User) *testAccountBuilder {
+	user.AccountID = b.account.Id
+	b.account.Users[user.Id] = user
+	return b
+}
+
+// withExternalList adds the external list to the account
+func (b *testAccountBuilder) withExternalList(list *server.ExternalList) *testAccountBuilder {
+	if b.account.ExternalLists == nil {
+		b.account.ExternalLists = map[string]*server.ExternalList{}
+	}
+	list.AccountID = b.account.Id
+	b.account.ExternalLists[list.ID] = list
+	return b
+}
+
+func (b *testAccountBuilder) build() *server.Account {
+	return b.account
+}
+
+// newFakeAccountManager returns an account manager answering the lookups of the token, the users, the peers, the
+// groups and the policies from the account. Like the real one it lets only the users with admin power view the
+// policies and all the peers. The tests set the funcs of the other methods they call
+func newFakeAccountManager(account *server.Account) *mock_server.MockAccountManager {
+	findUser := func(userID string) (*server.User, error) {
+		user, ok := account.Users[userID]
+		if !ok {
+			return nil, status.Errorf(status.NotFound, "user %s not found", userID)
+		}
+		return user, nil
+	}
+	findAdmin := func(userID, resource string) (*server.User, error) {
+		user, err := findUser(userID)
+		if err != nil {
+			return nil, err
+		}
+		if !(user.HasAdminPower() || user.IsServiceUser) {
+			return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view %s", resource)
+		}
+		return user, nil
+	}
+
+	return &mock_server.MockAccountManager{
+		GetAccountFromTokenFunc: func(claims jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error) {
+			user, err := findUser(claims.UserId)
+			if err != nil {
+				return nil, nil, err
+			}
+			return account, user, nil
+		},
+		GetAccountIDFromTokenFunc: func(claims jwtclaims.AuthorizationClaims) (string, string, error) {
+			if _, err := findUser(claims.UserId); err != nil {
+				return "", "", err
+			}
+			return account.Id, claims.UserId, nil
+		},
+		GetUserFunc: func(claims jwtclaims.AuthorizationClaims) (*server.User, error) {
+			return findUser(claims.UserId)
+		},
+		GetPeersFunc: func(_, userID string) ([]*nbpeer.Peer, error) {
+			user, err := findUser(userID)
+			if err != nil {
+				return nil, err
+			}
+			var peers []*nbpeer.Peer
+			for _, id := range sortedKeys(account.Peers) {
+				if peer := account.Peers[id]; user.HasAdminPower() || peer.UserID == userID {
+					peers = append(peers, peer)
+				}
+			}
+			return peers, nil
+		},
+		GetPeerFunc: func(_, peerID, userID string) (*nbpeer.Peer, error) {
+			user, err := findUser(userID)
+			if err != nil {
+				return nil, err
+			}
+			peer, ok := account.Peers[peerID]
+			if !ok || !(user.HasAdminPower() || peer.UserID == userID) {
+				return nil, status.Errorf(status.NotFound, "peer %s not found", peerID)
+			}
+			return peer, nil
+		},
+		GetGroupFunc: func(_, groupID, _ string) (*nbgroup.Group, error) {
+			group, ok := account.Groups[groupID]
+			if !ok {
+				return nil, status.Errorf(status.NotFound, "group %s not found", groupID)
+			}
+			return group, nil
+		},
+		GetAllGroupsFunc: func(_, _ string) ([]*nbgroup.Group, error) {
+			return sortedValues(account.Groups), nil
+		},
+		GetGroupsForUserFunc: func(_, userID string) ([]*nbgroup.Group, map[string]*nbpeer.Peer, error) {
+			if _, err := findUser(userID); err != nil {
+				return nil, nil, err
+			}
+			return sortedValues(account.Groups), account.Peers, nil
+		},
+		GetPolicyFunc: func(_, policyID, userID string) (*server.Policy, error) {
+			if _, err := findAdmin(userID, "policies"); err != nil {
+				return nil, err
+			}
+			for _, policy := range account.Policies {
+				if policy.ID == policyID {
+					return policy, nil
+				}
+			}
+			return nil, status.Errorf(status.NotFound, "policy %s not found", policyID)
+		},
+		ListPoliciesFunc: func(_, userID string) ([]*server.Policy, error) {
+			if _, err := findAdmin(userID, "policies"); err != nil {
+				return nil, err
+			}
+			return account.Policies, nil
+		},
+		GetPoliciesForUserFunc: func(_, userID string) ([]*server.Policy, map[string]*nbgroup.Group, error) {
+			if _, err := findAdmin(userID, "policies"); err != nil {
+				return nil, nil, err
+			}
+			return account.Policies, account.Groups, nil
+		},
+	}
+}
+
+// newTestClaimsExtractor returns a claims extractor injecting the claims of the user of the test account in the
+// requests
+func newTestClaimsExtractor(userID string) *jwtclaims.ClaimsExtractor {
+	return jwtclaims.NewClaimsExtractor(
+		jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+			return jwtclaims.AuthorizationClaims{
+				UserId:    userID,
+				Domain:    fakeDomain,
+				AccountId: fakeAccountID,
+			}
+		}),
+	)
+}
+
+// handlerTest is a request to a handler and the response it expects
+type handlerTest struct {
+	name string
+	// userID is the user making the request, the admin user when empty
+	userID         string
+	method         string
+	path           string
+	body           any
+	expectedStatus int
+	// check asserts the response when set
+	check func(t *testing.T, recorder *httptest.ResponseRecorder)
+}
+
+// runHandlerTests serves the requests of the tests with the handler newHandler returns for their user, registered on
+// the route template like the API handler does
+func runHandlerTests(t *testing.T, route string, newHandler func(claimsExtractor *jwtclaims.ClaimsExtractor) http.HandlerFunc, tests []handlerTest) {
+	t.Helper()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			userID := tc.userID
+			if userID == "" {
+				userID = fakeAdminUserID
+			}
+
+			recorder := serveTestRequest(t, route, newHandler(newTestClaimsExtractor(userID)), tc.method, tc.path, tc.body)
+			require.Equal(t, tc.expectedStatus, recorder.Code, "unexpected status, body: %s", recorder.Body.String())
+			if tc.check != nil {
+				tc.check(t, recorder)
+			}
+		})
+	}
+}
+
+// serveTestRequest serves the request with the handler registered on the route template, the body is encoded in JSON
+// unless it is a string or nil
+func serveTestRequest(t *testing.T, route string, handler http.HandlerFunc, method, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = bytes.NewBufferString(b)
+	default:
+		data, err := json.Marshal(b)
+		require.NoError(t, err, "failed to encode the request body")
+		reader = bytes.NewBuffer(data)
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc(route, handler).Methods(method)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(method, path, reader))
+	return recorder
+}
+
+// decodeTestResponse decodes the JSON body of the response
+func decodeTestResponse[T any](t *testing.T, recorder *httptest.ResponseRecorder) T {
+	t.Helper()
+	var got T
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got), "failed to decode the response: %s", recorder.Body.String())
+	return got
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedValues[T any](m map[string]T) []T {
+	values := make([]T, 0, len(m))
+	for _, key := range sortedKeys(m) {
+		values = append(values, m[key])
+	}
+	return values
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/http/util"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// IPAMHandler is the handler of the NetBird IPs of the peers of the account
+type IPAMHandler struct {
+	accountManager  server.AccountManager
+	claimsExtractor *jwtclaims.ClaimsExtractor
+}
+
+// NewIPAMHandler returns a new instance of IPAMHandler handler
+func NewIPAMHandler(accountManager server.AccountManager, authCfg AuthCfg) *IPAMHandler {
+	return &IPAMHandler{
+		accountManager: accountManager,
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithAudience(authCfg.Audience),
+			jwtclaims.WithUserIDClaim(authCfg.UserIDClaim),
+		),
+	}
+}
+
+// GetIPPool returns the usage of the network the peers of the account get their IPs from
+func (h *IPAMHandler) GetIPPool(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	pool, err := h.accountManager.GetIPPool(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toIPPoolResponse(pool))
+}
+
+// AssignPeerIP assigns a static IP to a peer
+func (h *IPAMHandler) AssignPeerIP(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	peerID := mux.Vars(r)["peerId"]
+	if len(peerID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid peer ID"), w)
+		return
+	}
+
+	var req api.PutApiIpamAllocationsPeerIdJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteJSONParseError(err, w)
+		return
+	}
+
+	ip := net.ParseIP(req.Ip)
+	if ip == nil {
+		util.WriteError(status.FieldErrorf(status.InvalidArgument, "ip", "invalid peer IP %s", req.Ip), w)
+		return
+	}
+
+	peer, err := h.accountManager.AssignPeerIP(account.Id, user.Id, peerID, ip)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toIPAllocationResponse(peer))
+}
+
+func toIPPoolResponse(pool *server.IPPool) *api.IPPool {
+	allocations := make([]api.IPAllocation, 0, len(pool.Allocations))
+	for _, allocation := range pool.Allocations {
+		allocations = append(allocations, api.IPAllocation{
+			Ip:       allocation.IP.String(),
+			PeerId:   allocation.PeerID,
+			PeerName: allocation.PeerName,
+			Static:   allocation.Static,
+		})
+	}
+
+	return &api.IPPool{
+		Network:     pool.Network.String(),
+		Capacity:    pool.Capacity,
+		Used:        pool.Capacity - pool.Free,
+		Free:        pool.Free,
+		Utilization: pool.Utilization(),
+		Allocations: allocations,
+	}
+}
+
+func toIPAllocationResponse(peer *nbpeer.Peer) *api.IPAllocation {
+	return &api.IPAllocation{
+		Ip:       peer.IP.String(),
+		PeerId:   peer.ID,
+		PeerName: peer.Name,
+		Static:   peer.StaticIP,
+	}
+}
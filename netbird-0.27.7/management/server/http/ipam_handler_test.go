@@ -0,0 +1,120 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestGetIPPool(t *testing.T) {
+	_, network, _ := net.ParseCIDR("100.64.0.0/29")
+	pool := &server.IPPool{
+		Network:  *network,
+		Capacity: 6,
+		Free:     4,
+		Allocations: []server.IPAllocation{
+			{IP: net.ParseIP("100.64.0.1"), PeerID: "peer1", PeerName: "router", Static: true},
+			{IP: net.ParseIP("100.64.0.3"), PeerID: "peer2", PeerName: "laptop"},
+		},
+	}
+
+	accountManager := newFakeAccountManager(newTestAccountBuilder().build())
+	accountManager.GetIPPoolFunc = func(_, userID string) (*server.IPPool, error) {
+		if userID != fakeAdminUserID {
+			return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view the IP pool")
+		}
+		return pool, nil
+	}
+
+	runHandlerTests(t, "/api/ipam", func(claimsExtractor *jwtclaims.ClaimsExtractor) http.HandlerFunc {
+		handler := &IPAMHandler{accountManager: accountManager, claimsExtractor: claimsExtractor}
+		return handler.GetIPPool
+	}, []handlerTest{
+		{
+			name:           "admin user",
+			method:         http.MethodGet,
+			path:           "/api/ipam",
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, api.IPPool{
+					Network:     "100.64.0.0/29",
+					Capacity:    6,
+					Used:        2,
+					Free:        4,
+					Utilization: pool.Utilization(),
+					Allocations: []api.IPAllocation{
+						{Ip: "100.64.0.1", PeerId: "peer1", PeerName: "router", Static: true},
+						{Ip: "100.64.0.3", PeerId: "peer2", PeerName: "laptop"},
+					},
+				}, decodeTestResponse[api.IPPool](t, recorder))
+			},
+		},
+		{
+			name:           "regular user",
+			userID:         fakeRegularUserID,
+			method:         http.MethodGet,
+			path:           "/api/ipam",
+			expectedStatus: http.StatusForbidden,
+		},
+	})
+}
+
+func TestAssignPeerIP(t *testing.T) {
+	accountManager := newFakeAccountManager(newTestAccountBuilder().build())
+	accountManager.AssignPeerIPFunc = func(_, _, peerID string, ip net.IP) (*nbpeer.Peer, error) {
+		switch {
+		case peerID != "peer1":
+			return nil, status.Errorf(status.NotFound, "peer %s not found", peerID)
+		case ip.Equal(net.ParseIP("100.64.0.3")):
+			return nil, status.Errorf(status.AlreadyExists, "peer IP %s is already assigned", ip)
+		}
+		return &nbpeer.Peer{ID: peerID, Name: "router", IP: ip, StaticIP: true}, nil
+	}
+
+	runHandlerTests(t, "/api/ipam/allocations/{peerId}", func(claimsExtractor *jwtclaims.ClaimsExtractor) http.HandlerFunc {
+		handler := &IPAMHandler{accountManager: accountManager, claimsExtractor: claimsExtractor}
+		return handler.AssignPeerIP
+	}, []handlerTest{
+		{
+			name:           "assign a free IP",
+			method:         http.MethodPut,
+			path:           "/api/ipam/allocations/peer1",
+			body:           api.IPAllocationRequest{Ip: "100.64.0.5"},
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, api.IPAllocation{Ip: "100.64.0.5", PeerId: "peer1", PeerName: "router", Static: true},
+					decodeTestResponse[api.IPAllocation](t, recorder))
+			},
+		},
+		{
+			name:           "invalid IP",
+			method:         http.MethodPut,
+			path:           "/api/ipam/allocations/peer1",
+			body:           api.IPAllocationRequest{Ip: "100.64.0"},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "IP of another peer",
+			method:         http.MethodPut,
+			path:           "/api/ipam/allocations/peer1",
+			body:           api.IPAllocationRequest{Ip: "100.64.0.3"},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "unknown peer",
+			method:         http.MethodPut,
+			path:           "/api/ipam/allocations/peer2",
+			body:           api.IPAllocationRequest{Ip: "100.64.0.5"},
+			expectedStatus: http.StatusNotFound,
+		},
+	})
+}
@@ -36,6 +36,8 @@ func NewAccessControl(audience, userIDClaim string, getUser GetUser) *AccessCont
 
 var tokenPathRegexp = regexp.MustCompile(`^.*/api/users/.*/tokens.*$`)
 
+var notificationsPathRegexp = regexp.MustCompile(`^.*/api/users/.*/notifications$`)
+
 // Handler method of the middleware which forbids all modify requests for non admin users
 func (a *AccessControl) Handler(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -62,7 +64,7 @@ func (a *AccessControl) Handler(h http.Handler) http.Handler {
 			switch r.Method {
 			case http.MethodDelete, http.MethodPost, http.MethodPatch, http.MethodPut:
 
-				if tokenPathRegexp.MatchString(r.URL.Path) {
+				if tokenPathRegexp.MatchString(r.URL.Path) || notificationsPathRegexp.MatchString(r.URL.Path) {
 					log.Debugf("valid Path")
 					h.ServeHTTP(w, r)
 					return
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/telemetry"
+)
+
+const redactedValue = "REDACTED"
+
+// defaultRedactedQueryParams are the query parameters usually holding secrets, they are always redacted
+var defaultRedactedQueryParams = []string{"token", "access_token", "key", "setup_key", "password", "secret"}
+
+// AccessLog middleware to write a structured log entry for the requests to the HTTP API with the identity of their
+// initiator
+type AccessLog struct {
+	logger          *log.Logger
+	claimsExtractor *jwtclaims.ClaimsExtractor
+	sampleRate      float64
+	redactedParams  map[string]struct{}
+	redactUserIDs   bool
+	// sample tells whether a request answered with a status below 400 is logged
+	sample func() bool
+}
+
+// NewAccessLog instance constructor
+func NewAccessLog(cfg server.AccessLogConfig, claimsExtractor *jwtclaims.ClaimsExtractor) *AccessLog {
+	sampleRate := cfg.SampleRate
+	if sampleRate <= 0 || sampleRate > 1 {
+		sampleRate = 1
+	}
+
+	redactedParams := make(map[string]struct{})
+	for _, param := range defaultRedactedQueryParams {
+		redactedParams[param] = struct{}{}
+	}
+	for _, param := range cfg.RedactQueryParams {
+		redactedParams[strings.ToLower(param)] = struct{}{}
+	}
+
+	return &AccessLog{
+		logger:          log.StandardLogger(),
+		claimsExtractor: claimsExtractor,
+		sampleRate:      sampleRate,
+		redactedParams:  redactedParams,
+		redactUserIDs:   cfg.RedactUserIDs,
+		sample: func() bool {
+			return rand.Float64() < sampleRate
+		},
+	}
+}
+
+// Handler method of the middleware which logs the request once it is answered. It has to run before the
+// authentication middleware, which sets the claims of the initiator on the request.
+func (a *AccessLog) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		w := telemetry.WrapResponseWriter(rw)
+
+		h.ServeHTTP(w, r)
+
+		status := w.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status < http.StatusBadRequest && a.sampleRate < 1 && !a.sample() {
+			return
+		}
+
+		claims := a.claimsExtractor.FromRequestContext(r)
+		patID, _ := r.Context().Value(patIDProperty).(string)
+
+		fields := log.Fields{
+			"method":     r.Method,
+			"path":       a.redactPath(r.URL),
+			"route":      routeTemplate(r),
+			"status":     status,
+			"latency_ms": time.Since(start).Milliseconds(),
+			"user_id":    a.redactUserID(claims.UserId),
+			"pat_id":     patID,
+			"account_id": claims.AccountId,
+		}
+		if a.sampleRate < 1 {
+			fields["sample_rate"] = a.sampleRate
+		}
+
+		a.logger.WithFields(fields).Info("HTTP API access")
+	})
+}
+
+// redactPath returns the path of the request with the values of the redacted query parameters replaced
+func (a *AccessLog) redactPath(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Path
+	}
+
+	query := u.Query()
+	for param := range query {
+		if _, ok := a.redactedParams[strings.ToLower(param)]; ok {
+			query[param] = []string{redactedValue}
+		}
+	}
+	return u.Path + "?" + query.Encode()
+}
+
+// redactUserID returns the user ID or the beginning of its SHA-256 hash when the user IDs are redacted
+func (a *AccessLog) redactUserID(userID string) string {
+	if !a.redactUserIDs || userID == "" {
+		return userID
+	}
+	sum := sha256.Sum256([]byte(userID))
+	return hex.EncodeToString(sum[:8])
+}
+
+// routeTemplate returns the path template of the route the request matched, e.g. /api/peers/{peerId}
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return template
+}
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+)
+
+func TestAccessLog_Handler(t *testing.T) {
+	tt := []struct {
+		name           string
+		cfg            server.AccessLogConfig
+		path           string
+		handlerStatus  int
+		sampled        bool
+		expectLogged   bool
+		expectedPath   string
+		expectedUserID string
+	}{
+		{
+			name:           "logs the request with its initiator",
+			path:           "/api/peers/peer1",
+			handlerStatus:  http.StatusOK,
+			expectLogged:   true,
+			expectedPath:   "/api/peers/peer1",
+			expectedUserID: userID,
+		},
+		{
+			name:           "redacts the query parameters holding secrets",
+			cfg:            server.AccessLogConfig{RedactQueryParams: []string{"Email"}},
+			path:           "/api/peers/peer1?setup_key=secret&email=user@example.com&limit=10",
+			handlerStatus:  http.StatusOK,
+			expectLogged:   true,
+			expectedPath:   "/api/peers/peer1?email=REDACTED&limit=10&setup_key=REDACTED",
+			expectedUserID: userID,
+		},
+		{
+			name:          "hashes the user IDs",
+			cfg:           server.AccessLogConfig{RedactUserIDs: true},
+			path:          "/api/peers/peer1",
+			handlerStatus: http.StatusOK,
+			expectLogged:  true,
+			expectedPath:  "/api/peers/peer1",
+		},
+		{
+			name:          "skips the successful requests left out of the sample",
+			cfg:           server.AccessLogConfig{SampleRate: 0.1},
+			path:          "/api/peers/peer1",
+			handlerStatus: http.StatusOK,
+		},
+		{
+			name:           "logs the sampled successful requests",
+			cfg:            server.AccessLogConfig{SampleRate: 0.1},
+			path:           "/api/peers/peer1",
+			handlerStatus:  http.StatusOK,
+			sampled:        true,
+			expectLogged:   true,
+			expectedPath:   "/api/peers/peer1",
+			expectedUserID: userID,
+		},
+		{
+			name:           "always logs the failed requests",
+			cfg:            server.AccessLogConfig{SampleRate: 0.1},
+			path:           "/api/peers/peer1",
+			handlerStatus:  http.StatusNotFound,
+			expectLogged:   true,
+			expectedPath:   "/api/peers/peer1",
+			expectedUserID: userID,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			claimsExtractor := jwtclaims.NewClaimsExtractor(
+				jwtclaims.WithAudience(audience),
+				jwtclaims.WithUserIDClaim(userIDClaim),
+			)
+			accessLog := NewAccessLog(tc.cfg, claimsExtractor)
+			logger, hook := test.NewNullLogger()
+			accessLog.logger = logger
+			accessLog.sample = func() bool { return tc.sampled }
+
+			// authenticates the request the way the auth middleware does
+			authenticate := func(h http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+						userIDClaim:                          userID,
+						audience + jwtclaims.AccountIDSuffix: accountID,
+					})
+					ctx := context.WithValue(r.Context(), jwtclaims.TokenUserProperty, token) //nolint
+					*r = *r.WithContext(context.WithValue(ctx, patIDProperty, tokenID))       //nolint
+					h.ServeHTTP(w, r)
+				})
+			}
+
+			router := mux.NewRouter()
+			router.Use(accessLog.Handler, authenticate)
+			router.HandleFunc("/api/peers/{peerId}", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.handlerStatus)
+			}).Methods(http.MethodGet)
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if !tc.expectLogged {
+				assert.Empty(t, hook.AllEntries(), "the request shouldn't be logged")
+				return
+			}
+
+			require.Len(t, hook.AllEntries(), 1)
+			fields := hook.LastEntry().Data
+			assert.Equal(t, http.MethodGet, fields["method"])
+			assert.Equal(t, tc.expectedPath, fields["path"])
+			assert.Equal(t, "/api/peers/{peerId}", fields["route"])
+			assert.Equal(t, tc.handlerStatus, fields["status"])
+			assert.Equal(t, accountID, fields["account_id"])
+			assert.Equal(t, tokenID, fields["pat_id"])
+			if tc.cfg.RedactUserIDs {
+				assert.NotEqual(t, userID, fields["user_id"], "the user ID should be hashed")
+				assert.Len(t, fields["user_id"], 16)
+			} else {
+				assert.Equal(t, tc.expectedUserID, fields["user_id"])
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/netbirdio/netbird/management/server/http/middleware/bypass"
+	"github.com/netbirdio/netbird/management/server/http/util"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+)
+
+// CountAPIRequest function defines a function to count a request of the account of the claims against its quota
+type CountAPIRequest func(claims jwtclaims.AuthorizationClaims) error
+
+// APIQuota middleware to reject the requests of the accounts that exceeded their HTTP API quota
+type APIQuota struct {
+	claimsExtractor *jwtclaims.ClaimsExtractor
+	countRequest    CountAPIRequest
+}
+
+// NewAPIQuota instance constructor
+func NewAPIQuota(countRequest CountAPIRequest, claimsExtractor *jwtclaims.ClaimsExtractor) *APIQuota {
+	return &APIQuota{
+		claimsExtractor: claimsExtractor,
+		countRequest:    countRequest,
+	}
+}
+
+// Handler method of the middleware which counts the requests and answers the ones over the quota with 429
+func (q *APIQuota) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if bypass.ShouldBypass(r.URL.Path, h, w, r) {
+			return
+		}
+
+		claims := q.claimsExtractor.FromRequestContext(r)
+		if err := q.countRequest(claims); err != nil {
+			util.WriteError(err, w)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestAPIQuota_Handler(t *testing.T) {
+	counted := map[string]int{}
+	quota := NewAPIQuota(func(claims jwtclaims.AuthorizationClaims) error {
+		counted[claims.UserId]++
+		if counted[claims.UserId] > 2 {
+			return status.Errorf(status.ResourceExhausted, "the account exceeded its quota of 2 API requests per minute")
+		}
+		return nil
+	}, jwtclaims.NewClaimsExtractor(jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+		return jwtclaims.AuthorizationClaims{UserId: "user"}
+	})))
+	handler := quota.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	var codes []int
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/peers", nil)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		codes = append(codes, recorder.Code)
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+}
@@ -43,6 +43,8 @@ type AuthMiddleware struct {
 
 const (
 	userProperty = "user"
+	// patIDProperty is the context key of the ID of the personal access token the request was authenticated with
+	patIDProperty = "pat_id"
 )
 
 // NewAuthMiddleware instance constructor
@@ -171,7 +173,8 @@ func (m *AuthMiddleware) checkPATFromRequest(w http.ResponseWriter, r *http.Requ
 	claimMaps[m.audience+jwtclaims.DomainIDSuffix] = account.Domain
 	claimMaps[m.audience+jwtclaims.DomainCategorySuffix] = account.DomainCategory
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claimMaps)
-	newRequest := r.WithContext(context.WithValue(r.Context(), jwtclaims.TokenUserProperty, jwtToken)) //nolint
+	ctx := context.WithValue(r.Context(), jwtclaims.TokenUserProperty, jwtToken) //nolint
+	newRequest := r.WithContext(context.WithValue(ctx, patIDProperty, pat.ID))   //nolint
 	// Update the current request with the new context information.
 	*r = *newRequest
 	return nil
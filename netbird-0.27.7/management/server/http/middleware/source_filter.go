@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/util"
+)
+
+// SourceFilter middleware to block the HTTP requests from the filtered source addresses before they are authenticated
+type SourceFilter struct {
+	filter *server.SourceFilter
+}
+
+// NewSourceFilter instance constructor
+func NewSourceFilter(rules server.SourceFilterRules) *SourceFilter {
+	return &SourceFilter{filter: server.NewSourceFilter("http", rules)}
+}
+
+// Handler method of the middleware which blocks the requests from the filtered sources
+func (m *SourceFilter) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.filter.Check(r.RemoteAddr, server.IsUnixSocketRequest(r.Context()), r.Method+" "+r.URL.Path) {
+			util.WriteErrorResponse("source address not allowed", http.StatusForbidden, w)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/management/server"
+)
+
+func TestSourceFilter_Handler(t *testing.T) {
+	filter := NewSourceFilter(server.SourceFilterRules{Allow: []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24")}})
+	handler := filter.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/peers", nil)
+	req.RemoteAddr = "192.168.1.10:4321"
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	req.RemoteAddr = "203.0.113.1:4321"
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	req.RemoteAddr = "@"
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusForbidden, recorder.Code, "an unparseable address out of a unix socket is blocked")
+
+	unixSocketListener := &net.UnixAddr{Name: "/var/run/netbird-mgmt-http.sock", Net: "unix"}
+	req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, unixSocketListener))
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code, "the unix socket requests aren't filtered")
+}
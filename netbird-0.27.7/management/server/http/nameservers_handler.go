@@ -69,7 +69,7 @@ func (h *NameserversHandler) CreateNameserverGroup(w http.ResponseWriter, r *htt
 	var req api.PostApiDnsNameserversJSONRequestBody
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		util.WriteJSONParseError(err, w)
 		return
 	}
 
@@ -79,7 +79,12 @@ func (h *NameserversHandler) CreateNameserverGroup(w http.ResponseWriter, r *htt
 		return
 	}
 
-	nsGroup, err := h.accountManager.CreateNameServerGroup(account.Id, req.Name, req.Description, nsList, req.Groups, req.Primary, req.Domains, req.Enabled, user.Id, req.SearchDomainsEnabled)
+	var priority int
+	if req.Priority != nil {
+		priority = *req.Priority
+	}
+
+	nsGroup, err := h.accountManager.CreateNameServerGroup(account.Id, req.Name, req.Description, nsList, req.Groups, req.Primary, req.Domains, req.Enabled, user.Id, req.SearchDomainsEnabled, priority)
 	if err != nil {
 		util.WriteError(err, w)
 		return
@@ -108,7 +113,7 @@ func (h *NameserversHandler) UpdateNameserverGroup(w http.ResponseWriter, r *htt
 	var req api.PutApiDnsNameserversNsgroupIdJSONRequestBody
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		util.WriteJSONParseError(err, w)
 		return
 	}
 
@@ -129,6 +134,9 @@ func (h *NameserversHandler) UpdateNameserverGroup(w http.ResponseWriter, r *htt
 		Enabled:              req.Enabled,
 		SearchDomainsEnabled: req.SearchDomainsEnabled,
 	}
+	if req.Priority != nil {
+		updatedNSGroup.Priority = *req.Priority
+	}
 
 	err = h.accountManager.SaveNameServerGroup(account.Id, user.Id, updatedNSGroup)
 	if err != nil {
@@ -199,6 +207,15 @@ func toServerNSList(apiNSList []api.Nameserver) ([]nbdns.NameServer, error) {
 		if err != nil {
 			return nil, err
 		}
+		if apiNS.ServerName != nil {
+			parsed.ServerName = *apiNS.ServerName
+		}
+		if apiNS.SpkiPins != nil {
+			parsed.SPKIPins = *apiNS.SpkiPins
+		}
+		if apiNS.Path != nil {
+			parsed.Path = *apiNS.Path
+		}
 		nsList = append(nsList, parsed)
 	}
 
@@ -213,6 +230,15 @@ func toNameserverGroupResponse(serverNSGroup *nbdns.NameServerGroup) *api.Namese
 			NsType: api.NameserverNsType(ns.NSType.String()),
 			Port:   ns.Port,
 		}
+		if serverName := ns.ServerName; serverName != "" {
+			apiNS.ServerName = &serverName
+		}
+		if pins := ns.SPKIPins; len(pins) != 0 {
+			apiNS.SpkiPins = &pins
+		}
+		if path := ns.Path; path != "" {
+			apiNS.Path = &path
+		}
 		nsList = append(nsList, apiNS)
 	}
 
@@ -226,5 +252,6 @@ func toNameserverGroupResponse(serverNSGroup *nbdns.NameServerGroup) *api.Namese
 		Nameservers:          nsList,
 		Enabled:              serverNSGroup.Enabled,
 		SearchDomainsEnabled: serverNSGroup.SearchDomainsEnabled,
+		Priority:             &serverNSGroup.Priority,
 	}
 }
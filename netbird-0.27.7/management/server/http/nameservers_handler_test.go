@@ -67,7 +67,7 @@ func initNameserversTestData() *NameserversHandler {
 				}
 				return nil, status.Errorf(status.NotFound, "nameserver group with ID %s not found", nsGroupID)
 			},
-			CreateNameServerGroupFunc: func(accountID string, name, description string, nameServerList []nbdns.NameServer, groups []string, primary bool, domains []string, enabled bool, _ string, searchDomains bool) (*nbdns.NameServerGroup, error) {
+			CreateNameServerGroupFunc: func(accountID string, name, description string, nameServerList []nbdns.NameServer, groups []string, primary bool, domains []string, enabled bool, _ string, searchDomains bool, priority int) (*nbdns.NameServerGroup, error) {
 				return &nbdns.NameServerGroup{
 					ID:                   existingNSGroupID,
 					Name:                 name,
@@ -78,6 +78,7 @@ func initNameserversTestData() *NameserversHandler {
 					Primary:              primary,
 					Domains:              domains,
 					SearchDomainsEnabled: searchDomains,
+					Priority:             priority,
 				}, nil
 			},
 			DeleteNameServerGroupFunc: func(accountID, nsGroupID, _ string) error {
@@ -106,6 +107,11 @@ func initNameserversTestData() *NameserversHandler {
 }
 
 func TestNameserversHandlers(t *testing.T) {
+	dohServerName := "cloudflare-dns.com"
+	dohPath := "/dns-query"
+	nsPriority := 100
+	nsDefaultPriority := 0
+
 	tt := []struct {
 		name            string
 		expectedStatus  int
@@ -134,7 +140,7 @@ func TestNameserversHandlers(t *testing.T) {
 			requestType: http.MethodPost,
 			requestPath: "/api/dns/nameservers",
 			requestBody: bytes.NewBuffer(
-				[]byte("{\"name\":\"name\",\"Description\":\"Post\",\"nameservers\":[{\"ip\":\"1.1.1.1\",\"ns_type\":\"udp\",\"port\":53}],\"groups\":[\"group\"],\"enabled\":true,\"primary\":true}")),
+				[]byte("{\"name\":\"name\",\"Description\":\"Post\",\"nameservers\":[{\"ip\":\"1.1.1.1\",\"ns_type\":\"udp\",\"port\":53}],\"groups\":[\"group\"],\"enabled\":true,\"primary\":true,\"priority\":100}")),
 			expectedStatus: http.StatusOK,
 			expectedBody:   true,
 			expectedNSGroup: &api.NameserverGroup{
@@ -148,9 +154,38 @@ func TestNameserversHandlers(t *testing.T) {
 						Port:   53,
 					},
 				},
-				Groups:  []string{"group"},
-				Enabled: true,
-				Primary: true,
+				Groups:   []string{"group"},
+				Enabled:  true,
+				Primary:  true,
+				Priority: &nsPriority,
+			},
+		},
+		{
+			name:        "POST DNS over HTTPS Nameserver",
+			requestType: http.MethodPost,
+			requestPath: "/api/dns/nameservers",
+			requestBody: bytes.NewBuffer(
+				[]byte("{\"name\":\"name\",\"Description\":\"Post\",\"nameservers\":[{\"ip\":\"1.1.1.1\",\"ns_type\":\"https\",\"port\":443,\"server_name\":\"cloudflare-dns.com\",\"spki_pins\":[\"jIRB2sX5MlBKWAaI6HXXdhAjHMXPU+KoijDbDRq2T4A=\"],\"path\":\"/dns-query\"}],\"groups\":[\"group\"],\"enabled\":true,\"primary\":true}")),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedNSGroup: &api.NameserverGroup{
+				Id:          existingNSGroupID,
+				Name:        "name",
+				Description: "Post",
+				Nameservers: []api.Nameserver{
+					{
+						Ip:         "1.1.1.1",
+						NsType:     "https",
+						Port:       443,
+						ServerName: &dohServerName,
+						SpkiPins:   &[]string{"jIRB2sX5MlBKWAaI6HXXdhAjHMXPU+KoijDbDRq2T4A="},
+						Path:       &dohPath,
+					},
+				},
+				Groups:   []string{"group"},
+				Enabled:  true,
+				Primary:  true,
+				Priority: &nsDefaultPriority,
 			},
 		},
 		{
@@ -167,7 +202,7 @@ func TestNameserversHandlers(t *testing.T) {
 			requestType: http.MethodPut,
 			requestPath: "/api/dns/nameservers/" + existingNSGroupID,
 			requestBody: bytes.NewBuffer(
-				[]byte("{\"name\":\"name\",\"Description\":\"Post\",\"nameservers\":[{\"ip\":\"1.1.1.1\",\"ns_type\":\"udp\",\"port\":53}],\"groups\":[\"group\"],\"enabled\":true,\"primary\":true}")),
+				[]byte("{\"name\":\"name\",\"Description\":\"Post\",\"nameservers\":[{\"ip\":\"1.1.1.1\",\"ns_type\":\"udp\",\"port\":53}],\"groups\":[\"group\"],\"enabled\":true,\"primary\":true,\"priority\":100}")),
 			expectedStatus: http.StatusOK,
 			expectedBody:   true,
 			expectedNSGroup: &api.NameserverGroup{
@@ -181,9 +216,10 @@ func TestNameserversHandlers(t *testing.T) {
 						Port:   53,
 					},
 				},
-				Groups:  []string{"group"},
-				Enabled: true,
-				Primary: true,
+				Groups:   []string{"group"},
+				Enabled:  true,
+				Primary:  true,
+				Priority: &nsPriority,
 			},
 		},
 		{
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/http/util"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+)
+
+// PeerQuotasHandler is a handler that returns the number of peers of the users and setup keys against their limits
+type PeerQuotasHandler struct {
+	accountManager  server.AccountManager
+	claimsExtractor *jwtclaims.ClaimsExtractor
+}
+
+// NewPeerQuotasHandler creates a new PeerQuotasHandler HTTP handler
+func NewPeerQuotasHandler(accountManager server.AccountManager, authCfg AuthCfg) *PeerQuotasHandler {
+	return &PeerQuotasHandler{
+		accountManager: accountManager,
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithAudience(authCfg.Audience),
+			jwtclaims.WithUserIDClaim(authCfg.UserIDClaim),
+		),
+	}
+}
+
+// GetPeerQuotas returns the peer quota usage of the account
+func (h *PeerQuotasHandler) GetPeerQuotas(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	usage, err := h.accountManager.GetPeerQuotaUsage(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toPeerQuotaUsageResponse(usage))
+}
+
+func toPeerQuotaUsageResponse(usage *server.PeerQuotaUsage) *api.PeerQuotaUsage {
+	users := make([]api.UserPeerQuota, 0, len(usage.Users))
+	for _, quota := range usage.Users {
+		users = append(users, api.UserPeerQuota{
+			UserId: quota.UserID,
+			Peers:  quota.Peers,
+			Limit:  quota.Limit,
+		})
+	}
+
+	setupKeys := make([]api.SetupKeyPeerQuota, 0, len(usage.SetupKeys))
+	for _, quota := range usage.SetupKeys {
+		setupKeys = append(setupKeys, api.SetupKeyPeerQuota{
+			SetupKeyId: quota.SetupKeyID,
+			Name:       quota.Name,
+			Peers:      quota.Peers,
+			Limit:      quota.Limit,
+		})
+	}
+
+	return &api.PeerQuotaUsage{
+		PeersPerUserLimit: usage.PeersPerUserLimit,
+		Users:             users,
+		SetupKeys:         setupKeys,
+	}
+}
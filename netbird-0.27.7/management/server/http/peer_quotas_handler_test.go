@@ -0,0 +1,56 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestGetPeerQuotas(t *testing.T) {
+	usage := &server.PeerQuotaUsage{
+		PeersPerUserLimit: 5,
+		Users:             []server.UserPeerQuota{{UserID: fakeAdminUserID, Peers: 2, Limit: 5}},
+		SetupKeys:         []server.SetupKeyPeerQuota{{SetupKeyID: "key1", Name: "office", Peers: 10, Limit: 10}},
+	}
+
+	accountManager := newFakeAccountManager(newTestAccountBuilder().build())
+	accountManager.GetPeerQuotaUsageFunc = func(_, userID string) (*server.PeerQuotaUsage, error) {
+		if userID != fakeAdminUserID {
+			return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view the peer quotas")
+		}
+		return usage, nil
+	}
+
+	runHandlerTests(t, "/api/peer-quotas", func(claimsExtractor *jwtclaims.ClaimsExtractor) http.HandlerFunc {
+		handler := &PeerQuotasHandler{accountManager: accountManager, claimsExtractor: claimsExtractor}
+		return handler.GetPeerQuotas
+	}, []handlerTest{
+		{
+			name:           "admin user",
+			method:         http.MethodGet,
+			path:           "/api/peer-quotas",
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, api.PeerQuotaUsage{
+					PeersPerUserLimit: 5,
+					Users:             []api.UserPeerQuota{{UserId: fakeAdminUserID, Peers: 2, Limit: 5}},
+					SetupKeys:         []api.SetupKeyPeerQuota{{SetupKeyId: "key1", Name: "office", Peers: 10, Limit: 10}},
+				}, decodeTestResponse[api.PeerQuotaUsage](t, recorder))
+			},
+		},
+		{
+			name:           "regular user",
+			userID:         fakeRegularUserID,
+			method:         http.MethodGet,
+			path:           "/api/peer-quotas",
+			expectedStatus: http.StatusForbidden,
+		},
+	})
+}
@@ -1,12 +1,17 @@
 package http
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
 
 	"github.com/netbirdio/netbird/management/server"
 	nbgroup "github.com/netbirdio/netbird/management/server/group"
@@ -74,14 +79,16 @@ func (h *PeersHandler) getPeer(account *server.Account, peerID, userID string, w
 	accessiblePeers := toAccessiblePeers(netMap, dnsDomain)
 
 	_, valid := validPeers[peer.ID]
-	util.WriteJSONObject(w, toSinglePeerResponse(peerToReturn, groupsInfo, dnsDomain, accessiblePeers, valid))
+	resp := toSinglePeerResponse(peerToReturn, groupsInfo, dnsDomain, accessiblePeers, valid)
+	resp.LoginExpiresAt = toLoginExpiresAt(account, peer)
+	util.WriteJSONObject(w, resp)
 }
 
 func (h *PeersHandler) updatePeer(account *server.Account, user *server.User, peerID string, w http.ResponseWriter, r *http.Request) {
 	req := &api.PeerRequest{}
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		util.WriteJSONParseError(err, w)
 		return
 	}
 
@@ -92,6 +99,21 @@ func (h *PeersHandler) updatePeer(account *server.Account, user *server.User, pe
 		LoginExpirationEnabled: req.LoginExpirationEnabled,
 	}
 
+	if req.Ip != nil {
+		update.IP = net.ParseIP(*req.Ip)
+		if update.IP == nil {
+			util.WriteError(status.FieldErrorf(status.InvalidArgument, "ip", "invalid peer IP %s", *req.Ip), w)
+			return
+		}
+	}
+
+	// the uplink is kept unless the request sets it, so clients unaware of it don't reset it
+	if req.Uplink != nil {
+		update.Uplink = *req.Uplink
+	} else if current := account.GetPeer(peerID); current != nil {
+		update.Uplink = current.Uplink
+	}
+
 	if req.ApprovalRequired != nil {
 		// todo: looks like that we reset all status property, is it right?
 		update.Status = &nbpeer.PeerStatus{
@@ -119,7 +141,9 @@ func (h *PeersHandler) updatePeer(account *server.Account, user *server.User, pe
 
 	_, valid := validPeers[peer.ID]
 
-	util.WriteJSONObject(w, toSinglePeerResponse(peer, groupMinimumInfo, dnsDomain, accessiblePeers, valid))
+	resp := toSinglePeerResponse(peer, groupMinimumInfo, dnsDomain, accessiblePeers, valid)
+	resp.LoginExpiresAt = toLoginExpiresAt(account, peer)
+	util.WriteJSONObject(w, resp)
 }
 
 func (h *PeersHandler) deletePeer(accountID, userID string, peerID string, w http.ResponseWriter) {
@@ -162,6 +186,467 @@ func (h *PeersHandler) HandlePeer(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetPeerTransferStats returns the WireGuard transfer statistics last reported by the peer
+func (h *PeersHandler) GetPeerTransferStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.WriteError(status.Errorf(status.NotFound, "unknown METHOD"), w)
+		return
+	}
+
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	peerID := mux.Vars(r)["peerId"]
+	if len(peerID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid peer ID"), w)
+		return
+	}
+
+	stats, err := h.accountManager.GetPeerTransferStats(account.Id, peerID, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toPeerTransferStatsResponse(account, stats))
+}
+
+// GetRelayedConnections returns the connections between the peers of the account going through a relay, as last
+// reported by the peers
+func (h *PeersHandler) GetRelayedConnections(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.WriteError(status.Errorf(status.NotFound, "unknown METHOD"), w)
+		return
+	}
+
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	conns, err := h.accountManager.GetRelayedConnections(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toRelayedConnectionsResponse(account, conns))
+}
+
+// GetPeersInventory returns the software inventory of the peers matching the filters of the query in JSON or CSV
+func (h *PeersHandler) GetPeersInventory(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	accountID, userID, err := h.accountManager.GetAccountIDFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		util.WriteError(status.FieldErrorf(status.InvalidArgument, "format", "unsupported inventory format %s, expected json or csv", format), w)
+		return
+	}
+
+	filter := server.InventoryFilter{
+		OS:           query.Get("os"),
+		Package:      query.Get("package"),
+		VersionBelow: query.Get("version_below"),
+		PatchLevel:   query.Get("patch_level"),
+	}
+	peers, err := h.accountManager.GetPeersInventory(accountID, userID, filter)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	if format == "csv" {
+		writePeersInventoryCSV(w, peers)
+		return
+	}
+
+	inventories := make([]*api.PeerInventory, 0, len(peers))
+	for _, peer := range peers {
+		inventories = append(inventories, toPeerInventoryResponse(peer))
+	}
+	util.WriteJSONObject(w, inventories)
+}
+
+// GetPeerDNSStats returns the DNS query counters last reported by the peer
+func (h *PeersHandler) GetPeerDNSStats(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	peerID := mux.Vars(r)["peerId"]
+	if len(peerID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid peer ID"), w)
+		return
+	}
+
+	stats, err := h.accountManager.GetPeerDNSStats(account.Id, peerID, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, &api.PeerDNSStats{
+		LocalQueries:     int64(stats.LocalQueries),
+		ForwardedQueries: int64(stats.ForwardedQueries),
+		BlockedQueries:   int64(stats.BlockedQueries),
+		FailedQueries:    int64(stats.FailedQueries),
+		AverageLatency:   stats.AverageLatency.Milliseconds(),
+		ReportedAt:       stats.ReportedAt,
+	})
+}
+
+// GetPeerDiagnostics returns the diagnostic data last reported by the peer
+func (h *PeersHandler) GetPeerDiagnostics(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	peerID := mux.Vars(r)["peerId"]
+	if len(peerID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid peer ID"), w)
+		return
+	}
+
+	diagnostics, err := h.accountManager.GetPeerDiagnostics(account.Id, peerID, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toPeerDiagnosticsResponse(diagnostics))
+}
+
+// GetPeerNetworkMap returns the network map the server pushes to the peer, in the JSON mapping of the peer protocol
+func (h *PeersHandler) GetPeerNetworkMap(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	peerID := mux.Vars(r)["peerId"]
+	if len(peerID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid peer ID"), w)
+		return
+	}
+
+	networkMap, err := h.accountManager.GetPeerNetworkMapDump(account.Id, peerID, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	// the unpopulated fields are kept, an empty list tells as much as a populated one when debugging
+	encoded, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(networkMap)
+	if err != nil {
+		log.Errorf("failed to encode the network map of peer %s: %v", peerID, err)
+		util.WriteError(status.Errorf(status.Internal, "failed to encode the network map"), w)
+		return
+	}
+
+	util.WriteJSONObject(w, json.RawMessage(encoded))
+}
+
+// RequestPeerAction sends a remote action to the peer, the peer reports its result as an activity event
+func (h *PeersHandler) RequestPeerAction(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	peerID := mux.Vars(r)["peerId"]
+	if len(peerID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid peer ID"), w)
+		return
+	}
+
+	req := &api.PostApiPeersPeerIdActionsJSONRequestBody{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteJSONParseError(err, w)
+		return
+	}
+
+	id, err := h.accountManager.RequestPeerAction(account.Id, peerID, user.Id, string(req.Action))
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, &api.PeerActionResponse{Id: id})
+}
+
+// RequestPeerPing makes the peer probe another peer over the WireGuard tunnel, the result is read with GetPeerPing
+func (h *PeersHandler) RequestPeerPing(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	peerID := mux.Vars(r)["peerId"]
+	if len(peerID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid peer ID"), w)
+		return
+	}
+
+	req := &api.PostApiPeersPeerIdPingJSONRequestBody{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteJSONParseError(err, w)
+		return
+	}
+	if req.TargetPeerId == "" {
+		util.WriteError(status.FieldErrorf(status.InvalidArgument, "target_peer_id", "target_peer_id is required"), w)
+		return
+	}
+
+	id, err := h.accountManager.RequestPeerPing(account.Id, peerID, user.Id, req.TargetPeerId)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, &api.PeerActionResponse{Id: id})
+}
+
+// GetPeerPing returns the status and the result of a ping the peer was requested to run
+func (h *PeersHandler) GetPeerPing(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	vars := mux.Vars(r)
+	peerID := vars["peerId"]
+	pingID := vars["pingId"]
+	if len(peerID) == 0 || len(pingID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid peer or ping ID"), w)
+		return
+	}
+
+	ping, err := h.accountManager.GetPeerPing(account.Id, peerID, user.Id, pingID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toPeerPingResponse(ping))
+}
+
+func toPeerPingResponse(ping *server.PeerPing) *api.PeerPing {
+	resp := &api.PeerPing{
+		Id:           ping.ID,
+		TargetPeerId: ping.TargetPeerID,
+		RequestedAt:  ping.RequestedAt,
+		Status:       api.PeerPingStatusPending,
+	}
+	if !ping.Done {
+		return resp
+	}
+
+	resp.Status = api.PeerPingStatusDone
+	if ping.Error != "" {
+		resp.Status = api.PeerPingStatusFailed
+		resp.Error = &ping.Error
+	}
+	if ping.Result == nil {
+		return resp
+	}
+
+	result := ping.Result
+	resp.Result = &api.PeerPingResult{
+		Ip:              result.IP,
+		Fqdn:            result.FQDN,
+		Sent:            result.Sent,
+		Received:        result.Received,
+		Path:            result.Path,
+		LocalCandidate:  result.LocalCandidate,
+		RemoteCandidate: result.RemoteCandidate,
+	}
+	if len(result.RTTs) > 0 {
+		var total time.Duration
+		for _, rtt := range result.RTTs {
+			total += rtt
+		}
+		resp.Result.RttMinMs = durationMs(slices.Min(result.RTTs))
+		resp.Result.RttMaxMs = durationMs(slices.Max(result.RTTs))
+		resp.Result.RttAvgMs = durationMs(total / time.Duration(len(result.RTTs)))
+	}
+	return resp
+}
+
+// GetPeerGeofence returns the geofence state of the peer and its override
+func (h *PeersHandler) GetPeerGeofence(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	peerID := mux.Vars(r)["peerId"]
+	if len(peerID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid peer ID"), w)
+		return
+	}
+
+	geofence, err := h.accountManager.GetPeerGeofence(account.Id, user.Id, peerID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toPeerGeofenceResponse(geofence))
+}
+
+// HandlePeerGeofenceOverride requests, approves or revokes the geofence override of the peer
+func (h *PeersHandler) HandlePeerGeofenceOverride(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	peerID := mux.Vars(r)["peerId"]
+	if len(peerID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid peer ID"), w)
+		return
+	}
+
+	var geofence *server.PeerGeofence
+	switch r.Method {
+	case http.MethodPost:
+		var req api.PostApiPeersPeerIdGeofenceOverrideJSONRequestBody
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			util.WriteJSONParseError(err, w)
+			return
+		}
+		if req.Reason == "" {
+			util.WriteError(status.FieldErrorf(status.InvalidArgument, "reason", "reason is required"), w)
+			return
+		}
+		geofence, err = h.accountManager.RequestPeerGeofenceOverride(account.Id, user.Id, peerID, req.Reason)
+	case http.MethodPut:
+		var req api.PutApiPeersPeerIdGeofenceOverrideJSONRequestBody
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			util.WriteJSONParseError(err, w)
+			return
+		}
+		var duration time.Duration
+		if req.Duration != nil {
+			if *req.Duration < 0 {
+				util.WriteError(status.FieldErrorf(status.InvalidArgument, "duration", "duration can't be negative"), w)
+				return
+			}
+			duration = time.Duration(*req.Duration) * time.Second
+		}
+		geofence, err = h.accountManager.ApprovePeerGeofenceOverride(account.Id, user.Id, peerID, duration)
+	case http.MethodDelete:
+		geofence, err = h.accountManager.RevokePeerGeofenceOverride(account.Id, user.Id, peerID)
+	default:
+		util.WriteError(status.Errorf(status.NotFound, "unknown METHOD"), w)
+		return
+	}
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toPeerGeofenceResponse(geofence))
+}
+
+func toPeerGeofenceResponse(geofence *server.PeerGeofence) *api.PeerGeofence {
+	resp := &api.PeerGeofence{
+		PeerId:      geofence.PeerID,
+		CountryCode: geofence.CountryCode,
+		Geofenced:   geofence.Geofenced,
+		Violation:   geofence.Violation,
+		Action:      api.PeerGeofenceAction(geofence.Action),
+	}
+	if !geofence.ViolatedAt.IsZero() {
+		resp.ViolatedAt = &geofence.ViolatedAt
+	}
+
+	override := geofence.Override
+	if override == nil {
+		return resp
+	}
+
+	resp.Override = &api.PeerGeofenceOverride{
+		Country:     override.Country,
+		Reason:      override.Reason,
+		RequestedBy: override.RequestedBy,
+		RequestedAt: override.RequestedAt,
+		Approved:    override.Approved(),
+	}
+	if override.Approved() {
+		resp.Override.ApprovedBy = &override.ApprovedBy
+		resp.Override.ApprovedAt = &override.ApprovedAt
+	}
+	if !override.ExpiresAt.IsZero() {
+		resp.Override.ExpiresAt = &override.ExpiresAt
+	}
+
+	return resp
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func toPeerDiagnosticsResponse(diagnostics *nbpeer.Diagnostics) *api.PeerDiagnostics {
+	resp := &api.PeerDiagnostics{
+		DroppedConnections: make([]api.PeerDroppedConnection, 0, len(diagnostics.DroppedConnections)),
+	}
+	for _, conn := range diagnostics.DroppedConnections {
+		policyIDs := conn.PolicyIDs
+		if policyIDs == nil {
+			policyIDs = []string{}
+		}
+		resp.DroppedConnections = append(resp.DroppedConnections, api.PeerDroppedConnection{
+			SourceIp:        conn.SourceIP,
+			SourcePort:      int(conn.SourcePort),
+			DestinationPort: int(conn.DestinationPort),
+			Protocol:        conn.Protocol,
+			PolicyIds:       policyIDs,
+			FirstSeen:       conn.FirstSeen,
+			LastSeen:        conn.LastSeen,
+			Packets:         int64(conn.Packets),
+		})
+	}
+	if !diagnostics.DroppedConnectionsReportedAt.IsZero() {
+		reportedAt := diagnostics.DroppedConnectionsReportedAt
+		resp.DroppedConnectionsReportedAt = &reportedAt
+	}
+	return resp
+}
+
 // GetAllPeers returns a list of all peers associated with a provided account
 func (h *PeersHandler) GetAllPeers(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -176,6 +661,15 @@ func (h *PeersHandler) GetAllPeers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var expiringWithin time.Duration
+	if param := r.URL.Query().Get("expiring_within"); param != "" {
+		expiringWithin, err = time.ParseDuration(param)
+		if err != nil || expiringWithin <= 0 {
+			util.WriteError(status.FieldErrorf(status.InvalidArgument, "expiring_within", "invalid expiring_within duration %s", param), w)
+			return
+		}
+	}
+
 	peers, err := h.accountManager.GetPeers(account.Id, user.Id)
 	if err != nil {
 		util.WriteError(err, w)
@@ -186,6 +680,11 @@ func (h *PeersHandler) GetAllPeers(w http.ResponseWriter, r *http.Request) {
 
 	respBody := make([]*api.PeerBatch, 0, len(peers))
 	for _, peer := range peers {
+		loginExpiresAt := toLoginExpiresAt(account, peer)
+		if expiringWithin != 0 && !loginExpiresWithin(loginExpiresAt, peer, expiringWithin) {
+			continue
+		}
+
 		peerToReturn, err := h.checkPeerStatus(peer)
 		if err != nil {
 			util.WriteError(err, w)
@@ -195,7 +694,9 @@ func (h *PeersHandler) GetAllPeers(w http.ResponseWriter, r *http.Request) {
 
 		accessiblePeerNumbers, _ := h.accessiblePeersNumber(account, peer.ID)
 
-		respBody = append(respBody, toPeerListItemResponse(peerToReturn, groupMinimumInfo, dnsDomain, accessiblePeerNumbers))
+		peerResponse := toPeerListItemResponse(peerToReturn, groupMinimumInfo, dnsDomain, accessiblePeerNumbers)
+		peerResponse.LoginExpiresAt = loginExpiresAt
+		respBody = append(respBody, peerResponse)
 	}
 
 	validPeersMap, err := h.accountManager.GetValidatedPeers(account)
@@ -278,6 +779,24 @@ func toGroupsInfo(groups map[string]*nbgroup.Group, peerID string) []api.GroupMi
 	return groupsInfo
 }
 
+// toLoginExpiresAt returns when the login of the peer expires, nil when it never expires
+func toLoginExpiresAt(account *server.Account, peer *nbpeer.Peer) *time.Time {
+	expiresAt, ok := account.GetPeerLoginExpiresAt(peer)
+	if !ok {
+		return nil
+	}
+	return &expiresAt
+}
+
+// loginExpiresWithin returns whether the login of the peer hasn't expired yet and expires within the duration
+func loginExpiresWithin(expiresAt *time.Time, peer *nbpeer.Peer, within time.Duration) bool {
+	if expiresAt == nil || peer.Status.LoginExpired {
+		return false
+	}
+	timeLeft := time.Until(*expiresAt)
+	return timeLeft > 0 && timeLeft <= within
+}
+
 func toSinglePeerResponse(peer *nbpeer.Peer, groupsInfo []api.GroupMinimum, dnsDomain string, accessiblePeer []api.AccessiblePeer, approved bool) *api.Peer {
 	osVersion := peer.Meta.OSVersion
 	if osVersion == "" {
@@ -309,6 +828,10 @@ func toSinglePeerResponse(peer *nbpeer.Peer, groupsInfo []api.GroupMinimum, dnsD
 		CountryCode:            peer.Location.CountryCode,
 		CityName:               peer.Location.CityName,
 		SerialNumber:           peer.Meta.SystemSerialNumber,
+		RouteConflicts:         toRouteConflictsResponse(peer.RouteConflicts),
+		Uplink:                 peer.Uplink,
+		NatType:                peer.Meta.NATType,
+		RosenpassEnabled:       len(peer.RosenpassPubKey) > 0,
 	}
 }
 
@@ -342,7 +865,62 @@ func toPeerListItemResponse(peer *nbpeer.Peer, groupsInfo []api.GroupMinimum, dn
 		CountryCode:            peer.Location.CountryCode,
 		CityName:               peer.Location.CityName,
 		SerialNumber:           peer.Meta.SystemSerialNumber,
+		RouteConflicts:         toRouteConflictsResponse(peer.RouteConflicts),
+		Uplink:                 peer.Uplink,
+		NatType:                peer.Meta.NATType,
+		RosenpassEnabled:       len(peer.RosenpassPubKey) > 0,
+	}
+}
+
+func toRouteConflictsResponse(conflicts []nbpeer.RouteConflict) []api.RouteConflict {
+	resp := make([]api.RouteConflict, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		resp = append(resp, api.RouteConflict{
+			NetworkId:     conflict.NetID,
+			Network:       conflict.Network.String(),
+			ConflictsWith: conflict.ConflictsWith,
+			Reason:        api.RouteConflictReason(conflict.Reason),
+		})
+	}
+	return resp
+}
+
+func toPeerTransferStatsResponse(account *server.Account, stats []nbpeer.TransferStats) []api.PeerTransferStats {
+	resp := make([]api.PeerTransferStats, 0, len(stats))
+	for _, peerStats := range stats {
+		var peerID string
+		if remotePeer, err := account.FindPeerByPubKey(peerStats.RemotePeerKey); err == nil {
+			peerID = remotePeer.ID
+		}
+		resp = append(resp, api.PeerTransferStats{
+			PeerId:        peerID,
+			PeerPubKey:    peerStats.RemotePeerKey,
+			RxBytes:       peerStats.RxBytes,
+			TxBytes:       peerStats.TxBytes,
+			LastHandshake: peerStats.LastHandshake,
+			Endpoint:      peerStats.Endpoint,
+			Relayed:       peerStats.Relayed,
+		})
+	}
+	return resp
+}
+
+func toRelayedConnectionsResponse(account *server.Account, conns []server.RelayedConnection) []api.RelayedConnection {
+	resp := make([]api.RelayedConnection, 0, len(conns))
+	for _, conn := range conns {
+		item := api.RelayedConnection{
+			PeerId:       conn.PeerID,
+			RemotePeerId: conn.RemotePeerID,
+		}
+		if peer := account.GetPeer(conn.PeerID); peer != nil {
+			item.PeerName = peer.Name
+		}
+		if peer := account.GetPeer(conn.RemotePeerID); peer != nil {
+			item.RemotePeerName = peer.Name
+		}
+		resp = append(resp, item)
 	}
+	return resp
 }
 
 func fqdn(peer *nbpeer.Peer, dnsDomain string) string {
@@ -353,3 +931,52 @@ func fqdn(peer *nbpeer.Peer, dnsDomain string) string {
 		return fqdn
 	}
 }
+
+func toPeerInventoryResponse(peer *nbpeer.Peer) *api.PeerInventory {
+	inventory := peer.Meta.Inventory
+	packages := make([]api.InstalledPackage, 0, len(inventory.Packages))
+	for _, pkg := range inventory.Packages {
+		packages = append(packages, api.InstalledPackage{
+			Name:    pkg.Name,
+			Version: pkg.Version,
+		})
+	}
+
+	return &api.PeerInventory{
+		PeerId:         peer.ID,
+		PeerName:       peer.Name,
+		Os:             peer.Meta.OS,
+		OsVersion:      peer.Meta.OSVersion,
+		PatchLevel:     inventory.PatchLevel,
+		PackageManager: inventory.PackageManager,
+		PackagesCount:  inventory.PackagesCount,
+		Packages:       packages,
+		LastLogin:      peer.LastLogin,
+	}
+}
+
+// writePeersInventoryCSV writes the inventory with a row per reported package of each peer, a peer without reported
+// packages having a single row without package
+func writePeersInventoryCSV(w http.ResponseWriter, peers []*nbpeer.Peer) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="peers-inventory.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	records := [][]string{{"peer_id", "peer_name", "os", "os_version", "patch_level", "package_manager", "package", "version"}}
+	for _, peer := range peers {
+		inventory := peer.Meta.Inventory
+		row := []string{peer.ID, peer.Name, peer.Meta.OS, peer.Meta.OSVersion, inventory.PatchLevel, inventory.PackageManager}
+		if len(inventory.Packages) == 0 {
+			records = append(records, append(row, "", ""))
+			continue
+		}
+		for _, pkg := range inventory.Packages {
+			records = append(records, append(slices.Clone(row), pkg.Name, pkg.Version))
+		}
+	}
+
+	if err := writer.WriteAll(records); err != nil {
+		log.Errorf("failed to write the peers inventory: %v", err)
+	}
+}
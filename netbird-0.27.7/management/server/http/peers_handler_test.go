@@ -2,6 +2,7 @@ package http
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"io"
 	"net"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/netbirdio/netbird/management/proto"
 	"github.com/netbirdio/netbird/management/server/http/api"
 	nbpeer "github.com/netbirdio/netbird/management/server/peer"
 
@@ -21,6 +23,7 @@ import (
 
 	"github.com/netbirdio/netbird/management/server"
 	"github.com/netbirdio/netbird/management/server/mock_server"
+	"github.com/netbirdio/netbird/management/server/status"
 )
 
 const testPeerID = "test_peer"
@@ -40,6 +43,10 @@ func initTestMetaData(peers ...*nbpeer.Peer) *PeersHandler {
 				p.SSHEnabled = update.SSHEnabled
 				p.LoginExpirationEnabled = update.LoginExpirationEnabled
 				p.Name = update.Name
+				p.Uplink = update.Uplink
+				if update.IP != nil {
+					p.IP = update.IP
+				}
 				return p, nil
 			},
 			GetPeerFunc: func(accountID, peerID, userID string) (*nbpeer.Peer, error) {
@@ -55,6 +62,83 @@ func initTestMetaData(peers ...*nbpeer.Peer) *PeersHandler {
 			GetPeersFunc: func(accountID, userID string) ([]*nbpeer.Peer, error) {
 				return peers, nil
 			},
+			GetPeerTransferStatsFunc: func(accountID, peerID, userID string) ([]nbpeer.TransferStats, error) {
+				return []nbpeer.TransferStats{
+					{
+						RemotePeerKey: peers[1].Key,
+						RxBytes:       2048,
+						TxBytes:       1024,
+						LastHandshake: time.Date(2023, 5, 5, 9, 0, 35, 0, time.UTC),
+						Endpoint:      "203.0.113.10:51820",
+						Relayed:       true,
+					},
+					{
+						RemotePeerKey: "unknown",
+					},
+				}, nil
+			},
+			GetRelayedConnectionsFunc: func(accountID, userID string) ([]server.RelayedConnection, error) {
+				return []server.RelayedConnection{{PeerID: peers[0].ID, RemotePeerID: peers[1].ID}}, nil
+			},
+			GetPeerDNSStatsFunc: func(accountID, peerID, userID string) (*nbpeer.DNSStats, error) {
+				return &nbpeer.DNSStats{
+					LocalQueries:     10,
+					ForwardedQueries: 90,
+					BlockedQueries:   2,
+					FailedQueries:    1,
+					AverageLatency:   15 * time.Millisecond,
+					ReportedAt:       time.Date(2023, 5, 5, 9, 0, 35, 0, time.UTC),
+				}, nil
+			},
+			GetPeerDiagnosticsFunc: func(accountID, peerID, userID string) (*nbpeer.Diagnostics, error) {
+				return &nbpeer.Diagnostics{
+					DroppedConnections: []nbpeer.DroppedConnection{
+						{
+							SourceIP:        "100.64.0.2",
+							SourcePort:      51334,
+							DestinationPort: 22,
+							Protocol:        "tcp",
+							PolicyIDs:       []string{"policy1"},
+							FirstSeen:       time.Date(2023, 5, 5, 9, 0, 30, 0, time.UTC),
+							LastSeen:        time.Date(2023, 5, 5, 9, 0, 33, 0, time.UTC),
+							Packets:         3,
+						},
+						{
+							SourceIP:  "100.64.0.2",
+							Protocol:  "icmp",
+							FirstSeen: time.Date(2023, 5, 5, 9, 0, 31, 0, time.UTC),
+							LastSeen:  time.Date(2023, 5, 5, 9, 0, 31, 0, time.UTC),
+							Packets:   1,
+						},
+					},
+					DroppedConnectionsReportedAt: time.Date(2023, 5, 5, 9, 0, 35, 0, time.UTC),
+				}, nil
+			},
+			GetPeerPingFunc: func(accountID, peerID, userID, pingID string) (*server.PeerPing, error) {
+				return &server.PeerPing{
+					ID:           pingID,
+					PeerID:       peerID,
+					TargetPeerID: peers[1].ID,
+					RequestedAt:  time.Date(2023, 5, 5, 9, 0, 35, 0, time.UTC),
+					Done:         true,
+					Result: &server.PeerPingResult{
+						IP:       peers[1].IP.String(),
+						Sent:     3,
+						Received: 2,
+						RTTs:     []time.Duration{10 * time.Millisecond, 20 * time.Millisecond},
+						Path:     "relayed",
+					},
+				}, nil
+			},
+			GetPeerNetworkMapDumpFunc: func(accountID, peerID, userID string) (*proto.NetworkMap, error) {
+				return &proto.NetworkMap{
+					Serial:     51,
+					PeerConfig: &proto.PeerConfig{Address: "100.64.0.1/16"},
+					RemotePeers: []*proto.RemotePeerConfig{
+						{WgPubKey: peers[1].Key, AllowedIps: []string{"100.64.0.2/32"}},
+					},
+				}, nil
+			},
 			GetDNSDomainFunc: func() string {
 				return "netbird.selfhosted"
 			},
@@ -129,6 +213,7 @@ func TestGetPeers(t *testing.T) {
 			OS:                 "OS",
 			WtVersion:          "development",
 			SystemSerialNumber: "C02XJ0J0JGH7",
+			NATType:            "symmetric",
 		},
 	}
 
@@ -140,6 +225,12 @@ func TestGetPeers(t *testing.T) {
 	expectedUpdatedPeer.SSHEnabled = true
 	expectedUpdatedPeer.Name = "New Name"
 
+	expectedPeerWithNewIP := expectedUpdatedPeer.Copy()
+	expectedPeerWithNewIP.IP = net.ParseIP("100.64.0.15")
+
+	expectedPeerWithUplink := expectedUpdatedPeer.Copy()
+	expectedPeerWithUplink.Uplink = "wan2"
+
 	expectedPeer1 := peer1.Copy()
 	expectedPeer1.Status.Connected = false
 
@@ -185,6 +276,24 @@ func TestGetPeers(t *testing.T) {
 			requestBody:    bytes.NewBufferString("{\"login_expiration_enabled\":true,\"name\":\"New Name\",\"ssh_enabled\":true}"),
 			expectedPeer:   expectedUpdatedPeer,
 		},
+		{
+			name:           "PutPeer with new IP",
+			requestType:    http.MethodPut,
+			requestPath:    "/api/peers/" + testPeerID,
+			expectedStatus: http.StatusOK,
+			expectedArray:  false,
+			requestBody:    bytes.NewBufferString("{\"login_expiration_enabled\":true,\"name\":\"New Name\",\"ssh_enabled\":true,\"ip\":\"100.64.0.15\"}"),
+			expectedPeer:   expectedPeerWithNewIP,
+		},
+		{
+			name:           "PutPeer with uplink",
+			requestType:    http.MethodPut,
+			requestPath:    "/api/peers/" + testPeerID,
+			expectedStatus: http.StatusOK,
+			expectedArray:  false,
+			requestBody:    bytes.NewBufferString("{\"login_expiration_enabled\":true,\"name\":\"New Name\",\"ssh_enabled\":true,\"uplink\":\"wan2\"}"),
+			expectedPeer:   expectedPeerWithUplink,
+		},
 	}
 
 	rr := httptest.NewRecorder()
@@ -247,6 +356,603 @@ func TestGetPeers(t *testing.T) {
 			assert.Equal(t, got.SshEnabled, tc.expectedPeer.SSHEnabled)
 			assert.Equal(t, got.Connected, tc.expectedPeer.Status.Connected)
 			assert.Equal(t, got.SerialNumber, tc.expectedPeer.Meta.SystemSerialNumber)
+			assert.Equal(t, got.Uplink, tc.expectedPeer.Uplink)
+			assert.Equal(t, got.NatType, tc.expectedPeer.Meta.NATType)
 		})
 	}
 }
+
+func TestGetPeersExpiringWithin(t *testing.T) {
+	expiringPeer := &nbpeer.Peer{
+		ID:                     testPeerID,
+		Key:                    "key",
+		IP:                     net.ParseIP("100.64.0.1"),
+		Status:                 &nbpeer.PeerStatus{Connected: true},
+		Name:                   "expiring",
+		UserID:                 "test_user",
+		LoginExpirationEnabled: true,
+		LastLogin:              time.Now().Add(-30 * time.Minute),
+	}
+	setupKeyPeer := &nbpeer.Peer{
+		ID:                     noUpdateChannelTestPeerID,
+		Key:                    "key1",
+		SetupKey:               "setupkey",
+		IP:                     net.ParseIP("100.64.0.2"),
+		Status:                 &nbpeer.PeerStatus{},
+		Name:                   "setup-key",
+		LoginExpirationEnabled: true,
+		LastLogin:              time.Now().Add(-30 * time.Minute),
+	}
+
+	tt := []struct {
+		name           string
+		requestPath    string
+		expectedStatus int
+		expectedPeers  []string
+	}{
+		{
+			name:           "all peers",
+			requestPath:    "/api/peers/",
+			expectedStatus: http.StatusOK,
+			expectedPeers:  []string{"expiring", "setup-key"},
+		},
+		{
+			name:           "expiring within an hour",
+			requestPath:    "/api/peers/?expiring_within=1h",
+			expectedStatus: http.StatusOK,
+			expectedPeers:  []string{"expiring"},
+		},
+		{
+			name:           "expiring within ten minutes",
+			requestPath:    "/api/peers/?expiring_within=10m",
+			expectedStatus: http.StatusOK,
+			expectedPeers:  []string{},
+		},
+		{
+			name:           "invalid duration",
+			requestPath:    "/api/peers/?expiring_within=tomorrow",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	p := initTestMetaData(expiringPeer, setupKeyPeer)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.requestPath, nil)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/peers/", p.GetAllPeers).Methods("GET")
+			router.ServeHTTP(recorder, req)
+
+			res := recorder.Result()
+			defer res.Body.Close()
+			assert.Equal(t, res.StatusCode, tc.expectedStatus)
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var got []*api.PeerBatch
+			err := json.NewDecoder(res.Body).Decode(&got)
+			if err != nil {
+				t.Fatalf("Sent content is not in correct json format; %v", err)
+			}
+
+			names := make([]string, 0, len(got))
+			for _, peer := range got {
+				names = append(names, peer.Name)
+				if peer.Name == "expiring" {
+					assert.Equal(t, peer.LoginExpiresAt != nil, true)
+				} else {
+					assert.Equal(t, peer.LoginExpiresAt == nil, true)
+				}
+			}
+			assert.Equal(t, names, tc.expectedPeers)
+		})
+	}
+}
+
+func TestGetPeerTransferStats(t *testing.T) {
+	peer := &nbpeer.Peer{
+		ID:   testPeerID,
+		Key:  "key",
+		IP:   net.ParseIP("100.64.0.1"),
+		Name: "PeerName",
+	}
+	peer1 := &nbpeer.Peer{
+		ID:   "peer1",
+		Key:  "key1",
+		IP:   net.ParseIP("100.64.0.2"),
+		Name: "PeerName1",
+	}
+
+	p := initTestMetaData(peer, peer1)
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/peers/"+testPeerID+"/stats", nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/peers/{peerId}/stats", p.GetPeerTransferStats).Methods("GET")
+	router.ServeHTTP(recorder, req)
+
+	res := recorder.Result()
+	defer res.Body.Close()
+	assert.Equal(t, res.StatusCode, http.StatusOK)
+
+	var got []api.PeerTransferStats
+	err := json.NewDecoder(res.Body).Decode(&got)
+	if err != nil {
+		t.Fatalf("Sent content is not in correct json format; %v", err)
+	}
+
+	assert.Equal(t, len(got), 2)
+	assert.Equal(t, got[0], api.PeerTransferStats{
+		PeerId:        peer1.ID,
+		PeerPubKey:    peer1.Key,
+		RxBytes:       2048,
+		TxBytes:       1024,
+		LastHandshake: time.Date(2023, 5, 5, 9, 0, 35, 0, time.UTC),
+		Endpoint:      "203.0.113.10:51820",
+		Relayed:       true,
+	})
+	assert.Equal(t, got[1].PeerId, "")
+	assert.Equal(t, got[1].PeerPubKey, "unknown")
+}
+
+func TestGetRelayedConnections(t *testing.T) {
+	peer := &nbpeer.Peer{
+		ID:   testPeerID,
+		Key:  "key",
+		IP:   net.ParseIP("100.64.0.1"),
+		Name: "PeerName",
+	}
+	peer1 := &nbpeer.Peer{
+		ID:   "peer1",
+		Key:  "key1",
+		IP:   net.ParseIP("100.64.0.2"),
+		Name: "PeerName1",
+	}
+
+	p := initTestMetaData(peer, peer1)
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/peers/relayed-connections", nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/peers/relayed-connections", p.GetRelayedConnections).Methods("GET")
+	router.ServeHTTP(recorder, req)
+
+	res := recorder.Result()
+	defer res.Body.Close()
+	assert.Equal(t, res.StatusCode, http.StatusOK)
+
+	var got []api.RelayedConnection
+	err := json.NewDecoder(res.Body).Decode(&got)
+	if err != nil {
+		t.Fatalf("Sent content is not in correct json format; %v", err)
+	}
+
+	assert.Equal(t, []api.RelayedConnection{{
+		PeerId:         peer.ID,
+		PeerName:       peer.Name,
+		RemotePeerId:   peer1.ID,
+		RemotePeerName: peer1.Name,
+	}}, got)
+}
+
+func TestGetPeerDNSStats(t *testing.T) {
+	peer := &nbpeer.Peer{
+		ID:   testPeerID,
+		Key:  "key",
+		IP:   net.ParseIP("100.64.0.1"),
+		Name: "PeerName",
+	}
+	peer1 := &nbpeer.Peer{
+		ID:   "peer1",
+		Key:  "key1",
+		IP:   net.ParseIP("100.64.0.2"),
+		Name: "PeerName1",
+	}
+
+	p := initTestMetaData(peer, peer1)
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/peers/"+testPeerID+"/dns-stats", nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/peers/{peerId}/dns-stats", p.GetPeerDNSStats).Methods("GET")
+	router.ServeHTTP(recorder, req)
+
+	res := recorder.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var got api.PeerDNSStats
+	err := json.NewDecoder(res.Body).Decode(&got)
+	if err != nil {
+		t.Fatalf("Sent content is not in correct json format; %v", err)
+	}
+
+	assert.Equal(t, api.PeerDNSStats{
+		LocalQueries:     10,
+		ForwardedQueries: 90,
+		BlockedQueries:   2,
+		FailedQueries:    1,
+		AverageLatency:   15,
+		ReportedAt:       time.Date(2023, 5, 5, 9, 0, 35, 0, time.UTC),
+	}, got)
+}
+
+func TestGetPeerDiagnostics(t *testing.T) {
+	peer := &nbpeer.Peer{
+		ID:   testPeerID,
+		Key:  "key",
+		IP:   net.ParseIP("100.64.0.1"),
+		Name: "PeerName",
+	}
+	peer1 := &nbpeer.Peer{
+		ID:   "peer1",
+		Key:  "key1",
+		IP:   net.ParseIP("100.64.0.2"),
+		Name: "PeerName1",
+	}
+
+	p := initTestMetaData(peer, peer1)
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/peers/"+testPeerID+"/diagnostics", nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/peers/{peerId}/diagnostics", p.GetPeerDiagnostics).Methods("GET")
+	router.ServeHTTP(recorder, req)
+
+	res := recorder.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var got api.PeerDiagnostics
+	err := json.NewDecoder(res.Body).Decode(&got)
+	if err != nil {
+		t.Fatalf("Sent content is not in correct json format; %v", err)
+	}
+
+	reportedAt := time.Date(2023, 5, 5, 9, 0, 35, 0, time.UTC)
+	assert.Equal(t, api.PeerDiagnostics{
+		DroppedConnections: []api.PeerDroppedConnection{
+			{
+				SourceIp:        "100.64.0.2",
+				SourcePort:      51334,
+				DestinationPort: 22,
+				Protocol:        "tcp",
+				PolicyIds:       []string{"policy1"},
+				FirstSeen:       time.Date(2023, 5, 5, 9, 0, 30, 0, time.UTC),
+				LastSeen:        time.Date(2023, 5, 5, 9, 0, 33, 0, time.UTC),
+				Packets:         3,
+			},
+			{
+				SourceIp:  "100.64.0.2",
+				Protocol:  "icmp",
+				PolicyIds: []string{},
+				FirstSeen: time.Date(2023, 5, 5, 9, 0, 31, 0, time.UTC),
+				LastSeen:  time.Date(2023, 5, 5, 9, 0, 31, 0, time.UTC),
+				Packets:   1,
+			},
+		},
+		DroppedConnectionsReportedAt: &reportedAt,
+	}, got)
+}
+
+func TestGetPeerNetworkMap(t *testing.T) {
+	peer := &nbpeer.Peer{
+		ID:   testPeerID,
+		Key:  "key",
+		IP:   net.ParseIP("100.64.0.1"),
+		Name: "PeerName",
+	}
+	peer1 := &nbpeer.Peer{
+		ID:   "peer1",
+		Key:  "key1",
+		IP:   net.ParseIP("100.64.0.2"),
+		Name: "PeerName1",
+	}
+
+	p := initTestMetaData(peer, peer1)
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/peers/"+testPeerID+"/network-map", nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/peers/{peerId}/network-map", p.GetPeerNetworkMap).Methods("GET")
+	router.ServeHTTP(recorder, req)
+
+	res := recorder.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var got struct {
+		Serial      string
+		PeerConfig  struct{ Address string }
+		RemotePeers []struct {
+			WgPubKey   string
+			AllowedIps []string
+		}
+		FirewallRules []json.RawMessage
+	}
+	err := json.NewDecoder(res.Body).Decode(&got)
+	if err != nil {
+		t.Fatalf("Sent content is not in correct json format; %v", err)
+	}
+
+	assert.Equal(t, "51", got.Serial)
+	assert.Equal(t, "100.64.0.1/16", got.PeerConfig.Address)
+	assert.Equal(t, 1, len(got.RemotePeers))
+	assert.Equal(t, "key1", got.RemotePeers[0].WgPubKey)
+	assert.Equal(t, []string{"100.64.0.2/32"}, got.RemotePeers[0].AllowedIps)
+	assert.Equal(t, 0, len(got.FirewallRules))
+}
+
+func TestGetPeerPing(t *testing.T) {
+	peer := &nbpeer.Peer{
+		ID:   testPeerID,
+		Key:  "key",
+		IP:   net.ParseIP("100.64.0.1"),
+		Name: "PeerName",
+	}
+	peer1 := &nbpeer.Peer{
+		ID:   "peer1",
+		Key:  "key1",
+		IP:   net.ParseIP("100.64.0.2"),
+		Name: "PeerName1",
+	}
+
+	p := initTestMetaData(peer, peer1)
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/peers/"+testPeerID+"/ping/ping1", nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/peers/{peerId}/ping/{pingId}", p.GetPeerPing).Methods("GET")
+	router.ServeHTTP(recorder, req)
+
+	res := recorder.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var got api.PeerPing
+	err := json.NewDecoder(res.Body).Decode(&got)
+	if err != nil {
+		t.Fatalf("Sent content is not in correct json format; %v", err)
+	}
+
+	assert.Equal(t, api.PeerPing{
+		Id:           "ping1",
+		TargetPeerId: "peer1",
+		RequestedAt:  time.Date(2023, 5, 5, 9, 0, 35, 0, time.UTC),
+		Status:       api.PeerPingStatusDone,
+		Result: &api.PeerPingResult{
+			Ip:       "100.64.0.2",
+			Sent:     3,
+			Received: 2,
+			RttMinMs: 10,
+			RttAvgMs: 15,
+			RttMaxMs: 20,
+			Path:     "relayed",
+		},
+	}, got)
+}
+
+func TestGetPeersInventory(t *testing.T) {
+	router := &nbpeer.Peer{
+		ID:        testPeerID,
+		Name:      "office-router",
+		LastLogin: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Meta: nbpeer.PeerSystemMeta{
+			OS:        "OpenWrt",
+			OSVersion: "23.05.3",
+			Inventory: nbpeer.Inventory{
+				PackageManager: "opkg",
+				PatchLevel:     "23.05.3 r23809",
+				PackagesCount:  142,
+				Packages: []nbpeer.Package{
+					{Name: "dropbear", Version: "2022.82-6"},
+					{Name: "libopenssl3", Version: "3.0.13-1"},
+				},
+			},
+		},
+	}
+	gateway := &nbpeer.Peer{
+		ID:   "gateway",
+		Name: "branch-gateway",
+		Meta: nbpeer.PeerSystemMeta{OS: "OpenWrt", Inventory: nbpeer.Inventory{PatchLevel: "22.03.6 r20265"}},
+	}
+
+	var requested server.InventoryFilter
+	p := &PeersHandler{
+		accountManager: &mock_server.MockAccountManager{
+			GetAccountIDFromTokenFunc: func(claims jwtclaims.AuthorizationClaims) (string, string, error) {
+				return claims.AccountId, claims.UserId, nil
+			},
+			GetPeersInventoryFunc: func(accountID, userID string, filter server.InventoryFilter) ([]*nbpeer.Peer, error) {
+				requested = filter
+				return []*nbpeer.Peer{gateway, router}, nil
+			},
+		},
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+				return jwtclaims.AuthorizationClaims{UserId: "test_user", AccountId: "test_id"}
+			}),
+		),
+	}
+
+	recorder := httptest.NewRecorder()
+	p.GetPeersInventory(recorder, httptest.NewRequest(http.MethodGet, "/api/peers/inventory?os=OpenWrt&package=libopenssl3&version_below=3.0.14", nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, requested, server.InventoryFilter{OS: "OpenWrt", Package: "libopenssl3", VersionBelow: "3.0.14"})
+
+	var got []api.PeerInventory
+	if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Sent content is not in correct json format; %v", err)
+	}
+	assert.Equal(t, len(got), 2)
+	assert.Equal(t, got[1].PeerId, router.ID)
+	assert.Equal(t, got[1].PackagesCount, 142)
+	assert.Equal(t, got[1].Packages, []api.InstalledPackage{
+		{Name: "dropbear", Version: "2022.82-6"},
+		{Name: "libopenssl3", Version: "3.0.13-1"},
+	})
+	assert.Equal(t, got[0].Packages, []api.InstalledPackage{})
+
+	recorder = httptest.NewRecorder()
+	p.GetPeersInventory(recorder, httptest.NewRequest(http.MethodGet, "/api/peers/inventory?format=csv", nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	records, err := csv.NewReader(recorder.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Sent content is not in correct csv format; %v", err)
+	}
+	assert.Equal(t, records, [][]string{
+		{"peer_id", "peer_name", "os", "os_version", "patch_level", "package_manager", "package", "version"},
+		{"gateway", "branch-gateway", "OpenWrt", "", "22.03.6 r20265", "", "", ""},
+		{testPeerID, "office-router", "OpenWrt", "23.05.3", "23.05.3 r23809", "opkg", "dropbear", "2022.82-6"},
+		{testPeerID, "office-router", "OpenWrt", "23.05.3", "23.05.3 r23809", "opkg", "libopenssl3", "3.0.13-1"},
+	})
+
+	recorder = httptest.NewRecorder()
+	p.GetPeersInventory(recorder, httptest.NewRequest(http.MethodGet, "/api/peers/inventory?format=xml", nil))
+	assert.Equal(t, recorder.Code, http.StatusUnprocessableEntity)
+}
+
+func TestPeerGeofence(t *testing.T) {
+	violatedAt := time.Date(2024, 5, 21, 9, 12, 0, 0, time.UTC)
+	requestedAt := violatedAt.Add(3 * time.Minute)
+	geofence := &server.PeerGeofence{
+		PeerID:      "peer1",
+		CountryCode: "FR",
+		Geofenced:   true,
+		Violation:   "FR",
+		ViolatedAt:  violatedAt,
+		Action:      server.GeofenceActionBlock,
+	}
+
+	accountManager := newFakeAccountManager(newTestAccountBuilder().build())
+	accountManager.GetPeerGeofenceFunc = func(_, _, peerID string) (*server.PeerGeofence, error) {
+		if peerID != "peer1" {
+			return nil, status.Errorf(status.NotFound, "peer %s not found", peerID)
+		}
+		return geofence, nil
+	}
+	accountManager.RequestPeerGeofenceOverrideFunc = func(_, userID, _, reason string) (*server.PeerGeofence, error) {
+		requested := *geofence
+		requested.Override = &nbpeer.GeofenceOverride{Country: "FR", Reason: reason, RequestedBy: userID, RequestedAt: requestedAt}
+		return &requested, nil
+	}
+	accountManager.ApprovePeerGeofenceOverrideFunc = func(_, userID, _ string, duration time.Duration) (*server.PeerGeofence, error) {
+		if userID != fakeAdminUserID {
+			return nil, status.Errorf(status.PermissionDenied, "only users with admin power can approve geofence overrides")
+		}
+		approved := &server.PeerGeofence{PeerID: "peer1", CountryCode: "FR", Geofenced: true}
+		approved.Override = &nbpeer.GeofenceOverride{
+			Country: "FR", Reason: "travel", RequestedBy: fakeRegularUserID, RequestedAt: requestedAt,
+			ApprovedBy: userID, ApprovedAt: requestedAt.Add(time.Hour), ExpiresAt: requestedAt.Add(time.Hour + duration),
+		}
+		return approved, nil
+	}
+	accountManager.RevokePeerGeofenceOverrideFunc = func(_, _, _ string) (*server.PeerGeofence, error) {
+		return geofence, nil
+	}
+
+	newHandler := func(claimsExtractor *jwtclaims.ClaimsExtractor) http.HandlerFunc {
+		handler := &PeersHandler{accountManager: accountManager, claimsExtractor: claimsExtractor}
+		return handler.GetPeerGeofence
+	}
+	runHandlerTests(t, "/api/peers/{peerId}/geofence", newHandler, []handlerTest{
+		{
+			name:           "get the geofence",
+			method:         http.MethodGet,
+			path:           "/api/peers/peer1/geofence",
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, decodeTestResponse[api.PeerGeofence](t, recorder), api.PeerGeofence{
+					PeerId:      "peer1",
+					CountryCode: "FR",
+					Geofenced:   true,
+					Violation:   "FR",
+					ViolatedAt:  &violatedAt,
+					Action:      api.PeerGeofenceActionBlock,
+				})
+			},
+		},
+		{
+			name:           "unknown peer",
+			method:         http.MethodGet,
+			path:           "/api/peers/peer2/geofence",
+			expectedStatus: http.StatusNotFound,
+		},
+	})
+
+	newHandler = func(claimsExtractor *jwtclaims.ClaimsExtractor) http.HandlerFunc {
+		handler := &PeersHandler{accountManager: accountManager, claimsExtractor: claimsExtractor}
+		return handler.HandlePeerGeofenceOverride
+	}
+	runHandlerTests(t, "/api/peers/{peerId}/geofence/override", newHandler, []handlerTest{
+		{
+			name:           "request an override",
+			userID:         fakeRegularUserID,
+			method:         http.MethodPost,
+			path:           "/api/peers/peer1/geofence/override",
+			body:           api.PeerGeofenceOverrideRequest{Reason: "travel"},
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				override := decodeTestResponse[api.PeerGeofence](t, recorder).Override
+				assert.Equal(t, *override, api.PeerGeofenceOverride{
+					Country:     "FR",
+					Reason:      "travel",
+					RequestedBy: fakeRegularUserID,
+					RequestedAt: requestedAt,
+				})
+			},
+		},
+		{
+			name:           "request an override without a reason",
+			method:         http.MethodPost,
+			path:           "/api/peers/peer1/geofence/override",
+			body:           api.PeerGeofenceOverrideRequest{},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "approve the override",
+			method:         http.MethodPut,
+			path:           "/api/peers/peer1/geofence/override",
+			body:           map[string]int{"duration": 3600},
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				resp := decodeTestResponse[api.PeerGeofence](t, recorder)
+				assert.Equal(t, resp.Violation, "")
+				assert.Equal(t, resp.Override.Approved, true)
+				assert.Equal(t, *resp.Override.ApprovedBy, fakeAdminUserID)
+				assert.Equal(t, *resp.Override.ExpiresAt, requestedAt.Add(2*time.Hour))
+			},
+		},
+		{
+			name:           "approve the override for a negative duration",
+			method:         http.MethodPut,
+			path:           "/api/peers/peer1/geofence/override",
+			body:           map[string]int{"duration": -1},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "approve the override as a regular user",
+			userID:         fakeRegularUserID,
+			method:         http.MethodPut,
+			path:           "/api/peers/peer1/geofence/override",
+			body:           map[string]int{},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "revoke the override",
+			method:         http.MethodDelete,
+			path:           "/api/peers/peer1/geofence/override",
+			expectedStatus: http.StatusOK,
+		},
+	})
+}
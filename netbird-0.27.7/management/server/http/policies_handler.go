@@ -3,12 +3,12 @@ package http
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/xid"
 
 	"github.com/netbirdio/netbird/management/server"
+	nbgroup "github.com/netbirdio/netbird/management/server/group"
 	"github.com/netbirdio/netbird/management/server/http/api"
 	"github.com/netbirdio/netbird/management/server/http/util"
 	"github.com/netbirdio/netbird/management/server/jwtclaims"
@@ -35,13 +35,13 @@ func NewPoliciesHandler(accountManager server.AccountManager, authCfg AuthCfg) *
 // GetAllPolicies list for the account
 func (h *Policies) GetAllPolicies(w http.ResponseWriter, r *http.Request) {
 	claims := h.claimsExtractor.FromRequestContext(r)
-	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	accountID, userID, err := h.accountManager.GetAccountIDFromToken(claims)
 	if err != nil {
 		util.WriteError(err, w)
 		return
 	}
 
-	accountPolicies, err := h.accountManager.ListPolicies(account.Id, user.Id)
+	accountPolicies, groups, err := h.accountManager.GetPoliciesForUser(accountID, userID)
 	if err != nil {
 		util.WriteError(err, w)
 		return
@@ -49,7 +49,7 @@ func (h *Policies) GetAllPolicies(w http.ResponseWriter, r *http.Request) {
 
 	policies := []*api.Policy{}
 	for _, policy := range accountPolicies {
-		resp := toPolicyResponse(account, policy)
+		resp := toPolicyResponse(groups, policy)
 		if len(resp.Rules) == 0 {
 			util.WriteError(status.Errorf(status.Internal, "no rules in the policy"), w)
 			return
@@ -113,17 +113,17 @@ func (h *Policies) savePolicy(
 ) {
 	var req api.PutApiPoliciesPolicyIdJSONRequestBody
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		util.WriteJSONParseError(err, w)
 		return
 	}
 
 	if req.Name == "" {
-		util.WriteError(status.Errorf(status.InvalidArgument, "policy name shouldn't be empty"), w)
+		util.WriteError(status.FieldErrorf(status.InvalidArgument, "name", "policy name shouldn't be empty"), w)
 		return
 	}
 
 	if len(req.Rules) == 0 {
-		util.WriteError(status.Errorf(status.InvalidArgument, "policy rules shouldn't be empty"), w)
+		util.WriteError(status.FieldErrorf(status.InvalidArgument, "rules", "policy rules shouldn't be empty"), w)
 		return
 	}
 
@@ -137,6 +137,15 @@ func (h *Policies) savePolicy(
 		Enabled:     req.Enabled,
 		Description: req.Description,
 	}
+	if req.RequireDirect != nil {
+		policy.RequireDirect = *req.RequireDirect
+	}
+	if req.SshEnabled != nil {
+		policy.SSHEnabled = *req.SshEnabled
+	}
+	if req.SpeedtestEnabled != nil {
+		policy.SpeedtestEnabled = *req.SpeedtestEnabled
+	}
 	for _, r := range req.Rules {
 		pr := server.PolicyRule{
 			ID:            policyID, //TODO: when policy can contain multiple rules, need refactor
@@ -150,6 +159,9 @@ func (h *Policies) savePolicy(
 		if r.Description != nil {
 			pr.Description = *r.Description
 		}
+		if r.Egress != nil {
+			pr.Egress = *r.Egress
+		}
 
 		switch r.Action {
 		case api.PolicyRuleUpdateActionAccept:
@@ -157,7 +169,7 @@ func (h *Policies) savePolicy(
 		case api.PolicyRuleUpdateActionDrop:
 			pr.Action = server.PolicyTrafficActionDrop
 		default:
-			util.WriteError(status.Errorf(status.InvalidArgument, "unknown action type"), w)
+			util.WriteError(status.FieldErrorf(status.InvalidArgument, "action", "unknown action type"), w)
 			return
 		}
 
@@ -171,36 +183,22 @@ func (h *Policies) savePolicy(
 		case api.PolicyRuleUpdateProtocolIcmp:
 			pr.Protocol = server.PolicyRuleProtocolICMP
 		default:
-			util.WriteError(status.Errorf(status.InvalidArgument, "unknown protocol type: %v", r.Protocol), w)
+			util.WriteError(status.FieldErrorf(status.InvalidArgument, "protocol", "unknown protocol type: %v", r.Protocol), w)
 			return
 		}
 
 		if r.Ports != nil && len(*r.Ports) != 0 {
-			for _, v := range *r.Ports {
-				if port, err := strconv.Atoi(v); err != nil || port < 1 || port > 65535 {
-					util.WriteError(status.Errorf(status.InvalidArgument, "valid port value is in 1..65535 range"), w)
-					return
-				}
-				pr.Ports = append(pr.Ports, v)
-			}
+			pr.Ports = append(pr.Ports, *r.Ports...)
+		}
+
+		if r.DestinationLists != nil && len(*r.DestinationLists) != 0 {
+			pr.DestinationLists = externalListsToStrings(account, *r.DestinationLists)
 		}
 
 		// validate policy object
-		switch pr.Protocol {
-		case server.PolicyRuleProtocolALL, server.PolicyRuleProtocolICMP:
-			if len(pr.Ports) != 0 {
-				util.WriteError(status.Errorf(status.InvalidArgument, "for ALL or ICMP protocol ports is not allowed"), w)
-				return
-			}
-			if !pr.Bidirectional {
-				util.WriteError(status.Errorf(status.InvalidArgument, "for ALL or ICMP protocol type flow can be only bi-directional"), w)
-				return
-			}
-		case server.PolicyRuleProtocolTCP, server.PolicyRuleProtocolUDP:
-			if !pr.Bidirectional && len(pr.Ports) == 0 {
-				util.WriteError(status.Errorf(status.InvalidArgument, "for ALL or ICMP protocol type flow can be only bi-directional"), w)
-				return
-			}
+		if err := pr.Validate(); err != nil {
+			util.WriteError(err, w)
+			return
 		}
 
 		policy.Rules = append(policy.Rules, &pr)
@@ -215,7 +213,7 @@ func (h *Policies) savePolicy(
 		return
 	}
 
-	resp := toPolicyResponse(account, &policy)
+	resp := toPolicyResponse(account.Groups, &policy)
 	if len(resp.Rules) == 0 {
 		util.WriteError(status.Errorf(status.Internal, "no rules in the policy"), w)
 		return
@@ -273,7 +271,7 @@ func (h *Policies) GetPolicy(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		resp := toPolicyResponse(account, policy)
+		resp := toPolicyResponse(account.Groups, policy)
 		if len(resp.Rules) == 0 {
 			util.WriteError(status.Errorf(status.Internal, "no rules in the policy"), w)
 			return
@@ -285,7 +283,41 @@ func (h *Policies) GetPolicy(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func toPolicyResponse(account *server.Account, policy *server.Policy) *api.Policy {
+// GetPolicyStats returns the traffic matched by the firewall rules of a policy as last reported by the peers
+func (h *Policies) GetPolicyStats(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	policyID := mux.Vars(r)["policyId"]
+	if len(policyID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid policy ID"), w)
+		return
+	}
+
+	stats, err := h.accountManager.GetPolicyStats(account.Id, policyID, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := &api.PolicyStats{
+		PolicyId: stats.PolicyID,
+		Packets:  int64(stats.Packets),
+		Bytes:    int64(stats.Bytes),
+		Peers:    stats.Peers,
+	}
+	if !stats.LastReportedAt.IsZero() {
+		resp.LastReportedAt = &stats.LastReportedAt
+	}
+
+	util.WriteJSONObject(w, resp)
+}
+
+func toPolicyResponse(groups map[string]*nbgroup.Group, policy *server.Policy) *api.Policy {
 	cache := make(map[string]api.GroupMinimum)
 	ap := &api.Policy{
 		Id:                  &policy.ID,
@@ -294,6 +326,18 @@ func toPolicyResponse(account *server.Account, policy *server.Policy) *api.Polic
 		Enabled:             policy.Enabled,
 		SourcePostureChecks: policy.SourcePostureChecks,
 	}
+	if policy.RequireDirect {
+		requireDirect := true
+		ap.RequireDirect = &requireDirect
+	}
+	if policy.SSHEnabled {
+		sshEnabled := true
+		ap.SshEnabled = &sshEnabled
+	}
+	if policy.SpeedtestEnabled {
+		speedtestEnabled := true
+		ap.SpeedtestEnabled = &speedtestEnabled
+	}
 	for _, r := range policy.Rules {
 		rID := r.ID
 		rDescription := r.Description
@@ -310,12 +354,20 @@ func toPolicyResponse(account *server.Account, policy *server.Policy) *api.Polic
 			portsCopy := r.Ports
 			rule.Ports = &portsCopy
 		}
+		if r.Egress {
+			egress := r.Egress
+			rule.Egress = &egress
+		}
+		if len(r.DestinationLists) != 0 {
+			destinationLists := r.DestinationLists
+			rule.DestinationLists = &destinationLists
+		}
 		for _, gid := range r.Sources {
 			_, ok := cache[gid]
 			if ok {
 				continue
 			}
-			if group, ok := account.Groups[gid]; ok {
+			if group, ok := groups[gid]; ok {
 				minimum := api.GroupMinimum{
 					Id:         group.ID,
 					Name:       group.Name,
@@ -331,7 +383,7 @@ func toPolicyResponse(account *server.Account, policy *server.Policy) *api.Polic
 				rule.Destinations = append(rule.Destinations, cachedMinimum)
 				continue
 			}
-			if group, ok := account.Groups[gid]; ok {
+			if group, ok := groups[gid]; ok {
 				minimum := api.GroupMinimum{
 					Id:         group.ID,
 					Name:       group.Name,
@@ -357,6 +409,18 @@ func groupMinimumsToStrings(account *server.Account, gm []string) []string {
 	return result
 }
 
+// externalListsToStrings drops the IDs of the external lists the account doesn't have
+func externalListsToStrings(account *server.Account, listIDs []string) []string {
+	result := make([]string, 0, len(listIDs))
+	for _, id := range listIDs {
+		if _, ok := account.ExternalLists[id]; !ok {
+			continue
+		}
+		result = append(result, id)
+	}
+	return result
+}
+
 func sourcePostureChecksToStrings(account *server.Account, postureChecksIds []string) []string {
 	result := make([]string, 0, len(postureChecksIds))
 	for _, id := range postureChecksIds {
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	nbgroup "github.com/netbirdio/netbird/management/server/group"
 	"github.com/netbirdio/netbird/management/server/http/api"
@@ -37,6 +38,18 @@ func initPoliciesTestData(policies ...*server.Policy) *Policies {
 				}
 				return policy, nil
 			},
+			GetPolicyStatsFunc: func(_, policyID, _ string) (*server.PolicyStats, error) {
+				if _, ok := testPolicies[policyID]; !ok {
+					return nil, status.Errorf(status.NotFound, "policy not found")
+				}
+				return &server.PolicyStats{
+					PolicyID:       policyID,
+					Packets:        120,
+					Bytes:          9000,
+					Peers:          2,
+					LastReportedAt: time.Date(2023, 5, 5, 9, 0, 35, 0, time.UTC),
+				}, nil
+			},
 			SavePolicyFunc: func(_, _ string, policy *server.Policy) error {
 				if !strings.HasPrefix(policy.ID, "id-") {
 					policy.ID = "id-was-set"
@@ -61,6 +74,19 @@ func initPoliciesTestData(policies ...*server.Policy) *Policies {
 					},
 				}, user, nil
 			},
+			GetAccountIDFromTokenFunc: func(claims jwtclaims.AuthorizationClaims) (string, string, error) {
+				return claims.AccountId, claims.UserId, nil
+			},
+			GetPoliciesForUserFunc: func(_, _ string) ([]*server.Policy, map[string]*nbgroup.Group, error) {
+				accountPolicies := make([]*server.Policy, 0, len(testPolicies))
+				for _, policy := range testPolicies {
+					accountPolicies = append(accountPolicies, policy)
+				}
+				return accountPolicies, map[string]*nbgroup.Group{
+					"F": {ID: "F"},
+					"G": {ID: "G"},
+				}, nil
+			},
 		},
 		claimsExtractor: jwtclaims.NewClaimsExtractor(
 			jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
@@ -146,8 +172,125 @@ func TestPoliciesGetPolicy(t *testing.T) {
 	}
 }
 
+func TestPoliciesGetAllPolicies(t *testing.T) {
+	policy := &server.Policy{
+		ID:   "idofthepolicy",
+		Name: "Rule",
+		Rules: []*server.PolicyRule{
+			{ID: "idoftherule", Name: "Rule", Sources: []string{"F"}, Destinations: []string{"G", "missing"}},
+		},
+	}
+
+	p := initPoliciesTestData(policy)
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/policies", nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/policies", p.GetAllPolicies).Methods("GET")
+	router.ServeHTTP(recorder, req)
+
+	res := recorder.Result()
+	defer res.Body.Close()
+
+	if status := recorder.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	content, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("I don't know what I expected; %v", err)
+	}
+
+	var got []api.Policy
+	if err = json.Unmarshal(content, &got); err != nil {
+		t.Fatalf("Sent content is not in correct json format; %v", err)
+	}
+
+	assert.Equal(t, len(got), 1)
+	assert.Equal(t, *got[0].Id, policy.ID)
+	assert.Equal(t, len(got[0].Rules[0].Sources), 1)
+	assert.Equal(t, got[0].Rules[0].Sources[0].Id, "F")
+	assert.Equal(t, len(got[0].Rules[0].Destinations), 1, "unknown groups should be skipped")
+}
+
+func TestPoliciesGetAllPoliciesOfAccount(t *testing.T) {
+	account := newTestAccountBuilder().withPeers(4).withGroups(2).withPolicies(3).build()
+	accountManager := newFakeAccountManager(account)
+
+	runHandlerTests(t, "/api/policies", func(claimsExtractor *jwtclaims.ClaimsExtractor) http.HandlerFunc {
+		p := &Policies{accountManager: accountManager, claimsExtractor: claimsExtractor}
+		return p.GetAllPolicies
+	}, []handlerTest{
+		{
+			name:           "admin user",
+			method:         http.MethodGet,
+			path:           "/api/policies",
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				got := decodeTestResponse[[]api.Policy](t, recorder)
+				assert.Equal(t, len(got), 3)
+				assert.Equal(t, got[2].Rules[0].Sources[0].Id, "group-1", "the policies should cycle through the groups")
+				assert.Equal(t, got[1].Rules[0].Sources[0].PeersCount, 2)
+			},
+		},
+		{
+			name:           "regular user",
+			userID:         fakeRegularUserID,
+			method:         http.MethodGet,
+			path:           "/api/policies",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "unknown user",
+			userID:         "unknown",
+			method:         http.MethodGet,
+			path:           "/api/policies",
+			expectedStatus: http.StatusNotFound,
+		},
+	})
+}
+
+func TestPoliciesGetPolicyStats(t *testing.T) {
+	policy := &server.Policy{
+		ID:   "idofthepolicy",
+		Name: "Rule",
+		Rules: []*server.PolicyRule{
+			{ID: "idoftherule", Name: "Rule"},
+		},
+	}
+
+	p := initPoliciesTestData(policy)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/policies/{policyId}/stats", p.GetPolicyStats).Methods("GET")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/policies/notexists/stats", nil))
+	assert.Equal(t, recorder.Code, http.StatusNotFound)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/policies/idofthepolicy/stats", nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+
+	var got api.PolicyStats
+	if err := json.NewDecoder(recorder.Body).Decode(&got); err != nil {
+		t.Fatalf("Sent content is not in correct json format; %v", err)
+	}
+
+	reportedAt := time.Date(2023, 5, 5, 9, 0, 35, 0, time.UTC)
+	assert.Equal(t, got, api.PolicyStats{
+		PolicyId:       policy.ID,
+		Packets:        120,
+		Bytes:          9000,
+		Peers:          2,
+		LastReportedAt: &reportedAt,
+	})
+}
+
 func TestPoliciesWritePolicy(t *testing.T) {
 	str := func(s string) *string { return &s }
+	egress := true
 	tt := []struct {
 		name           string
 		expectedStatus int
@@ -234,6 +377,58 @@ func TestPoliciesWritePolicy(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:        "WritePolicy POST Egress OK",
+			requestType: http.MethodPost,
+			requestPath: "/api/policies",
+			requestBody: bytes.NewBuffer(
+				[]byte(`{
+                    "Name":"Egress POSTed Policy",
+                    "Rules":[
+                        {
+                            "Name":"Egress POSTed Policy",
+                            "Description": "Description",
+                            "Protocol": "all",
+                            "Action": "drop",
+                            "Egress":true
+                        }
+                ]}`)),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedPolicy: &api.Policy{
+				Id:   str("id-was-set"),
+				Name: "Egress POSTed Policy",
+				Rules: []api.PolicyRule{
+					{
+						Id:          str("id-was-set"),
+						Name:        "Egress POSTed Policy",
+						Description: str("Description"),
+						Protocol:    "all",
+						Action:      "drop",
+						Egress:      &egress,
+					},
+				},
+			},
+		},
+		{
+			name:        "WritePolicy POST Bidirectional Egress",
+			requestType: http.MethodPost,
+			requestPath: "/api/policies",
+			requestBody: bytes.NewBuffer(
+				[]byte(`{
+                    "Name":"Egress POSTed Policy",
+                    "Rules":[
+                        {
+                            "Name":"Egress POSTed Policy",
+                            "Protocol": "all",
+                            "Action": "drop",
+                            "Bidirectional":true,
+                            "Egress":true
+                        }
+                ]}`)),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   false,
+		},
 		{
 			name:        "WritePolicy PUT Invalid Name",
 			requestType: http.MethodPut,
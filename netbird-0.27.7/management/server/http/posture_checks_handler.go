@@ -168,13 +168,13 @@ func (p *PostureChecksHandler) savePostureChecks(
 
 	var req api.PostureCheckUpdate
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		util.WriteJSONParseError(err, w)
 		return
 	}
 
 	err := validatePostureChecksUpdate(req)
 	if err != nil {
-		util.WriteErrorResponse(err.Error(), http.StatusBadRequest, w)
+		util.WriteBadRequest(err, w)
 		return
 	}
 
@@ -221,6 +221,10 @@ func (p *PostureChecksHandler) savePostureChecks(
 		}
 	}
 
+	if packageVersionCheck := req.Checks.PackageVersionCheck; packageVersionCheck != nil {
+		postureChecks.Checks.PackageVersionCheck = toPosturePackageVersionCheck(packageVersionCheck)
+	}
+
 	if err := p.accountManager.SavePostureChecks(account.Id, user.Id, &postureChecks); err != nil {
 		util.WriteError(err, w)
 		return
@@ -231,16 +235,17 @@ func (p *PostureChecksHandler) savePostureChecks(
 
 func validatePostureChecksUpdate(req api.PostureCheckUpdate) error {
 	if req.Name == "" {
-		return status.Errorf(status.InvalidArgument, "posture checks name shouldn't be empty")
+		return status.FieldErrorf(status.InvalidArgument, "name", "posture checks name shouldn't be empty")
 	}
 
 	if req.Checks == nil || (req.Checks.NbVersionCheck == nil && req.Checks.OsVersionCheck == nil &&
-		req.Checks.GeoLocationCheck == nil && req.Checks.PeerNetworkRangeCheck == nil) {
-		return status.Errorf(status.InvalidArgument, "posture checks shouldn't be empty")
+		req.Checks.GeoLocationCheck == nil && req.Checks.PeerNetworkRangeCheck == nil &&
+		req.Checks.PackageVersionCheck == nil) {
+		return status.FieldErrorf(status.InvalidArgument, "checks", "posture checks shouldn't be empty")
 	}
 
 	if req.Checks.NbVersionCheck != nil && req.Checks.NbVersionCheck.MinVersion == "" {
-		return status.Errorf(status.InvalidArgument, "minimum version for NetBird's version check shouldn't be empty")
+		return status.FieldErrorf(status.InvalidArgument, "checks.nb_version_check.min_version", "minimum version for NetBird's version check shouldn't be empty")
 	}
 
 	if osVersionCheck := req.Checks.OsVersionCheck; osVersionCheck != nil {
@@ -252,43 +257,57 @@ func validatePostureChecksUpdate(req api.PostureCheckUpdate) error {
 			osVersionCheck.Linux != nil && osVersionCheck.Linux.MinKernelVersion == "" ||
 			osVersionCheck.Windows != nil && osVersionCheck.Windows.MinKernelVersion == ""
 		if emptyOS || emptyMinVersion {
-			return status.Errorf(status.InvalidArgument,
+			return status.FieldErrorf(status.InvalidArgument, "checks.os_version_check",
 				"minimum version for at least one OS in the OS version check shouldn't be empty")
 		}
 	}
 
 	if geoLocationCheck := req.Checks.GeoLocationCheck; geoLocationCheck != nil {
 		if geoLocationCheck.Action == "" {
-			return status.Errorf(status.InvalidArgument, "action for geolocation check shouldn't be empty")
+			return status.FieldErrorf(status.InvalidArgument, "checks.geo_location_check.action", "action for geolocation check shouldn't be empty")
 		}
 		allowedActions := []api.GeoLocationCheckAction{api.GeoLocationCheckActionAllow, api.GeoLocationCheckActionDeny}
 		if !slices.Contains(allowedActions, geoLocationCheck.Action) {
-			return status.Errorf(status.InvalidArgument, "action for geolocation check is not valid value")
+			return status.FieldErrorf(status.InvalidArgument, "checks.geo_location_check.action", "action for geolocation check is not valid value")
 		}
 		if len(geoLocationCheck.Locations) == 0 {
-			return status.Errorf(status.InvalidArgument, "locations for geolocation check shouldn't be empty")
+			return status.FieldErrorf(status.InvalidArgument, "checks.geo_location_check.locations", "locations for geolocation check shouldn't be empty")
 		}
 		for _, loc := range geoLocationCheck.Locations {
 			if loc.CountryCode == "" {
-				return status.Errorf(status.InvalidArgument, "country code for geolocation check shouldn't be empty")
+				return status.FieldErrorf(status.InvalidArgument, "checks.geo_location_check.locations.country_code", "country code for geolocation check shouldn't be empty")
 			}
 			if !countryCodeRegex.MatchString(loc.CountryCode) {
-				return status.Errorf(status.InvalidArgument, "country code must be 2 letters (ISO 3166-1 alpha-2 format)")
+				return status.FieldErrorf(status.InvalidArgument, "checks.geo_location_check.locations.country_code", "country code must be 2 letters (ISO 3166-1 alpha-2 format)")
 			}
 		}
 	}
 
 	if peerNetworkRangeCheck := req.Checks.PeerNetworkRangeCheck; peerNetworkRangeCheck != nil {
 		if peerNetworkRangeCheck.Action == "" {
-			return status.Errorf(status.InvalidArgument, "action for peer network range check shouldn't be empty")
+			return status.FieldErrorf(status.InvalidArgument, "checks.peer_network_range_check.action", "action for peer network range check shouldn't be empty")
 		}
 
 		allowedActions := []api.PeerNetworkRangeCheckAction{api.PeerNetworkRangeCheckActionAllow, api.PeerNetworkRangeCheckActionDeny}
 		if !slices.Contains(allowedActions, peerNetworkRangeCheck.Action) {
-			return status.Errorf(status.InvalidArgument, "action for peer network range check is not valid value")
+			return status.FieldErrorf(status.InvalidArgument, "checks.peer_network_range_check.action", "action for peer network range check is not valid value")
 		}
 		if len(peerNetworkRangeCheck.Ranges) == 0 {
-			return status.Errorf(status.InvalidArgument, "network ranges for peer network range check shouldn't be empty")
+			return status.FieldErrorf(status.InvalidArgument, "checks.peer_network_range_check.ranges", "network ranges for peer network range check shouldn't be empty")
+		}
+	}
+
+	if packageVersionCheck := req.Checks.PackageVersionCheck; packageVersionCheck != nil {
+		if len(packageVersionCheck.Packages) == 0 {
+			return status.FieldErrorf(status.InvalidArgument, "checks.package_version_check.packages", "packages for package version check shouldn't be empty")
+		}
+		for _, pkg := range packageVersionCheck.Packages {
+			if pkg.Name == "" {
+				return status.FieldErrorf(status.InvalidArgument, "checks.package_version_check.packages.name", "package name for package version check shouldn't be empty")
+			}
+			if pkg.MinVersion == "" {
+				return status.FieldErrorf(status.InvalidArgument, "checks.package_version_check.packages.min_version", "minimum version for package version check shouldn't be empty")
+			}
 		}
 	}
 
@@ -322,6 +341,10 @@ func toPostureChecksResponse(postureChecks *posture.Checks) *api.PostureCheck {
 		checks.PeerNetworkRangeCheck = toPeerNetworkRangeCheckResponse(postureChecks.Checks.PeerNetworkRangeCheck)
 	}
 
+	if postureChecks.Checks.PackageVersionCheck != nil {
+		checks.PackageVersionCheck = toPackageVersionCheckResponse(postureChecks.Checks.PackageVersionCheck)
+	}
+
 	return &api.PostureCheck{
 		Id:          postureChecks.ID,
 		Name:        postureChecks.Name,
@@ -396,3 +419,38 @@ func toPeerNetworkRangeCheck(check *api.PeerNetworkRangeCheck) (*posture.PeerNet
 		Action: string(check.Action),
 	}, nil
 }
+
+func toPackageVersionCheckResponse(check *posture.PackageVersionCheck) *api.PackageVersionCheck {
+	packages := make([]api.MinPackageVersion, 0, len(check.Packages))
+	for _, pkg := range check.Packages {
+		packages = append(packages, api.MinPackageVersion{
+			Name:       pkg.Name,
+			MinVersion: pkg.MinVersion,
+		})
+	}
+
+	return &api.PackageVersionCheck{
+		Packages:         packages,
+		RequireInventory: &check.RequireInventory,
+	}
+}
+
+func toPosturePackageVersionCheck(check *api.PackageVersionCheck) *posture.PackageVersionCheck {
+	packages := make([]posture.MinPackageVersion, 0, len(check.Packages))
+	for _, pkg := range check.Packages {
+		packages = append(packages, posture.MinPackageVersion{
+			Name:       pkg.Name,
+			MinVersion: pkg.MinVersion,
+		})
+	}
+
+	var requireInventory bool
+	if check.RequireInventory != nil {
+		requireInventory = *check.RequireInventory
+	}
+
+	return &posture.PackageVersionCheck{
+		Packages:         packages,
+		RequireInventory: requireInventory,
+	}
+}
@@ -225,6 +225,7 @@ func TestGetPostureCheck(t *testing.T) {
 
 func TestPostureCheckUpdate(t *testing.T) {
 	str := func(s string) *string { return &s }
+	boolPtr := func(b bool) *bool { return &b }
 	tt := []struct {
 		name                 string
 		expectedStatus       int
@@ -407,6 +408,58 @@ func TestPostureCheckUpdate(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:        "Create Posture Checks Package Version",
+			requestType: http.MethodPost,
+			requestPath: "/api/posture-checks",
+			requestBody: bytes.NewBuffer(
+				[]byte(`{
+					"name": "default",
+					"description": "default",
+					"checks": {
+						"package_version_check": {
+							"packages": [
+								{
+									"name": "libopenssl3",
+									"min_version": "3.0.14-1"
+								}
+							],
+							"require_inventory": true
+						}
+					}
+					}`)),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedPostureCheck: &api.PostureCheck{
+				Id:          "postureCheck",
+				Name:        "default",
+				Description: str("default"),
+				Checks: api.Checks{
+					PackageVersionCheck: &api.PackageVersionCheck{
+						Packages: []api.MinPackageVersion{
+							{Name: "libopenssl3", MinVersion: "3.0.14-1"},
+						},
+						RequireInventory: boolPtr(true),
+					},
+				},
+			},
+		},
+		{
+			name:        "Create Posture Checks Package Version without packages",
+			requestType: http.MethodPost,
+			requestPath: "/api/posture-checks",
+			requestBody: bytes.NewBuffer(
+				[]byte(`{
+					"name": "default",
+					"checks": {
+						"package_version_check": {
+							"packages": []
+						}
+					}
+					}`)),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   false,
+		},
 		{
 			name:        "Create Posture Checks Geo Location with No geolocation DB",
 			requestType: http.MethodPost,
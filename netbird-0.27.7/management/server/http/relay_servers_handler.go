@@ -0,0 +1,242 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/http/util"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// RelayServersHandler is the TURN relay servers handler of the account
+type RelayServersHandler struct {
+	accountManager  server.AccountManager
+	claimsExtractor *jwtclaims.ClaimsExtractor
+}
+
+// NewRelayServersHandler returns a new instance of RelayServersHandler handler
+func NewRelayServersHandler(accountManager server.AccountManager, authCfg AuthCfg) *RelayServersHandler {
+	return &RelayServersHandler{
+		accountManager: accountManager,
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithAudience(authCfg.Audience),
+			jwtclaims.WithUserIDClaim(authCfg.UserIDClaim),
+		),
+	}
+}
+
+// GetAllRelayServers returns the list of relay servers for the account
+func (h *RelayServersHandler) GetAllRelayServers(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	relays, err := h.accountManager.ListRelayServers(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	apiRelays := make([]*api.RelayServer, 0)
+	for _, relay := range relays {
+		apiRelays = append(apiRelays, toRelayServerResponse(relay))
+	}
+
+	util.WriteJSONObject(w, apiRelays)
+}
+
+// CreateRelayServer handles relay server creation request
+func (h *RelayServersHandler) CreateRelayServer(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	var req api.PostApiRelayServersJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteJSONParseError(err, w)
+		return
+	}
+
+	relay, err := h.accountManager.CreateRelayServer(account.Id, user.Id, toServerRelayServer("", req, nil))
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toRelayServerResponse(relay)
+
+	util.WriteJSONObject(w, &resp)
+}
+
+// UpdateRelayServer handles update to a relay server identified by a given ID. The credentials omitted from the
+// request are kept
+func (h *RelayServersHandler) UpdateRelayServer(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	relayID := mux.Vars(r)["relayId"]
+	if len(relayID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid relay server ID"), w)
+		return
+	}
+
+	var req api.PutApiRelayServersRelayIdJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteJSONParseError(err, w)
+		return
+	}
+
+	current, err := h.accountManager.GetRelayServer(account.Id, user.Id, relayID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	err = h.accountManager.SaveRelayServer(account.Id, user.Id, toServerRelayServer(relayID, req, current))
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	// the account manager normalizes the relay server, return the stored version
+	relay, err := h.accountManager.GetRelayServer(account.Id, user.Id, relayID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toRelayServerResponse(relay)
+
+	util.WriteJSONObject(w, &resp)
+}
+
+// DeleteRelayServer handles relay server deletion request
+func (h *RelayServersHandler) DeleteRelayServer(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	relayID := mux.Vars(r)["relayId"]
+	if len(relayID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid relay server ID"), w)
+		return
+	}
+
+	err = h.accountManager.DeleteRelayServer(account.Id, user.Id, relayID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, emptyObject{})
+}
+
+// GetRelayServer handles a relay server Get request identified by ID
+func (h *RelayServersHandler) GetRelayServer(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	relayID := mux.Vars(r)["relayId"]
+	if len(relayID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid relay server ID"), w)
+		return
+	}
+
+	relay, err := h.accountManager.GetRelayServer(account.Id, user.Id, relayID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toRelayServerResponse(relay)
+
+	util.WriteJSONObject(w, &resp)
+}
+
+// toServerRelayServer converts the request, taking the credentials it omits from the current relay server if any
+func toServerRelayServer(relayID string, req api.RelayServerRequest, current *server.RelayServer) *server.RelayServer {
+	relay := &server.RelayServer{
+		ID:        relayID,
+		Name:      req.Name,
+		Addresses: req.Addresses,
+		Enabled:   req.Enabled,
+	}
+
+	if req.Description != nil {
+		relay.Description = *req.Description
+	}
+
+	if req.GeoLabels != nil {
+		relay.GeoLabels = *req.GeoLabels
+	}
+
+	if req.CredentialsTtl != nil {
+		relay.CredentialsTTL = time.Duration(*req.CredentialsTtl) * time.Second
+	}
+
+	if req.Username != nil {
+		relay.Username = *req.Username
+	}
+
+	switch {
+	case req.Secret != nil:
+		relay.Secret = *req.Secret
+	case req.Password != nil:
+		relay.Password = *req.Password
+	case current != nil:
+		relay.Secret = current.Secret
+		relay.Password = current.Password
+		if req.Username == nil {
+			relay.Username = current.Username
+		}
+	}
+
+	return relay
+}
+
+func toRelayServerResponse(relay *server.RelayServer) *api.RelayServer {
+	geoLabels := relay.GeoLabels
+	if geoLabels == nil {
+		geoLabels = []string{}
+	}
+
+	return &api.RelayServer{
+		Id:                   relay.ID,
+		Name:                 relay.Name,
+		Description:          relay.Description,
+		Addresses:            relay.Addresses,
+		Username:             relay.Username,
+		TimeBasedCredentials: relay.Secret != "",
+		CredentialsTtl:       int(relay.CredentialsTTL / time.Second),
+		GeoLabels:            geoLabels,
+		Enabled:              relay.Enabled,
+	}
+}
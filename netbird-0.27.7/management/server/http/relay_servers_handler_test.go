@@ -0,0 +1,220 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/mock_server"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	existingRelayServerID = "existingRelayServerID"
+	notFoundRelayServerID = "notFoundRelayServerID"
+)
+
+var baseExistingRelayServer = &server.RelayServer{
+	ID:             existingRelayServerID,
+	Name:           "Frankfurt relay",
+	Addresses:      []string{"turn:relay.example.com:3478?transport=udp"},
+	Secret:         "secret",
+	CredentialsTTL: 12 * time.Hour,
+	GeoLabels:      []string{"DE"},
+	Enabled:        true,
+}
+
+func initRelayServersTestData(saved **server.RelayServer) *RelayServersHandler {
+	return &RelayServersHandler{
+		accountManager: &mock_server.MockAccountManager{
+			GetRelayServerFunc: func(_, _, relayID string) (*server.RelayServer, error) {
+				if relayID == existingRelayServerID {
+					return baseExistingRelayServer.Copy(), nil
+				}
+				return nil, status.Errorf(status.NotFound, "relay server with ID %s not found", relayID)
+			},
+			CreateRelayServerFunc: func(_, _ string, relay *server.RelayServer) (*server.RelayServer, error) {
+				if len(relay.Addresses) == 0 {
+					return nil, status.Errorf(status.InvalidArgument, "relay server should have at least one address")
+				}
+				created := relay.Copy()
+				created.ID = existingRelayServerID
+				return created, nil
+			},
+			SaveRelayServerFunc: func(_, _ string, relay *server.RelayServer) error {
+				if relay.ID != existingRelayServerID {
+					return status.Errorf(status.NotFound, "relay server with ID %s was not found", relay.ID)
+				}
+				*saved = relay
+				return nil
+			},
+			DeleteRelayServerFunc: func(_, _, relayID string) error {
+				return nil
+			},
+			GetAccountFromTokenFunc: func(_ jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error) {
+				return testingNSAccount, testingAccount.Users["test_user"], nil
+			},
+		},
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+				return jwtclaims.AuthorizationClaims{
+					UserId:    "test_user",
+					Domain:    "hotmail.com",
+					AccountId: testNSGroupAccountID,
+				}
+			}),
+		),
+	}
+}
+
+func TestRelayServersHandlers(t *testing.T) {
+	tt := []struct {
+		name           string
+		expectedStatus int
+		expectedBody   bool
+		expectedRelay  *api.RelayServer
+		requestType    string
+		requestPath    string
+		requestBody    io.Reader
+	}{
+		{
+			name:           "Get Existing Relay Server",
+			requestType:    http.MethodGet,
+			requestPath:    "/api/relay-servers/" + existingRelayServerID,
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedRelay: &api.RelayServer{
+				Id:                   existingRelayServerID,
+				Name:                 "Frankfurt relay",
+				Addresses:            []string{"turn:relay.example.com:3478?transport=udp"},
+				TimeBasedCredentials: true,
+				CredentialsTtl:       43200,
+				GeoLabels:            []string{"DE"},
+				Enabled:              true,
+			},
+		},
+		{
+			name:           "Get Not Existing Relay Server",
+			requestType:    http.MethodGet,
+			requestPath:    "/api/relay-servers/" + notFoundRelayServerID,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:        "POST OK",
+			requestType: http.MethodPost,
+			requestPath: "/api/relay-servers",
+			requestBody: bytes.NewBufferString(`{"name":"Office relay","addresses":["turns:relay.example.com:5349"],` +
+				`"username":"netbird","password":"pass","enabled":true}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedRelay: &api.RelayServer{
+				Id:        existingRelayServerID,
+				Name:      "Office relay",
+				Addresses: []string{"turns:relay.example.com:5349"},
+				Username:  "netbird",
+				GeoLabels: []string{},
+				Enabled:   true,
+			},
+		},
+		{
+			name:           "POST Without Addresses",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/relay-servers",
+			requestBody:    bytes.NewBufferString(`{"name":"Office relay","addresses":[],"secret":"secret","enabled":true}`),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "PUT Not Existing Relay Server",
+			requestType:    http.MethodPut,
+			requestPath:    "/api/relay-servers/" + notFoundRelayServerID,
+			requestBody:    bytes.NewBufferString(`{"name":"Frankfurt relay","addresses":["turn:relay.example.com"],"enabled":true}`),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "DELETE Relay Server",
+			requestType:    http.MethodDelete,
+			requestPath:    "/api/relay-servers/" + existingRelayServerID,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	var saved *server.RelayServer
+	p := initRelayServersTestData(&saved)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.requestType, tc.requestPath, tc.requestBody)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/relay-servers/{relayId}", p.GetRelayServer).Methods("GET")
+			router.HandleFunc("/api/relay-servers", p.CreateRelayServer).Methods("POST")
+			router.HandleFunc("/api/relay-servers/{relayId}", p.DeleteRelayServer).Methods("DELETE")
+			router.HandleFunc("/api/relay-servers/{relayId}", p.UpdateRelayServer).Methods("PUT")
+			router.ServeHTTP(recorder, req)
+
+			res := recorder.Result()
+			defer res.Body.Close()
+
+			content, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("I don't know what I expected; %v", err)
+			}
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v, content: %s",
+					status, tc.expectedStatus, string(content))
+				return
+			}
+
+			if !tc.expectedBody {
+				return
+			}
+
+			got := &api.RelayServer{}
+			if err = json.Unmarshal(content, &got); err != nil {
+				t.Fatalf("Sent content is not in correct json format; %v", err)
+			}
+			assert.Equal(t, tc.expectedRelay, got)
+		})
+	}
+}
+
+func TestUpdateRelayServerKeepsCredentials(t *testing.T) {
+	var saved *server.RelayServer
+	p := initRelayServersTestData(&saved)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/relay-servers/{relayId}", p.UpdateRelayServer).Methods("PUT")
+
+	body := `{"name":"Frankfurt relay","addresses":["turn:relay2.example.com"],"geo_labels":["DE","AT"],"enabled":false}`
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/api/relay-servers/"+existingRelayServerID, bytes.NewBufferString(body)))
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	require.NotNil(t, saved)
+	assert.Equal(t, "secret", saved.Secret, "an omitted secret should be kept")
+	assert.Equal(t, []string{"turn:relay2.example.com"}, saved.Addresses)
+	assert.Equal(t, []string{"DE", "AT"}, saved.GeoLabels)
+	assert.False(t, saved.Enabled)
+
+	body = `{"name":"Frankfurt relay","addresses":["turn:relay2.example.com"],"username":"netbird","password":"pass","enabled":true}`
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/api/relay-servers/"+existingRelayServerID, bytes.NewBufferString(body)))
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	assert.Empty(t, saved.Secret, "static credentials should replace the secret")
+	assert.Equal(t, "netbird", saved.Username)
+	assert.Equal(t, "pass", saved.Password)
+}
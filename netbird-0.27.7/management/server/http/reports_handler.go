@@ -0,0 +1,258 @@
+package http
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/http/util"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const usageReportDateLayout = "2006-01-02"
+
+// ReportsHandler is a handler that returns the usage reports of the account
+type ReportsHandler struct {
+	accountManager  server.AccountManager
+	claimsExtractor *jwtclaims.ClaimsExtractor
+}
+
+// NewReportsHandler creates a new ReportsHandler HTTP handler
+func NewReportsHandler(accountManager server.AccountManager, authCfg AuthCfg) *ReportsHandler {
+	return &ReportsHandler{
+		accountManager: accountManager,
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithAudience(authCfg.Audience),
+			jwtclaims.WithUserIDClaim(authCfg.UserIDClaim),
+		),
+	}
+}
+
+// GetUsageReport returns the usage of the account per period in JSON or CSV
+func (h *ReportsHandler) GetUsageReport(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	query := r.URL.Query()
+	period := server.UsageReportPeriodMonth
+	if param := query.Get("period"); param != "" {
+		period = server.UsageReportPeriod(param)
+	}
+	if err := period.Validate(); err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		util.WriteError(status.Errorf(status.InvalidArgument, "unsupported usage report format %s, expected json or csv", format), w)
+		return
+	}
+
+	to := time.Now().UTC()
+	if param := query.Get("to"); param != "" {
+		to, err = parseUsageReportTime(param)
+		if err != nil {
+			util.WriteError(status.Errorf(status.InvalidArgument, "invalid usage report end %s", param), w)
+			return
+		}
+	}
+
+	// by default the report covers the period the end is in
+	from := to.Add(-time.Nanosecond)
+	if param := query.Get("from"); param != "" {
+		from, err = parseUsageReportTime(param)
+		if err != nil {
+			util.WriteError(status.Errorf(status.InvalidArgument, "invalid usage report start %s", param), w)
+			return
+		}
+	}
+
+	report, err := h.accountManager.GetUsageReport(account.Id, user.Id, from, to, period)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	if format == "csv" {
+		writeUsageReportCSV(w, report)
+		return
+	}
+
+	util.WriteJSONObject(w, toUsageReportResponse(report))
+}
+
+// parseUsageReportTime parses a RFC 3339 time or a date, taken at midnight UTC
+func parseUsageReportTime(value string) (time.Time, error) {
+	if t, err := time.Parse(usageReportDateLayout, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
+
+func toUsageReportResponse(report *server.UsageReport) *api.UsageReport {
+	periods := make([]api.UsagePeriod, 0, len(report.Periods))
+	for _, p := range report.Periods {
+		var routingPeers *[]api.RoutingPeerUsage
+		if p.RoutingPeers != nil {
+			usage := make([]api.RoutingPeerUsage, 0, len(p.RoutingPeers))
+			for _, peerUsage := range p.RoutingPeers {
+				usage = append(usage, api.RoutingPeerUsage{
+					PeerId:   peerUsage.PeerID,
+					PeerName: peerUsage.PeerName,
+					RxBytes:  peerUsage.RxBytes,
+					TxBytes:  peerUsage.TxBytes,
+				})
+			}
+			routingPeers = &usage
+		}
+
+		periods = append(periods, api.UsagePeriod{
+			Start:         p.Start,
+			End:           p.End,
+			ActivePeers:   p.ActivePeers,
+			NewPeers:      p.NewPeers,
+			PolicyChanges: p.PolicyChanges,
+			RoutingPeers:  routingPeers,
+		})
+	}
+
+	return &api.UsageReport{
+		From:    report.From,
+		To:      report.To,
+		Period:  api.UsageReportPeriod(report.Period),
+		Periods: periods,
+	}
+}
+
+// writeUsageReportCSV writes the report with a row per metric of each period, the transfer metrics having a row per
+// routing peer
+func writeUsageReportCSV(w http.ResponseWriter, report *server.UsageReport) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="usage-report.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	records := [][]string{{"period_start", "period_end", "metric", "peer_id", "peer_name", "value"}}
+	for _, p := range report.Periods {
+		start, end := p.Start.Format(time.RFC3339), p.End.Format(time.RFC3339)
+		records = append(records,
+			[]string{start, end, "active_peers", "", "", strconv.Itoa(p.ActivePeers)},
+			[]string{start, end, "new_peers", "", "", strconv.Itoa(p.NewPeers)},
+			[]string{start, end, "policy_changes", "", "", strconv.Itoa(p.PolicyChanges)},
+		)
+		for _, peerUsage := range p.RoutingPeers {
+			records = append(records,
+				[]string{start, end, "rx_bytes", peerUsage.PeerID, peerUsage.PeerName, strconv.FormatInt(peerUsage.RxBytes, 10)},
+				[]string{start, end, "tx_bytes", peerUsage.PeerID, peerUsage.PeerName, strconv.FormatInt(peerUsage.TxBytes, 10)},
+			)
+		}
+	}
+
+	if err := writer.WriteAll(records); err != nil {
+		log.Errorf("failed to write the usage report: %v", err)
+	}
+}
+
+// GetPackagesReport returns the versions of the packages the peers reported with the peers they are installed on
+func (h *ReportsHandler) GetPackagesReport(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	accountID, userID, err := h.accountManager.GetAccountIDFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	query := r.URL.Query()
+	name := query.Get("name")
+	var vulnerableOnly bool
+	if param := query.Get("vulnerable"); param != "" {
+		vulnerableOnly, err = strconv.ParseBool(param)
+		if err != nil {
+			util.WriteError(status.FieldErrorf(status.InvalidArgument, "vulnerable", "invalid vulnerable filter %s", param), w)
+			return
+		}
+	}
+
+	reports, err := h.accountManager.GetPackagesReport(accountID, userID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	response := make([]*api.PackageVersionReport, 0, len(reports))
+	for _, report := range reports {
+		if name != "" && report.Name != name {
+			continue
+		}
+		if vulnerableOnly && !report.Vulnerable() {
+			continue
+		}
+		response = append(response, toPackageVersionReportResponse(report))
+	}
+
+	util.WriteJSONObject(w, response)
+}
+
+func toPackageVersionReportResponse(report *server.PackageVersionReport) *api.PackageVersionReport {
+	var minVersion *string
+	if report.MinVersion != "" {
+		minVersion = &report.MinVersion
+	}
+
+	return &api.PackageVersionReport{
+		Name:       report.Name,
+		Version:    report.Version,
+		PeersCount: len(report.PeerIDs),
+		Peers:      report.PeerIDs,
+		MinVersion: minVersion,
+		Vulnerable: report.Vulnerable(),
+	}
+}
+
+// GetAPIUsage returns the HTTP API requests of the account against its quota
+func (h *ReportsHandler) GetAPIUsage(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	accountID, userID, err := h.accountManager.GetAccountIDFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	usage, err := h.accountManager.GetAPIUsage(accountID, userID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toAPIUsageResponse(usage))
+}
+
+func toAPIUsageResponse(usage *server.APIUsage) *api.APIUsage {
+	return &api.APIUsage{
+		Quota: api.APIQuota{
+			RequestsPerMinute: usage.Quota.RequestsPerMinute,
+			RequestsPerDay:    usage.Quota.RequestsPerDay,
+		},
+		MinuteRequests:   usage.MinuteRequests,
+		DayRequests:      usage.DayRequests,
+		MinuteResetsAt:   usage.MinuteResetsAt,
+		DayResetsAt:      usage.DayResetsAt,
+		TotalRequests:    usage.TotalRequests,
+		RejectedRequests: usage.RejectedRequests,
+	}
+}
@@ -0,0 +1,282 @@
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/mock_server"
+)
+
+func initReportsTestData(report *server.UsageReport, requested *[]any) *ReportsHandler {
+	return &ReportsHandler{
+		accountManager: &mock_server.MockAccountManager{
+			GetUsageReportFunc: func(accountID, userID string, from, to time.Time, period server.UsageReportPeriod) (*server.UsageReport, error) {
+				*requested = []any{from, to, period}
+				return report, nil
+			},
+			GetAccountFromTokenFunc: func(claims jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error) {
+				user := server.NewAdminUser("test_user")
+				return &server.Account{
+					Id:    claims.AccountId,
+					Users: map[string]*server.User{user.Id: user},
+				}, user, nil
+			},
+		},
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+				return jwtclaims.AuthorizationClaims{
+					UserId:    "test_user",
+					Domain:    "hotmail.com",
+					AccountId: "test_account",
+				}
+			}),
+		),
+	}
+}
+
+func TestGetUsageReport(t *testing.T) {
+	may := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	june := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	report := &server.UsageReport{
+		From:   may,
+		To:     june,
+		Period: server.UsageReportPeriodMonth,
+		Periods: []*server.UsagePeriod{
+			{
+				Start:         may,
+				End:           june,
+				ActivePeers:   4,
+				NewPeers:      2,
+				PolicyChanges: 1,
+				RoutingPeers:  []server.RoutingPeerUsage{{PeerID: "router", PeerName: "office", RxBytes: 2048, TxBytes: 1024}},
+			},
+		},
+	}
+
+	tt := []struct {
+		name           string
+		requestPath    string
+		expectedStatus int
+		expectedFrom   time.Time
+		expectedTo     time.Time
+		expectedPeriod server.UsageReportPeriod
+	}{
+		{
+			name:           "dates",
+			requestPath:    "/api/reports/usage?from=2024-05-01&to=2024-06-01",
+			expectedStatus: http.StatusOK,
+			expectedFrom:   may,
+			expectedTo:     june,
+			expectedPeriod: server.UsageReportPeriodMonth,
+		},
+		{
+			name:           "RFC 3339 times and period",
+			requestPath:    "/api/reports/usage?from=2024-05-01T02:00:00%2B02:00&to=2024-06-01T00:00:00Z&period=week",
+			expectedStatus: http.StatusOK,
+			expectedFrom:   may,
+			expectedTo:     june,
+			expectedPeriod: server.UsageReportPeriodWeek,
+		},
+		{
+			name:           "invalid period",
+			requestPath:    "/api/reports/usage?period=year",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "invalid format",
+			requestPath:    "/api/reports/usage?format=xml",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "invalid start",
+			requestPath:    "/api/reports/usage?from=yesterday",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var requested []any
+			handler := initReportsTestData(report, &requested)
+
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.requestPath, nil)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/reports/usage", handler.GetUsageReport).Methods("GET")
+			router.ServeHTTP(recorder, req)
+
+			res := recorder.Result()
+			defer res.Body.Close()
+			require.Equal(t, tc.expectedStatus, res.StatusCode)
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			require.Len(t, requested, 3)
+			assert.True(t, tc.expectedFrom.Equal(requested[0].(time.Time)))
+			assert.True(t, tc.expectedTo.Equal(requested[1].(time.Time)))
+			assert.Equal(t, tc.expectedPeriod, requested[2])
+
+			got := &api.UsageReport{}
+			err := json.NewDecoder(res.Body).Decode(got)
+			require.NoError(t, err)
+			assert.Equal(t, api.UsageReportPeriodMonth, got.Period)
+			require.Len(t, got.Periods, 1)
+			assert.Equal(t, 4, got.Periods[0].ActivePeers)
+			assert.Equal(t, 2, got.Periods[0].NewPeers)
+			assert.Equal(t, 1, got.Periods[0].PolicyChanges)
+			require.NotNil(t, got.Periods[0].RoutingPeers)
+			assert.Equal(t, []api.RoutingPeerUsage{{PeerId: "router", PeerName: "office", RxBytes: 2048, TxBytes: 1024}}, *got.Periods[0].RoutingPeers)
+		})
+	}
+}
+
+func TestGetUsageReportCSV(t *testing.T) {
+	may := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	june := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	var requested []any
+	handler := initReportsTestData(&server.UsageReport{
+		From:   may,
+		To:     june,
+		Period: server.UsageReportPeriodMonth,
+		Periods: []*server.UsagePeriod{
+			{
+				Start:         may,
+				End:           june,
+				ActivePeers:   4,
+				NewPeers:      2,
+				PolicyChanges: 1,
+				RoutingPeers:  []server.RoutingPeerUsage{{PeerID: "router", PeerName: "office", RxBytes: 2048, TxBytes: 1024}},
+			},
+		},
+	}, &requested)
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/reports/usage?format=csv", nil)
+	handler.GetUsageReport(recorder, req)
+
+	res := recorder.Result()
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/csv; charset=utf-8", res.Header.Get("Content-Type"))
+
+	records, err := csv.NewReader(res.Body).ReadAll()
+	require.NoError(t, err)
+	start, end := "2024-05-01T00:00:00Z", "2024-06-01T00:00:00Z"
+	assert.Equal(t, [][]string{
+		{"period_start", "period_end", "metric", "peer_id", "peer_name", "value"},
+		{start, end, "active_peers", "", "", "4"},
+		{start, end, "new_peers", "", "", "2"},
+		{start, end, "policy_changes", "", "", "1"},
+		{start, end, "rx_bytes", "router", "office", "2048"},
+		{start, end, "tx_bytes", "router", "office", "1024"},
+	}, records)
+}
+
+func TestGetAPIUsage(t *testing.T) {
+	resetsAt := time.Date(2024, 5, 1, 10, 1, 0, 0, time.UTC)
+	handler := &ReportsHandler{
+		accountManager: &mock_server.MockAccountManager{
+			GetAccountIDFromTokenFunc: func(claims jwtclaims.AuthorizationClaims) (string, string, error) {
+				return claims.AccountId, claims.UserId, nil
+			},
+			GetAPIUsageFunc: func(accountID, userID string) (*server.APIUsage, error) {
+				return &server.APIUsage{
+					Quota:            server.APIQuota{RequestsPerMinute: 10},
+					MinuteRequests:   4,
+					DayRequests:      20,
+					MinuteResetsAt:   resetsAt,
+					DayResetsAt:      resetsAt.Truncate(24 * time.Hour).Add(24 * time.Hour),
+					TotalRequests:    20,
+					RejectedRequests: 2,
+				}, nil
+			},
+		},
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+				return jwtclaims.AuthorizationClaims{UserId: "test_user", AccountId: "test_account"}
+			}),
+		),
+	}
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/usage", nil)
+	router := mux.NewRouter()
+	router.HandleFunc("/api/usage", handler.GetAPIUsage).Methods("GET")
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var got api.APIUsage
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	assert.Equal(t, api.APIQuota{RequestsPerMinute: 10}, got.Quota)
+	assert.Equal(t, 4, got.MinuteRequests)
+	assert.Equal(t, 20, got.DayRequests)
+	assert.True(t, resetsAt.Equal(got.MinuteResetsAt))
+	assert.Equal(t, int64(2), got.RejectedRequests)
+}
+
+func TestGetPackagesReport(t *testing.T) {
+	handler := &ReportsHandler{
+		accountManager: &mock_server.MockAccountManager{
+			GetAccountIDFromTokenFunc: func(claims jwtclaims.AuthorizationClaims) (string, string, error) {
+				return claims.AccountId, claims.UserId, nil
+			},
+			GetPackagesReportFunc: func(accountID, userID string) ([]*server.PackageVersionReport, error) {
+				return []*server.PackageVersionReport{
+					{Name: "dropbear", Version: "2022.82-6", PeerIDs: []string{"router"}},
+					{Name: "libopenssl3", Version: "3.0.13-1", PeerIDs: []string{"gateway", "router"}, MinVersion: "3.0.14-1"},
+					{Name: "libopenssl3", Version: "3.0.14-1", PeerIDs: []string{"laptop"}, MinVersion: "3.0.14-1"},
+				}, nil
+			},
+		},
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+				return jwtclaims.AuthorizationClaims{UserId: "test_user", AccountId: "test_account"}
+			}),
+		),
+	}
+
+	tt := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expected       []string
+	}{
+		{name: "all", expectedStatus: http.StatusOK, expected: []string{"dropbear 2022.82-6", "libopenssl3 3.0.13-1", "libopenssl3 3.0.14-1"}},
+		{name: "name", query: "?name=libopenssl3", expectedStatus: http.StatusOK, expected: []string{"libopenssl3 3.0.13-1", "libopenssl3 3.0.14-1"}},
+		{name: "vulnerable", query: "?vulnerable=true", expectedStatus: http.StatusOK, expected: []string{"libopenssl3 3.0.13-1"}},
+		{name: "invalid vulnerable", query: "?vulnerable=maybe", expectedStatus: http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.GetPackagesReport(recorder, httptest.NewRequest(http.MethodGet, "/api/reports/packages"+tc.query, nil))
+			require.Equal(t, tc.expectedStatus, recorder.Code, recorder.Body.String())
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var got []api.PackageVersionReport
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+			versions := make([]string, 0, len(got))
+			for _, report := range got {
+				versions = append(versions, report.Name+" "+report.Version)
+				assert.Equal(t, len(report.Peers), report.PeersCount)
+			}
+			assert.Equal(t, tc.expected, versions)
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/http/util"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+	"github.com/netbirdio/netbird/route"
+)
+
+// RouteGroupsHandler is the handler of the route groups, the highly available routes of the account
+type RouteGroupsHandler struct {
+	accountManager  server.AccountManager
+	claimsExtractor *jwtclaims.ClaimsExtractor
+}
+
+// NewRouteGroupsHandler returns a new instance of RouteGroupsHandler handler
+func NewRouteGroupsHandler(accountManager server.AccountManager, authCfg AuthCfg) *RouteGroupsHandler {
+	return &RouteGroupsHandler{
+		accountManager: accountManager,
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithAudience(authCfg.Audience),
+			jwtclaims.WithUserIDClaim(authCfg.UserIDClaim),
+		),
+	}
+}
+
+// GetAllRouteGroups returns the list of route groups for the account along with the health of their members
+func (h *RouteGroupsHandler) GetAllRouteGroups(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	groups, err := h.accountManager.ListRouteGroups(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	statuses, err := h.accountManager.GetRouteGroupMembersStatus(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	apiGroups := make([]*api.RouteGroup, 0)
+	for _, group := range groups {
+		apiGroups = append(apiGroups, toRouteGroupResponse(group, statuses[group.ID]))
+	}
+
+	util.WriteJSONObject(w, apiGroups)
+}
+
+// CreateRouteGroup handles route group creation request
+func (h *RouteGroupsHandler) CreateRouteGroup(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	var req api.PostApiRouteGroupsJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteJSONParseError(err, w)
+		return
+	}
+
+	group, err := toServerRouteGroup("", req)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	group, err = h.accountManager.CreateRouteGroup(account.Id, user.Id, group)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	h.writeRouteGroup(w, account.Id, user.Id, group)
+}
+
+// UpdateRouteGroup handles update to a route group identified by a given ID
+func (h *RouteGroupsHandler) UpdateRouteGroup(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	routeGroupID := mux.Vars(r)["routeGroupId"]
+	if len(routeGroupID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid route group ID"), w)
+		return
+	}
+
+	var req api.PutApiRouteGroupsRouteGroupIdJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteJSONParseError(err, w)
+		return
+	}
+
+	group, err := toServerRouteGroup(routeGroupID, req)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	err = h.accountManager.SaveRouteGroup(account.Id, user.Id, group)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	// the saved route group has the default priorities and weights of its members
+	group, err = h.accountManager.GetRouteGroup(account.Id, user.Id, routeGroupID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	h.writeRouteGroup(w, account.Id, user.Id, group)
+}
+
+// DeleteRouteGroup handles route group deletion request
+func (h *RouteGroupsHandler) DeleteRouteGroup(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	routeGroupID := mux.Vars(r)["routeGroupId"]
+	if len(routeGroupID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid route group ID"), w)
+		return
+	}
+
+	err = h.accountManager.DeleteRouteGroup(account.Id, user.Id, routeGroupID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, emptyObject{})
+}
+
+// GetRouteGroup handles a route group Get request identified by ID
+func (h *RouteGroupsHandler) GetRouteGroup(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	routeGroupID := mux.Vars(r)["routeGroupId"]
+	if len(routeGroupID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid route group ID"), w)
+		return
+	}
+
+	group, err := h.accountManager.GetRouteGroup(account.Id, user.Id, routeGroupID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	h.writeRouteGroup(w, account.Id, user.Id, group)
+}
+
+// writeRouteGroup writes the route group along with the health of its members
+func (h *RouteGroupsHandler) writeRouteGroup(w http.ResponseWriter, accountID, userID string, group *server.RouteGroup) {
+	statuses, err := h.accountManager.GetRouteGroupMembersStatus(accountID, userID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toRouteGroupResponse(group, statuses[group.ID]))
+}
+
+func toServerRouteGroup(routeGroupID string, req api.RouteGroupRequest) (*server.RouteGroup, error) {
+	_, network, err := route.ParseNetwork(req.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]server.RouteGroupMember, 0, len(req.Members))
+	for _, member := range req.Members {
+		serverMember := server.RouteGroupMember{PeerID: member.PeerId}
+		if member.Priority != nil {
+			serverMember.Priority = *member.Priority
+		}
+		if member.Weight != nil {
+			serverMember.Weight = *member.Weight
+		}
+		members = append(members, serverMember)
+	}
+
+	var thresholds server.RouteGroupLoadThresholds
+	if req.LoadThresholds != nil {
+		if req.LoadThresholds.ThroughputMbps < 0 {
+			return nil, status.FieldErrorf(status.InvalidArgument, "load_thresholds.throughput_mbps",
+				"the throughput load threshold shouldn't be negative")
+		}
+		thresholds = server.RouteGroupLoadThresholds{
+			CPUPercent:     req.LoadThresholds.CpuPercent,
+			MemoryPercent:  req.LoadThresholds.MemoryPercent,
+			ThroughputMbps: uint64(req.LoadThresholds.ThroughputMbps),
+		}
+	}
+
+	return &server.RouteGroup{
+		ID:             routeGroupID,
+		Name:           req.Name,
+		Description:    req.Description,
+		Network:        network,
+		FailoverPolicy: string(req.FailoverPolicy),
+		Members:        members,
+		Groups:         req.Groups,
+		Masquerade:     req.Masquerade,
+		Enabled:        req.Enabled,
+		LoadThresholds: thresholds,
+	}, nil
+}
+
+func toRouteGroupResponse(group *server.RouteGroup, statuses []server.RouteGroupMemberStatus) *api.RouteGroup {
+	members := make([]api.RouteGroupMemberRequest, 0, len(group.Members))
+	for _, member := range group.Members {
+		priority, weight := member.Priority, member.Weight
+		members = append(members, api.RouteGroupMemberRequest{
+			PeerId:   member.PeerID,
+			Priority: &priority,
+			Weight:   &weight,
+		})
+	}
+
+	membersStatus := make([]api.RouteGroupMemberStatus, 0, len(statuses))
+	for _, memberStatus := range statuses {
+		membersStatus = append(membersStatus, api.RouteGroupMemberStatus{
+			PeerId:     memberStatus.PeerID,
+			RouteId:    string(memberStatus.RouteID),
+			Connected:  memberStatus.Connected,
+			LastSeen:   memberStatus.LastSeen,
+			Active:     memberStatus.Active,
+			Overloaded: memberStatus.Overloaded,
+			Load:       toPeerLoadResponse(memberStatus.Load),
+		})
+	}
+
+	return &api.RouteGroup{
+		Id:             group.ID,
+		Name:           group.Name,
+		Description:    group.Description,
+		Network:        group.Network.String(),
+		FailoverPolicy: api.RouteGroupFailoverPolicy(group.FailoverPolicy),
+		Members:        members,
+		MembersStatus:  membersStatus,
+		Groups:         group.Groups,
+		Masquerade:     group.Masquerade,
+		Enabled:        group.Enabled,
+		LoadThresholds: &api.RouteGroupLoadThresholds{
+			CpuPercent:     group.LoadThresholds.CPUPercent,
+			MemoryPercent:  group.LoadThresholds.MemoryPercent,
+			ThroughputMbps: int(group.LoadThresholds.ThroughputMbps),
+		},
+	}
+}
+
+func toPeerLoadResponse(load *nbpeer.Load) *api.PeerLoad {
+	if load == nil {
+		return nil
+	}
+	return &api.PeerLoad{
+		CpuPercent:     load.CPUPercent,
+		MemoryPercent:  load.MemoryPercent,
+		ThroughputMbps: float64(load.ThroughputBps) / 1e6,
+		ReportedAt:     load.ReportedAt,
+	}
+}
@@ -0,0 +1,242 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/mock_server"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	existingRouteGroupID = "existingRouteGroupID"
+	notFoundRouteGroupID = "notFoundRouteGroupID"
+)
+
+var routeGroupLastSeen = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+var baseExistingRouteGroup = &server.RouteGroup{
+	ID:             existingRouteGroupID,
+	Name:           "office",
+	Network:        netip.MustParsePrefix("10.64.0.0/24"),
+	FailoverPolicy: server.RouteGroupFailoverPriority,
+	Members:        []server.RouteGroupMember{{PeerID: "gw1", Priority: 1}, {PeerID: "gw2", Priority: 2}},
+	Groups:         []string{"group1"},
+	Enabled:        true,
+}
+
+func initRouteGroupsTestData() *RouteGroupsHandler {
+	return &RouteGroupsHandler{
+		accountManager: &mock_server.MockAccountManager{
+			GetRouteGroupFunc: func(_, _, routeGroupID string) (*server.RouteGroup, error) {
+				if routeGroupID == existingRouteGroupID {
+					return baseExistingRouteGroup.Copy(), nil
+				}
+				return nil, status.Errorf(status.NotFound, "route group with ID %s not found", routeGroupID)
+			},
+			CreateRouteGroupFunc: func(_, _ string, routeGroup *server.RouteGroup) (*server.RouteGroup, error) {
+				if len(routeGroup.Members) == 0 {
+					return nil, status.Errorf(status.InvalidArgument, "route group should have at least one member")
+				}
+				created := routeGroup.Copy()
+				created.ID = existingRouteGroupID
+				return created, nil
+			},
+			SaveRouteGroupFunc: func(_, _ string, routeGroup *server.RouteGroup) error {
+				if routeGroup.ID != existingRouteGroupID {
+					return status.Errorf(status.NotFound, "route group with ID %s was not found", routeGroup.ID)
+				}
+				return nil
+			},
+			DeleteRouteGroupFunc: func(_, _, _ string) error {
+				return nil
+			},
+			ListRouteGroupsFunc: func(_, _ string) ([]*server.RouteGroup, error) {
+				return []*server.RouteGroup{baseExistingRouteGroup.Copy()}, nil
+			},
+			GetRouteGroupMembersStatusFunc: func(_, _ string) (map[string][]server.RouteGroupMemberStatus, error) {
+				return map[string][]server.RouteGroupMemberStatus{
+					existingRouteGroupID: {
+						{PeerID: "gw1", RouteID: "route1", LastSeen: routeGroupLastSeen, Overloaded: true, Load: &nbpeer.Load{
+							CPUPercent: 97.5, MemoryPercent: 40, ThroughputBps: 250_000_000, ReportedAt: routeGroupLastSeen,
+						}},
+						{PeerID: "gw2", RouteID: "route2", Connected: true, LastSeen: routeGroupLastSeen, Active: true},
+					},
+				}, nil
+			},
+			GetAccountFromTokenFunc: func(_ jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error) {
+				return testingNSAccount, testingAccount.Users["test_user"], nil
+			},
+		},
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+				return jwtclaims.AuthorizationClaims{
+					UserId:    "test_user",
+					Domain:    "hotmail.com",
+					AccountId: testNSGroupAccountID,
+				}
+			}),
+		),
+	}
+}
+
+func TestRouteGroupsHandlers(t *testing.T) {
+	zero, one, two := 0, 1, 2
+	existingMembersStatus := []api.RouteGroupMemberStatus{
+		{PeerId: "gw1", RouteId: "route1", LastSeen: routeGroupLastSeen, Overloaded: true, Load: &api.PeerLoad{
+			CpuPercent: 97.5, MemoryPercent: 40, ThroughputMbps: 250, ReportedAt: routeGroupLastSeen,
+		}},
+		{PeerId: "gw2", RouteId: "route2", Connected: true, LastSeen: routeGroupLastSeen, Active: true},
+	}
+
+	tt := []struct {
+		name               string
+		expectedStatus     int
+		expectedBody       bool
+		expectedRouteGroup *api.RouteGroup
+		requestType        string
+		requestPath        string
+		requestBody        io.Reader
+	}{
+		{
+			name:           "Get Existing Route Group",
+			requestType:    http.MethodGet,
+			requestPath:    "/api/route-groups/" + existingRouteGroupID,
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedRouteGroup: &api.RouteGroup{
+				Id:             existingRouteGroupID,
+				Name:           "office",
+				Network:        "10.64.0.0/24",
+				FailoverPolicy: api.RouteGroupFailoverPolicyPriority,
+				Members: []api.RouteGroupMemberRequest{
+					{PeerId: "gw1", Priority: &one, Weight: &zero},
+					{PeerId: "gw2", Priority: &two, Weight: &zero},
+				},
+				MembersStatus:  existingMembersStatus,
+				Groups:         []string{"group1"},
+				Enabled:        true,
+				LoadThresholds: &api.RouteGroupLoadThresholds{},
+			},
+		},
+		{
+			name:           "Get Not Existing Route Group",
+			requestType:    http.MethodGet,
+			requestPath:    "/api/route-groups/" + notFoundRouteGroupID,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:        "POST OK",
+			requestType: http.MethodPost,
+			requestPath: "/api/route-groups",
+			requestBody: bytes.NewBufferString(`{"name":"office","description":"","network":"10.64.0.5/24","failover_policy":"priority",` +
+				`"members":[{"peer_id":"gw1","priority":1},{"peer_id":"gw2","priority":2}],"groups":["group1"],"masquerade":false,"enabled":true,` +
+				`"load_thresholds":{"cpu_percent":80,"memory_percent":0,"throughput_mbps":500}}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedRouteGroup: &api.RouteGroup{
+				Id:             existingRouteGroupID,
+				Name:           "office",
+				Network:        "10.64.0.0/24",
+				FailoverPolicy: api.RouteGroupFailoverPolicyPriority,
+				Members: []api.RouteGroupMemberRequest{
+					{PeerId: "gw1", Priority: &one, Weight: &zero},
+					{PeerId: "gw2", Priority: &two, Weight: &zero},
+				},
+				MembersStatus:  existingMembersStatus,
+				Groups:         []string{"group1"},
+				Enabled:        true,
+				LoadThresholds: &api.RouteGroupLoadThresholds{CpuPercent: 80, ThroughputMbps: 500},
+			},
+		},
+		{
+			name:        "POST Negative Throughput Threshold",
+			requestType: http.MethodPost,
+			requestPath: "/api/route-groups",
+			requestBody: bytes.NewBufferString(`{"name":"office","network":"10.64.0.0/24","members":[{"peer_id":"gw1"}],"groups":["group1"],` +
+				`"load_thresholds":{"cpu_percent":0,"memory_percent":0,"throughput_mbps":-1}}`),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "POST Invalid Network",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/route-groups",
+			requestBody:    bytes.NewBufferString(`{"name":"office","network":"10.64.0.0/33","members":[{"peer_id":"gw1"}],"groups":["group1"]}`),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "POST Without Members",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/route-groups",
+			requestBody:    bytes.NewBufferString(`{"name":"office","network":"10.64.0.0/24","members":[],"groups":["group1"]}`),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "PUT Not Existing Route Group",
+			requestType:    http.MethodPut,
+			requestPath:    "/api/route-groups/" + notFoundRouteGroupID,
+			requestBody:    bytes.NewBufferString(`{"name":"office","network":"10.64.0.0/24","members":[{"peer_id":"gw1"}],"groups":["group1"]}`),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "DELETE Route Group",
+			requestType:    http.MethodDelete,
+			requestPath:    "/api/route-groups/" + existingRouteGroupID,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	p := initRouteGroupsTestData()
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.requestType, tc.requestPath, tc.requestBody)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/route-groups/{routeGroupId}", p.GetRouteGroup).Methods("GET")
+			router.HandleFunc("/api/route-groups", p.CreateRouteGroup).Methods("POST")
+			router.HandleFunc("/api/route-groups/{routeGroupId}", p.DeleteRouteGroup).Methods("DELETE")
+			router.HandleFunc("/api/route-groups/{routeGroupId}", p.UpdateRouteGroup).Methods("PUT")
+			router.ServeHTTP(recorder, req)
+
+			res := recorder.Result()
+			defer res.Body.Close()
+
+			content, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("I don't know what I expected; %v", err)
+			}
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v, content: %s",
+					status, tc.expectedStatus, string(content))
+				return
+			}
+
+			if !tc.expectedBody {
+				return
+			}
+
+			got := &api.RouteGroup{}
+			if err = json.Unmarshal(content, &got); err != nil {
+				t.Fatalf("Sent content is not in correct json format; %v", err)
+			}
+
+			assert.Equal(t, tc.expectedRouteGroup, got)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"encoding/json"
 	"net/http"
+	"net/netip"
 	"unicode/utf8"
 
 	"github.com/gorilla/mux"
@@ -11,6 +12,7 @@ import (
 	"github.com/netbirdio/netbird/management/server/http/api"
 	"github.com/netbirdio/netbird/management/server/http/util"
 	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
 	"github.com/netbirdio/netbird/management/server/status"
 	"github.com/netbirdio/netbird/route"
 )
@@ -46,9 +48,16 @@ func (h *RoutesHandler) GetAllRoutes(w http.ResponseWriter, r *http.Request) {
 		util.WriteError(err, w)
 		return
 	}
+
+	loads, err := h.accountManager.GetRoutingPeersLoad(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
 	apiRoutes := make([]*api.Route, 0)
 	for _, r := range routes {
-		apiRoutes = append(apiRoutes, toRouteResponse(r))
+		apiRoutes = append(apiRoutes, withPeerLoad(toRouteResponse(r), loads))
 	}
 
 	util.WriteJSONObject(w, apiRoutes)
@@ -66,18 +75,30 @@ func (h *RoutesHandler) CreateRoute(w http.ResponseWriter, r *http.Request) {
 	var req api.PostApiRoutesJSONRequestBody
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		util.WriteJSONParseError(err, w)
 		return
 	}
 
-	_, newPrefix, err := route.ParseNetwork(req.Network)
-	if err != nil {
+	if err = validateRouteDestination(req.Network, req.Domains); err != nil {
 		util.WriteError(err, w)
 		return
 	}
 
+	var network string
+	var domains []string
+	if req.Domains != nil {
+		domains = *req.Domains
+	} else {
+		_, newPrefix, err := route.ParseNetwork(*req.Network)
+		if err != nil {
+			util.WriteError(err, w)
+			return
+		}
+		network = newPrefix.String()
+	}
+
 	if utf8.RuneCountInString(req.NetworkId) > route.MaxNetIDChar || req.NetworkId == "" {
-		util.WriteError(status.Errorf(status.InvalidArgument, "identifier should be between 1 and %d",
+		util.WriteError(status.FieldErrorf(status.InvalidArgument, "network_id", "identifier should be between 1 and %d",
 			route.MaxNetIDChar), w)
 		return
 	}
@@ -105,9 +126,14 @@ func (h *RoutesHandler) CreateRoute(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	weight := 0
+	if req.Weight != nil {
+		weight = *req.Weight
+	}
+
 	newRoute, err := h.accountManager.CreateRoute(
-		account.Id, newPrefix.String(), peerId, peerGroupIds,
-		req.Description, route.NetID(req.NetworkId), req.Masquerade, req.Metric, req.Groups, req.Enabled, user.Id,
+		account.Id, network, domains, req.KeepRoute != nil && *req.KeepRoute, peerId, peerGroupIds,
+		req.Description, route.NetID(req.NetworkId), req.Masquerade, req.Metric, weight, req.Groups, req.Enabled, user.Id,
 	)
 	if err != nil {
 		util.WriteError(err, w)
@@ -144,17 +170,29 @@ func (h *RoutesHandler) UpdateRoute(w http.ResponseWriter, r *http.Request) {
 	var req api.PutApiRoutesRouteIdJSONRequestBody
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		util.WriteJSONParseError(err, w)
 		return
 	}
 
-	prefixType, newPrefix, err := route.ParseNetwork(req.Network)
-	if err != nil {
-		util.WriteError(status.Errorf(status.InvalidArgument, "couldn't parse update prefix %s for route ID %s",
-			req.Network, routeID), w)
+	if err = validateRouteDestination(req.Network, req.Domains); err != nil {
+		util.WriteError(err, w)
 		return
 	}
 
+	prefixType := route.DomainNetwork
+	var newPrefix netip.Prefix
+	var domains []string
+	if req.Domains != nil {
+		domains = *req.Domains
+	} else {
+		prefixType, newPrefix, err = route.ParseNetwork(*req.Network)
+		if err != nil {
+			util.WriteError(status.Errorf(status.InvalidArgument, "couldn't parse update prefix %s for route ID %s",
+				*req.Network, routeID), w)
+			return
+		}
+	}
+
 	if utf8.RuneCountInString(req.NetworkId) > route.MaxNetIDChar || req.NetworkId == "" {
 		util.WriteError(status.Errorf(status.InvalidArgument,
 			"identifier should be between 1 and %d", route.MaxNetIDChar), w)
@@ -194,6 +232,12 @@ func (h *RoutesHandler) UpdateRoute(w http.ResponseWriter, r *http.Request) {
 		Description: req.Description,
 		Enabled:     req.Enabled,
 		Groups:      req.Groups,
+		Domains:     domains,
+		KeepRoute:   req.KeepRoute != nil && *req.KeepRoute,
+	}
+
+	if req.Weight != nil {
+		newRoute.Weight = *req.Weight
 	}
 
 	if req.Peer != nil {
@@ -260,25 +304,92 @@ func (h *RoutesHandler) GetRoute(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	util.WriteJSONObject(w, toRouteResponse(foundRoute))
+	loads, err := h.accountManager.GetRoutingPeersLoad(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, withPeerLoad(toRouteResponse(foundRoute), loads))
+}
+
+// ApproveRoute handles route approval request
+func (h *RoutesHandler) ApproveRoute(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	routeID := mux.Vars(r)["routeId"]
+	if len(routeID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid route ID"), w)
+		return
+	}
+
+	approvedRoute, err := h.accountManager.ApproveRoute(account.Id, route.ID(routeID), user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toRouteResponse(approvedRoute))
 }
 
 func toRouteResponse(serverRoute *route.Route) *api.Route {
 	route := &api.Route{
-		Id:          string(serverRoute.ID),
-		Description: serverRoute.Description,
-		NetworkId:   string(serverRoute.NetID),
-		Enabled:     serverRoute.Enabled,
-		Peer:        &serverRoute.Peer,
-		Network:     serverRoute.Network.String(),
-		NetworkType: serverRoute.NetworkType.String(),
-		Masquerade:  serverRoute.Masquerade,
-		Metric:      serverRoute.Metric,
-		Groups:      serverRoute.Groups,
+		Id:              string(serverRoute.ID),
+		Description:     serverRoute.Description,
+		NetworkId:       string(serverRoute.NetID),
+		Enabled:         serverRoute.Enabled,
+		Peer:            &serverRoute.Peer,
+		NetworkType:     serverRoute.NetworkType.String(),
+		Masquerade:      serverRoute.Masquerade,
+		Metric:          serverRoute.Metric,
+		Groups:          serverRoute.Groups,
+		KeepRoute:       &serverRoute.KeepRoute,
+		Weight:          &serverRoute.Weight,
+		PendingApproval: serverRoute.PendingApproval,
+	}
+
+	if serverRoute.IsDynamic() {
+		route.Domains = &serverRoute.Domains
+	} else {
+		network := serverRoute.Network.String()
+		route.Network = &network
 	}
 
 	if len(serverRoute.PeerGroups) > 0 {
 		route.PeerGroups = &serverRoute.PeerGroups
 	}
+
+	if serverRoute.RouteGroupID != "" {
+		route.RouteGroupId = &serverRoute.RouteGroupID
+	}
+	return route
+}
+
+// withPeerLoad sets the latest load of the routing peer of the route
+func withPeerLoad(route *api.Route, loads map[string]nbpeer.Load) *api.Route {
+	if route.Peer == nil {
+		return route
+	}
+	if load, ok := loads[*route.Peer]; ok {
+		route.PeerLoad = toPeerLoadResponse(&load)
+	}
 	return route
 }
+
+// validateRouteDestination checks that exactly one of the network or domains route destinations is provided
+func validateRouteDestination(network *string, domains *[]string) error {
+	if network != nil && domains != nil {
+		return status.Errorf(status.InvalidArgument, "only one of network or domains should be provided")
+	}
+
+	if network == nil && domains == nil {
+		return status.Errorf(status.InvalidArgument, "either network or domains should be provided")
+	}
+
+	return nil
+}
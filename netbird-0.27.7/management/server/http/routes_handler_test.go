@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"net/netip"
 	"testing"
+	"time"
 
 	"github.com/netbirdio/netbird/management/server/http/api"
 	nbpeer "github.com/netbirdio/netbird/management/server/peer"
@@ -38,6 +39,10 @@ const (
 )
 
 var emptyString = ""
+var testNetwork = "192.168.0.0/16"
+var testKeepRoute = false
+var testDomainKeepRoute = true
+var testWeight = 0
 var existingPeerID = "peer-id"
 var nonLinuxExistingPeerID = "darwin-peer-id"
 
@@ -93,7 +98,7 @@ func initRoutesTestData() *RoutesHandler {
 				}
 				return nil, status.Errorf(status.NotFound, "route with ID %s not found", routeID)
 			},
-			CreateRouteFunc: func(accountID, network, peerID string, peerGroups []string, description string, netID route.NetID, masquerade bool, metric int, groups []string, enabled bool, _ string) (*route.Route, error) {
+			CreateRouteFunc: func(accountID, network string, domains []string, keepRoute bool, peerID string, peerGroups []string, description string, netID route.NetID, masquerade bool, metric, weight int, groups []string, enabled bool, _ string) (*route.Route, error) {
 				if peerID == notFoundPeerID {
 					return nil, status.Errorf(status.InvalidArgument, "peer with ID %s not found", peerID)
 				}
@@ -101,6 +106,9 @@ func initRoutesTestData() *RoutesHandler {
 					return nil, status.Errorf(status.InvalidArgument, "peer groups with ID %s not found", peerGroups[0])
 				}
 				networkType, p, _ := route.ParseNetwork(network)
+				if len(domains) > 0 {
+					networkType = route.DomainNetwork
+				}
 				return &route.Route{
 					ID:          existingRouteID,
 					NetID:       netID,
@@ -108,8 +116,11 @@ func initRoutesTestData() *RoutesHandler {
 					PeerGroups:  peerGroups,
 					Network:     p,
 					NetworkType: networkType,
+					Domains:     domains,
+					KeepRoute:   keepRoute,
 					Description: description,
 					Masquerade:  masquerade,
+					Weight:      weight,
 					Enabled:     enabled,
 					Groups:      groups,
 				}, nil
@@ -120,12 +131,23 @@ func initRoutesTestData() *RoutesHandler {
 				}
 				return nil
 			},
+			ApproveRouteFunc: func(_ string, routeID route.ID, _ string) (*route.Route, error) {
+				if routeID != existingRouteID {
+					return nil, status.Errorf(status.NotFound, "route with ID %s doesn't exist", routeID)
+				}
+				approved := baseExistingRoute.Copy()
+				approved.Enabled = true
+				return approved, nil
+			},
 			DeleteRouteFunc: func(_ string, routeID route.ID, _ string) error {
 				if routeID != existingRouteID {
 					return status.Errorf(status.NotFound, "Peer with ID %s not found", routeID)
 				}
 				return nil
 			},
+			GetRoutingPeersLoadFunc: func(_, _ string) (map[string]nbpeer.Load, error) {
+				return map[string]nbpeer.Load{}, nil
+			},
 			GetAccountFromTokenFunc: func(_ jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error) {
 				return testingAccount, testingAccount.Users["test_user"], nil
 			},
@@ -145,6 +167,8 @@ func initRoutesTestData() *RoutesHandler {
 func TestRoutesHandlers(t *testing.T) {
 	baseExistingRouteWithPeerGroups := baseExistingRoute.Copy()
 	baseExistingRouteWithPeerGroups.PeerGroups = []string{existingGroupID}
+	approvedRoute := baseExistingRoute.Copy()
+	approvedRoute.Enabled = true
 
 	tt := []struct {
 		name           string
@@ -177,6 +201,20 @@ func TestRoutesHandlers(t *testing.T) {
 			expectedBody:   true,
 			expectedRoute:  toRouteResponse(baseExistingRouteWithPeerGroups),
 		},
+		{
+			name:           "Approve Existing Route",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/routes/" + existingRouteID + "/approve",
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedRoute:  toRouteResponse(approvedRoute),
+		},
+		{
+			name:           "Approve Not Existing Route",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/routes/" + notFoundRouteID + "/approve",
+			expectedStatus: http.StatusNotFound,
+		},
 		{
 			name:           "Delete Existing Route",
 			requestType:    http.MethodDelete,
@@ -202,14 +240,54 @@ func TestRoutesHandlers(t *testing.T) {
 				Id:          existingRouteID,
 				Description: "Post",
 				NetworkId:   "awesomeNet",
-				Network:     "192.168.0.0/16",
+				Network:     &testNetwork,
 				Peer:        &existingPeerID,
 				NetworkType: route.IPv4NetworkString,
+				KeepRoute:   &testKeepRoute,
+				Weight:      &testWeight,
+				Masquerade:  false,
+				Enabled:     false,
+				Groups:      []string{existingGroupID},
+			},
+		},
+		{
+			name:        "POST Domains OK",
+			requestType: http.MethodPost,
+			requestPath: "/api/routes",
+			requestBody: bytes.NewBuffer(
+				[]byte(fmt.Sprintf("{\"Description\":\"Post\",\"domains\":[\"example.com\"],\"keep_route\":true,\"network_id\":\"awesomeNet\",\"Peer\":\"%s\",\"groups\":[\"%s\"]}", existingPeerID, existingGroupID))),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedRoute: &api.Route{
+				Id:          existingRouteID,
+				Description: "Post",
+				NetworkId:   "awesomeNet",
+				Domains:     &[]string{"example.com"},
+				Peer:        &existingPeerID,
+				NetworkType: route.DomainNetworkString,
+				KeepRoute:   &testDomainKeepRoute,
+				Weight:      &testWeight,
 				Masquerade:  false,
 				Enabled:     false,
 				Groups:      []string{existingGroupID},
 			},
 		},
+		{
+			name:           "POST Network And Domains",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/routes",
+			requestBody:    bytes.NewBufferString(fmt.Sprintf("{\"Description\":\"Post\",\"Network\":\"192.168.0.0/16\",\"domains\":[\"example.com\"],\"network_id\":\"awesomeNet\",\"Peer\":\"%s\",\"groups\":[\"%s\"]}", existingPeerID, existingGroupID)),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   false,
+		},
+		{
+			name:           "POST No Network Or Domains",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/routes",
+			requestBody:    bytes.NewBufferString(fmt.Sprintf("{\"Description\":\"Post\",\"network_id\":\"awesomeNet\",\"Peer\":\"%s\",\"groups\":[\"%s\"]}", existingPeerID, existingGroupID)),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   false,
+		},
 		{
 			name:           "POST Non Linux Peer",
 			requestType:    http.MethodPost,
@@ -271,9 +349,11 @@ func TestRoutesHandlers(t *testing.T) {
 				Id:          existingRouteID,
 				Description: "Post",
 				NetworkId:   "awesomeNet",
-				Network:     "192.168.0.0/16",
+				Network:     &testNetwork,
 				Peer:        &existingPeerID,
 				NetworkType: route.IPv4NetworkString,
+				KeepRoute:   &testKeepRoute,
+				Weight:      &testWeight,
 				Masquerade:  false,
 				Enabled:     false,
 				Groups:      []string{existingGroupID},
@@ -290,10 +370,12 @@ func TestRoutesHandlers(t *testing.T) {
 				Id:          existingRouteID,
 				Description: "Post",
 				NetworkId:   "awesomeNet",
-				Network:     "192.168.0.0/16",
+				Network:     &testNetwork,
 				Peer:        &emptyString,
 				PeerGroups:  &[]string{existingGroupID},
 				NetworkType: route.IPv4NetworkString,
+				KeepRoute:   &testKeepRoute,
+				Weight:      &testWeight,
 				Masquerade:  false,
 				Enabled:     false,
 				Groups:      []string{existingGroupID},
@@ -371,6 +453,7 @@ func TestRoutesHandlers(t *testing.T) {
 			router.HandleFunc("/api/routes/{routeId}", p.DeleteRoute).Methods("DELETE")
 			router.HandleFunc("/api/routes", p.CreateRoute).Methods("POST")
 			router.HandleFunc("/api/routes/{routeId}", p.UpdateRoute).Methods("PUT")
+			router.HandleFunc("/api/routes/{routeId}/approve", p.ApproveRoute).Methods("POST")
 			router.ServeHTTP(recorder, req)
 
 			res := recorder.Result()
@@ -399,3 +482,40 @@ func TestRoutesHandlers(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRoutePeerLoad(t *testing.T) {
+	p := initRoutesTestData()
+	load := nbpeer.Load{
+		CPUPercent:    42.5,
+		MemoryPercent: 60,
+		ThroughputBps: 12_500_000,
+		ReportedAt:    time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+	}
+	accountManager := p.accountManager.(*mock_server.MockAccountManager)
+	accountManager.GetRouteFunc = func(_ string, _ route.ID, _ string) (*route.Route, error) {
+		routed := baseExistingRoute.Copy()
+		routed.Peer = existingPeerID
+		return routed, nil
+	}
+	accountManager.GetRoutingPeersLoadFunc = func(_, _ string) (map[string]nbpeer.Load, error) {
+		return map[string]nbpeer.Load{existingPeerID: load}, nil
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/routes/{routeId}", p.GetRoute).Methods("GET")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/routes/"+existingRouteID, nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+
+	got := &api.Route{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), got); err != nil {
+		t.Fatalf("Sent content is not in correct json format; %v", err)
+	}
+	assert.Equal(t, got.PeerLoad, &api.PeerLoad{
+		CpuPercent:     42.5,
+		MemoryPercent:  60,
+		ThroughputMbps: 12.5,
+		ReportedAt:     load.ReportedAt,
+	})
+}
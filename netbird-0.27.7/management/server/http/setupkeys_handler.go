@@ -43,18 +43,18 @@ func (h *SetupKeysHandler) CreateSetupKey(w http.ResponseWriter, r *http.Request
 	req := &api.PostApiSetupKeysJSONRequestBody{}
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		util.WriteJSONParseError(err, w)
 		return
 	}
 
 	if req.Name == "" {
-		util.WriteError(status.Errorf(status.InvalidArgument, "setup key name shouldn't be empty"), w)
+		util.WriteError(status.FieldErrorf(status.InvalidArgument, "name", "setup key name shouldn't be empty"), w)
 		return
 	}
 
 	if !(server.SetupKeyType(req.Type) == server.SetupKeyReusable ||
 		server.SetupKeyType(req.Type) == server.SetupKeyOneOff) {
-		util.WriteError(status.Errorf(status.InvalidArgument, "unknown setup key type %s", req.Type), w)
+		util.WriteError(status.FieldErrorf(status.InvalidArgument, "type", "unknown setup key type %s", req.Type), w)
 		return
 	}
 
@@ -63,7 +63,7 @@ func (h *SetupKeysHandler) CreateSetupKey(w http.ResponseWriter, r *http.Request
 	day := time.Hour * 24
 	year := day * 365
 	if expiresIn < day || expiresIn > year {
-		util.WriteError(status.Errorf(status.InvalidArgument, "expiresIn should be between 1 day and 365 days"), w)
+		util.WriteError(status.FieldErrorf(status.InvalidArgument, "expires_in", "expiresIn should be between 1 day and 365 days"), w)
 		return
 	}
 
@@ -75,6 +75,11 @@ func (h *SetupKeysHandler) CreateSetupKey(w http.ResponseWriter, r *http.Request
 	if req.Ephemeral != nil {
 		ephemeral = *req.Ephemeral
 	}
+	if req.PeerLimit != nil && *req.PeerLimit < 0 {
+		util.WriteError(status.FieldErrorf(status.InvalidArgument, "peer_limit", "setup key peer limit can't be negative"), w)
+		return
+	}
+
 	setupKey, err := h.accountManager.CreateSetupKey(account.Id, req.Name, server.SetupKeyType(req.Type), expiresIn,
 		req.AutoGroups, req.UsageLimit, user.Id, ephemeral)
 	if err != nil {
@@ -82,6 +87,15 @@ func (h *SetupKeysHandler) CreateSetupKey(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if req.PeerLimit != nil && *req.PeerLimit > 0 {
+		setupKey.PeerLimit = *req.PeerLimit
+		setupKey, err = h.accountManager.SaveSetupKey(account.Id, setupKey, user.Id)
+		if err != nil {
+			util.WriteError(err, w)
+			return
+		}
+	}
+
 	writeSuccess(w, setupKey)
 }
 
@@ -129,17 +143,17 @@ func (h *SetupKeysHandler) UpdateSetupKey(w http.ResponseWriter, r *http.Request
 	req := &api.PutApiSetupKeysKeyIdJSONRequestBody{}
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		util.WriteJSONParseError(err, w)
 		return
 	}
 
 	if req.Name == "" {
-		util.WriteError(status.Errorf(status.InvalidArgument, "setup key name field is invalid: %s", req.Name), w)
+		util.WriteError(status.FieldErrorf(status.InvalidArgument, "name", "setup key name field is invalid: %s", req.Name), w)
 		return
 	}
 
 	if req.AutoGroups == nil {
-		util.WriteError(status.Errorf(status.InvalidArgument, "setup key AutoGroups field is invalid"), w)
+		util.WriteError(status.FieldErrorf(status.InvalidArgument, "auto_groups", "setup key AutoGroups field is invalid"), w)
 		return
 	}
 
@@ -149,6 +163,17 @@ func (h *SetupKeysHandler) UpdateSetupKey(w http.ResponseWriter, r *http.Request
 	newKey.Name = req.Name
 	newKey.Id = keyID
 
+	if req.PeerLimit != nil {
+		newKey.PeerLimit = *req.PeerLimit
+	} else {
+		currentKey, err := h.accountManager.GetSetupKey(account.Id, user.Id, keyID)
+		if err != nil {
+			util.WriteError(err, w)
+			return
+		}
+		newKey.PeerLimit = currentKey.PeerLimit
+	}
+
 	newKey, err = h.accountManager.SaveSetupKey(account.Id, newKey, user.Id)
 	if err != nil {
 		util.WriteError(err, w)
@@ -180,6 +205,63 @@ func (h *SetupKeysHandler) GetAllSetupKeys(w http.ResponseWriter, r *http.Reques
 	util.WriteJSONObject(w, apiSetupKeys)
 }
 
+// ReplaceSetupKey is a POST request that revokes a SetupKey and returns its successor
+func (h *SetupKeysHandler) ReplaceSetupKey(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	vars := mux.Vars(r)
+	keyID := vars["keyId"]
+	if len(keyID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid key ID"), w)
+		return
+	}
+
+	newKey, err := h.accountManager.ReplaceSetupKey(account.Id, user.Id, keyID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	writeSuccess(w, newKey)
+}
+
+// GetStaleSetupKeys is a GET request that returns the valid SetupKeys older than the max_age
+func (h *SetupKeysHandler) GetStaleSetupKeys(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	maxAge := server.DefaultSetupKeyMaxAge
+	if param := r.URL.Query().Get("max_age"); param != "" {
+		maxAge, err = time.ParseDuration(param)
+		if err != nil || maxAge <= 0 {
+			util.WriteError(status.FieldErrorf(status.InvalidArgument, "max_age", "invalid max_age duration %s", param), w)
+			return
+		}
+	}
+
+	setupKeys, err := h.accountManager.ListStaleSetupKeys(account.Id, user.Id, maxAge)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	apiSetupKeys := make([]*api.SetupKey, 0, len(setupKeys))
+	for _, key := range setupKeys {
+		apiSetupKeys = append(apiSetupKeys, toResponseBody(key))
+	}
+
+	util.WriteJSONObject(w, apiSetupKeys)
+}
+
 func writeSuccess(w http.ResponseWriter, key *server.SetupKey) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(200)
@@ -218,5 +300,6 @@ func toResponseBody(key *server.SetupKey) *api.SetupKey {
 		UpdatedAt:  key.UpdatedAt,
 		UsageLimit: key.UsageLimit,
 		Ephemeral:  key.Ephemeral,
+		PeerLimit:  key.PeerLimit,
 	}
 }
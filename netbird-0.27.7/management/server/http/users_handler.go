@@ -63,18 +63,18 @@ func (h *UsersHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	req := &api.PutApiUsersUserIdJSONRequestBody{}
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		util.WriteJSONParseError(err, w)
 		return
 	}
 
 	if req.AutoGroups == nil {
-		util.WriteErrorResponse("auto_groups field can't be absent", http.StatusBadRequest, w)
+		util.WriteError(status.FieldErrorf(status.BadRequest, "auto_groups", "auto_groups field can't be absent"), w)
 		return
 	}
 
 	userRole := server.StrRoleToUserRole(req.Role)
 	if userRole == server.UserRoleUnknown {
-		util.WriteError(status.Errorf(status.InvalidArgument, "invalid user role"), w)
+		util.WriteError(status.FieldErrorf(status.InvalidArgument, "role", "invalid user role"), w)
 		return
 	}
 
@@ -141,12 +141,12 @@ func (h *UsersHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	req := &api.PostApiUsersJSONRequestBody{}
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		util.WriteJSONParseError(err, w)
 		return
 	}
 
 	if server.StrRoleToUserRole(req.Role) == server.UserRoleUnknown {
-		util.WriteError(status.Errorf(status.InvalidArgument, "unknown user role %s", req.Role), w)
+		util.WriteError(status.FieldErrorf(status.InvalidArgument, "role", "unknown user role %s", req.Role), w)
 		return
 	}
 
@@ -211,7 +211,7 @@ func (h *UsersHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 		includeServiceUser, err := strconv.ParseBool(serviceUser)
 		log.Debugf("Should include service user: %v", includeServiceUser)
 		if err != nil {
-			util.WriteError(status.Errorf(status.InvalidArgument, "invalid service_user query parameter"), w)
+			util.WriteError(status.FieldErrorf(status.InvalidArgument, "service_user", "invalid service_user query parameter"), w)
 			return
 		}
 		if includeServiceUser == r.IsServiceUser {
@@ -253,6 +253,63 @@ func (h *UsersHandler) InviteUser(w http.ResponseWriter, r *http.Request) {
 	util.WriteJSONObject(w, emptyObject{})
 }
 
+// GetUserNotifications is a GET request to get the kinds of notifications the user receives by email
+func (h *UsersHandler) GetUserNotifications(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	vars := mux.Vars(r)
+	targetUserID := vars["userId"]
+	if len(targetUserID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid user ID"), w)
+		return
+	}
+
+	kinds, err := h.accountManager.GetUserNotifications(account.Id, user.Id, targetUserID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, &api.UserNotifications{EmailNotifications: kinds})
+}
+
+// UpdateUserNotifications is a PUT request to update the kinds of notifications the user receives by email
+func (h *UsersHandler) UpdateUserNotifications(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	vars := mux.Vars(r)
+	targetUserID := vars["userId"]
+	if len(targetUserID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid user ID"), w)
+		return
+	}
+
+	var req api.PutApiUsersUserIdNotificationsJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteJSONParseError(err, w)
+		return
+	}
+
+	kinds, err := h.accountManager.UpdateUserNotifications(account.Id, user.Id, targetUserID, req.EmailNotifications)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, &api.UserNotifications{EmailNotifications: kinds})
+}
+
 func toUserResponse(user *server.UserInfo, currenUserID string) *api.User {
 	autoGroups := user.AutoGroups
 	if autoGroups == nil {
@@ -122,6 +122,18 @@ func initUsersTestData() *UsersHandler {
 
 				return nil
 			},
+			GetUserNotificationsFunc: func(accountID, initiatorUserID, targetUserID string) ([]string, error) {
+				if _, ok := usersTestAccount.Users[targetUserID]; !ok {
+					return nil, status.Errorf(status.NotFound, "user with ID %s does not exists", targetUserID)
+				}
+				return []string{"peer_login_expired"}, nil
+			},
+			UpdateUserNotificationsFunc: func(accountID, initiatorUserID, targetUserID string, kinds []string) ([]string, error) {
+				if _, ok := usersTestAccount.Users[targetUserID]; !ok {
+					return nil, status.Errorf(status.NotFound, "user with ID %s does not exists", targetUserID)
+				}
+				return kinds, nil
+			},
 		},
 		claimsExtractor: jwtclaims.NewClaimsExtractor(
 			jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
@@ -410,6 +422,85 @@ func TestInviteUser(t *testing.T) {
 	}
 }
 
+func TestUserNotifications(t *testing.T) {
+	tt := []struct {
+		name             string
+		expectedStatus   int
+		requestType      string
+		requestPath      string
+		requestVars      map[string]string
+		requestBody      io.Reader
+		expectedResponse []string
+	}{
+		{
+			name:             "Get User Notifications",
+			requestType:      http.MethodGet,
+			requestPath:      "/api/users/" + regularUserID + "/notifications",
+			requestVars:      map[string]string{"userId": regularUserID},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: []string{"peer_login_expired"},
+		},
+		{
+			name:             "Update User Notifications",
+			requestType:      http.MethodPut,
+			requestPath:      "/api/users/" + regularUserID + "/notifications",
+			requestVars:      map[string]string{"userId": regularUserID},
+			requestBody:      bytes.NewBufferString(`{"email_notifications":["user_invited"]}`),
+			expectedStatus:   http.StatusOK,
+			expectedResponse: []string{"user_invited"},
+		},
+		{
+			name:           "Update User Notifications with invalid body",
+			requestType:    http.MethodPut,
+			requestPath:    "/api/users/" + regularUserID + "/notifications",
+			requestVars:    map[string]string{"userId": regularUserID},
+			requestBody:    bytes.NewBufferString(`{"email_notifications":`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Get Notifications of missing user",
+			requestType:    http.MethodGet,
+			requestPath:    "/api/users/" + notFoundUserID + "/notifications",
+			requestVars:    map[string]string{"userId": notFoundUserID},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	userHandler := initUsersTestData()
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.requestType, tc.requestPath, tc.requestBody)
+			req = mux.SetURLVars(req, tc.requestVars)
+			rr := httptest.NewRecorder()
+
+			if tc.requestType == http.MethodGet {
+				userHandler.GetUserNotifications(rr, req)
+			} else {
+				userHandler.UpdateUserNotifications(rr, req)
+			}
+
+			res := rr.Result()
+			defer res.Body.Close()
+
+			if status := rr.Code; status != tc.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v",
+					status, tc.expectedStatus)
+			}
+
+			if tc.expectedResponse == nil {
+				return
+			}
+
+			var got api.UserNotifications
+			if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+				t.Fatalf("Sent content is not in correct json format; %v", err)
+			}
+			assert.Equal(t, tc.expectedResponse, got.EmailNotifications)
+		})
+	}
+}
+
 func TestDeleteUser(t *testing.T) {
 	tt := []struct {
 		name           string
@@ -13,9 +13,45 @@ import (
 	"github.com/netbirdio/netbird/management/server/status"
 )
 
+// The machine-readable codes of the errors of the HTTP API. The clients branch on them, the messages are meant for
+// humans and may change
+const (
+	ErrorCodeUserAlreadyExists  = "user_already_exists"
+	ErrorCodePreconditionFailed = "precondition_failed"
+	ErrorCodePermissionDenied   = "permission_denied"
+	ErrorCodeNotFound           = "not_found"
+	ErrorCodeInternal           = "internal"
+	ErrorCodeInvalidArgument    = "invalid_argument"
+	ErrorCodeAlreadyExists      = "already_exists"
+	ErrorCodeUnauthorized       = "unauthorized"
+	ErrorCodeBadRequest         = "bad_request"
+	ErrorCodeUnauthenticated    = "unauthenticated"
+	ErrorCodeResourceExhausted  = "resource_exhausted"
+	ErrorCodeInvalidJSON        = "invalid_json"
+	ErrorCodeMethodNotAllowed   = "method_not_allowed"
+)
+
+// errorDocumentationURL is the page documenting the error codes, the responses don't link to it when empty
+var errorDocumentationURL string
+
+// ErrorResponse is the body of the error responses of the HTTP API
 type ErrorResponse struct {
+	// Message describes the error to humans
 	Message string `json:"message"`
-	Code    int    `json:"code"`
+	// Code is the HTTP status of the response
+	Code int `json:"code"`
+	// ErrorCode is the machine-readable code of the error
+	ErrorCode string `json:"error_code"`
+	// Field is the field of the request the error is about
+	Field string `json:"field,omitempty"`
+	// DocumentationURL links to the documentation of the error code
+	DocumentationURL string `json:"documentation_url,omitempty"`
+}
+
+// SetErrorDocumentationURL sets the page documenting the error codes the error responses link to, with the error code
+// as fragment
+func SetErrorDocumentationURL(url string) {
+	errorDocumentationURL = url
 }
 
 // WriteJSONObject simply writes object to the HTTP response in JSON format
@@ -61,53 +97,127 @@ func (d *Duration) UnmarshalJSON(b []byte) error {
 	}
 }
 
-// WriteErrorResponse prepares and writes an error response i nJSON
+// WriteErrorResponse prepares and writes an error response in JSON, its error code derived from the HTTP status
 func WriteErrorResponse(errMsg string, httpStatus int, w http.ResponseWriter) {
-	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-	w.WriteHeader(httpStatus)
-	err := json.NewEncoder(w).Encode(&ErrorResponse{
-		Message: errMsg,
-		Code:    httpStatus,
-	})
-	if err != nil {
-		http.Error(w, "failed handling request", http.StatusInternalServerError)
+	writeErrorResponse(&ErrorResponse{
+		Message:   errMsg,
+		Code:      httpStatus,
+		ErrorCode: httpStatusErrorCode(httpStatus),
+	}, w)
+}
+
+// WriteBadRequest writes the error as the response of a bad request, with its message as is and the field of the
+// request it is about when it is an Error of the status package
+func WriteBadRequest(err error, w http.ResponseWriter) {
+	resp := &ErrorResponse{
+		Message:   err.Error(),
+		Code:      http.StatusBadRequest,
+		ErrorCode: ErrorCodeBadRequest,
+	}
+	if errStatus, ok := status.FromError(err); ok {
+		resp.Field = errStatus.Field
+	}
+	writeErrorResponse(resp, w)
+}
+
+// WriteJSONParseError writes the error response of a request whose JSON body couldn't be decoded, about the field of
+// the body holding a value of the wrong type when it is known
+func WriteJSONParseError(err error, w http.ResponseWriter) {
+	log.Debugf("couldn't parse JSON request: %v", err)
+	resp := &ErrorResponse{
+		Message:   "couldn't parse JSON request",
+		Code:      http.StatusBadRequest,
+		ErrorCode: ErrorCodeInvalidJSON,
 	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		resp.Field = typeErr.Field
+	}
+	writeErrorResponse(resp, w)
 }
 
 // WriteError converts an error to an JSON error response.
-// If it is known internal error of type server.Error then it sets the messages from the error, a generic message otherwise
+// If it is known internal error of type server.Error then it sets the messages, the error code and the field from the
+// error, a generic message otherwise
 func WriteError(err error, w http.ResponseWriter) {
 	log.Errorf("got a handler error: %s", err.Error())
 	errStatus, ok := status.FromError(err)
-	httpStatus := http.StatusInternalServerError
-	msg := "internal server error"
+	resp := &ErrorResponse{
+		Message:   "internal server error",
+		Code:      http.StatusInternalServerError,
+		ErrorCode: ErrorCodeInternal,
+	}
 	if ok {
 		switch errStatus.Type() {
 		case status.UserAlreadyExists:
-			httpStatus = http.StatusConflict
+			resp.Code, resp.ErrorCode = http.StatusConflict, ErrorCodeUserAlreadyExists
 		case status.AlreadyExists:
-			httpStatus = http.StatusConflict
+			resp.Code, resp.ErrorCode = http.StatusConflict, ErrorCodeAlreadyExists
 		case status.PreconditionFailed:
-			httpStatus = http.StatusPreconditionFailed
+			resp.Code, resp.ErrorCode = http.StatusPreconditionFailed, ErrorCodePreconditionFailed
 		case status.PermissionDenied:
-			httpStatus = http.StatusForbidden
+			resp.Code, resp.ErrorCode = http.StatusForbidden, ErrorCodePermissionDenied
 		case status.NotFound:
-			httpStatus = http.StatusNotFound
+			resp.Code, resp.ErrorCode = http.StatusNotFound, ErrorCodeNotFound
 		case status.Internal:
-			httpStatus = http.StatusInternalServerError
+			resp.Code, resp.ErrorCode = http.StatusInternalServerError, ErrorCodeInternal
 		case status.InvalidArgument:
-			httpStatus = http.StatusUnprocessableEntity
+			resp.Code, resp.ErrorCode = http.StatusUnprocessableEntity, ErrorCodeInvalidArgument
 		case status.Unauthorized:
-			httpStatus = http.StatusUnauthorized
+			resp.Code, resp.ErrorCode = http.StatusUnauthorized, ErrorCodeUnauthorized
 		case status.BadRequest:
-			httpStatus = http.StatusBadRequest
+			resp.Code, resp.ErrorCode = http.StatusBadRequest, ErrorCodeBadRequest
+		case status.Unauthenticated:
+			resp.Code, resp.ErrorCode = http.StatusUnauthorized, ErrorCodeUnauthenticated
+		case status.ResourceExhausted:
+			resp.Code, resp.ErrorCode = http.StatusTooManyRequests, ErrorCodeResourceExhausted
 		default:
 		}
-		msg = strings.ToLower(err.Error())
+		resp.Message = strings.ToLower(err.Error())
+		resp.Field = errStatus.Field
 	} else {
 		unhandledMSG := fmt.Sprintf("got unhandled error code, error: %s", err.Error())
 		log.Error(unhandledMSG)
 	}
 
-	WriteErrorResponse(msg, httpStatus, w)
+	writeErrorResponse(resp, w)
+}
+
+func writeErrorResponse(resp *ErrorResponse, w http.ResponseWriter) {
+	if errorDocumentationURL != "" {
+		resp.DocumentationURL = errorDocumentationURL + "#" + resp.ErrorCode
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(resp.Code)
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		http.Error(w, "failed handling request", http.StatusInternalServerError)
+	}
+}
+
+// httpStatusErrorCode returns the error code of the errors answered with the HTTP status
+func httpStatusErrorCode(httpStatus int) string {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return ErrorCodeBadRequest
+	case http.StatusUnauthorized:
+		return ErrorCodeUnauthorized
+	case http.StatusForbidden:
+		return ErrorCodePermissionDenied
+	case http.StatusNotFound:
+		return ErrorCodeNotFound
+	case http.StatusMethodNotAllowed:
+		return ErrorCodeMethodNotAllowed
+	case http.StatusConflict:
+		return ErrorCodeAlreadyExists
+	case http.StatusPreconditionFailed:
+		return ErrorCodePreconditionFailed
+	case http.StatusUnprocessableEntity:
+		return ErrorCodeInvalidArgument
+	case http.StatusTooManyRequests:
+		return ErrorCodeResourceExhausted
+	default:
+		return ErrorCodeInternal
+	}
 }
@@ -0,0 +1,117 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestWriteError(t *testing.T) {
+	tt := []struct {
+		name     string
+		err      error
+		expected ErrorResponse
+	}{
+		{
+			name: "status error",
+			err:  status.Errorf(status.NotFound, "Peer peer-1 not found"),
+			expected: ErrorResponse{
+				Message:   "peer peer-1 not found",
+				Code:      http.StatusNotFound,
+				ErrorCode: ErrorCodeNotFound,
+			},
+		},
+		{
+			name: "field error",
+			err:  status.FieldErrorf(status.InvalidArgument, "name", "policy name shouldn't be empty"),
+			expected: ErrorResponse{
+				Message:   "policy name shouldn't be empty",
+				Code:      http.StatusUnprocessableEntity,
+				ErrorCode: ErrorCodeInvalidArgument,
+				Field:     "name",
+			},
+		},
+		{
+			name: "unauthenticated error",
+			err:  status.Errorf(status.Unauthenticated, "token expired"),
+			expected: ErrorResponse{
+				Message:   "token expired",
+				Code:      http.StatusUnauthorized,
+				ErrorCode: ErrorCodeUnauthenticated,
+			},
+		},
+		{
+			name: "quota error",
+			err:  status.Errorf(status.ResourceExhausted, "the account exceeded its quota"),
+			expected: ErrorResponse{
+				Message:   "the account exceeded its quota",
+				Code:      http.StatusTooManyRequests,
+				ErrorCode: ErrorCodeResourceExhausted,
+			},
+		},
+		{
+			name: "unknown error",
+			err:  errors.New("connection refused"),
+			expected: ErrorResponse{
+				Message:   "internal server error",
+				Code:      http.StatusInternalServerError,
+				ErrorCode: ErrorCodeInternal,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			WriteError(tc.err, recorder)
+			assert.Equal(t, tc.expected.Code, recorder.Code)
+			assert.Equal(t, tc.expected, decodeErrorResponse(t, recorder))
+		})
+	}
+}
+
+func TestWriteJSONParseError(t *testing.T) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	err := json.NewDecoder(strings.NewReader(`{"name": 1}`)).Decode(&req)
+	require.Error(t, err)
+
+	recorder := httptest.NewRecorder()
+	WriteJSONParseError(err, recorder)
+	assert.Equal(t, ErrorResponse{
+		Message:   "couldn't parse JSON request",
+		Code:      http.StatusBadRequest,
+		ErrorCode: ErrorCodeInvalidJSON,
+		Field:     "name",
+	}, decodeErrorResponse(t, recorder))
+}
+
+func TestWriteErrorResponse_DocumentationURL(t *testing.T) {
+	SetErrorDocumentationURL("https://example.com/api-errors")
+	defer SetErrorDocumentationURL("")
+
+	recorder := httptest.NewRecorder()
+	WriteErrorResponse("wrong HTTP method", http.StatusMethodNotAllowed, recorder)
+	assert.Equal(t, ErrorResponse{
+		Message:          "wrong HTTP method",
+		Code:             http.StatusMethodNotAllowed,
+		ErrorCode:        ErrorCodeMethodNotAllowed,
+		DocumentationURL: "https://example.com/api-errors#method_not_allowed",
+	}, decodeErrorResponse(t, recorder))
+}
+
+func decodeErrorResponse(t *testing.T, recorder *httptest.ResponseRecorder) ErrorResponse {
+	t.Helper()
+	var got ErrorResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got), "failed to decode the response: %s", recorder.Body.String())
+	return got
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"net/netip"
+	"slices"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/netbirdio/netbird/management/proto"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// iceCandidateTypes are the ICE candidate types the peers can be told not to gather
+var iceCandidateTypes = []string{"host", "srflx", "relay"}
+
+// ICESettings are the ICE settings of the account pushed to the peers. Peers with locally configured ICE settings
+// ignore the matching ones
+type ICESettings struct {
+	// DisabledCandidateTypes are the candidate types the peers never gather: host, srflx or relay
+	DisabledCandidateTypes []string
+	// ExcludedSubnets are the local subnets the peers gather no host candidate from, e.g. Docker bridges
+	ExcludedSubnets []string
+	// FailedTimeout is the time the candidates are checked for before a connection attempt fails, 0 keeps the default
+	FailedTimeout time.Duration
+	// DisconnectedTimeout is the time without traffic after which a connection is considered disconnected, 0 keeps
+	// the default
+	DisconnectedTimeout time.Duration
+}
+
+// iceSettings returns the ICE settings of the account settings
+func (s *Settings) iceSettings() ICESettings {
+	return ICESettings{
+		DisabledCandidateTypes: slices.Clone(s.ICEDisabledCandidateTypes),
+		ExcludedSubnets:        slices.Clone(s.ICEExcludedSubnets),
+		FailedTimeout:          s.ICEFailedTimeout,
+		DisconnectedTimeout:    s.ICEDisconnectedTimeout,
+	}
+}
+
+// equal returns true when both settings are the same
+func (s ICESettings) equal(other ICESettings) bool {
+	return slices.Equal(s.DisabledCandidateTypes, other.DisabledCandidateTypes) &&
+		slices.Equal(s.ExcludedSubnets, other.ExcludedSubnets) &&
+		s.FailedTimeout == other.FailedTimeout &&
+		s.DisconnectedTimeout == other.DisconnectedTimeout
+}
+
+func validateICESettings(settings ICESettings) error {
+	for _, candidateType := range settings.DisabledCandidateTypes {
+		if !slices.Contains(iceCandidateTypes, candidateType) {
+			return status.Errorf(status.InvalidArgument, "invalid ICE candidate type %s, expected host, srflx or relay", candidateType)
+		}
+	}
+
+	disabled := 0
+	for _, candidateType := range iceCandidateTypes {
+		if slices.Contains(settings.DisabledCandidateTypes, candidateType) {
+			disabled++
+		}
+	}
+	if disabled == len(iceCandidateTypes) {
+		return status.Errorf(status.InvalidArgument, "at least one ICE candidate type must stay enabled")
+	}
+
+	for _, subnet := range settings.ExcludedSubnets {
+		if _, err := netip.ParsePrefix(subnet); err != nil {
+			return status.Errorf(status.InvalidArgument, "invalid ICE excluded subnet %s", subnet)
+		}
+	}
+
+	if settings.FailedTimeout < 0 || settings.DisconnectedTimeout < 0 {
+		return status.Errorf(status.InvalidArgument, "ICE timeouts can't be negative")
+	}
+
+	return nil
+}
+
+// toICEConfig returns the ICE config pushed to the peers, nil when no setting is set
+func toICEConfig(settings ICESettings) *proto.ICEConfig {
+	if settings.equal(ICESettings{}) {
+		return nil
+	}
+
+	config := &proto.ICEConfig{
+		DisabledCandidateTypes: settings.DisabledCandidateTypes,
+		ExcludedSubnets:        settings.ExcludedSubnets,
+	}
+	if settings.FailedTimeout > 0 {
+		config.FailedTimeout = durationpb.New(settings.FailedTimeout)
+	}
+	if settings.DisconnectedTimeout > 0 {
+		config.DisconnectedTimeout = durationpb.New(settings.DisconnectedTimeout)
+	}
+	return config
+}
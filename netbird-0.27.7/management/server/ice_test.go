@@ -0,0 +1,22 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToICEConfig(t *testing.T) {
+	assert.Nil(t, toICEConfig(ICESettings{}), "no ICE config should be pushed without settings")
+
+	config := toICEConfig(ICESettings{
+		DisabledCandidateTypes: []string{"relay"},
+		ExcludedSubnets:        []string{"172.17.0.0/16"},
+		DisconnectedTimeout:    10 * time.Second,
+	})
+	assert.Equal(t, []string{"relay"}, config.GetDisabledCandidateTypes())
+	assert.Equal(t, []string{"172.17.0.0/16"}, config.GetExcludedSubnets())
+	assert.Nil(t, config.GetFailedTimeout(), "unset timeouts should not be pushed")
+	assert.Equal(t, 10*time.Second, config.GetDisconnectedTimeout().AsDuration())
+}
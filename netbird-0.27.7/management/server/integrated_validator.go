@@ -76,5 +76,17 @@ func (am *DefaultAccountManager) GroupValidation(accountId string, groups []stri
 }
 
 func (am *DefaultAccountManager) GetValidatedPeers(account *Account) (map[string]struct{}, error) {
-	return am.integratedPeerValidator.GetValidatedPeers(account.Id, account.Groups, account.Peers, account.Settings.Extra)
+	validatedPeers, err := am.integratedPeerValidator.GetValidatedPeers(account.Id, account.Groups, account.Peers, account.Settings.Extra)
+	if err != nil {
+		return nil, err
+	}
+
+	// the peers blocked by the geofence are left out of the network maps like the peers pending approval
+	for peerID := range validatedPeers {
+		if account.isPeerGeofenceBlocked(peerID) {
+			delete(validatedPeers, peerID)
+		}
+	}
+
+	return validatedPeers, nil
 }
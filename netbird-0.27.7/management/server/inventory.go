@@ -0,0 +1,155 @@
+package server
+
+import (
+	"sort"
+	"strings"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/posture"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// InventoryFilter selects the peers of an inventory, empty fields match all the peers
+type InventoryFilter struct {
+	// OS matches the OS of the peers case-insensitively, e.g. OpenWrt
+	OS string
+	// Package matches the peers with the package installed
+	Package string
+	// VersionBelow matches the peers with the Package installed below the version
+	VersionBelow string
+	// PatchLevel matches the peers with a patch level starting with it, e.g. 23.05 for all the 23.05.x releases
+	PatchLevel string
+}
+
+// Validate checks whether the filter can be applied
+func (f InventoryFilter) Validate() error {
+	if f.VersionBelow != "" && f.Package == "" {
+		return status.FieldErrorf(status.InvalidArgument, "version_below", "the version filter requires a package filter")
+	}
+	return nil
+}
+
+func (f InventoryFilter) match(peer *nbpeer.Peer) bool {
+	if f.OS != "" && !strings.EqualFold(peer.Meta.OS, f.OS) {
+		return false
+	}
+	if f.PatchLevel != "" && !strings.HasPrefix(peer.Meta.Inventory.PatchLevel, f.PatchLevel) {
+		return false
+	}
+	if f.Package == "" {
+		return true
+	}
+	installed, ok := peer.Meta.Inventory.PackageVersion(f.Package)
+	if !ok {
+		return false
+	}
+	return f.VersionBelow == "" || posture.ComparePackageVersions(installed, f.VersionBelow) < 0
+}
+
+// PackageVersionReport is a version of a package and the peers it is installed on
+type PackageVersionReport struct {
+	Name    string
+	Version string
+	// PeerIDs are the peers with the version installed
+	PeerIDs []string
+	// MinVersion is the highest minimum version of the package among the package version posture checks of the
+	// account, empty when none lists it
+	MinVersion string
+}
+
+// Vulnerable returns whether the version is below the minimum version of the posture checks
+func (r *PackageVersionReport) Vulnerable() bool {
+	return r.MinVersion != "" && posture.ComparePackageVersions(r.Version, r.MinVersion) < 0
+}
+
+// GetPeersInventory returns the peers of the account that reported a software inventory and match the filter, sorted
+// by name. Only users with admin power can view it.
+func (am *DefaultAccountManager) GetPeersInventory(accountID, userID string, filter InventoryFilter) ([]*nbpeer.Peer, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view the peers inventory")
+	}
+
+	peers := make([]*nbpeer.Peer, 0)
+	for _, peer := range account.Peers {
+		if peer.Meta.Inventory.Reported() && filter.match(peer) {
+			peers = append(peers, peer.Copy())
+		}
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Name < peers[j].Name
+	})
+
+	return peers, nil
+}
+
+// GetPackagesReport returns the versions of the packages the peers of the account reported with the peers they are
+// installed on, sorted by name and version. The versions are compared to the minimum versions of the package version
+// posture checks. Only users with admin power can view it.
+func (am *DefaultAccountManager) GetPackagesReport(accountID, userID string) ([]*PackageVersionReport, error) {
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view the packages report")
+	}
+
+	minVersions := make(map[string]string)
+	for _, checks := range account.PostureChecks {
+		check := checks.Checks.PackageVersionCheck
+		if check == nil {
+			continue
+		}
+		for _, pkg := range check.Packages {
+			if current, ok := minVersions[pkg.Name]; !ok || posture.ComparePackageVersions(pkg.MinVersion, current) > 0 {
+				minVersions[pkg.Name] = pkg.MinVersion
+			}
+		}
+	}
+
+	versions := make(map[nbpeer.Package]*PackageVersionReport)
+	for _, peer := range account.Peers {
+		for _, pkg := range peer.Meta.Inventory.Packages {
+			report, ok := versions[pkg]
+			if !ok {
+				report = &PackageVersionReport{Name: pkg.Name, Version: pkg.Version, MinVersion: minVersions[pkg.Name]}
+				versions[pkg] = report
+			}
+			report.PeerIDs = append(report.PeerIDs, peer.ID)
+		}
+	}
+
+	reports := make([]*PackageVersionReport, 0, len(versions))
+	for _, report := range versions {
+		sort.Strings(report.PeerIDs)
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Name != reports[j].Name {
+			return reports[i].Name < reports[j].Name
+		}
+		return posture.ComparePackageVersions(reports[i].Version, reports[j].Version) < 0
+	})
+
+	return reports, nil
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/posture"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func newInventoryTestAccount(t *testing.T, manager *DefaultAccountManager) *Account {
+	t.Helper()
+
+	account := newAccountWithId("test_account", userID, "")
+	account.Users["regularUser"] = NewRegularUser("regularUser")
+	account.Peers["router"] = &nbpeer.Peer{
+		ID:   "router",
+		Key:  "routerKey",
+		Name: "office-router",
+		Meta: nbpeer.PeerSystemMeta{
+			OS: "OpenWrt",
+			Inventory: nbpeer.Inventory{
+				PackageManager: "opkg",
+				PatchLevel:     "23.05.3 r23809-234f1a2efa",
+				PackagesCount:  142,
+				Packages: []nbpeer.Package{
+					{Name: "dropbear", Version: "2022.82-6"},
+					{Name: "libopenssl3", Version: "3.0.13-1"},
+				},
+			},
+		},
+	}
+	account.Peers["gateway"] = &nbpeer.Peer{
+		ID:   "gateway",
+		Key:  "gatewayKey",
+		Name: "branch-gateway",
+		Meta: nbpeer.PeerSystemMeta{
+			OS: "OpenWrt",
+			Inventory: nbpeer.Inventory{
+				PatchLevel: "22.03.6 r20265-f85a79bcb4",
+				Packages:   []nbpeer.Package{{Name: "libopenssl3", Version: "3.0.14-1"}},
+			},
+		},
+	}
+	account.Peers["laptop"] = &nbpeer.Peer{
+		ID:   "laptop",
+		Key:  "laptopKey",
+		Name: "laptop",
+		Meta: nbpeer.PeerSystemMeta{OS: "Darwin"},
+	}
+	account.PostureChecks = []*posture.Checks{
+		{
+			ID:   "openssl",
+			Name: "OpenSSL",
+			Checks: posture.ChecksDefinition{
+				PackageVersionCheck: &posture.PackageVersionCheck{
+					Packages: []posture.MinPackageVersion{{Name: "libopenssl3", MinVersion: "3.0.13-2"}},
+				},
+			},
+		},
+		{
+			ID:   "openssl-strict",
+			Name: "OpenSSL strict",
+			Checks: posture.ChecksDefinition{
+				PackageVersionCheck: &posture.PackageVersionCheck{
+					Packages: []posture.MinPackageVersion{{Name: "libopenssl3", MinVersion: "3.0.14-1"}},
+				},
+			},
+		},
+	}
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	return account
+}
+
+func TestDefaultAccountManager_GetPeersInventory(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+	account := newInventoryTestAccount(t, manager)
+
+	_, err = manager.GetPeersInventory(account.Id, "regularUser", InventoryFilter{})
+	sErr, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.PermissionDenied, sErr.Type(), "regular users shouldn't view the peers inventory")
+
+	_, err = manager.GetPeersInventory(account.Id, userID, InventoryFilter{VersionBelow: "3.0.14"})
+	sErr, ok = status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.InvalidArgument, sErr.Type(), "the version filter requires a package")
+
+	tt := []struct {
+		name     string
+		filter   InventoryFilter
+		expected []string
+	}{
+		{
+			name:     "all the peers reporting an inventory",
+			expected: []string{"gateway", "router"},
+		},
+		{
+			name:     "os",
+			filter:   InventoryFilter{OS: "openwrt"},
+			expected: []string{"gateway", "router"},
+		},
+		{
+			name:     "package",
+			filter:   InventoryFilter{Package: "dropbear"},
+			expected: []string{"router"},
+		},
+		{
+			name:     "package below a version",
+			filter:   InventoryFilter{Package: "libopenssl3", VersionBelow: "3.0.14"},
+			expected: []string{"router"},
+		},
+		{
+			name:     "patch level",
+			filter:   InventoryFilter{PatchLevel: "22.03"},
+			expected: []string{"gateway"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			peers, err := manager.GetPeersInventory(account.Id, userID, tc.filter)
+			require.NoError(t, err)
+
+			ids := make([]string, 0, len(peers))
+			for _, peer := range peers {
+				ids = append(ids, peer.ID)
+			}
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+}
+
+func TestDefaultAccountManager_GetPackagesReport(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+	account := newInventoryTestAccount(t, manager)
+
+	_, err = manager.GetPackagesReport(account.Id, "regularUser")
+	sErr, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.PermissionDenied, sErr.Type(), "regular users shouldn't view the packages report")
+
+	reports, err := manager.GetPackagesReport(account.Id, userID)
+	require.NoError(t, err)
+	require.Len(t, reports, 3)
+
+	assert.Equal(t, &PackageVersionReport{Name: "dropbear", Version: "2022.82-6", PeerIDs: []string{"router"}}, reports[0])
+	assert.False(t, reports[0].Vulnerable())
+
+	assert.Equal(t, &PackageVersionReport{
+		Name: "libopenssl3", Version: "3.0.13-1", PeerIDs: []string{"router"}, MinVersion: "3.0.14-1",
+	}, reports[1])
+	assert.True(t, reports[1].Vulnerable(), "the highest minimum version of the posture checks should apply")
+
+	assert.Equal(t, "3.0.14-1", reports[2].Version)
+	assert.False(t, reports[2].Vulnerable())
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"bytes"
+	"net"
+	"sort"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// IPPool is the usage of the network the peers of an account get their NetBird IPs from
+type IPPool struct {
+	// Network is the network of the account
+	Network net.IPNet
+	// Capacity is the number of addresses the automatic allocation picks from
+	Capacity int
+	// Free is the number of addresses left to the automatic allocation
+	Free int
+	// Allocations are the IPs of the peers sorted by IP
+	Allocations []IPAllocation
+}
+
+// IPAllocation is the NetBird IP of a peer
+type IPAllocation struct {
+	IP       net.IP
+	PeerID   string
+	PeerName string
+	// Static is true when the IP was assigned by an administrator or requested by the peer
+	Static bool
+}
+
+// Utilization returns the share of the addresses of the automatic allocation in use, in percent
+func (p *IPPool) Utilization() float64 {
+	if p.Capacity == 0 {
+		return 0
+	}
+	return float64(p.Capacity-p.Free) * 100 / float64(p.Capacity)
+}
+
+// GetIPPool validates a user role and returns the usage of the network of the account
+func (am *DefaultAccountManager) GetIPPool(accountID, userID string) (*IPPool, error) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view the IP pool")
+	}
+
+	network := account.Network.Net
+	// the allocation excludes the network address and the taken IPs the same way
+	exclusions := map[string]struct{}{network.IP.String(): {}}
+	_, capacity := generateIPs(&network, exclusions)
+
+	pool := &IPPool{
+		Network:     network,
+		Allocations: make([]IPAllocation, 0, len(account.Peers)),
+	}
+	for _, peer := range account.Peers {
+		exclusions[peer.IP.String()] = struct{}{}
+		pool.Allocations = append(pool.Allocations, IPAllocation{
+			IP:       peer.IP,
+			PeerID:   peer.ID,
+			PeerName: peer.Name,
+			Static:   peer.StaticIP,
+		})
+	}
+	_, free := generateIPs(&network, exclusions)
+
+	pool.Capacity = max(capacity, 0)
+	pool.Free = max(free, 0)
+	sort.Slice(pool.Allocations, func(i, j int) bool {
+		return bytes.Compare(pool.Allocations[i].IP.To4(), pool.Allocations[j].IP.To4()) < 0
+	})
+
+	return pool, nil
+}
+
+// AssignPeerIP validates a user role and assigns a static NetBird IP to a peer. The IP has to be a free host address
+// of the account network, the peer applies it without a restart
+func (am *DefaultAccountManager) AssignPeerIP(accountID, userID, peerID string, ip net.IP) (*nbpeer.Peer, error) {
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasAdminPower() {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can assign peer IPs")
+	}
+
+	peer := account.GetPeer(peerID)
+	if peer == nil {
+		return nil, status.Errorf(status.NotFound, "peer %s not found", peerID)
+	}
+
+	if peer.IP.Equal(ip) {
+		if peer.StaticIP {
+			return peer, nil
+		}
+		// keeping the allocated IP makes it static
+		peer.StaticIP = true
+	} else if err = am.assignPeerIP(account, userID, peer, ip); err != nil {
+		return nil, err
+	}
+
+	account.UpdatePeer(peer)
+
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return nil, err
+	}
+
+	am.updateAccountPeers(account)
+
+	return peer, nil
+}
+
+// assignPeerIP validates and sets the static IP of the peer. The caller saves the account
+func (am *DefaultAccountManager) assignPeerIP(account *Account, initiatorID string, peer *nbpeer.Peer, ip net.IP) error {
+	if err := validatePeerIP(account, peer.ID, ip); err != nil {
+		return err
+	}
+
+	meta := peer.EventMeta(am.GetDNSDomain())
+	meta["old_ip"] = peer.IP.String()
+	peer.IP = ip.To4()
+	peer.StaticIP = true
+	meta["ip"] = peer.IP
+	// the peers have to receive the new network map even if nothing else changed
+	account.Network.IncSerial()
+
+	am.StoreEvent(initiatorID, peer.ID, account.Id, activity.PeerIPUpdated, meta)
+	return nil
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestDefaultAccountManager_IPPool(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err, "unable to create an account")
+
+	peer1, _, err := manager.AddPeer("", userID, newQuotaTestPeer(t, "peer1"))
+	require.NoError(t, err, "unable to add peer")
+	peer2, _, err := manager.AddPeer("", userID, newQuotaTestPeer(t, "peer2"))
+	require.NoError(t, err, "unable to add peer")
+
+	pool, err := manager.GetIPPool(account.Id, userID)
+	require.NoError(t, err)
+	assert.Equal(t, account.Network.Net.String(), pool.Network.String())
+	assert.Equal(t, pool.Capacity-2, pool.Free, "the allocated IPs shouldn't be free")
+	require.Len(t, pool.Allocations, 2)
+	assert.False(t, pool.Allocations[0].Static)
+	assert.Greater(t, pool.Utilization(), 0.0)
+
+	network := account.Network.Net
+	staticIP := make(net.IP, net.IPv4len)
+	copy(staticIP, network.IP.To4())
+	staticIP[2], staticIP[3] = 1, 10
+
+	_, err = manager.AssignPeerIP(account.Id, userID, peer1.ID, peer2.IP)
+	sErr, ok := status.FromError(err)
+	require.True(t, ok, "expected a status error, got %v", err)
+	assert.Equal(t, status.AlreadyExists, sErr.Type(), "the IP of another peer shouldn't be assigned")
+
+	resolverIP := make(net.IP, net.IPv4len)
+	copy(resolverIP, network.IP.To4())
+	resolverIP[2], resolverIP[3] = 255, 254
+	_, err = manager.AssignPeerIP(account.Id, userID, peer1.ID, resolverIP)
+	assert.Error(t, err, "the IP of the DNS resolver of the peers shouldn't be assigned")
+
+	peer, err := manager.AssignPeerIP(account.Id, userID, peer1.ID, staticIP)
+	require.NoError(t, err)
+	assert.True(t, peer.IP.Equal(staticIP))
+	assert.True(t, peer.StaticIP)
+
+	peer, err = manager.AssignPeerIP(account.Id, userID, peer2.ID, peer2.IP)
+	require.NoError(t, err)
+	assert.True(t, peer.StaticIP, "assigning the current IP should make it static")
+
+	pool, err = manager.GetIPPool(account.Id, userID)
+	require.NoError(t, err)
+	require.Len(t, pool.Allocations, 2)
+	for _, allocation := range pool.Allocations {
+		assert.True(t, allocation.Static)
+	}
+
+	_, err = manager.AssignPeerIP(account.Id, userID, "unknown", staticIP)
+	sErr, ok = status.FromError(err)
+	require.True(t, ok, "expected a status error, got %v", err)
+	assert.Equal(t, status.NotFound, sErr.Type())
+}
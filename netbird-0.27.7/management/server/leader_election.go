@@ -0,0 +1,229 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/xid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultLeaderLeaseTTL is how long the lease of the leader lasts without being renewed when no TTL is configured
+	DefaultLeaderLeaseTTL = 30 * time.Second
+	// DefaultLeaderResyncInterval is how often the leader reschedules the jobs of all the accounts when no interval is
+	// configured
+	DefaultLeaderResyncInterval = 5 * time.Minute
+
+	// backgroundJobsLease is the name of the lease held by the instance running the background jobs
+	backgroundJobsLease = "background-jobs"
+)
+
+// LeaderElection elects one of the instances of the management service sharing a store to run the background jobs.
+// The leader holds a lease in the store and renews it every third of its TTL, another instance takes over once the
+// lease expired, so the TTL has to exceed the clock skew of the instances. A nil LeaderElection is always the leader.
+type LeaderElection struct {
+	store  Store
+	holder string
+	ttl    time.Duration
+
+	mu sync.Mutex
+	// leaseEnd is when the lease this instance holds expires, zero when it doesn't hold it
+	leaseEnd  time.Time
+	onElected []func()
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewLeaderElection instantiates a new LeaderElection. The holder identifies this instance in the store, the host name
+// with a random suffix when empty
+func NewLeaderElection(store Store, holder string, ttl time.Duration) *LeaderElection {
+	if holder == "" {
+		hostname, _ := os.Hostname()
+		holder = fmt.Sprintf("%s-%s", hostname, xid.New().String())
+	}
+	if ttl <= 0 {
+		ttl = DefaultLeaderLeaseTTL
+	}
+	return &LeaderElection{
+		store:  store,
+		holder: holder,
+		ttl:    ttl,
+	}
+}
+
+// Holder returns the identity of this instance in the store
+func (l *LeaderElection) Holder() string {
+	return l.holder
+}
+
+// IsLeader returns whether this instance holds an unexpired lease
+func (l *LeaderElection) IsLeader() bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return time.Now().Before(l.leaseEnd)
+}
+
+// OnElected registers a function called in the background every time this instance becomes the leader
+func (l *LeaderElection) OnElected(f func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onElected = append(l.onElected, f)
+}
+
+// Start campaigns for the lease right away and then every third of its TTL until the context is done or Stop is
+// called
+func (l *LeaderElection) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	l.mu.Lock()
+	l.cancel = cancel
+	l.done = done
+	l.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(l.ttl / 3)
+		defer ticker.Stop()
+		for {
+			l.campaign()
+			select {
+			case <-ctx.Done():
+				l.resign()
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops campaigning and releases the lease so that another instance takes over without waiting for its expiry
+func (l *LeaderElection) Stop() {
+	l.mu.Lock()
+	cancel, done := l.cancel, l.done
+	l.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// campaign takes or renews the lease. The instance steps down when the store fails, the lease may expire before the
+// next attempt
+func (l *LeaderElection) campaign() {
+	start := time.Now()
+	acquired, err := l.store.AcquireLease(backgroundJobsLease, l.holder, l.ttl)
+	if err != nil {
+		log.Warnf("failed to renew the lease of the background jobs: %v", err)
+	}
+
+	l.mu.Lock()
+	wasLeader := start.Before(l.leaseEnd)
+	if acquired && err == nil {
+		l.leaseEnd = start.Add(l.ttl)
+	} else {
+		l.leaseEnd = time.Time{}
+	}
+	onElected := l.onElected
+	l.mu.Unlock()
+
+	switch {
+	case acquired && err == nil && !wasLeader:
+		log.Infof("instance %s is the leader running the background jobs", l.holder)
+		for _, f := range onElected {
+			go f()
+		}
+	case !acquired && wasLeader:
+		log.Warnf("instance %s lost the lease of the background jobs", l.holder)
+	}
+}
+
+func (l *LeaderElection) resign() {
+	l.mu.Lock()
+	l.leaseEnd = time.Time{}
+	l.mu.Unlock()
+
+	if err := l.store.ReleaseLease(backgroundJobsLease, l.holder); err != nil {
+		log.Warnf("failed to release the lease of the background jobs: %v", err)
+	}
+}
+
+// leaderScheduler runs the jobs of the wrapped scheduler only while the instance is the leader. The jobs scheduled on
+// the other instances are dropped, the leader reschedules them on its resync
+type leaderScheduler struct {
+	Scheduler
+	election *LeaderElection
+}
+
+func newLeaderScheduler(scheduler Scheduler, election *LeaderElection) Scheduler {
+	return &leaderScheduler{Scheduler: scheduler, election: election}
+}
+
+// Schedule schedules the job when the instance is the leader, the job is dropped when it runs after the instance lost
+// the lease
+func (s *leaderScheduler) Schedule(in time.Duration, ID string, job func() (nextRunIn time.Duration, reschedule bool)) {
+	if !s.election.IsLeader() {
+		log.Tracef("dropped job %s, the instance isn't the leader", ID)
+		return
+	}
+	s.Scheduler.Schedule(in, ID, func() (time.Duration, bool) {
+		if !s.election.IsLeader() {
+			log.Debugf("dropped job %s, the instance isn't the leader anymore", ID)
+			return 0, false
+		}
+		return job()
+	})
+}
+
+// EnableLeaderElection makes the account manager run its scheduled jobs only while the instance is the leader. The
+// accounts changed through the other instances have their jobs dropped there, so the leader reschedules the jobs of
+// all the accounts when it is elected and then on every resync interval until the context is done
+func (am *DefaultAccountManager) EnableLeaderElection(ctx context.Context, election *LeaderElection, resyncInterval time.Duration) {
+	if resyncInterval <= 0 {
+		resyncInterval = DefaultLeaderResyncInterval
+	}
+
+	am.leaderElection = election
+	am.peerLoginExpiry = newLeaderScheduler(am.peerLoginExpiry, election)
+	am.peerKeyRotation = newLeaderScheduler(am.peerKeyRotation, election)
+	am.accountDeletion = newLeaderScheduler(am.accountDeletion, election)
+	am.externalListRefresh = newLeaderScheduler(am.externalListRefresh, election)
+	// cancels the jobs scheduled when the manager was built, before the instance took part in the election
+	am.rescheduleAccountJobs()
+	election.OnElected(am.rescheduleAccountJobs)
+
+	go func() {
+		ticker := time.NewTicker(resyncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if election.IsLeader() {
+					am.rescheduleAccountJobs()
+				}
+			}
+		}
+	}()
+}
+
+// rescheduleAccountJobs reschedules the peer login expiration, the peer key rotation and the deletion of every account
+func (am *DefaultAccountManager) rescheduleAccountJobs() {
+	for _, account := range am.Store.GetAllAccounts() {
+		am.checkAndSchedulePeerLoginExpiration(account)
+		am.checkAndSchedulePeerKeyRotation(account)
+		am.checkAndScheduleAccountDeletion(account)
+		am.checkAndScheduleExternalListRefresh(account)
+	}
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSqlite_AcquireLease(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The SQLite store is not properly supported by Windows yet")
+	}
+
+	store := newSqliteStore(t)
+
+	acquired, err := store.AcquireLease("jobs", "instance-a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired, "the first holder should get the lease")
+
+	acquired, err = store.AcquireLease("jobs", "instance-b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired, "the lease of another holder shouldn't be taken before it expired")
+
+	acquired, err = store.AcquireLease("jobs", "instance-a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired, "the holder should renew its lease")
+
+	acquired, err = store.AcquireLease("other", "instance-b", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired, "the leases with other names should be independent")
+
+	require.NoError(t, store.ReleaseLease("jobs", "instance-b"))
+	acquired, err = store.AcquireLease("jobs", "instance-b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired, "a release by another holder shouldn't free the lease")
+
+	require.NoError(t, store.ReleaseLease("jobs", "instance-a"))
+	acquired, err = store.AcquireLease("jobs", "instance-b", time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, acquired, "the released lease should be taken")
+
+	time.Sleep(10 * time.Millisecond)
+	acquired, err = store.AcquireLease("jobs", "instance-a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired, "the expired lease should be taken over")
+}
+
+func TestFileStore_AcquireLease(t *testing.T) {
+	store := newStore(t)
+
+	for _, holder := range []string{"instance-a", "instance-b"} {
+		acquired, err := store.AcquireLease("jobs", holder, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, acquired, "the file store should grant the lease to %s", holder)
+	}
+}
+
+func TestLeaderElection(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The SQLite store is not properly supported by Windows yet")
+	}
+
+	store := newSqliteStore(t)
+	first := NewLeaderElection(store, "instance-a", 300*time.Millisecond)
+	second := NewLeaderElection(store, "instance-b", 300*time.Millisecond)
+
+	var firstElected, secondElected atomic.Int32
+	first.OnElected(func() { firstElected.Add(1) })
+	second.OnElected(func() { secondElected.Add(1) })
+
+	first.Start(context.Background())
+	require.Eventually(t, first.IsLeader, time.Second, 10*time.Millisecond, "the first instance should be elected")
+	second.Start(context.Background())
+	defer second.Stop()
+
+	time.Sleep(400 * time.Millisecond)
+	assert.True(t, first.IsLeader(), "the leader should renew its lease")
+	assert.False(t, second.IsLeader(), "only one instance should be the leader")
+
+	first.Stop()
+	assert.False(t, first.IsLeader(), "the stopped instance shouldn't be the leader")
+	require.Eventually(t, second.IsLeader, time.Second, 10*time.Millisecond, "the second instance should take over")
+
+	assert.Eventually(t, func() bool { return firstElected.Load() == 1 && secondElected.Load() == 1 }, time.Second,
+		10*time.Millisecond, "each instance should be notified once about its election")
+}
+
+func TestLeaderElection_Nil(t *testing.T) {
+	var election *LeaderElection
+	assert.True(t, election.IsLeader(), "a nil election should always be the leader")
+}
+
+func TestLeaderScheduler(t *testing.T) {
+	election := NewLeaderElection(newStore(t), "instance-a", time.Minute)
+
+	var scheduled []func() (time.Duration, bool)
+	scheduler := newLeaderScheduler(&MockScheduler{
+		ScheduleFunc: func(_ time.Duration, _ string, job func() (time.Duration, bool)) {
+			scheduled = append(scheduled, job)
+		},
+	}, election)
+
+	var runs int
+	job := func() (time.Duration, bool) {
+		runs++
+		return time.Second, true
+	}
+
+	scheduler.Schedule(time.Second, "account", job)
+	require.Empty(t, scheduled, "a job shouldn't be scheduled before the instance is elected")
+
+	election.campaign()
+	scheduler.Schedule(time.Second, "account", job)
+	require.Len(t, scheduled, 1, "the leader should schedule the job")
+	_, reschedule := scheduled[0]()
+	assert.True(t, reschedule, "the leader should reschedule the job")
+	assert.Equal(t, 1, runs, "the leader should run the job")
+
+	election.resign()
+	_, reschedule = scheduled[0]()
+	assert.False(t, reschedule, "the job shouldn't be rescheduled after the instance lost the lease")
+	assert.Equal(t, 1, runs, "the job shouldn't run after the instance lost the lease")
+}
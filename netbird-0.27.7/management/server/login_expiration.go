@@ -0,0 +1,85 @@
+package server
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/netbirdio/netbird/management/proto"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	maxPeerLoginExpirationWarnings = 5
+	minPeerLoginExpirationWarning  = time.Minute
+)
+
+// DefaultPeerLoginExpirationWarnings are the lead times before the login expiration the peers of new accounts warn
+// their users at
+var DefaultPeerLoginExpirationWarnings = []time.Duration{24 * time.Hour, time.Hour}
+
+// LoginExpirationSettings describe the login expiration of a peer pushed to it so that it warns its user ahead
+type LoginExpirationSettings struct {
+	// ExpiresAt is when the login of the peer expires, zero when it never expires
+	ExpiresAt time.Time
+	// Warnings are the lead times before ExpiresAt the peer warns its user at
+	Warnings []time.Duration
+}
+
+// GetPeerLoginExpiresAt returns when the login of the peer expires, false when it never expires
+func (a *Account) GetPeerLoginExpiresAt(peer *nbpeer.Peer) (time.Time, bool) {
+	if !a.Settings.PeerLoginExpirationEnabled || !peer.LoginExpirationEnabled || !peer.AddedWithSSOLogin() {
+		return time.Time{}, false
+	}
+	return peer.LastLogin.Add(a.Settings.PeerLoginExpiration), true
+}
+
+// getPeerLoginExpiration returns the login expiration of the peer and the warnings of the account settings, the
+// warnings not shorter than the login expiration are left out as they would be reached right after the login
+func (a *Account) getPeerLoginExpiration(peer *nbpeer.Peer) LoginExpirationSettings {
+	expiresAt, ok := a.GetPeerLoginExpiresAt(peer)
+	if !ok {
+		return LoginExpirationSettings{}
+	}
+
+	warnings := make([]time.Duration, 0, len(a.Settings.PeerLoginExpirationWarnings))
+	for _, warning := range a.Settings.PeerLoginExpirationWarnings {
+		if warning < a.Settings.PeerLoginExpiration {
+			warnings = append(warnings, warning)
+		}
+	}
+	return LoginExpirationSettings{
+		ExpiresAt: expiresAt,
+		Warnings:  warnings,
+	}
+}
+
+func validatePeerLoginExpirationWarnings(warnings []time.Duration) error {
+	if len(warnings) > maxPeerLoginExpirationWarnings {
+		return status.Errorf(status.InvalidArgument, "at most %d peer login expiration warnings can be set", maxPeerLoginExpirationWarnings)
+	}
+	for _, warning := range warnings {
+		if warning < minPeerLoginExpirationWarning {
+			return status.Errorf(status.InvalidArgument, "peer login expiration warning %s must be at least one minute", warning)
+		}
+	}
+	return nil
+}
+
+// toLoginExpirationConfig returns the login expiration pushed to the peer, nil when its login never expires
+func toLoginExpirationConfig(settings LoginExpirationSettings) *proto.LoginExpirationConfig {
+	if settings.ExpiresAt.IsZero() {
+		return nil
+	}
+
+	warnings := make([]*durationpb.Duration, 0, len(settings.Warnings))
+	for _, warning := range settings.Warnings {
+		warnings = append(warnings, durationpb.New(warning))
+	}
+	return &proto.LoginExpirationConfig{
+		ExpiresAt: timestamppb.New(settings.ExpiresAt),
+		Warnings:  warnings,
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+func TestAccount_getPeerLoginExpiration(t *testing.T) {
+	lastLogin := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	account := newAccountWithId("test_account", userID, "")
+	account.Settings.PeerLoginExpirationEnabled = true
+	account.Settings.PeerLoginExpiration = 48 * time.Hour
+
+	ssoPeer := &nbpeer.Peer{ID: "sso", UserID: userID, LoginExpirationEnabled: true, LastLogin: lastLogin}
+	setupKeyPeer := &nbpeer.Peer{ID: "setupKey", LoginExpirationEnabled: true, LastLogin: lastLogin}
+	disabledPeer := &nbpeer.Peer{ID: "disabled", UserID: userID, LastLogin: lastLogin}
+
+	expiration := account.getPeerLoginExpiration(ssoPeer)
+	assert.Equal(t, lastLogin.Add(48*time.Hour), expiration.ExpiresAt)
+	assert.Equal(t, DefaultPeerLoginExpirationWarnings, expiration.Warnings)
+
+	config := toLoginExpirationConfig(expiration)
+	require.NotNil(t, config)
+	assert.Equal(t, lastLogin.Add(48*time.Hour), config.GetExpiresAt().AsTime())
+	require.Len(t, config.GetWarnings(), 2)
+	assert.Equal(t, 24*time.Hour, config.GetWarnings()[0].AsDuration())
+	assert.Equal(t, time.Hour, config.GetWarnings()[1].AsDuration())
+
+	assert.Equal(t, LoginExpirationSettings{}, account.getPeerLoginExpiration(setupKeyPeer),
+		"peers added with a setup key should never expire")
+	assert.Equal(t, LoginExpirationSettings{}, account.getPeerLoginExpiration(disabledPeer),
+		"peers with login expiration disabled should never expire")
+	assert.Nil(t, toLoginExpirationConfig(account.getPeerLoginExpiration(disabledPeer)))
+
+	account.Settings.PeerLoginExpiration = 12 * time.Hour
+	assert.Equal(t, []time.Duration{time.Hour}, account.getPeerLoginExpiration(ssoPeer).Warnings,
+		"warnings not shorter than the login expiration should be left out")
+
+	account.Settings.PeerLoginExpirationEnabled = false
+	assert.Equal(t, LoginExpirationSettings{}, account.getPeerLoginExpiration(ssoPeer),
+		"peers should never expire when the account disables login expiration")
+}
+
+func TestDefaultAccountManager_UpdateAccountSettings_PeerLoginExpirationWarnings(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account, err := manager.GetOrCreateAccountByUser(userID, "")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultPeerLoginExpirationWarnings, account.Settings.PeerLoginExpirationWarnings)
+
+	settings := account.Settings.Copy()
+	settings.PeerLoginExpiration = 24 * time.Hour
+	settings.PeerLoginExpirationWarnings = []time.Duration{30 * time.Second}
+	_, err = manager.UpdateAccountSettings(account.Id, userID, settings)
+	require.Error(t, err, "a warning shorter than a minute should be rejected")
+
+	settings.PeerLoginExpirationWarnings = []time.Duration{time.Hour, 2 * time.Hour, 3 * time.Hour, 4 * time.Hour, 5 * time.Hour, 6 * time.Hour}
+	_, err = manager.UpdateAccountSettings(account.Id, userID, settings)
+	require.Error(t, err, "too many warnings should be rejected")
+
+	settings.PeerLoginExpirationWarnings = []time.Duration{12 * time.Hour, 30 * time.Minute}
+	updated, err := manager.UpdateAccountSettings(account.Id, userID, settings)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{12 * time.Hour, 30 * time.Minute}, updated.Settings.PeerLoginExpirationWarnings)
+
+	ev := getEvent(t, account.Id, manager, activity.AccountPeerLoginExpirationWarningsUpdated)
+	assert.Equal(t, []string{"12h0m0s", "30m0s"}, ev.Meta["warnings"])
+}
@@ -2,12 +2,14 @@ package mock_server
 
 import (
 	"net"
+	"net/netip"
 	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	nbdns "github.com/netbirdio/netbird/dns"
+	"github.com/netbirdio/netbird/management/proto"
 	"github.com/netbirdio/netbird/management/server"
 	"github.com/netbirdio/netbird/management/server/activity"
 	"github.com/netbirdio/netbird/management/server/group"
@@ -31,10 +33,14 @@ type MockAccountManager struct {
 	SyncAndMarkPeerFunc                 func(peerPubKey string, realIP net.IP) (*nbpeer.Peer, *server.NetworkMap, error)
 	DeletePeerFunc                      func(accountID, peerKey, userID string) error
 	GetNetworkMapFunc                   func(peerKey string) (*server.NetworkMap, error)
+	ResyncAccountPeersFunc              func(accountID, userID string) error
 	GetPeerNetworkFunc                  func(peerKey string) (*server.Network, error)
 	AddPeerFunc                         func(setupKey string, userId string, peer *nbpeer.Peer) (*nbpeer.Peer, *server.NetworkMap, error)
 	GetGroupFunc                        func(accountID, groupID, userID string) (*group.Group, error)
 	GetAllGroupsFunc                    func(accountID, userID string) ([]*group.Group, error)
+	GetGroupsForUserFunc                func(accountID, userID string) ([]*group.Group, map[string]*nbpeer.Peer, error)
+	GetGroupsSummaryForUserFunc         func(accountID, userID string) ([]*group.Group, error)
+	GetGroupPeersForUserFunc            func(accountID, userID, groupID string, offset, limit int) ([]*nbpeer.Peer, int, error)
 	GetGroupByNameFunc                  func(accountID, groupName string) (*group.Group, error)
 	SaveGroupFunc                       func(accountID, userID string, group *group.Group) error
 	DeleteGroupFunc                     func(accountID, userId, groupID string) error
@@ -46,19 +52,47 @@ type MockAccountManager struct {
 	SavePolicyFunc                      func(accountID, userID string, policy *server.Policy) error
 	DeletePolicyFunc                    func(accountID, policyID, userID string) error
 	ListPoliciesFunc                    func(accountID, userID string) ([]*server.Policy, error)
+	GetPoliciesForUserFunc              func(accountID, userID string) ([]*server.Policy, map[string]*group.Group, error)
 	GetUsersFromAccountFunc             func(accountID, userID string) ([]*server.UserInfo, error)
 	GetAccountFromPATFunc               func(pat string) (*server.Account, *server.User, *server.PersonalAccessToken, error)
 	MarkPATUsedFunc                     func(pat string) error
 	UpdatePeerMetaFunc                  func(peerID string, meta nbpeer.PeerSystemMeta) error
 	UpdatePeerSSHKeyFunc                func(peerID string, sshKey string) error
+	UpdatePeerRouteConflictsFunc        func(peerPubKey string, conflicts []nbpeer.RouteConflict) error
+	UpdatePeerNATTypeFunc               func(peerPubKey string, natType string) error
+	UpdatePeerRosenpassPubKeyFunc       func(peerPubKey string, rosenpassPubKey []byte) error
+	UpdatePeerTransferStatsFunc         func(peerPubKey string, stats []nbpeer.TransferStats) error
+	GetPeerTransferStatsFunc            func(accountID, peerID, userID string) ([]nbpeer.TransferStats, error)
+	GetRelayedConnectionsFunc           func(accountID, userID string) ([]server.RelayedConnection, error)
+	CountRelayedConnectionsFunc         func() int
+	UpdatePeerDNSStatsFunc              func(peerPubKey string, stats nbpeer.DNSStats) error
+	GetPeerDNSStatsFunc                 func(accountID, peerID, userID string) (*nbpeer.DNSStats, error)
+	UpdatePeerFirewallStatsFunc         func(peerPubKey string, stats []nbpeer.FirewallStats) error
+	GetPolicyStatsFunc                  func(accountID, policyID, userID string) (*server.PolicyStats, error)
+	UpdatePeerDroppedConnectionsFunc    func(peerPubKey string, conns []nbpeer.DroppedConnection) error
+	StorePeerSSHSessionFunc             func(peerPubKey string, session nbpeer.SSHSession) error
+	StorePeerSpeedtestFunc              func(peerPubKey string, result nbpeer.SpeedtestResult) error
+	StorePeerDebugBundleFunc            func(peerPubKey string, bundle []byte) (string, error)
+	RequestPeerActionFunc               func(accountID, peerID, userID, action string) (string, error)
+	ReportPeerActionFunc                func(peerPubKey string, result server.PeerActionResult) error
+	RotatePeerKeyFunc                   func(peerPubKey, actionID, newPubKey string) error
+	RequestPeerPingFunc                 func(accountID, peerID, userID, targetPeerID string) (string, error)
+	GetPeerPingFunc                     func(accountID, peerID, userID, pingID string) (*server.PeerPing, error)
+	ResolvePeerFunc                     func(peerPubKey, target string) (*nbpeer.Peer, bool, error)
+	GetPeerDiagnosticsFunc              func(accountID, peerID, userID string) (*nbpeer.Diagnostics, error)
+	GetPeerNetworkMapDumpFunc           func(accountID, peerID, userID string) (*proto.NetworkMap, error)
 	UpdatePeerFunc                      func(accountID, userID string, peer *nbpeer.Peer) (*nbpeer.Peer, error)
-	CreateRouteFunc                     func(accountID, prefix, peer string, peerGroups []string, description string, netID route.NetID, masquerade bool, metric int, groups []string, enabled bool, userID string) (*route.Route, error)
+	CreateRouteFunc                     func(accountID, prefix string, domains []string, keepRoute bool, peer string, peerGroups []string, description string, netID route.NetID, masquerade bool, metric, weight int, groups []string, enabled bool, userID string) (*route.Route, error)
 	GetRouteFunc                        func(accountID string, routeID route.ID, userID string) (*route.Route, error)
 	SaveRouteFunc                       func(accountID string, userID string, route *route.Route) error
+	ApproveRouteFunc                    func(accountID string, routeID route.ID, userID string) (*route.Route, error)
+	ProposePeerRoutesFunc               func(peerPubKey string, networks []netip.Prefix) ([]*route.Route, error)
 	DeleteRouteFunc                     func(accountID string, routeID route.ID, userID string) error
 	ListRoutesFunc                      func(accountID, userID string) ([]*route.Route, error)
 	SaveSetupKeyFunc                    func(accountID string, key *server.SetupKey, userID string) (*server.SetupKey, error)
 	ListSetupKeysFunc                   func(accountID, userID string) ([]*server.SetupKey, error)
+	ReplaceSetupKeyFunc                 func(accountID, userID, keyID string) (*server.SetupKey, error)
+	ListStaleSetupKeysFunc              func(accountID, userID string, maxAge time.Duration) ([]*server.SetupKey, error)
 	SaveUserFunc                        func(accountID, userID string, user *server.User) (*server.UserInfo, error)
 	SaveOrAddUserFunc                   func(accountID, userID string, user *server.User, addIfNotExists bool) (*server.UserInfo, error)
 	DeleteUserFunc                      func(accountID string, initiatorUserID string, targetUserID string) error
@@ -66,18 +100,72 @@ type MockAccountManager struct {
 	DeletePATFunc                       func(accountID string, initiatorUserID string, targetUserId string, tokenID string) error
 	GetPATFunc                          func(accountID string, initiatorUserID string, targetUserId string, tokenID string) (*server.PersonalAccessToken, error)
 	GetAllPATsFunc                      func(accountID string, initiatorUserID string, targetUserId string) ([]*server.PersonalAccessToken, error)
+	GetUserNotificationsFunc            func(accountID, initiatorUserID, targetUserID string) ([]string, error)
+	UpdateUserNotificationsFunc         func(accountID, initiatorUserID, targetUserID string, kinds []string) ([]string, error)
 	GetNameServerGroupFunc              func(accountID, userID, nsGroupID string) (*nbdns.NameServerGroup, error)
-	CreateNameServerGroupFunc           func(accountID string, name, description string, nameServerList []nbdns.NameServer, groups []string, primary bool, domains []string, enabled bool, userID string, searchDomainsEnabled bool) (*nbdns.NameServerGroup, error)
+	CreateNameServerGroupFunc           func(accountID string, name, description string, nameServerList []nbdns.NameServer, groups []string, primary bool, domains []string, enabled bool, userID string, searchDomainsEnabled bool, priority int) (*nbdns.NameServerGroup, error)
 	SaveNameServerGroupFunc             func(accountID, userID string, nsGroupToSave *nbdns.NameServerGroup) error
 	DeleteNameServerGroupFunc           func(accountID, nsGroupID, userID string) error
 	ListNameServerGroupsFunc            func(accountID string, userID string) ([]*nbdns.NameServerGroup, error)
+	GetDNSRecordFunc                    func(accountID, userID, recordID string) (*nbdns.CustomRecord, error)
+	CreateDNSRecordFunc                 func(accountID, userID string, record *nbdns.CustomRecord) (*nbdns.CustomRecord, error)
+	SaveDNSRecordFunc                   func(accountID, userID string, record *nbdns.CustomRecord) error
+	DeleteDNSRecordFunc                 func(accountID, userID, recordID string) error
+	ListDNSRecordsFunc                  func(accountID, userID string) ([]*nbdns.CustomRecord, error)
+	ExportDNSZonesFunc                  func(accountID, userID string) ([]nbdns.CustomZone, error)
+	GetRelayServerFunc                  func(accountID, userID, relayID string) (*server.RelayServer, error)
+	CreateRelayServerFunc               func(accountID, userID string, relay *server.RelayServer) (*server.RelayServer, error)
+	SaveRelayServerFunc                 func(accountID, userID string, relay *server.RelayServer) error
+	DeleteRelayServerFunc               func(accountID, userID, relayID string) error
+	ListRelayServersFunc                func(accountID, userID string) ([]*server.RelayServer, error)
+	RefreshPeerRelaysFunc               func(peerID string) ([]*server.RelayServer, error)
+	GetClaimGroupMappingFunc            func(accountID, userID, mappingID string) (*server.ClaimGroupMapping, error)
+	CreateClaimGroupMappingFunc         func(accountID, userID string, mapping *server.ClaimGroupMapping) (*server.ClaimGroupMapping, error)
+	SaveClaimGroupMappingFunc           func(accountID, userID string, mapping *server.ClaimGroupMapping) error
+	DeleteClaimGroupMappingFunc         func(accountID, userID, mappingID string) error
+	ListClaimGroupMappingsFunc          func(accountID, userID string) ([]*server.ClaimGroupMapping, error)
+	GetRouteGroupFunc                   func(accountID, userID, routeGroupID string) (*server.RouteGroup, error)
+	CreateRouteGroupFunc                func(accountID, userID string, routeGroup *server.RouteGroup) (*server.RouteGroup, error)
+	SaveRouteGroupFunc                  func(accountID, userID string, routeGroup *server.RouteGroup) error
+	DeleteRouteGroupFunc                func(accountID, userID, routeGroupID string) error
+	ListRouteGroupsFunc                 func(accountID, userID string) ([]*server.RouteGroup, error)
+	GetRouteGroupMembersStatusFunc      func(accountID, userID string) (map[string][]server.RouteGroupMemberStatus, error)
+	UpdatePeerLoadFunc                  func(peerPubKey string, load nbpeer.Load) error
+	GetRoutingPeersLoadFunc             func(accountID, userID string) (map[string]nbpeer.Load, error)
+	GetPeerGeofenceFunc                 func(accountID, userID, peerID string) (*server.PeerGeofence, error)
+	RequestPeerGeofenceOverrideFunc     func(accountID, userID, peerID, reason string) (*server.PeerGeofence, error)
+	ApprovePeerGeofenceOverrideFunc     func(accountID, userID, peerID string, duration time.Duration) (*server.PeerGeofence, error)
+	RevokePeerGeofenceOverrideFunc      func(accountID, userID, peerID string) (*server.PeerGeofence, error)
+	GetExternalListFunc                 func(accountID, userID, listID string) (*server.ExternalList, error)
+	CreateExternalListFunc              func(accountID, userID string, list *server.ExternalList) (*server.ExternalList, error)
+	SaveExternalListFunc                func(accountID, userID string, list *server.ExternalList) error
+	DeleteExternalListFunc              func(accountID, userID, listID string) error
+	ListExternalListsFunc               func(accountID, userID string) ([]*server.ExternalList, error)
+	RefreshExternalListFunc             func(accountID, userID, listID string) (*server.ExternalList, error)
+	SyncUserClaimGroupsFunc             func(accountID string, claims jwtclaims.AuthorizationClaims) error
+	GetPeerQuotaUsageFunc               func(accountID, userID string) (*server.PeerQuotaUsage, error)
+	CountAPIRequestFunc                 func(claims jwtclaims.AuthorizationClaims) error
+	GetAPIUsageFunc                     func(accountID, userID string) (*server.APIUsage, error)
 	CreateUserFunc                      func(accountID, userID string, key *server.UserInfo) (*server.UserInfo, error)
 	GetAccountFromTokenFunc             func(claims jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error)
+	GetAccountIDFromTokenFunc           func(claims jwtclaims.AuthorizationClaims) (string, string, error)
 	CheckUserAccessByJWTGroupsFunc      func(claims jwtclaims.AuthorizationClaims) error
 	DeleteAccountFunc                   func(accountID, userID string) error
+	RequestAccountDeletionFunc          func(accountID, userID string) (*server.AccountDeletion, error)
+	ConfirmAccountDeletionFunc          func(accountID, userID, token string) (*server.AccountDeletion, error)
+	GetAccountDeletionFunc              func(accountID, userID string) (*server.AccountDeletion, error)
+	CancelAccountDeletionFunc           func(accountID, userID string) error
+	GetAccountExportFunc                func(accountID, userID string) (string, []byte, error)
 	GetDNSDomainFunc                    func() string
 	StoreEventFunc                      func(initiatorID, targetID, accountID string, activityID activity.ActivityDescriber, meta map[string]any)
 	GetEventsFunc                       func(accountID, userID string) ([]*activity.Event, error)
+	GetUsageReportFunc                  func(accountID, userID string, from, to time.Time, period server.UsageReportPeriod) (*server.UsageReport, error)
+	GetPeersInventoryFunc               func(accountID, userID string, filter server.InventoryFilter) ([]*nbpeer.Peer, error)
+	GetPackagesReportFunc               func(accountID, userID string) ([]*server.PackageVersionReport, error)
+	GetPeerDNSLabelsFunc                func(accountID, userID string) ([]server.PeerDNSLabel, error)
+	UpdatePeerDNSLabelFunc              func(accountID, userID, peerID, label string) (*nbpeer.Peer, error)
+	GetIPPoolFunc                       func(accountID, userID string) (*server.IPPool, error)
+	AssignPeerIPFunc                    func(accountID, userID, peerID string, ip net.IP) (*nbpeer.Peer, error)
 	GetDNSSettingsFunc                  func(accountID, userID string) (*server.DNSSettings, error)
 	SaveDNSSettingsFunc                 func(accountID, userID string, dnsSettingsToSave *server.DNSSettings) error
 	GetPeerFunc                         func(accountID, peerID, userID string) (*nbpeer.Peer, error)
@@ -133,6 +221,30 @@ func (am *MockAccountManager) GetAllGroups(accountID, userID string) ([]*group.G
 	return nil, status.Errorf(codes.Unimplemented, "method GetAllGroups is not implemented")
 }
 
+// GetGroupsForUser mock implementation of GetGroupsForUser from server.AccountManager interface
+func (am *MockAccountManager) GetGroupsForUser(accountID, userID string) ([]*group.Group, map[string]*nbpeer.Peer, error) {
+	if am.GetGroupsForUserFunc != nil {
+		return am.GetGroupsForUserFunc(accountID, userID)
+	}
+	return nil, nil, status.Errorf(codes.Unimplemented, "method GetGroupsForUser is not implemented")
+}
+
+// GetGroupsSummaryForUser mock implementation of GetGroupsSummaryForUser from server.AccountManager interface
+func (am *MockAccountManager) GetGroupsSummaryForUser(accountID, userID string) ([]*group.Group, error) {
+	if am.GetGroupsSummaryForUserFunc != nil {
+		return am.GetGroupsSummaryForUserFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetGroupsSummaryForUser is not implemented")
+}
+
+// GetGroupPeersForUser mock implementation of GetGroupPeersForUser from server.AccountManager interface
+func (am *MockAccountManager) GetGroupPeersForUser(accountID, userID, groupID string, offset, limit int) ([]*nbpeer.Peer, int, error) {
+	if am.GetGroupPeersForUserFunc != nil {
+		return am.GetGroupPeersForUserFunc(accountID, userID, groupID, offset, limit)
+	}
+	return nil, 0, status.Errorf(codes.Unimplemented, "method GetGroupPeersForUser is not implemented")
+}
+
 // GetUsersFromAccount mock implementation of GetUsersFromAccount from server.AccountManager interface
 func (am *MockAccountManager) GetUsersFromAccount(accountID string, userID string) ([]*server.UserInfo, error) {
 	if am.GetUsersFromAccountFunc != nil {
@@ -216,6 +328,46 @@ func (am *MockAccountManager) DeleteAccount(accountID, userID string) error {
 	return status.Errorf(codes.Unimplemented, "method DeleteAccount is not implemented")
 }
 
+// RequestAccountDeletion mock implementation of RequestAccountDeletion from server.AccountManager interface
+func (am *MockAccountManager) RequestAccountDeletion(accountID, userID string) (*server.AccountDeletion, error) {
+	if am.RequestAccountDeletionFunc != nil {
+		return am.RequestAccountDeletionFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method RequestAccountDeletion is not implemented")
+}
+
+// ConfirmAccountDeletion mock implementation of ConfirmAccountDeletion from server.AccountManager interface
+func (am *MockAccountManager) ConfirmAccountDeletion(accountID, userID, token string) (*server.AccountDeletion, error) {
+	if am.ConfirmAccountDeletionFunc != nil {
+		return am.ConfirmAccountDeletionFunc(accountID, userID, token)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmAccountDeletion is not implemented")
+}
+
+// GetAccountDeletion mock implementation of GetAccountDeletion from server.AccountManager interface
+func (am *MockAccountManager) GetAccountDeletion(accountID, userID string) (*server.AccountDeletion, error) {
+	if am.GetAccountDeletionFunc != nil {
+		return am.GetAccountDeletionFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccountDeletion is not implemented")
+}
+
+// CancelAccountDeletion mock implementation of CancelAccountDeletion from server.AccountManager interface
+func (am *MockAccountManager) CancelAccountDeletion(accountID, userID string) error {
+	if am.CancelAccountDeletionFunc != nil {
+		return am.CancelAccountDeletionFunc(accountID, userID)
+	}
+	return status.Errorf(codes.Unimplemented, "method CancelAccountDeletion is not implemented")
+}
+
+// GetAccountExport mock implementation of GetAccountExport from server.AccountManager interface
+func (am *MockAccountManager) GetAccountExport(accountID, userID string) (string, []byte, error) {
+	if am.GetAccountExportFunc != nil {
+		return am.GetAccountExportFunc(accountID, userID)
+	}
+	return "", nil, status.Errorf(codes.Unimplemented, "method GetAccountExport is not implemented")
+}
+
 // MarkPATUsed mock implementation of MarkPATUsed from server.AccountManager interface
 func (am *MockAccountManager) MarkPATUsed(pat string) error {
 	if am.MarkPATUsedFunc != nil {
@@ -256,6 +408,22 @@ func (am *MockAccountManager) GetAllPATs(accountID string, initiatorUserID strin
 	return nil, status.Errorf(codes.Unimplemented, "method GetAllPATs is not implemented")
 }
 
+// GetUserNotifications mock implementation of GetUserNotifications from server.AccountManager interface
+func (am *MockAccountManager) GetUserNotifications(accountID, initiatorUserID, targetUserID string) ([]string, error) {
+	if am.GetUserNotificationsFunc != nil {
+		return am.GetUserNotificationsFunc(accountID, initiatorUserID, targetUserID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserNotifications is not implemented")
+}
+
+// UpdateUserNotifications mock implementation of UpdateUserNotifications from server.AccountManager interface
+func (am *MockAccountManager) UpdateUserNotifications(accountID, initiatorUserID, targetUserID string, kinds []string) ([]string, error) {
+	if am.UpdateUserNotificationsFunc != nil {
+		return am.UpdateUserNotificationsFunc(accountID, initiatorUserID, targetUserID, kinds)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateUserNotifications is not implemented")
+}
+
 // GetNetworkMap mock implementation of GetNetworkMap from server.AccountManager interface
 func (am *MockAccountManager) GetNetworkMap(peerKey string) (*server.NetworkMap, error) {
 	if am.GetNetworkMapFunc != nil {
@@ -264,6 +432,14 @@ func (am *MockAccountManager) GetNetworkMap(peerKey string) (*server.NetworkMap,
 	return nil, status.Errorf(codes.Unimplemented, "method GetNetworkMap is not implemented")
 }
 
+// ResyncAccountPeers mock implementation of ResyncAccountPeers from server.AccountManager interface
+func (am *MockAccountManager) ResyncAccountPeers(accountID, userID string) error {
+	if am.ResyncAccountPeersFunc != nil {
+		return am.ResyncAccountPeersFunc(accountID, userID)
+	}
+	return status.Errorf(codes.Unimplemented, "method ResyncAccountPeers is not implemented")
+}
+
 // GetPeerNetwork mock implementation of GetPeerNetwork from server.AccountManager interface
 func (am *MockAccountManager) GetPeerNetwork(peerKey string) (*server.Network, error) {
 	if am.GetPeerNetworkFunc != nil {
@@ -372,6 +548,14 @@ func (am *MockAccountManager) ListPolicies(accountID, userID string) ([]*server.
 	return nil, status.Errorf(codes.Unimplemented, "method ListPolicies is not implemented")
 }
 
+// GetPoliciesForUser mock implementation of GetPoliciesForUser from server.AccountManager interface
+func (am *MockAccountManager) GetPoliciesForUser(accountID, userID string) ([]*server.Policy, map[string]*group.Group, error) {
+	if am.GetPoliciesForUserFunc != nil {
+		return am.GetPoliciesForUserFunc(accountID, userID)
+	}
+	return nil, nil, status.Errorf(codes.Unimplemented, "method GetPoliciesForUser is not implemented")
+}
+
 // UpdatePeerMeta mock implementation of UpdatePeerMeta from server.AccountManager interface
 func (am *MockAccountManager) UpdatePeerMeta(peerID string, meta nbpeer.PeerSystemMeta) error {
 	if am.UpdatePeerMetaFunc != nil {
@@ -403,6 +587,182 @@ func (am *MockAccountManager) UpdatePeerSSHKey(peerID string, sshKey string) err
 	return status.Errorf(codes.Unimplemented, "method UpdatePeerSSHKey is not implemented")
 }
 
+// UpdatePeerRouteConflicts mocks UpdatePeerRouteConflicts function of the account manager
+func (am *MockAccountManager) UpdatePeerRouteConflicts(peerPubKey string, conflicts []nbpeer.RouteConflict) error {
+	if am.UpdatePeerRouteConflictsFunc != nil {
+		return am.UpdatePeerRouteConflictsFunc(peerPubKey, conflicts)
+	}
+	return status.Errorf(codes.Unimplemented, "method UpdatePeerRouteConflicts is not implemented")
+}
+
+// UpdatePeerNATType mocks UpdatePeerNATType function of the account manager
+func (am *MockAccountManager) UpdatePeerNATType(peerPubKey string, natType string) error {
+	if am.UpdatePeerNATTypeFunc != nil {
+		return am.UpdatePeerNATTypeFunc(peerPubKey, natType)
+	}
+	return status.Errorf(codes.Unimplemented, "method UpdatePeerNATType is not implemented")
+}
+
+// UpdatePeerRosenpassPubKey mocks UpdatePeerRosenpassPubKey function of the account manager
+func (am *MockAccountManager) UpdatePeerRosenpassPubKey(peerPubKey string, rosenpassPubKey []byte) error {
+	if am.UpdatePeerRosenpassPubKeyFunc != nil {
+		return am.UpdatePeerRosenpassPubKeyFunc(peerPubKey, rosenpassPubKey)
+	}
+	return status.Errorf(codes.Unimplemented, "method UpdatePeerRosenpassPubKey is not implemented")
+}
+
+// UpdatePeerTransferStats mocks UpdatePeerTransferStats function of the account manager
+func (am *MockAccountManager) UpdatePeerTransferStats(peerPubKey string, stats []nbpeer.TransferStats) error {
+	if am.UpdatePeerTransferStatsFunc != nil {
+		return am.UpdatePeerTransferStatsFunc(peerPubKey, stats)
+	}
+	return status.Errorf(codes.Unimplemented, "method UpdatePeerTransferStats is not implemented")
+}
+
+// GetPeerTransferStats mocks GetPeerTransferStats function of the account manager
+func (am *MockAccountManager) GetPeerTransferStats(accountID, peerID, userID string) ([]nbpeer.TransferStats, error) {
+	if am.GetPeerTransferStatsFunc != nil {
+		return am.GetPeerTransferStatsFunc(accountID, peerID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetPeerTransferStats is not implemented")
+}
+
+// GetRelayedConnections mocks GetRelayedConnections function of the account manager
+func (am *MockAccountManager) GetRelayedConnections(accountID, userID string) ([]server.RelayedConnection, error) {
+	if am.GetRelayedConnectionsFunc != nil {
+		return am.GetRelayedConnectionsFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetRelayedConnections is not implemented")
+}
+
+// CountRelayedConnections mocks CountRelayedConnections function of the account manager
+func (am *MockAccountManager) CountRelayedConnections() int {
+	if am.CountRelayedConnectionsFunc != nil {
+		return am.CountRelayedConnectionsFunc()
+	}
+	return 0
+}
+
+// UpdatePeerDNSStats mocks UpdatePeerDNSStats function of the account manager
+func (am *MockAccountManager) UpdatePeerDNSStats(peerPubKey string, stats nbpeer.DNSStats) error {
+	if am.UpdatePeerDNSStatsFunc != nil {
+		return am.UpdatePeerDNSStatsFunc(peerPubKey, stats)
+	}
+	return status.Errorf(codes.Unimplemented, "method UpdatePeerDNSStats is not implemented")
+}
+
+// GetPeerDNSStats mocks GetPeerDNSStats function of the account manager
+func (am *MockAccountManager) GetPeerDNSStats(accountID, peerID, userID string) (*nbpeer.DNSStats, error) {
+	if am.GetPeerDNSStatsFunc != nil {
+		return am.GetPeerDNSStatsFunc(accountID, peerID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetPeerDNSStats is not implemented")
+}
+
+// UpdatePeerFirewallStats mocks UpdatePeerFirewallStats function of the account manager
+func (am *MockAccountManager) UpdatePeerFirewallStats(peerPubKey string, stats []nbpeer.FirewallStats) error {
+	if am.UpdatePeerFirewallStatsFunc != nil {
+		return am.UpdatePeerFirewallStatsFunc(peerPubKey, stats)
+	}
+	return status.Errorf(codes.Unimplemented, "method UpdatePeerFirewallStats is not implemented")
+}
+
+// GetPolicyStats mocks GetPolicyStats function of the account manager
+func (am *MockAccountManager) GetPolicyStats(accountID, policyID, userID string) (*server.PolicyStats, error) {
+	if am.GetPolicyStatsFunc != nil {
+		return am.GetPolicyStatsFunc(accountID, policyID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetPolicyStats is not implemented")
+}
+
+// UpdatePeerDroppedConnections mocks UpdatePeerDroppedConnections function of the account manager
+func (am *MockAccountManager) UpdatePeerDroppedConnections(peerPubKey string, conns []nbpeer.DroppedConnection) error {
+	if am.UpdatePeerDroppedConnectionsFunc != nil {
+		return am.UpdatePeerDroppedConnectionsFunc(peerPubKey, conns)
+	}
+	return status.Errorf(codes.Unimplemented, "method UpdatePeerDroppedConnections is not implemented")
+}
+
+// StorePeerSSHSession mocks StorePeerSSHSession function of the account manager
+func (am *MockAccountManager) StorePeerSSHSession(peerPubKey string, session nbpeer.SSHSession) error {
+	if am.StorePeerSSHSessionFunc != nil {
+		return am.StorePeerSSHSessionFunc(peerPubKey, session)
+	}
+	return status.Errorf(codes.Unimplemented, "method StorePeerSSHSession is not implemented")
+}
+
+// StorePeerDebugBundle mocks StorePeerDebugBundle function of the account manager
+func (am *MockAccountManager) StorePeerDebugBundle(peerPubKey string, bundle []byte) (string, error) {
+	if am.StorePeerDebugBundleFunc != nil {
+		return am.StorePeerDebugBundleFunc(peerPubKey, bundle)
+	}
+	return "", status.Errorf(codes.Unimplemented, "method StorePeerDebugBundle is not implemented")
+}
+
+// RequestPeerAction mocks RequestPeerAction function of the account manager
+func (am *MockAccountManager) RequestPeerAction(accountID, peerID, userID, action string) (string, error) {
+	if am.RequestPeerActionFunc != nil {
+		return am.RequestPeerActionFunc(accountID, peerID, userID, action)
+	}
+	return "", status.Errorf(codes.Unimplemented, "method RequestPeerAction is not implemented")
+}
+
+// ReportPeerAction mocks ReportPeerAction function of the account manager
+func (am *MockAccountManager) ReportPeerAction(peerPubKey string, result server.PeerActionResult) error {
+	if am.ReportPeerActionFunc != nil {
+		return am.ReportPeerActionFunc(peerPubKey, result)
+	}
+	return status.Errorf(codes.Unimplemented, "method ReportPeerAction is not implemented")
+}
+
+// RotatePeerKey mocks RotatePeerKey function of the account manager
+func (am *MockAccountManager) RotatePeerKey(peerPubKey, actionID, newPubKey string) error {
+	if am.RotatePeerKeyFunc != nil {
+		return am.RotatePeerKeyFunc(peerPubKey, actionID, newPubKey)
+	}
+	return status.Errorf(codes.Unimplemented, "method RotatePeerKey is not implemented")
+}
+
+// RequestPeerPing mocks RequestPeerPing function of the account manager
+func (am *MockAccountManager) RequestPeerPing(accountID, peerID, userID, targetPeerID string) (string, error) {
+	if am.RequestPeerPingFunc != nil {
+		return am.RequestPeerPingFunc(accountID, peerID, userID, targetPeerID)
+	}
+	return "", status.Errorf(codes.Unimplemented, "method RequestPeerPing is not implemented")
+}
+
+// GetPeerPing mocks GetPeerPing function of the account manager
+func (am *MockAccountManager) GetPeerPing(accountID, peerID, userID, pingID string) (*server.PeerPing, error) {
+	if am.GetPeerPingFunc != nil {
+		return am.GetPeerPingFunc(accountID, peerID, userID, pingID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetPeerPing is not implemented")
+}
+
+// ResolvePeer mocks ResolvePeer function of the account manager
+func (am *MockAccountManager) ResolvePeer(peerPubKey, target string) (*nbpeer.Peer, bool, error) {
+	if am.ResolvePeerFunc != nil {
+		return am.ResolvePeerFunc(peerPubKey, target)
+	}
+	return nil, false, status.Errorf(codes.Unimplemented, "method ResolvePeer is not implemented")
+}
+
+// GetPeerDiagnostics mocks GetPeerDiagnostics function of the account manager
+func (am *MockAccountManager) GetPeerDiagnostics(accountID, peerID, userID string) (*nbpeer.Diagnostics, error) {
+	if am.GetPeerDiagnosticsFunc != nil {
+		return am.GetPeerDiagnosticsFunc(accountID, peerID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetPeerDiagnostics is not implemented")
+}
+
+// GetPeerNetworkMapDump mocks GetPeerNetworkMapDump function of the account manager
+func (am *MockAccountManager) GetPeerNetworkMapDump(accountID, peerID, userID string) (*proto.NetworkMap, error) {
+	if am.GetPeerNetworkMapDumpFunc != nil {
+		return am.GetPeerNetworkMapDumpFunc(accountID, peerID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetPeerNetworkMapDump is not implemented")
+}
+
 // UpdatePeer mocks UpdatePeerFunc function of the account manager
 func (am *MockAccountManager) UpdatePeer(accountID, userID string, peer *nbpeer.Peer) (*nbpeer.Peer, error) {
 	if am.UpdatePeerFunc != nil {
@@ -412,9 +772,9 @@ func (am *MockAccountManager) UpdatePeer(accountID, userID string, peer *nbpeer.
 }
 
 // CreateRoute mock implementation of CreateRoute from server.AccountManager interface
-func (am *MockAccountManager) CreateRoute(accountID, prefix, peerID string, peerGroupIDs []string, description string, netID route.NetID, masquerade bool, metric int, groups []string, enabled bool, userID string) (*route.Route, error) {
+func (am *MockAccountManager) CreateRoute(accountID, prefix string, domains []string, keepRoute bool, peerID string, peerGroupIDs []string, description string, netID route.NetID, masquerade bool, metric, weight int, groups []string, enabled bool, userID string) (*route.Route, error) {
 	if am.CreateRouteFunc != nil {
-		return am.CreateRouteFunc(accountID, prefix, peerID, peerGroupIDs, description, netID, masquerade, metric, groups, enabled, userID)
+		return am.CreateRouteFunc(accountID, prefix, domains, keepRoute, peerID, peerGroupIDs, description, netID, masquerade, metric, weight, groups, enabled, userID)
 	}
 	return nil, status.Errorf(codes.Unimplemented, "method CreateRoute is not implemented")
 }
@@ -435,6 +795,22 @@ func (am *MockAccountManager) SaveRoute(accountID string, userID string, route *
 	return status.Errorf(codes.Unimplemented, "method SaveRoute is not implemented")
 }
 
+// ApproveRoute mock implementation of ApproveRoute from server.AccountManager interface
+func (am *MockAccountManager) ApproveRoute(accountID string, routeID route.ID, userID string) (*route.Route, error) {
+	if am.ApproveRouteFunc != nil {
+		return am.ApproveRouteFunc(accountID, routeID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method ApproveRoute is not implemented")
+}
+
+// ProposePeerRoutes mock implementation of ProposePeerRoutes from server.AccountManager interface
+func (am *MockAccountManager) ProposePeerRoutes(peerPubKey string, networks []netip.Prefix) ([]*route.Route, error) {
+	if am.ProposePeerRoutesFunc != nil {
+		return am.ProposePeerRoutesFunc(peerPubKey, networks)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method ProposePeerRoutes is not implemented")
+}
+
 // DeleteRoute mock implementation of DeleteRoute from server.AccountManager interface
 func (am *MockAccountManager) DeleteRoute(accountID string, routeID route.ID, userID string) error {
 	if am.DeleteRouteFunc != nil {
@@ -469,6 +845,24 @@ func (am *MockAccountManager) GetSetupKey(accountID, userID, keyID string) (*ser
 	return nil, status.Errorf(codes.Unimplemented, "method GetSetupKey is not implemented")
 }
 
+// ReplaceSetupKey mocks ReplaceSetupKey of the AccountManager interface
+func (am *MockAccountManager) ReplaceSetupKey(accountID, userID, keyID string) (*server.SetupKey, error) {
+	if am.ReplaceSetupKeyFunc != nil {
+		return am.ReplaceSetupKeyFunc(accountID, userID, keyID)
+	}
+
+	return nil, status.Errorf(codes.Unimplemented, "method ReplaceSetupKey is not implemented")
+}
+
+// ListStaleSetupKeys mocks ListStaleSetupKeys of the AccountManager interface
+func (am *MockAccountManager) ListStaleSetupKeys(accountID, userID string, maxAge time.Duration) ([]*server.SetupKey, error) {
+	if am.ListStaleSetupKeysFunc != nil {
+		return am.ListStaleSetupKeysFunc(accountID, userID, maxAge)
+	}
+
+	return nil, status.Errorf(codes.Unimplemented, "method ListStaleSetupKeys is not implemented")
+}
+
 // ListSetupKeys mocks ListSetupKeys of the AccountManager interface
 func (am *MockAccountManager) ListSetupKeys(accountID, userID string) ([]*server.SetupKey, error) {
 	if am.ListSetupKeysFunc != nil {
@@ -518,9 +912,9 @@ func (am *MockAccountManager) GetNameServerGroup(accountID, userID, nsGroupID st
 }
 
 // CreateNameServerGroup mocks CreateNameServerGroup of the AccountManager interface
-func (am *MockAccountManager) CreateNameServerGroup(accountID string, name, description string, nameServerList []nbdns.NameServer, groups []string, primary bool, domains []string, enabled bool, userID string, searchDomainsEnabled bool) (*nbdns.NameServerGroup, error) {
+func (am *MockAccountManager) CreateNameServerGroup(accountID string, name, description string, nameServerList []nbdns.NameServer, groups []string, primary bool, domains []string, enabled bool, userID string, searchDomainsEnabled bool, priority int) (*nbdns.NameServerGroup, error) {
 	if am.CreateNameServerGroupFunc != nil {
-		return am.CreateNameServerGroupFunc(accountID, name, description, nameServerList, groups, primary, domains, enabled, userID, searchDomainsEnabled)
+		return am.CreateNameServerGroupFunc(accountID, name, description, nameServerList, groups, primary, domains, enabled, userID, searchDomainsEnabled, priority)
 	}
 	return nil, nil
 }
@@ -549,6 +943,318 @@ func (am *MockAccountManager) ListNameServerGroups(accountID string, userID stri
 	return nil, nil
 }
 
+// GetDNSRecord mocks GetDNSRecord of the AccountManager interface
+func (am *MockAccountManager) GetDNSRecord(accountID, userID, recordID string) (*nbdns.CustomRecord, error) {
+	if am.GetDNSRecordFunc != nil {
+		return am.GetDNSRecordFunc(accountID, userID, recordID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetDNSRecord is not implemented")
+}
+
+// CreateDNSRecord mocks CreateDNSRecord of the AccountManager interface
+func (am *MockAccountManager) CreateDNSRecord(accountID, userID string, record *nbdns.CustomRecord) (*nbdns.CustomRecord, error) {
+	if am.CreateDNSRecordFunc != nil {
+		return am.CreateDNSRecordFunc(accountID, userID, record)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method CreateDNSRecord is not implemented")
+}
+
+// SaveDNSRecord mocks SaveDNSRecord of the AccountManager interface
+func (am *MockAccountManager) SaveDNSRecord(accountID, userID string, record *nbdns.CustomRecord) error {
+	if am.SaveDNSRecordFunc != nil {
+		return am.SaveDNSRecordFunc(accountID, userID, record)
+	}
+	return status.Errorf(codes.Unimplemented, "method SaveDNSRecord is not implemented")
+}
+
+// DeleteDNSRecord mocks DeleteDNSRecord of the AccountManager interface
+func (am *MockAccountManager) DeleteDNSRecord(accountID, userID, recordID string) error {
+	if am.DeleteDNSRecordFunc != nil {
+		return am.DeleteDNSRecordFunc(accountID, userID, recordID)
+	}
+	return status.Errorf(codes.Unimplemented, "method DeleteDNSRecord is not implemented")
+}
+
+// ListDNSRecords mocks ListDNSRecords of the AccountManager interface
+func (am *MockAccountManager) ListDNSRecords(accountID, userID string) ([]*nbdns.CustomRecord, error) {
+	if am.ListDNSRecordsFunc != nil {
+		return am.ListDNSRecordsFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method ListDNSRecords is not implemented")
+}
+
+// ExportDNSZones mocks ExportDNSZones of the AccountManager interface
+func (am *MockAccountManager) ExportDNSZones(accountID, userID string) ([]nbdns.CustomZone, error) {
+	if am.ExportDNSZonesFunc != nil {
+		return am.ExportDNSZonesFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method ExportDNSZones is not implemented")
+}
+
+// GetRelayServer mocks GetRelayServer of the AccountManager interface
+func (am *MockAccountManager) GetRelayServer(accountID, userID, relayID string) (*server.RelayServer, error) {
+	if am.GetRelayServerFunc != nil {
+		return am.GetRelayServerFunc(accountID, userID, relayID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetRelayServer is not implemented")
+}
+
+// CreateRelayServer mocks CreateRelayServer of the AccountManager interface
+func (am *MockAccountManager) CreateRelayServer(accountID, userID string, relay *server.RelayServer) (*server.RelayServer, error) {
+	if am.CreateRelayServerFunc != nil {
+		return am.CreateRelayServerFunc(accountID, userID, relay)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method CreateRelayServer is not implemented")
+}
+
+// SaveRelayServer mocks SaveRelayServer of the AccountManager interface
+func (am *MockAccountManager) SaveRelayServer(accountID, userID string, relay *server.RelayServer) error {
+	if am.SaveRelayServerFunc != nil {
+		return am.SaveRelayServerFunc(accountID, userID, relay)
+	}
+	return status.Errorf(codes.Unimplemented, "method SaveRelayServer is not implemented")
+}
+
+// DeleteRelayServer mocks DeleteRelayServer of the AccountManager interface
+func (am *MockAccountManager) DeleteRelayServer(accountID, userID, relayID string) error {
+	if am.DeleteRelayServerFunc != nil {
+		return am.DeleteRelayServerFunc(accountID, userID, relayID)
+	}
+	return status.Errorf(codes.Unimplemented, "method DeleteRelayServer is not implemented")
+}
+
+// ListRelayServers mocks ListRelayServers of the AccountManager interface
+func (am *MockAccountManager) ListRelayServers(accountID, userID string) ([]*server.RelayServer, error) {
+	if am.ListRelayServersFunc != nil {
+		return am.ListRelayServersFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method ListRelayServers is not implemented")
+}
+
+// RefreshPeerRelays mocks RefreshPeerRelays of the AccountManager interface
+func (am *MockAccountManager) RefreshPeerRelays(peerID string) ([]*server.RelayServer, error) {
+	if am.RefreshPeerRelaysFunc != nil {
+		return am.RefreshPeerRelaysFunc(peerID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method RefreshPeerRelays is not implemented")
+}
+
+// GetClaimGroupMapping mocks GetClaimGroupMapping of the AccountManager interface
+func (am *MockAccountManager) GetClaimGroupMapping(accountID, userID, mappingID string) (*server.ClaimGroupMapping, error) {
+	if am.GetClaimGroupMappingFunc != nil {
+		return am.GetClaimGroupMappingFunc(accountID, userID, mappingID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetClaimGroupMapping is not implemented")
+}
+
+// CreateClaimGroupMapping mocks CreateClaimGroupMapping of the AccountManager interface
+func (am *MockAccountManager) CreateClaimGroupMapping(accountID, userID string, mapping *server.ClaimGroupMapping) (*server.ClaimGroupMapping, error) {
+	if am.CreateClaimGroupMappingFunc != nil {
+		return am.CreateClaimGroupMappingFunc(accountID, userID, mapping)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method CreateClaimGroupMapping is not implemented")
+}
+
+// SaveClaimGroupMapping mocks SaveClaimGroupMapping of the AccountManager interface
+func (am *MockAccountManager) SaveClaimGroupMapping(accountID, userID string, mapping *server.ClaimGroupMapping) error {
+	if am.SaveClaimGroupMappingFunc != nil {
+		return am.SaveClaimGroupMappingFunc(accountID, userID, mapping)
+	}
+	return status.Errorf(codes.Unimplemented, "method SaveClaimGroupMapping is not implemented")
+}
+
+// DeleteClaimGroupMapping mocks DeleteClaimGroupMapping of the AccountManager interface
+func (am *MockAccountManager) DeleteClaimGroupMapping(accountID, userID, mappingID string) error {
+	if am.DeleteClaimGroupMappingFunc != nil {
+		return am.DeleteClaimGroupMappingFunc(accountID, userID, mappingID)
+	}
+	return status.Errorf(codes.Unimplemented, "method DeleteClaimGroupMapping is not implemented")
+}
+
+// ListClaimGroupMappings mocks ListClaimGroupMappings of the AccountManager interface
+func (am *MockAccountManager) ListClaimGroupMappings(accountID, userID string) ([]*server.ClaimGroupMapping, error) {
+	if am.ListClaimGroupMappingsFunc != nil {
+		return am.ListClaimGroupMappingsFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method ListClaimGroupMappings is not implemented")
+}
+
+// GetRouteGroup mocks GetRouteGroup of the AccountManager interface
+func (am *MockAccountManager) GetRouteGroup(accountID, userID, routeGroupID string) (*server.RouteGroup, error) {
+	if am.GetRouteGroupFunc != nil {
+		return am.GetRouteGroupFunc(accountID, userID, routeGroupID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetRouteGroup is not implemented")
+}
+
+// CreateRouteGroup mocks CreateRouteGroup of the AccountManager interface
+func (am *MockAccountManager) CreateRouteGroup(accountID, userID string, routeGroup *server.RouteGroup) (*server.RouteGroup, error) {
+	if am.CreateRouteGroupFunc != nil {
+		return am.CreateRouteGroupFunc(accountID, userID, routeGroup)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method CreateRouteGroup is not implemented")
+}
+
+// SaveRouteGroup mocks SaveRouteGroup of the AccountManager interface
+func (am *MockAccountManager) SaveRouteGroup(accountID, userID string, routeGroup *server.RouteGroup) error {
+	if am.SaveRouteGroupFunc != nil {
+		return am.SaveRouteGroupFunc(accountID, userID, routeGroup)
+	}
+	return status.Errorf(codes.Unimplemented, "method SaveRouteGroup is not implemented")
+}
+
+// DeleteRouteGroup mocks DeleteRouteGroup of the AccountManager interface
+func (am *MockAccountManager) DeleteRouteGroup(accountID, userID, routeGroupID string) error {
+	if am.DeleteRouteGroupFunc != nil {
+		return am.DeleteRouteGroupFunc(accountID, userID, routeGroupID)
+	}
+	return status.Errorf(codes.Unimplemented, "method DeleteRouteGroup is not implemented")
+}
+
+// ListRouteGroups mocks ListRouteGroups of the AccountManager interface
+func (am *MockAccountManager) ListRouteGroups(accountID, userID string) ([]*server.RouteGroup, error) {
+	if am.ListRouteGroupsFunc != nil {
+		return am.ListRouteGroupsFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method ListRouteGroups is not implemented")
+}
+
+// GetRouteGroupMembersStatus mocks GetRouteGroupMembersStatus of the AccountManager interface
+func (am *MockAccountManager) GetRouteGroupMembersStatus(accountID, userID string) (map[string][]server.RouteGroupMemberStatus, error) {
+	if am.GetRouteGroupMembersStatusFunc != nil {
+		return am.GetRouteGroupMembersStatusFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetRouteGroupMembersStatus is not implemented")
+}
+
+// UpdatePeerLoad mocks UpdatePeerLoad of the AccountManager interface
+func (am *MockAccountManager) UpdatePeerLoad(peerPubKey string, load nbpeer.Load) error {
+	if am.UpdatePeerLoadFunc != nil {
+		return am.UpdatePeerLoadFunc(peerPubKey, load)
+	}
+	return status.Errorf(codes.Unimplemented, "method UpdatePeerLoad is not implemented")
+}
+
+// GetRoutingPeersLoad mocks GetRoutingPeersLoad of the AccountManager interface
+func (am *MockAccountManager) GetRoutingPeersLoad(accountID, userID string) (map[string]nbpeer.Load, error) {
+	if am.GetRoutingPeersLoadFunc != nil {
+		return am.GetRoutingPeersLoadFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetRoutingPeersLoad is not implemented")
+}
+
+// GetPeerGeofence mocks GetPeerGeofence of the AccountManager interface
+func (am *MockAccountManager) GetPeerGeofence(accountID, userID, peerID string) (*server.PeerGeofence, error) {
+	if am.GetPeerGeofenceFunc != nil {
+		return am.GetPeerGeofenceFunc(accountID, userID, peerID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetPeerGeofence is not implemented")
+}
+
+// RequestPeerGeofenceOverride mocks RequestPeerGeofenceOverride of the AccountManager interface
+func (am *MockAccountManager) RequestPeerGeofenceOverride(accountID, userID, peerID, reason string) (*server.PeerGeofence, error) {
+	if am.RequestPeerGeofenceOverrideFunc != nil {
+		return am.RequestPeerGeofenceOverrideFunc(accountID, userID, peerID, reason)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method RequestPeerGeofenceOverride is not implemented")
+}
+
+// ApprovePeerGeofenceOverride mocks ApprovePeerGeofenceOverride of the AccountManager interface
+func (am *MockAccountManager) ApprovePeerGeofenceOverride(accountID, userID, peerID string, duration time.Duration) (*server.PeerGeofence, error) {
+	if am.ApprovePeerGeofenceOverrideFunc != nil {
+		return am.ApprovePeerGeofenceOverrideFunc(accountID, userID, peerID, duration)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method ApprovePeerGeofenceOverride is not implemented")
+}
+
+// RevokePeerGeofenceOverride mocks RevokePeerGeofenceOverride of the AccountManager interface
+func (am *MockAccountManager) RevokePeerGeofenceOverride(accountID, userID, peerID string) (*server.PeerGeofence, error) {
+	if am.RevokePeerGeofenceOverrideFunc != nil {
+		return am.RevokePeerGeofenceOverrideFunc(accountID, userID, peerID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method RevokePeerGeofenceOverride is not implemented")
+}
+
+// GetExternalList mocks GetExternalList of the AccountManager interface
+func (am *MockAccountManager) GetExternalList(accountID, userID, listID string) (*server.ExternalList, error) {
+	if am.GetExternalListFunc != nil {
+		return am.GetExternalListFunc(accountID, userID, listID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetExternalList is not implemented")
+}
+
+// CreateExternalList mocks CreateExternalList of the AccountManager interface
+func (am *MockAccountManager) CreateExternalList(accountID, userID string, list *server.ExternalList) (*server.ExternalList, error) {
+	if am.CreateExternalListFunc != nil {
+		return am.CreateExternalListFunc(accountID, userID, list)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method CreateExternalList is not implemented")
+}
+
+// SaveExternalList mocks SaveExternalList of the AccountManager interface
+func (am *MockAccountManager) SaveExternalList(accountID, userID string, list *server.ExternalList) error {
+	if am.SaveExternalListFunc != nil {
+		return am.SaveExternalListFunc(accountID, userID, list)
+	}
+	return status.Errorf(codes.Unimplemented, "method SaveExternalList is not implemented")
+}
+
+// DeleteExternalList mocks DeleteExternalList of the AccountManager interface
+func (am *MockAccountManager) DeleteExternalList(accountID, userID, listID string) error {
+	if am.DeleteExternalListFunc != nil {
+		return am.DeleteExternalListFunc(accountID, userID, listID)
+	}
+	return status.Errorf(codes.Unimplemented, "method DeleteExternalList is not implemented")
+}
+
+// ListExternalLists mocks ListExternalLists of the AccountManager interface
+func (am *MockAccountManager) ListExternalLists(accountID, userID string) ([]*server.ExternalList, error) {
+	if am.ListExternalListsFunc != nil {
+		return am.ListExternalListsFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method ListExternalLists is not implemented")
+}
+
+// RefreshExternalList mocks RefreshExternalList of the AccountManager interface
+func (am *MockAccountManager) RefreshExternalList(accountID, userID, listID string) (*server.ExternalList, error) {
+	if am.RefreshExternalListFunc != nil {
+		return am.RefreshExternalListFunc(accountID, userID, listID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method RefreshExternalList is not implemented")
+}
+
+// SyncUserClaimGroups mocks SyncUserClaimGroups of the AccountManager interface
+func (am *MockAccountManager) SyncUserClaimGroups(accountID string, claims jwtclaims.AuthorizationClaims) error {
+	if am.SyncUserClaimGroupsFunc != nil {
+		return am.SyncUserClaimGroupsFunc(accountID, claims)
+	}
+	return status.Errorf(codes.Unimplemented, "method SyncUserClaimGroups is not implemented")
+}
+
+// GetPeerQuotaUsage mocks GetPeerQuotaUsage of the AccountManager interface
+func (am *MockAccountManager) GetPeerQuotaUsage(accountID, userID string) (*server.PeerQuotaUsage, error) {
+	if am.GetPeerQuotaUsageFunc != nil {
+		return am.GetPeerQuotaUsageFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetPeerQuotaUsage is not implemented")
+}
+
+// CountAPIRequest mocks CountAPIRequest of the AccountManager interface
+func (am *MockAccountManager) CountAPIRequest(claims jwtclaims.AuthorizationClaims) error {
+	if am.CountAPIRequestFunc != nil {
+		return am.CountAPIRequestFunc(claims)
+	}
+	return status.Errorf(codes.Unimplemented, "method CountAPIRequest is not implemented")
+}
+
+// GetAPIUsage mocks GetAPIUsage of the AccountManager interface
+func (am *MockAccountManager) GetAPIUsage(accountID, userID string) (*server.APIUsage, error) {
+	if am.GetAPIUsageFunc != nil {
+		return am.GetAPIUsageFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetAPIUsage is not implemented")
+}
+
 // CreateUser mocks CreateUser of the AccountManager interface
 func (am *MockAccountManager) CreateUser(accountID, userID string, invite *server.UserInfo) (*server.UserInfo, error) {
 	if am.CreateUserFunc != nil {
@@ -567,6 +1273,14 @@ func (am *MockAccountManager) GetAccountFromToken(claims jwtclaims.Authorization
 	return nil, nil, status.Errorf(codes.Unimplemented, "method GetAccountFromToken is not implemented")
 }
 
+// GetAccountIDFromToken mocks GetAccountIDFromToken of the AccountManager interface
+func (am *MockAccountManager) GetAccountIDFromToken(claims jwtclaims.AuthorizationClaims) (string, string, error) {
+	if am.GetAccountIDFromTokenFunc != nil {
+		return am.GetAccountIDFromTokenFunc(claims)
+	}
+	return "", "", status.Errorf(codes.Unimplemented, "method GetAccountIDFromToken is not implemented")
+}
+
 func (am *MockAccountManager) CheckUserAccessByJWTGroups(claims jwtclaims.AuthorizationClaims) error {
 	if am.CheckUserAccessByJWTGroupsFunc != nil {
 		return am.CheckUserAccessByJWTGroupsFunc(claims)
@@ -598,6 +1312,62 @@ func (am *MockAccountManager) GetEvents(accountID, userID string) ([]*activity.E
 	return nil, status.Errorf(codes.Unimplemented, "method GetEvents is not implemented")
 }
 
+// GetUsageReport mocks GetUsageReport of the AccountManager interface
+func (am *MockAccountManager) GetUsageReport(accountID, userID string, from, to time.Time, period server.UsageReportPeriod) (*server.UsageReport, error) {
+	if am.GetUsageReportFunc != nil {
+		return am.GetUsageReportFunc(accountID, userID, from, to, period)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsageReport is not implemented")
+}
+
+// GetPeersInventory mocks GetPeersInventory of the AccountManager interface
+func (am *MockAccountManager) GetPeersInventory(accountID, userID string, filter server.InventoryFilter) ([]*nbpeer.Peer, error) {
+	if am.GetPeersInventoryFunc != nil {
+		return am.GetPeersInventoryFunc(accountID, userID, filter)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetPeersInventory is not implemented")
+}
+
+// GetPackagesReport mocks GetPackagesReport of the AccountManager interface
+func (am *MockAccountManager) GetPackagesReport(accountID, userID string) ([]*server.PackageVersionReport, error) {
+	if am.GetPackagesReportFunc != nil {
+		return am.GetPackagesReportFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetPackagesReport is not implemented")
+}
+
+// GetPeerDNSLabels mocks GetPeerDNSLabels of the AccountManager interface
+func (am *MockAccountManager) GetPeerDNSLabels(accountID, userID string) ([]server.PeerDNSLabel, error) {
+	if am.GetPeerDNSLabelsFunc != nil {
+		return am.GetPeerDNSLabelsFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetPeerDNSLabels is not implemented")
+}
+
+// UpdatePeerDNSLabel mocks UpdatePeerDNSLabel of the AccountManager interface
+func (am *MockAccountManager) UpdatePeerDNSLabel(accountID, userID, peerID, label string) (*nbpeer.Peer, error) {
+	if am.UpdatePeerDNSLabelFunc != nil {
+		return am.UpdatePeerDNSLabelFunc(accountID, userID, peerID, label)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePeerDNSLabel is not implemented")
+}
+
+// GetIPPool mocks GetIPPool of the AccountManager interface
+func (am *MockAccountManager) GetIPPool(accountID, userID string) (*server.IPPool, error) {
+	if am.GetIPPoolFunc != nil {
+		return am.GetIPPoolFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetIPPool is not implemented")
+}
+
+// AssignPeerIP mocks AssignPeerIP of the AccountManager interface
+func (am *MockAccountManager) AssignPeerIP(accountID, userID, peerID string, ip net.IP) (*nbpeer.Peer, error) {
+	if am.AssignPeerIPFunc != nil {
+		return am.AssignPeerIPFunc(accountID, userID, peerID, ip)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method AssignPeerIP is not implemented")
+}
+
 // GetDNSSettings mocks GetDNSSettings of the AccountManager interface
 func (am *MockAccountManager) GetDNSSettings(accountID string, userID string) (*server.DNSSettings, error) {
 	if am.GetDNSSettingsFunc != nil {
@@ -734,3 +1504,11 @@ func (am *MockAccountManager) GroupValidation(accountId string, groups []string)
 	}
 	return false, status.Errorf(codes.Unimplemented, "method GroupValidation is not implemented")
 }
+
+// StorePeerSpeedtest mocks StorePeerSpeedtest function of the account manager
+func (am *MockAccountManager) StorePeerSpeedtest(peerPubKey string, result nbpeer.SpeedtestResult) error {
+	if am.StorePeerSpeedtestFunc != nil {
+		return am.StorePeerSpeedtestFunc(peerPubKey, result)
+	}
+	return status.Errorf(codes.Unimplemented, "method StorePeerSpeedtest is not implemented")
+}
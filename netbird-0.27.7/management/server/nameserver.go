@@ -1,8 +1,11 @@
 package server
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"regexp"
+	"strings"
 	"unicode/utf8"
 
 	"github.com/miekg/dns"
@@ -45,7 +48,7 @@ func (am *DefaultAccountManager) GetNameServerGroup(accountID, userID, nsGroupID
 }
 
 // CreateNameServerGroup creates and saves a new nameserver group
-func (am *DefaultAccountManager) CreateNameServerGroup(accountID string, name, description string, nameServerList []nbdns.NameServer, groups []string, primary bool, domains []string, enabled bool, userID string, searchDomainEnabled bool) (*nbdns.NameServerGroup, error) {
+func (am *DefaultAccountManager) CreateNameServerGroup(accountID string, name, description string, nameServerList []nbdns.NameServer, groups []string, primary bool, domains []string, enabled bool, userID string, searchDomainEnabled bool, priority int) (*nbdns.NameServerGroup, error) {
 
 	unlock := am.Store.AcquireAccountWriteLock(accountID)
 	defer unlock()
@@ -65,6 +68,7 @@ func (am *DefaultAccountManager) CreateNameServerGroup(accountID string, name, d
 		Primary:              primary,
 		Domains:              domains,
 		SearchDomainsEnabled: searchDomainEnabled,
+		Priority:             priority,
 	}
 
 	err = validateNameServerGroup(false, newNSGroup, account)
@@ -209,6 +213,10 @@ func validateNameServerGroup(existingGroup bool, nameserverGroup *nbdns.NameServ
 		return err
 	}
 
+	if nameserverGroup.Priority < 0 || nameserverGroup.Priority > nbdns.MaxNameServerGroupPriority {
+		return status.Errorf(status.InvalidArgument, "nameserver group priority should be between 0 and %d", nbdns.MaxNameServerGroupPriority)
+	}
+
 	err = validateGroups(nameserverGroup.Groups, account.Groups)
 	if err != nil {
 		return err
@@ -218,11 +226,28 @@ func validateNameServerGroup(existingGroup bool, nameserverGroup *nbdns.NameServ
 }
 
 func validateDomainInput(primary bool, domains []string, searchDomainsEnabled bool) error {
-	if !primary && len(domains) == 0 {
+	var matchDomains, negatedDomains []nbdns.MatchDomain
+	for _, domain := range domains {
+		match := nbdns.ParseMatchDomain(domain)
+		if err := validateDomain(match.Zone); err != nil {
+			return status.Errorf(status.InvalidArgument, "nameserver group got an invalid domain: %s %q", domain, err)
+		}
+		if match.Negated && match.Wildcard {
+			return status.Errorf(status.InvalidArgument, "nameserver group got an invalid domain: %s, "+
+				"a negated domain excludes its subdomains already and can't be a wildcard", domain)
+		}
+		if match.Negated {
+			negatedDomains = append(negatedDomains, match)
+			continue
+		}
+		matchDomains = append(matchDomains, match)
+	}
+
+	if !primary && len(matchDomains) == 0 {
 		return status.Errorf(status.InvalidArgument, "nameserver group primary status is false and domains are empty,"+
 			" it should be primary or have at least one domain")
 	}
-	if primary && len(domains) != 0 {
+	if primary && len(matchDomains) != 0 {
 		return status.Errorf(status.InvalidArgument, "nameserver group primary status is true and domains are not empty,"+
 			" you should set either primary or domain")
 	}
@@ -232,14 +257,30 @@ func validateDomainInput(primary bool, domains []string, searchDomainsEnabled bo
 			" you should not set search domains for primary nameservers")
 	}
 
-	for _, domain := range domains {
-		if err := validateDomain(domain); err != nil {
-			return status.Errorf(status.InvalidArgument, "nameserver group got an invalid domain: %s %q", domain, err)
+	if primary {
+		return nil
+	}
+
+	for _, negated := range negatedDomains {
+		if !isUnderMatchDomain(negated.Zone, matchDomains) {
+			return status.Errorf(status.InvalidArgument, "nameserver group negated domain %s%s should be a subdomain of one of the group domains",
+				nbdns.NegatedDomainPrefix, negated.Zone)
 		}
 	}
+
 	return nil
 }
 
+// isUnderMatchDomain checks if the domain is a strict subdomain of one of the match domains
+func isUnderMatchDomain(domain string, matchDomains []nbdns.MatchDomain) bool {
+	for _, match := range matchDomains {
+		if dns.IsSubDomain(match.Zone, domain) && dns.CountLabel(domain) > dns.CountLabel(match.Zone) {
+			return true
+		}
+	}
+	return false
+}
+
 func validateNSGroupName(name, nsGroupID string, nsGroupMap map[string]*nbdns.NameServerGroup) error {
 	if utf8.RuneCountInString(name) > nbdns.MaxGroupNameChar || name == "" {
 		return status.Errorf(status.InvalidArgument, "nameserver group name should be between 1 and %d", nbdns.MaxGroupNameChar)
@@ -259,6 +300,54 @@ func validateNSList(list []nbdns.NameServer) error {
 	if nsListLenght == 0 || nsListLenght > 3 {
 		return status.Errorf(status.InvalidArgument, "the list of nameservers should be 1 or 3, got %d", len(list))
 	}
+
+	for i := range list {
+		if err := validateNSEncryption(&list[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateNSEncryption checks the DNS over TLS and DNS over HTTPS settings of a nameserver
+// and sets the default DNS over HTTPS path
+func validateNSEncryption(ns *nbdns.NameServer) error {
+	if !ns.IsEncrypted() {
+		if ns.ServerName != "" || len(ns.SPKIPins) != 0 || ns.Path != "" {
+			return status.Errorf(status.InvalidArgument, "nameserver %s: server name, SPKI pins and path are supported "+
+				"only for the %s and %s nameserver types", ns.IP, nbdns.TLSNameServerTypeString, nbdns.HTTPSNameServerTypeString)
+		}
+		return nil
+	}
+
+	if ns.ServerName != "" {
+		if _, valid := dns.IsDomainName(ns.ServerName); !valid || strings.HasSuffix(ns.ServerName, ".") {
+			return status.Errorf(status.InvalidArgument, "nameserver %s: invalid server name %s", ns.IP, ns.ServerName)
+		}
+	}
+
+	for _, pin := range ns.SPKIPins {
+		decoded, err := base64.StdEncoding.DecodeString(pin)
+		if err != nil || len(decoded) != sha256.Size {
+			return status.Errorf(status.InvalidArgument, "nameserver %s: SPKI pin %s should be a base64 encoded SHA-256 hash", ns.IP, pin)
+		}
+	}
+
+	if ns.NSType == nbdns.TLSNameServerType {
+		if ns.Path != "" {
+			return status.Errorf(status.InvalidArgument, "nameserver %s: path is supported only for the %s nameserver type", ns.IP, nbdns.HTTPSNameServerTypeString)
+		}
+		return nil
+	}
+
+	if ns.Path == "" {
+		ns.Path = nbdns.DefaultDoHPath
+	}
+	if !strings.HasPrefix(ns.Path, "/") || strings.ContainsAny(ns.Path, "?# ") {
+		return status.Errorf(status.InvalidArgument, "nameserver %s: invalid DNS over HTTPS path %s", ns.IP, ns.Path)
+	}
+
 	return nil
 }
 
@@ -34,6 +34,7 @@ func TestCreateNameServerGroup(t *testing.T) {
 		primary       bool
 		domains       []string
 		searchDomains bool
+		priority      int
 	}
 
 	testCases := []struct {
@@ -369,6 +370,26 @@ func TestCreateNameServerGroup(t *testing.T) {
 			errFunc:      require.Error,
 			shouldCreate: false,
 		},
+		{
+			name: "Should Not Create If Priority Is Out Of Range",
+			inputArgs: input{
+				name:        "super",
+				description: "super",
+				groups:      []string{group1ID},
+				domains:     []string{validDomain},
+				nameServers: []nbdns.NameServer{
+					{
+						IP:     netip.MustParseAddr("1.1.1.1"),
+						NSType: nbdns.UDPNameServerType,
+						Port:   nbdns.DefaultDNSPort,
+					},
+				},
+				enabled:  true,
+				priority: nbdns.MaxNameServerGroupPriority + 1,
+			},
+			errFunc:      require.Error,
+			shouldCreate: false,
+		},
 	}
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
@@ -393,6 +414,7 @@ func TestCreateNameServerGroup(t *testing.T) {
 				testCase.inputArgs.enabled,
 				userID,
 				testCase.inputArgs.searchDomains,
+				testCase.inputArgs.priority,
 			)
 
 			testCase.errFunc(t, err)
@@ -927,3 +949,134 @@ func TestValidateDomain(t *testing.T) {
 	}
 
 }
+
+func TestValidateDomainInput(t *testing.T) {
+	testCases := []struct {
+		name          string
+		primary       bool
+		domains       []string
+		searchDomains bool
+		errFunc       require.ErrorAssertionFunc
+	}{
+		{
+			name:    "Wildcard domain",
+			domains: []string{"*.corp.example.com"},
+			errFunc: require.NoError,
+		},
+		{
+			name:          "Negated subdomain of a match domain",
+			domains:       []string{"corp.example.com", "!public.corp.example.com"},
+			searchDomains: true,
+			errFunc:       require.NoError,
+		},
+		{
+			name:    "Negated subdomain of a wildcard domain",
+			domains: []string{"*.corp.example.com", "!public.corp.example.com"},
+			errFunc: require.NoError,
+		},
+		{
+			name:    "Primary with negated domains",
+			primary: true,
+			domains: []string{"!corp.example.com"},
+			errFunc: require.NoError,
+		},
+		{
+			name:    "Primary with a wildcard domain",
+			primary: true,
+			domains: []string{"*.corp.example.com"},
+			errFunc: require.Error,
+		},
+		{
+			name:    "Only negated domains",
+			domains: []string{"!corp.example.com"},
+			errFunc: require.Error,
+		},
+		{
+			name:    "Negated domain outside of the match domains",
+			domains: []string{"corp.example.com", "!example.org"},
+			errFunc: require.Error,
+		},
+		{
+			name:    "Negated match domain",
+			domains: []string{"corp.example.com", "!corp.example.com"},
+			errFunc: require.Error,
+		},
+		{
+			name:    "Negated wildcard domain",
+			domains: []string{"corp.example.com", "!*.public.corp.example.com"},
+			errFunc: require.Error,
+		},
+		{
+			name:    "Invalid wildcard position",
+			domains: []string{"corp.*.example.com"},
+			errFunc: require.Error,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.errFunc(t, validateDomainInput(testCase.primary, testCase.domains, testCase.searchDomains))
+		})
+	}
+}
+
+func TestValidateNSEncryption(t *testing.T) {
+	validPin := "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+	testCases := []struct {
+		name         string
+		nameServer   nbdns.NameServer
+		errFunc      require.ErrorAssertionFunc
+		expectedPath string
+	}{
+		{
+			name:       "Plain nameserver",
+			nameServer: nbdns.NameServer{NSType: nbdns.UDPNameServerType},
+			errFunc:    require.NoError,
+		},
+		{
+			name:       "Plain nameserver with a server name",
+			nameServer: nbdns.NameServer{NSType: nbdns.UDPNameServerType, ServerName: "dns.example.com"},
+			errFunc:    require.Error,
+		},
+		{
+			name:       "DNS over TLS with pins",
+			nameServer: nbdns.NameServer{NSType: nbdns.TLSNameServerType, ServerName: "dns.example.com", SPKIPins: []string{validPin}},
+			errFunc:    require.NoError,
+		},
+		{
+			name:       "DNS over TLS with a path",
+			nameServer: nbdns.NameServer{NSType: nbdns.TLSNameServerType, Path: "/dns-query"},
+			errFunc:    require.Error,
+		},
+		{
+			name:       "Invalid pin",
+			nameServer: nbdns.NameServer{NSType: nbdns.TLSNameServerType, SPKIPins: []string{"c2hvcnQ="}},
+			errFunc:    require.Error,
+		},
+		{
+			name:       "Invalid server name",
+			nameServer: nbdns.NameServer{NSType: nbdns.TLSNameServerType, ServerName: "dns..example.com"},
+			errFunc:    require.Error,
+		},
+		{
+			name:         "DNS over HTTPS with the default path",
+			nameServer:   nbdns.NameServer{NSType: nbdns.HTTPSNameServerType, ServerName: "dns.example.com"},
+			errFunc:      require.NoError,
+			expectedPath: nbdns.DefaultDoHPath,
+		},
+		{
+			name:       "DNS over HTTPS with a query in the path",
+			nameServer: nbdns.NameServer{NSType: nbdns.HTTPSNameServerType, Path: "/dns-query?dns=1"},
+			errFunc:    require.Error,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.errFunc(t, validateNSEncryption(&testCase.nameServer))
+			if testCase.expectedPath != "" {
+				require.Equal(t, testCase.expectedPath, testCase.nameServer.Path)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"math/rand"
 	"net"
+	"net/netip"
 	"sync"
 	"time"
 
@@ -32,6 +33,37 @@ type NetworkMap struct {
 	DNSConfig     nbdns.Config
 	OfflinePeers  []*nbpeer.Peer
 	FirewallRules []*FirewallRule
+	// PresharedKeys holds the WireGuard preshared keys of the connections keyed by the WireGuard key of the remote peer
+	PresharedKeys map[string]string
+	// MTU is the MTU of the WireGuard interface pushed to the peer, 0 if the peer keeps its default
+	MTU int
+	// Relays are the relay servers of the account selected for the peer
+	Relays []*RelayServer
+	// DirectOnlyPeers holds the WireGuard keys of the remote peers the connections must not be relayed to
+	DirectOnlyPeers map[string]struct{}
+	// KeepWarmPeers holds the WireGuard keys of the remote peers the connections must be kept established and ready to
+	KeepWarmPeers map[string]struct{}
+	// ICE holds the ICE settings of the account pushed to the peer
+	ICE ICESettings
+	// Rosenpass holds the Rosenpass settings of the account pushed to the peer
+	Rosenpass RosenpassSettings
+	// FeatureFlags holds the client behaviors switched for the peer by the account and its groups
+	FeatureFlags map[string]bool
+	// ClientUpdate holds the client version the groups of the peer set for its auto update
+	ClientUpdate ClientUpdateSettings
+	// LoginExpiration holds the login expiration of the peer and the warnings of the account pushed to the peer
+	LoginExpiration LoginExpirationSettings
+	// SSHEnabled is true when a policy enables the SSH server of the peer
+	SSHEnabled bool
+	// SSHAuthorizedPeers holds the WireGuard keys of the remote peers allowed to log in to the SSH server of the peer,
+	// nil allows all of them
+	SSHAuthorizedPeers map[string]struct{}
+	// ControlPlane lists the parts of the host the peer manages, empty manages all of them
+	ControlPlane []string
+	// SpeedtestPeers holds the WireGuard keys of the remote peers allowed to run throughput tests against the peer
+	SpeedtestPeers map[string]struct{}
+	// BlockedNetworks are the networks of the external lists the peer drops the traffic sent through the interface to
+	BlockedNetworks []netip.Prefix
 }
 
 type Network struct {
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/notification"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	// setupKeyExpiryWarning is how long before the expiry of a setup key the users are notified
+	setupKeyExpiryWarning = 7 * 24 * time.Hour
+	// setupKeyExpiryCheckInterval is how often the setup keys expiring soon are looked up
+	setupKeyExpiryCheckInterval = time.Hour
+)
+
+// EnableNotifications makes the account manager send the email notifications with the notifier and checks the setup
+// keys expiring soon until the context is done. Only the leader checks the setup keys when the instances elect one
+func (am *DefaultAccountManager) EnableNotifications(ctx context.Context, notifier *notification.Notifier) {
+	am.notifier = notifier
+
+	go func() {
+		ticker := time.NewTicker(setupKeyExpiryCheckInterval)
+		defer ticker.Stop()
+		for {
+			if am.leaderElection.IsLeader() {
+				am.notifyExpiringSetupKeys()
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// notify sends the notification to the users of the account subscribed to its kind. The regular users are only
+// notified about the peers they own, the users with admin power about all of them. The email addresses are looked up
+// in the IdP in the background so that the caller holding the account lock isn't delayed
+func (am *DefaultAccountManager) notify(account *Account, kind notification.Kind, ownerID string, data notification.Data) {
+	if am.notifier == nil || isNil(am.idpManager) {
+		return
+	}
+
+	recipients := make(map[string]struct{})
+	for _, user := range account.Users {
+		if user.IsServiceUser || user.IsBlocked() || !slices.Contains(user.EmailNotifications, string(kind)) {
+			continue
+		}
+		if !user.HasAdminPower() && (ownerID == "" || user.Id != ownerID) {
+			continue
+		}
+		recipients[user.Id] = struct{}{}
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	data.AccountID = account.Id
+	go func() {
+		usersData, err := am.getAccountFromCache(data.AccountID, false)
+		if err != nil {
+			log.Errorf("failed to look up the users of account %s to notify: %v", data.AccountID, err)
+			return
+		}
+
+		var emails []string
+		for _, userData := range usersData {
+			if _, ok := recipients[userData.ID]; ok && userData.Email != "" {
+				emails = append(emails, userData.Email)
+			}
+			if userData.ID == ownerID && data.UserEmail == "" {
+				data.UserEmail = userData.Email
+			}
+		}
+		am.notifier.Notify(kind, emails, data)
+	}()
+}
+
+// notifyPeer sends the notification about the peer to the users of the account subscribed to its kind
+func (am *DefaultAccountManager) notifyPeer(account *Account, kind notification.Kind, peer *nbpeer.Peer) {
+	am.notify(account, kind, peer.UserID, notification.Data{
+		PeerName: peer.Name,
+		PeerIP:   peer.IP.String(),
+	})
+}
+
+// notifyExpiringSetupKeys notifies the users about the valid setup keys expiring within setupKeyExpiryWarning, once
+// per key
+func (am *DefaultAccountManager) notifyExpiringSetupKeys() {
+	for _, account := range am.Store.GetAllAccounts() {
+		expiring := false
+		for _, key := range account.SetupKeys {
+			if key.IsValid() && !key.ExpiryNotified && time.Until(key.ExpiresAt) < setupKeyExpiryWarning {
+				expiring = true
+				break
+			}
+		}
+		if expiring {
+			am.notifyAccountExpiringSetupKeys(account.Id)
+		}
+	}
+}
+
+func (am *DefaultAccountManager) notifyAccountExpiringSetupKeys(accountID string) {
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		log.Errorf("failed getting account %s to notify its expiring setup keys: %v", accountID, err)
+		return
+	}
+
+	notified := false
+	for _, key := range account.SetupKeys {
+		if !key.IsValid() || key.ExpiryNotified || time.Until(key.ExpiresAt) >= setupKeyExpiryWarning {
+			continue
+		}
+		am.notify(account, notification.KindSetupKeyExpiring, "", notification.Data{
+			SetupKeyName:      key.Name,
+			SetupKeyExpiresAt: key.ExpiresAt,
+		})
+		key.ExpiryNotified = true
+		notified = true
+	}
+
+	if notified {
+		if err := am.Store.SaveAccount(account); err != nil {
+			log.Errorf("failed saving the notified setup keys of account %s: %v", accountID, err)
+		}
+	}
+}
+
+// GetUserNotifications returns the kinds of email notifications the target user receives. Users can get their own
+// notifications, users with admin power those of all the users
+func (am *DefaultAccountManager) GetUserNotifications(accountID, initiatorUserID, targetUserID string) ([]string, error) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	targetUser, err := getNotificationsTargetUser(account, initiatorUserID, targetUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if targetUser.EmailNotifications == nil {
+		return []string{}, nil
+	}
+	return targetUser.EmailNotifications, nil
+}
+
+// UpdateUserNotifications replaces the kinds of email notifications the target user receives. Users can update
+// their own notifications, users with admin power those of all the users
+func (am *DefaultAccountManager) UpdateUserNotifications(accountID, initiatorUserID, targetUserID string, kinds []string) ([]string, error) {
+	if err := notification.ValidateKinds(kinds); err != nil {
+		return nil, status.Errorf(status.InvalidArgument, "%s", err)
+	}
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	targetUser, err := getNotificationsTargetUser(account, initiatorUserID, targetUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	kinds = slices.Clone(kinds)
+	slices.Sort(kinds)
+	kinds = slices.Compact(kinds)
+	if slices.Equal(targetUser.EmailNotifications, kinds) {
+		return kinds, nil
+	}
+
+	targetUser.EmailNotifications = kinds
+	if err := am.Store.SaveAccount(account); err != nil {
+		return nil, err
+	}
+
+	am.StoreEvent(initiatorUserID, targetUserID, accountID, activity.UserNotificationsUpdated,
+		map[string]any{"notifications": kinds})
+
+	return kinds, nil
+}
+
+func getNotificationsTargetUser(account *Account, initiatorUserID, targetUserID string) (*User, error) {
+	initiatorUser, err := account.FindUser(initiatorUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	targetUser, err := account.FindUser(targetUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if initiatorUserID != targetUserID && !initiatorUser.HasAdminPower() {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can manage the notifications of other users")
+	}
+	if targetUser.IsServiceUser {
+		return nil, status.Errorf(status.InvalidArgument, "service users don't receive notifications")
+	}
+	return targetUser, nil
+}
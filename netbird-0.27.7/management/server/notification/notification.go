@@ -0,0 +1,85 @@
+package notification
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Kind is a type of email notification the users can subscribe to
+type Kind string
+
+const (
+	// KindPeerApprovalPending is sent when a new peer waits for the approval of an admin
+	KindPeerApprovalPending Kind = "peer_approval_pending"
+	// KindPeerLoginExpired is sent when the login of a peer expired and its user has to log in again
+	KindPeerLoginExpired Kind = "peer_login_expired"
+	// KindSetupKeyExpiring is sent when a setup key expires soon
+	KindSetupKeyExpiring Kind = "setup_key_expiring"
+	// KindUserInvited is sent when a user is invited to the account
+	KindUserInvited Kind = "user_invited"
+)
+
+// Kinds are all the kinds of notifications
+var Kinds = []Kind{KindPeerApprovalPending, KindPeerLoginExpired, KindSetupKeyExpiring, KindUserInvited}
+
+// ValidateKinds returns an error when one of the kinds isn't a known kind of notification
+func ValidateKinds(kinds []string) error {
+	for _, kind := range kinds {
+		if _, ok := templates[Kind(kind)]; !ok {
+			return fmt.Errorf("unknown notification %q", kind)
+		}
+	}
+	return nil
+}
+
+// Data is what the templates of the notifications are rendered with. Only the fields of the kind are set
+type Data struct {
+	// AccountID of the account the notification is about
+	AccountID string
+	// DashboardURL is the URL of the dashboard linked from the notifications, empty when not configured
+	DashboardURL string
+
+	PeerName string
+	PeerIP   string
+	// UserEmail is the email of the user the peer belongs to, or of the invited user
+	UserEmail string
+
+	SetupKeyName      string
+	SetupKeyExpiresAt time.Time
+}
+
+// Notifier renders the notifications and sends them in the background
+type Notifier struct {
+	mailer       Mailer
+	dashboardURL string
+}
+
+// NewNotifier creates a notifier sending the emails with the mailer. The dashboard URL is linked from the
+// notifications when set
+func NewNotifier(mailer Mailer, dashboardURL string) *Notifier {
+	return &Notifier{mailer: mailer, dashboardURL: dashboardURL}
+}
+
+// Notify sends the notification to the recipients in the background, the failures are logged
+func (n *Notifier) Notify(kind Kind, recipients []string, data Data) {
+	if len(recipients) == 0 {
+		return
+	}
+
+	data.DashboardURL = n.dashboardURL
+	subject, body, err := render(kind, data)
+	if err != nil {
+		log.Errorf("failed to render the %s notification: %v", kind, err)
+		return
+	}
+
+	go func() {
+		if err := n.mailer.Send(recipients, subject, body); err != nil {
+			log.Errorf("failed to send the %s notification of account %s: %v", kind, data.AccountID, err)
+			return
+		}
+		log.Debugf("sent the %s notification of account %s to %d recipients", kind, data.AccountID, len(recipients))
+	}()
+}
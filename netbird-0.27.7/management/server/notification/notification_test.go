@@ -0,0 +1,82 @@
+package notification
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockMailer struct {
+	mu   sync.Mutex
+	sent chan []string
+	body string
+}
+
+func (m *mockMailer) Send(to []string, _, body string) error {
+	m.mu.Lock()
+	m.body = body
+	m.mu.Unlock()
+	m.sent <- to
+	return nil
+}
+
+func TestRender(t *testing.T) {
+	data := Data{
+		PeerName:          "router",
+		PeerIP:            "100.64.0.1",
+		UserEmail:         "alice@example.com",
+		SetupKeyName:      "branch offices",
+		SetupKeyExpiresAt: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+		DashboardURL:      "https://netbird.example.com",
+	}
+
+	for _, kind := range Kinds {
+		subject, body, err := render(kind, data)
+		require.NoError(t, err, kind)
+		assert.NotEmpty(t, subject, kind)
+		assert.Contains(t, body, "Open the dashboard: https://netbird.example.com", kind)
+	}
+
+	subject, body, err := render(KindSetupKeyExpiring, data)
+	require.NoError(t, err)
+	assert.Equal(t, "[NetBird] Setup key branch offices expires soon", subject)
+	assert.Contains(t, body, "Expires at: 2024-06-01 12:00 UTC")
+
+	data.DashboardURL = ""
+	_, body, err = render(KindPeerApprovalPending, data)
+	require.NoError(t, err)
+	assert.NotContains(t, body, "Open the dashboard")
+	assert.Contains(t, body, "Peer: router (100.64.0.1)\nUser: alice@example.com\n")
+
+	_, _, err = render("unknown", data)
+	assert.Error(t, err)
+}
+
+func TestValidateKinds(t *testing.T) {
+	assert.NoError(t, ValidateKinds([]string{"peer_login_expired", "user_invited"}))
+	assert.NoError(t, ValidateKinds(nil))
+	assert.Error(t, ValidateKinds([]string{"peer_login_expired", "peer_deleted"}))
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	mailer := &mockMailer{sent: make(chan []string, 1)}
+	notifier := NewNotifier(mailer, "https://netbird.example.com")
+
+	notifier.Notify(KindUserInvited, nil, Data{UserEmail: "bob@example.com"})
+	notifier.Notify(KindUserInvited, []string{"admin@example.com"}, Data{UserEmail: "bob@example.com"})
+
+	select {
+	case to := <-mailer.sent:
+		assert.Equal(t, []string{"admin@example.com"}, to)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the notification wasn't sent")
+	}
+
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+	assert.Contains(t, mailer.body, "https://netbird.example.com", "the dashboard URL of the notifier should be linked")
+	assert.Empty(t, mailer.sent, "a notification without recipients shouldn't be sent")
+}
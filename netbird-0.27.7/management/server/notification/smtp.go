@@ -0,0 +1,187 @@
+package notification
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// EncryptionStartTLS upgrades the connection to the SMTP server with STARTTLS, the default
+	EncryptionStartTLS = "starttls"
+	// EncryptionTLS connects to the SMTP server with implicit TLS, usually on port 465
+	EncryptionTLS = "tls"
+	// EncryptionNone doesn't encrypt the connection. The credentials are only sent to a server on localhost then
+	EncryptionNone = "none"
+
+	smtpTimeout = 30 * time.Second
+)
+
+// Mailer sends emails
+type Mailer interface {
+	// Send the plain text email to the recipients
+	Send(to []string, subject, body string) error
+}
+
+// SMTPConfig is the SMTP server the notifications are sent through
+type SMTPConfig struct {
+	// Host of the SMTP server
+	Host string
+	// Port of the SMTP server, 587 for STARTTLS, 465 for TLS and 25 without encryption when 0
+	Port int
+	// Username and Password authenticate to the server with PLAIN authentication when set
+	Username string
+	Password string
+	// From is the sender address of the notifications, e.g. NetBird <netbird@example.com>
+	From string
+	// Encryption of the connection: starttls, tls or none. STARTTLS when empty
+	Encryption string
+}
+
+// SMTPMailer sends the emails through an SMTP server, opening a connection per email
+type SMTPMailer struct {
+	config SMTPConfig
+	from   *mail.Address
+}
+
+// NewSMTPMailer creates a mailer sending through the server of the config
+func NewSMTPMailer(config SMTPConfig) (*SMTPMailer, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("the SMTP host is required")
+	}
+
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP sender address %q: %w", config.From, err)
+	}
+
+	if config.Encryption == "" {
+		config.Encryption = EncryptionStartTLS
+	}
+	if config.Port == 0 {
+		switch config.Encryption {
+		case EncryptionStartTLS:
+			config.Port = 587
+		case EncryptionTLS:
+			config.Port = 465
+		case EncryptionNone:
+			config.Port = 25
+		}
+	}
+	switch config.Encryption {
+	case EncryptionStartTLS, EncryptionTLS, EncryptionNone:
+	default:
+		return nil, fmt.Errorf("invalid SMTP encryption %q, use starttls, tls or none", config.Encryption)
+	}
+
+	return &SMTPMailer{config: config, from: from}, nil
+}
+
+// Send the email to the recipients as blind copies so that they don't see each other's address
+func (m *SMTPMailer) Send(to []string, subject, body string) error {
+	msg, err := m.message(subject, body)
+	if err != nil {
+		return err
+	}
+
+	client, err := m.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close() //nolint
+
+	if m.config.Encryption == EncryptionStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("the SMTP server doesn't support STARTTLS")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: m.config.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("SMTP STARTTLS: %w", err)
+		}
+	}
+
+	if m.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.from.Address); err != nil {
+		return fmt.Errorf("SMTP sender: %w", err)
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("SMTP recipient %s: %w", rcpt, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP data: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("SMTP data: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP data: %w", err)
+	}
+	return client.Quit()
+}
+
+func (m *SMTPMailer) dial() (*smtp.Client, error) {
+	address := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+
+	var conn net.Conn
+	var err error
+	if m.config.Encryption == EncryptionTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, &tls.Config{ServerName: m.config.Host, MinVersion: tls.VersionTLS12})
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connect to SMTP server %s: %w", address, err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("connect to SMTP server %s: %w", address, err)
+	}
+	return client, nil
+}
+
+// message returns the email with its headers and its quoted-printable encoded body
+func (m *SMTPMailer) message(subject, body string) ([]byte, error) {
+	var msg bytes.Buffer
+	headers := [][2]string{
+		{"From", m.from.String()},
+		{"To", "undisclosed-recipients:;"},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&msg, "%s: %s\r\n", header[0], header[1])
+	}
+	msg.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&msg)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
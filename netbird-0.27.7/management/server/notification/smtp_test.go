@@ -0,0 +1,97 @@
+package notification
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveSMTP runs a minimal SMTP server accepting one email and sending the commands and the data it received
+func serveSMTP(t *testing.T, received chan<- []string) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var lines []string
+		reader := bufio.NewReader(conn)
+		reply := func(msg string) { _, _ = fmt.Fprintf(conn, "%s\r\n", msg) }
+		reply("220 localhost ESMTP")
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			lines = append(lines, line)
+			switch {
+			case inData && line == ".":
+				inData = false
+				reply("250 queued")
+			case inData:
+			case strings.HasPrefix(line, "EHLO"):
+				reply("250 localhost")
+			case line == "DATA":
+				inData = true
+				reply("354 go ahead")
+			case line == "QUIT":
+				reply("221 bye")
+				received <- lines
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	received := make(chan []string, 1)
+	port := serveSMTP(t, received)
+
+	mailer, err := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: port, From: "NetBird <netbird@example.com>", Encryption: EncryptionNone})
+	require.NoError(t, err)
+
+	err = mailer.Send([]string{"alice@example.com", "bob@example.com"}, "[NetBird] Peer router is waiting for approval", "Peer: router\n")
+	require.NoError(t, err)
+
+	lines := <-received
+	assert.Contains(t, lines, "MAIL FROM:<netbird@example.com>")
+	assert.Contains(t, lines, "RCPT TO:<alice@example.com>")
+	assert.Contains(t, lines, "RCPT TO:<bob@example.com>")
+	assert.Contains(t, lines, "To: undisclosed-recipients:;", "the recipients shouldn't see each other's address")
+	assert.Contains(t, lines, "Subject: [NetBird] Peer router is waiting for approval")
+	assert.Contains(t, lines, "Peer: router")
+}
+
+func TestNewSMTPMailer(t *testing.T) {
+	mailer, err := NewSMTPMailer(SMTPConfig{Host: "smtp.example.com", From: "netbird@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, EncryptionStartTLS, mailer.config.Encryption)
+	assert.Equal(t, 587, mailer.config.Port)
+
+	mailer, err = NewSMTPMailer(SMTPConfig{Host: "smtp.example.com", From: "netbird@example.com", Encryption: EncryptionTLS})
+	require.NoError(t, err)
+	assert.Equal(t, 465, mailer.config.Port)
+
+	_, err = NewSMTPMailer(SMTPConfig{Host: "smtp.example.com", From: "not an address"})
+	assert.Error(t, err)
+
+	_, err = NewSMTPMailer(SMTPConfig{Host: "smtp.example.com", From: "netbird@example.com", Encryption: "ssl"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,83 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// message is the template of the subject and the plain text body of a notification
+type message struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+func newMessage(subject, body string) message {
+	return message{
+		subject: template.Must(template.New("subject").Parse(subject)),
+		body:    template.Must(template.New("body").Parse(strings.TrimLeft(body, "\n"))),
+	}
+}
+
+const footer = `
+{{- if .DashboardURL}}
+
+Open the dashboard: {{.DashboardURL}}
+{{- end}}
+
+You receive this email because you subscribed to NetBird notifications. You can change your notification
+preferences at any time.
+`
+
+var templates = map[Kind]message{
+	KindPeerApprovalPending: newMessage(
+		`[NetBird] Peer {{.PeerName}} is waiting for approval`, `
+A new peer joined your NetBird network and waits for the approval of an admin before it can connect.
+
+Peer: {{.PeerName}} ({{.PeerIP}})
+{{- if .UserEmail}}
+User: {{.UserEmail}}
+{{- end}}
+`+footer),
+	KindPeerLoginExpired: newMessage(
+		`[NetBird] The login of peer {{.PeerName}} expired`, `
+The login of a peer expired. The peer is disconnected until its user logs in again.
+
+Peer: {{.PeerName}} ({{.PeerIP}})
+{{- if .UserEmail}}
+User: {{.UserEmail}}
+{{- end}}
+`+footer),
+	KindSetupKeyExpiring: newMessage(
+		`[NetBird] Setup key {{.SetupKeyName}} expires soon`, `
+A setup key of your NetBird network expires soon. The peers can't register with it once it expired, the peers
+already registered with it aren't affected.
+
+Setup key: {{.SetupKeyName}}
+Expires at: {{.SetupKeyExpiresAt.UTC.Format "2006-01-02 15:04 MST"}}
+`+footer),
+	KindUserInvited: newMessage(
+		`[NetBird] {{.UserEmail}} was invited`, `
+A user was invited to your NetBird network.
+
+User: {{.UserEmail}}
+`+footer),
+}
+
+// render returns the subject and the body of the notification of the kind
+func render(kind Kind, data Data) (string, string, error) {
+	msg, ok := templates[kind]
+	if !ok {
+		return "", "", fmt.Errorf("unknown notification %q", kind)
+	}
+
+	var subject, body bytes.Buffer
+	if err := msg.subject.Execute(&subject, data); err != nil {
+		return "", "", err
+	}
+	if err := msg.body.Execute(&body, data); err != nil {
+		return "", "", err
+	}
+	return subject.String(), body.String(), nil
+}
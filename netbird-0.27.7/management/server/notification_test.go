@@ -0,0 +1,153 @@
+package server
+
+import (
+	"net"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/idp"
+	"github.com/netbirdio/netbird/management/server/notification"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+type notificationMailer struct {
+	sent chan []string
+}
+
+func (m *notificationMailer) Send(to []string, _, _ string) error {
+	m.sent <- to
+	return nil
+}
+
+func (m *notificationMailer) next(t *testing.T) []string {
+	t.Helper()
+	select {
+	case to := <-m.sent:
+		sort.Strings(to)
+		return to
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification was sent")
+		return nil
+	}
+}
+
+func (m *notificationMailer) none(t *testing.T) {
+	t.Helper()
+	select {
+	case to := <-m.sent:
+		t.Fatalf("unexpected notification sent to %v", to)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestDefaultAccountManager_UpdateUserNotifications(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account := newAccountWithId("test_account", userID, "")
+	account.Users["regularUser"] = NewRegularUser("regularUser")
+	account.Users["otherUser"] = NewRegularUser("otherUser")
+	account.Users["serviceUser"] = NewUser("serviceUser", UserRoleAdmin, true, false, "service", nil, UserIssuedAPI)
+	err = manager.Store.SaveAccount(account)
+	require.NoError(t, err)
+
+	kinds, err := manager.GetUserNotifications(account.Id, "regularUser", "regularUser")
+	require.NoError(t, err)
+	assert.Empty(t, kinds)
+
+	kinds, err = manager.UpdateUserNotifications(account.Id, userID, "regularUser",
+		[]string{"peer_login_expired", "peer_approval_pending", "peer_login_expired"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"peer_approval_pending", "peer_login_expired"}, kinds, "the kinds should be sorted and unique")
+
+	ev := getEvent(t, account.Id, manager, activity.UserNotificationsUpdated)
+	assert.Equal(t, "regularUser", ev.TargetID)
+
+	kinds, err = manager.GetUserNotifications(account.Id, "regularUser", "regularUser")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"peer_approval_pending", "peer_login_expired"}, kinds)
+
+	_, err = manager.UpdateUserNotifications(account.Id, "regularUser", "regularUser", []string{"user_invited"})
+	require.NoError(t, err, "users should manage their own notifications")
+
+	_, err = manager.GetUserNotifications(account.Id, "regularUser", "otherUser")
+	sErr, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.PermissionDenied, sErr.Type(), "regular users shouldn't get the notifications of other users")
+
+	_, err = manager.UpdateUserNotifications(account.Id, "regularUser", "otherUser", []string{"user_invited"})
+	sErr, ok = status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.PermissionDenied, sErr.Type(), "regular users shouldn't update the notifications of other users")
+
+	_, err = manager.UpdateUserNotifications(account.Id, userID, "regularUser", []string{"peer_deleted"})
+	sErr, ok = status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.InvalidArgument, sErr.Type(), "unknown kinds should be rejected")
+
+	_, err = manager.UpdateUserNotifications(account.Id, userID, "serviceUser", []string{"user_invited"})
+	sErr, ok = status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.InvalidArgument, sErr.Type(), "service users shouldn't receive notifications")
+}
+
+func TestDefaultAccountManager_notify(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account := newAccountWithId("test_account", userID, "")
+	account.Users[userID].EmailNotifications = []string{string(notification.KindPeerLoginExpired), string(notification.KindSetupKeyExpiring)}
+	account.Users["regularUser"] = NewRegularUser("regularUser")
+	account.Users["regularUser"].EmailNotifications = []string{string(notification.KindPeerLoginExpired)}
+	account.Users["blockedUser"] = NewAdminUser("blockedUser")
+	account.Users["blockedUser"].Blocked = true
+	account.Users["blockedUser"].EmailNotifications = []string{string(notification.KindPeerLoginExpired)}
+	account.SetupKeys["expiring"] = &SetupKey{
+		Id:        "expiring",
+		Key:       "expiring",
+		Name:      "expiring",
+		ExpiresAt: time.Now().Add(24 * time.Hour),
+	}
+	err = manager.Store.SaveAccount(account)
+	require.NoError(t, err)
+
+	manager.idpManager = &idp.MockIDP{
+		GetAccountFunc: func(accountId string) ([]*idp.UserData, error) {
+			return []*idp.UserData{
+				{ID: userID, Email: "owner@example.com"},
+				{ID: "regularUser", Email: "regular@example.com"},
+				{ID: "blockedUser", Email: "blocked@example.com"},
+			}, nil
+		},
+	}
+	mailer := &notificationMailer{sent: make(chan []string, 10)}
+	manager.notifier = notification.NewNotifier(mailer, "")
+
+	peer := &nbpeer.Peer{ID: "peer", Name: "router", IP: net.ParseIP("100.64.0.1"), UserID: "regularUser"}
+	manager.notifyPeer(account, notification.KindPeerLoginExpired, peer)
+	assert.Equal(t, []string{"owner@example.com", "regular@example.com"}, mailer.next(t),
+		"the owner of the peer and the subscribed admins should be notified")
+
+	peer.UserID = "otherUser"
+	manager.notifyPeer(account, notification.KindPeerLoginExpired, peer)
+	assert.Equal(t, []string{"owner@example.com"}, mailer.next(t), "regular users should only be notified about their peers")
+
+	manager.notifyPeer(account, notification.KindPeerApprovalPending, peer)
+	mailer.none(t)
+
+	manager.notifyExpiringSetupKeys()
+	assert.Equal(t, []string{"owner@example.com"}, mailer.next(t))
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.True(t, account.SetupKeys["expiring"].ExpiryNotified)
+
+	manager.notifyExpiringSetupKeys()
+	mailer.none(t)
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"fmt"
 	"net"
+	"slices"
 	"strings"
 	"time"
 
@@ -11,10 +12,14 @@ import (
 
 	"github.com/netbirdio/netbird/management/proto"
 	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/notification"
 	nbpeer "github.com/netbirdio/netbird/management/server/peer"
 	"github.com/netbirdio/netbird/management/server/status"
 )
 
+// peerNATTypes are the NAT types the peers detect with the STUN servers
+var peerNATTypes = []string{"unknown", "none", "endpoint_independent", "symmetric", "udp_blocked"}
+
 // PeerSync used as a data object between the gRPC API and AccountManager on Sync request.
 type PeerSync struct {
 	// WireGuardPubKey is a peers WireGuard public key
@@ -35,6 +40,9 @@ type PeerLogin struct {
 	SetupKey string
 	// ConnectionIP is the real IP of the peer
 	ConnectionIP net.IP
+	// RequestedIP is the NetBird IP the peer asks for, nil if the peer accepts any IP.
+	// It is assigned if it is a free address of the account network, otherwise the peer keeps its IP.
+	RequestedIP net.IP
 }
 
 // GetPeers returns a list of peers under the given account filtering out peers that do not belong to a user if
@@ -127,11 +135,14 @@ func (am *DefaultAccountManager) MarkPeerConnected(peerPubKey string, connected
 		return err
 	}
 
+	// the peers are updated when the location of the peer moves it across the geofence
+	geofenceUpdated := connected && am.updateConnectedPeerGeofence(account, peer)
+
 	if peer.AddedWithSSOLogin() && peer.LoginExpirationEnabled && account.Settings.PeerLoginExpirationEnabled {
 		am.checkAndSchedulePeerLoginExpiration(account)
 	}
 
-	if oldStatus.LoginExpired {
+	if oldStatus.LoginExpired || geofenceUpdated {
 		// we need to update other peers because when peer login expires all other peers are notified to disconnect from
 		// the expired one. Here we notify them that connection is now allowed again.
 		am.updateAccountPeers(account)
@@ -170,18 +181,39 @@ func (am *DefaultAccountManager) UpdatePeer(accountID, userID string, update *nb
 	}
 
 	if peer.Name != update.Name {
+		newLabel, collision, err := account.getPeerDNSLabel(peer.ID, update.Name)
+		if err != nil {
+			am.storeDNSLabelCollisionEvent(userID, account, &nbpeer.Peer{ID: peer.ID, Name: update.Name}, collision)
+			return nil, err
+		}
+
 		peer.Name = update.Name
+		peer.DNSLabel = newLabel
 
-		existingLabels := account.getPeerDNSLabels()
+		am.StoreEvent(userID, peer.ID, accountID, activity.PeerRenamed, peer.EventMeta(am.GetDNSDomain()))
+		am.storeDNSLabelCollisionEvent(userID, account, peer, collision)
+	}
 
-		newLabel, err := getPeerHostLabel(peer.Name, existingLabels)
+	if update.IP != nil && !peer.IP.Equal(update.IP) {
+		err = am.assignPeerIP(account, userID, peer, update.IP)
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		peer.DNSLabel = newLabel
+	if peer.Uplink != update.Uplink {
+		err = validatePeerUplink(update.Uplink)
+		if err != nil {
+			return nil, err
+		}
 
-		am.StoreEvent(userID, peer.ID, accountID, activity.PeerRenamed, peer.EventMeta(am.GetDNSDomain()))
+		peer.Uplink = update.Uplink
+		// the uplink is delivered with the peer config, so the peer needs an update even if nothing else changed
+		account.Network.IncSerial()
+
+		meta := peer.EventMeta(am.GetDNSDomain())
+		meta["uplink"] = peer.Uplink
+		am.StoreEvent(userID, peer.ID, accountID, activity.PeerUplinkUpdated, meta)
 	}
 
 	if peer.LoginExpirationEnabled != update.LoginExpirationEnabled {
@@ -215,6 +247,63 @@ func (am *DefaultAccountManager) UpdatePeer(accountID, userID string, update *nb
 	return peer, nil
 }
 
+// maxUplinkInterfaceNameLength is the longest interface name accepted as an uplink, Windows allows the longest names
+const maxUplinkInterfaceNameLength = 256
+
+// validatePeerUplink checks that the uplink is either an IP address or a valid interface name
+func validatePeerUplink(uplink string) error {
+	if uplink == "" || net.ParseIP(uplink) != nil {
+		return nil
+	}
+
+	if len(uplink) > maxUplinkInterfaceNameLength || strings.ContainsAny(uplink, "/\x00") || strings.TrimSpace(uplink) != uplink {
+		return status.Errorf(status.InvalidArgument, "invalid peer uplink %q, it has to be an interface name or an IP address", uplink)
+	}
+	return nil
+}
+
+// validatePeerIP checks that the IP can be assigned to the peer, it has to be a free host address of the account network
+func validatePeerIP(account *Account, peerID string, ip net.IP) error {
+	ip = ip.To4()
+	if ip == nil {
+		return status.Errorf(status.InvalidArgument, "peer IP must be an IPv4 address")
+	}
+
+	network := account.Network.Net
+	if !network.Contains(ip) {
+		return status.Errorf(status.InvalidArgument, "peer IP %s is outside of the account network %s", ip, network.String())
+	}
+
+	mask := network.Mask
+	if len(mask) == net.IPv6len {
+		mask = mask[12:]
+	}
+	networkIP := network.IP.To4()
+	broadcast := make(net.IP, net.IPv4len)
+	for i := range broadcast {
+		broadcast[i] = networkIP[i] | ^mask[i]
+	}
+	// the allocation of peer IPs skips the addresses ending with 0 as well
+	if ip.Equal(networkIP) || ip.Equal(broadcast) || ip[3] == 0 {
+		return status.Errorf(status.InvalidArgument, "peer IP %s is not a valid host address of the account network %s", ip, network.String())
+	}
+
+	// the peers using a userspace WireGuard answer the DNS queries on the address before the broadcast one
+	resolver := copyIP(broadcast)
+	resolver[3]--
+	if ip.Equal(resolver) {
+		return status.Errorf(status.InvalidArgument, "peer IP %s is reserved for the DNS resolver of the peers", ip)
+	}
+
+	for _, p := range account.Peers {
+		if p.ID != peerID && p.IP.Equal(ip) {
+			return status.Errorf(status.AlreadyExists, "peer IP %s is already used by peer %s", ip, p.Name)
+		}
+	}
+
+	return nil
+}
+
 // deletePeers will delete all specified peers and send updates to the remote peers. Don't call without acquiring account lock
 func (am *DefaultAccountManager) deletePeers(account *Account, peerIDs []string, userID string) error {
 
@@ -256,6 +345,11 @@ func (am *DefaultAccountManager) deletePeers(account *Account, peerIDs []string,
 				},
 			})
 		am.peersUpdateManager.CloseChannel(peer.ID)
+		am.deletePeerTransferStats(peer.Key)
+		am.deletePeerDNSStats(peer.Key)
+		am.deletePeerFirewallStats(peer.Key)
+		am.deletePeerDiagnostics(peer.Key)
+		am.deletePeerLoad(peer.Key)
 		am.StoreEvent(userID, peer.ID, account.Id, activity.PeerRemovedByUser, peer.EventMeta(am.GetDNSDomain()))
 	}
 
@@ -311,6 +405,49 @@ func (am *DefaultAccountManager) GetNetworkMap(peerID string) (*NetworkMap, erro
 	return account.GetPeerNetworkMap(peer.ID, am.dnsDomain, validatedPeers), nil
 }
 
+// redactedPresharedKey replaces the WireGuard preshared keys in the network map dumps
+const redactedPresharedKey = "<redacted>"
+
+// GetPeerNetworkMapDump returns the network map the server pushes to the peer, as the peer receives it. The WireGuard
+// preshared keys are redacted. Only the users with admin power are allowed to dump it.
+func (am *DefaultAccountManager) GetPeerNetworkMapDump(accountID, peerID, userID string) (*proto.NetworkMap, error) {
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view the network map of a peer")
+	}
+
+	peer := account.GetPeer(peerID)
+	if peer == nil {
+		return nil, status.Errorf(status.NotFound, "peer with ID %s not found", peerID)
+	}
+
+	validatedPeers, err := am.GetValidatedPeers(account)
+	if err != nil {
+		return nil, err
+	}
+
+	networkMap := account.GetPeerNetworkMap(peer.ID, am.dnsDomain, validatedPeers)
+	dump := toSyncResponse(nil, peer, nil, networkMap, am.GetDNSDomain(), am.clientUpdates).NetworkMap
+	for _, remotePeers := range [][]*proto.RemotePeerConfig{dump.RemotePeers, dump.OfflinePeers} {
+		for _, remotePeer := range remotePeers {
+			if remotePeer.PresharedKey != "" {
+				remotePeer.PresharedKey = redactedPresharedKey
+			}
+		}
+	}
+
+	return dump, nil
+}
+
 // GetPeerNetwork returns the Network for a given peer
 func (am *DefaultAccountManager) GetPeerNetwork(peerID string) (*Network, error) {
 	account, err := am.Store.GetAccountByPeerID(peerID)
@@ -394,29 +531,51 @@ func (am *DefaultAccountManager) AddPeer(setupKey, userID string, peer *nbpeer.P
 			return nil, nil, status.Errorf(status.PreconditionFailed, "couldn't add peer: setup key is invalid")
 		}
 
+		if err := account.checkSetupKeyPeerQuota(sk); err != nil {
+			log.Warnf("rejected peer %s registering with setup key %s of account %s: %v", peer.Key, sk.Id, account.Id, err)
+			return nil, nil, err
+		}
+
 		account.SetupKeys[sk.Key] = sk.IncrementUsage()
 		opEvent.InitiatorID = sk.Id
 		opEvent.Activity = activity.PeerAddedWithSetupKey
 		ephemeral = sk.Ephemeral
 		setupKeyName = sk.Name
 	} else {
+		if err := account.checkUserPeerQuota(userID); err != nil {
+			log.Warnf("rejected peer %s registering for user %s of account %s: %v", peer.Key, userID, account.Id, err)
+			return nil, nil, err
+		}
 		opEvent.InitiatorID = userID
 		opEvent.Activity = activity.PeerAddedByUser
 	}
 
 	takenIps := account.getTakenIPs()
-	existingLabels := account.getPeerDNSLabels()
 
-	newLabel, err := getPeerHostLabel(peer.Meta.Hostname, existingLabels)
+	newLabel, labelCollision, err := account.getPeerDNSLabel("", peer.Meta.Hostname)
 	if err != nil {
+		am.storeDNSLabelCollisionEvent(opEvent.InitiatorID, account, &nbpeer.Peer{Name: peer.Meta.Hostname, Key: peer.Key}, labelCollision)
 		return nil, nil, err
 	}
 
 	peer.DNSLabel = newLabel
 	network := account.Network
-	nextIp, err := AllocatePeerIP(network.Net, takenIps)
-	if err != nil {
-		return nil, nil, err
+	var nextIp net.IP
+	staticIP := false
+	if peer.IP != nil {
+		// the peer asked for an IP, it gets one allocated if the IP can't be assigned
+		if err := validatePeerIP(account, "", peer.IP); err != nil {
+			log.Warnf("peer %s requested IP %s, allocating another IP: %v", peer.Key, peer.IP, err)
+		} else {
+			nextIp = peer.IP.To4()
+			staticIP = true
+		}
+	}
+	if nextIp == nil {
+		nextIp, err = AllocatePeerIP(network.Net, takenIps)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	registrationTime := time.Now().UTC()
@@ -426,6 +585,7 @@ func (am *DefaultAccountManager) AddPeer(setupKey, userID string, peer *nbpeer.P
 		Key:                    peer.Key,
 		SetupKey:               upperKey,
 		IP:                     nextIp,
+		StaticIP:               staticIP,
 		Meta:                   peer.Meta,
 		Name:                   peer.Meta.Hostname,
 		DNSLabel:               newLabel,
@@ -492,6 +652,12 @@ func (am *DefaultAccountManager) AddPeer(setupKey, userID string, peer *nbpeer.P
 	}
 
 	am.StoreEvent(opEvent.InitiatorID, opEvent.TargetID, opEvent.AccountID, opEvent.Activity, opEvent.Meta)
+	am.storeDNSLabelCollisionEvent(opEvent.InitiatorID, account, newPeer, labelCollision)
+	am.updateConnectedPeerGeofence(account, newPeer)
+
+	if newPeer.Status.RequiresApproval {
+		am.notifyPeer(account, notification.KindPeerApprovalPending, newPeer)
+	}
 
 	am.updateAccountPeers(account)
 
@@ -541,6 +707,10 @@ func (am *DefaultAccountManager) SyncPeer(sync PeerSync, account *Account) (*nbp
 // LoginPeer logs in or registers a peer.
 // If peer doesn't exist the function checks whether a setup key or a user is present and registers a new peer if so.
 func (am *DefaultAccountManager) LoginPeer(login PeerLogin) (*nbpeer.Peer, *NetworkMap, error) {
+	if err := am.revertPeerKeyRotation(login.WireGuardPubKey); err != nil {
+		log.Warnf("failed reverting the key rotation of peer %s: %v", login.WireGuardPubKey, err)
+	}
+
 	account, err := am.Store.GetAccountByPeerPubKey(login.WireGuardPubKey)
 	if err != nil {
 		if errStatus, ok := status.FromError(err); ok && errStatus.Type() == status.NotFound {
@@ -550,6 +720,7 @@ func (am *DefaultAccountManager) LoginPeer(login PeerLogin) (*nbpeer.Peer, *Netw
 				Key:    login.WireGuardPubKey,
 				Meta:   login.Meta,
 				SSHKey: login.SSHKey,
+				IP:     login.RequestedIP,
 			}
 			if am.geo != nil && login.ConnectionIP != nil {
 				location, err := am.geo.Lookup(login.ConnectionIP)
@@ -625,6 +796,16 @@ func (am *DefaultAccountManager) LoginPeer(login PeerLogin) (*nbpeer.Peer, *Netw
 		return nil, nil, err
 	}
 
+	if login.RequestedIP != nil && !peer.IP.Equal(login.RequestedIP) {
+		if err := am.assignPeerIP(account, peer.ID, peer, login.RequestedIP); err != nil {
+			log.Warnf("peer %s requested IP %s, keeping IP %s: %v", peer.ID, login.RequestedIP, peer.IP, err)
+		} else {
+			account.UpdatePeer(peer)
+			shouldStoreAccount = true
+			updateRemotePeers = true
+		}
+	}
+
 	if shouldStoreAccount {
 		err = am.Store.SaveAccount(account)
 		if err != nil {
@@ -761,6 +942,105 @@ func (am *DefaultAccountManager) UpdatePeerSSHKey(peerID string, sshKey string)
 	return nil
 }
 
+// UpdatePeerRouteConflicts stores the route conflicts reported by the peer identified by its WireGuard public key.
+// The conflicts replace the previously reported ones, an empty list clears them.
+func (am *DefaultAccountManager) UpdatePeerRouteConflicts(peerPubKey string, conflicts []nbpeer.RouteConflict) error {
+	accountID, err := am.Store.GetAccountIDByPeerPubKey(peerPubKey)
+	if err != nil {
+		return err
+	}
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	peer, err := account.FindPeerByPubKey(peerPubKey)
+	if err != nil {
+		return status.Errorf(status.NotFound, "peer with key %s not found", peerPubKey)
+	}
+
+	if !peer.UpdateRouteConflictsIfNew(conflicts) {
+		return nil
+	}
+
+	account.UpdatePeer(peer)
+
+	return am.Store.SaveAccount(account)
+}
+
+// UpdatePeerNATType stores the NAT type reported by the peer identified by its WireGuard public key in its meta
+func (am *DefaultAccountManager) UpdatePeerNATType(peerPubKey string, natType string) error {
+	if !slices.Contains(peerNATTypes, natType) {
+		return status.Errorf(status.InvalidArgument, "invalid NAT type %s", natType)
+	}
+
+	accountID, err := am.Store.GetAccountIDByPeerPubKey(peerPubKey)
+	if err != nil {
+		return err
+	}
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	peer, err := account.FindPeerByPubKey(peerPubKey)
+	if err != nil {
+		return status.Errorf(status.NotFound, "peer with key %s not found", peerPubKey)
+	}
+
+	if !peer.UpdateNATTypeIfNew(natType) {
+		return nil
+	}
+
+	account.UpdatePeer(peer)
+
+	return am.Store.SaveAccount(account)
+}
+
+// UpdatePeerRosenpassPubKey stores the Rosenpass public key reported by the peer identified by its WireGuard public key
+// and distributes it to the remote peers with their network maps
+func (am *DefaultAccountManager) UpdatePeerRosenpassPubKey(peerPubKey string, rosenpassPubKey []byte) error {
+	accountID, err := am.Store.GetAccountIDByPeerPubKey(peerPubKey)
+	if err != nil {
+		return err
+	}
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	peer, err := account.FindPeerByPubKey(peerPubKey)
+	if err != nil {
+		return status.Errorf(status.NotFound, "peer with key %s not found", peerPubKey)
+	}
+
+	if !peer.UpdateRosenpassPubKeyIfNew(rosenpassPubKey) {
+		return nil
+	}
+
+	account.UpdatePeer(peer)
+
+	if err := am.Store.SaveAccount(account); err != nil {
+		return err
+	}
+
+	am.updateAccountPeers(account)
+
+	return nil
+}
+
 // GetPeer for a given accountID, peerID and userID error if not found.
 func (am *DefaultAccountManager) GetPeer(accountID, peerID, userID string) (*nbpeer.Peer, error) {
 	unlock := am.Store.AcquireAccountWriteLock(accountID)
@@ -822,6 +1102,23 @@ func updatePeerMeta(peer *nbpeer.Peer, meta nbpeer.PeerSystemMeta, account *Acco
 	return peer, false
 }
 
+// ResyncAccountPeers sends the current network map to all the connected peers of the account, e.g. when a peer missed an
+// update. The peers accept a network map with the serial they already have.
+func (am *DefaultAccountManager) ResyncAccountPeers(accountID, userID string) error {
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	am.updateAccountPeers(account)
+	am.StoreEvent(userID, accountID, accountID, activity.AccountPeersResynced, nil)
+
+	return nil
+}
+
 // updateAccountPeers updates all peers that belong to an account.
 // Should be called when changes have to be synced to peers.
 func (am *DefaultAccountManager) updateAccountPeers(account *Account) {
@@ -838,7 +1135,7 @@ func (am *DefaultAccountManager) updateAccountPeers(account *Account) {
 			continue
 		}
 		remotePeerNetworkMap := account.GetPeerNetworkMap(peer.ID, am.dnsDomain, approvedPeersMap)
-		update := toSyncResponse(nil, peer, nil, remotePeerNetworkMap, am.GetDNSDomain())
-		am.peersUpdateManager.SendUpdate(peer.ID, &UpdateMessage{Update: update})
+		update := toSyncResponse(nil, peer, nil, remotePeerNetworkMap, am.GetDNSDomain(), am.clientUpdates)
+		am.peersUpdateManager.SendUpdate(peer.ID, &UpdateMessage{Update: update, NetworkMap: remotePeerNetworkMap})
 	}
 }
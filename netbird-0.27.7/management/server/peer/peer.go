@@ -1,9 +1,11 @@
 package peer
 
 import (
+	"bytes"
 	"fmt"
 	"net"
 	"net/netip"
+	"slices"
 	"time"
 )
 
@@ -46,6 +48,29 @@ type Peer struct {
 	Ephemeral bool
 	// Geo location based on connection IP
 	Location Location `gorm:"embedded;embeddedPrefix:location_"`
+	// RouteConflicts is a list of routes that the peer reported as not installed on its system
+	RouteConflicts []RouteConflict `gorm:"serializer:json"`
+	// StaticIP is true when the IP was assigned by an administrator or requested by the peer rather than allocated
+	StaticIP bool
+	// Uplink is the network interface name or the local source IP the peer pins its NetBird traffic to,
+	// empty if the peer picks the uplink itself
+	Uplink string
+	// RosenpassPubKey is the Rosenpass public key the peer reported with its status, empty if it doesn't run Rosenpass
+	RosenpassPubKey []byte
+	// KeyRotatedAt is the time the peer last rotated its WireGuard key, zero if it never did
+	KeyRotatedAt time.Time
+	// PreviousKey is the WireGuard public key the peer rotated from, a login with it reverts the rotation until
+	// PreviousKeyExpiresAt
+	PreviousKey string `gorm:"index"`
+	// PreviousKeyExpiresAt is the end of the grace period of the previous key
+	PreviousKeyExpiresAt time.Time
+	// GeofenceViolation is the country code of the location of the peer outside the geofence of the account, empty if
+	// the peer complies with it
+	GeofenceViolation string
+	// GeofenceViolatedAt is the time the peer was first located outside the geofence
+	GeofenceViolatedAt time.Time
+	// GeofenceOverride is the exception to the geofence requested for the peer, nil if none was requested
+	GeofenceOverride *GeofenceOverride `gorm:"serializer:json"`
 }
 
 type PeerStatus struct { //nolint:revive
@@ -67,6 +92,160 @@ type Location struct {
 	GeoNameID    uint // city level geoname id
 }
 
+// RouteConflict describes a route that a peer couldn't install because it conflicts with the peer's local routing
+type RouteConflict struct {
+	// NetID is the network identifier of the conflicting route
+	NetID string
+	// Network is the route network prefix
+	Network netip.Prefix `gorm:"serializer:json"`
+	// ConflictsWith is the local prefix or gateway the route conflicts with
+	ConflictsWith string
+	// Reason is the machine-readable conflict reason
+	Reason string
+}
+
+// GeofenceOverride is an exception to the geofence of the account letting a peer located in a country outside of it
+// connect as usual. It is requested by the owner of the peer or an admin and applies once an admin approves it
+type GeofenceOverride struct {
+	// Country is the country code of the location the override applies to
+	Country string
+	// Reason is why the override was requested
+	Reason string
+	// RequestedBy is the ID of the user who requested the override
+	RequestedBy string
+	// RequestedAt is the time the override was requested
+	RequestedAt time.Time
+	// ApprovedBy is the ID of the admin who approved the override, empty while it is pending
+	ApprovedBy string
+	// ApprovedAt is the time the override was approved
+	ApprovedAt time.Time
+	// ExpiresAt is the time the approved override ends, zero if it doesn't expire
+	ExpiresAt time.Time
+}
+
+// Copy returns a copy of the geofence override
+func (o *GeofenceOverride) Copy() *GeofenceOverride {
+	if o == nil {
+		return nil
+	}
+	override := *o
+	return &override
+}
+
+// Approved returns true if an admin approved the override
+func (o *GeofenceOverride) Approved() bool {
+	return o != nil && o.ApprovedBy != ""
+}
+
+// Allows returns true if the override is approved for the country and didn't expire at the given time
+func (o *GeofenceOverride) Allows(country string, now time.Time) bool {
+	if !o.Approved() || o.Country != country {
+		return false
+	}
+	return o.ExpiresAt.IsZero() || now.Before(o.ExpiresAt)
+}
+
+// TransferStats describes the WireGuard statistics of a peer's connection to a remote peer
+type TransferStats struct {
+	// RemotePeerKey is the WireGuard public key of the remote peer
+	RemotePeerKey string
+	RxBytes       int64
+	TxBytes       int64
+	LastHandshake time.Time
+	// Endpoint is the current remote address of the connection
+	Endpoint string
+	// Relayed tells whether the connection goes through a TURN relay
+	Relayed bool
+}
+
+// FirewallStats describes the traffic matched by the firewall rules of a policy on a peer since they were installed
+type FirewallStats struct {
+	PolicyID string
+	Packets  uint64
+	Bytes    uint64
+	// ReportedAt is when the peer reported the counters
+	ReportedAt time.Time
+}
+
+// DroppedConnection describes an inbound connection dropped by the firewall of a peer
+type DroppedConnection struct {
+	SourceIP        string
+	SourcePort      uint16
+	DestinationPort uint16
+	Protocol        string
+	// PolicyIDs are the policies of the drop rule the connection matched, empty when no policy allows it
+	PolicyIDs []string
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Packets   uint64
+}
+
+// SpeedtestResult is a throughput test a peer ran against another peer over the WireGuard tunnel
+type SpeedtestResult struct {
+	// PeerKey is the WireGuard public key of the tested peer
+	PeerKey string
+	// Protocol is tcp or udp, Direction is upload when the peer sent the data and download when it received it
+	Protocol  string
+	Direction string
+	// Bytes is the amount of data received over the Duration
+	Bytes    int64
+	Duration time.Duration
+	// PacketsSent and PacketsLost are the datagrams of an udp test
+	PacketsSent int64
+	PacketsLost int64
+}
+
+// MegabitsPerSecond returns the throughput measured by the test
+func (r SpeedtestResult) MegabitsPerSecond() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Bytes) * 8 / r.Duration.Seconds() / 1e6
+}
+
+// SSHSession describes an SSH session to the embedded SSH server of a peer reported when it ended
+type SSHSession struct {
+	// PeerKey is the WireGuard public key of the remote peer the session was opened from
+	PeerKey string
+	// User is the local user the remote peer logged in as
+	User      string
+	StartedAt time.Time
+	Duration  time.Duration
+}
+
+// Diagnostics holds the diagnostic data last reported by a peer
+type Diagnostics struct {
+	// DroppedConnections are the latest inbound connections dropped by the firewall of the peer,
+	// reported only when the peer enabled the log of the dropped connections
+	DroppedConnections []DroppedConnection
+	// DroppedConnectionsReportedAt is when the peer reported the dropped connections, zero when it never did
+	DroppedConnectionsReportedAt time.Time
+}
+
+// DNSStats describes the counters of the DNS queries handled by the resolver of a peer since its query log was enabled
+type DNSStats struct {
+	LocalQueries     uint64
+	ForwardedQueries uint64
+	BlockedQueries   uint64
+	FailedQueries    uint64
+	AverageLatency   time.Duration
+	// ReportedAt is when the peer reported the counters
+	ReportedAt time.Time
+}
+
+// Load describes the load of a routing peer
+type Load struct {
+	// CPUPercent is the share of the CPU time the system spent busy since the previous report
+	CPUPercent float64
+	// MemoryPercent is the share of the memory of the system in use
+	MemoryPercent float64
+	// ThroughputBps is the WireGuard traffic of the peer in bits per second since the previous report, which includes
+	// the traffic it forwards for the clients of its routes
+	ThroughputBps uint64
+	// ReportedAt is when the peer reported the load
+	ReportedAt time.Time
+}
+
 // NetworkAddress is the IP address with network and MAC address of a network interface
 type NetworkAddress struct {
 	NetIP netip.Prefix `gorm:"serializer:json"`
@@ -79,6 +258,46 @@ type Environment struct {
 	Platform string
 }
 
+// Package is a package installed on a peer with its version
+type Package struct {
+	Name    string
+	Version string
+}
+
+// Inventory is the summary of the software of a peer, reported when it is enabled on the peer
+type Inventory struct {
+	// PackageManager is the package manager of the peer, e.g. opkg, apk or dpkg
+	PackageManager string
+	// PatchLevel is the release and the build revision of the OS, e.g. the revision of an OpenWrt image
+	PatchLevel string
+	// PackagesCount is the number of packages installed with the package manager
+	PackagesCount int
+	// Packages are the installed packages the peer reports the versions of, sorted by name
+	Packages []Package
+}
+
+// Reported returns whether the peer reported any part of the inventory
+func (i Inventory) Reported() bool {
+	return i.PackageManager != "" || i.PatchLevel != "" || len(i.Packages) > 0
+}
+
+// PackageVersion returns the version of the installed package and whether the peer reported it
+func (i Inventory) PackageVersion(name string) (string, bool) {
+	for _, p := range i.Packages {
+		if p.Name == name {
+			return p.Version, true
+		}
+	}
+	return "", false
+}
+
+func (i Inventory) isEqual(other Inventory) bool {
+	return i.PackageManager == other.PackageManager &&
+		i.PatchLevel == other.PatchLevel &&
+		i.PackagesCount == other.PackagesCount &&
+		slices.Equal(i.Packages, other.Packages)
+}
+
 // PeerSystemMeta is a metadata of a Peer machine system
 type PeerSystemMeta struct { //nolint:revive
 	Hostname           string
@@ -96,6 +315,10 @@ type PeerSystemMeta struct { //nolint:revive
 	SystemProductName  string
 	SystemManufacturer string
 	Environment        Environment `gorm:"serializer:json"`
+	// NATType is the type of the NAT in front of the peer it detected with the STUN servers, reported with its status
+	NATType string
+	// Inventory is the software inventory the peer reported at login
+	Inventory Inventory `gorm:"serializer:json"`
 }
 
 func (p PeerSystemMeta) isEqual(other PeerSystemMeta) bool {
@@ -130,7 +353,9 @@ func (p PeerSystemMeta) isEqual(other PeerSystemMeta) bool {
 		p.SystemProductName == other.SystemProductName &&
 		p.SystemManufacturer == other.SystemManufacturer &&
 		p.Environment.Cloud == other.Environment.Cloud &&
-		p.Environment.Platform == other.Environment.Platform
+		p.Environment.Platform == other.Environment.Platform &&
+		p.NATType == other.NATType &&
+		p.Inventory.isEqual(other.Inventory)
 }
 
 // AddedWithSSOLogin indicates whether this peer has been added with an SSO login by a user.
@@ -162,6 +387,16 @@ func (p *Peer) Copy() *Peer {
 		CreatedAt:              p.CreatedAt,
 		Ephemeral:              p.Ephemeral,
 		Location:               p.Location,
+		RouteConflicts:         slices.Clone(p.RouteConflicts),
+		StaticIP:               p.StaticIP,
+		Uplink:                 p.Uplink,
+		RosenpassPubKey:        slices.Clone(p.RosenpassPubKey),
+		KeyRotatedAt:           p.KeyRotatedAt,
+		PreviousKey:            p.PreviousKey,
+		PreviousKeyExpiresAt:   p.PreviousKeyExpiresAt,
+		GeofenceViolation:      p.GeofenceViolation,
+		GeofenceViolatedAt:     p.GeofenceViolatedAt,
+		GeofenceOverride:       p.GeofenceOverride.Copy(),
 	}
 }
 
@@ -172,6 +407,10 @@ func (p *Peer) UpdateMetaIfNew(meta PeerSystemMeta) bool {
 	if meta.UIVersion == "" {
 		meta.UIVersion = p.Meta.UIVersion
 	}
+	// The NAT type is reported with the peer status rather than on login
+	if meta.NATType == "" {
+		meta.NATType = p.Meta.NATType
+	}
 
 	if p.Meta.isEqual(meta) {
 		return false
@@ -180,6 +419,36 @@ func (p *Peer) UpdateMetaIfNew(meta PeerSystemMeta) bool {
 	return true
 }
 
+// UpdateRouteConflictsIfNew updates the route conflicts reported by the peer if they differ from the stored ones
+// returns true if the conflicts were updated, false otherwise
+func (p *Peer) UpdateRouteConflictsIfNew(conflicts []RouteConflict) bool {
+	if slices.Equal(p.RouteConflicts, conflicts) {
+		return false
+	}
+	p.RouteConflicts = conflicts
+	return true
+}
+
+// UpdateNATTypeIfNew updates the NAT type reported by the peer if it differs from the stored one
+// returns true if the NAT type was updated, false otherwise
+func (p *Peer) UpdateNATTypeIfNew(natType string) bool {
+	if p.Meta.NATType == natType {
+		return false
+	}
+	p.Meta.NATType = natType
+	return true
+}
+
+// UpdateRosenpassPubKeyIfNew updates the Rosenpass public key reported by the peer if it differs from the stored one
+// returns true if the key was updated, false otherwise
+func (p *Peer) UpdateRosenpassPubKeyIfNew(rosenpassPubKey []byte) bool {
+	if bytes.Equal(p.RosenpassPubKey, rosenpassPubKey) {
+		return false
+	}
+	p.RosenpassPubKey = rosenpassPubKey
+	return true
+}
+
 // MarkLoginExpired marks peer's status expired or not
 func (p *Peer) MarkLoginExpired(expired bool) {
 	newStatus := p.Status.Copy()
@@ -206,6 +475,17 @@ func (p *Peer) LoginExpired(expiresIn time.Duration) (bool, time.Duration) {
 	return timeLeft <= 0, timeLeft
 }
 
+// KeyRotationDue indicates whether the peer has to rotate its WireGuard key, every interval since it was created or
+// last rotated its key, and the time left to the rotation (negative when due)
+func (p *Peer) KeyRotationDue(interval time.Duration) (bool, time.Duration) {
+	rotatedAt := p.KeyRotatedAt
+	if rotatedAt.IsZero() {
+		rotatedAt = p.CreatedAt
+	}
+	timeLeft := time.Until(rotatedAt.Add(interval))
+	return timeLeft <= 0, timeLeft
+}
+
 // FQDN returns peers FQDN combined of the peer's DNS label and the system's DNS domain
 func (p *Peer) FQDN(dnsDomain string) string {
 	if dnsDomain == "" {
@@ -0,0 +1,249 @@
+package server
+
+import (
+	"slices"
+	"time"
+
+	"github.com/rs/xid"
+	log "github.com/sirupsen/logrus"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/management/proto"
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// The remote actions the admins can run on the peers, each has to be allowed by the PeerActions of the account
+// settings
+const (
+	// PeerActionRestartEngine restarts the engine of the client, reconnecting all the peers
+	PeerActionRestartEngine = "restart_engine"
+	// PeerActionResync applies the current network map ignoring the state the peer holds
+	PeerActionResync = "resync"
+	// PeerActionRotateKey makes the peer generate a new WireGuard key, it reconnects with it
+	PeerActionRotateKey = "rotate_wireguard_key"
+	// PeerActionCollectDebugBundle makes the peer upload an anonymized debug bundle
+	PeerActionCollectDebugBundle = "collect_debug_bundle"
+)
+
+var peerActions = []string{PeerActionRestartEngine, PeerActionResync, PeerActionRotateKey, PeerActionCollectDebugBundle}
+
+// PeerActionResult is the result of a remote action a peer reported
+type PeerActionResult struct {
+	ID   string
+	Type string
+	// Error is the reason the action failed, empty when it succeeded
+	Error string
+	// DebugBundleName is the name the debug bundle collected by the action is stored under
+	DebugBundleName string
+	// Ping is the result of a ping_peer action
+	Ping *PeerPingResult
+}
+
+func validatePeerActions(actions []string) error {
+	for _, action := range actions {
+		if !slices.Contains(peerActions, action) {
+			return status.Errorf(status.InvalidArgument, "unknown peer action %q, expected one of %v", action, peerActions)
+		}
+	}
+	return nil
+}
+
+// RequestPeerAction sends a remote action to the peer and returns the ID its result is reported with. The action has
+// to be allowed by the account settings and the peer connected, the actions aren't queued for the offline peers.
+func (am *DefaultAccountManager) RequestPeerAction(accountID, peerID, userID, action string) (string, error) {
+	if !slices.Contains(peerActions, action) {
+		return "", status.Errorf(status.InvalidArgument, "unknown peer action %q, expected one of %v", action, peerActions)
+	}
+
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return "", err
+	}
+	if !user.HasAdminPower() {
+		return "", status.Errorf(status.PermissionDenied, "only users with admin power can run actions on peers")
+	}
+
+	if !slices.Contains(account.Settings.PeerActions, action) {
+		return "", status.Errorf(status.PermissionDenied, "the peer action %s isn't allowed by the account settings", action)
+	}
+
+	peer := account.GetPeer(peerID)
+	if peer == nil {
+		return "", status.Errorf(status.NotFound, "peer with ID %s not found", peerID)
+	}
+	if !am.peersUpdateManager.HasChannel(peer.ID) {
+		return "", status.Errorf(status.PreconditionFailed, "peer %s is not connected", peerID)
+	}
+
+	id, err := am.sendPeerAction(account, peer, action)
+	if err != nil {
+		return "", err
+	}
+
+	meta := peer.EventMeta(am.GetDNSDomain())
+	meta["action"] = action
+	meta["action_id"] = id
+	am.StoreEvent(userID, peer.ID, accountID, activity.PeerActionRequested, meta)
+
+	return id, nil
+}
+
+// sendPeerAction sends a remote action to the connected peer and returns its ID. A resync carries the network map of
+// the peer.
+func (am *DefaultAccountManager) sendPeerAction(account *Account, peer *nbpeer.Peer, action string) (string, error) {
+	id := xid.New().String()
+	update := &UpdateMessage{Update: &proto.SyncResponse{}}
+	if action == PeerActionResync {
+		approvedPeers, err := am.GetValidatedPeers(account)
+		if err != nil {
+			return "", err
+		}
+		networkMap := account.GetPeerNetworkMap(peer.ID, am.dnsDomain, approvedPeers)
+		update = &UpdateMessage{
+			Update:     toSyncResponse(nil, peer, nil, networkMap, am.GetDNSDomain(), am.clientUpdates),
+			NetworkMap: networkMap,
+		}
+	}
+	if action == PeerActionRotateKey {
+		am.keyRotationsMux.Lock()
+		am.keyRotations[peer.ID] = keyRotation{actionID: id, requestedAt: time.Now()}
+		am.keyRotationsMux.Unlock()
+	}
+	update.Update.Actions = []*proto.PeerAction{{Id: id, Type: action}}
+	am.peersUpdateManager.SendUpdate(peer.ID, update)
+
+	return id, nil
+}
+
+// ReportPeerAction stores the result of a remote action the peer reported as an activity event
+func (am *DefaultAccountManager) ReportPeerAction(peerPubKey string, result PeerActionResult) error {
+	accountID, err := am.Store.GetAccountIDByPeerPubKey(peerPubKey)
+	if err != nil {
+		return err
+	}
+
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	peer, err := account.FindPeerByPubKey(peerPubKey)
+	if err != nil {
+		return status.Errorf(status.NotFound, "peer with key %s not found", peerPubKey)
+	}
+
+	if result.Type == PeerActionRotateKey {
+		// the peer failed to rotate its key, a late rotation of the action isn't accepted. The request time is kept
+		// for a scheduled rotation to be retried later
+		am.keyRotationsMux.Lock()
+		if rotation, ok := am.keyRotations[peer.ID]; ok && rotation.actionID == result.ID {
+			rotation.actionID = ""
+			am.keyRotations[peer.ID] = rotation
+		}
+		am.keyRotationsMux.Unlock()
+	}
+
+	if result.Type == PeerActionPing {
+		am.storePeerPingResult(peer.ID, result)
+	}
+
+	meta := peer.EventMeta(am.GetDNSDomain())
+	meta["action"] = result.Type
+	meta["action_id"] = result.ID
+	event := activity.PeerActionSucceeded
+	if result.Error != "" {
+		event = activity.PeerActionFailed
+		meta["error"] = result.Error
+	}
+	if result.DebugBundleName != "" {
+		meta["bundle"] = result.DebugBundleName
+	}
+	if result.Ping != nil {
+		meta["target_ip"] = result.Ping.IP
+		meta["sent"] = result.Ping.Sent
+		meta["received"] = result.Ping.Received
+		meta["path"] = result.Ping.Path
+	}
+	am.StoreEvent(peer.ID, peer.ID, accountID, event, meta)
+
+	return nil
+}
+
+// RotatePeerKey replaces the WireGuard public key of the peer with the one it generated for a rotate_wireguard_key
+// action, the peers connected to it get the new key with their network maps. The rotation is the result of the
+// action.
+func (am *DefaultAccountManager) RotatePeerKey(peerPubKey, actionID, newPubKey string) error {
+	key, err := wgtypes.ParseKey(newPubKey)
+	if err != nil {
+		return status.Errorf(status.InvalidArgument, "invalid WireGuard public key: %v", err)
+	}
+	newPubKey = key.String()
+
+	accountID, err := am.Store.GetAccountIDByPeerPubKey(peerPubKey)
+	if err != nil {
+		return err
+	}
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	peer, err := account.FindPeerByPubKey(peerPubKey)
+	if err != nil {
+		return status.Errorf(status.NotFound, "peer with key %s not found", peerPubKey)
+	}
+
+	am.keyRotationsMux.Lock()
+	requested := actionID != "" && am.keyRotations[peer.ID].actionID == actionID
+	if requested {
+		delete(am.keyRotations, peer.ID)
+	}
+	am.keyRotationsMux.Unlock()
+	if !requested {
+		return status.Errorf(status.PermissionDenied, "no key rotation was requested for the peer with the action %s", actionID)
+	}
+
+	if _, err := am.Store.GetAccountByPeerPubKey(newPubKey); err == nil {
+		return status.Errorf(status.AlreadyExists, "the WireGuard public key is used by another peer")
+	}
+
+	// the previous key stays valid for the grace period, a peer that couldn't keep the new key reverts the rotation
+	// by logging in with it
+	peer.PreviousKey = peerPubKey
+	peer.PreviousKeyExpiresAt = time.Now().UTC().Add(peerKeyRotationGracePeriod)
+	peer.Key = newPubKey
+	peer.KeyRotatedAt = time.Now().UTC()
+	account.UpdatePeer(peer)
+	account.Network.IncSerial()
+	if err := am.Store.SaveAccount(account); err != nil {
+		return err
+	}
+	log.Infof("peer %s rotated its WireGuard key from %s to %s", peer.ID, peerPubKey, newPubKey)
+
+	meta := peer.EventMeta(am.GetDNSDomain())
+	meta["action"] = PeerActionRotateKey
+	meta["action_id"] = actionID
+	meta["old_key"] = peerPubKey
+	am.StoreEvent(peer.ID, peer.ID, accountID, activity.PeerActionSucceeded, meta)
+
+	am.updatePeersConnectedTo(account, peer.ID)
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestValidatePeerActions(t *testing.T) {
+	assert.NoError(t, validatePeerActions(nil))
+	assert.NoError(t, validatePeerActions([]string{PeerActionResync, PeerActionCollectDebugBundle}))
+	assert.Error(t, validatePeerActions([]string{PeerActionResync, "reboot"}))
+}
+
+func TestDefaultAccountManager_RequestPeerAction(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account := newAccountWithId("test_account", userID, "")
+	account.Settings.PeerActions = []string{PeerActionResync, PeerActionRotateKey}
+	err = manager.Store.SaveAccount(account)
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	key, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+		Key:  key.PublicKey().String(),
+		Meta: nbpeer.PeerSystemMeta{Hostname: "test-router"},
+	})
+	require.NoError(t, err)
+
+	assertErrorType := func(err error, expected status.Type, msg string) {
+		t.Helper()
+		sErr, ok := status.FromError(err)
+		require.True(t, ok, msg)
+		assert.Equal(t, expected, sErr.Type(), msg)
+	}
+
+	_, err = manager.RequestPeerAction(account.Id, peer.ID, userID, "reboot")
+	assertErrorType(err, status.InvalidArgument, "an unknown action should be rejected")
+	_, err = manager.RequestPeerAction(account.Id, peer.ID, userID, PeerActionRestartEngine)
+	assertErrorType(err, status.PermissionDenied, "an action not allowed by the settings should be rejected")
+	_, err = manager.RequestPeerAction(account.Id, peer.ID, userID, PeerActionResync)
+	assertErrorType(err, status.PreconditionFailed, "an action should not be sent to a disconnected peer")
+
+	updates := manager.peersUpdateManager.CreateChannel(peer.ID)
+	defer manager.peersUpdateManager.CloseChannel(peer.ID)
+
+	id, err := manager.RequestPeerAction(account.Id, peer.ID, userID, PeerActionResync)
+	require.NoError(t, err)
+	update := <-updates
+	require.Len(t, update.Update.Actions, 1)
+	assert.Equal(t, id, update.Update.Actions[0].Id)
+	assert.Equal(t, PeerActionResync, update.Update.Actions[0].Type)
+	assert.NotNil(t, update.Update.NetworkMap, "a resync should carry the network map of the peer")
+
+	ev := getEvent(t, account.Id, manager, activity.PeerActionRequested)
+	assert.Equal(t, peer.ID, ev.TargetID)
+	assert.Equal(t, id, ev.Meta["action_id"])
+
+	require.NoError(t, manager.ReportPeerAction(peer.Key, PeerActionResult{ID: id, Type: PeerActionResync, Error: "failed"}))
+	ev = getEvent(t, account.Id, manager, activity.PeerActionFailed)
+	assert.Equal(t, "failed", ev.Meta["error"])
+
+	newKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	err = manager.RotatePeerKey(peer.Key, id, newKey.PublicKey().String())
+	assertErrorType(err, status.PermissionDenied, "a key should only be rotated when requested")
+
+	id, err = manager.RequestPeerAction(account.Id, peer.ID, userID, PeerActionRotateKey)
+	require.NoError(t, err)
+	<-updates
+
+	require.NoError(t, manager.RotatePeerKey(peer.Key, id, newKey.PublicKey().String()))
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, newKey.PublicKey().String(), account.GetPeer(peer.ID).Key)
+
+	err = manager.RotatePeerKey(newKey.PublicKey().String(), id, key.PublicKey().String())
+	assertErrorType(err, status.PermissionDenied, "a rotation should only be accepted once")
+}
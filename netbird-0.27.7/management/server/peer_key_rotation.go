@@ -0,0 +1,179 @@
+package server
+
+import (
+	"slices"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+const (
+	minPeerKeyRotation = time.Hour
+	// peerKeyRotationGracePeriod is the time the previous key of a peer stays valid after a rotation
+	peerKeyRotationGracePeriod = 24 * time.Hour
+	// peerKeyRotationRetry is the time after which a peer that didn't rotate its key is asked again
+	peerKeyRotationRetry = time.Hour
+	// minPeerKeyRotationDelay leaves the peers that were due while offline the time to set up their sync stream
+	minPeerKeyRotationDelay = time.Minute
+)
+
+// keyRotation is a rotate_wireguard_key action sent to a peer
+type keyRotation struct {
+	// actionID is empty once the peer reported a failure, its rotation isn't accepted anymore
+	actionID    string
+	requestedAt time.Time
+}
+
+func (am *DefaultAccountManager) peerKeyRotationJob(accountID string) func() (time.Duration, bool) {
+	return func() (time.Duration, bool) {
+		unlock := am.Store.AcquireAccountReadLock(accountID)
+		defer unlock()
+
+		account, err := am.Store.GetAccount(accountID)
+		if err != nil {
+			log.Errorf("failed getting account %s rotating peer keys: %v", accountID, err)
+			return 0, false
+		}
+
+		interval := account.Settings.PeerKeyRotation
+		for _, peer := range account.Peers {
+			if !peer.Status.Connected || am.nextPeerKeyRotation(peer, interval) > 0 ||
+				!am.peersUpdateManager.HasChannel(peer.ID) {
+				continue
+			}
+			id, err := am.sendPeerAction(account, peer, PeerActionRotateKey)
+			if err != nil {
+				log.Errorf("failed requesting the key rotation of peer %s: %v", peer.ID, err)
+				continue
+			}
+			log.Debugf("requested the scheduled key rotation %s of peer %s", id, peer.ID)
+		}
+
+		return am.getNextPeerKeyRotation(account)
+	}
+}
+
+// getNextPeerKeyRotation returns the time until a connected peer of the account has to rotate its key next, false if
+// the rotation is disabled or no peer is connected
+func (am *DefaultAccountManager) getNextPeerKeyRotation(account *Account) (time.Duration, bool) {
+	interval := account.Settings.PeerKeyRotation
+	if interval == 0 {
+		return 0, false
+	}
+
+	var next *time.Duration
+	for _, peer := range account.Peers {
+		// the peers that aren't connected are asked on connecting
+		if !peer.Status.Connected {
+			continue
+		}
+		in := am.nextPeerKeyRotation(peer, interval)
+		if next == nil || in < *next {
+			next = &in
+		}
+	}
+
+	if next == nil {
+		return 0, false
+	}
+	return max(*next, minPeerKeyRotationDelay), true
+}
+
+// nextPeerKeyRotation returns the time until the peer has to rotate its key, a peer asked recently isn't asked again
+// before peerKeyRotationRetry
+func (am *DefaultAccountManager) nextPeerKeyRotation(peer *nbpeer.Peer, interval time.Duration) time.Duration {
+	_, in := peer.KeyRotationDue(interval)
+
+	am.keyRotationsMux.Lock()
+	rotation, ok := am.keyRotations[peer.ID]
+	am.keyRotationsMux.Unlock()
+	if ok {
+		in = max(in, time.Until(rotation.requestedAt.Add(peerKeyRotationRetry)))
+	}
+	return in
+}
+
+func (am *DefaultAccountManager) checkAndSchedulePeerKeyRotation(account *Account) {
+	am.peerKeyRotation.Cancel([]string{account.Id})
+	if nextRun, ok := am.getNextPeerKeyRotation(account); ok {
+		go am.peerKeyRotation.Schedule(nextRun, account.Id, am.peerKeyRotationJob(account.Id))
+	}
+}
+
+// revertPeerKeyRotation restores the previous key of a peer logging in with it in the grace period of a rotation, the
+// peer didn't keep the key it rotated to, e.g. it lost power before saving it
+func (am *DefaultAccountManager) revertPeerKeyRotation(peerPubKey string) error {
+	accountID, err := am.Store.GetAccountIDByPeerPubKey(peerPubKey)
+	if err != nil {
+		// an unknown key, the login registers the peer
+		return nil
+	}
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	peers := account.GetPeers()
+	i := slices.IndexFunc(peers, func(peer *nbpeer.Peer) bool {
+		return peer.PreviousKey == peerPubKey
+	})
+	if i < 0 {
+		return nil
+	}
+	peer := peers[i].Copy()
+	if time.Now().After(peer.PreviousKeyExpiresAt) {
+		return nil
+	}
+
+	rotatedKey := peer.Key
+	peer.Key = peer.PreviousKey
+	peer.PreviousKey = ""
+	peer.PreviousKeyExpiresAt = time.Time{}
+	// the rotation is requested again
+	peer.KeyRotatedAt = time.Time{}
+	account.UpdatePeer(peer)
+	account.Network.IncSerial()
+	if err := am.Store.SaveAccount(account); err != nil {
+		return err
+	}
+	log.Infof("peer %s logged in with its previous WireGuard key %s, reverted the rotation to %s", peer.ID,
+		peerPubKey, rotatedKey)
+
+	meta := peer.EventMeta(am.GetDNSDomain())
+	meta["rotated_key"] = rotatedKey
+	am.StoreEvent(peer.ID, peer.ID, accountID, activity.PeerKeyRotationReverted, meta)
+
+	am.updatePeersConnectedTo(account, peer.ID)
+
+	return nil
+}
+
+// updatePeersConnectedTo sends the network maps to the connected peers that have the peer in theirs, e.g. after it
+// changed its key. The other peers aren't affected.
+func (am *DefaultAccountManager) updatePeersConnectedTo(account *Account, peerID string) {
+	approvedPeersMap, err := am.GetValidatedPeers(account)
+	if err != nil {
+		log.Errorf("failed send out updates to peers, failed to validate peer: %v", err)
+		return
+	}
+
+	isPeer := func(peer *nbpeer.Peer) bool { return peer.ID == peerID }
+	for _, peer := range account.GetPeers() {
+		if peer.ID == peerID || !am.peersUpdateManager.HasChannel(peer.ID) {
+			continue
+		}
+		networkMap := account.GetPeerNetworkMap(peer.ID, am.dnsDomain, approvedPeersMap)
+		if !slices.ContainsFunc(networkMap.Peers, isPeer) && !slices.ContainsFunc(networkMap.OfflinePeers, isPeer) {
+			continue
+		}
+		update := toSyncResponse(nil, peer, nil, networkMap, am.GetDNSDomain(), am.clientUpdates)
+		am.peersUpdateManager.SendUpdate(peer.ID, &UpdateMessage{Update: update, NetworkMap: networkMap})
+	}
+}
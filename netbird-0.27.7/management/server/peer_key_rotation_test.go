@@ -0,0 +1,86 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+func TestDefaultAccountManager_PeerKeyRotation(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account := newAccountWithId("test_account", userID, "")
+	account.Settings.PeerKeyRotation = time.Hour
+	err = manager.Store.SaveAccount(account)
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	key, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+		Key:  key.PublicKey().String(),
+		Meta: nbpeer.PeerSystemMeta{Hostname: "test-router"},
+	})
+	require.NoError(t, err)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	_, ok := manager.getNextPeerKeyRotation(account)
+	assert.False(t, ok, "no rotation should be scheduled without connected peers")
+
+	account.Peers[peer.ID].CreatedAt = time.Now().Add(-2 * time.Hour)
+	account.Peers[peer.ID].Status.Connected = true
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	next, ok := manager.getNextPeerKeyRotation(account)
+	require.True(t, ok)
+	assert.Equal(t, minPeerKeyRotationDelay, next, "an overdue peer should be rotated once its sync stream is set up")
+
+	updates := manager.peersUpdateManager.CreateChannel(peer.ID)
+	defer manager.peersUpdateManager.CloseChannel(peer.ID)
+
+	next, ok = manager.peerKeyRotationJob(account.Id)()
+	require.True(t, ok)
+	assert.Greater(t, next, peerKeyRotationRetry-time.Minute, "a requested peer should not be asked again before the retry")
+	update := <-updates
+	require.Len(t, update.Update.Actions, 1)
+	assert.Equal(t, PeerActionRotateKey, update.Update.Actions[0].Type)
+
+	newKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	require.NoError(t, manager.RotatePeerKey(peer.Key, update.Update.Actions[0].Id, newKey.PublicKey().String()))
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	rotated := account.GetPeer(peer.ID)
+	assert.Equal(t, newKey.PublicKey().String(), rotated.Key)
+	assert.Equal(t, peer.Key, rotated.PreviousKey)
+	due, _ := rotated.KeyRotationDue(account.Settings.PeerKeyRotation)
+	assert.False(t, due)
+
+	accountID, err := manager.Store.GetAccountIDByPeerPubKey(peer.Key)
+	require.NoError(t, err)
+	assert.Equal(t, account.Id, accountID, "the previous key should resolve in the grace period")
+
+	require.NoError(t, manager.revertPeerKeyRotation(peer.Key))
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	reverted := account.GetPeer(peer.ID)
+	assert.Equal(t, peer.Key, reverted.Key, "a login with the previous key should revert the rotation")
+	assert.Empty(t, reverted.PreviousKey)
+	getEvent(t, account.Id, manager, activity.PeerKeyRotationReverted)
+
+	require.NoError(t, manager.revertPeerKeyRotation(newKey.PublicKey().String()))
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, peer.Key, account.GetPeer(peer.ID).Key, "the rotated key should not revert the rotation back")
+}
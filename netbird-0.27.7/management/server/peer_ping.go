@@ -0,0 +1,204 @@
+package server
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/netbirdio/netbird/management/proto"
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// PeerActionPing makes the peer probe another peer over the WireGuard tunnel. Unlike the other remote actions it
+// doesn't change the peer and isn't subject to the PeerActions of the account settings.
+const PeerActionPing = "ping_peer"
+
+// peerPingTTL is the time the result of a ping is kept for after it was requested
+const peerPingTTL = 10 * time.Minute
+
+// PeerPingResult is the outcome of a probe a peer ran over the WireGuard tunnel
+type PeerPingResult struct {
+	// IP and FQDN are the addresses the target was probed on
+	IP   string
+	FQDN string
+	// Sent and Received are the numbers of the echo requests sent and the replies received
+	Sent     int
+	Received int
+	// RTTs are the round trip times of the received replies
+	RTTs []time.Duration
+	// Path is how the peer reaches the target: p2p, relayed or disconnected
+	Path string
+	// LocalCandidate and RemoteCandidate are the ICE candidate types of the connection
+	LocalCandidate  string
+	RemoteCandidate string
+}
+
+// PeerPing is a probe of a peer that a user requested another peer to run
+type PeerPing struct {
+	ID           string
+	PeerID       string
+	TargetPeerID string
+	RequestedAt  time.Time
+	// Done is set once the peer reported the probe, Error is the reason it failed
+	Done   bool
+	Error  string
+	Result *PeerPingResult
+}
+
+// Copy returns a copy of the ping
+func (p *PeerPing) Copy() *PeerPing {
+	ping := *p
+	if p.Result != nil {
+		result := *p.Result
+		result.RTTs = slices.Clone(p.Result.RTTs)
+		ping.Result = &result
+	}
+	return &ping
+}
+
+// RequestPeerPing makes the peer probe the target peer over the tunnel and returns the ID the result is read with.
+// Both peers have to be in the account and the probing peer connected.
+func (am *DefaultAccountManager) RequestPeerPing(accountID, peerID, userID, targetPeerID string) (string, error) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return "", err
+	}
+	if !user.HasAdminPower() {
+		return "", status.Errorf(status.PermissionDenied, "only users with admin power can ping peers")
+	}
+
+	peer := account.GetPeer(peerID)
+	if peer == nil {
+		return "", status.Errorf(status.NotFound, "peer with ID %s not found", peerID)
+	}
+	target := account.GetPeer(targetPeerID)
+	if target == nil {
+		return "", status.Errorf(status.NotFound, "peer with ID %s not found", targetPeerID)
+	}
+	if target.ID == peer.ID {
+		return "", status.Errorf(status.InvalidArgument, "a peer can't ping itself")
+	}
+	if !am.peersUpdateManager.HasChannel(peer.ID) {
+		return "", status.Errorf(status.PreconditionFailed, "peer %s is not connected", peerID)
+	}
+
+	id := xid.New().String()
+	am.peerPingsMux.Lock()
+	for pingID, ping := range am.peerPings {
+		if time.Since(ping.RequestedAt) > peerPingTTL {
+			delete(am.peerPings, pingID)
+		}
+	}
+	am.peerPings[id] = &PeerPing{ID: id, PeerID: peer.ID, TargetPeerID: target.ID, RequestedAt: time.Now().UTC()}
+	am.peerPingsMux.Unlock()
+
+	action := &proto.PeerAction{Id: id, Type: PeerActionPing, Target: target.IP.String()}
+	am.peersUpdateManager.SendUpdate(peer.ID, &UpdateMessage{Update: &proto.SyncResponse{Actions: []*proto.PeerAction{action}}})
+
+	meta := peer.EventMeta(am.GetDNSDomain())
+	meta["action"] = PeerActionPing
+	meta["action_id"] = id
+	meta["target"] = target.FQDN(am.GetDNSDomain())
+	meta["target_ip"] = target.IP
+	am.StoreEvent(userID, peer.ID, accountID, activity.PeerActionRequested, meta)
+
+	return id, nil
+}
+
+// GetPeerPing returns a ping the peer was requested to run to the users that can view the peer, it isn't done until
+// the peer reported its result
+func (am *DefaultAccountManager) GetPeerPing(accountID, peerID, userID, pingID string) (*PeerPing, error) {
+	if _, err := am.GetPeer(accountID, peerID, userID); err != nil {
+		return nil, err
+	}
+
+	am.peerPingsMux.Lock()
+	defer am.peerPingsMux.Unlock()
+
+	ping, ok := am.peerPings[pingID]
+	if !ok || ping.PeerID != peerID || time.Since(ping.RequestedAt) > peerPingTTL {
+		return nil, status.Errorf(status.NotFound, "ping %s of peer %s not found", pingID, peerID)
+	}
+	return ping.Copy(), nil
+}
+
+// storePeerPingResult completes the ping with the result the peer reported
+func (am *DefaultAccountManager) storePeerPingResult(peerID string, result PeerActionResult) {
+	am.peerPingsMux.Lock()
+	defer am.peerPingsMux.Unlock()
+
+	ping, ok := am.peerPings[result.ID]
+	if !ok || ping.PeerID != peerID || ping.Done {
+		return
+	}
+	ping.Done = true
+	ping.Error = result.Error
+	ping.Result = result.Ping
+}
+
+// ResolvePeer looks up the target among the peers of the account of the requesting peer, by its ID, name, DNS label,
+// FQDN, IP or WireGuard key. The returned bool tells whether the policies let the requesting peer reach the target.
+func (am *DefaultAccountManager) ResolvePeer(peerPubKey, target string) (*nbpeer.Peer, bool, error) {
+	target = strings.TrimSuffix(strings.TrimSpace(target), ".")
+	if target == "" {
+		return nil, false, status.Errorf(status.InvalidArgument, "the peer to resolve is empty")
+	}
+
+	accountID, err := am.Store.GetAccountIDByPeerPubKey(peerPubKey)
+	if err != nil {
+		return nil, false, err
+	}
+
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	peer, err := account.FindPeerByPubKey(peerPubKey)
+	if err != nil {
+		return nil, false, status.Errorf(status.NotFound, "peer with key %s not found", peerPubKey)
+	}
+
+	var found *nbpeer.Peer
+	for _, p := range account.Peers {
+		if p.ID == target || p.Key == target || p.IP.String() == target ||
+			strings.EqualFold(p.DNSLabel, target) || strings.EqualFold(p.FQDN(am.GetDNSDomain()), target) ||
+			strings.EqualFold(p.Name, target) {
+			found = p
+			break
+		}
+	}
+	if found == nil || found.ID == peer.ID {
+		return nil, false, status.Errorf(status.NotFound, "peer %s not found", target)
+	}
+
+	approvedPeers, err := am.GetValidatedPeers(account)
+	if err != nil {
+		return nil, false, err
+	}
+	networkMap := account.GetPeerNetworkMap(peer.ID, am.GetDNSDomain(), approvedPeers)
+	accessible := false
+	for _, p := range append(networkMap.Peers, networkMap.OfflinePeers...) {
+		if p.ID == found.ID {
+			accessible = true
+			break
+		}
+	}
+
+	return found.Copy(), accessible, nil
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+func TestDefaultAccountManager_PeerPing(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	addPeer := func(hostname string) *nbpeer.Peer {
+		t.Helper()
+		key, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+		peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+			Key:  key.PublicKey().String(),
+			Meta: nbpeer.PeerSystemMeta{Hostname: hostname},
+		})
+		require.NoError(t, err)
+		return peer
+	}
+	router := addPeer("office-router")
+	laptop := addPeer("laptop")
+
+	for _, target := range []string{laptop.ID, laptop.IP.String(), laptop.DNSLabel, laptop.FQDN(manager.GetDNSDomain()) + ".", "LAPTOP"} {
+		resolved, accessible, err := manager.ResolvePeer(router.Key, target)
+		require.NoError(t, err, target)
+		assert.Equal(t, laptop.ID, resolved.ID, target)
+		assert.True(t, accessible, "the default policy should let the router reach %s", target)
+	}
+	_, _, err = manager.ResolvePeer(router.Key, "unknown")
+	assert.Error(t, err)
+	_, _, err = manager.ResolvePeer(router.Key, router.DNSLabel)
+	assert.Error(t, err, "a peer should not resolve itself")
+
+	_, err = manager.RequestPeerPing(account.Id, router.ID, userID, laptop.ID)
+	assert.Error(t, err, "a ping should not be sent to a disconnected peer")
+	_, err = manager.RequestPeerPing(account.Id, router.ID, userID, router.ID)
+	assert.Error(t, err, "a peer should not ping itself")
+
+	updates := manager.peersUpdateManager.CreateChannel(router.ID)
+	defer manager.peersUpdateManager.CloseChannel(router.ID)
+
+	id, err := manager.RequestPeerPing(account.Id, router.ID, userID, laptop.ID)
+	require.NoError(t, err)
+	update := <-updates
+	require.Len(t, update.Update.Actions, 1)
+	assert.Equal(t, PeerActionPing, update.Update.Actions[0].Type)
+	assert.Equal(t, laptop.IP.String(), update.Update.Actions[0].Target)
+
+	ping, err := manager.GetPeerPing(account.Id, router.ID, userID, id)
+	require.NoError(t, err)
+	assert.False(t, ping.Done)
+	_, err = manager.GetPeerPing(account.Id, laptop.ID, userID, id)
+	assert.Error(t, err, "a ping should only be read on the peer that runs it")
+
+	result := &PeerPingResult{IP: laptop.IP.String(), Sent: 2, Received: 2, RTTs: []time.Duration{time.Millisecond, 2 * time.Millisecond}, Path: "p2p"}
+	require.NoError(t, manager.ReportPeerAction(laptop.Key, PeerActionResult{ID: id, Type: PeerActionPing, Ping: result}))
+	ping, err = manager.GetPeerPing(account.Id, router.ID, userID, id)
+	require.NoError(t, err)
+	assert.False(t, ping.Done, "another peer should not report the ping")
+
+	require.NoError(t, manager.ReportPeerAction(router.Key, PeerActionResult{ID: id, Type: PeerActionPing, Ping: result}))
+	ping, err = manager.GetPeerPing(account.Id, router.ID, userID, id)
+	require.NoError(t, err)
+	assert.True(t, ping.Done)
+	assert.Equal(t, result, ping.Result)
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"sort"
+
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// PeerQuotaUsage is the number of peers registered by the users and with the setup keys of an account against their
+// limits
+type PeerQuotaUsage struct {
+	// PeersPerUserLimit is the maximum number of peers of each user, 0 is unlimited
+	PeersPerUserLimit int
+	Users             []UserPeerQuota
+	SetupKeys         []SetupKeyPeerQuota
+}
+
+// UserPeerQuota is the number of peers registered by a user
+type UserPeerQuota struct {
+	UserID string
+	Peers  int
+	Limit  int
+}
+
+// SetupKeyPeerQuota is the number of peers registered with a setup key
+type SetupKeyPeerQuota struct {
+	SetupKeyID string
+	Name       string
+	Peers      int
+	Limit      int
+}
+
+// countUserPeers returns the number of peers registered by the user
+func (a *Account) countUserPeers(userID string) int {
+	count := 0
+	for _, peer := range a.Peers {
+		if peer.UserID == userID {
+			count++
+		}
+	}
+	return count
+}
+
+// countSetupKeyPeers returns the number of peers registered with the setup key
+func (a *Account) countSetupKeyPeers(key string) int {
+	count := 0
+	for _, peer := range a.Peers {
+		if peer.SetupKey == key {
+			count++
+		}
+	}
+	return count
+}
+
+// checkUserPeerQuota returns an error when the user has already registered as many peers as the account allows
+func (a *Account) checkUserPeerQuota(userID string) error {
+	limit := a.Settings.PeersPerUserLimit
+	if limit > 0 && a.countUserPeers(userID) >= limit {
+		return status.Errorf(status.ResourceExhausted, "user %s reached the limit of %d peers, remove a peer before adding a new one", userID, limit)
+	}
+	return nil
+}
+
+// checkSetupKeyPeerQuota returns an error when as many peers as the setup key allows are already registered with it
+func (a *Account) checkSetupKeyPeerQuota(key *SetupKey) error {
+	if key.PeerLimit > 0 && a.countSetupKeyPeers(key.Key) >= key.PeerLimit {
+		return status.Errorf(status.ResourceExhausted, "setup key %s reached the limit of %d peers", key.Name, key.PeerLimit)
+	}
+	return nil
+}
+
+// GetPeerQuotaUsage returns the number of peers of every user and setup key of the account along with their limits,
+// sorted by user and setup key ID. Only users with admin power can view it.
+func (am *DefaultAccountManager) GetPeerQuotaUsage(accountID, userID string) (*PeerQuotaUsage, error) {
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view the peer quotas")
+	}
+
+	usage := &PeerQuotaUsage{
+		PeersPerUserLimit: account.Settings.PeersPerUserLimit,
+		Users:             make([]UserPeerQuota, 0, len(account.Users)),
+		SetupKeys:         make([]SetupKeyPeerQuota, 0, len(account.SetupKeys)),
+	}
+
+	for _, accountUser := range account.Users {
+		if accountUser.IsServiceUser {
+			continue
+		}
+		usage.Users = append(usage.Users, UserPeerQuota{
+			UserID: accountUser.Id,
+			Peers:  account.countUserPeers(accountUser.Id),
+			Limit:  account.Settings.PeersPerUserLimit,
+		})
+	}
+	sort.Slice(usage.Users, func(i, j int) bool {
+		return usage.Users[i].UserID < usage.Users[j].UserID
+	})
+
+	for _, key := range account.SetupKeys {
+		usage.SetupKeys = append(usage.SetupKeys, SetupKeyPeerQuota{
+			SetupKeyID: key.Id,
+			Name:       key.Name,
+			Peers:      account.countSetupKeyPeers(key.Key),
+			Limit:      key.PeerLimit,
+		})
+	}
+	sort.Slice(usage.SetupKeys, func(i, j int) bool {
+		return usage.SetupKeys[i].SetupKeyID < usage.SetupKeys[j].SetupKeyID
+	})
+
+	return usage, nil
+}
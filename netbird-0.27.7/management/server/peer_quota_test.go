@@ -0,0 +1,95 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func newQuotaTestPeer(t *testing.T, hostname string) *nbpeer.Peer {
+	t.Helper()
+	key, err := wgtypes.GenerateKey()
+	require.NoError(t, err, "unable to generate WireGuard key")
+	return &nbpeer.Peer{Key: key.PublicKey().String(), Meta: nbpeer.PeerSystemMeta{Hostname: hostname}}
+}
+
+func requireResourceExhausted(t *testing.T, err error) {
+	t.Helper()
+	require.Error(t, err, "the registration over the limit should be rejected")
+	sErr, ok := status.FromError(err)
+	require.True(t, ok, "expected a status error, got %v", err)
+	assert.Equal(t, status.ResourceExhausted, sErr.Type())
+}
+
+func TestDefaultAccountManager_AddPeer_SetupKeyPeerLimit(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err, "unable to create an account")
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err, "unable to create a setup key")
+
+	setupKey.PeerLimit = -1
+	_, err = manager.SaveSetupKey(account.Id, setupKey, userID)
+	require.Error(t, err, "a negative peer limit should be rejected")
+
+	setupKey.PeerLimit = 2
+	_, err = manager.SaveSetupKey(account.Id, setupKey, userID)
+	require.NoError(t, err, "unable to set the peer limit of the setup key")
+	ev := getEvent(t, account.Id, manager, activity.SetupKeyPeerLimitUpdated)
+	assert.Equal(t, 2, ev.Meta["limit"])
+
+	first, _, err := manager.AddPeer(setupKey.Key, "", newQuotaTestPeer(t, "first"))
+	require.NoError(t, err, "unable to add the first peer")
+	_, _, err = manager.AddPeer(setupKey.Key, "", newQuotaTestPeer(t, "second"))
+	require.NoError(t, err, "unable to add the second peer")
+
+	_, _, err = manager.AddPeer(setupKey.Key, "", newQuotaTestPeer(t, "third"))
+	requireResourceExhausted(t, err)
+
+	// a deleted peer frees its slot
+	require.NoError(t, manager.DeletePeer(account.Id, first.ID, userID))
+	_, _, err = manager.AddPeer(setupKey.Key, "", newQuotaTestPeer(t, "third"))
+	require.NoError(t, err, "the peer should be added once a slot is free")
+
+	usage, err := manager.GetPeerQuotaUsage(account.Id, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []SetupKeyPeerQuota{{SetupKeyID: setupKey.Id, Name: "test-key", Peers: 2, Limit: 2}}, usage.SetupKeys)
+}
+
+func TestDefaultAccountManager_AddPeer_PeersPerUserLimit(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err, "unable to create an account")
+
+	settings := account.Settings.Copy()
+	settings.PeersPerUserLimit = 1
+	_, err = manager.UpdateAccountSettings(account.Id, userID, settings)
+	require.NoError(t, err, "unable to update the account settings")
+	ev := getEvent(t, account.Id, manager, activity.AccountPeersPerUserLimitUpdated)
+	assert.Equal(t, 1, ev.Meta["limit"])
+
+	_, _, err = manager.AddPeer("", userID, newQuotaTestPeer(t, "laptop"))
+	require.NoError(t, err, "unable to add the first peer of the user")
+
+	_, _, err = manager.AddPeer("", userID, newQuotaTestPeer(t, "desktop"))
+	requireResourceExhausted(t, err)
+
+	usage, err := manager.GetPeerQuotaUsage(account.Id, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, usage.PeersPerUserLimit)
+	assert.Equal(t, []UserPeerQuota{{UserID: userID, Peers: 1, Limit: 1}}, usage.Users)
+
+	settings.PeersPerUserLimit = -1
+	_, err = manager.UpdateAccountSettings(account.Id, userID, settings)
+	require.Error(t, err, "a negative limit should be rejected")
+}
@@ -0,0 +1,286 @@
+package server
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// UpdatePeerTransferStats stores the transfer statistics reported by the peer identified by its WireGuard public key.
+// The statistics are kept in memory only and replace the previously reported ones.
+func (am *DefaultAccountManager) UpdatePeerTransferStats(peerPubKey string, stats []nbpeer.TransferStats) error {
+	if _, err := am.Store.GetAccountIDByPeerPubKey(peerPubKey); err != nil {
+		return err
+	}
+
+	am.transferStatsMux.Lock()
+	defer am.transferStatsMux.Unlock()
+
+	if am.transferStats == nil {
+		am.transferStats = make(map[string][]nbpeer.TransferStats)
+	}
+	am.transferStats[peerPubKey] = stats
+
+	return nil
+}
+
+// GetPeerTransferStats returns the latest transfer statistics reported by the peer.
+// The user must be allowed to see the peer.
+func (am *DefaultAccountManager) GetPeerTransferStats(accountID, peerID, userID string) ([]nbpeer.TransferStats, error) {
+	peer, err := am.GetPeer(accountID, peerID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	am.transferStatsMux.RLock()
+	defer am.transferStatsMux.RUnlock()
+
+	return slices.Clone(am.transferStats[peer.Key]), nil
+}
+
+func (am *DefaultAccountManager) deletePeerTransferStats(peerPubKey string) {
+	am.transferStatsMux.Lock()
+	defer am.transferStatsMux.Unlock()
+
+	delete(am.transferStats, peerPubKey)
+}
+
+// RelayedConnection is a connection between two peers of an account going through a TURN relay, PeerID being the
+// lowest of the peer IDs
+type RelayedConnection struct {
+	PeerID       string
+	RemotePeerID string
+}
+
+// GetRelayedConnections returns the connections between the peers of the account they last reported as relayed,
+// each pair of peers once whether one or both of them reported it. Only users with admin power can view them.
+func (am *DefaultAccountManager) GetRelayedConnections(accountID, userID string) ([]RelayedConnection, error) {
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view relayed connections")
+	}
+
+	peersByKey := make(map[string]*nbpeer.Peer, len(account.Peers))
+	for _, peer := range account.Peers {
+		peersByKey[peer.Key] = peer
+	}
+
+	am.transferStatsMux.RLock()
+	defer am.transferStatsMux.RUnlock()
+
+	pairs := make(map[RelayedConnection]struct{})
+	for _, peer := range account.Peers {
+		for _, peerStats := range am.transferStats[peer.Key] {
+			remotePeer, ok := peersByKey[peerStats.RemotePeerKey]
+			if !peerStats.Relayed || !ok {
+				continue
+			}
+			conn := RelayedConnection{PeerID: peer.ID, RemotePeerID: remotePeer.ID}
+			if conn.RemotePeerID < conn.PeerID {
+				conn.PeerID, conn.RemotePeerID = conn.RemotePeerID, conn.PeerID
+			}
+			pairs[conn] = struct{}{}
+		}
+	}
+
+	conns := make([]RelayedConnection, 0, len(pairs))
+	for conn := range pairs {
+		conns = append(conns, conn)
+	}
+	sort.Slice(conns, func(i, j int) bool {
+		if conns[i].PeerID != conns[j].PeerID {
+			return conns[i].PeerID < conns[j].PeerID
+		}
+		return conns[i].RemotePeerID < conns[j].RemotePeerID
+	})
+	return conns, nil
+}
+
+// CountRelayedConnections returns the number of connections between peers last reported as relayed, over all the
+// accounts
+func (am *DefaultAccountManager) CountRelayedConnections() int {
+	am.transferStatsMux.RLock()
+	defer am.transferStatsMux.RUnlock()
+
+	pairs := make(map[[2]string]struct{})
+	for peerKey, stats := range am.transferStats {
+		for _, peerStats := range stats {
+			if !peerStats.Relayed {
+				continue
+			}
+			pair := [2]string{peerKey, peerStats.RemotePeerKey}
+			if pair[1] < pair[0] {
+				pair[0], pair[1] = pair[1], pair[0]
+			}
+			pairs[pair] = struct{}{}
+		}
+	}
+	return len(pairs)
+}
+
+// UpdatePeerDNSStats stores the DNS query counters reported by the peer identified by its WireGuard public key.
+// The counters are kept in memory only and replace the previously reported ones.
+func (am *DefaultAccountManager) UpdatePeerDNSStats(peerPubKey string, stats nbpeer.DNSStats) error {
+	if _, err := am.Store.GetAccountIDByPeerPubKey(peerPubKey); err != nil {
+		return err
+	}
+
+	am.dnsStatsMux.Lock()
+	defer am.dnsStatsMux.Unlock()
+
+	if am.dnsStats == nil {
+		am.dnsStats = make(map[string]nbpeer.DNSStats)
+	}
+	am.dnsStats[peerPubKey] = stats
+
+	return nil
+}
+
+// GetPeerDNSStats returns the latest DNS query counters reported by the peer.
+// The user must be allowed to see the peer.
+func (am *DefaultAccountManager) GetPeerDNSStats(accountID, peerID, userID string) (*nbpeer.DNSStats, error) {
+	peer, err := am.GetPeer(accountID, peerID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	am.dnsStatsMux.RLock()
+	defer am.dnsStatsMux.RUnlock()
+
+	stats, ok := am.dnsStats[peer.Key]
+	if !ok {
+		return nil, status.Errorf(status.NotFound, "peer %s hasn't reported DNS stats, its DNS query log may be disabled", peerID)
+	}
+	return &stats, nil
+}
+
+func (am *DefaultAccountManager) deletePeerDNSStats(peerPubKey string) {
+	am.dnsStatsMux.Lock()
+	defer am.dnsStatsMux.Unlock()
+
+	delete(am.dnsStats, peerPubKey)
+}
+
+// PolicyStats is the traffic matched by the firewall rules of a policy, summed over the peers that reported it
+type PolicyStats struct {
+	PolicyID string
+	Packets  uint64
+	Bytes    uint64
+	// Peers is the number of peers that reported the counters of the policy
+	Peers int
+	// LastReportedAt is when the latest counters were reported, zero when no peer reported them
+	LastReportedAt time.Time
+}
+
+// UpdatePeerFirewallStats stores the firewall rule counters reported by the peer identified by its WireGuard public key.
+// The counters are kept in memory only and replace the previously reported ones.
+func (am *DefaultAccountManager) UpdatePeerFirewallStats(peerPubKey string, stats []nbpeer.FirewallStats) error {
+	if _, err := am.Store.GetAccountIDByPeerPubKey(peerPubKey); err != nil {
+		return err
+	}
+
+	am.firewallStatsMux.Lock()
+	defer am.firewallStatsMux.Unlock()
+
+	if am.firewallStats == nil {
+		am.firewallStats = make(map[string][]nbpeer.FirewallStats)
+	}
+	am.firewallStats[peerPubKey] = stats
+
+	return nil
+}
+
+// GetPolicyStats returns the traffic matched by the firewall rules of the policy on the peers of the account.
+// The user must be allowed to see the policy.
+func (am *DefaultAccountManager) GetPolicyStats(accountID, policyID, userID string) (*PolicyStats, error) {
+	if _, err := am.GetPolicy(accountID, policyID, userID); err != nil {
+		return nil, err
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	am.firewallStatsMux.RLock()
+	defer am.firewallStatsMux.RUnlock()
+
+	stats := &PolicyStats{PolicyID: policyID}
+	for _, peer := range account.Peers {
+		for _, peerStats := range am.firewallStats[peer.Key] {
+			if peerStats.PolicyID != policyID {
+				continue
+			}
+			stats.Packets += peerStats.Packets
+			stats.Bytes += peerStats.Bytes
+			stats.Peers++
+			if peerStats.ReportedAt.After(stats.LastReportedAt) {
+				stats.LastReportedAt = peerStats.ReportedAt
+			}
+		}
+	}
+	return stats, nil
+}
+
+func (am *DefaultAccountManager) deletePeerFirewallStats(peerPubKey string) {
+	am.firewallStatsMux.Lock()
+	defer am.firewallStatsMux.Unlock()
+
+	delete(am.firewallStats, peerPubKey)
+}
+
+// UpdatePeerDroppedConnections stores the dropped connections reported by the peer identified by its WireGuard public key.
+// The connections are kept in memory only and replace the previously reported ones.
+func (am *DefaultAccountManager) UpdatePeerDroppedConnections(peerPubKey string, conns []nbpeer.DroppedConnection) error {
+	if _, err := am.Store.GetAccountIDByPeerPubKey(peerPubKey); err != nil {
+		return err
+	}
+
+	am.diagnosticsMux.Lock()
+	defer am.diagnosticsMux.Unlock()
+
+	if am.diagnostics == nil {
+		am.diagnostics = make(map[string]nbpeer.Diagnostics)
+	}
+	diagnostics := am.diagnostics[peerPubKey]
+	diagnostics.DroppedConnections = conns
+	diagnostics.DroppedConnectionsReportedAt = time.Now().UTC()
+	am.diagnostics[peerPubKey] = diagnostics
+
+	return nil
+}
+
+// GetPeerDiagnostics returns the diagnostic data last reported by the peer, empty when it didn't report any.
+// The user must be allowed to see the peer.
+func (am *DefaultAccountManager) GetPeerDiagnostics(accountID, peerID, userID string) (*nbpeer.Diagnostics, error) {
+	peer, err := am.GetPeer(accountID, peerID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	am.diagnosticsMux.RLock()
+	defer am.diagnosticsMux.RUnlock()
+
+	diagnostics := am.diagnostics[peer.Key]
+	diagnostics.DroppedConnections = slices.Clone(diagnostics.DroppedConnections)
+	return &diagnostics, nil
+}
+
+func (am *DefaultAccountManager) deletePeerDiagnostics(peerPubKey string) {
+	am.diagnosticsMux.Lock()
+	defer am.diagnosticsMux.Unlock()
+
+	delete(am.diagnostics, peerPubKey)
+}
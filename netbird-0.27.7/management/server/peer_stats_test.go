@@ -0,0 +1,206 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+func TestDefaultAccountManager_PeerTransferStats(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err, "unable to create an account")
+
+	peer := &nbpeer.Peer{ID: "peer1", Key: "peer1Key", Status: &nbpeer.PeerStatus{}}
+	account.Peers[peer.ID] = peer
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	stats, err := manager.GetPeerTransferStats(account.Id, peer.ID, userID)
+	require.NoError(t, err)
+	assert.Empty(t, stats, "no stats should be returned before the peer reported them")
+
+	reported := []nbpeer.TransferStats{
+		{
+			RemotePeerKey: "remoteKey",
+			RxBytes:       200,
+			TxBytes:       100,
+			LastHandshake: time.Now().UTC(),
+			Endpoint:      "203.0.113.10:51820",
+		},
+	}
+	require.NoError(t, manager.UpdatePeerTransferStats(peer.Key, reported))
+
+	stats, err = manager.GetPeerTransferStats(account.Id, peer.ID, userID)
+	require.NoError(t, err)
+	assert.Equal(t, reported, stats)
+
+	err = manager.UpdatePeerTransferStats("unknownKey", reported)
+	require.Error(t, err, "stats of unknown peers should be rejected")
+
+	require.NoError(t, manager.DeletePeer(account.Id, peer.ID, userID))
+	assert.NotContains(t, manager.transferStats, peer.Key, "stats should be removed with the peer")
+}
+
+func TestDefaultAccountManager_RelayedConnections(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err, "unable to create an account")
+
+	peer1 := &nbpeer.Peer{ID: "peer1", Key: "peer1Key", Status: &nbpeer.PeerStatus{}}
+	peer2 := &nbpeer.Peer{ID: "peer2", Key: "peer2Key", Status: &nbpeer.PeerStatus{}}
+	peer3 := &nbpeer.Peer{ID: "peer3", Key: "peer3Key", Status: &nbpeer.PeerStatus{}}
+	for _, peer := range []*nbpeer.Peer{peer1, peer2, peer3} {
+		account.Peers[peer.ID] = peer
+	}
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	// both ends report the relayed connection between peer1 and peer2, peer1 and peer3 are connected directly
+	require.NoError(t, manager.UpdatePeerTransferStats(peer1.Key, []nbpeer.TransferStats{
+		{RemotePeerKey: peer2.Key, Relayed: true},
+		{RemotePeerKey: peer3.Key},
+	}))
+	require.NoError(t, manager.UpdatePeerTransferStats(peer2.Key, []nbpeer.TransferStats{
+		{RemotePeerKey: peer1.Key, Relayed: true},
+	}))
+
+	conns, err := manager.GetRelayedConnections(account.Id, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []RelayedConnection{{PeerID: peer1.ID, RemotePeerID: peer2.ID}}, conns)
+	assert.Equal(t, 1, manager.CountRelayedConnections())
+
+	require.NoError(t, manager.UpdatePeerTransferStats(peer3.Key, []nbpeer.TransferStats{
+		{RemotePeerKey: peer2.Key, Relayed: true},
+	}))
+	assert.Equal(t, 2, manager.CountRelayedConnections())
+}
+
+func TestDefaultAccountManager_PeerDNSStats(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err, "unable to create an account")
+
+	peer := &nbpeer.Peer{ID: "peer1", Key: "peer1Key", Status: &nbpeer.PeerStatus{}}
+	account.Peers[peer.ID] = peer
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	_, err = manager.GetPeerDNSStats(account.Id, peer.ID, userID)
+	require.Error(t, err, "no stats should be returned before the peer reported them")
+
+	reported := nbpeer.DNSStats{
+		LocalQueries:     10,
+		ForwardedQueries: 90,
+		BlockedQueries:   2,
+		FailedQueries:    1,
+		AverageLatency:   15 * time.Millisecond,
+		ReportedAt:       time.Now().UTC(),
+	}
+	require.NoError(t, manager.UpdatePeerDNSStats(peer.Key, reported))
+
+	stats, err := manager.GetPeerDNSStats(account.Id, peer.ID, userID)
+	require.NoError(t, err)
+	assert.Equal(t, reported, *stats)
+
+	err = manager.UpdatePeerDNSStats("unknownKey", reported)
+	require.Error(t, err, "stats of unknown peers should be rejected")
+
+	require.NoError(t, manager.DeletePeer(account.Id, peer.ID, userID))
+	assert.NotContains(t, manager.dnsStats, peer.Key, "stats should be removed with the peer")
+}
+
+func TestDefaultAccountManager_PolicyStats(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err, "unable to create an account")
+
+	peer1 := &nbpeer.Peer{ID: "peer1", Key: "peer1Key", Status: &nbpeer.PeerStatus{}}
+	peer2 := &nbpeer.Peer{ID: "peer2", Key: "peer2Key", Status: &nbpeer.PeerStatus{}}
+	account.Peers[peer1.ID] = peer1
+	account.Peers[peer2.ID] = peer2
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	var policyID string
+	for _, policy := range account.Policies {
+		policyID = policy.ID
+	}
+	require.NotEmpty(t, policyID, "the account should have the default policy")
+
+	stats, err := manager.GetPolicyStats(account.Id, policyID, userID)
+	require.NoError(t, err)
+	assert.Equal(t, &PolicyStats{PolicyID: policyID}, stats, "no traffic should be returned before the peers reported it")
+
+	reportedAt := time.Now().UTC()
+	require.NoError(t, manager.UpdatePeerFirewallStats(peer1.Key, []nbpeer.FirewallStats{
+		{PolicyID: policyID, Packets: 10, Bytes: 1000, ReportedAt: reportedAt.Add(-time.Minute)},
+		{PolicyID: "otherPolicy", Packets: 5, Bytes: 500, ReportedAt: reportedAt.Add(-time.Minute)},
+	}))
+	require.NoError(t, manager.UpdatePeerFirewallStats(peer2.Key, []nbpeer.FirewallStats{
+		{PolicyID: policyID, Packets: 2, Bytes: 200, ReportedAt: reportedAt},
+	}))
+
+	stats, err = manager.GetPolicyStats(account.Id, policyID, userID)
+	require.NoError(t, err)
+	assert.Equal(t, &PolicyStats{PolicyID: policyID, Packets: 12, Bytes: 1200, Peers: 2, LastReportedAt: reportedAt}, stats)
+
+	_, err = manager.GetPolicyStats(account.Id, "unknownPolicy", userID)
+	require.Error(t, err, "stats of unknown policies should be rejected")
+
+	err = manager.UpdatePeerFirewallStats("unknownKey", nil)
+	require.Error(t, err, "stats of unknown peers should be rejected")
+
+	require.NoError(t, manager.DeletePeer(account.Id, peer1.ID, userID))
+	assert.NotContains(t, manager.firewallStats, peer1.Key, "stats should be removed with the peer")
+}
+
+func TestDefaultAccountManager_PeerDiagnostics(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err, "unable to create an account")
+
+	peer := &nbpeer.Peer{ID: "peer1", Key: "peer1Key", Status: &nbpeer.PeerStatus{}}
+	account.Peers[peer.ID] = peer
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	diagnostics, err := manager.GetPeerDiagnostics(account.Id, peer.ID, userID)
+	require.NoError(t, err)
+	assert.Empty(t, diagnostics.DroppedConnections)
+	assert.True(t, diagnostics.DroppedConnectionsReportedAt.IsZero(), "peer didn't report dropped connections yet")
+
+	reported := []nbpeer.DroppedConnection{
+		{
+			SourceIP:        "100.64.0.2",
+			SourcePort:      51334,
+			DestinationPort: 22,
+			Protocol:        "tcp",
+			PolicyIDs:       []string{"policy1"},
+			FirstSeen:       time.Now().UTC(),
+			LastSeen:        time.Now().UTC(),
+			Packets:         3,
+		},
+	}
+	require.NoError(t, manager.UpdatePeerDroppedConnections(peer.Key, reported))
+
+	diagnostics, err = manager.GetPeerDiagnostics(account.Id, peer.ID, userID)
+	require.NoError(t, err)
+	assert.Equal(t, reported, diagnostics.DroppedConnections)
+	assert.False(t, diagnostics.DroppedConnectionsReportedAt.IsZero())
+
+	err = manager.UpdatePeerDroppedConnections("unknownKey", reported)
+	require.Error(t, err, "dropped connections of unknown peers should be rejected")
+
+	require.NoError(t, manager.DeletePeer(account.Id, peer.ID, userID))
+	assert.NotContains(t, manager.diagnostics, peer.Key, "diagnostics should be removed with the peer")
+}
@@ -1,15 +1,19 @@
 package server
 
 import (
+	"net"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/rs/xid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 
 	nbgroup "github.com/netbirdio/netbird/management/server/group"
 	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
 )
 
 func TestPeer_LoginExpired(t *testing.T) {
@@ -632,3 +636,310 @@ func TestDefaultAccountManager_GetPeers(t *testing.T) {
 	}
 
 }
+
+func TestDefaultAccountManager_UpdatePeerIP(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	adminUser := "account_creator"
+	account := newAccountWithId("test_account", adminUser, "")
+	err = manager.Store.SaveAccount(account)
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, adminUser, false)
+	require.NoError(t, err)
+
+	peers := make([]*nbpeer.Peer, 0, 2)
+	for _, hostname := range []string{"test-peer-1", "test-peer-2"} {
+		key, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+
+		peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+			Key:  key.PublicKey().String(),
+			Meta: nbpeer.PeerSystemMeta{Hostname: hostname},
+		})
+		require.NoError(t, err)
+		peers = append(peers, peer)
+	}
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	serial := account.Network.CurrentSerial()
+
+	network := account.Network.Net
+	newIP := make(net.IP, net.IPv4len)
+	copy(newIP, network.IP.To4())
+	newIP[3] = 200
+	if newIP.Equal(peers[1].IP) {
+		newIP[3] = 201
+	}
+
+	update := peers[0].Copy()
+	update.IP = newIP
+	updated, err := manager.UpdatePeer(account.Id, adminUser, update)
+	require.NoError(t, err)
+	assert.True(t, updated.IP.Equal(newIP))
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.True(t, account.Peers[peers[0].ID].IP.Equal(newIP), "the new IP should be stored")
+	assert.Greater(t, account.Network.CurrentSerial(), serial, "the network serial should be increased")
+
+	invalidIPs := map[string]net.IP{
+		"used by another peer": peers[1].IP,
+		"outside the network":  net.ParseIP("192.168.0.10"),
+		"network address":      network.IP,
+		"IPv6 address":         net.ParseIP("fd00::1"),
+	}
+	for name, ip := range invalidIPs {
+		t.Run(name, func(t *testing.T) {
+			update := peers[0].Copy()
+			update.IP = ip
+			_, err := manager.UpdatePeer(account.Id, adminUser, update)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestDefaultAccountManager_UpdatePeerUplink(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	adminUser := "account_creator"
+	account := newAccountWithId("test_account", adminUser, "")
+	err = manager.Store.SaveAccount(account)
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, adminUser, false)
+	require.NoError(t, err)
+
+	key, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+		Key:  key.PublicKey().String(),
+		Meta: nbpeer.PeerSystemMeta{Hostname: "test-peer"},
+	})
+	require.NoError(t, err)
+
+	for _, uplink := range []string{"wan2", "192.168.2.10", ""} {
+		account, err = manager.Store.GetAccount(account.Id)
+		require.NoError(t, err)
+		serial := account.Network.CurrentSerial()
+
+		update := peer.Copy()
+		update.Uplink = uplink
+		updated, err := manager.UpdatePeer(account.Id, adminUser, update)
+		require.NoError(t, err)
+		assert.Equal(t, uplink, updated.Uplink)
+
+		account, err = manager.Store.GetAccount(account.Id)
+		require.NoError(t, err)
+		assert.Equal(t, uplink, account.Peers[peer.ID].Uplink, "the uplink should be stored")
+		assert.Greater(t, account.Network.CurrentSerial(), serial, "the network serial should be increased")
+
+		peerConfig := toPeerConfig(account.Peers[peer.ID], account.Network, "", 0, ICESettings{}, RosenpassSettings{}, LoginExpirationSettings{}, false, nil, nil, nil)
+		assert.Equal(t, uplink, peerConfig.GetUplink())
+	}
+
+	for _, uplink := range []string{"wan/2", " wan2", strings.Repeat("a", maxUplinkInterfaceNameLength+1)} {
+		update := peer.Copy()
+		update.Uplink = uplink
+		_, err := manager.UpdatePeer(account.Id, adminUser, update)
+		assert.Error(t, err, "uplink %q should be rejected", uplink)
+	}
+}
+
+func TestDefaultAccountManager_UpdatePeerNATType(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	adminUser := "account_creator"
+	account := newAccountWithId("test_account", adminUser, "")
+	err = manager.Store.SaveAccount(account)
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, adminUser, false)
+	require.NoError(t, err)
+
+	key, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+		Key:  key.PublicKey().String(),
+		Meta: nbpeer.PeerSystemMeta{Hostname: "test-peer"},
+	})
+	require.NoError(t, err)
+
+	err = manager.UpdatePeerNATType(peer.Key, "symmetric")
+	require.NoError(t, err)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "symmetric", account.Peers[peer.ID].Meta.NATType, "the NAT type should be stored in the meta")
+
+	assert.False(t, account.Peers[peer.ID].UpdateMetaIfNew(nbpeer.PeerSystemMeta{Hostname: "test-peer"}),
+		"a login without NAT type should keep the reported one")
+
+	err = manager.UpdatePeerNATType(peer.Key, "full_cone")
+	assert.Error(t, err, "unknown NAT types should be rejected")
+}
+
+func TestDefaultAccountManager_UpdatePeerRosenpassPubKey(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	adminUser := "account_creator"
+	account := newAccountWithId("test_account", adminUser, "")
+	err = manager.Store.SaveAccount(account)
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, adminUser, false)
+	require.NoError(t, err)
+
+	addPeer := func(hostname string) *nbpeer.Peer {
+		key, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+		peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+			Key:  key.PublicKey().String(),
+			Meta: nbpeer.PeerSystemMeta{Hostname: hostname},
+		})
+		require.NoError(t, err)
+		return peer
+	}
+	peer1, peer2 := addPeer("test-peer-1"), addPeer("test-peer-2")
+
+	rosenpassPubKey := []byte("rosenpass-public-key")
+	err = manager.UpdatePeerRosenpassPubKey(peer1.Key, rosenpassPubKey)
+	require.NoError(t, err)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, rosenpassPubKey, account.Peers[peer1.ID].RosenpassPubKey, "the Rosenpass key should be stored")
+
+	networkMap := account.GetPeerNetworkMap(peer2.ID, "", validatedPeers(account))
+	remotePeers := toRemotePeerConfig(networkMap.Peers, "", nil, nil, nil, nil, nil)
+	require.Len(t, remotePeers, 1)
+	assert.Equal(t, rosenpassPubKey, remotePeers[0].GetRosenpassPubKey(), "the Rosenpass key should be distributed to the remote peers")
+
+	err = manager.UpdatePeerRosenpassPubKey(peer1.Key, nil)
+	require.NoError(t, err)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Empty(t, account.Peers[peer1.ID].RosenpassPubKey, "the Rosenpass key should be cleared when the peer disables Rosenpass")
+}
+
+func TestDefaultAccountManager_LoginPeerRequestedIP(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	adminUser := "account_creator"
+	account := newAccountWithId("test_account", adminUser, "")
+	err = manager.Store.SaveAccount(account)
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, adminUser, false)
+	require.NoError(t, err)
+
+	hostIP := func(host byte) net.IP {
+		ip := make(net.IP, net.IPv4len)
+		copy(ip, account.Network.Net.IP.To4())
+		ip[3] = host
+		return ip
+	}
+
+	key, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	login := PeerLogin{
+		WireGuardPubKey: key.PublicKey().String(),
+		Meta:            nbpeer.PeerSystemMeta{Hostname: "test-peer"},
+		SetupKey:        setupKey.Key,
+		RequestedIP:     hostIP(10),
+	}
+	peer, _, err := manager.LoginPeer(login)
+	require.NoError(t, err)
+	assert.Equal(t, hostIP(10).String(), peer.IP.String(), "a new peer should get the requested IP")
+
+	otherKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	otherPeer, _, err := manager.LoginPeer(PeerLogin{
+		WireGuardPubKey: otherKey.PublicKey().String(),
+		Meta:            nbpeer.PeerSystemMeta{Hostname: "other-peer"},
+		SetupKey:        setupKey.Key,
+		RequestedIP:     hostIP(10),
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, hostIP(10).String(), otherPeer.IP.String(), "a taken IP should not be assigned")
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	serial := account.Network.CurrentSerial()
+
+	login.RequestedIP = hostIP(20)
+	peer, _, err = manager.LoginPeer(login)
+	require.NoError(t, err)
+	assert.Equal(t, hostIP(20).String(), peer.IP.String(), "an existing peer should move to the requested IP")
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, hostIP(20).String(), account.Peers[peer.ID].IP.String(), "the IP should be stored")
+	assert.Greater(t, account.Network.CurrentSerial(), serial, "the network serial should be increased")
+
+	login.RequestedIP = net.ParseIP("192.168.1.10")
+	peer, _, err = manager.LoginPeer(login)
+	require.NoError(t, err)
+	assert.Equal(t, hostIP(20).String(), peer.IP.String(), "an IP outside of the network should be ignored")
+}
+
+func TestAccountManager_GetPeerNetworkMapDump(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	peerKey1, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	peer1, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+		Key:  peerKey1.PublicKey().String(),
+		Meta: nbpeer.PeerSystemMeta{Hostname: "test-peer-1"},
+	})
+	require.NoError(t, err)
+
+	peerKey2, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	_, _, err = manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+		Key:  peerKey2.PublicKey().String(),
+		Meta: nbpeer.PeerSystemMeta{Hostname: "test-peer-2"},
+	})
+	require.NoError(t, err)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	account.Settings.PresharedKeyMode = PresharedKeyModeAccount
+	account.Settings.PresharedKeySecret, err = newPresharedKeySecret()
+	require.NoError(t, err)
+	account.Users["regular_user"] = NewRegularUser("regular_user")
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	dump, err := manager.GetPeerNetworkMapDump(account.Id, peer1.ID, userID)
+	require.NoError(t, err)
+	require.Len(t, dump.RemotePeers, 1)
+	assert.Equal(t, peerKey2.PublicKey().String(), dump.RemotePeers[0].WgPubKey)
+	assert.Equal(t, redactedPresharedKey, dump.RemotePeers[0].PresharedKey, "the preshared keys should be redacted")
+	assert.Equal(t, account.Network.CurrentSerial(), dump.Serial)
+	assert.NotEmpty(t, dump.PeerConfig.Address)
+
+	_, err = manager.GetPeerNetworkMapDump(account.Id, peer1.ID, "regular_user")
+	sErr, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.PermissionDenied, sErr.Type(), "only the users with admin power can dump the network map")
+
+	_, err = manager.GetPeerNetworkMapDump(account.Id, "missing", userID)
+	sErr, ok = status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.NotFound, sErr.Type())
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	_ "embed"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -103,11 +104,51 @@ type PolicyRule struct {
 	// Bidirectional define if the rule is applicable in both directions, sources, and destinations
 	Bidirectional bool
 
+	// Egress defines if the rule is enforced only by the source peers on the connections they initiate
+	// toward the destinations, the destination peers don't get firewall rules for it
+	Egress bool
+
 	// Protocol type of the traffic
 	Protocol PolicyRuleProtocolType
 
 	// Ports or it ranges list
 	Ports []string `gorm:"serializer:json"`
+
+	// DestinationLists are the IDs of the external lists whose networks the source peers drop the traffic to
+	DestinationLists []string `gorm:"serializer:json"`
+}
+
+// Validate checks the ports of the rule and that its flow is supported by its protocol
+func (pm *PolicyRule) Validate() error {
+	for _, v := range pm.Ports {
+		if port, err := strconv.Atoi(v); err != nil || port < 1 || port > 65535 {
+			return status.Errorf(status.InvalidArgument, "valid port value is in 1..65535 range")
+		}
+	}
+
+	if pm.Egress && pm.Bidirectional {
+		return status.Errorf(status.InvalidArgument, "egress rule can't be bi-directional")
+	}
+
+	switch pm.Protocol {
+	case PolicyRuleProtocolALL, PolicyRuleProtocolICMP:
+		if len(pm.Ports) != 0 {
+			return status.Errorf(status.InvalidArgument, "for ALL or ICMP protocol ports is not allowed")
+		}
+		if !pm.Bidirectional && !pm.Egress {
+			return status.Errorf(status.InvalidArgument, "for ALL or ICMP protocol type flow can be only bi-directional")
+		}
+	case PolicyRuleProtocolTCP, PolicyRuleProtocolUDP:
+		if !pm.Bidirectional && !pm.Egress && len(pm.Ports) == 0 {
+			return status.Errorf(status.InvalidArgument, "for ALL or ICMP protocol type flow can be only bi-directional")
+		}
+	}
+
+	if len(pm.DestinationLists) != 0 && (pm.Action != PolicyTrafficActionDrop || pm.Protocol != PolicyRuleProtocolALL) {
+		return status.Errorf(status.InvalidArgument, "rules with destination lists should drop all the protocols")
+	}
+
+	return nil
 }
 
 // Copy returns a copy of a policy rule
@@ -121,12 +162,14 @@ func (pm *PolicyRule) Copy() *PolicyRule {
 		Destinations:  make([]string, len(pm.Destinations)),
 		Sources:       make([]string, len(pm.Sources)),
 		Bidirectional: pm.Bidirectional,
+		Egress:        pm.Egress,
 		Protocol:      pm.Protocol,
 		Ports:         make([]string, len(pm.Ports)),
 	}
 	copy(rule.Destinations, pm.Destinations)
 	copy(rule.Sources, pm.Sources)
 	copy(rule.Ports, pm.Ports)
+	rule.DestinationLists = slices.Clone(pm.DestinationLists)
 	return rule
 }
 
@@ -152,6 +195,18 @@ type Policy struct {
 
 	// SourcePostureChecks are ID references to Posture checks for policy source groups
 	SourcePostureChecks []string `gorm:"serializer:json"`
+
+	// RequireDirect forbids relaying the connections between the peers the policy connects, they are either direct
+	// or not established
+	RequireDirect bool
+
+	// SSHEnabled enables the SSH server of the destination peers of the policy and allows the source peers to log in
+	// to them, both ways for bidirectional rules
+	SSHEnabled bool
+
+	// SpeedtestEnabled allows the source peers of the policy to run throughput tests against the destination peers,
+	// both ways for bidirectional rules
+	SpeedtestEnabled bool
 }
 
 // Copy returns a copy of the policy.
@@ -161,6 +216,9 @@ func (p *Policy) Copy() *Policy {
 		Name:                p.Name,
 		Description:         p.Description,
 		Enabled:             p.Enabled,
+		RequireDirect:       p.RequireDirect,
+		SSHEnabled:          p.SSHEnabled,
+		SpeedtestEnabled:    p.SpeedtestEnabled,
 		Rules:               make([]*PolicyRule, len(p.Rules)),
 		SourcePostureChecks: make([]string, len(p.SourcePostureChecks)),
 	}
@@ -183,7 +241,7 @@ func (p *Policy) UpgradeAndFix() {
 		if r.Protocol == "" {
 			r.Protocol = PolicyRuleProtocolALL
 		}
-		if r.Protocol == PolicyRuleProtocolALL && !r.Bidirectional {
+		if r.Protocol == PolicyRuleProtocolALL && !r.Bidirectional && !r.Egress {
 			r.Bidirectional = true
 		}
 		// -- v0.20.4
@@ -192,6 +250,9 @@ func (p *Policy) UpgradeAndFix() {
 
 // FirewallRule is a rule of the firewall.
 type FirewallRule struct {
+	// PolicyID is the ID of the policy the rule is generated from
+	PolicyID string
+
 	// PeerIP of the peer
 	PeerIP string
 
@@ -227,21 +288,29 @@ func (a *Account) getPeerConnectionResources(peerID string, validatedPeersMap ma
 			sourcePeers, peerInSources := getAllPeersFromGroups(a, rule.Sources, peerID, policy.SourcePostureChecks, validatedPeersMap)
 			destinationPeers, peerInDestinations := getAllPeersFromGroups(a, rule.Destinations, peerID, nil, validatedPeersMap)
 
+			// egress rules restrict only the connections initiated by the source peers
+			if rule.Egress {
+				if peerInSources {
+					generateResources(policy, rule, destinationPeers, firewallRuleDirectionOUT)
+				}
+				continue
+			}
+
 			if rule.Bidirectional {
 				if peerInSources {
-					generateResources(rule, destinationPeers, firewallRuleDirectionIN)
+					generateResources(policy, rule, destinationPeers, firewallRuleDirectionIN)
 				}
 				if peerInDestinations {
-					generateResources(rule, sourcePeers, firewallRuleDirectionOUT)
+					generateResources(policy, rule, sourcePeers, firewallRuleDirectionOUT)
 				}
 			}
 
 			if peerInSources {
-				generateResources(rule, destinationPeers, firewallRuleDirectionOUT)
+				generateResources(policy, rule, destinationPeers, firewallRuleDirectionOUT)
 			}
 
 			if peerInDestinations {
-				generateResources(rule, sourcePeers, firewallRuleDirectionIN)
+				generateResources(policy, rule, sourcePeers, firewallRuleDirectionIN)
 			}
 		}
 	}
@@ -249,12 +318,45 @@ func (a *Account) getPeerConnectionResources(peerID string, validatedPeersMap ma
 	return getAccumulatedResources()
 }
 
+// getDirectOnlyPeers returns the WireGuard keys of the peers the connections of the peer must not be relayed to:
+// the peers an enabled policy requiring a direct connection connects it to, whatever the direction of its rules
+func (a *Account) getDirectOnlyPeers(peerID string, validatedPeersMap map[string]struct{}) map[string]struct{} {
+	directOnly := make(map[string]struct{})
+	for _, policy := range a.Policies {
+		if !policy.Enabled || !policy.RequireDirect {
+			continue
+		}
+
+		for _, rule := range policy.Rules {
+			if !rule.Enabled {
+				continue
+			}
+
+			sourcePeers, peerInSources := getAllPeersFromGroups(a, rule.Sources, peerID, policy.SourcePostureChecks, validatedPeersMap)
+			destinationPeers, peerInDestinations := getAllPeersFromGroups(a, rule.Destinations, peerID, nil, validatedPeersMap)
+
+			if peerInSources {
+				for _, peer := range destinationPeers {
+					directOnly[peer.Key] = struct{}{}
+				}
+			}
+			if peerInDestinations {
+				for _, peer := range sourcePeers {
+					directOnly[peer.Key] = struct{}{}
+				}
+			}
+		}
+	}
+
+	return directOnly
+}
+
 // connResourcesGenerator returns generator and accumulator function which returns the result of generator calls
 //
 // The generator function is used to generate the list of peers and firewall rules that are applicable to a given peer.
 // It safe to call the generator function multiple times for same peer and different rules no duplicates will be
 // generated. The accumulator function returns the result of all the generator calls.
-func (a *Account) connResourcesGenerator() (func(*PolicyRule, []*nbpeer.Peer, int), func() ([]*nbpeer.Peer, []*FirewallRule)) {
+func (a *Account) connResourcesGenerator() (func(*Policy, *PolicyRule, []*nbpeer.Peer, int), func() ([]*nbpeer.Peer, []*FirewallRule)) {
 	rulesExists := make(map[string]struct{})
 	peersExists := make(map[string]struct{})
 	rules := make([]*FirewallRule, 0)
@@ -266,7 +368,7 @@ func (a *Account) connResourcesGenerator() (func(*PolicyRule, []*nbpeer.Peer, in
 		all = &nbgroup.Group{}
 	}
 
-	return func(rule *PolicyRule, groupPeers []*nbpeer.Peer, direction int) {
+	return func(policy *Policy, rule *PolicyRule, groupPeers []*nbpeer.Peer, direction int) {
 			isAll := (len(all.Peers) - 1) == len(groupPeers)
 			for _, peer := range groupPeers {
 				if peer == nil {
@@ -279,6 +381,7 @@ func (a *Account) connResourcesGenerator() (func(*PolicyRule, []*nbpeer.Peer, in
 				}
 
 				fr := FirewallRule{
+					PolicyID:  policy.ID,
 					PeerIP:    peer.IP.String(),
 					Direction: direction,
 					Action:    string(rule.Action),
@@ -417,6 +520,42 @@ func (am *DefaultAccountManager) ListPolicies(accountID, userID string) ([]*Poli
 	return account.Policies, nil
 }
 
+// GetPoliciesForUser returns the policies of the account along with the groups of the account by ID, loading only
+// them instead of the whole account
+func (am *DefaultAccountManager) GetPoliciesForUser(accountID, userID string) ([]*Policy, map[string]*nbgroup.Group, error) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	user, err := am.Store.GetUserByUserID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.AccountID != accountID {
+		return nil, nil, status.Errorf(status.PermissionDenied, "user %s doesn't belong to account %s", userID, accountID)
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, nil, status.Errorf(status.PermissionDenied, "only users with admin power can view policies")
+	}
+
+	policies, err := am.Store.GetAccountPolicies(accountID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	accountGroups, err := am.Store.GetAccountGroups(accountID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groups := make(map[string]*nbgroup.Group, len(accountGroups))
+	for _, group := range accountGroups {
+		groups[group.ID] = group
+	}
+
+	return policies, groups, nil
+}
+
 func (am *DefaultAccountManager) deletePolicy(account *Account, policyID string) (*Policy, error) {
 	policyIdx := -1
 	for i, policy := range account.Policies {
@@ -478,6 +617,7 @@ func toProtocolFirewallRules(update []*FirewallRule) []*proto.FirewallRule {
 			Action:    action,
 			Protocol:  protocol,
 			Port:      update[i].Port,
+			PolicyID:  update[i].PolicyID,
 		}
 	}
 	return result
@@ -520,6 +660,10 @@ func getAllPeersFromGroups(account *Account, groups []string, peerID string, sou
 				continue
 			}
 
+			if !account.geofenceAllowsConnection(peerID, peer.ID) {
+				continue
+			}
+
 			filteredPeers = append(filteredPeers, peer)
 		}
 	}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/exp/slices"
 
 	nbgroup "github.com/netbirdio/netbird/management/server/group"
@@ -160,6 +161,7 @@ func TestAccount_getPeersByPolicy(t *testing.T) {
 
 		epectedFirewallRules := []*FirewallRule{
 			{
+				PolicyID:  "RuleDefault",
 				PeerIP:    "0.0.0.0",
 				Direction: firewallRuleDirectionIN,
 				Action:    "accept",
@@ -167,6 +169,7 @@ func TestAccount_getPeersByPolicy(t *testing.T) {
 				Port:      "",
 			},
 			{
+				PolicyID:  "RuleDefault",
 				PeerIP:    "0.0.0.0",
 				Direction: firewallRuleDirectionOUT,
 				Action:    "accept",
@@ -174,6 +177,7 @@ func TestAccount_getPeersByPolicy(t *testing.T) {
 				Port:      "",
 			},
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.14.88",
 				Direction: firewallRuleDirectionIN,
 				Action:    "accept",
@@ -181,6 +185,7 @@ func TestAccount_getPeersByPolicy(t *testing.T) {
 				Port:      "",
 			},
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.14.88",
 				Direction: firewallRuleDirectionOUT,
 				Action:    "accept",
@@ -188,6 +193,7 @@ func TestAccount_getPeersByPolicy(t *testing.T) {
 				Port:      "",
 			},
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.254.139",
 				Direction: firewallRuleDirectionOUT,
 				Action:    "accept",
@@ -195,6 +201,7 @@ func TestAccount_getPeersByPolicy(t *testing.T) {
 				Port:      "",
 			},
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.254.139",
 				Direction: firewallRuleDirectionIN,
 				Action:    "accept",
@@ -203,6 +210,7 @@ func TestAccount_getPeersByPolicy(t *testing.T) {
 			},
 
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.62.5",
 				Direction: firewallRuleDirectionOUT,
 				Action:    "accept",
@@ -210,6 +218,7 @@ func TestAccount_getPeersByPolicy(t *testing.T) {
 				Port:      "",
 			},
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.62.5",
 				Direction: firewallRuleDirectionIN,
 				Action:    "accept",
@@ -218,6 +227,7 @@ func TestAccount_getPeersByPolicy(t *testing.T) {
 			},
 
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.32.206",
 				Direction: firewallRuleDirectionOUT,
 				Action:    "accept",
@@ -225,6 +235,7 @@ func TestAccount_getPeersByPolicy(t *testing.T) {
 				Port:      "",
 			},
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.32.206",
 				Direction: firewallRuleDirectionIN,
 				Action:    "accept",
@@ -233,6 +244,7 @@ func TestAccount_getPeersByPolicy(t *testing.T) {
 			},
 
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.250.202",
 				Direction: firewallRuleDirectionOUT,
 				Action:    "accept",
@@ -240,6 +252,7 @@ func TestAccount_getPeersByPolicy(t *testing.T) {
 				Port:      "",
 			},
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.250.202",
 				Direction: firewallRuleDirectionIN,
 				Action:    "accept",
@@ -248,6 +261,7 @@ func TestAccount_getPeersByPolicy(t *testing.T) {
 			},
 
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.13.186",
 				Direction: firewallRuleDirectionOUT,
 				Action:    "accept",
@@ -255,6 +269,7 @@ func TestAccount_getPeersByPolicy(t *testing.T) {
 				Port:      "",
 			},
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.13.186",
 				Direction: firewallRuleDirectionIN,
 				Action:    "accept",
@@ -263,6 +278,7 @@ func TestAccount_getPeersByPolicy(t *testing.T) {
 			},
 
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.29.55",
 				Direction: firewallRuleDirectionOUT,
 				Action:    "accept",
@@ -270,6 +286,7 @@ func TestAccount_getPeersByPolicy(t *testing.T) {
 				Port:      "",
 			},
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.29.55",
 				Direction: firewallRuleDirectionIN,
 				Action:    "accept",
@@ -391,6 +408,7 @@ func TestAccount_getPeersByPolicyDirect(t *testing.T) {
 
 		epectedFirewallRules := []*FirewallRule{
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.254.139",
 				Direction: firewallRuleDirectionIN,
 				Action:    "accept",
@@ -398,6 +416,7 @@ func TestAccount_getPeersByPolicyDirect(t *testing.T) {
 				Port:      "",
 			},
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.254.139",
 				Direction: firewallRuleDirectionOUT,
 				Action:    "accept",
@@ -419,6 +438,7 @@ func TestAccount_getPeersByPolicyDirect(t *testing.T) {
 
 		epectedFirewallRules := []*FirewallRule{
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.80.39",
 				Direction: firewallRuleDirectionIN,
 				Action:    "accept",
@@ -426,6 +446,7 @@ func TestAccount_getPeersByPolicyDirect(t *testing.T) {
 				Port:      "",
 			},
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.80.39",
 				Direction: firewallRuleDirectionOUT,
 				Action:    "accept",
@@ -449,6 +470,7 @@ func TestAccount_getPeersByPolicyDirect(t *testing.T) {
 
 		epectedFirewallRules := []*FirewallRule{
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.254.139",
 				Direction: firewallRuleDirectionOUT,
 				Action:    "accept",
@@ -470,6 +492,7 @@ func TestAccount_getPeersByPolicyDirect(t *testing.T) {
 
 		epectedFirewallRules := []*FirewallRule{
 			{
+				PolicyID:  "RuleSwarm",
 				PeerIP:    "100.65.80.39",
 				Direction: firewallRuleDirectionIN,
 				Action:    "accept",
@@ -484,6 +507,91 @@ func TestAccount_getPeersByPolicyDirect(t *testing.T) {
 			assert.Equal(t, epectedFirewallRules[i], firewallRules[i])
 		}
 	})
+
+	account.Policies[1].Rules[0].Egress = true
+	account.Policies[1].Rules[0].Action = PolicyTrafficActionDrop
+
+	t.Run("check first peer map egress", func(t *testing.T) {
+		peers, firewallRules := account.getPeerConnectionResources("peerB", approvedPeers)
+		assert.Contains(t, peers, account.Peers["peerC"])
+
+		epectedFirewallRules := []*FirewallRule{
+			{
+				PolicyID:  "RuleSwarm",
+				PeerIP:    "100.65.254.139",
+				Direction: firewallRuleDirectionOUT,
+				Action:    "drop",
+				Protocol:  "all",
+				Port:      "",
+			},
+		}
+		assert.Equal(t, epectedFirewallRules, firewallRules)
+	})
+
+	t.Run("check second peer map egress", func(t *testing.T) {
+		peers, firewallRules := account.getPeerConnectionResources("peerC", approvedPeers)
+		assert.Empty(t, peers, "destination peers shouldn't get the sources of egress rules")
+		assert.Empty(t, firewallRules, "destination peers shouldn't get firewall rules of egress rules")
+	})
+}
+
+func TestAccount_getDirectOnlyPeers(t *testing.T) {
+	account := &Account{
+		Peers: map[string]*nbpeer.Peer{
+			"peerA": {ID: "peerA", Key: "peerAKey", IP: net.ParseIP("100.65.14.88"), Status: &nbpeer.PeerStatus{}},
+			"peerB": {ID: "peerB", Key: "peerBKey", IP: net.ParseIP("100.65.80.39"), Status: &nbpeer.PeerStatus{}},
+			"peerC": {ID: "peerC", Key: "peerCKey", IP: net.ParseIP("100.65.254.139"), Status: &nbpeer.PeerStatus{}},
+		},
+		Groups: map[string]*nbgroup.Group{
+			"GroupA": {ID: "GroupA", Peers: []string{"peerA"}},
+			"GroupB": {ID: "GroupB", Peers: []string{"peerB"}},
+			"GroupC": {ID: "GroupC", Peers: []string{"peerC"}},
+		},
+		Policies: []*Policy{
+			{
+				ID:            "RuleAB",
+				Enabled:       true,
+				RequireDirect: true,
+				Rules: []*PolicyRule{
+					{ID: "RuleAB", Enabled: true, Sources: []string{"GroupA"}, Destinations: []string{"GroupB"}, Action: PolicyTrafficActionAccept},
+				},
+			},
+			{
+				ID:      "RuleAC",
+				Enabled: true,
+				Rules: []*PolicyRule{
+					{ID: "RuleAC", Enabled: true, Sources: []string{"GroupA"}, Destinations: []string{"GroupC"}, Action: PolicyTrafficActionAccept},
+				},
+			},
+		},
+	}
+
+	validatedPeers := make(map[string]struct{})
+	for p := range account.Peers {
+		validatedPeers[p] = struct{}{}
+	}
+
+	t.Run("source peer", func(t *testing.T) {
+		directOnly := account.getDirectOnlyPeers("peerA", validatedPeers)
+		assert.Equal(t, map[string]struct{}{"peerBKey": {}}, directOnly)
+	})
+
+	t.Run("destination peer", func(t *testing.T) {
+		directOnly := account.getDirectOnlyPeers("peerB", validatedPeers)
+		assert.Equal(t, map[string]struct{}{"peerAKey": {}}, directOnly)
+	})
+
+	t.Run("peer without direct policy", func(t *testing.T) {
+		directOnly := account.getDirectOnlyPeers("peerC", validatedPeers)
+		assert.Empty(t, directOnly)
+	})
+
+	t.Run("disabled policy", func(t *testing.T) {
+		account.Policies[0].Enabled = false
+		defer func() { account.Policies[0].Enabled = true }()
+		directOnly := account.getDirectOnlyPeers("peerA", validatedPeers)
+		assert.Empty(t, directOnly)
+	})
 }
 
 func TestAccount_getPeersByPolicyPostureChecks(t *testing.T) {
@@ -677,6 +785,7 @@ func TestAccount_getPeersByPolicyPostureChecks(t *testing.T) {
 		assert.Len(t, firewallRules, 1)
 		expectedFirewallRules := []*FirewallRule{
 			{
+				PolicyID:  "PolicyPostureChecks",
 				PeerIP:    "0.0.0.0",
 				Direction: firewallRuleDirectionOUT,
 				Action:    "accept",
@@ -760,6 +869,7 @@ func TestAccount_getPeersByPolicyPostureChecks(t *testing.T) {
 
 		expectedFirewallRules := []*FirewallRule{
 			{
+				PolicyID:  "PolicyPostureChecks",
 				PeerIP:    "100.65.62.5",
 				Direction: firewallRuleDirectionOUT,
 				Action:    "accept",
@@ -767,6 +877,7 @@ func TestAccount_getPeersByPolicyPostureChecks(t *testing.T) {
 				Port:      "80",
 			},
 			{
+				PolicyID:  "PolicyPostureChecks",
 				PeerIP:    "100.65.32.206",
 				Direction: firewallRuleDirectionOUT,
 				Action:    "accept",
@@ -774,6 +885,7 @@ func TestAccount_getPeersByPolicyPostureChecks(t *testing.T) {
 				Port:      "80",
 			},
 			{
+				PolicyID:  "PolicyPostureChecks",
 				PeerIP:    "100.65.13.186",
 				Direction: firewallRuleDirectionOUT,
 				Action:    "accept",
@@ -781,6 +893,7 @@ func TestAccount_getPeersByPolicyPostureChecks(t *testing.T) {
 				Port:      "80",
 			},
 			{
+				PolicyID:  "PolicyPostureChecks",
 				PeerIP:    "100.65.29.55",
 				Direction: firewallRuleDirectionOUT,
 				Action:    "accept",
@@ -788,6 +901,7 @@ func TestAccount_getPeersByPolicyPostureChecks(t *testing.T) {
 				Port:      "80",
 			},
 			{
+				PolicyID:  "PolicyPostureChecks",
 				PeerIP:    "100.65.254.139",
 				Direction: firewallRuleDirectionIN,
 				Action:    "accept",
@@ -795,6 +909,7 @@ func TestAccount_getPeersByPolicyPostureChecks(t *testing.T) {
 				Port:      "80",
 			},
 			{
+				PolicyID:  "PolicyPostureChecks",
 				PeerIP:    "100.65.62.5",
 				Direction: firewallRuleDirectionIN,
 				Action:    "accept",
@@ -823,3 +938,23 @@ func sortFunc() func(a *FirewallRule, b *FirewallRule) int {
 		return 0 // a is equal to b
 	}
 }
+
+func TestDefaultAccountManager_GetPoliciesForUser(t *testing.T) {
+	am, err := createManager(t)
+	require.NoError(t, err, "failed to create account manager")
+
+	account, err := initTestGroupAccount(am)
+	require.NoError(t, err, "failed to init testing account")
+
+	policies, groups, err := am.GetPoliciesForUser(account.Id, groupAdminUserID)
+	require.NoError(t, err, "failed to get the policies")
+	require.Len(t, policies, len(account.Policies))
+	require.Contains(t, groups, "grp-for-policies", "the groups of the account should be returned by ID")
+	assert.Equal(t, "Group for policies", groups["grp-for-policies"].Name)
+
+	_, _, err = am.GetPoliciesForUser(account.Id, "example user")
+	require.Error(t, err, "regular users shouldn't view the policies")
+
+	_, _, err = am.GetPoliciesForUser("other-account", groupAdminUserID)
+	require.Error(t, err, "the user isn't part of the account")
+}
@@ -14,6 +14,7 @@ const (
 	OSVersionCheckName        = "OSVersionCheck"
 	GeoLocationCheckName      = "GeoLocationCheck"
 	PeerNetworkRangeCheckName = "PeerNetworkRangeCheck"
+	PackageVersionCheckName   = "PackageVersionCheck"
 
 	CheckActionAllow string = "allow"
 	CheckActionDeny  string = "deny"
@@ -48,6 +49,7 @@ type ChecksDefinition struct {
 	OSVersionCheck        *OSVersionCheck        `json:",omitempty"`
 	GeoLocationCheck      *GeoLocationCheck      `json:",omitempty"`
 	PeerNetworkRangeCheck *PeerNetworkRangeCheck `json:",omitempty"`
+	PackageVersionCheck   *PackageVersionCheck   `json:",omitempty"`
 }
 
 // Copy returns a copy of a checks definition.
@@ -93,6 +95,14 @@ func (cd ChecksDefinition) Copy() ChecksDefinition {
 		}
 		copy(cdCopy.PeerNetworkRangeCheck.Ranges, peerNetRangeCheck.Ranges)
 	}
+	if cd.PackageVersionCheck != nil {
+		packageCheck := cd.PackageVersionCheck
+		cdCopy.PackageVersionCheck = &PackageVersionCheck{
+			Packages:         make([]MinPackageVersion, len(packageCheck.Packages)),
+			RequireInventory: packageCheck.RequireInventory,
+		}
+		copy(cdCopy.PackageVersionCheck.Packages, packageCheck.Packages)
+	}
 	return cdCopy
 }
 
@@ -133,6 +143,9 @@ func (pc *Checks) GetChecks() []Check {
 	if pc.Checks.PeerNetworkRangeCheck != nil {
 		checks = append(checks, pc.Checks.PeerNetworkRangeCheck)
 	}
+	if pc.Checks.PackageVersionCheck != nil {
+		checks = append(checks, pc.Checks.PackageVersionCheck)
+	}
 	return checks
 }
 
@@ -177,6 +190,12 @@ func (pc *Checks) Validate() error {
 		}
 	}
 
+	if check := pc.Checks.PackageVersionCheck; check != nil {
+		if err := check.validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -261,6 +261,12 @@ func TestChecks_Copy(t *testing.T) {
 				},
 				Action: CheckActionDeny,
 			},
+			PackageVersionCheck: &PackageVersionCheck{
+				Packages: []MinPackageVersion{
+					{Name: "libopenssl3", MinVersion: "3.0.14-1"},
+				},
+				RequireInventory: true,
+			},
 		},
 	}
 	checkCopy := check.Copy()
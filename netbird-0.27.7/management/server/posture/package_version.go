@@ -0,0 +1,181 @@
+package posture
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+// PackageVersionCheck fails the peers with a package installed below its minimum version, e.g. a vulnerable OpenSSL.
+// The versions are compared like dpkg, opkg and apk do
+type PackageVersionCheck struct {
+	Packages []MinPackageVersion
+	// RequireInventory fails the peers that didn't report a software inventory, otherwise they pass the check
+	RequireInventory bool
+}
+
+// MinPackageVersion is the minimum version of a package
+type MinPackageVersion struct {
+	Name       string
+	MinVersion string
+}
+
+var _ Check = (*PackageVersionCheck)(nil)
+
+func (p *PackageVersionCheck) Check(peer nbpeer.Peer) (bool, error) {
+	if !peer.Meta.Inventory.Reported() {
+		return !p.RequireInventory, nil
+	}
+
+	for _, pkg := range p.Packages {
+		installed, ok := peer.Meta.Inventory.PackageVersion(pkg.Name)
+		if !ok {
+			continue
+		}
+		if ComparePackageVersions(installed, pkg.MinVersion) < 0 {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (p *PackageVersionCheck) Name() string {
+	return PackageVersionCheckName
+}
+
+// MinVersion returns the minimum version of the package, empty when the check doesn't list it
+func (p *PackageVersionCheck) MinVersion(name string) string {
+	for _, pkg := range p.Packages {
+		if pkg.Name == name {
+			return pkg.MinVersion
+		}
+	}
+	return ""
+}
+
+func (p *PackageVersionCheck) validate() error {
+	if len(p.Packages) == 0 {
+		return fmt.Errorf("%s packages shouldn't be empty", p.Name())
+	}
+	seen := make(map[string]struct{}, len(p.Packages))
+	for _, pkg := range p.Packages {
+		if pkg.Name == "" {
+			return fmt.Errorf("%s package name shouldn't be empty", p.Name())
+		}
+		if _, ok := seen[pkg.Name]; ok {
+			return fmt.Errorf("%s package %s is listed twice", p.Name(), pkg.Name)
+		}
+		seen[pkg.Name] = struct{}{}
+		if !isPackageVersionValid(pkg.MinVersion) {
+			return fmt.Errorf("%s package %s version: %s is not valid", p.Name(), pkg.Name, pkg.MinVersion)
+		}
+	}
+	return nil
+}
+
+// isPackageVersionValid checks a package version has an upstream version starting with a digit and a numeric epoch
+func isPackageVersionValid(ver string) bool {
+	epoch, upstream, _ := splitPackageVersion(ver)
+	if epoch < 0 || upstream == "" {
+		return false
+	}
+	return isDigit(upstream[0])
+}
+
+// ComparePackageVersions compares two package versions made of an optional epoch, an upstream version and an
+// optional revision, e.g. 1:3.0.13-1 or 1.36.1-r2. It returns -1, 0 or 1 when a is lower, equal or greater than b
+func ComparePackageVersions(a, b string) int {
+	epochA, upstreamA, revisionA := splitPackageVersion(a)
+	epochB, upstreamB, revisionB := splitPackageVersion(b)
+	if epochA != epochB {
+		if epochA < epochB {
+			return -1
+		}
+		return 1
+	}
+	if c := compareVersionPart(upstreamA, upstreamB); c != 0 {
+		return c
+	}
+	return compareVersionPart(revisionA, revisionB)
+}
+
+// splitPackageVersion splits a version in its epoch, -1 when it isn't a number, upstream version and revision
+func splitPackageVersion(ver string) (int, string, string) {
+	epoch := 0
+	if e, rest, ok := strings.Cut(ver, ":"); ok {
+		n, err := strconv.Atoi(e)
+		if err != nil || n < 0 {
+			n = -1
+		}
+		epoch, ver = n, rest
+	}
+	if i := strings.LastIndex(ver, "-"); i >= 0 {
+		return epoch, ver[:i], ver[i+1:]
+	}
+	return epoch, ver, ""
+}
+
+// compareVersionPart compares the alternating non-digit and digit runs of two versions like dpkg does: letters sort
+// before the other characters and ~ sorts before anything, even the end of the version, e.g. 1.0~rc1 < 1.0
+func compareVersionPart(a, b string) int {
+	for a != "" || b != "" {
+		for (a != "" && !isDigit(a[0])) || (b != "" && !isDigit(b[0])) {
+			ca, cb := versionCharOrder(a), versionCharOrder(b)
+			if ca != cb {
+				if ca < cb {
+					return -1
+				}
+				return 1
+			}
+			// equal orders are the same non-digit character in both versions
+			a, b = a[1:], b[1:]
+		}
+
+		var na, nb string
+		na, a = leadingDigits(a)
+		nb, b = leadingDigits(b)
+		na, nb = strings.TrimLeft(na, "0"), strings.TrimLeft(nb, "0")
+		if len(na) != len(nb) {
+			if len(na) < len(nb) {
+				return -1
+			}
+			return 1
+		}
+		if c := strings.Compare(na, nb); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// versionCharOrder returns the order of the first character of a version part, 0 for a digit or the end
+func versionCharOrder(s string) int {
+	if s == "" {
+		return 0
+	}
+	switch c := s[0]; {
+	case isDigit(c):
+		return 0
+	case c == '~':
+		return -1
+	case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		return int(c)
+	default:
+		return int(c) + 256
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func leadingDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
@@ -0,0 +1,93 @@
+package posture
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+func TestComparePackageVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"3.0.13-1", "3.0.13-1", 0},
+		{"3.0.13-1", "3.0.14-1", -1},
+		{"3.0.13-2", "3.0.13-1", 1},
+		{"1.36.1-r2", "1.36.1-r10", -1},
+		{"1.0~rc1", "1.0", -1},
+		{"1.0a", "1.0", 1},
+		{"1.0a", "1.0+", -1},
+		{"2022.82-6", "2020.81-1", 1},
+		{"1:1.0", "2.0", 1},
+		{"1.010", "1.9", 1},
+		{"1.0", "1.0.0", -1},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, ComparePackageVersions(tc.a, tc.b), "%s vs %s", tc.a, tc.b)
+		assert.Equal(t, -tc.expected, ComparePackageVersions(tc.b, tc.a), "%s vs %s", tc.b, tc.a)
+	}
+}
+
+func TestPackageVersionCheck_Check(t *testing.T) {
+	inventory := nbpeer.Inventory{
+		PackageManager: "opkg",
+		Packages: []nbpeer.Package{
+			{Name: "dropbear", Version: "2022.82-6"},
+			{Name: "libopenssl3", Version: "3.0.13-1"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		check   PackageVersionCheck
+		peer    nbpeer.Peer
+		isValid bool
+	}{
+		{
+			name:    "Peer packages meet the minimum versions",
+			check:   PackageVersionCheck{Packages: []MinPackageVersion{{Name: "libopenssl3", MinVersion: "3.0.13-1"}, {Name: "curl", MinVersion: "8.5.0"}}},
+			peer:    nbpeer.Peer{Meta: nbpeer.PeerSystemMeta{Inventory: inventory}},
+			isValid: true,
+		},
+		{
+			name:    "Peer package is below the minimum version",
+			check:   PackageVersionCheck{Packages: []MinPackageVersion{{Name: "libopenssl3", MinVersion: "3.0.14-1"}}},
+			peer:    nbpeer.Peer{Meta: nbpeer.PeerSystemMeta{Inventory: inventory}},
+			isValid: false,
+		},
+		{
+			name:    "Peer without an inventory",
+			check:   PackageVersionCheck{Packages: []MinPackageVersion{{Name: "libopenssl3", MinVersion: "3.0.14-1"}}},
+			peer:    nbpeer.Peer{},
+			isValid: true,
+		},
+		{
+			name:    "Peer without an inventory when it is required",
+			check:   PackageVersionCheck{Packages: []MinPackageVersion{{Name: "libopenssl3", MinVersion: "3.0.14-1"}}, RequireInventory: true},
+			peer:    nbpeer.Peer{},
+			isValid: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isValid, err := tt.check.Check(tt.peer)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.isValid, isValid)
+		})
+	}
+}
+
+func TestPackageVersionCheck_Validate(t *testing.T) {
+	assert.NoError(t, (&PackageVersionCheck{Packages: []MinPackageVersion{{Name: "libopenssl3", MinVersion: "1:3.0.14-r1"}}}).validate())
+	assert.Error(t, (&PackageVersionCheck{}).validate())
+	assert.Error(t, (&PackageVersionCheck{Packages: []MinPackageVersion{{MinVersion: "1.0"}}}).validate())
+	assert.Error(t, (&PackageVersionCheck{Packages: []MinPackageVersion{{Name: "curl", MinVersion: "latest"}}}).validate())
+	assert.Error(t, (&PackageVersionCheck{Packages: []MinPackageVersion{{Name: "curl", MinVersion: "x:1.0"}}}).validate())
+	assert.Error(t, (&PackageVersionCheck{Packages: []MinPackageVersion{
+		{Name: "curl", MinVersion: "8.5.0"}, {Name: "curl", MinVersion: "8.6.0"},
+	}}).validate())
+}
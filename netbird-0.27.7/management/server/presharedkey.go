@@ -0,0 +1,80 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	// PresharedKeyModeAccount distributes the same preshared key to all peers of the account
+	PresharedKeyModeAccount = "account"
+	// PresharedKeyModePeerPair distributes a distinct preshared key to every pair of peers
+	PresharedKeyModePeerPair = "peer_pair"
+
+	presharedKeySecretLength = 32
+)
+
+func validatePresharedKeyMode(mode string) error {
+	switch mode {
+	case "", PresharedKeyModeAccount, PresharedKeyModePeerPair:
+		return nil
+	default:
+		return status.Errorf(status.InvalidArgument, "invalid preshared key mode %s, expected %s or %s",
+			mode, PresharedKeyModeAccount, PresharedKeyModePeerPair)
+	}
+}
+
+func newPresharedKeySecret() (string, error) {
+	secret := make([]byte, presharedKeySecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("generate preshared key secret: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(secret), nil
+}
+
+// presharedKey derives the WireGuard preshared key of the connection between two peers.
+// Both peers derive the same key regardless of the order of the arguments.
+// It returns an empty string if preshared keys are disabled.
+func (s *Settings) presharedKey(peerKey, remotePeerKey string) string {
+	if s.PresharedKeySecret == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.PresharedKeySecret))
+	switch s.PresharedKeyMode {
+	case PresharedKeyModeAccount:
+		mac.Write([]byte(PresharedKeyModeAccount))
+	case PresharedKeyModePeerPair:
+		if peerKey > remotePeerKey {
+			peerKey, remotePeerKey = remotePeerKey, peerKey
+		}
+		mac.Write([]byte(peerKey))
+		mac.Write([]byte{0})
+		mac.Write([]byte(remotePeerKey))
+	default:
+		return ""
+	}
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// getPresharedKeys returns the preshared keys of the connections of the peer keyed by the WireGuard key of the remote peer
+func (a *Account) getPresharedKeys(peer *nbpeer.Peer, remotePeers ...[]*nbpeer.Peer) map[string]string {
+	if a.Settings == nil || a.Settings.PresharedKeyMode == "" {
+		return nil
+	}
+
+	keys := make(map[string]string)
+	for _, peers := range remotePeers {
+		for _, remotePeer := range peers {
+			keys[remotePeer.Key] = a.Settings.presharedKey(peer.Key, remotePeer.Key)
+		}
+	}
+	return keys
+}
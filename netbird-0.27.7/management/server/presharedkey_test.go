@@ -0,0 +1,85 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+func TestSettings_PresharedKey(t *testing.T) {
+	secret, err := newPresharedKeySecret()
+	require.NoError(t, err)
+
+	disabled := &Settings{PresharedKeySecret: secret}
+	assert.Empty(t, disabled.presharedKey("peerA", "peerB"), "no key should be derived without a mode")
+
+	accountSettings := &Settings{PresharedKeyMode: PresharedKeyModeAccount, PresharedKeySecret: secret}
+	accountKey := accountSettings.presharedKey("peerA", "peerB")
+	_, err = wgtypes.ParseKey(accountKey)
+	require.NoError(t, err, "derived key should be a valid WireGuard key")
+	assert.Equal(t, accountKey, accountSettings.presharedKey("peerC", "peerD"), "all connections should share the account key")
+
+	pairSettings := &Settings{PresharedKeyMode: PresharedKeyModePeerPair, PresharedKeySecret: secret}
+	pairKey := pairSettings.presharedKey("peerA", "peerB")
+	_, err = wgtypes.ParseKey(pairKey)
+	require.NoError(t, err, "derived key should be a valid WireGuard key")
+	assert.Equal(t, pairKey, pairSettings.presharedKey("peerB", "peerA"), "both peers should derive the same key")
+	assert.NotEqual(t, pairKey, pairSettings.presharedKey("peerA", "peerC"), "every pair should get its own key")
+
+	otherSecret, err := newPresharedKeySecret()
+	require.NoError(t, err)
+	otherSettings := &Settings{PresharedKeyMode: PresharedKeyModePeerPair, PresharedKeySecret: otherSecret}
+	assert.NotEqual(t, pairKey, otherSettings.presharedKey("peerA", "peerB"), "keys should depend on the secret")
+}
+
+func TestAccount_GetPresharedKeys(t *testing.T) {
+	peer := &nbpeer.Peer{Key: "peerA"}
+	remotePeers := []*nbpeer.Peer{{Key: "peerB"}}
+	offlinePeers := []*nbpeer.Peer{{Key: "peerC"}}
+
+	account := &Account{Settings: &Settings{}}
+	assert.Nil(t, account.getPresharedKeys(peer, remotePeers, offlinePeers))
+
+	account.Settings.PresharedKeyMode = PresharedKeyModePeerPair
+	account.Settings.PresharedKeySecret = "secret"
+	keys := account.getPresharedKeys(peer, remotePeers, offlinePeers)
+	require.Len(t, keys, 2)
+	assert.Equal(t, account.Settings.presharedKey("peerA", "peerB"), keys["peerB"])
+	assert.Equal(t, account.Settings.presharedKey("peerA", "peerC"), keys["peerC"])
+}
+
+func TestDefaultAccountManager_UpdateAccountSettings_PresharedKeys(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err, "unable to create an account")
+
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration: time.Hour,
+		PresharedKeyMode:    "invalid",
+	})
+	require.Error(t, err, "expecting to fail with an invalid preshared key mode")
+
+	updated, err := manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration: time.Hour,
+		PresharedKeyMode:    PresharedKeyModePeerPair,
+	})
+	require.NoError(t, err)
+	secret := updated.Settings.PresharedKeySecret
+	require.NotEmpty(t, secret, "a secret should be generated when preshared keys are enabled")
+
+	updated, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration: time.Hour,
+		PresharedKeyMode:    PresharedKeyModeAccount,
+		PresharedKeySecret:  "overwritten",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, PresharedKeyModeAccount, updated.Settings.PresharedKeyMode)
+	assert.Equal(t, secret, updated.Settings.PresharedKeySecret, "the secret should be kept")
+}
@@ -0,0 +1,441 @@
+package server
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pion/stun/v2"
+	"github.com/rs/xid"
+
+	"github.com/netbirdio/netbird/management/proto"
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	// DefaultRelayCredentialsTTL is the lifetime of the credentials derived from the secret of a relay server
+	DefaultRelayCredentialsTTL = 12 * time.Hour
+	// minRelayCredentialsTTL keeps the rotation of the credentials, at 3/4 of their lifetime, from flooding the peers
+	minRelayCredentialsTTL = 10 * time.Minute
+	// maxRelayCredentialsTTL limits the lifetime of the credentials of a removed peer
+	maxRelayCredentialsTTL = 7 * 24 * time.Hour
+)
+
+var relayGeoLabelRegex = regexp.MustCompile("^[A-Z]{2}$")
+
+// RelayServer is a TURN relay server of an account. The peers get the enabled relays serving their location in the
+// network map, with credentials derived from the secret of the relay when it has one
+type RelayServer struct {
+	// ID of the relay server
+	ID string `gorm:"primaryKey"`
+	// AccountID is a reference to Account that this object belongs
+	AccountID string `gorm:"index"`
+	// Name of the relay server
+	Name string
+	// Description of the relay server
+	Description string
+	// Addresses are the TURN URIs of the relay server, e.g. turn:relay.example.com:3478?transport=udp
+	Addresses []string `gorm:"serializer:json"`
+	// Username and Password are the static credentials of the relay server, used when it has no secret
+	Username string
+	Password string
+	// Secret is the shared secret of the relay server the time limited credentials of the peers are derived from,
+	// as in the TURN REST API of coturn
+	Secret string
+	// CredentialsTTL is the lifetime of the credentials derived from the secret, they are rotated at 3/4 of it
+	CredentialsTTL time.Duration
+	// GeoLabels are the ISO country codes of the peers the relay server serves, empty to serve the peers of any
+	// location
+	GeoLabels []string `gorm:"serializer:json"`
+	// Enabled tells whether the relay server is distributed to the peers
+	Enabled bool
+}
+
+// EventMeta returns activity event meta related to the relay server
+func (r *RelayServer) EventMeta() map[string]any {
+	return map[string]any{"name": r.Name}
+}
+
+// Copy copies a relay server object
+func (r *RelayServer) Copy() *RelayServer {
+	c := *r
+	c.Addresses = append([]string(nil), r.Addresses...)
+	c.GeoLabels = append([]string(nil), r.GeoLabels...)
+	return &c
+}
+
+// credentials returns the credentials of the peers on the relay server, derived from its secret at now
+func (r *RelayServer) credentials(now time.Time) TURNCredentials {
+	if r.Secret == "" {
+		return TURNCredentials{Username: r.Username, Password: r.Password}
+	}
+	return generateTimeBasedCredentials(r.Secret, now.Add(r.CredentialsTTL))
+}
+
+// servesLocation tells whether the relay server serves the peers of the country
+func (r *RelayServer) servesLocation(countryCode string) bool {
+	for _, label := range r.GeoLabels {
+		if strings.EqualFold(label, countryCode) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRelayServer gets a relay server object from account and relay server IDs
+func (am *DefaultAccountManager) GetRelayServer(accountID, userID, relayID string) (*RelayServer, error) {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view relay servers")
+	}
+
+	relay, found := account.RelayServers[relayID]
+	if found {
+		return relay.Copy(), nil
+	}
+
+	return nil, status.Errorf(status.NotFound, "relay server with ID %s not found", relayID)
+}
+
+// CreateRelayServer validates and saves a new relay server
+func (am *DefaultAccountManager) CreateRelayServer(accountID, userID string, relay *RelayServer) (*RelayServer, error) {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	if relay == nil {
+		return nil, status.Errorf(status.InvalidArgument, "relay server provided is nil")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	newRelay := relay.Copy()
+	newRelay.ID = xid.New().String()
+	newRelay.AccountID = accountID
+
+	err = validateRelayServer(false, newRelay, account)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.RelayServers == nil {
+		account.RelayServers = make(map[string]*RelayServer)
+	}
+
+	account.RelayServers[newRelay.ID] = newRelay
+
+	account.Network.IncSerial()
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return nil, err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, newRelay.ID, accountID, activity.RelayServerCreated, newRelay.EventMeta())
+
+	return newRelay.Copy(), nil
+}
+
+// SaveRelayServer validates and updates an existing relay server
+func (am *DefaultAccountManager) SaveRelayServer(accountID, userID string, relayToSave *RelayServer) error {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	if relayToSave == nil {
+		return status.Errorf(status.InvalidArgument, "relay server provided is nil")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	relay := relayToSave.Copy()
+	relay.AccountID = accountID
+
+	err = validateRelayServer(true, relay, account)
+	if err != nil {
+		return err
+	}
+
+	account.RelayServers[relay.ID] = relay
+
+	account.Network.IncSerial()
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, relay.ID, accountID, activity.RelayServerUpdated, relay.EventMeta())
+
+	return nil
+}
+
+// DeleteRelayServer deletes the relay server with relayID
+func (am *DefaultAccountManager) DeleteRelayServer(accountID, userID, relayID string) error {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	relay := account.RelayServers[relayID]
+	if relay == nil {
+		return status.Errorf(status.NotFound, "relay server %s wasn't found", relayID)
+	}
+	delete(account.RelayServers, relayID)
+
+	account.Network.IncSerial()
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, relay.ID, accountID, activity.RelayServerDeleted, relay.EventMeta())
+
+	return nil
+}
+
+// ListRelayServers returns a list of the relay servers from account
+func (am *DefaultAccountManager) ListRelayServers(accountID, userID string) ([]*RelayServer, error) {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view relay servers")
+	}
+
+	relays := make([]*RelayServer, 0, len(account.RelayServers))
+	for _, item := range account.RelayServers {
+		relays = append(relays, item.Copy())
+	}
+
+	return relays, nil
+}
+
+// validateRelayServer checks the relay server against the account and normalizes its geo labels and credentials TTL
+func validateRelayServer(existingRelay bool, relay *RelayServer, account *Account) error {
+	if existingRelay {
+		_, found := account.RelayServers[relay.ID]
+		if !found {
+			return status.Errorf(status.NotFound, "relay server with ID %s was not found", relay.ID)
+		}
+	}
+
+	if relay.Name == "" || len(relay.Name) > 40 {
+		return status.Errorf(status.InvalidArgument, "relay server name should be between 1 and 40 characters")
+	}
+
+	for _, other := range account.RelayServers {
+		if other.ID != relay.ID && other.Name == relay.Name {
+			return status.Errorf(status.InvalidArgument, "a relay server with name %s already exists", relay.Name)
+		}
+	}
+
+	if len(relay.Addresses) == 0 {
+		return status.Errorf(status.InvalidArgument, "relay server %s should have at least one address", relay.Name)
+	}
+	for _, address := range relay.Addresses {
+		uri, err := stun.ParseURI(address)
+		if err != nil || (uri.Scheme != stun.SchemeTypeTURN && uri.Scheme != stun.SchemeTypeTURNS) {
+			return status.Errorf(status.InvalidArgument, "relay server address %q should be a TURN URI, e.g. turn:relay.example.com:3478", address)
+		}
+	}
+
+	if relay.Secret == "" {
+		if relay.Username == "" || relay.Password == "" {
+			return status.Errorf(status.InvalidArgument, "relay server %s should have either a secret or a username and a password", relay.Name)
+		}
+		relay.CredentialsTTL = 0
+	} else {
+		relay.Username, relay.Password = "", ""
+		if relay.CredentialsTTL == 0 {
+			relay.CredentialsTTL = DefaultRelayCredentialsTTL
+		}
+		if relay.CredentialsTTL < minRelayCredentialsTTL || relay.CredentialsTTL > maxRelayCredentialsTTL {
+			return status.Errorf(status.InvalidArgument, "relay server credentials TTL should be between %s and %s",
+				minRelayCredentialsTTL, maxRelayCredentialsTTL)
+		}
+	}
+
+	labels := make([]string, 0, len(relay.GeoLabels))
+	for _, label := range relay.GeoLabels {
+		label = strings.ToUpper(label)
+		if !relayGeoLabelRegex.MatchString(label) {
+			return status.Errorf(status.InvalidArgument, "relay server geo label %q should be an ISO 3166-1 alpha-2 country code", label)
+		}
+		labels = append(labels, label)
+	}
+	relay.GeoLabels = labels
+
+	return nil
+}
+
+// selectPeerRelays returns the enabled relay servers of the account for the peer. The relays labeled with the
+// country of the peer are selected, else the relays without labels, else all the relays, so that the peers of the
+// locations without a relay of their own still get one
+func selectPeerRelays(peer *nbpeer.Peer, relays map[string]*RelayServer) []*RelayServer {
+	var matched, unlabeled, all []*RelayServer
+	for _, relay := range relays {
+		if !relay.Enabled {
+			continue
+		}
+		all = append(all, relay)
+		switch {
+		case len(relay.GeoLabels) == 0:
+			unlabeled = append(unlabeled, relay)
+		case peer.Location.CountryCode != "" && relay.servesLocation(peer.Location.CountryCode):
+			matched = append(matched, relay)
+		}
+	}
+
+	selected := all
+	if len(matched) > 0 {
+		selected = matched
+	} else if len(unlabeled) > 0 {
+		selected = unlabeled
+	}
+
+	// keep the network map stable between updates
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].ID < selected[j].ID
+	})
+	return selected
+}
+
+// toProtocolRelays converts the relay servers selected for a peer with the credentials valid from now
+func toProtocolRelays(relays []*RelayServer, now time.Time) []*proto.ProtectedHostConfig {
+	var hosts []*proto.ProtectedHostConfig
+	for _, relay := range relays {
+		credentials := relay.credentials(now)
+		for _, address := range relay.Addresses {
+			hosts = append(hosts, &proto.ProtectedHostConfig{
+				HostConfig: &proto.HostConfig{
+					Uri:      address,
+					Protocol: relayProtocol(address),
+				},
+				User:     credentials.Username,
+				Password: credentials.Password,
+			})
+		}
+	}
+	return hosts
+}
+
+// relayProtocol returns the transport protocol of a TURN URI, UDP unless it says otherwise
+func relayProtocol(address string) proto.HostConfig_Protocol {
+	uri, err := stun.ParseURI(address)
+	if err == nil && uri.Proto == stun.ProtoTypeTCP {
+		return proto.HostConfig_TCP
+	}
+	return proto.HostConfig_UDP
+}
+
+// relayRefreshInterval returns the interval the network map of a peer is sent again at for the credentials of its
+// relays to be rotated before they expire, zero when its relays have static credentials
+func relayRefreshInterval(relays []*RelayServer) time.Duration {
+	var ttl time.Duration
+	for _, relay := range relays {
+		if relay.Secret != "" && (ttl == 0 || relay.CredentialsTTL < ttl) {
+			ttl = relay.CredentialsTTL
+		}
+	}
+	return ttl / 4 * 3
+}
+
+// RefreshPeerRelays sends the network map to the peer again for the credentials of its relays to be rotated before
+// they expire. It returns the relays sent to the peer
+func (am *DefaultAccountManager) RefreshPeerRelays(peerID string) ([]*RelayServer, error) {
+	account, err := am.Store.GetAccountByPeerID(peerID)
+	if err != nil {
+		return nil, err
+	}
+
+	peer := account.GetPeer(peerID)
+	if peer == nil {
+		return nil, status.Errorf(status.NotFound, "peer with ID %s not found", peerID)
+	}
+
+	validatedPeers, err := am.GetValidatedPeers(account)
+	if err != nil {
+		return nil, err
+	}
+
+	networkMap := account.GetPeerNetworkMap(peerID, am.dnsDomain, validatedPeers)
+	if len(networkMap.Relays) > 0 {
+		update := toSyncResponse(nil, peer, nil, networkMap, am.GetDNSDomain(), am.clientUpdates)
+		am.peersUpdateManager.SendUpdate(peerID, &UpdateMessage{Update: update, NetworkMap: networkMap})
+	}
+	return networkMap.Relays, nil
+}
+
+// relayRefreshTimer fires when the credentials of the relays of a peer need to be rotated. Its channel blocks
+// forever while the relays have static credentials
+type relayRefreshTimer struct {
+	timer *time.Timer
+	C     <-chan time.Time
+}
+
+func newRelayRefreshTimer(relays []*RelayServer) *relayRefreshTimer {
+	t := &relayRefreshTimer{}
+	t.Reset(relays)
+	return t
+}
+
+// Reset schedules the next rotation for the relays
+func (t *relayRefreshTimer) Reset(relays []*RelayServer) {
+	t.Stop()
+	interval := relayRefreshInterval(relays)
+	if interval <= 0 {
+		t.timer, t.C = nil, nil
+		return
+	}
+	t.timer = time.NewTimer(interval)
+	t.C = t.timer.C
+}
+
+// Stop cancels the next rotation
+func (t *relayRefreshTimer) Stop() {
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+}
@@ -0,0 +1,180 @@
+// Package relay runs a TURN relay server co-located with the Management service, for the installations without a
+// TURN server of their own, e.g. a management server on an OpenWrt router
+package relay
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pion/logging"
+	"github.com/pion/turn/v3"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/util"
+)
+
+const (
+	// Realm of the relay server
+	Realm = "netbird"
+	// DefaultPort is the port of the relay server when its addresses have none
+	DefaultPort = 3478
+	// credentialsFile holds the generated credentials of the relay server in the data directory
+	credentialsFile = "relay.json"
+	// defaultUsername is the username of the static credentials of the relay server
+	defaultUsername = "netbird"
+)
+
+// Credentials of the relay server, generated on the first start and kept in the data directory
+type Credentials struct {
+	// Username and Password are the static credentials of the peers on the relay server
+	Username string
+	Password string
+	// Secret is the shared secret the time limited credentials are derived from when the Management service has
+	// none configured
+	Secret string
+}
+
+// LoadOrGenerateCredentials reads the credentials of the relay server from the data directory, generating and
+// storing them on the first start
+func LoadOrGenerateCredentials(datadir string) (*Credentials, error) {
+	path := filepath.Join(datadir, credentialsFile)
+
+	credentials := &Credentials{}
+	if _, err := os.Stat(path); err == nil {
+		if _, err := util.ReadJson(path, credentials); err != nil {
+			return nil, fmt.Errorf("read relay credentials %s: %w", path, err)
+		}
+		if credentials.Username != "" && credentials.Password != "" && credentials.Secret != "" {
+			return credentials, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("stat relay credentials %s: %w", path, err)
+	}
+
+	password, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	credentials = &Credentials{Username: defaultUsername, Password: password, Secret: secret}
+
+	if err := util.WriteJson(path, credentials); err != nil {
+		return nil, fmt.Errorf("write relay credentials %s: %w", path, err)
+	}
+	log.Infof("generated the credentials of the relay server in %s", path)
+	return credentials, nil
+}
+
+// Server is a TURN relay server accepting the static credentials and the time limited credentials derived from a
+// secret
+type Server struct {
+	turn *turn.Server
+	addr net.Addr
+	uri  string
+}
+
+// New starts a relay server listening on the UDP listenAddress, e.g. :3478. The peers reach it at publicAddress,
+// the host[:port] its relayed addresses are allocated for. Time limited credentials are accepted when secret is set
+func New(listenAddress, publicAddress string, credentials Credentials, secret string) (*Server, error) {
+	host, port, err := splitPublicAddress(publicAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	relayIP, err := net.ResolveIPAddr("ip4", host)
+	if err != nil {
+		return nil, fmt.Errorf("resolve relay public address %s: %w", host, err)
+	}
+
+	conn, err := net.ListenPacket("udp4", listenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("listen on %s: %w", listenAddress, err)
+	}
+
+	loggerFactory := logging.NewDefaultLoggerFactory()
+	server, err := turn.NewServer(turn.ServerConfig{
+		Realm:         Realm,
+		LoggerFactory: loggerFactory,
+		AuthHandler:   authHandler(credentials, secret, loggerFactory.NewLogger("turn")),
+		PacketConnConfigs: []turn.PacketConnConfig{
+			{
+				PacketConn: conn,
+				RelayAddressGenerator: &turn.RelayAddressGeneratorStatic{
+					RelayAddress: relayIP.IP,
+					Address:      "0.0.0.0",
+				},
+			},
+		},
+	})
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("create relay server: %w", err)
+	}
+
+	uri := fmt.Sprintf("turn:%s?transport=udp", net.JoinHostPort(host, strconv.Itoa(port)))
+	log.Infof("relay server listening on %s, advertised as %s", conn.LocalAddr(), uri)
+	return &Server{turn: server, addr: conn.LocalAddr(), uri: uri}, nil
+}
+
+// URI returns the TURN URI the peers reach the relay server at
+func (s *Server) URI() string {
+	return s.uri
+}
+
+// Addr returns the address the relay server listens on
+func (s *Server) Addr() net.Addr {
+	return s.addr
+}
+
+// Close stops the relay server, closing the allocations of the peers
+func (s *Server) Close() error {
+	return s.turn.Close()
+}
+
+// authHandler accepts the static credentials, and the time limited credentials derived from secret when set
+func authHandler(credentials Credentials, secret string, logger logging.LeveledLogger) turn.AuthHandler {
+	var timeLimited turn.AuthHandler
+	if secret != "" {
+		timeLimited = turn.NewLongTermAuthHandler(secret, logger)
+	}
+
+	return func(username, realm string, srcAddr net.Addr) ([]byte, bool) {
+		if username == credentials.Username {
+			return turn.GenerateAuthKey(username, realm, credentials.Password), true
+		}
+		if timeLimited == nil {
+			return nil, false
+		}
+		return timeLimited(username, realm, srcAddr)
+	}
+}
+
+// splitPublicAddress splits host[:port], the port defaulting to DefaultPort
+func splitPublicAddress(address string) (string, int, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return address, DefaultPort, nil
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return "", 0, fmt.Errorf("invalid relay public address %s: bad port", address)
+	}
+	return host, port, nil
+}
+
+func randomString() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate relay credentials: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
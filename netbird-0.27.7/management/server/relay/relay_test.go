@@ -0,0 +1,94 @@
+package relay
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/turn/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadOrGenerateCredentials(t *testing.T) {
+	datadir := t.TempDir()
+
+	generated, err := LoadOrGenerateCredentials(datadir)
+	require.NoError(t, err)
+	assert.Equal(t, defaultUsername, generated.Username)
+	assert.NotEmpty(t, generated.Password)
+	assert.NotEmpty(t, generated.Secret)
+
+	loaded, err := LoadOrGenerateCredentials(datadir)
+	require.NoError(t, err)
+	assert.Equal(t, generated, loaded, "the credentials should be kept between the starts")
+}
+
+func TestSplitPublicAddress(t *testing.T) {
+	host, port, err := splitPublicAddress("relay.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "relay.example.com", host)
+	assert.Equal(t, DefaultPort, port)
+
+	host, port, err = splitPublicAddress("203.0.113.1:3479")
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.1", host)
+	assert.Equal(t, 3479, port)
+
+	_, _, err = splitPublicAddress("relay.example.com:http")
+	assert.Error(t, err)
+}
+
+func TestServerAllocate(t *testing.T) {
+	credentials := Credentials{Username: defaultUsername, Password: "password"}
+	server, err := New("127.0.0.1:0", "127.0.0.1", credentials, "secret")
+	require.NoError(t, err)
+	defer server.Close()
+
+	assert.Equal(t, "turn:127.0.0.1:3478?transport=udp", server.URI())
+
+	timeLimitedUser, timeLimitedPassword, err := turn.GenerateLongTermCredentials("secret", time.Hour)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		username   string
+		password   string
+		shouldFail bool
+	}{
+		{name: "static credentials", username: credentials.Username, password: credentials.Password},
+		{name: "time limited credentials", username: timeLimitedUser, password: timeLimitedPassword},
+		{name: "wrong password", username: credentials.Username, password: "wrong", shouldFail: true},
+		{name: "wrong secret", username: timeLimitedUser, password: "wrong", shouldFail: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer conn.Close()
+
+			client, err := turn.NewClient(&turn.ClientConfig{
+				STUNServerAddr: server.Addr().String(),
+				TURNServerAddr: server.Addr().String(),
+				Username:       testCase.username,
+				Password:       testCase.password,
+				Realm:          Realm,
+				Conn:           conn,
+				RTO:            100 * time.Millisecond,
+			})
+			require.NoError(t, err)
+			defer client.Close()
+			require.NoError(t, client.Listen())
+
+			relayConn, err := client.Allocate()
+			if testCase.shouldFail {
+				assert.Error(t, err, "the allocation should be refused")
+				return
+			}
+			require.NoError(t, err, "the allocation should succeed")
+			defer relayConn.Close()
+			assert.Equal(t, "127.0.0.1", relayConn.LocalAddr().(*net.UDPAddr).IP.String())
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/proto"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+func TestCreateRelayServer(t *testing.T) {
+	am, err := createNSManager(t)
+	require.NoError(t, err, "failed to create account manager")
+
+	account, err := initTestNSAccount(t, am)
+	require.NoError(t, err, "failed to init testing account")
+
+	testCases := []struct {
+		name        string
+		relay       *RelayServer
+		shouldFail  bool
+		expectedTTL time.Duration
+	}{
+		{
+			name: "relay with a secret and the default TTL",
+			relay: &RelayServer{Name: "secret", Addresses: []string{"turn:relay.example.com:3478"}, Secret: "secret",
+				Username: "ignored", GeoLabels: []string{"de"}, Enabled: true},
+			expectedTTL: DefaultRelayCredentialsTTL,
+		},
+		{
+			name: "relay with static credentials",
+			relay: &RelayServer{Name: "static", Addresses: []string{"turns:relay.example.com:5349?transport=tcp"},
+				Username: "netbird", Password: "pass", CredentialsTTL: time.Hour, Enabled: true},
+		},
+		{
+			name:       "duplicated name",
+			relay:      &RelayServer{Name: "static", Addresses: []string{"turn:relay.example.com"}, Secret: "secret"},
+			shouldFail: true,
+		},
+		{
+			name:       "without address",
+			relay:      &RelayServer{Name: "empty", Secret: "secret"},
+			shouldFail: true,
+		},
+		{
+			name:       "STUN address",
+			relay:      &RelayServer{Name: "stun", Addresses: []string{"stun:relay.example.com:3478"}, Secret: "secret"},
+			shouldFail: true,
+		},
+		{
+			name:       "without credentials",
+			relay:      &RelayServer{Name: "open", Addresses: []string{"turn:relay.example.com"}, Username: "netbird"},
+			shouldFail: true,
+		},
+		{
+			name: "TTL under the minimum",
+			relay: &RelayServer{Name: "short", Addresses: []string{"turn:relay.example.com"}, Secret: "secret",
+				CredentialsTTL: time.Minute},
+			shouldFail: true,
+		},
+		{
+			name: "invalid geo label",
+			relay: &RelayServer{Name: "geo", Addresses: []string{"turn:relay.example.com"}, Secret: "secret",
+				GeoLabels: []string{"Germany"}},
+			shouldFail: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			relay, err := am.CreateRelayServer(account.Id, testUserID, testCase.relay)
+			if testCase.shouldFail {
+				require.Error(t, err, "should fail creating the relay server")
+				return
+			}
+			require.NoError(t, err, "should create the relay server")
+
+			assert.NotEmpty(t, relay.ID)
+			assert.Equal(t, testCase.expectedTTL, relay.CredentialsTTL)
+			if relay.Secret != "" {
+				assert.Empty(t, relay.Username, "a relay with a secret should have no static credentials")
+			}
+			for _, label := range relay.GeoLabels {
+				assert.True(t, relayGeoLabelRegex.MatchString(label), "geo labels should be normalized")
+			}
+		})
+	}
+}
+
+func TestSaveAndDeleteRelayServer(t *testing.T) {
+	am, err := createNSManager(t)
+	require.NoError(t, err, "failed to create account manager")
+
+	account, err := initTestNSAccount(t, am)
+	require.NoError(t, err, "failed to init testing account")
+
+	relay, err := am.CreateRelayServer(account.Id, testUserID, &RelayServer{
+		Name: "relay", Addresses: []string{"turn:relay.example.com"}, Secret: "secret", Enabled: true,
+	})
+	require.NoError(t, err)
+
+	relay.CredentialsTTL = time.Hour
+	relay.GeoLabels = []string{"fr"}
+	require.NoError(t, am.SaveRelayServer(account.Id, testUserID, relay))
+
+	saved, err := am.GetRelayServer(account.Id, testUserID, relay.ID)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, saved.CredentialsTTL)
+	assert.Equal(t, []string{"FR"}, saved.GeoLabels)
+
+	missing := relay.Copy()
+	missing.ID = "missing"
+	assert.Error(t, am.SaveRelayServer(account.Id, testUserID, missing), "should fail saving an unknown relay server")
+
+	relays, err := am.ListRelayServers(account.Id, testUserID)
+	require.NoError(t, err)
+	assert.Len(t, relays, 1)
+
+	require.NoError(t, am.DeleteRelayServer(account.Id, testUserID, relay.ID))
+	_, err = am.GetRelayServer(account.Id, testUserID, relay.ID)
+	assert.Error(t, err, "relay server should be deleted")
+	assert.Error(t, am.DeleteRelayServer(account.Id, testUserID, relay.ID), "should fail deleting a deleted relay server")
+}
+
+func TestSelectPeerRelays(t *testing.T) {
+	relays := map[string]*RelayServer{
+		"de":       {ID: "de", GeoLabels: []string{"DE", "AT"}, Enabled: true},
+		"fr":       {ID: "fr", GeoLabels: []string{"FR"}, Enabled: true},
+		"any":      {ID: "any", Enabled: true},
+		"disabled": {ID: "disabled", GeoLabels: []string{"US"}, Enabled: false},
+	}
+
+	ids := func(selected []*RelayServer) []string {
+		var result []string
+		for _, relay := range selected {
+			result = append(result, relay.ID)
+		}
+		return result
+	}
+	peerIn := func(country string) *nbpeer.Peer {
+		return &nbpeer.Peer{Location: nbpeer.Location{CountryCode: country}}
+	}
+
+	assert.Equal(t, []string{"de"}, ids(selectPeerRelays(peerIn("AT"), relays)), "the relays of the country should be selected")
+	assert.Equal(t, []string{"any"}, ids(selectPeerRelays(peerIn("US"), relays)), "disabled relays should not be selected")
+	assert.Equal(t, []string{"any"}, ids(selectPeerRelays(peerIn(""), relays)), "peers without location should get the unlabeled relays")
+
+	delete(relays, "any")
+	assert.Equal(t, []string{"de", "fr"}, ids(selectPeerRelays(peerIn("US"), relays)), "peers without relay of their own should get all of them")
+}
+
+func TestToProtocolRelays(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	relays := []*RelayServer{
+		{ID: "secret", Addresses: []string{"turn:a.example.com", "turn:a.example.com?transport=tcp"}, Secret: "secret", CredentialsTTL: time.Hour},
+		{ID: "static", Addresses: []string{"turns:b.example.com:5349"}, Username: "netbird", Password: "pass"},
+	}
+
+	hosts := toProtocolRelays(relays, now)
+	require.Len(t, hosts, 3)
+
+	expected := generateTimeBasedCredentials("secret", now.Add(time.Hour))
+	assert.Equal(t, "1700003600", hosts[0].User)
+	assert.Equal(t, expected.Password, hosts[0].Password)
+	assert.Equal(t, proto.HostConfig_UDP, hosts[0].HostConfig.Protocol)
+	assert.Equal(t, proto.HostConfig_TCP, hosts[1].HostConfig.Protocol)
+	assert.Equal(t, "netbird", hosts[2].User)
+	assert.Equal(t, "pass", hosts[2].Password)
+
+	assert.Equal(t, 45*time.Minute, relayRefreshInterval(relays))
+	assert.Zero(t, relayRefreshInterval(relays[1:]), "static credentials should not be rotated")
+}
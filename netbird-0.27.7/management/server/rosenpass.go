@@ -0,0 +1,41 @@
+package server
+
+import (
+	"github.com/netbirdio/netbird/management/proto"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// RosenpassSettings are the Rosenpass settings of the account pushed to the peers
+type RosenpassSettings struct {
+	// Enabled makes the peers run the post-quantum Rosenpass handshake alongside WireGuard
+	Enabled bool
+	// Permissive keeps the connections to the peers without Rosenpass working without the post-quantum key
+	Permissive bool
+}
+
+// rosenpassSettings returns the Rosenpass settings of the account settings
+func (s *Settings) rosenpassSettings() RosenpassSettings {
+	return RosenpassSettings{
+		Enabled:    s.RosenpassEnabled,
+		Permissive: s.RosenpassPermissive,
+	}
+}
+
+func validateRosenpassSettings(settings RosenpassSettings) error {
+	if settings.Permissive && !settings.Enabled {
+		return status.Errorf(status.InvalidArgument, "Rosenpass permissive mode requires Rosenpass to be enabled")
+	}
+	return nil
+}
+
+// toRosenpassConfig returns the Rosenpass config pushed to the peers, nil when Rosenpass isn't enabled for the account
+// so the peers keep their local setting
+func toRosenpassConfig(settings RosenpassSettings) *proto.RosenpassConfig {
+	if !settings.Enabled {
+		return nil
+	}
+	return &proto.RosenpassConfig{
+		Enabled:    true,
+		Permissive: settings.Permissive,
+	}
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"net/netip"
+	"slices"
 	"unicode/utf8"
 
 	"github.com/rs/xid"
@@ -12,6 +13,9 @@ import (
 	"github.com/netbirdio/netbird/route"
 )
 
+// maxPendingRoutesPerPeer limits the number of routes a peer can propose before they are reviewed by an admin
+const maxPendingRoutesPerPeer = 16
+
 // GetRoute gets a route object from account and route IDs
 func (am *DefaultAccountManager) GetRoute(accountID string, routeID route.ID, userID string) (*route.Route, error) {
 	unlock := am.Store.AcquireAccountWriteLock(accountID)
@@ -39,10 +43,10 @@ func (am *DefaultAccountManager) GetRoute(accountID string, routeID route.ID, us
 	return nil, status.Errorf(status.NotFound, "route with ID %s not found", routeID)
 }
 
-// checkRoutePrefixExistsForPeers checks if a route with a given prefix exists for a single peer or multiple peer groups.
-func (am *DefaultAccountManager) checkRoutePrefixExistsForPeers(account *Account, peerID string, routeID route.ID, peerGroupIDs []string, prefix netip.Prefix) error {
+// checkRoutePrefixExistsForPeers checks if a route with a given prefix (or domains) exists for a single peer or multiple peer groups.
+func (am *DefaultAccountManager) checkRoutePrefixExistsForPeers(account *Account, peerID string, routeID route.ID, peerGroupIDs []string, prefix string) error {
 	// routes can have both peer and peer_groups
-	routesWithPrefix := account.GetRoutesByPrefix(prefix)
+	routesWithPrefix := account.getRoutesByNetString(prefix)
 
 	// lets remember all the peers and the peer groups from routesWithPrefix
 	seenPeers := make(map[string]bool)
@@ -56,7 +60,7 @@ func (am *DefaultAccountManager) checkRoutePrefixExistsForPeers(account *Account
 		}
 
 		if prefixRoute.Peer != "" {
-			seenPeers[string(prefixRoute.ID)] = true
+			seenPeers[prefixRoute.Peer] = true
 		}
 		for _, groupID := range prefixRoute.PeerGroups {
 			seenPeerGroups[groupID] = true
@@ -65,7 +69,7 @@ func (am *DefaultAccountManager) checkRoutePrefixExistsForPeers(account *Account
 			if group == nil {
 				return status.Errorf(
 					status.InvalidArgument, "failed to add route with prefix %s - peer group %s doesn't exist",
-					prefix.String(), groupID)
+					prefix, groupID)
 			}
 
 			for _, pID := range group.Peers {
@@ -82,7 +86,7 @@ func (am *DefaultAccountManager) checkRoutePrefixExistsForPeers(account *Account
 		}
 		if _, ok := seenPeers[peerID]; ok {
 			return status.Errorf(status.AlreadyExists,
-				"failed to add route with prefix %s - peer %s already has this route", prefix.String(), peerID)
+				"failed to add route with prefix %s - peer %s already has this route", prefix, peerID)
 		}
 	}
 
@@ -93,7 +97,7 @@ func (am *DefaultAccountManager) checkRoutePrefixExistsForPeers(account *Account
 		if _, ok := seenPeerGroups[groupID]; ok {
 			return status.Errorf(
 				status.AlreadyExists, "failed to add route with prefix %s - peer group %s already has this route",
-				prefix.String(), group.Name)
+				prefix, group.Name)
 		}
 
 		// check that the peers from peerGroupIDs groups are not the same peers we saw in routesWithPrefix
@@ -105,7 +109,7 @@ func (am *DefaultAccountManager) checkRoutePrefixExistsForPeers(account *Account
 				}
 				return status.Errorf(status.AlreadyExists,
 					"failed to add route with prefix %s - peer %s from the group %s already has this route",
-					prefix.String(), peer.Name, group.Name)
+					prefix, peer.Name, group.Name)
 			}
 		}
 	}
@@ -113,8 +117,8 @@ func (am *DefaultAccountManager) checkRoutePrefixExistsForPeers(account *Account
 	return nil
 }
 
-// CreateRoute creates and saves a new route
-func (am *DefaultAccountManager) CreateRoute(accountID, network, peerID string, peerGroupIDs []string, description string, netID route.NetID, masquerade bool, metric int, groups []string, enabled bool, userID string) (*route.Route, error) {
+// CreateRoute creates and saves a new route. Either a network or a list of domains has to be provided.
+func (am *DefaultAccountManager) CreateRoute(accountID, network string, domains []string, keepRoute bool, peerID string, peerGroupIDs []string, description string, netID route.NetID, masquerade bool, metric, weight int, groups []string, enabled bool, userID string) (*route.Route, error) {
 	unlock := am.Store.AcquireAccountWriteLock(accountID)
 	defer unlock()
 
@@ -133,9 +137,23 @@ func (am *DefaultAccountManager) CreateRoute(accountID, network, peerID string,
 	var newRoute route.Route
 	newRoute.ID = route.ID(xid.New().String())
 
-	prefixType, newPrefix, err := route.ParseNetwork(network)
-	if err != nil {
-		return nil, status.Errorf(status.InvalidArgument, "failed to parse IP %s", network)
+	if network != "" && len(domains) != 0 {
+		return nil, status.Errorf(status.InvalidArgument, "network and domains should not be provided at the same time")
+	}
+
+	var prefixType route.NetworkType
+	var newPrefix netip.Prefix
+	if len(domains) > 0 {
+		prefixType = route.DomainNetwork
+		domains, err = route.ParseDomains(domains)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		prefixType, newPrefix, err = route.ParseNetwork(network)
+		if err != nil {
+			return nil, status.Errorf(status.InvalidArgument, "failed to parse IP %s", network)
+		}
 	}
 
 	if len(peerGroupIDs) > 0 {
@@ -145,7 +163,11 @@ func (am *DefaultAccountManager) CreateRoute(accountID, network, peerID string,
 		}
 	}
 
-	err = am.checkRoutePrefixExistsForPeers(account, peerID, newRoute.ID, peerGroupIDs, newPrefix)
+	newRoute.Network = newPrefix
+	newRoute.NetworkType = prefixType
+	newRoute.Domains = domains
+
+	err = am.checkRoutePrefixExistsForPeers(account, peerID, newRoute.ID, peerGroupIDs, newRoute.NetString())
 	if err != nil {
 		return nil, err
 	}
@@ -154,6 +176,10 @@ func (am *DefaultAccountManager) CreateRoute(accountID, network, peerID string,
 		return nil, status.Errorf(status.InvalidArgument, "metric should be between %d and %d", route.MinMetric, route.MaxMetric)
 	}
 
+	if weight < 0 || weight > route.MaxWeight {
+		return nil, status.Errorf(status.InvalidArgument, "weight should be between 0 and %d", route.MaxWeight)
+	}
+
 	if utf8.RuneCountInString(string(netID)) > route.MaxNetIDChar || netID == "" {
 		return nil, status.Errorf(status.InvalidArgument, "identifier should be between 1 and %d", route.MaxNetIDChar)
 	}
@@ -165,12 +191,12 @@ func (am *DefaultAccountManager) CreateRoute(accountID, network, peerID string,
 
 	newRoute.Peer = peerID
 	newRoute.PeerGroups = peerGroupIDs
-	newRoute.Network = newPrefix
-	newRoute.NetworkType = prefixType
+	newRoute.KeepRoute = keepRoute
 	newRoute.Description = description
 	newRoute.NetID = netID
 	newRoute.Masquerade = masquerade
 	newRoute.Metric = metric
+	newRoute.Weight = weight
 	newRoute.Enabled = enabled
 	newRoute.Groups = groups
 
@@ -192,6 +218,161 @@ func (am *DefaultAccountManager) CreateRoute(accountID, network, peerID string,
 	return &newRoute, nil
 }
 
+// ProposePeerRoutes registers the networks proposed by a routing peer as disabled routes pending admin approval.
+// Networks already routed by the peer are skipped. It returns the newly proposed routes.
+func (am *DefaultAccountManager) ProposePeerRoutes(peerPubKey string, networks []netip.Prefix) ([]*route.Route, error) {
+	accountID, err := am.Store.GetAccountIDByPeerPubKey(peerPubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	peer, err := account.FindPeerByPubKey(peerPubKey)
+	if err != nil {
+		return nil, status.Errorf(status.NotFound, "peer with key %s not found", peerPubKey)
+	}
+
+	allGroup, err := account.GetGroupAll()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := 0
+	for _, r := range account.Routes {
+		if r.Peer == peer.ID && r.PendingApproval {
+			pending++
+		}
+	}
+
+	netID := route.NetID("lan-" + peer.DNSLabel)
+	if utf8.RuneCountInString(string(netID)) > route.MaxNetIDChar {
+		netID = netID[:route.MaxNetIDChar]
+	}
+
+	var proposed []*route.Route
+	for _, network := range networks {
+		if !network.IsValid() || network.Bits() == 0 || network != network.Masked() {
+			return nil, status.Errorf(status.InvalidArgument, "invalid network %s", network)
+		}
+
+		if peerRoutesNetwork(account, peer.ID, network) {
+			continue
+		}
+
+		if pending >= maxPendingRoutesPerPeer {
+			return nil, status.Errorf(status.PreconditionFailed,
+				"peer %s has reached the limit of %d routes pending approval", peer.Name, maxPendingRoutesPerPeer)
+		}
+		pending++
+
+		newRoute := &route.Route{
+			ID:              route.ID(xid.New().String()),
+			Network:         network,
+			NetID:           netID,
+			Description:     "Proposed by peer " + peer.Name,
+			Peer:            peer.ID,
+			NetworkType:     route.IPv4Network,
+			Masquerade:      true,
+			Metric:          route.MaxMetric,
+			Groups:          []string{allGroup.ID},
+			PendingApproval: true,
+		}
+		if network.Addr().Is6() {
+			newRoute.NetworkType = route.IPv6Network
+		}
+
+		if account.Routes == nil {
+			account.Routes = make(map[route.ID]*route.Route)
+		}
+		account.Routes[newRoute.ID] = newRoute
+		proposed = append(proposed, newRoute)
+	}
+
+	if len(proposed) == 0 {
+		return nil, nil
+	}
+
+	// pending routes are disabled, the peers don't have to be updated
+	if err = am.Store.SaveAccount(account); err != nil {
+		return nil, err
+	}
+
+	for _, r := range proposed {
+		am.StoreEvent(peer.ID, string(r.ID), accountID, activity.RouteProposed, r.EventMeta())
+	}
+
+	return proposed, nil
+}
+
+// peerRoutesNetwork returns true if the peer is the routing peer of a route for the network
+func peerRoutesNetwork(account *Account, peerID string, network netip.Prefix) bool {
+	for _, r := range account.Routes {
+		if r.Network != network {
+			continue
+		}
+		if r.Peer == peerID {
+			return true
+		}
+		for _, groupID := range r.PeerGroups {
+			group := account.GetGroup(groupID)
+			if group != nil && slices.Contains(group.Peers, peerID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ApproveRoute approves and enables a route proposed by its routing peer
+func (am *DefaultAccountManager) ApproveRoute(accountID string, routeID route.ID, userID string) (*route.Route, error) {
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasAdminPower() {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can approve routes")
+	}
+
+	routeToApprove := account.Routes[routeID]
+	if routeToApprove == nil {
+		return nil, status.Errorf(status.NotFound, "route with ID %s doesn't exist", routeID)
+	}
+
+	if !routeToApprove.PendingApproval {
+		return nil, status.Errorf(status.PreconditionFailed, "route with ID %s is not pending approval", routeID)
+	}
+
+	routeToApprove.PendingApproval = false
+	routeToApprove.Enabled = true
+
+	account.Network.IncSerial()
+	if err = am.Store.SaveAccount(account); err != nil {
+		return nil, err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, string(routeToApprove.ID), accountID, activity.RouteApproved, routeToApprove.EventMeta())
+
+	return routeToApprove, nil
+}
+
 // SaveRoute saves route
 func (am *DefaultAccountManager) SaveRoute(accountID, userID string, routeToSave *route.Route) error {
 	unlock := am.Store.AcquireAccountWriteLock(accountID)
@@ -201,7 +382,14 @@ func (am *DefaultAccountManager) SaveRoute(accountID, userID string, routeToSave
 		return status.Errorf(status.InvalidArgument, "route provided is nil")
 	}
 
-	if !routeToSave.Network.IsValid() {
+	if routeToSave.IsDynamic() {
+		domains, err := route.ParseDomains(routeToSave.Domains)
+		if err != nil {
+			return err
+		}
+		routeToSave.Domains = domains
+		routeToSave.Network = netip.Prefix{}
+	} else if !routeToSave.Network.IsValid() {
 		return status.Errorf(status.InvalidArgument, "invalid Prefix %s", routeToSave.Network.String())
 	}
 
@@ -209,6 +397,10 @@ func (am *DefaultAccountManager) SaveRoute(accountID, userID string, routeToSave
 		return status.Errorf(status.InvalidArgument, "metric should be between %d and %d", route.MinMetric, route.MaxMetric)
 	}
 
+	if routeToSave.Weight < 0 || routeToSave.Weight > route.MaxWeight {
+		return status.Errorf(status.InvalidArgument, "weight should be between 0 and %d", route.MaxWeight)
+	}
+
 	if utf8.RuneCountInString(string(routeToSave.NetID)) > route.MaxNetIDChar || routeToSave.NetID == "" {
 		return status.Errorf(status.InvalidArgument, "identifier should be between 1 and %d", route.MaxNetIDChar)
 	}
@@ -229,7 +421,7 @@ func (am *DefaultAccountManager) SaveRoute(accountID, userID string, routeToSave
 		}
 	}
 
-	err = am.checkRoutePrefixExistsForPeers(account, routeToSave.Peer, routeToSave.ID, routeToSave.Copy().PeerGroups, routeToSave.Network)
+	err = am.checkRoutePrefixExistsForPeers(account, routeToSave.Peer, routeToSave.ID, routeToSave.Copy().PeerGroups, routeToSave.NetString())
 	if err != nil {
 		return err
 	}
@@ -239,6 +431,17 @@ func (am *DefaultAccountManager) SaveRoute(accountID, userID string, routeToSave
 		return err
 	}
 
+	if existing := account.Routes[routeToSave.ID]; existing != nil && existing.RouteGroupID != "" {
+		return status.Errorf(status.PreconditionFailed, "route with ID %s is managed by route group %s", routeToSave.ID, existing.RouteGroupID)
+	}
+
+	if existing := account.Routes[routeToSave.ID]; existing != nil && existing.PendingApproval {
+		if routeToSave.Enabled {
+			return status.Errorf(status.PreconditionFailed, "route with ID %s is pending approval and can't be enabled", routeToSave.ID)
+		}
+		routeToSave.PendingApproval = true
+	}
+
 	account.Routes[routeToSave.ID] = routeToSave
 
 	account.Network.IncSerial()
@@ -267,6 +470,9 @@ func (am *DefaultAccountManager) DeleteRoute(accountID string, routeID route.ID,
 	if routy == nil {
 		return status.Errorf(status.NotFound, "route with ID %s doesn't exist", routeID)
 	}
+	if routy.RouteGroupID != "" {
+		return status.Errorf(status.PreconditionFailed, "route with ID %s is managed by route group %s", routeID, routy.RouteGroupID)
+	}
 	delete(account.Routes, routeID)
 
 	account.Network.IncSerial()
@@ -309,14 +515,22 @@ func (am *DefaultAccountManager) ListRoutes(accountID, userID string) ([]*route.
 }
 
 func toProtocolRoute(route *route.Route) *proto.Route {
+	var network string
+	if !route.IsDynamic() {
+		network = route.Network.String()
+	}
+
 	return &proto.Route{
 		ID:          string(route.ID),
 		NetID:       string(route.NetID),
-		Network:     route.Network.String(),
+		Network:     network,
 		NetworkType: int64(route.NetworkType),
 		Peer:        route.Peer,
 		Metric:      int64(route.Metric),
 		Masquerade:  route.Masquerade,
+		Domains:     route.Domains,
+		KeepRoute:   route.KeepRoute,
+		Weight:      int64(route.Weight),
 	}
 }
 
@@ -0,0 +1,558 @@
+package server
+
+import (
+	"net/netip"
+	"slices"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/xid"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+	"github.com/netbirdio/netbird/route"
+)
+
+const (
+	// RouteGroupFailoverPriority makes the clients route through the connected member with the lowest priority and
+	// fail over to the next one when it disconnects
+	RouteGroupFailoverPriority = "priority"
+	// RouteGroupFailoverECMP makes the clients spread the traffic across the connected members proportionally to their
+	// weights. It is supported for the IPv4 default route only.
+	RouteGroupFailoverECMP = "ecmp"
+
+	// loadRecoveryRatio is the share of the load thresholds the load of an overloaded member has to fall below for the
+	// member to recover, so that a load around a threshold doesn't make the clients switch members at every report
+	loadRecoveryRatio = 0.9
+)
+
+// RouteGroupMember is a routing peer of a route group
+type RouteGroupMember struct {
+	// PeerID is the ID of the routing peer
+	PeerID string
+	// Priority orders the members with the priority failover policy, the lowest is preferred. It is the metric of the
+	// member route.
+	Priority int
+	// Weight is the share of the traffic the member receives with the ECMP failover policy
+	Weight int
+	// Overloaded tells whether the load the routing peer reported exceeds the load thresholds of the route group. It
+	// is maintained by the management service.
+	Overloaded bool
+}
+
+// RouteGroupLoadThresholds are the loads above which a member of a route group is overloaded, zero values disable a
+// threshold. The clients route through an overloaded member only when all the members are overloaded.
+type RouteGroupLoadThresholds struct {
+	CPUPercent     float64
+	MemoryPercent  float64
+	ThroughputMbps uint64
+}
+
+// Enabled tells whether any threshold is set
+func (t RouteGroupLoadThresholds) Enabled() bool {
+	return t.CPUPercent > 0 || t.MemoryPercent > 0 || t.ThroughputMbps > 0
+}
+
+// Exceeded tells whether the load exceeds a threshold. The load of an overloaded member is compared to the thresholds
+// lowered by the recovery ratio.
+func (t RouteGroupLoadThresholds) Exceeded(load nbpeer.Load, overloaded bool) bool {
+	ratio := 1.0
+	if overloaded {
+		ratio = loadRecoveryRatio
+	}
+	return t.CPUPercent > 0 && load.CPUPercent > t.CPUPercent*ratio ||
+		t.MemoryPercent > 0 && load.MemoryPercent > t.MemoryPercent*ratio ||
+		t.ThroughputMbps > 0 && float64(load.ThroughputBps) > float64(t.ThroughputMbps)*1e6*ratio
+}
+
+// RouteGroup is a highly available route: a network routed by several member routing peers with an explicit failover
+// policy. The route group maintains one member route per routing peer, sharing the network identifier and the network,
+// which the clients group for failover.
+type RouteGroup struct {
+	// ID of the route group
+	ID string `gorm:"primaryKey"`
+	// AccountID is a reference to Account that this object belongs
+	AccountID string `gorm:"index"`
+	// Name of the route group, it is the network identifier of the member routes
+	Name string
+	// Description of the route group
+	Description string
+	// Network is the routed network range
+	Network netip.Prefix `gorm:"serializer:json"`
+	// FailoverPolicy is either RouteGroupFailoverPriority or RouteGroupFailoverECMP
+	FailoverPolicy string
+	// Members are the routing peers of the route group
+	Members []RouteGroupMember `gorm:"serializer:json"`
+	// Groups are the IDs of the groups of the peers the network is distributed to
+	Groups []string `gorm:"serializer:json"`
+	// Masquerade tells whether the routing peers masquerade the traffic
+	Masquerade bool
+	// Enabled tells whether the member routes are enabled
+	Enabled bool
+	// LoadThresholds are the loads above which the members are overloaded
+	LoadThresholds RouteGroupLoadThresholds `gorm:"serializer:json"`
+}
+
+// RouteGroupMemberStatus is the health of a member of a route group
+type RouteGroupMemberStatus struct {
+	PeerID  string
+	RouteID route.ID
+	// Connected tells whether the routing peer is connected to the management service
+	Connected bool
+	LastSeen  time.Time
+	// Active tells whether the clients route through the member: the connected members with the lowest priority with
+	// the priority failover policy, all the connected members with the ECMP one, the members that aren't overloaded
+	// first
+	Active bool
+	// Overloaded tells whether the load of the member exceeds the load thresholds of the route group
+	Overloaded bool
+	// Load is the latest load the routing peer reported, nil when it didn't report any
+	Load *nbpeer.Load
+}
+
+// EventMeta returns activity event meta related to the route group
+func (g *RouteGroup) EventMeta() map[string]any {
+	return map[string]any{"name": g.Name, "network_range": g.Network.String(), "failover_policy": g.FailoverPolicy}
+}
+
+// Copy copies a route group object
+func (g *RouteGroup) Copy() *RouteGroup {
+	c := *g
+	c.Members = slices.Clone(g.Members)
+	c.Groups = slices.Clone(g.Groups)
+	return &c
+}
+
+// applyRouteGroup creates, updates and deletes the member routes of the route group to match its members
+func (a *Account) applyRouteGroup(group *RouteGroup) {
+	memberRoutes := make(map[string]*route.Route)
+	for _, r := range a.Routes {
+		if r.RouteGroupID == group.ID {
+			memberRoutes[r.Peer] = r
+		}
+	}
+
+	networkType := route.IPv4Network
+	if group.Network.Addr().Is6() {
+		networkType = route.IPv6Network
+	}
+
+	for _, member := range group.Members {
+		memberRoute, ok := memberRoutes[member.PeerID]
+		if ok {
+			delete(memberRoutes, member.PeerID)
+		} else {
+			memberRoute = &route.Route{ID: route.ID(xid.New().String()), AccountID: a.Id, RouteGroupID: group.ID}
+			a.Routes[memberRoute.ID] = memberRoute
+		}
+
+		memberRoute.NetID = route.NetID(group.Name)
+		memberRoute.Description = group.Description
+		memberRoute.Network = group.Network
+		memberRoute.NetworkType = networkType
+		memberRoute.Peer = member.PeerID
+		memberRoute.Metric, memberRoute.Weight = group.memberRouting(member)
+		memberRoute.Masquerade = group.Masquerade
+		memberRoute.Enabled = group.Enabled
+		memberRoute.Groups = slices.Clone(group.Groups)
+	}
+
+	for _, r := range memberRoutes {
+		delete(a.Routes, r.ID)
+	}
+}
+
+// memberRouting returns the metric and the ECMP weight of the member route. The deprioritized members get the highest
+// metric and no weight, so that the clients prefer the other members.
+func (g *RouteGroup) memberRouting(member RouteGroupMember) (int, int) {
+	if g.deprioritized(member) {
+		return route.MaxMetric, 0
+	}
+	if g.FailoverPolicy == RouteGroupFailoverECMP {
+		return member.Priority, member.Weight
+	}
+	return member.Priority, 0
+}
+
+// deprioritized tells whether the member is overloaded while another member isn't
+func (g *RouteGroup) deprioritized(member RouteGroupMember) bool {
+	return member.Overloaded && slices.ContainsFunc(g.Members, func(m RouteGroupMember) bool { return !m.Overloaded })
+}
+
+// deleteRouteGroupRoutes deletes the member routes of the route group
+func (a *Account) deleteRouteGroupRoutes(groupID string) {
+	for id, r := range a.Routes {
+		if r.RouteGroupID == groupID {
+			delete(a.Routes, id)
+		}
+	}
+}
+
+// getRouteGroupMembersStatus returns the health of the members of the route group in the order of its members
+func (a *Account) getRouteGroupMembersStatus(group *RouteGroup) []RouteGroupMemberStatus {
+	routeIDs := make(map[string]route.ID)
+	for _, r := range a.Routes {
+		if r.RouteGroupID == group.ID {
+			routeIDs[r.Peer] = r.ID
+		}
+	}
+
+	statuses := make([]RouteGroupMemberStatus, 0, len(group.Members))
+	bestMetric := route.MaxMetric + 1
+	ecmpCandidates := 0
+	for _, member := range group.Members {
+		memberStatus := RouteGroupMemberStatus{
+			PeerID:     member.PeerID,
+			RouteID:    routeIDs[member.PeerID],
+			Overloaded: member.Overloaded,
+		}
+		if peer := a.GetPeer(member.PeerID); peer != nil && peer.Status != nil {
+			memberStatus.Connected = peer.Status.Connected
+			memberStatus.LastSeen = peer.Status.LastSeen
+		}
+		if memberStatus.Connected {
+			metric, _ := group.memberRouting(member)
+			bestMetric = min(bestMetric, metric)
+			if !group.deprioritized(member) {
+				ecmpCandidates++
+			}
+		}
+		statuses = append(statuses, memberStatus)
+	}
+
+	if !group.Enabled {
+		return statuses
+	}
+
+	for i, member := range group.Members {
+		if !statuses[i].Connected {
+			continue
+		}
+		// with the ECMP failover policy the clients spread the traffic across the members that aren't deprioritized
+		// when there are two of them at least, and route through the member with the lowest metric otherwise
+		if group.FailoverPolicy == RouteGroupFailoverECMP && ecmpCandidates >= 2 {
+			statuses[i].Active = !group.deprioritized(member)
+			continue
+		}
+		metric, _ := group.memberRouting(member)
+		statuses[i].Active = metric == bestMetric
+	}
+
+	return statuses
+}
+
+// GetRouteGroup gets a route group object from account and route group IDs
+func (am *DefaultAccountManager) GetRouteGroup(accountID, userID, routeGroupID string) (*RouteGroup, error) {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view route groups")
+	}
+
+	group, found := account.RouteGroups[routeGroupID]
+	if found {
+		return group.Copy(), nil
+	}
+
+	return nil, status.Errorf(status.NotFound, "route group with ID %s not found", routeGroupID)
+}
+
+// CreateRouteGroup validates and saves a new route group along with its member routes
+func (am *DefaultAccountManager) CreateRouteGroup(accountID, userID string, routeGroup *RouteGroup) (*RouteGroup, error) {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	if routeGroup == nil {
+		return nil, status.Errorf(status.InvalidArgument, "route group provided is nil")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	newGroup := routeGroup.Copy()
+	newGroup.ID = xid.New().String()
+	newGroup.AccountID = accountID
+
+	err = validateRouteGroup(false, newGroup, account)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.RouteGroups == nil {
+		account.RouteGroups = make(map[string]*RouteGroup)
+	}
+
+	am.refreshRouteGroupOverload(account, newGroup, nil)
+	account.RouteGroups[newGroup.ID] = newGroup
+	account.applyRouteGroup(newGroup)
+
+	account.Network.IncSerial()
+	if err = am.Store.SaveAccount(account); err != nil {
+		return nil, err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, newGroup.ID, accountID, activity.RouteGroupCreated, newGroup.EventMeta())
+
+	return newGroup.Copy(), nil
+}
+
+// SaveRouteGroup validates and updates an existing route group along with its member routes
+func (am *DefaultAccountManager) SaveRouteGroup(accountID, userID string, routeGroupToSave *RouteGroup) error {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	if routeGroupToSave == nil {
+		return status.Errorf(status.InvalidArgument, "route group provided is nil")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	group := routeGroupToSave.Copy()
+	group.AccountID = accountID
+
+	err = validateRouteGroup(true, group, account)
+	if err != nil {
+		return err
+	}
+
+	am.refreshRouteGroupOverload(account, group, account.RouteGroups[group.ID])
+	account.RouteGroups[group.ID] = group
+	account.applyRouteGroup(group)
+
+	account.Network.IncSerial()
+	if err = am.Store.SaveAccount(account); err != nil {
+		return err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, group.ID, accountID, activity.RouteGroupUpdated, group.EventMeta())
+
+	return nil
+}
+
+// DeleteRouteGroup deletes the route group with routeGroupID along with its member routes
+func (am *DefaultAccountManager) DeleteRouteGroup(accountID, userID, routeGroupID string) error {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	group := account.RouteGroups[routeGroupID]
+	if group == nil {
+		return status.Errorf(status.NotFound, "route group %s wasn't found", routeGroupID)
+	}
+	delete(account.RouteGroups, routeGroupID)
+	account.deleteRouteGroupRoutes(routeGroupID)
+
+	account.Network.IncSerial()
+	if err = am.Store.SaveAccount(account); err != nil {
+		return err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, group.ID, accountID, activity.RouteGroupDeleted, group.EventMeta())
+
+	return nil
+}
+
+// ListRouteGroups returns a list of the route groups from account
+func (am *DefaultAccountManager) ListRouteGroups(accountID, userID string) ([]*RouteGroup, error) {
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view route groups")
+	}
+
+	groups := make([]*RouteGroup, 0, len(account.RouteGroups))
+	for _, item := range account.RouteGroups {
+		groups = append(groups, item.Copy())
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+
+	return groups, nil
+}
+
+// GetRouteGroupMembersStatus returns the health of the members of every route group of the account by route group ID
+func (am *DefaultAccountManager) GetRouteGroupMembersStatus(accountID, userID string) (map[string][]RouteGroupMemberStatus, error) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view route groups")
+	}
+
+	am.peerLoadsMux.RLock()
+	defer am.peerLoadsMux.RUnlock()
+
+	statuses := make(map[string][]RouteGroupMemberStatus, len(account.RouteGroups))
+	for id, group := range account.RouteGroups {
+		statuses[id] = account.getRouteGroupMembersStatus(group)
+		for i := range statuses[id] {
+			peer := account.GetPeer(statuses[id][i].PeerID)
+			if peer == nil {
+				continue
+			}
+			if load, ok := am.peerLoads[peer.Key]; ok {
+				statuses[id][i].Load = &load
+			}
+		}
+	}
+
+	return statuses, nil
+}
+
+// validateRouteGroup checks the route group against the account and sets the default priorities and weights of its
+// members
+func validateRouteGroup(existingGroup bool, group *RouteGroup, account *Account) error {
+	if existingGroup {
+		_, found := account.RouteGroups[group.ID]
+		if !found {
+			return status.Errorf(status.NotFound, "route group with ID %s was not found", group.ID)
+		}
+	}
+
+	if group.Name == "" || utf8.RuneCountInString(group.Name) > route.MaxNetIDChar {
+		return status.Errorf(status.InvalidArgument, "route group name should be between 1 and %d characters", route.MaxNetIDChar)
+	}
+
+	for _, other := range account.RouteGroups {
+		if other.ID != group.ID && other.Name == group.Name {
+			return status.Errorf(status.InvalidArgument, "a route group with name %s already exists", group.Name)
+		}
+	}
+
+	if !group.Network.IsValid() {
+		return status.Errorf(status.InvalidArgument, "invalid Prefix %s", group.Network.String())
+	}
+	group.Network = group.Network.Masked()
+
+	switch group.FailoverPolicy {
+	case "":
+		group.FailoverPolicy = RouteGroupFailoverPriority
+	case RouteGroupFailoverPriority:
+	case RouteGroupFailoverECMP:
+		if group.Network != netip.MustParsePrefix("0.0.0.0/0") {
+			return status.Errorf(status.InvalidArgument, "the ecmp failover policy is supported for the 0.0.0.0/0 network only")
+		}
+	default:
+		return status.Errorf(status.InvalidArgument, "unknown failover policy %s, should be %s or %s",
+			group.FailoverPolicy, RouteGroupFailoverPriority, RouteGroupFailoverECMP)
+	}
+
+	thresholds := group.LoadThresholds
+	if thresholds.CPUPercent < 0 || thresholds.CPUPercent > 100 || thresholds.MemoryPercent < 0 || thresholds.MemoryPercent > 100 {
+		return status.Errorf(status.InvalidArgument, "the CPU and memory load thresholds should be between 0 and 100 percent")
+	}
+
+	if len(group.Members) == 0 {
+		return status.Errorf(status.InvalidArgument, "route group %s should have at least one member", group.Name)
+	}
+
+	members := make(lookupMap)
+	for i := range group.Members {
+		member := &group.Members[i]
+		if account.GetPeer(member.PeerID) == nil {
+			return status.Errorf(status.InvalidArgument, "peer with ID %s doesn't exist", member.PeerID)
+		}
+		if _, ok := members[member.PeerID]; ok {
+			return status.Errorf(status.InvalidArgument, "peer %s is a member of route group %s more than once", member.PeerID, group.Name)
+		}
+		members[member.PeerID] = struct{}{}
+
+		// the members without a priority are preferred in the order they are listed
+		if member.Priority == 0 {
+			member.Priority = i + 1
+		}
+		if member.Priority < route.MinMetric || member.Priority > route.MaxMetric {
+			return status.Errorf(status.InvalidArgument, "priority should be between %d and %d", route.MinMetric, route.MaxMetric)
+		}
+
+		if member.Weight == 0 && group.FailoverPolicy == RouteGroupFailoverECMP {
+			member.Weight = 1
+		}
+		if member.Weight < 0 || member.Weight > route.MaxWeight {
+			return status.Errorf(status.InvalidArgument, "weight should be between 0 and %d", route.MaxWeight)
+		}
+	}
+
+	// the clients would fail over between the member routes and the other routes of the same network identifier and
+	// network, and a routing peer can route a network once
+	for _, r := range account.Routes {
+		if r.RouteGroupID == group.ID || r.IsDynamic() || r.Network != group.Network {
+			continue
+		}
+		if r.NetID == route.NetID(group.Name) {
+			return status.Errorf(status.InvalidArgument, "route %s already routes network %s with the same identifier", r.ID, group.Network)
+		}
+		for _, member := range group.Members {
+			if r.Peer == member.PeerID || account.peerInGroups(member.PeerID, r.PeerGroups) {
+				return status.Errorf(status.AlreadyExists, "peer %s already routes network %s", member.PeerID, group.Network)
+			}
+		}
+	}
+
+	return validateGroups(group.Groups, account.Groups)
+}
+
+// peerInGroups tells whether the peer is a member of one of the groups
+func (a *Account) peerInGroups(peerID string, groupIDs []string) bool {
+	for _, groupID := range groupIDs {
+		if group := a.GetGroup(groupID); group != nil && slices.Contains(group.Peers, peerID) {
+			return true
+		}
+	}
+	return false
+}
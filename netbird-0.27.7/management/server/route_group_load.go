@@ -0,0 +1,157 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// UpdatePeerLoad stores the load reported by the routing peer identified by its WireGuard public key. The load is kept
+// in memory only and replaces the previously reported one. When the load crosses the load thresholds of a route group
+// the peer is a member of, the member routes are updated so that the clients prefer the members that aren't overloaded.
+func (am *DefaultAccountManager) UpdatePeerLoad(peerPubKey string, load nbpeer.Load) error {
+	if load.CPUPercent < 0 || load.CPUPercent > 100 || load.MemoryPercent < 0 || load.MemoryPercent > 100 {
+		return status.Errorf(status.InvalidArgument, "invalid peer load")
+	}
+
+	accountID, err := am.Store.GetAccountIDByPeerPubKey(peerPubKey)
+	if err != nil {
+		return err
+	}
+
+	am.peerLoadsMux.Lock()
+	if am.peerLoads == nil {
+		am.peerLoads = make(map[string]nbpeer.Load)
+	}
+	am.peerLoads[peerPubKey] = load
+	am.peerLoadsMux.Unlock()
+
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	peer, err := account.FindPeerByPubKey(peerPubKey)
+	if err != nil {
+		return status.Errorf(status.NotFound, "peer with key %s not found", peerPubKey)
+	}
+
+	changed := false
+	for _, group := range account.RouteGroups {
+		if !group.LoadThresholds.Enabled() {
+			continue
+		}
+		for i := range group.Members {
+			member := &group.Members[i]
+			if member.PeerID != peer.ID {
+				continue
+			}
+			overloaded := group.LoadThresholds.Exceeded(load, member.Overloaded)
+			if overloaded == member.Overloaded {
+				continue
+			}
+			member.Overloaded = overloaded
+			account.applyRouteGroup(group)
+			changed = true
+
+			meta := group.EventMeta()
+			meta["peer_name"] = peer.Name
+			meta["peer_ip"] = peer.IP
+			meta["cpu_percent"] = fmt.Sprintf("%.1f", load.CPUPercent)
+			meta["memory_percent"] = fmt.Sprintf("%.1f", load.MemoryPercent)
+			meta["throughput_mbps"] = fmt.Sprintf("%.1f", float64(load.ThroughputBps)/1e6)
+			event := activity.RouteGroupMemberRecovered
+			if overloaded {
+				event = activity.RouteGroupMemberOverloaded
+			}
+			am.StoreEvent(peer.ID, group.ID, accountID, event, meta)
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	account.Network.IncSerial()
+	if err = am.Store.SaveAccount(account); err != nil {
+		return err
+	}
+
+	am.updateAccountPeers(account)
+
+	return nil
+}
+
+// GetRoutingPeersLoad returns the latest load reported by the routing peers of the account by peer ID. Only users with
+// admin power can view it.
+func (am *DefaultAccountManager) GetRoutingPeersLoad(accountID, userID string) (map[string]nbpeer.Load, error) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view the load of the routing peers")
+	}
+
+	am.peerLoadsMux.RLock()
+	defer am.peerLoadsMux.RUnlock()
+
+	loads := make(map[string]nbpeer.Load)
+	for _, peer := range account.Peers {
+		if load, ok := am.peerLoads[peer.Key]; ok {
+			loads[peer.ID] = load
+		}
+	}
+
+	return loads, nil
+}
+
+// refreshRouteGroupOverload sets the overloaded members of the route group from the latest load of their routing peers.
+// The members of the previous version of the route group keep their state until their load crosses a threshold.
+func (am *DefaultAccountManager) refreshRouteGroupOverload(account *Account, group, previous *RouteGroup) {
+	overloaded := make(map[string]bool)
+	if previous != nil {
+		for _, member := range previous.Members {
+			overloaded[member.PeerID] = member.Overloaded
+		}
+	}
+
+	am.peerLoadsMux.RLock()
+	defer am.peerLoadsMux.RUnlock()
+
+	for i := range group.Members {
+		member := &group.Members[i]
+		member.Overloaded = false
+		if !group.LoadThresholds.Enabled() {
+			continue
+		}
+		peer := account.GetPeer(member.PeerID)
+		if peer == nil {
+			continue
+		}
+		if load, ok := am.peerLoads[peer.Key]; ok {
+			member.Overloaded = group.LoadThresholds.Exceeded(load, overloaded[member.PeerID])
+		}
+	}
+}
+
+func (am *DefaultAccountManager) deletePeerLoad(peerPubKey string) {
+	am.peerLoadsMux.Lock()
+	defer am.peerLoadsMux.Unlock()
+
+	delete(am.peerLoads, peerPubKey)
+}
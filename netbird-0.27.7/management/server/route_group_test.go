@@ -0,0 +1,290 @@
+package server
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbgroup "github.com/netbirdio/netbird/management/server/group"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/route"
+)
+
+func getRouteGroupRoutes(account *Account, routeGroupID string) map[string]*route.Route {
+	routes := make(map[string]*route.Route)
+	for _, r := range account.Routes {
+		if r.RouteGroupID == routeGroupID {
+			routes[r.Peer] = r
+		}
+	}
+	return routes
+}
+
+func TestDefaultAccountManager_RouteGroup(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err, "unable to create an account")
+
+	gateway1, _, err := manager.AddPeer("", userID, newQuotaTestPeer(t, "gateway1"))
+	require.NoError(t, err, "unable to add peer")
+	gateway2, _, err := manager.AddPeer("", userID, newQuotaTestPeer(t, "gateway2"))
+	require.NoError(t, err, "unable to add peer")
+	require.NoError(t, manager.SaveGroup(account.Id, userID, &nbgroup.Group{ID: "clients", Name: "clients"}))
+
+	routeGroup, err := manager.CreateRouteGroup(account.Id, userID, &RouteGroup{
+		Name:    "office",
+		Network: netip.MustParsePrefix("10.64.0.0/24"),
+		Members: []RouteGroupMember{{PeerID: gateway1.ID}, {PeerID: gateway2.ID, Priority: 50}},
+		Groups:  []string{"clients"},
+		Enabled: true,
+	})
+	require.NoError(t, err, "unable to create the route group")
+	assert.Equal(t, RouteGroupFailoverPriority, routeGroup.FailoverPolicy)
+	assert.Equal(t, 1, routeGroup.Members[0].Priority, "the priority should default to the position of the member")
+	ev := getEvent(t, account.Id, manager, activity.RouteGroupCreated)
+	assert.Equal(t, routeGroup.ID, ev.TargetID)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	routes := getRouteGroupRoutes(account, routeGroup.ID)
+	require.Len(t, routes, 2, "a member route should be created for each member")
+	assert.Equal(t, route.NetID("office"), routes[gateway1.ID].NetID)
+	assert.Equal(t, 1, routes[gateway1.ID].Metric)
+	assert.Equal(t, 50, routes[gateway2.ID].Metric)
+	assert.Equal(t, []string{"clients"}, routes[gateway2.ID].Groups)
+
+	memberRoute := routes[gateway1.ID].Copy()
+	memberRoute.Metric = 10
+	err = manager.SaveRoute(account.Id, userID, memberRoute)
+	require.Error(t, err, "a member route should only be changed through its route group")
+	err = manager.DeleteRoute(account.Id, memberRoute.ID, userID)
+	require.Error(t, err, "a member route should only be deleted through its route group")
+
+	_, err = manager.CreateRoute(account.Id, "10.64.0.0/24", nil, false, gateway1.ID, []string{}, "", "other", false, 9999, 0, []string{"clients"}, true, userID)
+	require.Error(t, err, "a member shouldn't route the network of its route group twice")
+
+	routeGroup.Members = routeGroup.Members[:1]
+	routeGroup.Masquerade = true
+	require.NoError(t, manager.SaveRouteGroup(account.Id, userID, routeGroup))
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	routes = getRouteGroupRoutes(account, routeGroup.ID)
+	require.Len(t, routes, 1, "the route of a removed member should be deleted")
+	assert.Equal(t, memberRoute.ID, routes[gateway1.ID].ID, "the route of a kept member should be updated in place")
+	assert.True(t, routes[gateway1.ID].Masquerade)
+
+	require.NoError(t, manager.DeleteRouteGroup(account.Id, userID, routeGroup.ID))
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Empty(t, getRouteGroupRoutes(account, routeGroup.ID), "the member routes should be deleted with the route group")
+	_ = getEvent(t, account.Id, manager, activity.RouteGroupDeleted)
+}
+
+func TestCreateRouteGroup_Validation(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err, "unable to create an account")
+
+	gateway, _, err := manager.AddPeer("", userID, newQuotaTestPeer(t, "gateway"))
+	require.NoError(t, err, "unable to add peer")
+	require.NoError(t, manager.SaveGroup(account.Id, userID, &nbgroup.Group{ID: "clients", Name: "clients"}))
+
+	testCases := []struct {
+		name       string
+		routeGroup *RouteGroup
+	}{
+		{
+			name:       "without members",
+			routeGroup: &RouteGroup{Name: "office", Network: netip.MustParsePrefix("10.64.0.0/24"), Groups: []string{"clients"}},
+		},
+		{
+			name: "unknown member",
+			routeGroup: &RouteGroup{Name: "office", Network: netip.MustParsePrefix("10.64.0.0/24"), Groups: []string{"clients"},
+				Members: []RouteGroupMember{{PeerID: "missing"}}},
+		},
+		{
+			name: "duplicated member",
+			routeGroup: &RouteGroup{Name: "office", Network: netip.MustParsePrefix("10.64.0.0/24"), Groups: []string{"clients"},
+				Members: []RouteGroupMember{{PeerID: gateway.ID}, {PeerID: gateway.ID}}},
+		},
+		{
+			name: "ecmp on a network other than the default route",
+			routeGroup: &RouteGroup{Name: "office", Network: netip.MustParsePrefix("10.64.0.0/24"), Groups: []string{"clients"},
+				FailoverPolicy: RouteGroupFailoverECMP, Members: []RouteGroupMember{{PeerID: gateway.ID}}},
+		},
+		{
+			name: "unknown failover policy",
+			routeGroup: &RouteGroup{Name: "office", Network: netip.MustParsePrefix("10.64.0.0/24"), Groups: []string{"clients"},
+				FailoverPolicy: "random", Members: []RouteGroupMember{{PeerID: gateway.ID}}},
+		},
+		{
+			name: "without distribution groups",
+			routeGroup: &RouteGroup{Name: "office", Network: netip.MustParsePrefix("10.64.0.0/24"),
+				Members: []RouteGroupMember{{PeerID: gateway.ID}}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := manager.CreateRouteGroup(account.Id, userID, testCase.routeGroup)
+			require.Error(t, err, "should fail creating the route group")
+		})
+	}
+
+	routeGroup, err := manager.CreateRouteGroup(account.Id, userID, &RouteGroup{
+		Name: "internet", Network: netip.MustParsePrefix("0.0.0.0/0"), Groups: []string{"clients"},
+		FailoverPolicy: RouteGroupFailoverECMP, Members: []RouteGroupMember{{PeerID: gateway.ID}},
+	})
+	require.NoError(t, err, "the ecmp failover policy should be accepted for the default route")
+	assert.Equal(t, 1, routeGroup.Members[0].Weight, "the weight should default to 1")
+}
+
+func TestAccount_getRouteGroupMembersStatus(t *testing.T) {
+	lastSeen := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	account := &Account{
+		Peers: map[string]*nbpeer.Peer{
+			"gw1": {ID: "gw1", Status: &nbpeer.PeerStatus{Connected: false, LastSeen: lastSeen}},
+			"gw2": {ID: "gw2", Status: &nbpeer.PeerStatus{Connected: true, LastSeen: lastSeen}},
+			"gw3": {ID: "gw3", Status: &nbpeer.PeerStatus{Connected: true, LastSeen: lastSeen}},
+		},
+		Routes: map[route.ID]*route.Route{
+			"r1": {ID: "r1", Peer: "gw1", RouteGroupID: "rg"},
+			"r2": {ID: "r2", Peer: "gw2", RouteGroupID: "rg"},
+			"r3": {ID: "r3", Peer: "gw3", RouteGroupID: "rg"},
+		},
+	}
+	routeGroup := &RouteGroup{
+		ID:             "rg",
+		FailoverPolicy: RouteGroupFailoverPriority,
+		Members:        []RouteGroupMember{{PeerID: "gw1", Priority: 1}, {PeerID: "gw2", Priority: 2}, {PeerID: "gw3", Priority: 3}},
+		Enabled:        true,
+	}
+
+	statuses := account.getRouteGroupMembersStatus(routeGroup)
+	assert.Equal(t, []RouteGroupMemberStatus{
+		{PeerID: "gw1", RouteID: "r1", LastSeen: lastSeen},
+		{PeerID: "gw2", RouteID: "r2", Connected: true, LastSeen: lastSeen, Active: true},
+		{PeerID: "gw3", RouteID: "r3", Connected: true, LastSeen: lastSeen},
+	}, statuses, "the connected member with the lowest priority should be active")
+
+	routeGroup.FailoverPolicy = RouteGroupFailoverECMP
+	statuses = account.getRouteGroupMembersStatus(routeGroup)
+	assert.False(t, statuses[0].Active)
+	assert.True(t, statuses[1].Active, "all connected members should be active with ecmp")
+	assert.True(t, statuses[2].Active, "all connected members should be active with ecmp")
+
+	routeGroup.Enabled = false
+	for _, memberStatus := range account.getRouteGroupMembersStatus(routeGroup) {
+		assert.False(t, memberStatus.Active, "the members of a disabled route group shouldn't be active")
+	}
+}
+
+func TestAccount_getRouteGroupMembersStatusOverloaded(t *testing.T) {
+	account := &Account{
+		Peers: map[string]*nbpeer.Peer{
+			"gw1": {ID: "gw1", Status: &nbpeer.PeerStatus{Connected: true}},
+			"gw2": {ID: "gw2", Status: &nbpeer.PeerStatus{Connected: true}},
+			"gw3": {ID: "gw3", Status: &nbpeer.PeerStatus{Connected: true}},
+		},
+		Routes: map[route.ID]*route.Route{},
+	}
+	routeGroup := &RouteGroup{
+		ID:             "rg",
+		FailoverPolicy: RouteGroupFailoverPriority,
+		Members: []RouteGroupMember{
+			{PeerID: "gw1", Priority: 1, Weight: 1, Overloaded: true},
+			{PeerID: "gw2", Priority: 2, Weight: 1},
+			{PeerID: "gw3", Priority: 3, Weight: 1},
+		},
+		Enabled: true,
+	}
+
+	statuses := account.getRouteGroupMembersStatus(routeGroup)
+	assert.True(t, statuses[0].Overloaded)
+	assert.False(t, statuses[0].Active, "an overloaded member shouldn't be active")
+	assert.True(t, statuses[1].Active, "the next member should take over from the overloaded one")
+	assert.False(t, statuses[2].Active)
+
+	routeGroup.FailoverPolicy = RouteGroupFailoverECMP
+	statuses = account.getRouteGroupMembersStatus(routeGroup)
+	assert.False(t, statuses[0].Active, "an overloaded member shouldn't receive traffic with ecmp")
+	assert.True(t, statuses[1].Active)
+	assert.True(t, statuses[2].Active)
+
+	for i := range routeGroup.Members {
+		routeGroup.Members[i].Overloaded = true
+	}
+	statuses = account.getRouteGroupMembersStatus(routeGroup)
+	for _, memberStatus := range statuses {
+		assert.True(t, memberStatus.Active, "the members should be used as usual when they are all overloaded")
+	}
+}
+
+func TestDefaultAccountManager_UpdatePeerLoad(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err, "unable to create an account")
+
+	gateway1, _, err := manager.AddPeer("", userID, newQuotaTestPeer(t, "gateway1"))
+	require.NoError(t, err, "unable to add peer")
+	gateway2, _, err := manager.AddPeer("", userID, newQuotaTestPeer(t, "gateway2"))
+	require.NoError(t, err, "unable to add peer")
+	require.NoError(t, manager.SaveGroup(account.Id, userID, &nbgroup.Group{ID: "clients", Name: "clients"}))
+
+	routeGroup, err := manager.CreateRouteGroup(account.Id, userID, &RouteGroup{
+		Name:           "office",
+		Network:        netip.MustParsePrefix("10.64.0.0/24"),
+		Members:        []RouteGroupMember{{PeerID: gateway1.ID}, {PeerID: gateway2.ID}},
+		Groups:         []string{"clients"},
+		Enabled:        true,
+		LoadThresholds: RouteGroupLoadThresholds{CPUPercent: 80, ThroughputMbps: 100},
+	})
+	require.NoError(t, err, "unable to create the route group")
+
+	memberRoutes := func() map[string]*route.Route {
+		account, err := manager.Store.GetAccount(account.Id)
+		require.NoError(t, err)
+		return getRouteGroupRoutes(account, routeGroup.ID)
+	}
+
+	require.NoError(t, manager.UpdatePeerLoad(gateway1.Key, nbpeer.Load{CPUPercent: 95, ReportedAt: time.Now()}))
+	routes := memberRoutes()
+	assert.Equal(t, route.MaxMetric, routes[gateway1.ID].Metric, "the overloaded member should get the highest metric")
+	assert.Equal(t, 2, routes[gateway2.ID].Metric)
+	ev := getEvent(t, account.Id, manager, activity.RouteGroupMemberOverloaded)
+	assert.Equal(t, gateway1.ID, ev.InitiatorID)
+	assert.Equal(t, routeGroup.ID, ev.TargetID)
+
+	require.NoError(t, manager.UpdatePeerLoad(gateway1.Key, nbpeer.Load{CPUPercent: 75, ReportedAt: time.Now()}))
+	assert.Equal(t, route.MaxMetric, memberRoutes()[gateway1.ID].Metric,
+		"the member should stay overloaded until its load falls below the recovery ratio of the thresholds")
+
+	require.NoError(t, manager.UpdatePeerLoad(gateway1.Key, nbpeer.Load{CPUPercent: 50, ReportedAt: time.Now()}))
+	assert.Equal(t, 1, memberRoutes()[gateway1.ID].Metric, "the recovered member should get its priority back")
+	getEvent(t, account.Id, manager, activity.RouteGroupMemberRecovered)
+
+	require.NoError(t, manager.UpdatePeerLoad(gateway2.Key, nbpeer.Load{ThroughputBps: 150_000_000, ReportedAt: time.Now()}))
+	routeGroup.LoadThresholds = RouteGroupLoadThresholds{}
+	require.NoError(t, manager.SaveRouteGroup(account.Id, userID, routeGroup))
+	assert.Equal(t, 2, memberRoutes()[gateway2.ID].Metric, "removing the thresholds should clear the overloaded members")
+
+	loads, err := manager.GetRoutingPeersLoad(account.Id, userID)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(150_000_000), loads[gateway2.ID].ThroughputBps)
+	assert.Equal(t, 50.0, loads[gateway1.ID].CPUPercent)
+
+	statuses, err := manager.GetRouteGroupMembersStatus(account.Id, userID)
+	require.NoError(t, err)
+	require.NotNil(t, statuses[routeGroup.ID][0].Load)
+	assert.Equal(t, 50.0, statuses[routeGroup.ID][0].Load.CPUPercent)
+
+	err = manager.UpdatePeerLoad(gateway1.Key, nbpeer.Load{CPUPercent: 150})
+	assert.Error(t, err, "an invalid load should be rejected")
+}
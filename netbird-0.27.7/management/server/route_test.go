@@ -40,12 +40,15 @@ const (
 func TestCreateRoute(t *testing.T) {
 	type input struct {
 		network      string
+		domains      []string
+		keepRoute    bool
 		netID        route.NetID
 		peerKey      string
 		peerGroupIDs []string
 		description  string
 		masquerade   bool
 		metric       int
+		weight       int
 		enabled      bool
 		groups       []string
 	}
@@ -84,6 +87,61 @@ func TestCreateRoute(t *testing.T) {
 				Groups:      []string{routeGroup1},
 			},
 		},
+		{
+			name: "Happy Path Domains",
+			inputArgs: input{
+				domains:     []string{"Example.com", "*.example.org."},
+				keepRoute:   true,
+				netID:       "happy",
+				peerKey:     peer1ID,
+				description: "super",
+				metric:      9999,
+				enabled:     true,
+				groups:      []string{routeGroup1},
+			},
+			errFunc:      require.NoError,
+			shouldCreate: true,
+			expectedRoute: &route.Route{
+				NetworkType: route.DomainNetwork,
+				Domains:     []string{"example.com", "*.example.org"},
+				KeepRoute:   true,
+				NetID:       "happy",
+				Peer:        peer1ID,
+				Description: "super",
+				Metric:      9999,
+				Enabled:     true,
+				Groups:      []string{routeGroup1},
+			},
+		},
+		{
+			name: "Both network and domains Provided Should Fail",
+			inputArgs: input{
+				network:     "192.168.0.0/16",
+				domains:     []string{"example.com"},
+				netID:       "happy",
+				peerKey:     peer1ID,
+				description: "super",
+				metric:      9999,
+				enabled:     true,
+				groups:      []string{routeGroup1},
+			},
+			errFunc:      require.Error,
+			shouldCreate: false,
+		},
+		{
+			name: "Bad Domain Should Fail",
+			inputArgs: input{
+				domains:     []string{"example..com"},
+				netID:       "happy",
+				peerKey:     peer1ID,
+				description: "super",
+				metric:      9999,
+				enabled:     true,
+				groups:      []string{routeGroup1},
+			},
+			errFunc:      require.Error,
+			shouldCreate: false,
+		},
 		{
 			name: "Happy Path Peer Groups",
 			inputArgs: input{
@@ -229,6 +287,21 @@ func TestCreateRoute(t *testing.T) {
 			errFunc:      require.Error,
 			shouldCreate: false,
 		},
+		{
+			name: "Large Weight Should Fail",
+			inputArgs: input{
+				network:     "192.168.0.0/16",
+				peerKey:     peer1ID,
+				netID:       "happy",
+				description: "super",
+				metric:      9999,
+				weight:      route.MaxWeight + 1,
+				enabled:     true,
+				groups:      []string{routeGroup1},
+			},
+			errFunc:      require.Error,
+			shouldCreate: false,
+		},
 		{
 			name: "Small Metric Should Fail",
 			inputArgs: input{
@@ -337,8 +410,8 @@ func TestCreateRoute(t *testing.T) {
 				if errInit != nil {
 					t.Errorf("failed to get group all: %s", errInit)
 				}
-				_, errInit = am.CreateRoute(account.Id, existingNetwork, "", []string{routeGroup3, routeGroup4},
-					"", existingRouteID, false, 1000, []string{groupAll.ID}, true, userID)
+				_, errInit = am.CreateRoute(account.Id, existingNetwork, nil, false, "", []string{routeGroup3, routeGroup4},
+					"", existingRouteID, false, 1000, 0, []string{groupAll.ID}, true, userID)
 				if errInit != nil {
 					t.Errorf("failed to create init route: %s", errInit)
 				}
@@ -347,12 +420,15 @@ func TestCreateRoute(t *testing.T) {
 			outRoute, err := am.CreateRoute(
 				account.Id,
 				testCase.inputArgs.network,
+				testCase.inputArgs.domains,
+				testCase.inputArgs.keepRoute,
 				testCase.inputArgs.peerKey,
 				testCase.inputArgs.peerGroupIDs,
 				testCase.inputArgs.description,
 				testCase.inputArgs.netID,
 				testCase.inputArgs.masquerade,
 				testCase.inputArgs.metric,
+				testCase.inputArgs.weight,
 				testCase.inputArgs.groups,
 				testCase.inputArgs.enabled,
 				userID,
@@ -812,6 +888,55 @@ func TestDeleteRoute(t *testing.T) {
 	}
 }
 
+func TestProposeAndApproveRoutes(t *testing.T) {
+	am, err := createRouterManager(t)
+	require.NoError(t, err, "failed to create account manager")
+
+	account, err := initTestRouteAccount(t, am)
+	require.NoError(t, err, "failed to init testing account")
+
+	lan := netip.MustParsePrefix("192.168.1.0/24")
+
+	proposed, err := am.ProposePeerRoutes(peer1Key, []netip.Prefix{lan})
+	require.NoError(t, err)
+	require.Len(t, proposed, 1)
+	assert.Equal(t, lan, proposed[0].Network)
+	assert.Equal(t, peer1ID, proposed[0].Peer)
+	assert.True(t, proposed[0].PendingApproval)
+	assert.False(t, proposed[0].Enabled)
+
+	// proposing the same network again doesn't register another route
+	proposed, err = am.ProposePeerRoutes(peer1Key, []netip.Prefix{lan})
+	require.NoError(t, err)
+	assert.Empty(t, proposed)
+
+	_, err = am.ProposePeerRoutes(peer1Key, []netip.Prefix{netip.MustParsePrefix("192.168.1.1/24")})
+	require.Error(t, err, "unmasked networks should be rejected")
+
+	savedAccount, err := am.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	var routeID route.ID
+	for id, r := range savedAccount.Routes {
+		if r.Network == lan {
+			routeID = id
+		}
+	}
+	require.NotEmpty(t, routeID)
+
+	pendingRoute := savedAccount.Routes[routeID].Copy()
+	pendingRoute.Enabled = true
+	err = am.SaveRoute(account.Id, userID, pendingRoute)
+	require.Error(t, err, "pending routes shouldn't be enabled without approval")
+
+	approved, err := am.ApproveRoute(account.Id, routeID, userID)
+	require.NoError(t, err)
+	assert.False(t, approved.PendingApproval)
+	assert.True(t, approved.Enabled)
+
+	_, err = am.ApproveRoute(account.Id, routeID, userID)
+	require.Error(t, err, "approved routes can't be approved again")
+}
+
 func TestGetNetworkMap_RouteSyncPeerGroups(t *testing.T) {
 	baseRoute := &route.Route{
 		Network:     netip.MustParsePrefix("192.168.0.0/16"),
@@ -840,8 +965,8 @@ func TestGetNetworkMap_RouteSyncPeerGroups(t *testing.T) {
 	require.Len(t, newAccountRoutes.Routes, 0, "new accounts should have no routes")
 
 	newRoute, err := am.CreateRoute(
-		account.Id, baseRoute.Network.String(), baseRoute.Peer, baseRoute.PeerGroups, baseRoute.Description,
-		baseRoute.NetID, baseRoute.Masquerade, baseRoute.Metric, baseRoute.Groups, baseRoute.Enabled, userID)
+		account.Id, baseRoute.Network.String(), nil, false, baseRoute.Peer, baseRoute.PeerGroups, baseRoute.Description,
+		baseRoute.NetID, baseRoute.Masquerade, baseRoute.Metric, baseRoute.Weight, baseRoute.Groups, baseRoute.Enabled, userID)
 	require.NoError(t, err)
 	require.Equal(t, newRoute.Enabled, true)
 
@@ -932,8 +1057,8 @@ func TestGetNetworkMap_RouteSync(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, newAccountRoutes.Routes, 0, "new accounts should have no routes")
 
-	createdRoute, err := am.CreateRoute(account.Id, baseRoute.Network.String(), peer1ID, []string{},
-		baseRoute.Description, baseRoute.NetID, baseRoute.Masquerade, baseRoute.Metric, baseRoute.Groups, false,
+	createdRoute, err := am.CreateRoute(account.Id, baseRoute.Network.String(), nil, false, peer1ID, []string{},
+		baseRoute.Description, baseRoute.NetID, baseRoute.Masquerade, baseRoute.Metric, baseRoute.Weight, baseRoute.Groups, false,
 		userID)
 	require.NoError(t, err)
 
@@ -2,6 +2,7 @@ package server
 
 import (
 	"hash/fnv"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -26,6 +27,8 @@ const (
 	DefaultSetupKeyName = "Default key"
 	// SetupKeyUnlimitedUsage indicates an unlimited usage of a setup key
 	SetupKeyUnlimitedUsage = 0
+	// DefaultSetupKeyMaxAge is the age after which the valid setup keys are reported as stale
+	DefaultSetupKeyMaxAge = 90 * 24 * time.Hour
 )
 
 const (
@@ -90,6 +93,11 @@ type SetupKey struct {
 	UsageLimit int
 	// Ephemeral indicate if the peers will be ephemeral or not
 	Ephemeral bool
+	// ExpiryNotified indicates whether the users were notified that the key expires soon
+	ExpiryNotified bool
+	// PeerLimit is the maximum number of peers registered with this key at the same time, unlike UsageLimit the
+	// deleted peers don't count. The value of 0 indicates no limit.
+	PeerLimit int
 }
 
 // Copy copies SetupKey to a new object
@@ -100,20 +108,22 @@ func (key *SetupKey) Copy() *SetupKey {
 		key.UpdatedAt = key.CreatedAt
 	}
 	return &SetupKey{
-		Id:         key.Id,
-		AccountID:  key.AccountID,
-		Key:        key.Key,
-		Name:       key.Name,
-		Type:       key.Type,
-		CreatedAt:  key.CreatedAt,
-		ExpiresAt:  key.ExpiresAt,
-		UpdatedAt:  key.UpdatedAt,
-		Revoked:    key.Revoked,
-		UsedTimes:  key.UsedTimes,
-		LastUsed:   key.LastUsed,
-		AutoGroups: autoGroups,
-		UsageLimit: key.UsageLimit,
-		Ephemeral:  key.Ephemeral,
+		Id:             key.Id,
+		AccountID:      key.AccountID,
+		Key:            key.Key,
+		Name:           key.Name,
+		Type:           key.Type,
+		CreatedAt:      key.CreatedAt,
+		ExpiresAt:      key.ExpiresAt,
+		UpdatedAt:      key.UpdatedAt,
+		Revoked:        key.Revoked,
+		UsedTimes:      key.UsedTimes,
+		LastUsed:       key.LastUsed,
+		AutoGroups:     autoGroups,
+		UsageLimit:     key.UsageLimit,
+		Ephemeral:      key.Ephemeral,
+		ExpiryNotified: key.ExpiryNotified,
+		PeerLimit:      key.PeerLimit,
 	}
 }
 
@@ -278,11 +288,16 @@ func (am *DefaultAccountManager) SaveSetupKey(accountID string, keyToSave *Setup
 		return nil, status.Errorf(status.NotFound, "setup key not found")
 	}
 
-	// only auto groups, revoked status, and name can be updated for now
+	if keyToSave.PeerLimit < 0 {
+		return nil, status.Errorf(status.InvalidArgument, "setup key peer limit can't be negative")
+	}
+
+	// only auto groups, revoked status, name and peer limit can be updated for now
 	newKey := oldKey.Copy()
 	newKey.Name = keyToSave.Name
 	newKey.AutoGroups = keyToSave.AutoGroups
 	newKey.Revoked = keyToSave.Revoked
+	newKey.PeerLimit = keyToSave.PeerLimit
 	newKey.UpdatedAt = time.Now().UTC()
 
 	account.SetupKeys[newKey.Key] = newKey
@@ -295,6 +310,12 @@ func (am *DefaultAccountManager) SaveSetupKey(accountID string, keyToSave *Setup
 		am.StoreEvent(userID, newKey.Id, accountID, activity.SetupKeyRevoked, newKey.EventMeta())
 	}
 
+	if oldKey.PeerLimit != newKey.PeerLimit {
+		meta := newKey.EventMeta()
+		meta["limit"] = newKey.PeerLimit
+		am.StoreEvent(userID, newKey.Id, accountID, activity.SetupKeyPeerLimitUpdated, meta)
+	}
+
 	defer func() {
 		addedGroups := difference(newKey.AutoGroups, oldKey.AutoGroups)
 		removedGroups := difference(oldKey.AutoGroups, newKey.AutoGroups)
@@ -325,6 +346,104 @@ func (am *DefaultAccountManager) SaveSetupKey(accountID string, keyToSave *Setup
 	return newKey, nil
 }
 
+// ReplaceSetupKey revokes the setup key and issues a successor with its name, type, auto groups, usage and peer limits
+// and validity period, both in one save of the account. The usage of the successor starts over.
+func (am *DefaultAccountManager) ReplaceSetupKey(accountID, userID, keyID string) (*SetupKey, error) {
+	unlock := am.Store.AcquireAccountWriteLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasAdminPower() && !user.IsServiceUser {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can replace setup keys")
+	}
+
+	var oldKey *SetupKey
+	for _, key := range account.SetupKeys {
+		if key.Id == keyID {
+			oldKey = key.Copy()
+			break
+		}
+	}
+	if oldKey == nil {
+		return nil, status.Errorf(status.NotFound, "setup key not found")
+	}
+
+	// the groups deleted since the key was created aren't carried over
+	autoGroups := make([]string, 0, len(oldKey.AutoGroups))
+	for _, group := range oldKey.AutoGroups {
+		if _, ok := account.Groups[group]; ok {
+			autoGroups = append(autoGroups, group)
+		}
+	}
+
+	validFor := oldKey.ExpiresAt.Sub(oldKey.CreatedAt)
+	if validFor <= 0 {
+		validFor = DefaultSetupKeyDuration
+	}
+	newKey := GenerateSetupKey(oldKey.Name, oldKey.Type, validFor, autoGroups, oldKey.UsageLimit, oldKey.Ephemeral)
+	newKey.PeerLimit = oldKey.PeerLimit
+
+	revoked := !oldKey.Revoked
+	oldKey.Revoked = true
+	oldKey.UpdatedAt = time.Now().UTC()
+	account.SetupKeys[oldKey.Key] = oldKey
+	account.SetupKeys[newKey.Key] = newKey
+
+	if err = am.Store.SaveAccount(account); err != nil {
+		return nil, err
+	}
+
+	if revoked {
+		am.StoreEvent(userID, oldKey.Id, accountID, activity.SetupKeyRevoked, oldKey.EventMeta())
+	}
+	meta := newKey.EventMeta()
+	meta["replaced_key_id"] = oldKey.Id
+	am.StoreEvent(userID, newKey.Id, accountID, activity.SetupKeyReplaced, meta)
+
+	return newKey, nil
+}
+
+// ListStaleSetupKeys returns the valid setup keys of the account created more than maxAge ago, the oldest first
+func (am *DefaultAccountManager) ListStaleSetupKeys(accountID, userID string, maxAge time.Duration) ([]*SetupKey, error) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasAdminPower() && !user.IsServiceUser {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view setup keys")
+	}
+
+	keys := make([]*SetupKey, 0)
+	for _, key := range account.SetupKeys {
+		if key.IsValid() && time.Since(key.CreatedAt) > maxAge {
+			keys = append(keys, key.Copy())
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
 // ListSetupKeys returns a list of all setup keys of the account
 func (am *DefaultAccountManager) ListSetupKeys(accountID, userID string) ([]*SetupKey, error) {
 	unlock := am.Store.AcquireAccountWriteLock(accountID)
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/netbirdio/netbird/management/server/activity"
 	nbgroup "github.com/netbirdio/netbird/management/server/group"
@@ -198,6 +199,63 @@ func TestGetSetupKeys(t *testing.T) {
 	}
 }
 
+func TestDefaultAccountManager_ReplaceSetupKey(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "testingUser"
+	account, err := manager.GetOrCreateAccountByUser(userID, "")
+	require.NoError(t, err)
+
+	err = manager.SaveGroup(account.Id, userID, &nbgroup.Group{
+		ID:    "group_1",
+		Name:  "group_name_1",
+		Peers: []string{},
+	})
+	require.NoError(t, err)
+
+	key, err := manager.CreateSetupKey(account.Id, "my-test-key", SetupKeyReusable, 24*time.Hour, []string{"group_1"},
+		5, userID, true)
+	require.NoError(t, err)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	account.SetupKeys[key.Key].CreatedAt = time.Now().UTC().Add(-48 * time.Hour)
+	account.SetupKeys[key.Key].ExpiresAt = time.Now().UTC().Add(time.Hour)
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	stale, err := manager.ListStaleSetupKeys(account.Id, userID, 24*time.Hour)
+	require.NoError(t, err)
+	require.Len(t, stale, 1)
+	assert.Equal(t, key.Id, stale[0].Id)
+
+	newKey, err := manager.ReplaceSetupKey(account.Id, userID, key.Id)
+	require.NoError(t, err)
+	assert.NotEqual(t, key.Key, newKey.Key)
+	assert.Equal(t, key.Name, newKey.Name)
+	assert.Equal(t, key.AutoGroups, newKey.AutoGroups)
+	assert.Equal(t, key.UsageLimit, newKey.UsageLimit)
+	assert.True(t, newKey.Ephemeral)
+	assert.True(t, newKey.IsValid())
+	assert.WithinDuration(t, time.Now().Add(49*time.Hour), newKey.ExpiresAt, time.Minute,
+		"the successor should be valid as long as the replaced key was")
+
+	oldKey, err := manager.GetSetupKey(account.Id, userID, key.Id)
+	require.NoError(t, err)
+	assert.True(t, oldKey.Revoked)
+
+	stale, err = manager.ListStaleSetupKeys(account.Id, userID, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Empty(t, stale, "the revoked key should not be reported")
+
+	ev := getEvent(t, account.Id, manager, activity.SetupKeyReplaced)
+	assert.Equal(t, key.Id, ev.Meta["replaced_key_id"])
+	assert.Equal(t, newKey.Id, ev.TargetID)
+
+	_, err = manager.ReplaceSetupKey(account.Id, userID, "unknown")
+	assert.Error(t, err)
+}
+
 func TestGenerateDefaultSetupKey(t *testing.T) {
 	expectedName := "Default key"
 	expectedRevoke := false
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/realip"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// blockedLogInterval is how often the requests blocked from the same source are logged
+const blockedLogInterval = time.Minute
+
+// SourceFilter allows or denies the requests to an API by their source address and keeps an audit log of the blocked
+// ones
+type SourceFilter struct {
+	api    string
+	allow  []netip.Prefix
+	deny   []netip.Prefix
+	logger *log.Logger
+
+	mu sync.Mutex
+	// lastLogged holds when a blocked request was last logged per source, so a scan doesn't flood the logs
+	lastLogged map[netip.Addr]time.Time
+}
+
+// NewSourceFilter creates the source filter of the api, e.g. http or grpc
+func NewSourceFilter(api string, rules SourceFilterRules) *SourceFilter {
+	return &SourceFilter{
+		api:        api,
+		allow:      rules.Allow,
+		deny:       rules.Deny,
+		logger:     log.StandardLogger(),
+		lastLogged: make(map[netip.Addr]time.Time),
+	}
+}
+
+// Allowed returns whether the requests from the address are allowed
+func (f *SourceFilter) Allowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range f.deny {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	if len(f.allow) == 0 {
+		return true
+	}
+	for _, prefix := range f.allow {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns whether the request from the address is allowed and logs it when it is blocked. The requests received
+// on a unix socket are allowed, the other ones without a valid IP address are blocked.
+func (f *SourceFilter) Check(remoteAddr string, unixSocket bool, target string) bool {
+	if unixSocket {
+		return true
+	}
+
+	addr, err := netip.ParseAddrPort(remoteAddr)
+	if err != nil {
+		ip, err := netip.ParseAddr(remoteAddr)
+		if err != nil {
+			// the requests without a valid source are logged together
+			f.logBlocked(netip.Addr{}, remoteAddr, target, "without a valid source address")
+			return false
+		}
+		addr = netip.AddrPortFrom(ip, 0)
+	}
+
+	if f.Allowed(addr.Addr()) {
+		return true
+	}
+	source := addr.Addr().Unmap()
+	f.logBlocked(source, source.String(), target, "from a filtered source")
+	return false
+}
+
+func (f *SourceFilter) logBlocked(addr netip.Addr, source string, target string, reason string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	if last, ok := f.lastLogged[addr]; ok && now.Sub(last) < blockedLogInterval {
+		return
+	}
+	for logged, last := range f.lastLogged {
+		if now.Sub(last) >= blockedLogInterval {
+			delete(f.lastLogged, logged)
+		}
+	}
+	f.lastLogged[addr] = now
+
+	f.logger.WithFields(log.Fields{
+		"audit":  "source_filter",
+		"api":    f.api,
+		"source": source,
+		"target": target,
+	}).Warnf("blocked a request %s, the next ones from it are logged again in %s", reason, blockedLogInterval)
+}
+
+// UnaryServerInterceptor blocks the unary requests from the filtered sources, it has to run after the realip one
+func (f *SourceFilter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		source, unixSocket := grpcSource(ctx)
+		if !f.Check(source, unixSocket, info.FullMethod) {
+			return nil, status.Errorf(codes.PermissionDenied, "source address not allowed")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor blocks the streams from the filtered sources, it has to run after the realip one
+func (f *SourceFilter) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		source, unixSocket := grpcSource(ss.Context())
+		if !f.Check(source, unixSocket, info.FullMethod) {
+			return status.Errorf(codes.PermissionDenied, "source address not allowed")
+		}
+		return handler(srv, ss)
+	}
+}
+
+// grpcSource returns the real IP of the request or the address of the connection when there is none, and whether the
+// connection is a unix socket one
+func grpcSource(ctx context.Context) (string, bool) {
+	if ip, ok := realip.FromContext(ctx); ok && ip.IsValid() {
+		return ip.String(), false
+	}
+	if IsUnixSocketRequest(ctx) {
+		return "", true
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "", false
+	}
+	if _, isUnix := p.Addr.(*net.UnixAddr); isUnix {
+		return "", true
+	}
+	return p.Addr.String(), false
+}
+
+// IsUnixSocketRequest returns whether the context is the one of a request the HTTP server received on a unix socket,
+// the gRPC requests served by the HTTP server included
+func IsUnixSocketRequest(ctx context.Context) bool {
+	_, isUnix := ctx.Value(http.LocalAddrContextKey).(*net.UnixAddr)
+	return isUnix
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestSourceFilter_Allowed(t *testing.T) {
+	tt := []struct {
+		name     string
+		rules    SourceFilterRules
+		addr     string
+		expected bool
+	}{
+		{
+			name:     "allows every source without rules",
+			addr:     "203.0.113.1",
+			expected: true,
+		},
+		{
+			name:     "allows the sources in the allowed networks",
+			rules:    SourceFilterRules{Allow: []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24")}},
+			addr:     "192.168.1.10",
+			expected: true,
+		},
+		{
+			name:  "blocks the sources out of the allowed networks",
+			rules: SourceFilterRules{Allow: []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24")}},
+			addr:  "203.0.113.1",
+		},
+		{
+			name: "denies before allowing",
+			rules: SourceFilterRules{
+				Allow: []netip.Prefix{netip.MustParsePrefix("192.168.0.0/16")},
+				Deny:  []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24")},
+			},
+			addr: "192.168.1.10",
+		},
+		{
+			name:  "matches the IPv4 mapped addresses",
+			rules: SourceFilterRules{Deny: []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24")}},
+			addr:  "::ffff:203.0.113.1",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			filter := NewSourceFilter("http", tc.rules)
+			assert.Equal(t, tc.expected, filter.Allowed(netip.MustParseAddr(tc.addr)))
+		})
+	}
+}
+
+func TestSourceFilter_Check(t *testing.T) {
+	filter := NewSourceFilter("http", SourceFilterRules{Allow: []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24")}})
+	logger, hook := test.NewNullLogger()
+	filter.logger = logger
+
+	assert.True(t, filter.Check("192.168.1.10:4321", false, "GET /api/peers"))
+	assert.True(t, filter.Check("@", true, "GET /api/peers"), "the unix socket requests aren't filtered")
+
+	assert.False(t, filter.Check("203.0.113.1:4321", false, "GET /api/peers"))
+	assert.False(t, filter.Check("203.0.113.1", false, "GET /api/users"))
+	require.Len(t, hook.AllEntries(), 1, "the requests blocked from the same source are logged once per interval")
+	assert.Equal(t, "203.0.113.1", hook.LastEntry().Data["source"])
+	assert.Equal(t, "GET /api/peers", hook.LastEntry().Data["target"])
+	assert.Equal(t, "http", hook.LastEntry().Data["api"])
+
+	assert.False(t, filter.Check("203.0.113.2:4321", false, "GET /api/peers"))
+	assert.Len(t, hook.AllEntries(), 2)
+}
+
+func TestSourceFilter_CheckInvalidSource(t *testing.T) {
+	filter := NewSourceFilter("http", SourceFilterRules{Deny: []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24")}})
+	logger, hook := test.NewNullLogger()
+	filter.logger = logger
+
+	assert.False(t, filter.Check("@", false, "GET /api/peers"), "a unix socket address out of a unix socket request is blocked")
+	require.Len(t, hook.AllEntries(), 1)
+	assert.Equal(t, "@", hook.LastEntry().Data["source"])
+	assert.Contains(t, hook.LastEntry().Message, "without a valid source address")
+
+	assert.False(t, filter.Check("", false, "GET /api/peers"))
+	assert.False(t, filter.Check("not-an-address:4321", false, "GET /api/users"))
+	assert.Len(t, hook.AllEntries(), 1, "the requests without a valid source are logged once per interval")
+}
+
+func TestSourceFilter_Interceptors(t *testing.T) {
+	filter := NewSourceFilter("grpc", SourceFilterRules{Deny: []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24")}})
+	filter.logger, _ = test.NewNullLogger()
+
+	peerContext := func(addr net.Addr) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+	}
+	handler := func(context.Context, interface{}) (interface{}, error) { return "ok", nil }
+	info := &grpc.UnaryServerInfo{FullMethod: "/management.ManagementService/Sync"}
+	interceptor := filter.UnaryServerInterceptor()
+
+	_, err := interceptor(peerContext(&net.TCPAddr{IP: net.ParseIP("203.0.113.1"), Port: 4321}), nil, info, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	resp, err := interceptor(peerContext(&net.TCPAddr{IP: net.ParseIP("198.51.100.1"), Port: 4321}), nil, info, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+
+	_, err = interceptor(peerContext(&net.UnixAddr{Name: "@", Net: "unix"}), nil, info, handler)
+	assert.NoError(t, err, "the unix socket requests aren't filtered")
+
+	// the gRPC requests served by the HTTP server carry the remote address as a string and the address of the listener
+	httpContext := context.WithValue(peerContext(stringAddr("@")), http.LocalAddrContextKey, &net.UnixAddr{Name: "/var/run/mgmt.sock", Net: "unix"})
+	_, err = interceptor(httpContext, nil, info, handler)
+	assert.NoError(t, err, "the unix socket requests of the HTTP server aren't filtered")
+
+	_, err = interceptor(peerContext(stringAddr("@")), nil, info, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "an unparseable address out of a unix socket is blocked")
+
+	_, err = interceptor(context.Background(), nil, info, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "a request without a peer is blocked")
+
+	streamInterceptor := filter.StreamServerInterceptor()
+	streamHandler := func(interface{}, grpc.ServerStream) error { return nil }
+	streamInfo := &grpc.StreamServerInfo{FullMethod: "/management.ManagementService/Sync"}
+	err = streamInterceptor(nil, &testServerStream{ctx: context.Background()}, streamInfo, streamHandler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "a stream without a peer is blocked")
+}
+
+// stringAddr is a remote address like the ones of the gRPC requests served by the HTTP server
+type stringAddr string
+
+func (a stringAddr) Network() string { return "tcp" }
+func (a stringAddr) String() string  { return string(a) }
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context { return s.ctx }
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// getPeerSpeedtestPeers returns the WireGuard keys of the peers an enabled policy enabling the speed tests allows to run
+// them against the peer: the sources of the rules the peer is a destination of and the destinations of the
+// bidirectional rules it is a source of
+func (a *Account) getPeerSpeedtestPeers(peer *nbpeer.Peer, validatedPeersMap map[string]struct{}) map[string]struct{} {
+	allowed := make(map[string]struct{})
+	for _, policy := range a.Policies {
+		if !policy.Enabled || !policy.SpeedtestEnabled {
+			continue
+		}
+
+		for _, rule := range policy.Rules {
+			if !rule.Enabled {
+				continue
+			}
+
+			sourcePeers, peerInSources := getAllPeersFromGroups(a, rule.Sources, peer.ID, policy.SourcePostureChecks, validatedPeersMap)
+			destinationPeers, peerInDestinations := getAllPeersFromGroups(a, rule.Destinations, peer.ID, nil, validatedPeersMap)
+
+			if peerInDestinations {
+				for _, p := range sourcePeers {
+					allowed[p.Key] = struct{}{}
+				}
+			}
+			if peerInSources && rule.Bidirectional {
+				for _, p := range destinationPeers {
+					allowed[p.Key] = struct{}{}
+				}
+			}
+		}
+	}
+	return allowed
+}
+
+// StorePeerSpeedtest stores a throughput test the peer identified by its WireGuard public key ran against another
+// peer of the account as an activity event initiated by the peer
+func (am *DefaultAccountManager) StorePeerSpeedtest(peerPubKey string, result nbpeer.SpeedtestResult) error {
+	if result.Duration <= 0 || result.Bytes < 0 || result.PacketsLost < 0 || result.PacketsLost > result.PacketsSent {
+		return status.Errorf(status.InvalidArgument, "invalid speed test result")
+	}
+
+	accountID, err := am.Store.GetAccountIDByPeerPubKey(peerPubKey)
+	if err != nil {
+		return err
+	}
+
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	peer, err := account.FindPeerByPubKey(peerPubKey)
+	if err != nil {
+		return status.Errorf(status.NotFound, "peer with key %s not found", peerPubKey)
+	}
+	target, err := account.FindPeerByPubKey(result.PeerKey)
+	if err != nil {
+		return status.Errorf(status.NotFound, "peer with key %s not found", result.PeerKey)
+	}
+
+	meta := target.EventMeta(am.GetDNSDomain())
+	meta["source_name"] = peer.Name
+	meta["source_ip"] = peer.IP
+	meta["protocol"] = result.Protocol
+	meta["direction"] = result.Direction
+	meta["bytes"] = result.Bytes
+	meta["duration"] = result.Duration.Round(time.Millisecond).String()
+	meta["mbps"] = fmt.Sprintf("%.1f", result.MegabitsPerSecond())
+	if result.PacketsSent > 0 {
+		meta["packets_sent"] = result.PacketsSent
+		meta["packets_lost"] = result.PacketsLost
+		meta["loss_percent"] = fmt.Sprintf("%.1f", float64(result.PacketsLost)/float64(result.PacketsSent)*100)
+	}
+
+	am.StoreEvent(peer.ID, target.ID, accountID, activity.PeerSpeedtestRun, meta)
+
+	return nil
+}
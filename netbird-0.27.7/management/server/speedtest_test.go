@@ -0,0 +1,114 @@
+package server
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbgroup "github.com/netbirdio/netbird/management/server/group"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+func TestAccount_getPeerSpeedtestPeers(t *testing.T) {
+	account := &Account{
+		Peers: map[string]*nbpeer.Peer{
+			"peerA": {ID: "peerA", Key: "peerAKey", IP: net.ParseIP("100.65.14.88"), Status: &nbpeer.PeerStatus{}},
+			"peerB": {ID: "peerB", Key: "peerBKey", IP: net.ParseIP("100.65.80.39"), Status: &nbpeer.PeerStatus{}},
+			"peerC": {ID: "peerC", Key: "peerCKey", IP: net.ParseIP("100.65.254.139"), Status: &nbpeer.PeerStatus{}},
+		},
+		Groups: map[string]*nbgroup.Group{
+			"GroupAdmins":  {ID: "GroupAdmins", Peers: []string{"peerA"}},
+			"GroupRouters": {ID: "GroupRouters", Peers: []string{"peerB"}},
+			"GroupOther":   {ID: "GroupOther", Peers: []string{"peerC"}},
+		},
+		Policies: []*Policy{
+			{
+				ID:               "RuleSpeedtest",
+				Enabled:          true,
+				SpeedtestEnabled: true,
+				Rules: []*PolicyRule{
+					{ID: "RuleSpeedtest", Enabled: true, Sources: []string{"GroupAdmins"}, Destinations: []string{"GroupRouters"}, Action: PolicyTrafficActionAccept},
+				},
+			},
+			{
+				ID:      "RuleOther",
+				Enabled: true,
+				Rules: []*PolicyRule{
+					{ID: "RuleOther", Enabled: true, Sources: []string{"GroupOther"}, Destinations: []string{"GroupRouters"}, Bidirectional: true, Action: PolicyTrafficActionAccept},
+				},
+			},
+		},
+	}
+	validatedPeers := map[string]struct{}{"peerA": {}, "peerB": {}, "peerC": {}}
+
+	assert.Equal(t, map[string]struct{}{"peerAKey": {}}, account.getPeerSpeedtestPeers(account.Peers["peerB"], validatedPeers),
+		"only the sources of the policy enabling the speed tests should be allowed")
+	assert.Empty(t, account.getPeerSpeedtestPeers(account.Peers["peerA"], validatedPeers), "a one-way rule shouldn't allow the destinations")
+
+	account.Policies[0].Rules[0].Bidirectional = true
+	assert.Equal(t, map[string]struct{}{"peerBKey": {}}, account.getPeerSpeedtestPeers(account.Peers["peerA"], validatedPeers))
+
+	account.Policies[0].Enabled = false
+	assert.Empty(t, account.getPeerSpeedtestPeers(account.Peers["peerB"], validatedPeers))
+	account.Policies[0].Enabled = true
+
+	allowed := account.getPeerSpeedtestPeers(account.Peers["peerB"], validatedPeers)
+	remotePeers := toRemotePeerConfig([]*nbpeer.Peer{account.Peers["peerA"], account.Peers["peerC"]}, "", nil, nil, nil, nil, allowed)
+	require.Len(t, remotePeers, 2)
+	assert.True(t, remotePeers[0].GetSpeedtestAllowed())
+	assert.False(t, remotePeers[1].GetSpeedtestAllowed())
+}
+
+func TestDefaultAccountManager_StorePeerSpeedtest(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account := newAccountWithId("test_account", userID, "")
+	err = manager.Store.SaveAccount(account)
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	addPeer := func(hostname string) *nbpeer.Peer {
+		key, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+		peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+			Key:  key.PublicKey().String(),
+			Meta: nbpeer.PeerSystemMeta{Hostname: hostname},
+		})
+		require.NoError(t, err)
+		return peer
+	}
+	router, laptop := addPeer("test-router"), addPeer("test-laptop")
+
+	err = manager.StorePeerSpeedtest(laptop.Key, nbpeer.SpeedtestResult{PeerKey: router.Key, Protocol: "udp", Duration: time.Second, PacketsSent: 1, PacketsLost: 2})
+	require.Error(t, err, "more datagrams lost than sent should be rejected")
+
+	err = manager.StorePeerSpeedtest(laptop.Key, nbpeer.SpeedtestResult{PeerKey: "unknown", Protocol: "tcp", Duration: time.Second})
+	require.Error(t, err, "a peer missing from the account should be rejected")
+
+	err = manager.StorePeerSpeedtest(laptop.Key, nbpeer.SpeedtestResult{
+		PeerKey:     router.Key,
+		Protocol:    "udp",
+		Direction:   "download",
+		Bytes:       12_500_000,
+		Duration:    10 * time.Second,
+		PacketsSent: 10_000,
+		PacketsLost: 25,
+	})
+	require.NoError(t, err)
+
+	ev := getEvent(t, account.Id, manager, activity.PeerSpeedtestRun)
+	assert.Equal(t, laptop.ID, ev.InitiatorID, "the peer running the test should initiate the event")
+	assert.Equal(t, router.ID, ev.TargetID)
+	assert.Equal(t, "10.0", ev.Meta["mbps"])
+	assert.Equal(t, "0.2", ev.Meta["loss_percent"])
+	assert.Equal(t, "download", ev.Meta["direction"])
+	assert.Equal(t, laptop.Name, ev.Meta["source_name"])
+}
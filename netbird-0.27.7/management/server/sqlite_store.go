@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"net/netip"
+	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -43,6 +44,13 @@ type installation struct {
 	InstallationIDValue string
 }
 
+// lease is a named lock held by one instance of the management service sharing the store until it expires
+type lease struct {
+	Name      string `gorm:"primaryKey"`
+	Holder    string
+	ExpiresAt time.Time
+}
+
 type migrationFunc func(*gorm.DB) error
 
 // NewSqliteStore restores a store from the file located in the datadir
@@ -76,7 +84,9 @@ func NewSqliteStore(dataDir string, metrics telemetry.AppMetrics) (*SqliteStore,
 	err = db.AutoMigrate(
 		&SetupKey{}, &nbpeer.Peer{}, &User{}, &PersonalAccessToken{}, &nbgroup.Group{},
 		&Account{}, &Policy{}, &PolicyRule{}, &route.Route{}, &nbdns.NameServerGroup{},
+		&nbdns.CustomRecord{}, &RelayServer{}, &ClaimGroupMapping{}, &RouteGroup{}, &ExternalList{},
 		&installation{}, &account.ExtraSettings{}, &posture.Checks{}, &nbpeer.NetworkAddress{},
+		&lease{},
 	)
 	if err != nil {
 		return nil, fmt.Errorf("auto migrate: %w", err)
@@ -85,6 +95,44 @@ func NewSqliteStore(dataDir string, metrics telemetry.AppMetrics) (*SqliteStore,
 	return &SqliteStore{db: db, storeFile: file, metrics: metrics, installationPK: 1}, nil
 }
 
+// checkSqliteStore opens the database of the datadir read-only, checks its integrity and counts its accounts, 0 when
+// the database doesn't exist or wasn't migrated yet
+func checkSqliteStore(dataDir string) (int, error) {
+	file := filepath.Join(dataDir, "store.db")
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	db, err := gorm.Open(sqlite.Open("file:"+file+"?mode=ro"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return 0, err
+	}
+	sql, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+	defer sql.Close()
+
+	var result string
+	if err := db.Raw("PRAGMA quick_check").Scan(&result).Error; err != nil {
+		return 0, fmt.Errorf("check integrity: %w", err)
+	}
+	if result != "ok" {
+		return 0, fmt.Errorf("the database is corrupted: %s", result)
+	}
+
+	if !db.Migrator().HasTable(&Account{}) {
+		return 0, nil
+	}
+	var accounts int64
+	if err := db.Model(&Account{}).Count(&accounts).Error; err != nil {
+		return 0, fmt.Errorf("count accounts: %w", err)
+	}
+	return int(accounts), nil
+}
+
 // NewSqliteStoreFromFileStore restores a store from FileStore and stores SQLite DB in the file located in datadir
 func NewSqliteStoreFromFileStore(filestore *FileStore, dataDir string, metrics telemetry.AppMetrics) (*SqliteStore, error) {
 	store, err := NewSqliteStore(dataDir, metrics)
@@ -159,6 +207,37 @@ func (s *SqliteStore) AcquireAccountReadLock(accountID string) (unlock func()) {
 	return unlock
 }
 
+// AcquireLease renews the lease when the holder has it or takes it over when it expired, and creates it when it doesn't
+// exist. Each of the statements is atomic, so only one of the holders racing for the lease gets it
+func (s *SqliteStore) AcquireLease(name, holder string, ttl time.Duration) (bool, error) {
+	now := time.Now().UTC()
+	result := s.db.Model(&lease{}).
+		Where("name = ? AND (holder = ? OR expires_at < ?)", name, holder, now).
+		Updates(map[string]any{"holder": holder, "expires_at": now.Add(ttl)})
+	if result.Error != nil {
+		return false, status.Errorf(status.Internal, "failed to acquire lease %s: %v", name, result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	result = s.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&lease{Name: name, Holder: holder, ExpiresAt: now.Add(ttl)})
+	if result.Error != nil {
+		return false, status.Errorf(status.Internal, "failed to acquire lease %s: %v", name, result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ReleaseLease deletes the lease when the holder has it
+func (s *SqliteStore) ReleaseLease(name, holder string) error {
+	result := s.db.Where("name = ? AND holder = ?", name, holder).Delete(&lease{})
+	if result.Error != nil {
+		return status.Errorf(status.Internal, "failed to release lease %s: %v", name, result.Error)
+	}
+	return nil
+}
+
 func (s *SqliteStore) SaveAccount(account *Account) error {
 	start := time.Now()
 
@@ -195,6 +274,31 @@ func (s *SqliteStore) SaveAccount(account *Account) error {
 		account.NameServerGroupsG = append(account.NameServerGroupsG, *ns)
 	}
 
+	for id, record := range account.CustomDNSRecords {
+		record.ID = id
+		account.CustomDNSRecordsG = append(account.CustomDNSRecordsG, *record)
+	}
+
+	for id, relay := range account.RelayServers {
+		relay.ID = id
+		account.RelayServersG = append(account.RelayServersG, *relay)
+	}
+
+	for id, mapping := range account.ClaimGroupMappings {
+		mapping.ID = id
+		account.ClaimGroupMappingsG = append(account.ClaimGroupMappingsG, *mapping)
+	}
+
+	for id, group := range account.RouteGroups {
+		group.ID = id
+		account.RouteGroupsG = append(account.RouteGroupsG, *group)
+	}
+
+	for id, list := range account.ExternalLists {
+		list.ID = id
+		account.ExternalListsG = append(account.ExternalListsG, *list)
+	}
+
 	err := s.db.Transaction(func(tx *gorm.DB) error {
 		result := tx.Select(clause.Associations).Delete(account.Policies, "account_id = ?", account.Id)
 		if result.Error != nil {
@@ -318,6 +422,29 @@ func (s *SqliteStore) SavePeerLocation(accountID string, peerWithLocation *nbpee
 	return nil
 }
 
+func (s *SqliteStore) SavePeerGeofence(accountID string, peerWithGeofence *nbpeer.Peer) error {
+	var peerCopy nbpeer.Peer
+	peerCopy.GeofenceViolation = peerWithGeofence.GeofenceViolation
+	peerCopy.GeofenceViolatedAt = peerWithGeofence.GeofenceViolatedAt
+	peerCopy.GeofenceOverride = peerWithGeofence.GeofenceOverride
+
+	// the fields are selected so that a cleared violation or override is stored as well
+	result := s.db.Model(&nbpeer.Peer{}).
+		Where("account_id = ? and id = ?", accountID, peerWithGeofence.ID).
+		Select("GeofenceViolation", "GeofenceViolatedAt", "GeofenceOverride").
+		Updates(peerCopy)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return status.Errorf(status.NotFound, "peer %s not found", peerWithGeofence.ID)
+	}
+
+	return nil
+}
+
 // DeleteHashedPAT2TokenIDIndex is noop in Sqlite
 func (s *SqliteStore) DeleteHashedPAT2TokenIDIndex(hashedToken string) error {
 	return nil
@@ -487,6 +614,36 @@ func (s *SqliteStore) GetAccount(accountID string) (*Account, error) {
 	}
 	account.NameServerGroupsG = nil
 
+	account.CustomDNSRecords = make(map[string]*nbdns.CustomRecord, len(account.CustomDNSRecordsG))
+	for _, record := range account.CustomDNSRecordsG {
+		account.CustomDNSRecords[record.ID] = record.Copy()
+	}
+	account.CustomDNSRecordsG = nil
+
+	account.RelayServers = make(map[string]*RelayServer, len(account.RelayServersG))
+	for _, relay := range account.RelayServersG {
+		account.RelayServers[relay.ID] = relay.Copy()
+	}
+	account.RelayServersG = nil
+
+	account.ClaimGroupMappings = make(map[string]*ClaimGroupMapping, len(account.ClaimGroupMappingsG))
+	for _, mapping := range account.ClaimGroupMappingsG {
+		account.ClaimGroupMappings[mapping.ID] = mapping.Copy()
+	}
+	account.ClaimGroupMappingsG = nil
+
+	account.RouteGroups = make(map[string]*RouteGroup, len(account.RouteGroupsG))
+	for _, group := range account.RouteGroupsG {
+		account.RouteGroups[group.ID] = group.Copy()
+	}
+	account.RouteGroupsG = nil
+
+	account.ExternalLists = make(map[string]*ExternalList, len(account.ExternalListsG))
+	for _, list := range account.ExternalListsG {
+		account.ExternalLists[list.ID] = list.Copy()
+	}
+	account.ExternalListsG = nil
+
 	return &account, nil
 }
 
@@ -545,10 +702,12 @@ func (s *SqliteStore) GetAccountByPeerPubKey(peerKey string) (*Account, error) {
 	return s.GetAccount(peer.AccountID)
 }
 
+// GetAccountIDByPeerPubKey returns the ID of the account of the peer with the WireGuard public key, or with the
+// previous key of a key rotation
 func (s *SqliteStore) GetAccountIDByPeerPubKey(peerKey string) (string, error) {
 	var peer nbpeer.Peer
 	var accountID string
-	result := s.db.Model(&peer).Select("account_id").Where("key = ?", peerKey).First(&accountID)
+	result := s.db.Model(&peer).Select("account_id").Where("key = ? OR previous_key = ?", peerKey, peerKey).First(&accountID)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return "", status.Errorf(status.NotFound, "account not found: index lookup failed")
@@ -560,6 +719,127 @@ func (s *SqliteStore) GetAccountIDByPeerPubKey(peerKey string) (string, error) {
 	return accountID, nil
 }
 
+// GetAccountIDByUserID returns the ID of the account of the user
+func (s *SqliteStore) GetAccountIDByUserID(userID string) (string, error) {
+	var user User
+	var accountID string
+	result := s.db.Model(&user).Select("account_id").Where("id = ?", userID).First(&accountID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return "", status.Errorf(status.NotFound, "account not found: index lookup failed")
+		}
+		log.Errorf("error when getting user from the store: %s", result.Error)
+		return "", status.Errorf(status.Internal, "issue getting account from store")
+	}
+
+	if accountID == "" {
+		return "", status.Errorf(status.NotFound, "account not found: index lookup failed")
+	}
+
+	return accountID, nil
+}
+
+// GetUserByUserID returns the user without loading its personal access tokens
+func (s *SqliteStore) GetUserByUserID(userID string) (*User, error) {
+	var user User
+	result := s.db.First(&user, "id = ?", userID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(status.NotFound, "user %s not found", userID)
+		}
+		log.Errorf("error when getting user from the store: %s", result.Error)
+		return nil, status.Errorf(status.Internal, "issue getting user from store")
+	}
+
+	return &user, nil
+}
+
+// GetAccountSettings returns the settings of the account without loading its associations
+func (s *SqliteStore) GetAccountSettings(accountID string) (*Settings, error) {
+	var account Account
+	result := s.db.First(&account, "id = ?", accountID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(status.NotFound, "account not found")
+		}
+		log.Errorf("error when getting account from the store: %s", result.Error)
+		return nil, status.Errorf(status.Internal, "issue getting account from store")
+	}
+
+	if account.Settings == nil {
+		return &Settings{}, nil
+	}
+
+	return account.Settings, nil
+}
+
+// GetAccountGroups returns the groups of the account
+func (s *SqliteStore) GetAccountGroups(accountID string) ([]*nbgroup.Group, error) {
+	var groups []*nbgroup.Group
+	result := s.db.Find(&groups, "account_id = ?", accountID)
+	if result.Error != nil {
+		log.Errorf("error when getting groups from the store: %s", result.Error)
+		return nil, status.Errorf(status.Internal, "issue getting groups from store")
+	}
+
+	return groups, nil
+}
+
+// GetAccountGroup returns the group of the account
+func (s *SqliteStore) GetAccountGroup(accountID, groupID string) (*nbgroup.Group, error) {
+	var group nbgroup.Group
+	result := s.db.First(&group, "account_id = ? and id = ?", accountID, groupID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(status.NotFound, "group %s not found", groupID)
+		}
+		log.Errorf("error when getting group from the store: %s", result.Error)
+		return nil, status.Errorf(status.Internal, "issue getting group from store")
+	}
+
+	return &group, nil
+}
+
+// GetAccountPolicies returns the policies of the account along with their rules
+func (s *SqliteStore) GetAccountPolicies(accountID string) ([]*Policy, error) {
+	var policies []*Policy
+	result := s.db.Preload(clause.Associations).Find(&policies, "account_id = ?", accountID)
+	if result.Error != nil {
+		log.Errorf("error when getting policies from the store: %s", result.Error)
+		return nil, status.Errorf(status.Internal, "issue getting policies from store")
+	}
+
+	return policies, nil
+}
+
+// GetAccountPeers returns the peers of the account
+func (s *SqliteStore) GetAccountPeers(accountID string) ([]*nbpeer.Peer, error) {
+	var peers []*nbpeer.Peer
+	result := s.db.Find(&peers, "account_id = ?", accountID)
+	if result.Error != nil {
+		log.Errorf("error when getting peers from the store: %s", result.Error)
+		return nil, status.Errorf(status.Internal, "issue getting peers from store")
+	}
+
+	return peers, nil
+}
+
+// GetAccountPeersByIDs returns the peers of the account with the IDs
+func (s *SqliteStore) GetAccountPeersByIDs(accountID string, peerIDs []string) ([]*nbpeer.Peer, error) {
+	var peers []*nbpeer.Peer
+	if len(peerIDs) == 0 {
+		return peers, nil
+	}
+
+	result := s.db.Find(&peers, "account_id = ? and id IN ?", accountID, peerIDs)
+	if result.Error != nil {
+		log.Errorf("error when getting peers from the store: %s", result.Error)
+		return nil, status.Errorf(status.Internal, "issue getting peers from store")
+	}
+
+	return peers, nil
+}
+
 // SaveUserLastLogin stores the last login time for a user in DB.
 func (s *SqliteStore) SaveUserLastLogin(accountID, userID string, lastLogin time.Time) error {
 	var user User
@@ -592,6 +872,38 @@ func (s *SqliteStore) GetStoreEngine() StoreEngine {
 	return SqliteStoreEngine
 }
 
+// GetStoreStats counts the rows of the accounts, peers and users and returns the state of the connection pool
+func (s *SqliteStore) GetStoreStats() (*StoreStats, error) {
+	stats := &StoreStats{
+		Engine:       SqliteStoreEngine,
+		AccountLocks: countLocks(&s.accountLocks),
+	}
+
+	for _, count := range []struct {
+		model any
+		value *int
+	}{
+		{&Account{}, &stats.Accounts},
+		{&nbpeer.Peer{}, &stats.Peers},
+		{&User{}, &stats.Users},
+	} {
+		var rows int64
+		if err := s.db.Model(count.model).Count(&rows).Error; err != nil {
+			return nil, status.Errorf(status.Internal, "issue counting the store rows: %s", err)
+		}
+		*count.value = int(rows)
+	}
+
+	db, err := s.db.DB()
+	if err != nil {
+		return nil, status.Errorf(status.Internal, "issue getting the store connections: %s", err)
+	}
+	connections := db.Stats()
+	stats.Connections = &connections
+
+	return stats, nil
+}
+
 // migrate migrates the SQLite database to the latest schema
 func migrate(db *gorm.DB) error {
 	migrations := getMigrations()
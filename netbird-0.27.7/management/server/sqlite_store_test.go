@@ -208,12 +208,13 @@ func TestSqlite_SaveAccount(t *testing.T) {
 	setupKey = GenerateDefaultSetupKey()
 	account2.SetupKeys[setupKey.Key] = setupKey
 	account2.Peers["testpeer2"] = &nbpeer.Peer{
-		Key:      "peerkey2",
-		SetupKey: "peerkeysetupkey2",
-		IP:       net.IP{127, 0, 0, 2},
-		Meta:     nbpeer.PeerSystemMeta{},
-		Name:     "peer name 2",
-		Status:   &nbpeer.PeerStatus{Connected: true, LastSeen: time.Now().UTC()},
+		Key:         "peerkey2",
+		SetupKey:    "peerkeysetupkey2",
+		IP:          net.IP{127, 0, 0, 2},
+		Meta:        nbpeer.PeerSystemMeta{},
+		Name:        "peer name 2",
+		Status:      &nbpeer.PeerStatus{Connected: true, LastSeen: time.Now().UTC()},
+		PreviousKey: "previouspeerkey2",
 	}
 
 	err = store.SaveAccount(account2)
@@ -241,6 +242,10 @@ func TestSqlite_SaveAccount(t *testing.T) {
 		t.Errorf("expecting PeerKeyID2AccountID index updated after SaveAccount(): %v", err)
 	}
 
+	if accountID, err := store.GetAccountIDByPeerPubKey("previouspeerkey2"); accountID != account2.Id {
+		t.Errorf("expecting the previous peer key to resolve after SaveAccount(): %v", err)
+	}
+
 	if a, err := store.GetAccountByUser("testuser"); a == nil {
 		t.Errorf("expecting UserID2AccountID index updated after SaveAccount(): %v", err)
 	}
@@ -440,6 +445,54 @@ func TestSqlite_SavePeerLocation(t *testing.T) {
 	require.Equal(t, status.NotFound, parsedErr.Type(), "should return not found error")
 }
 
+func TestSqlite_SavePeerGeofence(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The SQLite store is not properly supported by Windows yet")
+	}
+
+	store := newSqliteStoreFromFile(t, "testdata/store.json")
+
+	account, err := store.GetAccount("bf1c8084-ba50-4ce7-9439-34653001fc3b")
+	require.NoError(t, err)
+
+	peer := &nbpeer.Peer{
+		AccountID: account.Id,
+		ID:        "testpeer",
+		Name:      "testpeer",
+		Meta:      nbpeer.PeerSystemMeta{},
+	}
+	account.Peers[peer.ID] = peer
+	require.NoError(t, store.SaveAccount(account))
+
+	peer.GeofenceViolation = "FR"
+	peer.GeofenceViolatedAt = time.Now().UTC().Truncate(time.Second)
+	peer.GeofenceOverride = &nbpeer.GeofenceOverride{Country: "FR", Reason: "travel", RequestedBy: "user"}
+	require.NoError(t, store.SavePeerGeofence(account.Id, peer))
+
+	account, err = store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "FR", account.Peers[peer.ID].GeofenceViolation)
+	assert.True(t, peer.GeofenceViolatedAt.Equal(account.Peers[peer.ID].GeofenceViolatedAt))
+	assert.Equal(t, "travel", account.Peers[peer.ID].GeofenceOverride.Reason)
+	assert.Equal(t, "testpeer", account.Peers[peer.ID].Name, "the other fields of the peer should be kept")
+
+	peer.GeofenceViolation = ""
+	peer.GeofenceViolatedAt = time.Time{}
+	peer.GeofenceOverride = nil
+	require.NoError(t, store.SavePeerGeofence(account.Id, peer))
+
+	account, err = store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Empty(t, account.Peers[peer.ID].GeofenceViolation, "a cleared violation should be stored")
+	assert.Nil(t, account.Peers[peer.ID].GeofenceOverride)
+
+	peer.ID = "non-existing-peer"
+	err = store.SavePeerGeofence(account.Id, peer)
+	parsedErr, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, status.NotFound, parsedErr.Type(), "should return not found error")
+}
+
 func TestSqlite_TestGetAccountByPrivateDomain(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("The SQLite store is not properly supported by Windows yet")
@@ -501,6 +554,58 @@ func TestSqlite_GetUserByTokenID(t *testing.T) {
 	require.Equal(t, status.NotFound, parsedErr.Type(), "should return not found error")
 }
 
+func TestSqlite_GetAccountPartially(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The SQLite store is not properly supported by Windows yet")
+	}
+
+	store := newSqliteStoreFromFile(t, "testdata/store.json")
+
+	accountID := "bf1c8084-ba50-4ce7-9439-34653001fc3b"
+	userID := "f4f6d672-63fb-11ec-90d6-0242ac120003"
+
+	id, err := store.GetAccountIDByUserID(userID)
+	require.NoError(t, err)
+	require.Equal(t, accountID, id)
+
+	user, err := store.GetUserByUserID(userID)
+	require.NoError(t, err)
+	require.Equal(t, accountID, user.AccountID)
+	require.Empty(t, user.PATs, "the personal access tokens shouldn't be loaded")
+
+	settings, err := store.GetAccountSettings(accountID)
+	require.NoError(t, err)
+	require.Equal(t, 24*time.Hour, settings.PeerLoginExpiration)
+
+	groups, err := store.GetAccountGroups(accountID)
+	require.NoError(t, err)
+	require.Empty(t, groups)
+
+	policies, err := store.GetAccountPolicies(accountID)
+	require.NoError(t, err)
+	require.Empty(t, policies)
+
+	peers, err := store.GetAccountPeers(accountID)
+	require.NoError(t, err)
+	require.Empty(t, peers)
+
+	peers, err = store.GetAccountPeersByIDs(accountID, []string{"non-existing-peer"})
+	require.NoError(t, err)
+	require.Empty(t, peers)
+
+	for _, err = range []error{
+		func() error { _, err := store.GetAccountIDByUserID("non-existing-user"); return err }(),
+		func() error { _, err := store.GetUserByUserID("non-existing-user"); return err }(),
+		func() error { _, err := store.GetAccountSettings("non-existing-account"); return err }(),
+		func() error { _, err := store.GetAccountGroup(accountID, "non-existing-group"); return err }(),
+	} {
+		require.Error(t, err)
+		parsedErr, ok := status.FromError(err)
+		require.True(t, ok)
+		require.Equal(t, status.NotFound, parsedErr.Type(), "should return not found error")
+	}
+}
+
 func TestMigrate(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("The SQLite store is not properly supported by Windows yet")
@@ -613,3 +718,27 @@ func newAccount(store Store, id int) error {
 
 	return store.SaveAccount(account)
 }
+
+func TestSqlite_GetStoreStats(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The SQLite store is not properly supported by Windows yet")
+	}
+
+	store := newSqliteStoreFromFile(t, "testdata/store.json")
+
+	var peers, users int
+	accounts := store.GetAllAccounts()
+	for _, account := range accounts {
+		peers += len(account.Peers)
+		users += len(account.Users)
+	}
+
+	stats, err := store.GetStoreStats()
+	require.NoError(t, err)
+	assert.Equal(t, SqliteStoreEngine, stats.Engine)
+	assert.Equal(t, len(accounts), stats.Accounts)
+	assert.Equal(t, peers, stats.Peers)
+	assert.Equal(t, users, stats.Users)
+	require.NotNil(t, stats.Connections)
+	assert.Positive(t, stats.Connections.MaxOpenConnections)
+}
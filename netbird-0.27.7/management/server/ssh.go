@@ -0,0 +1,93 @@
+package server
+
+import (
+	"time"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// getPeerSSHAccess returns whether an enabled policy enabling SSH enables the SSH server of the peer, and the WireGuard
+// keys of the peers these policies allow to log in to it: the sources of the rules the peer is a destination of and
+// the destinations of the bidirectional rules it is a source of. The keys are nil when the peer may accept all the
+// peers it is connected to, either because SSH is enabled on the peer itself or because no policy enables it
+func (a *Account) getPeerSSHAccess(peer *nbpeer.Peer, validatedPeersMap map[string]struct{}) (bool, map[string]struct{}) {
+	enabled := false
+	authorized := make(map[string]struct{})
+	for _, policy := range a.Policies {
+		if !policy.Enabled || !policy.SSHEnabled {
+			continue
+		}
+
+		for _, rule := range policy.Rules {
+			if !rule.Enabled {
+				continue
+			}
+
+			sourcePeers, peerInSources := getAllPeersFromGroups(a, rule.Sources, peer.ID, policy.SourcePostureChecks, validatedPeersMap)
+			destinationPeers, peerInDestinations := getAllPeersFromGroups(a, rule.Destinations, peer.ID, nil, validatedPeersMap)
+
+			if peerInDestinations {
+				enabled = true
+				for _, p := range sourcePeers {
+					authorized[p.Key] = struct{}{}
+				}
+			}
+			if peerInSources && rule.Bidirectional {
+				enabled = true
+				for _, p := range destinationPeers {
+					authorized[p.Key] = struct{}{}
+				}
+			}
+		}
+	}
+
+	if !enabled || peer.SSHEnabled {
+		return enabled, nil
+	}
+	return enabled, authorized
+}
+
+// StorePeerSSHSession stores an SSH session to the embedded SSH server of the peer identified by its WireGuard public
+// key as an audit event initiated by the remote peer the session was opened from
+func (am *DefaultAccountManager) StorePeerSSHSession(peerPubKey string, session nbpeer.SSHSession) error {
+	if session.Duration < 0 {
+		return status.Errorf(status.InvalidArgument, "invalid SSH session duration %s", session.Duration)
+	}
+
+	accountID, err := am.Store.GetAccountIDByPeerPubKey(peerPubKey)
+	if err != nil {
+		return err
+	}
+
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	peer, err := account.FindPeerByPubKey(peerPubKey)
+	if err != nil {
+		return status.Errorf(status.NotFound, "peer with key %s not found", peerPubKey)
+	}
+
+	meta := peer.EventMeta(am.GetDNSDomain())
+	meta["user"] = session.User
+	meta["started_at"] = session.StartedAt
+	meta["duration"] = session.Duration.Round(time.Second).String()
+
+	// the remote peer might have been deleted since the session started, the event keeps its key then
+	initiatorID := session.PeerKey
+	if remote, err := account.FindPeerByPubKey(session.PeerKey); err == nil {
+		initiatorID = remote.ID
+		meta["source_name"] = remote.Name
+		meta["source_ip"] = remote.IP
+	}
+
+	am.StoreEvent(initiatorID, peer.ID, accountID, activity.PeerSSHSessionClosed, meta)
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbgroup "github.com/netbirdio/netbird/management/server/group"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+func TestAccount_getPeerSSHAccess(t *testing.T) {
+	account := &Account{
+		Peers: map[string]*nbpeer.Peer{
+			"peerA": {ID: "peerA", Key: "peerAKey", IP: net.ParseIP("100.65.14.88"), Status: &nbpeer.PeerStatus{}},
+			"peerB": {ID: "peerB", Key: "peerBKey", IP: net.ParseIP("100.65.80.39"), Status: &nbpeer.PeerStatus{}},
+			"peerC": {ID: "peerC", Key: "peerCKey", IP: net.ParseIP("100.65.254.139"), Status: &nbpeer.PeerStatus{}},
+			"peerD": {ID: "peerD", Key: "peerDKey", IP: net.ParseIP("100.65.62.5"), Status: &nbpeer.PeerStatus{}},
+		},
+		Groups: map[string]*nbgroup.Group{
+			"GroupAdmins":  {ID: "GroupAdmins", Peers: []string{"peerA"}},
+			"GroupServers": {ID: "GroupServers", Peers: []string{"peerB", "peerC"}},
+			"GroupOther":   {ID: "GroupOther", Peers: []string{"peerD"}},
+		},
+		Policies: []*Policy{
+			{
+				ID:         "RuleSSH",
+				Enabled:    true,
+				SSHEnabled: true,
+				Rules: []*PolicyRule{
+					{ID: "RuleSSH", Enabled: true, Sources: []string{"GroupAdmins"}, Destinations: []string{"GroupServers"}, Action: PolicyTrafficActionAccept},
+				},
+			},
+			{
+				ID:      "RuleOther",
+				Enabled: true,
+				Rules: []*PolicyRule{
+					{ID: "RuleOther", Enabled: true, Sources: []string{"GroupOther"}, Destinations: []string{"GroupServers"}, Bidirectional: true, Action: PolicyTrafficActionAccept},
+				},
+			},
+		},
+	}
+	validatedPeers := map[string]struct{}{"peerA": {}, "peerB": {}, "peerC": {}, "peerD": {}}
+
+	t.Run("destination of an SSH policy", func(t *testing.T) {
+		enabled, authorized := account.getPeerSSHAccess(account.Peers["peerB"], validatedPeers)
+		assert.True(t, enabled)
+		assert.Equal(t, map[string]struct{}{"peerAKey": {}}, authorized, "only the sources of the SSH policy may log in")
+	})
+
+	t.Run("source of a one-way SSH policy", func(t *testing.T) {
+		enabled, authorized := account.getPeerSSHAccess(account.Peers["peerA"], validatedPeers)
+		assert.False(t, enabled)
+		assert.Nil(t, authorized)
+	})
+
+	t.Run("source of a bidirectional SSH policy", func(t *testing.T) {
+		account.Policies[0].Rules[0].Bidirectional = true
+		defer func() { account.Policies[0].Rules[0].Bidirectional = false }()
+		enabled, authorized := account.getPeerSSHAccess(account.Peers["peerA"], validatedPeers)
+		assert.True(t, enabled)
+		assert.Equal(t, map[string]struct{}{"peerBKey": {}, "peerCKey": {}}, authorized)
+	})
+
+	t.Run("SSH enabled on the peer", func(t *testing.T) {
+		account.Peers["peerB"].SSHEnabled = true
+		defer func() { account.Peers["peerB"].SSHEnabled = false }()
+		enabled, authorized := account.getPeerSSHAccess(account.Peers["peerB"], validatedPeers)
+		assert.True(t, enabled)
+		assert.Nil(t, authorized, "the peer's own SSH setting should authorize all the connected peers")
+	})
+
+	t.Run("disabled policy", func(t *testing.T) {
+		account.Policies[0].Enabled = false
+		defer func() { account.Policies[0].Enabled = true }()
+		enabled, authorized := account.getPeerSSHAccess(account.Peers["peerB"], validatedPeers)
+		assert.False(t, enabled)
+		assert.Nil(t, authorized)
+	})
+
+	t.Run("SSH keys pushed to the peer", func(t *testing.T) {
+		for _, peer := range account.Peers {
+			peer.SSHKey = peer.Key + "-ssh"
+		}
+		_, authorized := account.getPeerSSHAccess(account.Peers["peerB"], validatedPeers)
+		remotePeers := toRemotePeerConfig([]*nbpeer.Peer{account.Peers["peerA"], account.Peers["peerD"]}, "", nil, nil, nil, authorized, nil)
+		require.Len(t, remotePeers, 2)
+		assert.Equal(t, "peerAKey-ssh", string(remotePeers[0].GetSshConfig().GetSshPubKey()))
+		assert.Empty(t, remotePeers[1].GetSshConfig().GetSshPubKey(), "the SSH key of a peer not allowed to log in shouldn't be pushed")
+	})
+}
+
+func TestDefaultAccountManager_StorePeerSSHSession(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account := newAccountWithId("test_account", userID, "")
+	err = manager.Store.SaveAccount(account)
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	addPeer := func(hostname string) *nbpeer.Peer {
+		key, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+		peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+			Key:  key.PublicKey().String(),
+			Meta: nbpeer.PeerSystemMeta{Hostname: hostname},
+		})
+		require.NoError(t, err)
+		return peer
+	}
+	server, client := addPeer("test-server"), addPeer("test-client")
+
+	err = manager.StorePeerSSHSession(server.Key, nbpeer.SSHSession{PeerKey: client.Key, User: "root", Duration: -time.Second})
+	require.Error(t, err, "a negative duration should be rejected")
+
+	err = manager.StorePeerSSHSession(server.Key, nbpeer.SSHSession{
+		PeerKey:   client.Key,
+		User:      "root",
+		StartedAt: time.Now().UTC(),
+		Duration:  90 * time.Second,
+	})
+	require.NoError(t, err)
+
+	ev := getEvent(t, account.Id, manager, activity.PeerSSHSessionClosed)
+	assert.Equal(t, client.ID, ev.InitiatorID, "the remote peer should initiate the event")
+	assert.Equal(t, server.ID, ev.TargetID)
+	assert.Equal(t, "root", ev.Meta["user"])
+	assert.Equal(t, "1m30s", ev.Meta["duration"])
+	assert.Equal(t, client.Name, ev.Meta["source_name"])
+}
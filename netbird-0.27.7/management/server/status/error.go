@@ -35,6 +35,9 @@ const (
 
 	// Unauthenticated indicates that user is not authenticated due to absence of valid credentials
 	Unauthenticated Type = 10
+
+	// ResourceExhausted indicates that a quota or a limit of the account was reached
+	ResourceExhausted Type = 11
 )
 
 // Type is a type of the Error
@@ -44,6 +47,8 @@ type Type int32
 type Error struct {
 	ErrorType Type
 	Message   string
+	// Field is the field of the request the error is about, empty when the error isn't about a single field
+	Field string
 }
 
 // Type returns the Type of the error
@@ -64,6 +69,15 @@ func Errorf(errorType Type, format string, a ...interface{}) error {
 	}
 }
 
+// FieldErrorf returns Error(ErrorType, fmt.Sprintf(format, a...)) about the field of the request.
+func FieldErrorf(errorType Type, field, format string, a ...interface{}) error {
+	return &Error{
+		ErrorType: errorType,
+		Message:   fmt.Sprintf(format, a...),
+		Field:     field,
+	}
+}
+
 // FromError returns Error, true if the provided error is of type of Error. nil, false otherwise
 func FromError(err error) (s *Error, ok bool) {
 	if err == nil {
@@ -1,14 +1,17 @@
 package server
 
 import (
+	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	nbgroup "github.com/netbirdio/netbird/management/server/group"
 	nbpeer "github.com/netbirdio/netbird/management/server/peer"
 	"github.com/netbirdio/netbird/management/server/telemetry"
 )
@@ -20,6 +23,18 @@ type Store interface {
 	GetAccountByUser(userID string) (*Account, error)
 	GetAccountByPeerPubKey(peerKey string) (*Account, error)
 	GetAccountIDByPeerPubKey(peerKey string) (string, error)
+	// GetAccountIDByUserID returns the ID of the account of the user without loading the account
+	GetAccountIDByUserID(userID string) (string, error)
+	// GetUserByUserID returns the user without its personal access tokens
+	GetUserByUserID(userID string) (*User, error)
+	GetAccountSettings(accountID string) (*Settings, error)
+	GetAccountGroups(accountID string) ([]*nbgroup.Group, error)
+	// GetAccountGroup returns the group of the account without loading the account
+	GetAccountGroup(accountID, groupID string) (*nbgroup.Group, error)
+	GetAccountPolicies(accountID string) ([]*Policy, error)
+	GetAccountPeers(accountID string) ([]*nbpeer.Peer, error)
+	// GetAccountPeersByIDs returns the peers of the account with the IDs, the IDs that don't exist are skipped
+	GetAccountPeersByIDs(accountID string, peerIDs []string) ([]*nbpeer.Peer, error)
 	GetAccountByPeerID(peerID string) (*Account, error)
 	GetAccountBySetupKey(setupKey string) (*Account, error) // todo use key hash later
 	GetAccountByPrivateDomain(domain string) (*Account, error)
@@ -36,14 +51,45 @@ type Store interface {
 	AcquireAccountReadLock(accountID string) func()
 	// AcquireGlobalLock should attempt to acquire a global lock and return a function that releases the lock
 	AcquireGlobalLock() func()
+	// AcquireLease takes or renews the lease with the name for the holder until the TTL passes and returns whether the
+	// holder has it, the lease of another holder is only taken once it expired
+	AcquireLease(name, holder string, ttl time.Duration) (bool, error)
+	// ReleaseLease gives up the lease with the name when the holder has it
+	ReleaseLease(name, holder string) error
 	SavePeerStatus(accountID, peerID string, status nbpeer.PeerStatus) error
 	SavePeerLocation(accountID string, peer *nbpeer.Peer) error
+	// SavePeerGeofence stores the geofence violation and override of the peer only
+	SavePeerGeofence(accountID string, peer *nbpeer.Peer) error
 	SaveUserLastLogin(accountID, userID string, lastLogin time.Time) error
 	// Close should close the store persisting all unsaved data.
 	Close() error
 	// GetStoreEngine should return StoreEngine of the current store implementation.
 	// This is also a method of metrics.DataSource interface.
 	GetStoreEngine() StoreEngine
+	// GetStoreStats returns the number of the stored objects and the state of the connections of the store
+	GetStoreStats() (*StoreStats, error)
+}
+
+// StoreStats are the statistics of the store reported by the debug endpoint
+type StoreStats struct {
+	Engine   StoreEngine `json:"engine"`
+	Accounts int         `json:"accounts"`
+	Peers    int         `json:"peers"`
+	Users    int         `json:"users"`
+	// AccountLocks is the number of accounts a lock was created for since the start
+	AccountLocks int `json:"account_locks"`
+	// Connections is the state of the connection pool of the SQL stores, nil for the file store
+	Connections *sql.DBStats `json:"connections,omitempty"`
+}
+
+// countLocks returns the number of locks of the map
+func countLocks(locks *sync.Map) int {
+	count := 0
+	locks.Range(func(_, _ any) bool {
+		count++
+		return true
+	})
+	return count
 }
 
 type StoreEngine string
@@ -79,6 +125,21 @@ func getStoreEngineFromDatadir(dataDir string) StoreEngine {
 }
 
 func NewStore(kind StoreEngine, dataDir string, metrics telemetry.AppMetrics) (Store, error) {
+	kind = ResolveStoreEngine(kind, dataDir)
+	switch kind {
+	case FileStoreEngine:
+		log.Info("using JSON file store engine")
+		return NewFileStore(dataDir, metrics)
+	case SqliteStoreEngine:
+		log.Info("using SQLite store engine")
+		return NewSqliteStore(dataDir, metrics)
+	default:
+		return nil, fmt.Errorf("unsupported kind of store %s", kind)
+	}
+}
+
+// ResolveStoreEngine returns the engine of the store NewStore opens for the configured kind
+func ResolveStoreEngine(kind StoreEngine, dataDir string) StoreEngine {
 	if kind == "" {
 		// if store engine is not set in the config we first try to evaluate NETBIRD_STORE_ENGINE
 		kind = getStoreEngineFromEnv()
@@ -87,15 +148,20 @@ func NewStore(kind StoreEngine, dataDir string, metrics telemetry.AppMetrics) (S
 			kind = getStoreEngineFromDatadir(dataDir)
 		}
 	}
-	switch kind {
+	return kind
+}
+
+// CheckStore reads the store NewStore opens for the configured kind and returns its number of accounts, 0 when it
+// doesn't exist yet. Unlike NewStore it neither migrates nor writes the store, so it can check the store of a running
+// Management service
+func CheckStore(kind StoreEngine, dataDir string) (int, error) {
+	switch kind = ResolveStoreEngine(kind, dataDir); kind {
 	case FileStoreEngine:
-		log.Info("using JSON file store engine")
-		return NewFileStore(dataDir, metrics)
+		return checkFileStore(dataDir)
 	case SqliteStoreEngine:
-		log.Info("using SQLite store engine")
-		return NewSqliteStore(dataDir, metrics)
+		return checkSqliteStore(dataDir)
 	default:
-		return nil, fmt.Errorf("unsupported kind of store %s", kind)
+		return 0, fmt.Errorf("unsupported kind of store %s", kind)
 	}
 }
 
@@ -0,0 +1,45 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+)
+
+// APIQuotaMetrics represents all metrics related to the metering of the HTTP API requests of the accounts
+type APIQuotaMetrics struct {
+	requests         syncint64.Counter
+	rejectedRequests syncint64.Counter
+	ctx              context.Context
+}
+
+// NewAPIQuotaMetrics creates an instance of APIQuotaMetrics
+func NewAPIQuotaMetrics(ctx context.Context, meter metric.Meter) (*APIQuotaMetrics, error) {
+	requests, err := meter.SyncInt64().Counter("management.api.account.requests.counter")
+	if err != nil {
+		return nil, err
+	}
+
+	rejectedRequests, err := meter.SyncInt64().Counter("management.api.account.rejected.requests.counter")
+	if err != nil {
+		return nil, err
+	}
+
+	return &APIQuotaMetrics{
+		requests:         requests,
+		rejectedRequests: rejectedRequests,
+		ctx:              ctx,
+	}, nil
+}
+
+// CountRequest counts an HTTP API request of the account within its quota
+func (metrics *APIQuotaMetrics) CountRequest(accountID string) {
+	metrics.requests.Add(metrics.ctx, 1, attribute.String("account_id", accountID))
+}
+
+// CountRejectedRequest counts an HTTP API request of the account rejected for exceeding the quota of the period
+func (metrics *APIQuotaMetrics) CountRejectedRequest(accountID, period string) {
+	metrics.rejectedRequests.Add(metrics.ctx, 1, attribute.String("account_id", accountID), attribute.String("period", period))
+}
@@ -2,9 +2,11 @@ package telemetry
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/pprof"
 	"reflect"
 
 	"github.com/gorilla/mux"
@@ -16,18 +18,25 @@ import (
 	"go.opentelemetry.io/otel/sdk/metric"
 )
 
-const defaultEndpoint = "/metrics"
+const (
+	defaultEndpoint = "/metrics"
+	// pprofEndpoint is the prefix of the runtime profiles
+	pprofEndpoint = "/debug/pprof/"
+)
 
 // MockAppMetrics mocks the AppMetrics interface
 type MockAppMetrics struct {
 	GetMeterFunc             func() metric2.Meter
 	CloseFunc                func() error
-	ExposeFunc               func(port int, endpoint string) error
+	ExposeFunc               func(listener net.Listener, endpoint string, config ExposeConfig) error
 	IDPMetricsFunc           func() *IDPMetrics
 	HTTPMiddlewareFunc       func() *HTTPMiddleware
 	GRPCMetricsFunc          func() *GRPCMetrics
 	StoreMetricsFunc         func() *StoreMetrics
 	UpdateChannelMetricsFunc func() *UpdateChannelMetrics
+	EventSinkMetricsFunc     func() *EventSinkMetrics
+	APIQuotaMetricsFunc      func() *APIQuotaMetrics
+	ExternalListMetricsFunc  func() *ExternalListMetrics
 }
 
 // GetMeter mocks the GetMeter function of the AppMetrics interface
@@ -47,9 +56,9 @@ func (mock *MockAppMetrics) Close() error {
 }
 
 // Expose mocks the Expose function of the AppMetrics interface
-func (mock *MockAppMetrics) Expose(port int, endpoint string) error {
+func (mock *MockAppMetrics) Expose(listener net.Listener, endpoint string, config ExposeConfig) error {
 	if mock.ExposeFunc != nil {
-		return mock.ExposeFunc(port, endpoint)
+		return mock.ExposeFunc(listener, endpoint, config)
 	}
 	return fmt.Errorf("unimplemented")
 }
@@ -94,16 +103,43 @@ func (mock *MockAppMetrics) UpdateChannelMetrics() *UpdateChannelMetrics {
 	return nil
 }
 
+// EventSinkMetrics mocks the MockAppMetrics function of the EventSinkMetrics interface
+func (mock *MockAppMetrics) EventSinkMetrics() *EventSinkMetrics {
+	if mock.EventSinkMetricsFunc != nil {
+		return mock.EventSinkMetricsFunc()
+	}
+	return nil
+}
+
+// APIQuotaMetrics mocks the MockAppMetrics function of the APIQuotaMetrics interface
+func (mock *MockAppMetrics) APIQuotaMetrics() *APIQuotaMetrics {
+	if mock.APIQuotaMetricsFunc != nil {
+		return mock.APIQuotaMetricsFunc()
+	}
+	return nil
+}
+
+// ExternalListMetrics mocks the MockAppMetrics function of the ExternalListMetrics interface
+func (mock *MockAppMetrics) ExternalListMetrics() *ExternalListMetrics {
+	if mock.ExternalListMetricsFunc != nil {
+		return mock.ExternalListMetricsFunc()
+	}
+	return nil
+}
+
 // AppMetrics is metrics interface
 type AppMetrics interface {
 	GetMeter() metric2.Meter
 	Close() error
-	Expose(port int, endpoint string) error
+	Expose(listener net.Listener, endpoint string, config ExposeConfig) error
 	IDPMetrics() *IDPMetrics
 	HTTPMiddleware() *HTTPMiddleware
 	GRPCMetrics() *GRPCMetrics
 	StoreMetrics() *StoreMetrics
 	UpdateChannelMetrics() *UpdateChannelMetrics
+	EventSinkMetrics() *EventSinkMetrics
+	APIQuotaMetrics() *APIQuotaMetrics
+	ExternalListMetrics() *ExternalListMetrics
 }
 
 // defaultAppMetrics are core application metrics based on OpenTelemetry https://opentelemetry.io/
@@ -117,6 +153,9 @@ type defaultAppMetrics struct {
 	grpcMetrics          *GRPCMetrics
 	storeMetrics         *StoreMetrics
 	updateChannelMetrics *UpdateChannelMetrics
+	eventSinkMetrics     *EventSinkMetrics
+	apiQuotaMetrics      *APIQuotaMetrics
+	externalListMetrics  *ExternalListMetrics
 }
 
 // IDPMetrics returns metrics for the idp package
@@ -144,6 +183,21 @@ func (appMetrics *defaultAppMetrics) UpdateChannelMetrics() *UpdateChannelMetric
 	return appMetrics.updateChannelMetrics
 }
 
+// EventSinkMetrics returns metrics for the event sink
+func (appMetrics *defaultAppMetrics) EventSinkMetrics() *EventSinkMetrics {
+	return appMetrics.eventSinkMetrics
+}
+
+// APIQuotaMetrics returns metrics for the HTTP API quotas of the accounts
+func (appMetrics *defaultAppMetrics) APIQuotaMetrics() *APIQuotaMetrics {
+	return appMetrics.apiQuotaMetrics
+}
+
+// ExternalListMetrics returns metrics for the external lists of the accounts
+func (appMetrics *defaultAppMetrics) ExternalListMetrics() *ExternalListMetrics {
+	return appMetrics.externalListMetrics
+}
+
 // Close stop application metrics HTTP handler and closes listener.
 func (appMetrics *defaultAppMetrics) Close() error {
 	if appMetrics.listener == nil {
@@ -152,9 +206,9 @@ func (appMetrics *defaultAppMetrics) Close() error {
 	return appMetrics.listener.Close()
 }
 
-// Expose metrics on a given port and endpoint. If endpoint is empty a defaultEndpoint one will be used.
+// Expose metrics on a given listener and endpoint. If endpoint is empty a defaultEndpoint one will be used.
 // Exposes metrics in the Prometheus format https://prometheus.io/
-func (appMetrics *defaultAppMetrics) Expose(port int, endpoint string) error {
+func (appMetrics *defaultAppMetrics) Expose(listener net.Listener, endpoint string, config ExposeConfig) error {
 	if endpoint == "" {
 		endpoint = defaultEndpoint
 	}
@@ -162,19 +216,29 @@ func (appMetrics *defaultAppMetrics) Expose(port int, endpoint string) error {
 	rootRouter.Handle(endpoint, promhttp.HandlerFor(
 		prometheus2.DefaultGatherer,
 		promhttp.HandlerOpts{EnableOpenMetrics: true}))
-	listener, err := net.Listen("tcp4", fmt.Sprintf(":%d", port))
-	if err != nil {
-		return err
+	if config.Pprof {
+		rootRouter.HandleFunc(pprofEndpoint+"cmdline", pprof.Cmdline)
+		rootRouter.HandleFunc(pprofEndpoint+"profile", pprof.Profile)
+		rootRouter.HandleFunc(pprofEndpoint+"symbol", pprof.Symbol)
+		rootRouter.HandleFunc(pprofEndpoint+"trace", pprof.Trace)
+		// the index serves the named profiles, e.g. heap or goroutine, it is matched after the handlers above
+		rootRouter.PathPrefix(pprofEndpoint).HandlerFunc(pprof.Index)
+	}
+
+	scheme := "http"
+	if config.TLSConfig != nil {
+		listener = tls.NewListener(listener, config.TLSConfig)
+		scheme = "https"
 	}
 	appMetrics.listener = listener
 	go func() {
-		err := http.Serve(listener, rootRouter)
+		err := http.Serve(listener, config.authenticate(rootRouter))
 		if err != nil {
 			return
 		}
 	}()
 
-	log.Infof("enabled application metrics and exposing on http://%s", listener.Addr().String())
+	log.Infof("enabled application metrics and exposing on %s://%s", scheme, listener.Addr().String())
 
 	return nil
 }
@@ -220,6 +284,21 @@ func NewDefaultAppMetrics(ctx context.Context) (AppMetrics, error) {
 		return nil, err
 	}
 
+	eventSinkMetrics, err := NewEventSinkMetrics(ctx, meter)
+	if err != nil {
+		return nil, err
+	}
+
+	apiQuotaMetrics, err := NewAPIQuotaMetrics(ctx, meter)
+	if err != nil {
+		return nil, err
+	}
+
+	externalListMetrics, err := NewExternalListMetrics(ctx, meter)
+	if err != nil {
+		return nil, err
+	}
+
 	return &defaultAppMetrics{
 		Meter:                meter,
 		ctx:                  ctx,
@@ -228,5 +307,8 @@ func NewDefaultAppMetrics(ctx context.Context) (AppMetrics, error) {
 		grpcMetrics:          grpcMetrics,
 		storeMetrics:         storeMetrics,
 		updateChannelMetrics: updateChannelMetrics,
+		eventSinkMetrics:     eventSinkMetrics,
+		apiQuotaMetrics:      apiQuotaMetrics,
+		externalListMetrics:  externalListMetrics,
 	}, nil
 }
@@ -0,0 +1,71 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+)
+
+// EventSinkMetrics represents all metrics related to the publishing of the activity events to the event sink
+type EventSinkMetrics struct {
+	publishLagMs   syncint64.Histogram
+	queuedEvents   syncint64.UpDownCounter
+	publishRetries syncint64.Counter
+	droppedEvents  syncint64.Counter
+	ctx            context.Context
+}
+
+// NewEventSinkMetrics creates an instance of EventSinkMetrics
+func NewEventSinkMetrics(ctx context.Context, meter metric.Meter) (*EventSinkMetrics, error) {
+	publishLagMs, err := meter.SyncInt64().Histogram("management.eventsink.publish.lag.ms",
+		instrument.WithUnit("milliseconds"))
+	if err != nil {
+		return nil, err
+	}
+
+	queuedEvents, err := meter.SyncInt64().UpDownCounter("management.eventsink.queued.events")
+	if err != nil {
+		return nil, err
+	}
+
+	publishRetries, err := meter.SyncInt64().Counter("management.eventsink.publish.retries")
+	if err != nil {
+		return nil, err
+	}
+
+	droppedEvents, err := meter.SyncInt64().Counter("management.eventsink.dropped.events")
+	if err != nil {
+		return nil, err
+	}
+
+	return &EventSinkMetrics{
+		publishLagMs:   publishLagMs,
+		queuedEvents:   queuedEvents,
+		publishRetries: publishRetries,
+		droppedEvents:  droppedEvents,
+		ctx:            ctx,
+	}, nil
+}
+
+// CountPublishLag counts the time between an event and its delivery to the sink
+func (metrics *EventSinkMetrics) CountPublishLag(lag time.Duration) {
+	metrics.publishLagMs.Record(metrics.ctx, lag.Milliseconds())
+}
+
+// CountQueued counts the events added to (positive delta) or removed from (negative delta) the publish queue
+func (metrics *EventSinkMetrics) CountQueued(delta int64) {
+	metrics.queuedEvents.Add(metrics.ctx, delta)
+}
+
+// CountPublishRetry counts a failed delivery attempt that is retried
+func (metrics *EventSinkMetrics) CountPublishRetry() {
+	metrics.publishRetries.Add(metrics.ctx, 1)
+}
+
+// CountDropped counts an event that was never delivered, because the queue was full or the retries ran out
+func (metrics *EventSinkMetrics) CountDropped() {
+	metrics.droppedEvents.Add(metrics.ctx, 1)
+}
@@ -0,0 +1,73 @@
+package telemetry
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"net/http"
+)
+
+// ExposeConfig secures the HTTP listener of the metrics
+type ExposeConfig struct {
+	// BearerToken is the token the requests may authenticate with in their Authorization header
+	BearerToken string
+	// BasicAuthUsername and BasicAuthPassword are the credentials the requests may authenticate with instead
+	BasicAuthUsername string
+	BasicAuthPassword string
+	// TLSConfig serves the endpoints over HTTPS when set
+	TLSConfig *tls.Config
+	// Pprof exposes the runtime profiles of the server under /debug/pprof/
+	Pprof bool
+}
+
+// HasAuth tells whether the requests have to authenticate
+func (c ExposeConfig) HasAuth() bool {
+	return c.BearerToken != "" || c.BasicAuthUsername != ""
+}
+
+// authenticate rejects the requests authenticating with neither the bearer token nor the basic credentials, every
+// request is let through without them
+func (c ExposeConfig) authenticate(next http.Handler) http.Handler {
+	if !c.HasAuth() {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.authorized(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if c.BasicAuthUsername != "" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="metrics"`)
+		} else {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+		}
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	})
+}
+
+func (c ExposeConfig) authorized(r *http.Request) bool {
+	if c.BearerToken != "" {
+		const prefix = "Bearer "
+		header := r.Header.Get("Authorization")
+		if len(header) > len(prefix) && header[:len(prefix)] == prefix && secureEqual(header[len(prefix):], c.BearerToken) {
+			return true
+		}
+	}
+
+	if c.BasicAuthUsername != "" {
+		username, password, ok := r.BasicAuth()
+		// both are compared so a wrong username takes as long as a wrong password
+		usernameMatches := secureEqual(username, c.BasicAuthUsername)
+		passwordMatches := secureEqual(password, c.BasicAuthPassword)
+		if ok && usernameMatches && passwordMatches {
+			return true
+		}
+	}
+
+	return false
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,67 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/asyncint64"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+)
+
+// ExternalListMetrics represents all metrics related to the refresh of the external lists of the accounts
+type ExternalListMetrics struct {
+	meter          metric.Meter
+	refreshes      syncint64.Counter
+	staleListGauge asyncint64.Gauge
+	maxAgeGauge    asyncint64.Gauge
+	ctx            context.Context
+}
+
+// NewExternalListMetrics creates an instance of ExternalListMetrics
+func NewExternalListMetrics(ctx context.Context, meter metric.Meter) (*ExternalListMetrics, error) {
+	refreshes, err := meter.SyncInt64().Counter("management.externallist.refresh.counter", instrument.WithUnit("1"))
+	if err != nil {
+		return nil, err
+	}
+
+	staleListGauge, err := meter.AsyncInt64().Gauge("management.externallist.stale.lists", instrument.WithUnit("1"))
+	if err != nil {
+		return nil, err
+	}
+
+	maxAgeGauge, err := meter.AsyncInt64().Gauge("management.externallist.max.age.seconds", instrument.WithUnit("seconds"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExternalListMetrics{
+		meter:          meter,
+		refreshes:      refreshes,
+		staleListGauge: staleListGauge,
+		maxAgeGauge:    maxAgeGauge,
+		ctx:            ctx,
+	}, nil
+}
+
+// CountRefresh counts a refresh of an external list with its result: success, failure or too_large
+func (metrics *ExternalListMetrics) CountRefresh(result string) {
+	metrics.refreshes.Add(metrics.ctx, 1, attribute.String("result", result))
+}
+
+// RegisterStaleness registers a function that collects the number of stale external lists and the age in seconds of
+// the least recently refreshed enabled one, and feeds them to the metrics gauges
+func (metrics *ExternalListMetrics) RegisterStaleness(producer func() (stale int64, maxAge int64)) error {
+	return metrics.meter.RegisterCallback(
+		[]instrument.Asynchronous{
+			metrics.staleListGauge,
+			metrics.maxAgeGauge,
+		},
+		func(ctx context.Context) {
+			stale, maxAge := producer()
+			metrics.staleListGauge.Observe(ctx, stale)
+			metrics.maxAgeGauge.Observe(ctx, maxAge)
+		},
+	)
+}
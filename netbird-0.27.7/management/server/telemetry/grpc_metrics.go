@@ -17,6 +17,7 @@ type GRPCMetrics struct {
 	loginRequestsCounter  syncint64.Counter
 	getKeyRequestsCounter syncint64.Counter
 	activeStreamsGauge    asyncint64.Gauge
+	relayedConnsGauge     asyncint64.Gauge
 	syncRequestDuration   syncint64.Histogram
 	loginRequestDuration  syncint64.Histogram
 	channelQueueLength    syncint64.Histogram
@@ -43,6 +44,11 @@ func NewGRPCMetrics(ctx context.Context, meter metric.Meter) (*GRPCMetrics, erro
 		return nil, err
 	}
 
+	relayedConnsGauge, err := meter.AsyncInt64().Gauge("management.grpc.relayed.connections", instrument.WithUnit("1"))
+	if err != nil {
+		return nil, err
+	}
+
 	syncRequestDuration, err := meter.SyncInt64().Histogram("management.grpc.sync.request.duration.ms", instrument.WithUnit("milliseconds"))
 	if err != nil {
 		return nil, err
@@ -71,6 +77,7 @@ func NewGRPCMetrics(ctx context.Context, meter metric.Meter) (*GRPCMetrics, erro
 		loginRequestsCounter:  loginRequestsCounter,
 		getKeyRequestsCounter: getKeyRequestsCounter,
 		activeStreamsGauge:    activeStreamsGauge,
+		relayedConnsGauge:     relayedConnsGauge,
 		syncRequestDuration:   syncRequestDuration,
 		loginRequestDuration:  loginRequestDuration,
 		channelQueueLength:    channelQueue,
@@ -115,6 +122,19 @@ func (grpcMetrics *GRPCMetrics) RegisterConnectedStreams(producer func() int64)
 	)
 }
 
+// RegisterRelayedConnections registers a function that collects the number of connections between peers going
+// through a relay and feeds it to the metrics gauge.
+func (grpcMetrics *GRPCMetrics) RegisterRelayedConnections(producer func() int64) error {
+	return grpcMetrics.meter.RegisterCallback(
+		[]instrument.Asynchronous{
+			grpcMetrics.relayedConnsGauge,
+		},
+		func(ctx context.Context) {
+			grpcMetrics.relayedConnsGauge.Observe(ctx, producer())
+		},
+	)
+}
+
 // UpdateChannelQueueLength update the histogram that keep distribution of the update messages channel queue
 func (metrics *GRPCMetrics) UpdateChannelQueueLength(length int) {
 	metrics.channelQueueLength.Record(metrics.ctx, int64(length))
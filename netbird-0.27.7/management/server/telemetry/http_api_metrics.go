@@ -8,6 +8,7 @@ import (
 	"strings"
 	time "time"
 
+	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
@@ -19,6 +20,7 @@ const (
 	httpRequestCounterPrefix  = "management.http.request.counter"
 	httpResponseCounterPrefix = "management.http.response.counter"
 	httpRequestDurationPrefix = "management.http.request.duration.ms"
+	httpRouteDurationName     = "management.http.route.request.duration.ms"
 )
 
 // WrappedResponseWriter is a wrapper for http.ResponseWriter that allows the
@@ -69,6 +71,8 @@ type HTTPMiddleware struct {
 	httpRequestDurations map[string]syncint64.Histogram
 	// all HTTP requests durations
 	totalHTTPRequestDuration syncint64.Histogram
+	// all HTTP requests durations by route template, method and status code
+	httpRouteRequestDuration syncint64.Histogram
 }
 
 // NewMetricsMiddleware creates a new HTTPMiddleware
@@ -95,6 +99,12 @@ func NewMetricsMiddleware(ctx context.Context, meter metric.Meter) (*HTTPMiddlew
 		return nil, err
 	}
 
+	httpRouteRequestDuration, err := meter.SyncInt64().Histogram(httpRouteDurationName,
+		instrument.WithUnit("milliseconds"))
+	if err != nil {
+		return nil, err
+	}
+
 	return &HTTPMiddleware{
 			ctx:                           ctx,
 			httpRequestCounters:           map[string]syncint64.Counter{},
@@ -105,6 +115,7 @@ func NewMetricsMiddleware(ctx context.Context, meter metric.Meter) (*HTTPMiddlew
 			totalHTTPRequestsCounter:      totalHTTPRequestsCounter,
 			totalHTTPResponseCounter:      totalHTTPResponseCounter,
 			totalHTTPRequestDuration:      totalHTTPRequestDuration,
+			httpRouteRequestDuration:      httpRouteRequestDuration,
 		},
 		nil
 }
@@ -200,6 +211,16 @@ func (m *HTTPMiddleware) Handler(h http.Handler) http.Handler {
 		}
 		log.Debugf("request %s %s took %d ms and finished with status %d", r.Method, r.URL.Path, reqTook.Milliseconds(), w.Status())
 
+		// the route template keeps the cardinality of the histogram bounded, unlike paths holding resource IDs
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				m.httpRouteRequestDuration.Record(m.ctx, reqTook.Milliseconds(),
+					attribute.String("route", template),
+					attribute.String("method", r.Method),
+					attribute.Int("status", w.Status()))
+			}
+		}
+
 		if w.Status() == 200 && (r.Method == http.MethodPut || r.Method == http.MethodPost || r.Method == http.MethodDelete) {
 			m.totalHTTPRequestDuration.Record(m.ctx, reqTook.Milliseconds(), attribute.String("type", "write"))
 		} else {
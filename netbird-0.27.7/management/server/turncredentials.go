@@ -44,11 +44,15 @@ func NewTimeBasedAuthSecretsManager(updateManager *PeersUpdateManager, config *T
 
 // GenerateCredentials generates new time-based secret credentials - basically username is a unix timestamp and password is a HMAC hash of a timestamp with a preshared TURN secret
 func (m *TimeBasedAuthSecretsManager) GenerateCredentials() TURNCredentials {
-	mac := hmac.New(sha1.New, []byte(m.config.Secret))
+	return generateTimeBasedCredentials(m.config.Secret, time.Now().Add(m.config.CredentialsTTL.Duration))
+}
 
-	timeAuth := time.Now().Add(m.config.CredentialsTTL.Duration).Unix()
+// generateTimeBasedCredentials generates the credentials of the TURN REST API expiring at expiry: the username is
+// the expiry unix timestamp and the password a HMAC hash of the username with the secret shared with the TURN server
+func generateTimeBasedCredentials(secret string, expiry time.Time) TURNCredentials {
+	mac := hmac.New(sha1.New, []byte(secret))
 
-	username := fmt.Sprint(timeAuth)
+	username := fmt.Sprint(expiry.Unix())
 
 	_, err := mac.Write([]byte(username))
 	if err != nil {
@@ -62,7 +66,6 @@ func (m *TimeBasedAuthSecretsManager) GenerateCredentials() TURNCredentials {
 		Username: username,
 		Password: password,
 	}
-
 }
 
 func (m *TimeBasedAuthSecretsManager) cancel(peerID string) {
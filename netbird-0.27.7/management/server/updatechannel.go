@@ -1,7 +1,9 @@
 package server
 
 import (
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -10,10 +12,16 @@ import (
 	"github.com/netbirdio/netbird/management/server/telemetry"
 )
 
-const channelBufferSize = 100
+const (
+	channelBufferSize = 100
+	// busiestChannelsCount is the number of channels with the most queued updates reported by the stats
+	busiestChannelsCount = 10
+)
 
 type UpdateMessage struct {
 	Update *proto.SyncResponse
+	// NetworkMap the update was built from, nil for the updates without a network map
+	NetworkMap *NetworkMap
 }
 
 type PeersUpdateManager struct {
@@ -23,6 +31,33 @@ type PeersUpdateManager struct {
 	channelsMux *sync.Mutex
 	// metrics provides method to collect application metrics
 	metrics telemetry.AppMetrics
+	// sentUpdates and droppedUpdates count the updates sent to the channels and dropped as their channel was full
+	sentUpdates    atomic.Uint64
+	droppedUpdates atomic.Uint64
+}
+
+// UpdateChannelStats are the statistics of the update channels reported by the debug endpoint
+type UpdateChannelStats struct {
+	Channels int `json:"channels"`
+	// BufferSize is the number of updates a channel holds before the next ones are dropped
+	BufferSize int `json:"buffer_size"`
+	// QueuedUpdates is the number of updates waiting in all the channels
+	QueuedUpdates int `json:"queued_updates"`
+	// FullChannels is the number of channels the next updates are dropped from
+	FullChannels int `json:"full_channels"`
+	// SentUpdates and DroppedUpdates are counted since the start
+	SentUpdates    uint64 `json:"sent_updates"`
+	DroppedUpdates uint64 `json:"dropped_updates"`
+	// LockWait is the time the stats waited for the lock of the channels, long when it is held by a stalled call
+	LockWait time.Duration `json:"lock_wait_ns"`
+	// Busiest are the channels with the most queued updates
+	Busiest []PeerUpdateChannelStats `json:"busiest"`
+}
+
+// PeerUpdateChannelStats is the number of updates queued in the channel of a peer
+type PeerUpdateChannelStats struct {
+	PeerID        string `json:"peer_id"`
+	QueuedUpdates int    `json:"queued_updates"`
 }
 
 // NewPeersUpdateManager returns a new instance of PeersUpdateManager
@@ -51,9 +86,11 @@ func (p *PeersUpdateManager) SendUpdate(peerID string, update *UpdateMessage) {
 		found = true
 		select {
 		case channel <- update:
+			p.sentUpdates.Add(1)
 			log.Debugf("update was sent to channel for peer %s", peerID)
 		default:
 			dropped = true
+			p.droppedUpdates.Add(1)
 			log.Warnf("channel for peer %s is %d full", peerID, len(channel))
 		}
 	} else {
@@ -169,3 +206,43 @@ func (p *PeersUpdateManager) HasChannel(peerID string) bool {
 
 	return ok
 }
+
+// Stats returns the number of channels and of updates queued in them with the channels having the most of them
+func (p *PeersUpdateManager) Stats() *UpdateChannelStats {
+	start := time.Now()
+	p.channelsMux.Lock()
+	defer p.channelsMux.Unlock()
+
+	stats := &UpdateChannelStats{
+		Channels:       len(p.peerChannels),
+		BufferSize:     channelBufferSize,
+		SentUpdates:    p.sentUpdates.Load(),
+		DroppedUpdates: p.droppedUpdates.Load(),
+		LockWait:       time.Since(start),
+	}
+
+	busiest := make([]PeerUpdateChannelStats, 0, len(p.peerChannels))
+	for peerID, channel := range p.peerChannels {
+		queued := len(channel)
+		stats.QueuedUpdates += queued
+		if queued == cap(channel) {
+			stats.FullChannels++
+		}
+		if queued > 0 {
+			busiest = append(busiest, PeerUpdateChannelStats{PeerID: peerID, QueuedUpdates: queued})
+		}
+	}
+
+	sort.Slice(busiest, func(i, j int) bool {
+		if busiest[i].QueuedUpdates != busiest[j].QueuedUpdates {
+			return busiest[i].QueuedUpdates > busiest[j].QueuedUpdates
+		}
+		return busiest[i].PeerID < busiest[j].PeerID
+	})
+	if len(busiest) > busiestChannelsCount {
+		busiest = busiest[:busiestChannelsCount]
+	}
+	stats.Busiest = busiest
+
+	return stats
+}
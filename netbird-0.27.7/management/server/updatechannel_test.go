@@ -76,3 +76,25 @@ func TestCloseChannel(t *testing.T) {
 		t.Error("Error closing the channel")
 	}
 }
+
+func TestUpdateChannelStats(t *testing.T) {
+	peersUpdater := NewPeersUpdateManager(nil)
+	_ = peersUpdater.CreateChannel("busy")
+	_ = peersUpdater.CreateChannel("idle")
+	defer peersUpdater.CloseChannels([]string{"busy", "idle"})
+
+	for i := 0; i < channelBufferSize+2; i++ {
+		peersUpdater.SendUpdate("busy", &UpdateMessage{Update: &proto.SyncResponse{}})
+	}
+
+	stats := peersUpdater.Stats()
+	if stats.Channels != 2 || stats.QueuedUpdates != channelBufferSize || stats.FullChannels != 1 {
+		t.Errorf("unexpected stats of the channels: %+v", stats)
+	}
+	if stats.SentUpdates != channelBufferSize || stats.DroppedUpdates != 2 {
+		t.Errorf("unexpected counts of the updates: sent %d, dropped %d", stats.SentUpdates, stats.DroppedUpdates)
+	}
+	if len(stats.Busiest) != 1 || stats.Busiest[0].PeerID != "busy" {
+		t.Errorf("unexpected busiest channels: %+v", stats.Busiest)
+	}
+}
@@ -0,0 +1,199 @@
+package server
+
+import (
+	"sort"
+	"time"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// UsageReportPeriod is the length of the periods a usage report is split into
+type UsageReportPeriod string
+
+const (
+	UsageReportPeriodDay   UsageReportPeriod = "day"
+	UsageReportPeriodWeek  UsageReportPeriod = "week"
+	UsageReportPeriodMonth UsageReportPeriod = "month"
+
+	// maxUsageReportPeriods limits the number of periods of a single report
+	maxUsageReportPeriods = 366
+)
+
+// UsageReport summarizes the usage of an account over consecutive periods
+type UsageReport struct {
+	From    time.Time
+	To      time.Time
+	Period  UsageReportPeriod
+	Periods []*UsagePeriod
+}
+
+// UsagePeriod is the usage of an account over a period, Start included and End excluded
+type UsagePeriod struct {
+	Start time.Time
+	End   time.Time
+	// ActivePeers is the number of peers added, logged in or last seen during the period, or connected during the
+	// current period
+	ActivePeers int
+	// NewPeers is the number of peers added during the period, including the ones deleted since
+	NewPeers int
+	// PolicyChanges is the number of policies added, updated or deleted during the period
+	PolicyChanges int
+	// RoutingPeers is the data transferred by the routing peers, only known for the current period as the peers report
+	// their transfer statistics since their WireGuard interface came up
+	RoutingPeers []RoutingPeerUsage
+}
+
+// RoutingPeerUsage is the data transferred by a routing peer with the other peers
+type RoutingPeerUsage struct {
+	PeerID   string
+	PeerName string
+	RxBytes  int64
+	TxBytes  int64
+}
+
+// Validate checks whether the period is supported
+func (p UsageReportPeriod) Validate() error {
+	switch p {
+	case UsageReportPeriodDay, UsageReportPeriodWeek, UsageReportPeriodMonth:
+		return nil
+	default:
+		return status.Errorf(status.InvalidArgument, "unsupported usage report period %s, expected day, week or month", p)
+	}
+}
+
+// start returns the UTC start of the period t is in, weeks start on Monday
+func (p UsageReportPeriod) start(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch p {
+	case UsageReportPeriodWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case UsageReportPeriodMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	default:
+		return day
+	}
+}
+
+// next returns the start of the period following the one starting at start
+func (p UsageReportPeriod) next(start time.Time) time.Time {
+	switch p {
+	case UsageReportPeriodWeek:
+		return start.AddDate(0, 0, 7)
+	case UsageReportPeriodMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// GetUsageReport returns the usage of the account between from and to split into periods aligned on UTC days, weeks
+// or months. Only users with admin power can view it.
+func (am *DefaultAccountManager) GetUsageReport(accountID, userID string, from, to time.Time, period UsageReportPeriod) (*UsageReport, error) {
+	if err := period.Validate(); err != nil {
+		return nil, err
+	}
+	if !from.Before(to) {
+		return nil, status.Errorf(status.InvalidArgument, "the usage report should start before it ends")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(user.HasAdminPower() || user.IsServiceUser) {
+		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view usage reports")
+	}
+
+	report := &UsageReport{From: from.UTC(), To: to.UTC(), Period: period}
+	for start := period.start(from); start.Before(to); start = period.next(start) {
+		if len(report.Periods) == maxUsageReportPeriods {
+			return nil, status.Errorf(status.InvalidArgument, "the usage report can't have more than %d periods", maxUsageReportPeriods)
+		}
+		report.Periods = append(report.Periods, &UsagePeriod{Start: start, End: period.next(start)})
+	}
+
+	events, err := am.eventStore.GetBefore(accountID, report.Periods[len(report.Periods)-1].End)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	for _, p := range report.Periods {
+		activePeers := make(map[string]struct{})
+		for _, peer := range account.Peers {
+			if peerActiveDuring(peer, p, now) {
+				activePeers[peer.ID] = struct{}{}
+			}
+		}
+
+		for _, event := range events {
+			if event.Timestamp.Before(p.Start) || !event.Timestamp.Before(p.End) {
+				continue
+			}
+			switch event.Activity {
+			case activity.PeerAddedByUser, activity.PeerAddedWithSetupKey:
+				p.NewPeers++
+				activePeers[event.TargetID] = struct{}{}
+			case activity.UserLoggedInPeer:
+				activePeers[event.TargetID] = struct{}{}
+			case activity.PolicyAdded, activity.PolicyUpdated, activity.PolicyRemoved:
+				p.PolicyChanges++
+			}
+		}
+		p.ActivePeers = len(activePeers)
+
+		if !now.Before(p.Start) && now.Before(p.End) {
+			p.RoutingPeers = am.getRoutingPeersUsage(account)
+		}
+	}
+
+	return report, nil
+}
+
+func peerActiveDuring(peer *nbpeer.Peer, p *UsagePeriod, now time.Time) bool {
+	if peer.Status != nil && peer.Status.Connected && !now.Before(p.Start) && now.Before(p.End) {
+		return true
+	}
+	for _, t := range []time.Time{peer.CreatedAt, peer.LastLogin} {
+		if !t.Before(p.Start) && t.Before(p.End) {
+			return true
+		}
+	}
+	return peer.Status != nil && !peer.Status.LastSeen.Before(p.Start) && peer.Status.LastSeen.Before(p.End)
+}
+
+// getRoutingPeersUsage returns the data transferred by the routing peers of the account as last reported by them,
+// sorted by peer ID
+func (am *DefaultAccountManager) getRoutingPeersUsage(account *Account) []RoutingPeerUsage {
+	am.transferStatsMux.RLock()
+	defer am.transferStatsMux.RUnlock()
+
+	usage := make([]RoutingPeerUsage, 0)
+	for _, peer := range account.Peers {
+		enabledRoutes, disabledRoutes := account.getRoutingPeerRoutes(peer.ID)
+		if len(enabledRoutes) == 0 && len(disabledRoutes) == 0 {
+			continue
+		}
+
+		peerUsage := RoutingPeerUsage{PeerID: peer.ID, PeerName: peer.Name}
+		for _, stats := range am.transferStats[peer.Key] {
+			peerUsage.RxBytes += stats.RxBytes
+			peerUsage.TxBytes += stats.TxBytes
+		}
+		usage = append(usage, peerUsage)
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].PeerID < usage[j].PeerID
+	})
+	return usage
+}
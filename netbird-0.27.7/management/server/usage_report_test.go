@@ -0,0 +1,106 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+	"github.com/netbirdio/netbird/route"
+)
+
+func TestUsageReportPeriod_start(t *testing.T) {
+	// Thursday
+	at := time.Date(2024, 5, 16, 13, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 5, 16, 0, 0, 0, 0, time.UTC), UsageReportPeriodDay.start(at))
+	assert.Equal(t, time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC), UsageReportPeriodWeek.start(at))
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), UsageReportPeriodMonth.start(at))
+
+	sunday := time.Date(2024, 5, 19, 23, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC), UsageReportPeriodWeek.start(sunday))
+}
+
+func TestDefaultAccountManager_GetUsageReport(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	currentMonth := UsageReportPeriodMonth.start(now)
+	lastMonth := currentMonth.AddDate(0, -1, 0)
+
+	account := newAccountWithId("test_account", userID, "")
+	account.Users["regularUser"] = NewRegularUser("regularUser")
+	account.Peers["router"] = &nbpeer.Peer{
+		ID:        "router",
+		Key:       "routerKey",
+		Name:      "office",
+		Meta:      nbpeer.PeerSystemMeta{GoOS: "linux"},
+		CreatedAt: lastMonth.Add(time.Hour),
+		Status:    &nbpeer.PeerStatus{Connected: true, LastSeen: now},
+	}
+	account.Peers["laptop"] = &nbpeer.Peer{
+		ID:        "laptop",
+		Key:       "laptopKey",
+		Name:      "laptop",
+		CreatedAt: lastMonth.Add(-24 * time.Hour),
+		LastLogin: lastMonth.Add(48 * time.Hour),
+		Status:    &nbpeer.PeerStatus{LastSeen: lastMonth.Add(48 * time.Hour)},
+	}
+	account.Routes["office"] = &route.Route{ID: "office", Peer: "router", Enabled: true}
+	err = manager.Store.SaveAccount(account)
+	require.NoError(t, err)
+
+	for _, event := range []*activity.Event{
+		{Timestamp: lastMonth.Add(time.Hour), Activity: activity.PeerAddedWithSetupKey, TargetID: "router"},
+		{Timestamp: lastMonth.Add(2 * time.Hour), Activity: activity.PeerAddedByUser, TargetID: "deleted"},
+		{Timestamp: lastMonth.Add(3 * time.Hour), Activity: activity.PolicyAdded, TargetID: "policy"},
+		{Timestamp: currentMonth, Activity: activity.PolicyUpdated, TargetID: "policy"},
+		{Timestamp: currentMonth.Add(-time.Second), Activity: activity.PolicyRemoved, TargetID: "policy", AccountID: "other"},
+	} {
+		if event.AccountID == "" {
+			event.AccountID = account.Id
+		}
+		_, err = manager.eventStore.Save(event)
+		require.NoError(t, err)
+	}
+
+	err = manager.UpdatePeerTransferStats("routerKey", []nbpeer.TransferStats{
+		{RemotePeerKey: "laptopKey", RxBytes: 2048, TxBytes: 1024},
+		{RemotePeerKey: "otherKey", RxBytes: 10, TxBytes: 20},
+	})
+	require.NoError(t, err)
+
+	_, err = manager.GetUsageReport(account.Id, "regularUser", lastMonth, now, UsageReportPeriodMonth)
+	sErr, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.PermissionDenied, sErr.Type(), "regular users shouldn't view usage reports")
+
+	_, err = manager.GetUsageReport(account.Id, userID, now, lastMonth, UsageReportPeriodMonth)
+	require.Error(t, err, "a report ending before it starts should be rejected")
+
+	_, err = manager.GetUsageReport(account.Id, userID, now.AddDate(-2, 0, 0), now, UsageReportPeriodDay)
+	require.Error(t, err, "a report with too many periods should be rejected")
+
+	report, err := manager.GetUsageReport(account.Id, userID, lastMonth.Add(24*time.Hour), now, UsageReportPeriodMonth)
+	require.NoError(t, err)
+	require.Len(t, report.Periods, 2)
+
+	previous := report.Periods[0]
+	assert.Equal(t, lastMonth, previous.Start)
+	assert.Equal(t, currentMonth, previous.End)
+	assert.Equal(t, 3, previous.ActivePeers, "the peers added, logged in or seen during the period should be active")
+	assert.Equal(t, 2, previous.NewPeers)
+	assert.Equal(t, 1, previous.PolicyChanges)
+	assert.Nil(t, previous.RoutingPeers, "the data transferred is only known for the current period")
+
+	current := report.Periods[1]
+	assert.Equal(t, currentMonth, current.Start)
+	assert.Equal(t, 1, current.ActivePeers)
+	assert.Equal(t, 0, current.NewPeers)
+	assert.Equal(t, 1, current.PolicyChanges)
+	assert.Equal(t, []RoutingPeerUsage{{PeerID: "router", PeerName: "office", RxBytes: 2058, TxBytes: 1044}}, current.RoutingPeers)
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/netbirdio/netbird/management/server/idp"
 	"github.com/netbirdio/netbird/management/server/integration_reference"
 	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/notification"
 	nbpeer "github.com/netbirdio/netbird/management/server/peer"
 	"github.com/netbirdio/netbird/management/server/status"
 )
@@ -76,6 +78,12 @@ type User struct {
 	Issued string `gorm:"default:api"`
 
 	IntegrationReference integration_reference.IntegrationReference `gorm:"embedded;embeddedPrefix:integration_ref_"`
+
+	// EmailNotifications are the kinds of notifications the user receives by email
+	EmailNotifications []string `gorm:"serializer:json"`
+
+	// ClaimGroups is a list of Group IDs the claim group mappings assigned to the peers of the user at its last login
+	ClaimGroups []string `gorm:"serializer:json"`
 }
 
 // IsBlocked returns true if the user is blocked, false otherwise
@@ -176,6 +184,8 @@ func (u *User) Copy() *User {
 		CreatedAt:            u.CreatedAt,
 		Issued:               u.Issued,
 		IntegrationReference: u.IntegrationReference,
+		EmailNotifications:   slices.Clone(u.EmailNotifications),
+		ClaimGroups:          slices.Clone(u.ClaimGroups),
 	}
 }
 
@@ -356,6 +366,8 @@ func (am *DefaultAccountManager) inviteNewUser(accountID, userID string, invite
 
 	am.StoreEvent(userID, newUser.Id, accountID, activity.UserInvited, nil)
 
+	am.notify(account, notification.KindUserInvited, "", notification.Data{UserEmail: invite.Email})
+
 	return newUser.ToUserInfo(idpUser, account.Settings)
 }
 
@@ -1066,6 +1078,7 @@ func (am *DefaultAccountManager) expireAndUpdatePeers(account *Account, peers []
 			peer.UserID, peer.ID, account.Id,
 			activity.PeerLoginExpired, peer.EventMeta(am.GetDNSDomain()),
 		)
+		am.notifyPeer(account, notification.KindPeerLoginExpired, peer)
 	}
 
 	if len(peerIDs) != 0 {
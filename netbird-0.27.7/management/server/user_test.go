@@ -281,6 +281,8 @@ func TestUser_Copy(t *testing.T) {
 			ID:              0,
 			IntegrationType: "test",
 		},
+		EmailNotifications: []string{"peer_login_expired"},
+		ClaimGroups:        []string{"group1"},
 	}
 
 	err := validateStruct(user)
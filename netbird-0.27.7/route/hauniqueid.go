@@ -1,11 +1,20 @@
 package route
 
-import "strings"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
 
 type HAUniqueID string
 
 // GetHAUniqueID returns a highly available route ID by combining Network ID and Network range address
+// Domain routes use a hash of their domains instead, as domains may contain the "-" separator.
 func GetHAUniqueID(input *Route) HAUniqueID {
+	if input.IsDynamic() {
+		sum := sha256.Sum256([]byte(input.NetString()))
+		return HAUniqueID(string(input.NetID) + "-" + hex.EncodeToString(sum[:8]))
+	}
 	return HAUniqueID(string(input.NetID) + "-" + input.Network.String())
 }
 
@@ -2,6 +2,10 @@ package route
 
 import (
 	"net/netip"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/idna"
 
 	"github.com/netbirdio/netbird/management/server/status"
 )
@@ -16,6 +20,10 @@ const (
 	MaxMetric = 9999
 	// MaxNetIDChar Max Network Identifier
 	MaxNetIDChar = 40
+	// MaxDomains max number of domains of a single route
+	MaxDomains = 32
+	// MaxWeight max ECMP weight input
+	MaxWeight = 100
 )
 
 const (
@@ -25,6 +33,8 @@ const (
 	IPv4NetworkString = "IPv4"
 	// IPv6NetworkString IPv6 network type string
 	IPv6NetworkString = "IPv6"
+	// DomainNetworkString domain network type string
+	DomainNetworkString = "Domain"
 )
 
 const (
@@ -34,6 +44,8 @@ const (
 	IPv4Network
 	// IPv6Network IPv6 network type
 	IPv6Network
+	// DomainNetwork domain network type, the route destinations are resolved from domain names
+	DomainNetwork
 )
 
 type ID string
@@ -52,6 +64,8 @@ func (p NetworkType) String() string {
 		return IPv4NetworkString
 	case IPv6Network:
 		return IPv6NetworkString
+	case DomainNetwork:
+		return DomainNetworkString
 	default:
 		return InvalidNetworkString
 	}
@@ -64,6 +78,8 @@ func ToPrefixType(prefix string) NetworkType {
 		return IPv4Network
 	case IPv6NetworkString:
 		return IPv6Network
+	case DomainNetworkString:
+		return DomainNetwork
 	default:
 		return InvalidNetwork
 	}
@@ -84,27 +100,58 @@ type Route struct {
 	Metric      int
 	Enabled     bool
 	Groups      []string `gorm:"serializer:json"`
+	// Domains holds the domain names the route destinations are resolved from. Domain routes don't have a Network.
+	// A domain starting with "*." matches all its subdomains.
+	Domains []string `gorm:"serializer:json"`
+	// KeepRoute keeps the routes of resolved addresses after they disappear from the DNS responses
+	KeepRoute bool
+	// Weight enables ECMP for default routes when set: the traffic is spread across the connected routing peers
+	// of the network proportionally to their weights instead of failing over between them
+	Weight int
+	// PendingApproval is set on routes proposed by their routing peer. They stay disabled until an admin approves them.
+	PendingApproval bool
+	// RouteGroupID is the ID of the route group the route is a member route of. Member routes are managed through
+	// their route group only.
+	RouteGroupID string
 }
 
 // EventMeta returns activity event meta related to the route
 func (r *Route) EventMeta() map[string]any {
-	return map[string]any{"name": r.NetID, "network_range": r.Network.String(), "peer_id": r.Peer, "peer_groups": r.PeerGroups}
+	return map[string]any{"name": r.NetID, "network_range": r.NetString(), "peer_id": r.Peer, "peer_groups": r.PeerGroups}
+}
+
+// IsDynamic returns true if the route destinations are resolved from domain names
+func (r *Route) IsDynamic() bool {
+	return r.NetworkType == DomainNetwork
+}
+
+// NetString returns the network range of the route or its comma separated domains for domain routes
+func (r *Route) NetString() string {
+	if r.IsDynamic() {
+		return strings.Join(r.Domains, ",")
+	}
+	return r.Network.String()
 }
 
 // Copy copies a route object
 func (r *Route) Copy() *Route {
 	route := &Route{
-		ID:          r.ID,
-		Description: r.Description,
-		NetID:       r.NetID,
-		Network:     r.Network,
-		NetworkType: r.NetworkType,
-		Peer:        r.Peer,
-		PeerGroups:  make([]string, len(r.PeerGroups)),
-		Metric:      r.Metric,
-		Masquerade:  r.Masquerade,
-		Enabled:     r.Enabled,
-		Groups:      make([]string, len(r.Groups)),
+		ID:              r.ID,
+		Description:     r.Description,
+		NetID:           r.NetID,
+		Network:         r.Network,
+		NetworkType:     r.NetworkType,
+		Peer:            r.Peer,
+		PeerGroups:      make([]string, len(r.PeerGroups)),
+		Metric:          r.Metric,
+		Masquerade:      r.Masquerade,
+		Enabled:         r.Enabled,
+		Groups:          make([]string, len(r.Groups)),
+		Domains:         slices.Clone(r.Domains),
+		KeepRoute:       r.KeepRoute,
+		Weight:          r.Weight,
+		PendingApproval: r.PendingApproval,
+		RouteGroupID:    r.RouteGroupID,
 	}
 	copy(route.Groups, r.Groups)
 	copy(route.PeerGroups, r.PeerGroups)
@@ -128,8 +175,12 @@ func (r *Route) IsEqual(other *Route) bool {
 		other.Metric == r.Metric &&
 		other.Masquerade == r.Masquerade &&
 		other.Enabled == r.Enabled &&
+		other.KeepRoute == r.KeepRoute &&
+		other.Weight == r.Weight &&
+		other.RouteGroupID == r.RouteGroupID &&
 		compareList(r.Groups, other.Groups) &&
-		compareList(r.PeerGroups, other.PeerGroups)
+		compareList(r.PeerGroups, other.PeerGroups) &&
+		compareList(r.Domains, other.Domains)
 }
 
 // ParseNetwork Parses a network prefix string and returns a netip.Prefix object and if is invalid, IPv4 or IPv6
@@ -152,6 +203,54 @@ func ParseNetwork(networkString string) (NetworkType, netip.Prefix, error) {
 	return IPv4Network, masked, nil
 }
 
+// ParseDomains validates a list of route domains and returns them in their normalized (lower case, punycode) form.
+// A domain may start with "*." to match all its subdomains.
+func ParseDomains(domains []string) ([]string, error) {
+	if len(domains) == 0 || len(domains) > MaxDomains {
+		return nil, status.Errorf(status.InvalidArgument, "domains should contain between 1 and %d entries", MaxDomains)
+	}
+
+	parsed := make([]string, 0, len(domains))
+	for _, d := range domains {
+		name := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(d), "."))
+		wildcard := strings.HasPrefix(name, "*.")
+		name = strings.TrimPrefix(name, "*.")
+
+		ascii, err := idna.Lookup.ToASCII(name)
+		if err != nil || !isValidDomainName(ascii) {
+			return nil, status.Errorf(status.InvalidArgument, "invalid domain %s", d)
+		}
+
+		if wildcard {
+			ascii = "*." + ascii
+		}
+		parsed = append(parsed, ascii)
+	}
+
+	return parsed, nil
+}
+
+// isValidDomainName checks that an ascii domain has at least two labels and that every label is a valid hostname label
+func isValidDomainName(name string) bool {
+	labels := strings.Split(name, ".")
+	if len(name) > 253 || len(labels) < 2 {
+		return false
+	}
+
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
 func compareList(list, other []string) bool {
 	if len(list) != len(other) {
 		return false
@@ -1,8 +1,15 @@
 package util
 
 import (
+	"fmt"
 	"io"
+	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -10,27 +17,324 @@ import (
 	"github.com/netbirdio/netbird/formatter"
 )
 
+const (
+	// LogFormatText renders the log entries as text lines, the default
+	LogFormatText = "text"
+	// LogFormatJSON renders the log entries as JSON objects, one per line
+	LogFormatJSON = "json"
+
+	defaultLogMaxSize    = 5  // MB
+	defaultLogMaxBackups = 10 // files
+	defaultLogMaxAge     = 30 // days
+)
+
+// LogComponents are the components their own log level can be set for with the source paths of their log entries,
+// relative to the root of the module. A path ending with a slash matches the whole package
+var LogComponents = map[string][]string{
+	"grpc": {
+		"management/client/",
+		"signal/client/",
+		"signal/server/",
+		"util/grpc/",
+		"management/server/grpcserver.go",
+	},
+	"store": {
+		"management/server/store.go",
+		"management/server/sqlite_store.go",
+		"management/server/file_store.go",
+		"management/server/migration/",
+	},
+	"http": {
+		"management/server/http/",
+	},
+	"routemanager": {
+		"client/internal/routemanager/",
+	},
+	"dns": {
+		"client/internal/dns/",
+		"management/server/dns.go",
+		"dns/",
+	},
+}
+
+// LogConfig is the configuration of the logger
+type LogConfig struct {
+	// Level is the level of the components without a level of their own, the current level is kept when empty
+	Level string
+	// Format is the format of the log entries: text or json, text when empty
+	Format string
+	// Components are the levels of the components of LogComponents, e.g. dns: debug. A component may be more or
+	// less verbose than the others
+	Components map[string]string
+	// MaxSize is the size in megabytes the log file is rotated at, 5 when zero
+	MaxSize int
+	// MaxBackups is the number of rotated log files kept, 10 when zero
+	MaxBackups int
+	// MaxAge is the number of days the rotated log files are kept for, 30 when zero
+	MaxAge int
+	// RotationInterval rotates the log file at the interval whatever its size, zero rotates it on its size only
+	RotationInterval Duration
+}
+
+var (
+	logMu         sync.Mutex
+	logLevel      = log.InfoLevel
+	logConfig     LogConfig
+	logPath       string
+	logComponents map[string]log.Level
+	logFile       *lumberjack.Logger
+	logRotateStop chan struct{}
+	logInitDone   bool
+)
+
 // InitLog parses and sets log-level input
 func InitLog(logLevel string, logPath string) error {
-	level, err := log.ParseLevel(logLevel)
-	if err != nil {
-		log.Errorf("Failed parsing log-level %s: %s", logLevel, err)
+	return InitLogWithConfig(logPath, LogConfig{Level: logLevel})
+}
+
+// InitLogWithConfig sets up the logger writing to the log path with the configuration, console writes to the
+// standard error. Nothing changes when the logger already runs with the same path and configuration
+func InitLogWithConfig(path string, config LogConfig) error {
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	if err := config.Validate(); err != nil {
+		log.Errorf("Failed parsing the log config: %s", err)
+		return err
+	}
+
+	level := logLevel
+	if config.Level != "" {
+		level, _ = log.ParseLevel(config.Level)
+	}
+	components, _ := parseLogComponentLevels(config.Components)
+
+	config.Level = level.String()
+	if logInitDone && path == logPath && reflect.DeepEqual(config, logConfig) {
+		return nil
+	}
+
+	setLogOutput(path, config)
+
+	if config.Format == LogFormatJSON {
+		formatter.SetJSONFormatter(log.StandardLogger())
+	} else {
+		formatter.SetTextFormatter(log.StandardLogger())
+	}
+
+	logLevel = level
+	logComponents = components
+	logConfig = config
+	logPath = path
+	logInitDone = true
+	applyLogLevels()
+	return nil
+}
+
+// Validate checks the levels, the format and the rotation of the config
+func (c LogConfig) Validate() error {
+	if c.Level != "" {
+		if _, err := log.ParseLevel(c.Level); err != nil {
+			return err
+		}
+	}
+
+	if _, err := parseLogComponentLevels(c.Components); err != nil {
 		return err
 	}
 
-	if logPath != "" && logPath != "console" {
-		lumberjackLogger := &lumberjack.Logger{
+	switch c.Format {
+	case "", LogFormatText, LogFormatJSON:
+	default:
+		return fmt.Errorf("invalid log format %q, expected %s or %s", c.Format, LogFormatText, LogFormatJSON)
+	}
+
+	if c.MaxSize < 0 || c.MaxBackups < 0 || c.MaxAge < 0 || c.RotationInterval.Duration < 0 {
+		return fmt.Errorf("invalid log rotation, the sizes, counts and durations can't be negative")
+	}
+	return nil
+}
+
+// SetLogLevel sets the level of the components without a level of their own
+func SetLogLevel(level log.Level) {
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	logLevel = level
+	logConfig.Level = level.String()
+	applyLogLevels()
+}
+
+// ParseLogComponents parses the component levels given in the component=level form, e.g. dns=debug
+func ParseLogComponents(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	components := make(map[string]string, len(values))
+	for _, value := range values {
+		component, level, found := strings.Cut(value, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid log component %q, expected component=level", value)
+		}
+		components[strings.TrimSpace(component)] = strings.TrimSpace(level)
+	}
+
+	if _, err := parseLogComponentLevels(components); err != nil {
+		return nil, err
+	}
+	return components, nil
+}
+
+// parseLogComponentLevels returns the levels of the known components
+func parseLogComponentLevels(components map[string]string) (map[string]log.Level, error) {
+	levels := make(map[string]log.Level, len(components))
+	for component, value := range components {
+		if _, ok := LogComponents[component]; !ok {
+			return nil, fmt.Errorf("unknown log component %q, expected one of %s", component, strings.Join(logComponentNames(), ", "))
+		}
+		level, err := log.ParseLevel(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid level of the log component %s: %w", component, err)
+		}
+		levels[component] = level
+	}
+	return levels, nil
+}
+
+func logComponentNames() []string {
+	names := make([]string, 0, len(LogComponents))
+	for name := range LogComponents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// setLogOutput writes the log to the rotated log file of the path, the previous log file is closed once replaced
+func setLogOutput(path string, config LogConfig) {
+	previousFile := logFile
+	if logRotateStop != nil {
+		close(logRotateStop)
+		logRotateStop = nil
+	}
+
+	if path == "" || path == "console" {
+		logFile = nil
+		if logInitDone {
+			log.SetOutput(os.Stderr)
+		}
+	} else {
+		logFile = &lumberjack.Logger{
 			// Log file absolute path, os agnostic
-			Filename:   filepath.ToSlash(logPath),
-			MaxSize:    5, // MB
-			MaxBackups: 10,
-			MaxAge:     30, // days
+			Filename:   filepath.ToSlash(path),
+			MaxSize:    valueOrDefault(config.MaxSize, defaultLogMaxSize),
+			MaxBackups: valueOrDefault(config.MaxBackups, defaultLogMaxBackups),
+			MaxAge:     valueOrDefault(config.MaxAge, defaultLogMaxAge),
 			Compress:   true,
 		}
-		log.SetOutput(io.Writer(lumberjackLogger))
+		log.SetOutput(io.Writer(logFile))
+
+		if interval := config.RotationInterval.Duration; interval > 0 {
+			logRotateStop = make(chan struct{})
+			go rotateLog(logFile, interval, logRotateStop)
+		}
 	}
 
-	formatter.SetTextFormatter(log.StandardLogger())
-	log.SetLevel(level)
-	return nil
+	if previousFile != nil {
+		if err := previousFile.Close(); err != nil {
+			log.Warnf("failed to close the previous log file: %v", err)
+		}
+	}
+}
+
+// rotateLog rotates the log file at every interval until it is stopped
+func rotateLog(file *lumberjack.Logger, interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := file.Rotate(); err != nil {
+				log.Errorf("failed to rotate the log file: %v", err)
+			}
+		}
+	}
+}
+
+// applyLogLevels sets the level of the logger to the most verbose level of the components, the entries of the
+// components logged above their own level are dropped by the formatter
+func applyLogLevels() {
+	logger := log.StandardLogger()
+	if base, ok := logger.Formatter.(*componentFormatter); ok {
+		logger.Formatter = base.Formatter
+	}
+
+	level := logLevel
+	for _, componentLevel := range logComponents {
+		if componentLevel > level {
+			level = componentLevel
+		}
+	}
+
+	if len(logComponents) > 0 {
+		logger.Formatter = &componentFormatter{
+			Formatter:  logger.Formatter,
+			level:      logLevel,
+			components: logComponents,
+		}
+	}
+	logger.SetLevel(level)
+}
+
+func valueOrDefault(value, defaultValue int) int {
+	if value == 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// componentFormatter drops the entries logged above the level of their component before formatting them
+type componentFormatter struct {
+	log.Formatter
+	level      log.Level
+	components map[string]log.Level
+}
+
+// Format renders the entry with the wrapped formatter, nothing is written for a dropped entry
+func (f *componentFormatter) Format(entry *log.Entry) ([]byte, error) {
+	level := f.level
+	if componentLevel, ok := f.components[entryLogComponent(entry)]; ok {
+		level = componentLevel
+	}
+	if entry.Level > level {
+		return nil, nil
+	}
+	return f.Formatter.Format(entry)
+}
+
+// entryLogComponent returns the component of the entry given by its component field or its source
+func entryLogComponent(entry *log.Entry) string {
+	if component, ok := entry.Data["component"].(string); ok {
+		return component
+	}
+
+	source, ok := entry.Data["source"].(string)
+	if !ok {
+		return ""
+	}
+	if i := strings.LastIndex(source, ":"); i >= 0 {
+		source = source[:i]
+	}
+
+	for component, paths := range LogComponents {
+		for _, path := range paths {
+			if source == path || strings.HasSuffix(path, "/") && strings.HasPrefix(source, path) {
+				return component
+			}
+		}
+	}
+	return ""
 }
@@ -0,0 +1,125 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitLogWithConfig_Components(t *testing.T) {
+	t.Cleanup(func() {
+		require.NoError(t, InitLog("info", "console"))
+	})
+
+	require.NoError(t, InitLogWithConfig("console", LogConfig{
+		Level:      "info",
+		Components: map[string]string{"dns": "debug", "store": "error"},
+	}))
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+
+	assert.Equal(t, log.DebugLevel, log.GetLevel(), "the logger should run at the most verbose level of the components")
+
+	log.WithField("component", "dns").Debug("dns debug")
+	log.WithField("component", "store").Warn("store warning")
+	log.WithField("component", "store").Error("store error")
+	log.Debug("other debug")
+	log.Info("other info")
+
+	output := buf.String()
+	assert.Contains(t, output, "dns debug")
+	assert.NotContains(t, output, "store warning")
+	assert.Contains(t, output, "store error")
+	assert.NotContains(t, output, "other debug")
+	assert.Contains(t, output, "other info")
+
+	// the level of the other components changes without changing the levels of the components
+	SetLogLevel(log.TraceLevel)
+	log.Debug("other debug")
+	log.WithField("component", "store").Warn("store warning")
+	assert.Contains(t, buf.String(), "other debug")
+	assert.NotContains(t, buf.String(), "store warning")
+}
+
+func TestInitLogWithConfig_JSON(t *testing.T) {
+	t.Cleanup(func() {
+		require.NoError(t, InitLog("info", "console"))
+	})
+
+	require.NoError(t, InitLogWithConfig("console", LogConfig{Level: "info", Format: LogFormatJSON}))
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+
+	log.WithField("peer", "abc").Info("connected")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "connected", entry["msg"])
+	assert.Equal(t, "info", entry["level"])
+	assert.Equal(t, "abc", entry["peer"])
+	assert.True(t, strings.HasPrefix(entry["source"].(string), "util/log_test.go:"), "unexpected source %v", entry["source"])
+	assert.NotContains(t, entry, "func")
+}
+
+func TestInitLogWithConfig_Rotation(t *testing.T) {
+	t.Cleanup(func() {
+		require.NoError(t, InitLog("info", "console"))
+	})
+
+	dir := t.TempDir()
+	require.NoError(t, InitLogWithConfig(filepath.Join(dir, "client.log"), LogConfig{
+		Level:            "info",
+		RotationInterval: Duration{Duration: 50 * time.Millisecond},
+	}))
+	log.Info("before the rotation")
+
+	require.Eventually(t, func() bool {
+		files, err := os.ReadDir(dir)
+		return err == nil && len(files) > 1
+	}, 5*time.Second, 20*time.Millisecond, "the log file should be rotated at the interval")
+}
+
+func TestLogConfig_Validate(t *testing.T) {
+	assert.NoError(t, LogConfig{}.Validate())
+	assert.NoError(t, LogConfig{Level: "debug", Format: LogFormatJSON, Components: map[string]string{"grpc": "trace"}}.Validate())
+
+	assert.Error(t, LogConfig{Level: "verbose"}.Validate())
+	assert.Error(t, LogConfig{Format: "xml"}.Validate())
+	assert.Error(t, LogConfig{Components: map[string]string{"firewall": "debug"}}.Validate())
+	assert.Error(t, LogConfig{Components: map[string]string{"dns": "loud"}}.Validate())
+	assert.Error(t, LogConfig{MaxSize: -1}.Validate())
+}
+
+func TestParseLogComponents(t *testing.T) {
+	components, err := ParseLogComponents([]string{"dns=debug", " http = warn "})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"dns": "debug", "http": "warn"}, components)
+
+	_, err = ParseLogComponents([]string{"dns"})
+	assert.Error(t, err)
+	_, err = ParseLogComponents([]string{"unknown=debug"})
+	assert.Error(t, err)
+}
+
+func TestEntryLogComponent(t *testing.T) {
+	for source, component := range map[string]string{
+		"management/server/sqlite_store.go:120":    "store",
+		"management/server/http/peers_handler.go":  "http",
+		"client/internal/routemanager/client.go:9": "routemanager",
+		"client/internal/dns/server.go:44":         "dns",
+		"management/server/grpcserver.go:10":       "grpc",
+		"management/server/account.go:10":          "",
+		"dnsmasq/file.go:1":                        "",
+	} {
+		entry := &log.Entry{Data: log.Fields{"source": source}}
+		assert.Equal(t, component, entryLogComponent(entry), source)
+	}
+}
@@ -0,0 +1,24 @@
+package net
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// BindToInterface binds the socket to the network interface, so its traffic leaves through the interface
+// regardless of the routing table
+func BindToInterface(conn syscall.RawConn, name string) error {
+	var bindErr error
+	err := conn.Control(func(fd uintptr) {
+		bindErr = syscall.BindToDevice(int(fd), name)
+	})
+	if err != nil {
+		return fmt.Errorf("control: %w", err)
+	}
+
+	if bindErr != nil {
+		return fmt.Errorf("set SO_BINDTODEVICE: %w", bindErr)
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package net
+
+import (
+	"errors"
+	"syscall"
+)
+
+// BindToInterface is not supported on this platform, the traffic follows the routing table
+func BindToInterface(syscall.RawConn, string) error {
+	return errors.New("binding a socket to an interface is not supported on this platform")
+}
@@ -25,3 +25,13 @@ func GenerateConnID() ConnectionID {
 func CustomRoutingDisabled() bool {
 	return os.Getenv(envDisableCustomRouting) == "true"
 }
+
+// SetCustomRoutingDisabled disables the fwmark and the routing table of NetBird, the routes going through the
+// interface instead
+func SetCustomRoutingDisabled(disabled bool) {
+	if disabled {
+		os.Setenv(envDisableCustomRouting, "true")
+	} else {
+		os.Unsetenv(envDisableCustomRouting)
+	}
+}